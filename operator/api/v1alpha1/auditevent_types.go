@@ -0,0 +1,119 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Audit actions recorded by the workspace exec gateway
+const (
+	AuditActionExecStarted = "ExecStarted"
+	AuditActionExecEnded   = "ExecEnded"
+)
+
+// AuditEventSpec describes one audited action on an environment
+type AuditEventSpec struct {
+	// Action is ExecStarted or ExecEnded
+	Action string `json:"action"`
+
+	// Time is when the action happened
+	Time metav1.Time `json:"time"`
+
+	// SessionID correlates the events of one exec session
+	// +optional
+	SessionID string `json:"sessionID,omitempty"`
+
+	// Namespace of the Environment
+	Namespace string `json:"namespace"`
+
+	// Environment is the name of the Environment
+	Environment string `json:"environment"`
+
+	// Pod is the "namespace/name" of the pod the session attached to
+	// +optional
+	Pod string `json:"pod,omitempty"`
+
+	// Container the session attached to
+	// +optional
+	Container string `json:"container,omitempty"`
+
+	// Command executed in the container
+	// +optional
+	Command []string `json:"command,omitempty"`
+
+	// Actor is the user the client authenticated as with an identity token (the
+	// "X-Catalyst-Identity" header), or the user a caller allowed to impersonate acts for.
+	// Empty when the client presented only the per-environment gateway token.
+	// +optional
+	Actor string `json:"actor,omitempty"`
+
+	// RemoteAddr is the client address, taken from X-Forwarded-For behind a trusted proxy
+	// +optional
+	RemoteAddr string `json:"remoteAddr,omitempty"`
+
+	// UserAgent of the client
+	// +optional
+	UserAgent string `json:"userAgent,omitempty"`
+
+	// Duration of the session (ExecEnded)
+	// +optional
+	Duration *metav1.Duration `json:"duration,omitempty"`
+
+	// Error the session ended with (ExecEnded)
+	// +optional
+	Error string `json:"error,omitempty"`
+}
+
+// +genclient
+// +genclient:noStatus
+// +kubebuilder:object:root=true
+// +kubebuilder:printcolumn:name="Action",type=string,JSONPath=`.spec.action`
+// +kubebuilder:printcolumn:name="Environment",type=string,JSONPath=`.spec.environment`
+// +kubebuilder:printcolumn:name="Actor",type=string,JSONPath=`.spec.actor`
+// +kubebuilder:printcolumn:name="Pod",type=string,JSONPath=`.spec.pod`,priority=1
+// +kubebuilder:printcolumn:name="Duration",type=string,JSONPath=`.spec.duration`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// AuditEvent is an immutable record of an action on an environment, e.g. a workspace exec
+// session. Created in the Environment's namespace so it outlives the environment; retention
+// is left to the cluster administrator.
+type AuditEvent struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// metadata is a standard object metadata
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitzero"`
+
+	// spec is the audited action
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="audit events are immutable"
+	// +required
+	Spec AuditEventSpec `json:"spec"`
+}
+
+// +kubebuilder:object:root=true
+
+// AuditEventList contains a list of AuditEvent
+type AuditEventList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitzero"`
+	Items           []AuditEvent `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&AuditEvent{}, &AuditEventList{})
+}
@@ -0,0 +1,25 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package v1alpha1 contains API Schema definitions for the catalyst v1alpha1 API group.
+//
+// Typed clients, listers and informers for these types are generated into pkg/client
+// (make generate-client).
+//
+// +kubebuilder:object:generate=true
+// +groupName=catalyst.catalyst.dev
+// +groupGoName=Catalyst
+package v1alpha1
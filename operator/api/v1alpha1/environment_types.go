@@ -17,12 +17,15 @@ limitations under the License.
 package v1alpha1
 
 import (
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 // EnvironmentSpec defines the desired state of Environment
 // Spec referenced from operator/spec.md
+// +kubebuilder:validation:XValidation:rule="!has(self.promoteFrom) || !has(self.rollbackTo)",message="promoteFrom and rollbackTo are mutually exclusive"
 type EnvironmentSpec struct {
 	// ProjectRef references the parent Project
 	ProjectRef ProjectReference `json:"projectRef"`
@@ -31,9 +34,11 @@ type EnvironmentSpec struct {
 	Type string `json:"type"`
 
 	// DeploymentMode specifies how the operator should deploy this environment.
-	// Valid values: "production", "development", "workspace" (default).
+	// Valid values: "production", "development", "helm", "docker-compose", "kustomize", "gitops", "workspace" (default).
 	// - "production": Static deployment from manifest pattern
 	// - "development": Hot-reload with volume mounts and init containers
+	// - "kustomize": kustomize build of the template path, applied with server-side apply
+	// - "gitops": Hand off to Argo CD or Flux, tracking sync status in conditions
 	// - "workspace": Simple workspace pod (default, existing behavior)
 	// +optional
 	DeploymentMode string `json:"deploymentMode,omitempty"`
@@ -44,6 +49,318 @@ type EnvironmentSpec struct {
 
 	// Config overrides
 	Config EnvironmentConfig `json:"config,omitempty"`
+
+	// Hibernate scales all Deployments and StatefulSets in the environment namespace to zero
+	// (PersistentVolumeClaims are kept) and sets phase Hibernated. Clearing the flag, or setting the
+	// "catalyst.dev/wake" annotation, restores the previous replica counts.
+	// +optional
+	Hibernate bool `json:"hibernate,omitempty"`
+
+	// Priority schedules the environment's pods with the operator-managed PriorityClass of the
+	// level (catalyst-low, catalyst-normal, catalyst-high), so that when the cluster is full
+	// higher-priority environments preempt lower ones. Low pods never preempt others: use low for
+	// previews and high for production. Pods that already name a PriorityClass (e.g. builds with
+	// an operator or project default) keep it, as do workloads installed by Helm charts.
+	// Unset leaves pods at the cluster default priority.
+	// +kubebuilder:validation:Enum=low;normal;high
+	// +optional
+	Priority string `json:"priority,omitempty"`
+
+	// Access protects the environment's preview hosts. Unset leaves them public.
+	// +optional
+	Access *EnvironmentAccess `json:"access,omitempty"`
+
+	// Ingress customizes the preview Ingresses of the environment over the Project's
+	// spec.ingress: their class, annotations, and additional hosts and paths.
+	// +optional
+	Ingress *EnvironmentIngress `json:"ingress,omitempty"`
+
+	// Alias is a stable, human-readable host label for the environment (e.g. "feature-login"
+	// routes feature-login.<preview domain>). The alias host is derived only from this value, so
+	// it survives re-creating the environment for the same branch. An alias already served by
+	// another Ingress is not routed and reported on the AliasAvailable condition.
+	// +kubebuilder:validation:MaxLength=63
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	// +optional
+	Alias string `json:"alias,omitempty"`
+
+	// Runs are ad-hoc commands (e.g. "npm run test:e2e") executed once each as a Job in the
+	// environment namespace, with the web container's image, environment and volumes.
+	// Results are recorded in status.runs; to run a command again, append it under a new name.
+	// Removing an entry deletes its Job and status.
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	Runs []EnvironmentRun `json:"runs,omitempty"`
+
+	// Tasks are one-off commands (e.g. "npm run db:migrate", a seed, smoke tests) executed as a
+	// Job in the environment namespace once per deploy, with the web container's image,
+	// environment and volumes. A task runs again when the deployed commits or template change,
+	// when its definition changes, or when its rerun token is changed. Results are recorded in
+	// status.tasks and the TasksSucceeded condition.
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	Tasks []EnvironmentTask `json:"tasks,omitempty"`
+
+	// CloneFrom names an Environment in the same namespace and Project to duplicate. When the
+	// clone is first reconciled, the source's resolved config (under this spec's overrides), its
+	// sources (unless set here, e.g. to a new branch or commit) and deployment mode are copied
+	// into this spec, the Secrets the config references are copied into the new namespace, and
+	// managed-service volumes are cloned from VolumeSnapshots of the source's volumes where the
+	// cluster supports snapshots. The copy is one-time; later changes to the source are not followed.
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="cloneFrom is immutable"
+	// +optional
+	CloneFrom string `json:"cloneFrom,omitempty"`
+
+	// PromoteFrom deploys a revision of another Environment (preview -> staging -> production):
+	// the images of the revision by digest, without building, rendered from the template
+	// revision it was deployed with. This environment's own config overrides still apply.
+	// Clearing it builds from spec.sources again.
+	// +optional
+	PromoteFrom *EnvironmentPromotion `json:"promoteFrom,omitempty"`
+
+	// RollbackTo redeploys a revision of this environment's own status.deploymentHistory
+	// without building: its images by digest, rendered from the template revision (and so the
+	// Helm values) it was deployed with, as spec.promoteFrom does for other environments.
+	// spec.config is not rolled back. The annotation catalyst.dev/rollback-to=<revision|previous>
+	// sets it. Clearing it builds from spec.sources again.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	RollbackTo int64 `json:"rollbackTo,omitempty"`
+
+	// Hooks run at points of the environment lifecycle
+	// +optional
+	Hooks *EnvironmentHooks `json:"hooks,omitempty"`
+
+	// DependsOn names Environments in the same namespace, e.g. the API preview a frontend
+	// preview calls, that must be Ready before this environment is first deployed. The
+	// environment stays Pending until then; status.dependencies reports their phase and URL.
+	// +listType=set
+	// +optional
+	DependsOn []string `json:"dependsOn,omitempty"`
+}
+
+// Access types of a preview environment (spec.access.type)
+const (
+	AccessTypePublic     = "public"
+	AccessTypeBasicAuth  = "basicAuth"
+	AccessTypeOAuthProxy = "oauthProxy"
+)
+
+// EnvironmentAccess protects the preview Ingresses (including the alias host) of an
+// environment. Protection relies on ingress-nginx annotations; with Gateway API routing a
+// protected environment fails (ConfigInvalid) instead of serving its hosts unprotected.
+// +kubebuilder:validation:XValidation:rule="self.type != 'oauthProxy' || has(self.oauthProxy)",message="oauthProxy is required for type oauthProxy"
+type EnvironmentAccess struct {
+	// Type of protection: public, basicAuth (HTTP basic authentication) or oauthProxy
+	// (sign-in through an identity provider with oauth2-proxy)
+	// +kubebuilder:validation:Enum=public;basicAuth;oauthProxy
+	Type string `json:"type"`
+
+	// BasicAuth configures type basicAuth
+	// +optional
+	BasicAuth *BasicAuthAccess `json:"basicAuth,omitempty"`
+
+	// OAuthProxy configures type oauthProxy
+	// +optional
+	OAuthProxy *OAuthProxyAccess `json:"oauthProxy,omitempty"`
+}
+
+// BasicAuthAccess configures HTTP basic authentication of the preview hosts
+type BasicAuthAccess struct {
+	// SecretName is a Secret in the environment namespace holding an htpasswd file under the
+	// "auth" key. Unset generates the Secret "preview-basic-auth" with a random password for
+	// the user "preview" (keys username and password).
+	// +optional
+	SecretName string `json:"secretName,omitempty"`
+}
+
+// OAuthProxyAccess configures sign-in through oauth2-proxy, deployed in the environment
+// namespace and consulted by the Ingress for every request
+type OAuthProxyAccess struct {
+	// Provider is the oauth2-proxy provider, e.g. github, google or oidc
+	// +kubebuilder:default=github
+	// +optional
+	Provider string `json:"provider,omitempty"`
+
+	// SecretName is a Secret in the environment namespace with the OAuth client
+	// (client-id, client-secret) and the cookie-secret keys
+	SecretName string `json:"secretName"`
+
+	// EmailDomains allowed to sign in, e.g. [acme.com]. Required unless args restrict who may
+	// sign in (e.g. --github-org); ["*"] allows any account of the provider.
+	// +optional
+	EmailDomains []string `json:"emailDomains,omitempty"`
+
+	// Args are additional oauth2-proxy flags, e.g. --github-org=acme or --oidc-issuer-url
+	// +optional
+	Args []string `json:"args,omitempty"`
+}
+
+// IngressDefaults customizes the preview Ingresses of environments. It applies to Ingress
+// routing only; Gateway API routes are left as they are.
+type IngressDefaults struct {
+	// IngressClassName of the preview Ingresses, e.g. traefik or haproxy. Unset uses nginx, or
+	// the cluster default class when nginx is not installed.
+	// +optional
+	IngressClassName string `json:"ingressClassName,omitempty"`
+
+	// Annotations added to the preview Ingresses, e.g. the proxy timeouts, maximum body size or
+	// sticky sessions of the ingress controller. Annotations the operator manages (access,
+	// DNS and TLS) take precedence.
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// Paths route additional paths of the preview hosts to other Services of the environment
+	// namespace, e.g. /api to the api Service. The rest of each host is routed to web.
+	// +listType=map
+	// +listMapKey=path
+	// +optional
+	Paths []IngressPath `json:"paths,omitempty"`
+}
+
+// EnvironmentIngress customizes the preview Ingresses of an environment. Its class and
+// annotations override the Project's; its paths are added to the Project's, replacing those
+// of the same path.
+type EnvironmentIngress struct {
+	IngressDefaults `json:",inline"`
+
+	// Hosts are additional hostnames routed like the preview host, e.g. a custom domain whose
+	// DNS record points at the ingress controller. A host already served by an Ingress or
+	// HTTPRoute of another namespace is not routed.
+	// +listType=set
+	// +kubebuilder:validation:items:MaxLength=253
+	// +kubebuilder:validation:items:Pattern=`^(\*\.)?[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`
+	// +optional
+	Hosts []string `json:"hosts,omitempty"`
+
+	// TLSSecretName is a Secret in the environment namespace with the certificate of hosts
+	// outside the preview domain, e.g. issued by cert-manager through annotations. Without it
+	// those hosts are served over plain HTTP.
+	// +optional
+	TLSSecretName string `json:"tlsSecretName,omitempty"`
+}
+
+// IngressPath routes a path of the preview hosts to a Service of the environment namespace
+type IngressPath struct {
+	// Path matched on every preview host, e.g. /api
+	// +kubebuilder:validation:Pattern=`^/`
+	Path string `json:"path"`
+
+	// PathType of the match: Prefix (default), Exact or ImplementationSpecific
+	// +kubebuilder:validation:Enum=Prefix;Exact;ImplementationSpecific
+	// +optional
+	PathType string `json:"pathType,omitempty"`
+
+	// Service the path is routed to
+	Service string `json:"service"`
+
+	// Port of the Service
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	Port int32 `json:"port"`
+}
+
+// Environment priority levels (spec.priority)
+const (
+	EnvironmentPriorityLow    = "low"
+	EnvironmentPriorityNormal = "normal"
+	EnvironmentPriorityHigh   = "high"
+)
+
+// Failure policies of a lifecycle hook
+const (
+	// HookFailurePolicyIgnore proceeds as if the hook succeeded
+	HookFailurePolicyIgnore = "Ignore"
+	// HookFailurePolicyFail blocks the lifecycle step until the hook succeeds
+	HookFailurePolicyFail = "Fail"
+)
+
+// EnvironmentHooks are lifecycle hooks of an Environment
+type EnvironmentHooks struct {
+	// PreDelete runs when the Environment is deleted, before its Helm release is uninstalled
+	// and its namespace is deleted, to clean up what the environment created outside the
+	// cluster (DNS records, external database schemas, notifications)
+	// +optional
+	PreDelete *PreDeleteHook `json:"preDelete,omitempty"`
+}
+
+// PreDeleteHook deprovisions external resources of an environment before teardown
+type PreDeleteHook struct {
+	// Job runs in the environment namespace (as "catalyst-pre-delete"); teardown continues
+	// once it completes. Unset fields of the pod security context default to the restricted
+	// Pod Security Standard, and the Job is bounded by Timeout unless it sets
+	// activeDeadlineSeconds. The template is validated when the Job is created, keeping the
+	// Job schema out of the CRD.
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:validation:Type=object
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +optional
+	Job *batchv1.JobSpec `json:"job,omitempty"`
+
+	// Helm bounds the chart's own pre-delete hooks (helm.sh/hook: pre-delete), which run when
+	// the release of a helm-mode environment is uninstalled, by Timeout and FailurePolicy
+	// +optional
+	Helm bool `json:"helm,omitempty"`
+
+	// Timeout bounds the hook (default 10m)
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+
+	// FailurePolicy is what happens when the hook fails or times out: "Ignore" (default)
+	// continues the teardown, "Fail" keeps the environment and its namespace until the hook
+	// succeeds (delete the Job to run it again)
+	// +kubebuilder:validation:Enum=Ignore;Fail
+	// +optional
+	FailurePolicy string `json:"failurePolicy,omitempty"`
+}
+
+// EnvironmentRun is an ad-hoc command executed in the environment
+type EnvironmentRun struct {
+	// Name identifies the run within the environment and names its Job ("run-<name>")
+	// +kubebuilder:validation:MaxLength=40
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	Name string `json:"name"`
+
+	// Command is the entrypoint array (mirrors corev1.Container.Command)
+	// Example: ["npm", "run", "test:e2e"]
+	// +kubebuilder:validation:MinItems=1
+	Command []string `json:"command"`
+
+	// Image overrides the web container image
+	// +optional
+	Image string `json:"image,omitempty"`
+}
+
+// EnvironmentTask is a command run once per deploy
+type EnvironmentTask struct {
+	// Name identifies the task within the environment and names its Job ("task-<name>")
+	// +kubebuilder:validation:MaxLength=40
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	Name string `json:"name"`
+
+	// Command is the entrypoint array (mirrors corev1.Container.Command)
+	// Example: ["npm", "run", "db:migrate"]
+	// +kubebuilder:validation:MinItems=1
+	Command []string `json:"command"`
+
+	// Image overrides the web container image
+	// +optional
+	Image string `json:"image,omitempty"`
+
+	// Env are added to the web container's environment variables; variables with the same
+	// name override them
+	// +optional
+	Env []corev1.EnvVar `json:"env,omitempty"`
+
+	// Timeout fails the task when it runs longer
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+
+	// Rerun is an arbitrary token; changing it runs the task again for the current deploy
+	// +optional
+	Rerun string `json:"rerun,omitempty"`
 }
 
 type ProjectReference struct {
@@ -82,6 +399,13 @@ type EnvironmentSource struct {
 //
 //	lifecycle, securityContext, stdin, tty, terminationMessagePath, etc.
 type EnvironmentConfig struct {
+	// Framework expands, in development mode, into the dev server of a framework: image,
+	// command, port, probes and file watcher env. Fields set on the config override the
+	// preset; env vars are merged by name. Unset, nothing is assumed about the application.
+	// +kubebuilder:validation:Enum=nextjs;vite;django;rails;go-air;dotnet-watch
+	// +optional
+	Framework string `json:"framework,omitempty"`
+
 	// --- Curated corev1.Container fields (FR-ENV-026) ---
 
 	// Image is the container image to deploy (e.g., "node:22-slim")
@@ -140,6 +464,15 @@ type EnvironmentConfig struct {
 	// +optional
 	InitContainers []InitContainerSpec `json:"initContainers,omitempty"`
 
+	// Sidecars run next to the main container in the same pod, sharing its environment
+	// variables and the catalyst-secrets Secret. Each entry creates a container on the
+	// Deployment (development and production modes).
+	// Example: a queue worker, a cron runner, stripe-cli, an otel-collector
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	Sidecars []ContainerSpec `json:"sidecars,omitempty"`
+
 	// --- Managed services (FR-ENV-028) ---
 
 	// Services are named service entries, each creating a separate StatefulSet.
@@ -152,6 +485,95 @@ type EnvironmentConfig struct {
 	// Volumes defines PVCs and other volumes for the environment namespace.
 	// +optional
 	Volumes []VolumeSpec `json:"volumes,omitempty"`
+
+	// --- Scaling (production mode) ---
+
+	// Replicas is the number of web pods. Defaults to 1; ignored when autoscaling is set.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Replicas *int32 `json:"replicas,omitempty"`
+
+	// Autoscaling scales the web Deployment with a HorizontalPodAutoscaler on CPU utilization.
+	// Requires config.resources.requests.cpu.
+	// +optional
+	Autoscaling *AutoscalingSpec `json:"autoscaling,omitempty"`
+
+	// --- Scheduling ---
+
+	// NodeSelector places the pods of the environment (web, managed services, poolers, seed
+	// Jobs and compose services) on nodes with these labels, e.g. a GPU node pool
+	// +optional
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+
+	// Tolerations let the pods of the environment run on tainted nodes
+	// +optional
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+
+	// Affinity of the pods of the environment. Its pod anti-affinity replaces the spreading of
+	// replicated production Deployments.
+	// +optional
+	Affinity *corev1.Affinity `json:"affinity,omitempty"`
+
+	// Arch places the pods of the environment on nodes of this CPU architecture (the
+	// kubernetes.io/arch node label). Images must be built for it, see builds[].platforms.
+	// +kubebuilder:validation:Enum=amd64;arm64
+	// +optional
+	Arch string `json:"arch,omitempty"`
+
+	// --- File sync (development mode) ---
+
+	// FileSync replaces the git clone of development mode with an endpoint developers push
+	// file changes to from their machine, for hot reload without committing.
+	// +optional
+	FileSync *FileSyncConfig `json:"fileSync,omitempty"`
+
+	// --- Docker Compose ---
+
+	// ComposeProfiles selects the docker-compose profiles to deploy. Services with profiles
+	// are skipped unless one of them is listed; "*" enables all profiles.
+	// +optional
+	ComposeProfiles []string `json:"composeProfiles,omitempty"`
+}
+
+// FileSyncConfig configures the file sync endpoint of a development environment: an rsync
+// daemon next to the app, tunneled through a WebSocket at /.catalyst/sync on the preview host
+// (status.fileSync.url). Files are pushed with rsync and websocat, e.g.
+//
+//	RSYNC_PASSWORD=<password> RSYNC_CONNECT_PROG='websocat --binary <url>' \
+//	  rsync -a --delete ./ rsync://catalyst@preview/code/
+//
+// The password is in the password key of the Secret named by status.fileSync.secretName.
+type FileSyncConfig struct {
+	// Clone still clones the source before the first sync, so the app starts with the
+	// committed code. Without it the code volume is empty until the first sync.
+	// +optional
+	Clone bool `json:"clone,omitempty"`
+
+	// Exclude are rsync patterns of paths the endpoint neither writes nor deletes, e.g.
+	// dependencies installed in the environment like node_modules
+	// +optional
+	Exclude []string `json:"exclude,omitempty"`
+}
+
+// AutoscalingSpec configures the HorizontalPodAutoscaler of the web Deployment
+// +kubebuilder:validation:XValidation:rule="!has(self.minReplicas) || self.minReplicas <= self.maxReplicas",message="minReplicas must not exceed maxReplicas"
+type AutoscalingSpec struct {
+	// MinReplicas is the lower replica bound
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=1
+	// +optional
+	MinReplicas *int32 `json:"minReplicas,omitempty"`
+
+	// MaxReplicas is the upper replica bound
+	// +kubebuilder:validation:Minimum=1
+	MaxReplicas int32 `json:"maxReplicas"`
+
+	// TargetCPUUtilizationPercentage is the average CPU utilization, relative to the
+	// requested CPU, the autoscaler maintains
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=80
+	// +optional
+	TargetCPUUtilizationPercentage *int32 `json:"targetCPUUtilizationPercentage,omitempty"`
 }
 
 // InitContainerSpec is a curated subset of corev1.Container for init containers.
@@ -187,30 +609,203 @@ type InitContainerSpec struct {
 	// VolumeMounts for the init container
 	// +optional
 	VolumeMounts []corev1.VolumeMount `json:"volumeMounts,omitempty"`
+
+	// Timeout fails the environment when a single run of the init container takes longer
+	// (default 15m)
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+}
+
+// ContainerSpec defines a sidecar container, a curated subset of a Kubernetes container
+type ContainerSpec struct {
+	// Name of the container; "app" and "web" are taken by the main container
+	// +kubebuilder:validation:XValidation:rule="self != 'app' && self != 'web'",message="app and web are reserved for the main container"
+	Name string `json:"name"`
+
+	// Image for the container (default: the main container's image)
+	// +optional
+	Image string `json:"image,omitempty"`
+
+	// Command is the entrypoint array
+	// +optional
+	Command []string `json:"command,omitempty"`
+
+	// Args are arguments to the command
+	// +optional
+	Args []string `json:"args,omitempty"`
+
+	// WorkingDir is the container's working directory
+	// +optional
+	WorkingDir string `json:"workingDir,omitempty"`
+
+	// Ports the container listens on; they are not exposed by the environment's Service
+	// +optional
+	Ports []corev1.ContainerPort `json:"ports,omitempty"`
+
+	// Env are environment variables, added to those of the main container. Variables with
+	// the same name override the main container's.
+	// +optional
+	Env []corev1.EnvVar `json:"env,omitempty"`
+
+	// Resources are CPU/memory requests and limits
+	// +optional
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// LivenessProbe restarts the container when it fails
+	// +optional
+	LivenessProbe *corev1.Probe `json:"livenessProbe,omitempty"`
+
+	// ReadinessProbe gates the pod's readiness on the container
+	// +optional
+	ReadinessProbe *corev1.Probe `json:"readinessProbe,omitempty"`
+
+	// VolumeMounts for the container
+	// +optional
+	VolumeMounts []corev1.VolumeMount `json:"volumeMounts,omitempty"`
 }
 
 // ManagedServiceSpec defines a named service entry that maps to a StatefulSet.
 // Models services like PostgreSQL, Redis — each gets its own StatefulSet + Service (FR-ENV-028).
+// +kubebuilder:validation:XValidation:rule="!has(self.sharedCluster) || has(self.provider)",message="sharedCluster requires provider"
+// +kubebuilder:validation:XValidation:rule="has(self.preset) || (has(self.container) && has(self.container.image))",message="container.image is required unless preset is set"
 type ManagedServiceSpec struct {
 	// Name identifies this service (e.g., "postgres", "redis")
 	Name string `json:"name"`
 
-	// Container spec for the service (curated subset: image, env, ports, resources)
-	Container ManagedServiceContainer `json:"container"`
+	// Preset expands into a built-in service definition: image, ports, env, readiness probe
+	// and storage for redis, minio (S3-compatible object storage) or mailpit (SMTP capture
+	// with a web UI). Fields set on the service override the preset; env vars are merged by name.
+	// +kubebuilder:validation:Enum=redis;minio;mailpit
+	// +optional
+	Preset string `json:"preset,omitempty"`
+
+	// Container spec for the service (curated subset: image, args, env, ports, resources,
+	// readiness probe). Optional with a preset.
+	// +optional
+	Container ManagedServiceContainer `json:"container,omitempty"`
 
 	// Storage defines the PVC template for the StatefulSet (mirrors StatefulSet volumeClaimTemplates)
 	// +optional
 	Storage *corev1.PersistentVolumeClaimSpec `json:"storage,omitempty"`
 
+	// Size of the data volume, overriding storage.resources.requests.storage; setting it
+	// alone gives the service a ReadWriteOnce data volume. Growing it expands the existing
+	// claim when its StorageClass allows volume expansion.
+	// +optional
+	Size *resource.Quantity `json:"size,omitempty"`
+
+	// StorageClassName of the data volume, overriding storage.storageClassName. Only applies
+	// to claims created after it is set.
+	// +optional
+	StorageClassName *string `json:"storageClassName,omitempty"`
+
 	// Database name to create (postgres-only convenience field)
 	// +optional
 	Database string `json:"database,omitempty"`
+
+	// Pooler enables a pgbouncer connection pooler in front of the service (postgres-only).
+	// DATABASE_URL in the web container is rewritten to point at the pooler. Development mode
+	// only: production mode and provider services reject it. The pooler authenticates with
+	// POSTGRES_USER and POSTGRES_PASSWORD, given as a value or a valueFrom.secretKeyRef.
+	// +optional
+	Pooler *ConnectionPoolerSpec `json:"pooler,omitempty"`
+
+	// Seed populates the service before the app starts, either by restoring a dump with a
+	// one-off Job (postgres-only) or by cloning the data volume from a golden VolumeSnapshot.
+	// +optional
+	Seed *ServiceSeedSpec `json:"seed,omitempty"`
+
+	// Provider hands a postgres service to a Postgres operator installed in the cluster: a
+	// Cluster (cloudnative-pg) or postgresql (zalando) resource is created instead of the
+	// StatefulSet. container.image is the Postgres image of cloudnative-pg and gives the major
+	// version on zalando. The web container gets DATABASE_URL and the PG* variables from the
+	// "<name>-connection" Secret. Pooler and seed do not apply.
+	// +kubebuilder:validation:Enum=cloudnative-pg;zalando
+	// +optional
+	Provider string `json:"provider,omitempty"`
+
+	// Instances is the number of Postgres instances of the provider cluster (default 1)
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	Instances *int32 `json:"instances,omitempty"`
+
+	// SharedCluster places the environment on an existing cluster of the provider instead of
+	// creating one: the environment gets its own database and user, released on deletion.
+	// Requires provider.
+	// +optional
+	SharedCluster *SharedPostgresCluster `json:"sharedCluster,omitempty"`
+}
+
+// SharedPostgresCluster references a Postgres cluster managed by the provider operator
+type SharedPostgresCluster struct {
+	// Name of the Cluster (cloudnative-pg) or postgresql (zalando) resource
+	Name string `json:"name"`
+
+	// Namespace of the cluster
+	Namespace string `json:"namespace"`
+}
+
+// ServiceSeedSpec configures how a managed service is seeded. Exactly one of dumpURL and
+// volumeSnapshot is set.
+// +kubebuilder:validation:XValidation:rule="has(self.dumpURL) != has(self.volumeSnapshot)",message="exactly one of dumpURL and volumeSnapshot must be set"
+type ServiceSeedSpec struct {
+	// DumpURL is a pg_dump archive (custom format) or plain SQL file, optionally gzipped
+	// (".gz"), restored by the "<service>-seed" Job once the service is ready.
+	// Supported schemes: s3:// and https://
+	// +kubebuilder:validation:Pattern=`^(s3|https)://`
+	// +optional
+	DumpURL string `json:"dumpURL,omitempty"`
+
+	// CredentialsSecret names a Secret in the environment namespace exposed to the dump
+	// download as environment variables (e.g. AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY,
+	// AWS_ENDPOINT_URL for S3-compatible stores)
+	// +optional
+	CredentialsSecret string `json:"credentialsSecret,omitempty"`
+
+	// VolumeSnapshot is the golden snapshot the data volume is cloned from: a name in the
+	// environment namespace, or "namespace/name" (requires the CrossNamespaceVolumeDataSource
+	// feature gate and a ReferenceGrant). Requires storage.
+	// +optional
+	VolumeSnapshot string `json:"volumeSnapshot,omitempty"`
+}
+
+// ConnectionPoolerSpec configures a pgbouncer Deployment in front of a managed Postgres.
+type ConnectionPoolerSpec struct {
+	// Image for pgbouncer
+	// +optional
+	// +kubebuilder:default="edoburu/pgbouncer:v1.23.1-p2"
+	Image string `json:"image,omitempty"`
+
+	// PoolMode is the pgbouncer pool_mode
+	// +optional
+	// +kubebuilder:validation:Enum=session;transaction;statement
+	// +kubebuilder:default=session
+	PoolMode string `json:"poolMode,omitempty"`
+
+	// DefaultPoolSize is the number of server connections per user/database pair
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	DefaultPoolSize int32 `json:"defaultPoolSize,omitempty"`
+
+	// MaxClientConnections is the maximum number of client connections accepted
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	MaxClientConnections int32 `json:"maxClientConnections,omitempty"`
+
+	// Resources are CPU/memory requests and limits for the pooler
+	// +optional
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
 }
 
 // ManagedServiceContainer is a curated subset of corev1.Container for service pods.
 type ManagedServiceContainer struct {
 	// Image for the service (e.g., "postgres:16")
-	Image string `json:"image"`
+	// +optional
+	Image string `json:"image,omitempty"`
+
+	// Args to the image entrypoint
+	// +optional
+	Args []string `json:"args,omitempty"`
 
 	// Ports to expose
 	// +optional
@@ -223,6 +818,10 @@ type ManagedServiceContainer struct {
 	// Resources are CPU/memory requests and limits
 	// +optional
 	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// ReadinessProbe gates the environment on the service accepting connections
+	// +optional
+	ReadinessProbe *corev1.Probe `json:"readinessProbe,omitempty"`
 }
 
 // VolumeSpec defines a PVC to create in the environment namespace (FR-ENV-032).
@@ -233,18 +832,179 @@ type VolumeSpec struct {
 	// PersistentVolumeClaim spec (mirrors corev1.PersistentVolumeClaimSpec)
 	// +optional
 	PersistentVolumeClaim *corev1.PersistentVolumeClaimSpec `json:"persistentVolumeClaim,omitempty"`
+
+	// Size of the claim, overriding persistentVolumeClaim.resources.requests.storage; setting
+	// it alone creates a ReadWriteOnce claim. Growing it expands the existing claim when its
+	// StorageClass allows volume expansion.
+	// +optional
+	Size *resource.Quantity `json:"size,omitempty"`
+
+	// StorageClassName of the claim, overriding persistentVolumeClaim.storageClassName. Only
+	// applies to claims created after it is set.
+	// +optional
+	StorageClassName *string `json:"storageClassName,omitempty"`
 }
 
+// Reasons of EnvironmentStatus.FailureReason
+const (
+	// FailureReasonSourceCloneFailed: a source repository could not be cloned or checked out
+	FailureReasonSourceCloneFailed = "SourceCloneFailed"
+	// FailureReasonBuildFailed: an image build Job failed
+	FailureReasonBuildFailed = "BuildFailed"
+	// FailureReasonHelmInstallFailed: the Helm release could not be installed or upgraded
+	FailureReasonHelmInstallFailed = "HelmInstallFailed"
+	// FailureReasonQuotaExceeded: the namespace ResourceQuota rejected a workload
+	FailureReasonQuotaExceeded = "QuotaExceeded"
+	// FailureReasonConfigInvalid: the Environment, Project or template configuration is invalid
+	FailureReasonConfigInvalid = "ConfigInvalid"
+	// FailureReasonInitContainerFailed: a development-mode init container exited with an
+	// error or exceeded its timeout
+	FailureReasonInitContainerFailed = "InitContainerFailed"
+	// FailureReasonDeploymentFailed: any other failure to deploy the workloads
+	FailureReasonDeploymentFailed = "DeploymentFailed"
+)
+
 // EnvironmentStatus defines the observed state of Environment.
 type EnvironmentStatus struct {
-	// Phase represents the current lifecycle state (Pending, Building, Deploying, Ready, Failed)
+	// Phase represents the current lifecycle state (Pending, Building, Deploying, Ready, Failed, Hibernated)
 	// +optional
 	Phase string `json:"phase,omitempty"`
 
+	// Message explains why the environment failed (phase Failed)
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// FailureReason categorizes the failure (phase Failed)
+	// +kubebuilder:validation:Enum=SourceCloneFailed;BuildFailed;HelmInstallFailed;QuotaExceeded;ConfigInvalid;InitContainerFailed;DeploymentFailed
+	// +optional
+	FailureReason string `json:"failureReason,omitempty"`
+
 	// URL is the public endpoint if available
 	// +optional
 	URL string `json:"url,omitempty"`
 
+	// URLs lists every endpoint the environment is reachable at: the canonical URL first,
+	// followed by the alias URL when spec.alias is routed
+	// +optional
+	URLs []string `json:"urls,omitempty"`
+
+	// DeploymentMode is the resolved deployment mode: spec.deploymentMode, or the mode
+	// inferred from the template and spec.type
+	// +optional
+	DeploymentMode string `json:"deploymentMode,omitempty"`
+
+	// Commit is the abbreviated commit of the primary source (the first of spec.sources)
+	// +optional
+	Commit string `json:"commit,omitempty"`
+
+	// TemplateRevision is the Project template revision this environment was rendered from
+	// +optional
+	TemplateRevision int64 `json:"templateRevision,omitempty"`
+
+	// TemplateHash is the content hash of the template this environment was rendered from
+	// +optional
+	TemplateHash string `json:"templateHash,omitempty"`
+
+	// BuildDuration is the wall-clock time of the most recent set of image builds
+	// (earliest build start to latest build completion)
+	// +optional
+	BuildDuration *metav1.Duration `json:"buildDuration,omitempty"`
+
+	// BuiltImages records the images produced by the template builds, with the digest
+	// the registry reported for each push
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	BuiltImages []BuiltImage `json:"builtImages,omitempty"`
+
+	// Builds tracks the build Job of each template build of the current commits
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	Builds []BuildJobStatus `json:"builds,omitempty"`
+
+	// QueuePosition is the place of the environment's first queued build in the cluster-wide
+	// build queue (1 is next), while builds wait for a build slot
+	// +optional
+	QueuePosition *int32 `json:"queuePosition,omitempty"`
+
+	// OperationInProgress is the Helm install or upgrade of the environment running in the
+	// background. Reconciles observe it rather than starting another; it is cleared when the
+	// operation finishes.
+	// +optional
+	OperationInProgress *OperationStatus `json:"operationInProgress,omitempty"`
+
+	// DeploymentHistory lists the image sets the environment was deployed with, most recent
+	// first (bounded). Setting spec.sources[].commitSha back to a commit found here redeploys
+	// its images by digest instead of rebuilding them. With registry garbage collection, image
+	// sets beyond the retained builds are deleted from the registry and dropped from the history.
+	// +optional
+	DeploymentHistory []DeploymentRecord `json:"deploymentHistory,omitempty"`
+
+	// ExpiresAt is when the maximum lifetime policy deletes this environment.
+	// Unset when no cap applies or the environment is exempt.
+	// +optional
+	ExpiresAt *metav1.Time `json:"expiresAt,omitempty"`
+
+	// Runs records the outcome of each spec.runs entry
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	Runs []RunStatus `json:"runs,omitempty"`
+
+	// Tasks records the outcome of each spec.tasks entry for the current deploy
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	Tasks []RunStatus `json:"tasks,omitempty"`
+
+	// Dependencies reports the Environments of spec.dependsOn
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	Dependencies []DependencyStatus `json:"dependencies,omitempty"`
+
+	// Clone records the duplication of spec.cloneFrom
+	// +optional
+	Clone *CloneStatus `json:"clone,omitempty"`
+
+	// Promotion records the revision of spec.promoteFrom (or spec.rollbackTo) being deployed
+	// +optional
+	Promotion *PromotionStatus `json:"promotion,omitempty"`
+
+	// Resources is the current resource usage of the environment namespace, refreshed
+	// periodically from metrics-server
+	// +optional
+	Resources *ResourceUsage `json:"resources,omitempty"`
+
+	// Notification records the last phase reported to GitHub (Project spec.notifications)
+	// +optional
+	Notification *NotificationStatus `json:"notification,omitempty"`
+
+	// FileSync is the file sync endpoint of development mode (config.fileSync)
+	// +optional
+	FileSync *FileSyncStatus `json:"fileSync,omitempty"`
+
+	// Render is the last rendering of the resources the environment deploys, requested with
+	// the catalyst.dev/render annotation or kept current by catalyst.dev/render-only
+	// +optional
+	Render *RenderStatus `json:"render,omitempty"`
+
+	// URLProbe is the last HTTP probe of status.url, with URL probing enabled in the operator;
+	// the environment turns Ready once the URL answers
+	// +optional
+	URLProbe *URLProbeStatus `json:"urlProbe,omitempty"`
+
+	// Drifted is set when a reconcile found resources the operator manages changed or
+	// deleted outside of it, before repairing them; the next reconcile finding none clears it
+	// +optional
+	Drifted bool `json:"drifted,omitempty"`
+
+	// AppliedHashes maps "Kind/name" of the resources the operator applies itself to the
+	// hash of their last applied desired spec, for drift detection
+	// +optional
+	AppliedHashes map[string]string `json:"appliedHashes,omitempty"`
+
 	// conditions represent the current state of the Environment resource.
 	// +listType=map
 	// +listMapKey=type
@@ -252,8 +1012,331 @@ type EnvironmentStatus struct {
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
+// ResourceUsage is the aggregated resource usage of the pods in an environment namespace
+type ResourceUsage struct {
+	// CPU is the current CPU usage summed over the pods
+	// +optional
+	CPU *resource.Quantity `json:"cpu,omitempty"`
+
+	// Memory is the current working set memory summed over the pods
+	// +optional
+	Memory *resource.Quantity `json:"memory,omitempty"`
+
+	// Pods is the number of pods metrics were reported for
+	// +optional
+	Pods int32 `json:"pods,omitempty"`
+
+	// Quota is the percentage of each namespace ResourceQuota limit in use
+	// (e.g. "requests.cpu": 45)
+	// +optional
+	Quota map[corev1.ResourceName]int32 `json:"quota,omitempty"`
+
+	// QuotaPercent is the highest percentage in Quota
+	// +optional
+	QuotaPercent int32 `json:"quotaPercent,omitempty"`
+
+	// ObservedAt is when the usage was collected
+	ObservedAt metav1.Time `json:"observedAt"`
+}
+
+// NotificationStatus records the last phase transition reported to GitHub
+type NotificationStatus struct {
+	// Phase is the reported phase
+	Phase string `json:"phase"`
+
+	// Commit is the commit the phase was reported for
+	// +optional
+	Commit string `json:"commit,omitempty"`
+
+	// CommentID is the pull request comment kept up to date with the environment phase
+	// +optional
+	CommentID int64 `json:"commentID,omitempty"`
+}
+
+// EnvironmentPromotion names the revision of an Environment to promote
+type EnvironmentPromotion struct {
+	// Environment is a Ready Environment in the same namespace and Project
+	// +kubebuilder:validation:MinLength=1
+	Environment string `json:"environment"`
+
+	// Revision is the revision of the source's status.deploymentHistory to deploy
+	// +kubebuilder:validation:Minimum=1
+	Revision int64 `json:"revision"`
+}
+
+// PromotionStatus records the revision of spec.promoteFrom the environment deploys
+type PromotionStatus struct {
+	// Source is the promoted Environment
+	Source string `json:"source"`
+
+	// Revision is the promoted revision of the source's deployment history
+	Revision int64 `json:"revision"`
+
+	// TemplateRevision is the Project template revision the source deployed it with
+	// +optional
+	TemplateRevision int64 `json:"templateRevision,omitempty"`
+
+	// TemplateHash is the content hash of Template
+	TemplateHash string `json:"templateHash"`
+
+	// Template is the snapshot of the template the revision was deployed with
+	Template EnvironmentTemplateSpec `json:"template"`
+
+	// Images are the images of the revision, deployed by digest
+	Images []BuiltImage `json:"images"`
+
+	// PromotedAt is when the revision was promoted
+	PromotedAt metav1.Time `json:"promotedAt"`
+}
+
+// CloneStatus records how an environment was cloned from spec.cloneFrom
+type CloneStatus struct {
+	// Source is the Environment the spec was copied from
+	Source string `json:"source"`
+
+	// SourceNamespace is the target namespace of the source, holding the VolumeSnapshots
+	SourceNamespace string `json:"sourceNamespace"`
+
+	// Snapshots are the VolumeSnapshots taken in SourceNamespace for the managed-service
+	// volumes; they are deleted with this environment
+	// +optional
+	Snapshots []string `json:"snapshots,omitempty"`
+
+	// SecretsCopied is true once the referenced Secrets were copied into the new namespace
+	// +optional
+	SecretsCopied bool `json:"secretsCopied,omitempty"`
+
+	// ClonedAt is when the spec was copied
+	ClonedAt metav1.Time `json:"clonedAt"`
+}
+
+// BuiltImage is an image produced by a template build
+type BuiltImage struct {
+	// Name of the build (matches EnvironmentTemplateSpec.Builds[].Name)
+	Name string `json:"name"`
+
+	// Image is the pushed image reference (repository:tag)
+	Image string `json:"image"`
+
+	// Digest is the manifest digest of the pushed image (e.g. "sha256:...")
+	// +optional
+	Digest string `json:"digest,omitempty"`
+
+	// Commit is the source commit the image was built from, when pinned by spec.sources[].commitSha
+	// +optional
+	Commit string `json:"commit,omitempty"`
+
+	// Vulnerabilities are the severity counts of the build scan (Project spec.buildScan)
+	// +optional
+	Vulnerabilities *VulnerabilityCounts `json:"vulnerabilities,omitempty"`
+
+	// Signature is the reference of the cosign signature of the image (Project spec.buildSigning),
+	// e.g. "ghcr.io/acme/web:sha256-<digest>.sig"
+	// +optional
+	Signature string `json:"signature,omitempty"`
+}
+
+// VulnerabilityCounts are the vulnerabilities found in an image by severity
+type VulnerabilityCounts struct {
+	Critical int32 `json:"critical"`
+	High     int32 `json:"high"`
+	Medium   int32 `json:"medium"`
+	Low      int32 `json:"low"`
+}
+
+// DependencyStatus is the state of an Environment of spec.dependsOn
+type DependencyStatus struct {
+	// Name of the Environment
+	Name string `json:"name"`
+
+	// Phase of the Environment; empty if it does not exist
+	// +optional
+	Phase string `json:"phase,omitempty"`
+
+	// URL of the Environment's preview
+	// +optional
+	URL string `json:"url,omitempty"`
+}
+
+// FileSyncStatus is the endpoint files are pushed to
+type FileSyncStatus struct {
+	// URL of the WebSocket tunnel to the rsync daemon. Empty with Gateway API routing,
+	// which the endpoint is not exposed through.
+	// +optional
+	URL string `json:"url,omitempty"`
+
+	// SecretName is the Secret in the environment namespace with the rsync password
+	SecretName string `json:"secretName"`
+}
+
+// RenderStatus records a rendering of the resources an environment deploys, produced
+// without applying anything
+type RenderStatus struct {
+	// ConfigMap next to the Environment holding the rendering: the resolved config, the
+	// manifests and any guardrail violations
+	// +optional
+	ConfigMap string `json:"configMap,omitempty"`
+
+	// DeploymentMode the resources were rendered for
+	// +optional
+	DeploymentMode string `json:"deploymentMode,omitempty"`
+
+	// ObservedGeneration is the Environment generation that was rendered
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// RenderedAt is when the rendering was produced
+	RenderedAt metav1.Time `json:"renderedAt"`
+
+	// Error explains why the resources could not be rendered
+	// +optional
+	Error string `json:"error,omitempty"`
+}
+
+// URLProbeStatus records the last HTTP probe of an environment URL
+type URLProbeStatus struct {
+	// URL that was probed
+	URL string `json:"url"`
+
+	// LastProbeTime is when the URL was last probed
+	LastProbeTime metav1.Time `json:"lastProbeTime"`
+
+	// HTTPStatus is the status code of the response; unset when no response arrived
+	// +optional
+	HTTPStatus int32 `json:"httpStatus,omitempty"`
+
+	// Error explains why no response arrived or why the response is not healthy
+	// +optional
+	Error string `json:"error,omitempty"`
+
+	// ConsecutiveFailures counts the failed probes since the URL last answered; probes back
+	// off as it grows
+	// +optional
+	ConsecutiveFailures int32 `json:"consecutiveFailures,omitempty"`
+}
+
+// DeploymentRecord is an image set the environment was deployed with
+type DeploymentRecord struct {
+	// Revision numbers the records of the environment, increasing with each new image set;
+	// spec.promoteFrom of other environments names it
+	// +optional
+	Revision int64 `json:"revision,omitempty"`
+
+	// Images are the template build outputs of this deployment
+	Images []BuiltImage `json:"images"`
+
+	// TemplateHash is the content hash of the template the images were deployed with
+	// +optional
+	TemplateHash string `json:"templateHash,omitempty"`
+
+	// ConfigHash is the content hash of the spec.config overrides (Helm values, replicas,
+	// hosts) the images were deployed with
+	// +optional
+	ConfigHash string `json:"configHash,omitempty"`
+
+	// DeployedAt is when the image set was first deployed
+	DeployedAt metav1.Time `json:"deployedAt"`
+
+	// Outcome is Deploying until the environment first becomes Ready with the revision
+	// (Ready), or Failed if it fails before. A Ready revision stays Ready.
+	// +kubebuilder:validation:Enum=Deploying;Ready;Failed
+	// +optional
+	Outcome string `json:"outcome,omitempty"`
+
+	// CompletedAt is when the revision reached its outcome
+	// +optional
+	CompletedAt *metav1.Time `json:"completedAt,omitempty"`
+}
+
+// Outcomes of a DeploymentRecord
+const (
+	DeploymentOutcomeDeploying = "Deploying"
+	DeploymentOutcomeReady     = "Ready"
+	DeploymentOutcomeFailed    = "Failed"
+)
+
+// OperationStatus is a long-running operation on an environment
+type OperationStatus struct {
+	// Type of the operation
+	// +kubebuilder:validation:Enum=HelmInstall;HelmUpgrade
+	Type string `json:"type"`
+
+	// StartedAt is when the operation started
+	StartedAt metav1.Time `json:"startedAt"`
+}
+
+// BuildJobStatus is the progress of a single template build
+type BuildJobStatus struct {
+	// Name of the build (matches the template builds[].name)
+	Name string `json:"name"`
+
+	// Phase is Queued, Running, Succeeded, Failed or Reused (an image built before was kept)
+	Phase string `json:"phase"`
+
+	// JobName is the Job executing the build
+	// +optional
+	JobName string `json:"jobName,omitempty"`
+
+	// Message explains a Queued or Failed phase
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// QueuedAt is when the build started waiting for a build slot. Queued builds get slots
+	// by environment type (production, then staging, then the rest) and then in this order.
+	// +optional
+	QueuedAt *metav1.Time `json:"queuedAt,omitempty"`
+}
+
+// RunStatus is the observed state of an ad-hoc run or a task
+type RunStatus struct {
+	// Name of the run or task (matches EnvironmentSpec.Runs[].Name or Tasks[].Name)
+	Name string `json:"name"`
+
+	// Revision identifies the deploy and task definition a task ran for (tasks only)
+	// +optional
+	Revision string `json:"revision,omitempty"`
+
+	// Phase is Pending, Running, Succeeded or Failed
+	Phase string `json:"phase"`
+
+	// JobName is the Job executing the run
+	// +optional
+	JobName string `json:"jobName,omitempty"`
+
+	// ExitCode of the command once it terminated
+	// +optional
+	ExitCode *int32 `json:"exitCode,omitempty"`
+
+	// StartTime is when the command started
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// Duration is the wall-clock time of the command once it terminated
+	// +optional
+	Duration *metav1.Duration `json:"duration,omitempty"`
+
+	// LogRef is the "namespace/pod" whose logs hold the command output
+	// +optional
+	LogRef string `json:"logRef,omitempty"`
+
+	// Message explains a Pending or Failed phase
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// +genclient
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
+// +kubebuilder:resource:shortName=env,categories=catalyst
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Reason",type=string,JSONPath=`.status.failureReason`,priority=1
+// +kubebuilder:printcolumn:name="URL",type=string,JSONPath=`.status.url`
+// +kubebuilder:printcolumn:name="Mode",type=string,JSONPath=`.status.deploymentMode`
+// +kubebuilder:printcolumn:name="Commit",type=string,JSONPath=`.status.commit`
+// +kubebuilder:printcolumn:name="CPU",type=string,JSONPath=`.status.resources.cpu`,priority=1
+// +kubebuilder:printcolumn:name="Memory",type=string,JSONPath=`.status.resources.memory`,priority=1
+// +kubebuilder:printcolumn:name="Quota %",type=integer,JSONPath=`.status.resources.quotaPercent`,priority=1
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 // Environment is the Schema for the environments API
 type Environment struct {
@@ -0,0 +1,104 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// EnvironmentRevisionSpec is the snapshot of one deployment of an environment
+type EnvironmentRevisionSpec struct {
+	// Environment is the name of the Environment, in the same namespace
+	Environment string `json:"environment"`
+
+	// Revision is the revision of the Environment's status.deploymentHistory;
+	// spec.rollbackTo and spec.promoteFrom name it
+	Revision int64 `json:"revision"`
+
+	// Commit is the commit of the primary source the revision was built from
+	// +optional
+	Commit string `json:"commit,omitempty"`
+
+	// Images are the template build outputs of the deployment
+	Images []BuiltImage `json:"images"`
+
+	// TemplateHash is the content hash of the template the images were deployed with
+	// +optional
+	TemplateHash string `json:"templateHash,omitempty"`
+
+	// ConfigHash is the content hash of the spec.config overrides the images were deployed with
+	// +optional
+	ConfigHash string `json:"configHash,omitempty"`
+
+	// DeployedAt is when the revision was first deployed
+	DeployedAt metav1.Time `json:"deployedAt"`
+}
+
+// EnvironmentRevisionStatus is the outcome of the deployment
+type EnvironmentRevisionStatus struct {
+	// Outcome is Deploying, Ready or Failed, as in the Environment's deployment history
+	// +optional
+	Outcome string `json:"outcome,omitempty"`
+
+	// CompletedAt is when the revision reached its outcome
+	// +optional
+	CompletedAt *metav1.Time `json:"completedAt,omitempty"`
+}
+
+// +genclient
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Environment",type=string,JSONPath=`.spec.environment`
+// +kubebuilder:printcolumn:name="Revision",type=integer,JSONPath=`.spec.revision`
+// +kubebuilder:printcolumn:name="Commit",type=string,JSONPath=`.spec.commit`
+// +kubebuilder:printcolumn:name="Outcome",type=string,JSONPath=`.status.outcome`
+// +kubebuilder:printcolumn:name="Deployed",type=date,JSONPath=`.spec.deployedAt`
+
+// EnvironmentRevision records one deployment of an environment, when the operator runs with
+// ENVIRONMENT_REVISIONS=true. Unlike status.deploymentHistory it is not trimmed and it outlives
+// the environment, so "what was deployed when" can be answered with
+// kubectl get environmentrevisions -l catalyst.dev/environment=<name>; retention is left to the
+// cluster administrator.
+type EnvironmentRevision struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// metadata is a standard object metadata
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitzero"`
+
+	// spec is the deployed revision
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="revisions are immutable"
+	// +required
+	Spec EnvironmentRevisionSpec `json:"spec"`
+
+	// status is the outcome of the deployment
+	// +optional
+	Status EnvironmentRevisionStatus `json:"status,omitzero"`
+}
+
+// +kubebuilder:object:root=true
+
+// EnvironmentRevisionList contains a list of EnvironmentRevision
+type EnvironmentRevisionList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitzero"`
+	Items           []EnvironmentRevision `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&EnvironmentRevision{}, &EnvironmentRevisionList{})
+}
@@ -0,0 +1,141 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// EnvironmentScheduleSpec defines the desired state of EnvironmentSchedule
+type EnvironmentScheduleSpec struct {
+	// Up is the cron expression ("minute hour day-of-month month day-of-week") at which the
+	// Environment is created, e.g. "0 6 * * MON-FRI"
+	Up string `json:"up"`
+
+	// Down is the cron expression at which the Environment is deleted, e.g. "0 20 * * MON-FRI".
+	// The Environment exists from an up time until the next down time.
+	Down string `json:"down"`
+
+	// TimeZone of the cron expressions and skip dates, an IANA name such as
+	// "Europe/Berlin" (default UTC)
+	// +optional
+	TimeZone string `json:"timeZone,omitempty"`
+
+	// StartingDeadline bounds how late an up time is still acted on, so an operator that was
+	// down or whose clock was skewed does not bring an environment up far into its window.
+	// Unset acts on it anywhere in the window.
+	// +optional
+	StartingDeadline *metav1.Duration `json:"startingDeadline,omitempty"`
+
+	// Suspend stops creating Environments; an active one is still deleted at the down time
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
+
+	// Template is the Environment created at each up time, named after the schedule
+	Template EnvironmentScheduleTemplate `json:"template"`
+}
+
+// EnvironmentScheduleTemplate describes the Environment created by an EnvironmentSchedule
+type EnvironmentScheduleTemplate struct {
+	// Labels and annotations of the Environment
+	// +optional
+	Metadata EnvironmentScheduleTemplateMetadata `json:"metadata,omitzero"`
+
+	// Spec of the Environment
+	Spec EnvironmentSpec `json:"spec"`
+}
+
+// EnvironmentScheduleTemplateMetadata is the metadata copied onto scheduled Environments
+type EnvironmentScheduleTemplateMetadata struct {
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// EnvironmentScheduleStatus defines the observed state of EnvironmentSchedule.
+type EnvironmentScheduleStatus struct {
+	// Active is the Environment currently up, if any
+	// +optional
+	Active string `json:"active,omitempty"`
+
+	// LastUpTime is the up time the current or last Environment was created for
+	// +optional
+	LastUpTime *metav1.Time `json:"lastUpTime,omitempty"`
+
+	// LastDownTime is when the last Environment was deleted
+	// +optional
+	LastDownTime *metav1.Time `json:"lastDownTime,omitempty"`
+
+	// NextUpTime is the next time the Environment is created
+	// +optional
+	NextUpTime *metav1.Time `json:"nextUpTime,omitempty"`
+
+	// NextDownTime is the next time the Environment is deleted
+	// +optional
+	NextDownTime *metav1.Time `json:"nextDownTime,omitempty"`
+
+	// conditions represent the current state of the EnvironmentSchedule resource.
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// +genclient
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:shortName=envsched,categories=catalyst
+// +kubebuilder:printcolumn:name="Up",type=string,JSONPath=`.spec.up`
+// +kubebuilder:printcolumn:name="Down",type=string,JSONPath=`.spec.down`
+// +kubebuilder:printcolumn:name="Active",type=string,JSONPath=`.status.active`
+// +kubebuilder:printcolumn:name="Next Up",type=date,JSONPath=`.status.nextUpTime`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// EnvironmentSchedule creates an Environment at the up times of a cron schedule and deletes
+// it at the down times, e.g. a nightly QA environment from 6am to 8pm on weekdays. The
+// catalyst.dev/skip-dates annotation lists dates ("2025-12-24,2025-12-25", in the schedule's
+// time zone) on which it does not come up, e.g. holidays.
+type EnvironmentSchedule struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// metadata is a standard object metadata
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitzero"`
+
+	// spec defines the desired state of EnvironmentSchedule
+	// +required
+	Spec EnvironmentScheduleSpec `json:"spec"`
+
+	// status defines the observed state of EnvironmentSchedule
+	// +optional
+	Status EnvironmentScheduleStatus `json:"status,omitzero"`
+}
+
+// +kubebuilder:object:root=true
+
+// EnvironmentScheduleList contains a list of EnvironmentSchedule
+type EnvironmentScheduleList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitzero"`
+	Items           []EnvironmentSchedule `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&EnvironmentSchedule{}, &EnvironmentScheduleList{})
+}
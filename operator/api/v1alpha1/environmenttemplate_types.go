@@ -0,0 +1,56 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +genclient
+// +genclient:noStatus
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:shortName=envtpl,categories=catalyst
+// +kubebuilder:printcolumn:name="Type",type=string,JSONPath=`.spec.type`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// EnvironmentTemplate is a shared ("golden") template in the template catalog, e.g.
+// "nextjs-postgres" or "go-api". Projects reference it by name from spec.templateRefs
+// instead of duplicating the template in every Project.
+type EnvironmentTemplate struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// metadata is a standard object metadata
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitzero"`
+
+	// spec is the template content, identical to an inline Project template
+	// +required
+	Spec EnvironmentTemplateSpec `json:"spec"`
+}
+
+// +kubebuilder:object:root=true
+
+// EnvironmentTemplateList contains a list of EnvironmentTemplate
+type EnvironmentTemplateList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitzero"`
+	Items           []EnvironmentTemplate `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&EnvironmentTemplate{}, &EnvironmentTemplateList{})
+}
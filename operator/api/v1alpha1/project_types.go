@@ -18,6 +18,7 @@ package v1alpha1
 
 import (
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
@@ -41,12 +42,297 @@ type ProjectSpec struct {
 	// Templates for different environment types.
 	// Standard keys: "development" (previews) and "deployment" (production/staging).
 	// +optional
-	Templates map[string]EnvironmentTemplate `json:"templates,omitempty"`
+	Templates map[string]EnvironmentTemplateSpec `json:"templates,omitempty"`
+
+	// TemplateRefs use shared EnvironmentTemplates from the template catalog, keyed like
+	// Templates (e.g. "development": {name: "nextjs-postgres"}). An inline template with the
+	// same key takes precedence.
+	// +optional
+	TemplateRefs map[string]TemplateReference `json:"templateRefs,omitempty"`
+
+	// BaseDomain overrides the operator preview domain (PREVIEW_DOMAIN) for this project's
+	// environment hosts, e.g. "preview.acme.dev". Requires a matching wildcard DNS record.
+	// +optional
+	// +kubebuilder:validation:MaxLength=253
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`
+	BaseDomain string `json:"baseDomain,omitempty"`
+
+	// Routing selects how environment hosts are exposed: "ingress" (default) creates Ingresses,
+	// "gateway" creates Gateway API HTTPRoutes. Unset uses the operator setting (PREVIEW_ROUTING).
+	// +optional
+	// +kubebuilder:validation:Enum=ingress;gateway
+	Routing string `json:"routing,omitempty"`
+
+	// Ingress customizes the preview Ingresses of the project's environments: their class,
+	// annotations and additional paths. Environments override it with their own spec.ingress.
+	// +optional
+	Ingress *IngressDefaults `json:"ingress,omitempty"`
 
 	// Resources configuration (quotas, limits)
 	Resources ResourceConfig `json:"resources,omitempty"`
+
+	// BuildCache configures a shared layer cache repository for image builds,
+	// so successive builds (e.g. PR commits) reuse layers.
+	// +optional
+	BuildCache *BuildCacheSpec `json:"buildCache,omitempty"`
+
+	// BuildScan adds an SBOM and vulnerability scanning stage to image builds.
+	// Severity counts are recorded in Environment status.builtImages and on the BuildScanned condition.
+	// +optional
+	BuildScan *BuildScanSpec `json:"buildScan,omitempty"`
+
+	// BuildSigning signs pushed images with cosign and attests their SLSA provenance.
+	// Signature references are recorded in Environment status.builtImages.
+	// +optional
+	BuildSigning *BuildSigningSpec `json:"buildSigning,omitempty"`
+
+	// ImagePullSecrets are dockerconfigjson Secrets of the project namespace with the credentials
+	// of private registries. They are copied into environment namespaces: builds pull private
+	// base images with them, merged with the push credentials of the build registry, and the
+	// default ServiceAccount pulls environment images with them.
+	// +optional
+	ImagePullSecrets []corev1.LocalObjectReference `json:"imagePullSecrets,omitempty"`
+
+	// MaxParallelBuilds caps the build Jobs running at once across the Project's environments.
+	// Further builds are queued until a slot frees up. Unset leaves only the operator-wide limit.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxParallelBuilds *int32 `json:"maxParallelBuilds,omitempty"`
+
+	// Secrets selects the backend environment secrets are synced from into the catalyst-secrets
+	// Secret. Unset uses the Catalyst web API.
+	// +optional
+	Secrets *SecretsSpec `json:"secrets,omitempty"`
+
+	// DependencyCache shares a package manager cache (npm/pnpm/yarn, Go modules, pip) between
+	// the environments of the project, mounted into development-mode init containers.
+	// +optional
+	DependencyCache *DependencyCacheSpec `json:"dependencyCache,omitempty"`
+
+	// Storage sets the storage class and size of the environment volume, managed-service and
+	// compose volume claims that do not set their own.
+	// +optional
+	Storage *StorageDefaultsSpec `json:"storage,omitempty"`
+
+	// Notifications reports environment phase transitions (building, ready, failed, torn down)
+	// back to GitHub through the GitHub App installation (githubInstallationId).
+	// +optional
+	Notifications *NotificationsSpec `json:"notifications,omitempty"`
+
+	// PodSecurity is the Pod Security Standard enforced, audited and warned about in the
+	// project and environment namespaces. Generated workloads always meet restricted;
+	// lower it for Helm charts or kustomizations that need more. Defaults to restricted.
+	// +kubebuilder:validation:Enum=privileged;baseline;restricted
+	// +optional
+	PodSecurity string `json:"podSecurity,omitempty"`
+
+	// HelmValuesPolicy restricts the values helm-type templates may set. Values are also
+	// validated against the chart's values.schema.json before every install or upgrade.
+	// +optional
+	HelmValuesPolicy *HelmValuesPolicySpec `json:"helmValuesPolicy,omitempty"`
+
+	// HelmPostRender fills in what app charts commonly omit in the rendered output of
+	// helm-type templates, before every install or upgrade and ahead of the guardrails.
+	// Unset deploys charts as rendered.
+	// +optional
+	HelmPostRender *HelmPostRenderSpec `json:"helmPostRender,omitempty"`
+
+	// Prebuild keeps the workspace of a development template prebuilt for the default branch
+	// (the first source's branch) in the project namespace: the code cloned and the install
+	// steps run. Development environments of the branch create their code volume as a CSI
+	// clone of it instead of an empty volume.
+	// +optional
+	Prebuild *PrebuildSpec `json:"prebuild,omitempty"`
+}
+
+// HelmValuesPolicySpec lists the Helm values the environments of a project may set
+type HelmValuesPolicySpec struct {
+	// AllowedKeys are dotted value paths (e.g. "replicaCount", "ingress.annotations"); a path
+	// allows everything beneath it. Merged template and environment values outside of them
+	// fail the environment. The values the operator injects (global.images,
+	// global.catalystSecrets) are always allowed.
+	// +listType=set
+	AllowedKeys []string `json:"allowedKeys"`
+}
+
+// HelmPostRenderSpec selects the mutations applied to rendered Helm charts. Fields a chart
+// sets are kept.
+type HelmPostRenderSpec struct {
+	// Labels adds the catalyst.dev team, project and environment labels to every object and
+	// to the pod templates of workloads (not their selectors)
+	// +optional
+	Labels bool `json:"labels,omitempty"`
+
+	// ImagePullSecrets adds the pull secrets of the environment namespace's default
+	// ServiceAccount (the registry credentials and spec.imagePullSecrets) to every pod, so pods
+	// of chart-created ServiceAccounts pull private images too
+	// +optional
+	ImagePullSecrets bool `json:"imagePullSecrets,omitempty"`
+
+	// SecurityContext applies the restricted defaults of generated workloads to the fields
+	// pods and containers leave unset: a non-root user, the runtime default seccomp profile,
+	// no capabilities and privilege escalation, and a read-only root filesystem with a
+	// writable /tmp
+	// +optional
+	SecurityContext bool `json:"securityContext,omitempty"`
+
+	// DefaultResources are the requests and limits of containers that set none for a
+	// resource, so charts fit the namespace quota. Defaults never put a request above the
+	// container's limit.
+	// +optional
+	DefaultResources *corev1.ResourceRequirements `json:"defaultResources,omitempty"`
+}
+
+// PrebuildSpec selects the workspace prebuilt for the default branch
+type PrebuildSpec struct {
+	// Template is the key of the development template prebuilt, whose first volume mount is
+	// the code volume. Defaults to "development".
+	// +optional
+	Template string `json:"template,omitempty"`
+
+	// CommitSha of the default branch to prebuild. The Catalyst web app sets it on pushes to
+	// the branch; unset prebuilds the branch head once.
+	// +optional
+	CommitSha string `json:"commitSha,omitempty"`
+
+	// Steps are the names of the template init containers run on the cloned code, e.g. the
+	// dependency install. Unset runs those that mount only the code volume.
+	// +listType=set
+	// +optional
+	Steps []string `json:"steps,omitempty"`
+}
+
+// NotificationsSpec selects how environment phase transitions are reported to GitHub
+type NotificationsSpec struct {
+	// CommitStatus posts a commit status (context "catalyst/<environment>") on the deployed
+	// commit, linking to the preview URL once ready
+	// +optional
+	CommitStatus bool `json:"commitStatus,omitempty"`
+
+	// PullRequestComment keeps a comment on the environment's pull request up to date with
+	// the phase, the preview URL and build failures
+	// +optional
+	PullRequestComment bool `json:"pullRequestComment,omitempty"`
+}
+
+// StorageDefaultsSpec is the storage of environment claims that do not choose their own
+type StorageDefaultsSpec struct {
+	// StorageClassName of claims without one, e.g. a faster or cheaper class than the cluster
+	// default
+	// +optional
+	StorageClassName *string `json:"storageClassName,omitempty"`
+
+	// Size of claims that request no storage. Service presets request their own.
+	// +optional
+	Size *resource.Quantity `json:"size,omitempty"`
+}
+
+// DependencyCacheSpec configures the project dependency cache: a ReadWriteMany volume in the
+// Project namespace, bound into each environment namespace through a PersistentVolume with the
+// same backing storage.
+type DependencyCacheSpec struct {
+	// Size of the cache volume
+	// +kubebuilder:default="20Gi"
+	// +optional
+	Size resource.Quantity `json:"size,omitempty"`
+
+	// StorageClassName of the cache volume. The class must provision ReadWriteMany volumes
+	// with Immediate binding (e.g. NFS, CephFS, EFS). Defaults to the cluster default class.
+	// +optional
+	StorageClassName *string `json:"storageClassName,omitempty"`
+
+	// MountPath of the cache in the init containers. Package managers are pointed at
+	// subdirectories through npm_config_cache, npm_config_store_dir, YARN_CACHE_FOLDER,
+	// GOMODCACHE and PIP_CACHE_DIR, unless the container sets them.
+	// +kubebuilder:default="/cache"
+	// +optional
+	MountPath string `json:"mountPath,omitempty"`
+}
+
+// SecretsSpec configures the environment secrets backend. The Vault server and AWS region are
+// operator settings, so a Project can only read secrets below its team.
+type SecretsSpec struct {
+	// Provider of the secrets: "webapi" reads the environment named by the
+	// catalyst.dev/environment-id annotation from the Catalyst web API; "vault" and
+	// "aws-secrets-manager" read the secret at Path from the backend the operator
+	// configuration sets up.
+	// +kubebuilder:validation:Enum=webapi;vault;aws-secrets-manager
+	// +kubebuilder:default=webapi
+	// +optional
+	Provider string `json:"provider,omitempty"`
+
+	// Path of an environment's secret in Vault or AWS Secrets Manager, below the path of the
+	// team: the operator reads <team>/<path>. Supports the {{project}}, {{env}} and {{team}}
+	// placeholders.
+	// +kubebuilder:default="catalyst/{{project}}/{{env}}"
+	// +optional
+	Path string `json:"path,omitempty"`
+}
+
+// BuildScanSpec configures the post-build scan: trivy generates a CycloneDX SBOM of the pushed
+// image and scans it for vulnerabilities, then oras attaches the SBOM to the image as an OCI referrer.
+type BuildScanSpec struct {
+	// Image for trivy
+	// +kubebuilder:default="aquasec/trivy:0.56.2"
+	// +optional
+	Image string `json:"image,omitempty"`
+
+	// AttachImage for oras, which pushes the SBOM referrer
+	// +kubebuilder:default="ghcr.io/oras-project/oras:v1.2.0"
+	// +optional
+	AttachImage string `json:"attachImage,omitempty"`
+
+	// BlockOnCritical stops the deploy of environments whose built images have critical vulnerabilities
+	// +optional
+	BlockOnCritical bool `json:"blockOnCritical,omitempty"`
+}
+
+// BuildSigningSpec configures build signing: once a build pushed its image, a Job in the
+// Project namespace runs cosign to sign it and attach an SLSA provenance attestation
+// recording the source commit, the build Job and the Dockerfile digest. Images are signed
+// with a key pair (keySecret) or keyless.
+// +kubebuilder:validation:XValidation:rule="has(self.keySecret) != (has(self.keyless) && self.keyless)",message="exactly one of keySecret and keyless is required"
+type BuildSigningSpec struct {
+	// Image for cosign; the signing script needs a shell, as in the -dev variants
+	// +kubebuilder:default="ghcr.io/sigstore/cosign/cosign:v2.4.1-dev"
+	// +optional
+	Image string `json:"image,omitempty"`
+
+	// KeySecret names a Secret in the Project namespace holding cosign.key and
+	// cosign.password, as created by cosign generate-key-pair k8s://<namespace>/<name>.
+	// It is only mounted by the signing Jobs there, never copied to environment namespaces.
+	// +optional
+	KeySecret string `json:"keySecret,omitempty"`
+
+	// Keyless signs with a short-lived Fulcio certificate issued for the token (audience
+	// "sigstore") of the catalyst-build-signer ServiceAccount in the Project namespace.
+	// Fulcio must trust the cluster's OIDC issuer.
+	// +optional
+	Keyless bool `json:"keyless,omitempty"`
+
+	// FulcioURL is the Fulcio instance of keyless signing (default: https://fulcio.sigstore.dev)
+	// +optional
+	FulcioURL string `json:"fulcioURL,omitempty"`
+
+	// RekorURL is the transparency log signatures are recorded in. Keyless signing defaults to
+	// https://rekor.sigstore.dev; signatures with a key are only recorded when it is set.
+	// +optional
+	RekorURL string `json:"rekorURL,omitempty"`
+}
+
+// BuildCacheSpec configures the registry-backed build cache (kaniko --cache-repo).
+type BuildCacheSpec struct {
+	// Repository to push/pull cached layers (e.g. "ghcr.io/acme/app/cache").
+	// Defaults to "<registry>/<project>/cache" on the configured build registry.
+	// +optional
+	Repository string `json:"repository,omitempty"`
+
+	// TTL for cached layers (e.g. "168h"). Defaults to the builder default (two weeks).
+	// +optional
+	TTL *metav1.Duration `json:"ttl,omitempty"`
 }
 
+// +kubebuilder:validation:XValidation:rule="!has(self.credentialsSecret) || (has(self.provider) && self.provider != 'github')",message="github sources authenticate with the GitHub App installation; credentialsSecret is only supported for gitlab and bitbucket"
 type SourceConfig struct {
 	// Name to identify this source component (e.g. "frontend", "backend")
 	Name string `json:"name"`
@@ -56,9 +342,60 @@ type SourceConfig struct {
 
 	// Branch is the default branch to use
 	Branch string `json:"branch"`
+
+	// Provider hosts the repository: github (default), gitlab or bitbucket
+	// +kubebuilder:validation:Enum=github;gitlab;bitbucket
+	// +optional
+	Provider string `json:"provider,omitempty"`
+
+	// CredentialsSecret names a Secret in the Project namespace the operator mints the
+	// short-lived tokens of clones from; it is never copied into environment namespaces.
+	// gitlab: "token", an access token allowed to create project access tokens (api scope,
+	// Maintainer role or higher), which mints a read_repository token for each environment
+	// namespace, revoked when the environment is deleted. Project access tokens need the
+	// Premium or Ultimate tier on gitlab.com.
+	// bitbucket: "clientId" and "clientSecret" of an OAuth consumer, exchanged for access
+	// tokens. Required for private gitlab and bitbucket repositories; github sources use the
+	// GitHub App installation.
+	// +optional
+	CredentialsSecret string `json:"credentialsSecret,omitempty"`
+
+	// LFS downloads the Git LFS objects of the checked out commit. Build Jobs and development
+	// pods need a git-clone image with git-lfs (GIT_CLONE_IMAGE).
+	// +optional
+	LFS bool `json:"lfs,omitempty"`
+
+	// Submodules checks out the submodules of the commit: none (default), init (the
+	// submodules of the repository) or recursive (nested submodules as well). Submodules on
+	// the repository host reuse its credentials, and their SSH URLs are fetched over HTTPS.
+	// +kubebuilder:validation:Enum=none;init;recursive
+	// +optional
+	Submodules string `json:"submodules,omitempty"`
+}
+
+// Submodule checkouts of a source
+const (
+	// SubmodulesNone leaves submodule directories empty
+	SubmodulesNone = "none"
+	// SubmodulesInit checks out the submodules of the repository
+	SubmodulesInit = "init"
+	// SubmodulesRecursive checks out nested submodules as well
+	SubmodulesRecursive = "recursive"
+)
+
+// TemplateReference names an EnvironmentTemplate in the template catalog
+type TemplateReference struct {
+	// Name of the EnvironmentTemplate
+	Name string `json:"name"`
+
+	// Namespace of the EnvironmentTemplate. Defaults to the Project namespace.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
 }
 
-type EnvironmentTemplate struct {
+// EnvironmentTemplateSpec defines how environments of one type are deployed. It is used
+// inline in Project templates and as the spec of a catalog EnvironmentTemplate.
+type EnvironmentTemplateSpec struct {
 	// SourceRef refers to one of the sources defined in Project.Sources
 	// containing the deployment configuration (e.g. Chart.yaml or k8s manifests).
 	// If empty, assumes the first source or a primary source.
@@ -90,12 +427,37 @@ type EnvironmentTemplate struct {
 	// For Managed deployments, this provides the container/probe/resource configuration.
 	// +optional
 	Config *EnvironmentConfig `json:"config,omitempty"`
+
+	// Rollout controls how a new revision of this template reaches existing environments.
+	// Every content change to a template is recorded as a new revision in Project status;
+	// environments record the revision they were rendered from.
+	// +optional
+	Rollout *TemplateRollout `json:"rollout,omitempty"`
 }
 
+// TemplateRollout defines how environments adopt a new template revision.
+type TemplateRollout struct {
+	// Strategy for adopting a new revision:
+	// - "Immediate" (default): all environments re-render from the new revision
+	// - "NewEnvironmentsOnly": existing environments stay on the revision they were created with
+	// - "Batched": existing environments migrate at most BatchSize at a time
+	// +kubebuilder:validation:Enum=Immediate;NewEnvironmentsOnly;Batched
+	// +optional
+	Strategy string `json:"strategy,omitempty"`
+
+	// BatchSize is the number of environments allowed to migrate concurrently
+	// when Strategy is "Batched". An environment counts as migrating until it is Ready.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	BatchSize int `json:"batchSize,omitempty"`
+}
+
+// +kubebuilder:validation:XValidation:rule="!has(self.buildStrategy) || self.buildStrategy != 'nix' || (!has(self.target) && !has(self.buildArgs) && !has(self.platforms))",message="target, buildArgs and platforms apply to docker builds"
 type BuildSpec struct {
 	// Name identifies this build artifact (e.g. "frontend", "api").
 	// This name is used to inject the built image into the deployment values.
-	// Example: for name "frontend", values might receive global.images.frontend.repository
+	// Example: for name "frontend", values receive global.images.frontend.repository, .tag and
+	// (once the push digest is known) .digest for immutable deploys
 	Name string `json:"name"`
 
 	// SourceRef refers to the Project.Source containing the application code.
@@ -111,9 +473,106 @@ type BuildSpec struct {
 	// +optional
 	Dockerfile string `json:"dockerfile,omitempty"`
 
+	// Target is the stage of a multi-stage Dockerfile to build (e.g. "dev").
+	// Defaults to the last stage.
+	// +optional
+	Target string `json:"target,omitempty"`
+
+	// BuildArgs are passed to the Dockerfile ARGs, e.g. the NEXT_PUBLIC_ variables inlined
+	// into a frontend bundle at build time. ARG values are part of the image history.
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	BuildArgs []BuildArg `json:"buildArgs,omitempty"`
+
+	// Platforms the image is built for (e.g. "linux/amd64", "linux/arm64"). Several build once
+	// per platform, each pushed under "<tag>-<os>-<arch>", and push an image index under the
+	// tag. Platforms other than the build node's need QEMU binfmt emulation on the build
+	// nodes. Defaults to the platform of the build node.
+	// +kubebuilder:validation:items:Pattern=`^[a-z0-9]+/[a-z0-9]+(/[a-z0-9]+)?$`
+	// +listType=set
+	// +optional
+	Platforms []string `json:"platforms,omitempty"`
+
+	// BuildStrategy selects how the image is built: "docker" (default) builds the Dockerfile
+	// with kaniko, "nix" builds the flake output NixAttribute of Path (a dockerTools image or
+	// streamLayeredImage script) and pushes it with skopeo. The build cache only applies to
+	// docker builds.
+	// +kubebuilder:validation:Enum=docker;nix
+	// +optional
+	BuildStrategy string `json:"buildStrategy,omitempty"`
+
+	// NixAttribute is the flake output nix builds produce the image with (default "dockerImage")
+	// +optional
+	NixAttribute string `json:"nixAttribute,omitempty"`
+
 	// Resources allows customizing the build job resources (requests/limits)
 	// +optional
 	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// IncludePaths limits rebuilds to commits changing matching files: globs relative to the
+	// source root, where "**" matches any number of directories (e.g. "apps/web/**",
+	// "packages/ui/**"). A build unaffected by the changes since its previous image reuses
+	// that image from status.builtImages. Empty matches every file.
+	// +optional
+	IncludePaths []string `json:"includePaths,omitempty"`
+
+	// ExcludePaths are ignored when deciding whether to rebuild (e.g. "**/*.md")
+	// +optional
+	ExcludePaths []string `json:"excludePaths,omitempty"`
+
+	// PodOverrides customizes the resources and scheduling of the build Job pods, on top of
+	// the operator's build defaults (BUILD_NODE_SELECTOR, BUILD_TOLERATIONS,
+	// BUILD_PRIORITY_CLASS_NAME)
+	// +optional
+	PodOverrides *BuildPodOverrides `json:"podOverrides,omitempty"`
+}
+
+// BuildArg is a Dockerfile ARG of a build, set to Value or read from a Secret
+// +kubebuilder:validation:XValidation:rule="!(has(self.value) && has(self.secretKeyRef))",message="value and secretKeyRef are mutually exclusive"
+type BuildArg struct {
+	// Name of the ARG
+	// +kubebuilder:validation:Pattern=`^[A-Za-z_][A-Za-z0-9_]*$`
+	Name string `json:"name"`
+
+	// Value of the ARG
+	// +optional
+	Value string `json:"value,omitempty"`
+
+	// SecretKeyRef reads the value from a key of a Secret in the environment namespace that
+	// the operator config allows build args to read (builds.buildArgSecrets, default: the
+	// catalyst-secrets Secret). The value is recorded in the image history like any ARG.
+	// +optional
+	SecretKeyRef *corev1.SecretKeySelector `json:"secretKeyRef,omitempty"`
+}
+
+// BuildPodOverrides customizes the pods of a build Job
+type BuildPodOverrides struct {
+	// Resources of the build containers, replacing Resources
+	// +optional
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// NodeSelector is merged over the operator default; keys set here win
+	// +optional
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+
+	// Tolerations are added to the operator defaults
+	// +optional
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+
+	// PriorityClassName replaces the operator default
+	// +optional
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+
+	// Affinity of the build pods
+	// +optional
+	Affinity *corev1.Affinity `json:"affinity,omitempty"`
+
+	// Arch runs the build on nodes of this CPU architecture (the kubernetes.io/arch node
+	// label), building natively instead of emulating other platforms
+	// +kubebuilder:validation:Enum=amd64;arm64
+	// +optional
+	Arch string `json:"arch,omitempty"`
 }
 
 type ResourceConfig struct {
@@ -136,10 +595,71 @@ type ProjectStatus struct {
 	// +listMapKey=type
 	// +optional
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// Namespace is the project namespace provisioned for the Project's Environments
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// TemplateRevisions is the revision history of each template in spec.templates.
+	// The most recent revisions per template are retained, along with any older
+	// revision an environment is still pinned to, so it can keep rendering from it.
+	// +optional
+	TemplateRevisions []TemplateRevision `json:"templateRevisions,omitempty"`
+
+	// Prebuild is the state of the workspace prebuild (spec.prebuild)
+	// +optional
+	Prebuild *PrebuildStatus `json:"prebuild,omitempty"`
+}
+
+// PrebuildStatus is the observed state of the workspace prebuild
+type PrebuildStatus struct {
+	// Revision is the commit or branch of the latest prebuild
+	Revision string `json:"revision"`
+
+	// JobName is the Job of the latest prebuild in the project namespace
+	JobName string `json:"jobName"`
+
+	// Phase of the latest prebuild: Running, Succeeded or Failed
+	Phase string `json:"phase"`
+
+	// Message explains a Failed phase
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// ClaimName is the claim of the last successful prebuild in the project namespace,
+	// which development environments clone
+	// +optional
+	ClaimName string `json:"claimName,omitempty"`
+
+	// CompletedAt is when ClaimName was prebuilt
+	// +optional
+	CompletedAt *metav1.Time `json:"completedAt,omitempty"`
+}
+
+// TemplateRevision is an immutable snapshot of a template at a point in time.
+type TemplateRevision struct {
+	// Template is the key of the template in spec.templates (e.g. "development")
+	Template string `json:"template"`
+
+	// Revision is a monotonically increasing number per template
+	Revision int64 `json:"revision"`
+
+	// Hash is the content hash of the template snapshot
+	Hash string `json:"hash"`
+
+	// CreatedAt is when this revision was first observed
+	CreatedAt metav1.Time `json:"createdAt"`
+
+	// Spec is the template content of this revision
+	Spec EnvironmentTemplateSpec `json:"spec"`
 }
 
+// +genclient
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
+// +kubebuilder:resource:shortName=proj,categories=catalyst
+// +kubebuilder:printcolumn:name="Namespace",type=string,JSONPath=`.status.namespace`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 // Project is the Schema for the projects API
 type Project struct {
@@ -0,0 +1,128 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Roles of TeamMember.Role, bound to the built-in ClusterRoles of the same name
+const (
+	TeamRoleAdmin = "admin"
+	TeamRoleEdit  = "edit"
+	TeamRoleView  = "view"
+)
+
+// TeamMember grants a user, group or ServiceAccount access to the team and project namespaces
+type TeamMember struct {
+	// Kind of the subject: User, Group or ServiceAccount
+	// +kubebuilder:validation:Enum=User;Group;ServiceAccount
+	// +kubebuilder:default=User
+	// +optional
+	Kind string `json:"kind,omitempty"`
+
+	// Name of the subject
+	Name string `json:"name"`
+
+	// Namespace of a ServiceAccount subject, defaulting to the team namespace
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// Role is the ClusterRole bound in the team and project namespaces
+	// +kubebuilder:validation:Enum=admin;edit;view
+	// +kubebuilder:default=edit
+	// +optional
+	Role string `json:"role,omitempty"`
+}
+
+// TeamSpec defines the desired state of Team
+type TeamSpec struct {
+	// Members are bound to their role in the team namespace and every project namespace
+	// +listType=atomic
+	// +optional
+	Members []TeamMember `json:"members,omitempty"`
+
+	// Quota replaces the default hard limits of the team and project namespaces
+	// +optional
+	Quota corev1.ResourceList `json:"quota,omitempty"`
+
+	// Budget caps the sum of the quota hard limits of the team's environment namespaces.
+	// A new Environment whose quota would exceed it waits in the Pending phase with a False
+	// Admitted condition (reason QuotaExceeded) instead of deploying. Resources the budget
+	// does not list are not capped.
+	// +optional
+	Budget corev1.ResourceList `json:"budget,omitempty"`
+
+	// QueueOverBudget retries Environments over the budget every minute until capacity frees
+	// up. Otherwise they are only checked again when reconciled, e.g. on spec changes.
+	// +optional
+	QueueOverBudget bool `json:"queueOverBudget,omitempty"`
+}
+
+// TeamStatus defines the observed state of Team.
+type TeamStatus struct {
+	// Namespace is the team namespace, which holds the team's Projects
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// conditions represent the current state of the Team resource.
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// +genclient
+// +genclient:nonNamespaced
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster,categories=catalyst
+// +kubebuilder:printcolumn:name="Namespace",type=string,JSONPath=`.status.namespace`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// Team is the top of the namespace hierarchy: the operator provisions the team namespace
+// holding its Projects, and each Project gets a project namespace holding its Environments.
+// Namespaces are kept when their Team or Project is deleted so the resources in them survive.
+type Team struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// metadata is a standard object metadata
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitzero"`
+
+	// spec defines the desired state of Team
+	// +optional
+	Spec TeamSpec `json:"spec,omitzero"`
+
+	// status defines the observed state of Team
+	// +optional
+	Status TeamStatus `json:"status,omitzero"`
+}
+
+// +kubebuilder:object:root=true
+
+// TeamList contains a list of Team
+type TeamList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitzero"`
+	Items           []Team `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&Team{}, &TeamList{})
+}
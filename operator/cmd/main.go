@@ -21,6 +21,8 @@ import (
 	"crypto/tls"
 	"flag"
 	"os"
+	"strings"
+	"time"
 
 	"go.uber.org/zap/zapcore"
 
@@ -31,6 +33,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/discovery"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -41,7 +44,17 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+	"github.com/ncrmro/catalyst/operator/internal/audit"
+	"github.com/ncrmro/catalyst/operator/internal/capabilities"
 	"github.com/ncrmro/catalyst/operator/internal/controller"
+	"github.com/ncrmro/catalyst/operator/internal/dashboard"
+	"github.com/ncrmro/catalyst/operator/internal/gateway"
+	"github.com/ncrmro/catalyst/operator/internal/gitevents"
+	"github.com/ncrmro/catalyst/operator/internal/health"
+	"github.com/ncrmro/catalyst/operator/internal/operatorconfig"
+	"github.com/ncrmro/catalyst/operator/internal/sharding"
+	"github.com/ncrmro/catalyst/operator/internal/tracing"
+	webhookv1alpha1 "github.com/ncrmro/catalyst/operator/internal/webhook/v1alpha1"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -63,9 +76,26 @@ func main() {
 	var metricsCertPath, metricsCertName, metricsCertKey string
 	var webhookCertPath, webhookCertName, webhookCertKey string
 	var enableLeaderElection bool
+	var leaderElectionNamespace string
+	var leaseDuration, renewDeadline, retryPeriod time.Duration
+	var gracefulShutdownTimeout time.Duration
+	var readyCheckWebAPI bool
 	var probeAddr string
 	var secureMetrics bool
 	var enableHTTP2 bool
+	var dashboardAddr string
+	var gatewayAddr string
+	var gatewayOrigins string
+	var gatewayTrustedProxies string
+	var auditSink, auditWebhookURL string
+	var gitWebhookAddr string
+	var shardIndex, shardCount int
+	var namespaceSelector string
+	var maxConcurrentBuilds int
+	var maxConcurrentHelmOperations int
+	var resyncInterval time.Duration
+	var tracingEndpoint string
+	var configFile string
 	var tlsOpts []func(*tls.Config)
 	flag.StringVar(&metricsAddr, "metrics-bind-address", "0", "The address the metrics endpoint binds to. "+
 		"Use :8443 for HTTPS or :8080 for HTTP, or leave as 0 to disable the metrics service.")
@@ -73,6 +103,19 @@ func main() {
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	flag.StringVar(&leaderElectionNamespace, "leader-elect-namespace", "", "The namespace of the leader election "+
+		"Lease. Defaults to the namespace the operator runs in.")
+	flag.DurationVar(&leaseDuration, "leader-elect-lease-duration", 15*time.Second, "How long standby replicas "+
+		"wait before taking over a Lease that was not renewed.")
+	flag.DurationVar(&renewDeadline, "leader-elect-renew-deadline", 10*time.Second, "How long the leader retries "+
+		"renewing its Lease before it steps down.")
+	flag.DurationVar(&retryPeriod, "leader-elect-retry-period", 2*time.Second, "How often replicas try to "+
+		"acquire or renew the Lease.")
+	flag.DurationVar(&gracefulShutdownTimeout, "graceful-shutdown-timeout", 2*time.Minute, "How long in-flight "+
+		"reconciles and background Helm installs may run after a shutdown signal before the operator exits. "+
+		"Keep it below the pod's terminationGracePeriodSeconds.")
+	flag.BoolVar(&readyCheckWebAPI, "ready-check-web-api", false, "If set, /readyz also requires the Catalyst "+
+		"web API (CATALYST_WEB_URL) to be reachable.")
 	flag.BoolVar(&secureMetrics, "metrics-secure", true,
 		"If set, the metrics endpoint is served securely via HTTPS. Use --metrics-secure=false to use HTTP instead.")
 	flag.StringVar(&webhookCertPath, "webhook-cert-path", "", "The directory that contains the webhook certificate.")
@@ -84,6 +127,36 @@ func main() {
 	flag.StringVar(&metricsCertKey, "metrics-cert-key", "tls.key", "The name of the metrics server key file.")
 	flag.BoolVar(&enableHTTP2, "enable-http2", false,
 		"If set, HTTP/2 will be enabled for the metrics and webhook servers")
+	flag.StringVar(&dashboardAddr, "dashboard-bind-address", "0", "The address the read-only status page (/dashboard) "+
+		"binds to, e.g. :8082. Access requires RBAC on the /dashboard nonResourceURL. Leave as 0 to disable.")
+	flag.StringVar(&gatewayAddr, "gateway-bind-address", "0", "The address the workspace exec gateway (/exec/) "+
+		"binds to, e.g. :8083. Sessions authenticate with per-environment tokens. Leave as 0 to disable.")
+	flag.StringVar(&gatewayOrigins, "gateway-allowed-origins", "", "Comma-separated browser origins allowed "+
+		"to open gateway WebSocket sessions, e.g. https://catalyst.example.com.")
+	flag.StringVar(&gatewayTrustedProxies, "gateway-trusted-proxies", "", "Comma-separated addresses or CIDRs "+
+		"of the reverse proxies in front of the gateway whose X-Forwarded-For header is trusted.")
+	flag.StringVar(&auditSink, "audit-sink", audit.SinkStdout, "Where gateway exec sessions are audited: "+
+		"stdout (JSON lines), webhook (POST to --audit-webhook-url) or auditevent (AuditEvent resources).")
+	flag.StringVar(&auditWebhookURL, "audit-webhook-url", "", "The URL audit events are POSTed to with --audit-sink=webhook.")
+	flag.StringVar(&gitWebhookAddr, "git-webhook-bind-address", "0", "The address the git push receiver "+
+		"(/webhooks/github, /webhooks/gitlab) binds to, e.g. :8084. Deliveries are verified with the "+
+		"GIT_WEBHOOK_SECRET environment variable. Leave as 0 to disable.")
+	flag.IntVar(&shardIndex, "shard-index", 0, "The shard this instance reconciles, in [0, --shard-count).")
+	flag.IntVar(&shardCount, "shard-count", 1, "The number of operator deployments splitting Projects between them. "+
+		"Each shard elects its own leader; 1 disables sharding.")
+	flag.StringVar(&namespaceSelector, "namespace-selector", "", "A label selector restricting this instance to the "+
+		"Projects of matching namespaces, e.g. catalyst.dev/team=team-a for a per-team operator. Empty reconciles every namespace.")
+	flag.IntVar(&maxConcurrentBuilds, "max-concurrent-builds", 0, "The number of image build Jobs allowed to run at once "+
+		"across all environments. Further builds are queued, production before staging before previews, "+
+		"then first come first served. 0 disables the limit.")
+	flag.IntVar(&maxConcurrentHelmOperations, "max-concurrent-helm-operations", 0, "The number of Helm installs and "+
+		"upgrades run in the background at once, one per release. 0 runs one per CPU.")
+	flag.DurationVar(&resyncInterval, "resync-interval", 10*time.Minute, "How often Ready environments are "+
+		"re-reconciled to detect and repair drift of the resources the operator manages. 0 disables the resync.")
+	flag.StringVar(&tracingEndpoint, "tracing-endpoint", "", "The OTLP/gRPC collector reconcile traces are exported to, "+
+		"e.g. http://otel-collector.observability:4317 (https:// for TLS). Empty disables tracing.")
+	flag.StringVar(&configFile, "config", "", "The operator configuration file (kind OperatorConfig), reloaded "+
+		"when it changes. Settings it leaves unset are read from the environment variables.")
 	opts := zap.Options{
 		Development: false,
 		Level:       zapcore.WarnLevel,
@@ -93,6 +166,32 @@ func main() {
 
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 
+	operatorConfig, err := operatorconfig.Load(configFile)
+	if err != nil {
+		setupLog.Error(err, "invalid operator configuration")
+		os.Exit(1)
+	}
+	operatorconfig.Set(operatorConfig)
+
+	shutdownTracing, err := tracing.Setup(context.Background(), tracingEndpoint)
+	if err != nil {
+		setupLog.Error(err, "unable to set up tracing")
+		os.Exit(1)
+	}
+
+	shard, err := sharding.New(shardIndex, shardCount)
+	if err != nil {
+		setupLog.Error(err, "invalid sharding flags")
+		os.Exit(1)
+	}
+	if err := shard.SetNamespaceSelector(namespaceSelector); err != nil {
+		setupLog.Error(err, "invalid namespace selector")
+		os.Exit(1)
+	}
+	if shard.Partial() {
+		setupLog.Info("Reconciling a subset of Projects", "shard", shard.String())
+	}
+
 	// if the enable-http2 flag is false (the default), http/2 should be disabled
 	// due to its vulnerabilities. More specifically, disabling http/2 will
 	// prevent from being vulnerable to the HTTP/2 Stream Cancellation and
@@ -161,23 +260,21 @@ func main() {
 	}
 
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
-		Scheme:                 scheme,
-		Metrics:                metricsServerOptions,
-		WebhookServer:          webhookServer,
-		HealthProbeBindAddress: probeAddr,
-		LeaderElection:         enableLeaderElection,
-		LeaderElectionID:       "27340b24.catalyst.dev",
-		// LeaderElectionReleaseOnCancel defines if the leader should step down voluntarily
-		// when the Manager ends. This requires the binary to immediately end when the
-		// Manager is stopped, otherwise, this setting is unsafe. Setting this significantly
-		// speeds up voluntary leader transitions as the new leader don't have to wait
-		// LeaseDuration time first.
-		//
-		// In the default scaffold provided, the program ends immediately after
-		// the manager stops, so would be fine to enable this option. However,
-		// if you are doing or is intended to do any operation such as perform cleanups
-		// after the manager stops then its usage might be unsafe.
-		// LeaderElectionReleaseOnCancel: true,
+		Scheme:                  scheme,
+		Metrics:                 metricsServerOptions,
+		WebhookServer:           webhookServer,
+		HealthProbeBindAddress:  probeAddr,
+		LeaderElection:          enableLeaderElection,
+		LeaderElectionID:        shard.LeaseName("27340b24.catalyst.dev"),
+		LeaderElectionNamespace: leaderElectionNamespace,
+		LeaseDuration:           &leaseDuration,
+		RenewDeadline:           &renewDeadline,
+		RetryPeriod:             &retryPeriod,
+		// Step down as soon as the in-flight reconciles have drained, so a rolling update
+		// hands over without waiting out the lease. Safe because main exits right after
+		// the manager stops.
+		LeaderElectionReleaseOnCancel: true,
+		GracefulShutdownTimeout:       &gracefulShutdownTimeout,
 	})
 	if err != nil {
 		setupLog.Error(err, "unable to start manager")
@@ -185,21 +282,191 @@ func main() {
 	}
 
 	if err := (&controller.ProjectReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Shard:    shard,
+		Recorder: mgr.GetEventRecorderFor("project-controller"),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Project")
 		os.Exit(1)
 	}
+	if err := (&controller.TeamReconciler{
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Shard:    shard,
+		Recorder: mgr.GetEventRecorderFor("team-controller"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "Team")
+		os.Exit(1)
+	}
+	// Optional cluster components; reconcilers skip or substitute features that are missing.
+	// On detection failure every capability is assumed available.
+	var clusterCapabilities *capabilities.Capabilities
+	if discoveryClient, err := discovery.NewDiscoveryClientForConfig(mgr.GetConfig()); err != nil {
+		setupLog.Error(err, "unable to create discovery client, assuming all cluster capabilities")
+	} else if clusterCapabilities, err = capabilities.Detect(context.Background(), discoveryClient, mgr.GetAPIReader()); err != nil {
+		setupLog.Error(err, "unable to detect cluster capabilities, assuming all are available")
+	} else {
+		setupLog.Info("Detected cluster capabilities", "capabilities", clusterCapabilities.String())
+	}
+
+	// Helm installs and upgrades run outside the reconcile workers; shutdown waits for them
+	helmOperations := &controller.HelmOperations{Workers: maxConcurrentHelmOperations}
+	if err := mgr.Add(helmOperations); err != nil {
+		setupLog.Error(err, "unable to set up Helm operations")
+		os.Exit(1)
+	}
 	if err := (&controller.EnvironmentReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:              mgr.GetClient(),
+		Scheme:              mgr.GetScheme(),
+		Capabilities:        clusterCapabilities,
+		Shard:               shard,
+		MaxConcurrentBuilds: maxConcurrentBuilds,
+		Recorder:            mgr.GetEventRecorderFor("environment-controller"),
+		ResyncInterval:      resyncInterval,
+		HelmOperations:      helmOperations,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Environment")
 		os.Exit(1)
 	}
+	if err := (&controller.EnvironmentJanitorReconciler{
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Shard:    shard,
+		Recorder: mgr.GetEventRecorderFor("environment-janitor"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "EnvironmentJanitor")
+		os.Exit(1)
+	}
+	if err := (&controller.EnvironmentScheduleReconciler{
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Shard:    shard,
+		Recorder: mgr.GetEventRecorderFor("environment-schedule-controller"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "EnvironmentSchedule")
+		os.Exit(1)
+	}
+	if configFile != "" {
+		if err := mgr.Add(&operatorconfig.Watcher{Path: configFile}); err != nil {
+			setupLog.Error(err, "unable to watch the operator configuration")
+			os.Exit(1)
+		}
+	}
+	// The in-cluster registry builds push to without REGISTRY_ENDPOINT
+	if err := mgr.Add(&controller.InternalRegistry{Client: mgr.GetClient()}); err != nil {
+		setupLog.Error(err, "unable to set up the in-cluster registry")
+		os.Exit(1)
+	}
+	// Namespaces and claims left behind by deleted Environments; orphanSweep: delete deletes
+	// them instead of only marking them
+	if err := mgr.Add(&controller.OrphanSweeper{
+		Client: mgr.GetClient(),
+		Shard:  shard,
+	}); err != nil {
+		setupLog.Error(err, "unable to set up the orphan sweeper")
+		os.Exit(1)
+	}
+	// Images pulled onto every node with imagePrePull in the operator configuration. Nodes are
+	// shared, so only the first shard pulls, the images of all environments.
+	if shard.Index == 0 {
+		if err := mgr.Add(&controller.ImagePrePuller{Client: mgr.GetClient(), Namespace: os.Getenv("POD_NAMESPACE")}); err != nil {
+			setupLog.Error(err, "unable to set up the image pre-puller")
+			os.Exit(1)
+		}
+	}
+	// The CA of TLS for local preview routing
+	if err := mgr.Add(&controller.LocalCA{Client: mgr.GetClient()}); err != nil {
+		setupLog.Error(err, "unable to set up the local preview CA")
+		os.Exit(1)
+	}
+	// The guardrails and lifetime exemption webhooks need serving certificates (--webhook-cert-path);
+	// opt in with ENABLE_WEBHOOKS=true
+	if os.Getenv("ENABLE_WEBHOOKS") == "true" {
+		if err := webhookv1alpha1.SetupProjectWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "Project")
+			os.Exit(1)
+		}
+		if err := webhookv1alpha1.SetupEnvironmentWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "Environment")
+			os.Exit(1)
+		}
+	}
 	// +kubebuilder:scaffold:builder
 
+	if dashboardAddr != "" && dashboardAddr != "0" {
+		dashboardFilter, err := filters.WithAuthenticationAndAuthorization(mgr.GetConfig(), mgr.GetHTTPClient())
+		if err != nil {
+			setupLog.Error(err, "unable to create dashboard auth filter")
+			os.Exit(1)
+		}
+		if err := mgr.Add(&dashboard.Server{
+			Reader:      mgr.GetClient(),
+			BindAddress: dashboardAddr,
+			Filter:      dashboardFilter,
+		}); err != nil {
+			setupLog.Error(err, "unable to set up status page")
+			os.Exit(1)
+		}
+	}
+
+	if gatewayAddr != "" && gatewayAddr != "0" {
+		executor, err := gateway.NewSPDYExecutor(mgr.GetConfig())
+		if err != nil {
+			setupLog.Error(err, "unable to create workspace gateway executor")
+			os.Exit(1)
+		}
+		var allowedOrigins []string
+		for _, origin := range strings.Split(gatewayOrigins, ",") {
+			if origin = strings.TrimSpace(origin); origin != "" {
+				allowedOrigins = append(allowedOrigins, origin)
+			}
+		}
+		trustedProxies, err := gateway.ParseTrustedProxies(gatewayTrustedProxies)
+		if err != nil {
+			setupLog.Error(err, "invalid --gateway-trusted-proxies")
+			os.Exit(1)
+		}
+		authenticator, err := gateway.NewKubeAuthenticator(mgr.GetConfig())
+		if err != nil {
+			setupLog.Error(err, "unable to create workspace gateway authenticator")
+			os.Exit(1)
+		}
+		auditor, err := audit.New(auditSink, auditWebhookURL, mgr.GetClient())
+		if err != nil {
+			setupLog.Error(err, "unable to create audit sink")
+			os.Exit(1)
+		}
+		if err := mgr.Add(&gateway.Server{
+			Reader:         mgr.GetAPIReader(),
+			Executor:       executor,
+			BindAddress:    gatewayAddr,
+			AllowedOrigins: allowedOrigins,
+			Audit:          auditor,
+			Authenticator:  authenticator,
+			TrustedProxies: trustedProxies,
+		}); err != nil {
+			setupLog.Error(err, "unable to set up workspace gateway")
+			os.Exit(1)
+		}
+	}
+
+	if gitWebhookAddr != "" && gitWebhookAddr != "0" {
+		secret := os.Getenv("GIT_WEBHOOK_SECRET")
+		if secret == "" {
+			setupLog.Error(nil, "GIT_WEBHOOK_SECRET is required with --git-webhook-bind-address")
+			os.Exit(1)
+		}
+		if err := mgr.Add(&gitevents.Server{
+			Client:      mgr.GetClient(),
+			BindAddress: gitWebhookAddr,
+			Secret:      secret,
+		}); err != nil {
+			setupLog.Error(err, "unable to set up git webhook receiver")
+			os.Exit(1)
+		}
+	}
+
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		setupLog.Error(err, "unable to set up health check")
 		os.Exit(1)
@@ -208,6 +475,16 @@ func main() {
 		setupLog.Error(err, "unable to set up ready check")
 		os.Exit(1)
 	}
+	if err := mgr.AddReadyzCheck("informers", health.CacheSynced(mgr.GetCache())); err != nil {
+		setupLog.Error(err, "unable to set up informer cache ready check")
+		os.Exit(1)
+	}
+	if readyCheckWebAPI {
+		if err := mgr.AddReadyzCheck("web-api", health.Reachable(nil, controller.CatalystWebURL())); err != nil {
+			setupLog.Error(err, "unable to set up web API ready check")
+			os.Exit(1)
+		}
+	}
 
 	// Ensure the current namespace has the kubernetes.io/metadata.name label
 	// This is required for NetworkPolicies to correctly select the operator/ingress namespace
@@ -237,7 +514,14 @@ func main() {
 	}
 
 	setupLog.Info("starting manager")
-	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
+	err = mgr.Start(ctrl.SetupSignalHandler())
+	// Flush the spans of the last reconciles
+	flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	if err := shutdownTracing(flushCtx); err != nil {
+		setupLog.Error(err, "unable to flush traces")
+	}
+	cancel()
+	if err != nil {
 		setupLog.Error(err, "problem running manager")
 		os.Exit(1)
 	}
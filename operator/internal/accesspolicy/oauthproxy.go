@@ -0,0 +1,65 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package accesspolicy holds the rules of Environment spec.access shared by the Environment
+// validating webhook, which rejects specs breaking them, and the controller, which refuses
+// to deploy them for Environments admitted before.
+package accesspolicy
+
+import (
+	"errors"
+	"slices"
+	"strings"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+// restrictionFlags are the oauth2-proxy flags limiting who may sign in. Without one of them or
+// email domains, every account of the provider (e.g. anyone on GitHub) is let in.
+var restrictionFlags = []string{
+	"--email-domain",
+	"--authenticated-emails-file",
+	"--github-org",
+	"--github-team",
+	"--github-repo",
+	"--github-user",
+	"--gitlab-group",
+	"--gitlab-project",
+	"--google-group",
+	"--allowed-group",
+	"--allowed-role",
+}
+
+// Validate requires oauthProxy access to restrict who may sign in, with emailDomains or one
+// of the restriction flags in args. emailDomains: ["*"] lets any account in on purpose.
+func Validate(access *catalystv1alpha1.EnvironmentAccess) error {
+	if access == nil || access.Type != catalystv1alpha1.AccessTypeOAuthProxy || access.OAuthProxy == nil {
+		return nil
+	}
+	cfg := access.OAuthProxy
+	if len(cfg.EmailDomains) > 0 {
+		return nil
+	}
+	restricted := slices.ContainsFunc(cfg.Args, func(arg string) bool {
+		flag, _, _ := strings.Cut(arg, "=")
+		return slices.Contains(restrictionFlags, flag)
+	})
+	if !restricted {
+		return errors.New("spec.access.oauthProxy must restrict who may sign in with emailDomains or args " +
+			"such as --github-org, --github-team or --allowed-group; emailDomains: [\"*\"] allows any account")
+	}
+	return nil
+}
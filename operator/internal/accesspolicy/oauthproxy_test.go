@@ -0,0 +1,24 @@
+package accesspolicy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+func TestValidate(t *testing.T) {
+	oauthProxy := func(cfg catalystv1alpha1.OAuthProxyAccess) *catalystv1alpha1.EnvironmentAccess {
+		return &catalystv1alpha1.EnvironmentAccess{Type: catalystv1alpha1.AccessTypeOAuthProxy, OAuthProxy: &cfg}
+	}
+
+	assert.NoError(t, Validate(nil))
+	assert.NoError(t, Validate(&catalystv1alpha1.EnvironmentAccess{Type: catalystv1alpha1.AccessTypeBasicAuth}))
+	assert.Error(t, Validate(oauthProxy(catalystv1alpha1.OAuthProxyAccess{SecretName: "oauth"})))
+	assert.Error(t, Validate(oauthProxy(catalystv1alpha1.OAuthProxyAccess{SecretName: "oauth", Args: []string{"--oidc-issuer-url=https://id.acme.dev"}})))
+	assert.NoError(t, Validate(oauthProxy(catalystv1alpha1.OAuthProxyAccess{SecretName: "oauth", EmailDomains: []string{"acme.dev"}})))
+	assert.NoError(t, Validate(oauthProxy(catalystv1alpha1.OAuthProxyAccess{SecretName: "oauth", EmailDomains: []string{"*"}})), "explicitly open")
+	assert.NoError(t, Validate(oauthProxy(catalystv1alpha1.OAuthProxyAccess{SecretName: "oauth", Args: []string{"--github-team=acme:previews"}})))
+	assert.NoError(t, Validate(oauthProxy(catalystv1alpha1.OAuthProxyAccess{SecretName: "oauth", Args: []string{"--allowed-group", "previews"}})))
+}
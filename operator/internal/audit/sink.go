@@ -0,0 +1,144 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package audit records who accessed environments (e.g. workspace exec sessions) to a
+// configurable sink: JSON lines on stdout, a webhook, or AuditEvent resources.
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+// +kubebuilder:rbac:groups=catalyst.catalyst.dev,resources=auditevents,verbs=create
+
+// Sink kinds accepted by New
+const (
+	SinkStdout     = "stdout"
+	SinkWebhook    = "webhook"
+	SinkAuditEvent = "auditevent"
+)
+
+// Sink records audit events. Implementations must be safe for concurrent use.
+type Sink interface {
+	Record(ctx context.Context, event catalystv1alpha1.AuditEventSpec) error
+}
+
+// New returns the sink of the given kind. target is the webhook URL for SinkWebhook.
+// An empty kind disables auditing (nil sink).
+func New(kind, target string, c client.Client) (Sink, error) {
+	switch strings.ToLower(kind) {
+	case "":
+		return nil, nil
+	case SinkStdout:
+		return NewWriterSink(os.Stdout), nil
+	case SinkWebhook:
+		if target == "" {
+			return nil, fmt.Errorf("the %s audit sink requires a URL", SinkWebhook)
+		}
+		return &WebhookSink{URL: target, HTTPClient: &http.Client{Timeout: 10 * time.Second}}, nil
+	case SinkAuditEvent:
+		return &ResourceSink{Client: c}, nil
+	default:
+		return nil, fmt.Errorf("unknown audit sink %q (expected %s, %s or %s)", kind, SinkStdout, SinkWebhook, SinkAuditEvent)
+	}
+}
+
+// WriterSink writes one JSON object per line
+type WriterSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewWriterSink returns a sink writing JSON lines to w
+func NewWriterSink(w io.Writer) *WriterSink {
+	return &WriterSink{w: w}
+}
+
+func (s *WriterSink) Record(_ context.Context, event catalystv1alpha1.AuditEventSpec) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.w.Write(append(data, '\n'))
+	return err
+}
+
+// WebhookSink POSTs each event as JSON to URL
+type WebhookSink struct {
+	URL        string
+	HTTPClient *http.Client
+}
+
+func (s *WebhookSink) Record(ctx context.Context, event catalystv1alpha1.AuditEventSpec) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send audit event: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("audit webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// ResourceSink creates an AuditEvent in the Environment's namespace for each event
+type ResourceSink struct {
+	Client client.Client
+}
+
+func (s *ResourceSink) Record(ctx context.Context, event catalystv1alpha1.AuditEventSpec) error {
+	name := "audit-"
+	if event.Environment != "" {
+		name = event.Environment + "-"
+	}
+	obj := &catalystv1alpha1.AuditEvent{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: name,
+			Namespace:    event.Namespace,
+			Labels:       map[string]string{"catalyst.dev/environment": event.Environment},
+		},
+		Spec: event,
+	}
+	if err := s.Client.Create(ctx, obj); err != nil {
+		return fmt.Errorf("failed to create AuditEvent: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+func testEvent() catalystv1alpha1.AuditEventSpec {
+	return catalystv1alpha1.AuditEventSpec{
+		Action:      catalystv1alpha1.AuditActionExecEnded,
+		Time:        metav1.NewTime(time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)),
+		SessionID:   "0123456789abcdef",
+		Namespace:   "team",
+		Environment: "pr-1",
+		Pod:         "team-app-pr-1/workspace-app-latest",
+		Container:   "workspace",
+		Command:     []string{"sh"},
+		Actor:       "alice@example.com",
+		Duration:    &metav1.Duration{Duration: 90 * time.Second},
+	}
+}
+
+func TestWriterSink(t *testing.T) {
+	var buf bytes.Buffer
+	sink := NewWriterSink(&buf)
+	require.NoError(t, sink.Record(context.Background(), testEvent()))
+	require.NoError(t, sink.Record(context.Background(), testEvent()))
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	require.Len(t, lines, 2)
+	var decoded catalystv1alpha1.AuditEventSpec
+	require.NoError(t, json.Unmarshal(lines[0], &decoded))
+	assert.Equal(t, "alice@example.com", decoded.Actor)
+	assert.Equal(t, 90*time.Second, decoded.Duration.Duration)
+}
+
+func TestWebhookSink(t *testing.T) {
+	var received catalystv1alpha1.AuditEventSpec
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		if received.Environment == "rejected" {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer server.Close()
+
+	sink, err := New(SinkWebhook, server.URL, nil)
+	require.NoError(t, err)
+	require.NoError(t, sink.Record(context.Background(), testEvent()))
+	assert.Equal(t, "0123456789abcdef", received.SessionID)
+
+	event := testEvent()
+	event.Environment = "rejected"
+	assert.ErrorContains(t, sink.Record(context.Background(), event), "502")
+}
+
+func TestResourceSink(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, catalystv1alpha1.AddToScheme(scheme))
+	c := fake.NewClientBuilder().WithScheme(scheme).Build()
+	sink, err := New(SinkAuditEvent, "", c)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	require.NoError(t, sink.Record(ctx, testEvent()))
+
+	events := &catalystv1alpha1.AuditEventList{}
+	require.NoError(t, c.List(ctx, events, client.InNamespace("team"), client.MatchingLabels{"catalyst.dev/environment": "pr-1"}))
+	require.Len(t, events.Items, 1)
+	spec := events.Items[0].Spec
+	assert.Equal(t, "0123456789abcdef", spec.SessionID)
+	assert.Equal(t, []string{"sh"}, spec.Command)
+	assert.True(t, testEvent().Time.Time.Equal(spec.Time.Time))
+}
+
+func TestNew(t *testing.T) {
+	sink, err := New("", "", nil)
+	require.NoError(t, err)
+	assert.Nil(t, sink)
+
+	_, err = New(SinkWebhook, "", nil)
+	assert.ErrorContains(t, err, "requires a URL")
+	_, err = New("syslog", "", nil)
+	assert.ErrorContains(t, err, "unknown audit sink")
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package budget accounts the resource quotas of a team's environment namespaces against
+// the team budget (Team spec.budget). Each environment namespace reserves the hard limits
+// of its ResourceQuotas; a new Environment is admitted when the reserved total plus its own
+// quota fits the budget. Namespaces being deleted no longer count.
+package budget
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Labels of the environment namespaces of a team
+const (
+	TeamLabel          = "catalyst.dev/team"
+	NamespaceTypeLabel = "catalyst.dev/namespace-type"
+	environmentType    = "environment"
+)
+
+// Reserved sums the hard limits of the ResourceQuotas in the environment namespaces of team,
+// except namespace exclude
+func Reserved(ctx context.Context, c client.Reader, team, exclude string) (corev1.ResourceList, error) {
+	namespaces := &corev1.NamespaceList{}
+	if err := c.List(ctx, namespaces, client.MatchingLabels{TeamLabel: team, NamespaceTypeLabel: environmentType}); err != nil {
+		return nil, fmt.Errorf("failed to list environment namespaces of team %s: %w", team, err)
+	}
+	reserved := corev1.ResourceList{}
+	for _, ns := range namespaces.Items {
+		if ns.Name == exclude || !ns.DeletionTimestamp.IsZero() {
+			continue
+		}
+		quotas := &corev1.ResourceQuotaList{}
+		if err := c.List(ctx, quotas, client.InNamespace(ns.Name)); err != nil {
+			return nil, fmt.Errorf("failed to list resource quotas of namespace %s: %w", ns.Name, err)
+		}
+		for _, quota := range quotas.Items {
+			Add(reserved, quota.Spec.Hard)
+		}
+	}
+	return reserved, nil
+}
+
+// Add adds the quantities of list to total
+func Add(total, list corev1.ResourceList) {
+	for name, quantity := range list {
+		sum := total[name]
+		sum.Add(quantity)
+		total[name] = sum
+	}
+}
+
+// Exceeded describes the resources of budget that reserved plus request exceed, e.g.
+// "limits.cpu: 10 reserved + 4 requested > 12". Resources the budget does not cap are
+// unlimited. Empty if the request fits.
+func Exceeded(budget, reserved, request corev1.ResourceList) []string {
+	var exceeded []string
+	for name, limit := range budget {
+		requested, ok := request[name]
+		if !ok {
+			continue
+		}
+		total := reserved[name].DeepCopy()
+		total.Add(requested)
+		if total.Cmp(limit) > 0 {
+			exceeded = append(exceeded, fmt.Sprintf("%s: %s reserved + %s requested > %s", name, quantityString(reserved[name]), requested.String(), limit.String()))
+		}
+	}
+	sort.Strings(exceeded)
+	return exceeded
+}
+
+// quantityString formats a zero quantity as 0 rather than with the format of its type
+func quantityString(q resource.Quantity) string {
+	if q.IsZero() {
+		return "0"
+	}
+	return q.String()
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package budget
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func environmentNamespace(name, team string) *corev1.Namespace {
+	return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:   name,
+		Labels: map[string]string{TeamLabel: team, NamespaceTypeLabel: environmentType},
+	}}
+}
+
+func quota(namespace, cpu, memory string) *corev1.ResourceQuota {
+	return &corev1.ResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{Name: "default", Namespace: namespace},
+		Spec: corev1.ResourceQuotaSpec{Hard: corev1.ResourceList{
+			corev1.ResourceLimitsCPU:    resource.MustParse(cpu),
+			corev1.ResourceLimitsMemory: resource.MustParse(memory),
+		}},
+	}
+}
+
+func TestReserved(t *testing.T) {
+	terminating := environmentNamespace("acme-shop-old", "acme")
+	terminating.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+	terminating.Finalizers = []string{"kubernetes"}
+	c := fake.NewClientBuilder().WithObjects(
+		environmentNamespace("acme-shop-pr-1", "acme"),
+		environmentNamespace("acme-shop-pr-2", "acme"),
+		environmentNamespace("acme-shop-pr-3", "acme"),
+		terminating,
+		environmentNamespace("other-app-pr-1", "other"),
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "acme", Labels: map[string]string{TeamLabel: "acme", NamespaceTypeLabel: "team"}}},
+		quota("acme-shop-pr-1", "4", "8Gi"),
+		quota("acme-shop-pr-2", "2", "4Gi"),
+		quota("acme-shop-pr-3", "1", "1Gi"),
+		quota("acme-shop-old", "4", "8Gi"),
+		quota("other-app-pr-1", "4", "8Gi"),
+		quota("acme", "16", "32Gi"),
+	).Build()
+
+	reserved, err := Reserved(context.Background(), c, "acme", "acme-shop-pr-3")
+	require.NoError(t, err)
+	assert.True(t, resource.MustParse("6").Equal(reserved[corev1.ResourceLimitsCPU]))
+	assert.True(t, resource.MustParse("12Gi").Equal(reserved[corev1.ResourceLimitsMemory]))
+
+	reserved, err = Reserved(context.Background(), c, "nobody", "")
+	require.NoError(t, err)
+	assert.Empty(t, reserved)
+}
+
+func TestExceeded(t *testing.T) {
+	budget := corev1.ResourceList{
+		corev1.ResourceLimitsCPU:    resource.MustParse("8"),
+		corev1.ResourceLimitsMemory: resource.MustParse("16Gi"),
+	}
+	request := corev1.ResourceList{
+		corev1.ResourceLimitsCPU:    resource.MustParse("4"),
+		corev1.ResourceLimitsMemory: resource.MustParse("8Gi"),
+		corev1.ResourcePods:         resource.MustParse("20"),
+	}
+
+	assert.Empty(t, Exceeded(budget, corev1.ResourceList{}, request))
+	assert.Empty(t, Exceeded(budget, corev1.ResourceList{corev1.ResourceLimitsCPU: resource.MustParse("4")}, request), "the budget may be used up exactly")
+	assert.Equal(t, []string{"limits.cpu: 6 reserved + 4 requested > 8"},
+		Exceeded(budget, corev1.ResourceList{corev1.ResourceLimitsCPU: resource.MustParse("6")}, request))
+	assert.Equal(t, []string{"limits.memory: 0 reserved + 32Gi requested > 16Gi"},
+		Exceeded(budget, nil, corev1.ResourceList{corev1.ResourceLimitsMemory: resource.MustParse("32Gi")}))
+}
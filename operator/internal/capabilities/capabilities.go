@@ -0,0 +1,140 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package capabilities probes the cluster for optional components at operator startup,
+// so reconcilers can skip or substitute features instead of failing on missing APIs.
+// Installing a component after startup requires an operator restart to be picked up.
+package capabilities
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// +kubebuilder:rbac:groups=networking.k8s.io,resources=ingressclasses,verbs=get;list
+
+// API groups whose presence signals a capability
+const (
+	certManagerGroup    = "cert-manager.io"
+	gatewayAPIGroup     = "gateway.networking.k8s.io"
+	volumeSnapshotGroup = "snapshot.storage.k8s.io"
+	metricsServerGroup  = "metrics.k8s.io"
+)
+
+// networkPolicyCNIGroups are API groups of CNIs known to enforce NetworkPolicy. The
+// NetworkPolicy API is always served, even by CNIs that ignore it (e.g. kindnet, flannel),
+// so enforcement is inferred from the CNI; CNIs without CRDs are reported as not enforcing.
+var networkPolicyCNIGroups = []string{
+	"crd.projectcalico.org",
+	"cilium.io",
+	"crd.antrea.io",
+}
+
+// GroupLister lists the API groups served by the cluster (discovery.DiscoveryInterface)
+type GroupLister interface {
+	ServerGroups() (*metav1.APIGroupList, error)
+}
+
+// Capabilities are the optional cluster components detected at startup
+type Capabilities struct {
+	// CertManager is true when the cert-manager API is served
+	CertManager bool
+	// GatewayAPI is true when the Gateway API (HTTPRoute) is served
+	GatewayAPI bool
+	// VolumeSnapshots is true when the snapshot CRDs (VolumeSnapshot) are installed
+	VolumeSnapshots bool
+	// MetricsServer is true when the resource metrics API is served
+	MetricsServer bool
+	// NetworkPolicyEnforced is true when a CNI known to enforce NetworkPolicy is installed
+	NetworkPolicyEnforced bool
+	// IngressClasses are the names of the installed IngressClasses; nil means unknown
+	IngressClasses []string
+	// DefaultIngressClass is the IngressClass marked as cluster default, if any
+	DefaultIngressClass string
+}
+
+// Detect probes the cluster. Reader must work before the manager cache starts (mgr.GetAPIReader()).
+func Detect(ctx context.Context, groups GroupLister, reader client.Reader) (*Capabilities, error) {
+	list, err := groups.ServerGroups()
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover API groups: %w", err)
+	}
+	served := map[string]bool{}
+	for _, group := range list.Groups {
+		served[group.Name] = true
+	}
+
+	caps := &Capabilities{
+		CertManager:     served[certManagerGroup],
+		GatewayAPI:      served[gatewayAPIGroup],
+		VolumeSnapshots: served[volumeSnapshotGroup],
+		MetricsServer:   served[metricsServerGroup],
+	}
+	for _, group := range networkPolicyCNIGroups {
+		if served[group] {
+			caps.NetworkPolicyEnforced = true
+		}
+	}
+
+	classes := &networkingv1.IngressClassList{}
+	if err := reader.List(ctx, classes); err != nil {
+		return nil, fmt.Errorf("failed to list IngressClasses: %w", err)
+	}
+	caps.IngressClasses = []string{}
+	for _, class := range classes.Items {
+		caps.IngressClasses = append(caps.IngressClasses, class.Name)
+		if class.Annotations[networkingv1.AnnotationIsDefaultIngressClass] == "true" {
+			caps.DefaultIngressClass = class.Name
+		}
+	}
+	slices.Sort(caps.IngressClasses)
+	return caps, nil
+}
+
+// HasIngressClass reports whether the named IngressClass is installed.
+// Unknown classes (nil Capabilities or IngressClasses) are assumed installed.
+func (c *Capabilities) HasIngressClass(name string) bool {
+	return c == nil || c.IngressClasses == nil || slices.Contains(c.IngressClasses, name)
+}
+
+// String summarizes the detected capabilities for logging
+func (c *Capabilities) String() string {
+	var available, missing []string
+	for _, f := range []struct {
+		name string
+		ok   bool
+	}{
+		{"cert-manager", c.CertManager},
+		{"gateway-api", c.GatewayAPI},
+		{"volume-snapshots", c.VolumeSnapshots},
+		{"metrics-server", c.MetricsServer},
+		{"networkpolicy-enforcement", c.NetworkPolicyEnforced},
+	} {
+		if f.ok {
+			available = append(available, f.name)
+		} else {
+			missing = append(missing, f.name)
+		}
+	}
+	return fmt.Sprintf("available=[%s] missing=[%s] ingressClasses=[%s]",
+		strings.Join(available, ","), strings.Join(missing, ","), strings.Join(c.IngressClasses, ","))
+}
@@ -0,0 +1,51 @@
+package capabilities
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+type staticGroups []string
+
+func (g staticGroups) ServerGroups() (*metav1.APIGroupList, error) {
+	list := &metav1.APIGroupList{}
+	for _, name := range g {
+		list.Groups = append(list.Groups, metav1.APIGroup{Name: name})
+	}
+	return list, nil
+}
+
+func TestDetect(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	reader := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&networkingv1.IngressClass{ObjectMeta: metav1.ObjectMeta{Name: "traefik", Annotations: map[string]string{networkingv1.AnnotationIsDefaultIngressClass: "true"}}},
+	).Build()
+
+	caps, err := Detect(context.Background(), staticGroups{"apps", "networking.k8s.io", "gateway.networking.k8s.io", "cilium.io"}, reader)
+	require.NoError(t, err)
+	assert.True(t, caps.GatewayAPI)
+	assert.True(t, caps.NetworkPolicyEnforced)
+	assert.False(t, caps.CertManager)
+	assert.False(t, caps.VolumeSnapshots)
+	assert.False(t, caps.MetricsServer)
+	assert.Equal(t, []string{"traefik"}, caps.IngressClasses)
+	assert.Equal(t, "traefik", caps.DefaultIngressClass)
+	assert.False(t, caps.HasIngressClass("nginx"))
+	assert.Contains(t, caps.String(), "missing=[cert-manager,volume-snapshots,metrics-server]")
+}
+
+func TestHasIngressClassUnknown(t *testing.T) {
+	var caps *Capabilities
+	assert.True(t, caps.HasIngressClass("nginx"))
+	assert.True(t, (&Capabilities{}).HasIngressClass("nginx"))
+	assert.False(t, (&Capabilities{IngressClasses: []string{}}).HasIngressClass("nginx"))
+}
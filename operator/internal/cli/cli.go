@@ -0,0 +1,156 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package cli implements catalystctl, the command line client for Catalyst environments. It
+// talks to the cluster with the user's kubeconfig: Environments and Projects are read and
+// written directly, logs are streamed from the environment namespace and terminals are
+// attached through the workspace gateway, so developers need neither raw kubectl nor YAML.
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+// Clients are the cluster connections of a command
+type Clients struct {
+	// Client reads and writes Catalyst resources, Secrets and Pods
+	Client client.Client
+	// Clientset streams pod logs
+	Clientset kubernetes.Interface
+	// Namespace is --namespace, or the namespace of the kubeconfig context
+	Namespace string
+	// Token is the bearer token of the kubeconfig user, presented to the gateway as the identity
+	// exec sessions are audited under. Empty for users authenticating otherwise (e.g. with
+	// client certificates).
+	Token string
+}
+
+// Options are the global flags and the streams of the commands
+type Options struct {
+	Kubeconfig string
+	Context    string
+	Namespace  string
+
+	In     io.Reader
+	Out    io.Writer
+	ErrOut io.Writer
+
+	// NewClients connects to the cluster. Defaults to the kubeconfig; tests replace it.
+	NewClients func(o *Options) (*Clients, error)
+}
+
+// NewOptions returns Options on the process streams
+func NewOptions() *Options {
+	return &Options{In: os.Stdin, Out: os.Stdout, ErrOut: os.Stderr, NewClients: kubeconfigClients}
+}
+
+// NewRootCommand returns the catalystctl command
+func NewRootCommand(o *Options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:           "catalystctl",
+		Short:         "Manage Catalyst environments",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+	cmd.SetIn(o.In)
+	cmd.SetOut(o.Out)
+	cmd.SetErr(o.ErrOut)
+	flags := cmd.PersistentFlags()
+	flags.StringVar(&o.Kubeconfig, "kubeconfig", "", "Path to the kubeconfig file")
+	flags.StringVar(&o.Context, "context", "", "Kubeconfig context to use")
+	flags.StringVarP(&o.Namespace, "namespace", "n", "", "Namespace; with --project the team namespace of the Project")
+
+	cmd.AddCommand(newEnvCommand(o), newProjectCommand(o))
+	return cmd
+}
+
+// scheme knows the core and Catalyst kinds
+func scheme() (*runtime.Scheme, error) {
+	s := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(s); err != nil {
+		return nil, err
+	}
+	if err := catalystv1alpha1.AddToScheme(s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// kubeconfigClients connects with the kubeconfig ($KUBECONFIG, ~/.kube/config or in-cluster)
+func kubeconfigClients(o *Options) (*Clients, error) {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	rules.ExplicitPath = o.Kubeconfig
+	overrides := &clientcmd.ConfigOverrides{CurrentContext: o.Context}
+	if o.Namespace != "" {
+		overrides.Context.Namespace = o.Namespace
+	}
+	loader := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, overrides)
+
+	config, err := loader.ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load kubeconfig: %w", err)
+	}
+	namespace, _, err := loader.Namespace()
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve namespace: %w", err)
+	}
+	s, err := scheme()
+	if err != nil {
+		return nil, err
+	}
+	c, err := client.New(config, client.Options{Scheme: s})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to the cluster: %w", err)
+	}
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to the cluster: %w", err)
+	}
+
+	clients := &Clients{Client: c, Clientset: clientset, Namespace: namespace, Token: config.BearerToken}
+	if clients.Token == "" && config.BearerTokenFile != "" {
+		token, err := os.ReadFile(config.BearerTokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read bearer token: %w", err)
+		}
+		clients.Token = strings.TrimSpace(string(token))
+	}
+	return clients, nil
+}
+
+var invalidNameChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// dnsLabel turns a branch or other free-form name into a DNS-1123 label
+func dnsLabel(s string) string {
+	s = invalidNameChars.ReplaceAllString(strings.ToLower(s), "-")
+	if len(s) > 63 {
+		s = s[:63]
+	}
+	return strings.Trim(s, "-")
+}
@@ -0,0 +1,424 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+	"github.com/ncrmro/catalyst/operator/internal/controller"
+)
+
+// pollInterval is how often --wait checks on an Environment
+var pollInterval = 2 * time.Second
+
+// envOptions are the flags shared by the env commands
+type envOptions struct {
+	*Options
+	// project locates Environments in the project namespace of the Project in --namespace
+	project string
+}
+
+func newEnvCommand(o *Options) *cobra.Command {
+	e := &envOptions{Options: o}
+	cmd := &cobra.Command{
+		Use:     "env",
+		Aliases: []string{"environment"},
+		Short:   "Create, inspect and delete environments",
+		Long: `Create, inspect and delete environments.
+
+With --project, --namespace is the team namespace holding the Project and environments are
+looked up in its project namespace; without it, --namespace is the environment's namespace.`,
+	}
+	cmd.PersistentFlags().StringVarP(&e.project, "project", "p", "", "Project of the environment")
+	cmd.AddCommand(e.createCommand(), e.urlCommand(), e.deleteCommand(), e.logsCommand(), e.execCommand(), e.renderCommand())
+	return cmd
+}
+
+// environmentNamespace is the namespace the env commands find Environments in
+func (e *envOptions) environmentNamespace(c *Clients) string {
+	if e.project != "" {
+		return controller.GenerateProjectNamespace(c.Namespace, e.project)
+	}
+	return c.Namespace
+}
+
+// getEnvironment fetches an Environment by name
+func (e *envOptions) getEnvironment(ctx context.Context, c *Clients, name string) (*catalystv1alpha1.Environment, error) {
+	env := &catalystv1alpha1.Environment{}
+	namespace := e.environmentNamespace(c)
+	if err := c.Client.Get(ctx, client.ObjectKey{Name: name, Namespace: namespace}, env); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, fmt.Errorf("environment %s not found in namespace %s", name, namespace)
+		}
+		return nil, err
+	}
+	return env, nil
+}
+
+// createOptions are the flags of env create
+type createOptions struct {
+	name     string
+	envType  string
+	source   string
+	branch   string
+	commit   string
+	prNumber int
+	wait     bool
+	timeout  time.Duration
+	// renderOnly creates the environment to be rendered, not deployed
+	renderOnly bool
+}
+
+func (e *envOptions) createCommand() *cobra.Command {
+	o := &createOptions{}
+	cmd := &cobra.Command{
+		Use:   "create --project PROJECT --branch BRANCH",
+		Short: "Create an environment of a branch",
+		Example: `  catalystctl -n acme env create --project shop --branch feature/checkout
+  catalystctl -n acme env create --project shop --branch main --type deployment --name staging --wait`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			c, err := e.NewClients(e.Options)
+			if err != nil {
+				return err
+			}
+			return e.create(cmd.Context(), c, o)
+		},
+	}
+	flags := cmd.Flags()
+	flags.StringVar(&o.name, "name", "", "Environment name (defaults to the branch)")
+	flags.StringVar(&o.envType, "type", "development", "Template of the environment: development or deployment")
+	flags.StringVar(&o.source, "source", "", "Project source the branch belongs to (defaults to the first)")
+	flags.StringVar(&o.branch, "branch", "", "Branch to deploy")
+	flags.StringVar(&o.commit, "commit", "", "Commit to deploy (defaults to the tip of the branch)")
+	flags.IntVar(&o.prNumber, "pr", 0, "Pull request number of the branch")
+	flags.BoolVar(&o.wait, "wait", false, "Wait for the environment to be Ready and print its URL")
+	flags.DurationVar(&o.timeout, "timeout", 15*time.Minute, "How long --wait waits")
+	flags.BoolVar(&o.renderOnly, "render-only", false, "Only render the environment (see env render); it deploys once the catalyst.dev/render-only annotation is removed")
+	_ = cmd.MarkFlagRequired("branch")
+	cmd.MarkFlagsMutuallyExclusive("wait", "render-only")
+	return cmd
+}
+
+// desiredEnvironment builds the Environment of env create for a Project
+func desiredEnvironment(project *catalystv1alpha1.Project, o *createOptions) (*catalystv1alpha1.Environment, error) {
+	if len(project.Spec.Sources) == 0 {
+		return nil, fmt.Errorf("project %s has no sources", project.Name)
+	}
+	source := o.source
+	if source == "" {
+		source = project.Spec.Sources[0].Name
+	}
+	found := false
+	for _, s := range project.Spec.Sources {
+		found = found || s.Name == source
+	}
+	if !found {
+		return nil, fmt.Errorf("project %s has no source %s", project.Name, source)
+	}
+	name := o.name
+	if name == "" {
+		name = dnsLabel(o.branch)
+	}
+	if name == "" {
+		return nil, errors.New("cannot derive an environment name from the branch, pass --name")
+	}
+
+	env := &catalystv1alpha1.Environment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: controller.GenerateProjectNamespace(project.Namespace, project.Name),
+			Labels: map[string]string{
+				"catalyst.dev/team":        dnsLabel(project.Namespace),
+				"catalyst.dev/project":     dnsLabel(project.Name),
+				"catalyst.dev/environment": dnsLabel(name),
+			},
+		},
+		Spec: catalystv1alpha1.EnvironmentSpec{
+			ProjectRef: catalystv1alpha1.ProjectReference{Name: project.Name},
+			Type:       o.envType,
+			Sources: []catalystv1alpha1.EnvironmentSource{{
+				Name:      source,
+				Branch:    o.branch,
+				CommitSha: o.commit,
+				PrNumber:  o.prNumber,
+			}},
+		},
+	}
+	if o.renderOnly {
+		env.Annotations = map[string]string{controller.RenderOnlyAnnotation: "true"}
+	}
+	return env, nil
+}
+
+func (e *envOptions) create(ctx context.Context, c *Clients, o *createOptions) error {
+	if e.project == "" {
+		return errors.New("--project is required")
+	}
+	project := &catalystv1alpha1.Project{}
+	if err := c.Client.Get(ctx, client.ObjectKey{Name: e.project, Namespace: c.Namespace}, project); err != nil {
+		if apierrors.IsNotFound(err) {
+			return fmt.Errorf("project %s not found in namespace %s", e.project, c.Namespace)
+		}
+		return err
+	}
+	env, err := desiredEnvironment(project, o)
+	if err != nil {
+		return err
+	}
+	if err := c.Client.Create(ctx, env); err != nil {
+		if apierrors.IsAlreadyExists(err) {
+			return fmt.Errorf("environment %s already exists in namespace %s", env.Name, env.Namespace)
+		}
+		return err
+	}
+	_, _ = fmt.Fprintf(e.Out, "environment/%s created in namespace %s\n", env.Name, env.Namespace)
+	if !o.wait {
+		return nil
+	}
+
+	ready, err := waitForReady(ctx, c, client.ObjectKeyFromObject(env), o.timeout)
+	if err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintln(e.Out, ready.Status.URL)
+	return nil
+}
+
+// waitForReady polls an Environment until it is Ready, failing when it fails or times out
+func waitForReady(ctx context.Context, c *Clients, key client.ObjectKey, timeout time.Duration) (*catalystv1alpha1.Environment, error) {
+	env := &catalystv1alpha1.Environment{}
+	err := wait.PollUntilContextTimeout(ctx, pollInterval, timeout, true, func(ctx context.Context) (bool, error) {
+		if err := c.Client.Get(ctx, key, env); err != nil {
+			return false, err
+		}
+		switch env.Status.Phase {
+		case "Ready":
+			return true, nil
+		case "Failed":
+			return false, fmt.Errorf("environment %s failed: %s", key.Name, env.Status.Message)
+		}
+		return false, nil
+	})
+	if wait.Interrupted(err) {
+		return nil, fmt.Errorf("timed out waiting for environment %s to be Ready (phase %s)", key.Name, env.Status.Phase)
+	}
+	return env, err
+}
+
+func (e *envOptions) urlCommand() *cobra.Command {
+	all := false
+	cmd := &cobra.Command{
+		Use:   "url NAME",
+		Short: "Print the URL of an environment",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := e.NewClients(e.Options)
+			if err != nil {
+				return err
+			}
+			env, err := e.getEnvironment(cmd.Context(), c, args[0])
+			if err != nil {
+				return err
+			}
+			if env.Status.URL == "" {
+				phase := env.Status.Phase
+				if phase == "" {
+					phase = "Pending"
+				}
+				return fmt.Errorf("environment %s has no URL yet (phase %s)", env.Name, phase)
+			}
+			urls := []string{env.Status.URL}
+			if all && len(env.Status.URLs) > 0 {
+				urls = env.Status.URLs
+			}
+			for _, url := range urls {
+				_, _ = fmt.Fprintln(e.Out, url)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&all, "all", false, "Print every URL, including the alias URL")
+	return cmd
+}
+
+func (e *envOptions) deleteCommand() *cobra.Command {
+	waitForDeletion := false
+	timeout := 10 * time.Minute
+	cmd := &cobra.Command{
+		Use:   "delete NAME",
+		Short: "Delete an environment and everything it deployed",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := e.NewClients(e.Options)
+			if err != nil {
+				return err
+			}
+			ctx := cmd.Context()
+			env, err := e.getEnvironment(ctx, c, args[0])
+			if err != nil {
+				return err
+			}
+			if err := c.Client.Delete(ctx, env); client.IgnoreNotFound(err) != nil {
+				return err
+			}
+			_, _ = fmt.Fprintf(e.Out, "environment/%s deleted\n", env.Name)
+			if !waitForDeletion {
+				return nil
+			}
+			// The finalizer tears the environment namespace down first
+			err = wait.PollUntilContextTimeout(ctx, pollInterval, timeout, true, func(ctx context.Context) (bool, error) {
+				err := c.Client.Get(ctx, client.ObjectKeyFromObject(env), &catalystv1alpha1.Environment{})
+				if apierrors.IsNotFound(err) {
+					return true, nil
+				}
+				return false, err
+			})
+			if wait.Interrupted(err) {
+				return fmt.Errorf("timed out waiting for environment %s to be torn down", env.Name)
+			}
+			return err
+		},
+	}
+	cmd.Flags().BoolVar(&waitForDeletion, "wait", false, "Wait until the environment is torn down")
+	cmd.Flags().DurationVar(&timeout, "timeout", timeout, "How long --wait waits")
+	return cmd
+}
+
+// logsOptions are the flags of env logs
+type logsOptions struct {
+	pod       string
+	container string
+	follow    bool
+	tail      int64
+	since     time.Duration
+}
+
+func (e *envOptions) logsCommand() *cobra.Command {
+	o := &logsOptions{}
+	cmd := &cobra.Command{
+		Use:   "logs NAME",
+		Short: "Print the logs of an environment's web pod",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := e.NewClients(e.Options)
+			if err != nil {
+				return err
+			}
+			env, err := e.getEnvironment(cmd.Context(), c, args[0])
+			if err != nil {
+				return err
+			}
+			return e.logs(cmd.Context(), c, env, o)
+		},
+	}
+	flags := cmd.Flags()
+	flags.StringVar(&o.pod, "pod", "", "Pod to print the logs of (defaults to the web pod)")
+	flags.StringVarP(&o.container, "container", "c", "", "Container to print the logs of")
+	flags.BoolVarP(&o.follow, "follow", "f", false, "Stream new log lines")
+	flags.Int64Var(&o.tail, "tail", -1, "Lines of recent logs to print (-1 prints all)")
+	flags.DurationVar(&o.since, "since", 0, "Only print logs newer than this duration")
+	return cmd
+}
+
+// targetNamespace returns the namespace an Environment deploys to
+func targetNamespace(env *catalystv1alpha1.Environment) (string, error) {
+	hierarchy := controller.ExtractNamespaceHierarchy(env.Labels)
+	if hierarchy == nil {
+		return "", fmt.Errorf("environment %s is missing its hierarchy labels", env.Name)
+	}
+	return controller.GenerateEnvironmentNamespace(hierarchy.Team, hierarchy.Project, hierarchy.Environment), nil
+}
+
+// logsPod picks the pod of env logs: the running web pod unless one is named, otherwise any
+// running pod of the environment
+func logsPod(pods []corev1.Pod, name string) (*corev1.Pod, error) {
+	var fallback *corev1.Pod
+	for i := range pods {
+		pod := &pods[i]
+		if name != "" {
+			if pod.Name == name {
+				return pod, nil
+			}
+			continue
+		}
+		if pod.Status.Phase != corev1.PodRunning || !pod.DeletionTimestamp.IsZero() {
+			continue
+		}
+		if pod.Labels["app"] == "web" {
+			return pod, nil
+		}
+		if fallback == nil {
+			fallback = pod
+		}
+	}
+	if name != "" {
+		return nil, fmt.Errorf("pod %s not found", name)
+	}
+	if fallback == nil {
+		return nil, errors.New("the environment has no running pods")
+	}
+	return fallback, nil
+}
+
+func (e *envOptions) logs(ctx context.Context, c *Clients, env *catalystv1alpha1.Environment, o *logsOptions) error {
+	namespace, err := targetNamespace(env)
+	if err != nil {
+		return err
+	}
+	pods := &corev1.PodList{}
+	if err := c.Client.List(ctx, pods, client.InNamespace(namespace)); err != nil {
+		return err
+	}
+	pod, err := logsPod(pods.Items, o.pod)
+	if err != nil {
+		return err
+	}
+	container := o.container
+	if container == "" && len(pod.Spec.Containers) > 1 {
+		// The app container comes first; sidecars follow
+		container = pod.Spec.Containers[0].Name
+	}
+
+	options := &corev1.PodLogOptions{Container: container, Follow: o.follow}
+	if o.tail >= 0 {
+		options.TailLines = &o.tail
+	}
+	if o.since > 0 {
+		seconds := int64(o.since.Seconds())
+		options.SinceSeconds = &seconds
+	}
+	stream, err := c.Clientset.CoreV1().Pods(namespace).GetLogs(pod.Name, options).Stream(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to stream logs of %s: %w", pod.Name, err)
+	}
+	defer func() { _ = stream.Close() }()
+	_, err = io.Copy(e.Out, stream)
+	return err
+}
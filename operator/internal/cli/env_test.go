@@ -0,0 +1,209 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cli
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+	"github.com/ncrmro/catalyst/operator/internal/controller"
+)
+
+// testProject is the Project "shop" of the team namespace "acme"
+func testProject() *catalystv1alpha1.Project {
+	return &catalystv1alpha1.Project{
+		TypeMeta:   metav1.TypeMeta{APIVersion: catalystv1alpha1.GroupVersion.String(), Kind: "Project"},
+		ObjectMeta: metav1.ObjectMeta{Name: "shop", Namespace: "acme"},
+		Spec: catalystv1alpha1.ProjectSpec{
+			Sources: []catalystv1alpha1.SourceConfig{
+				{Name: "web", RepositoryURL: "https://github.com/acme/shop", Branch: "main"},
+				{Name: "api", RepositoryURL: "https://github.com/acme/api", Branch: "main"},
+			},
+			Templates: map[string]catalystv1alpha1.EnvironmentTemplateSpec{
+				"development": {SourceRef: "web", Type: "docker-compose"},
+				"deployment":  {SourceRef: "web", Type: "helm"},
+			},
+		},
+	}
+}
+
+// run executes catalystctl against a fake cluster holding objs
+func run(t *testing.T, objs []client.Object, clientset *kubefake.Clientset, args ...string) (client.Client, string, error) {
+	t.Helper()
+	s, err := scheme()
+	require.NoError(t, err)
+	c := fake.NewClientBuilder().WithScheme(s).WithObjects(objs...).
+		WithStatusSubresource(&catalystv1alpha1.Environment{}).Build()
+	if clientset == nil {
+		clientset = kubefake.NewClientset()
+	}
+
+	out := &bytes.Buffer{}
+	o := &Options{In: strings.NewReader(""), Out: out, ErrOut: out}
+	o.NewClients = func(o *Options) (*Clients, error) {
+		namespace := o.Namespace
+		if namespace == "" {
+			namespace = "default"
+		}
+		return &Clients{Client: c, Clientset: clientset, Namespace: namespace, Token: "alice-token"}, nil
+	}
+	cmd := NewRootCommand(o)
+	cmd.SetArgs(args)
+	err = cmd.ExecuteContext(context.Background())
+	return c, out.String(), err
+}
+
+func TestEnvCreate(t *testing.T) {
+	c, out, err := run(t, []client.Object{testProject()}, nil,
+		"-n", "acme", "env", "create", "--project", "shop", "--branch", "Feature/Checkout_v2", "--pr", "42")
+	require.NoError(t, err)
+
+	projectNamespace := controller.GenerateProjectNamespace("acme", "shop")
+	assert.Equal(t, "environment/feature-checkout-v2 created in namespace "+projectNamespace+"\n", out)
+	env := &catalystv1alpha1.Environment{}
+	require.NoError(t, c.Get(context.Background(), client.ObjectKey{Name: "feature-checkout-v2", Namespace: projectNamespace}, env))
+	assert.Equal(t, map[string]string{
+		"catalyst.dev/team":        "acme",
+		"catalyst.dev/project":     "shop",
+		"catalyst.dev/environment": "feature-checkout-v2",
+	}, env.Labels)
+	assert.Equal(t, "shop", env.Spec.ProjectRef.Name)
+	assert.Equal(t, "development", env.Spec.Type)
+	assert.Equal(t, []catalystv1alpha1.EnvironmentSource{
+		{Name: "web", Branch: "Feature/Checkout_v2", PrNumber: 42},
+	}, env.Spec.Sources, "the first source deploys the tip of the branch")
+
+	_, _, err = run(t, []client.Object{testProject()}, nil,
+		"-n", "acme", "env", "create", "--project", "shop", "--branch", "main", "--source", "docs")
+	assert.EqualError(t, err, "project shop has no source docs")
+	_, _, err = run(t, nil, nil, "-n", "acme", "env", "create", "--project", "shop", "--branch", "main")
+	assert.EqualError(t, err, "project shop not found in namespace acme")
+	_, _, err = run(t, []client.Object{testProject()}, nil, "-n", "acme", "env", "create", "--branch", "main")
+	assert.EqualError(t, err, "--project is required")
+}
+
+func TestWaitForReady(t *testing.T) {
+	pollInterval = 10 * time.Millisecond
+	s, err := scheme()
+	require.NoError(t, err)
+	env := func(name, phase string) *catalystv1alpha1.Environment {
+		return &catalystv1alpha1.Environment{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "acme-shop"},
+			Status:     catalystv1alpha1.EnvironmentStatus{Phase: phase, Message: "build failed", URL: "https://" + name + ".preview.dev"},
+		}
+	}
+	c := &Clients{Client: fake.NewClientBuilder().WithScheme(s).WithObjects(
+		env("ready", "Ready"), env("failed", "Failed"), env("building", "Building"),
+	).Build()}
+	ctx := context.Background()
+
+	ready, err := waitForReady(ctx, c, client.ObjectKey{Name: "ready", Namespace: "acme-shop"}, time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "https://ready.preview.dev", ready.Status.URL)
+	_, err = waitForReady(ctx, c, client.ObjectKey{Name: "failed", Namespace: "acme-shop"}, time.Second)
+	assert.EqualError(t, err, "environment failed failed: build failed")
+	_, err = waitForReady(ctx, c, client.ObjectKey{Name: "building", Namespace: "acme-shop"}, 50*time.Millisecond)
+	assert.EqualError(t, err, "timed out waiting for environment building to be Ready (phase Building)")
+}
+
+func TestEnvURLAndDelete(t *testing.T) {
+	projectNamespace := controller.GenerateProjectNamespace("acme", "shop")
+	env := &catalystv1alpha1.Environment{
+		ObjectMeta: metav1.ObjectMeta{Name: "pr-42", Namespace: projectNamespace},
+		Status: catalystv1alpha1.EnvironmentStatus{
+			Phase: "Ready",
+			URL:   "https://pr-42.preview.dev",
+			URLs:  []string{"https://pr-42.preview.dev", "https://checkout.preview.dev"},
+		},
+	}
+	pending := &catalystv1alpha1.Environment{ObjectMeta: metav1.ObjectMeta{Name: "pr-43", Namespace: projectNamespace}}
+
+	_, out, err := run(t, []client.Object{env}, nil, "-n", "acme", "env", "url", "--project", "shop", "pr-42")
+	require.NoError(t, err)
+	assert.Equal(t, "https://pr-42.preview.dev\n", out)
+	_, out, err = run(t, []client.Object{env}, nil, "-n", projectNamespace, "env", "url", "--all", "pr-42")
+	require.NoError(t, err)
+	assert.Equal(t, "https://pr-42.preview.dev\nhttps://checkout.preview.dev\n", out, "without --project -n is the environment namespace")
+	_, _, err = run(t, []client.Object{pending}, nil, "-n", projectNamespace, "env", "url", "pr-43")
+	assert.EqualError(t, err, "environment pr-43 has no URL yet (phase Pending)")
+	_, _, err = run(t, nil, nil, "-n", projectNamespace, "env", "url", "pr-44")
+	assert.EqualError(t, err, "environment pr-44 not found in namespace "+projectNamespace)
+
+	c, out, err := run(t, []client.Object{env}, nil, "-n", "acme", "env", "-p", "shop", "delete", "pr-42", "--wait")
+	require.NoError(t, err)
+	assert.Equal(t, "environment/pr-42 deleted\n", out)
+	err = c.Get(context.Background(), client.ObjectKeyFromObject(env), &catalystv1alpha1.Environment{})
+	assert.True(t, apierrors.IsNotFound(err))
+}
+
+func TestEnvLogs(t *testing.T) {
+	labels := map[string]string{
+		"catalyst.dev/team":        "acme",
+		"catalyst.dev/project":     "shop",
+		"catalyst.dev/environment": "pr-42",
+	}
+	env := &catalystv1alpha1.Environment{
+		ObjectMeta: metav1.ObjectMeta{Name: "pr-42", Namespace: controller.GenerateProjectNamespace("acme", "shop"), Labels: labels},
+	}
+	namespace := controller.GenerateEnvironmentNamespace("acme", "shop", "pr-42")
+	pod := func(name, app string, phase corev1.PodPhase) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: map[string]string{"app": app}},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: app}, {Name: "proxy"}}},
+			Status:     corev1.PodStatus{Phase: phase},
+		}
+	}
+	pods := []client.Object{pod("web-old", "web", corev1.PodFailed), pod("postgres-0", "postgres", corev1.PodRunning), pod("web-1", "web", corev1.PodRunning)}
+
+	_, out, err := run(t, append(pods, env), kubefake.NewClientset(), "-n", "acme", "env", "logs", "--project", "shop", "pr-42")
+	require.NoError(t, err)
+	assert.Equal(t, "fake logs", out)
+
+	var list []corev1.Pod
+	for _, p := range pods {
+		list = append(list, *p.(*corev1.Pod))
+	}
+	picked, err := logsPod(list, "")
+	require.NoError(t, err)
+	assert.Equal(t, "web-1", picked.Name, "the running web pod is preferred")
+	picked, err = logsPod(list[:2], "")
+	require.NoError(t, err)
+	assert.Equal(t, "postgres-0", picked.Name)
+	picked, err = logsPod(list, "web-old")
+	require.NoError(t, err)
+	assert.Equal(t, "web-old", picked.Name)
+	_, err = logsPod(list[:1], "")
+	assert.EqualError(t, err, "the environment has no running pods")
+
+	unlabelled := env.DeepCopy()
+	unlabelled.Labels = nil
+	_, _, err = run(t, []client.Object{unlabelled}, nil, "-n", "acme", "env", "logs", "--project", "shop", "pr-42")
+	assert.EqualError(t, err, "environment pr-42 is missing its hierarchy labels")
+}
@@ -0,0 +1,227 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+	"github.com/ncrmro/catalyst/operator/internal/controller"
+)
+
+// gatewayURLEnv is the environment variable --gateway defaults to
+const gatewayURLEnv = "CATALYST_GATEWAY_URL"
+
+// resizeInterval is how often the terminal size is checked during a session
+var resizeInterval = 500 * time.Millisecond
+
+// execOptions are the flags of env exec
+type execOptions struct {
+	gateway   string
+	container string
+}
+
+func (e *envOptions) execCommand() *cobra.Command {
+	o := &execOptions{}
+	cmd := &cobra.Command{
+		Use:   "exec NAME [-- COMMAND [ARGS...]]",
+		Short: "Open a terminal in an environment's workspace",
+		Long: `Open a terminal in an environment's workspace through the workspace gateway.
+
+The session authenticates with the environment's gateway token, so only read access to the
+token Secret next to the Environment is needed. Without a command the workspace shell starts.`,
+		Example: `  catalystctl -n acme env exec --project shop feature-checkout
+  catalystctl -n acme env exec --project shop feature-checkout -- npm test`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := e.NewClients(e.Options)
+			if err != nil {
+				return err
+			}
+			env, err := e.getEnvironment(cmd.Context(), c, args[0])
+			if err != nil {
+				return err
+			}
+			return e.exec(cmd.Context(), c, env, o, args[1:])
+		},
+	}
+	cmd.Flags().StringVar(&o.gateway, "gateway", os.Getenv(gatewayURLEnv), "URL of the workspace gateway (default $"+gatewayURLEnv+")")
+	cmd.Flags().StringVarP(&o.container, "container", "c", "", "Container of the workspace pod")
+	return cmd
+}
+
+// gatewayURL returns the exec endpoint of an Environment on the gateway at base
+func gatewayURL(base string, env *catalystv1alpha1.Environment, container string, command []string) (string, error) {
+	u, err := url.Parse(base)
+	if err != nil {
+		return "", fmt.Errorf("invalid gateway URL: %w", err)
+	}
+	switch u.Scheme {
+	case "http":
+		u.Scheme = "ws"
+	case "https":
+		u.Scheme = "wss"
+	case "ws", "wss":
+	default:
+		return "", fmt.Errorf("invalid gateway URL %q: expected an http(s) or ws(s) URL", base)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/exec/" + env.Namespace + "/" + env.Name
+	query := url.Values{}
+	if container != "" {
+		query.Set("container", container)
+	}
+	for _, arg := range command {
+		query.Add("command", arg)
+	}
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+func (e *envOptions) exec(ctx context.Context, c *Clients, env *catalystv1alpha1.Environment, o *execOptions, command []string) error {
+	if o.gateway == "" {
+		return fmt.Errorf("no gateway URL, pass --gateway or set $%s", gatewayURLEnv)
+	}
+	endpoint, err := gatewayURL(o.gateway, env, o.container, command)
+	if err != nil {
+		return err
+	}
+	secret := &corev1.Secret{}
+	key := client.ObjectKey{Name: controller.GatewayTokenSecretName(env.Name), Namespace: env.Namespace}
+	if err := c.Client.Get(ctx, key, secret); err != nil {
+		return fmt.Errorf("failed to read the gateway token of %s: %w", env.Name, err)
+	}
+
+	header := http.Header{}
+	header.Set("Authorization", "Bearer "+string(secret.Data[controller.GatewayTokenKey]))
+	if c.Token != "" {
+		header.Set("X-Catalyst-Identity", c.Token)
+	}
+	conn, resp, err := websocket.DefaultDialer.DialContext(ctx, endpoint, header)
+	if err != nil {
+		if resp != nil {
+			// The gateway explains refusals in the response body
+			body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+			_ = resp.Body.Close()
+			return fmt.Errorf("gateway refused the session (%s): %s", resp.Status, strings.TrimSpace(string(body)))
+		}
+		return fmt.Errorf("failed to connect to the gateway: %w", err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	var size func() (int, int, error)
+	if f, ok := e.In.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		state, err := term.MakeRaw(int(f.Fd()))
+		if err != nil {
+			return fmt.Errorf("failed to put the terminal into raw mode: %w", err)
+		}
+		defer func() { _ = term.Restore(int(f.Fd()), state) }()
+		if out, ok := e.Out.(*os.File); ok && term.IsTerminal(int(out.Fd())) {
+			size = func() (int, int, error) { return term.GetSize(int(out.Fd())) }
+		}
+	}
+	return attach(conn, e.In, e.Out, size)
+}
+
+// attach connects the streams to a gateway session until the gateway ends it: stdin is sent
+// as binary frames, terminal output written to out and, when size is set, terminal resizes
+// sent as control messages. The protocol has no half-close, so when in ends the session
+// lasts until the remote command exits.
+func attach(conn *websocket.Conn, in io.Reader, out io.Writer, size func() (int, int, error)) error {
+	var writeMu sync.Mutex
+	write := func(msgType int, data []byte) error {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		return conn.WriteMessage(msgType, data)
+	}
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		buf := make([]byte, 4096)
+		for {
+			n, err := in.Read(buf)
+			if n > 0 {
+				if write(websocket.BinaryMessage, buf[:n]) != nil {
+					return
+				}
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	if size != nil {
+		go func() {
+			var cols, rows int
+			ticker := time.NewTicker(resizeInterval)
+			defer ticker.Stop()
+			for {
+				if w, h, err := size(); err == nil && (w != cols || h != rows) {
+					cols, rows = w, h
+					msg, _ := json.Marshal(map[string]any{"type": "resize", "cols": cols, "rows": rows})
+					if write(websocket.TextMessage, msg) != nil {
+						return
+					}
+				}
+				select {
+				case <-ticker.C:
+				case <-done:
+					return
+				}
+			}
+		}()
+	}
+
+	for {
+		msgType, data, err := conn.ReadMessage()
+		if err != nil {
+			var closeErr *websocket.CloseError
+			if errors.As(err, &closeErr) {
+				if closeErr.Code == websocket.CloseNormalClosure {
+					return nil
+				}
+				if closeErr.Text != "" {
+					return errors.New(closeErr.Text)
+				}
+			}
+			return fmt.Errorf("gateway session ended: %w", err)
+		}
+		if msgType != websocket.BinaryMessage {
+			continue
+		}
+		if _, err := out.Write(data); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,142 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/remotecommand"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+	"github.com/ncrmro/catalyst/operator/internal/controller"
+	"github.com/ncrmro/catalyst/operator/internal/gateway"
+)
+
+// tokenAuthenticator knows the token of alice
+type tokenAuthenticator struct{}
+
+func (tokenAuthenticator) Authenticate(_ context.Context, token string) (*authenticationv1.UserInfo, error) {
+	if token != "alice-token" {
+		return nil, nil
+	}
+	return &authenticationv1.UserInfo{Username: "alice"}, nil
+}
+
+func (tokenAuthenticator) CanImpersonate(context.Context, authenticationv1.UserInfo, string) (bool, error) {
+	return false, nil
+}
+
+// scriptExecutor prints the command it runs and what it reads from stdin, then fails with err
+type scriptExecutor struct {
+	container string
+	err       error
+}
+
+func (e *scriptExecutor) Exec(_ context.Context, _, _, container string, command []string, streams remotecommand.StreamOptions) error {
+	e.container = container
+	buf := make([]byte, 64)
+	n, _ := streams.Stdin.Read(buf)
+	_, _ = fmt.Fprintf(streams.Stdout, "%s: %s", strings.Join(command, " "), buf[:n])
+	return e.err
+}
+
+func TestGatewayURL(t *testing.T) {
+	env := &catalystv1alpha1.Environment{ObjectMeta: metav1.ObjectMeta{Name: "pr-42", Namespace: "acme-shop"}}
+
+	u, err := gatewayURL("https://gateway.example.com/", env, "", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "wss://gateway.example.com/exec/acme-shop/pr-42", u)
+	u, err = gatewayURL("http://localhost:8083/catalyst", env, "workspace", []string{"npm", "run", "test:unit"})
+	require.NoError(t, err)
+	assert.Equal(t, "ws://localhost:8083/catalyst/exec/acme-shop/pr-42?command=npm&command=run&command=test%3Aunit&container=workspace", u)
+	_, err = gatewayURL("gateway:8083", env, "", nil)
+	assert.Error(t, err)
+}
+
+func TestEnvExec(t *testing.T) {
+	s, err := scheme()
+	require.NoError(t, err)
+	labels := map[string]string{
+		"catalyst.dev/team":        "acme",
+		"catalyst.dev/project":     "shop",
+		"catalyst.dev/environment": "pr-42",
+	}
+	projectNamespace := controller.GenerateProjectNamespace("acme", "shop")
+	objs := []client.Object{
+		&catalystv1alpha1.Environment{ObjectMeta: metav1.ObjectMeta{Name: "pr-42", Namespace: projectNamespace, Labels: labels}},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: controller.GatewayTokenSecretName("pr-42"), Namespace: projectNamespace},
+			Data:       map[string][]byte{controller.GatewayTokenKey: []byte("s3cret")},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "workspace-shop",
+				Namespace: controller.GenerateEnvironmentNamespace("acme", "shop", "pr-42"),
+				Labels:    map[string]string{"catalyst.dev/pod-type": "workspace", "catalyst.dev/environment": "pr-42"},
+			},
+			Status: corev1.PodStatus{Phase: corev1.PodRunning},
+		},
+	}
+	executor := &scriptExecutor{}
+	server := &gateway.Server{
+		Reader:        fake.NewClientBuilder().WithScheme(s).WithObjects(objs...).Build(),
+		Executor:      executor,
+		Authenticator: tokenAuthenticator{},
+	}
+	ts := httptest.NewServer(server.Handler())
+	defer ts.Close()
+
+	c := &Clients{Client: fake.NewClientBuilder().WithScheme(s).WithObjects(objs...).Build(), Namespace: "acme", Token: "alice-token"}
+	out := &strings.Builder{}
+	e := &envOptions{Options: &Options{In: strings.NewReader("hello"), Out: out}, project: "shop"}
+	env, err := e.getEnvironment(context.Background(), c, "pr-42")
+	require.NoError(t, err)
+
+	o := &execOptions{gateway: ts.URL, container: "workspace"}
+	require.NoError(t, e.exec(context.Background(), c, env, o, []string{"cat", "-"}))
+	assert.Equal(t, "cat -: hello", out.String())
+	assert.Equal(t, "workspace", executor.container)
+
+	// Exec failures end the command with the gateway's reason
+	executor.err = errors.New("command terminated with exit code 2")
+	e.In = strings.NewReader("hello")
+	err = e.exec(context.Background(), c, env, o, []string{"false"})
+	assert.EqualError(t, err, "command terminated with exit code 2")
+
+	// Refusals carry the gateway's explanation
+	wrongToken := objs[1].(*corev1.Secret).DeepCopy()
+	wrongToken.Data[controller.GatewayTokenKey] = []byte("guess")
+	c.Client = fake.NewClientBuilder().WithScheme(s).WithObjects(objs[0], wrongToken).Build()
+	err = e.exec(context.Background(), c, env, o, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "gateway refused the session (401 Unauthorized)")
+
+	o.gateway = ""
+	assert.EqualError(t, e.exec(context.Background(), c, env, o, nil), "no gateway URL, pass --gateway or set $CATALYST_GATEWAY_URL")
+}
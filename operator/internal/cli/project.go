@@ -0,0 +1,265 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+// standardTemplates are the template keys environments are created with
+var standardTemplates = []string{"development", "deployment"}
+
+func newProjectCommand(o *Options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "project",
+		Short: "Inspect projects",
+	}
+	cmd.AddCommand(newProjectValidateCommand(o))
+	return cmd
+}
+
+// validateOptions are the flags of project validate
+type validateOptions struct {
+	file  string
+	local bool
+}
+
+func newProjectValidateCommand(o *Options) *cobra.Command {
+	v := &validateOptions{}
+	cmd := &cobra.Command{
+		Use:   "validate [NAME]",
+		Short: "Check a Project for mistakes before environments are created from it",
+		Long: `Check a Project for mistakes before environments are created from it.
+
+Validates the Project NAME in --namespace, or the manifest given with -f. Template and build
+sources must exist, template references must resolve to EnvironmentTemplates and the API
+server must accept the Project (checked with a server-side dry run). --local skips the checks
+that need the cluster.`,
+		Example: `  catalystctl -n acme project validate shop
+  catalystctl project validate -f project.yaml --local`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if (len(args) == 0) == (v.file == "") {
+				return errors.New("pass either a Project name or -f FILE")
+			}
+			return validate(cmd.Context(), o, v, args)
+		},
+	}
+	cmd.Flags().StringVarP(&v.file, "filename", "f", "", "Project manifest to validate (- reads stdin)")
+	cmd.Flags().BoolVar(&v.local, "local", false, "Only run the checks that need no cluster")
+	return cmd
+}
+
+// findings are the problems project validate reports; errors fail the command
+type findings struct {
+	errors   []string
+	warnings []string
+}
+
+func (f *findings) errorf(format string, args ...any) {
+	f.errors = append(f.errors, fmt.Sprintf(format, args...))
+}
+
+func (f *findings) warnf(format string, args ...any) {
+	f.warnings = append(f.warnings, fmt.Sprintf(format, args...))
+}
+
+func validate(ctx context.Context, o *Options, v *validateOptions, args []string) error {
+	var c *Clients
+	if !v.local || v.file == "" {
+		var err error
+		if c, err = o.NewClients(o); err != nil {
+			return err
+		}
+	}
+
+	project := &catalystv1alpha1.Project{}
+	if v.file != "" {
+		if err := readProject(o.In, v.file, project); err != nil {
+			return err
+		}
+		if project.Namespace == "" && c != nil {
+			project.Namespace = c.Namespace
+		}
+	} else if err := c.Client.Get(ctx, client.ObjectKey{Name: args[0], Namespace: c.Namespace}, project); err != nil {
+		if apierrors.IsNotFound(err) {
+			return fmt.Errorf("project %s not found in namespace %s", args[0], c.Namespace)
+		}
+		return err
+	}
+
+	f := &findings{}
+	checkProject(project, f)
+	if !v.local {
+		if err := checkTemplateRefs(ctx, c.Client, project, f); err != nil {
+			return err
+		}
+		if v.file != "" {
+			if err := dryRunProject(ctx, c.Client, project, f); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, w := range f.warnings {
+		_, _ = fmt.Fprintf(o.Out, "warning: %s\n", w)
+	}
+	for _, e := range f.errors {
+		_, _ = fmt.Fprintf(o.Out, "error: %s\n", e)
+	}
+	if len(f.errors) > 0 {
+		return fmt.Errorf("project %s is invalid: %d error(s)", project.Name, len(f.errors))
+	}
+	_, _ = fmt.Fprintf(o.Out, "project %s is valid\n", project.Name)
+	return nil
+}
+
+// readProject decodes a Project manifest, rejecting unknown fields
+func readProject(stdin io.Reader, file string, project *catalystv1alpha1.Project) error {
+	var data []byte
+	var err error
+	if file == "-" {
+		data, err = io.ReadAll(stdin)
+	} else {
+		data, err = os.ReadFile(file)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", file, err)
+	}
+	if err := yaml.UnmarshalStrict(data, project); err != nil {
+		return fmt.Errorf("failed to decode %s: %w", file, err)
+	}
+	if project.Kind != "Project" {
+		return fmt.Errorf("%s is a %q, not a Project", file, project.Kind)
+	}
+	return nil
+}
+
+// checkProject runs the checks that need no cluster
+func checkProject(project *catalystv1alpha1.Project, f *findings) {
+	sources := map[string]bool{}
+	if len(project.Spec.Sources) == 0 {
+		f.errorf("sources: the project has no sources")
+	}
+	for _, s := range project.Spec.Sources {
+		if sources[s.Name] {
+			f.errorf("sources: duplicate source %q", s.Name)
+		}
+		sources[s.Name] = true
+	}
+
+	keys := make([]string, 0, len(project.Spec.Templates))
+	for key := range project.Spec.Templates {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		checkTemplate(fmt.Sprintf("templates.%s", key), project.Spec.Templates[key], sources, f)
+		if _, ok := project.Spec.TemplateRefs[key]; ok {
+			f.warnf("templateRefs.%s: shadowed by the inline %s template", key, key)
+		}
+	}
+
+	for _, key := range standardTemplates {
+		_, inline := project.Spec.Templates[key]
+		_, ref := project.Spec.TemplateRefs[key]
+		if !inline && !ref {
+			f.warnf("templates: no %s template, %s environments deploy with the defaults", key, key)
+		}
+	}
+}
+
+// checkTemplate checks that a template only refers to sources of the project
+func checkTemplate(path string, template catalystv1alpha1.EnvironmentTemplateSpec, sources map[string]bool, f *findings) {
+	if template.SourceRef != "" && !sources[template.SourceRef] {
+		f.errorf("%s.sourceRef: unknown source %q", path, template.SourceRef)
+	}
+	for i, build := range template.Builds {
+		if !sources[build.SourceRef] {
+			f.errorf("%s.builds[%d].sourceRef: unknown source %q", path, i, build.SourceRef)
+		}
+	}
+}
+
+// checkTemplateRefs checks that template references resolve to catalog EnvironmentTemplates
+// that fit the project's sources
+func checkTemplateRefs(ctx context.Context, c client.Client, project *catalystv1alpha1.Project, f *findings) error {
+	sources := map[string]bool{}
+	for _, s := range project.Spec.Sources {
+		sources[s.Name] = true
+	}
+	keys := make([]string, 0, len(project.Spec.TemplateRefs))
+	for key := range project.Spec.TemplateRefs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		ref := project.Spec.TemplateRefs[key]
+		if _, inline := project.Spec.Templates[key]; inline {
+			continue
+		}
+		namespace := ref.Namespace
+		if namespace == "" {
+			namespace = project.Namespace
+		}
+		template := &catalystv1alpha1.EnvironmentTemplate{}
+		if err := c.Get(ctx, client.ObjectKey{Name: ref.Name, Namespace: namespace}, template); err != nil {
+			if apierrors.IsNotFound(err) {
+				f.errorf("templateRefs.%s: EnvironmentTemplate %s/%s not found", key, namespace, ref.Name)
+				continue
+			}
+			return err
+		}
+		checkTemplate(fmt.Sprintf("templateRefs.%s (%s/%s)", key, namespace, ref.Name), template.Spec, sources, f)
+	}
+	return nil
+}
+
+// dryRunProject submits a manifest with a server-side dry run, so schema and CEL validation
+// run without changing the cluster
+func dryRunProject(ctx context.Context, c client.Client, project *catalystv1alpha1.Project, f *findings) error {
+	existing := &catalystv1alpha1.Project{}
+	err := c.Get(ctx, client.ObjectKeyFromObject(project), existing)
+	switch {
+	case apierrors.IsNotFound(err):
+		err = c.Create(ctx, project.DeepCopy(), client.DryRunAll)
+	case err != nil:
+		return err
+	default:
+		candidate := project.DeepCopy()
+		candidate.ResourceVersion = existing.ResourceVersion
+		err = c.Update(ctx, candidate, client.DryRunAll)
+	}
+	if apierrors.IsInvalid(err) || apierrors.IsBadRequest(err) || apierrors.IsForbidden(err) {
+		f.errorf("rejected by the API server: %v", err)
+		return nil
+	}
+	return err
+}
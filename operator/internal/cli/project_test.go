@@ -0,0 +1,135 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+func TestCheckProject(t *testing.T) {
+	f := &findings{}
+	checkProject(testProject(), f)
+	assert.Empty(t, f.errors)
+	assert.Empty(t, f.warnings)
+
+	project := testProject()
+	project.Spec.Sources = append(project.Spec.Sources, catalystv1alpha1.SourceConfig{Name: "api"})
+	project.Spec.Templates = map[string]catalystv1alpha1.EnvironmentTemplateSpec{
+		"development": {
+			SourceRef: "frontend",
+			Builds:    []catalystv1alpha1.BuildSpec{{Name: "web", SourceRef: "web"}, {Name: "worker", SourceRef: "jobs"}},
+		},
+	}
+	project.Spec.TemplateRefs = map[string]catalystv1alpha1.TemplateReference{"development": {Name: "nextjs"}}
+	f = &findings{}
+	checkProject(project, f)
+	assert.Equal(t, []string{
+		`sources: duplicate source "api"`,
+		`templates.development.sourceRef: unknown source "frontend"`,
+		`templates.development.builds[1].sourceRef: unknown source "jobs"`,
+	}, f.errors)
+	assert.Equal(t, []string{
+		"templateRefs.development: shadowed by the inline development template",
+		"templates: no deployment template, deployment environments deploy with the defaults",
+	}, f.warnings)
+}
+
+func TestProjectValidate(t *testing.T) {
+	project := testProject()
+	project.Spec.Templates = nil
+	project.Spec.TemplateRefs = map[string]catalystv1alpha1.TemplateReference{
+		"development": {Name: "nextjs"},
+		"deployment":  {Name: "helm-app", Namespace: "catalog"},
+	}
+	nextjs := &catalystv1alpha1.EnvironmentTemplate{
+		ObjectMeta: metav1.ObjectMeta{Name: "nextjs", Namespace: "acme"},
+		Spec:       catalystv1alpha1.EnvironmentTemplateSpec{SourceRef: "app", Type: "docker-compose"},
+	}
+
+	_, out, err := run(t, []client.Object{project, nextjs}, nil, "-n", "acme", "project", "validate", "shop")
+	assert.EqualError(t, err, "project shop is invalid: 2 error(s)")
+	assert.Equal(t, `error: templateRefs.deployment: EnvironmentTemplate catalog/helm-app not found
+error: templateRefs.development (acme/nextjs).sourceRef: unknown source "app"
+`, out)
+
+	_, out, err = run(t, []client.Object{testProject()}, nil, "-n", "acme", "project", "validate", "shop")
+	require.NoError(t, err)
+	assert.Equal(t, "project shop is valid\n", out)
+
+	_, _, err = run(t, nil, nil, "-n", "acme", "project", "validate")
+	assert.EqualError(t, err, "pass either a Project name or -f FILE")
+}
+
+func TestProjectValidateFile(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, manifest string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(manifest), 0o600))
+		return path
+	}
+	valid := write("valid.yaml", `apiVersion: catalyst.catalyst.dev/v1alpha1
+kind: Project
+metadata:
+  name: shop
+spec:
+  sources:
+    - name: web
+      repositoryUrl: https://github.com/acme/shop
+      branch: main
+  templates:
+    development:
+      sourceRef: web
+      type: docker-compose
+`)
+
+	// The dry run submits the manifest to the namespace of the flags
+	c, out, err := run(t, nil, nil, "-n", "acme", "project", "validate", "-f", valid)
+	require.NoError(t, err)
+	assert.Equal(t, `warning: templates: no deployment template, deployment environments deploy with the defaults
+project shop is valid
+`, out)
+	assert.Error(t, c.Get(t.Context(), client.ObjectKey{Name: "shop", Namespace: "acme"}, &catalystv1alpha1.Project{}),
+		"the dry run creates nothing")
+	_, _, err = run(t, []client.Object{testProject()}, nil, "-n", "acme", "project", "validate", "-f", valid)
+	require.NoError(t, err, "existing projects are dry-run updated")
+
+	typo := write("typo.yaml", `apiVersion: catalyst.catalyst.dev/v1alpha1
+kind: Project
+metadata:
+  name: shop
+spec:
+  sources:
+    - name: web
+      repo: https://github.com/acme/shop
+`)
+	_, _, err = run(t, nil, nil, "project", "validate", "--local", "-f", typo)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown field "repo"`)
+
+	template := write("template.yaml", "apiVersion: catalyst.catalyst.dev/v1alpha1\nkind: EnvironmentTemplate\n")
+	_, _, err = run(t, nil, nil, "project", "validate", "--local", "-f", template)
+	assert.EqualError(t, err, template+` is a "EnvironmentTemplate", not a Project`)
+}
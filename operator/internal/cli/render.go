@@ -0,0 +1,132 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+	"github.com/ncrmro/catalyst/operator/internal/controller"
+)
+
+// renderOptions are the flags of env render
+type renderOptions struct {
+	config  bool
+	timeout time.Duration
+}
+
+func (e *envOptions) renderCommand() *cobra.Command {
+	o := &renderOptions{}
+	cmd := &cobra.Command{
+		Use:   "render NAME",
+		Short: "Print the resources an environment deploys, without deploying them",
+		Long: `Print the resources an environment deploys, without deploying them.
+
+The operator renders the environment's deployment mode (the resolved config, the compose
+translation or the Helm chart rendered from the source) into the ConfigMap NAME-render and
+the manifests are printed. Guardrail violations and what the rendering leaves out are
+reported on stderr. Environments created with --render-only are rendered on every change and
+never deploy until the catalyst.dev/render-only annotation is removed.`,
+		Example: `  catalystctl -n acme env render --project shop feature-checkout
+  catalystctl -n acme env render --project shop feature-checkout --config`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := e.NewClients(e.Options)
+			if err != nil {
+				return err
+			}
+			env, err := e.getEnvironment(cmd.Context(), c, args[0])
+			if err != nil {
+				return err
+			}
+			return e.render(cmd.Context(), c, env, o)
+		},
+	}
+	cmd.Flags().BoolVar(&o.config, "config", false, "Print the resolved config instead of the manifests")
+	cmd.Flags().DurationVar(&o.timeout, "timeout", 5*time.Minute, "How long to wait for the rendering")
+	return cmd
+}
+
+func (e *envOptions) render(ctx context.Context, c *Clients, env *catalystv1alpha1.Environment, o *renderOptions) error {
+	patch := client.MergeFrom(env.DeepCopy())
+	if env.Annotations == nil {
+		env.Annotations = map[string]string{}
+	}
+	env.Annotations[controller.RenderAnnotation] = time.Now().UTC().Format(time.RFC3339)
+	if err := c.Client.Patch(ctx, env, patch); err != nil {
+		return fmt.Errorf("failed to request a rendering of %s: %w", env.Name, err)
+	}
+
+	// The operator removes the annotation once status.render is written
+	key := client.ObjectKeyFromObject(env)
+	err := wait.PollUntilContextTimeout(ctx, pollInterval, o.timeout, true, func(ctx context.Context) (bool, error) {
+		if err := c.Client.Get(ctx, key, env); err != nil {
+			return false, err
+		}
+		_, pending := env.Annotations[controller.RenderAnnotation]
+		return !pending && env.Status.Render != nil, nil
+	})
+	if wait.Interrupted(err) {
+		return fmt.Errorf("timed out waiting for environment %s to be rendered", env.Name)
+	}
+	if err != nil {
+		return err
+	}
+	if env.Status.Render.Error != "" {
+		return fmt.Errorf("cannot render environment %s: %s", env.Name, env.Status.Render.Error)
+	}
+
+	configMap := &corev1.ConfigMap{}
+	if err := c.Client.Get(ctx, client.ObjectKey{Name: env.Status.Render.ConfigMap, Namespace: env.Namespace}, configMap); err != nil {
+		return fmt.Errorf("failed to read the rendering of %s: %w", env.Name, err)
+	}
+	for _, line := range lines(configMap.Data[controller.RenderNotesKey]) {
+		_, _ = fmt.Fprintf(e.ErrOut, "note: %s\n", line)
+	}
+	for _, line := range lines(configMap.Data[controller.RenderViolationsKey]) {
+		_, _ = fmt.Fprintf(e.ErrOut, "violation: %s\n", line)
+	}
+	if o.config {
+		config, ok := configMap.Data[controller.RenderConfigKey]
+		if !ok {
+			return fmt.Errorf("the %s mode has no resolved config", env.Status.Render.DeploymentMode)
+		}
+		_, _ = fmt.Fprint(e.Out, config)
+		return nil
+	}
+	_, _ = fmt.Fprint(e.Out, configMap.Data[controller.RenderManifestsKey])
+	return nil
+}
+
+// lines splits a newline-separated list, dropping empty lines
+func lines(s string) []string {
+	var out []string
+	for _, line := range strings.Split(s, "\n") {
+		if line != "" {
+			out = append(out, line)
+		}
+	}
+	return out
+}
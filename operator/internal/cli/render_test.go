@@ -0,0 +1,112 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cli
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+	"github.com/ncrmro/catalyst/operator/internal/controller"
+)
+
+// renderingClients returns Clients on a fake cluster whose operator answers rendering
+// requests with status
+func renderingClients(t *testing.T, status catalystv1alpha1.RenderStatus, objs ...client.Object) *Clients {
+	t.Helper()
+	s, err := scheme()
+	require.NoError(t, err)
+	c := fake.NewClientBuilder().WithScheme(s).WithObjects(objs...).
+		WithStatusSubresource(&catalystv1alpha1.Environment{}).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+				if err := c.Patch(ctx, obj, patch, opts...); err != nil {
+					return err
+				}
+				env, ok := obj.(*catalystv1alpha1.Environment)
+				if !ok {
+					return nil
+				}
+				env.Status.Render = status.DeepCopy()
+				if err := c.Status().Update(ctx, env); err != nil {
+					return err
+				}
+				delete(env.Annotations, controller.RenderAnnotation)
+				return c.Update(ctx, env)
+			},
+		}).Build()
+	return &Clients{Client: c, Namespace: "acme"}
+}
+
+func TestEnvRender(t *testing.T) {
+	pollInterval = 10 * time.Millisecond
+	env := &catalystv1alpha1.Environment{ObjectMeta: metav1.ObjectMeta{Name: "pr-42", Namespace: "acme-shop"}}
+	rendering := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "pr-42-render", Namespace: "acme-shop"},
+		Data: map[string]string{
+			controller.RenderManifestsKey:  "---\nkind: Deployment\n",
+			controller.RenderConfigKey:     "image: node:22\n",
+			controller.RenderViolationsKey: "Deployment web: privileged\n",
+			controller.RenderNotesKey:      "service db: the seed Job is created once the service is ready\n",
+		},
+	}
+	status := catalystv1alpha1.RenderStatus{ConfigMap: "pr-42-render", DeploymentMode: "development", RenderedAt: metav1.Now()}
+	ctx := context.Background()
+
+	out, errOut := &bytes.Buffer{}, &bytes.Buffer{}
+	e := &envOptions{Options: &Options{Out: out, ErrOut: errOut}, project: "shop"}
+	c := renderingClients(t, status, env.DeepCopy(), rendering)
+	target, err := e.getEnvironment(ctx, c, "pr-42")
+	require.NoError(t, err)
+	require.NoError(t, e.render(ctx, c, target, &renderOptions{timeout: time.Second}))
+	assert.Equal(t, "---\nkind: Deployment\n", out.String())
+	assert.Equal(t, "note: service db: the seed Job is created once the service is ready\nviolation: Deployment web: privileged\n", errOut.String())
+
+	out.Reset()
+	require.NoError(t, e.render(ctx, c, target, &renderOptions{config: true, timeout: time.Second}))
+	assert.Equal(t, "image: node:22\n", out.String())
+
+	status.Error = "helm mode requires a template"
+	c = renderingClients(t, status, env.DeepCopy())
+	target, err = e.getEnvironment(ctx, c, "pr-42")
+	require.NoError(t, err)
+	assert.EqualError(t, e.render(ctx, c, target, &renderOptions{timeout: time.Second}),
+		"cannot render environment pr-42: helm mode requires a template")
+}
+
+func TestEnvCreateRenderOnly(t *testing.T) {
+	c, _, err := run(t, []client.Object{testProject()}, nil,
+		"-n", "acme", "env", "create", "--project", "shop", "--branch", "main", "--render-only")
+	require.NoError(t, err)
+	env := &catalystv1alpha1.Environment{}
+	require.NoError(t, c.Get(context.Background(), client.ObjectKey{Name: "main", Namespace: "acme-shop"}, env))
+	assert.Equal(t, map[string]string{controller.RenderOnlyAnnotation: "true"}, env.Annotations)
+
+	_, _, err = run(t, []client.Object{testProject()}, nil,
+		"-n", "acme", "env", "create", "--project", "shop", "--branch", "main", "--render-only", "--wait")
+	assert.Error(t, err, "a render-only environment never becomes Ready")
+}
@@ -0,0 +1,426 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha1" //nolint:gosec // {SSHA} is the salted SHA-1 htpasswd scheme nginx supports
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+	"github.com/ncrmro/catalyst/operator/internal/accesspolicy"
+)
+
+// Access protection (spec.access) of the preview Ingresses. basicAuth points ingress-nginx at
+// an htpasswd Secret; oauthProxy deploys oauth2-proxy next to the workload and has
+// ingress-nginx check every request against it (auth-url), sending signed-out users to its
+// sign-in page. The proxy serves /oauth2 on the preview hosts through an Ingress of its own,
+// which is not protected itself.
+
+const (
+	// conditionAccessProtected reports whether the preview hosts are protected by spec.access
+	conditionAccessProtected = "AccessProtected"
+
+	basicAuthSecretName    = "preview-basic-auth"
+	basicAuthUser          = "preview"
+	oauthProxyName         = "oauth2-proxy"
+	oauthProxyPort         = 4180
+	defaultOAuthProxyImage = "quay.io/oauth2-proxy/oauth2-proxy:v7.7.1"
+
+	nginxAuthTypeAnnotation            = "nginx.ingress.kubernetes.io/auth-type"
+	nginxAuthSecretAnnotation          = "nginx.ingress.kubernetes.io/auth-secret"
+	nginxAuthRealmAnnotation           = "nginx.ingress.kubernetes.io/auth-realm"
+	nginxAuthURLAnnotation             = "nginx.ingress.kubernetes.io/auth-url"
+	nginxAuthSigninAnnotation          = "nginx.ingress.kubernetes.io/auth-signin"
+	nginxAuthResponseHeadersAnnotation = "nginx.ingress.kubernetes.io/auth-response-headers"
+)
+
+// accessAnnotations are the Ingress annotations managed for spec.access
+var accessAnnotations = []string{
+	nginxAuthTypeAnnotation,
+	nginxAuthSecretAnnotation,
+	nginxAuthRealmAnnotation,
+	nginxAuthURLAnnotation,
+	nginxAuthSigninAnnotation,
+	nginxAuthResponseHeadersAnnotation,
+}
+
+// accessType returns the access type of an Environment, public when unset
+func accessType(env *catalystv1alpha1.Environment) string {
+	if env.Spec.Access == nil || env.Spec.Access.Type == "" {
+		return catalystv1alpha1.AccessTypePublic
+	}
+	return env.Spec.Access.Type
+}
+
+// basicAuthSecret returns the htpasswd Secret of basicAuth access, and whether the operator generates it
+func basicAuthSecret(env *catalystv1alpha1.Environment) (string, bool) {
+	if env.Spec.Access != nil && env.Spec.Access.BasicAuth != nil && env.Spec.Access.BasicAuth.SecretName != "" {
+		return env.Spec.Access.BasicAuth.SecretName, false
+	}
+	return basicAuthSecretName, true
+}
+
+// applyAccess sets the ingress-nginx annotations protecting a preview Ingress
+func applyAccess(env *catalystv1alpha1.Environment, ingress *networkingv1.Ingress) {
+	var annotations map[string]string
+	switch accessType(env) {
+	case catalystv1alpha1.AccessTypeBasicAuth:
+		secret, _ := basicAuthSecret(env)
+		annotations = map[string]string{
+			nginxAuthTypeAnnotation:   "basic",
+			nginxAuthSecretAnnotation: secret,
+			nginxAuthRealmAnnotation:  "Preview environment " + env.Name,
+		}
+	case catalystv1alpha1.AccessTypeOAuthProxy:
+		annotations = map[string]string{
+			nginxAuthURLAnnotation:             fmt.Sprintf("http://%s.%s.svc.cluster.local:%d/oauth2/auth", oauthProxyName, ingress.Namespace, oauthProxyPort),
+			nginxAuthSigninAnnotation:          "$scheme://$host/oauth2/start?rd=$escaped_request_uri",
+			nginxAuthResponseHeadersAnnotation: "X-Auth-Request-User,X-Auth-Request-Email",
+		}
+	default:
+		return
+	}
+	if ingress.Annotations == nil {
+		ingress.Annotations = map[string]string{}
+	}
+	for k, v := range annotations {
+		ingress.Annotations[k] = v
+	}
+}
+
+// syncIngressAnnotations copies the annotations of the given keys from desired onto existing,
+// removing the ones desired no longer sets. Reports whether existing changed.
+func syncIngressAnnotations(existing, desired *networkingv1.Ingress, keys ...[]string) bool {
+	changed := false
+	for _, key := range slices.Concat(keys...) {
+		want, ok := desired.Annotations[key]
+		got, has := existing.Annotations[key]
+		switch {
+		case ok && (!has || got != want):
+			if existing.Annotations == nil {
+				existing.Annotations = map[string]string{}
+			}
+			existing.Annotations[key] = want
+			changed = true
+		case !ok && has:
+			delete(existing.Annotations, key)
+			changed = true
+		}
+	}
+	return changed
+}
+
+// htpasswdSSHA returns an htpasswd line for user with a salted SHA-1 password hash
+func htpasswdSSHA(user, password string) (string, error) {
+	salt := make([]byte, 8)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	hash := sha1.Sum(append([]byte(password), salt...)) //nolint:gosec
+	return user + ":{SSHA}" + base64.StdEncoding.EncodeToString(append(hash[:], salt...)), nil
+}
+
+// desiredBasicAuthSecret creates the generated htpasswd Secret with a random password
+func desiredBasicAuthSecret(namespace string) (*corev1.Secret, error) {
+	password, err := generateGatewayToken()
+	if err != nil {
+		return nil, err
+	}
+	password = password[:24]
+	auth, err := htpasswdSSHA(basicAuthUser, password)
+	if err != nil {
+		return nil, err
+	}
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      basicAuthSecretName,
+			Namespace: namespace,
+		},
+		Type: corev1.SecretTypeOpaque,
+		StringData: map[string]string{
+			"auth":     auth,
+			"username": basicAuthUser,
+			"password": password,
+		},
+	}, nil
+}
+
+// desiredOAuthProxyDeployment runs oauth2-proxy as the ingress-nginx auth backend
+func desiredOAuthProxyDeployment(env *catalystv1alpha1.Environment, namespace string, isLocal bool) *appsv1.Deployment {
+	cfg := env.Spec.Access.OAuthProxy
+	provider := cfg.Provider
+	if provider == "" {
+		provider = "github"
+	}
+	args := []string{
+		fmt.Sprintf("--http-address=0.0.0.0:%d", oauthProxyPort),
+		"--provider=" + provider,
+		"--reverse-proxy=true",
+		"--upstream=static://202",
+		"--set-xauthrequest=true",
+		"--skip-provider-button=true",
+		"--cookie-secure=" + strconv.FormatBool(!isLocal),
+	}
+	// Restricted by args otherwise (enforced by accesspolicy.Validate): oauth2-proxy
+	// still checks email domains, so any domain passes the provider's own check
+	domains := cfg.EmailDomains
+	emailArgs := slices.ContainsFunc(cfg.Args, func(arg string) bool {
+		return strings.HasPrefix(arg, "--email-domain") || strings.HasPrefix(arg, "--authenticated-emails-file")
+	})
+	if len(domains) == 0 && !emailArgs {
+		domains = []string{"*"}
+	}
+	for _, domain := range domains {
+		args = append(args, "--email-domain="+domain)
+	}
+	args = append(args, cfg.Args...)
+
+	secretEnv := func(name, key string) corev1.EnvVar {
+		return corev1.EnvVar{Name: name, ValueFrom: &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: cfg.SecretName}, Key: key},
+		}}
+	}
+	labels := map[string]string{"app": oauthProxyName}
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      oauthProxyName,
+			Namespace: namespace,
+			Labels:    map[string]string{"app": oauthProxyName},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: int32Ptr(1),
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": oauthProxyName}},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{
+						Name:  oauthProxyName,
+						Image: defaultOAuthProxyImage,
+						Args:  args,
+						Env: []corev1.EnvVar{
+							secretEnv("OAUTH2_PROXY_CLIENT_ID", "client-id"),
+							secretEnv("OAUTH2_PROXY_CLIENT_SECRET", "client-secret"),
+							secretEnv("OAUTH2_PROXY_COOKIE_SECRET", "cookie-secret"),
+						},
+						Ports: []corev1.ContainerPort{
+							{Name: "http", ContainerPort: oauthProxyPort, Protocol: corev1.ProtocolTCP},
+						},
+						ReadinessProbe: &corev1.Probe{
+							ProbeHandler: corev1.ProbeHandler{
+								HTTPGet: &corev1.HTTPGetAction{Path: "/ping", Port: intstr.FromInt(oauthProxyPort)},
+							},
+							PeriodSeconds: 5,
+						},
+						Resources: corev1.ResourceRequirements{
+							Requests: corev1.ResourceList{
+								corev1.ResourceCPU:    resource.MustParse("10m"),
+								corev1.ResourceMemory: resource.MustParse("32Mi"),
+							},
+							Limits: corev1.ResourceList{
+								corev1.ResourceMemory: resource.MustParse("128Mi"),
+							},
+						},
+					}},
+				},
+			},
+		},
+	}
+	applyPodSecurity(&deployment.Spec.Template.Spec)
+	labelEnvironmentWorkload(env, deployment)
+	prioritizeEnvironmentWorkload(env, deployment)
+	return deployment
+}
+
+// desiredOAuthProxyService exposes oauth2-proxy to ingress-nginx
+func desiredOAuthProxyService(namespace string) *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      oauthProxyName,
+			Namespace: namespace,
+		},
+		Spec: corev1.ServiceSpec{
+			Selector: map[string]string{"app": oauthProxyName},
+			Ports: []corev1.ServicePort{
+				{
+					Name:       "http",
+					Port:       oauthProxyPort,
+					TargetPort: intstr.FromInt(oauthProxyPort),
+					Protocol:   corev1.ProtocolTCP,
+				},
+			},
+		},
+	}
+}
+
+// desiredOAuthProxyIngress routes /oauth2 (sign-in and callback) of the preview hosts to oauth2-proxy
+func desiredOAuthProxyIngress(env *catalystv1alpha1.Environment, namespace string, hosts []string, tls *previewTLS) *networkingv1.Ingress {
+	pathType := networkingv1.PathTypePrefix
+	ingress := &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      oauthProxyName,
+			Namespace: namespace,
+			Labels:    map[string]string{"catalyst.dev/environment": sanitizeLabelValue(env.Name)},
+		},
+	}
+	for _, host := range hosts {
+		ingress.Spec.Rules = append(ingress.Spec.Rules, networkingv1.IngressRule{
+			Host: host,
+			IngressRuleValue: networkingv1.IngressRuleValue{
+				HTTP: &networkingv1.HTTPIngressRuleValue{
+					Paths: []networkingv1.HTTPIngressPath{{
+						Path:     "/oauth2",
+						PathType: &pathType,
+						Backend: networkingv1.IngressBackend{
+							Service: &networkingv1.IngressServiceBackend{
+								Name: oauthProxyName,
+								Port: networkingv1.ServiceBackendPort{Number: oauthProxyPort},
+							},
+						},
+					}},
+				},
+			},
+		})
+	}
+	tls.applyTo(ingress)
+	return ingress
+}
+
+// refuseUnprotectedRouting fails an Environment whose spec.access Gateway routing cannot
+// enforce, after deleting the HTTPRoutes that would serve its hosts unprotected. It reports
+// whether the Environment was refused.
+func (r *EnvironmentReconciler) refuseUnprotectedRouting(ctx context.Context, env *catalystv1alpha1.Environment, namespace, routing string) (bool, error) {
+	access := accessType(env)
+	if access == catalystv1alpha1.AccessTypePublic || routing != routingGateway {
+		return false, nil
+	}
+	for _, name := range []string{"web", aliasIngressName, sharedRouteName} {
+		route := &unstructured.Unstructured{}
+		route.SetGroupVersionKind(httpRouteGVK)
+		route.SetName(name)
+		route.SetNamespace(namespace)
+		if err := r.Delete(ctx, route); err != nil && !apierrors.IsNotFound(err) && !meta.IsNoMatchError(err) {
+			return true, fmt.Errorf("failed to delete unprotected HTTPRoute %s: %w", name, err)
+		}
+	}
+	message := fmt.Sprintf("Access type %s requires Ingress routing; the preview hosts are not served", access)
+	meta.SetStatusCondition(&env.Status.Conditions, metav1.Condition{
+		Type:               conditionAccessProtected,
+		Status:             metav1.ConditionFalse,
+		Reason:             "UnsupportedRouting",
+		Message:            message,
+		ObservedGeneration: env.Generation,
+	})
+	return true, r.markFailed(ctx, env, catalystv1alpha1.FailureReasonConfigInvalid, errors.New(message))
+}
+
+// reconcileAccess provisions what spec.access needs besides the Ingress annotations (the
+// generated htpasswd Secret, or oauth2-proxy for the given preview hosts), removes what it
+// no longer needs and records the AccessProtected condition. Gateway routing never gets
+// here with a protected Environment (see refuseUnprotectedRouting).
+func (r *EnvironmentReconciler) reconcileAccess(ctx context.Context, env *catalystv1alpha1.Environment, namespace string, isLocal bool, hosts []string, tls *previewTLS, ingressSpec *catalystv1alpha1.EnvironmentIngress) error {
+	log := logf.FromContext(ctx)
+	access := accessType(env)
+	protected := access != catalystv1alpha1.AccessTypePublic
+
+	// Generated htpasswd Secret; its password is kept across reconciles
+	secretName, generated := basicAuthSecret(env)
+	if protected && access == catalystv1alpha1.AccessTypeBasicAuth && generated {
+		existing := &corev1.Secret{}
+		if err := r.Get(ctx, client.ObjectKey{Name: basicAuthSecretName, Namespace: namespace}, existing); apierrors.IsNotFound(err) {
+			secret, err := desiredBasicAuthSecret(namespace)
+			if err != nil {
+				return err
+			}
+			log.Info("Creating basic auth Secret", "namespace", namespace)
+			if err := r.Create(ctx, secret); err != nil && !isAlreadyExists(err) {
+				return fmt.Errorf("failed to create basic auth Secret: %w", err)
+			}
+		} else if err != nil {
+			return err
+		}
+	} else if access != catalystv1alpha1.AccessTypeBasicAuth || secretName != basicAuthSecretName {
+		// Keep a Secret of that name the spec references itself
+		secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: basicAuthSecretName, Namespace: namespace}}
+		if err := r.Delete(ctx, secret); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("failed to delete basic auth Secret: %w", err)
+		}
+	}
+
+	if protected && access == catalystv1alpha1.AccessTypeOAuthProxy {
+		// Admitted before the webhook checked it; the Ingress stays closed without oauth2-proxy
+		if err := accesspolicy.Validate(env.Spec.Access); err != nil {
+			return withFailureReason(catalystv1alpha1.FailureReasonConfigInvalid, err)
+		}
+		// Local previews served over HTTPS keep Secure cookies
+		if err := r.patchOrUpdate(ctx, desiredOAuthProxyDeployment(env, namespace, isLocal && tls == nil)); err != nil {
+			return fmt.Errorf("failed to reconcile oauth2-proxy Deployment: %w", err)
+		}
+		if err := r.Create(ctx, desiredOAuthProxyService(namespace)); err != nil && !isAlreadyExists(err) {
+			return fmt.Errorf("failed to create oauth2-proxy Service: %w", err)
+		}
+		ingress := desiredOAuthProxyIngress(env, namespace, hosts, tls)
+		r.applyIngressClass(ingress, ingressSpec)
+		if err := r.patchOrUpdate(ctx, ingress); err != nil {
+			return fmt.Errorf("failed to reconcile oauth2-proxy Ingress: %w", err)
+		}
+	} else {
+		for _, obj := range []client.Object{&networkingv1.Ingress{}, &corev1.Service{}, &appsv1.Deployment{}} {
+			obj.SetName(oauthProxyName)
+			obj.SetNamespace(namespace)
+			if err := r.Delete(ctx, obj); client.IgnoreNotFound(err) != nil {
+				return fmt.Errorf("failed to delete oauth2-proxy: %w", err)
+			}
+		}
+	}
+
+	changed := false
+	switch {
+	case access == catalystv1alpha1.AccessTypePublic:
+		changed = meta.RemoveStatusCondition(&env.Status.Conditions, conditionAccessProtected)
+	default:
+		changed = meta.SetStatusCondition(&env.Status.Conditions, metav1.Condition{
+			Type:               conditionAccessProtected,
+			Status:             metav1.ConditionTrue,
+			Reason:             "Protected",
+			Message:            fmt.Sprintf("Preview hosts require %s sign-in", access),
+			ObservedGeneration: env.Generation,
+		})
+	}
+	if changed {
+		return r.Status().Update(ctx, env)
+	}
+	return nil
+}
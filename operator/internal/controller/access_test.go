@@ -0,0 +1,148 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+func TestApplyAccess(t *testing.T) {
+	env := &catalystv1alpha1.Environment{
+		ObjectMeta: metav1.ObjectMeta{Name: "pr-1"},
+		Spec: catalystv1alpha1.EnvironmentSpec{
+			Access: &catalystv1alpha1.EnvironmentAccess{Type: catalystv1alpha1.AccessTypeBasicAuth},
+		},
+	}
+	ingress := desiredIngress(env, "env-ns", false, "preview.example.com")
+	applyAccess(env, ingress)
+	assert.Equal(t, "basic", ingress.Annotations[nginxAuthTypeAnnotation])
+	assert.Equal(t, basicAuthSecretName, ingress.Annotations[nginxAuthSecretAnnotation])
+
+	env.Spec.Access = &catalystv1alpha1.EnvironmentAccess{
+		Type:       catalystv1alpha1.AccessTypeOAuthProxy,
+		OAuthProxy: &catalystv1alpha1.OAuthProxyAccess{SecretName: "oauth"},
+	}
+	desired := desiredIngress(env, "env-ns", false, "preview.example.com")
+	applyAccess(env, desired)
+	assert.Equal(t, "http://oauth2-proxy.env-ns.svc.cluster.local:4180/oauth2/auth", desired.Annotations[nginxAuthURLAnnotation])
+
+	// Switching types replaces the annotations and keeps unrelated ones
+	ingress.Annotations["example.com/team"] = "a"
+	assert.True(t, syncIngressAnnotations(ingress, desired, accessAnnotations))
+	assert.NotContains(t, ingress.Annotations, nginxAuthTypeAnnotation)
+	assert.Equal(t, desired.Annotations[nginxAuthURLAnnotation], ingress.Annotations[nginxAuthURLAnnotation])
+	assert.Equal(t, "a", ingress.Annotations["example.com/team"])
+	assert.False(t, syncIngressAnnotations(ingress, desired, accessAnnotations))
+
+	public := &catalystv1alpha1.Environment{ObjectMeta: metav1.ObjectMeta{Name: "pr-1"}}
+	assert.True(t, syncIngressAnnotations(ingress, desiredIngress(public, "env-ns", false), accessAnnotations))
+	assert.Equal(t, map[string]string{"example.com/team": "a"}, ingress.Annotations)
+}
+
+func TestReconcileAccess(t *testing.T) {
+	env := &catalystv1alpha1.Environment{
+		ObjectMeta: metav1.ObjectMeta{Name: "pr-1", Namespace: "team"},
+		Spec: catalystv1alpha1.EnvironmentSpec{
+			Access: &catalystv1alpha1.EnvironmentAccess{Type: catalystv1alpha1.AccessTypeBasicAuth},
+		},
+	}
+	c := newFakeClientBuilder().WithStatusSubresource(env).WithObjects(env).Build()
+	r := &EnvironmentReconciler{Client: c, Scheme: testScheme}
+	ctx := context.Background()
+	hosts := []string{"pr-1.preview.example.com"}
+
+	// The generated password survives reconciles
+	require.NoError(t, r.reconcileAccess(ctx, env, "env-ns", false, hosts, nil, nil))
+	secret := &corev1.Secret{}
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: basicAuthSecretName, Namespace: "env-ns"}, secret))
+	assert.Regexp(t, `^preview:\{SSHA\}`, secret.StringData["auth"])
+	password := secret.StringData["password"]
+	require.NoError(t, r.reconcileAccess(ctx, env, "env-ns", false, hosts, nil, nil))
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(secret), secret))
+	assert.Equal(t, password, secret.StringData["password"])
+	assert.True(t, meta.IsStatusConditionTrue(env.Status.Conditions, conditionAccessProtected))
+
+	// oauth2-proxy is not deployed unless sign-in is restricted
+	env.Spec.Access = &catalystv1alpha1.EnvironmentAccess{
+		Type:       catalystv1alpha1.AccessTypeOAuthProxy,
+		OAuthProxy: &catalystv1alpha1.OAuthProxyAccess{SecretName: "oauth"},
+	}
+	err := r.reconcileAccess(ctx, env, "env-ns", false, hosts, nil, nil)
+	require.Error(t, err)
+	assert.Equal(t, catalystv1alpha1.FailureReasonConfigInvalid, failureReasonOf(err, ""))
+	assert.True(t, apierrors.IsNotFound(c.Get(ctx, client.ObjectKey{Name: oauthProxyName, Namespace: "env-ns"}, &appsv1.Deployment{})))
+
+	env.Spec.Access.OAuthProxy.EmailDomains = []string{"example.com"}
+	require.NoError(t, r.reconcileAccess(ctx, env, "env-ns", false, hosts, nil, nil))
+	assert.True(t, apierrors.IsNotFound(c.Get(ctx, client.ObjectKeyFromObject(secret), secret)))
+	deployment := &appsv1.Deployment{}
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: oauthProxyName, Namespace: "env-ns"}, deployment))
+	assert.Contains(t, deployment.Spec.Template.Spec.Containers[0].Args, "--email-domain=example.com")
+	ingress := &networkingv1.Ingress{}
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: oauthProxyName, Namespace: "env-ns"}, ingress))
+	assert.Equal(t, "/oauth2", ingress.Spec.Rules[0].HTTP.Paths[0].Path)
+	assert.Equal(t, hosts[0], ingress.Spec.Rules[0].Host)
+
+	env.Spec.Access = nil
+	require.NoError(t, r.reconcileAccess(ctx, env, "env-ns", false, hosts, nil, nil))
+	assert.Nil(t, meta.FindStatusCondition(env.Status.Conditions, conditionAccessProtected))
+}
+
+func TestDesiredOAuthProxyDeployment_EmailDomains(t *testing.T) {
+	env := &catalystv1alpha1.Environment{Spec: catalystv1alpha1.EnvironmentSpec{Access: &catalystv1alpha1.EnvironmentAccess{
+		Type:       catalystv1alpha1.AccessTypeOAuthProxy,
+		OAuthProxy: &catalystv1alpha1.OAuthProxyAccess{SecretName: "oauth", Args: []string{"--github-org=acme"}},
+	}}}
+	args := desiredOAuthProxyDeployment(env, "env-ns", false).Spec.Template.Spec.Containers[0].Args
+	assert.Contains(t, args, "--email-domain=*", "the organization restricts sign-in")
+
+	env.Spec.Access.OAuthProxy.Args = []string{"--email-domain=acme.dev"}
+	args = desiredOAuthProxyDeployment(env, "env-ns", false).Spec.Template.Spec.Containers[0].Args
+	assert.NotContains(t, args, "--email-domain=*")
+}
+
+func TestRefuseUnprotectedRouting(t *testing.T) {
+	env := &catalystv1alpha1.Environment{
+		ObjectMeta: metav1.ObjectMeta{Name: "pr-1", Namespace: "team"},
+		Spec: catalystv1alpha1.EnvironmentSpec{
+			Access: &catalystv1alpha1.EnvironmentAccess{Type: catalystv1alpha1.AccessTypeBasicAuth},
+		},
+	}
+	route := desiredHTTPRoute(env, "env-ns", "web", previewGateway{Name: "catalyst-preview"}, "pr-1.preview.example.com")
+	c := newFakeClientBuilder().WithStatusSubresource(env).WithObjects(env, route).Build()
+	r := &EnvironmentReconciler{Client: c, Scheme: testScheme}
+	ctx := context.Background()
+
+	refused, err := r.refuseUnprotectedRouting(ctx, env, "env-ns", routingIngress)
+	require.NoError(t, err)
+	assert.False(t, refused, "Ingress routing enforces spec.access")
+
+	refused, err = r.refuseUnprotectedRouting(ctx, env, "env-ns", routingGateway)
+	require.NoError(t, err)
+	assert.True(t, refused)
+	assert.Equal(t, "Failed", env.Status.Phase)
+	assert.Equal(t, catalystv1alpha1.FailureReasonConfigInvalid, env.Status.FailureReason)
+	condition := meta.FindStatusCondition(env.Status.Conditions, conditionAccessProtected)
+	require.NotNil(t, condition)
+	assert.Equal(t, "UnsupportedRouting", condition.Reason)
+	existing := &unstructured.Unstructured{}
+	existing.SetGroupVersionKind(httpRouteGVK)
+	assert.True(t, apierrors.IsNotFound(c.Get(ctx, client.ObjectKey{Name: "web", Namespace: "env-ns"}, existing)), "hosts are not served unprotected")
+
+	env.Spec.Access = nil
+	refused, err = r.refuseUnprotectedRouting(ctx, env, "env-ns", routingGateway)
+	require.NoError(t, err)
+	assert.False(t, refused)
+}
@@ -0,0 +1,208 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+// Environment aliases:
+// spec.alias adds a second Ingress ("web-alias") in the environment namespace whose host is
+// derived only from the alias, so the URL stays the same when the environment is deleted and
+// re-created for the same branch. A host already served by an Ingress in another namespace is
+// a collision: the first claimant keeps it and the alias is not routed until it is released.
+// With Gateway routing the alias is an HTTPRoute of the same name, and hosts claimed by
+// HTTPRoutes count as collisions too.
+
+const (
+	aliasIngressName = "web-alias"
+	// conditionAliasAvailable reports whether spec.alias is routed
+	conditionAliasAvailable = "AliasAvailable"
+)
+
+// aliasHost returns the Ingress host for an alias
+func aliasHost(alias string, isLocal bool, previewDomain string) string {
+	if isLocal {
+		return fmt.Sprintf("%s.localhost", alias)
+	}
+	if previewDomain == "" {
+		previewDomain = defaultPreviewDomain
+	}
+	return fmt.Sprintf("%s.%s", alias, previewDomain)
+}
+
+// hostURL returns the public URL for an Ingress host, mirroring generateURL. Local hosts are
+// served over HTTPS on the local TLS port when tls is set.
+func hostURL(host string, isLocal bool, ingressPort string, tls *previewTLS) string {
+	if isLocal && tls != nil {
+		return fmt.Sprintf("https://%s:%s/", host, tls.Port)
+	}
+	if isLocal {
+		if ingressPort == "" {
+			ingressPort = "8080"
+		}
+		return fmt.Sprintf("http://%s:%s/", host, ingressPort)
+	}
+	return fmt.Sprintf("https://%s/", host)
+}
+
+// desiredAliasIngress routes the alias host to the same backends (and wildcard TLS) as the canonical Ingress
+func desiredAliasIngress(env *catalystv1alpha1.Environment, namespace, host string, isLocal bool, tls *previewTLS, spec *catalystv1alpha1.EnvironmentIngress) *networkingv1.Ingress {
+	ingress := desiredIngress(env, namespace, isLocal)
+	ingress.Name = aliasIngressName
+	ingress.Labels = map[string]string{
+		"catalyst.dev/environment": sanitizeLabelValue(env.Name),
+		"catalyst.dev/alias":       env.Spec.Alias,
+	}
+	ingress.Spec.Rules[0].Host = host
+	tls.applyTo(ingress)
+	applyAccess(env, ingress)
+	applyDNS(ingress, currentDNSConfig())
+	applyIngressSpec(ingress, spec)
+	return ingress
+}
+
+// findHostConflict returns "Kind namespace/name" of an Ingress or HTTPRoute outside the
+// environment namespace that already serves host, or "" if the host is free.
+func (r *EnvironmentReconciler) findHostConflict(ctx context.Context, host, namespace string) (string, error) {
+	ingresses := &networkingv1.IngressList{}
+	if err := r.List(ctx, ingresses); err != nil {
+		return "", err
+	}
+	for _, ing := range ingresses.Items {
+		if ing.Namespace == namespace {
+			continue
+		}
+		for _, rule := range ing.Spec.Rules {
+			if rule.Host == host {
+				return "Ingress " + ing.Namespace + "/" + ing.Name, nil
+			}
+		}
+	}
+
+	routes := &unstructured.UnstructuredList{}
+	routes.SetGroupVersionKind(httpRouteGVK.GroupVersion().WithKind(httpRouteGVK.Kind + "List"))
+	if err := r.List(ctx, routes); err != nil {
+		if meta.IsNoMatchError(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	for _, route := range routes.Items {
+		if route.GetNamespace() == namespace {
+			continue
+		}
+		hostnames, _, _ := unstructured.NestedStringSlice(route.Object, "spec", "hostnames")
+		for _, h := range hostnames {
+			if h == host {
+				return "HTTPRoute " + route.GetNamespace() + "/" + route.GetName(), nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// deleteAliasRoute removes the alias Ingress, or the alias HTTPRoute with Gateway routing
+func (r *EnvironmentReconciler) deleteAliasRoute(ctx context.Context, namespace, routing string) error {
+	var existing client.Object = &networkingv1.Ingress{ObjectMeta: metav1.ObjectMeta{Name: aliasIngressName, Namespace: namespace}}
+	if routing == routingGateway {
+		route := &unstructured.Unstructured{}
+		route.SetGroupVersionKind(httpRouteGVK)
+		route.SetName(aliasIngressName)
+		route.SetNamespace(namespace)
+		existing = route
+	}
+	if err := r.Delete(ctx, existing); err != nil && !apierrors.IsNotFound(err) && !meta.IsNoMatchError(err) {
+		return err
+	}
+	return nil
+}
+
+// reconcileAlias creates, updates or removes the alias Ingress (or HTTPRoute) and records the AliasAvailable
+// condition. It returns the alias URL when the alias is routed, and whether it is blocked by a
+// collision (so the caller can retry once the host is released).
+func (r *EnvironmentReconciler) reconcileAlias(ctx context.Context, env *catalystv1alpha1.Environment, namespace string, isLocal bool, ingressPort, previewDomain, routing string, tls *previewTLS, ingressSpec *catalystv1alpha1.EnvironmentIngress) (string, bool, error) {
+	log := logf.FromContext(ctx)
+
+	if env.Spec.Alias == "" {
+		if err := r.deleteAliasRoute(ctx, namespace, routing); err != nil {
+			return "", false, fmt.Errorf("failed to delete alias route: %w", err)
+		}
+		if meta.RemoveStatusCondition(&env.Status.Conditions, conditionAliasAvailable) {
+			if err := r.Status().Update(ctx, env); err != nil {
+				return "", false, err
+			}
+		}
+		return "", false, nil
+	}
+
+	host := aliasHost(env.Spec.Alias, isLocal, previewDomain)
+	condition := metav1.Condition{
+		Type:               conditionAliasAvailable,
+		Status:             metav1.ConditionTrue,
+		Reason:             "Routed",
+		Message:            fmt.Sprintf("Alias host %s routes to this environment", host),
+		ObservedGeneration: env.Generation,
+	}
+
+	conflict, err := r.findHostConflict(ctx, host, namespace)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to check alias host: %w", err)
+	}
+	if conflict != "" {
+		log.Info("Alias host already in use", "host", host, "route", conflict)
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "HostConflict"
+		condition.Message = fmt.Sprintf("Alias host %s is already served by %s", host, conflict)
+		// Stop routing a host this environment no longer owns
+		if err := r.deleteAliasRoute(ctx, namespace, routing); err != nil {
+			return "", true, fmt.Errorf("failed to delete alias route: %w", err)
+		}
+	} else if routing == routingGateway {
+		route := desiredHTTPRoute(env, namespace, aliasIngressName, currentPreviewGateway(), host)
+		if err := r.patchOrUpdate(ctx, route); err != nil && !meta.IsNoMatchError(err) {
+			return "", false, fmt.Errorf("failed to reconcile alias HTTPRoute: %w", err)
+		}
+	} else {
+		ingress := desiredAliasIngress(env, namespace, host, isLocal, tls, ingressSpec)
+		r.applyIngressClass(ingress, ingressSpec)
+		if err := r.patchOrUpdate(ctx, ingress); err != nil {
+			return "", false, fmt.Errorf("failed to reconcile alias Ingress: %w", err)
+		}
+	}
+
+	if meta.SetStatusCondition(&env.Status.Conditions, condition) {
+		if err := r.Status().Update(ctx, env); err != nil {
+			return "", conflict != "", err
+		}
+	}
+	if conflict != "" {
+		return "", true, nil
+	}
+	return hostURL(host, isLocal, ingressPort, tls), false, nil
+}
@@ -0,0 +1,73 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+func TestAliasHostAndURL(t *testing.T) {
+	assert.Equal(t, "feature-login.preview.example.com", aliasHost("feature-login", false, "preview.example.com"))
+	assert.Equal(t, "feature-login.preview.catalyst.dev", aliasHost("feature-login", false, ""))
+	assert.Equal(t, "feature-login.localhost", aliasHost("feature-login", true, ""))
+
+	assert.Equal(t, "https://feature-login.preview.example.com/", hostURL("feature-login.preview.example.com", false, "", nil))
+	assert.Equal(t, "http://feature-login.localhost:8080/", hostURL("feature-login.localhost", true, "", nil))
+	assert.Equal(t, "https://feature-login.localhost:8443/", hostURL("feature-login.localhost", true, "8080", &previewTLS{Port: "8443"}))
+}
+
+func TestReconcileAlias(t *testing.T) {
+	env := &catalystv1alpha1.Environment{
+		ObjectMeta: metav1.ObjectMeta{Name: "pr-1", Namespace: "team"},
+		Spec:       catalystv1alpha1.EnvironmentSpec{Alias: "feature-login"},
+	}
+	c := newFakeClientBuilder().WithStatusSubresource(env).WithObjects(env).Build()
+	r := &EnvironmentReconciler{Client: c, Scheme: testScheme}
+	ctx := context.Background()
+
+	url, conflict, err := r.reconcileAlias(ctx, env, "env-ns", false, "", "preview.example.com", routingIngress, nil, nil)
+	require.NoError(t, err)
+	assert.False(t, conflict)
+	assert.Equal(t, "https://feature-login.preview.example.com/", url)
+	assert.True(t, meta.IsStatusConditionTrue(env.Status.Conditions, conditionAliasAvailable))
+
+	ingress := &networkingv1.Ingress{}
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: aliasIngressName, Namespace: "env-ns"}, ingress))
+	assert.Equal(t, "feature-login.preview.example.com", ingress.Spec.Rules[0].Host)
+
+	// A second environment claiming the same alias collides
+	other := &catalystv1alpha1.Environment{
+		ObjectMeta: metav1.ObjectMeta{Name: "pr-2", Namespace: "team"},
+		Spec:       catalystv1alpha1.EnvironmentSpec{Alias: "feature-login"},
+	}
+	require.NoError(t, c.Create(ctx, other))
+	url, conflict, err = r.reconcileAlias(ctx, other, "other-ns", false, "", "preview.example.com", routingIngress, nil, nil)
+	require.NoError(t, err)
+	assert.True(t, conflict)
+	assert.Empty(t, url)
+	cond := meta.FindStatusCondition(other.Status.Conditions, conditionAliasAvailable)
+	require.NotNil(t, cond)
+	assert.Equal(t, "HostConflict", cond.Reason)
+	assert.Contains(t, cond.Message, "env-ns/"+aliasIngressName)
+	err = c.Get(ctx, client.ObjectKey{Name: aliasIngressName, Namespace: "other-ns"}, &networkingv1.Ingress{})
+	assert.True(t, apierrors.IsNotFound(err))
+
+	// Clearing the alias removes the Ingress and the condition
+	env.Spec.Alias = ""
+	url, conflict, err = r.reconcileAlias(ctx, env, "env-ns", false, "", "preview.example.com", routingIngress, nil, nil)
+	require.NoError(t, err)
+	assert.False(t, conflict)
+	assert.Empty(t, url)
+	assert.Nil(t, meta.FindStatusCondition(env.Status.Conditions, conditionAliasAvailable))
+	err = c.Get(ctx, client.ObjectKey{Name: aliasIngressName, Namespace: "env-ns"}, &networkingv1.Ingress{})
+	assert.True(t, apierrors.IsNotFound(err))
+}
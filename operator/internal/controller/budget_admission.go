@@ -0,0 +1,90 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+	"github.com/ncrmro/catalyst/operator/internal/budget"
+)
+
+const (
+	// conditionAdmitted reports whether a new Environment fits the budget of its team
+	conditionAdmitted = "Admitted"
+	// budgetRetryInterval is how often Environments of teams with queueOverBudget retry
+	budgetRetryInterval = time.Minute
+)
+
+// admitToBudget admits an Environment whose namespace does not exist yet into the budget of
+// its Team. While it is over budget it returns false, with the delay to retry after when the
+// team queues. Teams without a Team resource or budget admit everything.
+func (r *EnvironmentReconciler) admitToBudget(ctx context.Context, env *catalystv1alpha1.Environment, teamName, namespace string) (bool, time.Duration, error) {
+	team := &catalystv1alpha1.Team{}
+	if err := r.Get(ctx, client.ObjectKey{Name: teamName}, team); err != nil {
+		if apierrors.IsNotFound(err) {
+			return true, 0, nil
+		}
+		return false, 0, err
+	}
+	if len(team.Spec.Budget) == 0 {
+		return true, 0, nil
+	}
+
+	reserved, err := budget.Reserved(ctx, r, sanitizeLabelValue(teamName), namespace)
+	if err != nil {
+		return false, 0, err
+	}
+	exceeded := budget.Exceeded(team.Spec.Budget, reserved, desiredResourceQuota(namespace).Spec.Hard)
+	condition := metav1.Condition{
+		Type:               conditionAdmitted,
+		Status:             metav1.ConditionTrue,
+		Reason:             "WithinBudget",
+		Message:            fmt.Sprintf("The quota fits the budget of team %s", teamName),
+		ObservedGeneration: env.Generation,
+	}
+	if len(exceeded) > 0 {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "QuotaExceeded"
+		condition.Message = fmt.Sprintf("The quota exceeds the budget of team %s: %s", teamName, strings.Join(exceeded, "; "))
+	}
+	changed := meta.SetStatusCondition(&env.Status.Conditions, condition)
+	if len(exceeded) > 0 && env.Status.Phase != phasePending {
+		env.Status.Phase = phasePending
+		changed = true
+	}
+	if changed {
+		if err := r.Status().Update(ctx, env); err != nil {
+			return false, 0, err
+		}
+	}
+	if len(exceeded) == 0 {
+		return true, 0, nil
+	}
+	if team.Spec.QueueOverBudget {
+		return false, budgetRetryInterval, nil
+	}
+	return false, 0, nil
+}
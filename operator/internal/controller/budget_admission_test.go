@@ -0,0 +1,65 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+func TestAdmitToBudget(t *testing.T) {
+	team := &catalystv1alpha1.Team{
+		ObjectMeta: metav1.ObjectMeta{Name: "acme"},
+		Spec: catalystv1alpha1.TeamSpec{
+			// Room for one environment with the default quota (4 CPU limits)
+			Budget:          corev1.ResourceList{corev1.ResourceLimitsCPU: resource.MustParse("6")},
+			QueueOverBudget: true,
+		},
+	}
+	existing := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:   "acme-shop-pr-1",
+		Labels: map[string]string{"catalyst.dev/team": "acme", "catalyst.dev/namespace-type": "environment"},
+	}}
+	env := &catalystv1alpha1.Environment{ObjectMeta: metav1.ObjectMeta{Name: "pr-2", Namespace: "acme-shop"}}
+	c := newFakeClientBuilder().WithStatusSubresource(env).
+		WithObjects(team, existing, desiredResourceQuota(existing.Name), env).Build()
+	r := &EnvironmentReconciler{Client: c, Scheme: testScheme}
+	ctx := context.Background()
+
+	admitted, retry, err := r.admitToBudget(ctx, env, "acme", "acme-shop-pr-2")
+	require.NoError(t, err)
+	assert.False(t, admitted)
+	assert.Equal(t, budgetRetryInterval, retry)
+	assert.Equal(t, phasePending, env.Status.Phase)
+	condition := meta.FindStatusCondition(env.Status.Conditions, conditionAdmitted)
+	require.NotNil(t, condition)
+	assert.Equal(t, "QuotaExceeded", condition.Reason)
+	assert.Contains(t, condition.Message, "limits.cpu: 4 reserved + 4 requested > 6")
+
+	// Without queueing the environment is not retried
+	team.Spec.QueueOverBudget = false
+	require.NoError(t, c.Update(ctx, team))
+	admitted, retry, err = r.admitToBudget(ctx, env, "acme", "acme-shop-pr-2")
+	require.NoError(t, err)
+	assert.False(t, admitted)
+	assert.Zero(t, retry)
+
+	// Capacity frees up once the other environment is gone
+	require.NoError(t, c.Delete(ctx, existing))
+	admitted, _, err = r.admitToBudget(ctx, env, "acme", "acme-shop-pr-2")
+	require.NoError(t, err)
+	assert.True(t, admitted)
+	assert.True(t, meta.IsStatusConditionTrue(env.Status.Conditions, conditionAdmitted))
+
+	// Teams without a Team resource are not budgeted
+	admitted, _, err = r.admitToBudget(ctx, env, "other", "other-app-pr-1")
+	require.NoError(t, err)
+	assert.True(t, admitted)
+}
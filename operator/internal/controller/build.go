@@ -33,7 +33,8 @@ func workspacePodName(env *catalystv1alpha1.Environment) string {
 	return fmt.Sprintf("workspace-%s-%s", env.Spec.ProjectRef.Name, strings.ToLower(commitPart))
 }
 
-func desiredWorkspacePod(env *catalystv1alpha1.Environment, namespace string) *corev1.Pod {
+// desiredWorkspacePod creates the workspace pod, following devContainer unless nil
+func desiredWorkspacePod(env *catalystv1alpha1.Environment, namespace string, devContainer *workspaceDevContainer) *corev1.Pod {
 	podName := workspacePodName(env)
 
 	commitSha := "latest"
@@ -41,7 +42,7 @@ func desiredWorkspacePod(env *catalystv1alpha1.Environment, namespace string) *c
 		commitSha = env.Spec.Sources[0].CommitSha
 	}
 
-	return &corev1.Pod{
+	pod := &corev1.Pod{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      podName,
 			Namespace: namespace,
@@ -49,7 +50,7 @@ func desiredWorkspacePod(env *catalystv1alpha1.Environment, namespace string) *c
 				"catalyst.dev/pod-type":    "workspace",
 				"catalyst.dev/commit":      commitSha,
 				"catalyst.dev/project":     env.Spec.ProjectRef.Name,
-				"catalyst.dev/environment": env.Name,
+				"catalyst.dev/environment": sanitizeLabelValue(env.Name),
 			},
 		},
 		Spec: corev1.PodSpec{
@@ -73,4 +74,10 @@ func desiredWorkspacePod(env *catalystv1alpha1.Environment, namespace string) *c
 			},
 		},
 	}
+	if devContainer != nil {
+		applyDevContainer(pod, devContainer)
+	}
+	applyPodSecurity(&pod.Spec)
+	prioritizeEnvironmentWorkload(env, pod)
+	return pod
 }
@@ -20,19 +20,26 @@ package controller
 import (
 	"context"
 	_ "embed"
+	"errors"
 	"fmt"
-	"os"
+	"path"
+	"slices"
 	"strings"
 
+	appsv1 "k8s.io/api/apps/v1"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 
 	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+	"github.com/ncrmro/catalyst/operator/internal/operatorconfig"
+	"github.com/ncrmro/catalyst/operator/internal/tracing"
 )
 
 const (
@@ -42,13 +49,17 @@ const (
 	registryInternal     = "registry.default.svc.cluster.local:5000"
 	gitScriptsConfigMap  = "catalyst-git-scripts"
 	gitScriptsVolumeName = "git-scripts"
+
+	// gitScriptsHashAnnotation holds the content hash of the git scripts, on the ConfigMap
+	// and on the pod templates mounting it
+	gitScriptsHashAnnotation = "catalyst.dev/git-scripts-hash"
 )
 
-// getCatalystWebURL returns the URL for the Catalyst web service.
-// It checks the CATALYST_WEB_URL environment variable first, falling back
+// CatalystWebURL returns the URL of the Catalyst web service.
+// It checks catalystWebURL of the operator configuration first, falling back
 // to the default in-cluster service URL in the catalyst-system namespace.
-func getCatalystWebURL() string {
-	if url := os.Getenv("CATALYST_WEB_URL"); url != "" {
+func CatalystWebURL() string {
+	if url := operatorconfig.Current().CatalystWebURL; url != "" {
 		return url
 	}
 	return "http://catalyst-web.catalyst-system.svc.cluster.local:3000"
@@ -63,83 +74,330 @@ var gitCredentialHelperScript string
 var gitCloneScript string
 
 // reconcileBuilds handles the build process for all defined builds in the template.
-func (r *EnvironmentReconciler) reconcileBuilds(ctx context.Context, env *catalystv1alpha1.Environment, project *catalystv1alpha1.Project, namespace string, template *catalystv1alpha1.EnvironmentTemplate) (map[string]string, error) {
+func (r *EnvironmentReconciler) reconcileBuilds(ctx context.Context, env *catalystv1alpha1.Environment, project *catalystv1alpha1.Project, namespace string, template *catalystv1alpha1.EnvironmentTemplateSpec) (_ map[string]string, err error) {
 	log := logf.FromContext(ctx)
+	ctx, span := startSpan(ctx, "reconcileBuilds", env)
+	defer func() { tracing.End(span, err) }()
 	builtImages := make(map[string]string)
 
 	// Ensure git scripts ConfigMap exists in the namespace
 	if err := r.ensureGitScriptsConfigMap(ctx, namespace); err != nil {
 		return nil, fmt.Errorf("failed to ensure git scripts ConfigMap: %w", err)
 	}
+	if err := r.ensureBuildSigner(ctx, project, namespace); err != nil {
+		return nil, err
+	}
+
+	limiter, err := r.newBuildLimiter(ctx, env, project)
+	if err != nil {
+		return nil, err
+	}
 
-	// Iterate over builds
+	// Launch every build at once (up to the limiter) and collect those that finished
+	var jobs []*batchv1.Job
+	var failures []string
+	builds := make([]catalystv1alpha1.BuildJobStatus, 0, len(template.Builds))
+	scans := make(map[string]*catalystv1alpha1.VulnerabilityCounts)
+	signatures := make(map[string]string)
+	promotion := activePromotion(env)
 	for _, build := range template.Builds {
-		imageTag, err := r.reconcileSingleBuild(ctx, env, project, namespace, build)
+		if promotion != nil {
+			// Promoted environments deploy the images of the promoted revision as they are
+			image := promotedImage(promotion, build.Name)
+			if image == nil {
+				return nil, fmt.Errorf("promoted revision %d of %s has no image for build %s", promotion.Revision, promotion.Source, build.Name)
+			}
+			builds = append(builds, catalystv1alpha1.BuildJobStatus{Name: build.Name, Phase: buildPhaseReused})
+			builtImages[build.Name] = image.Image + "@" + image.Digest
+			scans[build.Name] = image.Vulnerabilities
+			signatures[build.Name] = image.Signature
+			continue
+		}
+		imageTag, job, status, err := r.reconcileSingleBuild(ctx, env, project, namespace, build, limiter)
 		if err != nil {
 			return nil, err
 		}
+		if imageTag != "" && project.Spec.BuildSigning != nil {
+			signature, failure, err := r.reconcileBuildSigning(ctx, env, project, namespace, build, job, imageTag)
+			if err != nil {
+				return nil, err
+			}
+			switch {
+			case failure != "":
+				status.Phase, status.Message = buildPhaseFailed, failure
+				imageTag = ""
+			case signature == "" && job != nil:
+				status.Phase, status.Message = buildPhaseRunning, "signing"
+				imageTag = ""
+			}
+			signatures[build.Name] = signature
+		}
+		builds = append(builds, status)
+		if status.Phase == buildPhaseFailed {
+			failures = append(failures, status.Message)
+		}
 		if imageTag != "" {
 			builtImages[build.Name] = imageTag
+			jobs = append(jobs, job)
+			if project.Spec.BuildScan != nil {
+				if scans[build.Name], err = r.resolveBuildScan(ctx, env, namespace, job, build.Name, imageTag); err != nil {
+					return nil, err
+				}
+			}
 		}
 	}
 
 	// Check if all builds are ready
+	queuePosition := limiter.position()
 	if len(builtImages) < len(template.Builds) {
+		if !equality.Semantic.DeepEqual(env.Status.Builds, builds) || !equality.Semantic.DeepEqual(env.Status.QueuePosition, queuePosition) {
+			env.Status.Builds = builds
+			env.Status.QueuePosition = queuePosition
+			if err := r.Status().Update(ctx, env); err != nil {
+				return nil, err
+			}
+		}
+		if len(failures) > 0 {
+			return nil, withFailureReason(catalystv1alpha1.FailureReasonBuildFailed, errors.New(strings.Join(failures, "; ")))
+		}
 		log.Info("Waiting for builds to complete", "completed", len(builtImages), "total", len(template.Builds))
 		return nil, nil // Return nil to signal not ready (caller should requeue)
 	}
 
+	// Track build duration and the pushed images in status
+	statusChanged := false
+	if !equality.Semantic.DeepEqual(env.Status.Builds, builds) || env.Status.QueuePosition != nil {
+		env.Status.Builds = builds
+		env.Status.QueuePosition = nil
+		statusChanged = true
+	}
+	if duration := buildDuration(jobs); duration != nil {
+		if env.Status.BuildDuration == nil || env.Status.BuildDuration.Duration != duration.Duration {
+			env.Status.BuildDuration = duration
+			log.Info("Builds completed", "duration", duration.Duration.String())
+			recordEvent(r.Recorder, env, corev1.EventTypeNormal, eventBuildSucceeded, "Builds completed in %s", duration.Duration)
+			statusChanged = true
+		}
+	}
+	recorded := recordBuiltImages(template.Builds, builtImages)
+	for i, build := range template.Builds {
+		if promotion != nil {
+			recorded[i].Commit = promotedImage(promotion, build.Name).Commit
+		} else if commit, pinned := buildCommit(env, build); pinned {
+			recorded[i].Commit = commit
+		}
+		recorded[i].Vulnerabilities = scans[build.Name]
+		recorded[i].Signature = signatures[build.Name]
+	}
+	if !equality.Semantic.DeepEqual(env.Status.BuiltImages, recorded) {
+		env.Status.BuiltImages = recorded
+		statusChanged = true
+	}
+	var blocked []string
+	if scan := project.Spec.BuildScan; scan != nil {
+		var changed bool
+		changed, blocked = setBuildScannedCondition(env, scan, recorded)
+		statusChanged = statusChanged || changed
+	} else if meta.RemoveStatusCondition(&env.Status.Conditions, conditionBuildScanned) {
+		statusChanged = true
+	}
+	if len(blocked) == 0 {
+		if history, changed := recordDeployment(env.Status.DeploymentHistory, recorded, env.Status.TemplateHash, configHash(env.Spec.Config), metav1.Now().Rfc3339Copy()); changed {
+			env.Status.DeploymentHistory = r.pruneImageHistory(ctx, env, project, env.Status.DeploymentHistory, history)
+			statusChanged = true
+		}
+	}
+	if statusChanged {
+		if err := r.Status().Update(ctx, env); err != nil {
+			return nil, err
+		}
+	}
+	if len(blocked) > 0 {
+		return nil, fmt.Errorf("deploy blocked by build scan: critical vulnerabilities in %s", strings.Join(blocked, ", "))
+	}
+
 	return builtImages, nil
 }
 
-// ensureGitScriptsConfigMap creates or updates the ConfigMap containing git scripts
-func (r *EnvironmentReconciler) ensureGitScriptsConfigMap(ctx context.Context, namespace string) error {
-	configMap := &corev1.ConfigMap{}
-	err := r.Get(ctx, client.ObjectKey{Name: gitScriptsConfigMap, Namespace: namespace}, configMap)
+// buildDuration returns the wall-clock time from the earliest job start to the latest
+// job completion, or nil if timing is unavailable.
+func buildDuration(jobs []*batchv1.Job) *metav1.Duration {
+	var start, end *metav1.Time
+	for _, job := range jobs {
+		if job == nil || job.Status.StartTime == nil || job.Status.CompletionTime == nil {
+			return nil
+		}
+		if start == nil || job.Status.StartTime.Before(start) {
+			start = job.Status.StartTime
+		}
+		if end == nil || end.Before(job.Status.CompletionTime) {
+			end = job.Status.CompletionTime
+		}
+	}
+	if start == nil || end == nil {
+		return nil
+	}
+	return &metav1.Duration{Duration: end.Sub(start.Time)}
+}
+
+// recordBuiltImages converts built image references into status entries, in template order
+func recordBuiltImages(builds []catalystv1alpha1.BuildSpec, builtImages map[string]string) []catalystv1alpha1.BuiltImage {
+	recorded := make([]catalystv1alpha1.BuiltImage, 0, len(builds))
+	for _, build := range builds {
+		repository, tag, digest := parseImageRef(builtImages[build.Name])
+		recorded = append(recorded, catalystv1alpha1.BuiltImage{Name: build.Name, Image: repository + ":" + tag, Digest: digest})
+	}
+	return recorded
+}
+
+// resolveBuildDigest returns the digest of a succeeded build. Kaniko (skopeo for nix builds,
+// crane for multi-platform builds) writes it to the termination message of its container; once the pod is gone, the digest previously recorded
+// in status for the same image is reused. Empty if neither is available.
+func (r *EnvironmentReconciler) resolveBuildDigest(ctx context.Context, env *catalystv1alpha1.Environment, namespace, jobName, buildName, image string) (string, error) {
+	pods := &corev1.PodList{}
+	if err := r.List(ctx, pods, client.InNamespace(namespace), client.MatchingLabels{batchv1.JobNameLabel: jobName}); err != nil {
+		return "", err
+	}
+	for i := range pods.Items {
+		// kaniko is an init container when the build is scanned
+		for _, container := range []string{"kaniko", "skopeo", buildIndexContainer} {
+			if digest, ok := containerTerminationMessage(&pods.Items[i], container); ok && strings.HasPrefix(digest, "sha256:") {
+				return digest, nil
+			}
+		}
+	}
+
+	for _, built := range env.Status.BuiltImages {
+		if built.Name == buildName && built.Image == image {
+			return built.Digest, nil
+		}
+	}
+	return "", nil
+}
 
-	desiredData := map[string]string{
+// resolveBuildCache fills in the default cache repository for a project's build cache.
+// Returns nil if the project has no build cache configured.
+func resolveBuildCache(project *catalystv1alpha1.Project, registry RegistryConfig) *catalystv1alpha1.BuildCacheSpec {
+	if project.Spec.BuildCache == nil {
+		return nil
+	}
+	cache := project.Spec.BuildCache.DeepCopy()
+	if cache.Repository == "" {
+		cache.Repository = fmt.Sprintf("%s/%s/cache", registry.Endpoint, strings.ToLower(project.Name))
+	}
+	return cache
+}
+
+// gitScriptsData is the content of the git scripts ConfigMap, embedded in the binary
+func gitScriptsData() map[string]string {
+	return map[string]string{
 		"git-credential-catalyst.sh": gitCredentialHelperScript,
 		"git-clone.sh":               gitCloneScript,
 	}
+}
 
-	if err != nil {
+// gitScriptsHash returns the content hash of the git scripts, recorded on the ConfigMap and
+// on the pod templates mounting it
+func gitScriptsHash() string {
+	data := map[string][]byte{}
+	for key, value := range gitScriptsData() {
+		data[key] = []byte(value)
+	}
+	return secretsHash(data)
+}
+
+// ensureGitScriptsConfigMap creates the ConfigMap containing the git scripts, and updates it
+// when its hash, or content edited in the cluster, differs from the scripts of this binary
+func (r *EnvironmentReconciler) ensureGitScriptsConfigMap(ctx context.Context, namespace string) error {
+	return ensureGitScripts(ctx, r.Client, namespace)
+}
+
+// ensureGitScripts implements ensureGitScriptsConfigMap for any namespace git-clone runs in
+func ensureGitScripts(ctx context.Context, c client.Client, namespace string) error {
+	desired := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        gitScriptsConfigMap,
+			Namespace:   namespace,
+			Labels:      map[string]string{"catalyst.dev/component": "git-scripts"},
+			Annotations: map[string]string{gitScriptsHashAnnotation: gitScriptsHash()},
+		},
+		Data: gitScriptsData(),
+	}
+
+	configMap := &corev1.ConfigMap{}
+	if err := c.Get(ctx, client.ObjectKeyFromObject(desired), configMap); err != nil {
 		if apierrors.IsNotFound(err) {
-			// Create the ConfigMap
-			configMap = &corev1.ConfigMap{
-				ObjectMeta: metav1.ObjectMeta{
-					Name:      gitScriptsConfigMap,
-					Namespace: namespace,
-					Labels: map[string]string{
-						"catalyst.dev/component": "git-scripts",
-					},
-				},
-				Data: desiredData,
-			}
-			return r.Create(ctx, configMap)
+			return c.Create(ctx, desired)
 		}
 		return err
 	}
+	if configMap.Annotations[gitScriptsHashAnnotation] == desired.Annotations[gitScriptsHashAnnotation] &&
+		equality.Semantic.DeepEqual(configMap.Data, desired.Data) {
+		return nil
+	}
+	logf.FromContext(ctx).Info("Updating git scripts", "namespace", namespace)
+	if configMap.Annotations == nil {
+		configMap.Annotations = map[string]string{}
+	}
+	configMap.Annotations[gitScriptsHashAnnotation] = desired.Annotations[gitScriptsHashAnnotation]
+	configMap.Data = desired.Data
+	return c.Update(ctx, configMap)
+}
 
-	// ConfigMap exists - check if it needs update
-	needsUpdate := false
-	for key, value := range desiredData {
-		if configMap.Data[key] != value {
-			needsUpdate = true
-			break
+// setGitScriptsHash records the git scripts hash on a pod template that mounts them
+func setGitScriptsHash(template *corev1.PodTemplateSpec) {
+	if !mountsGitScripts(template) {
+		return
+	}
+	if template.Annotations == nil {
+		template.Annotations = map[string]string{}
+	}
+	template.Annotations[gitScriptsHashAnnotation] = gitScriptsHash()
+}
+
+// mountsGitScripts reports whether a pod template has the git scripts volume
+func mountsGitScripts(template *corev1.PodTemplateSpec) bool {
+	for _, volume := range template.Spec.Volumes {
+		if volume.ConfigMap != nil && volume.ConfigMap.Name == gitScriptsConfigMap {
+			return true
 		}
 	}
+	return false
+}
 
-	if needsUpdate {
-		configMap.Data = desiredData
-		return r.Update(ctx, configMap)
+// rolloutGitScriptsHash updates the git scripts hash on an existing Deployment's pod template,
+// restarting its pods so their git-clone init containers run the current scripts
+func (r *EnvironmentReconciler) rolloutGitScriptsHash(ctx context.Context, namespace, name string) error {
+	deployment := &appsv1.Deployment{}
+	if err := r.Get(ctx, client.ObjectKey{Name: name, Namespace: namespace}, deployment); err != nil {
+		return client.IgnoreNotFound(err)
 	}
+	if !mountsGitScripts(&deployment.Spec.Template) ||
+		deployment.Spec.Template.Annotations[gitScriptsHashAnnotation] == gitScriptsHash() {
+		return nil
+	}
+	logf.FromContext(ctx).Info("Restarting Deployment for changed git scripts", "namespace", namespace, "deployment", name)
+	patch := client.MergeFrom(deployment.DeepCopy())
+	setGitScriptsHash(&deployment.Spec.Template)
+	return r.Patch(ctx, deployment, patch)
+}
 
-	return nil
+// buildImageRef returns the image a build of commit is pushed to
+func buildImageRef(registry RegistryConfig, project *catalystv1alpha1.Project, env *catalystv1alpha1.Environment, build, commit string) string {
+	// Sanitize commit for use as Docker tag (no slashes, colons, or other invalid chars)
+	sanitizedCommit := strings.ReplaceAll(commit, "/", "-")
+	sanitizedCommit = strings.ReplaceAll(sanitizedCommit, ":", "-")
+	sanitizedCommit = strings.ReplaceAll(sanitizedCommit, " ", "-")
+	return registry.imageRef(project.Name, build, env.Name, sanitizedCommit)
 }
 
 // reconcileSingleBuild manages the build job for a single artifact.
-func (r *EnvironmentReconciler) reconcileSingleBuild(ctx context.Context, env *catalystv1alpha1.Environment, project *catalystv1alpha1.Project, namespace string, build catalystv1alpha1.BuildSpec) (string, error) {
+// Returns the image reference and the completed Job once the build succeeded; the reference
+// is pinned to the pushed digest (repo:tag@sha256:...) when it could be resolved. New Jobs
+// are only created while the limiter has slots; the returned status reports the progress.
+func (r *EnvironmentReconciler) reconcileSingleBuild(ctx context.Context, env *catalystv1alpha1.Environment, project *catalystv1alpha1.Project, namespace string, build catalystv1alpha1.BuildSpec, limiter *buildLimiter) (string, *batchv1.Job, catalystv1alpha1.BuildJobStatus, error) {
 	log := logf.FromContext(ctx)
+	status := catalystv1alpha1.BuildJobStatus{Name: build.Name}
 
 	// Determine Source Config
 	var sourceConfig *catalystv1alpha1.SourceConfig
@@ -150,40 +408,36 @@ func (r *EnvironmentReconciler) reconcileSingleBuild(ctx context.Context, env *c
 		}
 	}
 	if sourceConfig == nil {
-		return "", fmt.Errorf("source ref '%s' not found in project", build.SourceRef)
+		return "", nil, status, fmt.Errorf("source ref '%s' not found in project", build.SourceRef)
 	}
 
 	// Determine Commit/Branch
-	var commit string
-	for _, s := range env.Spec.Sources {
-		if s.Name == build.SourceRef {
-			if s.CommitSha != "" && s.CommitSha != "HEAD" {
-				commit = s.CommitSha
-			} else if s.Branch != "" {
-				commit = s.Branch
-			}
-			break
+	commit, pinned := buildCommit(env, build)
+
+	// Time travel: a commit deployed before is redeployed by digest without rebuilding
+	if pinned {
+		if past := historicalImage(env.Status.DeploymentHistory, build.Name, commit); past != nil {
+			log.Info("Reusing image built for commit", "build", build.Name, "commit", commit, "image", past.Image, "digest", past.Digest)
+			status.Phase = buildPhaseReused
+			return past.Image + "@" + past.Digest, nil, status, nil
 		}
 	}
-	if commit == "" {
-		commit = "latest" // Fallback
-	}
 
-	// Check if registry secret exists (for pushing)
-	hasRegistrySecret := false
+	registry := currentRegistryConfig()
+
+	// Check if registry secret exists (for pushing, and pulling private base images)
+	pushSecret := ""
+	credentialsSecret := registry.SecretName
+	if len(project.Spec.ImagePullSecrets) > 0 {
+		credentialsSecret = buildCredentialsSecretName
+	}
 	secret := &corev1.Secret{}
-	if err := r.Get(ctx, client.ObjectKey{Name: registrySecretName, Namespace: namespace}, secret); err == nil {
-		hasRegistrySecret = true
+	if err := r.Get(ctx, client.ObjectKey{Name: credentialsSecret, Namespace: namespace}, secret); err == nil {
+		pushSecret = credentialsSecret
 	}
 
 	// Image Tag
-	// Sanitize commit for use as Docker tag (no slashes, colons, or other invalid chars)
-	sanitizedCommit := strings.ReplaceAll(commit, "/", "-")
-	sanitizedCommit = strings.ReplaceAll(sanitizedCommit, ":", "-")
-	sanitizedCommit = strings.ReplaceAll(sanitizedCommit, " ", "-")
-
-	imageName := fmt.Sprintf("%s/%s-%s", project.Name, build.Name, env.Name)
-	imageTag := fmt.Sprintf("%s/%s:%s", registryInternal, imageName, sanitizedCommit)
+	imageTag := buildImageRef(registry, project, env, build.Name, commit)
 
 	// Job Name
 	// Use first 7 chars if it looks like a SHA, otherwise use sanitized branch name
@@ -198,39 +452,122 @@ func (r *EnvironmentReconciler) reconcileSingleBuild(ctx context.Context, env *c
 	jobName = strings.ToLower(jobName)
 
 	// Check if Job exists
+	status.JobName = jobName
 	job := &batchv1.Job{}
 	err := r.Get(ctx, client.ObjectKey{Name: jobName, Namespace: namespace}, job)
 	if err != nil {
 		if apierrors.IsNotFound(err) {
+			// Monorepo builds skip commits that leave their paths untouched
+			if pinned && hasPathFilter(build) {
+				if previous := r.reusableBuildImage(ctx, env, project, sourceConfig, build, commit); previous != nil {
+					return previous.Image + "@" + previous.Digest, nil, catalystv1alpha1.BuildJobStatus{Name: build.Name, Phase: buildPhaseReused}, nil
+				}
+			}
+
+			if err := checkBuildArgSecrets(build.BuildArgs); err != nil {
+				return "", nil, status, err
+			}
+
 			// Validate githubInstallationId is set before creating Job
 			// For private repos, this is required for the credential helper to work
-			if project.Spec.GitHubInstallationId == "" {
-				return "", fmt.Errorf("project.spec.githubInstallationId is required for builds but is not set")
+			installation := usesInstallationToken(sourceConfig)
+			if installation && project.Spec.GitHubInstallationId == "" {
+				return "", nil, status, fmt.Errorf("project.spec.githubInstallationId is required for builds but is not set")
 			}
 
 			// Create Job
-			log.Info("Creating Build Job", "job", jobName, "image", imageTag, "installationId", project.Spec.GitHubInstallationId)
-			job = desiredBuildJob(jobName, namespace, imageTag, sourceConfig.RepositoryURL, commit, project.Spec.GitHubInstallationId, build, hasRegistrySecret)
+			job = desiredBuildJob(jobName, namespace, imageTag, sourceConfig.RepositoryURL, commit, append(gitCloneCredentialEnv(project, sourceConfig), gitCheckoutEnv(sourceConfig)...), build, pushSecret, registry.Insecure, resolveBuildCache(project, registry), project.Spec.BuildScan, project.Spec.BuildSigning)
+			applyRegistryMirrors(&job.Spec.Template.Spec, registry)
+			applyRegistryCA(&job.Spec.Template.Spec, registry)
+			job.Labels[buildProjectLabel] = string(project.UID)
+			if installation {
+				job.Labels["catalyst.dev/github-installation-id"] = project.Spec.GitHubInstallationId
+			}
+			labelEnvironmentWorkload(env, job)
+			prioritizeEnvironmentWorkload(env, job)
+
+			// Builds yield to the primary workload when the namespace quota is nearly full
+			status.Phase = buildPhaseQueued
+			if deferred, err := r.deferForQuota(ctx, env, namespace, "build "+build.Name, &job.Spec.Template.Spec); err != nil || deferred {
+				status.Message = "waiting for namespace quota"
+				return "", nil, status, err
+			}
+			queuedAt := buildQueuedAt(env, build.Name, jobName)
+			if ok, position, reason := limiter.take(build.Name, queuedAt.Time); !ok {
+				status.Message = reason
+				status.QueuedAt = queuedAt
+				limiter.queued(position)
+				return "", nil, status, nil
+			}
+
+			log.Info("Creating Build Job", "job", jobName, "image", imageTag, "provider", gitProvider(sourceConfig))
 			if err := r.Create(ctx, job); err != nil {
-				return "", err
+				return "", nil, status, err
 			}
-			return "", nil // Job started
+			recordEvent(r.Recorder, env, corev1.EventTypeNormal, eventBuildStarted, "Started build %s of %s", build.Name, imageTag)
+			status.Phase = buildPhaseRunning
+			return "", nil, status, nil // Job started
 		}
-		return "", err
+		return "", nil, status, err
 	}
 
 	// Check Job Status
+	observeBuildJob(job)
 	if job.Status.Succeeded > 0 {
-		return imageTag, nil
+		status.Phase = buildPhaseSucceeded
+		digest, err := r.resolveBuildDigest(ctx, env, namespace, jobName, build.Name, imageTag)
+		if err != nil {
+			return "", nil, status, err
+		}
+		if digest != "" {
+			return imageTag + "@" + digest, job, status, nil
+		}
+		return imageTag, job, status, nil
 	}
 	if job.Status.Failed > 0 {
-		return "", fmt.Errorf("build job failed: %s", jobName)
+		status.Phase = buildPhaseFailed
+		status.Message = fmt.Sprintf("build job failed: %s", jobName)
+		return "", nil, status, nil
 	}
 
-	return "", nil // Job running
+	status.Phase = buildPhaseRunning
+	return "", nil, status, nil // Job running
+}
+
+// checkBuildArgSecrets rejects build args reading Secrets the operator config does not allow:
+// their values are recorded in the history of the pushed image
+func checkBuildArgSecrets(args []catalystv1alpha1.BuildArg) error {
+	allowed := operatorconfig.Current().Builds.BuildArgSecrets
+	if len(allowed) == 0 {
+		allowed = []string{catalystSecretsName}
+	}
+	for _, arg := range args {
+		if arg.SecretKeyRef != nil && !slices.Contains(allowed, arg.SecretKeyRef.Name) {
+			return withFailureReason(catalystv1alpha1.FailureReasonConfigInvalid,
+				fmt.Errorf("build arg %s reads Secret %s, build args may only read %s", arg.Name, arg.SecretKeyRef.Name, strings.Join(allowed, ", ")))
+		}
+	}
+	return nil
 }
 
-func desiredBuildJob(name, namespace, destination, repoURL, commit, githubInstallationId string, build catalystv1alpha1.BuildSpec, hasRegistrySecret bool) *batchv1.Job {
+// buildArgs passes the build args to kaniko through its environment, so Secret values stay
+// out of the Job spec
+func buildArgs(args []catalystv1alpha1.BuildArg) ([]corev1.EnvVar, []string) {
+	var env []corev1.EnvVar
+	var flags []string
+	for _, arg := range args {
+		name := "BUILD_ARG_" + arg.Name
+		variable := corev1.EnvVar{Name: name, Value: strings.ReplaceAll(arg.Value, "$", "$$")}
+		if arg.SecretKeyRef != nil {
+			variable = corev1.EnvVar{Name: name, ValueFrom: &corev1.EnvVarSource{SecretKeyRef: arg.SecretKeyRef}}
+		}
+		env = append(env, variable)
+		flags = append(flags, "--build-arg="+arg.Name+"=$("+name+")")
+	}
+	return env, flags
+}
+
+func desiredBuildJob(name, namespace, destination, repoURL, commit string, credentialEnv []corev1.EnvVar, build catalystv1alpha1.BuildSpec, pushSecret string, insecure bool, cache *catalystv1alpha1.BuildCacheSpec, scan *catalystv1alpha1.BuildScanSpec, signing *catalystv1alpha1.BuildSigningSpec) *batchv1.Job {
 	backoff := int32(0)
 	defaultMode := int32(0755) // Make scripts executable
 
@@ -253,12 +590,12 @@ func desiredBuildJob(name, namespace, destination, repoURL, commit, githubInstal
 
 	kanikoVolumeMounts := []corev1.VolumeMount{workspaceVolume}
 
-	if hasRegistrySecret {
+	if pushSecret != "" {
 		volumes = append(volumes, corev1.Volume{
 			Name: "registry-creds",
 			VolumeSource: corev1.VolumeSource{
 				Secret: &corev1.SecretVolumeSource{
-					SecretName: registrySecretName,
+					SecretName: pushSecret,
 					Items: []corev1.KeyToPath{
 						{Key: ".dockerconfigjson", Path: "config.json"},
 					},
@@ -292,15 +629,46 @@ func desiredBuildJob(name, namespace, destination, repoURL, commit, githubInstal
 	if build.Resources != nil {
 		resources = *build.Resources
 	}
+	if build.PodOverrides != nil && build.PodOverrides.Resources != nil {
+		resources = *build.PodOverrides.Resources
+	}
+
+	dockerfile := build.Dockerfile
+	if dockerfile == "" {
+		dockerfile = "Dockerfile"
+	}
+	kanikoArgs := []string{
+		"--dockerfile=" + path.Join(workdir, dockerfile),
+		"--context=dir://" + workdir,
+		"--destination=" + destination,
+		"--cache=true",
+		// The pushed digest is read back from the termination message
+		"--digest-file=" + corev1.TerminationMessagePathDefault,
+	}
+	if insecure {
+		// Plain-HTTP registries (e.g. the in-cluster registry without TLS)
+		kanikoArgs = append(kanikoArgs, "--insecure")
+	}
+	if build.Target != "" {
+		kanikoArgs = append(kanikoArgs, "--target="+build.Target)
+	}
+	buildArgEnv, buildArgFlags := buildArgs(build.BuildArgs)
+	kanikoArgs = append(kanikoArgs, buildArgFlags...)
+	if cache != nil {
+		// Shared layer cache across builds of the project
+		kanikoArgs = append(kanikoArgs, "--cache-repo="+cache.Repository)
+		if cache.TTL != nil {
+			kanikoArgs = append(kanikoArgs, "--cache-ttl="+cache.TTL.Duration.String())
+		}
+	}
 
-	return &batchv1.Job{
+	job := &batchv1.Job{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      name,
 			Namespace: namespace,
 			Labels: map[string]string{
-				"catalyst.dev/job-type":               "build",
-				"catalyst.dev/build":                  build.Name,
-				"catalyst.dev/github-installation-id": githubInstallationId,
+				"catalyst.dev/job-type": "build",
+				"catalyst.dev/build":    build.Name,
 			},
 		},
 		Spec: batchv1.JobSpec{
@@ -315,14 +683,12 @@ func desiredBuildJob(name, namespace, destination, repoURL, commit, githubInstal
 							Name:    "git-clone",
 							Image:   gitCloneImage,
 							Command: []string{"/scripts/git-clone.sh"},
-							Env: []corev1.EnvVar{
-								{Name: "INSTALLATION_ID", Value: githubInstallationId},
-								{Name: "CATALYST_WEB_URL", Value: getCatalystWebURL()},
-								{Name: "GIT_REPO_URL", Value: repoURL},
-								{Name: "GIT_COMMIT", Value: commit},
-								{Name: "GIT_CLONE_ROOT", Value: gitCloneRoot},
-								{Name: "GIT_CLONE_DEST", Value: gitCloneDest},
-							},
+							Env: append(append([]corev1.EnvVar{}, credentialEnv...),
+								corev1.EnvVar{Name: "GIT_REPO_URL", Value: repoURL},
+								corev1.EnvVar{Name: "GIT_COMMIT", Value: commit},
+								corev1.EnvVar{Name: "GIT_CLONE_ROOT", Value: gitCloneRoot},
+								corev1.EnvVar{Name: "GIT_CLONE_DEST", Value: gitCloneDest},
+							),
 							VolumeMounts: []corev1.VolumeMount{workspaceVolume, scriptsVolume},
 							Resources:    resources,
 						},
@@ -330,21 +696,38 @@ func desiredBuildJob(name, namespace, destination, repoURL, commit, githubInstal
 					Containers: []corev1.Container{
 						// Kaniko Build
 						{
-							Name:  "kaniko",
-							Image: kanikoImage,
-							Args: []string{
-								"--dockerfile=Dockerfile",
-								"--context=dir://" + workdir,
-								"--destination=" + destination,
-								"--insecure", // Still needed for internal registry if not TLS
-								"--cache=true",
-							},
+							Name:         "kaniko",
+							Image:        kanikoImage,
+							Args:         kanikoArgs,
+							Env:          buildArgEnv,
 							Resources:    resources,
 							VolumeMounts: kanikoVolumeMounts,
+							// Kaniko unpacks the base image into its own root filesystem
+							SecurityContext: &corev1.SecurityContext{ReadOnlyRootFilesystem: ptr(false)},
 						},
 					},
 				},
 			},
 		},
 	}
+	if build.BuildStrategy == buildStrategyNix {
+		applyNixBuild(&job.Spec.Template.Spec, build, workdir, destination, pushSecret != "", insecure)
+	}
+	if len(build.Platforms) > 0 && build.BuildStrategy != buildStrategyNix {
+		applyBuildPlatforms(&job.Spec.Template.Spec, build.Platforms, destination, pushSecret != "", insecure)
+	}
+	if scan != nil {
+		applyBuildScan(&job.Spec.Template.Spec, scan, pushSecret != "", insecure)
+	}
+	if signing != nil {
+		definition := dockerfile
+		if build.BuildStrategy == buildStrategyNix {
+			definition = nixFlakeFile
+		}
+		applyBuildSource(&job.Spec.Template.Spec, path.Join(workdir, definition))
+	}
+	applyPodSecurity(&job.Spec.Template.Spec)
+	// Keep builds off the nodes of the workloads they would otherwise evict
+	applyBuildScheduling(&job.Spec.Template.Spec, currentBuildScheduling(), build.PodOverrides)
+	return job
 }
@@ -0,0 +1,188 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+	"github.com/ncrmro/catalyst/operator/internal/operatorconfig"
+)
+
+func completedJob(start time.Time, d time.Duration) *batchv1.Job {
+	return &batchv1.Job{
+		Status: batchv1.JobStatus{
+			StartTime:      &metav1.Time{Time: start},
+			CompletionTime: &metav1.Time{Time: start.Add(d)},
+		},
+	}
+}
+
+func TestBuildDuration(t *testing.T) {
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	// Parallel builds: earliest start to latest completion
+	duration := buildDuration([]*batchv1.Job{
+		completedJob(start, 3*time.Minute),
+		completedJob(start.Add(time.Minute), 4*time.Minute),
+	})
+	assert.Equal(t, 5*time.Minute, duration.Duration)
+
+	assert.Nil(t, buildDuration(nil))
+	assert.Nil(t, buildDuration([]*batchv1.Job{{}}))
+}
+
+func TestDesiredBuildJob_Cache(t *testing.T) {
+	project := &catalystv1alpha1.Project{
+		ObjectMeta: metav1.ObjectMeta{Name: "catalyst"},
+		Spec: catalystv1alpha1.ProjectSpec{
+			BuildCache: &catalystv1alpha1.BuildCacheSpec{TTL: &metav1.Duration{Duration: 168 * time.Hour}},
+		},
+	}
+	cache := resolveBuildCache(project, RegistryConfig{Endpoint: "ghcr.io/acme"})
+	assert.Equal(t, "ghcr.io/acme/catalyst/cache", cache.Repository)
+
+	job := desiredBuildJob("build-web", "ns", "ghcr.io/acme/web:1", "https://github.com/acme/app", "main", nil, catalystv1alpha1.BuildSpec{Name: "web"}, "", false, cache, nil, nil)
+	args := job.Spec.Template.Spec.Containers[0].Args
+	assert.Contains(t, args, "--cache-repo=ghcr.io/acme/catalyst/cache")
+	assert.Contains(t, args, "--cache-ttl=168h0m0s")
+	assert.Contains(t, args, "--digest-file=/dev/termination-log")
+
+	assert.Nil(t, resolveBuildCache(&catalystv1alpha1.Project{}, RegistryConfig{}))
+}
+
+func TestResolveBuildDigest(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "build-web-abc1234-x", Namespace: "env-ns", Labels: map[string]string{batchv1.JobNameLabel: "build-web-abc1234"}},
+		Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{
+			Name:  "kaniko",
+			State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 0, Message: "sha256:4f2a9d\n"}},
+		}}},
+	}
+	env := &catalystv1alpha1.Environment{
+		Status: catalystv1alpha1.EnvironmentStatus{BuiltImages: []catalystv1alpha1.BuiltImage{
+			{Name: "api", Image: "registry/acme/api:abc1234", Digest: "sha256:77e0b1"},
+		}},
+	}
+	r := &EnvironmentReconciler{Client: newFakeClientBuilder().WithObjects(pod).Build(), Scheme: testScheme}
+	ctx := context.Background()
+
+	// From the kaniko termination message
+	digest, err := r.resolveBuildDigest(ctx, env, "env-ns", "build-web-abc1234", "web", "registry/acme/web:abc1234")
+	require.NoError(t, err)
+	assert.Equal(t, "sha256:4f2a9d", digest)
+
+	// Pod gone: reuse the digest recorded for the same image
+	digest, err = r.resolveBuildDigest(ctx, env, "env-ns", "build-api-abc1234", "api", "registry/acme/api:abc1234")
+	require.NoError(t, err)
+	assert.Equal(t, "sha256:77e0b1", digest)
+
+	// A rebuilt tag doesn't inherit a stale digest
+	digest, err = r.resolveBuildDigest(ctx, env, "env-ns", "build-api-def5678", "api", "registry/acme/api:def5678")
+	require.NoError(t, err)
+	assert.Empty(t, digest)
+}
+
+func TestRecordBuiltImages(t *testing.T) {
+	builds := []catalystv1alpha1.BuildSpec{{Name: "web"}, {Name: "api"}}
+	recorded := recordBuiltImages(builds, map[string]string{
+		"web": "registry.local:5000/acme/web:abc1234@sha256:4f2a9d",
+		"api": "registry.local:5000/acme/api:abc1234",
+	})
+	assert.Equal(t, []catalystv1alpha1.BuiltImage{
+		{Name: "web", Image: "registry.local:5000/acme/web:abc1234", Digest: "sha256:4f2a9d"},
+		{Name: "api", Image: "registry.local:5000/acme/api:abc1234"},
+	}, recorded)
+}
+
+func TestEnsureGitScriptsConfigMap(t *testing.T) {
+	// A ConfigMap written by an older operator with outdated scripts
+	stale := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: gitScriptsConfigMap, Namespace: "env-ns"},
+		Data:       map[string]string{"git-clone.sh": "#!/bin/sh\nold", "git-credential-catalyst.sh": "old"},
+	}
+	web := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "env-ns"},
+		Spec: appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{
+			Spec: corev1.PodSpec{Volumes: []corev1.Volume{gitScriptsVolume()}},
+		}},
+	}
+	c := newFakeClientBuilder().WithObjects(stale, web).Build()
+	r := &EnvironmentReconciler{Client: c, Scheme: testScheme}
+	ctx := context.Background()
+
+	require.NoError(t, r.ensureGitScriptsConfigMap(ctx, "env-ns"))
+	configMap := &corev1.ConfigMap{}
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(stale), configMap))
+	assert.Equal(t, gitScriptsData(), configMap.Data)
+	assert.Equal(t, gitScriptsHash(), configMap.Annotations[gitScriptsHashAnnotation])
+
+	// Up to date: no further writes
+	version := configMap.ResourceVersion
+	require.NoError(t, r.ensureGitScriptsConfigMap(ctx, "env-ns"))
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(stale), configMap))
+	assert.Equal(t, version, configMap.ResourceVersion)
+
+	// New scripts roll the Deployments mounting them
+	require.NoError(t, r.rolloutGitScriptsHash(ctx, "env-ns", "web"))
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(web), web))
+	assert.Equal(t, gitScriptsHash(), web.Spec.Template.Annotations[gitScriptsHashAnnotation])
+
+	// Pod templates without the volume are left alone
+	template := &corev1.PodTemplateSpec{}
+	setGitScriptsHash(template)
+	assert.Empty(t, template.Annotations)
+}
+
+func TestDesiredBuildJob_BuildArgs(t *testing.T) {
+	build := catalystv1alpha1.BuildSpec{
+		Name:       "web",
+		Path:       "/apps/web",
+		Dockerfile: "docker/Dockerfile.web",
+		Target:     "dev",
+		BuildArgs: []catalystv1alpha1.BuildArg{
+			{Name: "NEXT_PUBLIC_API_URL", Value: "https://api.example.com/$(HOME)"},
+			{Name: "SENTRY_AUTH_TOKEN", SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: catalystSecretsName},
+				Key:                  "SENTRY_AUTH_TOKEN",
+			}},
+		},
+	}
+	job := desiredBuildJob("build-web", "ns", "ghcr.io/acme/web:1", "https://github.com/acme/app", "main", nil, build, "", false, nil, nil, nil)
+	kaniko := job.Spec.Template.Spec.Containers[0]
+	assert.Contains(t, kaniko.Args, "--dockerfile=/workspace/source/apps/web/docker/Dockerfile.web")
+	assert.Contains(t, kaniko.Args, "--target=dev")
+	assert.Contains(t, kaniko.Args, "--build-arg=NEXT_PUBLIC_API_URL=$(BUILD_ARG_NEXT_PUBLIC_API_URL)")
+	assert.Contains(t, kaniko.Args, "--build-arg=SENTRY_AUTH_TOKEN=$(BUILD_ARG_SENTRY_AUTH_TOKEN)")
+	// Values are taken literally, Secret values are not copied into the Job
+	assert.Equal(t, "https://api.example.com/$$(HOME)", kaniko.Env[0].Value)
+	assert.Empty(t, kaniko.Env[1].Value)
+	assert.Equal(t, "SENTRY_AUTH_TOKEN", kaniko.Env[1].ValueFrom.SecretKeyRef.Key)
+
+	job = desiredBuildJob("build-web", "ns", "ghcr.io/acme/web:1", "https://github.com/acme/app", "main", nil, catalystv1alpha1.BuildSpec{Name: "web"}, "", false, nil, nil, nil)
+	assert.Contains(t, job.Spec.Template.Spec.Containers[0].Args, "--dockerfile=/workspace/source/Dockerfile")
+}
+
+func TestCheckBuildArgSecrets(t *testing.T) {
+	args := []catalystv1alpha1.BuildArg{{Name: "TOKEN", SecretKeyRef: &corev1.SecretKeySelector{
+		LocalObjectReference: corev1.LocalObjectReference{Name: catalystSecretsName}, Key: "TOKEN",
+	}}}
+	assert.NoError(t, checkBuildArgSecrets(args))
+
+	// Operator-managed Secrets of the namespace cannot be baked into an image
+	args[0].SecretKeyRef.Name = "git-credentials-web"
+	err := checkBuildArgSecrets(args)
+	assert.ErrorContains(t, err, "build arg TOKEN reads Secret git-credentials-web")
+	assert.Equal(t, catalystv1alpha1.FailureReasonConfigInvalid, failureReasonOf(err, ""))
+
+	setOperatorConfig(t, func(c *operatorconfig.Config) { c.Builds.BuildArgSecrets = []string{"git-credentials-web"} })
+	assert.NoError(t, checkBuildArgSecrets(args))
+}
@@ -0,0 +1,275 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+// Phases of status.builds entries
+const (
+	buildPhaseQueued    = "Queued"
+	buildPhaseRunning   = "Running"
+	buildPhaseSucceeded = "Succeeded"
+	buildPhaseFailed    = "Failed"
+	buildPhaseReused    = "Reused"
+)
+
+// buildProjectLabel ties build Jobs to their Project for spec.maxParallelBuilds
+const buildProjectLabel = "catalyst.dev/project-uid"
+
+// Ranks of the build queue by environment type: production builds get slots before staging
+// builds, staging builds before the rest (previews)
+const (
+	buildRankProduction = iota
+	buildRankStaging
+	buildRankPreview
+)
+
+// buildQueueRank returns the rank of an Environment's builds in the build queue
+func buildQueueRank(env *catalystv1alpha1.Environment) int {
+	switch env.Spec.Type {
+	case "production":
+		return buildRankProduction
+	case "staging":
+		return buildRankStaging
+	default:
+		return buildRankPreview
+	}
+}
+
+// buildQueueEntry is a build waiting for a slot
+type buildQueueEntry struct {
+	env      client.ObjectKey
+	build    string
+	project  string
+	rank     int
+	queuedAt time.Time
+}
+
+// compare orders the queue by rank, then first come first served
+func (e buildQueueEntry) compare(other buildQueueEntry) int {
+	return cmp.Or(
+		cmp.Compare(e.rank, other.rank),
+		e.queuedAt.Compare(other.queuedAt),
+		cmp.Compare(e.env.String(), other.env.String()),
+		cmp.Compare(e.build, other.build),
+	)
+}
+
+// buildLimiter hands out the build Job slots left under the operator-wide and Project limits
+// to the builds of one Environment, in the order of the cluster-wide build queue: a build only
+// gets a slot when the slots left also cover the queued builds ahead of it. Running Jobs and
+// queued builds are read from the cache, so parallel reconciles may briefly overshoot.
+type buildLimiter struct {
+	env     client.ObjectKey
+	project string
+	rank    int
+
+	// operatorAvailable is the number of Jobs that may still be created; negative is unbounded
+	operatorAvailable int
+	operatorReason    string
+	// projectAvailable is the same per Project UID, for the Projects that set a limit
+	projectAvailable map[string]int
+	projectReason    string
+
+	// queue holds the queued builds, in order
+	queue []buildQueueEntry
+	// first is the position of the Environment's first build that has to queue
+	first int
+}
+
+// take claims a slot for a build of the Environment that has been queued since queuedAt.
+// When the build has to queue, it returns its 1-based position in the queue and the limit
+// it waits for.
+func (l *buildLimiter) take(build string, queuedAt time.Time) (bool, int, string) {
+	entry := buildQueueEntry{env: l.env, build: build, project: l.project, rank: l.rank, queuedAt: queuedAt}
+	l.queue = slices.DeleteFunc(l.queue, func(e buildQueueEntry) bool { return e.env == entry.env && e.build == entry.build })
+	at, _ := slices.BinarySearchFunc(l.queue, entry, buildQueueEntry.compare)
+	l.queue = slices.Insert(l.queue, at, entry)
+
+	// Hand out the slots left in queue order until reaching the build
+	operator := l.operatorAvailable
+	projects := maps.Clone(l.projectAvailable)
+	for i, e := range l.queue {
+		project, limited := projects[e.project]
+		free := operator != 0 && (!limited || project > 0)
+		if i == at {
+			if !free {
+				if operator == 0 {
+					return false, i + 1, l.operatorReason
+				}
+				return false, i + 1, l.projectReason
+			}
+			l.queue = slices.Delete(l.queue, i, i+1)
+			if l.operatorAvailable > 0 {
+				l.operatorAvailable--
+			}
+			if limited {
+				l.projectAvailable[e.project]--
+			}
+			return true, 0, ""
+		}
+		if !free {
+			continue
+		}
+		if operator > 0 {
+			operator--
+		}
+		if limited {
+			projects[e.project]--
+		}
+	}
+	return false, 0, ""
+}
+
+// queued records the queue position of a build of the Environment that has to queue
+func (l *buildLimiter) queued(position int) {
+	if l.first == 0 || position < l.first {
+		l.first = position
+	}
+}
+
+// position returns the position of the Environment in the queue, nil when no build queues
+func (l *buildLimiter) position() *int32 {
+	if l.first == 0 {
+		return nil
+	}
+	return ptr(int32(l.first))
+}
+
+// buildQueuedAt returns when a build of an Environment started waiting for a slot: kept
+// while the build stays queued for the same Job, now when it starts queuing
+func buildQueuedAt(env *catalystv1alpha1.Environment, build, jobName string) *metav1.Time {
+	for _, status := range env.Status.Builds {
+		if status.Name == build && status.JobName == jobName && status.Phase == buildPhaseQueued && status.QueuedAt != nil {
+			return status.QueuedAt
+		}
+	}
+	now := metav1.Now().Rfc3339Copy()
+	return &now
+}
+
+// buildJobActive reports whether a build Job still occupies a slot
+func buildJobActive(job *batchv1.Job) bool {
+	return job.Status.Succeeded == 0 && job.Status.Failed == 0
+}
+
+// newBuildLimiter counts the running build Jobs against MaxConcurrentBuilds and the Projects'
+// spec.maxParallelBuilds, and collects the builds queued by the other Environments
+func (r *EnvironmentReconciler) newBuildLimiter(ctx context.Context, env *catalystv1alpha1.Environment, project *catalystv1alpha1.Project) (*buildLimiter, error) {
+	limiter := &buildLimiter{
+		env:               client.ObjectKeyFromObject(env),
+		project:           string(project.UID),
+		rank:              buildQueueRank(env),
+		operatorAvailable: -1,
+		projectAvailable:  map[string]int{},
+	}
+	if r.MaxConcurrentBuilds <= 0 && project.Spec.MaxParallelBuilds == nil {
+		return limiter, nil
+	}
+
+	// The limits of the other Projects only matter when they share the operator-wide limit
+	limits := map[string]int32{}
+	projectUIDs := map[client.ObjectKey]string{client.ObjectKeyFromObject(project): limiter.project}
+	if limit := project.Spec.MaxParallelBuilds; limit != nil {
+		limits[limiter.project] = *limit
+	}
+	if r.MaxConcurrentBuilds > 0 {
+		projects := &catalystv1alpha1.ProjectList{}
+		if err := r.List(ctx, projects); err != nil {
+			return nil, fmt.Errorf("failed to list projects for the build queue: %w", err)
+		}
+		for i := range projects.Items {
+			p := &projects.Items[i]
+			projectUIDs[client.ObjectKeyFromObject(p)] = string(p.UID)
+			if p.Spec.MaxParallelBuilds != nil {
+				limits[string(p.UID)] = *p.Spec.MaxParallelBuilds
+			}
+		}
+	}
+
+	jobs := &batchv1.JobList{}
+	if err := r.List(ctx, jobs, client.MatchingLabels{"catalyst.dev/job-type": "build"}); err != nil {
+		return nil, fmt.Errorf("failed to count running builds: %w", err)
+	}
+	var operatorActive int
+	projectActive := map[string]int{}
+	for i := range jobs.Items {
+		if !buildJobActive(&jobs.Items[i]) {
+			continue
+		}
+		operatorActive++
+		projectActive[jobs.Items[i].Labels[buildProjectLabel]]++
+	}
+
+	if r.MaxConcurrentBuilds > 0 {
+		limiter.operatorAvailable = max(r.MaxConcurrentBuilds-operatorActive, 0)
+		limiter.operatorReason = fmt.Sprintf("waiting for one of %d operator build slots", r.MaxConcurrentBuilds)
+	}
+	for uid, limit := range limits {
+		limiter.projectAvailable[uid] = max(int(limit)-projectActive[uid], 0)
+	}
+	if limit := project.Spec.MaxParallelBuilds; limit != nil {
+		limiter.projectReason = fmt.Sprintf("waiting for one of %d project build slots", *limit)
+	}
+
+	// Queued builds of failed, hibernated or deleted Environments are not retried, so they
+	// give up their place
+	envs := &catalystv1alpha1.EnvironmentList{}
+	if err := r.List(ctx, envs); err != nil {
+		return nil, fmt.Errorf("failed to list queued builds: %w", err)
+	}
+	for i := range envs.Items {
+		other := &envs.Items[i]
+		if other.DeletionTimestamp != nil || other.Status.Phase == "Failed" || other.Status.Phase == phaseHibernated {
+			continue
+		}
+		uid := ""
+		if hierarchy := ExtractNamespaceHierarchy(other.Labels); hierarchy != nil {
+			uid = projectUIDs[client.ObjectKey{Namespace: hierarchy.Team, Name: other.Spec.ProjectRef.Name}]
+		}
+		if other.Namespace == env.Namespace && other.Name == env.Name {
+			uid = limiter.project
+		}
+		for _, build := range other.Status.Builds {
+			if build.Phase != buildPhaseQueued || build.QueuedAt == nil {
+				continue
+			}
+			limiter.queue = append(limiter.queue, buildQueueEntry{
+				env:      client.ObjectKeyFromObject(other),
+				build:    build.Name,
+				project:  uid,
+				rank:     buildQueueRank(other),
+				queuedAt: build.QueuedAt.Time,
+			})
+		}
+	}
+	slices.SortFunc(limiter.queue, buildQueueEntry.compare)
+	return limiter, nil
+}
@@ -0,0 +1,160 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+func TestReconcileBuilds_Limits(t *testing.T) {
+	env := &catalystv1alpha1.Environment{
+		ObjectMeta: metav1.ObjectMeta{Name: "pr-1", Namespace: "team"},
+		Spec: catalystv1alpha1.EnvironmentSpec{
+			Sources: []catalystv1alpha1.EnvironmentSource{{Name: "app", CommitSha: "abc1234def", Branch: "main"}},
+		},
+	}
+	project := &catalystv1alpha1.Project{
+		ObjectMeta: metav1.ObjectMeta{Name: "acme", Namespace: "team", UID: "project-uid"},
+		Spec: catalystv1alpha1.ProjectSpec{
+			GitHubInstallationId: "12345",
+			Sources:              []catalystv1alpha1.SourceConfig{{Name: "app", RepositoryURL: "https://github.com/acme/app"}},
+			MaxParallelBuilds:    ptr(int32(2)),
+		},
+	}
+	template := &catalystv1alpha1.EnvironmentTemplateSpec{Builds: []catalystv1alpha1.BuildSpec{
+		{Name: "web", SourceRef: "app"},
+		{Name: "api", SourceRef: "app"},
+		{Name: "worker", SourceRef: "app"},
+	}}
+	// A build of another project only counts against the operator-wide limit
+	other := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "build-web-1111111", Namespace: "other-ns", Labels: map[string]string{
+		"catalyst.dev/job-type": "build",
+		buildProjectLabel:       "other-uid",
+	}}}
+	c := newFakeClientBuilder().WithStatusSubresource(env).WithObjects(env, other).Build()
+	r := &EnvironmentReconciler{Client: c, Scheme: testScheme}
+	ctx := context.Background()
+
+	builtImages, err := r.reconcileBuilds(ctx, env, project, "env-ns", template)
+	require.NoError(t, err)
+	assert.Nil(t, builtImages)
+	require.Len(t, env.Status.Builds, 3)
+	require.NotNil(t, env.Status.Builds[2].QueuedAt)
+	assert.Equal(t, []catalystv1alpha1.BuildJobStatus{
+		{Name: "web", Phase: buildPhaseRunning, JobName: "build-web-abc1234"},
+		{Name: "api", Phase: buildPhaseRunning, JobName: "build-api-abc1234"},
+		{Name: "worker", Phase: buildPhaseQueued, JobName: "build-worker-abc1234", Message: "waiting for one of 2 project build slots", QueuedAt: env.Status.Builds[2].QueuedAt},
+	}, env.Status.Builds)
+	assert.Equal(t, ptr(int32(1)), env.Status.QueuePosition)
+
+	jobs := &batchv1.JobList{}
+	require.NoError(t, c.List(ctx, jobs, client.InNamespace("env-ns")))
+	require.Len(t, jobs.Items, 2, "both project slots are launched at once")
+
+	// A failed build frees its slot and fails the reconcile after the rest were advanced
+	web := &batchv1.Job{}
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "build-web-abc1234", Namespace: "env-ns"}, web))
+	web.Status.Failed = 1
+	require.NoError(t, c.Status().Update(ctx, web))
+
+	_, err = r.reconcileBuilds(ctx, env, project, "env-ns", template)
+	assert.EqualError(t, err, "build job failed: build-web-abc1234")
+	assert.Equal(t, buildPhaseFailed, env.Status.Builds[0].Phase)
+	assert.Equal(t, buildPhaseRunning, env.Status.Builds[2].Phase)
+	assert.Nil(t, env.Status.QueuePosition)
+
+	// The operator-wide limit counts every project's builds
+	r.MaxConcurrentBuilds = 3
+	limiter, err := r.newBuildLimiter(ctx, env, project)
+	require.NoError(t, err)
+	assert.Equal(t, 0, limiter.operatorAvailable)
+	assert.Equal(t, "waiting for one of 3 operator build slots", limiter.operatorReason)
+}
+
+func TestBuildLimiter_Queue(t *testing.T) {
+	labels := func(project string) map[string]string {
+		return map[string]string{"catalyst.dev/team": "team", "catalyst.dev/project": project, "catalyst.dev/environment": "env"}
+	}
+	queued := func(name string, at time.Time) catalystv1alpha1.BuildJobStatus {
+		return catalystv1alpha1.BuildJobStatus{Name: name, Phase: buildPhaseQueued, QueuedAt: &metav1.Time{Time: at}}
+	}
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	acme := &catalystv1alpha1.Project{
+		ObjectMeta: metav1.ObjectMeta{Name: "acme", Namespace: "team", UID: "acme-uid"},
+		Spec:       catalystv1alpha1.ProjectSpec{MaxParallelBuilds: ptr(int32(1))},
+	}
+	other := &catalystv1alpha1.Project{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "team", UID: "other-uid"}}
+	// An earlier preview, a later production environment and a preview blocked by its
+	// project limit wait for slots
+	preview := &catalystv1alpha1.Environment{
+		ObjectMeta: metav1.ObjectMeta{Name: "pr-1", Namespace: "team", Labels: labels("other")},
+		Spec:       catalystv1alpha1.EnvironmentSpec{ProjectRef: catalystv1alpha1.ProjectReference{Name: "other"}, Type: "development"},
+		Status:     catalystv1alpha1.EnvironmentStatus{Builds: []catalystv1alpha1.BuildJobStatus{queued("web", start)}},
+	}
+	production := &catalystv1alpha1.Environment{
+		ObjectMeta: metav1.ObjectMeta{Name: "production", Namespace: "team", Labels: labels("other")},
+		Spec:       catalystv1alpha1.EnvironmentSpec{ProjectRef: catalystv1alpha1.ProjectReference{Name: "other"}, Type: "production"},
+		Status:     catalystv1alpha1.EnvironmentStatus{Builds: []catalystv1alpha1.BuildJobStatus{queued("web", start.Add(time.Hour))}},
+	}
+	blocked := &catalystv1alpha1.Environment{
+		ObjectMeta: metav1.ObjectMeta{Name: "pr-2", Namespace: "team", Labels: labels("acme")},
+		Spec:       catalystv1alpha1.EnvironmentSpec{ProjectRef: catalystv1alpha1.ProjectReference{Name: "acme"}, Type: "development"},
+		Status:     catalystv1alpha1.EnvironmentStatus{Builds: []catalystv1alpha1.BuildJobStatus{queued("web", start)}},
+	}
+	// Failed environments give up their place
+	failed := &catalystv1alpha1.Environment{
+		ObjectMeta: metav1.ObjectMeta{Name: "pr-3", Namespace: "team", Labels: labels("other")},
+		Spec:       catalystv1alpha1.EnvironmentSpec{ProjectRef: catalystv1alpha1.ProjectReference{Name: "other"}, Type: "production"},
+		Status:     catalystv1alpha1.EnvironmentStatus{Phase: "Failed", Builds: []catalystv1alpha1.BuildJobStatus{queued("web", start)}},
+	}
+	running := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "build-web-1111111", Namespace: "acme-ns", Labels: map[string]string{
+		"catalyst.dev/job-type": "build",
+		buildProjectLabel:       "acme-uid",
+	}}}
+	c := newFakeClientBuilder().WithObjects(acme, other, preview, production, blocked, failed, running).Build()
+	r := &EnvironmentReconciler{Client: c, Scheme: testScheme, MaxConcurrentBuilds: 2}
+	ctx := context.Background()
+
+	// With one operator slot left, staging builds queue behind production, ahead of previews
+	staging := &catalystv1alpha1.Environment{
+		ObjectMeta: metav1.ObjectMeta{Name: "staging", Namespace: "team", Labels: labels("other")},
+		Spec:       catalystv1alpha1.EnvironmentSpec{ProjectRef: catalystv1alpha1.ProjectReference{Name: "other"}, Type: "staging"},
+	}
+	limiter, err := r.newBuildLimiter(ctx, staging, other)
+	require.NoError(t, err)
+	ok, position, reason := limiter.take("web", start.Add(2*time.Hour))
+	assert.False(t, ok)
+	assert.Equal(t, 2, position)
+	assert.Equal(t, "waiting for one of 2 operator build slots", reason)
+
+	// With three, the earliest preview gets the one production leaves, and the preview
+	// blocked by its project limit holds none
+	r.MaxConcurrentBuilds = 4
+	limiter, err = r.newBuildLimiter(ctx, preview, other)
+	require.NoError(t, err)
+	ok, _, _ = limiter.take("web", start)
+	assert.True(t, ok)
+	ok, _, _ = limiter.take("api", start.Add(3*time.Hour))
+	assert.True(t, ok)
+	ok, position, _ = limiter.take("worker", start.Add(3*time.Hour))
+	assert.False(t, ok)
+	assert.Equal(t, 3, position)
+	limiter.queued(position)
+	assert.Equal(t, ptr(int32(3)), limiter.position())
+
+	limiter, err = r.newBuildLimiter(ctx, blocked, acme)
+	require.NoError(t, err)
+	ok, position, reason = limiter.take("web", start)
+	assert.False(t, ok)
+	assert.Equal(t, 3, position)
+	assert.Equal(t, "waiting for one of 1 project build slots", reason)
+}
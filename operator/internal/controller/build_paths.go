@@ -0,0 +1,214 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+// Path-filtered builds (spec.builds[].includePaths / excludePaths):
+// Before starting a build Job for a new commit, the files changed since the commit of the
+// build's previous image (status.builtImages) are computed with a git diff. When none of them
+// matches the build's paths, the previous image is reused and recorded for the new commit, so
+// the deployment history resolves it directly on later reconciles. Any failure to compute the
+// diff (unreachable repository, rewritten history) falls back to building.
+
+// hasPathFilter reports whether a build only rebuilds for matching changes
+func hasPathFilter(build catalystv1alpha1.BuildSpec) bool {
+	return len(build.IncludePaths) > 0 || len(build.ExcludePaths) > 0
+}
+
+// matchPath matches a slash-separated file path against a glob pattern. "**" matches any
+// number of directories, a pattern without a slash matches the file name at any depth, and a
+// pattern naming a directory matches everything below it.
+func matchPath(pattern, file string) bool {
+	pattern = strings.TrimPrefix(pattern, "/")
+	if strings.HasSuffix(pattern, "/") {
+		pattern += "**"
+	}
+	if !strings.Contains(pattern, "/") {
+		pattern = "**/" + pattern
+	}
+	patternParts := strings.Split(pattern, "/")
+	fileParts := strings.Split(file, "/")
+	if matchSegments(patternParts, fileParts) {
+		return true
+	}
+	// Directory prefix: "apps/web" matches "apps/web/src/index.ts"
+	return matchSegments(append(patternParts, "**"), fileParts)
+}
+
+func matchSegments(pattern, file []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(file); i++ {
+				if matchSegments(pattern[1:], file[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(file) == 0 {
+			return false
+		}
+		if ok, err := path.Match(pattern[0], file[0]); err != nil || !ok {
+			return false
+		}
+		pattern, file = pattern[1:], file[1:]
+	}
+	return len(file) == 0
+}
+
+func matchAny(patterns []string, file string) bool {
+	for _, pattern := range patterns {
+		if matchPath(pattern, file) {
+			return true
+		}
+	}
+	return false
+}
+
+// buildAffected reports whether any changed file is in the build's paths
+func buildAffected(build catalystv1alpha1.BuildSpec, changed []string) bool {
+	for _, file := range changed {
+		if (len(build.IncludePaths) == 0 || matchAny(build.IncludePaths, file)) && !matchAny(build.ExcludePaths, file) {
+			return true
+		}
+	}
+	return false
+}
+
+// changedPaths returns the files that differ between two commits of repo, including both
+// sides of renames
+func changedPaths(repo *git.Repository, from, to string) ([]string, error) {
+	trees := make([]*object.Tree, 0, 2)
+	for _, sha := range []string{from, to} {
+		hash, err := resolveCommit(repo, sha)
+		if err != nil {
+			return nil, err
+		}
+		commit, err := repo.CommitObject(hash)
+		if err != nil {
+			return nil, fmt.Errorf("commit %s: %w", sha, err)
+		}
+		tree, err := commit.Tree()
+		if err != nil {
+			return nil, err
+		}
+		trees = append(trees, tree)
+	}
+	changes, err := object.DiffTree(trees[0], trees[1])
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, change := range changes {
+		if change.From.Name != "" {
+			paths = append(paths, change.From.Name)
+		}
+		if change.To.Name != "" && change.To.Name != change.From.Name {
+			paths = append(paths, change.To.Name)
+		}
+	}
+	return paths, nil
+}
+
+// sourceBranch returns the branch an environment source is built from
+func sourceBranch(env *catalystv1alpha1.Environment, source *catalystv1alpha1.SourceConfig) string {
+	for _, s := range env.Spec.Sources {
+		if s.Name == source.Name && s.Branch != "" {
+			return s.Branch
+		}
+	}
+	return source.Branch
+}
+
+// cloneSourceHistory bare clones the branch history of an environment source, without a
+// worktree, into a temporary directory the returned cleanup removes
+func (r *EnvironmentReconciler) cloneSourceHistory(ctx context.Context, env *catalystv1alpha1.Environment, project *catalystv1alpha1.Project, source *catalystv1alpha1.SourceConfig) (*git.Repository, func(), error) {
+	auth, err := r.gitAuth(ctx, env, project, source)
+	if err != nil {
+		return nil, nil, err
+	}
+	tempDir, err := os.MkdirTemp("", "catalyst-source-*")
+	if err != nil {
+		return nil, nil, err
+	}
+	cleanup := func() {
+		tempDirCleanupsTotal.WithLabelValues("source", metricResult(os.RemoveAll(tempDir))).Inc()
+	}
+	cloneOptions := &git.CloneOptions{URL: source.RepositoryURL, Auth: auth, Tags: git.NoTags}
+	if branch := sourceBranch(env, source); branch != "" {
+		cloneOptions.SingleBranch = true
+		cloneOptions.ReferenceName = plumbing.NewBranchReferenceName(branch)
+	}
+	cloneStart := time.Now()
+	repo, err := git.PlainCloneContext(ctx, tempDir, true, cloneOptions)
+	observeSince(gitCloneDuration.WithLabelValues(metricResult(err)), cloneStart)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	return repo, cleanup, nil
+}
+
+// reusableBuildImage returns the previous image of a path-filtered build when no file in its
+// paths changed between the commit it was built from and commit. Nil means build.
+func (r *EnvironmentReconciler) reusableBuildImage(ctx context.Context, env *catalystv1alpha1.Environment, project *catalystv1alpha1.Project, source *catalystv1alpha1.SourceConfig, build catalystv1alpha1.BuildSpec, commit string) *catalystv1alpha1.BuiltImage {
+	log := logf.FromContext(ctx)
+
+	var previous *catalystv1alpha1.BuiltImage
+	for i := range env.Status.BuiltImages {
+		if built := &env.Status.BuiltImages[i]; built.Name == build.Name {
+			previous = built
+		}
+	}
+	if previous == nil || previous.Commit == "" || previous.Digest == "" || previous.Commit == commit {
+		return nil
+	}
+
+	repo, cleanup, err := r.cloneSourceHistory(ctx, env, project, source)
+	if err != nil {
+		log.Error(err, "Cannot compute changed paths; building", "build", build.Name)
+		return nil
+	}
+	defer cleanup()
+
+	changed, err := changedPaths(repo, previous.Commit, commit)
+	if err != nil {
+		log.Error(err, "Cannot compute changed paths; building", "build", build.Name)
+		return nil
+	}
+	if buildAffected(build, changed) {
+		log.Info("Build affected by changes", "build", build.Name, "since", previous.Commit, "changedFiles", len(changed))
+		return nil
+	}
+	log.Info("Build unaffected by changes; reusing previous image", "build", build.Name, "since", previous.Commit, "image", previous.Image)
+	return previous
+}
@@ -0,0 +1,102 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-billy/v5/util"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+func TestMatchPath(t *testing.T) {
+	tests := []struct {
+		pattern string
+		file    string
+		want    bool
+	}{
+		{"apps/web/**", "apps/web/src/index.ts", true},
+		{"apps/web/**", "apps/website/index.ts", false},
+		{"apps/web", "apps/web/package.json", true},
+		{"apps/web/", "apps/web/src/index.ts", true},
+		{"/apps/web", "apps/web/src/index.ts", true},
+		{"apps/*/package.json", "apps/api/package.json", true},
+		{"apps/*/package.json", "apps/api/src/package.json", false},
+		{"packages/**/*.ts", "packages/ui/src/button.ts", true},
+		{"packages/**/*.ts", "packages/ui.ts", true},
+		{"*.md", "docs/guide/README.md", true},
+		{"*.md", "README.md", true},
+		{"*.md", "README.mdx", false},
+		{"pnpm-lock.yaml", "pnpm-lock.yaml", true},
+		{"apps/web", "apps/api/index.ts", false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, matchPath(tt.pattern, tt.file), "%s ~ %s", tt.pattern, tt.file)
+	}
+}
+
+func TestBuildAffected(t *testing.T) {
+	web := catalystv1alpha1.BuildSpec{
+		Name:         "web",
+		IncludePaths: []string{"apps/web/**", "packages/**", "pnpm-lock.yaml"},
+		ExcludePaths: []string{"*.md"},
+	}
+	assert.True(t, buildAffected(web, []string{"apps/api/main.go", "apps/web/src/app.tsx"}))
+	assert.True(t, buildAffected(web, []string{"pnpm-lock.yaml"}))
+	assert.False(t, buildAffected(web, []string{"apps/api/main.go"}))
+	assert.False(t, buildAffected(web, []string{"apps/web/README.md"}), "excluded")
+	assert.False(t, buildAffected(web, nil))
+
+	// Exclusions alone rebuild for everything else
+	docsIgnored := catalystv1alpha1.BuildSpec{Name: "api", ExcludePaths: []string{"docs/"}}
+	assert.False(t, buildAffected(docsIgnored, []string{"docs/index.md"}))
+	assert.True(t, buildAffected(docsIgnored, []string{"docs/index.md", "main.go"}))
+}
+
+func TestChangedPaths(t *testing.T) {
+	fs := memfs.New()
+	repo, err := git.Init(memory.NewStorage(), fs)
+	require.NoError(t, err)
+	worktree, err := repo.Worktree()
+	require.NoError(t, err)
+
+	commit := func(files map[string]string, removed ...string) string {
+		for name, content := range files {
+			require.NoError(t, util.WriteFile(fs, name, []byte(content), 0644))
+			_, err := worktree.Add(name)
+			require.NoError(t, err)
+		}
+		for _, name := range removed {
+			_, err := worktree.Remove(name)
+			require.NoError(t, err)
+		}
+		hash, err := worktree.Commit("change", &git.CommitOptions{
+			Author: &object.Signature{Name: "dev", Email: "dev@example.com", When: time.Now()},
+		})
+		require.NoError(t, err)
+		return hash.String()
+	}
+
+	base := commit(map[string]string{
+		"apps/web/index.ts": "web",
+		"apps/api/main.go":  "api",
+		"README.md":         "readme",
+	})
+	head := commit(map[string]string{
+		"apps/api/main.go":    "api v2",
+		"apps/api/handler.go": "handler",
+	}, "README.md")
+
+	changed, err := changedPaths(repo, base, head)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"apps/api/main.go", "apps/api/handler.go", "README.md"}, changed)
+
+	_, err = changedPaths(repo, "0123456789abcdef0123456789abcdef01234567", head)
+	assert.Error(t, err)
+}
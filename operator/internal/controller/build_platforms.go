@@ -0,0 +1,114 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/ncrmro/catalyst/operator/internal/operatorconfig"
+)
+
+// Multi-platform builds (BuildSpec platforms):
+// A single platform is passed to kaniko as --custom-platform. Several run kaniko once per
+// platform:
+//   - "kaniko-<os>-<arch>" (init): builds and pushes the platform image under
+//     "<tag>-<os>-<arch>", writing its digest to /workspace/digest-<os>-<arch>
+//   - "index": crane pushes the image index of the platform images under the tag, writing
+//     its digest to its termination message
+//
+// The index image is configurable with CRANE_IMAGE.
+
+const (
+	defaultCraneImage   = "gcr.io/go-containerregistry/crane/debug:v0.20.2"
+	buildIndexContainer = "index"
+)
+
+// buildIndexScript pushes the image index of the platform images and reports its digest like
+// kaniko does
+const buildIndexScript = `set -eu
+set --
+for platform in $PLATFORMS; do
+  set -- "$@" --manifest "$DESTINATION-$platform@$(cat /workspace/digest-$platform)"
+done
+crane index append $CRANE_FLAGS --tag "$DESTINATION" "$@"
+digest=$(crane digest $CRANE_FLAGS "$DESTINATION")
+printf '%s' "$digest" > ` + corev1.TerminationMessagePathDefault + `
+echo "${DESTINATION%:*}@$digest" > ` + scanImageRefFile + `
+`
+
+// platformSuffix turns a platform ("linux/arm64/v8") into a tag and container name suffix
+// ("linux-arm64-v8")
+func platformSuffix(platform string) string {
+	return strings.ReplaceAll(platform, "/", "-")
+}
+
+// applyBuildPlatforms builds the kaniko container of a build pod for the given platforms
+func applyBuildPlatforms(spec *corev1.PodSpec, platforms []string, destination string, registryCreds, insecure bool) {
+	kaniko := spec.Containers[0]
+	if len(platforms) == 1 {
+		spec.Containers[0].Args = append(spec.Containers[0].Args, "--custom-platform="+platforms[0])
+		return
+	}
+
+	craneImage := operatorconfig.Current().Images.Crane
+	if craneImage == "" {
+		craneImage = defaultCraneImage
+	}
+	var suffixes []string
+	for _, platform := range platforms {
+		suffix := platformSuffix(platform)
+		suffixes = append(suffixes, suffix)
+
+		container := *kaniko.DeepCopy()
+		container.Name = "kaniko-" + suffix
+		container.Args = nil
+		for _, arg := range kaniko.Args {
+			switch {
+			case strings.HasPrefix(arg, "--destination="):
+				arg = "--destination=" + destination + "-" + suffix
+			case strings.HasPrefix(arg, "--digest-file="):
+				arg = "--digest-file=/workspace/digest-" + suffix
+			}
+			container.Args = append(container.Args, arg)
+		}
+		container.Args = append(container.Args, "--custom-platform="+platform)
+		spec.InitContainers = append(spec.InitContainers, container)
+	}
+
+	craneFlags := ""
+	if insecure {
+		craneFlags = "--insecure"
+	}
+	env := []corev1.EnvVar{
+		{Name: "DESTINATION", Value: destination},
+		{Name: "PLATFORMS", Value: strings.Join(suffixes, " ")},
+		{Name: "CRANE_FLAGS", Value: craneFlags},
+		{Name: "HOME", Value: "/tmp"},
+	}
+	if registryCreds {
+		env = append(env, corev1.EnvVar{Name: "DOCKER_CONFIG", Value: "/kaniko/.docker"})
+	}
+	spec.Containers = []corev1.Container{{
+		Name:         buildIndexContainer,
+		Image:        craneImage,
+		Command:      []string{"sh", "-c", buildIndexScript},
+		Env:          env,
+		VolumeMounts: kaniko.VolumeMounts,
+	}}
+}
@@ -0,0 +1,51 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+func TestDesiredBuildJob_Platforms(t *testing.T) {
+	names := func(containers []corev1.Container) []string {
+		var names []string
+		for _, c := range containers {
+			names = append(names, c.Name)
+		}
+		return names
+	}
+
+	build := catalystv1alpha1.BuildSpec{Name: "web", Platforms: []string{"linux/arm64"}}
+	job := desiredBuildJob("build-web", "ns", "registry/web:abc", "https://github.com/acme/app", "main", nil, build, "", false, nil, nil, nil)
+	spec := job.Spec.Template.Spec
+	assert.Equal(t, []string{"kaniko"}, names(spec.Containers))
+	assert.Contains(t, spec.Containers[0].Args, "--custom-platform=linux/arm64")
+
+	build.Platforms = []string{"linux/amd64", "linux/arm64/v8"}
+	job = desiredBuildJob("build-web", "ns", "registry/web:abc", "https://github.com/acme/app", "main", nil, build, "ghcr-push", true, nil, nil, nil)
+	spec = job.Spec.Template.Spec
+	assert.Equal(t, []string{"git-clone", "kaniko-linux-amd64", "kaniko-linux-arm64-v8"}, names(spec.InitContainers))
+	assert.Equal(t, []string{buildIndexContainer}, names(spec.Containers))
+
+	arm := spec.InitContainers[2]
+	assert.Contains(t, arm.Args, "--destination=registry/web:abc-linux-arm64-v8")
+	assert.Contains(t, arm.Args, "--digest-file=/workspace/digest-linux-arm64-v8")
+	assert.Contains(t, arm.Args, "--custom-platform=linux/arm64/v8")
+	assert.Contains(t, arm.Args, "--insecure")
+	assert.NotContains(t, arm.Args, "--destination=registry/web:abc")
+
+	index := spec.Containers[0]
+	assert.Contains(t, index.Env, corev1.EnvVar{Name: "PLATFORMS", Value: "linux-amd64 linux-arm64-v8"})
+	assert.Contains(t, index.Env, corev1.EnvVar{Name: "CRANE_FLAGS", Value: "--insecure"})
+	assert.Contains(t, index.Env, corev1.EnvVar{Name: "DOCKER_CONFIG", Value: "/kaniko/.docker"})
+	assertRestricted(t, spec)
+
+	// Scanned builds scan the index
+	job = desiredBuildJob("build-web", "ns", "registry/web:abc", "https://github.com/acme/app", "main", nil, build, "", false, nil, &catalystv1alpha1.BuildScanSpec{}, nil)
+	spec = job.Spec.Template.Spec
+	assert.Equal(t, []string{"git-clone", "kaniko-linux-amd64", "kaniko-linux-arm64-v8", buildIndexContainer, "scan"}, names(spec.InitContainers))
+	assert.Equal(t, []string{"attach"}, names(spec.Containers))
+}
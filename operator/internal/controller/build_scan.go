@@ -0,0 +1,224 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+// Build scanning (Project spec.buildScan):
+// The build pod runs kaniko as an init container, followed by
+//   - "scan" (init): trivy writes a CycloneDX SBOM of the pushed image, scans the SBOM and
+//     writes the severity counts to its termination message ("critical=0 high=2 ...")
+//   - "attach": oras attaches the SBOM to the image as an OCI referrer
+//
+// The counts are recorded per image in status.builtImages and summarized on the BuildScanned
+// condition. With blockOnCritical, environments with critical vulnerabilities are not deployed.
+
+const (
+	defaultScanImage   = "aquasec/trivy:0.56.2"
+	defaultAttachImage = "ghcr.io/oras-project/oras:v1.2.0"
+
+	// conditionBuildScanned carries the severity counts of the scanned images
+	conditionBuildScanned = "BuildScanned"
+
+	// scanImageRefFile holds the pushed image reference with digest (kaniko --image-name-with-digest-file)
+	scanImageRefFile = "/workspace/image-ref"
+	scanSBOMFile     = "sbom.cdx.json"
+	sbomMediaType    = "application/vnd.cyclonedx+json"
+)
+
+// buildScanScript generates and scans the SBOM, and reports the severity counts
+const buildScanScript = `set -eu
+image=$(cat ` + scanImageRefFile + `)
+trivy image --quiet --format cyclonedx --output /workspace/` + scanSBOMFile + ` "$image"
+trivy sbom --quiet --format json --output /workspace/vulns.json /workspace/` + scanSBOMFile + `
+count() { grep -o "\"Severity\": *\"$1\"" /workspace/vulns.json | wc -l | tr -d ' '; }
+echo "critical=$(count CRITICAL) high=$(count HIGH) medium=$(count MEDIUM) low=$(count LOW)" > ` + corev1.TerminationMessagePathDefault + `
+`
+
+// buildAttachScript pushes the SBOM as an OCI referrer of the image
+const buildAttachScript = `set -eu
+cd /workspace
+oras attach $ORAS_FLAGS --artifact-type ` + sbomMediaType + ` "$(cat ` + scanImageRefFile + `)" ` + scanSBOMFile + `:` + sbomMediaType + `
+`
+
+// applyBuildScan moves kaniko (or skopeo) to the init containers and appends the scan and attach stages
+func applyBuildScan(spec *corev1.PodSpec, scan *catalystv1alpha1.BuildScanSpec, registryCreds, insecure bool) {
+	kaniko := spec.Containers[0]
+	if kaniko.Name == "kaniko" {
+		// skopeo pushes of nix builds always write the file
+		kaniko.Args = append(kaniko.Args, "--image-name-with-digest-file="+scanImageRefFile)
+	}
+
+	scanImage := scan.Image
+	if scanImage == "" {
+		scanImage = defaultScanImage
+	}
+	attachImage := scan.AttachImage
+	if attachImage == "" {
+		attachImage = defaultAttachImage
+	}
+
+	scanEnv := []corev1.EnvVar{{Name: "TRIVY_CACHE_DIR", Value: "/workspace/.trivy"}}
+	var orasFlags []string
+	if registryCreds {
+		scanEnv = append(scanEnv, corev1.EnvVar{Name: "DOCKER_CONFIG", Value: "/kaniko/.docker"})
+		orasFlags = append(orasFlags, "--registry-config", "/kaniko/.docker/config.json")
+	}
+	if insecure {
+		scanEnv = append(scanEnv, corev1.EnvVar{Name: "TRIVY_INSECURE", Value: "true"})
+		orasFlags = append(orasFlags, "--plain-http")
+	}
+
+	spec.InitContainers = append(spec.InitContainers, kaniko, corev1.Container{
+		Name:         "scan",
+		Image:        scanImage,
+		Command:      []string{"sh", "-c", buildScanScript},
+		Env:          scanEnv,
+		Resources:    kaniko.Resources,
+		VolumeMounts: kaniko.VolumeMounts,
+	})
+	spec.Containers = []corev1.Container{{
+		Name:         "attach",
+		Image:        attachImage,
+		Command:      []string{"sh", "-c", buildAttachScript},
+		Env:          []corev1.EnvVar{{Name: "ORAS_FLAGS", Value: strings.Join(orasFlags, " ")}},
+		VolumeMounts: kaniko.VolumeMounts,
+	}}
+}
+
+// parseScanCounts parses the termination message of the scan container
+func parseScanCounts(message string) (*catalystv1alpha1.VulnerabilityCounts, error) {
+	counts := &catalystv1alpha1.VulnerabilityCounts{}
+	fields := map[string]*int32{"critical": &counts.Critical, "high": &counts.High, "medium": &counts.Medium, "low": &counts.Low}
+	for _, field := range strings.Fields(message) {
+		key, value, ok := strings.Cut(field, "=")
+		target, known := fields[key]
+		if !ok || !known {
+			return nil, fmt.Errorf("unexpected scan result %q", field)
+		}
+		n, err := strconv.ParseInt(value, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("unexpected scan result %q: %w", field, err)
+		}
+		*target = int32(n)
+		delete(fields, key)
+	}
+	if len(fields) > 0 {
+		return nil, fmt.Errorf("incomplete scan result %q", message)
+	}
+	return counts, nil
+}
+
+// containerTerminationMessage returns the termination message of a container (or init
+// container) that exited successfully
+func containerTerminationMessage(pod *corev1.Pod, name string) (string, bool) {
+	statuses := append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+	for _, status := range statuses {
+		if status.Name == name && status.State.Terminated != nil && status.State.Terminated.ExitCode == 0 {
+			return strings.TrimSpace(status.State.Terminated.Message), true
+		}
+	}
+	return "", false
+}
+
+// setBuildScannedCondition summarizes the scan results of the built images. Returns whether the
+// condition changed and the images blocking the deploy under blockOnCritical.
+func setBuildScannedCondition(env *catalystv1alpha1.Environment, scan *catalystv1alpha1.BuildScanSpec, images []catalystv1alpha1.BuiltImage) (bool, []string) {
+	var summary, blocked, unscanned []string
+	for _, image := range images {
+		counts := image.Vulnerabilities
+		if counts == nil {
+			unscanned = append(unscanned, image.Name)
+			continue
+		}
+		summary = append(summary, fmt.Sprintf("%s: critical=%d high=%d medium=%d low=%d",
+			image.Name, counts.Critical, counts.High, counts.Medium, counts.Low))
+		if counts.Critical > 0 {
+			blocked = append(blocked, image.Name)
+		}
+	}
+
+	condition := metav1.Condition{
+		Type:    conditionBuildScanned,
+		Status:  metav1.ConditionTrue,
+		Reason:  "Scanned",
+		Message: strings.Join(summary, "; "),
+	}
+	if len(unscanned) > 0 {
+		summary = append(summary, "no scan result for "+strings.Join(unscanned, ", "))
+		condition.Message = strings.Join(summary, "; ")
+	}
+	switch {
+	case len(blocked) > 0 && scan.BlockOnCritical:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "CriticalVulnerabilities"
+	case len(unscanned) > 0:
+		condition.Status = metav1.ConditionUnknown
+		condition.Reason = "ScanResultUnavailable"
+	case len(blocked) > 0:
+		condition.Reason = "ScannedWithCriticalVulnerabilities"
+	}
+	if !scan.BlockOnCritical {
+		blocked = nil
+	}
+	return meta.SetStatusCondition(&env.Status.Conditions, condition), blocked
+}
+
+// resolveBuildScan returns the scan result of a succeeded build from the termination message
+// of its scan container. Once the pod is gone, or for images reused from the deployment
+// history (job is nil), the counts previously recorded for the same image are reused.
+// Nil if the image was not scanned.
+func (r *EnvironmentReconciler) resolveBuildScan(ctx context.Context, env *catalystv1alpha1.Environment, namespace string, job *batchv1.Job, buildName, imageRef string) (*catalystv1alpha1.VulnerabilityCounts, error) {
+	if job != nil {
+		pods := &corev1.PodList{}
+		if err := r.List(ctx, pods, client.InNamespace(namespace), client.MatchingLabels{batchv1.JobNameLabel: job.Name}); err != nil {
+			return nil, err
+		}
+		for i := range pods.Items {
+			if message, ok := containerTerminationMessage(&pods.Items[i], "scan"); ok {
+				return parseScanCounts(message)
+			}
+		}
+	}
+
+	repository, tag, _ := parseImageRef(imageRef)
+	image := repository + ":" + tag
+	candidates := slices.Clone(env.Status.BuiltImages)
+	for _, record := range env.Status.DeploymentHistory {
+		candidates = append(candidates, record.Images...)
+	}
+	for _, built := range candidates {
+		if built.Name == buildName && built.Image == image && built.Vulnerabilities != nil {
+			return built.Vulnerabilities.DeepCopy(), nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,111 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+func TestDesiredBuildJob_Scan(t *testing.T) {
+	job := desiredBuildJob("build-web", "ns", "registry/web:1", "https://github.com/acme/app", "main", nil, catalystv1alpha1.BuildSpec{Name: "web"}, "ghcr-push", true, nil, &catalystv1alpha1.BuildScanSpec{}, nil)
+	spec := job.Spec.Template.Spec
+
+	require.Len(t, spec.InitContainers, 3)
+	assert.Equal(t, []string{"git-clone", "kaniko", "scan"}, []string{spec.InitContainers[0].Name, spec.InitContainers[1].Name, spec.InitContainers[2].Name})
+	assert.Contains(t, spec.InitContainers[1].Args, "--image-name-with-digest-file="+scanImageRefFile)
+	assert.Contains(t, spec.InitContainers[1].Args, "--digest-file=/dev/termination-log", "the digest is still reported by kaniko")
+
+	scan := spec.InitContainers[2]
+	assert.Equal(t, defaultScanImage, scan.Image)
+	assert.Contains(t, scan.Env, corev1.EnvVar{Name: "DOCKER_CONFIG", Value: "/kaniko/.docker"})
+	assert.Contains(t, scan.Env, corev1.EnvVar{Name: "TRIVY_INSECURE", Value: "true"})
+
+	require.Len(t, spec.Containers, 1)
+	attach := spec.Containers[0]
+	assert.Equal(t, "attach", attach.Name)
+	assert.Equal(t, defaultAttachImage, attach.Image)
+	assert.Equal(t, []corev1.EnvVar{{Name: "ORAS_FLAGS", Value: "--registry-config /kaniko/.docker/config.json --plain-http"}}, attach.Env)
+
+	// Without scanning kaniko stays the main container
+	job = desiredBuildJob("build-web", "ns", "registry/web:1", "https://github.com/acme/app", "main", nil, catalystv1alpha1.BuildSpec{Name: "web"}, "", false, nil, nil, nil)
+	assert.Equal(t, "kaniko", job.Spec.Template.Spec.Containers[0].Name)
+}
+
+func TestParseScanCounts(t *testing.T) {
+	counts, err := parseScanCounts("critical=1 high=2 medium=13 low=40\n")
+	require.NoError(t, err)
+	assert.Equal(t, &catalystv1alpha1.VulnerabilityCounts{Critical: 1, High: 2, Medium: 13, Low: 40}, counts)
+
+	_, err = parseScanCounts("critical=1 high=2")
+	assert.ErrorContains(t, err, "incomplete")
+	_, err = parseScanCounts("FATAL image not found")
+	assert.Error(t, err)
+}
+
+func TestSetBuildScannedCondition(t *testing.T) {
+	images := []catalystv1alpha1.BuiltImage{
+		{Name: "web", Vulnerabilities: &catalystv1alpha1.VulnerabilityCounts{High: 2}},
+		{Name: "api", Vulnerabilities: &catalystv1alpha1.VulnerabilityCounts{Critical: 1, Low: 3}},
+	}
+
+	env := &catalystv1alpha1.Environment{}
+	changed, blocked := setBuildScannedCondition(env, &catalystv1alpha1.BuildScanSpec{}, images)
+	assert.True(t, changed)
+	assert.Empty(t, blocked, "critical vulnerabilities only block with blockOnCritical")
+	condition := meta.FindStatusCondition(env.Status.Conditions, conditionBuildScanned)
+	require.NotNil(t, condition)
+	assert.Equal(t, metav1.ConditionTrue, condition.Status)
+	assert.Equal(t, "web: critical=0 high=2 medium=0 low=0; api: critical=1 high=0 medium=0 low=3", condition.Message)
+
+	_, blocked = setBuildScannedCondition(env, &catalystv1alpha1.BuildScanSpec{BlockOnCritical: true}, images)
+	assert.Equal(t, []string{"api"}, blocked)
+	assert.Equal(t, "CriticalVulnerabilities", meta.FindStatusCondition(env.Status.Conditions, conditionBuildScanned).Reason)
+
+	_, blocked = setBuildScannedCondition(env, &catalystv1alpha1.BuildScanSpec{BlockOnCritical: true}, []catalystv1alpha1.BuiltImage{{Name: "web"}})
+	assert.Empty(t, blocked)
+	assert.Equal(t, metav1.ConditionUnknown, meta.FindStatusCondition(env.Status.Conditions, conditionBuildScanned).Status)
+}
+
+func TestResolveBuildScan(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "build-web-abc", Namespace: "ns", Labels: map[string]string{batchv1.JobNameLabel: "build-web-aaa1111"}},
+		Status: corev1.PodStatus{InitContainerStatuses: []corev1.ContainerStatus{
+			{Name: "kaniko", State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Message: "sha256:abc"}}},
+			{Name: "scan", State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Message: "critical=0 high=1 medium=0 low=0"}}},
+		}},
+	}
+	c := newFakeClientBuilder().WithObjects(pod).Build()
+	r := &EnvironmentReconciler{Client: c, Scheme: testScheme}
+	ctx := context.Background()
+	job := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "build-web-aaa1111", Namespace: "ns"}}
+
+	counts, err := r.resolveBuildScan(ctx, &catalystv1alpha1.Environment{}, "ns", job, "web", "registry/web:aaa1111@sha256:abc")
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), counts.High)
+
+	digest, err := r.resolveBuildDigest(ctx, &catalystv1alpha1.Environment{}, "ns", "build-web-aaa1111", "web", "registry/web:aaa1111")
+	require.NoError(t, err)
+	assert.Equal(t, "sha256:abc", digest, "read from the kaniko init container")
+
+	// Reused images keep the counts recorded in the deployment history
+	env := &catalystv1alpha1.Environment{Status: catalystv1alpha1.EnvironmentStatus{
+		DeploymentHistory: []catalystv1alpha1.DeploymentRecord{{Images: []catalystv1alpha1.BuiltImage{
+			{Name: "web", Image: "registry/web:old", Digest: "sha256:old", Vulnerabilities: &catalystv1alpha1.VulnerabilityCounts{Critical: 2}},
+		}}},
+	}}
+	counts, err = r.resolveBuildScan(ctx, env, "ns", nil, "web", "registry/web:old@sha256:old")
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), counts.Critical)
+
+	counts, err = r.resolveBuildScan(ctx, env, "ns", nil, "web", "registry/web:new")
+	require.NoError(t, err)
+	assert.Nil(t, counts)
+}
@@ -0,0 +1,63 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"maps"
+
+	corev1 "k8s.io/api/core/v1"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+	"github.com/ncrmro/catalyst/operator/internal/operatorconfig"
+)
+
+// BuildSchedulingConfig places build Jobs on dedicated build infrastructure nodes.
+// Configured via operator environment variables:
+//   - BUILD_NODE_SELECTOR: JSON object of node labels, e.g. {"catalyst.dev/pool":"builds"}
+//   - BUILD_TOLERATIONS: JSON array of tolerations, e.g. for tainted build nodes
+//   - BUILD_PRIORITY_CLASS_NAME: PriorityClass keeping builds from being preempted
+//
+// Malformed JSON is ignored. BuildSpec.PodOverrides apply on top.
+type BuildSchedulingConfig struct {
+	NodeSelector      map[string]string
+	Tolerations       []corev1.Toleration
+	PriorityClassName string
+}
+
+// currentBuildScheduling returns the build scheduling defaults in effect.
+func currentBuildScheduling() BuildSchedulingConfig {
+	builds := operatorconfig.Current().Builds
+	return BuildSchedulingConfig{
+		NodeSelector:      builds.NodeSelector,
+		Tolerations:       builds.Tolerations,
+		PriorityClassName: builds.PriorityClassName,
+	}
+}
+
+// applyBuildScheduling sets the node selector, tolerations, affinity and priority class of a
+// build pod from the operator defaults and the build's overrides
+func applyBuildScheduling(spec *corev1.PodSpec, cfg BuildSchedulingConfig, overrides *catalystv1alpha1.BuildPodOverrides) {
+	spec.NodeSelector = maps.Clone(cfg.NodeSelector)
+	spec.PriorityClassName = cfg.PriorityClassName
+	applyNodePlacement(spec, nil, "", cfg.Tolerations, nil)
+	if overrides != nil {
+		applyNodePlacement(spec, overrides.NodeSelector, overrides.Arch, overrides.Tolerations, overrides.Affinity)
+		if overrides.PriorityClassName != "" {
+			spec.PriorityClassName = overrides.PriorityClassName
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+func TestCurrentBuildScheduling(t *testing.T) {
+	t.Setenv("BUILD_NODE_SELECTOR", `{"catalyst.dev/pool":"builds"}`)
+	t.Setenv("BUILD_TOLERATIONS", `[{"key":"builds","operator":"Exists","effect":"NoSchedule"}]`)
+	t.Setenv("BUILD_PRIORITY_CLASS_NAME", "builds")
+
+	cfg := currentBuildScheduling()
+	assert.Equal(t, map[string]string{"catalyst.dev/pool": "builds"}, cfg.NodeSelector)
+	assert.Equal(t, []corev1.Toleration{{Key: "builds", Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule}}, cfg.Tolerations)
+	assert.Equal(t, "builds", cfg.PriorityClassName)
+
+	t.Setenv("BUILD_NODE_SELECTOR", "pool=builds")
+	assert.Nil(t, currentBuildScheduling().NodeSelector, "malformed JSON is ignored")
+}
+
+func TestDesiredBuildJob_PodOverrides(t *testing.T) {
+	t.Setenv("BUILD_NODE_SELECTOR", `{"catalyst.dev/pool":"builds","kubernetes.io/arch":"amd64"}`)
+	t.Setenv("BUILD_TOLERATIONS", `[{"key":"builds","operator":"Exists"}]`)
+	t.Setenv("BUILD_PRIORITY_CLASS_NAME", "builds")
+
+	build := catalystv1alpha1.BuildSpec{Name: "web", SourceRef: "app"}
+	job := desiredBuildJob("build-web", "ns", "registry/web:1", "https://github.com/acme/app", "main", nil, build, "", false, nil, nil, nil)
+	spec := job.Spec.Template.Spec
+	assert.Equal(t, "amd64", spec.NodeSelector["kubernetes.io/arch"])
+	assert.Len(t, spec.Tolerations, 1)
+	assert.Equal(t, "builds", spec.PriorityClassName)
+
+	resources := corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("8Gi")},
+	}
+	build.PodOverrides = &catalystv1alpha1.BuildPodOverrides{
+		Resources:         &resources,
+		NodeSelector:      map[string]string{"kubernetes.io/arch": "arm64"},
+		Tolerations:       []corev1.Toleration{{Key: "arm", Operator: corev1.TolerationOpExists}},
+		PriorityClassName: "urgent-builds",
+	}
+	job = desiredBuildJob("build-web", "ns", "registry/web:1", "https://github.com/acme/app", "main", nil, build, "", false, nil, nil, nil)
+	spec = job.Spec.Template.Spec
+	assert.Equal(t, map[string]string{"catalyst.dev/pool": "builds", "kubernetes.io/arch": "arm64"}, spec.NodeSelector)
+	assert.Equal(t, []string{"builds", "arm"}, []string{spec.Tolerations[0].Key, spec.Tolerations[1].Key})
+	assert.Equal(t, "urgent-builds", spec.PriorityClassName)
+	assert.Equal(t, resources, spec.Containers[0].Resources)
+	assert.Equal(t, resources, spec.InitContainers[0].Resources)
+
+	// arch wins over the node selector; the affinity is passed through
+	affinity := &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{}}
+	build.PodOverrides = &catalystv1alpha1.BuildPodOverrides{Arch: "arm64", Affinity: affinity}
+	job = desiredBuildJob("build-web", "ns", "registry/web:1", "https://github.com/acme/app", "main", nil, build, "", false, nil, nil, nil)
+	spec = job.Spec.Template.Spec
+	assert.Equal(t, "arm64", spec.NodeSelector["kubernetes.io/arch"])
+	assert.Equal(t, affinity, spec.Affinity)
+}
+
+func TestDesiredBuildJob_NoSchedulingDefaults(t *testing.T) {
+	job := desiredBuildJob("build-web", "ns", "registry/web:1", "https://github.com/acme/app", "main", nil, catalystv1alpha1.BuildSpec{Name: "web"}, "", false, nil, nil, nil)
+	assert.Nil(t, job.Spec.Template.Spec.NodeSelector)
+	assert.Nil(t, job.Spec.Template.Spec.Tolerations)
+	assert.Empty(t, job.Spec.Template.Spec.PriorityClassName)
+}
@@ -0,0 +1,450 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+// Build signing (Project spec.buildSigning):
+// The build Job records the digests of its inputs in a "source-digest" stage that runs after
+// the clone and before the image is built, so RUN steps cannot rewrite them: the checked out
+// commit and the sha256 of the Dockerfile (flake.nix of nix builds). Once the build pushed
+// its image, a signing Job in the Project namespace runs cosign to
+//   - sign the pushed image by digest, with the key pair of keySecret or keyless via Fulcio
+//   - attest an SLSA v1 provenance predicate recording the source repository and commit, the
+//     build Job and the digest of the Dockerfile
+//   - write the signature reference (cosign triangulate) to its termination message
+//
+// The key Secret never leaves the Project namespace, and keyless certificates are issued for
+// the signing ServiceAccount there rather than for one the environment's workloads share.
+// The reference is recorded per image in status.builtImages[].signature, so admission
+// policies (e.g. Kyverno verifyImages, sigstore policy-controller) can require images to be
+// signed by the build key or identity.
+
+const (
+	defaultSigningImage = "ghcr.io/sigstore/cosign/cosign:v2.4.1-dev"
+	defaultFulcioURL    = "https://fulcio.sigstore.dev"
+	defaultRekorURL     = "https://rekor.sigstore.dev"
+
+	signingContainer   = "sign"
+	signingKeyPath     = "/var/run/cosign"
+	signingTokenPath   = "/var/run/sigstore"
+	signingTokenVolume = "sigstore-token"
+	signingKeyVolume   = "cosign-key"
+	// sigstoreAudience is the audience Fulcio accepts identity tokens for
+	sigstoreAudience = "sigstore"
+	// signingServiceAccount signs in the Project namespace; keyless signatures are issued to it
+	signingServiceAccount = "catalyst-build-signer"
+	// buildSourceContainer records the commit and Dockerfile digest of signed builds
+	buildSourceContainer = "source-digest"
+
+	// signingJobDeadline bounds a signing Job; signingJobTTL keeps it until its signature has
+	// been recorded in the environment status
+	signingJobDeadline = int64(600)
+	signingJobTTL      = int32(3600)
+
+	// slsaBuildType identifies builds by the operator in provenance predicates
+	slsaBuildType = "https://catalyst.dev/build/v1"
+	slsaBuilderID = "https://catalyst.dev/operator"
+)
+
+// buildSourceScript reports the checked out commit and the Dockerfile digest before the build
+const buildSourceScript = `set -eu
+commit=$(git -C /workspace/source rev-parse HEAD)
+digest=$(sha256sum "$DOCKERFILE" | cut -d' ' -f1)
+printf '%s %s' "$commit" "$digest" > ` + corev1.TerminationMessagePathDefault + `
+`
+
+// buildSigningScript signs and attests the image and reports the signature reference
+const buildSigningScript = `set -eu
+printf '%s' "$PROVENANCE" > /workspace/provenance.json
+cosign sign $COSIGN_FLAGS $REGISTRY_FLAGS "$IMAGE"
+cosign attest $COSIGN_FLAGS $REGISTRY_FLAGS --type slsaprovenance1 --predicate /workspace/provenance.json "$IMAGE"
+cosign triangulate $REGISTRY_FLAGS "$IMAGE" > ` + corev1.TerminationMessagePathDefault + `
+`
+
+// buildSigningJob describes the build a signing Job attests
+type buildSigningJob struct {
+	Name      string
+	Namespace string
+	RepoURL   string
+	// Ref is the requested revision, Commit the commit it was checked out at
+	Ref    string
+	Commit string
+	// DockerfilePath is the path of the build definition (the Dockerfile, or flake.nix of nix
+	// builds) in the repository; DockerfileDigest its sha256
+	DockerfilePath   string
+	DockerfileDigest string
+}
+
+// provenance returns the SLSA v1 provenance predicate of the build
+func (b buildSigningJob) provenance() ([]byte, error) {
+	job := b.Namespace + "/" + b.Name
+	return json.Marshal(map[string]interface{}{
+		"buildDefinition": map[string]interface{}{
+			"buildType":          slsaBuildType,
+			"externalParameters": map[string]string{"repository": b.RepoURL, "ref": b.Ref, "dockerfile": b.DockerfilePath},
+			"internalParameters": map[string]string{"job": job},
+			"resolvedDependencies": []interface{}{
+				map[string]interface{}{"uri": "git+" + b.RepoURL, "digest": map[string]string{"gitCommit": b.Commit}},
+				map[string]interface{}{"uri": "file:" + b.DockerfilePath, "digest": map[string]string{"sha256": b.DockerfileDigest}},
+			},
+		},
+		"runDetails": map[string]interface{}{
+			"builder":  map[string]string{"id": slsaBuilderID},
+			"metadata": map[string]string{"invocationId": job},
+		},
+	})
+}
+
+// applyBuildSource inserts the source-digest stage of a signed build right after the clone
+func applyBuildSource(spec *corev1.PodSpec, dockerfile string) {
+	spec.InitContainers = slices.Insert(spec.InitContainers, 1, corev1.Container{
+		Name:    buildSourceContainer,
+		Image:   gitCloneImage,
+		Command: []string{"sh", "-c", buildSourceScript},
+		Env: []corev1.EnvVar{
+			{Name: "HOME", Value: "/tmp"},
+			{Name: "DOCKERFILE", Value: dockerfile},
+		},
+		VolumeMounts: []corev1.VolumeMount{{Name: "workspace", MountPath: "/workspace", ReadOnly: true}},
+		Resources: corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("10m"), corev1.ResourceMemory: resource.MustParse("32Mi")},
+			Limits:   corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("128Mi")},
+		},
+	})
+}
+
+// signingJobName names the signing Job of an image, unique per repository and digest
+func signingJobName(build, imageRef string) string {
+	sum := sha256.Sum256([]byte(imageRef))
+	if len(build) > 40 {
+		build = build[:40]
+	}
+	return strings.TrimSuffix("sign-"+build, "-") + "-" + hex.EncodeToString(sum[:])[:16]
+}
+
+// desiredSigningJob returns the Job signing imageRef (repo:tag@digest) in the Project
+// namespace. registrySecret is the dockerconfigjson Secret there cosign pushes with.
+func desiredSigningJob(name, namespace string, signing *catalystv1alpha1.BuildSigningSpec, build buildSigningJob, imageRef, registrySecret string, insecure bool) (*batchv1.Job, error) {
+	provenance, err := build.provenance()
+	if err != nil {
+		return nil, err
+	}
+	image := signing.Image
+	if image == "" {
+		image = defaultSigningImage
+	}
+	var cosignFlags, registryFlags []string
+	env := []corev1.EnvVar{
+		{Name: "COSIGN_YES", Value: "true"},
+		// cosign caches the sigstore trust roots in $HOME
+		{Name: "HOME", Value: "/tmp"},
+		{Name: "IMAGE", Value: imageRef},
+		{Name: "PROVENANCE", Value: string(provenance)},
+	}
+	volumes := []corev1.Volume{{Name: "workspace", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}}}
+	mounts := []corev1.VolumeMount{{Name: "workspace", MountPath: "/workspace"}}
+	if registrySecret != "" {
+		volumes = append(volumes, corev1.Volume{
+			Name: "registry-creds",
+			VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{
+				SecretName: registrySecret,
+				Items:      []corev1.KeyToPath{{Key: corev1.DockerConfigJsonKey, Path: "config.json"}},
+			}},
+		})
+		env = append(env, corev1.EnvVar{Name: "DOCKER_CONFIG", Value: "/kaniko/.docker"})
+		mounts = append(mounts, corev1.VolumeMount{Name: "registry-creds", MountPath: "/kaniko/.docker", ReadOnly: true})
+	}
+	if insecure {
+		registryFlags = append(registryFlags, "--allow-insecure-registry", "--allow-http-registry")
+	}
+
+	rekorURL := signing.RekorURL
+	if signing.Keyless {
+		fulcioURL := signing.FulcioURL
+		if fulcioURL == "" {
+			fulcioURL = defaultFulcioURL
+		}
+		if rekorURL == "" {
+			rekorURL = defaultRekorURL
+		}
+		cosignFlags = append(cosignFlags, "--fulcio-url="+fulcioURL, "--identity-token="+signingTokenPath+"/token")
+		volumes = append(volumes, corev1.Volume{
+			Name: signingTokenVolume,
+			VolumeSource: corev1.VolumeSource{Projected: &corev1.ProjectedVolumeSource{
+				Sources: []corev1.VolumeProjection{{ServiceAccountToken: &corev1.ServiceAccountTokenProjection{
+					Audience:          sigstoreAudience,
+					ExpirationSeconds: ptr(int64(600)),
+					Path:              "token",
+				}}},
+			}},
+		})
+		mounts = append(mounts, corev1.VolumeMount{Name: signingTokenVolume, MountPath: signingTokenPath, ReadOnly: true})
+	} else {
+		cosignFlags = append(cosignFlags, "--key="+signingKeyPath+"/cosign.key")
+		volumes = append(volumes, corev1.Volume{
+			Name: signingKeyVolume,
+			VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{
+				SecretName: signing.KeySecret,
+				Items:      []corev1.KeyToPath{{Key: "cosign.key", Path: "cosign.key"}},
+			}},
+		})
+		mounts = append(mounts, corev1.VolumeMount{Name: signingKeyVolume, MountPath: signingKeyPath, ReadOnly: true})
+		env = append(env, corev1.EnvVar{Name: "COSIGN_PASSWORD", ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: signing.KeySecret},
+			Key:                  "cosign.password",
+			Optional:             ptr(true),
+		}}})
+	}
+	if rekorURL != "" {
+		cosignFlags = append(cosignFlags, "--rekor-url="+rekorURL)
+	} else {
+		// Signatures with a key stay out of the public transparency log
+		cosignFlags = append(cosignFlags, "--tlog-upload=false")
+	}
+	env = append(env,
+		corev1.EnvVar{Name: "COSIGN_FLAGS", Value: strings.Join(cosignFlags, " ")},
+		corev1.EnvVar{Name: "REGISTRY_FLAGS", Value: strings.Join(registryFlags, " ")},
+	)
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels: map[string]string{
+				"catalyst.dev/job-type": "sign",
+				"catalyst.dev/build":    sanitizeLabelValue(build.Name),
+			},
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:            ptr(int32(0)),
+			ActiveDeadlineSeconds:   ptr(signingJobDeadline),
+			TTLSecondsAfterFinished: ptr(signingJobTTL),
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					RestartPolicy:      corev1.RestartPolicyNever,
+					ServiceAccountName: signingServiceAccount,
+					// Only the projected sigstore token of keyless signing is mounted
+					AutomountServiceAccountToken: ptr(false),
+					Volumes:                      volumes,
+					Containers: []corev1.Container{{
+						Name:         signingContainer,
+						Image:        image,
+						Command:      []string{"sh", "-c", buildSigningScript},
+						Env:          env,
+						VolumeMounts: mounts,
+						Resources: corev1.ResourceRequirements{
+							Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("50m"), corev1.ResourceMemory: resource.MustParse("64Mi")},
+							Limits:   corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("256Mi")},
+						},
+					}},
+				},
+			},
+		},
+	}
+	applyPodSecurity(&job.Spec.Template.Spec)
+	applyBuildScheduling(&job.Spec.Template.Spec, currentBuildScheduling(), nil)
+	return job, nil
+}
+
+// ensureBuildSigner checks the signing key Secret of the project and creates the signing
+// ServiceAccount in the Project namespace. Key Secrets copied into the environment
+// namespace by earlier versions are removed.
+func (r *EnvironmentReconciler) ensureBuildSigner(ctx context.Context, project *catalystv1alpha1.Project, namespace string) error {
+	stale := &corev1.SecretList{}
+	if err := r.List(ctx, stale, client.InNamespace(namespace), client.MatchingLabels{"catalyst.dev/component": "signing-key"}); err != nil {
+		return err
+	}
+	for i := range stale.Items {
+		if err := r.Delete(ctx, &stale.Items[i]); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+	signing := project.Spec.BuildSigning
+	if signing == nil {
+		return nil
+	}
+	if signing.KeySecret != "" {
+		if err := r.Get(ctx, client.ObjectKey{Name: signing.KeySecret, Namespace: project.Namespace}, &corev1.Secret{}); err != nil {
+			if apierrors.IsNotFound(err) {
+				return withFailureReason(catalystv1alpha1.FailureReasonConfigInvalid,
+					fmt.Errorf("signing key Secret %s/%s not found", project.Namespace, signing.KeySecret))
+			}
+			return err
+		}
+	}
+	sa := &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{
+		Name:      signingServiceAccount,
+		Namespace: project.Namespace,
+		Labels:    map[string]string{"catalyst.dev/component": "build-signer"},
+	}, AutomountServiceAccountToken: ptr(false)}
+	if err := r.Create(ctx, sa); err != nil && !apierrors.IsAlreadyExists(err) {
+		return err
+	}
+	return nil
+}
+
+// reconcileBuildSigning signs a succeeded build from the Project namespace. Returns the
+// signature reference once the image is signed, and the failure message if signing failed;
+// neither while the signing Job runs. Images signed before keep their recorded signature.
+func (r *EnvironmentReconciler) reconcileBuildSigning(ctx context.Context, env *catalystv1alpha1.Environment, project *catalystv1alpha1.Project, namespace string, build catalystv1alpha1.BuildSpec, job *batchv1.Job, imageRef string) (signature, failure string, err error) {
+	if job == nil {
+		// Reused images keep the signature of their first build
+		signature, err := r.resolveBuildSignature(ctx, env, namespace, nil, build.Name, imageRef)
+		return signature, "", err
+	}
+	if _, _, digest := parseImageRef(imageRef); digest == "" {
+		return "", fmt.Sprintf("build %s: the pushed digest is unknown, the image cannot be signed", build.Name), nil
+	}
+	if recorded, err := r.resolveBuildSignature(ctx, env, namespace, nil, build.Name, imageRef); err != nil || recorded != "" {
+		return recorded, "", err
+	}
+
+	name := signingJobName(build.Name, imageRef)
+	signer := &batchv1.Job{}
+	if err := r.Get(ctx, client.ObjectKey{Name: name, Namespace: project.Namespace}, signer); err == nil {
+		switch {
+		case signer.Status.Succeeded > 0:
+			signature, err := r.resolveBuildSignature(ctx, env, project.Namespace, signer, build.Name, imageRef)
+			if err == nil && signature == "" {
+				return "", fmt.Sprintf("signing job %s/%s reported no signature", project.Namespace, name), nil
+			}
+			return signature, "", err
+		case signer.Status.Failed > 0:
+			return "", fmt.Sprintf("signing job failed: %s/%s", project.Namespace, name), nil
+		}
+		return "", "", nil
+	} else if !apierrors.IsNotFound(err) {
+		return "", "", err
+	}
+
+	commit, dockerfileDigest, err := r.resolveBuildSource(ctx, namespace, job.Name)
+	if err != nil || commit == "" {
+		if err == nil {
+			return "", fmt.Sprintf("build job %s: the source digests are gone, the image cannot be attested", job.Name), nil
+		}
+		return "", "", err
+	}
+	// The commit the operator pinned wins over what the clone reports
+	ref, _ := buildCommit(env, build)
+	if commitShaPattern.MatchString(ref) {
+		commit = ref
+	}
+	definition := build.Dockerfile
+	if definition == "" {
+		definition = "Dockerfile"
+	}
+	if build.BuildStrategy == buildStrategyNix {
+		definition = nixFlakeFile
+	}
+	var repoURL string
+	for _, source := range project.Spec.Sources {
+		if source.Name == build.SourceRef {
+			repoURL = source.RepositoryURL
+		}
+	}
+	registry := currentRegistryConfig()
+	registrySecret := ""
+	if err := r.Get(ctx, client.ObjectKey{Name: registry.SecretName, Namespace: project.Namespace}, &corev1.Secret{}); err == nil {
+		registrySecret = registry.SecretName
+	}
+	signer, err = desiredSigningJob(name, project.Namespace, project.Spec.BuildSigning, buildSigningJob{
+		Name:             job.Name,
+		Namespace:        namespace,
+		RepoURL:          repoURL,
+		Ref:              ref,
+		Commit:           commit,
+		DockerfilePath:   path.Join(strings.TrimPrefix(build.Path, "/"), definition),
+		DockerfileDigest: dockerfileDigest,
+	}, imageRef, registrySecret, registry.Insecure)
+	if err != nil {
+		return "", "", err
+	}
+	applyRegistryCA(&signer.Spec.Template.Spec, registry)
+	logf.FromContext(ctx).Info("Creating signing Job", "job", name, "namespace", project.Namespace, "image", imageRef)
+	if err := r.Create(ctx, signer); err != nil && !apierrors.IsAlreadyExists(err) {
+		return "", "", err
+	}
+	return "", "", nil
+}
+
+// resolveBuildSource returns the commit and Dockerfile digest the source-digest stage of a
+// build Job recorded. Empty once its pod is gone.
+func (r *EnvironmentReconciler) resolveBuildSource(ctx context.Context, namespace, jobName string) (commit, dockerfileDigest string, err error) {
+	pods := &corev1.PodList{}
+	if err := r.List(ctx, pods, client.InNamespace(namespace), client.MatchingLabels{batchv1.JobNameLabel: jobName}); err != nil {
+		return "", "", err
+	}
+	for i := range pods.Items {
+		if message, ok := containerTerminationMessage(&pods.Items[i], buildSourceContainer); ok {
+			if fields := strings.Fields(message); len(fields) == 2 && commitShaPattern.MatchString(fields[0]) {
+				return fields[0], fields[1], nil
+			}
+		}
+	}
+	return "", "", nil
+}
+
+// resolveBuildSignature returns the signature reference of a succeeded build from the
+// termination message of its sign container. Once the pod is gone, or for images reused from
+// the deployment history (job is nil), the signature previously recorded for the same image is
+// reused. Empty if the image was not signed.
+func (r *EnvironmentReconciler) resolveBuildSignature(ctx context.Context, env *catalystv1alpha1.Environment, namespace string, job *batchv1.Job, buildName, imageRef string) (string, error) {
+	if job != nil {
+		pods := &corev1.PodList{}
+		if err := r.List(ctx, pods, client.InNamespace(namespace), client.MatchingLabels{batchv1.JobNameLabel: job.Name}); err != nil {
+			return "", err
+		}
+		for i := range pods.Items {
+			if message, ok := containerTerminationMessage(&pods.Items[i], signingContainer); ok && message != "" {
+				return message, nil
+			}
+		}
+	}
+
+	repository, tag, _ := parseImageRef(imageRef)
+	image := repository + ":" + tag
+	candidates := slices.Clone(env.Status.BuiltImages)
+	for _, record := range env.Status.DeploymentHistory {
+		candidates = append(candidates, record.Images...)
+	}
+	for _, built := range candidates {
+		if built.Name == buildName && built.Image == image && built.Signature != "" {
+			return built.Signature, nil
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,221 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+func envValue(env []corev1.EnvVar, name string) string {
+	for _, e := range env {
+		if e.Name == name {
+			return e.Value
+		}
+	}
+	return ""
+}
+
+func TestDesiredBuildJob_Signing(t *testing.T) {
+	build := catalystv1alpha1.BuildSpec{Name: "web", Path: "/services/web"}
+	signing := &catalystv1alpha1.BuildSigningSpec{KeySecret: "cosign"}
+	job := desiredBuildJob("build-web", "ns", "registry/web:1", "https://github.com/acme/app", "abc123", nil, build, "ghcr-push", true, nil, nil, signing)
+	spec := job.Spec.Template.Spec
+
+	// The sources are digested before kaniko runs; nothing signs in the environment namespace
+	require.Len(t, spec.InitContainers, 2)
+	assert.Equal(t, []string{"git-clone", buildSourceContainer}, []string{spec.InitContainers[0].Name, spec.InitContainers[1].Name})
+	assert.Equal(t, "/workspace/source/services/web/Dockerfile", envValue(spec.InitContainers[1].Env, "DOCKERFILE"))
+	assert.Equal(t, "kaniko", spec.Containers[0].Name)
+	for _, volume := range spec.Volumes {
+		assert.Nil(t, volume.Projected, "no sigstore token in build pods")
+		assert.NotEqual(t, signingKeyVolume, volume.Name)
+	}
+
+	// Scanned builds run the scan stages after kaniko
+	job = desiredBuildJob("build-web", "ns", "registry/web:1", "https://github.com/acme/app", "abc123", nil, build, "", false, nil, &catalystv1alpha1.BuildScanSpec{}, signing)
+	var names []string
+	for _, c := range job.Spec.Template.Spec.InitContainers {
+		names = append(names, c.Name)
+	}
+	assert.Equal(t, []string{"git-clone", buildSourceContainer, "kaniko", "scan"}, names)
+}
+
+func TestDesiredSigningJob(t *testing.T) {
+	build := buildSigningJob{
+		Name: "build-web-abc1234", Namespace: "acme-shop-pr-1", RepoURL: "https://github.com/acme/app", Ref: "main",
+		Commit: "0123456789abcdef0123456789abcdef01234567", DockerfilePath: `services/"web"/Dockerfile`, DockerfileDigest: "f00d",
+	}
+	job, err := desiredSigningJob("sign-web-1", "acme", &catalystv1alpha1.BuildSigningSpec{KeySecret: "cosign"}, build, "registry/web:1@sha256:abc", "ghcr-push", true)
+	require.NoError(t, err)
+	assert.Equal(t, "acme", job.Namespace, "signs in the Project namespace")
+	assert.Equal(t, int32(0), *job.Spec.BackoffLimit)
+	assert.NotNil(t, job.Spec.ActiveDeadlineSeconds)
+	spec := job.Spec.Template.Spec
+	assert.Equal(t, signingServiceAccount, spec.ServiceAccountName)
+	assert.False(t, *spec.AutomountServiceAccountToken)
+	require.Len(t, spec.Containers, 1)
+	sign := spec.Containers[0]
+	assert.Equal(t, signingContainer, sign.Name)
+	assert.Equal(t, defaultSigningImage, sign.Image)
+	assert.Equal(t, "registry/web:1@sha256:abc", envValue(sign.Env, "IMAGE"))
+	assert.Equal(t, "--key=/var/run/cosign/cosign.key --tlog-upload=false", envValue(sign.Env, "COSIGN_FLAGS"), "keys stay out of the public transparency log")
+	assert.Equal(t, "--allow-insecure-registry --allow-http-registry", envValue(sign.Env, "REGISTRY_FLAGS"))
+	assert.Equal(t, "/kaniko/.docker", envValue(sign.Env, "DOCKER_CONFIG"))
+	assert.Contains(t, sign.Env, corev1.EnvVar{Name: "COSIGN_PASSWORD", ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{
+		LocalObjectReference: corev1.LocalObjectReference{Name: "cosign"}, Key: "cosign.password", Optional: ptr(true),
+	}}})
+	assert.True(t, hasMountPath(sign.VolumeMounts, signingKeyPath))
+	assert.True(t, *sign.SecurityContext.ReadOnlyRootFilesystem, "the sign container is hardened like the others")
+
+	// The predicate is JSON, whatever the paths contain
+	var provenance struct {
+		BuildDefinition struct {
+			ExternalParameters   map[string]string `json:"externalParameters"`
+			ResolvedDependencies []struct {
+				URI    string            `json:"uri"`
+				Digest map[string]string `json:"digest"`
+			} `json:"resolvedDependencies"`
+		} `json:"buildDefinition"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(envValue(sign.Env, "PROVENANCE")), &provenance))
+	assert.Equal(t, `services/"web"/Dockerfile`, provenance.BuildDefinition.ExternalParameters["dockerfile"])
+	assert.Equal(t, build.Commit, provenance.BuildDefinition.ResolvedDependencies[0].Digest["gitCommit"])
+	assert.Equal(t, "f00d", provenance.BuildDefinition.ResolvedDependencies[1].Digest["sha256"])
+
+	job, err = desiredSigningJob("sign-web-1", "acme", &catalystv1alpha1.BuildSigningSpec{Keyless: true, FulcioURL: "https://fulcio.acme.dev"}, build, "registry/web:1@sha256:abc", "", false)
+	require.NoError(t, err)
+	sign = job.Spec.Template.Spec.Containers[0]
+	assert.Equal(t, "--fulcio-url=https://fulcio.acme.dev --identity-token=/var/run/sigstore/token --rekor-url="+defaultRekorURL, envValue(sign.Env, "COSIGN_FLAGS"))
+	assert.Empty(t, envValue(sign.Env, "REGISTRY_FLAGS"))
+	assert.True(t, hasMountPath(sign.VolumeMounts, signingTokenPath))
+	var token *corev1.ServiceAccountTokenProjection
+	for _, volume := range job.Spec.Template.Spec.Volumes {
+		if volume.Name == signingTokenVolume {
+			token = volume.Projected.Sources[0].ServiceAccountToken
+		}
+	}
+	require.NotNil(t, token)
+	assert.Equal(t, sigstoreAudience, token.Audience)
+}
+
+func TestEnsureBuildSigner(t *testing.T) {
+	key := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "cosign", Namespace: "acme"}, Data: map[string][]byte{"cosign.key": []byte("key")}}
+	copied := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "cosign", Namespace: "acme-pr-1", Labels: map[string]string{"catalyst.dev/component": "signing-key"}}}
+	c := newFakeClientBuilder().WithObjects(key, copied).Build()
+	r := &EnvironmentReconciler{Client: c, Scheme: testScheme}
+	ctx := context.Background()
+	project := &catalystv1alpha1.Project{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "acme"},
+		Spec:       catalystv1alpha1.ProjectSpec{BuildSigning: &catalystv1alpha1.BuildSigningSpec{KeySecret: "cosign"}},
+	}
+
+	require.NoError(t, r.ensureBuildSigner(ctx, project, "acme-pr-1"))
+	assert.True(t, apierrors.IsNotFound(c.Get(ctx, client.ObjectKeyFromObject(copied), &corev1.Secret{})), "copies of the key are removed")
+	sa := &corev1.ServiceAccount{}
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: signingServiceAccount, Namespace: "acme"}, sa))
+	assert.False(t, *sa.AutomountServiceAccountToken)
+	require.NoError(t, r.ensureBuildSigner(ctx, project, "acme-pr-1"))
+
+	project.Spec.BuildSigning.KeySecret = "missing"
+	err := r.ensureBuildSigner(ctx, project, "acme-pr-1")
+	assert.ErrorContains(t, err, "acme/missing not found")
+	assert.Equal(t, catalystv1alpha1.FailureReasonConfigInvalid, failureReasonOf(err, ""))
+
+	project.Spec.BuildSigning = &catalystv1alpha1.BuildSigningSpec{Keyless: true}
+	assert.NoError(t, r.ensureBuildSigner(ctx, project, "acme-pr-1"), "keyless signing needs no key")
+}
+
+func TestReconcileBuildSigning(t *testing.T) {
+	commit := "0123456789abcdef0123456789abcdef01234567"
+	buildPod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "build-web-abc", Namespace: "acme-shop-pr-1", Labels: map[string]string{batchv1.JobNameLabel: "build-web-abc1234"}},
+		Status: corev1.PodStatus{InitContainerStatuses: []corev1.ContainerStatus{
+			{Name: buildSourceContainer, State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Message: commit + " f00d"}}},
+		}},
+	}
+	c := newFakeClientBuilder().WithObjects(buildPod).Build()
+	r := &EnvironmentReconciler{Client: c, Scheme: testScheme}
+	ctx := context.Background()
+	project := &catalystv1alpha1.Project{
+		ObjectMeta: metav1.ObjectMeta{Name: "shop", Namespace: "acme"},
+		Spec: catalystv1alpha1.ProjectSpec{
+			Sources:      []catalystv1alpha1.SourceConfig{{Name: "app", RepositoryURL: "https://github.com/acme/app"}},
+			BuildSigning: &catalystv1alpha1.BuildSigningSpec{Keyless: true},
+		},
+	}
+	build := catalystv1alpha1.BuildSpec{Name: "web", SourceRef: "app"}
+	env := &catalystv1alpha1.Environment{}
+	buildJob := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "build-web-abc1234", Namespace: "acme-shop-pr-1"}}
+	imageRef := "registry/web:abc1234@sha256:abc"
+
+	signature, failure, err := r.reconcileBuildSigning(ctx, env, project, "acme-shop-pr-1", build, buildJob, imageRef)
+	require.NoError(t, err)
+	assert.Empty(t, signature+failure, "signing has started")
+	signer := &batchv1.Job{}
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: signingJobName("web", imageRef), Namespace: "acme"}, signer))
+	assert.Contains(t, envValue(signer.Spec.Template.Spec.Containers[0].Env, "PROVENANCE"), `"gitCommit":"`+commit+`"`)
+
+	// The signature is read from the signing pod in the Project namespace
+	signer.Status.Succeeded = 1
+	require.NoError(t, c.Status().Update(ctx, signer))
+	require.NoError(t, c.Create(ctx, &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "sign-pod", Namespace: "acme", Labels: map[string]string{batchv1.JobNameLabel: signer.Name}},
+		Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{
+			{Name: signingContainer, State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Message: "registry/web:sha256-abc.sig\n"}}},
+		}},
+	}))
+	signature, failure, err = r.reconcileBuildSigning(ctx, env, project, "acme-shop-pr-1", build, buildJob, imageRef)
+	require.NoError(t, err)
+	assert.Empty(t, failure)
+	assert.Equal(t, "registry/web:sha256-abc.sig", signature)
+
+	// Without the pushed digest or the source digests nothing is signed
+	_, failure, err = r.reconcileBuildSigning(ctx, env, project, "acme-shop-pr-1", build, buildJob, "registry/web:abc1234")
+	require.NoError(t, err)
+	assert.Contains(t, failure, "digest is unknown")
+	buildJob.Name = "build-web-gone"
+	_, failure, err = r.reconcileBuildSigning(ctx, env, project, "acme-shop-pr-1", build, buildJob, "registry/web:gone@sha256:def")
+	require.NoError(t, err)
+	assert.Contains(t, failure, "source digests are gone")
+}
+
+func TestResolveBuildSignature(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "build-web-abc", Namespace: "ns", Labels: map[string]string{batchv1.JobNameLabel: "build-web-aaa1111"}},
+		Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{
+			{Name: signingContainer, State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Message: "registry/web:sha256-abc.sig\n"}}},
+		}},
+	}
+	c := newFakeClientBuilder().WithObjects(pod).Build()
+	r := &EnvironmentReconciler{Client: c, Scheme: testScheme}
+	ctx := context.Background()
+	job := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "build-web-aaa1111", Namespace: "ns"}}
+
+	signature, err := r.resolveBuildSignature(ctx, &catalystv1alpha1.Environment{}, "ns", job, "web", "registry/web:aaa1111@sha256:abc")
+	require.NoError(t, err)
+	assert.Equal(t, "registry/web:sha256-abc.sig", signature)
+
+	// Reused images keep the signature recorded in the deployment history
+	env := &catalystv1alpha1.Environment{Status: catalystv1alpha1.EnvironmentStatus{
+		DeploymentHistory: []catalystv1alpha1.DeploymentRecord{{Images: []catalystv1alpha1.BuiltImage{
+			{Name: "web", Image: "registry/web:old", Digest: "sha256:old", Signature: "registry/web:sha256-old.sig"},
+		}}},
+	}}
+	signature, err = r.resolveBuildSignature(ctx, env, "ns", nil, "web", "registry/web:old@sha256:old")
+	require.NoError(t, err)
+	assert.Equal(t, "registry/web:sha256-old.sig", signature)
+
+	signature, err = r.resolveBuildSignature(ctx, env, "ns", nil, "web", "registry/web:new")
+	require.NoError(t, err)
+	assert.Empty(t, signature)
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+	"github.com/ncrmro/catalyst/operator/internal/operatorconfig"
+)
+
+const (
+	// conditionCapabilitiesAvailable is False when the cluster lacks components the environment would use
+	conditionCapabilitiesAvailable = "CapabilitiesAvailable"
+	// previewIngressClass is the IngressClass preview Ingresses are created with
+	previewIngressClass = "nginx"
+)
+
+// previewIngressClassName returns the IngressClass for preview Ingresses: className when set
+// (spec.ingress), else nginx, or the cluster default class when nginx is not installed.
+func (r *EnvironmentReconciler) previewIngressClassName(className string) *string {
+	if className != "" {
+		return ptr(className)
+	}
+	if !r.Capabilities.HasIngressClass(previewIngressClass) && r.Capabilities.DefaultIngressClass != "" {
+		return ptr(r.Capabilities.DefaultIngressClass)
+	}
+	return ptr(previewIngressClass)
+}
+
+// applyIngressClass substitutes the IngressClass of a preview Ingress (see previewIngressClassName)
+func (r *EnvironmentReconciler) applyIngressClass(ingress *networkingv1.Ingress, spec *catalystv1alpha1.EnvironmentIngress) {
+	ingress.Spec.IngressClassName = r.previewIngressClassName(ingressClassName(spec))
+}
+
+// degradedFeatures lists the features an environment loses on this cluster. Empty when
+// capability detection is disabled. className is the IngressClass spec.ingress selects.
+func (r *EnvironmentReconciler) degradedFeatures(routing, className string) []string {
+	caps := r.Capabilities
+	if caps == nil {
+		return nil
+	}
+	var degraded []string
+	if routing == routingGateway {
+		if !caps.GatewayAPI {
+			degraded = append(degraded, "Gateway API not installed, preview URLs are not served")
+		}
+	} else if className != "" {
+		if !caps.HasIngressClass(className) {
+			degraded = append(degraded, fmt.Sprintf("IngressClass %s not installed, preview URLs are not served", className))
+		}
+	} else if !caps.HasIngressClass(previewIngressClass) {
+		if caps.DefaultIngressClass != "" {
+			degraded = append(degraded, fmt.Sprintf("IngressClass %s not installed, using default class %s", previewIngressClass, caps.DefaultIngressClass))
+		} else {
+			degraded = append(degraded, fmt.Sprintf("IngressClass %s not installed, preview URLs are not served", previewIngressClass))
+		}
+	}
+	if !caps.CertManager && currentLocalTLS() != nil {
+		degraded = append(degraded, "cert-manager not installed, local previews are served with the ingress controller's default certificate")
+	}
+	if !caps.NetworkPolicyEnforced {
+		degraded = append(degraded, "NetworkPolicy is not enforced by the CNI, the namespace is not isolated")
+	}
+	if cfg := operatorconfig.Current(); !caps.GatewayAPI && cfg.Preview.SharedHost != "" && cfg.Gateway.Name != "" {
+		degraded = append(degraded, "Gateway API not installed, shared-host routing is disabled")
+	}
+	return degraded
+}
+
+// recordCapabilities reports missing cluster capabilities on the Environment
+func (r *EnvironmentReconciler) recordCapabilities(ctx context.Context, env *catalystv1alpha1.Environment, routing, className string) error {
+	if r.Capabilities == nil {
+		return nil
+	}
+	condition := metav1.Condition{
+		Type:               conditionCapabilitiesAvailable,
+		Status:             metav1.ConditionTrue,
+		Reason:             "AllAvailable",
+		Message:            "The cluster provides every capability this environment uses",
+		ObservedGeneration: env.Generation,
+	}
+	if degraded := r.degradedFeatures(routing, className); len(degraded) > 0 {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "Degraded"
+		condition.Message = strings.Join(degraded, "; ")
+	}
+	if meta.SetStatusCondition(&env.Status.Conditions, condition) {
+		return r.Status().Update(ctx, env)
+	}
+	return nil
+}
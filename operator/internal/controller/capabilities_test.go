@@ -0,0 +1,59 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+	"github.com/ncrmro/catalyst/operator/internal/capabilities"
+)
+
+func TestPreviewIngressClassName(t *testing.T) {
+	r := &EnvironmentReconciler{}
+	assert.Equal(t, "nginx", *r.previewIngressClassName(""))
+
+	r.Capabilities = &capabilities.Capabilities{IngressClasses: []string{"nginx", "traefik"}, DefaultIngressClass: "traefik"}
+	assert.Equal(t, "nginx", *r.previewIngressClassName(""))
+
+	// Substitute the cluster default when nginx is missing
+	r.Capabilities = &capabilities.Capabilities{IngressClasses: []string{"traefik"}, DefaultIngressClass: "traefik"}
+	assert.Equal(t, "traefik", *r.previewIngressClassName(""))
+
+	// spec.ingress selects the class explicitly
+	assert.Equal(t, "haproxy", *r.previewIngressClassName("haproxy"))
+	assert.Contains(t, r.degradedFeatures(routingIngress, "haproxy"), "IngressClass haproxy not installed, preview URLs are not served")
+}
+
+func TestRecordCapabilities(t *testing.T) {
+	env := &catalystv1alpha1.Environment{ObjectMeta: metav1.ObjectMeta{Name: "pr-1", Namespace: "team"}}
+	c := newFakeClientBuilder().WithStatusSubresource(env).WithObjects(env).Build()
+	ctx := context.Background()
+
+	// Detection disabled: no condition
+	r := &EnvironmentReconciler{Client: c, Scheme: testScheme}
+	require.NoError(t, r.recordCapabilities(ctx, env, routingIngress, ""))
+	assert.Nil(t, meta.FindStatusCondition(env.Status.Conditions, conditionCapabilitiesAvailable))
+
+	t.Setenv("SHARED_PREVIEW_HOST", "app.preview.example.com")
+	t.Setenv("GATEWAY_NAME", "preview")
+	r.Capabilities = &capabilities.Capabilities{IngressClasses: []string{}}
+	require.NoError(t, r.recordCapabilities(ctx, env, routingIngress, ""))
+	cond := meta.FindStatusCondition(env.Status.Conditions, conditionCapabilitiesAvailable)
+	require.NotNil(t, cond)
+	assert.Equal(t, metav1.ConditionFalse, cond.Status)
+	assert.Contains(t, cond.Message, "preview URLs are not served")
+	assert.Contains(t, cond.Message, "NetworkPolicy is not enforced")
+	assert.Contains(t, cond.Message, "shared-host routing is disabled")
+
+	// Shared routing is skipped instead of failing on the missing HTTPRoute kind
+	require.NoError(t, r.reconcileSharedRouting(ctx, env, "env-ns"))
+
+	r.Capabilities = &capabilities.Capabilities{GatewayAPI: true, NetworkPolicyEnforced: true, IngressClasses: []string{"nginx"}}
+	require.NoError(t, r.recordCapabilities(ctx, env, routingIngress, ""))
+	assert.True(t, meta.IsStatusConditionTrue(env.Status.Conditions, conditionCapabilitiesAvailable))
+}
@@ -0,0 +1,247 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+	"github.com/ncrmro/catalyst/operator/internal/operatorconfig"
+	"github.com/ncrmro/catalyst/operator/internal/secrets"
+)
+
+// Environment secrets:
+// The secrets of an environment are materialized as the catalyst-secrets Secret in its
+// namespace, re-fetched every catalystSecretsResyncInterval. The Project spec.secrets provider
+// selects the backend: the Catalyst web API (default) for environments annotated with
+// catalyst.dev/environment-id, or the secret at spec.secrets.path below the team in the Vault
+// or AWS Secrets Manager of the operator configuration. Workloads load it with envFrom (Helm charts through global.catalystSecrets) and
+// carry its content hash as a pod template annotation, so a changed secret rolls the pods.
+
+const (
+	catalystSecretsName = "catalyst-secrets"
+
+	// environmentIDAnnotation identifies the environment in the web API
+	environmentIDAnnotation = "catalyst.dev/environment-id"
+
+	// secretsHashAnnotation holds the content hash of catalyst-secrets, on the Secret and on
+	// the pod templates consuming it
+	secretsHashAnnotation = "catalyst.dev/secrets-hash"
+
+	catalystSecretsResyncInterval = 5 * time.Minute
+
+	secretsProviderWebAPI            = "webapi"
+	secretsProviderVault             = "vault"
+	secretsProviderAWSSecretsManager = "aws-secrets-manager"
+
+	defaultSecretsPath = "catalyst/{{project}}/{{env}}"
+
+	// defaultVaultAudience is the audience of the tokens Vault logins request
+	defaultVaultAudience = "vault"
+	// serviceAccountTokenExpirationSeconds is the lifetime of requested ServiceAccount tokens,
+	// the API server minimum
+	serviceAccountTokenExpirationSeconds int64 = 600
+)
+
+// catalystSecretsEnvFrom loads catalyst-secrets into a container's environment, if it exists
+func catalystSecretsEnvFrom() corev1.EnvFromSource {
+	return corev1.EnvFromSource{
+		SecretRef: &corev1.SecretEnvSource{
+			LocalObjectReference: corev1.LocalObjectReference{Name: catalystSecretsName},
+			Optional:             boolPtr(true), // Don't fail if secret doesn't exist
+		},
+	}
+}
+
+// secretsHash returns a stable hash of the Secret data
+func secretsHash(data map[string][]byte) string {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	h := sha256.New()
+	for _, key := range keys {
+		h.Write([]byte(key))
+		h.Write([]byte{0})
+		h.Write(data[key])
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// setSecretsHash records the catalyst-secrets hash on a pod template. No-op for an empty hash.
+func setSecretsHash(template *corev1.PodTemplateSpec, hash string) {
+	if hash == "" {
+		return
+	}
+	if template.Annotations == nil {
+		template.Annotations = map[string]string{}
+	}
+	template.Annotations[secretsHashAnnotation] = hash
+}
+
+// secretsProvider returns the project's secrets backend and the key of the environment's
+// secrets in it. An empty key means the environment has no secrets to sync. Vault and AWS
+// Secrets Manager keys are always below the team, <team>/<path>.
+func (r *EnvironmentReconciler) secretsProvider(env *catalystv1alpha1.Environment, project *catalystv1alpha1.Project, team string) (secrets.SecretsProvider, string, error) {
+	spec := project.Spec.Secrets
+	if spec == nil || spec.Provider == "" || spec.Provider == secretsProviderWebAPI {
+		if r.SecretsFetcher != nil {
+			return r.SecretsFetcher, env.Annotations[environmentIDAnnotation], nil
+		}
+		return secrets.NewSecretsFetcher(CatalystWebURL()), env.Annotations[environmentIDAnnotation], nil
+	}
+
+	tmpl := spec.Path
+	if tmpl == "" {
+		tmpl = defaultSecretsPath
+	}
+	path := strings.NewReplacer(
+		"{{env}}", env.Name,
+		"{{project}}", project.Name,
+		"{{team}}", team,
+	).Replace(tmpl)
+	if strings.Contains(path, "{{") {
+		return nil, "", fmt.Errorf("secrets path %q has an unknown placeholder", tmpl)
+	}
+	if team == "" {
+		return nil, "", fmt.Errorf("secrets provider %s requires the environment's team", spec.Provider)
+	}
+	for _, segment := range strings.Split(path, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return nil, "", fmt.Errorf("secrets path %q must be relative, without empty, . or .. segments", tmpl)
+		}
+	}
+	path = team + "/" + path
+
+	backends := operatorconfig.Current().Secrets
+	switch spec.Provider {
+	case secretsProviderVault:
+		vault := backends.Vault
+		if vault == nil {
+			return nil, "", fmt.Errorf("secrets provider vault is not configured for the operator")
+		}
+		saNamespace, saName, _ := strings.Cut(vault.ServiceAccount, "/")
+		audience := vault.Audience
+		if audience == "" {
+			audience = defaultVaultAudience
+		}
+		provider := secrets.NewVaultProvider(vault.Address, vault.Role, func(ctx context.Context) (string, error) {
+			return r.serviceAccountToken(ctx, saNamespace, saName, audience)
+		})
+		if vault.AuthMount != "" {
+			provider.AuthMount = vault.AuthMount
+		}
+		if vault.Mount != "" {
+			provider.Mount = vault.Mount
+		}
+		provider.Namespace = vault.Namespace
+		return provider, path, nil
+	case secretsProviderAWSSecretsManager:
+		aws := backends.AWSSecretsManager
+		if aws == nil {
+			return nil, "", fmt.Errorf("secrets provider aws-secrets-manager is not configured for the operator")
+		}
+		return secrets.NewAWSSecretsManagerProvider(aws.Region, aws.RoleARN), path, nil
+	default:
+		return nil, "", fmt.Errorf("unknown secrets provider %q", spec.Provider)
+	}
+}
+
+// serviceAccountToken requests a short-lived token of a ServiceAccount for audience
+func (r *EnvironmentReconciler) serviceAccountToken(ctx context.Context, namespace, name, audience string) (string, error) {
+	sa := &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}}
+	expiration := serviceAccountTokenExpirationSeconds
+	request := &authenticationv1.TokenRequest{Spec: authenticationv1.TokenRequestSpec{
+		Audiences:         []string{audience},
+		ExpirationSeconds: &expiration,
+	}}
+	if err := r.SubResource("token").Create(ctx, sa, request); err != nil {
+		return "", err
+	}
+	return request.Status.Token, nil
+}
+
+// +kubebuilder:rbac:groups="",resources=serviceaccounts/token,verbs=create
+
+// reconcileCatalystSecrets syncs the environment's secrets from the project's secrets backend
+// into catalyst-secrets. Fetch failures degrade gracefully: the last synced Secret is kept.
+// Returns whether the environment is synced and needs periodic re-syncs.
+func (r *EnvironmentReconciler) reconcileCatalystSecrets(ctx context.Context, env *catalystv1alpha1.Environment, project *catalystv1alpha1.Project, team, namespace string) (bool, error) {
+	log := logf.FromContext(ctx)
+
+	provider, key, err := r.secretsProvider(env, project, team)
+	if err != nil {
+		return false, err
+	}
+	if key == "" {
+		return false, nil
+	}
+
+	fetchedSecrets, err := provider.FetchSecrets(ctx, key)
+	if err != nil {
+		log.Error(err, "Failed to fetch environment secrets; keeping the last synced secrets", "key", key)
+		return true, nil
+	}
+	return true, r.SyncCatalystSecrets(ctx, namespace, fetchedSecrets)
+}
+
+// catalystSecretsHash returns the content hash of catalyst-secrets, empty if it does not exist
+func (r *EnvironmentReconciler) catalystSecretsHash(ctx context.Context, namespace string) (string, error) {
+	secret := &corev1.Secret{}
+	if err := r.Get(ctx, client.ObjectKey{Name: catalystSecretsName, Namespace: namespace}, secret); err != nil {
+		if apierrors.IsNotFound(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	return secret.Annotations[secretsHashAnnotation], nil
+}
+
+// rolloutSecretsHash updates the catalyst-secrets hash on an existing Deployment's pod
+// template, restarting its pods when the secrets changed
+func (r *EnvironmentReconciler) rolloutSecretsHash(ctx context.Context, namespace, name, hash string) error {
+	if hash == "" {
+		return nil
+	}
+	deployment := &appsv1.Deployment{}
+	if err := r.Get(ctx, client.ObjectKey{Name: name, Namespace: namespace}, deployment); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	if deployment.Spec.Template.Annotations[secretsHashAnnotation] == hash {
+		return nil
+	}
+	logf.FromContext(ctx).Info("Restarting Deployment for changed secrets", "namespace", namespace, "deployment", name)
+	patch := client.MergeFrom(deployment.DeepCopy())
+	setSecretsHash(&deployment.Spec.Template, hash)
+	return r.Patch(ctx, deployment, patch)
+}
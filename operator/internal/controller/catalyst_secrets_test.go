@@ -0,0 +1,182 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+	"github.com/ncrmro/catalyst/operator/internal/operatorconfig"
+	"github.com/ncrmro/catalyst/operator/internal/secrets"
+)
+
+func TestSecretsHash(t *testing.T) {
+	a := secretsHash(map[string][]byte{"A": []byte("1"), "B": []byte("2")})
+	assert.Equal(t, a, secretsHash(map[string][]byte{"B": []byte("2"), "A": []byte("1")}), "independent of map order")
+	assert.NotEqual(t, a, secretsHash(map[string][]byte{"A": []byte("12")}))
+	assert.Len(t, a, 16)
+}
+
+func TestReconcileCatalystSecrets(t *testing.T) {
+	body := `{"secrets":{"API_KEY":"one"}}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "/api/internal/secrets/env-123", req.URL.Path)
+		assert.Equal(t, "Bearer sa-token", req.Header.Get("Authorization"))
+		_, _ = w.Write([]byte(body))
+	}))
+	defer server.Close()
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("sa-token"), 0o600))
+	fetcher := secrets.NewSecretsFetcher(server.URL)
+	fetcher.ServiceAccount = tokenFile
+
+	c := newFakeClientBuilder().Build()
+	r := &EnvironmentReconciler{Client: c, Scheme: testScheme, SecretsFetcher: fetcher}
+	ctx := context.Background()
+
+	synced, err := r.reconcileCatalystSecrets(ctx, &catalystv1alpha1.Environment{}, &catalystv1alpha1.Project{}, "team", "env-ns")
+	require.NoError(t, err)
+	assert.False(t, synced, "environments without an environment-id are not synced")
+
+	env := &catalystv1alpha1.Environment{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{environmentIDAnnotation: "env-123"}}}
+	synced, err = r.reconcileCatalystSecrets(ctx, env, &catalystv1alpha1.Project{}, "team", "env-ns")
+	require.NoError(t, err)
+	assert.True(t, synced)
+
+	secret := &corev1.Secret{}
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: catalystSecretsName, Namespace: "env-ns"}, secret))
+	assert.Equal(t, []byte("one"), secret.Data["API_KEY"])
+	first, err := r.catalystSecretsHash(ctx, "env-ns")
+	require.NoError(t, err)
+	assert.Equal(t, secretsHash(secret.Data), first)
+
+	// Unchanged secrets are not rewritten
+	_, err = r.reconcileCatalystSecrets(ctx, env, &catalystv1alpha1.Project{}, "team", "env-ns")
+	require.NoError(t, err)
+	unchanged := &corev1.Secret{}
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: catalystSecretsName, Namespace: "env-ns"}, unchanged))
+	assert.Equal(t, secret.ResourceVersion, unchanged.ResourceVersion)
+
+	body = `{"secrets":{"API_KEY":"two"}}`
+	_, err = r.reconcileCatalystSecrets(ctx, env, &catalystv1alpha1.Project{}, "team", "env-ns")
+	require.NoError(t, err)
+	second, err := r.catalystSecretsHash(ctx, "env-ns")
+	require.NoError(t, err)
+	assert.NotEqual(t, first, second)
+
+	// Fetch failures keep the last synced secret
+	server.Close()
+	synced, err = r.reconcileCatalystSecrets(ctx, env, &catalystv1alpha1.Project{}, "team", "env-ns")
+	require.NoError(t, err)
+	assert.True(t, synced)
+	kept, err := r.catalystSecretsHash(ctx, "env-ns")
+	require.NoError(t, err)
+	assert.Equal(t, second, kept)
+}
+
+func TestSecretsProvider(t *testing.T) {
+	r := &EnvironmentReconciler{}
+	env := &catalystv1alpha1.Environment{ObjectMeta: metav1.ObjectMeta{Name: "pr-1", Annotations: map[string]string{environmentIDAnnotation: "env-123"}}}
+	project := &catalystv1alpha1.Project{ObjectMeta: metav1.ObjectMeta{Name: "shop"}}
+
+	provider, key, err := r.secretsProvider(env, project, "acme")
+	require.NoError(t, err)
+	assert.IsType(t, &secrets.SecretsFetcher{}, provider)
+	assert.Equal(t, "env-123", key)
+
+	// Backends are operator settings; a Project only selects one
+	project.Spec.Secrets = &catalystv1alpha1.SecretsSpec{Provider: secretsProviderVault}
+	_, _, err = r.secretsProvider(env, project, "acme")
+	assert.ErrorContains(t, err, "not configured")
+
+	setOperatorConfig(t, func(c *operatorconfig.Config) {
+		c.Secrets.Vault = &operatorconfig.VaultSecrets{Address: "https://vault:8200", Role: "catalyst", Mount: "kv", ServiceAccount: "catalyst-system/catalyst-operator"}
+		c.Secrets.AWSSecretsManager = &operatorconfig.AWSSecretsManagerSecrets{Region: "eu-west-1"}
+	})
+	provider, key, err = r.secretsProvider(env, project, "acme")
+	require.NoError(t, err)
+	require.IsType(t, &secrets.VaultProvider{}, provider)
+	assert.Equal(t, "kv", provider.(*secrets.VaultProvider).Mount)
+	assert.Equal(t, "kubernetes", provider.(*secrets.VaultProvider).AuthMount)
+	assert.Equal(t, "acme/catalyst/shop/pr-1", key, "keys are below the team")
+
+	project.Spec.Secrets = &catalystv1alpha1.SecretsSpec{
+		Provider: secretsProviderAWSSecretsManager,
+		Path:     "previews/{{env}}",
+	}
+	provider, key, err = r.secretsProvider(env, project, "acme")
+	require.NoError(t, err)
+	assert.IsType(t, &secrets.AWSSecretsManagerProvider{}, provider)
+	assert.Equal(t, "acme/previews/pr-1", key)
+
+	project.Spec.Secrets.Path = "{{namespace}}/{{env}}"
+	_, _, err = r.secretsProvider(env, project, "acme")
+	assert.ErrorContains(t, err, "unknown placeholder")
+
+	// A path cannot escape the team
+	for _, path := range []string{"../other/{{env}}", "/other/{{env}}", "a//b"} {
+		project.Spec.Secrets.Path = path
+		_, _, err = r.secretsProvider(env, project, "acme")
+		assert.ErrorContains(t, err, "must be relative", path)
+	}
+}
+
+func TestServiceAccountToken(t *testing.T) {
+	sa := &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: "catalyst-operator", Namespace: "catalyst-system"}}
+	var requested *authenticationv1.TokenRequest
+	c := newFakeClientBuilder().WithObjects(sa).WithInterceptorFuncs(interceptor.Funcs{
+		SubResourceCreate: func(_ context.Context, _ client.Client, subResource string, obj client.Object, subResourceObj client.Object, _ ...client.SubResourceCreateOption) error {
+			assert.Equal(t, "token", subResource)
+			assert.Equal(t, "catalyst-operator", obj.GetName())
+			requested = subResourceObj.(*authenticationv1.TokenRequest)
+			requested.Status.Token = "short-lived"
+			return nil
+		},
+	}).Build()
+	r := &EnvironmentReconciler{Client: c, Scheme: testScheme}
+
+	token, err := r.serviceAccountToken(context.Background(), "catalyst-system", "catalyst-operator", "vault")
+	require.NoError(t, err)
+	assert.Equal(t, "short-lived", token)
+	assert.Equal(t, []string{"vault"}, requested.Spec.Audiences)
+	assert.Equal(t, serviceAccountTokenExpirationSeconds, *requested.Spec.ExpirationSeconds)
+}
+
+func TestRolloutSecretsHash(t *testing.T) {
+	deployment := desiredDeploymentFromConfig("env-ns", &catalystv1alpha1.EnvironmentConfig{Image: "app:1"})
+	assert.Equal(t, []corev1.EnvFromSource{catalystSecretsEnvFrom()}, deployment.Spec.Template.Spec.Containers[0].EnvFrom)
+	c := newFakeClientBuilder().WithObjects(deployment).Build()
+	r := &EnvironmentReconciler{Client: c, Scheme: testScheme}
+	ctx := context.Background()
+
+	require.NoError(t, r.rolloutSecretsHash(ctx, "env-ns", "web", ""))
+	require.NoError(t, r.rolloutSecretsHash(ctx, "env-ns", "web", "abc"))
+	updated := &appsv1.Deployment{}
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "web", Namespace: "env-ns"}, updated))
+	assert.Equal(t, "abc", updated.Spec.Template.Annotations[secretsHashAnnotation])
+
+	assert.NoError(t, r.rolloutSecretsHash(ctx, "env-ns", "missing", "abc"))
+}
+
+func TestInjectCatalystSecrets(t *testing.T) {
+	vals := map[string]interface{}{"global": map[string]interface{}{"images": map[string]interface{}{}}}
+	injectCatalystSecrets(vals, "")
+	assert.NotContains(t, vals["global"], "catalystSecrets")
+
+	injectCatalystSecrets(vals, "abc")
+	global := vals["global"].(map[string]interface{})
+	assert.Contains(t, global, "images")
+	assert.Equal(t, map[string]interface{}{"name": catalystSecretsName, "hash": "abc"}, global["catalystSecrets"])
+}
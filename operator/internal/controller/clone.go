@@ -0,0 +1,323 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+// Environment cloning (spec.cloneFrom):
+//  1. On the first reconcile the source's resolved config, its sources and deployment mode are
+//     copied into the clone's spec (the clone's own values win) and status.clone is recorded.
+//  2. Managed services with storage get a VolumeSnapshot of the source's data volume, taken in
+//     the source namespace, and are seeded from it ("namespace/name" seed.volumeSnapshot). A
+//     ReferenceGrant lets the clone's claims reference the snapshots.
+//  3. Once the clone's namespace exists, the Secrets its config references are copied into it.
+//
+// The snapshots and the ReferenceGrant live in the source namespace and are deleted with the clone.
+
+// +kubebuilder:rbac:groups=snapshot.storage.k8s.io,resources=volumesnapshots,verbs=get;create;delete
+// +kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=referencegrants,verbs=get;create;delete
+
+const (
+	cloneLabel = "catalyst.dev/clone"
+	// cloneSourceAnnotation records the namespace a copied Secret came from
+	cloneSourceAnnotation = "catalyst.dev/cloned-from"
+)
+
+var (
+	volumeSnapshotGVK = schema.GroupVersionKind{Group: volumeSnapshotAPIGroup, Version: "v1", Kind: "VolumeSnapshot"}
+	referenceGrantGVK = schema.GroupVersionKind{Group: "gateway.networking.k8s.io", Version: "v1beta1", Kind: "ReferenceGrant"}
+)
+
+// cloneResourceName names the snapshots and the ReferenceGrant a clone owns in the source namespace
+func cloneResourceName(env *catalystv1alpha1.Environment, suffix string) string {
+	name := "clone-" + sanitizeLabelValue(env.Name)
+	if suffix != "" {
+		name += "-" + suffix
+	}
+	return name
+}
+
+// managedServiceClaimName is the data volume claim of the single managed service replica
+func managedServiceClaimName(svcSpec catalystv1alpha1.ManagedServiceSpec) string {
+	return svcSpec.Name + "-data-" + svcSpec.Name + "-0"
+}
+
+// sourceTemplateConfig returns the template config the source is rendered from: its pinned
+// revision when still recorded, the current template otherwise
+func sourceTemplateConfig(project *catalystv1alpha1.Project, source *catalystv1alpha1.Environment) *catalystv1alpha1.EnvironmentConfig {
+	tmpl, ok := project.Spec.Templates[source.Spec.Type]
+	if !ok {
+		return nil
+	}
+	if rev := findTemplateRevision(project.Status.TemplateRevisions, source.Spec.Type, source.Status.TemplateHash); rev != nil {
+		return rev.Spec.Config
+	}
+	return tmpl.Config
+}
+
+// cloneSpec copies the source's resolved config, sources and deployment mode into env.
+// Values set on env win: its config overrides the copy and its sources replace those of the same name.
+func cloneSpec(env, source *catalystv1alpha1.Environment, sourceConfig *catalystv1alpha1.EnvironmentConfig) {
+	resolved := resolveConfig(&source.Spec.Config, sourceConfig)
+	merged := resolveConfig(&env.Spec.Config, &resolved)
+	env.Spec.Config = *merged.DeepCopy()
+
+	for _, s := range source.Spec.Sources {
+		if !slices.ContainsFunc(env.Spec.Sources, func(own catalystv1alpha1.EnvironmentSource) bool { return own.Name == s.Name }) {
+			env.Spec.Sources = append(env.Spec.Sources, s)
+		}
+	}
+	if env.Spec.DeploymentMode == "" {
+		env.Spec.DeploymentMode = source.Spec.DeploymentMode
+	}
+}
+
+// desiredCloneSnapshot snapshots the source's data volume of a managed service
+func desiredCloneSnapshot(env *catalystv1alpha1.Environment, sourceNamespace string, svcSpec catalystv1alpha1.ManagedServiceSpec) *unstructured.Unstructured {
+	snapshot := &unstructured.Unstructured{}
+	snapshot.SetGroupVersionKind(volumeSnapshotGVK)
+	snapshot.SetName(cloneResourceName(env, svcSpec.Name))
+	snapshot.SetNamespace(sourceNamespace)
+	snapshot.SetLabels(map[string]string{
+		cloneLabel:                     sanitizeLabelValue(env.Name),
+		"app.kubernetes.io/managed-by": "catalyst-operator",
+	})
+	snapshot.Object["spec"] = map[string]interface{}{
+		"source": map[string]interface{}{"persistentVolumeClaimName": managedServiceClaimName(svcSpec)},
+	}
+	return snapshot
+}
+
+// desiredCloneReferenceGrant allows claims in the clone's namespace to be restored from its snapshots
+func desiredCloneReferenceGrant(env *catalystv1alpha1.Environment, sourceNamespace, targetNamespace string, snapshots []string) *unstructured.Unstructured {
+	grant := &unstructured.Unstructured{}
+	grant.SetGroupVersionKind(referenceGrantGVK)
+	grant.SetName(cloneResourceName(env, ""))
+	grant.SetNamespace(sourceNamespace)
+	grant.SetLabels(map[string]string{
+		cloneLabel:                     sanitizeLabelValue(env.Name),
+		"app.kubernetes.io/managed-by": "catalyst-operator",
+	})
+	to := make([]interface{}, 0, len(snapshots))
+	for _, name := range snapshots {
+		to = append(to, map[string]interface{}{"group": volumeSnapshotAPIGroup, "kind": "VolumeSnapshot", "name": name})
+	}
+	grant.Object["spec"] = map[string]interface{}{
+		"from": []interface{}{
+			map[string]interface{}{"group": "", "kind": "PersistentVolumeClaim", "namespace": targetNamespace},
+		},
+		"to": to,
+	}
+	return grant
+}
+
+// snapshotCloneVolumes snapshots the source's managed-service volumes and seeds the clone's
+// services from them. Services the source never deployed start empty (or from their own seed).
+func (r *EnvironmentReconciler) snapshotCloneVolumes(ctx context.Context, env *catalystv1alpha1.Environment, sourceNamespace, targetNamespace string) ([]string, error) {
+	log := logf.FromContext(ctx)
+	if r.Capabilities != nil && !r.Capabilities.VolumeSnapshots {
+		log.Info("VolumeSnapshots not installed, clone starts without the source's service data")
+		return nil, nil
+	}
+
+	var snapshots []string
+	for i := range env.Spec.Config.Services {
+		svcSpec := &env.Spec.Config.Services[i]
+		if svcSpec.Storage == nil && svcSpec.Size == nil {
+			continue
+		}
+		claim := &corev1.PersistentVolumeClaim{}
+		if err := r.Get(ctx, client.ObjectKey{Name: managedServiceClaimName(*svcSpec), Namespace: sourceNamespace}, claim); err != nil {
+			if apierrors.IsNotFound(err) {
+				log.Info("Source has no data volume for service, not cloning its data", "service", svcSpec.Name)
+				continue
+			}
+			return nil, err
+		}
+		snapshot := desiredCloneSnapshot(env, sourceNamespace, *svcSpec)
+		if err := r.Create(ctx, snapshot); err != nil && !isAlreadyExists(err) {
+			if meta.IsNoMatchError(err) {
+				log.Info("VolumeSnapshots not installed, clone starts without the source's service data")
+				return snapshots, nil
+			}
+			return nil, fmt.Errorf("failed to snapshot %s for clone: %w", claim.Name, err)
+		}
+		svcSpec.Seed = &catalystv1alpha1.ServiceSeedSpec{VolumeSnapshot: sourceNamespace + "/" + snapshot.GetName()}
+		snapshots = append(snapshots, snapshot.GetName())
+	}
+	if len(snapshots) == 0 {
+		return nil, nil
+	}
+
+	grant := desiredCloneReferenceGrant(env, sourceNamespace, targetNamespace, snapshots)
+	if err := r.Create(ctx, grant); err != nil && !isAlreadyExists(err) {
+		if !meta.IsNoMatchError(err) {
+			return nil, fmt.Errorf("failed to create ReferenceGrant for clone: %w", err)
+		}
+		log.Info("ReferenceGrant API not installed, cross-namespace snapshot restores may be rejected")
+	}
+	return snapshots, nil
+}
+
+// reconcileCloneSource copies spec.cloneFrom into the spec once and records status.clone.
+// Returns true when the Environment was updated; the update triggers a fresh reconcile.
+func (r *EnvironmentReconciler) reconcileCloneSource(ctx context.Context, env *catalystv1alpha1.Environment, project *catalystv1alpha1.Project, targetNamespace string) (bool, error) {
+	if env.Spec.CloneFrom == "" || env.Status.Clone != nil {
+		return false, nil
+	}
+	log := logf.FromContext(ctx)
+	if env.Spec.CloneFrom == env.Name {
+		return false, fmt.Errorf("environment cannot be cloned from itself")
+	}
+
+	source := &catalystv1alpha1.Environment{}
+	if err := r.Get(ctx, client.ObjectKey{Name: env.Spec.CloneFrom, Namespace: env.Namespace}, source); err != nil {
+		return false, fmt.Errorf("failed to fetch clone source %s: %w", env.Spec.CloneFrom, err)
+	}
+	if source.Spec.ProjectRef.Name != env.Spec.ProjectRef.Name {
+		return false, fmt.Errorf("clone source %s belongs to project %s, not %s", source.Name, source.Spec.ProjectRef.Name, env.Spec.ProjectRef.Name)
+	}
+	hierarchy := ExtractNamespaceHierarchy(source.Labels)
+	if hierarchy == nil {
+		return false, fmt.Errorf("clone source %s is missing hierarchy labels", source.Name)
+	}
+	sourceNamespace := GenerateEnvironmentNamespace(hierarchy.Team, hierarchy.Project, hierarchy.Environment)
+
+	cloneSpec(env, source, sourceTemplateConfig(project, source))
+	snapshots, err := r.snapshotCloneVolumes(ctx, env, sourceNamespace, targetNamespace)
+	if err != nil {
+		return false, err
+	}
+
+	log.Info("Cloning environment", "source", source.Name, "sourceNamespace", sourceNamespace, "snapshots", len(snapshots))
+	if err := r.Update(ctx, env); err != nil {
+		return false, err
+	}
+	env.Status.Clone = &catalystv1alpha1.CloneStatus{
+		Source:          source.Name,
+		SourceNamespace: sourceNamespace,
+		Snapshots:       snapshots,
+		ClonedAt:        metav1.Now().Rfc3339Copy(),
+	}
+	if err := r.Status().Update(ctx, env); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// cloneSecretNames lists the Secrets the config references, plus catalyst-secrets
+func cloneSecretNames(config *catalystv1alpha1.EnvironmentConfig) []string {
+	names := []string{catalystSecretsName}
+	addEnv := func(vars []corev1.EnvVar) {
+		for _, v := range vars {
+			if v.ValueFrom != nil && v.ValueFrom.SecretKeyRef != nil {
+				names = append(names, v.ValueFrom.SecretKeyRef.Name)
+			}
+		}
+	}
+	addEnv(config.Env)
+	for _, c := range config.InitContainers {
+		addEnv(c.Env)
+	}
+	for _, c := range config.Sidecars {
+		addEnv(c.Env)
+	}
+	for _, svc := range config.Services {
+		addEnv(svc.Container.Env)
+		if svc.Seed != nil && svc.Seed.CredentialsSecret != "" {
+			names = append(names, svc.Seed.CredentialsSecret)
+		}
+	}
+	slices.Sort(names)
+	return slices.Compact(names)
+}
+
+// reconcileCloneSecrets copies the referenced Secrets from the source namespace into the
+// clone's namespace once. Secrets already present in the clone's namespace are kept.
+func (r *EnvironmentReconciler) reconcileCloneSecrets(ctx context.Context, env *catalystv1alpha1.Environment, targetNamespace string) error {
+	clone := env.Status.Clone
+	if clone == nil || clone.SecretsCopied {
+		return nil
+	}
+	log := logf.FromContext(ctx)
+
+	copied := 0
+	for _, name := range cloneSecretNames(&env.Spec.Config) {
+		source := &corev1.Secret{}
+		if err := r.Get(ctx, client.ObjectKey{Name: name, Namespace: clone.SourceNamespace}, source); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return err
+		}
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Namespace:   targetNamespace,
+				Labels:      source.Labels,
+				Annotations: map[string]string{cloneSourceAnnotation: clone.SourceNamespace},
+			},
+			Type: source.Type,
+			Data: source.Data,
+		}
+		if err := r.Create(ctx, secret); err != nil && !isAlreadyExists(err) {
+			return fmt.Errorf("failed to copy Secret %s for clone: %w", name, err)
+		}
+		copied++
+	}
+
+	log.Info("Copied Secrets from clone source", "sourceNamespace", clone.SourceNamespace, "count", copied)
+	clone.SecretsCopied = true
+	return r.Status().Update(ctx, env)
+}
+
+// deleteCloneResources removes the snapshots and the ReferenceGrant a clone owns in the source namespace
+func (r *EnvironmentReconciler) deleteCloneResources(ctx context.Context, env *catalystv1alpha1.Environment) error {
+	clone := env.Status.Clone
+	if clone == nil || len(clone.Snapshots) == 0 {
+		return nil
+	}
+	objects := []*unstructured.Unstructured{desiredCloneReferenceGrant(env, clone.SourceNamespace, "", nil)}
+	for _, name := range clone.Snapshots {
+		snapshot := &unstructured.Unstructured{}
+		snapshot.SetGroupVersionKind(volumeSnapshotGVK)
+		snapshot.SetName(name)
+		snapshot.SetNamespace(clone.SourceNamespace)
+		objects = append(objects, snapshot)
+	}
+	for _, obj := range objects {
+		if err := r.Delete(ctx, obj); err != nil && !apierrors.IsNotFound(err) && !meta.IsNoMatchError(err) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+	"github.com/ncrmro/catalyst/operator/internal/capabilities"
+)
+
+func cloneHierarchy(env string) map[string]string {
+	return map[string]string{
+		"catalyst.dev/team":        "acme",
+		"catalyst.dev/project":     "shop",
+		"catalyst.dev/environment": env,
+	}
+}
+
+func TestCloneSpec(t *testing.T) {
+	source := &catalystv1alpha1.Environment{Spec: catalystv1alpha1.EnvironmentSpec{
+		DeploymentMode: "development",
+		Sources: []catalystv1alpha1.EnvironmentSource{
+			{Name: "app", Branch: "main", CommitSha: "aaa1111"},
+			{Name: "worker", Branch: "main", CommitSha: "bbb2222"},
+		},
+		Config: catalystv1alpha1.EnvironmentConfig{
+			Env: []corev1.EnvVar{{Name: "FEATURE_FLAG", Value: "on"}},
+		},
+	}}
+	template := &catalystv1alpha1.EnvironmentConfig{Image: "node:22", Env: []corev1.EnvVar{{Name: "NODE_ENV", Value: "development"}}}
+	clone := &catalystv1alpha1.Environment{Spec: catalystv1alpha1.EnvironmentSpec{
+		Sources: []catalystv1alpha1.EnvironmentSource{{Name: "app", Branch: "fix-login", CommitSha: "ccc3333"}},
+		Config:  catalystv1alpha1.EnvironmentConfig{Env: []corev1.EnvVar{{Name: "FEATURE_FLAG", Value: "off"}}},
+	}}
+
+	cloneSpec(clone, source, template)
+
+	assert.Equal(t, "node:22", clone.Spec.Config.Image, "resolved from the source's template")
+	assert.Equal(t, []corev1.EnvVar{{Name: "NODE_ENV", Value: "development"}, {Name: "FEATURE_FLAG", Value: "off"}}, clone.Spec.Config.Env)
+	assert.Equal(t, []catalystv1alpha1.EnvironmentSource{
+		{Name: "app", Branch: "fix-login", CommitSha: "ccc3333"},
+		{Name: "worker", Branch: "main", CommitSha: "bbb2222"},
+	}, clone.Spec.Sources)
+	assert.Equal(t, "development", clone.Spec.DeploymentMode)
+
+	clone.Spec.Config.Env[0].Value = "changed"
+	assert.Equal(t, "development", template.Env[0].Value, "the copy does not alias the template")
+}
+
+func TestCloneSecretNames(t *testing.T) {
+	secretRef := func(name string) *corev1.EnvVarSource {
+		return &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: name}, Key: "value"}}
+	}
+	config := &catalystv1alpha1.EnvironmentConfig{
+		Env:            []corev1.EnvVar{{Name: "API_KEY", ValueFrom: secretRef("app")}, {Name: "PLAIN", Value: "x"}},
+		InitContainers: []catalystv1alpha1.InitContainerSpec{{Name: "migrate", Env: []corev1.EnvVar{{Name: "DB", ValueFrom: secretRef("db")}}}},
+		Services: []catalystv1alpha1.ManagedServiceSpec{{
+			Name:      "postgres",
+			Container: catalystv1alpha1.ManagedServiceContainer{Env: []corev1.EnvVar{{Name: "POSTGRES_PASSWORD", ValueFrom: secretRef("db")}}},
+			Seed:      &catalystv1alpha1.ServiceSeedSpec{DumpURL: "s3://fixtures/app.dump", CredentialsSecret: "fixtures-s3"},
+		}},
+	}
+	assert.Equal(t, []string{"app", "catalyst-secrets", "db", "fixtures-s3"}, cloneSecretNames(config))
+}
+
+func TestReconcileCloneSource(t *testing.T) {
+	storage := &corev1.PersistentVolumeClaimSpec{
+		Resources: corev1.VolumeResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("1Gi")}},
+	}
+	project := &catalystv1alpha1.Project{
+		ObjectMeta: metav1.ObjectMeta{Name: "shop", Namespace: "acme"},
+		Spec: catalystv1alpha1.ProjectSpec{Templates: map[string]catalystv1alpha1.EnvironmentTemplateSpec{
+			"development": {Config: &catalystv1alpha1.EnvironmentConfig{
+				Image: "node:22",
+				Services: []catalystv1alpha1.ManagedServiceSpec{
+					{Name: "postgres", Container: catalystv1alpha1.ManagedServiceContainer{Image: "postgres:16"}, Storage: storage},
+					{Name: "redis", Container: catalystv1alpha1.ManagedServiceContainer{Image: "redis:7"}, Storage: storage},
+				},
+			}},
+		}},
+	}
+	source := &catalystv1alpha1.Environment{
+		ObjectMeta: metav1.ObjectMeta{Name: "staging", Namespace: "acme", Labels: cloneHierarchy("staging")},
+		Spec: catalystv1alpha1.EnvironmentSpec{
+			ProjectRef: catalystv1alpha1.ProjectReference{Name: "shop"},
+			Type:       "development",
+			Sources:    []catalystv1alpha1.EnvironmentSource{{Name: "app", Branch: "main", CommitSha: "aaa1111"}},
+		},
+	}
+	clone := &catalystv1alpha1.Environment{
+		ObjectMeta: metav1.ObjectMeta{Name: "qa-run-1", Namespace: "acme", Labels: cloneHierarchy("qa-run-1")},
+		Spec: catalystv1alpha1.EnvironmentSpec{
+			ProjectRef: catalystv1alpha1.ProjectReference{Name: "shop"},
+			Type:       "development",
+			CloneFrom:  "staging",
+		},
+	}
+	sourceNamespace := GenerateEnvironmentNamespace("acme", "shop", "staging")
+	targetNamespace := GenerateEnvironmentNamespace("acme", "shop", "qa-run-1")
+	// Only postgres was deployed in the source
+	claim := &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "postgres-data-postgres-0", Namespace: sourceNamespace}}
+	dbSecret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "catalyst-secrets", Namespace: sourceNamespace}, Data: map[string][]byte{"TOKEN": []byte("s3cr3t")}}
+
+	c := newFakeClientBuilder().WithStatusSubresource(clone).WithObjects(source, clone, claim, dbSecret).Build()
+	r := &EnvironmentReconciler{Client: c, Scheme: testScheme, Capabilities: &capabilities.Capabilities{VolumeSnapshots: true}}
+	ctx := context.Background()
+
+	updated, err := r.reconcileCloneSource(ctx, clone, project, targetNamespace)
+	require.NoError(t, err)
+	assert.True(t, updated)
+
+	stored := &catalystv1alpha1.Environment{}
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(clone), stored))
+	assert.Equal(t, "node:22", stored.Spec.Config.Image)
+	assert.Equal(t, source.Spec.Sources, stored.Spec.Sources)
+	require.Len(t, stored.Spec.Config.Services, 2)
+	require.NotNil(t, stored.Spec.Config.Services[0].Seed)
+	assert.Equal(t, sourceNamespace+"/clone-qa-run-1-postgres", stored.Spec.Config.Services[0].Seed.VolumeSnapshot)
+	assert.Nil(t, stored.Spec.Config.Services[1].Seed, "redis has no source volume to clone")
+	require.NotNil(t, stored.Status.Clone)
+	assert.Equal(t, "staging", stored.Status.Clone.Source)
+	assert.Equal(t, []string{"clone-qa-run-1-postgres"}, stored.Status.Clone.Snapshots)
+
+	snapshot := &unstructured.Unstructured{}
+	snapshot.SetGroupVersionKind(volumeSnapshotGVK)
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "clone-qa-run-1-postgres", Namespace: sourceNamespace}, snapshot))
+	pvc, _, _ := unstructured.NestedString(snapshot.Object, "spec", "source", "persistentVolumeClaimName")
+	assert.Equal(t, "postgres-data-postgres-0", pvc)
+
+	grant := &unstructured.Unstructured{}
+	grant.SetGroupVersionKind(referenceGrantGVK)
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "clone-qa-run-1", Namespace: sourceNamespace}, grant))
+	from, _, _ := unstructured.NestedSlice(grant.Object, "spec", "from")
+	assert.Equal(t, targetNamespace, from[0].(map[string]interface{})["namespace"])
+
+	// The copy is one-time
+	updated, err = r.reconcileCloneSource(ctx, stored, project, targetNamespace)
+	require.NoError(t, err)
+	assert.False(t, updated)
+
+	require.NoError(t, r.reconcileCloneSecrets(ctx, stored, targetNamespace))
+	copied := &corev1.Secret{}
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "catalyst-secrets", Namespace: targetNamespace}, copied))
+	assert.Equal(t, []byte("s3cr3t"), copied.Data["TOKEN"])
+	assert.True(t, stored.Status.Clone.SecretsCopied)
+
+	require.NoError(t, r.deleteCloneResources(ctx, stored))
+	err = c.Get(ctx, client.ObjectKey{Name: "clone-qa-run-1-postgres", Namespace: sourceNamespace}, snapshot)
+	assert.True(t, client.IgnoreNotFound(err) == nil && err != nil, "snapshot deleted with the clone")
+}
+
+func TestReconcileCloneSourceWithoutSnapshots(t *testing.T) {
+	seed := &catalystv1alpha1.ServiceSeedSpec{DumpURL: "https://fixtures.example.com/app.sql"}
+	source := &catalystv1alpha1.Environment{
+		ObjectMeta: metav1.ObjectMeta{Name: "staging", Namespace: "acme", Labels: cloneHierarchy("staging")},
+		Spec: catalystv1alpha1.EnvironmentSpec{
+			ProjectRef: catalystv1alpha1.ProjectReference{Name: "shop"},
+			Config: catalystv1alpha1.EnvironmentConfig{Services: []catalystv1alpha1.ManagedServiceSpec{
+				{Name: "postgres", Storage: &corev1.PersistentVolumeClaimSpec{}, Seed: seed},
+			}},
+		},
+	}
+	clone := &catalystv1alpha1.Environment{
+		ObjectMeta: metav1.ObjectMeta{Name: "qa-run-2", Namespace: "acme", Labels: cloneHierarchy("qa-run-2")},
+		Spec:       catalystv1alpha1.EnvironmentSpec{ProjectRef: catalystv1alpha1.ProjectReference{Name: "shop"}, CloneFrom: "staging"},
+	}
+	c := newFakeClientBuilder().WithStatusSubresource(clone).WithObjects(source, clone).Build()
+	r := &EnvironmentReconciler{Client: c, Scheme: testScheme, Capabilities: &capabilities.Capabilities{}}
+
+	_, err := r.reconcileCloneSource(context.Background(), clone, &catalystv1alpha1.Project{}, "target")
+	require.NoError(t, err)
+	assert.Equal(t, seed, clone.Spec.Config.Services[0].Seed, "the source's own seed is kept")
+	assert.Empty(t, clone.Status.Clone.Snapshots)
+
+	other := &catalystv1alpha1.Environment{
+		ObjectMeta: metav1.ObjectMeta{Name: "qa-run-3", Namespace: "acme", Labels: cloneHierarchy("qa-run-3")},
+		Spec:       catalystv1alpha1.EnvironmentSpec{ProjectRef: catalystv1alpha1.ProjectReference{Name: "blog"}, CloneFrom: "staging"},
+	}
+	_, err = r.reconcileCloneSource(context.Background(), other, &catalystv1alpha1.Project{}, "target")
+	assert.ErrorContains(t, err, "belongs to project shop")
+}
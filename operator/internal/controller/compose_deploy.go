@@ -21,36 +21,66 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 
 	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+	"github.com/ncrmro/catalyst/operator/internal/guardrails"
 )
 
 // DockerCompose represents a simplified version of docker-compose.yml
 type DockerCompose struct {
 	Version  string                    `yaml:"version"`
 	Services map[string]ComposeService `yaml:"services"`
+	Volumes  map[string]yaml.Node      `yaml:"volumes"`  // Named volumes (translated to PVCs)
+	Networks map[string]yaml.Node      `yaml:"networks"` // Networks (translated to NetworkPolicies)
 }
 
 type ComposeService struct {
-	Image       string    `yaml:"image"`
-	Build       yaml.Node `yaml:"build"` // Using yaml.Node to handle string or struct
-	Ports       []string  `yaml:"ports"`
-	Environment yaml.Node `yaml:"environment"` // Using yaml.Node to handle list or map
-	Command     yaml.Node `yaml:"command"`     // Using yaml.Node to handle string or list safely
+	Image       string              `yaml:"image"`
+	Build       yaml.Node           `yaml:"build"`       // Using yaml.Node to handle string or struct
+	Ports       []yaml.Node         `yaml:"ports"`       // Short ("8080:80/udp") or long syntax
+	Expose      []yaml.Node         `yaml:"expose"`      // Ports reachable by other services only
+	Networks    yaml.Node           `yaml:"networks"`    // Using yaml.Node to handle list or map (with aliases)
+	Environment yaml.Node           `yaml:"environment"` // Using yaml.Node to handle list or map
+	Command     yaml.Node           `yaml:"command"`     // Using yaml.Node to handle string or list safely
+	Volumes     []yaml.Node         `yaml:"volumes"`     // Short ("name:/path[:ro]") or long syntax
+	DependsOn   yaml.Node           `yaml:"depends_on"`  // Using yaml.Node to handle list or map (with conditions)
+	Healthcheck *ComposeHealthcheck `yaml:"healthcheck"`
+	Profiles    []string            `yaml:"profiles"` // Only deployed when one of them is selected
+	EnvFile     yaml.Node           `yaml:"env_file"` // Using yaml.Node to handle string, list or long syntax
 }
 
+// ComposeHealthcheck mirrors the compose healthcheck block (translated to a readiness probe)
+type ComposeHealthcheck struct {
+	Test        yaml.Node `yaml:"test"` // String (CMD-SHELL) or list ["CMD", ...] / ["CMD-SHELL", "..."]
+	Interval    string    `yaml:"interval"`
+	Timeout     string    `yaml:"timeout"`
+	Retries     int32     `yaml:"retries"`
+	StartPeriod string    `yaml:"start_period"`
+	Disable     bool      `yaml:"disable"`
+}
+
+const (
+	// composeVolumeSize is the default size of PVCs created for named volumes
+	composeVolumeSize = "1Gi"
+)
+
 // ReconcileComposeMode handles the reconciliation for Docker Compose deployment mode.
-func (r *EnvironmentReconciler) ReconcileComposeMode(ctx context.Context, env *catalystv1alpha1.Environment, project *catalystv1alpha1.Project, namespace string, template *catalystv1alpha1.EnvironmentTemplate) (bool, error) {
+func (r *EnvironmentReconciler) ReconcileComposeMode(ctx context.Context, env *catalystv1alpha1.Environment, project *catalystv1alpha1.Project, namespace string, template *catalystv1alpha1.EnvironmentTemplateSpec) (bool, error) {
 	// 1. Prepare Source
 	sourcePath, cleanup, err := r.prepareSource(ctx, env, project, template)
 	if cleanup != nil {
@@ -61,29 +91,155 @@ func (r *EnvironmentReconciler) ReconcileComposeMode(ctx context.Context, env *c
 	}
 
 	// 2. Parse docker-compose.yml
-	composeFile := filepath.Join(sourcePath, "docker-compose.yml")
-	if _, err := os.Stat(composeFile); os.IsNotExist(err) {
-		// Try .yaml
-		composeFile = filepath.Join(sourcePath, "docker-compose.yaml")
+	variables, err := r.composeVariables(ctx, env, template, namespace)
+	if err != nil {
+		return false, err
+	}
+	compose, err := readComposeFile(sourcePath, env, template, variables)
+	if err != nil {
+		return false, err
+	}
+
+	// 3. Dynamic Builds Identification
+	dynamicBuilds := composeBuilds(compose, project, template)
+
+	// 4. Execute Builds
+	var builtImages map[string]string
+	if len(dynamicBuilds) > 0 {
+		// Temporary Template for builds
+		tempTemplate := *template
+		tempBuilds := append([]catalystv1alpha1.BuildSpec{}, template.Builds...)
+		tempBuilds = append(tempBuilds, dynamicBuilds...)
+		tempTemplate.Builds = tempBuilds
+
+		builtImages, err = r.reconcileBuilds(ctx, env, project, namespace, &tempTemplate)
+		if err != nil {
+			return false, err
+		}
+		if builtImages == nil {
+			return false, nil // Waiting for builds
+		}
+	}
+
+	// 5. Create PVCs for named volumes
+	claimSizes := map[string]resource.Quantity{}
+	for _, pvc := range desiredComposePVCs(namespace, compose, project.Spec.Storage) {
+		if err := r.Create(ctx, pvc); err != nil && !isAlreadyExists(err) {
+			return false, fmt.Errorf("failed to create PVC for volume %s: %w", pvc.Name, err)
+		}
+		claimSizes[pvc.Name] = pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+	}
+	if err := r.expandClaims(ctx, env, namespace, claimSizes); err != nil {
+		return false, err
+	}
+
+	// 6. Generate K8s Resources and check them against the guardrails
+	secretsHash, err := r.catalystSecretsHash(ctx, namespace)
+	if err != nil {
+		return false, err
+	}
+	objects, networkPolicies, err := r.desiredComposeObjects(namespace, sourcePath, env, compose, builtImages, secretsHash)
+	if err != nil {
+		return false, err
+	}
+	policy := composeGuardrails(builtImages)
+	var violations []guardrails.Violation
+	for _, obj := range objects {
+		violations = append(violations, policy.CheckObject(obj)...)
+	}
+	if err := guardrails.AsError(violations); err != nil {
+		return false, err
+	}
+
+	// 7. Apply K8s Resources
+	config := resolveConfig(&env.Spec.Config, template.Config)
+	for _, obj := range objects {
+		labelEnvironmentWorkload(env, obj)
+		prioritizeEnvironmentWorkload(env, obj)
+		scheduleEnvironmentWorkload(&config, obj)
+		live := obj.DeepCopyObject().(client.Object)
+		if err := r.Get(ctx, client.ObjectKeyFromObject(obj), live); apierrors.IsNotFound(err) {
+			live = nil
+		} else if err != nil {
+			return false, err
+		}
+		if err := r.checkDrift(ctx, env, obj, live); err != nil {
+			return false, err
+		}
+		if err := r.patchOrUpdate(ctx, obj); err != nil {
+			return false, err
+		}
+	}
+
+	if err := r.pruneComposeNetworkPolicies(ctx, namespace, networkPolicies); err != nil {
+		return false, err
+	}
+
+	allReady := true
+	for name := range compose.Services {
+		// Check readiness
+		ready, err := r.isDeploymentReady(ctx, namespace, name)
+		if err != nil {
+			return false, err
+		}
+		if !ready {
+			allReady = false
+		}
 	}
 
-	data, err := os.ReadFile(composeFile)
+	return allReady, nil
+}
+
+// readComposeFile parses the docker-compose.yml (or .yaml) of a source checkout, merged with its
+// override file and interpolated with variables (see compose_files.go), keeping the services of
+// the selected profiles
+func readComposeFile(sourcePath string, env *catalystv1alpha1.Environment, template *catalystv1alpha1.EnvironmentTemplateSpec, variables map[string]string) (*DockerCompose, error) {
+	root, err := loadComposeNode(sourcePath)
 	if err != nil {
-		return false, fmt.Errorf("failed to read docker-compose file: %w", err)
+		return nil, withFailureReason(catalystv1alpha1.FailureReasonConfigInvalid, fmt.Errorf("failed to read docker-compose file: %w", err))
+	}
+
+	// The .env file of the compose project has the lowest precedence
+	var dotEnv []corev1.EnvVar
+	if data, err := os.ReadFile(filepath.Join(sourcePath, ".env")); err == nil {
+		dotEnv = parseEnvFile(string(data))
+	}
+	lookup := func(name string) (string, bool) {
+		if value, ok := variables[name]; ok {
+			return value, true
+		}
+		for i := len(dotEnv) - 1; i >= 0; i-- {
+			if dotEnv[i].Name == name {
+				return dotEnv[i].Value, true
+			}
+		}
+		return "", false
+	}
+	if err := interpolateComposeNode(root, lookup); err != nil {
+		return nil, withFailureReason(catalystv1alpha1.FailureReasonConfigInvalid, fmt.Errorf("failed to interpolate docker-compose file: %w", err))
 	}
 
 	var compose DockerCompose
-	if err := yaml.Unmarshal(data, &compose); err != nil {
-		return false, fmt.Errorf("failed to parse docker-compose file: %w", err)
+	if err := root.Decode(&compose); err != nil {
+		return nil, withFailureReason(catalystv1alpha1.FailureReasonConfigInvalid, fmt.Errorf("failed to parse docker-compose file: %w", err))
 	}
+	profiles := resolveConfig(&env.Spec.Config, template.Config).ComposeProfiles
+	for name, service := range compose.Services {
+		if !composeProfileEnabled(service, profiles) {
+			delete(compose.Services, name)
+		}
+	}
+	return &compose, nil
+}
 
-	// 3. Dynamic Builds Identification
+// composeBuilds returns a build for every service with a build directive
+func composeBuilds(compose *DockerCompose, project *catalystv1alpha1.Project, template *catalystv1alpha1.EnvironmentTemplateSpec) []catalystv1alpha1.BuildSpec {
 	sourceRef := template.SourceRef
 	if sourceRef == "" && len(project.Spec.Sources) > 0 {
 		sourceRef = project.Spec.Sources[0].Name
 	}
 
-	dynamicBuilds := []catalystv1alpha1.BuildSpec{}
+	builds := []catalystv1alpha1.BuildSpec{}
 	for name, service := range compose.Services {
 		if !service.Build.IsZero() {
 			buildPath := "."
@@ -99,35 +255,39 @@ func (r *EnvironmentReconciler) ReconcileComposeMode(ctx context.Context, env *c
 				}
 			}
 
-			dynamicBuilds = append(dynamicBuilds, catalystv1alpha1.BuildSpec{
+			builds = append(builds, catalystv1alpha1.BuildSpec{
 				Name:      name,
 				SourceRef: sourceRef,
 				Path:      filepath.Join(template.Path, buildPath),
 			})
 		}
 	}
+	sort.Slice(builds, func(i, j int) bool { return builds[i].Name < builds[j].Name })
+	return builds
+}
 
-	// 4. Execute Builds
-	var builtImages map[string]string
-	if len(dynamicBuilds) > 0 {
-		// Temporary Template for builds
-		tempTemplate := *template
-		tempBuilds := append([]catalystv1alpha1.BuildSpec{}, template.Builds...)
-		tempBuilds = append(tempBuilds, dynamicBuilds...)
-		tempTemplate.Builds = tempBuilds
+// composeGuardrails is the guardrail policy of compose resources; built images are pushed to
+// the operator's registry
+func composeGuardrails(builtImages map[string]string) guardrails.Policy {
+	policy := guardrails.Current().WithExemptImages(waitForImage)
+	for _, image := range builtImages {
+		policy = policy.WithExemptImages(image)
+	}
+	return policy
+}
 
-		builtImages, err = r.reconcileBuilds(ctx, env, project, namespace, &tempTemplate)
-		if err != nil {
-			return false, err
-		}
-		if builtImages == nil {
-			return false, nil // Waiting for builds
-		}
+// desiredComposeObjects translates the compose services into Deployments, Services and the
+// NetworkPolicies of their networks (also returned on their own for pruning)
+func (r *EnvironmentReconciler) desiredComposeObjects(namespace, sourcePath string, env *catalystv1alpha1.Environment, compose *DockerCompose, builtImages map[string]string, secretsHash string) ([]client.Object, []*networkingv1.NetworkPolicy, error) {
+	names := make([]string, 0, len(compose.Services))
+	for name := range compose.Services {
+		names = append(names, name)
 	}
+	sort.Strings(names)
 
-	// 5. Generate and Apply K8s Resources
-	allReady := true
-	for name, service := range compose.Services {
+	var objects []client.Object
+	for _, name := range names {
+		service := compose.Services[name]
 		// Determine Image
 		image := service.Image
 		if img, ok := builtImages[name]; ok {
@@ -135,37 +295,32 @@ func (r *EnvironmentReconciler) ReconcileComposeMode(ctx context.Context, env *c
 		}
 
 		if image == "" && service.Build.IsZero() {
-			return false, fmt.Errorf("service %s has no image or build directive", name)
+			return nil, nil, withFailureReason(catalystv1alpha1.FailureReasonConfigInvalid, fmt.Errorf("service %s has no image or build directive", name))
 		}
 
-		// Create Deployment
-		deploy := r.desiredComposeDeployment(namespace, name, image, service, env)
-		if err := r.patchOrUpdate(ctx, deploy); err != nil {
-			return false, err
+		fileEnv, err := readComposeEnvFiles(sourcePath, service)
+		if err != nil {
+			return nil, nil, withFailureReason(catalystv1alpha1.FailureReasonConfigInvalid, fmt.Errorf("service %s: %w", name, err))
 		}
+		deploy := r.desiredComposeDeployment(namespace, name, image, service, fileEnv, env, compose)
+		setSecretsHash(&deploy.Spec.Template, secretsHash)
+		objects = append(objects, deploy)
 
 		// Create Service if ports exposed
-		if len(service.Ports) > 0 {
-			svc := r.desiredComposeService(namespace, name, service)
-			if err := r.patchOrUpdate(ctx, svc); err != nil {
-				return false, err
-			}
-		}
-
-		// Check readiness
-		ready, err := r.isDeploymentReady(ctx, namespace, name)
-		if err != nil {
-			return false, err
-		}
-		if !ready {
-			allReady = false
+		if len(composeServicePorts(name, service)) > 0 {
+			objects = append(objects, r.desiredComposeService(namespace, name, service))
 		}
 	}
-
-	return allReady, nil
+	networkPolicies := desiredComposeNetworkPolicies(namespace, compose)
+	for _, networkPolicy := range networkPolicies {
+		objects = append(objects, networkPolicy)
+	}
+	return objects, networkPolicies, nil
 }
 
-func (r *EnvironmentReconciler) desiredComposeDeployment(namespace, name, image string, service ComposeService, env *catalystv1alpha1.Environment) *appsv1.Deployment {
+// desiredComposeDeployment translates a compose service. fileEnv holds the variables read from
+// the service's env_file entries; the environment block takes precedence over them.
+func (r *EnvironmentReconciler) desiredComposeDeployment(namespace, name, image string, service ComposeService, fileEnv []corev1.EnvVar, env *catalystv1alpha1.Environment, compose *DockerCompose) *appsv1.Deployment {
 	replicas := int32(1)
 
 	// Convert environment yaml.Node to K8s EnvVars
@@ -193,16 +348,20 @@ func (r *EnvironmentReconciler) desiredComposeDeployment(namespace, name, image
 		}
 	}
 
+	envVars = mergeComposeEnv(fileEnv, envVars)
+
 	// Add environment-level overrides from K8s-native Env field
 	envVars = append(envVars, env.Spec.Config.Env...)
 
-	return &appsv1.Deployment{
+	volumes, volumeMounts := composeVolumes(name, service, compose)
+
+	deployment := &appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      name,
 			Namespace: namespace,
 			Labels: map[string]string{
-				"app":                          name,
-				"catalyst.dev/compose-service": name,
+				"app":               name,
+				composeServiceLabel: name,
 			},
 		},
 		Spec: appsv1.DeploymentSpec{
@@ -212,47 +371,30 @@ func (r *EnvironmentReconciler) desiredComposeDeployment(namespace, name, image
 			},
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
-					Labels: map[string]string{"app": name},
+					Labels: composePodLabels(name, service),
 				},
 				Spec: corev1.PodSpec{
+					InitContainers: composeDependsOnInitContainers(name, service, compose),
 					Containers: []corev1.Container{
 						{
-							Name:  name,
-							Image: image,
-							Env:   envVars,
+							Name:           name,
+							Image:          image,
+							Env:            envVars,
+							EnvFrom:        []corev1.EnvFromSource{catalystSecretsEnvFrom()},
+							VolumeMounts:   volumeMounts,
+							ReadinessProbe: composeHealthcheckProbe(service.Healthcheck),
 						},
 					},
+					Volumes: volumes,
 				},
 			},
 		},
 	}
+	applyPodSecurity(&deployment.Spec.Template.Spec)
+	return deployment
 }
 
 func (r *EnvironmentReconciler) desiredComposeService(namespace, name string, service ComposeService) *corev1.Service {
-	ports := []corev1.ServicePort{}
-	for _, p := range service.Ports {
-		// Parse port: "80", "8080:80", or "127.0.0.1:8080:80"
-		var hostPort, containerPort int
-
-		// Try to parse "hostPort:containerPort" format (error ignored as we check count)
-		if n, _ := fmt.Sscanf(p, "%d:%d", &hostPort, &containerPort); n == 2 {
-			// Use the container port when both host and container ports are specified
-			ports = append(ports, corev1.ServicePort{
-				Name:       fmt.Sprintf("port-%d", containerPort),
-				Port:       int32(containerPort),
-				TargetPort: intstr.FromInt(containerPort),
-			})
-		} else if _, err := fmt.Sscanf(p, "%d", &containerPort); err == nil {
-			// Single port specification like "80"
-			ports = append(ports, corev1.ServicePort{
-				Name:       fmt.Sprintf("port-%d", containerPort),
-				Port:       int32(containerPort),
-				TargetPort: intstr.FromInt(containerPort),
-			})
-		}
-		// Skip if port cannot be parsed
-	}
-
 	return &corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      name,
@@ -260,29 +402,296 @@ func (r *EnvironmentReconciler) desiredComposeService(namespace, name string, se
 		},
 		Spec: corev1.ServiceSpec{
 			Selector: map[string]string{"app": name},
-			Ports:    ports,
+			Ports:    composeServicePorts(name, service),
 		},
 	}
 }
 
-func (r *EnvironmentReconciler) patchOrUpdate(ctx context.Context, obj client.Object) error {
-	// Simple create or update logic
-	existing := obj.DeepCopyObject().(client.Object)
+// composeServicePorts translates the published and exposed ports of a service into Service
+// ports, one per container port and protocol
+func composeServicePorts(name string, service ComposeService) []corev1.ServicePort {
+	log := logf.Log.WithName("compose-deploy")
+	seen := map[composePort]bool{}
+	ports := []corev1.ServicePort{}
+	for _, node := range append(append([]yaml.Node{}, service.Ports...), service.Expose...) {
+		parsed, err := parseComposePort(&node)
+		if err != nil {
+			log.Info("Skipping port", "service", name, "port", node.Value, "reason", err.Error())
+			continue
+		}
+		for _, p := range parsed {
+			if seen[p] {
+				continue
+			}
+			seen[p] = true
+			ports = append(ports, corev1.ServicePort{
+				Name:       composePortName(p),
+				Port:       p.port,
+				Protocol:   p.protocol,
+				TargetPort: intstr.FromInt32(p.port),
+			})
+		}
+	}
+	return ports
+}
 
-	err := r.Get(ctx, client.ObjectKey{Name: obj.GetName(), Namespace: obj.GetNamespace()}, existing)
-	if err != nil {
-		if apierrors.IsNotFound(err) {
-			return r.Create(ctx, obj)
+// desiredComposePVCs creates a PVC for every named volume declared at the top level, with the
+// project storage class and size when set
+func desiredComposePVCs(namespace string, compose *DockerCompose, storage *catalystv1alpha1.StorageDefaultsSpec) []*corev1.PersistentVolumeClaim {
+	names := make([]string, 0, len(compose.Volumes))
+	for name := range compose.Volumes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pvcs := make([]*corev1.PersistentVolumeClaim, 0, len(names))
+	for _, name := range names {
+		pvc := &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      composeVolumeName(name),
+				Namespace: namespace,
+				Labels: map[string]string{
+					"catalyst.dev/compose-volume": sanitizeLabelValue(name),
+				},
+			},
+			Spec: corev1.PersistentVolumeClaimSpec{
+				AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+				Resources: corev1.VolumeResourceRequirements{
+					Requests: corev1.ResourceList{
+						corev1.ResourceStorage: resource.MustParse(composeVolumeSize),
+					},
+				},
+			},
+		}
+		if storage != nil && storage.Size != nil {
+			pvc.Spec.Resources.Requests[corev1.ResourceStorage] = storage.Size.DeepCopy()
+		}
+		applyStorageDefaults(&pvc.Spec, storage)
+		pvcs = append(pvcs, pvc)
+	}
+	return pvcs
+}
+
+// composeVolumeName converts a compose volume name into a DNS-1123 compliant PVC name
+func composeVolumeName(name string) string {
+	return strings.ToLower(strings.ReplaceAll(sanitizeLabelValue(name), "_", "-"))
+}
+
+// composeVolume is a volume entry of a compose service
+type composeVolume struct {
+	kind     string // volume, bind or tmpfs
+	source   string
+	target   string
+	readOnly bool
+}
+
+// parseComposeVolume parses a service volume entry: short syntax ("/path", "name:/path[:ro]",
+// "./src:/app") or long syntax (type, source, target, read_only)
+func parseComposeVolume(node *yaml.Node) (composeVolume, error) {
+	var v composeVolume
+	switch node.Kind {
+	case yaml.ScalarNode:
+		parts := strings.Split(node.Value, ":")
+		v.target = parts[0]
+		if len(parts) > 1 {
+			v.source, v.target = parts[0], parts[1]
+		}
+		v.readOnly = len(parts) > 2 && parts[2] == "ro"
+		switch {
+		case v.source == "":
+			v.kind = "volume"
+		case strings.HasPrefix(v.source, ".") || strings.HasPrefix(v.source, "/") || strings.HasPrefix(v.source, "~"):
+			v.kind = "bind"
+		default:
+			v.kind = "volume"
+		}
+	case yaml.MappingNode:
+		v.kind = "volume"
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			value := node.Content[i+1]
+			switch node.Content[i].Value {
+			case "type":
+				v.kind = value.Value
+			case "source":
+				v.source = value.Value
+			case "target":
+				v.target = value.Value
+			case "read_only":
+				if err := value.Decode(&v.readOnly); err != nil {
+					return v, fmt.Errorf("invalid read_only %q", value.Value)
+				}
+			}
+		}
+	default:
+		return v, fmt.Errorf("unsupported volume syntax")
+	}
+	if v.target == "" {
+		return v, fmt.Errorf("volume has no target")
+	}
+	return v, nil
+}
+
+// composeVolumes translates service volumes into pod volumes and mounts. Named volumes map to
+// PVCs, anonymous volumes ("/path") to emptyDir and tmpfs mounts to memory-backed emptyDir.
+// Bind mounts ("./src:/app") have no cluster equivalent and are skipped.
+func composeVolumes(serviceName string, service ComposeService, compose *DockerCompose) ([]corev1.Volume, []corev1.VolumeMount) {
+	log := logf.Log.WithName("compose-deploy")
+	var volumes []corev1.Volume
+	var mounts []corev1.VolumeMount
+
+	for i := range service.Volumes {
+		v, err := parseComposeVolume(&service.Volumes[i])
+		if err != nil {
+			log.Info("Skipping volume", "service", serviceName, "volume", service.Volumes[i].Value, "reason", err.Error())
+			continue
+		}
+
+		switch {
+		case v.kind == "tmpfs" || (v.kind == "volume" && v.source == ""):
+			volName := fmt.Sprintf("anon-%d", i)
+			emptyDir := &corev1.EmptyDirVolumeSource{}
+			if v.kind == "tmpfs" {
+				emptyDir.Medium = corev1.StorageMediumMemory
+			}
+			volumes = append(volumes, corev1.Volume{
+				Name:         volName,
+				VolumeSource: corev1.VolumeSource{EmptyDir: emptyDir},
+			})
+			mounts = append(mounts, corev1.VolumeMount{Name: volName, MountPath: v.target, ReadOnly: v.readOnly})
+		case v.kind == "volume":
+			if _, declared := compose.Volumes[v.source]; !declared {
+				log.Info("Skipping undeclared named volume", "service", serviceName, "volume", v.source)
+				continue
+			}
+			volName := composeVolumeName(v.source)
+			volumes = append(volumes, corev1.Volume{
+				Name: volName,
+				VolumeSource: corev1.VolumeSource{
+					PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: volName},
+				},
+			})
+			mounts = append(mounts, corev1.VolumeMount{Name: volName, MountPath: v.target, ReadOnly: v.readOnly})
+		default:
+			log.Info("Skipping volume (not supported in cluster)", "service", serviceName, "type", v.kind, "source", v.source)
+		}
+	}
+
+	return volumes, mounts
+}
+
+// composeDependsOn returns the service names listed in depends_on (list or map format)
+func composeDependsOn(service ComposeService) []string {
+	deps := []string{}
+	switch service.DependsOn.Kind {
+	case yaml.SequenceNode:
+		for _, item := range service.DependsOn.Content {
+			deps = append(deps, item.Value)
+		}
+	case yaml.MappingNode:
+		for i := 0; i < len(service.DependsOn.Content); i += 2 {
+			deps = append(deps, service.DependsOn.Content[i].Value)
+		}
+	}
+	sort.Strings(deps)
+	return deps
+}
+
+// composeDependsOnInitContainers creates one init container per dependency that waits
+// until the dependency's Service accepts TCP connections. Services only route to ready
+// pods, so this also honors "condition: service_healthy" when the dependency has a healthcheck.
+func composeDependsOnInitContainers(serviceName string, service ComposeService, compose *DockerCompose) []corev1.Container {
+	log := logf.Log.WithName("compose-deploy")
+	var initContainers []corev1.Container
+
+	for _, dep := range composeDependsOn(service) {
+		depService, ok := compose.Services[dep]
+		if !ok {
+			log.Info("Skipping unknown depends_on service", "service", serviceName, "dependsOn", dep)
+			continue
+		}
+		var port int32
+		for _, p := range composeServicePorts(dep, depService) {
+			if p.Protocol == corev1.ProtocolTCP {
+				port = p.Port
+				break
+			}
+		}
+		if port == 0 {
+			log.Info("Skipping depends_on without exposed TCP ports", "service", serviceName, "dependsOn", dep)
+			continue
 		}
-		return err
+		initContainers = append(initContainers, waitForInitContainer(waitTarget{name: composeVolumeName(dep), host: dep, port: port}))
 	}
 
-	// Update: preserve important metadata from the existing object
-	obj.SetResourceVersion(existing.GetResourceVersion())
-	obj.SetUID(existing.GetUID())
-	obj.SetGeneration(existing.GetGeneration())
-	obj.SetManagedFields(existing.GetManagedFields())
-	return r.Update(ctx, obj)
+	return initContainers
+}
+
+// composeHealthcheckProbe translates a compose healthcheck into an exec readiness probe
+func composeHealthcheckProbe(hc *ComposeHealthcheck) *corev1.Probe {
+	if hc == nil || hc.Disable {
+		return nil
+	}
+
+	var command []string
+	switch hc.Test.Kind {
+	case yaml.ScalarNode:
+		command = []string{"/bin/sh", "-c", hc.Test.Value}
+	case yaml.SequenceNode:
+		items := make([]string, 0, len(hc.Test.Content))
+		for _, item := range hc.Test.Content {
+			items = append(items, item.Value)
+		}
+		if len(items) == 0 {
+			return nil
+		}
+		switch items[0] {
+		case "NONE":
+			return nil
+		case "CMD":
+			command = items[1:]
+		case "CMD-SHELL":
+			command = []string{"/bin/sh", "-c", strings.Join(items[1:], " ")}
+		default:
+			command = items
+		}
+	}
+	if len(command) == 0 {
+		return nil
+	}
+
+	probe := &corev1.Probe{
+		ProbeHandler: corev1.ProbeHandler{
+			Exec: &corev1.ExecAction{Command: command},
+		},
+		PeriodSeconds:       composeDurationSeconds(hc.Interval, 30),
+		TimeoutSeconds:      composeDurationSeconds(hc.Timeout, 30),
+		InitialDelaySeconds: composeDurationSeconds(hc.StartPeriod, 0),
+		FailureThreshold:    3,
+	}
+	if hc.Retries > 0 {
+		probe.FailureThreshold = hc.Retries
+	}
+	return probe
+}
+
+// composeDurationSeconds parses a compose duration ("30s", "1m30s") into whole seconds
+func composeDurationSeconds(s string, fallback int32) int32 {
+	if s == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return fallback
+	}
+	seconds := int32(d.Seconds())
+	if seconds < 1 && fallback > 0 {
+		return 1
+	}
+	return seconds
+}
+
+func (r *EnvironmentReconciler) patchOrUpdate(ctx context.Context, obj client.Object) error {
+	return createOrReplace(ctx, r.Client, obj)
 }
 
 // splitEnvVar parses an environment variable string in docker-compose list format.
@@ -300,3 +709,120 @@ func splitEnvVar(s string) []string {
 	}
 	return nil
 }
+
+// composeProfileEnabled reports whether a service is deployed with the selected profiles.
+// Services without profiles are always deployed; selecting "*" enables every profile.
+func composeProfileEnabled(service ComposeService, selected []string) bool {
+	if len(service.Profiles) == 0 || slices.Contains(selected, "*") {
+		return true
+	}
+	for _, profile := range service.Profiles {
+		if slices.Contains(selected, profile) {
+			return true
+		}
+	}
+	return false
+}
+
+// composeEnvFile is one env_file entry. Files are required unless the long syntax says otherwise:
+//
+//	env_file:
+//	  - path: ./.env.local
+//	    required: false
+type composeEnvFile struct {
+	Path     string
+	Required bool
+}
+
+// composeEnvFiles normalizes the string, list and long syntax forms of env_file
+func composeEnvFiles(node yaml.Node) []composeEnvFile {
+	var files []composeEnvFile
+	switch node.Kind {
+	case yaml.ScalarNode:
+		files = append(files, composeEnvFile{Path: node.Value, Required: true})
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			switch item.Kind {
+			case yaml.ScalarNode:
+				files = append(files, composeEnvFile{Path: item.Value, Required: true})
+			case yaml.MappingNode:
+				file := composeEnvFile{Required: true}
+				for i := 0; i+1 < len(item.Content); i += 2 {
+					switch item.Content[i].Value {
+					case "path":
+						file.Path = item.Content[i+1].Value
+					case "required":
+						file.Required = item.Content[i+1].Value != "false"
+					}
+				}
+				files = append(files, file)
+			}
+		}
+	}
+	return files
+}
+
+// readComposeEnvFiles reads the env_file entries of a service, resolved against the directory of
+// the compose file. Paths must stay inside that directory. Later files override earlier ones.
+func readComposeEnvFiles(dir string, service ComposeService) ([]corev1.EnvVar, error) {
+	var envVars []corev1.EnvVar
+	for _, file := range composeEnvFiles(service.EnvFile) {
+		if file.Path == "" {
+			continue
+		}
+		path := filepath.Clean(file.Path)
+		if !filepath.IsLocal(path) {
+			return nil, fmt.Errorf("env_file %s must be a relative path inside the repository", file.Path)
+		}
+		data, err := os.ReadFile(filepath.Join(dir, path))
+		if os.IsNotExist(err) && !file.Required {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read env_file %s: %w", file.Path, err)
+		}
+		envVars = mergeComposeEnv(envVars, parseEnvFile(string(data)))
+	}
+	return envVars, nil
+}
+
+// parseEnvFile parses KEY=value lines. Blank lines, "#" comments and an "export " prefix are
+// ignored, and single or double quotes around values are removed. Lines without "=" would be
+// taken from the host environment by docker compose and are skipped.
+func parseEnvFile(data string) []corev1.EnvVar {
+	var envVars []corev1.EnvVar
+	for _, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := splitEnvVar(strings.TrimPrefix(line, "export "))
+		if parts == nil {
+			continue
+		}
+		key := strings.TrimSpace(parts[0])
+		value := strings.TrimSpace(parts[1])
+		if n := len(value); n >= 2 && (value[0] == '"' || value[0] == '\'') && value[n-1] == value[0] {
+			value = value[1 : n-1]
+		} else if i := strings.Index(value, " #"); i >= 0 {
+			value = strings.TrimSpace(value[:i])
+		}
+		envVars = append(envVars, corev1.EnvVar{Name: key, Value: value})
+	}
+	return envVars
+}
+
+// mergeComposeEnv overlays overrides onto base by name, keeping the order of first appearance
+func mergeComposeEnv(base, overrides []corev1.EnvVar) []corev1.EnvVar {
+	merged := make([]corev1.EnvVar, 0, len(base)+len(overrides))
+	index := map[string]int{}
+	for _, envVar := range append(append([]corev1.EnvVar{}, base...), overrides...) {
+		if i, ok := index[envVar.Name]; ok {
+			merged[i] = envVar
+			continue
+		}
+		index[envVar.Name] = len(merged)
+		merged = append(merged, envVar)
+	}
+	return merged
+}
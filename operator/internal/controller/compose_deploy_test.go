@@ -0,0 +1,281 @@
+package controller
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+const testComposeFile = `
+services:
+  db:
+    image: postgres:16
+    ports:
+      - "5432"
+    volumes:
+      - db_data:/var/lib/postgresql/data
+    healthcheck:
+      test: ["CMD-SHELL", "pg_isready -U postgres"]
+      interval: 10s
+      timeout: 5s
+      retries: 5
+  web:
+    image: node:22
+    ports:
+      - "3000:3000"
+    volumes:
+      - ./src:/app/src
+      - /app/node_modules
+    depends_on:
+      db:
+        condition: service_healthy
+volumes:
+  db_data:
+`
+
+func parseTestCompose(t *testing.T) DockerCompose {
+	var compose DockerCompose
+	require.NoError(t, yaml.Unmarshal([]byte(testComposeFile), &compose))
+	return compose
+}
+
+func TestDesiredComposePVCs(t *testing.T) {
+	compose := parseTestCompose(t)
+
+	pvcs := desiredComposePVCs("test-ns", &compose, nil)
+	require.Len(t, pvcs, 1)
+	assert.Equal(t, "db-data", pvcs[0].Name)
+	assert.Equal(t, "test-ns", pvcs[0].Namespace)
+	assert.Equal(t, composeVolumeSize, pvcs[0].Spec.Resources.Requests.Storage().String())
+	assert.Nil(t, pvcs[0].Spec.StorageClassName)
+
+	// Project storage defaults
+	pvcs = desiredComposePVCs("test-ns", &compose, &catalystv1alpha1.StorageDefaultsSpec{StorageClassName: ptr("fast"), Size: ptr(resource.MustParse("8Gi"))})
+	assert.Equal(t, "8Gi", pvcs[0].Spec.Resources.Requests.Storage().String())
+	assert.Equal(t, "fast", *pvcs[0].Spec.StorageClassName)
+}
+
+func TestComposeVolumes(t *testing.T) {
+	compose := parseTestCompose(t)
+
+	volumes, mounts := composeVolumes("db", compose.Services["db"], &compose)
+	require.Len(t, volumes, 1)
+	assert.Equal(t, "db-data", volumes[0].PersistentVolumeClaim.ClaimName)
+	assert.Equal(t, "/var/lib/postgresql/data", mounts[0].MountPath)
+
+	// Bind mount skipped, anonymous volume becomes emptyDir
+	volumes, mounts = composeVolumes("web", compose.Services["web"], &compose)
+	require.Len(t, volumes, 1)
+	assert.NotNil(t, volumes[0].EmptyDir)
+	assert.Equal(t, "/app/node_modules", mounts[0].MountPath)
+}
+
+func TestComposeDependsOnInitContainers(t *testing.T) {
+	compose := parseTestCompose(t)
+
+	initContainers := composeDependsOnInitContainers("web", compose.Services["web"], &compose)
+	require.Len(t, initContainers, 1)
+	assert.Equal(t, "wait-for-db", initContainers[0].Name)
+	assert.Contains(t, initContainers[0].Command[2], "nc -z db 5432")
+}
+
+func TestComposeHealthcheckProbe(t *testing.T) {
+	compose := parseTestCompose(t)
+
+	probe := composeHealthcheckProbe(compose.Services["db"].Healthcheck)
+	require.NotNil(t, probe)
+	assert.Equal(t, []string{"/bin/sh", "-c", "pg_isready -U postgres"}, probe.Exec.Command)
+	assert.Equal(t, int32(10), probe.PeriodSeconds)
+	assert.Equal(t, int32(5), probe.TimeoutSeconds)
+	assert.Equal(t, int32(5), probe.FailureThreshold)
+
+	assert.Nil(t, composeHealthcheckProbe(nil))
+	assert.Nil(t, composeHealthcheckProbe(&ComposeHealthcheck{Disable: true}))
+}
+
+func TestDesiredComposeDeployment_Translation(t *testing.T) {
+	compose := parseTestCompose(t)
+	r := &EnvironmentReconciler{}
+
+	deploy := r.desiredComposeDeployment("test-ns", "web", "node:22", compose.Services["web"], nil, &catalystv1alpha1.Environment{}, &compose)
+	assert.Len(t, deploy.Spec.Template.Spec.InitContainers, 1)
+	assert.Len(t, deploy.Spec.Template.Spec.Volumes, 2) // Anonymous volume and /tmp
+	assert.Nil(t, deploy.Spec.Template.Spec.Containers[0].ReadinessProbe)
+}
+
+func TestComposeProfileEnabled(t *testing.T) {
+	var compose DockerCompose
+	require.NoError(t, yaml.Unmarshal([]byte(`
+services:
+  web:
+    image: node:22
+  mailhog:
+    image: mailhog/mailhog
+    profiles: [mail, debug]
+`), &compose))
+
+	assert.True(t, composeProfileEnabled(compose.Services["web"], nil))
+	assert.False(t, composeProfileEnabled(compose.Services["mailhog"], nil))
+	assert.False(t, composeProfileEnabled(compose.Services["mailhog"], []string{"search"}))
+	assert.True(t, composeProfileEnabled(compose.Services["mailhog"], []string{"debug"}))
+	assert.True(t, composeProfileEnabled(compose.Services["mailhog"], []string{"*"}))
+}
+
+func TestReadComposeEnvFiles(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".env"), []byte(`
+# shared defaults
+export NODE_ENV=development
+DATABASE_URL="postgres://db:5432/app"
+GREETING='hello # world'
+PORT=3000 # web port
+HOST_ONLY
+`), 0600))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "web"), 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "web", ".env"), []byte("PORT=4000\n"), 0600))
+
+	var compose DockerCompose
+	require.NoError(t, yaml.Unmarshal([]byte(`
+services:
+  web:
+    image: node:22
+    env_file:
+      - .env
+      - path: web/.env
+      - path: .env.local
+        required: false
+    environment:
+      NODE_ENV: test
+  worker:
+    image: node:22
+    env_file: .env.missing
+  escape:
+    image: node:22
+    env_file: ../secrets.env
+`), &compose))
+
+	envVars, err := readComposeEnvFiles(dir, compose.Services["web"])
+	require.NoError(t, err)
+	assert.Equal(t, []corev1.EnvVar{
+		{Name: "NODE_ENV", Value: "development"},
+		{Name: "DATABASE_URL", Value: "postgres://db:5432/app"},
+		{Name: "GREETING", Value: "hello # world"},
+		{Name: "PORT", Value: "4000"},
+	}, envVars)
+
+	deploy := (&EnvironmentReconciler{}).desiredComposeDeployment("test-ns", "web", "node:22", compose.Services["web"], envVars, &catalystv1alpha1.Environment{}, &compose)
+	env := deploy.Spec.Template.Spec.Containers[0].Env
+	require.Len(t, env, 4)
+	assert.Equal(t, corev1.EnvVar{Name: "NODE_ENV", Value: "test"}, env[0], "environment wins over env_file")
+
+	_, err = readComposeEnvFiles(dir, compose.Services["worker"])
+	assert.ErrorContains(t, err, "failed to read env_file .env.missing")
+
+	_, err = readComposeEnvFiles(dir, compose.Services["escape"])
+	assert.ErrorContains(t, err, "inside the repository")
+}
+
+func TestComposeVolumes_LongSyntax(t *testing.T) {
+	var compose DockerCompose
+	require.NoError(t, yaml.Unmarshal([]byte(`
+services:
+  db:
+    image: postgres:16
+    volumes:
+      - type: volume
+        source: db_data
+        target: /var/lib/postgresql/data
+      - type: volume
+        source: backups
+        target: /backups
+        read_only: true
+      - type: bind
+        source: ./init.sql
+        target: /docker-entrypoint-initdb.d/init.sql
+      - type: tmpfs
+        target: /run/postgresql
+      - type: volume
+        target: /scratch
+      - cache:/cache
+volumes:
+  db_data:
+  backups:
+  cache:
+`), &compose), "long and short syntax mix in one service")
+
+	volumes, mounts := composeVolumes("db", compose.Services["db"], &compose)
+	require.Len(t, volumes, 5, "the bind mount is skipped")
+	assert.Equal(t, "db-data", volumes[0].PersistentVolumeClaim.ClaimName)
+	assert.Equal(t, corev1.VolumeMount{Name: "db-data", MountPath: "/var/lib/postgresql/data"}, mounts[0])
+	assert.Equal(t, corev1.VolumeMount{Name: "backups", MountPath: "/backups", ReadOnly: true}, mounts[1])
+	assert.Equal(t, corev1.StorageMediumMemory, volumes[2].EmptyDir.Medium, "tmpfs is memory-backed")
+	assert.Equal(t, "/run/postgresql", mounts[2].MountPath)
+	assert.Equal(t, corev1.StorageMediumDefault, volumes[3].EmptyDir.Medium, "volumes without a source are anonymous")
+	assert.Equal(t, "/scratch", mounts[3].MountPath)
+	assert.Equal(t, "cache", volumes[4].PersistentVolumeClaim.ClaimName)
+
+	_, err := parseComposeVolume(&yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{
+		{Kind: yaml.ScalarNode, Value: "source"}, {Kind: yaml.ScalarNode, Value: "db_data"},
+	}})
+	assert.EqualError(t, err, "volume has no target")
+}
+
+func TestComposeHealthcheckProbe_TestForms(t *testing.T) {
+	var compose DockerCompose
+	require.NoError(t, yaml.Unmarshal([]byte(`
+services:
+  shell:
+    image: redis:7
+    healthcheck:
+      test: redis-cli ping
+  exec:
+    image: redis:7
+    healthcheck:
+      test: ["CMD", "redis-cli", "ping"]
+  none:
+    image: redis:7
+    healthcheck:
+      test: ["NONE"]
+`), &compose))
+
+	probe := composeHealthcheckProbe(compose.Services["shell"].Healthcheck)
+	require.NotNil(t, probe)
+	assert.Equal(t, []string{"/bin/sh", "-c", "redis-cli ping"}, probe.Exec.Command)
+	assert.Equal(t, int32(30), probe.PeriodSeconds)
+	assert.Equal(t, int32(3), probe.FailureThreshold)
+
+	probe = composeHealthcheckProbe(compose.Services["exec"].Healthcheck)
+	require.NotNil(t, probe)
+	assert.Equal(t, []string{"redis-cli", "ping"}, probe.Exec.Command)
+
+	assert.Nil(t, composeHealthcheckProbe(compose.Services["none"].Healthcheck))
+}
+
+func TestComposeDependsOnInitContainers_ListFormat(t *testing.T) {
+	var compose DockerCompose
+	require.NoError(t, yaml.Unmarshal([]byte(`
+services:
+  web:
+    image: node:22
+    depends_on: [cache, worker, missing]
+  cache:
+    image: redis:7
+    expose: ["6379"]
+  worker:
+    image: node:22
+`), &compose))
+
+	assert.Equal(t, []string{"cache", "missing", "worker"}, composeDependsOn(compose.Services["web"]))
+	initContainers := composeDependsOnInitContainers("web", compose.Services["web"], &compose)
+	require.Len(t, initContainers, 1, "services without ports and unknown services are not waited for")
+	assert.Equal(t, "wait-for-cache", initContainers[0].Name)
+	assert.Contains(t, initContainers[0].Command[2], "nc -z cache 6379")
+}
@@ -0,0 +1,343 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+// Compose files, as docker compose reads them:
+//   - docker-compose.override.yml (or .yaml) next to the compose file is merged onto it.
+//     Mappings merge by key, environment and labels by variable name (list or map syntax),
+//     volumes by container path, and ports, expose and the other multi-value options
+//     concatenate; any other value of the override replaces the original.
+//   - ${VAR}, $VAR, ${VAR:-default}, ${VAR-default}, ${VAR:?error}, ${VAR?error},
+//     ${VAR:+replacement} and ${VAR+replacement} are interpolated in values, "$$" escapes a
+//     dollar. Variables come from the .env file next to the compose file, the synced
+//     catalyst-secrets and the resolved config env, later ones winning. Unset variables
+//     without a default interpolate to an empty string.
+
+// composeConcatenatedKeys are the multi-value service options an override adds to
+var composeConcatenatedKeys = []string{"ports", "expose", "dns", "dns_search", "tmpfs", "external_links", "cap_add", "cap_drop"}
+
+// composeKeyedKeys are the service options an override merges by key, in list ("KEY=value" or
+// "name") or map syntax
+var composeKeyedKeys = []string{"environment", "labels", "depends_on", "networks"}
+
+// composeFilePath returns the path of the compose file of a source checkout named name
+// (e.g. "docker-compose"), with the .yml or .yaml extension, and whether it exists
+func composeFilePath(sourcePath, name string) (string, bool) {
+	for _, ext := range []string{".yml", ".yaml"} {
+		path := filepath.Join(sourcePath, name+ext)
+		if _, err := os.Stat(path); err == nil {
+			return path, true
+		}
+	}
+	return filepath.Join(sourcePath, name+".yml"), false
+}
+
+// loadComposeNode reads and merges the compose file of a source checkout and its override
+func loadComposeNode(sourcePath string) (*yaml.Node, error) {
+	path, _ := composeFilePath(sourcePath, "docker-compose")
+	root, err := readYAMLNode(path)
+	if err != nil {
+		return nil, err
+	}
+	if path, ok := composeFilePath(sourcePath, "docker-compose.override"); ok {
+		override, err := readYAMLNode(path)
+		if err != nil {
+			return nil, err
+		}
+		root = mergeComposeNode(root, override, "")
+	}
+	return root, nil
+}
+
+// readYAMLNode parses a YAML file into the node of its (first) document
+func readYAMLNode(path string) (*yaml.Node, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 {
+		return &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}, nil
+	}
+	return doc.Content[0], nil
+}
+
+// mergeComposeNode merges override onto base, the values of an option named key
+func mergeComposeNode(base, override *yaml.Node, key string) *yaml.Node {
+	if slices.Contains(composeKeyedKeys, key) && (base.Kind != override.Kind || base.Kind == yaml.SequenceNode) {
+		base, override = composeKeyedMapping(base), composeKeyedMapping(override)
+	}
+	switch {
+	case base.Kind == yaml.MappingNode && override.Kind == yaml.MappingNode:
+		merged := &yaml.Node{Kind: yaml.MappingNode, Tag: base.Tag, Content: slices.Clone(base.Content)}
+		for i := 0; i+1 < len(override.Content); i += 2 {
+			name, value := override.Content[i], override.Content[i+1]
+			if j := mappingKeyIndex(merged, name.Value); j >= 0 {
+				merged.Content[j+1] = mergeComposeNode(merged.Content[j+1], value, name.Value)
+			} else {
+				merged.Content = append(merged.Content, name, value)
+			}
+		}
+		return merged
+	case base.Kind == yaml.SequenceNode && override.Kind == yaml.SequenceNode && key == "volumes":
+		merged := &yaml.Node{Kind: yaml.SequenceNode, Tag: base.Tag}
+		for _, item := range base.Content {
+			if !slices.ContainsFunc(override.Content, func(n *yaml.Node) bool { return composeVolumeTarget(n) == composeVolumeTarget(item) }) {
+				merged.Content = append(merged.Content, item)
+			}
+		}
+		merged.Content = append(merged.Content, override.Content...)
+		return merged
+	case base.Kind == yaml.SequenceNode && override.Kind == yaml.SequenceNode && slices.Contains(composeConcatenatedKeys, key):
+		merged := &yaml.Node{Kind: yaml.SequenceNode, Tag: base.Tag, Content: slices.Clone(base.Content)}
+		for _, item := range override.Content {
+			if item.Kind != yaml.ScalarNode || !slices.ContainsFunc(merged.Content, func(n *yaml.Node) bool { return n.Kind == yaml.ScalarNode && n.Value == item.Value }) {
+				merged.Content = append(merged.Content, item)
+			}
+		}
+		return merged
+	}
+	return override
+}
+
+// composeKeyedMapping converts the list syntax of a keyed option ("KEY=value", or a name) to
+// its map syntax
+func composeKeyedMapping(node *yaml.Node) *yaml.Node {
+	if node.Kind != yaml.SequenceNode {
+		return node
+	}
+	mapping := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	for _, item := range node.Content {
+		if item.Kind != yaml.ScalarNode {
+			continue
+		}
+		valueNode := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null"}
+		name, value, hasValue := strings.Cut(item.Value, "=")
+		if hasValue {
+			valueNode = &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+		}
+		mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: name}, valueNode)
+	}
+	return mapping
+}
+
+// mappingKeyIndex returns the index of the key node named key of a mapping node, -1 if absent
+func mappingKeyIndex(mapping *yaml.Node, key string) int {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// composeVolumeTarget returns the container path of a volume entry, short or long syntax
+func composeVolumeTarget(node *yaml.Node) string {
+	if node.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == "target" {
+				return node.Content[i+1].Value
+			}
+		}
+		return ""
+	}
+	parts := strings.Split(node.Value, ":")
+	if len(parts) == 1 {
+		return parts[0]
+	}
+	return parts[1]
+}
+
+// composeVariables returns the variables the compose file is interpolated with: the synced
+// catalyst-secrets overlaid with the literal values of the resolved config env
+func (r *EnvironmentReconciler) composeVariables(ctx context.Context, env *catalystv1alpha1.Environment, template *catalystv1alpha1.EnvironmentTemplateSpec, namespace string) (map[string]string, error) {
+	variables := map[string]string{}
+	secret := &corev1.Secret{}
+	if err := r.Get(ctx, client.ObjectKey{Name: catalystSecretsName, Namespace: namespace}, secret); err == nil {
+		for name, value := range secret.Data {
+			variables[name] = string(value)
+		}
+	} else if !apierrors.IsNotFound(err) {
+		return nil, err
+	}
+	for _, envVar := range resolveConfig(&env.Spec.Config, template.Config).Env {
+		if envVar.ValueFrom == nil {
+			variables[envVar.Name] = envVar.Value
+		}
+	}
+	return variables, nil
+}
+
+// interpolateComposeNode interpolates the scalar values of a compose document in place
+func interpolateComposeNode(node *yaml.Node, lookup func(string) (string, bool)) error {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		value, err := interpolateCompose(node.Value, lookup)
+		if err != nil {
+			return err
+		}
+		if value != node.Value {
+			node.Value = value
+			if node.Style == 0 {
+				// Resolve the tag of the interpolated value, e.g. an int from ${PORT:-8080}
+				node.Tag = ""
+			}
+		}
+	case yaml.MappingNode:
+		// Keys are not interpolated
+		for i := 1; i < len(node.Content); i += 2 {
+			if err := interpolateComposeNode(node.Content[i], lookup); err != nil {
+				return err
+			}
+		}
+	case yaml.SequenceNode, yaml.DocumentNode:
+		for _, item := range node.Content {
+			if err := interpolateComposeNode(item, lookup); err != nil {
+				return err
+			}
+		}
+	}
+	// Aliases point at anchored nodes, interpolated where they are defined
+	return nil
+}
+
+// interpolateCompose substitutes the variables of s, see the compose files comment above
+func interpolateCompose(s string, lookup func(string) (string, bool)) (string, error) {
+	var out strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' || i+1 == len(s) {
+			out.WriteByte(s[i])
+			continue
+		}
+		switch next := s[i+1]; {
+		case next == '$':
+			out.WriteByte('$')
+			i++
+		case next == '{':
+			end := matchingBrace(s, i+1)
+			if end < 0 {
+				return "", fmt.Errorf("invalid interpolation format for %q: missing }", s)
+			}
+			value, err := interpolateComposeExpression(s[i+2:end], lookup)
+			if err != nil {
+				return "", err
+			}
+			out.WriteString(value)
+			i = end
+		case isComposeVariableStart(next):
+			end := i + 1
+			for end < len(s) && isComposeVariableChar(s[end]) {
+				end++
+			}
+			value, _ := lookup(s[i+1 : end])
+			out.WriteString(value)
+			i = end - 1
+		default:
+			out.WriteByte('$')
+		}
+	}
+	return out.String(), nil
+}
+
+// interpolateComposeExpression evaluates the expression between "${" and "}"
+func interpolateComposeExpression(expression string, lookup func(string) (string, bool)) (string, error) {
+	end := 0
+	for end < len(expression) && isComposeVariableChar(expression[end]) {
+		end++
+	}
+	name, modifier := expression[:end], expression[end:]
+	if name == "" || !isComposeVariableStart(name[0]) {
+		return "", fmt.Errorf("invalid interpolation format for ${%s}", expression)
+	}
+	value, set := lookup(name)
+	if modifier == "" {
+		return value, nil
+	}
+	// With a colon, empty variables count as unset
+	operator, argument := modifier[:1], modifier[1:]
+	unset := !set
+	if operator == ":" && len(modifier) > 1 {
+		operator, argument = modifier[1:2], modifier[2:]
+		unset = value == ""
+	}
+	switch operator {
+	case "-":
+		if unset {
+			return interpolateCompose(argument, lookup)
+		}
+		return value, nil
+	case "+":
+		if unset {
+			return "", nil
+		}
+		return interpolateCompose(argument, lookup)
+	case "?":
+		if unset {
+			message, err := interpolateCompose(argument, lookup)
+			if err != nil {
+				return "", err
+			}
+			return "", fmt.Errorf("required variable %s is missing a value: %s", name, message)
+		}
+		return value, nil
+	}
+	return "", fmt.Errorf("invalid interpolation format for ${%s}", expression)
+}
+
+// matchingBrace returns the index of the "}" closing the "{" at open, skipping nested ones
+func matchingBrace(s string, open int) int {
+	depth := 0
+	for i := open; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func isComposeVariableStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isComposeVariableChar(c byte) bool {
+	return isComposeVariableStart(c) || (c >= '0' && c <= '9')
+}
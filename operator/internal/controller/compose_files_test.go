@@ -0,0 +1,133 @@
+package controller
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+func TestInterpolateCompose(t *testing.T) {
+	variables := map[string]string{"HOST": "db", "EMPTY": "", "PORT": "5432"}
+	lookup := func(name string) (string, bool) {
+		value, ok := variables[name]
+		return value, ok
+	}
+	tests := []struct {
+		in, want string
+	}{
+		{"postgres://${HOST}:$PORT/app", "postgres://db:5432/app"},
+		{"${MISSING}", ""},
+		{"${MISSING:-fallback}", "fallback"},
+		{"${EMPTY:-fallback}", "fallback"},
+		{"${EMPTY-fallback}", ""},
+		{"${MISSING-fallback}", "fallback"},
+		{"${HOST:+set}", "set"},
+		{"${EMPTY:+set}", ""},
+		{"${EMPTY+set}", "set"},
+		{"${MISSING:-${HOST}:${PORT}}", "db:5432"},
+		{"$$HOST costs $5", "$HOST costs $5"},
+		{"trailing $", "trailing $"},
+	}
+	for _, tt := range tests {
+		got, err := interpolateCompose(tt.in, lookup)
+		require.NoError(t, err, tt.in)
+		assert.Equal(t, tt.want, got, tt.in)
+	}
+
+	_, err := interpolateCompose("${SECRET_KEY:?set it in the project secrets}", lookup)
+	assert.ErrorContains(t, err, "required variable SECRET_KEY is missing a value: set it in the project secrets")
+	_, err = interpolateCompose("${HOST", lookup)
+	assert.ErrorContains(t, err, "missing }")
+	_, err = interpolateCompose("${HOST/x}", lookup)
+	assert.ErrorContains(t, err, "invalid interpolation format")
+}
+
+func TestMergeComposeNode(t *testing.T) {
+	parse := func(s string) *yaml.Node {
+		var doc yaml.Node
+		require.NoError(t, yaml.Unmarshal([]byte(s), &doc))
+		return doc.Content[0]
+	}
+	merged := mergeComposeNode(parse(`
+services:
+  web:
+    image: web:1
+    command: ["npm", "start"]
+    ports: ["3000:3000"]
+    environment:
+      - NODE_ENV=production
+      - LOG_LEVEL=info
+    volumes: ["data:/data", "./src:/app/src"]
+    depends_on: [db]
+`), parse(`
+services:
+  web:
+    command: ["npm", "run", "dev"]
+    ports: ["3000:3000", "9229:9229"]
+    environment:
+      NODE_ENV: development
+    volumes: ["cache:/data"]
+    depends_on:
+      cache:
+        condition: service_started
+  cache:
+    image: redis:7
+`), "")
+
+	var compose DockerCompose
+	require.NoError(t, merged.Decode(&compose))
+	require.Contains(t, compose.Services, "cache")
+	web := compose.Services["web"]
+	assert.Equal(t, "web:1", web.Image)
+	var command, ports []string
+	require.NoError(t, web.Command.Decode(&command))
+	assert.Equal(t, []string{"npm", "run", "dev"}, command, "overrides replace commands")
+	for _, port := range web.Ports {
+		ports = append(ports, port.Value)
+	}
+	assert.Equal(t, []string{"3000:3000", "9229:9229"}, ports)
+	var environment map[string]string
+	require.NoError(t, web.Environment.Decode(&environment))
+	assert.Equal(t, map[string]string{"NODE_ENV": "development", "LOG_LEVEL": "info"}, environment)
+	var volumes []string
+	for _, volume := range web.Volumes {
+		volumes = append(volumes, volume.Value)
+	}
+	assert.Equal(t, []string{"./src:/app/src", "cache:/data"}, volumes, "volumes merge by container path")
+	assert.Equal(t, []string{"cache", "db"}, composeDependsOn(web))
+}
+
+func TestReadComposeFile_OverrideAndInterpolation(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "docker-compose.yml"), []byte(`
+services:
+  web:
+    image: "acme/web:${TAG:-latest}"
+    environment:
+      DATABASE_URL: postgres://${DB_USER}:${DB_PASSWORD}@db/app
+    healthcheck:
+      test: ["CMD", "true"]
+      retries: ${RETRIES:-3}
+`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "docker-compose.override.yaml"), []byte(`
+services:
+  web:
+    environment:
+      DEBUG: "1"
+`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".env"), []byte("TAG=dotenv\nDB_USER=app\n"), 0o644))
+	compose, err := readComposeFile(dir, &catalystv1alpha1.Environment{}, &catalystv1alpha1.EnvironmentTemplateSpec{}, map[string]string{"TAG": "pr-1", "DB_PASSWORD": "s3cret"})
+	require.NoError(t, err)
+	web := compose.Services["web"]
+	assert.Equal(t, "acme/web:pr-1", web.Image, "the environment wins over .env")
+	assert.Equal(t, int32(3), web.Healthcheck.Retries, "interpolated numbers keep their type")
+	var environment map[string]string
+	require.NoError(t, web.Environment.Decode(&environment))
+	assert.Equal(t, map[string]string{"DATABASE_URL": "postgres://app:s3cret@db/app", "DEBUG": "1"}, environment)
+}
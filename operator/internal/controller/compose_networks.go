@@ -0,0 +1,235 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Compose networks and ports:
+// Compose pods are labelled with the compose service and every network they join (services
+// without networks join "default", as with docker compose). The environment default-policy
+// admits traffic from pods of the namespace that are not compose services, and each network
+// gets a NetworkPolicy "compose-network-<name>" admitting traffic between its members, so
+// compose services only reach the services they share a network with. Published ports and
+// expose entries (short or long syntax, port ranges, udp and sctp) become the ports of the
+// service's Service.
+
+const (
+	// composeServiceLabel labels the pods of a compose service with its name
+	composeServiceLabel = "catalyst.dev/compose-service"
+	// composeNetworkLabel labels the NetworkPolicy of a compose network with its name
+	composeNetworkLabel = "catalyst.dev/compose-network"
+	// composeNetworkLabelPrefix labels compose pods with the networks they join
+	composeNetworkLabelPrefix = "compose-network.catalyst.dev/"
+	// composeDefaultNetwork is the network of services that declare none
+	composeDefaultNetwork = "default"
+)
+
+// composeServiceNetworks returns the sanitized names of the networks a service joins, given as
+// a list or as a map (with aliases)
+func composeServiceNetworks(service ComposeService) []string {
+	var names []string
+	switch service.Networks.Kind {
+	case yaml.SequenceNode:
+		for _, item := range service.Networks.Content {
+			names = append(names, item.Value)
+		}
+	case yaml.MappingNode:
+		for i := 0; i < len(service.Networks.Content); i += 2 {
+			names = append(names, service.Networks.Content[i].Value)
+		}
+	}
+	if len(names) == 0 {
+		names = []string{composeDefaultNetwork}
+	}
+	result := make([]string, 0, len(names))
+	for _, name := range names {
+		result = append(result, composeVolumeName(name))
+	}
+	sort.Strings(result)
+	return result
+}
+
+// composePodLabels are the pod labels of a compose service
+func composePodLabels(name string, service ComposeService) map[string]string {
+	labels := map[string]string{
+		"app":               name,
+		composeServiceLabel: name,
+	}
+	for _, network := range composeServiceNetworks(service) {
+		labels[composeNetworkLabelPrefix+network] = "true"
+	}
+	return labels
+}
+
+// desiredComposeNetworkPolicies returns a NetworkPolicy for every network the services join
+func desiredComposeNetworkPolicies(namespace string, compose *DockerCompose) []*networkingv1.NetworkPolicy {
+	networks := map[string]bool{}
+	for _, service := range compose.Services {
+		for _, network := range composeServiceNetworks(service) {
+			networks[network] = true
+		}
+	}
+	names := make([]string, 0, len(networks))
+	for name := range networks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	policies := make([]*networkingv1.NetworkPolicy, 0, len(names))
+	for _, name := range names {
+		members := metav1.LabelSelector{MatchLabels: map[string]string{composeNetworkLabelPrefix + name: "true"}}
+		policies = append(policies, &networkingv1.NetworkPolicy{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "compose-network-" + name,
+				Namespace: namespace,
+				Labels:    map[string]string{composeNetworkLabel: name},
+			},
+			Spec: networkingv1.NetworkPolicySpec{
+				PodSelector: members,
+				PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+				Ingress: []networkingv1.NetworkPolicyIngressRule{
+					{From: []networkingv1.NetworkPolicyPeer{{PodSelector: members.DeepCopy()}}},
+				},
+			},
+		})
+	}
+	return policies
+}
+
+// pruneComposeNetworkPolicies deletes the policies of networks no service joins anymore
+func (r *EnvironmentReconciler) pruneComposeNetworkPolicies(ctx context.Context, namespace string, desired []*networkingv1.NetworkPolicy) error {
+	keep := map[string]bool{}
+	for _, policy := range desired {
+		keep[policy.Name] = true
+	}
+	policies := &networkingv1.NetworkPolicyList{}
+	if err := r.List(ctx, policies, client.InNamespace(namespace), client.HasLabels{composeNetworkLabel}); err != nil {
+		return fmt.Errorf("failed to list compose network policies: %w", err)
+	}
+	for i := range policies.Items {
+		if keep[policies.Items[i].Name] {
+			continue
+		}
+		if err := r.Delete(ctx, &policies.Items[i]); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("failed to delete NetworkPolicy %s: %w", policies.Items[i].Name, err)
+		}
+	}
+	return nil
+}
+
+// composePort is a container port of a compose service
+type composePort struct {
+	port     int32
+	protocol corev1.Protocol
+}
+
+// parseComposeProtocol parses a compose port protocol, tcp when empty
+func parseComposeProtocol(s string) (corev1.Protocol, error) {
+	switch strings.ToLower(s) {
+	case "", "tcp":
+		return corev1.ProtocolTCP, nil
+	case "udp":
+		return corev1.ProtocolUDP, nil
+	case "sctp":
+		return corev1.ProtocolSCTP, nil
+	}
+	return "", fmt.Errorf("unsupported protocol %q", s)
+}
+
+// parseComposePortRange parses a container port or port range ("8000-8002")
+func parseComposePortRange(s string) ([]int32, error) {
+	first, last, isRange := strings.Cut(s, "-")
+	start, err := strconv.Atoi(first)
+	if err != nil {
+		return nil, fmt.Errorf("invalid port %q", s)
+	}
+	end := start
+	if isRange {
+		if end, err = strconv.Atoi(last); err != nil {
+			return nil, fmt.Errorf("invalid port range %q", s)
+		}
+	}
+	if start < 1 || end > 65535 || end < start {
+		return nil, fmt.Errorf("invalid port range %q", s)
+	}
+	ports := make([]int32, 0, end-start+1)
+	for port := start; port <= end; port++ {
+		ports = append(ports, int32(port))
+	}
+	return ports, nil
+}
+
+// parseComposePort parses a ports or expose entry into its container ports: short syntax
+// ("80", "8080:80", "127.0.0.1:8080:80/udp", "9000-9001:9000-9001") or long syntax (target,
+// published, protocol). The host side is dropped; Services expose the container ports.
+func parseComposePort(node *yaml.Node) ([]composePort, error) {
+	var target, protocol string
+	switch node.Kind {
+	case yaml.ScalarNode:
+		spec, proto, _ := strings.Cut(node.Value, "/")
+		target = spec[strings.LastIndex(spec, ":")+1:]
+		protocol = proto
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			switch node.Content[i].Value {
+			case "target":
+				target = node.Content[i+1].Value
+			case "protocol":
+				protocol = node.Content[i+1].Value
+			}
+		}
+		if target == "" {
+			return nil, fmt.Errorf("port has no target")
+		}
+	default:
+		return nil, fmt.Errorf("unsupported port syntax")
+	}
+
+	proto, err := parseComposeProtocol(protocol)
+	if err != nil {
+		return nil, err
+	}
+	numbers, err := parseComposePortRange(target)
+	if err != nil {
+		return nil, err
+	}
+	ports := make([]composePort, 0, len(numbers))
+	for _, number := range numbers {
+		ports = append(ports, composePort{port: number, protocol: proto})
+	}
+	return ports, nil
+}
+
+// composePortName names a Service port: "port-<n>" for tcp, "<protocol>-<n>" otherwise
+func composePortName(p composePort) string {
+	if p.protocol == corev1.ProtocolTCP {
+		return fmt.Sprintf("port-%d", p.port)
+	}
+	return fmt.Sprintf("%s-%d", strings.ToLower(string(p.protocol)), p.port)
+}
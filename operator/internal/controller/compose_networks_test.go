@@ -0,0 +1,107 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+const testComposeNetworksFile = `
+services:
+  proxy:
+    image: nginx
+    ports:
+      - "80:8080"
+      - "443:8443"
+      - target: 8443
+        published: 443
+        protocol: udp
+    networks: [frontend]
+  api:
+    image: api
+    expose:
+      - "3000"
+    networks:
+      frontend:
+      back_end:
+        aliases: [backend]
+  db:
+    image: postgres:16
+    ports:
+      - "5432"
+    networks: [back_end]
+  dns:
+    image: coredns
+    ports:
+      - "127.0.0.1:5353:53/udp"
+      - "9000-9002:9000-9002"
+      - "bogus"
+networks:
+  frontend:
+  back_end:
+`
+
+func TestComposeServicePorts(t *testing.T) {
+	var compose DockerCompose
+	require.NoError(t, yaml.Unmarshal([]byte(testComposeNetworksFile), &compose))
+
+	assert.Equal(t, []corev1.ServicePort{
+		{Name: "port-8080", Port: 8080, Protocol: corev1.ProtocolTCP, TargetPort: intstr.FromInt32(8080)},
+		{Name: "port-8443", Port: 8443, Protocol: corev1.ProtocolTCP, TargetPort: intstr.FromInt32(8443)},
+		{Name: "udp-8443", Port: 8443, Protocol: corev1.ProtocolUDP, TargetPort: intstr.FromInt32(8443)},
+	}, composeServicePorts("proxy", compose.Services["proxy"]))
+
+	// Expose entries are reachable by other services
+	assert.Equal(t, []corev1.ServicePort{
+		{Name: "port-3000", Port: 3000, Protocol: corev1.ProtocolTCP, TargetPort: intstr.FromInt32(3000)},
+	}, composeServicePorts("api", compose.Services["api"]))
+
+	// Host addresses are dropped, ranges expanded and unparseable entries skipped
+	var names []string
+	for _, p := range composeServicePorts("dns", compose.Services["dns"]) {
+		names = append(names, p.Name)
+	}
+	assert.Equal(t, []string{"udp-53", "port-9000", "port-9001", "port-9002"}, names)
+
+	_, err := parseComposePort(&yaml.Node{Kind: yaml.ScalarNode, Value: "80/icmp"})
+	assert.Error(t, err)
+	_, err = parseComposePort(&yaml.Node{Kind: yaml.ScalarNode, Value: "9002-9000"})
+	assert.Error(t, err)
+}
+
+func TestComposeNetworks(t *testing.T) {
+	var compose DockerCompose
+	require.NoError(t, yaml.Unmarshal([]byte(testComposeNetworksFile), &compose))
+
+	assert.Equal(t, []string{"back-end", "frontend"}, composeServiceNetworks(compose.Services["api"]))
+	assert.Equal(t, []string{"default"}, composeServiceNetworks(compose.Services["dns"]))
+	assert.Equal(t, map[string]string{
+		"app":                                   "db",
+		composeServiceLabel:                     "db",
+		"compose-network.catalyst.dev/back-end": "true",
+	}, composePodLabels("db", compose.Services["db"]))
+
+	policies := desiredComposeNetworkPolicies("env-ns", &compose)
+	require.Len(t, policies, 3)
+	assert.Equal(t, "compose-network-back-end", policies[0].Name)
+	assert.Equal(t, "compose-network-default", policies[1].Name)
+	assert.Equal(t, "compose-network-frontend", policies[2].Name)
+	frontend := policies[2]
+	assert.Equal(t, "frontend", frontend.Labels[composeNetworkLabel])
+	assert.Equal(t, map[string]string{"compose-network.catalyst.dev/frontend": "true"}, frontend.Spec.PodSelector.MatchLabels)
+	require.Len(t, frontend.Spec.Ingress, 1)
+	assert.Equal(t, frontend.Spec.PodSelector.MatchLabels, frontend.Spec.Ingress[0].From[0].PodSelector.MatchLabels,
+		"only members of the network are admitted")
+
+	// The pods of the deployment join their networks
+	deploy := (&EnvironmentReconciler{}).desiredComposeDeployment("env-ns", "api", "api", compose.Services["api"], nil, &catalystv1alpha1.Environment{}, &compose)
+	assert.Equal(t, "true", deploy.Spec.Template.Labels["compose-network.catalyst.dev/frontend"])
+	assert.Equal(t, "api", deploy.Spec.Template.Labels[composeServiceLabel])
+	assert.Equal(t, map[string]string{"app": "api"}, deploy.Spec.Selector.MatchLabels)
+}
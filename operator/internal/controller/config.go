@@ -18,6 +18,7 @@ package controller
 
 import (
 	"fmt"
+	"maps"
 
 	corev1 "k8s.io/api/core/v1"
 
@@ -49,6 +50,11 @@ func resolveConfig(envConfig *catalystv1alpha1.EnvironmentConfig, tmplConfig *ca
 
 	// Override with environment-specific values (non-zero/non-nil only)
 
+	// Framework preset, expanded after the merge
+	if envConfig.Framework != "" {
+		result.Framework = envConfig.Framework
+	}
+
 	// Container fields
 	if envConfig.Image != "" {
 		result.Image = envConfig.Image
@@ -67,32 +73,7 @@ func resolveConfig(envConfig *catalystv1alpha1.EnvironmentConfig, tmplConfig *ca
 	}
 	if len(envConfig.Env) > 0 {
 		// Merge env vars by name (environment config wins for duplicates)
-		// Maintain order from template followed by environment-specific variables
-		envOverrides := make(map[string]corev1.EnvVar)
-		for _, env := range envConfig.Env {
-			envOverrides[env.Name] = env
-		}
-
-		var mergedEnv []corev1.EnvVar
-		processedNames := make(map[string]bool)
-
-		// First, add all from template (applying overrides if present)
-		for _, env := range result.Env {
-			if override, ok := envOverrides[env.Name]; ok {
-				mergedEnv = append(mergedEnv, override)
-			} else {
-				mergedEnv = append(mergedEnv, env)
-			}
-			processedNames[env.Name] = true
-		}
-
-		// Then, add all from environment config that weren't in template
-		for _, env := range envConfig.Env {
-			if !processedNames[env.Name] {
-				mergedEnv = append(mergedEnv, env)
-			}
-		}
-		result.Env = mergedEnv
+		result.Env = mergeEnvVars(result.Env, envConfig.Env)
 	}
 	if envConfig.Resources != nil {
 		result.Resources = envConfig.Resources
@@ -119,6 +100,11 @@ func resolveConfig(envConfig *catalystv1alpha1.EnvironmentConfig, tmplConfig *ca
 		result.InitContainers = envConfig.InitContainers
 	}
 
+	// Sidecars
+	if len(envConfig.Sidecars) > 0 {
+		result.Sidecars = envConfig.Sidecars
+	}
+
 	// Services
 	if len(envConfig.Services) > 0 {
 		result.Services = envConfig.Services
@@ -129,6 +115,38 @@ func resolveConfig(envConfig *catalystv1alpha1.EnvironmentConfig, tmplConfig *ca
 		result.Volumes = envConfig.Volumes
 	}
 
+	// Scaling
+	if envConfig.Replicas != nil {
+		result.Replicas = envConfig.Replicas
+	}
+	if envConfig.Autoscaling != nil {
+		result.Autoscaling = envConfig.Autoscaling
+	}
+
+	// Scheduling
+	if len(envConfig.NodeSelector) > 0 {
+		result.NodeSelector = envConfig.NodeSelector
+	}
+	if len(envConfig.Tolerations) > 0 {
+		result.Tolerations = envConfig.Tolerations
+	}
+	if envConfig.Affinity != nil {
+		result.Affinity = envConfig.Affinity
+	}
+	if envConfig.Arch != "" {
+		result.Arch = envConfig.Arch
+	}
+
+	// File sync
+	if envConfig.FileSync != nil {
+		result.FileSync = envConfig.FileSync
+	}
+
+	// Docker Compose
+	if len(envConfig.ComposeProfiles) > 0 {
+		result.ComposeProfiles = envConfig.ComposeProfiles
+	}
+
 	return result
 }
 
@@ -141,6 +159,7 @@ func deepCopyConfig(cfg *catalystv1alpha1.EnvironmentConfig) *catalystv1alpha1.E
 	}
 
 	result := &catalystv1alpha1.EnvironmentConfig{
+		Framework:  cfg.Framework,
 		Image:      cfg.Image,
 		Command:    copyStrings(cfg.Command),
 		Args:       copyStrings(cfg.Args),
@@ -206,6 +225,14 @@ func deepCopyConfig(cfg *catalystv1alpha1.EnvironmentConfig) *catalystv1alpha1.E
 		}
 	}
 
+	// Copy Sidecars
+	if len(cfg.Sidecars) > 0 {
+		result.Sidecars = make([]catalystv1alpha1.ContainerSpec, len(cfg.Sidecars))
+		for i := range cfg.Sidecars {
+			cfg.Sidecars[i].DeepCopyInto(&result.Sidecars[i])
+		}
+	}
+
 	// Copy Services
 	if len(cfg.Services) > 0 {
 		result.Services = make([]catalystv1alpha1.ManagedServiceSpec, len(cfg.Services))
@@ -213,8 +240,11 @@ func deepCopyConfig(cfg *catalystv1alpha1.EnvironmentConfig) *catalystv1alpha1.E
 			result.Services[i] = catalystv1alpha1.ManagedServiceSpec{
 				Name:     svc.Name,
 				Database: svc.Database,
+				Provider: svc.Provider,
+				Preset:   svc.Preset,
 				Container: catalystv1alpha1.ManagedServiceContainer{
 					Image: svc.Container.Image,
+					Args:  copyStrings(svc.Container.Args),
 				},
 			}
 			if len(svc.Container.Ports) > 0 {
@@ -228,9 +258,30 @@ func deepCopyConfig(cfg *catalystv1alpha1.EnvironmentConfig) *catalystv1alpha1.E
 			if svc.Container.Resources != nil {
 				result.Services[i].Container.Resources = svc.Container.Resources.DeepCopy()
 			}
+			if svc.Container.ReadinessProbe != nil {
+				result.Services[i].Container.ReadinessProbe = svc.Container.ReadinessProbe.DeepCopy()
+			}
 			if svc.Storage != nil {
 				result.Services[i].Storage = svc.Storage.DeepCopy()
 			}
+			if svc.Size != nil {
+				result.Services[i].Size = ptr(svc.Size.DeepCopy())
+			}
+			if svc.StorageClassName != nil {
+				result.Services[i].StorageClassName = ptr(*svc.StorageClassName)
+			}
+			if svc.Pooler != nil {
+				result.Services[i].Pooler = svc.Pooler.DeepCopy()
+			}
+			if svc.Seed != nil {
+				result.Services[i].Seed = svc.Seed.DeepCopy()
+			}
+			if svc.Instances != nil {
+				result.Services[i].Instances = ptr(*svc.Instances)
+			}
+			if svc.SharedCluster != nil {
+				result.Services[i].SharedCluster = svc.SharedCluster.DeepCopy()
+			}
 		}
 	}
 
@@ -244,12 +295,78 @@ func deepCopyConfig(cfg *catalystv1alpha1.EnvironmentConfig) *catalystv1alpha1.E
 			if vol.PersistentVolumeClaim != nil {
 				result.Volumes[i].PersistentVolumeClaim = vol.PersistentVolumeClaim.DeepCopy()
 			}
+			if vol.Size != nil {
+				result.Volumes[i].Size = ptr(vol.Size.DeepCopy())
+			}
+			if vol.StorageClassName != nil {
+				result.Volumes[i].StorageClassName = ptr(*vol.StorageClassName)
+			}
 		}
 	}
 
+	// Copy scaling (pointers)
+	if cfg.Replicas != nil {
+		result.Replicas = ptr(*cfg.Replicas)
+	}
+	if cfg.Autoscaling != nil {
+		result.Autoscaling = cfg.Autoscaling.DeepCopy()
+	}
+
+	// Copy scheduling
+	if len(cfg.NodeSelector) > 0 {
+		result.NodeSelector = maps.Clone(cfg.NodeSelector)
+	}
+	if len(cfg.Tolerations) > 0 {
+		result.Tolerations = make([]corev1.Toleration, len(cfg.Tolerations))
+		for i := range cfg.Tolerations {
+			cfg.Tolerations[i].DeepCopyInto(&result.Tolerations[i])
+		}
+	}
+	if cfg.Affinity != nil {
+		result.Affinity = cfg.Affinity.DeepCopy()
+	}
+	result.Arch = cfg.Arch
+
+	// Copy file sync
+	if cfg.FileSync != nil {
+		result.FileSync = cfg.FileSync.DeepCopy()
+	}
+
+	result.ComposeProfiles = copyStrings(cfg.ComposeProfiles)
+
 	return result
 }
 
+// mergeEnvVars merges environment variables by name: the order of base is kept, overrides
+// replace the variables of base with the same name and the remaining overrides follow
+func mergeEnvVars(base, overrides []corev1.EnvVar) []corev1.EnvVar {
+	envOverrides := make(map[string]corev1.EnvVar)
+	for _, env := range overrides {
+		envOverrides[env.Name] = env
+	}
+
+	var mergedEnv []corev1.EnvVar
+	processedNames := make(map[string]bool)
+
+	// First, add all from base (applying overrides if present)
+	for _, env := range base {
+		if override, ok := envOverrides[env.Name]; ok {
+			mergedEnv = append(mergedEnv, override)
+		} else {
+			mergedEnv = append(mergedEnv, env)
+		}
+		processedNames[env.Name] = true
+	}
+
+	// Then, add all overrides that weren't in base
+	for _, env := range overrides {
+		if !processedNames[env.Name] {
+			mergedEnv = append(mergedEnv, env)
+		}
+	}
+	return mergedEnv
+}
+
 // copyStrings creates a copy of a string slice
 func copyStrings(s []string) []string {
 	if s == nil {
@@ -293,6 +410,11 @@ func validateConfig(config *catalystv1alpha1.EnvironmentConfig) error {
 		return fmt.Errorf("config.ports is required (at least one port must be defined)")
 	}
 
+	// CPU utilization is relative to the requested CPU
+	if config.Autoscaling != nil && (config.Resources == nil || config.Resources.Requests.Cpu().IsZero()) {
+		return fmt.Errorf("config.resources.requests.cpu is required for config.autoscaling")
+	}
+
 	// Command is optional (image may have ENTRYPOINT)
 	// WorkingDir is optional (image may have WORKDIR)
 	// Resources are optional (but recommended)
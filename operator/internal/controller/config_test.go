@@ -218,6 +218,36 @@ func TestResolveConfig_InitContainers(t *testing.T) {
 	}
 }
 
+func TestResolveConfig_Sidecars(t *testing.T) {
+	tmpl := &catalystv1alpha1.EnvironmentConfig{
+		Sidecars: []catalystv1alpha1.ContainerSpec{
+			{Name: "worker", Command: []string{"npm", "run", "worker"}},
+		},
+	}
+
+	// Template sidecars are inherited as copies
+	result := resolveConfig(&catalystv1alpha1.EnvironmentConfig{Image: "node:22-slim"}, tmpl)
+	if len(result.Sidecars) != 1 || result.Sidecars[0].Name != "worker" {
+		t.Fatalf("expected the template worker sidecar, got %+v", result.Sidecars)
+	}
+	result.Sidecars[0].Command[0] = "yarn"
+	if tmpl.Sidecars[0].Command[0] != "npm" {
+		t.Error("modifying the resolved sidecars should not affect the template")
+	}
+
+	env := &catalystv1alpha1.EnvironmentConfig{
+		Sidecars: []catalystv1alpha1.ContainerSpec{
+			{Name: "stripe-cli", Image: "stripe/stripe-cli:v1.21.0"},
+		},
+	}
+	result = resolveConfig(env, tmpl)
+
+	// Sidecars should be replaced (not appended)
+	if len(result.Sidecars) != 1 || result.Sidecars[0].Name != "stripe-cli" {
+		t.Errorf("expected only the stripe-cli sidecar (env override), got %+v", result.Sidecars)
+	}
+}
+
 func TestResolveConfig_Services(t *testing.T) {
 	tmpl := &catalystv1alpha1.EnvironmentConfig{
 		Services: []catalystv1alpha1.ManagedServiceSpec{
@@ -294,6 +324,41 @@ func TestResolveConfig_Volumes(t *testing.T) {
 	}
 }
 
+func TestResolveConfig_Scaling(t *testing.T) {
+	tmpl := &catalystv1alpha1.EnvironmentConfig{
+		Replicas: ptr(int32(2)),
+	}
+	env := &catalystv1alpha1.EnvironmentConfig{
+		Autoscaling: &catalystv1alpha1.AutoscalingSpec{MaxReplicas: 5},
+	}
+
+	result := resolveConfig(env, tmpl)
+
+	if result.Replicas == nil || *result.Replicas != 2 {
+		t.Errorf("expected template replicas 2, got %v", result.Replicas)
+	}
+	if result.Autoscaling == nil || result.Autoscaling.MaxReplicas != 5 {
+		t.Errorf("expected env autoscaling with maxReplicas 5, got %v", result.Autoscaling)
+	}
+
+	// The template replica count is copied, not shared
+	*result.Replicas = 3
+	if *tmpl.Replicas != 2 {
+		t.Errorf("template Replicas was mutated: got %d", *tmpl.Replicas)
+	}
+
+	// Autoscaling on CPU utilization needs a CPU request
+	result.Image = "app:1"
+	result.Ports = []corev1.ContainerPort{{ContainerPort: 3000}}
+	if err := validateConfig(&result); err == nil {
+		t.Errorf("expected autoscaling without a CPU request to be rejected")
+	}
+	result.Resources = &corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("250m")}}
+	if err := validateConfig(&result); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
 // Test deep copy to ensure we don't mutate the original template
 func TestResolveConfig_DeepCopyNoMutation(t *testing.T) {
 	tmpl := &catalystv1alpha1.EnvironmentConfig{
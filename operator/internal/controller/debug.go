@@ -0,0 +1,188 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+// Debugging (the catalyst.dev/debug annotation):
+// Users troubleshoot an environment without patch rights on its Deployments: the operator adds
+// a debug container to a running web pod ("true"), or starts a debug pod mounting the web pod's
+// volumes ("pod"), and removes the annotation. An Event tells how to attach. Debug containers
+// run as the non-root user of the pod, so tools needing capabilities (tcpdump) are unavailable.
+
+// +kubebuilder:rbac:groups="",resources=pods/ephemeralcontainers,verbs=update
+
+const (
+	debugAnnotation = "catalyst.dev/debug"
+	debugEphemeral  = "true"
+	debugPod        = "pod"
+
+	// debugImage carries a shell and network troubleshooting tools
+	debugImage = "nicolaka/netshoot:v0.13"
+
+	// debugPodName is the debug pod of an environment namespace
+	debugPodName = "debug"
+	// debugPodLifetime bounds how long a debug pod runs
+	debugPodLifetime int64 = 3600
+)
+
+// debugSecurityContext runs a debug container within the restricted Pod Security Standard.
+// The root filesystem stays writable for the tools, which is allowed there.
+func debugSecurityContext() *corev1.SecurityContext {
+	return &corev1.SecurityContext{
+		RunAsNonRoot:             ptr(true),
+		AllowPrivilegeEscalation: ptr(false),
+		ReadOnlyRootFilesystem:   ptr(false),
+		Capabilities:             &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}},
+	}
+}
+
+// debugEphemeralContainer returns a debug container for pod sharing the processes and volume
+// mounts of its first (main) container. Its name is unique within the pod.
+func debugEphemeralContainer(pod *corev1.Pod) corev1.EphemeralContainer {
+	main := pod.Spec.Containers[0]
+	return corev1.EphemeralContainer{
+		EphemeralContainerCommon: corev1.EphemeralContainerCommon{
+			Name:            fmt.Sprintf("debug-%d", len(pod.Spec.EphemeralContainers)+1),
+			Image:           debugImage,
+			Stdin:           true,
+			TTY:             true,
+			VolumeMounts:    main.VolumeMounts,
+			SecurityContext: debugSecurityContext(),
+		},
+		TargetContainerName: main.Name,
+	}
+}
+
+// desiredDebugPod returns a debug pod with the volumes of pod, on its node so that
+// ReadWriteOnce claims can be mounted. It sleeps for debugPodLifetime.
+func desiredDebugPod(pod *corev1.Pod) *corev1.Pod {
+	debug := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      debugPodName,
+			Namespace: pod.Namespace,
+			Labels:    map[string]string{"app": debugPodName},
+		},
+		Spec: corev1.PodSpec{
+			NodeName:              pod.Spec.NodeName,
+			RestartPolicy:         corev1.RestartPolicyNever,
+			ActiveDeadlineSeconds: ptr(debugPodLifetime),
+			Volumes:               pod.Spec.Volumes,
+			Containers: []corev1.Container{{
+				Name:            "debug",
+				Image:           debugImage,
+				Command:         []string{"sleep", fmt.Sprint(debugPodLifetime)},
+				EnvFrom:         pod.Spec.Containers[0].EnvFrom,
+				VolumeMounts:    pod.Spec.Containers[0].VolumeMounts,
+				SecurityContext: debugSecurityContext(),
+			}},
+		},
+	}
+	applyPodSecurity(&debug.Spec)
+	return debug
+}
+
+// runningWebPod returns a running pod of the web Deployment, or nil
+func (r *EnvironmentReconciler) runningWebPod(ctx context.Context, namespace string) (*corev1.Pod, error) {
+	deployment := &appsv1.Deployment{}
+	if err := r.Get(ctx, client.ObjectKey{Name: "web", Namespace: namespace}, deployment); err != nil {
+		return nil, client.IgnoreNotFound(err)
+	}
+	pods := &corev1.PodList{}
+	if err := r.List(ctx, pods, client.InNamespace(namespace), client.MatchingLabels(deployment.Spec.Selector.MatchLabels)); err != nil {
+		return nil, err
+	}
+	for i := range pods.Items {
+		if pods.Items[i].Status.Phase == corev1.PodRunning && pods.Items[i].DeletionTimestamp.IsZero() {
+			return &pods.Items[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// consumeDebugRequest starts the debugging requested by the debug annotation and removes it.
+// Requests that cannot be served are dropped with a Warning Event.
+// Returns true if the Environment was updated.
+func (r *EnvironmentReconciler) consumeDebugRequest(ctx context.Context, env *catalystv1alpha1.Environment, namespace string) (bool, error) {
+	value, ok := env.Annotations[debugAnnotation]
+	if !ok {
+		return false, nil
+	}
+	log := logf.FromContext(ctx)
+	rejection, err := r.startDebugging(ctx, env, namespace, value)
+	if err != nil {
+		return false, err
+	}
+	if rejection != "" {
+		log.Info("Ignoring debug request", "value", value, "reason", rejection)
+		recordEvent(r.Recorder, env, corev1.EventTypeWarning, eventDebugRejected, "Debug request %q rejected: %s", value, rejection)
+	}
+	delete(env.Annotations, debugAnnotation)
+	if err := r.Update(ctx, env); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// startDebugging adds a debug container to a running web pod or starts the debug pod.
+// Returns why the request cannot be served, if it cannot.
+func (r *EnvironmentReconciler) startDebugging(ctx context.Context, env *catalystv1alpha1.Environment, namespace, value string) (string, error) {
+	if value != debugEphemeral && value != debugPod {
+		return fmt.Sprintf("want %q or %q", debugEphemeral, debugPod), nil
+	}
+	pod, err := r.runningWebPod(ctx, namespace)
+	if err != nil {
+		return "", err
+	}
+	if pod == nil || len(pod.Spec.Containers) == 0 {
+		return "no running web pod", nil
+	}
+
+	if value == debugPod {
+		debug := desiredDebugPod(pod)
+		labelEnvironmentWorkload(env, debug)
+		if err := r.Create(ctx, debug); apierrors.IsAlreadyExists(err) {
+			return fmt.Sprintf("pod %s/%s exists", namespace, debugPodName), nil
+		} else if err != nil {
+			return "", fmt.Errorf("failed to create debug pod: %w", err)
+		}
+		recordEvent(r.Recorder, env, corev1.EventTypeNormal, eventDebugStarted,
+			"Debug pod started: kubectl exec -it -n %s %s -- sh", namespace, debugPodName)
+		return "", nil
+	}
+
+	container := debugEphemeralContainer(pod)
+	pod.Spec.EphemeralContainers = append(pod.Spec.EphemeralContainers, container)
+	if err := r.SubResource("ephemeralcontainers").Update(ctx, pod); err != nil {
+		return "", fmt.Errorf("failed to add debug container to pod %s: %w", pod.Name, err)
+	}
+	recordEvent(r.Recorder, env, corev1.EventTypeNormal, eventDebugStarted,
+		"Debug container started: kubectl attach -it -n %s %s -c %s", namespace, pod.Name, container.Name)
+	return "", nil
+}
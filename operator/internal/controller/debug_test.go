@@ -0,0 +1,88 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+func debugWebPod() *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "web-abc", Namespace: "ns", Labels: map[string]string{"app": "web"}},
+		Spec: corev1.PodSpec{
+			NodeName: "node-1",
+			Volumes:  []corev1.Volume{{Name: "code", VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "code"}}}},
+			Containers: []corev1.Container{{
+				Name:         "app",
+				Image:        "app:1",
+				VolumeMounts: []corev1.VolumeMount{{Name: "code", MountPath: "/workspace"}},
+			}},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+}
+
+func TestDebugEphemeralContainer(t *testing.T) {
+	pod := debugWebPod()
+	container := debugEphemeralContainer(pod)
+	assert.Equal(t, "debug-1", container.Name)
+	assert.Equal(t, "app", container.TargetContainerName, "shares the processes of the main container")
+	assert.Equal(t, pod.Spec.Containers[0].VolumeMounts, container.VolumeMounts)
+	assert.Equal(t, []corev1.Capability{"ALL"}, container.SecurityContext.Capabilities.Drop)
+
+	pod.Spec.EphemeralContainers = append(pod.Spec.EphemeralContainers, container)
+	assert.Equal(t, "debug-2", debugEphemeralContainer(pod).Name)
+}
+
+func TestConsumeDebugRequest(t *testing.T) {
+	env := &catalystv1alpha1.Environment{
+		ObjectMeta: metav1.ObjectMeta{Name: "dev", Namespace: "team", Annotations: map[string]string{debugAnnotation: debugPod}},
+	}
+	web := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "ns"},
+		Spec:       appsv1.DeploymentSpec{Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}}},
+	}
+	c := newFakeClientBuilder().WithObjects(env, web, debugWebPod()).Build()
+	recorder := record.NewFakeRecorder(10)
+	r := &EnvironmentReconciler{Client: c, Scheme: testScheme, Recorder: recorder}
+	ctx := context.Background()
+
+	updated, err := r.consumeDebugRequest(ctx, env, "ns")
+	require.NoError(t, err)
+	assert.True(t, updated)
+	assert.NotContains(t, env.Annotations, debugAnnotation)
+	debug := &corev1.Pod{}
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: debugPodName, Namespace: "ns"}, debug))
+	assert.Equal(t, "node-1", debug.Spec.NodeName, "next to the web pod for ReadWriteOnce claims")
+	assert.Equal(t, "code", debug.Spec.Volumes[0].PersistentVolumeClaim.ClaimName)
+	assert.True(t, hasMountPath(debug.Spec.Containers[0].VolumeMounts, "/workspace"))
+	assert.Equal(t, debugPodLifetime, *debug.Spec.ActiveDeadlineSeconds)
+	assert.Contains(t, <-recorder.Events, eventDebugStarted)
+
+	updated, err = r.consumeDebugRequest(ctx, env, "ns")
+	require.NoError(t, err)
+	assert.False(t, updated, "no request")
+
+	// Requests that cannot be served are dropped with a Warning
+	for _, value := range []string{debugPod, "shell"} {
+		env.Annotations = map[string]string{debugAnnotation: value}
+		updated, err = r.consumeDebugRequest(ctx, env, "ns")
+		require.NoError(t, err)
+		assert.True(t, updated)
+		assert.NotContains(t, env.Annotations, debugAnnotation)
+		assert.Contains(t, <-recorder.Events, eventDebugRejected)
+	}
+	env.Annotations = map[string]string{debugAnnotation: debugEphemeral}
+	_, err = r.consumeDebugRequest(ctx, env, "other")
+	require.NoError(t, err)
+	assert.Contains(t, <-recorder.Events, "no running web pod")
+}
@@ -0,0 +1,178 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+// Ordered provisioning (spec.dependsOn):
+// An Environment stays Pending until every Environment it depends on is Ready, then deploys.
+// Once deployed it no longer waits, so a dependency redeploying does not hold back the
+// dependent's updates. Changes of an Environment enqueue the Environments depending on it
+// through an index on spec.dependsOn.
+
+const (
+	// conditionDependenciesReady reports whether the Environments of spec.dependsOn are Ready
+	conditionDependenciesReady = "DependenciesReady"
+	// environmentDependsOnIndex indexes Environments by the names in spec.dependsOn
+	environmentDependsOnIndex = "catalyst.dev/depends-on"
+	// phasePending is the phase of environments waiting for their dependencies
+	phasePending = "Pending"
+)
+
+// environmentDependsOn is the index function for environmentDependsOnIndex
+func environmentDependsOn(obj client.Object) []string {
+	env, ok := obj.(*catalystv1alpha1.Environment)
+	if !ok {
+		return nil
+	}
+	return env.Spec.DependsOn
+}
+
+// environmentsDependingOn enqueues the Environments of the namespace depending on an Environment
+func (r *EnvironmentReconciler) environmentsDependingOn(ctx context.Context, obj client.Object) []reconcile.Request {
+	envs := &catalystv1alpha1.EnvironmentList{}
+	if err := r.List(ctx, envs, client.InNamespace(obj.GetNamespace()), client.MatchingFields{environmentDependsOnIndex: obj.GetName()}); err != nil {
+		logf.FromContext(ctx).Error(err, "Failed to list dependent Environments", "namespace", obj.GetNamespace(), "name", obj.GetName())
+		return nil
+	}
+	requests := make([]reconcile.Request, 0, len(envs.Items))
+	for _, env := range envs.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&env)})
+	}
+	return requests
+}
+
+// dependencyCycle returns a dependency cycle through start, e.g. [web api web], nil if there is none
+func dependencyCycle(start string, dependsOn map[string][]string) []string {
+	visited := map[string]bool{}
+	var walk func(path []string) []string
+	walk = func(path []string) []string {
+		for _, dep := range dependsOn[path[len(path)-1]] {
+			if dep == start {
+				return append(slices.Clone(path), dep)
+			}
+			if visited[dep] {
+				continue
+			}
+			visited[dep] = true
+			if cycle := walk(append(path, dep)); cycle != nil {
+				return cycle
+			}
+		}
+		return nil
+	}
+	return walk([]string{start})
+}
+
+// reconcileDependencies records the Environments of spec.dependsOn in status.dependencies and
+// the DependenciesReady condition. It returns false while an environment that was not
+// deployed yet has to wait for them.
+func (r *EnvironmentReconciler) reconcileDependencies(ctx context.Context, env *catalystv1alpha1.Environment) (bool, error) {
+	if len(env.Spec.DependsOn) == 0 {
+		changed := meta.RemoveStatusCondition(&env.Status.Conditions, conditionDependenciesReady)
+		if env.Status.Dependencies != nil {
+			env.Status.Dependencies = nil
+			changed = true
+		}
+		if changed {
+			return true, r.Status().Update(ctx, env)
+		}
+		return true, nil
+	}
+
+	envs := &catalystv1alpha1.EnvironmentList{}
+	if err := r.List(ctx, envs, client.InNamespace(env.Namespace)); err != nil {
+		return false, fmt.Errorf("failed to list Environments for dependencies: %w", err)
+	}
+	byName := make(map[string]*catalystv1alpha1.Environment, len(envs.Items))
+	dependsOn := make(map[string][]string, len(envs.Items))
+	for i := range envs.Items {
+		byName[envs.Items[i].Name] = &envs.Items[i]
+		dependsOn[envs.Items[i].Name] = envs.Items[i].Spec.DependsOn
+	}
+	dependsOn[env.Name] = env.Spec.DependsOn
+
+	dependencies := make([]catalystv1alpha1.DependencyStatus, 0, len(env.Spec.DependsOn))
+	var waiting []string
+	for _, name := range env.Spec.DependsOn {
+		status := catalystv1alpha1.DependencyStatus{Name: name}
+		dep, ok := byName[name]
+		switch {
+		case !ok:
+			waiting = append(waiting, name+" (not found)")
+		case dep.Status.Phase != "Ready":
+			status.Phase, status.URL = dep.Status.Phase, dep.Status.URL
+			phase := dep.Status.Phase
+			if phase == "" {
+				phase = phasePending
+			}
+			waiting = append(waiting, fmt.Sprintf("%s (%s)", name, phase))
+		default:
+			status.Phase, status.URL = dep.Status.Phase, dep.Status.URL
+		}
+		dependencies = append(dependencies, status)
+	}
+
+	condition := metav1.Condition{
+		Type:               conditionDependenciesReady,
+		Status:             metav1.ConditionTrue,
+		Reason:             "Ready",
+		Message:            "All dependencies are Ready",
+		ObservedGeneration: env.Generation,
+	}
+	if cycle := dependencyCycle(env.Name, dependsOn); cycle != nil {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "Cycle"
+		condition.Message = "Dependency cycle: " + strings.Join(cycle, " -> ")
+	} else if len(waiting) > 0 {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "Waiting"
+		condition.Message = "Waiting for " + strings.Join(waiting, ", ")
+	}
+
+	// Environments deploying or deployed before keep reconciling
+	blocked := condition.Status == metav1.ConditionFalse && (env.Status.Phase == "" || env.Status.Phase == phasePending)
+	changed := meta.SetStatusCondition(&env.Status.Conditions, condition)
+	if !equality.Semantic.DeepEqual(env.Status.Dependencies, dependencies) {
+		env.Status.Dependencies = dependencies
+		changed = true
+	}
+	if blocked && env.Status.Phase != phasePending {
+		env.Status.Phase = phasePending
+		changed = true
+	}
+	if changed {
+		if err := r.Status().Update(ctx, env); err != nil {
+			return false, err
+		}
+	}
+	return !blocked, nil
+}
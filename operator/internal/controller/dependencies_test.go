@@ -0,0 +1,127 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+func TestReconcileDependencies(t *testing.T) {
+	db := &catalystv1alpha1.Environment{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "team"},
+		Status:     catalystv1alpha1.EnvironmentStatus{Phase: "Provisioning"},
+	}
+	web := &catalystv1alpha1.Environment{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "team"},
+		Spec:       catalystv1alpha1.EnvironmentSpec{DependsOn: []string{"db", "api"}},
+	}
+	c := newFakeClientBuilder().WithStatusSubresource(db, web).WithObjects(db, web).Build()
+	r := &EnvironmentReconciler{Client: c, Scheme: testScheme}
+	ctx := context.Background()
+
+	ready, err := r.reconcileDependencies(ctx, web)
+	require.NoError(t, err)
+	assert.False(t, ready)
+	assert.Equal(t, phasePending, web.Status.Phase)
+	condition := meta.FindStatusCondition(web.Status.Conditions, conditionDependenciesReady)
+	require.NotNil(t, condition)
+	assert.Equal(t, "Waiting", condition.Reason)
+	assert.Equal(t, "Waiting for db (Provisioning), api (not found)", condition.Message)
+	assert.Equal(t, []catalystv1alpha1.DependencyStatus{{Name: "db", Phase: "Provisioning"}, {Name: "api"}}, web.Status.Dependencies)
+
+	// Ready once every dependency is
+	db.Status = catalystv1alpha1.EnvironmentStatus{Phase: "Ready", URL: "https://db.preview.example.com"}
+	require.NoError(t, c.Status().Update(ctx, db))
+	api := &catalystv1alpha1.Environment{
+		ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "team"},
+		Status:     catalystv1alpha1.EnvironmentStatus{Phase: "Ready"},
+	}
+	require.NoError(t, c.Create(ctx, api))
+	require.NoError(t, c.Status().Update(ctx, api))
+	ready, err = r.reconcileDependencies(ctx, web)
+	require.NoError(t, err)
+	assert.True(t, ready)
+	assert.True(t, meta.IsStatusConditionTrue(web.Status.Conditions, conditionDependenciesReady))
+	assert.Equal(t, "https://db.preview.example.com", web.Status.Dependencies[0].URL)
+
+	// A deployed environment keeps reconciling while a dependency redeploys
+	web.Status.Phase = "Ready"
+	require.NoError(t, c.Status().Update(ctx, web))
+	db.Status.Phase = "Building"
+	require.NoError(t, c.Status().Update(ctx, db))
+	ready, err = r.reconcileDependencies(ctx, web)
+	require.NoError(t, err)
+	assert.True(t, ready)
+	assert.False(t, meta.IsStatusConditionTrue(web.Status.Conditions, conditionDependenciesReady))
+
+	web.Spec.DependsOn = nil
+	ready, err = r.reconcileDependencies(ctx, web)
+	require.NoError(t, err)
+	assert.True(t, ready)
+	assert.Nil(t, meta.FindStatusCondition(web.Status.Conditions, conditionDependenciesReady))
+	assert.Nil(t, web.Status.Dependencies)
+}
+
+func TestReconcileDependencies_Cycle(t *testing.T) {
+	api := &catalystv1alpha1.Environment{
+		ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "team"},
+		Spec:       catalystv1alpha1.EnvironmentSpec{DependsOn: []string{"web"}},
+		Status:     catalystv1alpha1.EnvironmentStatus{Phase: "Ready"},
+	}
+	web := &catalystv1alpha1.Environment{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "team"},
+		Spec:       catalystv1alpha1.EnvironmentSpec{DependsOn: []string{"api"}},
+	}
+	c := newFakeClientBuilder().WithStatusSubresource(api, web).WithObjects(api, web).Build()
+	r := &EnvironmentReconciler{Client: c, Scheme: testScheme}
+
+	ready, err := r.reconcileDependencies(context.Background(), web)
+	require.NoError(t, err)
+	assert.False(t, ready)
+	condition := meta.FindStatusCondition(web.Status.Conditions, conditionDependenciesReady)
+	require.NotNil(t, condition)
+	assert.Equal(t, "Cycle", condition.Reason)
+	assert.Equal(t, "Dependency cycle: web -> api -> web", condition.Message)
+}
+
+func TestDependencyCycle(t *testing.T) {
+	assert.Equal(t, []string{"a", "a"}, dependencyCycle("a", map[string][]string{"a": {"a"}}))
+	assert.Equal(t, []string{"a", "b", "d", "a"}, dependencyCycle("a", map[string][]string{
+		"a": {"b", "c"},
+		"b": {"d"},
+		"c": {"d"},
+		"d": {"a"},
+	}))
+	assert.Nil(t, dependencyCycle("a", map[string][]string{"a": {"b"}, "b": {"c"}, "c": {"b"}}), "cycles not through a are ignored")
+}
+
+func TestEnvironmentsDependingOn(t *testing.T) {
+	c := newFakeClientBuilder().
+		WithIndex(&catalystv1alpha1.Environment{}, environmentDependsOnIndex, environmentDependsOn).
+		WithObjects(
+			&catalystv1alpha1.Environment{
+				ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "team"},
+				Spec:       catalystv1alpha1.EnvironmentSpec{DependsOn: []string{"db"}},
+			},
+			&catalystv1alpha1.Environment{
+				ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "other"},
+				Spec:       catalystv1alpha1.EnvironmentSpec{DependsOn: []string{"db"}},
+			},
+			&catalystv1alpha1.Environment{ObjectMeta: metav1.ObjectMeta{Name: "worker", Namespace: "team"}},
+		).Build()
+	r := &EnvironmentReconciler{Client: c, Scheme: testScheme}
+
+	db := &catalystv1alpha1.Environment{ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "team"}}
+	assert.Equal(t, []reconcile.Request{{NamespacedName: types.NamespacedName{Name: "web", Namespace: "team"}}},
+		r.environmentsDependingOn(context.Background(), db))
+	web := &catalystv1alpha1.Environment{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "team"}}
+	assert.Empty(t, r.environmentsDependingOn(context.Background(), web))
+}
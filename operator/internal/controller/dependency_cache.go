@@ -0,0 +1,207 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"path"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+// +kubebuilder:rbac:groups="",resources=persistentvolumes,verbs=get;list;watch;create;delete
+
+// Dependency cache (Project spec.dependencyCache):
+// A ReadWriteMany PVC "dependency-cache-<project>" in the Project namespace holds the package
+// manager caches. PVCs cannot be mounted across namespaces, so each environment namespace gets
+// a "dependency-cache" PVC pre-bound to its own PersistentVolume, a copy of the project volume's
+// PV pointing at the same backing storage with the Retain reclaim policy. Deleting an
+// environment deletes its PV and leaves the shared storage alone.
+
+const (
+	dependencyCacheName             = "dependency-cache"
+	defaultDependencyCacheMountPath = "/cache"
+	dependencyCacheProjectLabel     = "catalyst.dev/dependency-cache"
+)
+
+var defaultDependencyCacheSize = resource.MustParse("20Gi")
+
+// dependencyCacheEnv points package managers at subdirectories of the cache mount
+var dependencyCacheEnv = []struct{ name, dir string }{
+	{"npm_config_cache", "npm"},
+	{"npm_config_store_dir", "pnpm-store"},
+	{"YARN_CACHE_FOLDER", "yarn"},
+	{"GOMODCACHE", "go/mod"},
+	{"PIP_CACHE_DIR", "pip"},
+}
+
+// projectDependencyCacheName is the name of the project cache PVC in the Project namespace
+func projectDependencyCacheName(project *catalystv1alpha1.Project) string {
+	return dependencyCacheName + "-" + project.Name
+}
+
+// dependencyCacheVolumeName is the name of the PersistentVolume binding the cache into namespace
+func dependencyCacheVolumeName(namespace string) string {
+	return dependencyCacheName + "-" + namespace
+}
+
+// desiredProjectDependencyCache builds the project cache PVC
+func desiredProjectDependencyCache(project *catalystv1alpha1.Project) *corev1.PersistentVolumeClaim {
+	cache := project.Spec.DependencyCache
+	size := cache.Size
+	if size.IsZero() {
+		size = defaultDependencyCacheSize
+	}
+	return &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      projectDependencyCacheName(project),
+			Namespace: project.Namespace,
+			Labels: map[string]string{
+				"app.kubernetes.io/managed-by": "catalyst-operator",
+				dependencyCacheProjectLabel:    sanitizeLabelValue(project.Name),
+			},
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteMany},
+			StorageClassName: cache.StorageClassName,
+			Resources: corev1.VolumeResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceStorage: size},
+			},
+		},
+	}
+}
+
+// desiredDependencyCacheVolume copies the project cache PV for the environment namespace
+func desiredDependencyCacheVolume(project *catalystv1alpha1.Project, source *corev1.PersistentVolume, namespace string) *corev1.PersistentVolume {
+	return &corev1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: dependencyCacheVolumeName(namespace),
+			Labels: map[string]string{
+				"app.kubernetes.io/managed-by": "catalyst-operator",
+				dependencyCacheProjectLabel:    sanitizeLabelValue(project.Name),
+			},
+		},
+		Spec: corev1.PersistentVolumeSpec{
+			PersistentVolumeSource:        *source.Spec.PersistentVolumeSource.DeepCopy(),
+			Capacity:                      source.Spec.Capacity.DeepCopy(),
+			AccessModes:                   []corev1.PersistentVolumeAccessMode{corev1.ReadWriteMany},
+			MountOptions:                  slices.Clone(source.Spec.MountOptions),
+			VolumeMode:                    source.Spec.VolumeMode,
+			NodeAffinity:                  source.Spec.NodeAffinity.DeepCopy(),
+			PersistentVolumeReclaimPolicy: corev1.PersistentVolumeReclaimRetain, // Never delete the shared storage
+			StorageClassName:              "",
+			ClaimRef: &corev1.ObjectReference{
+				APIVersion: "v1",
+				Kind:       "PersistentVolumeClaim",
+				Namespace:  namespace,
+				Name:       dependencyCacheName,
+			},
+		},
+	}
+}
+
+// desiredDependencyCacheClaim builds the environment PVC pre-bound to its cache PV
+func desiredDependencyCacheClaim(namespace string, volume *corev1.PersistentVolume) *corev1.PersistentVolumeClaim {
+	return &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      dependencyCacheName,
+			Namespace: namespace,
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteMany},
+			StorageClassName: ptr(""), // Bind the pre-created PV, never provision
+			VolumeName:       volume.Name,
+			Resources: corev1.VolumeResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceStorage: volume.Spec.Capacity[corev1.ResourceStorage]},
+			},
+		},
+	}
+}
+
+// reconcileDependencyCache binds the project dependency cache into the environment namespace.
+// Returns false while the project cache volume is not provisioned yet.
+func (r *EnvironmentReconciler) reconcileDependencyCache(ctx context.Context, project *catalystv1alpha1.Project, namespace string) (bool, error) {
+	log := logf.FromContext(ctx)
+	if project.Spec.DependencyCache == nil {
+		return true, nil
+	}
+
+	projectCache := desiredProjectDependencyCache(project)
+	if err := r.Create(ctx, projectCache); err != nil && !isAlreadyExists(err) {
+		return false, err
+	}
+	if err := r.Get(ctx, client.ObjectKeyFromObject(projectCache), projectCache); err != nil {
+		return false, err
+	}
+	if projectCache.Status.Phase != corev1.ClaimBound || projectCache.Spec.VolumeName == "" {
+		log.Info("Waiting for the project dependency cache volume", "pvc", projectCache.Name, "namespace", project.Namespace)
+		return false, nil
+	}
+
+	source := &corev1.PersistentVolume{}
+	if err := r.Get(ctx, client.ObjectKey{Name: projectCache.Spec.VolumeName}, source); err != nil {
+		return false, err
+	}
+	volume := desiredDependencyCacheVolume(project, source, namespace)
+	if err := r.Create(ctx, volume); err != nil && !isAlreadyExists(err) {
+		return false, err
+	}
+	if err := r.Create(ctx, desiredDependencyCacheClaim(namespace, volume)); err != nil && !isAlreadyExists(err) {
+		return false, err
+	}
+	return true, nil
+}
+
+// deleteDependencyCacheVolume deletes the cluster-scoped cache PV of an environment namespace
+func (r *EnvironmentReconciler) deleteDependencyCacheVolume(ctx context.Context, namespace string) error {
+	volume := &corev1.PersistentVolume{ObjectMeta: metav1.ObjectMeta{Name: dependencyCacheVolumeName(namespace)}}
+	return client.IgnoreNotFound(r.Delete(ctx, volume))
+}
+
+// applyDependencyCache mounts the dependency cache into the user-defined init containers
+func applyDependencyCache(spec *corev1.PodSpec, cache *catalystv1alpha1.DependencyCacheSpec) {
+	mountPath := cache.MountPath
+	if mountPath == "" {
+		mountPath = defaultDependencyCacheMountPath
+	}
+	spec.Volumes = append(spec.Volumes, corev1.Volume{
+		Name: dependencyCacheName,
+		VolumeSource: corev1.VolumeSource{
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: dependencyCacheName},
+		},
+	})
+	for i := range spec.InitContainers {
+		container := &spec.InitContainers[i]
+		if container.Name == "git-clone" || strings.HasPrefix(container.Name, "wait-for-") {
+			continue
+		}
+		container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{Name: dependencyCacheName, MountPath: mountPath})
+		for _, cacheEnv := range dependencyCacheEnv {
+			if !slices.ContainsFunc(container.Env, func(e corev1.EnvVar) bool { return e.Name == cacheEnv.name }) {
+				container.Env = append(container.Env, corev1.EnvVar{Name: cacheEnv.name, Value: path.Join(mountPath, cacheEnv.dir)})
+			}
+		}
+	}
+}
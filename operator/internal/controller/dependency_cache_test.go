@@ -0,0 +1,114 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+func TestApplyDependencyCache(t *testing.T) {
+	env := &catalystv1alpha1.Environment{ObjectMeta: metav1.ObjectMeta{Name: "pr-1"}}
+	project := &catalystv1alpha1.Project{Spec: catalystv1alpha1.ProjectSpec{
+		Sources:         []catalystv1alpha1.SourceConfig{{Name: "app", RepositoryURL: "https://github.com/acme/app", Branch: "main"}},
+		DependencyCache: &catalystv1alpha1.DependencyCacheSpec{},
+	}}
+	config := &catalystv1alpha1.EnvironmentConfig{
+		Image: "node:22-slim",
+		InitContainers: []catalystv1alpha1.InitContainerSpec{{
+			Name:  "npm-install",
+			Image: "node:22-slim",
+			Env:   []corev1.EnvVar{{Name: "npm_config_cache", Value: "/tmp/npm"}},
+		}},
+	}
+
+	deployment := desiredDevelopmentDeploymentFromConfig(env, project, "ns", config)
+	spec := deployment.Spec.Template.Spec
+
+	assert.Contains(t, spec.Volumes, corev1.Volume{Name: dependencyCacheName, VolumeSource: corev1.VolumeSource{
+		PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: dependencyCacheName},
+	}})
+	require.Len(t, spec.InitContainers, 2)
+	assert.Equal(t, "git-clone", spec.InitContainers[0].Name)
+	assert.NotContains(t, spec.InitContainers[0].VolumeMounts, corev1.VolumeMount{Name: dependencyCacheName, MountPath: "/cache"})
+
+	install := spec.InitContainers[1]
+	assert.Contains(t, install.VolumeMounts, corev1.VolumeMount{Name: dependencyCacheName, MountPath: "/cache"})
+	assert.Contains(t, install.Env, corev1.EnvVar{Name: "npm_config_cache", Value: "/tmp/npm"}, "explicit settings win")
+	assert.Contains(t, install.Env, corev1.EnvVar{Name: "npm_config_store_dir", Value: "/cache/pnpm-store"})
+	assert.Contains(t, install.Env, corev1.EnvVar{Name: "GOMODCACHE", Value: "/cache/go/mod"})
+	assert.Len(t, install.Env, len(dependencyCacheEnv))
+}
+
+func TestReconcileDependencyCache(t *testing.T) {
+	project := &catalystv1alpha1.Project{
+		ObjectMeta: metav1.ObjectMeta{Name: "shop", Namespace: "acme"},
+		Spec: catalystv1alpha1.ProjectSpec{DependencyCache: &catalystv1alpha1.DependencyCacheSpec{
+			StorageClassName: ptr("nfs"),
+		}},
+	}
+	c := newFakeClientBuilder().Build()
+	r := &EnvironmentReconciler{Client: c, Scheme: testScheme}
+	ctx := context.Background()
+
+	// The project cache is created and awaited
+	ready, err := r.reconcileDependencyCache(ctx, project, "acme-shop-pr-1")
+	require.NoError(t, err)
+	assert.False(t, ready)
+	projectCache := &corev1.PersistentVolumeClaim{}
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "dependency-cache-shop", Namespace: "acme"}, projectCache))
+	assert.Equal(t, []corev1.PersistentVolumeAccessMode{corev1.ReadWriteMany}, projectCache.Spec.AccessModes)
+	assert.Equal(t, "nfs", *projectCache.Spec.StorageClassName)
+	assert.True(t, defaultDependencyCacheSize.Equal(projectCache.Spec.Resources.Requests[corev1.ResourceStorage]))
+
+	// Once bound, the environment namespace gets a copy of the PV
+	source := &corev1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: "pvc-123"},
+		Spec: corev1.PersistentVolumeSpec{
+			PersistentVolumeSource: corev1.PersistentVolumeSource{CSI: &corev1.CSIPersistentVolumeSource{Driver: "nfs.csi.k8s.io", VolumeHandle: "nfs#share#pvc-123"}},
+			Capacity:               corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("20Gi")},
+			MountOptions:           []string{"nfsvers=4.1"},
+		},
+	}
+	require.NoError(t, c.Create(ctx, source))
+	projectCache.Spec.VolumeName = source.Name
+	require.NoError(t, c.Update(ctx, projectCache))
+	projectCache.Status.Phase = corev1.ClaimBound
+	require.NoError(t, c.Status().Update(ctx, projectCache))
+
+	ready, err = r.reconcileDependencyCache(ctx, project, "acme-shop-pr-1")
+	require.NoError(t, err)
+	assert.True(t, ready)
+
+	volume := &corev1.PersistentVolume{}
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "dependency-cache-acme-shop-pr-1"}, volume))
+	assert.Equal(t, source.Spec.CSI, volume.Spec.CSI)
+	assert.Equal(t, corev1.PersistentVolumeReclaimRetain, volume.Spec.PersistentVolumeReclaimPolicy)
+	assert.Equal(t, []string{"nfsvers=4.1"}, volume.Spec.MountOptions)
+	assert.Equal(t, "acme-shop-pr-1", volume.Spec.ClaimRef.Namespace)
+	assert.Equal(t, dependencyCacheName, volume.Spec.ClaimRef.Name)
+
+	claim := &corev1.PersistentVolumeClaim{}
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: dependencyCacheName, Namespace: "acme-shop-pr-1"}, claim))
+	assert.Equal(t, volume.Name, claim.Spec.VolumeName)
+	assert.Equal(t, "", *claim.Spec.StorageClassName)
+
+	// Environment deletion removes the PV but not the project volume
+	require.NoError(t, r.deleteDependencyCacheVolume(ctx, "acme-shop-pr-1"))
+	assert.True(t, apierrors.IsNotFound(c.Get(ctx, client.ObjectKey{Name: volume.Name}, &corev1.PersistentVolume{})))
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: source.Name}, &corev1.PersistentVolume{}))
+	require.NoError(t, r.deleteDependencyCacheVolume(ctx, "acme-shop-pr-1"))
+
+	// Projects without a dependency cache need nothing
+	ready, err = r.reconcileDependencyCache(ctx, &catalystv1alpha1.Project{}, "ns")
+	require.NoError(t, err)
+	assert.True(t, ready)
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
 	corev1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -21,7 +22,7 @@ import (
 // desiredDeploymentFromConfig creates a deployment from resolved config
 func desiredDeploymentFromConfig(namespace string, config *catalystv1alpha1.EnvironmentConfig) *appsv1.Deployment {
 	name := "web" // Standard name within the isolated namespace
-	replicas := int32(1)
+	replicas := desiredReplicas(config)
 
 	// Build environment variables from config
 	envVars := config.Env
@@ -35,6 +36,7 @@ func desiredDeploymentFromConfig(namespace string, config *catalystv1alpha1.Envi
 		WorkingDir:   config.WorkingDir,
 		Ports:        config.Ports,
 		Env:          envVars,
+		EnvFrom:      []corev1.EnvFromSource{catalystSecretsEnvFrom()},
 		VolumeMounts: config.VolumeMounts,
 	}
 
@@ -66,6 +68,21 @@ func desiredDeploymentFromConfig(namespace string, config *catalystv1alpha1.Envi
 		}
 	}
 
+	// Production mode runs only the provider-backed managed services
+	var providerServices []catalystv1alpha1.ManagedServiceSpec
+	for _, svcSpec := range config.Services {
+		if svcSpec.Provider != "" {
+			providerServices = append(providerServices, svcSpec)
+		}
+	}
+
+	podSpec := corev1.PodSpec{
+		InitContainers: waitForInitContainers(managedServiceWaitTargets(namespace, providerServices)),
+		Containers:     append([]corev1.Container{container}, sidecarContainers(config, envVars)...),
+		Volumes:        volumes,
+	}
+	applyPodSecurity(&podSpec)
+
 	return &appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      name,
@@ -83,15 +100,42 @@ func desiredDeploymentFromConfig(namespace string, config *catalystv1alpha1.Envi
 				ObjectMeta: metav1.ObjectMeta{
 					Labels: map[string]string{"app": name},
 				},
-				Spec: corev1.PodSpec{
-					Containers: []corev1.Container{container},
-					Volumes:    volumes,
-				},
+				Spec: podSpec,
 			},
 		},
 	}
 }
 
+// sidecarContainers builds the sidecar containers of config. They run the main container's
+// image unless they set their own, and see its environment variables (env) with their own
+// merged on top.
+func sidecarContainers(config *catalystv1alpha1.EnvironmentConfig, env []corev1.EnvVar) []corev1.Container {
+	var containers []corev1.Container
+	for _, sidecar := range config.Sidecars {
+		container := corev1.Container{
+			Name:           sidecar.Name,
+			Image:          sidecar.Image,
+			Command:        sidecar.Command,
+			Args:           sidecar.Args,
+			WorkingDir:     sidecar.WorkingDir,
+			Ports:          sidecar.Ports,
+			Env:            mergeEnvVars(env, sidecar.Env),
+			EnvFrom:        []corev1.EnvFromSource{catalystSecretsEnvFrom()},
+			VolumeMounts:   sidecar.VolumeMounts,
+			LivenessProbe:  sidecar.LivenessProbe,
+			ReadinessProbe: sidecar.ReadinessProbe,
+		}
+		if container.Image == "" {
+			container.Image = config.Image
+		}
+		if sidecar.Resources != nil {
+			container.Resources = *sidecar.Resources
+		}
+		containers = append(containers, container)
+	}
+	return containers
+}
+
 // desiredServiceFromConfig creates a service from resolved config
 func desiredServiceFromConfig(namespace string, config *catalystv1alpha1.EnvironmentConfig) *corev1.Service {
 	// Build service ports from config ports
@@ -121,6 +165,58 @@ func desiredServiceFromConfig(namespace string, config *catalystv1alpha1.Environ
 	}
 }
 
+// desiredReplicas returns the initial replica count of the web Deployment: the autoscaler's
+// lower bound when autoscaling, else config.replicas (default 1)
+func desiredReplicas(config *catalystv1alpha1.EnvironmentConfig) int32 {
+	if config.Autoscaling != nil {
+		if config.Autoscaling.MinReplicas != nil {
+			return *config.Autoscaling.MinReplicas
+		}
+		return 1
+	}
+	if config.Replicas != nil {
+		return *config.Replicas
+	}
+	return 1
+}
+
+// desiredHorizontalPodAutoscaler creates the autoscaler of the web Deployment from config.autoscaling
+func desiredHorizontalPodAutoscaler(namespace string, autoscaling *catalystv1alpha1.AutoscalingSpec) *autoscalingv2.HorizontalPodAutoscaler {
+	minReplicas := int32(1)
+	if autoscaling.MinReplicas != nil {
+		minReplicas = *autoscaling.MinReplicas
+	}
+	targetCPU := int32(80)
+	if autoscaling.TargetCPUUtilizationPercentage != nil {
+		targetCPU = *autoscaling.TargetCPUUtilizationPercentage
+	}
+	return &autoscalingv2.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "web",
+			Namespace: namespace,
+		},
+		Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{
+				APIVersion: "apps/v1",
+				Kind:       "Deployment",
+				Name:       "web",
+			},
+			MinReplicas: &minReplicas,
+			MaxReplicas: autoscaling.MaxReplicas,
+			Metrics: []autoscalingv2.MetricSpec{{
+				Type: autoscalingv2.ResourceMetricSourceType,
+				Resource: &autoscalingv2.ResourceMetricSource{
+					Name: corev1.ResourceCPU,
+					Target: autoscalingv2.MetricTarget{
+						Type:               autoscalingv2.UtilizationMetricType,
+						AverageUtilization: &targetCPU,
+					},
+				},
+			}},
+		},
+	}
+}
+
 // desiredIngress creates an Ingress resource for the environment.
 // When isLocal is true, it uses hostname-based routing with *.localhost (e.g., namespace.localhost:8080).
 // When isLocal is false, it uses hostname-based routing with TLS (production mode).
@@ -167,7 +263,7 @@ func desiredIngress(env *catalystv1alpha1.Environment, namespace string, isLocal
 	}
 
 	// Production mode: hostname-based routing with TLS
-	domain := "preview.catalyst.dev"
+	domain := defaultPreviewDomain
 	if len(previewDomain) > 0 && previewDomain[0] != "" {
 		domain = previewDomain[0]
 	}
@@ -222,7 +318,7 @@ func generateURL(env *catalystv1alpha1.Environment, namespace string, isLocal bo
 	}
 
 	// Production hostname-based URL with HTTPS
-	domain := "preview.catalyst.dev"
+	domain := defaultPreviewDomain
 	if len(previewDomain) > 0 && previewDomain[0] != "" {
 		domain = previewDomain[0]
 	}
@@ -0,0 +1,139 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"slices"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+// Deployment history (status.deploymentHistory):
+// Every image set produced by the template builds is recorded with the commit each image was
+// built from. When spec.sources[].commitSha is moved to a commit in the history, its images are
+// redeployed by digest without a build Job, so bisecting a regression across commits only costs
+// a Helm upgrade or a Deployment rollout. spec.rollbackTo redeploys a recorded revision
+// as a whole, with the template it was deployed with (see promotion.go).
+//
+// Each record also keeps the hash of the spec.config overrides it was deployed with and its
+// outcome: Deploying until the environment is Ready with it (Ready) or fails (Failed).
+
+// maxDeploymentHistory bounds the recorded image sets per environment
+const maxDeploymentHistory = 20
+
+// buildCommit returns the commit (or branch) a build is built from, and whether it is pinned
+// to a commit SHA. Only pinned builds are looked up in the deployment history, since branches move.
+func buildCommit(env *catalystv1alpha1.Environment, build catalystv1alpha1.BuildSpec) (string, bool) {
+	for _, s := range env.Spec.Sources {
+		if s.Name != build.SourceRef {
+			continue
+		}
+		if s.CommitSha != "" && s.CommitSha != "HEAD" {
+			return s.CommitSha, true
+		}
+		if s.Branch != "" {
+			return s.Branch, false
+		}
+		break
+	}
+	return "latest", false // Fallback
+}
+
+// historicalImage returns the image previously built for a build from commit, with a known digest
+func historicalImage(history []catalystv1alpha1.DeploymentRecord, buildName, commit string) *catalystv1alpha1.BuiltImage {
+	for i := range history {
+		for j := range history[i].Images {
+			image := &history[i].Images[j]
+			if image.Name == buildName && image.Commit == commit && image.Digest != "" {
+				return image
+			}
+		}
+	}
+	return nil
+}
+
+// configHash returns the content hash of the spec.config overrides of an environment
+func configHash(config catalystv1alpha1.EnvironmentConfig) string {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+// recordDeployment moves images to the front of the history, adding a record with the next
+// revision if the image set was not deployed before with the template of templateHash and
+// the config of configHash, and trims the history to maxDeploymentHistory.
+// Returns the updated history and whether it changed.
+func recordDeployment(history []catalystv1alpha1.DeploymentRecord, images []catalystv1alpha1.BuiltImage, templateHash, configHash string, now metav1.Time) ([]catalystv1alpha1.DeploymentRecord, bool) {
+	sameDeployment := func(record catalystv1alpha1.DeploymentRecord) bool {
+		// Records without a config hash were recorded before it was
+		return record.TemplateHash == templateHash && (record.ConfigHash == "" || record.ConfigHash == configHash) &&
+			equality.Semantic.DeepEqual(record.Images, images)
+	}
+	if len(images) == 0 || (len(history) > 0 && sameDeployment(history[0]) && history[0].ConfigHash == configHash) {
+		return history, false
+	}
+	record := catalystv1alpha1.DeploymentRecord{
+		Images:       slices.Clone(images),
+		TemplateHash: templateHash,
+		ConfigHash:   configHash,
+		DeployedAt:   now,
+		Outcome:      catalystv1alpha1.DeploymentOutcomeDeploying,
+	}
+	for _, previous := range history {
+		record.Revision = max(record.Revision, previous.Revision)
+	}
+	record.Revision++
+	result := []catalystv1alpha1.DeploymentRecord{record}
+	for _, previous := range history {
+		if sameDeployment(previous) {
+			// Redeployed: keep the original revision, deployment time and outcome
+			result[0] = previous
+			result[0].ConfigHash = configHash
+			continue
+		}
+		result = append(result, previous)
+	}
+	if len(result) > maxDeploymentHistory {
+		result = result[:maxDeploymentHistory]
+	}
+	return result, true
+}
+
+// recordDeploymentOutcome records outcome (Ready or Failed) on the revision the environment
+// deploys, the front of its history. A Ready revision stays Ready: later failures are not its
+// deployment's. Reports whether the history changed.
+func recordDeploymentOutcome(env *catalystv1alpha1.Environment, outcome string, now metav1.Time) bool {
+	if len(env.Status.DeploymentHistory) == 0 {
+		return false
+	}
+	record := &env.Status.DeploymentHistory[0]
+	if record.Outcome == outcome || record.Outcome == catalystv1alpha1.DeploymentOutcomeReady {
+		return false
+	}
+	record.Outcome = outcome
+	record.CompletedAt = &now
+	return true
+}
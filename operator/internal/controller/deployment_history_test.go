@@ -0,0 +1,158 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+func TestBuildCommit(t *testing.T) {
+	build := catalystv1alpha1.BuildSpec{Name: "web", SourceRef: "app"}
+	env := &catalystv1alpha1.Environment{Spec: catalystv1alpha1.EnvironmentSpec{
+		Sources: []catalystv1alpha1.EnvironmentSource{{Name: "app", CommitSha: "abc1234def", Branch: "main"}},
+	}}
+
+	commit, pinned := buildCommit(env, build)
+	assert.Equal(t, "abc1234def", commit)
+	assert.True(t, pinned)
+
+	env.Spec.Sources[0].CommitSha = "HEAD"
+	commit, pinned = buildCommit(env, build)
+	assert.Equal(t, "main", commit)
+	assert.False(t, pinned, "branches move, so they are never reused from history")
+
+	commit, pinned = buildCommit(&catalystv1alpha1.Environment{}, build)
+	assert.Equal(t, "latest", commit)
+	assert.False(t, pinned)
+}
+
+func TestRecordDeployment(t *testing.T) {
+	t0 := metav1.NewTime(time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC))
+	a := []catalystv1alpha1.BuiltImage{{Name: "web", Image: "registry/acme/web:aaa", Digest: "sha256:a", Commit: "aaa"}}
+	b := []catalystv1alpha1.BuiltImage{{Name: "web", Image: "registry/acme/web:bbb", Digest: "sha256:b", Commit: "bbb"}}
+
+	history, changed := recordDeployment(nil, a, "t1", "", t0)
+	assert.True(t, changed)
+	require.Len(t, history, 1)
+	assert.Equal(t, int64(1), history[0].Revision)
+	assert.Equal(t, "t1", history[0].TemplateHash)
+
+	_, changed = recordDeployment(history, a, "t1", "", t0)
+	assert.False(t, changed, "redeploying the current image set is not a new record")
+
+	history, _ = recordDeployment(history, b, "t1", "", metav1.NewTime(t0.Add(time.Hour)))
+	assert.Equal(t, int64(2), history[0].Revision)
+	// Going back to a moves it to the front without duplicating it
+	history, changed = recordDeployment(history, a, "t1", "", metav1.NewTime(t0.Add(2*time.Hour)))
+	assert.True(t, changed)
+	require.Len(t, history, 2)
+	assert.Equal(t, a, history[0].Images)
+	assert.Equal(t, t0, history[0].DeployedAt)
+	assert.Equal(t, int64(1), history[0].Revision)
+	assert.Equal(t, b, history[1].Images)
+
+	// The same images with another template are a new revision
+	history, changed = recordDeployment(history, a, "t2", "", metav1.NewTime(t0.Add(3*time.Hour)))
+	assert.True(t, changed)
+	require.Len(t, history, 3)
+	assert.Equal(t, int64(3), history[0].Revision)
+
+	// ... as are the same images with other config overrides
+	history, changed = recordDeployment(history, a, "t2", "c1", metav1.NewTime(t0.Add(3*time.Hour)))
+	assert.True(t, changed)
+	assert.Equal(t, int64(3), history[0].Revision, "records without a config hash predate it and match any config")
+	assert.Equal(t, "c1", history[0].ConfigHash)
+	history, changed = recordDeployment(history, a, "t2", "c2", metav1.NewTime(t0.Add(3*time.Hour)))
+	assert.True(t, changed)
+	assert.Equal(t, int64(4), history[0].Revision)
+	assert.Equal(t, catalystv1alpha1.DeploymentOutcomeDeploying, history[0].Outcome)
+
+	for i := 0; i < maxDeploymentHistory+5; i++ {
+		commit := fmt.Sprintf("c%d", i)
+		history, _ = recordDeployment(history, []catalystv1alpha1.BuiltImage{{Name: "web", Image: "registry/acme/web:" + commit, Commit: commit}}, "t2", "", t0)
+	}
+	assert.Len(t, history, maxDeploymentHistory)
+
+	assert.Nil(t, historicalImage(history, "web", "c30"))
+	assert.Nil(t, historicalImage(history, "web", "c24"), "images without a digest are rebuilt")
+}
+
+func TestRecordDeploymentOutcome(t *testing.T) {
+	t0 := metav1.NewTime(time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC))
+	env := &catalystv1alpha1.Environment{}
+	assert.False(t, recordDeploymentOutcome(env, catalystv1alpha1.DeploymentOutcomeReady, t0), "nothing deployed yet")
+
+	env.Status.DeploymentHistory = []catalystv1alpha1.DeploymentRecord{
+		{Revision: 2, Outcome: catalystv1alpha1.DeploymentOutcomeDeploying},
+		{Revision: 1, Outcome: catalystv1alpha1.DeploymentOutcomeReady},
+	}
+	assert.True(t, recordDeploymentOutcome(env, catalystv1alpha1.DeploymentOutcomeFailed, t0))
+	assert.Equal(t, catalystv1alpha1.DeploymentOutcomeFailed, env.Status.DeploymentHistory[0].Outcome)
+	assert.False(t, recordDeploymentOutcome(env, catalystv1alpha1.DeploymentOutcomeFailed, t0))
+
+	// A retry that succeeds makes the revision Ready, for good
+	later := metav1.NewTime(t0.Add(time.Minute))
+	assert.True(t, recordDeploymentOutcome(env, catalystv1alpha1.DeploymentOutcomeReady, later))
+	assert.Equal(t, &later, env.Status.DeploymentHistory[0].CompletedAt)
+	assert.False(t, recordDeploymentOutcome(env, catalystv1alpha1.DeploymentOutcomeFailed, later))
+	assert.Equal(t, catalystv1alpha1.DeploymentOutcomeReady, env.Status.DeploymentHistory[0].Outcome)
+	assert.Equal(t, catalystv1alpha1.DeploymentOutcomeReady, env.Status.DeploymentHistory[1].Outcome)
+}
+
+func TestConfigHash(t *testing.T) {
+	config := catalystv1alpha1.EnvironmentConfig{}
+	assert.Len(t, configHash(config), 12)
+	assert.Equal(t, configHash(config), configHash(catalystv1alpha1.EnvironmentConfig{}))
+	config.Image = "nginx:1.27"
+	assert.NotEqual(t, configHash(catalystv1alpha1.EnvironmentConfig{}), configHash(config))
+}
+
+func TestReconcileBuildsReusesHistoricalImages(t *testing.T) {
+	deployedAt := metav1.NewTime(time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC))
+	old := []catalystv1alpha1.BuiltImage{{Name: "web", Image: "registry/acme/web:aaa1111", Digest: "sha256:a11", Commit: "aaa1111"}}
+	current := []catalystv1alpha1.BuiltImage{{Name: "web", Image: "registry/acme/web:bbb2222", Digest: "sha256:b22", Commit: "bbb2222"}}
+	env := &catalystv1alpha1.Environment{
+		ObjectMeta: metav1.ObjectMeta{Name: "pr-1", Namespace: "team"},
+		Spec: catalystv1alpha1.EnvironmentSpec{
+			Sources: []catalystv1alpha1.EnvironmentSource{{Name: "app", CommitSha: "aaa1111", Branch: "main"}},
+		},
+		Status: catalystv1alpha1.EnvironmentStatus{
+			BuiltImages: current,
+			DeploymentHistory: []catalystv1alpha1.DeploymentRecord{
+				{Images: current, DeployedAt: deployedAt},
+				{Images: old, DeployedAt: deployedAt},
+			},
+		},
+	}
+	project := &catalystv1alpha1.Project{
+		ObjectMeta: metav1.ObjectMeta{Name: "acme", Namespace: "team"},
+		Spec: catalystv1alpha1.ProjectSpec{
+			Sources: []catalystv1alpha1.SourceConfig{{Name: "app", RepositoryURL: "https://github.com/acme/app"}},
+		},
+	}
+	template := &catalystv1alpha1.EnvironmentTemplateSpec{Builds: []catalystv1alpha1.BuildSpec{{Name: "web", SourceRef: "app"}}}
+	c := newFakeClientBuilder().WithStatusSubresource(env).WithObjects(env).Build()
+	r := &EnvironmentReconciler{Client: c, Scheme: testScheme}
+	ctx := context.Background()
+
+	builtImages, err := r.reconcileBuilds(ctx, env, project, "env-ns", template)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"web": "registry/acme/web:aaa1111@sha256:a11"}, builtImages)
+
+	jobs := &batchv1.JobList{}
+	require.NoError(t, c.List(ctx, jobs, client.InNamespace("env-ns")))
+	assert.Empty(t, jobs.Items, "no build Job for a commit deployed before")
+
+	assert.Equal(t, old, env.Status.BuiltImages)
+	require.Len(t, env.Status.DeploymentHistory, 2)
+	assert.Equal(t, old, env.Status.DeploymentHistory[0].Images)
+}
@@ -0,0 +1,418 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	corev1 "k8s.io/api/core/v1"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+// Dev containers (workspace mode):
+// When the first source of the project has a devcontainer.json, the workspace pod follows it
+// like VS Code does: the repository is cloned into the workspace folder, the container runs
+// the image with containerEnv and remoteEnv, exposes forwardPorts, mounts the volume and
+// tmpfs mounts as emptyDirs and runs postCreateCommand once. Features cannot be installed
+// into a running pod; images should be prebuilt with them (devcontainer build), and the
+// requested features are recorded in the catalyst.dev/devcontainer-features annotation.
+// Bind mounts, build and dockerComposeFile configurations are not supported. Repositories
+// with a flake.nix instead get the nix workspace (see nix.go).
+
+// devContainerPaths are the locations of devcontainer.json, in lookup order
+var devContainerPaths = []string{".devcontainer/devcontainer.json", ".devcontainer.json"}
+
+const (
+	devContainerFeaturesAnnotation = "catalyst.dev/devcontainer-features"
+	devContainerVolumeName         = "workspace"
+	// devContainerPostCreateMarker records that postCreateCommand ran, so container restarts
+	// do not run it again
+	devContainerPostCreateMarker = "/tmp/.catalyst-post-create"
+)
+
+// devContainer is the subset of devcontainer.json workspace pods use
+type devContainer struct {
+	Image             string                     `json:"image"`
+	Features          map[string]json.RawMessage `json:"features"`
+	ForwardPorts      []json.RawMessage          `json:"forwardPorts"`
+	PostCreateCommand json.RawMessage            `json:"postCreateCommand"`
+	Mounts            []json.RawMessage          `json:"mounts"`
+	ContainerEnv      map[string]string          `json:"containerEnv"`
+	RemoteEnv         map[string]string          `json:"remoteEnv"`
+	WorkspaceFolder   string                     `json:"workspaceFolder"`
+
+	// postCreate is the shell command of PostCreateCommand
+	postCreate string
+	// nix mounts a writable nix store (nix workspaces)
+	nix bool
+}
+
+// workspaceDevContainer is the dev container of a workspace pod: its devcontainer.json and the
+// source cloned into the workspace folder
+type workspaceDevContainer struct {
+	Config  *devContainer
+	Project *catalystv1alpha1.Project
+	Source  *catalystv1alpha1.SourceConfig
+	Commit  string
+}
+
+// parseDevContainer parses a devcontainer.json, which is JSON with comments and trailing commas
+func parseDevContainer(data []byte) (*devContainer, error) {
+	dc := &devContainer{}
+	if err := json.Unmarshal(stripJSONCommas(stripJSONComments(data)), dc); err != nil {
+		return nil, fmt.Errorf("invalid devcontainer.json: %w", err)
+	}
+	postCreate, err := devContainerCommand(dc.PostCreateCommand)
+	if err != nil {
+		return nil, fmt.Errorf("invalid devcontainer.json postCreateCommand: %w", err)
+	}
+	dc.postCreate = postCreate
+	return dc, nil
+}
+
+// jsonStringEnd returns the index after the JSON string starting at data[start]
+func jsonStringEnd(data []byte, start int) int {
+	for i := start + 1; i < len(data); i++ {
+		switch data[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		}
+	}
+	return len(data)
+}
+
+// stripJSONComments removes // and /* */ comments outside of strings
+func stripJSONComments(data []byte) []byte {
+	out := make([]byte, 0, len(data))
+	for i := 0; i < len(data); {
+		switch {
+		case data[i] == '"':
+			end := jsonStringEnd(data, i)
+			out = append(out, data[i:end]...)
+			i = end
+		case bytes.HasPrefix(data[i:], []byte("//")):
+			end := bytes.IndexByte(data[i:], '\n')
+			if end < 0 {
+				return out
+			}
+			i += end
+		case bytes.HasPrefix(data[i:], []byte("/*")):
+			end := bytes.Index(data[i+2:], []byte("*/"))
+			if end < 0 {
+				return out
+			}
+			i += end + 4
+		default:
+			out = append(out, data[i])
+			i++
+		}
+	}
+	return out
+}
+
+// stripJSONCommas removes trailing commas before closing brackets outside of strings
+func stripJSONCommas(data []byte) []byte {
+	out := make([]byte, 0, len(data))
+	for i := 0; i < len(data); {
+		switch data[i] {
+		case '"':
+			end := jsonStringEnd(data, i)
+			out = append(out, data[i:end]...)
+			i = end
+		case ',':
+			next := bytes.TrimLeft(data[i+1:], " \t\r\n")
+			if len(next) == 0 || (next[0] != '}' && next[0] != ']') {
+				out = append(out, ',')
+			}
+			i++
+		default:
+			out = append(out, data[i])
+			i++
+		}
+	}
+	return out
+}
+
+// readDevContainer reads the devcontainer.json of revision, falling back to the nix workspace
+// when it has a flake.nix. Nil if it has neither.
+func readDevContainer(repo *git.Repository, revision string) (*devContainer, error) {
+	hash, err := resolveCommit(repo, revision)
+	if err != nil {
+		return nil, err
+	}
+	commit, err := repo.CommitObject(hash)
+	if err != nil {
+		return nil, fmt.Errorf("commit %s: %w", revision, err)
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range devContainerPaths {
+		file, err := tree.File(name)
+		if errors.Is(err, object.ErrFileNotFound) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		contents, err := file.Contents()
+		if err != nil {
+			return nil, err
+		}
+		return parseDevContainer([]byte(contents))
+	}
+	if _, err := tree.File(nixFlakeFile); err == nil {
+		return nixWorkspace(), nil
+	} else if !errors.Is(err, object.ErrFileNotFound) {
+		return nil, err
+	}
+	return nil, nil
+}
+
+// loadWorkspaceDevContainer reads the devcontainer.json of the project's first source at the
+// environment's revision, nil if there is none
+func (r *EnvironmentReconciler) loadWorkspaceDevContainer(ctx context.Context, env *catalystv1alpha1.Environment, project *catalystv1alpha1.Project) (*workspaceDevContainer, error) {
+	if project == nil || len(project.Spec.Sources) == 0 || project.Spec.Sources[0].RepositoryURL == "" {
+		return nil, nil
+	}
+	source := &project.Spec.Sources[0]
+	repo, cleanup, err := r.cloneSourceHistory(ctx, env, project, source)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+	commit := cloneRevision(env, project)
+	dc, err := readDevContainer(repo, commit)
+	if err != nil || dc == nil {
+		return nil, err
+	}
+	return &workspaceDevContainer{Config: dc, Project: project, Source: source, Commit: commit}, nil
+}
+
+// workspaceFolder is where the repository is cloned, /workspaces/<repository> by default
+func (w *workspaceDevContainer) workspaceFolder() string {
+	if w.Config.WorkspaceFolder != "" {
+		return w.Config.WorkspaceFolder
+	}
+	return "/workspaces/" + path.Base(strings.TrimSuffix(strings.TrimSuffix(w.Source.RepositoryURL, "/"), ".git"))
+}
+
+// shellQuote quotes s for POSIX shells
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// devContainerCommand returns the shell command of a lifecycle command, which is a string run
+// by a shell, an array run without one, or an object of commands run in parallel
+func devContainerCommand(raw json.RawMessage) (string, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return "", nil
+	}
+	var command string
+	if err := json.Unmarshal(raw, &command); err == nil {
+		return command, nil
+	}
+	var args []string
+	if err := json.Unmarshal(raw, &args); err == nil {
+		quoted := make([]string, len(args))
+		for i, arg := range args {
+			quoted[i] = shellQuote(arg)
+		}
+		return strings.Join(quoted, " "), nil
+	}
+	var parallel map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &parallel); err != nil {
+		return "", fmt.Errorf("invalid lifecycle command %s", raw)
+	}
+	names := make([]string, 0, len(parallel))
+	for name := range parallel {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var script strings.Builder
+	for _, name := range names {
+		command, err := devContainerCommand(parallel[name])
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&script, "(%s) &\n", command)
+	}
+	script.WriteString("wait")
+	return script.String(), nil
+}
+
+// devContainerPorts returns the forwardPorts of the container itself; "service:port" entries
+// of other compose services are skipped
+func devContainerPorts(forwardPorts []json.RawMessage) []corev1.ContainerPort {
+	var ports []corev1.ContainerPort
+	for _, raw := range forwardPorts {
+		var value string
+		var number int
+		if err := json.Unmarshal(raw, &number); err == nil {
+			value = strconv.Itoa(number)
+		} else if err := json.Unmarshal(raw, &value); err != nil {
+			continue
+		}
+		if host, port, ok := strings.Cut(value, ":"); ok {
+			if host != "localhost" && host != "127.0.0.1" {
+				continue
+			}
+			value = port
+		}
+		port, err := strconv.Atoi(value)
+		if err != nil || port < 1 || port > 65535 {
+			continue
+		}
+		if !slices.ContainsFunc(ports, func(p corev1.ContainerPort) bool { return p.ContainerPort == int32(port) }) {
+			ports = append(ports, corev1.ContainerPort{ContainerPort: int32(port), Protocol: corev1.ProtocolTCP})
+		}
+	}
+	return ports
+}
+
+// devContainerMount is a mount of devcontainer.json, in its object form
+type devContainerMount struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+	Type   string `json:"type"`
+}
+
+// parseDevContainerMount parses a mount, either an object or a
+// "source=...,target=...,type=..." string
+func parseDevContainerMount(raw json.RawMessage) (devContainerMount, bool) {
+	var mount devContainerMount
+	var spec string
+	if err := json.Unmarshal(raw, &spec); err != nil {
+		return mount, json.Unmarshal(raw, &mount) == nil && mount.Target != ""
+	}
+	for _, field := range strings.Split(spec, ",") {
+		key, value, _ := strings.Cut(field, "=")
+		switch key {
+		case "source", "src":
+			mount.Source = value
+		case "target", "destination", "dst":
+			mount.Target = value
+		case "type":
+			mount.Type = value
+		}
+	}
+	return mount, mount.Target != ""
+}
+
+// devContainerVariables substitutes the ${containerWorkspaceFolder} variables
+func devContainerVariables(folder string) *strings.Replacer {
+	return strings.NewReplacer(
+		"${containerWorkspaceFolder}", folder,
+		"${containerWorkspaceFolderBasename}", path.Base(folder),
+	)
+}
+
+// devContainerEnv returns containerEnv and remoteEnv, remoteEnv taking precedence
+func devContainerEnv(dc *devContainer, folder string) []corev1.EnvVar {
+	replacer := devContainerVariables(folder)
+	values := map[string]string{}
+	for name, value := range dc.ContainerEnv {
+		values[name] = replacer.Replace(value)
+	}
+	for name, value := range dc.RemoteEnv {
+		values[name] = replacer.Replace(value)
+	}
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	envVars := make([]corev1.EnvVar, 0, len(names))
+	for _, name := range names {
+		envVars = append(envVars, corev1.EnvVar{Name: name, Value: values[name]})
+	}
+	return envVars
+}
+
+// applyDevContainer turns the workspace container of pod into the dev container, with an
+// init container cloning the source into the workspace folder
+func applyDevContainer(pod *corev1.Pod, w *workspaceDevContainer) {
+	dc := w.Config
+	folder := w.workspaceFolder()
+
+	spec := &pod.Spec
+	spec.InitContainers = append(spec.InitContainers, gitCloneInitContainer(w.Project, w.Source, w.Commit, devContainerVolumeName, folder))
+	spec.Volumes = append(spec.Volumes,
+		corev1.Volume{Name: devContainerVolumeName, VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+		gitScriptsVolume(),
+	)
+
+	container := &spec.Containers[0]
+	if dc.Image != "" {
+		container.Image = dc.Image
+	}
+	container.WorkingDir = folder
+	container.Env = append(container.Env, devContainerEnv(dc, folder)...)
+	container.Ports = append(container.Ports, devContainerPorts(dc.ForwardPorts)...)
+	container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{Name: devContainerVolumeName, MountPath: folder})
+	for i, raw := range dc.Mounts {
+		mount, ok := parseDevContainerMount(raw)
+		mount.Target = devContainerVariables(folder).Replace(mount.Target)
+		if !ok || mount.Target == folder || (mount.Type != "volume" && mount.Type != "tmpfs") {
+			continue
+		}
+		name := fmt.Sprintf("devcontainer-mount-%d", i)
+		emptyDir := &corev1.EmptyDirVolumeSource{}
+		if mount.Type == "tmpfs" {
+			emptyDir.Medium = corev1.StorageMediumMemory
+		}
+		spec.Volumes = append(spec.Volumes, corev1.Volume{Name: name, VolumeSource: corev1.VolumeSource{EmptyDir: emptyDir}})
+		container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{Name: name, MountPath: mount.Target})
+	}
+	if dc.nix {
+		applyNixStore(spec, container)
+	}
+	if dc.postCreate != "" {
+		container.Command = []string{"sh", "-c", fmt.Sprintf(`if [ ! -f %[1]s ]; then
+  touch %[1]s
+  (%[2]s) || echo "postCreateCommand failed" >&2
+fi
+exec sleep infinity
+`, devContainerPostCreateMarker, dc.postCreate)}
+	}
+
+	if len(dc.Features) > 0 {
+		features := make([]string, 0, len(dc.Features))
+		for feature := range dc.Features {
+			features = append(features, feature)
+		}
+		sort.Strings(features)
+		if pod.Annotations == nil {
+			pod.Annotations = map[string]string{}
+		}
+		pod.Annotations[devContainerFeaturesAnnotation] = strings.Join(features, ",")
+	}
+}
@@ -0,0 +1,142 @@
+package controller
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-billy/v5/util"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+const testDevContainer = `{
+	// Node workspace, see https://containers.dev
+	"image": "mcr.microsoft.com/devcontainers/typescript-node:22",
+	"features": {
+		"ghcr.io/devcontainers/features/github-cli:1": {},
+		"ghcr.io/devcontainers/features/docker-in-docker:2": {"version": "latest"},
+	},
+	/* the app and storybook */
+	"forwardPorts": [3000, "localhost:6006", "db:5432"],
+	"postCreateCommand": "npm ci",
+	"mounts": [
+		"source=node-modules,target=${containerWorkspaceFolder}/node_modules,type=volume",
+		{"source": "/var/run/docker.sock", "target": "/var/run/docker.sock", "type": "bind"},
+		{"target": "/scratch", "type": "tmpfs"},
+	],
+	"containerEnv": {"NODE_ENV": "development", "APP_ROOT": "${containerWorkspaceFolder}"},
+	"remoteEnv": {"NODE_ENV": "test"},
+}`
+
+func TestParseDevContainer(t *testing.T) {
+	dc, err := parseDevContainer([]byte(testDevContainer))
+	require.NoError(t, err)
+	assert.Equal(t, "mcr.microsoft.com/devcontainers/typescript-node:22", dc.Image)
+	assert.Len(t, dc.Features, 2)
+	assert.Equal(t, "npm ci", dc.postCreate)
+
+	_, err = parseDevContainer([]byte(`{"image": `))
+	assert.Error(t, err)
+	_, err = parseDevContainer([]byte(`{"postCreateCommand": 1}`))
+	assert.Error(t, err)
+}
+
+func TestStripJSONComments(t *testing.T) {
+	assert.Equal(t, `{"url": "https://example.com/*x*/"} `+"\n",
+		string(stripJSONComments([]byte(`{"url": "https://example.com/*x*/"} // trailing`+"\n"))))
+	assert.Equal(t, `{"a": "x\",", "b": [1]}`, string(stripJSONCommas([]byte(`{"a": "x\",", "b": [1,],}`))))
+}
+
+func TestDevContainerCommand(t *testing.T) {
+	command, err := devContainerCommand(json.RawMessage(`["npm", "run", "it's"]`))
+	require.NoError(t, err)
+	assert.Equal(t, `'npm' 'run' 'it'\''s'`, command)
+
+	command, err = devContainerCommand(json.RawMessage(`{"web": "npm ci", "api": ["go", "mod", "download"]}`))
+	require.NoError(t, err)
+	assert.Equal(t, "('go' 'mod' 'download') &\n(npm ci) &\nwait", command)
+
+	command, err = devContainerCommand(nil)
+	require.NoError(t, err)
+	assert.Empty(t, command)
+}
+
+func TestDesiredWorkspacePod_DevContainer(t *testing.T) {
+	dc, err := parseDevContainer([]byte(testDevContainer))
+	require.NoError(t, err)
+	project := &catalystv1alpha1.Project{
+		Spec: catalystv1alpha1.ProjectSpec{
+			Sources: []catalystv1alpha1.SourceConfig{{Name: "primary", RepositoryURL: "https://github.com/acme/shop.git"}},
+		},
+	}
+	env := &catalystv1alpha1.Environment{
+		ObjectMeta: metav1.ObjectMeta{Name: "pr-1"},
+		Spec:       catalystv1alpha1.EnvironmentSpec{ProjectRef: catalystv1alpha1.ProjectReference{Name: "shop"}},
+	}
+	pod := desiredWorkspacePod(env, "env-ns", &workspaceDevContainer{
+		Config: dc, Project: project, Source: &project.Spec.Sources[0], Commit: "main",
+	})
+
+	require.Len(t, pod.Spec.InitContainers, 1)
+	clone := pod.Spec.InitContainers[0]
+	assert.Equal(t, "git-clone", clone.Name)
+	assert.Contains(t, clone.Env, corev1.EnvVar{Name: "GIT_CLONE_ROOT", Value: "/workspaces/shop"})
+
+	container := pod.Spec.Containers[0]
+	assert.Equal(t, dc.Image, container.Image)
+	assert.Equal(t, "/workspaces/shop", container.WorkingDir)
+	assert.Equal(t, []corev1.EnvVar{
+		{Name: "APP_ROOT", Value: "/workspaces/shop"},
+		{Name: "NODE_ENV", Value: "test"},
+	}, container.Env)
+	assert.Equal(t, []corev1.ContainerPort{
+		{ContainerPort: 3000, Protocol: corev1.ProtocolTCP},
+		{ContainerPort: 6006, Protocol: corev1.ProtocolTCP},
+	}, container.Ports)
+	assert.True(t, hasMountPath(container.VolumeMounts, "/workspaces/shop"))
+	assert.True(t, hasMountPath(container.VolumeMounts, "/workspaces/shop/node_modules"))
+	assert.True(t, hasMountPath(container.VolumeMounts, "/scratch"))
+	assert.False(t, hasMountPath(container.VolumeMounts, "/var/run/docker.sock"), "bind mounts are skipped")
+	assert.Contains(t, container.Command[2], "(npm ci) || echo")
+	assert.Equal(t, "ghcr.io/devcontainers/features/docker-in-docker:2,ghcr.io/devcontainers/features/github-cli:1",
+		pod.Annotations[devContainerFeaturesAnnotation])
+	assertRestricted(t, pod.Spec)
+}
+
+func TestReadDevContainer(t *testing.T) {
+	fs := memfs.New()
+	repo, err := git.Init(memory.NewStorage(), fs)
+	require.NoError(t, err)
+	worktree, err := repo.Worktree()
+	require.NoError(t, err)
+	commit := func(name, content string) string {
+		require.NoError(t, util.WriteFile(fs, name, []byte(content), 0644))
+		_, err := worktree.Add(name)
+		require.NoError(t, err)
+		hash, err := worktree.Commit("change", &git.CommitOptions{
+			Author: &object.Signature{Name: "dev", Email: "dev@example.com", When: time.Now()},
+		})
+		require.NoError(t, err)
+		return hash.String()
+	}
+
+	without := commit("README.md", "readme")
+	dc, err := readDevContainer(repo, without)
+	require.NoError(t, err)
+	assert.Nil(t, dc)
+
+	with := commit(".devcontainer/devcontainer.json", `{"image": "golang:1.25"}`)
+	dc, err = readDevContainer(repo, with)
+	require.NoError(t, err)
+	require.NotNil(t, dc)
+	assert.Equal(t, "golang:1.25", dc.Image)
+}
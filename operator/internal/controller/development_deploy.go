@@ -19,7 +19,7 @@ package controller
 import (
 	"context"
 	"fmt"
-	"os"
+	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -30,7 +30,8 @@ import (
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 
 	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
-	"github.com/ncrmro/catalyst/operator/internal/secrets"
+	"github.com/ncrmro/catalyst/operator/internal/guardrails"
+	"github.com/ncrmro/catalyst/operator/internal/operatorconfig"
 )
 
 // gitScriptsConfigMapName is the name of the ConfigMap containing git scripts
@@ -38,7 +39,7 @@ const gitScriptsConfigMapName = "catalyst-git-scripts"
 
 // ReconcileDevelopmentMode handles the reconciliation for development deployment mode.
 // It creates PVCs, managed services, and a web deployment based on the resolved config from the Project template and Environment overrides.
-func (r *EnvironmentReconciler) ReconcileDevelopmentMode(ctx context.Context, env *catalystv1alpha1.Environment, project *catalystv1alpha1.Project, namespace string, template *catalystv1alpha1.EnvironmentTemplate) (bool, error) {
+func (r *EnvironmentReconciler) ReconcileDevelopmentMode(ctx context.Context, env *catalystv1alpha1.Environment, project *catalystv1alpha1.Project, namespace string, template *catalystv1alpha1.EnvironmentTemplateSpec) (bool, error) {
 	log := logf.FromContext(ctx)
 
 	// 0. Get and validate configuration (required - no fallbacks)
@@ -46,6 +47,10 @@ func (r *EnvironmentReconciler) ReconcileDevelopmentMode(ctx context.Context, en
 	if err != nil {
 		return false, fmt.Errorf("failed to get template config: %w", err)
 	}
+	if template != nil {
+		// Honor the template revision the environment is pinned to
+		templateConfig = template.Config
+	}
 
 	// Debug logging for config resolution
 	log.Info("Template config resolution",
@@ -55,10 +60,21 @@ func (r *EnvironmentReconciler) ReconcileDevelopmentMode(ctx context.Context, en
 
 	// Resolve config (merge template + environment overrides)
 	config := resolveConfig(&env.Spec.Config, templateConfig)
+	expandFrameworkPreset(&config)
+	config.Services = expandServicePresets(config.Services)
+	resolveStorage(&config, project.Spec.Storage)
 
 	// Validate that required fields are present
 	if err := validateConfig(&config); err != nil {
-		return false, fmt.Errorf("invalid configuration: %w", err)
+		return false, withFailureReason(catalystv1alpha1.FailureReasonConfigInvalid, fmt.Errorf("invalid configuration: %w", err))
+	}
+	if err := validatePoolers(&config, "development"); err != nil {
+		return false, withFailureReason(catalystv1alpha1.FailureReasonConfigInvalid, fmt.Errorf("invalid configuration: %w", err))
+	}
+
+	// Enforce platform guardrails on the resolved config
+	if err := guardrails.AsError(guardrails.Current().CheckConfig("environment config", &config)); err != nil {
+		return false, err
 	}
 
 	log.Info("Using resolved config",
@@ -77,7 +93,8 @@ func (r *EnvironmentReconciler) ReconcileDevelopmentMode(ctx context.Context, en
 		log.Info("Git scripts ConfigMap ensured", "namespace", namespace)
 	}
 
-	// 2. Create volumes from config
+	// 2. Create volumes from config; a new code volume starts from the workspace prebuild
+	codeVolumeName, _ := codeVolume(&config)
 	for _, volSpec := range config.Volumes {
 		if volSpec.PersistentVolumeClaim != nil {
 			pvc := &corev1.PersistentVolumeClaim{
@@ -85,7 +102,12 @@ func (r *EnvironmentReconciler) ReconcileDevelopmentMode(ctx context.Context, en
 					Name:      volSpec.Name,
 					Namespace: namespace,
 				},
-				Spec: *volSpec.PersistentVolumeClaim,
+				Spec: *volSpec.PersistentVolumeClaim.DeepCopy(),
+			}
+			if volSpec.Name == codeVolumeName {
+				if err := r.clonePrebuiltWorkspace(ctx, env, project, pvc); err != nil {
+					return false, err
+				}
 			}
 			if err := r.Create(ctx, pvc); err != nil && !isAlreadyExists(err) {
 				return false, fmt.Errorf("failed to create PVC %s: %w", volSpec.Name, err)
@@ -93,11 +115,40 @@ func (r *EnvironmentReconciler) ReconcileDevelopmentMode(ctx context.Context, en
 		}
 	}
 	log.Info("PVCs created/verified from config", "namespace", namespace, "count", len(config.Volumes))
+	if err := r.releasePrebuildGrant(ctx, namespace, codeVolumeName); err != nil {
+		return false, fmt.Errorf("failed to release prebuild ReferenceGrant: %w", err)
+	}
+
+	// 2a. Grow the volume and managed-service claims whose size grew
+	if err := r.expandClaims(ctx, env, namespace, configClaimSizes(&config)); err != nil {
+		return false, err
+	}
+
+	// 2b. Bind the project dependency cache into the namespace
+	if cacheReady, err := r.reconcileDependencyCache(ctx, project, namespace); err != nil {
+		return false, fmt.Errorf("failed to reconcile dependency cache: %w", err)
+	} else if !cacheReady {
+		return false, nil // Requeue
+	}
 
 	// 3. Create managed services from config (e.g., postgres, redis)
 	for _, svcSpec := range config.Services {
+		// Services handed to a Postgres operator get no StatefulSet
+		if svcSpec.Provider != "" {
+			if ready, err := r.reconcilePostgresProvider(ctx, env, namespace, svcSpec); err != nil {
+				return false, err
+			} else if !ready {
+				return false, nil // Requeue
+			}
+			log.Info("Managed service created/verified", "service", svcSpec.Name, "provider", svcSpec.Provider, "namespace", namespace)
+			continue
+		}
+
 		// Create StatefulSet for the service
 		statefulSet := desiredManagedServiceStatefulSet(namespace, svcSpec)
+		labelEnvironmentWorkload(env, statefulSet)
+		prioritizeEnvironmentWorkload(env, statefulSet)
+		scheduleEnvironmentWorkload(&config, statefulSet)
 		if err := r.Create(ctx, statefulSet); err != nil && !isAlreadyExists(err) {
 			return false, fmt.Errorf("failed to create StatefulSet for service %s: %w", svcSpec.Name, err)
 		}
@@ -119,39 +170,56 @@ func (r *EnvironmentReconciler) ReconcileDevelopmentMode(ctx context.Context, en
 			log.Info("Waiting for managed service to be ready", "service", svcSpec.Name, "namespace", namespace)
 			return false, nil // Requeue
 		}
-	}
 
-	// 4. Fetch and sync secrets from web API (before creating deployment)
-	// Read environmentId from annotation
-	environmentId := env.ObjectMeta.Annotations["catalyst.dev/environment-id"]
-	if environmentId != "" {
-		webAPIURL := getCatalystWebURL()
-		log.Info("Fetching secrets from web API", "environmentId", environmentId, "webAPIURL", webAPIURL)
+		// Optional seeding from a dump before the app starts
+		if svcSpec.Seed != nil && svcSpec.Seed.DumpURL != "" {
+			if !isPostgresService(svcSpec) {
+				log.Info("Ignoring seed on non-postgres service", "service", svcSpec.Name)
+			} else if seeded, err := r.reconcileSeed(ctx, env, namespace, &config, svcSpec); err != nil {
+				return false, err
+			} else if !seeded {
+				return false, nil // Requeue
+			}
+		}
 
-		// Import secrets package
-		secretsFetcher := secrets.NewSecretsFetcher(webAPIURL)
-		fetchedSecrets, err := secretsFetcher.FetchSecrets(ctx, environmentId)
-		if err != nil {
-			log.Error(err, "Failed to fetch secrets from web API", "environmentId", environmentId)
-			log.Info("Continuing deployment without secrets - graceful degradation")
-		} else {
-			// Sync secrets to K8s Secret
-			if err := r.SyncCatalystSecrets(ctx, namespace, fetchedSecrets); err != nil {
-				log.Error(err, "Failed to sync secrets to Kubernetes", "namespace", namespace)
-				log.Info("Continuing deployment without secrets - graceful degradation")
-			} else {
-				log.Info("Successfully synced secrets", "namespace", namespace, "secretCount", len(fetchedSecrets))
+		// Optional pgbouncer pooler in front of postgres
+		if svcSpec.Pooler != nil {
+			poolerReady, err := r.reconcilePooler(ctx, env, namespace, &config, svcSpec)
+			if err != nil {
+				return false, err
+			}
+			if !poolerReady {
+				return false, nil // Requeue
 			}
 		}
-	} else {
-		log.Info("Environment does not have environmentId annotation, skipping secret fetching")
+	}
+
+	// 4. Secrets from the web API are synced into catalyst-secrets before the mode runs;
+	// their hash restarts the web pods when they change
+	secretsHash, err := r.catalystSecretsHash(ctx, namespace)
+	if err != nil {
+		return false, err
 	}
 
 	// 5. Create web deployment and service using config
+	// Route DATABASE_URL through the connection pooler when enabled
+	config.Env = rewriteDatabaseURLForPooler(config.Env, config.Services)
+	config.Env = postgresConnectionEnv(config.Env, config.Services)
 	webDeployment := desiredDevelopmentDeploymentFromConfig(env, project, namespace, &config)
+	labelEnvironmentWorkload(env, webDeployment)
+	prioritizeEnvironmentWorkload(env, webDeployment)
+	scheduleEnvironmentWorkload(&config, webDeployment)
+	setSecretsHash(&webDeployment.Spec.Template, secretsHash)
+	setGitScriptsHash(&webDeployment.Spec.Template)
 	if err := r.Create(ctx, webDeployment); err != nil && !isAlreadyExists(err) {
 		return false, err
 	}
+	if err := r.rolloutSecretsHash(ctx, namespace, webDeployment.Name, secretsHash); err != nil {
+		return false, err
+	}
+	if err := r.rolloutGitScriptsHash(ctx, namespace, webDeployment.Name); err != nil {
+		return false, err
+	}
 
 	webService := desiredDevelopmentServiceFromConfig(namespace, &config)
 	if err := r.Create(ctx, webService); err != nil && !isAlreadyExists(err) {
@@ -199,10 +267,15 @@ func (r *EnvironmentReconciler) ReconcileDevelopmentMode(ctx context.Context, en
 					"initContainers", fmt.Sprintf("%v", initStatuses),
 				)
 			}
+			// A failing init step never makes the pod ready on its own (e.g. npm install OOM)
+			if failure := findInitContainerFailure(podList.Items, initContainerTimeouts(&config), time.Now()); failure != nil {
+				return false, reportInitContainerFailure(env, failure)
+			}
 		}
+		return false, nil
 	}
 
-	return webReady, nil
+	return true, r.clearInitContainerFailure(ctx, env)
 }
 
 // isDeploymentReady checks if a deployment has ready replicas
@@ -240,11 +313,13 @@ func desiredManagedServiceStatefulSet(namespace string, svcSpec catalystv1alpha1
 
 	// Build container spec from service config
 	container := corev1.Container{
-		Name:      svcSpec.Name,
-		Image:     svcSpec.Container.Image,
-		Ports:     svcSpec.Container.Ports,
-		Env:       svcSpec.Container.Env,
-		Resources: corev1.ResourceRequirements{},
+		Name:           svcSpec.Name,
+		Image:          svcSpec.Container.Image,
+		Args:           svcSpec.Container.Args,
+		Ports:          svcSpec.Container.Ports,
+		Env:            svcSpec.Container.Env,
+		Resources:      corev1.ResourceRequirements{},
+		ReadinessProbe: svcSpec.Container.ReadinessProbe,
 	}
 
 	if svcSpec.Container.Resources != nil {
@@ -254,10 +329,8 @@ func desiredManagedServiceStatefulSet(namespace string, svcSpec catalystv1alpha1
 	// Add volume mount if storage is defined
 	if svcSpec.Storage != nil {
 		// Use correct mount path for known services
-		mountPath := "/var/lib/" + svcSpec.Name
-		if svcSpec.Name == "postgres" || svcSpec.Name == "postgresql" {
-			mountPath = "/var/lib/postgresql/data"
-
+		mountPath := managedServiceDataPath(svcSpec)
+		if isPostgresService(svcSpec) {
 			// Ensure PGDATA env var is set to match mount path
 			hasPGDATA := false
 			for _, env := range container.Env {
@@ -282,6 +355,20 @@ func desiredManagedServiceStatefulSet(namespace string, svcSpec catalystv1alpha1
 		}
 	}
 
+	podSpec := corev1.PodSpec{
+		Containers: []corev1.Container{container},
+	}
+	switch {
+	case isPostgresService(svcSpec):
+		// The server socket and lock file
+		applyPodSecurity(&podSpec, "/var/run/postgresql")
+	case servicePresets[svcSpec.Preset].dataPath != "":
+		// Presets write their data path, with or without a data volume
+		applyPodSecurity(&podSpec, servicePresets[svcSpec.Preset].dataPath)
+	default:
+		applyPodSecurity(&podSpec)
+	}
+
 	// Build StatefulSet
 	statefulSet := &appsv1.StatefulSet{
 		ObjectMeta: metav1.ObjectMeta{
@@ -301,9 +388,7 @@ func desiredManagedServiceStatefulSet(namespace string, svcSpec catalystv1alpha1
 				ObjectMeta: metav1.ObjectMeta{
 					Labels: map[string]string{"app": svcSpec.Name},
 				},
-				Spec: corev1.PodSpec{
-					Containers: []corev1.Container{container},
-				},
+				Spec: podSpec,
 			},
 		},
 	}
@@ -315,9 +400,13 @@ func desiredManagedServiceStatefulSet(namespace string, svcSpec catalystv1alpha1
 				ObjectMeta: metav1.ObjectMeta{
 					Name: svcSpec.Name + "-data",
 				},
-				Spec: *svcSpec.Storage,
+				Spec: *svcSpec.Storage.DeepCopy(),
 			},
 		}
+		// Clone the data volume from the golden snapshot
+		if svcSpec.Seed != nil && svcSpec.Seed.VolumeSnapshot != "" {
+			seedVolumeDataSource(&statefulSet.Spec.VolumeClaimTemplates[0].Spec, svcSpec.Seed.VolumeSnapshot)
+		}
 	}
 
 	return statefulSet
@@ -329,6 +418,8 @@ func desiredManagedServiceService(namespace string, svcSpec catalystv1alpha1.Man
 	servicePorts := []corev1.ServicePort{}
 	for _, containerPort := range svcSpec.Container.Ports {
 		servicePorts = append(servicePorts, corev1.ServicePort{
+			// Required once the service exposes several ports
+			Name:       containerPort.Name,
 			Port:       containerPort.ContainerPort,
 			TargetPort: intstr.FromInt(int(containerPort.ContainerPort)),
 			Protocol:   containerPort.Protocol,
@@ -354,91 +445,36 @@ func desiredDevelopmentDeploymentFromConfig(env *catalystv1alpha1.Environment, p
 	// Build environment variables from config
 	envVars := config.Env
 
-	// Check if SEED_SELF_DEPLOY should be injected (from operator env)
-	if seedSelfDeploy := os.Getenv("SEED_SELF_DEPLOY"); seedSelfDeploy == "true" {
+	// Inject SEED_SELF_DEPLOY when the operator configuration asks for it
+	if operatorconfig.Current().SeedSelfDeploy {
 		envVars = append(envVars, corev1.EnvVar{Name: "SEED_SELF_DEPLOY", Value: "true"})
 	}
 
 	// Determine repository URL and commit to clone
-	var repoURL, commit string
-	githubInstallationId := project.Spec.GitHubInstallationId
+	var repoURL string
+	var source *catalystv1alpha1.SourceConfig
 
 	// Get from project sources
 	if len(project.Spec.Sources) > 0 {
-		repoURL = project.Spec.Sources[0].RepositoryURL
-	}
-
-	// Override with environment-specific commit if provided
-	if len(env.Spec.Sources) > 0 {
-		s := env.Spec.Sources[0]
-		if s.CommitSha != "" && s.CommitSha != "HEAD" {
-			commit = s.CommitSha
-		} else if s.Branch != "" {
-			commit = s.Branch
-		}
-	}
-
-	// Default to main branch if no commit specified
-	if commit == "" {
-		if len(project.Spec.Sources) > 0 {
-			commit = project.Spec.Sources[0].Branch
-		}
-		if commit == "" {
-			commit = "main"
-		}
+		source = &project.Spec.Sources[0]
+		repoURL = source.RepositoryURL
 	}
+	commit := cloneRevision(env, project)
 
 	// Build init containers from config
 	initContainers := []corev1.Container{}
 
-	// Add git-clone init container if we have a repo URL (prepend before user init containers)
-	if repoURL != "" {
-		// Find the code volume mount path (first volume usually)
-		codeVolumeName := "code"
-		codeMountPath := "/code"
-		if len(config.VolumeMounts) > 0 {
-			codeVolumeName = config.VolumeMounts[0].Name
-			codeMountPath = config.VolumeMounts[0].MountPath
-		}
-
-		// Git clone image - configurable via environment variable
-		// Pinned by SHA256 digest for reproducibility (alpine/git:2.45.2)
-		gitCloneImage := os.Getenv("GIT_CLONE_IMAGE")
-		if gitCloneImage == "" {
-			gitCloneImage = "alpine/git@sha256:16ad8e788e1d3b0c30f18da8dde5c0ace3b187445a62d8af893b003ca1e70592"
-		}
+	codeVolumeName, codeMountPath := codeVolume(config)
 
-		gitCloneContainer := corev1.Container{
-			Name:    "git-clone",
-			Image:   gitCloneImage,
-			Command: []string{"/scripts/git-clone.sh"},
-			Env: []corev1.EnvVar{
-				{Name: "INSTALLATION_ID", Value: githubInstallationId},
-				{Name: "ENABLE_PAT_FALLBACK", Value: os.Getenv("ENABLE_PAT_FALLBACK")},
-				{Name: "CATALYST_WEB_URL", Value: getCatalystWebURL()},
-				{Name: "GIT_REPO_URL", Value: repoURL},
-				{Name: "GIT_COMMIT", Value: commit},
-				{Name: "GIT_CLONE_ROOT", Value: codeMountPath},
-				{Name: "GIT_CLONE_DEST", Value: "."},
-			},
-			VolumeMounts: []corev1.VolumeMount{
-				{Name: codeVolumeName, MountPath: codeMountPath},
-				{Name: gitScriptsVolumeName, MountPath: "/scripts", ReadOnly: true},
-			},
-			Resources: corev1.ResourceRequirements{
-				Requests: corev1.ResourceList{
-					corev1.ResourceCPU:    resource.MustParse("100m"),
-					corev1.ResourceMemory: resource.MustParse("256Mi"),
-				},
-				Limits: corev1.ResourceList{
-					corev1.ResourceCPU:    resource.MustParse("500m"),
-					corev1.ResourceMemory: resource.MustParse("512Mi"),
-				},
-			},
-		}
-		initContainers = append(initContainers, gitCloneContainer)
+	// Add git-clone init container if we have a repo URL (prepend before user init containers).
+	// With file sync the code is pushed instead, unless it clones first.
+	if repoURL != "" && (config.FileSync == nil || config.FileSync.Clone) {
+		initContainers = append(initContainers, gitCloneInitContainer(project, source, commit, codeVolumeName, codeMountPath))
 	}
 
+	// Wait for the managed services, ahead of user init containers such as migrations
+	initContainers = append(initContainers, waitForInitContainers(managedServiceWaitTargets(namespace, config.Services))...)
+
 	// Add user-defined init containers from config
 	for _, initSpec := range config.InitContainers {
 		initContainer := corev1.Container{
@@ -467,16 +503,7 @@ func desiredDevelopmentDeploymentFromConfig(env *catalystv1alpha1.Environment, p
 		Env:          envVars,
 		VolumeMounts: config.VolumeMounts,
 		// Inject secrets from catalyst-secrets Secret
-		EnvFrom: []corev1.EnvFromSource{
-			{
-				SecretRef: &corev1.SecretEnvSource{
-					LocalObjectReference: corev1.LocalObjectReference{
-						Name: "catalyst-secrets",
-					},
-					Optional: boolPtr(true), // Don't fail if secret doesn't exist
-				},
-			},
-		},
+		EnvFrom: []corev1.EnvFromSource{catalystSecretsEnvFrom()},
 	}
 
 	if config.Resources != nil {
@@ -497,17 +524,7 @@ func desiredDevelopmentDeploymentFromConfig(env *catalystv1alpha1.Environment, p
 
 	// Add git scripts volume if we have a repo
 	if repoURL != "" {
-		volumes = append(volumes, corev1.Volume{
-			Name: gitScriptsVolumeName,
-			VolumeSource: corev1.VolumeSource{
-				ConfigMap: &corev1.ConfigMapVolumeSource{
-					LocalObjectReference: corev1.LocalObjectReference{
-						Name: gitScriptsConfigMapName,
-					},
-					DefaultMode: int32Ptr(0755),
-				},
-			},
-		})
+		volumes = append(volumes, gitScriptsVolume())
 	}
 
 	// Add PVC volumes from config
@@ -524,6 +541,19 @@ func desiredDevelopmentDeploymentFromConfig(env *catalystv1alpha1.Environment, p
 		}
 	}
 
+	podSpec := corev1.PodSpec{
+		InitContainers: initContainers,
+		Containers:     append([]corev1.Container{mainContainer}, sidecarContainers(config, envVars)...),
+		Volumes:        volumes,
+	}
+	if project.Spec.DependencyCache != nil {
+		applyDependencyCache(&podSpec, project.Spec.DependencyCache)
+	}
+	if config.FileSync != nil {
+		applyFileSync(&podSpec, config.FileSync, codeVolumeName, codeMountPath)
+	}
+	applyPodSecurity(&podSpec)
+
 	return &appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      "web",
@@ -541,11 +571,85 @@ func desiredDevelopmentDeploymentFromConfig(env *catalystv1alpha1.Environment, p
 				ObjectMeta: metav1.ObjectMeta{
 					Labels: map[string]string{"app": "web"},
 				},
-				Spec: corev1.PodSpec{
-					InitContainers: initContainers,
-					Containers:     []corev1.Container{mainContainer},
-					Volumes:        volumes,
+				Spec: podSpec,
+			},
+		},
+	}
+}
+
+// codeVolume returns the volume and mount path the code is cloned into: the first volume
+// mount of the config, /code otherwise
+func codeVolume(config *catalystv1alpha1.EnvironmentConfig) (string, string) {
+	if len(config.VolumeMounts) > 0 {
+		return config.VolumeMounts[0].Name, config.VolumeMounts[0].MountPath
+	}
+	return "code", "/code"
+}
+
+// cloneRevision returns the commit or branch pods clone of the first source: the environment's
+// commit, then its branch, then the project's branch, defaulting to main
+func cloneRevision(env *catalystv1alpha1.Environment, project *catalystv1alpha1.Project) string {
+	if len(env.Spec.Sources) > 0 {
+		s := env.Spec.Sources[0]
+		if s.CommitSha != "" && s.CommitSha != "HEAD" {
+			return s.CommitSha
+		} else if s.Branch != "" {
+			return s.Branch
+		}
+	}
+	if len(project.Spec.Sources) > 0 && project.Spec.Sources[0].Branch != "" {
+		return project.Spec.Sources[0].Branch
+	}
+	return "main"
+}
+
+// gitCloneInitContainer clones a source at commit into the volume mounted at mountPath,
+// with the scripts of gitScriptsVolume
+func gitCloneInitContainer(project *catalystv1alpha1.Project, source *catalystv1alpha1.SourceConfig, commit, volumeName, mountPath string) corev1.Container {
+	// Git clone image - configurable with images.gitClone
+	// Pinned by SHA256 digest for reproducibility (alpine/git:2.45.2)
+	gitCloneImage := operatorconfig.Current().Images.GitClone
+	if gitCloneImage == "" {
+		gitCloneImage = "alpine/git@sha256:16ad8e788e1d3b0c30f18da8dde5c0ace3b187445a62d8af893b003ca1e70592"
+	}
+
+	return corev1.Container{
+		Name:    "git-clone",
+		Image:   gitCloneImage,
+		Command: []string{"/scripts/git-clone.sh"},
+		Env: append(append(gitCloneCredentialEnv(project, source), gitCheckoutEnv(source)...),
+			corev1.EnvVar{Name: "GIT_REPO_URL", Value: source.RepositoryURL},
+			corev1.EnvVar{Name: "GIT_COMMIT", Value: commit},
+			corev1.EnvVar{Name: "GIT_CLONE_ROOT", Value: mountPath},
+			corev1.EnvVar{Name: "GIT_CLONE_DEST", Value: "."},
+		),
+		VolumeMounts: []corev1.VolumeMount{
+			{Name: volumeName, MountPath: mountPath},
+			{Name: gitScriptsVolumeName, MountPath: "/scripts", ReadOnly: true},
+		},
+		Resources: corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("100m"),
+				corev1.ResourceMemory: resource.MustParse("256Mi"),
+			},
+			Limits: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("500m"),
+				corev1.ResourceMemory: resource.MustParse("512Mi"),
+			},
+		},
+	}
+}
+
+// gitScriptsVolume mounts the git scripts ConfigMap into git-clone init containers
+func gitScriptsVolume() corev1.Volume {
+	return corev1.Volume{
+		Name: gitScriptsVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{
+					Name: gitScriptsConfigMapName,
 				},
+				DefaultMode: int32Ptr(0755),
 			},
 		},
 	}
@@ -0,0 +1,141 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+// Disruption tolerance (production mode):
+// A web Deployment with more than one replica gets soft pod anti-affinity, spreading its pods
+// across nodes and then zones, and a PodDisruptionBudget letting a node drain evict one pod at
+// a time. Provider-backed cloudnative-pg clusters with more than one instance are spread the
+// same way (see cnpgSpreadAffinity); cloudnative-pg and the Zalando operator create their own
+// PodDisruptionBudgets, and Zalando clusters spread as its operator configuration says.
+// Single-replica workloads get neither: their budget could only block the drain.
+
+const (
+	// zoneTopologyKey is the well-known node label of the zone a node runs in
+	zoneTopologyKey = "topology.kubernetes.io/zone"
+)
+
+// spreadAffinity returns soft pod anti-affinity for the pods of selector, preferring other
+// nodes over other zones
+func spreadAffinity(selector map[string]string) *corev1.Affinity {
+	term := func(weight int32, topologyKey string) corev1.WeightedPodAffinityTerm {
+		return corev1.WeightedPodAffinityTerm{
+			Weight: weight,
+			PodAffinityTerm: corev1.PodAffinityTerm{
+				LabelSelector: &metav1.LabelSelector{MatchLabels: selector},
+				TopologyKey:   topologyKey,
+			},
+		}
+	}
+	return &corev1.Affinity{
+		PodAntiAffinity: &corev1.PodAntiAffinity{
+			PreferredDuringSchedulingIgnoredDuringExecution: []corev1.WeightedPodAffinityTerm{
+				term(100, corev1.LabelHostname),
+				term(50, zoneTopologyKey),
+			},
+		},
+	}
+}
+
+// cnpgSpreadAffinity returns the affinity of a cloudnative-pg cluster: soft anti-affinity
+// across nodes, from the cluster's own setting, and across zones
+func cnpgSpreadAffinity(cluster string) map[string]interface{} {
+	return map[string]interface{}{
+		"enablePodAntiAffinity": true,
+		"podAntiAffinityType":   "preferred",
+		"topologyKey":           corev1.LabelHostname,
+		"additionalPodAntiAffinity": map[string]interface{}{
+			"preferredDuringSchedulingIgnoredDuringExecution": []interface{}{
+				map[string]interface{}{
+					"weight": int64(50),
+					"podAffinityTerm": map[string]interface{}{
+						"labelSelector": map[string]interface{}{"matchLabels": map[string]interface{}{"cnpg.io/cluster": cluster}},
+						"topologyKey":   zoneTopologyKey,
+					},
+				},
+			},
+		},
+	}
+}
+
+// desiredPodDisruptionBudget creates the PodDisruptionBudget of the pods of selector, allowing
+// one of them to be evicted at a time
+func desiredPodDisruptionBudget(namespace, name string, selector map[string]string) *policyv1.PodDisruptionBudget {
+	maxUnavailable := intstr.FromInt32(1)
+	return &policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    map[string]string{"app.kubernetes.io/managed-by": "catalyst-operator"},
+		},
+		Spec: policyv1.PodDisruptionBudgetSpec{
+			MaxUnavailable: &maxUnavailable,
+			Selector:       &metav1.LabelSelector{MatchLabels: selector},
+		},
+	}
+}
+
+// +kubebuilder:rbac:groups=policy,resources=poddisruptionbudgets,verbs=get;list;watch;create;update;patch;delete
+
+// reconcilePodDisruptionBudget creates or updates the PodDisruptionBudget of the web Deployment
+// when it runs more than one replica, and deletes it otherwise
+func (r *EnvironmentReconciler) reconcilePodDisruptionBudget(ctx context.Context, env *catalystv1alpha1.Environment, namespace string, config *catalystv1alpha1.EnvironmentConfig) error {
+	log := logf.FromContext(ctx)
+
+	existing := &policyv1.PodDisruptionBudget{}
+	err := r.Get(ctx, client.ObjectKey{Name: "web", Namespace: namespace}, existing)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	found := err == nil
+
+	if desiredReplicas(config) < 2 {
+		if found {
+			log.Info("Deleting PodDisruptionBudget", "namespace", namespace)
+			return client.IgnoreNotFound(r.Delete(ctx, existing))
+		}
+		return nil
+	}
+
+	desired := desiredPodDisruptionBudget(namespace, "web", map[string]string{"app": "web"})
+	labelEnvironmentWorkload(env, desired)
+	if !found {
+		log.Info("Creating PodDisruptionBudget", "namespace", namespace)
+		return r.Create(ctx, desired)
+	}
+	if !equality.Semantic.DeepEqual(existing.Spec, desired.Spec) {
+		log.Info("Updating PodDisruptionBudget", "namespace", namespace)
+		existing.Spec = desired.Spec
+		return r.Update(ctx, existing)
+	}
+	return nil
+}
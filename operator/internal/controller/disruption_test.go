@@ -0,0 +1,72 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+func TestSpreadAffinity(t *testing.T) {
+	affinity := spreadAffinity(map[string]string{"app": "web"})
+	require.NotNil(t, affinity.PodAntiAffinity)
+	assert.Empty(t, affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution, "spreading never blocks scheduling")
+	terms := affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution
+	require.Len(t, terms, 2)
+	assert.Equal(t, corev1.LabelHostname, terms[0].PodAffinityTerm.TopologyKey)
+	assert.Equal(t, zoneTopologyKey, terms[1].PodAffinityTerm.TopologyKey)
+	assert.Greater(t, terms[0].Weight, terms[1].Weight)
+	assert.Equal(t, map[string]string{"app": "web"}, terms[1].PodAffinityTerm.LabelSelector.MatchLabels)
+}
+
+func TestReconcilePodDisruptionBudget(t *testing.T) {
+	c := newFakeClientBuilder().Build()
+	r := &EnvironmentReconciler{Client: c, Scheme: testScheme}
+	ctx := context.Background()
+	env := &catalystv1alpha1.Environment{ObjectMeta: metav1.ObjectMeta{Name: "staging"}}
+	key := client.ObjectKey{Name: "web", Namespace: "acme-staging"}
+
+	require.NoError(t, r.reconcilePodDisruptionBudget(ctx, env, "acme-staging", &catalystv1alpha1.EnvironmentConfig{}))
+	assert.True(t, apierrors.IsNotFound(c.Get(ctx, key, &policyv1.PodDisruptionBudget{})), "a single replica gets no budget")
+
+	config := &catalystv1alpha1.EnvironmentConfig{Replicas: ptr(int32(3))}
+	require.NoError(t, r.reconcilePodDisruptionBudget(ctx, env, "acme-staging", config))
+	pdb := &policyv1.PodDisruptionBudget{}
+	require.NoError(t, c.Get(ctx, key, pdb))
+	assert.Equal(t, int32(1), pdb.Spec.MaxUnavailable.IntVal)
+	assert.Equal(t, map[string]string{"app": "web"}, pdb.Spec.Selector.MatchLabels)
+	assert.Equal(t, "staging", pdb.Labels[environmentLabel])
+
+	// Autoscaled Deployments are covered from a floor of two replicas
+	config = &catalystv1alpha1.EnvironmentConfig{Autoscaling: &catalystv1alpha1.AutoscalingSpec{MinReplicas: ptr(int32(2)), MaxReplicas: 5}}
+	require.NoError(t, r.reconcilePodDisruptionBudget(ctx, env, "acme-staging", config))
+	require.NoError(t, c.Get(ctx, key, pdb))
+
+	config.Autoscaling.MinReplicas = nil
+	require.NoError(t, r.reconcilePodDisruptionBudget(ctx, env, "acme-staging", config))
+	assert.True(t, apierrors.IsNotFound(c.Get(ctx, key, &policyv1.PodDisruptionBudget{})), "scaled down to one replica")
+}
+
+func TestDesiredPostgresCluster_Spread(t *testing.T) {
+	svc := catalystv1alpha1.ManagedServiceSpec{Name: "db", Provider: postgresProviderCNPG}
+	cluster := desiredPostgresCluster("ns", svc)
+	_, found, _ := unstructured.NestedMap(cluster.Object, "spec", "affinity")
+	assert.False(t, found)
+
+	svc.Instances = ptr(int32(3))
+	cluster = desiredPostgresCluster("ns", svc)
+	key, _, _ := unstructured.NestedString(cluster.Object, "spec", "affinity", "topologyKey")
+	assert.Equal(t, corev1.LabelHostname, key)
+	terms, _, _ := unstructured.NestedSlice(cluster.Object, "spec", "affinity", "additionalPodAntiAffinity", "preferredDuringSchedulingIgnoredDuringExecution")
+	require.Len(t, terms, 1)
+	assert.Equal(t, zoneTopologyKey, terms[0].(map[string]interface{})["podAffinityTerm"].(map[string]interface{})["topologyKey"])
+}
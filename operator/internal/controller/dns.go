@@ -0,0 +1,213 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+	"github.com/ncrmro/catalyst/operator/internal/dns"
+	"github.com/ncrmro/catalyst/operator/internal/operatorconfig"
+)
+
+// DNSConfig publishes the DNS records of preview hosts (hostname-based routing only).
+// Configured via operator environment variables:
+//   - DNS_PROVIDER: external-dns (annotate preview Ingresses for external-dns) or cloudflare
+//     (create the records through the Cloudflare API). Unset leaves DNS alone.
+//   - DNS_TARGET: the address records point at, the ingress controller's load balancer IP
+//     or hostname. Required with cloudflare; external-dns defaults to the Ingress status address.
+//   - DNS_TTL: record TTL in seconds; unset uses the provider default
+//   - DNS_CLOUDFLARE_ZONE_ID and DNS_CLOUDFLARE_API_TOKEN: the zone of the preview domain
+//     and an API token allowed to edit its DNS records
+//
+// With a provider set, the DNSReady condition reports whether every preview host resolves.
+type DNSConfig struct {
+	Provider           string
+	Target             string
+	TTL                int
+	CloudflareZoneID   string
+	CloudflareAPIToken string
+}
+
+const (
+	dnsProviderExternalDNS = "external-dns"
+	dnsProviderCloudflare  = "cloudflare"
+
+	// conditionDNSReady reports whether the preview hosts resolve
+	conditionDNSReady = "DNSReady"
+	// dnsRetryInterval is how often resolution is checked until every preview host resolves
+	dnsRetryInterval = 10 * time.Second
+
+	externalDNSHostnameAnnotation = "external-dns.alpha.kubernetes.io/hostname"
+	externalDNSTargetAnnotation   = "external-dns.alpha.kubernetes.io/target"
+	externalDNSTTLAnnotation      = "external-dns.alpha.kubernetes.io/ttl"
+)
+
+// dnsAnnotations are the Ingress annotations managed for external-dns
+var dnsAnnotations = []string{
+	externalDNSHostnameAnnotation,
+	externalDNSTargetAnnotation,
+	externalDNSTTLAnnotation,
+}
+
+// currentDNSConfig returns the DNS settings in effect. The Cloudflare API token is a credential
+// and stays the DNS_CLOUDFLARE_API_TOKEN environment variable.
+func currentDNSConfig() DNSConfig {
+	dns := operatorconfig.Current().DNS
+	return DNSConfig{
+		Provider:           dns.Provider,
+		Target:             dns.Target,
+		TTL:                dns.TTL,
+		CloudflareZoneID:   dns.CloudflareZoneID,
+		CloudflareAPIToken: os.Getenv("DNS_CLOUDFLARE_API_TOKEN"),
+	}
+}
+
+// applyDNS sets the external-dns annotations publishing the hosts of a preview Ingress
+func applyDNS(ingress *networkingv1.Ingress, cfg DNSConfig) {
+	if cfg.Provider != dnsProviderExternalDNS {
+		return
+	}
+	var hosts []string
+	for _, rule := range ingress.Spec.Rules {
+		if rule.Host != "" && !strings.HasSuffix(rule.Host, ".localhost") {
+			hosts = append(hosts, rule.Host)
+		}
+	}
+	if len(hosts) == 0 {
+		return
+	}
+	if ingress.Annotations == nil {
+		ingress.Annotations = map[string]string{}
+	}
+	ingress.Annotations[externalDNSHostnameAnnotation] = strings.Join(hosts, ",")
+	if cfg.Target != "" {
+		ingress.Annotations[externalDNSTargetAnnotation] = cfg.Target
+	}
+	if cfg.TTL > 0 {
+		ingress.Annotations[externalDNSTTLAnnotation] = strconv.Itoa(cfg.TTL)
+	}
+}
+
+// dnsProvider returns the provider creating records with DNS_PROVIDER=cloudflare
+func (r *EnvironmentReconciler) dnsProvider(cfg DNSConfig) (dns.Provider, error) {
+	if r.DNSProvider != nil {
+		return r.DNSProvider, nil
+	}
+	if cfg.CloudflareZoneID == "" || cfg.CloudflareAPIToken == "" {
+		return nil, fmt.Errorf("DNS_CLOUDFLARE_ZONE_ID and DNS_CLOUDFLARE_API_TOKEN are required with DNS_PROVIDER=cloudflare")
+	}
+	return dns.NewCloudflareProvider(cfg.CloudflareZoneID, cfg.CloudflareAPIToken), nil
+}
+
+// lookupHost resolves a host with r.LookupHost, or the system resolver
+func (r *EnvironmentReconciler) lookupHost(ctx context.Context, host string) ([]string, error) {
+	if r.LookupHost != nil {
+		return r.LookupHost(ctx, host)
+	}
+	return net.DefaultResolver.LookupHost(ctx, host)
+}
+
+// reconcileDNS creates the records of the preview hosts with a DNS API provider, then checks
+// that every host resolves and records the DNSReady condition. It returns whether a host
+// does not resolve yet, so the caller can check again.
+func (r *EnvironmentReconciler) reconcileDNS(ctx context.Context, env *catalystv1alpha1.Environment, hosts []string, isLocal bool) (bool, error) {
+	cfg := currentDNSConfig()
+	if isLocal || cfg.Provider == "" {
+		if meta.RemoveStatusCondition(&env.Status.Conditions, conditionDNSReady) {
+			return false, r.Status().Update(ctx, env)
+		}
+		return false, nil
+	}
+
+	if cfg.Provider == dnsProviderCloudflare {
+		if cfg.Target == "" {
+			return false, fmt.Errorf("DNS_TARGET is required with DNS_PROVIDER=cloudflare")
+		}
+		provider, err := r.dnsProvider(cfg)
+		if err != nil {
+			return false, err
+		}
+		for _, host := range hosts {
+			if err := provider.Ensure(ctx, dns.Record{Host: host, Target: cfg.Target, TTL: cfg.TTL}); err != nil {
+				return false, err
+			}
+		}
+	}
+
+	var unresolved []string
+	for _, host := range hosts {
+		lookupCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		addrs, err := r.lookupHost(lookupCtx, host)
+		cancel()
+		if err != nil || len(addrs) == 0 {
+			unresolved = append(unresolved, host)
+		}
+	}
+	condition := metav1.Condition{
+		Type:               conditionDNSReady,
+		Status:             metav1.ConditionTrue,
+		Reason:             "Resolved",
+		Message:            "Every preview host resolves",
+		ObservedGeneration: env.Generation,
+	}
+	if len(unresolved) > 0 {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "Propagating"
+		condition.Message = fmt.Sprintf("Waiting for %s to resolve", strings.Join(unresolved, ", "))
+	}
+	if meta.SetStatusCondition(&env.Status.Conditions, condition) {
+		if err := r.Status().Update(ctx, env); err != nil {
+			return false, err
+		}
+	}
+	return len(unresolved) > 0, nil
+}
+
+// deleteDNSRecords removes the records a DNS API provider created for the hosts in
+// status.urls. external-dns removes its records with the Ingresses.
+func (r *EnvironmentReconciler) deleteDNSRecords(ctx context.Context, env *catalystv1alpha1.Environment) error {
+	cfg := currentDNSConfig()
+	if cfg.Provider != dnsProviderCloudflare {
+		return nil
+	}
+	provider, err := r.dnsProvider(cfg)
+	if err != nil {
+		return err
+	}
+	for _, u := range env.Status.URLs {
+		parsed, err := url.Parse(u)
+		if err != nil || parsed.Hostname() == "" || strings.HasSuffix(parsed.Hostname(), ".localhost") {
+			continue
+		}
+		if err := provider.Delete(ctx, parsed.Hostname()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+	"github.com/ncrmro/catalyst/operator/internal/dns"
+)
+
+// fakeDNSProvider records the hosts it holds records for
+type fakeDNSProvider struct {
+	records map[string]dns.Record
+}
+
+func (f *fakeDNSProvider) Ensure(_ context.Context, record dns.Record) error {
+	f.records[record.Host] = record
+	return nil
+}
+
+func (f *fakeDNSProvider) Delete(_ context.Context, host string) error {
+	delete(f.records, host)
+	return nil
+}
+
+func TestApplyDNS(t *testing.T) {
+	env := &catalystv1alpha1.Environment{ObjectMeta: metav1.ObjectMeta{Name: "pr-1"}}
+	ingress := desiredIngress(env, "env-ns", false, "preview.example.com")
+
+	applyDNS(ingress, DNSConfig{})
+	assert.Empty(t, ingress.Annotations)
+
+	applyDNS(ingress, DNSConfig{Provider: dnsProviderExternalDNS, Target: "lb.example.com", TTL: 60})
+	assert.Equal(t, map[string]string{
+		externalDNSHostnameAnnotation: "pr-1.preview.example.com",
+		externalDNSTargetAnnotation:   "lb.example.com",
+		externalDNSTTLAnnotation:      "60",
+	}, ingress.Annotations)
+
+	// Local hosts resolve without records
+	local := desiredIngress(env, "env-ns", true)
+	applyDNS(local, DNSConfig{Provider: dnsProviderExternalDNS})
+	assert.Empty(t, local.Annotations)
+}
+
+func TestReconcileDNS(t *testing.T) {
+	t.Setenv("DNS_PROVIDER", dnsProviderCloudflare)
+	t.Setenv("DNS_TARGET", "203.0.113.10")
+
+	env := &catalystv1alpha1.Environment{ObjectMeta: metav1.ObjectMeta{Name: "pr-1", Namespace: "team"}}
+	c := newFakeClientBuilder().WithStatusSubresource(env).WithObjects(env).Build()
+
+	provider := &fakeDNSProvider{records: map[string]dns.Record{}}
+	resolved := map[string]bool{}
+	r := &EnvironmentReconciler{
+		Client:      c,
+		Scheme:      testScheme,
+		DNSProvider: provider,
+		LookupHost: func(_ context.Context, host string) ([]string, error) {
+			if !resolved[host] {
+				return nil, errors.New("no such host")
+			}
+			return []string{"203.0.113.10"}, nil
+		},
+	}
+	ctx := context.Background()
+	hosts := []string{"pr-1.preview.example.com", "login.preview.example.com"}
+
+	pending, err := r.reconcileDNS(ctx, env, hosts, false)
+	require.NoError(t, err)
+	assert.True(t, pending)
+	assert.Equal(t, dns.Record{Host: hosts[0], Target: "203.0.113.10"}, provider.records[hosts[0]])
+	assert.Len(t, provider.records, 2)
+	condition := meta.FindStatusCondition(env.Status.Conditions, conditionDNSReady)
+	require.NotNil(t, condition)
+	assert.Equal(t, "Propagating", condition.Reason)
+	assert.Contains(t, condition.Message, hosts[1])
+
+	resolved[hosts[0]], resolved[hosts[1]] = true, true
+	pending, err = r.reconcileDNS(ctx, env, hosts, false)
+	require.NoError(t, err)
+	assert.False(t, pending)
+	assert.True(t, meta.IsStatusConditionTrue(env.Status.Conditions, conditionDNSReady))
+
+	// Teardown removes the records of the status URLs
+	env.Status.URLs = []string{"https://pr-1.preview.example.com/", "https://login.preview.example.com/"}
+	require.NoError(t, r.deleteDNSRecords(ctx, env))
+	assert.Empty(t, provider.records)
+
+	// Local routing needs no records
+	pending, err = r.reconcileDNS(ctx, env, []string{"env-ns.localhost"}, true)
+	require.NoError(t, err)
+	assert.False(t, pending)
+	assert.Nil(t, meta.FindStatusCondition(env.Status.Conditions, conditionDNSReady))
+}
@@ -0,0 +1,38 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// drainingReconciler lets in-flight reconciles finish when the manager shuts down.
+// controller-runtime cancels the reconcile context on shutdown, which would abort e.g. a Helm
+// install halfway; the wrapped reconciler runs on a context that ignores the cancellation.
+// The queue stops handing out work, and the manager waits for running reconciles up to
+// --graceful-shutdown-timeout before releasing the leader lease.
+type drainingReconciler struct {
+	reconcile.Reconciler
+}
+
+// Reconcile runs the wrapped reconciler detached from manager shutdown
+func (d drainingReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	return d.Reconciler.Reconcile(context.WithoutCancel(ctx), req)
+}
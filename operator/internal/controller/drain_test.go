@@ -0,0 +1,25 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestDrainingReconciler(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var reconcileErr error
+	d := drainingReconciler{reconcile.Func(func(ctx context.Context, _ ctrl.Request) (ctrl.Result, error) {
+		// Shutdown begins mid-reconcile
+		cancel()
+		reconcileErr = ctx.Err()
+		return ctrl.Result{}, nil
+	})}
+
+	_, err := d.Reconcile(ctx, ctrl.Request{})
+	assert.NoError(t, err)
+	assert.NoError(t, reconcileErr, "the in-flight reconcile keeps running")
+}
@@ -0,0 +1,181 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"sort"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+// Drift detection:
+// Resources the operator applies itself (the web Ingress, production and docker-compose
+// workloads) are hashed when applied and the hash of each desired spec is kept in
+// status.appliedHashes. When a later reconcile of a Ready environment renders the same
+// desired spec but the live resource no longer matches it, or is gone, the environment
+// reports status.drifted with a Warning Event before the apply repairs it. Only the fields
+// the operator sets are hashed, so server-side defaults are not mistaken for drift.
+
+// driftState is the drift bookkeeping of one reconcile
+type driftState struct {
+	wasDrifted bool
+	applied    map[string]string
+}
+
+// beginDriftCheck resets status.drifted for this reconcile; endDriftCheck reports whether
+// the drift status changed and needs to be written
+func beginDriftCheck(env *catalystv1alpha1.Environment) *driftState {
+	state := &driftState{wasDrifted: env.Status.Drifted, applied: maps.Clone(env.Status.AppliedHashes)}
+	env.Status.Drifted = false
+	return state
+}
+
+func (s *driftState) endDriftCheck(env *catalystv1alpha1.Environment) bool {
+	return s.wasDrifted != env.Status.Drifted || !maps.Equal(s.applied, env.Status.AppliedHashes)
+}
+
+// checkDrift compares the live resource (nil when missing) with the desired one it is
+// about to be replaced with and records the desired hash. Drift is written to status
+// right away, so it is reported even when the repair fails.
+func (r *EnvironmentReconciler) checkDrift(ctx context.Context, env *catalystv1alpha1.Environment, desired, live client.Object) error {
+	key, hash, ok := driftHash(desired)
+	if !ok {
+		return nil
+	}
+	recorded, tracked := env.Status.AppliedHashes[key]
+	if env.Status.AppliedHashes == nil {
+		env.Status.AppliedHashes = map[string]string{}
+	}
+	env.Status.AppliedHashes[key] = hash
+
+	// A changed desired spec is an update, not drift
+	if !tracked || recorded != hash || env.Status.Phase != "Ready" {
+		return nil
+	}
+	reason := ""
+	if live == nil {
+		reason = "was deleted"
+	} else if driftSpecHash(live) != hash {
+		reason = "was modified"
+	}
+	if reason == "" {
+		return nil
+	}
+
+	message := fmt.Sprintf("%s %s outside of the operator, repairing", key, reason)
+	logf.FromContext(ctx).Info("Drift detected", "resource", key, "change", reason)
+	recordEvent(r.Recorder, env, corev1.EventTypeWarning, eventDriftDetected, "%s", message)
+	kind, _, _ := strings.Cut(key, "/")
+	driftDetectedTotal.WithLabelValues(kind).Inc()
+	if env.Status.Drifted {
+		return nil
+	}
+	env.Status.Drifted = true
+	return r.Status().Update(ctx, env)
+}
+
+// driftProjection holds the fields of a resource the operator manages
+type driftProjection struct {
+	Replicas   *int32                     `json:"replicas,omitempty"`
+	Containers []string                   `json:"containers,omitempty"`
+	Ports      []string                   `json:"ports,omitempty"`
+	Selector   map[string]string          `json:"selector,omitempty"`
+	Rules      []networkingv1.IngressRule `json:"rules,omitempty"`
+	TLS        []networkingv1.IngressTLS  `json:"tls,omitempty"`
+}
+
+// driftHash returns the "Kind/name" key and the hash of the managed fields of obj.
+// ok is false for kinds drift is not tracked for.
+func driftHash(obj client.Object) (key, hash string, ok bool) {
+	var p driftProjection
+	var kind string
+	switch o := obj.(type) {
+	case *appsv1.Deployment:
+		kind = "Deployment"
+		p.Replicas = replicasOrDefault(o.Spec.Replicas)
+		p.Containers = containerImages(o.Spec.Template.Spec.Containers)
+	case *appsv1.StatefulSet:
+		kind = "StatefulSet"
+		p.Replicas = replicasOrDefault(o.Spec.Replicas)
+		p.Containers = containerImages(o.Spec.Template.Spec.Containers)
+	case *corev1.Service:
+		kind = "Service"
+		for _, port := range o.Spec.Ports {
+			protocol := port.Protocol
+			if protocol == "" {
+				protocol = corev1.ProtocolTCP
+			}
+			targetPort := port.TargetPort.String()
+			if port.TargetPort.IntVal == 0 && port.TargetPort.StrVal == "" {
+				// Defaulted to the port by the API server
+				targetPort = fmt.Sprint(port.Port)
+			}
+			p.Ports = append(p.Ports, fmt.Sprintf("%s/%d/%s/%s", port.Name, port.Port, targetPort, protocol))
+		}
+		sort.Strings(p.Ports)
+		p.Selector = o.Spec.Selector
+	case *networkingv1.Ingress:
+		kind = "Ingress"
+		p.Rules = o.Spec.Rules
+		p.TLS = o.Spec.TLS
+	default:
+		return "", "", false
+	}
+	data, err := json.Marshal(p)
+	if err != nil {
+		return "", "", false
+	}
+	sum := sha256.Sum256(data)
+	return kind + "/" + obj.GetName(), hex.EncodeToString(sum[:8]), true
+}
+
+// driftSpecHash is the hash of the managed fields of obj
+func driftSpecHash(obj client.Object) string {
+	_, hash, _ := driftHash(obj)
+	return hash
+}
+
+// replicasOrDefault applies the API server default of one replica
+func replicasOrDefault(replicas *int32) *int32 {
+	if replicas == nil {
+		return ptr(int32(1))
+	}
+	return replicas
+}
+
+// containerImages lists "name=image" of containers, sorted
+func containerImages(containers []corev1.Container) []string {
+	images := make([]string, 0, len(containers))
+	for _, c := range containers {
+		images = append(images, c.Name+"="+c.Image)
+	}
+	sort.Strings(images)
+	return images
+}
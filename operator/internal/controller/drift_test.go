@@ -0,0 +1,105 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+func TestDriftHash_IgnoresServerDefaults(t *testing.T) {
+	desired := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "web"},
+		Spec: corev1.ServiceSpec{
+			Selector: map[string]string{"app": "web"},
+			Ports:    []corev1.ServicePort{{Port: 80}},
+		},
+	}
+	live := desired.DeepCopy()
+	live.Spec.ClusterIP = "10.0.0.12"
+	live.Spec.Type = corev1.ServiceTypeClusterIP
+	live.Spec.Ports[0].Protocol = corev1.ProtocolTCP
+	live.Spec.Ports[0].TargetPort = intstr.FromInt(80)
+	assert.Equal(t, driftSpecHash(desired), driftSpecHash(live))
+
+	live.Spec.Ports[0].TargetPort = intstr.FromInt(8080)
+	assert.NotEqual(t, driftSpecHash(desired), driftSpecHash(live))
+
+	deployment := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "web"}}
+	defaulted := deployment.DeepCopy()
+	defaulted.Spec.Replicas = ptr(int32(1))
+	assert.Equal(t, driftSpecHash(deployment), driftSpecHash(defaulted))
+
+	key, _, ok := driftHash(deployment)
+	assert.True(t, ok)
+	assert.Equal(t, "Deployment/web", key)
+	_, _, ok = driftHash(&corev1.ConfigMap{})
+	assert.False(t, ok, "drift is only tracked for workloads, Services and Ingresses")
+}
+
+func TestCheckDrift(t *testing.T) {
+	env := &catalystv1alpha1.Environment{
+		ObjectMeta: metav1.ObjectMeta{Name: "pr-1", Namespace: "team"},
+		Status:     catalystv1alpha1.EnvironmentStatus{Phase: "Ready"},
+	}
+	c := newFakeClientBuilder().WithStatusSubresource(env).WithObjects(env).Build()
+	recorder := record.NewFakeRecorder(10)
+	r := &EnvironmentReconciler{Client: c, Scheme: testScheme, Recorder: recorder}
+	ctx := context.Background()
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(env), env))
+
+	desired := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "team-shop-pr-1"},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: ptr(int32(2)),
+			Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "web", Image: "app:v1"}}}},
+		},
+	}
+
+	// First apply: nothing to compare against yet
+	drift := beginDriftCheck(env)
+	require.NoError(t, r.checkDrift(ctx, env, desired, nil))
+	assert.False(t, env.Status.Drifted)
+	assert.True(t, drift.endDriftCheck(env), "the applied hash is recorded")
+	assert.Contains(t, env.Status.AppliedHashes, "Deployment/web")
+
+	// Unchanged desired spec and live resource
+	drift = beginDriftCheck(env)
+	require.NoError(t, r.checkDrift(ctx, env, desired, desired.DeepCopy()))
+	assert.False(t, drift.endDriftCheck(env))
+
+	// Scaled by hand
+	scaled := desired.DeepCopy()
+	scaled.Spec.Replicas = ptr(int32(0))
+	beginDriftCheck(env)
+	require.NoError(t, r.checkDrift(ctx, env, desired, scaled))
+	assert.True(t, env.Status.Drifted)
+	assert.Equal(t, "Warning DriftDetected Deployment/web was modified outside of the operator, repairing", <-recorder.Events)
+	got := &catalystv1alpha1.Environment{}
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(env), got))
+	assert.True(t, got.Status.Drifted, "drift is reported before the repair")
+
+	// Deleted
+	drift = beginDriftCheck(env)
+	require.NoError(t, r.checkDrift(ctx, env, desired, nil))
+	assert.Contains(t, <-recorder.Events, "Deployment/web was deleted")
+	assert.False(t, drift.endDriftCheck(env), "still drifted")
+
+	// A new desired spec is an update, and a clean reconcile clears the drift
+	updated := desired.DeepCopy()
+	updated.Spec.Template.Spec.Containers[0].Image = "app:v2"
+	drift = beginDriftCheck(env)
+	require.NoError(t, r.checkDrift(ctx, env, updated, desired))
+	assert.False(t, env.Status.Drifted)
+	assert.True(t, drift.endDriftCheck(env))
+	assert.Empty(t, recorder.Events)
+}
@@ -20,33 +20,93 @@ package controller
 import (
 	"context"
 	"fmt"
+	"maps"
+	"net/http"
 	"os"
 	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+	"github.com/ncrmro/catalyst/operator/internal/capabilities"
+	"github.com/ncrmro/catalyst/operator/internal/dns"
+	"github.com/ncrmro/catalyst/operator/internal/notify"
+	"github.com/ncrmro/catalyst/operator/internal/operatorconfig"
+	"github.com/ncrmro/catalyst/operator/internal/registry"
+	"github.com/ncrmro/catalyst/operator/internal/secrets"
+	"github.com/ncrmro/catalyst/operator/internal/sharding"
+	"github.com/ncrmro/catalyst/operator/internal/tracing"
 )
 
 //nolint:goconst
 const environmentFinalizer = "catalyst.dev/finalizer"
 
+// phaseDeleting is the Environment phase while its resources are torn down
+const phaseDeleting = "Deleting"
+
 // EnvironmentReconciler reconciles a Environment object
 type EnvironmentReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
 	Config *rest.Config
+	// Capabilities are the optional cluster components detected at startup.
+	// Nil assumes a fully featured cluster.
+	Capabilities *capabilities.Capabilities
+	// Shard limits reconciliation to the Projects owned by this operator instance.
+	// Nil reconciles every Environment.
+	Shard *sharding.Shard
+	// SecretsFetcher fetches environment secrets from the web API.
+	// Nil fetches from the Catalyst web service URL.
+	SecretsFetcher *secrets.SecretsFetcher
+	// Notifier reports phase transitions to GitHub (Project spec.notifications).
+	// Nil posts through the Catalyst web service.
+	Notifier notify.Notifier
+	// MaxConcurrentBuilds caps the build Jobs running at once across all environments.
+	// Zero leaves builds unbounded.
+	MaxConcurrentBuilds int
+	// Recorder emits Normal Events for the transitions of an Environment and a Warning
+	// Event for each failure recorded in status. Nil records none.
+	Recorder record.EventRecorder
+	// ResyncInterval re-reconciles Ready environments to detect and repair drift.
+	// Zero only reconciles on changes.
+	ResyncInterval time.Duration
+	// DNSProvider creates preview host records with DNS_PROVIDER=cloudflare.
+	// Nil calls the Cloudflare API with the DNS_CLOUDFLARE_* settings.
+	DNSProvider dns.Provider
+	// LookupHost resolves preview hosts for the DNSReady condition.
+	// Nil uses the system resolver.
+	LookupHost func(ctx context.Context, host string) ([]string, error)
+	// ImageDeleter deletes stale images from the registry with REGISTRY_GC.
+	// Nil calls the registry API with the project's registry credentials.
+	ImageDeleter registry.Deleter
+	// HTTPClient probes environment URLs with URL_PROBE=true.
+	// Nil uses a client that does not follow redirects.
+	HTTPClient *http.Client
+	// HelmOperations runs Helm installs and upgrades in the background.
+	// Nil runs them inside Reconcile.
+	HelmOperations *HelmOperations
 }
 
 // sanitizeLabelValue sanitizes a string for use as a Kubernetes label value.
@@ -84,6 +144,7 @@ func sanitizeLabelValue(s string) string {
 // +kubebuilder:rbac:groups=apps,resources=statefulsets,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups="",resources=services,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=networking.k8s.io,resources=ingresses,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=httproutes,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=catalyst.catalyst.dev,resources=environments/finalizers,verbs=update
 // +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups="",resources=resourcequotas,verbs=get;list;watch;create;update;patch;delete
@@ -94,15 +155,22 @@ func sanitizeLabelValue(s string) string {
 // +kubebuilder:rbac:groups="",resources=serviceaccounts,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=authentication.k8s.io,resources=tokenreviews,verbs=create
+// +kubebuilder:rbac:groups=scheduling.k8s.io,resources=priorityclasses,verbs=create
 
 //nolint:gocyclo
-func (r *EnvironmentReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+func (r *EnvironmentReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, err error) {
 	log := logf.FromContext(ctx)
+	ctx, span := startReconcileSpan(ctx, req)
+	defer func() { tracing.End(span, err) }()
 
 	env := &catalystv1alpha1.Environment{}
 	if err := r.Get(ctx, req.NamespacedName, env); err != nil {
+		if apierrors.IsNotFound(err) {
+			environmentPhases.forget(req.NamespacedName)
+		}
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
+	span.SetAttributes(environmentAttributes(env)...)
 
 	// Extract namespace hierarchy from Environment CR labels (FR-ENV-020)
 	// Generate target namespace for workload deployment (FR-ENV-021)
@@ -137,18 +205,155 @@ func (r *EnvironmentReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		log.Error(err, "Failed to fetch Project", "projectName", env.Spec.ProjectRef.Name, "teamNamespace", teamNamespace)
 		return ctrl.Result{}, err
 	}
+	if owned, err := ownsProject(ctx, r, r.Shard, project); err != nil {
+		return ctrl.Result{}, err
+	} else if !owned {
+		// Reconciled (and exported in metrics) by the shard owning the Project
+		environmentPhases.forget(req.NamespacedName)
+		return ctrl.Result{}, nil
+	}
+	// Status updates trigger a new reconcile, so the phase gauge follows every transition
+	environmentPhases.observe(req.NamespacedName, env.Status.Phase)
+	if err := resolveTemplateCatalog(ctx, r, project); err != nil {
+		log.Error(err, "Failed to resolve catalog templates", "projectName", project.Name)
+		return ctrl.Result{}, err
+	}
+
+	// One-time copy of spec.cloneFrom; the update triggers a fresh reconcile
+	if env.DeletionTimestamp.IsZero() {
+		if updated, err := r.reconcileCloneSource(ctx, env, project, targetNamespace); err != nil || updated {
+			return ctrl.Result{}, err
+		}
+		// Rollback requests set spec.rollbackTo; the update triggers a fresh reconcile
+		if updated, err := r.consumeRollbackRequest(ctx, env); err != nil || updated {
+			return ctrl.Result{}, err
+		}
+		if updated, err := r.consumeDebugRequest(ctx, env, targetNamespace); err != nil || updated {
+			return ctrl.Result{}, err
+		}
+		// Promotion pins the template and images of another environment's revision (or of
+		// its own history for a rollback)
+		if ready, err := r.reconcilePromotion(ctx, env, project); err != nil {
+			return ctrl.Result{}, err
+		} else if !ready {
+			// Changes of the source are watched
+			requested := requestedPromotion(env)
+			log.Info("Waiting for the promoted revision", "promoteFrom", requested.Environment, "revision", requested.Revision)
+			return ctrl.Result{}, nil
+		}
+	}
 
 	// Resolve Template
-	var envTemplate *catalystv1alpha1.EnvironmentTemplate
+	var envTemplate *catalystv1alpha1.EnvironmentTemplateSpec
 	if t, ok := project.Spec.Templates[env.Spec.Type]; ok {
 		envTemplate = &t
 	} else {
 		log.Info("No template found for environment type, using defaults", "type", env.Spec.Type)
 	}
 
+	// Pin to a template revision according to the template's rollout strategy; promoted
+	// environments render from the template of the promoted revision
+	var rolloutDeferred bool
+	if promotion := activePromotion(env); promotion != nil {
+		envTemplate = promotion.Template.DeepCopy()
+		if env.DeletionTimestamp.IsZero() && (env.Status.TemplateHash != promotion.TemplateHash || env.Status.TemplateRevision != promotion.TemplateRevision) {
+			env.Status.TemplateHash = promotion.TemplateHash
+			env.Status.TemplateRevision = promotion.TemplateRevision
+			if err := r.Status().Update(ctx, env); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+	} else if envTemplate != nil {
+		selection, err := r.resolveTemplateRevision(ctx, env, project, envTemplate)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		envTemplate = selection.Template
+		rolloutDeferred = selection.Deferred
+		if env.DeletionTimestamp.IsZero() && (env.Status.TemplateHash != selection.Hash || env.Status.TemplateRevision != selection.Revision) {
+			env.Status.TemplateHash = selection.Hash
+			env.Status.TemplateRevision = selection.Revision
+			log.Info("Recording template revision", "revision", selection.Revision, "hash", selection.Hash)
+			if err := r.Status().Update(ctx, env); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+	}
+
 	// Finalizer logic
 	if !env.DeletionTimestamp.IsZero() {
 		if controllerutil.ContainsFinalizer(env, environmentFinalizer) {
+			if markTeardownStarted(env) {
+				recordEvent(r.Recorder, env, corev1.EventTypeNormal, eventTeardownStarted, "Tearing down namespace %s", targetNamespace)
+				if err := r.Status().Update(ctx, env); err != nil {
+					return ctrl.Result{}, err
+				}
+			}
+			// The pre-delete hook runs while the workloads it may need are still up
+			if done, err := r.runPreDeleteHook(ctx, env, targetNamespace); err != nil {
+				log.Error(err, "Failed to run pre-delete hook", "namespace", targetNamespace)
+				return ctrl.Result{}, err
+			} else if !done {
+				// The hook Job is watched; resync as a safety net
+				return ctrl.Result{RequeueAfter: workloadResyncInterval}, nil
+			}
+
+			// Uninstall the Helm release first: the chart may template cluster-scoped
+			// or cross-namespace resources that namespace deletion would leak
+			switch resolveDeploymentMode(env, envTemplate) {
+			case "helm":
+				if err := r.uninstallHelmRelease(ctx, env, targetNamespace); err != nil {
+					log.Error(err, "Failed to uninstall Helm release", "release", env.Name, "namespace", targetNamespace)
+					return ctrl.Result{}, err
+				}
+			case "gitops":
+				// The Argo CD Application lives outside the target namespace
+				if err := r.deleteGitOpsApplication(ctx, env, targetNamespace); err != nil {
+					log.Error(err, "Failed to delete GitOps application", "namespace", targetNamespace)
+					return ctrl.Result{}, err
+				}
+			}
+
+			// Databases on shared Postgres clusters live in the cluster's namespace
+			if err := r.releaseSharedPostgres(ctx, env, envTemplate, targetNamespace); err != nil {
+				log.Error(err, "Failed to release shared Postgres databases", "namespace", targetNamespace)
+				return ctrl.Result{}, err
+			}
+
+			// Clone snapshots live in the source namespace
+			if err := r.deleteCloneResources(ctx, env); err != nil {
+				log.Error(err, "Failed to delete clone snapshots", "sourceNamespace", env.Status.Clone.SourceNamespace)
+				return ctrl.Result{}, err
+			}
+
+			// A code volume still cloning from the prebuild leaves its grant in the project namespace
+			if project.Spec.Prebuild != nil && project.Status.Namespace != "" {
+				if err := r.deletePrebuildGrant(ctx, project.Status.Namespace+"/"+prebuildGrantName(targetNamespace)); err != nil {
+					log.Error(err, "Failed to delete prebuild ReferenceGrant", "namespace", project.Status.Namespace)
+					return ctrl.Result{}, err
+				}
+			}
+
+			// The dependency cache PV is cluster-scoped
+			if err := r.deleteDependencyCacheVolume(ctx, targetNamespace); err != nil {
+				log.Error(err, "Failed to delete dependency cache volume", "namespace", targetNamespace)
+				return ctrl.Result{}, err
+			}
+
+			// Records created through a DNS API outlive the namespace
+			if err := r.deleteDNSRecords(ctx, env); err != nil {
+				log.Error(err, "Failed to delete DNS records")
+				return ctrl.Result{}, err
+			}
+
+			// Pushed images outlive the namespace
+			r.deleteEnvironmentImages(ctx, env, project)
+
+			// So do the GitLab tokens minted for its clones, until they expire
+			r.revokeGitTokens(ctx, project, targetNamespace)
+
+			r.notifyTeardown(ctx, env, project)
+
 			// Delete external resources
 			log.Info("Deleting target namespace", "namespace", targetNamespace)
 			ns := &corev1.Namespace{
@@ -169,6 +374,14 @@ func (r *EnvironmentReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		return ctrl.Result{}, nil
 	}
 
+	// Renderings are served before anything is deployed; render-only environments stop there
+	if rendered, err := r.reconcileRender(ctx, env, project, envTemplate, targetNamespace); err != nil || rendered {
+		return ctrl.Result{}, err
+	}
+	if isRenderOnly(env) {
+		return ctrl.Result{}, nil
+	}
+
 	// Add Finalizer
 	if !controllerutil.ContainsFinalizer(env, environmentFinalizer) {
 		controllerutil.AddFinalizer(env, environmentFinalizer)
@@ -177,10 +390,23 @@ func (r *EnvironmentReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		}
 	}
 
+	// Wake requests clear spec.hibernate; the update triggers a fresh reconcile
+	if updated, err := r.consumeWakeRequest(ctx, env); err != nil || updated {
+		return ctrl.Result{}, err
+	}
+
 	// 1. Namespace Management
 	ns := &corev1.Namespace{}
-	err := r.Get(ctx, client.ObjectKey{Name: targetNamespace}, ns)
+	err = r.Get(ctx, client.ObjectKey{Name: targetNamespace}, ns)
 	if err != nil && apierrors.IsNotFound(err) {
+		// New environments reserve their quota in the team budget first
+		if admitted, retry, err := r.admitToBudget(ctx, env, hierarchy.Team, targetNamespace); err != nil {
+			return ctrl.Result{}, err
+		} else if !admitted {
+			log.Info("Environment exceeds the team budget", "team", hierarchy.Team, "retryAfter", retry)
+			return ctrl.Result{RequeueAfter: retry}, nil
+		}
+
 		log.Info("Creating Namespace", "namespace", targetNamespace)
 
 		// Use first source branch if available
@@ -198,6 +424,7 @@ func (r *EnvironmentReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 			"catalyst.dev/team":           sanitizeLabelValue(hierarchy.Team),
 			"catalyst.dev/project":        sanitizeLabelValue(hierarchy.Project),
 		}
+		maps.Copy(labels, podSecurityLabels(project))
 
 		ns = &corev1.Namespace{
 			ObjectMeta: metav1.ObjectMeta{
@@ -210,12 +437,16 @@ func (r *EnvironmentReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		if err := r.Create(ctx, ns); err != nil {
 			return ctrl.Result{}, err
 		}
+		recordEvent(r.Recorder, env, corev1.EventTypeNormal, eventNamespaceCreated, "Created namespace %s", targetNamespace)
 	} else if err != nil {
 		return ctrl.Result{}, err
+	} else if err := ensureHierarchyNamespace(ctx, r.Client, targetNamespace, podSecurityLabels(project)); err != nil {
+		// Follow changes of the project's pod security level
+		return ctrl.Result{}, err
 	}
 
 	// 2. Manage ResourceQuota & NetworkPolicy
-	ingressNamespace := os.Getenv("INGRESS_NAMESPACE")
+	ingressNamespace := operatorconfig.Current().IngressNamespace
 	if ingressNamespace == "" {
 		// Fallback to POD_NAMESPACE (where the operator and usually ingress-nginx are)
 		ingressNamespace = os.Getenv("POD_NAMESPACE")
@@ -229,13 +460,19 @@ func (r *EnvironmentReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		return ctrl.Result{}, err
 	}
 
+	// Replaced, so namespaces created before follow changes of the policy
 	policy := desiredNetworkPolicy(targetNamespace, ingressNamespace)
-	if err := r.Create(ctx, policy); err != nil && !apierrors.IsAlreadyExists(err) {
+	if err := createOrReplace(ctx, r.Client, policy); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// 2a. PriorityClass of spec.priority, referenced by every pod of the environment
+	if err := r.ensurePriorityClass(ctx, env); err != nil {
 		return ctrl.Result{}, err
 	}
 
 	// 2b. Manage Registry Credentials
-	if err := r.ensureRegistryCredentials(ctx, project.Namespace, targetNamespace); err != nil {
+	if err := r.ensureRegistryCredentials(ctx, project, targetNamespace); err != nil {
 		if apierrors.IsNotFound(err) {
 			log.Info("Waiting for resources (Secret/SA) for registry credentials")
 			return ctrl.Result{RequeueAfter: time.Second}, nil
@@ -243,56 +480,335 @@ func (r *EnvironmentReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		log.Error(err, "Failed to ensure registry credentials")
 		return ctrl.Result{}, err
 	}
+	// Build Jobs run in the environment namespace, signing Jobs in the project namespace
+	for _, namespace := range []string{targetNamespace, project.Namespace} {
+		if err := ensureRegistryCA(ctx, r.Client, namespace, currentRegistryConfig()); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	// Mint the git tokens of token-authenticated sources (GitLab, Bitbucket) as well
+	gitTokensMinted, err := r.ensureGitCredentials(ctx, project, targetNamespace)
+	if err != nil {
+		log.Error(err, "Failed to ensure git credentials")
+		return ctrl.Result{}, err
+	}
+
+	// 2c. Copy Secrets referenced by a cloned config
+	if err := r.reconcileCloneSecrets(ctx, env, targetNamespace); err != nil {
+		log.Error(err, "Failed to copy Secrets from clone source")
+		return ctrl.Result{}, err
+	}
+
+	// 2d. Sync environment secrets from the project's secrets backend into catalyst-secrets
+	secretsSynced, err := r.reconcileCatalystSecrets(ctx, env, project, hierarchy.Team, targetNamespace)
+	if err != nil {
+		log.Error(err, "Failed to sync secrets to Kubernetes", "namespace", targetNamespace)
+		return ctrl.Result{}, err
+	}
+
+	// Resources applied from here on are checked for drift
+	drift := beginDriftCheck(env)
 
 	// 3. Ingress Management
 	// Determine if we're in local mode (path-based routing) or production mode (hostname-based routing)
-	isLocal := os.Getenv("LOCAL_PREVIEW_ROUTING") == "true"
-	ingressPort := os.Getenv("INGRESS_PORT")
-	previewDomain := os.Getenv("PREVIEW_DOMAIN")
+	operatorConfig := operatorconfig.Current()
+	isLocal := operatorConfig.LocalPreviewRouting
+	ingressPort := strconv.Itoa(int(operatorConfig.IngressPort))
+	previewDomain := previewBaseDomain(project)
+
+	// Shared wildcard certificate for preview hosts, or certificates of the local CA
+	var tls *previewTLS
+	var previewHost string
+	if isLocal {
+		tls = currentLocalTLS().previewTLS()
+	} else {
+		if tls, err = r.ensurePreviewTLS(ctx, targetNamespace, operatorconfig.Current().Preview.Domain); err != nil {
+			return ctrl.Result{}, err
+		}
+		if previewHost, err = renderPreviewHost(previewHostTemplate(), env, project, hierarchy.Team, previewDomain); err != nil {
+			log.Error(err, "Cannot generate preview host", "environment", env.Name)
+			return ctrl.Result{}, err
+		}
+	}
 
+	routing := previewRouting(project)
+	ingressSpec := resolveIngress(env, project)
 	ingress := desiredIngress(env, targetNamespace, isLocal, previewDomain)
+	if !isLocal {
+		ingress.Spec.Rules[0].Host = previewHost
+	}
+	var extraHosts []string
+	if routing != routingGateway {
+		if extraHosts, err = r.ingressHosts(ctx, env, targetNamespace, ingressSpec); err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to check ingress hosts: %w", err)
+		}
+	}
+	addIngressHosts(ingress, extraHosts)
+	r.applyIngressClass(ingress, ingressSpec)
+	tls.applyTo(ingress)
+	applyAccess(env, ingress)
+	applyDNS(ingress, currentDNSConfig())
+	applyIngressSpec(ingress, ingressSpec)
+	// Gateway routing cannot protect the hosts: they are not served rather than public
+	if refused, err := r.refuseUnprotectedRouting(ctx, env, targetNamespace, routing); refused || err != nil {
+		return ctrl.Result{}, err
+	}
 	existingIngress := &networkingv1.Ingress{}
-	err = r.Get(ctx, client.ObjectKey{Name: "web", Namespace: targetNamespace}, existingIngress)
-
-	if err != nil && apierrors.IsNotFound(err) {
+	if routing == routingGateway {
+		// Gateway API routing: an HTTPRoute replaces the Ingress
+		if err := r.reconcilePreviewRoute(ctx, env, targetNamespace, ingress.Spec.Rules[0].Host, previewDomain); err != nil {
+			return ctrl.Result{}, err
+		}
+	} else if err = r.Get(ctx, client.ObjectKey{Name: "web", Namespace: targetNamespace}, existingIngress); err != nil && apierrors.IsNotFound(err) {
 		// Ingress doesn't exist, create it
+		if err := r.checkDrift(ctx, env, ingress, nil); err != nil {
+			return ctrl.Result{}, err
+		}
 		log.Info("Creating Ingress", "namespace", targetNamespace, "isLocal", isLocal)
 		if err := r.Create(ctx, ingress); err != nil {
 			return ctrl.Result{}, err
 		}
+		recordEvent(r.Recorder, env, corev1.EventTypeNormal, eventIngressCreated, "Created Ingress for %s", ingress.Spec.Rules[0].Host)
 	} else if err != nil {
 		return ctrl.Result{}, err
+	} else if err := r.checkDrift(ctx, env, ingress, existingIngress); err != nil {
+		return ctrl.Result{}, err
+	} else if annotationsChanged := syncIngressAnnotations(existingIngress, ingress, accessAnnotations, dnsAnnotations, previewTLSAnnotations, ingressAnnotationKeys(existingIngress, ingress)); annotationsChanged ||
+		!equality.Semantic.DeepEqual(existingIngress.Spec.TLS, ingress.Spec.TLS) || !equality.Semantic.DeepEqual(existingIngress.Spec.Rules, ingress.Spec.Rules) ||
+		!equality.Semantic.DeepEqual(existingIngress.Spec.IngressClassName, ingress.Spec.IngressClassName) {
+		// Only the class, hosts, the TLS section and the managed annotations are kept in sync on existing Ingresses
+		log.Info("Updating Ingress class, hosts, TLS and annotations", "namespace", targetNamespace)
+		existingIngress.Spec.IngressClassName = ingress.Spec.IngressClassName
+		existingIngress.Spec.Rules = ingress.Spec.Rules
+		existingIngress.Spec.TLS = ingress.Spec.TLS
+		if err := r.Update(ctx, existingIngress); err != nil {
+			return ctrl.Result{}, err
+		}
 	}
 
-	// Generate and update the URL in status
+	// 3b. Shared-host header/cookie routing (debug routing to this environment)
+	if err := r.reconcileSharedRouting(ctx, env, targetNamespace); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// Surface cluster capabilities this environment would use but that are missing
+	if err := r.recordCapabilities(ctx, env, routing, ingressClassName(ingressSpec)); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// 3c. Stable alias host (spec.alias)
+	aliasEndpoint, aliasConflict, err := r.reconcileAlias(ctx, env, targetNamespace, isLocal, ingressPort, previewDomain, routing, tls, ingressSpec)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// Access protection of the preview hosts (spec.access)
+	previewHosts := append([]string{ingress.Spec.Rules[0].Host}, extraHosts...)
+	if aliasEndpoint != "" {
+		previewHosts = append(previewHosts, aliasHost(env.Spec.Alias, isLocal, previewDomain))
+	}
+	if err := r.reconcileAccess(ctx, env, targetNamespace, isLocal, previewHosts, tls, ingressSpec); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// DNS records of the preview hosts, and whether they resolve yet
+	dnsPending, err := r.reconcileDNS(ctx, env, previewHosts, isLocal)
+	if err != nil {
+		log.Error(err, "Failed to reconcile DNS records", "hosts", previewHosts)
+		return ctrl.Result{}, err
+	}
+
+	// Generate and update the URLs in status
 	publicURL := generateURL(env, targetNamespace, isLocal, ingressPort, previewDomain)
-	if env.Status.URL != publicURL {
+	if !isLocal {
+		publicURL = hostURL(previewHost, isLocal, ingressPort, tls)
+	} else if tls != nil {
+		publicURL = hostURL(ingress.Spec.Rules[0].Host, isLocal, ingressPort, tls)
+	}
+	urls := []string{publicURL}
+	if aliasEndpoint != "" {
+		urls = append(urls, aliasEndpoint)
+	}
+	if env.Status.URL != publicURL || !slices.Equal(env.Status.URLs, urls) {
 		env.Status.URL = publicURL
-		log.Info("Updating Environment URL", "url", publicURL)
+		env.Status.URLs = urls
+		log.Info("Updating Environment URL", "url", publicURL, "urls", urls)
 		if err := r.Status().Update(ctx, env); err != nil {
 			return ctrl.Result{}, err
 		}
 	}
 
+	// File sync endpoint of development mode (config.fileSync)
+	if err := r.reconcileFileSync(ctx, env, envTemplate, targetNamespace, routing, previewHosts, tls, ingressSpec); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// Resource usage for kubectl get environments (metrics-server)
+	usageTracked, err := r.reconcileResourceUsage(ctx, env, targetNamespace)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// 3d. Hibernation: scale workloads to zero, or restore them once the flag is cleared
+	if env.Spec.Hibernate {
+		log.Info("Hibernating environment", "namespace", targetNamespace)
+		return ctrl.Result{}, r.reconcileHibernation(ctx, env, targetNamespace)
+	}
+	if env.Status.Phase == phaseHibernated {
+		log.Info("Waking environment", "namespace", targetNamespace)
+		if err := r.wakeFromHibernation(ctx, env, targetNamespace); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	// 3e. Ad-hoc runs (spec.runs) as Jobs next to the web workload
+	runsActive, err := r.reconcileRuns(ctx, env, targetNamespace)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// 3f. Ordered provisioning: wait for the Environments of spec.dependsOn to be Ready
+	if ready, err := r.reconcileDependencies(ctx, env); err != nil {
+		return ctrl.Result{}, err
+	} else if !ready {
+		// Changes of the dependencies are watched
+		log.Info("Waiting for dependencies", "dependsOn", env.Spec.DependsOn)
+		return ctrl.Result{}, nil
+	}
+
 	// 4. Deployment Mode Branching
-	// Infer mode from env.Spec.Type if DeploymentMode is not explicitly set
-	deploymentMode := env.Spec.DeploymentMode
-	if deploymentMode == "" {
-		if envTemplate != nil && envTemplate.Type == "helm" {
-			deploymentMode = "helm"
-		} else if envTemplate != nil && envTemplate.Type == "docker-compose" {
-			deploymentMode = "docker-compose"
-		} else if env.Spec.Type == "development" {
-			deploymentMode = "development"
-		} else if env.Spec.Type == "deployment" || env.Spec.Type == "staging" || env.Spec.Type == "production" {
-			deploymentMode = "production"
-		} else {
-			deploymentMode = "workspace" // Default fallback
+	deploymentMode := resolveDeploymentMode(env, envTemplate)
+	if recordDeploymentSummary(env, deploymentMode) {
+		if err := r.Status().Update(ctx, env); err != nil {
+			return ctrl.Result{}, err
 		}
 	}
 
 	log.Info("Reconciling deployment mode", "mode", deploymentMode, "namespace", targetNamespace, "templateFound", envTemplate != nil)
 
+	phase := env.Status.Phase
+	start := time.Now()
+	result, err := r.reconcileDeploymentMode(ctx, deploymentMode, env, project, targetNamespace, isLocal, ingressPort, envTemplate)
+	observeSince(reconcileDuration.WithLabelValues(deploymentMode, metricResult(err)), start)
+	if err == nil && env.Status.Phase == "Ready" && phase != "Ready" {
+		recordEvent(r.Recorder, env, corev1.EventTypeNormal, eventURLReady, "Environment is ready at %s", env.Status.URL)
+	}
+	violation, recordErr := r.recordGuardrailResult(ctx, env, err)
+	if recordErr != nil {
+		return ctrl.Result{}, recordErr
+	}
+	cleared := clearFailure(env)
+	if err == nil && (drift.endDriftCheck(env) || cleared) {
+		if updateErr := r.Status().Update(ctx, env); updateErr != nil {
+			return ctrl.Result{}, updateErr
+		}
+	}
+	if revisionErr := r.reconcileEnvironmentRevisions(ctx, env); revisionErr != nil {
+		return ctrl.Result{}, revisionErr
+	}
+	// 5. One-off tasks (spec.tasks), once per deploy against the web Deployment just applied
+	tasksActive := false
+	if err == nil {
+		if tasksActive, err = r.reconcileTasks(ctx, env, targetNamespace); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	// Report phase transitions to the commit and pull request
+	if notifyErr := r.reconcileNotifications(ctx, env, project, err); notifyErr != nil {
+		return ctrl.Result{}, notifyErr
+	}
+	if violation {
+		return ctrl.Result{RequeueAfter: guardrailRetryInterval}, nil
+	}
+	if err == nil && rolloutDeferred && result.RequeueAfter == 0 {
+		// Retry the migration once a Batched rollout slot frees up
+		result.RequeueAfter = 30 * time.Second
+	}
+	if err == nil && aliasConflict && result.RequeueAfter == 0 {
+		// Claim the alias host once its current owner releases it
+		result.RequeueAfter = 30 * time.Second
+	}
+	if err == nil && dnsPending && (result.RequeueAfter == 0 || result.RequeueAfter > dnsRetryInterval) {
+		// Check again until the preview hosts resolve
+		result.RequeueAfter = dnsRetryInterval
+	}
+	if err == nil && secretsSynced && (result.RequeueAfter == 0 || result.RequeueAfter > catalystSecretsResyncInterval) {
+		// Pick up secrets changed in the secrets backend
+		result.RequeueAfter = catalystSecretsResyncInterval
+	}
+	if err == nil && gitTokensMinted && (result.RequeueAfter == 0 || result.RequeueAfter > gitCredentialsResyncInterval) {
+		// Renew the minted git tokens before they expire
+		result.RequeueAfter = gitCredentialsResyncInterval
+	}
+	if err == nil && usageTracked && (result.RequeueAfter == 0 || result.RequeueAfter > resourceUsageInterval) {
+		// Refresh status.resources
+		result.RequeueAfter = resourceUsageInterval
+	}
+	if err == nil && (runsActive || tasksActive) && result.RequeueAfter == 0 {
+		// Run and task Jobs are watched; resync in case an event is missed
+		result.RequeueAfter = workloadResyncInterval
+	}
+	if err == nil && r.ResyncInterval > 0 && env.Status.Phase == "Ready" && (result.RequeueAfter == 0 || result.RequeueAfter > r.ResyncInterval) {
+		// Detect and repair drift of Ready environments
+		result.RequeueAfter = r.ResyncInterval
+	}
+	return result, err
+}
+
+// resolveDeploymentMode returns env.Spec.DeploymentMode, or infers the mode from the
+// template type and env.Spec.Type when it is not explicitly set
+func resolveDeploymentMode(env *catalystv1alpha1.Environment, envTemplate *catalystv1alpha1.EnvironmentTemplateSpec) string {
+	if env.Spec.DeploymentMode != "" {
+		return env.Spec.DeploymentMode
+	}
+	switch {
+	case envTemplate != nil && envTemplate.Type == "helm":
+		return "helm"
+	case envTemplate != nil && envTemplate.Type == "docker-compose":
+		return "docker-compose"
+	case envTemplate != nil && envTemplate.Type == "kustomize":
+		return "kustomize"
+	case env.Spec.Type == "development":
+		return "development"
+	case env.Spec.Type == "deployment" || env.Spec.Type == "staging" || env.Spec.Type == "production":
+		return "production"
+	default:
+		return "workspace" // Default fallback
+	}
+}
+
+// recordDeploymentSummary records the resolved deployment mode and the abbreviated commit of
+// the primary source in the status, for the Mode and Commit columns. Reports whether the
+// status changed.
+func recordDeploymentSummary(env *catalystv1alpha1.Environment, deploymentMode string) bool {
+	commit := ""
+	if len(env.Spec.Sources) > 0 {
+		commit = env.Spec.Sources[0].CommitSha
+		if len(commit) > 7 {
+			commit = commit[:7]
+		}
+	}
+	if env.Status.DeploymentMode == deploymentMode && env.Status.Commit == commit {
+		return false
+	}
+	env.Status.DeploymentMode = deploymentMode
+	env.Status.Commit = commit
+	return true
+}
+
+// markTeardownStarted moves a deleted environment to phase Deleting, and reports whether
+// the status changed
+func markTeardownStarted(env *catalystv1alpha1.Environment) bool {
+	if env.Status.Phase == phaseDeleting {
+		return false
+	}
+	env.Status.Phase = phaseDeleting
+	return true
+}
+
+// reconcileDeploymentMode dispatches to the reconciler for the resolved deployment mode
+func (r *EnvironmentReconciler) reconcileDeploymentMode(ctx context.Context, deploymentMode string, env *catalystv1alpha1.Environment, project *catalystv1alpha1.Project, targetNamespace string, isLocal bool, ingressPort string, envTemplate *catalystv1alpha1.EnvironmentTemplateSpec) (ctrl.Result, error) {
 	switch deploymentMode {
 	case "development":
 		return r.reconcileDevelopmentModeWithStatus(ctx, env, project, targetNamespace, isLocal, ingressPort, envTemplate)
@@ -306,13 +822,19 @@ func (r *EnvironmentReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 	case "docker-compose":
 		return r.reconcileComposeModeWithStatus(ctx, env, project, targetNamespace, envTemplate)
 
+	case "kustomize":
+		return r.reconcileKustomizeModeWithStatus(ctx, env, project, targetNamespace, envTemplate)
+
+	case "gitops":
+		return r.reconcileGitOpsModeWithStatus(ctx, env, project, targetNamespace, envTemplate)
+
 	default: // "workspace" or any unrecognized value defaults to workspace
-		return r.reconcileWorkspaceMode(ctx, env, targetNamespace)
+		return r.reconcileWorkspaceMode(ctx, env, project, targetNamespace)
 	}
 }
 
 // reconcileComposeModeWithStatus handles docker-compose deployment with status updates
-func (r *EnvironmentReconciler) reconcileComposeModeWithStatus(ctx context.Context, env *catalystv1alpha1.Environment, project *catalystv1alpha1.Project, namespace string, template *catalystv1alpha1.EnvironmentTemplate) (ctrl.Result, error) {
+func (r *EnvironmentReconciler) reconcileComposeModeWithStatus(ctx context.Context, env *catalystv1alpha1.Environment, project *catalystv1alpha1.Project, namespace string, template *catalystv1alpha1.EnvironmentTemplateSpec) (ctrl.Result, error) {
 	log := logf.FromContext(ctx)
 
 	// Wait for default service account
@@ -338,11 +860,8 @@ func (r *EnvironmentReconciler) reconcileComposeModeWithStatus(ctx context.Conte
 	// Run compose reconciliation
 	ready, err := r.ReconcileComposeMode(ctx, env, project, namespace, template)
 	if err != nil {
-		if env.Status.Phase != "Failed" {
-			env.Status.Phase = "Failed"
-			if updateErr := r.Status().Update(ctx, env); updateErr != nil {
-				return ctrl.Result{}, updateErr
-			}
+		if updateErr := r.markFailed(ctx, env, catalystv1alpha1.FailureReasonDeploymentFailed, err); updateErr != nil {
+			return ctrl.Result{}, updateErr
 		}
 
 		if strings.Contains(err.Error(), "source not found") {
@@ -353,21 +872,61 @@ func (r *EnvironmentReconciler) reconcileComposeModeWithStatus(ctx context.Conte
 	}
 
 	if ready {
-		if env.Status.Phase != "Ready" {
-			env.Status.Phase = "Ready"
-			if err := r.Status().Update(ctx, env); err != nil {
-				return ctrl.Result{}, err
-			}
+		return r.markReady(ctx, env, namespace)
+	}
+
+	// Not ready yet: builds and workloads are watched, resync as a safety net
+	return ctrl.Result{RequeueAfter: workloadResyncInterval}, nil
+}
+
+// reconcileKustomizeModeWithStatus handles kustomize deployment with status updates
+func (r *EnvironmentReconciler) reconcileKustomizeModeWithStatus(ctx context.Context, env *catalystv1alpha1.Environment, project *catalystv1alpha1.Project, namespace string, template *catalystv1alpha1.EnvironmentTemplateSpec) (ctrl.Result, error) {
+	log := logf.FromContext(ctx)
+
+	// Wait for default service account
+	sa := &corev1.ServiceAccount{}
+	if err := r.Get(ctx, client.ObjectKey{Name: "default", Namespace: namespace}, sa); err != nil {
+		if apierrors.IsNotFound(err) {
+			log.Info("Waiting for default ServiceAccount", "namespace", namespace)
+			return ctrl.Result{RequeueAfter: time.Second}, nil
 		}
-		return ctrl.Result{}, nil
+		return ctrl.Result{}, err
 	}
 
-	// Not ready yet (re-reconcile for builds or resources)
-	return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
+	// Set provisioning status
+	// Note: We check != "Failed" to prevent an infinite loop where the controller flip-flops
+	// between "Failed" (due to error) and "Provisioning" (here), triggering constant updates.
+	if env.Status.Phase != "Provisioning" && env.Status.Phase != "Ready" && env.Status.Phase != "Building" && env.Status.Phase != "Failed" {
+		env.Status.Phase = "Provisioning"
+		if err := r.Status().Update(ctx, env); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	// Run kustomize reconciliation
+	ready, err := r.ReconcileKustomizeMode(ctx, env, project, namespace, template)
+	if err != nil {
+		if updateErr := r.markFailed(ctx, env, catalystv1alpha1.FailureReasonDeploymentFailed, err); updateErr != nil {
+			return ctrl.Result{}, updateErr
+		}
+
+		if strings.Contains(err.Error(), "source not found") {
+			log.Error(err, "Kustomize reconciliation failed due to missing source; pausing until fixed")
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	if ready {
+		return r.markReady(ctx, env, namespace)
+	}
+
+	// Not ready yet: builds and workloads are watched, resync as a safety net
+	return ctrl.Result{RequeueAfter: workloadResyncInterval}, nil
 }
 
 // reconcileHelmModeWithStatus handles helm deployment with status updates
-func (r *EnvironmentReconciler) reconcileHelmModeWithStatus(ctx context.Context, env *catalystv1alpha1.Environment, project *catalystv1alpha1.Project, namespace string, _ bool, _ string, template *catalystv1alpha1.EnvironmentTemplate) (ctrl.Result, error) {
+func (r *EnvironmentReconciler) reconcileHelmModeWithStatus(ctx context.Context, env *catalystv1alpha1.Environment, project *catalystv1alpha1.Project, namespace string, _ bool, _ string, template *catalystv1alpha1.EnvironmentTemplateSpec) (ctrl.Result, error) {
 	log := logf.FromContext(ctx)
 
 	// Wait for default service account
@@ -395,14 +954,13 @@ func (r *EnvironmentReconciler) reconcileHelmModeWithStatus(ctx context.Context,
 		builtImages, err = r.reconcileBuilds(ctx, env, project, namespace, template)
 		if err != nil {
 			log.Error(err, "Build failed")
-			env.Status.Phase = "Failed"
-			_ = r.Status().Update(ctx, env)
+			_ = r.markFailed(ctx, env, catalystv1alpha1.FailureReasonBuildFailed, err)
 			return ctrl.Result{}, err
 		}
 
 		if builtImages == nil {
 			log.Info("Builds in progress...")
-			return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
+			return ctrl.Result{RequeueAfter: workloadResyncInterval}, nil
 		}
 	}
 
@@ -419,11 +977,8 @@ func (r *EnvironmentReconciler) reconcileHelmModeWithStatus(ctx context.Context,
 	// Run helm reconciliation
 	ready, err := r.ReconcileHelmMode(ctx, env, project, namespace, template, builtImages)
 	if err != nil {
-		if env.Status.Phase != "Failed" {
-			env.Status.Phase = "Failed"
-			if updateErr := r.Status().Update(ctx, env); updateErr != nil {
-				return ctrl.Result{}, updateErr
-			}
+		if updateErr := r.markFailed(ctx, env, catalystv1alpha1.FailureReasonHelmInstallFailed, err); updateErr != nil {
+			return ctrl.Result{}, updateErr
 		}
 
 		if strings.Contains(err.Error(), "source not found") {
@@ -434,13 +989,7 @@ func (r *EnvironmentReconciler) reconcileHelmModeWithStatus(ctx context.Context,
 	}
 
 	if ready {
-		if env.Status.Phase != "Ready" {
-			env.Status.Phase = "Ready"
-			if err := r.Status().Update(ctx, env); err != nil {
-				return ctrl.Result{}, err
-			}
-		}
-		return ctrl.Result{}, nil
+		return r.markReady(ctx, env, namespace)
 	}
 
 	// Not ready yet, requeue
@@ -448,7 +997,7 @@ func (r *EnvironmentReconciler) reconcileHelmModeWithStatus(ctx context.Context,
 }
 
 // reconcileDevelopmentModeWithStatus handles development mode deployment with status updates
-func (r *EnvironmentReconciler) reconcileDevelopmentModeWithStatus(ctx context.Context, env *catalystv1alpha1.Environment, project *catalystv1alpha1.Project, namespace string, _ bool, _ string, template *catalystv1alpha1.EnvironmentTemplate) (ctrl.Result, error) {
+func (r *EnvironmentReconciler) reconcileDevelopmentModeWithStatus(ctx context.Context, env *catalystv1alpha1.Environment, project *catalystv1alpha1.Project, namespace string, _ bool, _ string, template *catalystv1alpha1.EnvironmentTemplateSpec) (ctrl.Result, error) {
 	log := logf.FromContext(ctx)
 
 	// Wait for default service account
@@ -474,27 +1023,20 @@ func (r *EnvironmentReconciler) reconcileDevelopmentModeWithStatus(ctx context.C
 	// Run development mode reconciliation
 	ready, err := r.ReconcileDevelopmentMode(ctx, env, project, namespace, template)
 	if err != nil {
-		env.Status.Phase = "Failed"
-		_ = r.Status().Update(ctx, env)
+		_ = r.markFailed(ctx, env, catalystv1alpha1.FailureReasonDeploymentFailed, err)
 		return ctrl.Result{}, err
 	}
 
 	if ready {
-		if env.Status.Phase != "Ready" {
-			env.Status.Phase = "Ready"
-			if err := r.Status().Update(ctx, env); err != nil {
-				return ctrl.Result{}, err
-			}
-		}
-		return ctrl.Result{}, nil
+		return r.markReady(ctx, env, namespace)
 	}
 
-	// Not ready yet, requeue
-	return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
+	// Not ready yet: the workloads are watched, resync as a safety net
+	return ctrl.Result{RequeueAfter: workloadResyncInterval}, nil
 }
 
 // reconcileProductionModeWithStatus handles production mode deployment with status updates
-func (r *EnvironmentReconciler) reconcileProductionModeWithStatus(ctx context.Context, env *catalystv1alpha1.Environment, project *catalystv1alpha1.Project, namespace string, isLocal bool, _ string, template *catalystv1alpha1.EnvironmentTemplate) (ctrl.Result, error) {
+func (r *EnvironmentReconciler) reconcileProductionModeWithStatus(ctx context.Context, env *catalystv1alpha1.Environment, project *catalystv1alpha1.Project, namespace string, isLocal bool, _ string, template *catalystv1alpha1.EnvironmentTemplateSpec) (ctrl.Result, error) {
 	log := logf.FromContext(ctx)
 
 	// Wait for default service account
@@ -520,27 +1062,20 @@ func (r *EnvironmentReconciler) reconcileProductionModeWithStatus(ctx context.Co
 	// Run production mode reconciliation
 	ready, err := r.ReconcileProductionMode(ctx, env, project, namespace, isLocal, template)
 	if err != nil {
-		env.Status.Phase = "Failed"
-		_ = r.Status().Update(ctx, env)
+		_ = r.markFailed(ctx, env, catalystv1alpha1.FailureReasonDeploymentFailed, err)
 		return ctrl.Result{}, err
 	}
 
 	if ready {
-		if env.Status.Phase != "Ready" {
-			env.Status.Phase = "Ready"
-			if err := r.Status().Update(ctx, env); err != nil {
-				return ctrl.Result{}, err
-			}
-		}
-		return ctrl.Result{}, nil
+		return r.markReady(ctx, env, namespace)
 	}
 
-	// Not ready yet, requeue
-	return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
+	// Not ready yet: the workloads are watched, resync as a safety net
+	return ctrl.Result{RequeueAfter: workloadResyncInterval}, nil
 }
 
 // reconcileWorkspaceMode handles workspace mode (original behavior)
-func (r *EnvironmentReconciler) reconcileWorkspaceMode(ctx context.Context, env *catalystv1alpha1.Environment, namespace string) (ctrl.Result, error) {
+func (r *EnvironmentReconciler) reconcileWorkspaceMode(ctx context.Context, env *catalystv1alpha1.Environment, project *catalystv1alpha1.Project, namespace string) (ctrl.Result, error) {
 	log := logf.FromContext(ctx)
 
 	// Wait for default service account to be ready
@@ -553,6 +1088,11 @@ func (r *EnvironmentReconciler) reconcileWorkspaceMode(ctx context.Context, env
 		return ctrl.Result{}, err
 	}
 
+	// Per-environment token for attaching terminals through the workspace gateway
+	if err := r.ensureGatewayToken(ctx, env); err != nil {
+		return ctrl.Result{}, err
+	}
+
 	// Create a workspace pod that runs indefinitely for exec access from UI
 	podName := workspacePodName(env)
 	workspacePod := &corev1.Pod{}
@@ -561,7 +1101,16 @@ func (r *EnvironmentReconciler) reconcileWorkspaceMode(ctx context.Context, env
 	if err != nil && apierrors.IsNotFound(err) {
 		// Pod doesn't exist, create it
 		log.Info("Creating Workspace Pod", "pod", podName)
-		workspacePod = desiredWorkspacePod(env, namespace)
+		// The repository's devcontainer.json shapes the pod, when it has one
+		devContainer, err := r.loadWorkspaceDevContainer(ctx, env, project)
+		if err != nil {
+			log.Error(err, "Cannot read devcontainer.json; using the default workspace pod")
+		} else if devContainer != nil {
+			if err := r.ensureGitScriptsConfigMap(ctx, namespace); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+		workspacePod = desiredWorkspacePod(env, namespace, devContainer)
 
 		// Note: Pod is in different namespace, cannot set owner ref.
 		// It will be garbage collected when the Namespace is deleted.
@@ -573,7 +1122,7 @@ func (r *EnvironmentReconciler) reconcileWorkspaceMode(ctx context.Context, env
 		if err := r.Status().Update(ctx, env); err != nil {
 			return ctrl.Result{}, err
 		}
-		return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
+		return ctrl.Result{RequeueAfter: workloadResyncInterval}, nil
 	} else if err != nil {
 		return ctrl.Result{}, err
 	}
@@ -591,9 +1140,14 @@ func (r *EnvironmentReconciler) reconcileWorkspaceMode(ctx context.Context, env
 			}
 		}
 	case corev1.PodFailed:
-		env.Status.Phase = "Failed"
-		if err := r.Status().Update(ctx, env); err != nil {
-			return ctrl.Result{}, err
+		message := fmt.Sprintf("workspace pod %s failed", podName)
+		if workspacePod.Status.Message != "" {
+			message += ": " + workspacePod.Status.Message
+		}
+		if r.setFailed(env, catalystv1alpha1.FailureReasonDeploymentFailed, message) {
+			if err := r.Status().Update(ctx, env); err != nil {
+				return ctrl.Result{}, err
+			}
 		}
 		return ctrl.Result{}, nil
 	default:
@@ -604,28 +1158,29 @@ func (r *EnvironmentReconciler) reconcileWorkspaceMode(ctx context.Context, env
 				return ctrl.Result{}, err
 			}
 		}
-		// Poll pod status
-		return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
+		// Pod status changes are watched
+		return ctrl.Result{RequeueAfter: workloadResyncInterval}, nil
 	}
 
 	return ctrl.Result{}, nil
 }
 
 // SyncCatalystSecrets creates or updates the catalyst-secrets Secret in the namespace
-// with the provided key-value pairs from the web API
+// with the provided key-value pairs from the web API, annotated with their content hash
 func (r *EnvironmentReconciler) SyncCatalystSecrets(
 	ctx context.Context,
 	namespace string,
 	secrets map[string]string,
 ) error {
 	log := logf.FromContext(ctx)
-	secretName := "catalyst-secrets"
+	secretName := catalystSecretsName
 
 	// Convert secrets to bytes for K8s Secret data
 	data := make(map[string][]byte)
 	for key, value := range secrets {
 		data[key] = []byte(value)
 	}
+	hash := secretsHash(data)
 
 	secret := &corev1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
@@ -634,6 +1189,7 @@ func (r *EnvironmentReconciler) SyncCatalystSecrets(
 			Labels: map[string]string{
 				"app.kubernetes.io/managed-by": "catalyst-operator",
 			},
+			Annotations: map[string]string{secretsHashAnnotation: hash},
 		},
 		Data: data,
 	}
@@ -655,9 +1211,13 @@ func (r *EnvironmentReconciler) SyncCatalystSecrets(
 		} else {
 			return fmt.Errorf("failed to get secret: %w", err)
 		}
-	} else {
+	} else if existing.Annotations[secretsHashAnnotation] != hash {
 		// Update existing secret
 		existing.Data = data
+		if existing.Annotations == nil {
+			existing.Annotations = map[string]string{}
+		}
+		existing.Annotations[secretsHashAnnotation] = hash
 		if err := r.Update(ctx, existing); err != nil {
 			return fmt.Errorf("failed to update secret: %w", err)
 		}
@@ -670,10 +1230,82 @@ func (r *EnvironmentReconciler) SyncCatalystSecrets(
 // SetupWithManager sets up the controller with the Manager.
 func (r *EnvironmentReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	r.Config = mgr.GetConfig()
-	return ctrl.NewControllerManagedBy(mgr).
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &catalystv1alpha1.Environment{},
+		environmentTargetNamespaceIndex, environmentTargetNamespace); err != nil {
+		return err
+	}
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &catalystv1alpha1.Environment{},
+		environmentDependsOnIndex, environmentDependsOn); err != nil {
+		return err
+	}
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &catalystv1alpha1.Environment{},
+		environmentPromoteFromIndex, environmentPromoteFrom); err != nil {
+		return err
+	}
+	workloads := handler.EnqueueRequestsFromMapFunc(r.environmentsForWorkload)
+	b := ctrl.NewControllerManagedBy(mgr)
+	if r.HelmOperations != nil {
+		// Environments whose background Helm operation finished
+		b = b.WatchesRawSource(r.HelmOperations.Source())
+	}
+	return b.
 		For(&catalystv1alpha1.Environment{}).
+		// Settings of a reloaded operator configuration apply to every Environment
+		WatchesRawSource(source.Func(r.operatorConfigReloads)).
 		// Note: Resources in target namespace are not owned via OwnerRef due to cross-namespace restrictions.
-		// We rely on polling for Job status and Finalizer for cleanup.
+		// Labeled workloads are mapped back through the target namespace index; Finalizer handles cleanup.
+		Watches(&appsv1.Deployment{}, workloads, builder.WithPredicates(hasEnvironmentLabel)).
+		Watches(&appsv1.StatefulSet{}, workloads, builder.WithPredicates(hasEnvironmentLabel)).
+		Watches(&batchv1.Job{}, workloads, builder.WithPredicates(hasEnvironmentLabel)).
+		Watches(&corev1.Pod{}, workloads, builder.WithPredicates(hasEnvironmentLabel)).
+		// Dependents of an Environment (spec.dependsOn) deploy once it is Ready
+		Watches(&catalystv1alpha1.Environment{}, handler.EnqueueRequestsFromMapFunc(r.environmentsDependingOn)).
+		// Environments promoting an Environment (spec.promoteFrom) follow its revisions
+		Watches(&catalystv1alpha1.Environment{}, handler.EnqueueRequestsFromMapFunc(r.environmentsPromotedFrom)).
+		// Renewals of the shared wildcard certificate are copied to every environment.
+		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(r.environmentsForPreviewTLSSecret)).
 		Named("environment").
-		Complete(r)
+		Complete(drainingReconciler{r})
+}
+
+// environmentsForPreviewTLSSecret enqueues all Environments when the central wildcard
+// TLS Secret (preview.tls.secret) changes
+func (r *EnvironmentReconciler) environmentsForPreviewTLSSecret(ctx context.Context, obj client.Object) []reconcile.Request {
+	if operatorconfig.Current().Preview.TLS.Secret != obj.GetNamespace()+"/"+obj.GetName() {
+		return nil
+	}
+	return r.allEnvironments(ctx, "wildcard TLS renewal")
+}
+
+// operatorConfigReloads enqueues all Environments when the operator configuration file is
+// reloaded, so changed settings apply without waiting for the resync
+func (r *EnvironmentReconciler) operatorConfigReloads(ctx context.Context, queue workqueue.TypedRateLimitingInterface[reconcile.Request]) error {
+	reloads := operatorconfig.Subscribe()
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-reloads:
+				for _, req := range r.allEnvironments(ctx, "operator configuration reload") {
+					queue.Add(req)
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// allEnvironments returns a request for every Environment
+func (r *EnvironmentReconciler) allEnvironments(ctx context.Context, reason string) []reconcile.Request {
+	envs := &catalystv1alpha1.EnvironmentList{}
+	if err := r.List(ctx, envs); err != nil {
+		logf.FromContext(ctx).Error(err, "Failed to list Environments for "+reason)
+		return nil
+	}
+	requests := make([]reconcile.Request, 0, len(envs.Items))
+	for _, env := range envs.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&env)})
+	}
+	return requests
 }
@@ -90,7 +90,7 @@ var _ = Describe("Environment Controller", func() {
 							Branch:        "main",
 						},
 					},
-					Templates: map[string]catalystv1alpha1.EnvironmentTemplate{
+					Templates: map[string]catalystv1alpha1.EnvironmentTemplateSpec{
 						"development": {
 							Type: "development",
 						},
@@ -361,7 +361,7 @@ var _ = Describe("Environment Controller", func() {
 							Branch:        "main",
 						},
 					},
-					Templates: map[string]catalystv1alpha1.EnvironmentTemplate{
+					Templates: map[string]catalystv1alpha1.EnvironmentTemplateSpec{
 						"helm-env": {
 							Type: "helm",
 							Path: "../../../charts/example",
@@ -479,7 +479,7 @@ var _ = Describe("Environment Controller", func() {
 							Branch:        "main",
 						},
 					},
-					Templates: map[string]catalystv1alpha1.EnvironmentTemplate{
+					Templates: map[string]catalystv1alpha1.EnvironmentTemplateSpec{
 						"helm-env": {
 							Type: "helm",
 							Path: "/nonexistent/path/to/chart",
@@ -572,7 +572,7 @@ var _ = Describe("Environment Controller", func() {
 							Branch:        "main",
 						},
 					},
-					Templates: map[string]catalystv1alpha1.EnvironmentTemplate{
+					Templates: map[string]catalystv1alpha1.EnvironmentTemplateSpec{
 						"helm-env": {
 							Type:      "helm",
 							SourceRef: "nonexistent-source",
@@ -666,7 +666,7 @@ var _ = Describe("Environment Controller", func() {
 							Branch:        "main",
 						},
 					},
-					Templates: map[string]catalystv1alpha1.EnvironmentTemplate{},
+					Templates: map[string]catalystv1alpha1.EnvironmentTemplateSpec{},
 				},
 			}
 			_ = k8sClient.Delete(ctx, noTemplateProject)
@@ -807,7 +807,7 @@ var _ = Describe("Environment Controller", func() {
 							Branch:        "main",
 						},
 					},
-					Templates: map[string]catalystv1alpha1.EnvironmentTemplate{
+					Templates: map[string]catalystv1alpha1.EnvironmentTemplateSpec{
 						"zero-config": {
 							Type: "helm",
 							Path: "../../../charts/example",
@@ -1004,7 +1004,7 @@ var _ = Describe("Environment Controller", func() {
 							Branch:        "main",
 						},
 					},
-					Templates: map[string]catalystv1alpha1.EnvironmentTemplate{
+					Templates: map[string]catalystv1alpha1.EnvironmentTemplateSpec{
 						"compose": {
 							Type: "docker-compose",
 							Path: "./testdata/compose",
@@ -0,0 +1,112 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+	"github.com/ncrmro/catalyst/operator/internal/operatorconfig"
+)
+
+// Environment revisions (environmentRevisions: true):
+// Every record of status.deploymentHistory is mirrored to an EnvironmentRevision named
+// <environment>-r<revision> in the Environment's namespace, its outcome in status. The objects
+// are not owned by the Environment: they outlive the trimmed history and the environment
+// itself, as an audit trail of what was deployed when.
+
+// +kubebuilder:rbac:groups=catalyst.catalyst.dev,resources=environmentrevisions,verbs=get;list;watch;create
+// +kubebuilder:rbac:groups=catalyst.catalyst.dev,resources=environmentrevisions/status,verbs=get;update;patch
+
+// environmentRevisionsEnabled reports whether deployments are recorded as EnvironmentRevisions
+func environmentRevisionsEnabled() bool {
+	return operatorconfig.Current().EnvironmentRevisions
+}
+
+// environmentRevisionName returns the name of the EnvironmentRevision of a revision
+func environmentRevisionName(env *catalystv1alpha1.Environment, revision int64) string {
+	return fmt.Sprintf("%s-r%d", env.Name, revision)
+}
+
+// desiredEnvironmentRevision returns the EnvironmentRevision mirroring a deployment record
+func desiredEnvironmentRevision(env *catalystv1alpha1.Environment, record catalystv1alpha1.DeploymentRecord) *catalystv1alpha1.EnvironmentRevision {
+	commit := ""
+	for _, image := range record.Images {
+		if image.Commit != "" {
+			commit = image.Commit
+			break
+		}
+	}
+	return &catalystv1alpha1.EnvironmentRevision{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      environmentRevisionName(env, record.Revision),
+			Namespace: env.Namespace,
+			Labels: map[string]string{
+				"catalyst.dev/project":     env.Spec.ProjectRef.Name,
+				"catalyst.dev/environment": sanitizeLabelValue(env.Name),
+			},
+		},
+		Spec: catalystv1alpha1.EnvironmentRevisionSpec{
+			Environment:  env.Name,
+			Revision:     record.Revision,
+			Commit:       commit,
+			Images:       record.Images,
+			TemplateHash: record.TemplateHash,
+			ConfigHash:   record.ConfigHash,
+			DeployedAt:   record.DeployedAt,
+		},
+		Status: catalystv1alpha1.EnvironmentRevisionStatus{Outcome: record.Outcome, CompletedAt: record.CompletedAt},
+	}
+}
+
+// reconcileEnvironmentRevisions creates the missing EnvironmentRevisions of the deployment
+// history and updates their outcome
+func (r *EnvironmentReconciler) reconcileEnvironmentRevisions(ctx context.Context, env *catalystv1alpha1.Environment) error {
+	if !environmentRevisionsEnabled() {
+		return nil
+	}
+	for _, record := range env.Status.DeploymentHistory {
+		if record.Revision == 0 {
+			continue
+		}
+		desired := desiredEnvironmentRevision(env, record)
+		existing := &catalystv1alpha1.EnvironmentRevision{}
+		err := r.Get(ctx, client.ObjectKeyFromObject(desired), existing)
+		if apierrors.IsNotFound(err) {
+			// The status is dropped on create
+			existing = desired.DeepCopy()
+			if err := r.Create(ctx, existing); err != nil {
+				return fmt.Errorf("failed to record revision %d: %w", record.Revision, err)
+			}
+		} else if err != nil {
+			return err
+		}
+		if existing.Status.Outcome == desired.Status.Outcome {
+			continue
+		}
+		existing.Status = desired.Status
+		if err := r.Status().Update(ctx, existing); err != nil {
+			return fmt.Errorf("failed to record the outcome of revision %d: %w", record.Revision, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+func TestReconcileEnvironmentRevisions(t *testing.T) {
+	c := newFakeClientBuilder().WithStatusSubresource(&catalystv1alpha1.EnvironmentRevision{}).Build()
+	r := &EnvironmentReconciler{Client: c, Scheme: testScheme}
+	ctx := context.Background()
+
+	deployedAt := metav1.NewTime(time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC))
+	env := &catalystv1alpha1.Environment{
+		ObjectMeta: metav1.ObjectMeta{Name: "pr-1", Namespace: "team"},
+		Spec:       catalystv1alpha1.EnvironmentSpec{ProjectRef: catalystv1alpha1.ProjectReference{Name: "shop"}},
+		Status: catalystv1alpha1.EnvironmentStatus{DeploymentHistory: []catalystv1alpha1.DeploymentRecord{{
+			Revision:   1,
+			Images:     []catalystv1alpha1.BuiltImage{{Name: "web", Image: "registry/shop/web:aaa", Digest: "sha256:a", Commit: "aaa"}},
+			ConfigHash: "c1",
+			DeployedAt: deployedAt,
+			Outcome:    catalystv1alpha1.DeploymentOutcomeDeploying,
+		}}},
+	}
+
+	require.NoError(t, r.reconcileEnvironmentRevisions(ctx, env))
+	revisions := &catalystv1alpha1.EnvironmentRevisionList{}
+	require.NoError(t, c.List(ctx, revisions))
+	assert.Empty(t, revisions.Items, "revisions are only recorded with ENVIRONMENT_REVISIONS")
+
+	t.Setenv("ENVIRONMENT_REVISIONS", "true")
+	require.NoError(t, r.reconcileEnvironmentRevisions(ctx, env))
+	revision := &catalystv1alpha1.EnvironmentRevision{}
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "pr-1-r1", Namespace: "team"}, revision))
+	assert.Equal(t, "pr-1", revision.Spec.Environment)
+	assert.Equal(t, "aaa", revision.Spec.Commit)
+	assert.Equal(t, "c1", revision.Spec.ConfigHash)
+	assert.Equal(t, "pr-1", revision.Labels["catalyst.dev/environment"])
+	assert.Equal(t, catalystv1alpha1.DeploymentOutcomeDeploying, revision.Status.Outcome)
+	assert.Empty(t, revision.OwnerReferences, "revisions outlive the environment")
+
+	completedAt := metav1.NewTime(deployedAt.Add(time.Minute))
+	env.Status.DeploymentHistory[0].Outcome = catalystv1alpha1.DeploymentOutcomeReady
+	env.Status.DeploymentHistory[0].CompletedAt = &completedAt
+	require.NoError(t, r.reconcileEnvironmentRevisions(ctx, env))
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(revision), revision))
+	assert.Equal(t, catalystv1alpha1.DeploymentOutcomeReady, revision.Status.Outcome)
+	assert.True(t, completedAt.Equal(revision.Status.CompletedAt))
+}
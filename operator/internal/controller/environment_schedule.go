@@ -0,0 +1,294 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+	"github.com/ncrmro/catalyst/operator/internal/cron"
+	"github.com/ncrmro/catalyst/operator/internal/sharding"
+)
+
+// Scheduled environments (EnvironmentSchedule):
+// An Environment named after the schedule exists from each up time until the following down
+// time. Every reconcile works out the current window from the last activations of both
+// expressions, so a missed wake-up (operator restart, clock skew) is caught up on the next
+// reconcile; startingDeadline bounds how late into a window that still happens. Each up time
+// creates the Environment at most once, recorded in status.lastUpTime, so deleting it by hand
+// keeps it down until the next window.
+
+const (
+	// conditionScheduleReady reports whether the schedule is valid and in effect
+	conditionScheduleReady = "Ready"
+
+	// scheduleLabel carries the schedule name on the Environments it creates
+	scheduleLabel = "catalyst.dev/schedule"
+	// scheduleSkipDatesAnnotation lists dates ("2025-12-24,2025-12-25", in the schedule's time
+	// zone) on which the schedule does not bring its Environment up
+	scheduleSkipDatesAnnotation = "catalyst.dev/skip-dates"
+
+	// scheduleRequeueSkew is added to the next activation so the requeue lands after it
+	scheduleRequeueSkew = time.Second
+)
+
+// EnvironmentScheduleReconciler creates and deletes Environments at the up and down times of
+// EnvironmentSchedules
+type EnvironmentScheduleReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+	// Shard limits scheduling to the Projects owned by this operator instance.
+	// Nil schedules every EnvironmentSchedule.
+	Shard *sharding.Shard
+	// Recorder emits an Event each time the schedule brings its Environment up or down.
+	// Nil records none.
+	Recorder record.EventRecorder
+	// Now returns the current time; nil uses time.Now
+	Now func() time.Time
+}
+
+// +kubebuilder:rbac:groups=catalyst.catalyst.dev,resources=environmentschedules,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=catalyst.catalyst.dev,resources=environmentschedules/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=catalyst.catalyst.dev,resources=environmentschedules/finalizers,verbs=update
+
+// Reconcile brings the scheduled Environment up or down for the current window and requeues
+// for the next activation
+func (r *EnvironmentScheduleReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := logf.FromContext(ctx)
+
+	schedule := &catalystv1alpha1.EnvironmentSchedule{}
+	if err := r.Get(ctx, req.NamespacedName, schedule); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if !schedule.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+	original := schedule.Status.DeepCopy()
+
+	if r.Shard.Partial() {
+		teamNamespace := schedule.Namespace
+		if hierarchy := ExtractNamespaceHierarchy(schedule.Spec.Template.Metadata.Labels); hierarchy != nil {
+			teamNamespace = hierarchy.Team
+		}
+		project := &catalystv1alpha1.Project{}
+		if err := r.Get(ctx, client.ObjectKey{Name: schedule.Spec.Template.Spec.ProjectRef.Name, Namespace: teamNamespace}, project); err != nil {
+			return ctrl.Result{}, client.IgnoreNotFound(err)
+		}
+		if owned, err := ownsProject(ctx, r, r.Shard, project); err != nil || !owned {
+			return ctrl.Result{}, err
+		}
+	}
+
+	up, down, loc, err := parseSchedule(schedule.Spec)
+	if err != nil {
+		return ctrl.Result{}, r.setScheduleCondition(ctx, schedule, original, metav1.ConditionFalse, "InvalidSchedule", err.Error())
+	}
+	now := r.now().In(loc)
+	lastUp, lastDown := up.Prev(now), down.Prev(now)
+	// An up and down at the same minute leave the Environment down
+	inWindow := !lastUp.IsZero() && lastUp.After(lastDown)
+
+	env := &catalystv1alpha1.Environment{}
+	err = r.Get(ctx, client.ObjectKey{Name: schedule.Name, Namespace: schedule.Namespace}, env)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return ctrl.Result{}, err
+	}
+	exists := err == nil
+	if exists && !metav1.IsControlledBy(env, schedule) {
+		return ctrl.Result{}, r.setScheduleCondition(ctx, schedule, original, metav1.ConditionFalse, "Conflict",
+			fmt.Sprintf("Environment %s exists and is not managed by this schedule", env.Name))
+	}
+
+	reason, message := "Inactive", "Waiting for the next up time"
+	switch {
+	case inWindow && exists:
+		reason, message = "Active", "Environment is up until the next down time"
+		if !lastUp.Equal(timeOf(schedule.Status.LastUpTime)) {
+			// Adopted from an earlier window the operator missed the down time of
+			schedule.Status.LastUpTime = ptr(metav1.NewTime(lastUp))
+		}
+	case inWindow && lastUp.Equal(timeOf(schedule.Status.LastUpTime)):
+		// Created for this window already and deleted since
+		message = "Environment was deleted during the current window, waiting for the next up time"
+	case inWindow:
+		if skip := scheduleSkipReason(schedule, lastUp, now); skip != "" {
+			reason, message = "Skipped", skip
+			break
+		}
+		env = desiredScheduledEnvironment(schedule)
+		if err := controllerutil.SetControllerReference(schedule, env, r.Scheme); err != nil {
+			return ctrl.Result{}, err
+		}
+		log.Info("Creating scheduled Environment", "schedule", schedule.Name, "environment", env.Name, "upTime", lastUp)
+		if err := r.Create(ctx, env); err != nil && !isAlreadyExists(err) {
+			return ctrl.Result{}, fmt.Errorf("failed to create scheduled Environment: %w", err)
+		}
+		scheduledEnvironmentsTotal.WithLabelValues("up").Inc()
+		recordEvent(r.Recorder, schedule, corev1.EventTypeNormal, eventScheduledUp, "Created Environment %s for the up time %s", env.Name, lastUp.Format(time.RFC3339))
+		exists = true
+		schedule.Status.LastUpTime = ptr(metav1.NewTime(lastUp))
+		reason, message = "Active", "Environment is up until the next down time"
+	case exists:
+		if env.DeletionTimestamp.IsZero() {
+			log.Info("Deleting scheduled Environment", "schedule", schedule.Name, "environment", env.Name, "downTime", lastDown)
+			if err := r.Delete(ctx, env); err != nil && !apierrors.IsNotFound(err) {
+				return ctrl.Result{}, err
+			}
+			scheduledEnvironmentsTotal.WithLabelValues("down").Inc()
+			recordEvent(r.Recorder, schedule, corev1.EventTypeNormal, eventScheduledDown, "Deleted Environment %s at the down time %s", env.Name, lastDown.Format(time.RFC3339))
+			schedule.Status.LastDownTime = ptr(metav1.NewTime(now).Rfc3339Copy())
+		}
+		exists = false
+	}
+
+	schedule.Status.Active = ""
+	if exists {
+		schedule.Status.Active = env.Name
+	}
+	nextUp, nextDown := up.Next(now), down.Next(now)
+	schedule.Status.NextUpTime = optionalTime(nextUp)
+	schedule.Status.NextDownTime = optionalTime(nextDown)
+	if err := r.setScheduleCondition(ctx, schedule, original, metav1.ConditionTrue, reason, message); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	var next time.Time
+	for _, t := range []time.Time{nextUp, nextDown} {
+		if !t.IsZero() && (next.IsZero() || t.Before(next)) {
+			next = t
+		}
+	}
+	if next.IsZero() {
+		return ctrl.Result{}, nil
+	}
+	return ctrl.Result{RequeueAfter: next.Sub(now) + scheduleRequeueSkew}, nil
+}
+
+// parseSchedule parses the up and down expressions and loads the time zone
+func parseSchedule(spec catalystv1alpha1.EnvironmentScheduleSpec) (up, down *cron.Schedule, loc *time.Location, err error) {
+	if up, err = cron.Parse(spec.Up); err != nil {
+		return nil, nil, nil, fmt.Errorf("invalid up schedule: %w", err)
+	}
+	if down, err = cron.Parse(spec.Down); err != nil {
+		return nil, nil, nil, fmt.Errorf("invalid down schedule: %w", err)
+	}
+	loc = time.UTC
+	if spec.TimeZone != "" {
+		if loc, err = time.LoadLocation(spec.TimeZone); err != nil {
+			return nil, nil, nil, fmt.Errorf("invalid time zone %q: %w", spec.TimeZone, err)
+		}
+	}
+	return up, down, loc, nil
+}
+
+// scheduleSkipReason explains why the up time is not acted on, empty if it is
+func scheduleSkipReason(schedule *catalystv1alpha1.EnvironmentSchedule, upTime, now time.Time) string {
+	if schedule.Spec.Suspend {
+		return "Schedule is suspended"
+	}
+	day := upTime.Format(time.DateOnly)
+	for _, date := range strings.Split(schedule.Annotations[scheduleSkipDatesAnnotation], ",") {
+		if strings.TrimSpace(date) == day {
+			return fmt.Sprintf("%s is listed in %s", day, scheduleSkipDatesAnnotation)
+		}
+	}
+	if deadline := schedule.Spec.StartingDeadline; deadline != nil && now.Sub(upTime) > deadline.Duration {
+		return fmt.Sprintf("Missed the up time %s by more than the starting deadline %s", upTime.Format(time.RFC3339), deadline.Duration)
+	}
+	return ""
+}
+
+// desiredScheduledEnvironment builds the Environment of the schedule's template
+func desiredScheduledEnvironment(schedule *catalystv1alpha1.EnvironmentSchedule) *catalystv1alpha1.Environment {
+	labels := maps.Clone(schedule.Spec.Template.Metadata.Labels)
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	labels[scheduleLabel] = sanitizeLabelValue(schedule.Name)
+	return &catalystv1alpha1.Environment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        schedule.Name,
+			Namespace:   schedule.Namespace,
+			Labels:      labels,
+			Annotations: maps.Clone(schedule.Spec.Template.Metadata.Annotations),
+		},
+		Spec: *schedule.Spec.Template.Spec.DeepCopy(),
+	}
+}
+
+// setScheduleCondition records the Ready condition, updating the status when anything changed
+func (r *EnvironmentScheduleReconciler) setScheduleCondition(ctx context.Context, schedule *catalystv1alpha1.EnvironmentSchedule, original *catalystv1alpha1.EnvironmentScheduleStatus, status metav1.ConditionStatus, reason, message string) error {
+	meta.SetStatusCondition(&schedule.Status.Conditions, metav1.Condition{
+		Type:               conditionScheduleReady,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: schedule.Generation,
+	})
+	if equality.Semantic.DeepEqual(*original, schedule.Status) {
+		return nil
+	}
+	return r.Status().Update(ctx, schedule)
+}
+
+func (r *EnvironmentScheduleReconciler) now() time.Time {
+	if r.Now != nil {
+		return r.Now()
+	}
+	return time.Now()
+}
+
+// timeOf returns the time of an optional status timestamp, zero if unset
+func timeOf(t *metav1.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return t.Time
+}
+
+// optionalTime returns a status timestamp for t, nil if t is zero
+func optionalTime(t time.Time) *metav1.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return ptr(metav1.NewTime(t))
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *EnvironmentScheduleReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&catalystv1alpha1.EnvironmentSchedule{}).
+		Owns(&catalystv1alpha1.Environment{}).
+		Named("environment-schedule").
+		Complete(drainingReconciler{r})
+}
@@ -0,0 +1,151 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+func TestEnvironmentScheduleReconciler(t *testing.T) {
+	schedule := &catalystv1alpha1.EnvironmentSchedule{
+		ObjectMeta: metav1.ObjectMeta{Name: "nightly-qa", Namespace: "team"},
+		Spec: catalystv1alpha1.EnvironmentScheduleSpec{
+			Up:       "0 6 * * MON-FRI",
+			Down:     "0 20 * * MON-FRI",
+			TimeZone: "Europe/Berlin",
+			Template: catalystv1alpha1.EnvironmentScheduleTemplate{
+				Metadata: catalystv1alpha1.EnvironmentScheduleTemplateMetadata{Labels: map[string]string{"catalyst.dev/project": "my-project"}},
+				Spec: catalystv1alpha1.EnvironmentSpec{
+					ProjectRef: catalystv1alpha1.ProjectReference{Name: "my-project"},
+					Type:       "deployment",
+				},
+			},
+		},
+	}
+	c := newFakeClientBuilder().
+		WithStatusSubresource(&catalystv1alpha1.EnvironmentSchedule{}).
+		WithObjects(schedule).Build()
+
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	// Wednesday 2025-06-11
+	now := time.Date(2025, 6, 11, 5, 0, 0, 0, berlin)
+	recorder := record.NewFakeRecorder(10)
+	r := &EnvironmentScheduleReconciler{Client: c, Scheme: testScheme, Recorder: recorder, Now: func() time.Time { return now }}
+	ctx := context.Background()
+	key := client.ObjectKey{Name: "nightly-qa", Namespace: "team"}
+	reconcileSchedule := func() (ctrl.Result, *catalystv1alpha1.EnvironmentSchedule) {
+		result, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+		require.NoError(t, err)
+		got := &catalystv1alpha1.EnvironmentSchedule{}
+		require.NoError(t, c.Get(ctx, key, got))
+		return result, got
+	}
+	envExists := func() bool {
+		err := c.Get(ctx, key, &catalystv1alpha1.Environment{})
+		if apierrors.IsNotFound(err) {
+			return false
+		}
+		require.NoError(t, err)
+		return true
+	}
+
+	// Before the up time: nothing yet, requeued for 6am
+	result, got := reconcileSchedule()
+	assert.False(t, envExists())
+	assert.Equal(t, time.Hour+scheduleRequeueSkew, result.RequeueAfter)
+	require.NotNil(t, got.Status.NextUpTime)
+	assert.True(t, got.Status.NextUpTime.Time.Equal(time.Date(2025, 6, 11, 6, 0, 0, 0, berlin)))
+
+	// In the window: the Environment is created from the template and owned by the schedule
+	now = time.Date(2025, 6, 11, 6, 0, 1, 0, berlin)
+	result, got = reconcileSchedule()
+	env := &catalystv1alpha1.Environment{}
+	require.NoError(t, c.Get(ctx, key, env))
+	assert.Equal(t, "my-project", env.Labels["catalyst.dev/project"])
+	assert.Equal(t, "nightly-qa", env.Labels[scheduleLabel])
+	assert.Equal(t, "deployment", env.Spec.Type)
+	assert.True(t, metav1.IsControlledBy(env, got))
+	assert.Equal(t, "nightly-qa", got.Status.Active)
+	assert.Equal(t, "Active", meta.FindStatusCondition(got.Status.Conditions, conditionScheduleReady).Reason)
+	assert.InDelta(t, (14 * time.Hour).Seconds(), result.RequeueAfter.Seconds(), 1)
+	assert.Contains(t, <-recorder.Events, "Normal ScheduledUp Created Environment nightly-qa")
+
+	// Deleted by hand: stays down for the rest of the window
+	require.NoError(t, c.Delete(ctx, env))
+	now = now.Add(time.Hour)
+	_, got = reconcileSchedule()
+	assert.False(t, envExists())
+	assert.Empty(t, got.Status.Active)
+
+	// The next morning it comes up again and is deleted at the down time
+	now = time.Date(2025, 6, 12, 7, 0, 0, 0, berlin)
+	reconcileSchedule()
+	assert.True(t, envExists())
+	now = time.Date(2025, 6, 12, 20, 0, 0, 0, berlin)
+	_, got = reconcileSchedule()
+	assert.False(t, envExists())
+	require.NotNil(t, got.Status.LastDownTime)
+	<-recorder.Events
+	assert.Contains(t, <-recorder.Events, "Normal ScheduledDown Deleted Environment nightly-qa")
+
+	// Holidays listed in the annotation are skipped
+	got.Annotations = map[string]string{scheduleSkipDatesAnnotation: "2025-06-12, 2025-06-13"}
+	require.NoError(t, c.Update(ctx, got))
+	now = time.Date(2025, 6, 13, 6, 5, 0, 0, berlin)
+	_, got = reconcileSchedule()
+	assert.False(t, envExists())
+	assert.Equal(t, "Skipped", meta.FindStatusCondition(got.Status.Conditions, conditionScheduleReady).Reason)
+
+	// Past the starting deadline (e.g. the operator was down) the window is skipped
+	got.Spec.StartingDeadline = &metav1.Duration{Duration: 30 * time.Minute}
+	require.NoError(t, c.Update(ctx, got))
+	now = time.Date(2025, 6, 16, 7, 0, 0, 0, berlin)
+	_, got = reconcileSchedule()
+	assert.False(t, envExists())
+	assert.Contains(t, meta.FindStatusCondition(got.Status.Conditions, conditionScheduleReady).Message, "starting deadline")
+}
+
+func TestEnvironmentScheduleReconciler_Invalid(t *testing.T) {
+	schedule := &catalystv1alpha1.EnvironmentSchedule{
+		ObjectMeta: metav1.ObjectMeta{Name: "broken", Namespace: "team"},
+		Spec:       catalystv1alpha1.EnvironmentScheduleSpec{Up: "0 6 * *", Down: "0 20 * * *"},
+	}
+	// An Environment of the same name not created by the schedule is left alone
+	other := &catalystv1alpha1.Environment{ObjectMeta: metav1.ObjectMeta{Name: "taken", Namespace: "team"}}
+	taken := &catalystv1alpha1.EnvironmentSchedule{
+		ObjectMeta: metav1.ObjectMeta{Name: "taken", Namespace: "team"},
+		Spec:       catalystv1alpha1.EnvironmentScheduleSpec{Up: "@daily", Down: "0 23 * * *"},
+	}
+	c := newFakeClientBuilder().
+		WithStatusSubresource(&catalystv1alpha1.EnvironmentSchedule{}).
+		WithObjects(schedule, other, taken).Build()
+	r := &EnvironmentScheduleReconciler{Client: c, Scheme: testScheme, Now: func() time.Time {
+		return time.Date(2025, 6, 11, 12, 0, 0, 0, time.UTC)
+	}}
+	ctx := context.Background()
+
+	for name, reason := range map[string]string{"broken": "InvalidSchedule", "taken": "Conflict"} {
+		result, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKey{Name: name, Namespace: "team"}})
+		require.NoError(t, err)
+		assert.Zero(t, result.RequeueAfter)
+		got := &catalystv1alpha1.EnvironmentSchedule{}
+		require.NoError(t, c.Get(ctx, client.ObjectKey{Name: name, Namespace: "team"}, got))
+		condition := meta.FindStatusCondition(got.Status.Conditions, conditionScheduleReady)
+		require.NotNil(t, condition)
+		assert.Equal(t, metav1.ConditionFalse, condition.Status)
+		assert.Equal(t, reason, condition.Reason)
+	}
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "taken", Namespace: "team"}, &catalystv1alpha1.Environment{}))
+}
@@ -0,0 +1,64 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+)
+
+// Events:
+// The controllers emit a Normal Event for each significant transition of the objects they
+// manage and a Warning Event for each failure, so `kubectl describe` shows what happened
+// without the operator logs. Failures use their status.failureReason as the event reason.
+
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+const (
+	eventNamespaceCreated    = "NamespaceCreated"
+	eventBuildStarted        = "BuildStarted"
+	eventBuildSucceeded      = "BuildSucceeded"
+	eventHelmInstalled       = "HelmInstalled"
+	eventHelmUpgraded        = "HelmUpgraded"
+	eventIngressCreated      = "IngressCreated"
+	eventURLReady            = "URLReady"
+	eventTeardownStarted     = "TeardownStarted"
+	eventExpired             = "Expired"
+	eventScheduledUp         = "ScheduledUp"
+	eventScheduledDown       = "ScheduledDown"
+	eventDriftDetected       = "DriftDetected"
+	eventPreDeleteHookFailed = "PreDeleteHookFailed"
+	eventPromoted            = "Promoted"
+	eventRolledBack          = "RolledBack"
+	eventRollbackRejected    = "RollbackRejected"
+	eventVolumeExpanding     = "VolumeExpanding"
+	eventRendered            = "Rendered"
+	eventRenderFailed        = "RenderFailed"
+	eventDebugStarted        = "DebugStarted"
+	eventDebugRejected       = "DebugRejected"
+	eventPrebuildSucceeded   = "PrebuildSucceeded"
+	eventPrebuildFailed      = "PrebuildFailed"
+	eventIngressHostConflict = "IngressHostConflict"
+)
+
+// recordEvent emits an Event on obj. A nil recorder records none.
+func recordEvent(recorder record.EventRecorder, obj runtime.Object, eventType, reason, messageFmt string, args ...any) {
+	if recorder == nil {
+		return
+	}
+	recorder.Eventf(obj, eventType, reason, messageFmt, args...)
+}
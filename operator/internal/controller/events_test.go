@@ -0,0 +1,34 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+func TestRecordEvent(t *testing.T) {
+	env := &catalystv1alpha1.Environment{ObjectMeta: metav1.ObjectMeta{Name: "pr-1", Namespace: "team"}}
+
+	// A nil recorder records nothing
+	recordEvent(nil, env, corev1.EventTypeNormal, eventNamespaceCreated, "Created namespace %s", "team-shop-pr-1")
+
+	recorder := record.NewFakeRecorder(2)
+	recordEvent(recorder, env, corev1.EventTypeNormal, eventNamespaceCreated, "Created namespace %s", "team-shop-pr-1")
+	assert.Equal(t, "Normal NamespaceCreated Created namespace team-shop-pr-1", <-recorder.Events)
+
+	// Failure messages are passed through verbatim
+	recordEvent(recorder, env, corev1.EventTypeWarning, catalystv1alpha1.FailureReasonBuildFailed, "%s", "quota 100% used")
+	assert.Equal(t, "Warning BuildFailed quota 100% used", <-recorder.Events)
+}
+
+func TestMarkTeardownStarted(t *testing.T) {
+	env := &catalystv1alpha1.Environment{Status: catalystv1alpha1.EnvironmentStatus{Phase: "Ready"}}
+	assert.True(t, markTeardownStarted(env))
+	assert.Equal(t, phaseDeleting, env.Status.Phase)
+	assert.False(t, markTeardownStarted(env), "reported once")
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+// failureError attaches a status.failureReason to an error
+type failureError struct {
+	reason string
+	err    error
+}
+
+func (e *failureError) Error() string { return e.err.Error() }
+func (e *failureError) Unwrap() error { return e.err }
+
+// withFailureReason marks err as a failure of the given reason. Nil stays nil.
+func withFailureReason(reason string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &failureError{reason: reason, err: err}
+}
+
+// failureReasonOf returns the reason err was marked with, or fallback. Quota rejections
+// win over any mark: "exceeded quota" is the actionable part of e.g. a failed Helm install.
+func failureReasonOf(err error, fallback string) string {
+	if isQuotaExceeded(err) {
+		return catalystv1alpha1.FailureReasonQuotaExceeded
+	}
+	var marked *failureError
+	if errors.As(err, &marked) {
+		return marked.reason
+	}
+	return fallback
+}
+
+// isQuotaExceeded reports whether err is a ResourceQuota admission rejection. Helm and
+// kustomize flatten API errors into text, so the message is matched as well.
+func isQuotaExceeded(err error) bool {
+	if err == nil {
+		return false
+	}
+	return (apierrors.IsForbidden(err) || strings.Contains(err.Error(), "forbidden")) &&
+		strings.Contains(err.Error(), "exceeded quota")
+}
+
+// setFailed moves the environment to phase Failed with reason and message, and reports
+// whether the status changed. A Warning Event is emitted for every new failure.
+func (r *EnvironmentReconciler) setFailed(env *catalystv1alpha1.Environment, reason, message string) bool {
+	outcomeChanged := recordDeploymentOutcome(env, catalystv1alpha1.DeploymentOutcomeFailed, metav1.Now().Rfc3339Copy())
+	if env.Status.Phase == "Failed" && env.Status.FailureReason == reason && env.Status.Message == message {
+		return outcomeChanged
+	}
+	env.Status.Phase = "Failed"
+	env.Status.FailureReason = reason
+	env.Status.Message = message
+	recordEvent(r.Recorder, env, corev1.EventTypeWarning, reason, "%s", message)
+	return true
+}
+
+// markFailed records a failed reconcile of env in its status, classifying err with
+// fallback when it carries no reason of its own
+func (r *EnvironmentReconciler) markFailed(ctx context.Context, env *catalystv1alpha1.Environment, fallback string, err error) error {
+	if !r.setFailed(env, failureReasonOf(err, fallback), err.Error()) {
+		return nil
+	}
+	return r.Status().Update(ctx, env)
+}
+
+// clearFailure drops the failure details once the environment left phase Failed.
+// Reports whether anything was cleared.
+func clearFailure(env *catalystv1alpha1.Environment) bool {
+	if env.Status.Phase == "Failed" || (env.Status.FailureReason == "" && env.Status.Message == "") {
+		return false
+	}
+	env.Status.FailureReason = ""
+	env.Status.Message = ""
+	return true
+}
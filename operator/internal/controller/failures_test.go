@@ -0,0 +1,59 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+func TestFailureReasonOf(t *testing.T) {
+	clone := withFailureReason(catalystv1alpha1.FailureReasonSourceCloneFailed, errors.New("failed to clone repo: authentication required"))
+	assert.Equal(t, catalystv1alpha1.FailureReasonSourceCloneFailed, failureReasonOf(fmt.Errorf("prepare: %w", clone), catalystv1alpha1.FailureReasonHelmInstallFailed))
+	assert.Equal(t, catalystv1alpha1.FailureReasonHelmInstallFailed, failureReasonOf(errors.New("timed out waiting for the condition"), catalystv1alpha1.FailureReasonHelmInstallFailed))
+
+	forbidden := apierrors.NewForbidden(schema.GroupResource{Resource: "pods"}, "web", errors.New("exceeded quota: default-quota, requested: limits.cpu=8"))
+	assert.Equal(t, catalystv1alpha1.FailureReasonQuotaExceeded, failureReasonOf(forbidden, catalystv1alpha1.FailureReasonDeploymentFailed))
+	// Helm flattens the API error into text
+	helmErr := errors.New(`pods "web" is forbidden: exceeded quota: default-quota`)
+	assert.Equal(t, catalystv1alpha1.FailureReasonQuotaExceeded, failureReasonOf(helmErr, catalystv1alpha1.FailureReasonHelmInstallFailed))
+
+	assert.NoError(t, withFailureReason(catalystv1alpha1.FailureReasonBuildFailed, nil))
+}
+
+func TestMarkFailed(t *testing.T) {
+	env := &catalystv1alpha1.Environment{ObjectMeta: metav1.ObjectMeta{Name: "pr-1", Namespace: "team"}}
+	c := newFakeClientBuilder().WithStatusSubresource(env).WithObjects(env).Build()
+	recorder := record.NewFakeRecorder(10)
+	r := &EnvironmentReconciler{Client: c, Scheme: testScheme, Recorder: recorder}
+	ctx := context.Background()
+
+	buildErr := withFailureReason(catalystv1alpha1.FailureReasonBuildFailed, errors.New("build job failed: build-web"))
+	require.NoError(t, r.markFailed(ctx, env, catalystv1alpha1.FailureReasonDeploymentFailed, buildErr))
+	got := &catalystv1alpha1.Environment{}
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(env), got))
+	assert.Equal(t, "Failed", got.Status.Phase)
+	assert.Equal(t, catalystv1alpha1.FailureReasonBuildFailed, got.Status.FailureReason)
+	assert.Equal(t, "build job failed: build-web", got.Status.Message)
+	assert.Equal(t, "Warning BuildFailed build job failed: build-web", <-recorder.Events)
+
+	// The same failure again is neither written nor reported twice
+	require.NoError(t, r.markFailed(ctx, env, catalystv1alpha1.FailureReasonDeploymentFailed, buildErr))
+	assert.Empty(t, recorder.Events)
+
+	env.Status.Phase = "Ready"
+	assert.True(t, clearFailure(env))
+	assert.Empty(t, env.Status.FailureReason)
+	assert.Empty(t, env.Status.Message)
+	assert.False(t, clearFailure(env))
+}
@@ -0,0 +1,294 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+	"github.com/ncrmro/catalyst/operator/internal/operatorconfig"
+)
+
+// File sync (config.fileSync, development mode):
+// Instead of the git clone, an rsync daemon sidecar receives the files a developer pushes
+// into the code volume. rsync speaks its daemon protocol over plain TCP, so a websocat
+// sidecar tunnels it through a WebSocket the preview hosts route at /.catalyst/sync. The
+// daemon only listens on localhost and authenticates with a generated password.
+// Images are configurable with FILE_SYNC_IMAGE (rsync) and FILE_SYNC_TUNNEL_IMAGE (websocat).
+
+const (
+	fileSyncName = "file-sync"
+	fileSyncPath = "/.catalyst/sync"
+	// fileSyncPort is the WebSocket port of the tunnel, fileSyncRsyncPort the daemon's
+	fileSyncPort      = 8874
+	fileSyncRsyncPort = 8873
+	fileSyncUser      = "catalyst"
+
+	defaultFileSyncImage       = "eeacms/rsync:2.6"
+	defaultFileSyncTunnelImage = "ghcr.io/vi/websocat:1.14.0"
+)
+
+// fileSyncScript writes the daemon configuration, with the code module at FILE_SYNC_PATH,
+// and runs the daemon in the foreground
+var fileSyncScript = fmt.Sprintf(`cat > /tmp/rsyncd.conf <<CONF
+use chroot = no
+address = 127.0.0.1
+port = %d
+pid file = /tmp/rsyncd.pid
+log file = /dev/stdout
+[code]
+path = $FILE_SYNC_PATH
+read only = no
+auth users = %s
+secrets file = /etc/rsyncd/rsyncd.secrets
+strict modes = no
+exclude = $FILE_SYNC_EXCLUDE
+CONF
+exec rsync --daemon --no-detach --config=/tmp/rsyncd.conf
+`, fileSyncRsyncPort, fileSyncUser)
+
+// fileSyncConfig returns the file sync configuration of a development environment, nil if
+// it has none. The environment's config.fileSync replaces the template's.
+func fileSyncConfig(env *catalystv1alpha1.Environment, envTemplate *catalystv1alpha1.EnvironmentTemplateSpec) *catalystv1alpha1.FileSyncConfig {
+	if resolveDeploymentMode(env, envTemplate) != "development" {
+		return nil
+	}
+	if env.Spec.Config.FileSync != nil {
+		return env.Spec.Config.FileSync
+	}
+	if envTemplate != nil && envTemplate.Config != nil {
+		return envTemplate.Config.FileSync
+	}
+	return nil
+}
+
+// applyFileSync adds the rsync daemon and WebSocket tunnel sidecars syncing into the code volume
+func applyFileSync(spec *corev1.PodSpec, cfg *catalystv1alpha1.FileSyncConfig, codeVolumeName, codeMountPath string) {
+	images := operatorconfig.Current().Images
+	image, tunnelImage := images.FileSync, images.FileSyncTunnel
+	if image == "" {
+		image = defaultFileSyncImage
+	}
+	if tunnelImage == "" {
+		tunnelImage = defaultFileSyncTunnelImage
+	}
+	resources := corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("10m"),
+			corev1.ResourceMemory: resource.MustParse("32Mi"),
+		},
+		Limits: corev1.ResourceList{
+			corev1.ResourceMemory: resource.MustParse("256Mi"),
+		},
+	}
+	spec.Containers = append(spec.Containers,
+		corev1.Container{
+			Name:    fileSyncName,
+			Image:   image,
+			Command: []string{"sh", "-c", fileSyncScript},
+			Env: []corev1.EnvVar{
+				{Name: "FILE_SYNC_PATH", Value: codeMountPath},
+				{Name: "FILE_SYNC_EXCLUDE", Value: strings.Join(cfg.Exclude, " ")},
+			},
+			VolumeMounts: []corev1.VolumeMount{
+				{Name: codeVolumeName, MountPath: codeMountPath},
+				{Name: fileSyncName, MountPath: "/etc/rsyncd", ReadOnly: true},
+			},
+			Resources: resources,
+		},
+		corev1.Container{
+			Name:  fileSyncName + "-tunnel",
+			Image: tunnelImage,
+			Args: []string{
+				"--binary",
+				fmt.Sprintf("ws-l:0.0.0.0:%d", fileSyncPort),
+				fmt.Sprintf("tcp:127.0.0.1:%d", fileSyncRsyncPort),
+			},
+			Ports: []corev1.ContainerPort{
+				{Name: fileSyncName, ContainerPort: fileSyncPort, Protocol: corev1.ProtocolTCP},
+			},
+			Resources: resources,
+		},
+	)
+	spec.Volumes = append(spec.Volumes, corev1.Volume{
+		Name: fileSyncName,
+		VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{
+				SecretName: fileSyncName,
+				Items:      []corev1.KeyToPath{{Key: "rsyncd.secrets", Path: "rsyncd.secrets"}},
+				// Readable through the pod's fsGroup
+				DefaultMode: int32Ptr(0440),
+			},
+		},
+	})
+}
+
+// desiredFileSyncSecret creates the daemon's secrets file with a random password
+func desiredFileSyncSecret(namespace string) (*corev1.Secret, error) {
+	password, err := generateGatewayToken()
+	if err != nil {
+		return nil, err
+	}
+	password = password[:24]
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fileSyncName,
+			Namespace: namespace,
+		},
+		Type: corev1.SecretTypeOpaque,
+		StringData: map[string]string{
+			"username":       fileSyncUser,
+			"password":       password,
+			"rsyncd.secrets": fileSyncUser + ":" + password + "\n",
+		},
+	}, nil
+}
+
+// desiredFileSyncService exposes the tunnel of the web pods
+func desiredFileSyncService(namespace string) *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fileSyncName,
+			Namespace: namespace,
+		},
+		Spec: corev1.ServiceSpec{
+			Selector: map[string]string{"app": "web"},
+			Ports: []corev1.ServicePort{
+				{
+					Name:       fileSyncName,
+					Port:       fileSyncPort,
+					TargetPort: intstr.FromString(fileSyncName),
+					Protocol:   corev1.ProtocolTCP,
+				},
+			},
+		},
+	}
+}
+
+// desiredFileSyncIngress routes /.catalyst/sync of the preview hosts to the tunnel
+func desiredFileSyncIngress(env *catalystv1alpha1.Environment, namespace string, hosts []string, tls *previewTLS) *networkingv1.Ingress {
+	pathType := networkingv1.PathTypePrefix
+	ingress := &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fileSyncName,
+			Namespace: namespace,
+			Labels:    map[string]string{"catalyst.dev/environment": sanitizeLabelValue(env.Name)},
+			Annotations: map[string]string{
+				// Keep idle sync sessions open
+				"nginx.ingress.kubernetes.io/proxy-read-timeout": "3600",
+				"nginx.ingress.kubernetes.io/proxy-send-timeout": "3600",
+			},
+		},
+	}
+	for _, host := range hosts {
+		ingress.Spec.Rules = append(ingress.Spec.Rules, networkingv1.IngressRule{
+			Host: host,
+			IngressRuleValue: networkingv1.IngressRuleValue{
+				HTTP: &networkingv1.HTTPIngressRuleValue{
+					Paths: []networkingv1.HTTPIngressPath{{
+						Path:     fileSyncPath,
+						PathType: &pathType,
+						Backend: networkingv1.IngressBackend{
+							Service: &networkingv1.IngressServiceBackend{
+								Name: fileSyncName,
+								Port: networkingv1.ServiceBackendPort{Number: fileSyncPort},
+							},
+						},
+					}},
+				},
+			},
+		})
+	}
+	tls.applyTo(ingress)
+	return ingress
+}
+
+// fileSyncURL returns the WebSocket URL of the tunnel behind a preview URL
+func fileSyncURL(previewURL string) string {
+	previewURL = strings.TrimSuffix(previewURL, "/")
+	if rest, ok := strings.CutPrefix(previewURL, "https://"); ok {
+		return "wss://" + rest + fileSyncPath
+	}
+	return "ws://" + strings.TrimPrefix(previewURL, "http://") + fileSyncPath
+}
+
+// reconcileFileSync provisions the password Secret, Service and Ingress of the file sync
+// endpoint for the given preview hosts and records it in status.fileSync, or removes them
+// once the environment no longer syncs files. The sidecars are part of the web Deployment.
+func (r *EnvironmentReconciler) reconcileFileSync(ctx context.Context, env *catalystv1alpha1.Environment, envTemplate *catalystv1alpha1.EnvironmentTemplateSpec, namespace, routing string, hosts []string, tls *previewTLS, ingressSpec *catalystv1alpha1.EnvironmentIngress) error {
+	log := logf.FromContext(ctx)
+	if fileSyncConfig(env, envTemplate) == nil {
+		if env.Status.FileSync == nil {
+			return nil
+		}
+		for _, obj := range []client.Object{&networkingv1.Ingress{}, &corev1.Service{}, &corev1.Secret{}} {
+			obj.SetName(fileSyncName)
+			obj.SetNamespace(namespace)
+			if err := r.Delete(ctx, obj); client.IgnoreNotFound(err) != nil {
+				return fmt.Errorf("failed to delete file sync endpoint: %w", err)
+			}
+		}
+		env.Status.FileSync = nil
+		return r.Status().Update(ctx, env)
+	}
+
+	// The password is kept across reconciles
+	existing := &corev1.Secret{}
+	if err := r.Get(ctx, client.ObjectKey{Name: fileSyncName, Namespace: namespace}, existing); apierrors.IsNotFound(err) {
+		secret, err := desiredFileSyncSecret(namespace)
+		if err != nil {
+			return err
+		}
+		log.Info("Creating file sync Secret", "namespace", namespace)
+		if err := r.Create(ctx, secret); err != nil && !isAlreadyExists(err) {
+			return fmt.Errorf("failed to create file sync Secret: %w", err)
+		}
+	} else if err != nil {
+		return err
+	}
+	if err := r.Create(ctx, desiredFileSyncService(namespace)); err != nil && !isAlreadyExists(err) {
+		return fmt.Errorf("failed to create file sync Service: %w", err)
+	}
+
+	status := &catalystv1alpha1.FileSyncStatus{SecretName: fileSyncName}
+	if routing == routingGateway {
+		log.Info("File sync endpoint requires Ingress routing; not exposed", "namespace", namespace)
+	} else {
+		ingress := desiredFileSyncIngress(env, namespace, hosts, tls)
+		r.applyIngressClass(ingress, ingressSpec)
+		if err := r.patchOrUpdate(ctx, ingress); err != nil {
+			return fmt.Errorf("failed to reconcile file sync Ingress: %w", err)
+		}
+		status.URL = fileSyncURL(env.Status.URL)
+	}
+	if env.Status.FileSync == nil || *env.Status.FileSync != *status {
+		env.Status.FileSync = status
+		return r.Status().Update(ctx, env)
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+func TestDesiredDevelopmentDeploymentFromConfig_FileSync(t *testing.T) {
+	env := &catalystv1alpha1.Environment{
+		Spec: catalystv1alpha1.EnvironmentSpec{ProjectRef: catalystv1alpha1.ProjectReference{Name: "shop"}},
+	}
+	project := &catalystv1alpha1.Project{
+		Spec: catalystv1alpha1.ProjectSpec{
+			Sources: []catalystv1alpha1.SourceConfig{{Name: "primary", RepositoryURL: "https://github.com/acme/shop"}},
+		},
+	}
+	config := &catalystv1alpha1.EnvironmentConfig{
+		Image:        "node:22-slim",
+		VolumeMounts: []corev1.VolumeMount{{Name: "code", MountPath: "/app"}},
+		FileSync:     &catalystv1alpha1.FileSyncConfig{Exclude: []string{"node_modules", ".next"}},
+	}
+
+	spec := desiredDevelopmentDeploymentFromConfig(env, project, "env-ns", config).Spec.Template.Spec
+	assert.Empty(t, spec.InitContainers, "the code is pushed instead of cloned")
+	require.Len(t, spec.Containers, 3)
+	rsync := spec.Containers[1]
+	assert.Contains(t, rsync.Env, corev1.EnvVar{Name: "FILE_SYNC_PATH", Value: "/app"})
+	assert.Contains(t, rsync.Env, corev1.EnvVar{Name: "FILE_SYNC_EXCLUDE", Value: "node_modules .next"})
+	assert.True(t, hasMountPath(rsync.VolumeMounts, "/app"))
+	assert.True(t, hasMountPath(rsync.VolumeMounts, "/tmp"), "the daemon writes its configuration to /tmp")
+	assert.Equal(t, []string{"--binary", "ws-l:0.0.0.0:8874", "tcp:127.0.0.1:8873"}, spec.Containers[2].Args)
+	assert.True(t, hasVolume(spec.Volumes, fileSyncName))
+
+	config.FileSync.Clone = true
+	spec = desiredDevelopmentDeploymentFromConfig(env, project, "env-ns", config).Spec.Template.Spec
+	require.Len(t, spec.InitContainers, 1)
+	assert.Equal(t, "git-clone", spec.InitContainers[0].Name)
+}
+
+func TestReconcileFileSync(t *testing.T) {
+	env := &catalystv1alpha1.Environment{
+		ObjectMeta: metav1.ObjectMeta{Name: "dev-ana", Namespace: "team"},
+		Spec: catalystv1alpha1.EnvironmentSpec{
+			Type:   "development",
+			Config: catalystv1alpha1.EnvironmentConfig{FileSync: &catalystv1alpha1.FileSyncConfig{}},
+		},
+		Status: catalystv1alpha1.EnvironmentStatus{URL: "https://dev-ana.preview.example.com"},
+	}
+	c := newFakeClientBuilder().WithStatusSubresource(env).WithObjects(env).Build()
+	r := &EnvironmentReconciler{Client: c, Scheme: testScheme}
+	ctx := context.Background()
+	hosts := []string{"dev-ana.preview.example.com"}
+
+	// The generated password survives reconciles
+	require.NoError(t, r.reconcileFileSync(ctx, env, nil, "env-ns", routingIngress, hosts, nil, nil))
+	secret := &corev1.Secret{}
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: fileSyncName, Namespace: "env-ns"}, secret))
+	password := secret.StringData["password"]
+	assert.Equal(t, "catalyst:"+password+"\n", secret.StringData["rsyncd.secrets"])
+	require.NoError(t, r.reconcileFileSync(ctx, env, nil, "env-ns", routingIngress, hosts, nil, nil))
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(secret), secret))
+	assert.Equal(t, password, secret.StringData["password"])
+
+	ingress := &networkingv1.Ingress{}
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: fileSyncName, Namespace: "env-ns"}, ingress))
+	assert.Equal(t, fileSyncPath, ingress.Spec.Rules[0].HTTP.Paths[0].Path)
+	assert.Equal(t, hosts[0], ingress.Spec.Rules[0].Host)
+	assert.Equal(t, &catalystv1alpha1.FileSyncStatus{
+		URL:        "wss://dev-ana.preview.example.com/.catalyst/sync",
+		SecretName: fileSyncName,
+	}, env.Status.FileSync)
+
+	// Turning file sync off removes the endpoint
+	env.Spec.Config.FileSync = nil
+	require.NoError(t, r.reconcileFileSync(ctx, env, nil, "env-ns", routingIngress, hosts, nil, nil))
+	assert.True(t, apierrors.IsNotFound(c.Get(ctx, client.ObjectKeyFromObject(ingress), ingress)))
+	assert.True(t, apierrors.IsNotFound(c.Get(ctx, client.ObjectKeyFromObject(secret), secret)))
+	assert.Nil(t, env.Status.FileSync)
+}
+
+func TestFileSyncURL(t *testing.T) {
+	assert.Equal(t, "ws://dev.localhost:8080/.catalyst/sync", fileSyncURL("http://dev.localhost:8080/"))
+	assert.Equal(t, "wss://dev.example.com/.catalyst/sync", fileSyncURL("https://dev.example.com"))
+}
@@ -0,0 +1,122 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+// frameworkPreset is the development server of a framework a config framework expands into
+type frameworkPreset struct {
+	image   string
+	port    int32
+	command []string
+	// env polls for file changes where the watcher needs telling: inotify events do not
+	// cross file sync and most network volumes
+	env []corev1.EnvVar
+}
+
+// frameworkPresets are the framework presets by name
+var frameworkPresets = map[string]frameworkPreset{
+	"nextjs": {
+		image:   "node:22-slim",
+		port:    3000,
+		command: []string{"./node_modules/.bin/next", "dev", "--turbopack", "--hostname", "0.0.0.0", "--port", "3000"},
+		env: []corev1.EnvVar{
+			{Name: "WATCHPACK_POLLING", Value: "true"},
+			{Name: "NEXT_TELEMETRY_DISABLED", Value: "1"},
+		},
+	},
+	"vite": {
+		image:   "node:22-slim",
+		port:    5173,
+		command: []string{"./node_modules/.bin/vite", "--host", "0.0.0.0", "--port", "5173", "--strictPort"},
+		env:     []corev1.EnvVar{{Name: "CHOKIDAR_USEPOLLING", Value: "true"}},
+	},
+	"django": {
+		image:   "python:3.12-slim",
+		port:    8000,
+		command: []string{"python", "manage.py", "runserver", "0.0.0.0:8000"},
+		env:     []corev1.EnvVar{{Name: "PYTHONUNBUFFERED", Value: "1"}},
+	},
+	"rails": {
+		image:   "ruby:3.3-slim",
+		port:    3000,
+		command: []string{"bin/rails", "server", "--binding", "0.0.0.0", "--port", "3000"},
+		env: []corev1.EnvVar{
+			{Name: "RAILS_ENV", Value: "development"},
+			{Name: "RAILS_LOG_TO_STDOUT", Value: "true"},
+		},
+	},
+	"go-air": {
+		image:   "cosmtrek/air:v1.61.1",
+		port:    8080,
+		command: []string{"air", "--build.poll", "true"},
+		env:     []corev1.EnvVar{{Name: "PORT", Value: "8080"}},
+	},
+	"dotnet-watch": {
+		image:   "mcr.microsoft.com/dotnet/sdk:8.0",
+		port:    8080,
+		command: []string{"dotnet", "watch", "run", "--no-launch-profile"},
+		env: []corev1.EnvVar{
+			{Name: "ASPNETCORE_URLS", Value: "http://0.0.0.0:8080"},
+			{Name: "DOTNET_USE_POLLING_FILE_WATCHER", Value: "true"},
+			{Name: "DOTNET_WATCH_RESTART_ON_RUDE_EDIT", Value: "true"},
+			{Name: "DOTNET_CLI_TELEMETRY_OPTOUT", Value: "1"},
+		},
+	},
+}
+
+// expandFrameworkPreset fills in the fields of config its framework preset sets and config
+// leaves unset, and appends the preset env vars config does not set. The command applies
+// to configs setting neither command nor args. HOME moves to the writable /tmp of the
+// read-only root filesystem, for the caches of package managers and compilers. The probes
+// are TCP on the first port, dev servers compiling on the first request. A config without
+// a known framework is left as is.
+func expandFrameworkPreset(config *catalystv1alpha1.EnvironmentConfig) {
+	preset, ok := frameworkPresets[config.Framework]
+	if !ok {
+		return
+	}
+	if config.Image == "" {
+		config.Image = preset.image
+	}
+	if len(config.Command) == 0 && len(config.Args) == 0 {
+		config.Command = slices.Clone(preset.command)
+	}
+	if len(config.Ports) == 0 {
+		config.Ports = []corev1.ContainerPort{{Name: "http", ContainerPort: preset.port, Protocol: corev1.ProtocolTCP}}
+	}
+	for _, e := range append([]corev1.EnvVar{{Name: "HOME", Value: "/tmp"}}, preset.env...) {
+		if !hasEnvVar(config.Env, e.Name) {
+			config.Env = append(config.Env, e)
+		}
+	}
+	port := corev1.ProbeHandler{TCPSocket: &corev1.TCPSocketAction{Port: intstr.FromInt32(config.Ports[0].ContainerPort)}}
+	if config.ReadinessProbe == nil {
+		config.ReadinessProbe = &corev1.Probe{ProbeHandler: port, PeriodSeconds: 5}
+	}
+	if config.StartupProbe == nil {
+		// Installs and first builds take minutes
+		config.StartupProbe = &corev1.Probe{ProbeHandler: *port.DeepCopy(), PeriodSeconds: 5, FailureThreshold: 120}
+	}
+}
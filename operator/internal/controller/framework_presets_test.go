@@ -0,0 +1,56 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+func TestExpandFrameworkPreset(t *testing.T) {
+	config := catalystv1alpha1.EnvironmentConfig{Framework: "vite"}
+	expandFrameworkPreset(&config)
+	assert.Equal(t, "node:22-slim", config.Image)
+	assert.Equal(t, []string{"./node_modules/.bin/vite", "--host", "0.0.0.0", "--port", "5173", "--strictPort"}, config.Command)
+	require.Len(t, config.Ports, 1)
+	assert.Equal(t, int32(5173), config.Ports[0].ContainerPort)
+	assert.Equal(t, []corev1.EnvVar{{Name: "HOME", Value: "/tmp"}, {Name: "CHOKIDAR_USEPOLLING", Value: "true"}}, config.Env)
+	assert.Equal(t, int32(5173), config.ReadinessProbe.TCPSocket.Port.IntVal)
+	assert.Equal(t, int32(120), config.StartupProbe.FailureThreshold)
+	require.NoError(t, validateConfig(&config), "a framework alone is a complete config")
+
+	// Fields the config sets win; env vars merge by name and probes follow the port
+	config = catalystv1alpha1.EnvironmentConfig{
+		Framework: "django",
+		Image:     "ghcr.io/acme/api-dev:1",
+		Args:      []string{"--settings", "dev"},
+		Ports:     []corev1.ContainerPort{{Name: "http", ContainerPort: 9000}},
+		Env:       []corev1.EnvVar{{Name: "PYTHONUNBUFFERED", Value: "0"}},
+	}
+	expandFrameworkPreset(&config)
+	assert.Equal(t, "ghcr.io/acme/api-dev:1", config.Image)
+	assert.Empty(t, config.Command, "args without a command run the image entrypoint")
+	assert.Equal(t, []corev1.EnvVar{{Name: "PYTHONUNBUFFERED", Value: "0"}, {Name: "HOME", Value: "/tmp"}}, config.Env)
+	assert.Equal(t, int32(9000), config.ReadinessProbe.TCPSocket.Port.IntVal)
+
+	// Without a framework nothing is assumed
+	config = catalystv1alpha1.EnvironmentConfig{Image: "ghcr.io/acme/web:1"}
+	expandFrameworkPreset(&config)
+	assert.Equal(t, catalystv1alpha1.EnvironmentConfig{Image: "ghcr.io/acme/web:1"}, config)
+
+	for name, preset := range frameworkPresets {
+		config := catalystv1alpha1.EnvironmentConfig{Framework: name}
+		expandFrameworkPreset(&config)
+		assert.NoError(t, validateConfig(&config), name)
+		assert.NotEmpty(t, preset.command, name)
+	}
+}
+
+func TestResolveConfigFramework(t *testing.T) {
+	template := &catalystv1alpha1.EnvironmentConfig{Framework: "nextjs"}
+	assert.Equal(t, "nextjs", resolveConfig(&catalystv1alpha1.EnvironmentConfig{}, template).Framework)
+	assert.Equal(t, "rails", resolveConfig(&catalystv1alpha1.EnvironmentConfig{Framework: "rails"}, template).Framework)
+}
@@ -0,0 +1,273 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+	"github.com/ncrmro/catalyst/operator/internal/operatorconfig"
+)
+
+// Gateway API routing (PREVIEW_ROUTING=gateway, or Project spec.routing: gateway):
+// Environments are exposed with HTTPRoutes attached to a shared Gateway (GATEWAY_NAME in
+// GATEWAY_NAMESPACE, default "catalyst-preview" in the operator namespace) instead of
+// Ingresses, for clusters without an ingress controller (e.g. Envoy Gateway).
+// When GATEWAY_CLASS is set the operator also manages that Gateway: an HTTP listener plus an
+// HTTPS listener per preview base domain. With GATEWAY_CLUSTER_ISSUER the Gateway carries the
+// cert-manager.io/cluster-issuer annotation, so cert-manager issues the wildcard certificates
+// (which requires a DNS-01 solver). Without it, the listener Secrets must be provided.
+
+const (
+	routingIngress = "ingress"
+	routingGateway = "gateway"
+
+	defaultPreviewGatewayName = "catalyst-preview"
+	// certManagerClusterIssuerAnnotation makes cert-manager issue certificates for Gateway listeners
+	certManagerClusterIssuerAnnotation = "cert-manager.io/cluster-issuer"
+)
+
+// +kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=gateways,verbs=get;list;watch;create;update;patch
+
+var gatewayGVK = schema.GroupVersionKind{Group: "gateway.networking.k8s.io", Version: "v1", Kind: "Gateway"}
+
+// previewRouting returns the Project routing mode, else preview.routing, else ingress
+func previewRouting(project *catalystv1alpha1.Project) string {
+	if project != nil && project.Spec.Routing != "" {
+		return project.Spec.Routing
+	}
+	if operatorconfig.Current().Preview.Routing == routingGateway {
+		return routingGateway
+	}
+	return routingIngress
+}
+
+// previewGateway is the shared Gateway preview HTTPRoutes attach to
+type previewGateway struct {
+	Name      string
+	Namespace string
+	// Class is the GatewayClass of an operator-managed Gateway; empty if managed externally
+	Class string
+	// ClusterIssuer is the cert-manager ClusterIssuer for the HTTPS listeners
+	ClusterIssuer string
+}
+
+// currentPreviewGateway returns the gateway section of the operator configuration
+func currentPreviewGateway() previewGateway {
+	cfg := operatorconfig.Current().Gateway
+	gw := previewGateway{
+		Name:          cfg.Name,
+		Namespace:     cfg.Namespace,
+		Class:         cfg.Class,
+		ClusterIssuer: cfg.ClusterIssuer,
+	}
+	if gw.Name == "" {
+		gw.Name = defaultPreviewGatewayName
+	}
+	if gw.Namespace == "" {
+		gw.Namespace = os.Getenv("POD_NAMESPACE")
+	}
+	return gw
+}
+
+// gatewayListenerName returns the HTTPS listener name for a base domain
+func gatewayListenerName(domain string) string {
+	name := "https-" + strings.ReplaceAll(domain, ".", "-")
+	if len(name) > 63 {
+		name = strings.TrimRight(name[:63], "-")
+	}
+	return name
+}
+
+// gatewayListenerSecretName returns the certificate Secret of a base domain listener
+func gatewayListenerSecretName(domain string) string {
+	return "preview-" + strings.ReplaceAll(domain, ".", "-") + "-tls"
+}
+
+// desiredGatewayListener returns the HTTPS listener for *.<domain>
+func desiredGatewayListener(domain string) map[string]interface{} {
+	return map[string]interface{}{
+		"name":     gatewayListenerName(domain),
+		"hostname": "*." + domain,
+		"port":     int64(443),
+		"protocol": "HTTPS",
+		"tls": map[string]interface{}{
+			"mode": "Terminate",
+			"certificateRefs": []interface{}{
+				map[string]interface{}{"kind": "Secret", "name": gatewayListenerSecretName(domain)},
+			},
+		},
+		"allowedRoutes": map[string]interface{}{"namespaces": map[string]interface{}{"from": "All"}},
+	}
+}
+
+// desiredPreviewGateway creates the operator-managed Gateway with an HTTP listener and the
+// HTTPS listener for domain
+func desiredPreviewGateway(gw previewGateway, domain string) *unstructured.Unstructured {
+	gateway := &unstructured.Unstructured{}
+	gateway.SetGroupVersionKind(gatewayGVK)
+	gateway.SetName(gw.Name)
+	gateway.SetNamespace(gw.Namespace)
+	if gw.ClusterIssuer != "" {
+		gateway.SetAnnotations(map[string]string{certManagerClusterIssuerAnnotation: gw.ClusterIssuer})
+	}
+	gateway.Object["spec"] = map[string]interface{}{
+		"gatewayClassName": gw.Class,
+		"listeners": []interface{}{
+			map[string]interface{}{
+				"name":          "http",
+				"port":          int64(80),
+				"protocol":      "HTTP",
+				"allowedRoutes": map[string]interface{}{"namespaces": map[string]interface{}{"from": "All"}},
+			},
+			desiredGatewayListener(domain),
+		},
+	}
+	return gateway
+}
+
+// ensurePreviewGateway creates the managed Gateway, or adds the HTTPS listener for domain to it.
+// Existing listeners are left untouched so other domains keep being served.
+func (r *EnvironmentReconciler) ensurePreviewGateway(ctx context.Context, gw previewGateway, domain string) error {
+	if gw.Class == "" {
+		return nil
+	}
+	desired := desiredPreviewGateway(gw, domain)
+	existing := &unstructured.Unstructured{}
+	existing.SetGroupVersionKind(gatewayGVK)
+	if err := r.Get(ctx, client.ObjectKeyFromObject(desired), existing); err != nil {
+		if apierrors.IsNotFound(err) {
+			logf.FromContext(ctx).Info("Creating preview Gateway", "gateway", gw.Namespace+"/"+gw.Name, "domain", domain)
+			return r.Create(ctx, desired)
+		}
+		return err
+	}
+
+	changed := false
+	if gw.ClusterIssuer != "" && existing.GetAnnotations()[certManagerClusterIssuerAnnotation] != gw.ClusterIssuer {
+		annotations := existing.GetAnnotations()
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations[certManagerClusterIssuerAnnotation] = gw.ClusterIssuer
+		existing.SetAnnotations(annotations)
+		changed = true
+	}
+	listeners, _, _ := unstructured.NestedSlice(existing.Object, "spec", "listeners")
+	found := false
+	for _, l := range listeners {
+		if listener, ok := l.(map[string]interface{}); ok && listener["name"] == gatewayListenerName(domain) {
+			found = true
+			break
+		}
+	}
+	if !found {
+		listeners = append(listeners, desiredGatewayListener(domain))
+		if err := unstructured.SetNestedSlice(existing.Object, listeners, "spec", "listeners"); err != nil {
+			return err
+		}
+		changed = true
+	}
+	if !changed {
+		return nil
+	}
+	logf.FromContext(ctx).Info("Updating preview Gateway", "gateway", gw.Namespace+"/"+gw.Name, "domain", domain)
+	return r.Update(ctx, existing)
+}
+
+// desiredHTTPRoute routes hostnames to the environment's web Service through the preview Gateway
+func desiredHTTPRoute(env *catalystv1alpha1.Environment, namespace, name string, gw previewGateway, hostnames ...string) *unstructured.Unstructured {
+	parentRef := map[string]interface{}{"name": gw.Name}
+	if gw.Namespace != "" {
+		parentRef["namespace"] = gw.Namespace
+	}
+	hosts := make([]interface{}, 0, len(hostnames))
+	for _, h := range hostnames {
+		hosts = append(hosts, h)
+	}
+
+	route := &unstructured.Unstructured{}
+	route.SetGroupVersionKind(httpRouteGVK)
+	route.SetName(name)
+	route.SetNamespace(namespace)
+	route.SetLabels(map[string]string{
+		"catalyst.dev/environment": sanitizeLabelValue(env.Name),
+	})
+	route.Object["spec"] = map[string]interface{}{
+		"parentRefs": []interface{}{parentRef},
+		"hostnames":  hosts,
+		"rules": []interface{}{
+			map[string]interface{}{
+				"matches": []interface{}{
+					map[string]interface{}{"path": map[string]interface{}{"type": "PathPrefix", "value": "/"}},
+				},
+				"backendRefs": []interface{}{
+					map[string]interface{}{"name": sharedRouteBackend, "port": int64(sharedRouteBackPort)},
+				},
+			},
+		},
+	}
+	return route
+}
+
+// reconcilePreviewRoute serves the environment host with an HTTPRoute in place of the web
+// Ingress, which is removed if it was created before switching to Gateway routing.
+// Missing Gateway API CRDs are reported on the CapabilitiesAvailable condition.
+func (r *EnvironmentReconciler) reconcilePreviewRoute(ctx context.Context, env *catalystv1alpha1.Environment, namespace, host, domain string) error {
+	log := logf.FromContext(ctx)
+
+	stale := &networkingv1.Ingress{}
+	if err := r.Get(ctx, client.ObjectKey{Name: "web", Namespace: namespace}, stale); err == nil {
+		log.Info("Deleting Ingress replaced by HTTPRoute", "namespace", namespace)
+		if err := r.Delete(ctx, stale); err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete Ingress replaced by HTTPRoute: %w", err)
+		}
+	} else if !apierrors.IsNotFound(err) {
+		return err
+	}
+	if r.Capabilities != nil && !r.Capabilities.GatewayAPI {
+		return nil
+	}
+
+	gw := currentPreviewGateway()
+	if err := r.ensurePreviewGateway(ctx, gw, domain); err != nil {
+		if meta.IsNoMatchError(err) {
+			log.Info("Gateway API not installed, preview host is not routed", "host", host)
+			return nil
+		}
+		return fmt.Errorf("failed to reconcile preview Gateway: %w", err)
+	}
+	if err := r.patchOrUpdate(ctx, desiredHTTPRoute(env, namespace, "web", gw, host)); err != nil {
+		if meta.IsNoMatchError(err) {
+			log.Info("Gateway API not installed, preview host is not routed", "host", host)
+			return nil
+		}
+		return fmt.Errorf("failed to reconcile HTTPRoute: %w", err)
+	}
+	return nil
+}
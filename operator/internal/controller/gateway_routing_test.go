@@ -0,0 +1,106 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+func TestPreviewRouting(t *testing.T) {
+	t.Setenv("PREVIEW_ROUTING", "")
+	assert.Equal(t, routingIngress, previewRouting(nil))
+
+	t.Setenv("PREVIEW_ROUTING", "gateway")
+	assert.Equal(t, routingGateway, previewRouting(&catalystv1alpha1.Project{}))
+
+	// The Project setting wins over the operator setting
+	project := &catalystv1alpha1.Project{Spec: catalystv1alpha1.ProjectSpec{Routing: routingIngress}}
+	assert.Equal(t, routingIngress, previewRouting(project))
+}
+
+func TestDesiredHTTPRoute(t *testing.T) {
+	env := &catalystv1alpha1.Environment{ObjectMeta: metav1.ObjectMeta{Name: "pr-42"}}
+	gw := previewGateway{Name: "catalyst-preview", Namespace: "catalyst-system"}
+
+	route := desiredHTTPRoute(env, "team-proj-pr-42", "web", gw, "pr-42.preview.example.com")
+
+	assert.Equal(t, "HTTPRoute", route.GetKind())
+	assert.Equal(t, "web", route.GetName())
+	hostnames, _, _ := unstructured.NestedStringSlice(route.Object, "spec", "hostnames")
+	assert.Equal(t, []string{"pr-42.preview.example.com"}, hostnames)
+
+	parents, _, _ := unstructured.NestedSlice(route.Object, "spec", "parentRefs")
+	require.Len(t, parents, 1)
+	assert.Equal(t, map[string]interface{}{"name": "catalyst-preview", "namespace": "catalyst-system"}, parents[0])
+
+	rules, _, _ := unstructured.NestedSlice(route.Object, "spec", "rules")
+	require.Len(t, rules, 1)
+	backend := rules[0].(map[string]interface{})["backendRefs"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "web", backend["name"])
+	assert.Equal(t, int64(80), backend["port"])
+}
+
+func TestEnsurePreviewGateway(t *testing.T) {
+	c := newFakeClientBuilder().Build()
+	r := &EnvironmentReconciler{Client: c, Scheme: testScheme}
+	ctx := context.Background()
+
+	// Externally managed Gateways are left alone
+	require.NoError(t, r.ensurePreviewGateway(ctx, previewGateway{Name: "gw", Namespace: "catalyst-system"}, "preview.example.com"))
+
+	gw := previewGateway{Name: "gw", Namespace: "catalyst-system", Class: "eg", ClusterIssuer: "letsencrypt"}
+	require.NoError(t, r.ensurePreviewGateway(ctx, gw, "preview.example.com"))
+
+	gateway := &unstructured.Unstructured{}
+	gateway.SetGroupVersionKind(gatewayGVK)
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "gw", Namespace: "catalyst-system"}, gateway))
+	assert.Equal(t, "letsencrypt", gateway.GetAnnotations()[certManagerClusterIssuerAnnotation])
+	class, _, _ := unstructured.NestedString(gateway.Object, "spec", "gatewayClassName")
+	assert.Equal(t, "eg", class)
+	listeners, _, _ := unstructured.NestedSlice(gateway.Object, "spec", "listeners")
+	require.Len(t, listeners, 2)
+	https := listeners[1].(map[string]interface{})
+	assert.Equal(t, "*.preview.example.com", https["hostname"])
+
+	// A project base domain adds a listener and keeps the existing ones
+	require.NoError(t, r.ensurePreviewGateway(ctx, gw, "preview.acme.dev"))
+	require.NoError(t, r.ensurePreviewGateway(ctx, gw, "preview.acme.dev"))
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "gw", Namespace: "catalyst-system"}, gateway))
+	listeners, _, _ = unstructured.NestedSlice(gateway.Object, "spec", "listeners")
+	require.Len(t, listeners, 3)
+	assert.Equal(t, gatewayListenerName("preview.acme.dev"), listeners[2].(map[string]interface{})["name"])
+}
+
+func TestReconcilePreviewRouteReplacesIngress(t *testing.T) {
+	t.Setenv("GATEWAY_NAME", "")
+	t.Setenv("GATEWAY_NAMESPACE", "catalyst-system")
+	t.Setenv("GATEWAY_CLASS", "")
+
+	env := &catalystv1alpha1.Environment{ObjectMeta: metav1.ObjectMeta{Name: "pr-1", Namespace: "team"}}
+	stale := &networkingv1.Ingress{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "env-ns"}}
+	c := newFakeClientBuilder().WithObjects(env, stale).Build()
+	r := &EnvironmentReconciler{Client: c, Scheme: testScheme}
+	ctx := context.Background()
+
+	require.NoError(t, r.reconcilePreviewRoute(ctx, env, "env-ns", "pr-1.preview.example.com", "preview.example.com"))
+
+	err := c.Get(ctx, client.ObjectKey{Name: "web", Namespace: "env-ns"}, &networkingv1.Ingress{})
+	assert.True(t, apierrors.IsNotFound(err), "Ingress should be replaced by the HTTPRoute")
+
+	route := &unstructured.Unstructured{}
+	route.SetGroupVersionKind(httpRouteGVK)
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "web", Namespace: "env-ns"}, route))
+	hostnames, _, _ := unstructured.NestedStringSlice(route.Object, "spec", "hostnames")
+	assert.Equal(t, []string{"pr-1.preview.example.com"}, hostnames)
+	parents, _, _ := unstructured.NestedSlice(route.Object, "spec", "parentRefs")
+	assert.Equal(t, defaultPreviewGatewayName, parents[0].(map[string]interface{})["name"])
+}
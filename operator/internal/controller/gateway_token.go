@@ -0,0 +1,95 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+// GatewayTokenKey is the Secret data key holding an environment's workspace gateway token
+const GatewayTokenKey = "token"
+
+// GatewayTokenSecretName returns the name of the Secret holding the workspace gateway token.
+// The Secret lives next to the Environment CR (team namespace), so the web UI and CLI can
+// read it without access to the environment namespace.
+func GatewayTokenSecretName(envName string) string {
+	return envName + "-gateway-token"
+}
+
+// generateGatewayToken returns a random 256-bit token, hex-encoded
+func generateGatewayToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func desiredGatewayTokenSecret(env *catalystv1alpha1.Environment, token string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      GatewayTokenSecretName(env.Name),
+			Namespace: env.Namespace,
+			Labels: map[string]string{
+				"app.kubernetes.io/managed-by": "catalyst-operator",
+				"catalyst.dev/environment":     sanitizeLabelValue(env.Name),
+				"catalyst.dev/secret-type":     "gateway-token",
+			},
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{
+			GatewayTokenKey: []byte(token),
+		},
+	}
+}
+
+// ensureGatewayToken creates the per-environment gateway token Secret if it doesn't exist.
+// The Secret is owned by the Environment and garbage collected with it.
+// Rotate a token by deleting the Secret; a new one is generated on the next reconcile.
+func (r *EnvironmentReconciler) ensureGatewayToken(ctx context.Context, env *catalystv1alpha1.Environment) error {
+	existing := &corev1.Secret{}
+	err := r.Get(ctx, client.ObjectKey{Name: GatewayTokenSecretName(env.Name), Namespace: env.Namespace}, existing)
+	if err == nil || !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	token, err := generateGatewayToken()
+	if err != nil {
+		return err
+	}
+	secret := desiredGatewayTokenSecret(env, token)
+	if err := controllerutil.SetControllerReference(env, secret, r.Scheme); err != nil {
+		return err
+	}
+
+	logf.FromContext(ctx).Info("Creating workspace gateway token", "secret", secret.Name)
+	if err := r.Create(ctx, secret); err != nil && !isAlreadyExists(err) {
+		return err
+	}
+	return nil
+}
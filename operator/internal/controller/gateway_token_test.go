@@ -0,0 +1,27 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+func TestDesiredGatewayTokenSecret(t *testing.T) {
+	token, err := generateGatewayToken()
+	require.NoError(t, err)
+	assert.Len(t, token, 64)
+
+	other, err := generateGatewayToken()
+	require.NoError(t, err)
+	assert.NotEqual(t, token, other)
+
+	env := &catalystv1alpha1.Environment{ObjectMeta: metav1.ObjectMeta{Name: "pr-1", Namespace: "team"}}
+	secret := desiredGatewayTokenSecret(env, token)
+	assert.Equal(t, "pr-1-gateway-token", secret.Name)
+	assert.Equal(t, "team", secret.Namespace)
+	assert.Equal(t, token, string(secret.Data[GatewayTokenKey]))
+}
@@ -0,0 +1,247 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	corev1 "k8s.io/api/core/v1"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+	"github.com/ncrmro/catalyst/operator/internal/gitlfs"
+)
+
+// Git LFS and submodules (SourceConfig lfs, submodules):
+// git-clone init containers check them out with git (GIT_LFS, GIT_SUBMODULES in
+// git-clone.sh); operator clones (prepareSource) with go-git and the gitlfs package. In
+// both, credentials only go to the repository host: submodules on it reuse them, and their
+// SSH URLs are fetched over HTTPS so they do.
+
+// maxSubmoduleDepth bounds recursive submodule checkouts
+const maxSubmoduleDepth = 10
+
+// gitCheckoutEnv configures the LFS download and submodule checkout of git-clone.sh
+func gitCheckoutEnv(source *catalystv1alpha1.SourceConfig) []corev1.EnvVar {
+	if source == nil {
+		return nil
+	}
+	var env []corev1.EnvVar
+	if source.LFS {
+		env = append(env, corev1.EnvVar{Name: "GIT_LFS", Value: "true"})
+	}
+	if source.Submodules == catalystv1alpha1.SubmodulesInit || source.Submodules == catalystv1alpha1.SubmodulesRecursive {
+		env = append(env, corev1.EnvVar{Name: "GIT_SUBMODULES", Value: source.Submodules})
+	}
+	return env
+}
+
+// checkedOutRepository is a worktree of the clone, the repository itself or a submodule
+type checkedOutRepository struct {
+	dir  string
+	url  string
+	auth transport.AuthMethod
+}
+
+// checkoutSourceExtras checks out the submodules and downloads the LFS objects of a go-git
+// clone at dir, as the source asks for
+func checkoutSourceExtras(ctx context.Context, repo *git.Repository, dir string, source *catalystv1alpha1.SourceConfig, auth transport.AuthMethod) error {
+	host := gitURLHost(source.RepositoryURL)
+	repositories := []checkedOutRepository{{dir: dir, url: httpsOnHost(source.RepositoryURL, host), auth: auth}}
+
+	if source.Submodules == catalystv1alpha1.SubmodulesInit || source.Submodules == catalystv1alpha1.SubmodulesRecursive {
+		w, err := repo.Worktree()
+		if err != nil {
+			return err
+		}
+		submodules, err := updateSubmodules(ctx, w, repositories[0], host, source.Submodules == catalystv1alpha1.SubmodulesRecursive, 0)
+		if err != nil {
+			return err
+		}
+		repositories = append(repositories, submodules...)
+	}
+
+	if source.LFS {
+		for _, r := range repositories {
+			if err := gitlfs.Pull(ctx, nil, r.dir, r.url, lfsCredentials(r.auth)); err != nil {
+				return fmt.Errorf("failed to download LFS objects of %s: %w", r.url, err)
+			}
+		}
+	}
+	return nil
+}
+
+// updateSubmodules checks out the submodules of the worktree, and theirs when recursive
+func updateSubmodules(ctx context.Context, w *git.Worktree, parent checkedOutRepository, host string, recursive bool, depth int) ([]checkedOutRepository, error) {
+	if err := rewriteGitmodules(w, host); err != nil {
+		return nil, err
+	}
+	submodules, err := w.Submodules()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read submodules: %w", err)
+	}
+
+	var checkedOut []checkedOutRepository
+	for _, sub := range submodules {
+		config := sub.Config()
+		subURL := resolveSubmoduleURL(parent.url, config.URL)
+		var auth transport.AuthMethod
+		if gitURLHost(subURL) == host {
+			auth = parent.auth
+		}
+		if err := sub.UpdateContext(ctx, &git.SubmoduleUpdateOptions{Init: true, Auth: auth}); err != nil {
+			return nil, fmt.Errorf("failed to check out submodule %s: %w", config.Path, err)
+		}
+		repository := checkedOutRepository{dir: filepath.Join(parent.dir, config.Path), url: subURL, auth: auth}
+		checkedOut = append(checkedOut, repository)
+
+		if !recursive || depth+1 >= maxSubmoduleDepth {
+			continue
+		}
+		subRepo, err := sub.Repository()
+		if err != nil {
+			return nil, err
+		}
+		subWorktree, err := subRepo.Worktree()
+		if err != nil {
+			return nil, err
+		}
+		nested, err := updateSubmodules(ctx, subWorktree, repository, host, true, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		checkedOut = append(checkedOut, nested...)
+	}
+	return checkedOut, nil
+}
+
+// rewriteGitmodules points the SSH URLs of submodules on host at HTTPS. go-git reads
+// submodule URLs from the .gitmodules of the worktree, which is discarded with the clone.
+func rewriteGitmodules(w *git.Worktree, host string) error {
+	f, err := w.Filesystem.Open(".gitmodules")
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	data, err := io.ReadAll(f)
+	_ = f.Close()
+	if err != nil {
+		return err
+	}
+	modules := gitconfig.NewModules()
+	if err := modules.Unmarshal(data); err != nil {
+		return fmt.Errorf("failed to parse .gitmodules: %w", err)
+	}
+	changed := false
+	for _, sub := range modules.Submodules {
+		if rewritten := httpsOnHost(sub.URL, host); rewritten != sub.URL {
+			sub.URL = rewritten
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	if data, err = modules.Marshal(); err != nil {
+		return err
+	}
+	out, err := w.Filesystem.Create(".gitmodules")
+	if err != nil {
+		return err
+	}
+	if _, err := out.Write(data); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// httpsOnHost rewrites an SSH URL ("git@host:org/repo.git", "ssh://git@host/org/repo.git")
+// on host to HTTPS. Other URLs are returned unchanged.
+func httpsOnHost(rawURL, host string) string {
+	if host == "" {
+		return rawURL
+	}
+	if strings.HasPrefix(rawURL, "ssh://") {
+		if u, err := url.Parse(rawURL); err == nil && u.Hostname() == host {
+			return "https://" + host + u.Path
+		}
+		return rawURL
+	}
+	if userHost, repoPath, ok := strings.Cut(rawURL, ":"); ok && !strings.Contains(rawURL, "://") {
+		_, h, _ := strings.Cut(userHost, "@")
+		if h == "" {
+			h = userHost
+		}
+		if h == host {
+			return "https://" + host + "/" + strings.TrimPrefix(repoPath, "/")
+		}
+	}
+	return rawURL
+}
+
+// gitURLHost returns the host of a repository URL, SSH ones included. Empty for local paths.
+func gitURLHost(rawURL string) string {
+	if strings.Contains(rawURL, "://") {
+		if u, err := url.Parse(rawURL); err == nil {
+			return u.Hostname()
+		}
+		return ""
+	}
+	if userHost, _, ok := strings.Cut(rawURL, ":"); ok {
+		if _, h, ok := strings.Cut(userHost, "@"); ok {
+			return h
+		}
+		return userHost
+	}
+	return ""
+}
+
+// resolveSubmoduleURL resolves a relative submodule URL ("../lib.git") against the URL of
+// the repository holding it, as git does
+func resolveSubmoduleURL(parentURL, subURL string) string {
+	if !strings.HasPrefix(subURL, "./") && !strings.HasPrefix(subURL, "../") {
+		return subURL
+	}
+	u, err := url.Parse(parentURL)
+	if err != nil {
+		return subURL
+	}
+	u.Path = path.Join(u.Path, subURL)
+	return u.String()
+}
+
+// lfsCredentials returns the basic auth credentials of a clone for its LFS server
+func lfsCredentials(auth transport.AuthMethod) *gitlfs.Credentials {
+	basic, ok := auth.(*githttp.BasicAuth)
+	if !ok || basic == nil {
+		return nil
+	}
+	return &gitlfs.Credentials{Username: basic.Username, Password: basic.Password}
+}
@@ -0,0 +1,97 @@
+package controller
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/format/index"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+func TestGitCheckoutEnv(t *testing.T) {
+	assert.Empty(t, gitCheckoutEnv(&catalystv1alpha1.SourceConfig{Submodules: catalystv1alpha1.SubmodulesNone}))
+	assert.Equal(t, []corev1.EnvVar{{Name: "GIT_LFS", Value: "true"}, {Name: "GIT_SUBMODULES", Value: "recursive"}},
+		gitCheckoutEnv(&catalystv1alpha1.SourceConfig{LFS: true, Submodules: catalystv1alpha1.SubmodulesRecursive}))
+
+	container := gitCloneInitContainer(&catalystv1alpha1.Project{}, &catalystv1alpha1.SourceConfig{Submodules: catalystv1alpha1.SubmodulesInit}, "abc", "code", "/code")
+	assert.Contains(t, container.Env, corev1.EnvVar{Name: "GIT_SUBMODULES", Value: "init"})
+}
+
+func TestSubmoduleURLs(t *testing.T) {
+	assert.Equal(t, "https://github.com/acme/lib.git", httpsOnHost("git@github.com:acme/lib.git", "github.com"))
+	assert.Equal(t, "https://github.com/acme/lib.git", httpsOnHost("ssh://git@github.com/acme/lib.git", "github.com"))
+	// Other hosts keep their URL and get no credentials
+	assert.Equal(t, "git@gitlab.com:acme/lib.git", httpsOnHost("git@gitlab.com:acme/lib.git", "github.com"))
+	assert.Equal(t, "https://gitlab.com/acme/lib.git", httpsOnHost("https://gitlab.com/acme/lib.git", "github.com"))
+
+	assert.Equal(t, "github.com", gitURLHost("https://x-access-token@github.com/acme/app.git"))
+	assert.Equal(t, "gitlab.com", gitURLHost("git@gitlab.com:acme/lib.git"))
+	assert.Empty(t, gitURLHost("/tmp/repo"))
+
+	assert.Equal(t, "https://github.com/acme/lib.git", resolveSubmoduleURL("https://github.com/acme/app.git", "../lib.git"))
+	assert.Equal(t, "https://github.com/acme/app.git/vendor", resolveSubmoduleURL("https://github.com/acme/app.git", "./vendor"))
+	assert.Equal(t, "git@gitlab.com:acme/lib.git", resolveSubmoduleURL("https://github.com/acme/app.git", "git@gitlab.com:acme/lib.git"))
+
+	assert.Equal(t, "oauth2", lfsCredentials(&githttp.BasicAuth{Username: "oauth2", Password: "token"}).Username)
+	assert.Nil(t, lfsCredentials(nil))
+}
+
+// commitFile commits a file to the repository at dir
+func commitFile(t *testing.T, repo *git.Repository, dir, name, content string) plumbing.Hash {
+	t.Helper()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	w, err := repo.Worktree()
+	require.NoError(t, err)
+	_, err = w.Add(name)
+	require.NoError(t, err)
+	hash, err := w.Commit("add "+name, &git.CommitOptions{Author: &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()}})
+	require.NoError(t, err)
+	return hash
+}
+
+func TestCheckoutSourceExtras_Submodules(t *testing.T) {
+	// lib is a submodule of app
+	libDir := t.TempDir()
+	lib, err := git.PlainInit(libDir, false)
+	require.NoError(t, err)
+	libCommit := commitFile(t, lib, libDir, "lib.txt", "shared code")
+
+	appDir := t.TempDir()
+	app, err := git.PlainInit(appDir, false)
+	require.NoError(t, err)
+	idx, err := app.Storer.Index()
+	require.NoError(t, err)
+	idx.Entries = append(idx.Entries, &index.Entry{Name: "lib", Mode: filemode.Submodule, Hash: libCommit})
+	require.NoError(t, app.Storer.SetIndex(idx))
+	commitFile(t, app, appDir, ".gitmodules", "[submodule \"lib\"]\n\tpath = lib\n\turl = "+libDir+"\n")
+
+	cloneDir := t.TempDir()
+	clone, err := git.PlainClone(cloneDir, false, &git.CloneOptions{URL: appDir})
+	require.NoError(t, err)
+	_, err = os.Stat(filepath.Join(cloneDir, "lib", "lib.txt"))
+	require.True(t, os.IsNotExist(err), "go-git leaves submodules empty")
+
+	// No submodules requested
+	source := &catalystv1alpha1.SourceConfig{Name: "app", RepositoryURL: appDir}
+	require.NoError(t, checkoutSourceExtras(context.Background(), clone, cloneDir, source, nil))
+	_, err = os.Stat(filepath.Join(cloneDir, "lib", "lib.txt"))
+	require.True(t, os.IsNotExist(err))
+
+	source.Submodules = catalystv1alpha1.SubmodulesInit
+	require.NoError(t, checkoutSourceExtras(context.Background(), clone, cloneDir, source, nil))
+	data, err := os.ReadFile(filepath.Join(cloneDir, "lib", "lib.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "shared code", string(data))
+}
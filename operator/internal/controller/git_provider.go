@@ -0,0 +1,266 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+	"github.com/ncrmro/catalyst/operator/internal/operatorconfig"
+)
+
+// Git providers of SourceConfig.Provider
+const (
+	gitProviderGitHub    = "github"
+	gitProviderGitLab    = "gitlab"
+	gitProviderBitbucket = "bitbucket"
+)
+
+// Keys of SourceConfig.CredentialsSecret
+const (
+	gitTokenKey    = "token"
+	gitUsernameKey = "username"
+)
+
+// gitProvider returns the provider of a source, defaulting to GitHub
+func gitProvider(source *catalystv1alpha1.SourceConfig) string {
+	if source == nil || source.Provider == "" {
+		return gitProviderGitHub
+	}
+	return source.Provider
+}
+
+// gitDefaultUsername is the username token clones authenticate with when the credentials
+// Secret has none
+func gitDefaultUsername(provider string) string {
+	switch provider {
+	case gitProviderGitLab:
+		return "oauth2"
+	case gitProviderBitbucket:
+		return "x-token-auth"
+	default:
+		return "x-access-token"
+	}
+}
+
+// usesInstallationToken reports whether clones of the source fetch GitHub App installation
+// tokens from the Catalyst web service
+func usesInstallationToken(source *catalystv1alpha1.SourceConfig) bool {
+	return gitProvider(source) == gitProviderGitHub
+}
+
+// gitCredentialsSecretName is the Secret with the minted token of a source in environment
+// namespaces
+func gitCredentialsSecretName(source *catalystv1alpha1.SourceConfig) string {
+	return "git-credentials-" + sanitizeLabelValue(source.Name)
+}
+
+// gitCloneCredentialEnv returns the environment the git credential helper of git-clone init
+// containers authenticates with: the source's minted token, or the GitHub App installation.
+func gitCloneCredentialEnv(project *catalystv1alpha1.Project, source *catalystv1alpha1.SourceConfig) []corev1.EnvVar {
+	if source == nil || usesInstallationToken(source) || source.CredentialsSecret == "" {
+		patFallback := ""
+		if operatorconfig.Current().EnablePATFallback {
+			patFallback = "true"
+		}
+		return []corev1.EnvVar{
+			{Name: "INSTALLATION_ID", Value: project.Spec.GitHubInstallationId},
+			{Name: "ENABLE_PAT_FALLBACK", Value: patFallback},
+			{Name: "CATALYST_WEB_URL", Value: CatalystWebURL()},
+		}
+	}
+	secretKey := func(key string, optional bool) *corev1.EnvVarSource {
+		return &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: gitCredentialsSecretName(source)},
+			Key:                  key,
+			Optional:             ptr(optional),
+		}}
+	}
+	return []corev1.EnvVar{
+		{Name: "GIT_TOKEN", ValueFrom: secretKey(gitTokenKey, false)},
+		{Name: "GIT_USERNAME", ValueFrom: secretKey(gitUsernameKey, true)},
+		{Name: "GIT_DEFAULT_USERNAME", Value: gitDefaultUsername(gitProvider(source))},
+	}
+}
+
+// ensureGitCredentials mints the tokens of the Project's GitLab and Bitbucket sources into
+// the environment namespace, where build Jobs and development pods reference them. It
+// returns whether a token was minted, to be renewed every gitCredentialsResyncInterval.
+func (r *EnvironmentReconciler) ensureGitCredentials(ctx context.Context, project *catalystv1alpha1.Project, targetNamespace string) (bool, error) {
+	return copyGitCredentials(ctx, r.Client, project, targetNamespace)
+}
+
+// copyGitCredentials implements ensureGitCredentials for any namespace git-clone runs in.
+// The tokens of sources removed from the Project, or no longer using a credentials Secret,
+// are revoked and deleted.
+func copyGitCredentials(ctx context.Context, c client.Client, project *catalystv1alpha1.Project, targetNamespace string) (bool, error) {
+	log := logf.FromContext(ctx)
+	now := time.Now()
+	minted := false
+	desired := map[string]bool{}
+	for i := range project.Spec.Sources {
+		source := &project.Spec.Sources[i]
+		if source.CredentialsSecret == "" {
+			continue
+		}
+		minted = true
+		desired[gitCredentialsSecretName(source)] = true
+
+		current := &corev1.Secret{}
+		err := c.Get(ctx, client.ObjectKey{Name: gitCredentialsSecretName(source), Namespace: targetNamespace}, current)
+		if err != nil && !apierrors.IsNotFound(err) {
+			return false, err
+		}
+		if err == nil {
+			if refreshAt, err := time.Parse(time.RFC3339, current.Annotations[gitTokenRefreshAnnotation]); err == nil && now.Before(refreshAt) {
+				continue
+			}
+		}
+
+		credentials := &corev1.Secret{}
+		if err := c.Get(ctx, client.ObjectKey{Name: source.CredentialsSecret, Namespace: project.Namespace}, credentials); err != nil {
+			return false, fmt.Errorf("failed to read credentials of source %s: %w", source.Name, err)
+		}
+		token, err := mintGitToken(ctx, source, credentials, targetNamespace, now)
+		if err != nil {
+			return false, withFailureReason(catalystv1alpha1.FailureReasonSourceCloneFailed, err)
+		}
+		annotations := map[string]string{gitTokenRefreshAnnotation: gitTokenRefreshAt(token, now).UTC().Format(time.RFC3339)}
+		if token.ID != "" {
+			annotations[gitTokenIDAnnotation] = token.ID
+			annotations[gitTokenRepositoryAnnotation] = source.RepositoryURL
+			annotations[gitTokenCredentialsAnnotation] = source.CredentialsSecret
+		}
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        gitCredentialsSecretName(source),
+				Namespace:   targetNamespace,
+				Labels:      map[string]string{gitCredentialsLabel: sanitizeLabelValue(source.Name)},
+				Annotations: annotations,
+			},
+			Type: corev1.SecretTypeOpaque,
+			Data: map[string][]byte{gitTokenKey: []byte(token.Token), gitUsernameKey: []byte(token.Username)},
+		}
+		if err := createOrReplace(ctx, c, secret); err != nil {
+			return false, fmt.Errorf("failed to write the token of source %s: %w", source.Name, err)
+		}
+		// The renewed token replaces the previous one, which would otherwise live until it expires
+		if previous := current.Annotations[gitTokenIDAnnotation]; previous != "" && gitProvider(source) == gitProviderGitLab {
+			if err := revokeGitLabToken(ctx, source, credentials, previous); err != nil {
+				log.Error(err, "Failed to revoke the previous git token", "source", source.Name)
+			}
+		}
+	}
+
+	existing := &corev1.SecretList{}
+	if err := c.List(ctx, existing, client.InNamespace(targetNamespace), client.HasLabels{gitCredentialsLabel}); err != nil {
+		return false, err
+	}
+	for i := range existing.Items {
+		secret := &existing.Items[i]
+		if desired[secret.Name] {
+			continue
+		}
+		log.Info("Deleting the token of a removed source", "namespace", targetNamespace, "secret", secret.Name)
+		if err := revokeMintedGitToken(ctx, c, project.Namespace, secret); err != nil {
+			log.Error(err, "Failed to revoke the git token of a removed source", "secret", secret.Name)
+		}
+		if err := c.Delete(ctx, secret); client.IgnoreNotFound(err) != nil {
+			return false, err
+		}
+	}
+	return minted, nil
+}
+
+// revokeGitTokens revokes the GitLab tokens minted into namespace, which would otherwise stay
+// valid until they expire once the namespace is gone. Failures are logged: teardown proceeds.
+func (r *EnvironmentReconciler) revokeGitTokens(ctx context.Context, project *catalystv1alpha1.Project, namespace string) {
+	log := logf.FromContext(ctx)
+	secrets := &corev1.SecretList{}
+	if err := r.List(ctx, secrets, client.InNamespace(namespace), client.HasLabels{gitCredentialsLabel}); err != nil {
+		log.Error(err, "Failed to list git tokens to revoke", "namespace", namespace)
+		return
+	}
+	for i := range secrets.Items {
+		if err := revokeMintedGitToken(ctx, r.Client, project.Namespace, &secrets.Items[i]); err != nil {
+			log.Error(err, "Failed to revoke git token", "namespace", namespace, "secret", secrets.Items[i].Name)
+		}
+	}
+}
+
+// gitAuth returns the credentials operator-side clones of a source use: a GitHub App
+// installation token, the GitLab token of the credentials Secret, or a minted Bitbucket
+// token. Nil means anonymous, for GitHub projects without an installation.
+func (r *EnvironmentReconciler) gitAuth(ctx context.Context, env *catalystv1alpha1.Environment, project *catalystv1alpha1.Project, source *catalystv1alpha1.SourceConfig) (transport.AuthMethod, error) {
+	if usesInstallationToken(source) {
+		if source.CredentialsSecret != "" {
+			return nil, fmt.Errorf("source %s: github sources clone with the GitHub App installation, credentialsSecret is only supported for gitlab and bitbucket", source.Name)
+		}
+		token, err := r.installationToken(ctx, env, project)
+		if err != nil || token == "" {
+			return nil, err
+		}
+		return &githttp.BasicAuth{Username: gitDefaultUsername(gitProviderGitHub), Password: token}, nil
+	}
+	if source.CredentialsSecret == "" {
+		return nil, nil
+	}
+	secret := &corev1.Secret{}
+	if err := r.Get(ctx, client.ObjectKey{Name: source.CredentialsSecret, Namespace: project.Namespace}, secret); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, fmt.Errorf("credentials Secret %s of source %s not found", source.CredentialsSecret, source.Name)
+		}
+		return nil, err
+	}
+	if gitProvider(source) == gitProviderBitbucket {
+		token, err := mintBitbucketToken(ctx, source, secret, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		return &githttp.BasicAuth{Username: token.Username, Password: token.Token}, nil
+	}
+	username := string(secret.Data[gitUsernameKey])
+	if username == "" {
+		username = gitDefaultUsername(gitProvider(source))
+	}
+	return &githttp.BasicAuth{Username: username, Password: string(secret.Data[gitTokenKey])}, nil
+}
+
+// resolveCommit resolves a commit SHA, full or abbreviated: Bitbucket webhooks and links
+// report 12 character hashes, which go-git only resolves through the revision parser.
+func resolveCommit(repo *git.Repository, commit string) (plumbing.Hash, error) {
+	if len(commit) == 40 {
+		return plumbing.NewHash(commit), nil
+	}
+	hash, err := repo.ResolveRevision(plumbing.Revision(commit))
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("failed to resolve commit %s: %w", commit, err)
+	}
+	return *hash, nil
+}
@@ -0,0 +1,247 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-billy/v5/util"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+func TestGitCloneCredentialEnv(t *testing.T) {
+	project := &catalystv1alpha1.Project{Spec: catalystv1alpha1.ProjectSpec{GitHubInstallationId: "12345"}}
+
+	env := gitCloneCredentialEnv(project, &catalystv1alpha1.SourceConfig{Name: "app"})
+	assert.Equal(t, corev1.EnvVar{Name: "INSTALLATION_ID", Value: "12345"}, env[0], "GitHub sources use the App installation")
+
+	env = gitCloneCredentialEnv(project, &catalystv1alpha1.SourceConfig{Name: "app", Provider: gitProviderGitLab, CredentialsSecret: "gitlab-token"})
+	require.Len(t, env, 3)
+	assert.Equal(t, "GIT_TOKEN", env[0].Name)
+	assert.Equal(t, "git-credentials-app", env[0].ValueFrom.SecretKeyRef.Name)
+	assert.Equal(t, gitTokenKey, env[0].ValueFrom.SecretKeyRef.Key)
+	assert.True(t, *env[1].ValueFrom.SecretKeyRef.Optional, "the username is optional")
+	assert.Equal(t, corev1.EnvVar{Name: "GIT_DEFAULT_USERNAME", Value: "oauth2"}, env[2])
+
+	env = gitCloneCredentialEnv(project, &catalystv1alpha1.SourceConfig{Name: "app", Provider: gitProviderBitbucket, CredentialsSecret: "bb"})
+	assert.Equal(t, "x-token-auth", env[2].Value)
+}
+
+func TestEnsureGitCredentials(t *testing.T) {
+	var gitlabTokens, revoked []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/site/oauth2/access_token":
+			id, secret, _ := r.BasicAuth()
+			assert.Equal(t, "consumer:consumer-secret", id+":"+secret)
+			_, _ = w.Write([]byte(`{"access_token":"bb-short-lived","expires_in":7200}`))
+		case r.Method == http.MethodPost && r.URL.EscapedPath() == "/api/v4/projects/acme%2Fcharts/access_tokens":
+			assert.Equal(t, "glpat-maintainer", r.Header.Get("PRIVATE-TOKEN"))
+			var body map[string]interface{}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			assert.Equal(t, []interface{}{"read_repository"}, body["scopes"])
+			gitlabTokens = append(gitlabTokens, body["name"].(string))
+			_, _ = fmt.Fprintf(w, `{"id":%d,"token":"gl-short-lived"}`, len(gitlabTokens))
+		case r.Method == http.MethodDelete:
+			revoked = append(revoked, path.Base(r.URL.Path))
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	defer func(url string) { bitbucketTokenURL = url }(bitbucketTokenURL)
+	bitbucketTokenURL = server.URL + "/site/oauth2/access_token"
+
+	project := &catalystv1alpha1.Project{
+		ObjectMeta: metav1.ObjectMeta{Name: "shop", Namespace: "acme"},
+		Spec: catalystv1alpha1.ProjectSpec{Sources: []catalystv1alpha1.SourceConfig{
+			{Name: "app", RepositoryURL: "https://bitbucket.org/acme/app.git", Provider: gitProviderBitbucket, CredentialsSecret: "bitbucket"},
+			{Name: "charts", RepositoryURL: server.URL + "/acme/charts.git", Provider: gitProviderGitLab, CredentialsSecret: "gitlab"},
+			{Name: "web", RepositoryURL: "https://github.com/acme/web"},
+		}},
+	}
+	c := newFakeClientBuilder().WithObjects(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "bitbucket", Namespace: "acme"},
+		Data:       map[string][]byte{gitClientIDKey: []byte("consumer"), gitClientSecretKey: []byte("consumer-secret")},
+	}, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "gitlab", Namespace: "acme"},
+		Data:       map[string][]byte{gitTokenKey: []byte("glpat-maintainer")},
+	}).Build()
+	r := &EnvironmentReconciler{Client: c, Scheme: testScheme}
+	ctx := context.Background()
+
+	minted, err := r.ensureGitCredentials(ctx, project, "acme-shop-pr-1")
+	require.NoError(t, err)
+	assert.True(t, minted)
+	copied := &corev1.Secret{}
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "git-credentials-app", Namespace: "acme-shop-pr-1"}, copied))
+	assert.Equal(t, []byte("bb-short-lived"), copied.Data[gitTokenKey], "the OAuth consumer is not copied")
+	assert.Equal(t, []byte("x-token-auth"), copied.Data[gitUsernameKey])
+	refreshAt, err := time.Parse(time.RFC3339, copied.Annotations[gitTokenRefreshAnnotation])
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), refreshAt, time.Minute, "renewed half way through the lifetime")
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "git-credentials-charts", Namespace: "acme-shop-pr-1"}, copied))
+	assert.Equal(t, []byte("gl-short-lived"), copied.Data[gitTokenKey])
+	assert.Equal(t, []string{"catalyst-acme-shop-pr-1"}, gitlabTokens)
+
+	// Tokens are kept until they are due for renewal, then the previous GitLab token is revoked
+	_, err = r.ensureGitCredentials(ctx, project, "acme-shop-pr-1")
+	require.NoError(t, err)
+	assert.Len(t, gitlabTokens, 1)
+	copied.Annotations[gitTokenRefreshAnnotation] = time.Now().Add(-time.Minute).UTC().Format(time.RFC3339)
+	require.NoError(t, c.Update(ctx, copied))
+	_, err = r.ensureGitCredentials(ctx, project, "acme-shop-pr-1")
+	require.NoError(t, err)
+	assert.Len(t, gitlabTokens, 2)
+	assert.Equal(t, []string{"1"}, revoked)
+
+	// Operator-side clones use the credentials directly
+	auth, err := r.gitAuth(ctx, &catalystv1alpha1.Environment{}, project, &project.Spec.Sources[0])
+	require.NoError(t, err)
+	assert.Equal(t, &githttp.BasicAuth{Username: "x-token-auth", Password: "bb-short-lived"}, auth)
+	auth, err = r.gitAuth(ctx, &catalystv1alpha1.Environment{}, project, &project.Spec.Sources[1])
+	require.NoError(t, err)
+	assert.Equal(t, &githttp.BasicAuth{Username: "oauth2", Password: "glpat-maintainer"}, auth)
+	auth, err = r.gitAuth(ctx, &catalystv1alpha1.Environment{}, project, &project.Spec.Sources[2])
+	require.NoError(t, err)
+	assert.Nil(t, auth, "projects without an installation clone anonymously")
+
+	project.Spec.Sources[0].CredentialsSecret = "missing"
+	_, err = r.ensureGitCredentials(ctx, project, "acme-shop-pr-2")
+	assert.Error(t, err)
+	_, err = r.gitAuth(ctx, &catalystv1alpha1.Environment{}, project, &project.Spec.Sources[0])
+	assert.ErrorContains(t, err, "credentials Secret missing of source app not found")
+
+	// GitHub sources only authenticate with the App installation
+	project.Spec.Sources = []catalystv1alpha1.SourceConfig{{Name: "web", RepositoryURL: "https://github.com/acme/web", CredentialsSecret: "gitlab"}}
+	_, err = r.ensureGitCredentials(ctx, project, "acme-shop-pr-1")
+	assert.ErrorContains(t, err, "GitHub App installation")
+}
+
+func TestRevokeGitTokens(t *testing.T) {
+	var minted int
+	var revoked []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			minted++
+			_, _ = fmt.Fprintf(w, `{"id":%d,"token":"gl-short-lived"}`, minted)
+		case http.MethodDelete:
+			assert.Equal(t, "glpat-maintainer", r.Header.Get("PRIVATE-TOKEN"))
+			assert.Equal(t, "/api/v4/projects/acme%2Fcharts/access_tokens/"+path.Base(r.URL.Path), r.URL.EscapedPath())
+			revoked = append(revoked, path.Base(r.URL.Path))
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+
+	charts := catalystv1alpha1.SourceConfig{Name: "charts", RepositoryURL: server.URL + "/acme/charts.git", Provider: gitProviderGitLab, CredentialsSecret: "gitlab"}
+	project := &catalystv1alpha1.Project{
+		ObjectMeta: metav1.ObjectMeta{Name: "shop", Namespace: "acme"},
+		Spec:       catalystv1alpha1.ProjectSpec{Sources: []catalystv1alpha1.SourceConfig{charts}},
+	}
+	c := newFakeClientBuilder().WithObjects(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "gitlab", Namespace: "acme"},
+		Data:       map[string][]byte{gitTokenKey: []byte("glpat-maintainer")},
+	}).Build()
+	r := &EnvironmentReconciler{Client: c, Scheme: testScheme}
+	ctx := context.Background()
+	key := client.ObjectKey{Name: "git-credentials-charts", Namespace: "acme-shop-pr-1"}
+
+	// Removing the source revokes and deletes its token
+	_, err := r.ensureGitCredentials(ctx, project, "acme-shop-pr-1")
+	require.NoError(t, err)
+	project.Spec.Sources = nil
+	_, err = r.ensureGitCredentials(ctx, project, "acme-shop-pr-1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1"}, revoked)
+	assert.True(t, apierrors.IsNotFound(c.Get(ctx, key, &corev1.Secret{})))
+
+	// Deleting the environment revokes the tokens of its namespace
+	project.Spec.Sources = []catalystv1alpha1.SourceConfig{charts}
+	_, err = r.ensureGitCredentials(ctx, project, "acme-shop-pr-1")
+	require.NoError(t, err)
+	r.revokeGitTokens(ctx, project, "acme-shop-pr-1")
+	assert.Equal(t, []string{"1", "2"}, revoked)
+}
+
+func TestGitAuthInstallationToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/git-token/12345", r.URL.Path)
+		assert.Equal(t, "Bearer sa-token", r.Header.Get("Authorization"))
+		_, _ = w.Write([]byte("ghs_installation\n"))
+	}))
+	defer server.Close()
+	t.Setenv("CATALYST_WEB_URL", server.URL)
+
+	var tokenNamespace string
+	c := newFakeClientBuilder().WithInterceptorFuncs(interceptor.Funcs{
+		SubResourceCreate: func(_ context.Context, _ client.Client, _ string, obj client.Object, subResourceObj client.Object, _ ...client.SubResourceCreateOption) error {
+			tokenNamespace = obj.GetNamespace()
+			subResourceObj.(*authenticationv1.TokenRequest).Status.Token = "sa-token"
+			return nil
+		},
+	}).Build()
+	r := &EnvironmentReconciler{Client: c, Scheme: testScheme}
+	project := &catalystv1alpha1.Project{Spec: catalystv1alpha1.ProjectSpec{GitHubInstallationId: "12345"}}
+	env := &catalystv1alpha1.Environment{ObjectMeta: metav1.ObjectMeta{Name: "pr-1", Labels: map[string]string{
+		"catalyst.dev/team": "acme", "catalyst.dev/project": "shop", "catalyst.dev/environment": "pr-1",
+	}}}
+	source := &catalystv1alpha1.SourceConfig{Name: "web", RepositoryURL: "https://github.com/acme/web"}
+
+	auth, err := r.gitAuth(context.Background(), env, project, source)
+	require.NoError(t, err)
+	assert.Equal(t, &githttp.BasicAuth{Username: "x-access-token", Password: "ghs_installation"}, auth)
+	assert.Equal(t, GenerateEnvironmentNamespace("acme", "shop", "pr-1"), tokenNamespace, "authenticates as the environment namespace")
+
+	// Without the hierarchy the clone fails instead of going unauthenticated
+	_, err = r.gitAuth(context.Background(), &catalystv1alpha1.Environment{}, project, source)
+	assert.ErrorContains(t, err, "hierarchy")
+}
+
+func TestResolveCommit(t *testing.T) {
+	fs := memfs.New()
+	repo, err := git.Init(memory.NewStorage(), fs)
+	require.NoError(t, err)
+	worktree, err := repo.Worktree()
+	require.NoError(t, err)
+	require.NoError(t, util.WriteFile(fs, "README.md", []byte("readme"), 0644))
+	_, err = worktree.Add("README.md")
+	require.NoError(t, err)
+	hash, err := worktree.Commit("init", &git.CommitOptions{
+		Author: &object.Signature{Name: "dev", Email: "dev@example.com", When: time.Now()},
+	})
+	require.NoError(t, err)
+
+	resolved, err := resolveCommit(repo, hash.String())
+	require.NoError(t, err)
+	assert.Equal(t, hash, resolved)
+
+	// Bitbucket webhooks report abbreviated hashes
+	resolved, err = resolveCommit(repo, hash.String()[:12])
+	require.NoError(t, err)
+	assert.Equal(t, hash, resolved)
+
+	_, err = resolveCommit(repo, "0123456789ab")
+	assert.Error(t, err)
+}
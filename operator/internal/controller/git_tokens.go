@@ -0,0 +1,273 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+	"github.com/ncrmro/catalyst/operator/internal/operatorconfig"
+)
+
+// Short-lived git tokens: the credentials Secret of a source stays in the Project namespace,
+// where only the operator reads it. Environment namespaces get tokens minted from it, which
+// expire on their own: a GitLab project access token with read_repository scope valid for
+// a day or two, or a Bitbucket OAuth access token (client credentials grant) valid for two
+// hours. Reconciles mint a new token once half the lifetime of the current one has passed.
+// GitLab tokens are revoked when renewed, when their source is removed from the Project and
+// when the environment is deleted.
+
+const (
+	// Keys of a Bitbucket OAuth consumer in SourceConfig.CredentialsSecret
+	gitClientIDKey     = "clientId"
+	gitClientSecretKey = "clientSecret"
+
+	// gitTokenRefreshAnnotation is when the minted token of a copied credentials Secret is renewed
+	gitTokenRefreshAnnotation = "catalyst.dev/refresh-at"
+	// gitTokenIDAnnotation identifies a minted GitLab token, revoked when it is renewed
+	gitTokenIDAnnotation = "catalyst.dev/token-id"
+	// gitTokenRepositoryAnnotation and gitTokenCredentialsAnnotation record the repository and
+	// credentials Secret a GitLab token was minted with, to revoke it after its source is gone
+	gitTokenRepositoryAnnotation  = "catalyst.dev/token-repository"
+	gitTokenCredentialsAnnotation = "catalyst.dev/token-credentials"
+	// gitCredentialsLabel marks the Secrets of minted tokens with the name of their source
+	gitCredentialsLabel = "catalyst.dev/git-credentials"
+
+	// gitCredentialsResyncInterval is how often environments with minted tokens are reconciled
+	gitCredentialsResyncInterval = 30 * time.Minute
+
+	// gitTokenTimeout bounds the provider API calls minting a token
+	gitTokenTimeout = 30 * time.Second
+)
+
+// bitbucketTokenURL is the OAuth token endpoint of Bitbucket Cloud
+var bitbucketTokenURL = "https://bitbucket.org/site/oauth2/access_token"
+
+// gitToken is a token minted for the clones of an environment namespace
+type gitToken struct {
+	Username  string
+	Token     string
+	ExpiresAt time.Time
+	// ID is the provider's identifier of the token, if it can be revoked
+	ID string
+}
+
+// gitTokenHTTPClient calls the provider APIs
+var gitTokenHTTPClient = &http.Client{Timeout: gitTokenTimeout}
+
+// mintGitToken mints a token for the clones of source in namespace from its credentials
+// Secret
+func mintGitToken(ctx context.Context, source *catalystv1alpha1.SourceConfig, credentials *corev1.Secret, namespace string, now time.Time) (gitToken, error) {
+	switch gitProvider(source) {
+	case gitProviderGitLab:
+		return mintGitLabToken(ctx, source, credentials, namespace, now)
+	case gitProviderBitbucket:
+		return mintBitbucketToken(ctx, source, credentials, now)
+	default:
+		return gitToken{}, fmt.Errorf("source %s: github sources clone with the GitHub App installation, credentialsSecret is only supported for gitlab and bitbucket", source.Name)
+	}
+}
+
+// mintGitLabToken creates a project access token with the credentials Secret token. That
+// token needs the api scope and the Maintainer role (or higher) on the project, to create and
+// revoke project access tokens. On gitlab.com project access tokens require the Premium or
+// Ultimate tier; self-managed instances offer them on every tier.
+func mintGitLabToken(ctx context.Context, source *catalystv1alpha1.SourceConfig, credentials *corev1.Secret, namespace string, now time.Time) (gitToken, error) {
+	token := string(credentials.Data[gitTokenKey])
+	if token == "" {
+		return gitToken{}, fmt.Errorf("credentials Secret %s of source %s has no %q", credentials.Name, source.Name, gitTokenKey)
+	}
+	repo, err := url.Parse(source.RepositoryURL)
+	if err != nil {
+		return gitToken{}, fmt.Errorf("invalid repository URL of source %s: %w", source.Name, err)
+	}
+	projectPath := strings.TrimSuffix(strings.Trim(repo.Path, "/"), ".git")
+	endpoint := fmt.Sprintf("%s://%s/api/v4/projects/%s/access_tokens", repo.Scheme, repo.Host, url.PathEscape(projectPath))
+
+	// GitLab tokens expire at the start of a day (UTC); two days out keeps at least one
+	expiresAt := now.UTC().Truncate(24*time.Hour).AddDate(0, 0, 2)
+	body, err := json.Marshal(map[string]interface{}{
+		"name":         "catalyst-" + namespace,
+		"scopes":       []string{"read_repository"},
+		"access_level": 20, // Reporter
+		"expires_at":   expiresAt.Format(time.DateOnly),
+	})
+	if err != nil {
+		return gitToken{}, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return gitToken{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("PRIVATE-TOKEN", token)
+	var result struct {
+		ID    int64  `json:"id"`
+		Token string `json:"token"`
+	}
+	if err := doGitTokenRequest(req, "gitlab", &result); err != nil {
+		return gitToken{}, fmt.Errorf("failed to mint a token for source %s: %w", source.Name, err)
+	}
+	return gitToken{Username: gitDefaultUsername(gitProviderGitLab), Token: result.Token, ExpiresAt: expiresAt, ID: fmt.Sprint(result.ID)}, nil
+}
+
+// revokeGitLabToken revokes a project access token minted by mintGitLabToken
+func revokeGitLabToken(ctx context.Context, source *catalystv1alpha1.SourceConfig, credentials *corev1.Secret, id string) error {
+	repo, err := url.Parse(source.RepositoryURL)
+	if err != nil {
+		return err
+	}
+	projectPath := strings.TrimSuffix(strings.Trim(repo.Path, "/"), ".git")
+	endpoint := fmt.Sprintf("%s://%s/api/v4/projects/%s/access_tokens/%s", repo.Scheme, repo.Host, url.PathEscape(projectPath), url.PathEscape(id))
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("PRIVATE-TOKEN", string(credentials.Data[gitTokenKey]))
+	return doGitTokenRequest(req, "gitlab", nil)
+}
+
+// revokeMintedGitToken revokes the GitLab token of a Secret written by copyGitCredentials,
+// with the credentials Secret it was minted with. Secrets without a revocable token are skipped.
+func revokeMintedGitToken(ctx context.Context, c client.Client, projectNamespace string, secret *corev1.Secret) error {
+	id := secret.Annotations[gitTokenIDAnnotation]
+	source := &catalystv1alpha1.SourceConfig{
+		Name:              secret.Labels[gitCredentialsLabel],
+		RepositoryURL:     secret.Annotations[gitTokenRepositoryAnnotation],
+		CredentialsSecret: secret.Annotations[gitTokenCredentialsAnnotation],
+	}
+	if id == "" || source.RepositoryURL == "" || source.CredentialsSecret == "" {
+		return nil
+	}
+	credentials := &corev1.Secret{}
+	if err := c.Get(ctx, client.ObjectKey{Name: source.CredentialsSecret, Namespace: projectNamespace}, credentials); err != nil {
+		return fmt.Errorf("failed to read credentials of source %s: %w", source.Name, err)
+	}
+	return revokeGitLabToken(ctx, source, credentials, id)
+}
+
+// mintBitbucketToken exchanges the OAuth consumer of the credentials Secret for an access
+// token (client credentials grant)
+func mintBitbucketToken(ctx context.Context, source *catalystv1alpha1.SourceConfig, credentials *corev1.Secret, now time.Time) (gitToken, error) {
+	clientID, clientSecret := string(credentials.Data[gitClientIDKey]), string(credentials.Data[gitClientSecretKey])
+	if clientID == "" || clientSecret == "" {
+		return gitToken{}, fmt.Errorf("credentials Secret %s of bitbucket source %s needs an OAuth consumer (%q and %q)", credentials.Name, source.Name, gitClientIDKey, gitClientSecretKey)
+	}
+	form := url.Values{"grant_type": {"client_credentials"}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, bitbucketTokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return gitToken{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(clientID, clientSecret)
+	var result struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := doGitTokenRequest(req, "bitbucket", &result); err != nil {
+		return gitToken{}, fmt.Errorf("failed to mint a token for source %s: %w", source.Name, err)
+	}
+	return gitToken{
+		Username:  gitDefaultUsername(gitProviderBitbucket),
+		Token:     result.AccessToken,
+		ExpiresAt: now.Add(time.Duration(result.ExpiresIn) * time.Second),
+	}, nil
+}
+
+// doGitTokenRequest sends a provider API request, decoding the JSON response into result
+func doGitTokenRequest(req *http.Request, provider string, result interface{}) error {
+	resp, err := gitTokenHTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s: unexpected status code %d: %s", provider, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	if result == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("%s: invalid response: %w", provider, err)
+	}
+	return nil
+}
+
+// gitTokenRefreshAt is when a token minted at now is renewed: half way through its lifetime
+func gitTokenRefreshAt(token gitToken, now time.Time) time.Time {
+	return now.Add(token.ExpiresAt.Sub(now) / 2)
+}
+
+// installationToken fetches a GitHub App installation token for operator-side clones from
+// the Catalyst web API, authenticating as the environment namespace the way its pods do.
+// The empty token means the project has no installation: public repositories only.
+func (r *EnvironmentReconciler) installationToken(ctx context.Context, env *catalystv1alpha1.Environment, project *catalystv1alpha1.Project) (string, error) {
+	installationID := project.Spec.GitHubInstallationId
+	if installationID == "" {
+		if !operatorconfig.Current().EnablePATFallback {
+			return "", nil
+		}
+		installationID = "pat"
+	}
+	hierarchy := ExtractNamespaceHierarchy(env.Labels)
+	if hierarchy == nil {
+		return "", fmt.Errorf("environment %s has no namespace hierarchy labels to authenticate GitHub clones with", env.Name)
+	}
+	namespace := GenerateEnvironmentNamespace(hierarchy.Team, hierarchy.Project, hierarchy.Environment)
+
+	// The web API reviews the token and maps its namespace to the project's installation
+	sa := &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: "default", Namespace: namespace}}
+	expiration := serviceAccountTokenExpirationSeconds
+	request := &authenticationv1.TokenRequest{Spec: authenticationv1.TokenRequestSpec{ExpirationSeconds: &expiration}}
+	if err := r.SubResource("token").Create(ctx, sa, request); err != nil {
+		return "", fmt.Errorf("failed to request a ServiceAccount token of %s: %w", namespace, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, CatalystWebURL()+"/api/git-token/"+url.PathEscape(installationID), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+request.Status.Token)
+	resp, err := gitTokenHTTPClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch a GitHub installation token: %w", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to fetch a GitHub installation token: unexpected status code %d", resp.StatusCode)
+	}
+	token := strings.TrimSpace(string(body))
+	if token == "" {
+		return "", fmt.Errorf("the Catalyst web API returned an empty GitHub installation token")
+	}
+	return token, nil
+}
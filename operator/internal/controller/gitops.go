@@ -0,0 +1,475 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+	"github.com/ncrmro/catalyst/operator/internal/operatorconfig"
+)
+
+// GitOps handoff (deploymentMode: gitops):
+// Instead of running Helm itself, the operator renders a GitOps object pointing at the
+// resolved commit and values, and reports its sync status on the GitOpsSynced condition.
+// The engine is selected with GITOPS_ENGINE:
+//   - "argocd" (default): an Argo CD Application in ARGOCD_NAMESPACE (default "argocd"),
+//     in Argo CD project ARGOCD_PROJECT (default "default"), deploying into the environment namespace.
+//   - "flux": a GitRepository plus a HelmRelease (helm templates) or Kustomization (other
+//     templates) in the environment namespace.
+//
+// Builds still run in the operator and are passed as values (global.images). Repository
+// credentials must be configured in the GitOps engine. Render-time guardrails do not apply,
+// since the engine renders the chart.
+
+const (
+	gitopsEngineArgoCD = "argocd"
+	gitopsEngineFlux   = "flux"
+
+	// conditionGitOpsSynced reports the sync status of the GitOps object
+	conditionGitOpsSynced = "GitOpsSynced"
+
+	// gitopsPollInterval re-reads the sync status, since GitOps objects are not watched
+	gitopsPollInterval = 15 * time.Second
+	// argoCascadeFinalizer makes Argo CD delete the deployed resources with the Application
+	argoCascadeFinalizer = "resources-finalizer.argocd.argoproj.io"
+)
+
+// +kubebuilder:rbac:groups=argoproj.io,resources=applications,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=source.toolkit.fluxcd.io,resources=gitrepositories,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=helm.toolkit.fluxcd.io,resources=helmreleases,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=kustomize.toolkit.fluxcd.io,resources=kustomizations,verbs=get;list;watch;create;update;patch;delete
+
+var (
+	argoApplicationGVK   = schema.GroupVersionKind{Group: "argoproj.io", Version: "v1alpha1", Kind: "Application"}
+	fluxGitRepositoryGVK = schema.GroupVersionKind{Group: "source.toolkit.fluxcd.io", Version: "v1", Kind: "GitRepository"}
+	fluxHelmReleaseGVK   = schema.GroupVersionKind{Group: "helm.toolkit.fluxcd.io", Version: "v2", Kind: "HelmRelease"}
+	fluxKustomizationGVK = schema.GroupVersionKind{Group: "kustomize.toolkit.fluxcd.io", Version: "v1", Kind: "Kustomization"}
+
+	commitShaPattern = regexp.MustCompile(`^[0-9a-f]{40}$`)
+)
+
+// gitopsConfig is the operator-wide GitOps engine configuration
+type gitopsConfig struct {
+	Engine        string
+	ArgoNamespace string
+	ArgoProject   string
+}
+
+// currentGitOpsConfig reads the gitops section of the operator configuration
+func currentGitOpsConfig() (gitopsConfig, error) {
+	gitops := operatorconfig.Current().GitOps
+	cfg := gitopsConfig{
+		Engine:        gitops.Engine,
+		ArgoNamespace: gitops.ArgoCDNamespace,
+		ArgoProject:   gitops.ArgoCDProject,
+	}
+	switch cfg.Engine {
+	case "":
+		cfg.Engine = gitopsEngineArgoCD
+	case gitopsEngineArgoCD, gitopsEngineFlux:
+	default:
+		return cfg, fmt.Errorf("unsupported gitops.engine %q", cfg.Engine)
+	}
+	if cfg.ArgoNamespace == "" {
+		cfg.ArgoNamespace = "argocd"
+	}
+	if cfg.ArgoProject == "" {
+		cfg.ArgoProject = "default"
+	}
+	return cfg, nil
+}
+
+// gitopsSource is the resolved git location the GitOps engine deploys from
+type gitopsSource struct {
+	RepoURL  string
+	Revision string
+	Path     string
+}
+
+// resolveGitOpsSource resolves the template source to a repository, revision and path.
+// The revision is the environment's commit, else its branch, else the project default branch.
+func resolveGitOpsSource(env *catalystv1alpha1.Environment, project *catalystv1alpha1.Project, template *catalystv1alpha1.EnvironmentTemplateSpec) (gitopsSource, error) {
+	sourceRef := template.SourceRef
+	if sourceRef == "" && len(project.Spec.Sources) > 0 {
+		sourceRef = project.Spec.Sources[0].Name
+	}
+	var sourceConfig *catalystv1alpha1.SourceConfig
+	for _, s := range project.Spec.Sources {
+		if s.Name == sourceRef {
+			sourceConfig = &s
+			break
+		}
+	}
+	if sourceConfig == nil {
+		return gitopsSource{}, fmt.Errorf("source ref '%s' not found in project", sourceRef)
+	}
+
+	src := gitopsSource{RepoURL: sourceConfig.RepositoryURL, Revision: sourceConfig.Branch, Path: strings.Trim(template.Path, "/")}
+	for _, s := range env.Spec.Sources {
+		if s.Name != sourceRef {
+			continue
+		}
+		if s.CommitSha != "" && s.CommitSha != "HEAD" {
+			src.Revision = s.CommitSha
+		} else if s.Branch != "" {
+			src.Revision = s.Branch
+		}
+		break
+	}
+	if src.Revision == "" {
+		src.Revision = "HEAD"
+	}
+	if src.Path == "" {
+		src.Path = "."
+	}
+	return src, nil
+}
+
+// gitopsObjectName names the GitOps objects of an environment. Argo CD Applications share
+// one namespace, so they are named after the (unique) environment namespace.
+func gitopsObjectName(env *catalystv1alpha1.Environment, namespace string, cfg gitopsConfig) string {
+	if cfg.Engine == gitopsEngineArgoCD {
+		return namespace
+	}
+	return env.Name
+}
+
+// jsonValues converts Helm values to plain JSON types, as required by unstructured objects
+func jsonValues(vals map[string]interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(vals)
+	if err != nil {
+		return nil, err
+	}
+	out := map[string]interface{}{}
+	return out, json.Unmarshal(data, &out)
+}
+
+// desiredArgoApplication renders the Argo CD Application for an environment
+func desiredArgoApplication(env *catalystv1alpha1.Environment, namespace string, cfg gitopsConfig, src gitopsSource, isHelm bool, values map[string]interface{}) *unstructured.Unstructured {
+	source := map[string]interface{}{
+		"repoURL":        src.RepoURL,
+		"targetRevision": src.Revision,
+		"path":           src.Path,
+	}
+	if isHelm {
+		source["helm"] = map[string]interface{}{
+			"releaseName":  env.Name,
+			"valuesObject": values,
+		}
+	}
+
+	app := &unstructured.Unstructured{}
+	app.SetGroupVersionKind(argoApplicationGVK)
+	app.SetName(gitopsObjectName(env, namespace, cfg))
+	app.SetNamespace(cfg.ArgoNamespace)
+	app.SetLabels(map[string]string{
+		"catalyst.dev/environment": sanitizeLabelValue(env.Name),
+	})
+	app.SetFinalizers([]string{argoCascadeFinalizer})
+	app.Object["spec"] = map[string]interface{}{
+		"project": cfg.ArgoProject,
+		"source":  source,
+		"destination": map[string]interface{}{
+			"server":    "https://kubernetes.default.svc",
+			"namespace": namespace,
+		},
+		"syncPolicy": map[string]interface{}{
+			"automated": map[string]interface{}{"prune": true, "selfHeal": true},
+		},
+	}
+	return app
+}
+
+// desiredFluxObjects renders the Flux GitRepository and the HelmRelease or Kustomization
+// deploying from it. The last object is the one whose Ready condition is tracked.
+func desiredFluxObjects(env *catalystv1alpha1.Environment, namespace string, cfg gitopsConfig, src gitopsSource, isHelm bool, values map[string]interface{}) []*unstructured.Unstructured {
+	name := gitopsObjectName(env, namespace, cfg)
+	labels := map[string]string{"catalyst.dev/environment": sanitizeLabelValue(env.Name)}
+
+	ref := map[string]interface{}{"branch": src.Revision}
+	if commitShaPattern.MatchString(src.Revision) {
+		ref = map[string]interface{}{"commit": src.Revision}
+	}
+	repo := &unstructured.Unstructured{}
+	repo.SetGroupVersionKind(fluxGitRepositoryGVK)
+	repo.SetName(name)
+	repo.SetNamespace(namespace)
+	repo.SetLabels(labels)
+	repo.Object["spec"] = map[string]interface{}{
+		"url":      src.RepoURL,
+		"interval": "1m",
+		"ref":      ref,
+	}
+
+	sourceRef := map[string]interface{}{"kind": fluxGitRepositoryGVK.Kind, "name": name}
+	deploy := &unstructured.Unstructured{}
+	deploy.SetName(name)
+	deploy.SetNamespace(namespace)
+	deploy.SetLabels(labels)
+	if isHelm {
+		deploy.SetGroupVersionKind(fluxHelmReleaseGVK)
+		deploy.Object["spec"] = map[string]interface{}{
+			"interval":    "5m",
+			"releaseName": env.Name,
+			"chart": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"chart":     fluxPath(src.Path),
+					"sourceRef": sourceRef,
+				},
+			},
+			"values": values,
+		}
+	} else {
+		deploy.SetGroupVersionKind(fluxKustomizationGVK)
+		deploy.Object["spec"] = map[string]interface{}{
+			"interval":        "5m",
+			"path":            fluxPath(src.Path),
+			"prune":           true,
+			"targetNamespace": namespace,
+			"sourceRef":       sourceRef,
+		}
+	}
+	return []*unstructured.Unstructured{repo, deploy}
+}
+
+// fluxPath formats a repository path the way Flux expects it ("./charts/web")
+func fluxPath(path string) string {
+	if path == "." {
+		return "./"
+	}
+	return "./" + strings.TrimPrefix(path, "./")
+}
+
+// gitopsSyncStatus derives readiness and a condition reason/message from a GitOps object
+func gitopsSyncStatus(obj *unstructured.Unstructured) (bool, string, string) {
+	if obj.GroupVersionKind() == argoApplicationGVK {
+		syncStatus, _, _ := unstructured.NestedString(obj.Object, "status", "sync", "status")
+		health, _, _ := unstructured.NestedString(obj.Object, "status", "health", "status")
+		message := fmt.Sprintf("Argo CD sync status %q, health %q", syncStatus, health)
+		if opMessage, _, _ := unstructured.NestedString(obj.Object, "status", "operationState", "message"); opMessage != "" {
+			message += ": " + opMessage
+		}
+		if syncStatus == "" {
+			return false, "Pending", "Waiting for Argo CD to reconcile the Application"
+		}
+		return syncStatus == "Synced" && health == "Healthy", syncStatus, message
+	}
+
+	conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	for _, c := range conditions {
+		cond, ok := c.(map[string]interface{})
+		if !ok || cond["type"] != "Ready" {
+			continue
+		}
+		reason, _ := cond["reason"].(string)
+		message, _ := cond["message"].(string)
+		if reason == "" {
+			reason = "Unknown"
+		}
+		return cond["status"] == string(metav1.ConditionTrue), reason, message
+	}
+	return false, "Pending", fmt.Sprintf("Waiting for Flux to reconcile the %s", obj.GetKind())
+}
+
+// setGitOpsCondition records the GitOpsSynced condition and the matching phase
+func (r *EnvironmentReconciler) setGitOpsCondition(ctx context.Context, env *catalystv1alpha1.Environment, synced bool, reason, message, phase string) error {
+	status := metav1.ConditionFalse
+	if synced {
+		status = metav1.ConditionTrue
+	}
+	changed := meta.SetStatusCondition(&env.Status.Conditions, metav1.Condition{
+		Type:               conditionGitOpsSynced,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: env.Generation,
+	})
+	if phase == "Failed" {
+		// Failed GitOps handoffs are missing sources or engines: configuration to fix
+		changed = r.setFailed(env, catalystv1alpha1.FailureReasonConfigInvalid, message) || changed
+	} else if env.Status.Phase != phase {
+		env.Status.Phase = phase
+		changed = true
+	}
+	if phase == "Ready" {
+		changed = recordDeploymentOutcome(env, catalystv1alpha1.DeploymentOutcomeReady, metav1.Now().Rfc3339Copy()) || changed
+	}
+	if changed {
+		return r.Status().Update(ctx, env)
+	}
+	return nil
+}
+
+// reconcileGitOpsModeWithStatus hands the deployment off to the configured GitOps engine
+func (r *EnvironmentReconciler) reconcileGitOpsModeWithStatus(ctx context.Context, env *catalystv1alpha1.Environment, project *catalystv1alpha1.Project, namespace string, template *catalystv1alpha1.EnvironmentTemplateSpec) (ctrl.Result, error) {
+	log := logf.FromContext(ctx)
+
+	if template == nil {
+		return ctrl.Result{}, fmt.Errorf("gitops mode requires a template")
+	}
+	cfg, err := currentGitOpsConfig()
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// Wait for default service account
+	sa := &corev1.ServiceAccount{}
+	if err := r.Get(ctx, client.ObjectKey{Name: "default", Namespace: namespace}, sa); err != nil {
+		if apierrors.IsNotFound(err) {
+			log.Info("Waiting for default ServiceAccount", "namespace", namespace)
+			return ctrl.Result{RequeueAfter: time.Second}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	// Builds run in the operator; the GitOps engine only deploys
+	var builtImages map[string]string
+	if len(template.Builds) > 0 {
+		if env.Status.Phase != "Building" && env.Status.Phase != "Ready" && env.Status.Phase != "Failed" {
+			env.Status.Phase = "Building"
+			if err := r.Status().Update(ctx, env); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+
+		builtImages, err = r.reconcileBuilds(ctx, env, project, namespace, template)
+		if err != nil {
+			log.Error(err, "Build failed")
+			_ = r.markFailed(ctx, env, catalystv1alpha1.FailureReasonBuildFailed, err)
+			return ctrl.Result{}, err
+		}
+		if builtImages == nil {
+			log.Info("Builds in progress...")
+			return ctrl.Result{RequeueAfter: workloadResyncInterval}, nil
+		}
+	}
+
+	src, err := resolveGitOpsSource(env, project, template)
+	if err != nil {
+		return ctrl.Result{}, r.setGitOpsCondition(ctx, env, false, "SourceNotFound", err.Error(), "Failed")
+	}
+
+	isHelm := template.Type == "helm"
+	var values map[string]interface{}
+	if isHelm {
+		vals, err := r.mergeHelmValues(template, env)
+		if err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to merge helm values: %w", err)
+		}
+		if len(builtImages) > 0 {
+			injectBuiltImages(vals, builtImages, log)
+		}
+		if values, err = jsonValues(vals); err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to encode helm values: %w", err)
+		}
+	}
+
+	var objects []*unstructured.Unstructured
+	if cfg.Engine == gitopsEngineArgoCD {
+		objects = []*unstructured.Unstructured{desiredArgoApplication(env, namespace, cfg, src, isHelm, values)}
+	} else {
+		objects = desiredFluxObjects(env, namespace, cfg, src, isHelm, values)
+	}
+	var current *unstructured.Unstructured
+	for _, obj := range objects {
+		if current, err = r.applyGitOpsObject(ctx, obj); err != nil {
+			if meta.IsNoMatchError(err) {
+				log.Info("GitOps engine not installed", "engine", cfg.Engine, "kind", obj.GetKind())
+				return ctrl.Result{}, r.setGitOpsCondition(ctx, env, false, "EngineNotInstalled",
+					fmt.Sprintf("%s is not installed (no %s API)", cfg.Engine, obj.GroupVersionKind().GroupKind()), "Failed")
+			}
+			return ctrl.Result{}, fmt.Errorf("failed to reconcile %s: %w", obj.GetKind(), err)
+		}
+	}
+
+	// Track the sync status of the object that deploys the environment
+	synced, reason, message := gitopsSyncStatus(current)
+	phase := "Provisioning"
+	if synced {
+		phase = "Ready"
+	}
+	if err := r.setGitOpsCondition(ctx, env, synced, reason, message, phase); err != nil {
+		return ctrl.Result{}, err
+	}
+	if !synced {
+		log.Info("Waiting for GitOps sync", "engine", cfg.Engine, "reason", reason)
+	}
+	// Keep polling so drift and failed syncs are reflected in status
+	return ctrl.Result{RequeueAfter: gitopsPollInterval}, nil
+}
+
+// applyGitOpsObject creates or updates a GitOps object and returns its current state.
+// Unlike patchOrUpdate it only replaces spec, labels and finalizers: Argo CD Applications
+// have no status subresource, so a full update would wipe the sync status.
+func (r *EnvironmentReconciler) applyGitOpsObject(ctx context.Context, desired *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	current := &unstructured.Unstructured{}
+	current.SetGroupVersionKind(desired.GroupVersionKind())
+	if err := r.Get(ctx, client.ObjectKeyFromObject(desired), current); err != nil {
+		if apierrors.IsNotFound(err) {
+			return desired, r.Create(ctx, desired)
+		}
+		return nil, err
+	}
+
+	current.Object["spec"] = desired.Object["spec"]
+	labels := current.GetLabels()
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	for k, v := range desired.GetLabels() {
+		labels[k] = v
+	}
+	current.SetLabels(labels)
+	for _, f := range desired.GetFinalizers() {
+		controllerutil.AddFinalizer(current, f)
+	}
+	return current, r.Update(ctx, current)
+}
+
+// deleteGitOpsApplication deletes the Argo CD Application of an environment, which lives
+// outside the environment namespace. Flux objects are removed with the namespace.
+func (r *EnvironmentReconciler) deleteGitOpsApplication(ctx context.Context, env *catalystv1alpha1.Environment, namespace string) error {
+	cfg, err := currentGitOpsConfig()
+	if err != nil || cfg.Engine != gitopsEngineArgoCD {
+		return err
+	}
+	app := &unstructured.Unstructured{}
+	app.SetGroupVersionKind(argoApplicationGVK)
+	app.SetName(gitopsObjectName(env, namespace, cfg))
+	app.SetNamespace(cfg.ArgoNamespace)
+	if err := r.Delete(ctx, app); err != nil && !apierrors.IsNotFound(err) && !meta.IsNoMatchError(err) {
+		return err
+	}
+	return nil
+}
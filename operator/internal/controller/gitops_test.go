@@ -0,0 +1,165 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+func gitopsTestProject() *catalystv1alpha1.Project {
+	return &catalystv1alpha1.Project{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"},
+		Spec: catalystv1alpha1.ProjectSpec{
+			Sources: []catalystv1alpha1.SourceConfig{
+				{Name: "web", RepositoryURL: "https://github.com/org/web.git", Branch: "main"},
+			},
+		},
+	}
+}
+
+func TestCurrentGitOpsConfig(t *testing.T) {
+	cfg, err := currentGitOpsConfig()
+	require.NoError(t, err)
+	assert.Equal(t, gitopsConfig{Engine: gitopsEngineArgoCD, ArgoNamespace: "argocd", ArgoProject: "default"}, cfg)
+
+	t.Setenv("GITOPS_ENGINE", "flux")
+	cfg, err = currentGitOpsConfig()
+	require.NoError(t, err)
+	assert.Equal(t, gitopsEngineFlux, cfg.Engine)
+}
+
+func TestResolveGitOpsSource(t *testing.T) {
+	project := gitopsTestProject()
+	template := &catalystv1alpha1.EnvironmentTemplateSpec{Type: "helm", Path: "/charts/web/"}
+	env := &catalystv1alpha1.Environment{}
+
+	src, err := resolveGitOpsSource(env, project, template)
+	require.NoError(t, err)
+	assert.Equal(t, gitopsSource{RepoURL: "https://github.com/org/web.git", Revision: "main", Path: "charts/web"}, src)
+
+	env.Spec.Sources = []catalystv1alpha1.EnvironmentSource{{Name: "web", CommitSha: "HEAD", Branch: "feature"}}
+	src, err = resolveGitOpsSource(env, project, template)
+	require.NoError(t, err)
+	assert.Equal(t, "feature", src.Revision)
+
+	sha := "0123456789abcdef0123456789abcdef01234567"
+	env.Spec.Sources[0].CommitSha = sha
+	src, err = resolveGitOpsSource(env, project, template)
+	require.NoError(t, err)
+	assert.Equal(t, sha, src.Revision)
+
+	template.SourceRef = "missing"
+	_, err = resolveGitOpsSource(env, project, template)
+	assert.Error(t, err)
+}
+
+func TestDesiredFluxObjects(t *testing.T) {
+	env := &catalystv1alpha1.Environment{ObjectMeta: metav1.ObjectMeta{Name: "pr-1"}}
+	cfg := gitopsConfig{Engine: gitopsEngineFlux}
+	sha := "0123456789abcdef0123456789abcdef01234567"
+
+	objs := desiredFluxObjects(env, "env-ns", cfg, gitopsSource{RepoURL: "https://x/y.git", Revision: sha, Path: "charts/web"}, true, map[string]interface{}{"replicas": float64(1)})
+	require.Len(t, objs, 2)
+	commit, _, _ := unstructured.NestedString(objs[0].Object, "spec", "ref", "commit")
+	assert.Equal(t, sha, commit)
+	assert.Equal(t, fluxHelmReleaseGVK, objs[1].GroupVersionKind())
+	chart, _, _ := unstructured.NestedString(objs[1].Object, "spec", "chart", "spec", "chart")
+	assert.Equal(t, "./charts/web", chart)
+
+	objs = desiredFluxObjects(env, "env-ns", cfg, gitopsSource{RepoURL: "https://x/y.git", Revision: "main", Path: "."}, false, nil)
+	branch, _, _ := unstructured.NestedString(objs[0].Object, "spec", "ref", "branch")
+	assert.Equal(t, "main", branch)
+	assert.Equal(t, fluxKustomizationGVK, objs[1].GroupVersionKind())
+	path, _, _ := unstructured.NestedString(objs[1].Object, "spec", "path")
+	assert.Equal(t, "./", path)
+}
+
+func TestGitOpsSyncStatus(t *testing.T) {
+	app := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	app.SetGroupVersionKind(argoApplicationGVK)
+	synced, reason, _ := gitopsSyncStatus(app)
+	assert.False(t, synced)
+	assert.Equal(t, "Pending", reason)
+
+	app.Object["status"] = map[string]interface{}{
+		"sync":   map[string]interface{}{"status": "Synced"},
+		"health": map[string]interface{}{"status": "Progressing"},
+	}
+	synced, reason, _ = gitopsSyncStatus(app)
+	assert.False(t, synced)
+	assert.Equal(t, "Synced", reason)
+
+	require.NoError(t, unstructured.SetNestedField(app.Object, "Healthy", "status", "health", "status"))
+	synced, _, _ = gitopsSyncStatus(app)
+	assert.True(t, synced)
+
+	hr := &unstructured.Unstructured{Object: map[string]interface{}{
+		"status": map[string]interface{}{
+			"conditions": []interface{}{
+				map[string]interface{}{"type": "Ready", "status": "False", "reason": "InstallFailed", "message": "boom"},
+			},
+		},
+	}}
+	hr.SetGroupVersionKind(fluxHelmReleaseGVK)
+	synced, reason, message := gitopsSyncStatus(hr)
+	assert.False(t, synced)
+	assert.Equal(t, "InstallFailed", reason)
+	assert.Equal(t, "boom", message)
+}
+
+func TestReconcileGitOpsModeArgoCD(t *testing.T) {
+	env := &catalystv1alpha1.Environment{
+		ObjectMeta: metav1.ObjectMeta{Name: "pr-1", Namespace: "default"},
+		Spec: catalystv1alpha1.EnvironmentSpec{
+			DeploymentMode: "gitops",
+		},
+	}
+	sa := &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: "default", Namespace: "env-ns"}}
+	c := newFakeClientBuilder().WithStatusSubresource(env).WithObjects(env, sa).Build()
+	r := &EnvironmentReconciler{Client: c, Scheme: testScheme}
+	ctx := context.Background()
+	template := &catalystv1alpha1.EnvironmentTemplateSpec{Type: "helm", Path: "charts/web"}
+
+	result, err := r.reconcileGitOpsModeWithStatus(ctx, env, gitopsTestProject(), "env-ns", template)
+	require.NoError(t, err)
+	assert.Equal(t, gitopsPollInterval, result.RequeueAfter)
+	assert.Equal(t, "Provisioning", env.Status.Phase)
+	cond := meta.FindStatusCondition(env.Status.Conditions, conditionGitOpsSynced)
+	require.NotNil(t, cond)
+	assert.Equal(t, metav1.ConditionFalse, cond.Status)
+
+	app := &unstructured.Unstructured{}
+	app.SetGroupVersionKind(argoApplicationGVK)
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "env-ns", Namespace: "argocd"}, app))
+	dest, _, _ := unstructured.NestedString(app.Object, "spec", "destination", "namespace")
+	assert.Equal(t, "env-ns", dest)
+	revision, _, _ := unstructured.NestedString(app.Object, "spec", "source", "targetRevision")
+	assert.Equal(t, "main", revision)
+	assert.Contains(t, app.GetFinalizers(), argoCascadeFinalizer)
+
+	// Argo CD reports the sync; the next reconcile keeps the status and marks the env Ready
+	app.Object["status"] = map[string]interface{}{
+		"sync":   map[string]interface{}{"status": "Synced"},
+		"health": map[string]interface{}{"status": "Healthy"},
+	}
+	require.NoError(t, c.Update(ctx, app))
+
+	_, err = r.reconcileGitOpsModeWithStatus(ctx, env, gitopsTestProject(), "env-ns", template)
+	require.NoError(t, err)
+	assert.Equal(t, "Ready", env.Status.Phase)
+	assert.True(t, meta.IsStatusConditionTrue(env.Status.Conditions, conditionGitOpsSynced))
+
+	require.NoError(t, r.deleteGitOpsApplication(ctx, env, "env-ns"))
+	// The cascade finalizer keeps the Application until Argo CD has pruned its resources
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "env-ns", Namespace: "argocd"}, app))
+	assert.NotNil(t, app.GetDeletionTimestamp())
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	"helm.sh/helm/v3/pkg/postrender"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+	"github.com/ncrmro/catalyst/operator/internal/guardrails"
+)
+
+const (
+	// conditionGuardrailViolation is set on the Environment when rendered output violates the guardrails
+	conditionGuardrailViolation = "GuardrailViolation"
+	// guardrailRetryInterval re-renders violating environments, since chart and compose
+	// sources can change in git without an Environment update
+	guardrailRetryInterval = 5 * time.Minute
+)
+
+// guardrailsPostRenderer returns the Helm post-renderer enforcing the guardrails, or nil if none are configured
+func guardrailsPostRenderer() postrender.PostRenderer {
+	policy := guardrails.Current()
+	if !policy.Enabled() {
+		return nil
+	}
+	return &guardrails.PostRenderer{Policy: policy}
+}
+
+// recordGuardrailResult reports the outcome of a deployment mode reconcile on the
+// GuardrailViolation condition. Violations fail the environment without retry backoff;
+// handled is true when err was a violation and has been recorded.
+func (r *EnvironmentReconciler) recordGuardrailResult(ctx context.Context, env *catalystv1alpha1.Environment, reconcileErr error) (bool, error) {
+	violation, isViolation := guardrails.IsViolation(reconcileErr)
+	if !isViolation && (reconcileErr != nil || meta.FindStatusCondition(env.Status.Conditions, conditionGuardrailViolation) == nil) {
+		return false, nil
+	}
+
+	condition := metav1.Condition{
+		Type:               conditionGuardrailViolation,
+		Status:             metav1.ConditionFalse,
+		Reason:             "Compliant",
+		Message:            "Rendered resources comply with the platform guardrails",
+		ObservedGeneration: env.Generation,
+	}
+	changed := false
+	if isViolation {
+		logf.FromContext(ctx).Info("Rendered resources violate guardrails", "violations", violation.Error())
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "DisallowedFields"
+		condition.Message = violation.Error()
+		changed = r.setFailed(env, catalystv1alpha1.FailureReasonConfigInvalid, violation.Error())
+	}
+
+	if meta.SetStatusCondition(&env.Status.Conditions, condition) || changed {
+		if err := r.Status().Update(ctx, env); err != nil {
+			return isViolation, err
+		}
+	}
+	return isViolation, nil
+}
@@ -0,0 +1,48 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+	"github.com/ncrmro/catalyst/operator/internal/guardrails"
+)
+
+func TestRecordGuardrailResult(t *testing.T) {
+	env := &catalystv1alpha1.Environment{ObjectMeta: metav1.ObjectMeta{Name: "pr-1", Namespace: "team"}}
+	c := newFakeClientBuilder().WithStatusSubresource(env).WithObjects(env).Build()
+	r := &EnvironmentReconciler{Client: c, Scheme: testScheme}
+	ctx := context.Background()
+
+	// Unrelated errors and successes before any violation leave the condition unset
+	handled, err := r.recordGuardrailResult(ctx, env, errors.New("clone failed"))
+	require.NoError(t, err)
+	assert.False(t, handled)
+	handled, err = r.recordGuardrailResult(ctx, env, nil)
+	require.NoError(t, err)
+	assert.False(t, handled)
+	assert.Nil(t, meta.FindStatusCondition(env.Status.Conditions, conditionGuardrailViolation))
+
+	violation := guardrails.AsError([]guardrails.Violation{{Object: "Service/web", Message: "Service type LoadBalancer is not allowed"}})
+	handled, err = r.recordGuardrailResult(ctx, env, fmt.Errorf("helm install failed: %w", violation))
+	require.NoError(t, err)
+	assert.True(t, handled)
+	assert.Equal(t, "Failed", env.Status.Phase)
+	cond := meta.FindStatusCondition(env.Status.Conditions, conditionGuardrailViolation)
+	require.NotNil(t, cond)
+	assert.Equal(t, metav1.ConditionTrue, cond.Status)
+	assert.Contains(t, cond.Message, "Service/web")
+
+	// A compliant render clears the condition
+	handled, err = r.recordGuardrailResult(ctx, env, nil)
+	require.NoError(t, err)
+	assert.False(t, handled)
+	assert.True(t, meta.IsStatusConditionFalse(env.Status.Conditions, conditionGuardrailViolation))
+}
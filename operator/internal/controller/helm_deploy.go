@@ -18,7 +18,10 @@ package controller
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -29,20 +32,39 @@ import (
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-logr/logr"
+	"go.opentelemetry.io/otel/attribute"
 	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart"
 	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/postrender"
 	"helm.sh/helm/v3/pkg/release"
 	"helm.sh/helm/v3/pkg/storage/driver"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/discovery/cached/memory"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/restmapper"
 	"k8s.io/client-go/tools/clientcmd"
 	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 
 	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+	"github.com/ncrmro/catalyst/operator/internal/guardrails"
+	"github.com/ncrmro/catalyst/operator/internal/operatorconfig"
+	"github.com/ncrmro/catalyst/operator/internal/tracing"
+)
+
+const (
+	// helmInputsHashLabel is the release label holding the hash of the chart, values and
+	// post-render inputs the release was deployed from
+	helmInputsHashLabel = "catalyst.dev/inputs-hash"
+	// helmMaxHistory caps the revisions kept per release
+	helmMaxHistory = 10
 )
 
 var (
@@ -52,69 +74,65 @@ var (
 	cleanupMutex sync.Mutex
 )
 
-// ReconcileHelmMode handles the reconciliation for Helm deployment mode.
-func (r *EnvironmentReconciler) ReconcileHelmMode(ctx context.Context, env *catalystv1alpha1.Environment, project *catalystv1alpha1.Project, namespace string, template *catalystv1alpha1.EnvironmentTemplate, builtImages map[string]string) (bool, error) {
+// ReconcileHelmMode handles the reconciliation for Helm deployment mode. With HelmOperations
+// the install or upgrade runs in the background: the reconcile that starts it and those
+// observing it return not ready, and the one collecting it checks the release.
+func (r *EnvironmentReconciler) ReconcileHelmMode(ctx context.Context, env *catalystv1alpha1.Environment, project *catalystv1alpha1.Project, namespace string, template *catalystv1alpha1.EnvironmentTemplateSpec, builtImages map[string]string) (bool, error) {
 	log := logf.FromContext(ctx)
 
+	releaseName := env.Name // Use environment name as release name
+	releaseKey := types.NamespacedName{Namespace: namespace, Name: releaseName}
+	if r.HelmOperations != nil {
+		if op, found := r.HelmOperations.Observe(releaseKey); found {
+			if !op.finished {
+				log.V(1).Info("Helm operation in progress", "release", releaseName, "operation", op.kind, "startedAt", op.startedAt)
+				return false, r.recordOperationInProgress(ctx, env, &op)
+			}
+			if err := r.recordOperationInProgress(ctx, env, nil); err != nil {
+				return false, err
+			}
+			if op.err != nil {
+				return false, op.err
+			}
+			recordHelmOperationEvent(r, env, op.kind, releaseName)
+			return r.helmReleaseDeployed(ctx, namespace, releaseName)
+		}
+	}
+	// No operation is tracked for the release, including one lost to an operator restart
+	if err := r.recordOperationInProgress(ctx, env, nil); err != nil {
+		return false, err
+	}
+
 	// Clean up stale temporary directories (older than 24 hours)
 	// This helps prevent disk space accumulation from failed deployments
 	cleanupStaleTempDirs(log)
 
 	if template == nil {
-		return false, fmt.Errorf("helm template is required")
+		return false, withFailureReason(catalystv1alpha1.FailureReasonConfigInvalid, fmt.Errorf("helm template is required"))
 	}
 
-	// Prepare source (local path or clone from git)
+	// Prepare source (local path or clone from git); a background operation removes it
 	sourcePath, cleanup, err := r.prepareSource(ctx, env, project, template)
-	if cleanup != nil {
-		defer cleanup()
-	}
+	defer func() {
+		if cleanup != nil {
+			cleanup()
+		}
+	}()
 	if err != nil {
 		log.Error(err, "Failed to prepare source")
 		return false, err
 	}
 
-	// Initialize Helm Action Config
-	// We need to construct a REST Client Getter from the controller's config
-	cfg := r.Config
-	if cfg == nil {
-		return false, fmt.Errorf("reconciler config is nil")
-	}
-
-	// Validate HELM_DRIVER to avoid passing unexpected values to Helm.
-	helmDriver := os.Getenv("HELM_DRIVER")
-	switch helmDriver {
-	case "", "secret", "configmap", "memory":
-		// allowed values; use as-is (empty string lets Helm pick its default)
-	default:
-		log.Info("Invalid HELM_DRIVER value detected, defaulting to 'secret'", "value", helmDriver)
-		helmDriver = "secret"
-	}
-	// Ensure the process environment reflects the validated/sanitized value
-	// for consistency across subsequent Helm operations in this process.
-	if err := os.Setenv("HELM_DRIVER", helmDriver); err != nil {
-		log.Error(err, "Failed to set HELM_DRIVER environment variable")
-	}
-
-	actionConfig := new(action.Configuration)
-	if err := actionConfig.Init(
-		&genericRESTClientGetter{cfg: cfg, namespace: namespace},
-		namespace,
-		helmDriver,
-		func(format string, v ...interface{}) {
-			log.Info(fmt.Sprintf(format, v...))
-		},
-	); err != nil {
+	actionConfig, err := r.helmActionConfig(ctx, namespace)
+	if err != nil {
 		return false, err
 	}
 
-	releaseName := env.Name // Use environment name as release name
-
 	// Values
 	// Merge values from template.Values and env.Spec.Config
 	vals, err := r.mergeHelmValues(template, env)
 	if err != nil {
-		return false, fmt.Errorf("failed to merge helm values: %w", err)
+		return false, withFailureReason(catalystv1alpha1.FailureReasonConfigInvalid, fmt.Errorf("failed to merge helm values: %w", err))
 	}
 
 	// Inject built images into Helm values
@@ -123,68 +141,327 @@ func (r *EnvironmentReconciler) ReconcileHelmMode(ctx context.Context, env *cata
 		injectBuiltImages(vals, builtImages, log)
 	}
 
+	// Web API secrets: charts load global.catalystSecrets.name with envFrom and roll pods on the hash
+	secretsHash, err := r.catalystSecretsHash(ctx, namespace)
+	if err != nil {
+		return false, err
+	}
+	injectCatalystSecrets(vals, secretsHash)
+
+	// Load Chart
+	chartRequested, err := loader.Load(sourcePath)
+	if err != nil {
+		return false, withFailureReason(catalystv1alpha1.FailureReasonConfigInvalid, err)
+	}
+
+	// Fail fast on values the chart schema or the project policy rejects, rather than
+	// rendering them (e.g. an override disabling TLS)
+	if err := r.recordHelmValuesValidation(ctx, env, validateHelmValues(chartRequested, vals, project.Spec.HelmValuesPolicy)); err != nil {
+		return false, err
+	}
+
+	postRenderer, err := r.helmPostRenderer(ctx, env, project, namespace)
+	if err != nil {
+		return false, err
+	}
+
+	// The operation may outlive the reconcile, whose status updates rewrite env
+	opEnv := env.DeepCopy()
+
+	// A release deployed from the same chart, values and post-render inputs is not upgraded
+	// again: every reconcile reaches here, and each upgrade adds a revision
+	inputsHash, err := helmInputsHash(chartRequested, vals, postRenderer)
+	if err != nil {
+		return false, err
+	}
+	labels := map[string]string{helmInputsHashLabel: inputsHash}
+
 	// Check if release exists
-	histClient := action.NewHistory(actionConfig)
-	histClient.Max = 1
-	if _, err := histClient.Run(releaseName); err == driver.ErrReleaseNotFound {
-		// Install
-		log.Info("Installing Helm release", "release", releaseName, "chart", sourcePath)
-		install := action.NewInstall(actionConfig)
-		install.ReleaseName = releaseName
-		install.Namespace = namespace
-		install.CreateNamespace = false // Namespace already managed by controller
-
-		// Load Chart
-		chartRequested, err := loader.Load(sourcePath)
-		if err != nil {
+	var kind string
+	var run func(ctx context.Context) error
+	last, err := actionConfig.Releases.Last(releaseName)
+	if err == nil && last.Info != nil && last.Info.Status.IsPending() {
+		// No operation of ours holds the release: an operator restart cut it short, and
+		// Helm refuses to upgrade a release locked in a pending state
+		if err := recoverPendingHelmRelease(log, actionConfig, last); err != nil {
 			return false, err
 		}
-
-		if _, err := install.Run(chartRequested, vals); err != nil {
-			return false, err
+		last, err = actionConfig.Releases.Last(releaseName)
+	}
+	if errors.Is(err, driver.ErrReleaseNotFound) {
+		kind = helmOperationInstall
+		run = func(ctx context.Context) error {
+			log.Info("Installing Helm release", "release", releaseName, "chart", sourcePath)
+			install := action.NewInstall(actionConfig)
+			install.ReleaseName = releaseName
+			install.Namespace = namespace
+			install.CreateNamespace = false // Namespace already managed by controller
+			install.PostRenderer = postRenderer
+			install.Labels = labels
+
+			start := time.Now()
+			_, span := startSpan(ctx, "helm.install", opEnv, attribute.String("catalyst.helm.release", releaseName))
+			_, err := install.Run(chartRequested, vals)
+			tracing.End(span, err)
+			observeSince(helmOperationDuration.WithLabelValues("install", metricResult(err)), start)
+			return err
 		}
 	} else if err != nil {
 		return false, err
+	} else if last.Info != nil && last.Info.Status == release.StatusDeployed && last.Labels[helmInputsHashLabel] == inputsHash {
+		log.V(1).Info("Helm release is up to date", "release", releaseName, "revision", last.Version)
+		return true, nil
 	} else {
-		// Upgrade
-		log.Info("Upgrading Helm release", "release", releaseName, "chart", sourcePath)
-		upgrade := action.NewUpgrade(actionConfig)
-		upgrade.Namespace = namespace
+		kind = helmOperationUpgrade
+		run = func(ctx context.Context) error {
+			log.Info("Upgrading Helm release", "release", releaseName, "chart", sourcePath)
+			upgrade := action.NewUpgrade(actionConfig)
+			upgrade.Namespace = namespace
+			upgrade.PostRenderer = postRenderer
+			upgrade.Labels = labels
+			upgrade.MaxHistory = helmMaxHistory
+
+			start := time.Now()
+			_, span := startSpan(ctx, "helm.upgrade", opEnv, attribute.String("catalyst.helm.release", releaseName))
+			_, err := upgrade.Run(releaseName, chartRequested, vals)
+			tracing.End(span, err)
+			observeSince(helmOperationDuration.WithLabelValues("upgrade", metricResult(err)), start)
+			return err
+		}
+	}
 
-		// Load Chart
-		chartRequested, err := loader.Load(sourcePath)
-		if err != nil {
+	if r.HelmOperations == nil {
+		if err := run(ctx); err != nil {
 			return false, err
 		}
+		recordHelmOperationEvent(r, env, kind, releaseName)
+		return r.helmReleaseDeployed(ctx, namespace, releaseName)
+	}
+
+	// The operation owns the source and vals from here
+	removeSource := cleanup
+	background := func(ctx context.Context) error {
+		if removeSource != nil {
+			defer removeSource()
+		}
+		return run(ctx)
+	}
+	op, started := r.HelmOperations.Begin(ctx, releaseKey, client.ObjectKeyFromObject(env), kind, background)
+	if !started {
+		// Shutting down, or an operation started concurrently; observed next reconcile
+		return false, nil
+	}
+	cleanup = nil
+	// Recorded even if the operation already finished: the reconcile it enqueued collects it
+	return false, r.recordOperationInProgress(ctx, env, &op)
+}
 
-		if _, err := upgrade.Run(releaseName, chartRequested, vals); err != nil {
-			return false, err
+// recoverPendingHelmRelease unlocks a release left pending by an interrupted operation. It
+// rolls back to the last revision that was deployed, or uninstalls a release that never was,
+// so the install starts over.
+func recoverPendingHelmRelease(log logr.Logger, actionConfig *action.Configuration, rel *release.Release) error {
+	history, err := actionConfig.Releases.History(rel.Name)
+	if err != nil {
+		return err
+	}
+	var target *release.Release
+	for _, h := range history {
+		if h.Version >= rel.Version || h.Info == nil {
+			continue
+		}
+		if h.Info.Status != release.StatusDeployed && h.Info.Status != release.StatusSuperseded {
+			continue
+		}
+		if target == nil || h.Version > target.Version {
+			target = h
+		}
+	}
+
+	if target == nil {
+		log.Info("Uninstalling Helm release left pending", "release", rel.Name, "revision", rel.Version, "status", rel.Info.Status)
+		uninstall := action.NewUninstall(actionConfig)
+		// The chart never finished installing, so its delete hooks have nothing to act on
+		uninstall.DisableHooks = true
+		start := time.Now()
+		_, err := uninstall.Run(rel.Name)
+		observeSince(helmOperationDuration.WithLabelValues("uninstall", metricResult(err)), start)
+		return err
+	}
+
+	log.Info("Rolling back Helm release left pending", "release", rel.Name, "revision", rel.Version, "status", rel.Info.Status, "to", target.Version)
+	rollback := action.NewRollback(actionConfig)
+	rollback.Version = target.Version
+	rollback.MaxHistory = helmMaxHistory
+	start := time.Now()
+	err = rollback.Run(rel.Name)
+	observeSince(helmOperationDuration.WithLabelValues("rollback", metricResult(err)), start)
+	return err
+}
+
+// helmInputsHash hashes what a Helm release renders from: the chart with its dependencies,
+// the values and the post-renderer configuration. It fits a label value.
+func helmInputsHash(c *chart.Chart, vals map[string]interface{}, postRenderer postrender.PostRenderer) (string, error) {
+	h := sha256.New()
+	var writeChart func(c *chart.Chart)
+	writeChart = func(c *chart.Chart) {
+		fmt.Fprintf(h, "chart %s %s\n", c.Name(), c.Metadata.Version)
+		for _, files := range [][]*chart.File{c.Raw, c.Templates, c.Files} {
+			for _, f := range files {
+				fmt.Fprintf(h, "%s %d\n", f.Name, len(f.Data))
+				h.Write(f.Data)
+			}
+		}
+		for _, dep := range c.Dependencies() {
+			writeChart(dep)
+		}
+	}
+	writeChart(c)
+	data, err := json.Marshal(struct {
+		Values     map[string]interface{} `json:"values"`
+		PostRender interface{}            `json:"postRender"`
+	}{vals, postRenderInputs(postRenderer)})
+	if err != nil {
+		return "", err
+	}
+	h.Write(data)
+	return hex.EncodeToString(h.Sum(nil))[:32], nil
+}
+
+// postRenderInputs returns the configuration of a post-renderer chain, for hashing
+func postRenderInputs(p postrender.PostRenderer) interface{} {
+	switch p := p.(type) {
+	case *helmPostRenderer:
+		return map[string]interface{}{
+			"spec":        p.spec,
+			"labels":      p.labels,
+			"pullSecrets": p.pullSecrets,
+			"next":        postRenderInputs(p.next),
 		}
+	case *guardrails.PostRenderer:
+		return p.Policy
 	}
+	return nil
+}
 
-	// Verify status (simple check if release is deployed)
-	statusClient := action.NewStatus(actionConfig)
-	rel, err := statusClient.Run(releaseName)
+// helmReleaseDeployed reports whether the release is deployed
+func (r *EnvironmentReconciler) helmReleaseDeployed(ctx context.Context, namespace, releaseName string) (bool, error) {
+	actionConfig, err := r.helmActionConfig(ctx, namespace)
 	if err != nil {
 		return false, err
 	}
+	rel, err := action.NewStatus(actionConfig).Run(releaseName)
+	if err != nil {
+		return false, err
+	}
+	return rel.Info.Status == release.StatusDeployed, nil
+}
 
-	if rel.Info.Status == release.StatusDeployed {
-		return true, nil
+// recordHelmOperationEvent emits the event of a successful install or upgrade
+func recordHelmOperationEvent(r *EnvironmentReconciler, env *catalystv1alpha1.Environment, kind, releaseName string) {
+	if kind == helmOperationInstall {
+		recordEvent(r.Recorder, env, corev1.EventTypeNormal, eventHelmInstalled, "Installed Helm release %s", releaseName)
+	} else {
+		recordEvent(r.Recorder, env, corev1.EventTypeNormal, eventHelmUpgraded, "Upgraded Helm release %s", releaseName)
 	}
+}
 
-	return false, nil
+// recordOperationInProgress records op in status.operationInProgress, or clears it for nil
+func (r *EnvironmentReconciler) recordOperationInProgress(ctx context.Context, env *catalystv1alpha1.Environment, op *helmOperation) error {
+	var status *catalystv1alpha1.OperationStatus
+	if op != nil {
+		status = &catalystv1alpha1.OperationStatus{Type: op.kind, StartedAt: metav1.NewTime(op.startedAt.Truncate(time.Second))}
+	}
+	if equality.Semantic.DeepEqual(env.Status.OperationInProgress, status) {
+		return nil
+	}
+	env.Status.OperationInProgress = status
+	return r.Status().Update(ctx, env)
+}
+
+// helmActionConfig initializes a Helm action configuration for releases stored in namespace
+func (r *EnvironmentReconciler) helmActionConfig(ctx context.Context, namespace string) (*action.Configuration, error) {
+	log := logf.FromContext(ctx)
+
+	// We need to construct a REST Client Getter from the controller's config
+	cfg := r.Config
+	if cfg == nil {
+		return nil, fmt.Errorf("reconciler config is nil")
+	}
+
+	// Validated with the operator configuration; empty lets Helm pick its default (secret)
+	helmDriver := operatorconfig.Current().HelmDriver
+
+	actionConfig := new(action.Configuration)
+	if err := actionConfig.Init(
+		&genericRESTClientGetter{cfg: cfg, namespace: namespace},
+		namespace,
+		helmDriver,
+		func(format string, v ...interface{}) {
+			log.Info(fmt.Sprintf(format, v...))
+		},
+	); err != nil {
+		return nil, err
+	}
+	return actionConfig, nil
+}
+
+// uninstallHelmRelease removes the Helm release of an environment, including resources the
+// chart created outside the environment namespace. A missing release is not an error.
+func (r *EnvironmentReconciler) uninstallHelmRelease(ctx context.Context, env *catalystv1alpha1.Environment, namespace string) error {
+	log := logf.FromContext(ctx)
+
+	actionConfig, err := r.helmActionConfig(ctx, namespace)
+	if err != nil {
+		return err
+	}
+
+	releaseName := env.Name
+	if r.HelmOperations != nil {
+		// Helm refuses to uninstall a release locked by a pending install or upgrade
+		release := types.NamespacedName{Namespace: namespace, Name: releaseName}
+		if r.HelmOperations.Running(release) {
+			return errHelmOperationInProgress
+		}
+		r.HelmOperations.Forget(release)
+	}
+	if _, err := actionConfig.Releases.History(releaseName); errors.Is(err, driver.ErrReleaseNotFound) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	log.Info("Uninstalling Helm release", "release", releaseName, "namespace", namespace)
+	uninstall := action.NewUninstall(actionConfig)
+	hook := preDeleteHook(env)
+	if hook != nil && hook.Helm {
+		// Helm waits for the chart's pre-delete hooks without a deadline by default
+		uninstall.Timeout = hookTimeout(hook)
+	}
+	start := time.Now()
+	_, err = uninstall.Run(releaseName)
+	if err != nil && hook != nil && hook.Helm && hook.FailurePolicy != catalystv1alpha1.HookFailurePolicyFail {
+		log.Error(err, "Failed to uninstall Helm release, retrying without hooks", "release", releaseName)
+		uninstall.DisableHooks = true
+		_, err = uninstall.Run(releaseName)
+	}
+	observeSince(helmOperationDuration.WithLabelValues("uninstall", metricResult(err)), start)
+	if errors.Is(err, driver.ErrReleaseNotFound) {
+		return nil
+	}
+	return err
 }
 
 // prepareSource resolves the path to the source files, cloning the repository if necessary.
-func (r *EnvironmentReconciler) prepareSource(ctx context.Context, env *catalystv1alpha1.Environment, project *catalystv1alpha1.Project, template *catalystv1alpha1.EnvironmentTemplate) (string, func(), error) {
+func (r *EnvironmentReconciler) prepareSource(ctx context.Context, env *catalystv1alpha1.Environment, project *catalystv1alpha1.Project, template *catalystv1alpha1.EnvironmentTemplateSpec) (_ string, _ func(), err error) {
 	log := logf.FromContext(ctx)
+	ctx, span := startSpan(ctx, "prepareSource", env, attribute.String("catalyst.source", template.SourceRef))
+	defer func() { tracing.End(span, err) }()
 
 	// If no SourceRef, assume local path (for testing or pre-baked images)
 	if template.SourceRef == "" {
 		sourcePath := template.Path
 		if sourcePath == "" {
-			return "", nil, fmt.Errorf("path is required in template")
+			return "", nil, withFailureReason(catalystv1alpha1.FailureReasonConfigInvalid, fmt.Errorf("path is required in template"))
 		}
 
 		// Check if local path exists
@@ -193,7 +470,7 @@ func (r *EnvironmentReconciler) prepareSource(ctx context.Context, env *catalyst
 			if _, err := os.Stat("../../" + sourcePath); err == nil {
 				sourcePath = "../../" + sourcePath
 			} else {
-				return "", nil, fmt.Errorf("source not found at path: %s", sourcePath)
+				return "", nil, withFailureReason(catalystv1alpha1.FailureReasonConfigInvalid, fmt.Errorf("source not found at path: %s", sourcePath))
 			}
 		}
 		return sourcePath, nil, nil
@@ -208,7 +485,7 @@ func (r *EnvironmentReconciler) prepareSource(ctx context.Context, env *catalyst
 		}
 	}
 	if sourceConfig == nil {
-		return "", nil, fmt.Errorf("source ref '%s' not found in project", template.SourceRef)
+		return "", nil, withFailureReason(catalystv1alpha1.FailureReasonConfigInvalid, fmt.Errorf("source ref '%s' not found in project", template.SourceRef))
 	}
 
 	// Determine Commit/Branch
@@ -228,6 +505,11 @@ func (r *EnvironmentReconciler) prepareSource(ctx context.Context, env *catalyst
 		log.V(1).Info("Source not found in environment, will clone default branch", "sourceRef", template.SourceRef)
 	}
 
+	auth, err := r.gitAuth(ctx, env, project, sourceConfig)
+	if err != nil {
+		return "", nil, withFailureReason(catalystv1alpha1.FailureReasonSourceCloneFailed, err)
+	}
+
 	// Clone Repository
 	tempDir, err := os.MkdirTemp("", "catalyst-source-*")
 	if err != nil {
@@ -235,19 +517,14 @@ func (r *EnvironmentReconciler) prepareSource(ctx context.Context, env *catalyst
 	}
 
 	cleanup := func() {
-		_ = os.RemoveAll(tempDir)
+		tempDirCleanupsTotal.WithLabelValues("source", metricResult(os.RemoveAll(tempDir))).Inc()
 	}
 
 	log.Info("Cloning repository for source", "url", sourceConfig.RepositoryURL, "commit", commitSha, "tempDir", tempDir)
 
 	cloneOptions := &git.CloneOptions{
-		URL: sourceConfig.RepositoryURL,
-		// TODO: Add authentication support for private repositories.
-		// This will require:
-		// 1. SSH key or personal access token management via Kubernetes Secrets
-		// 2. Auth injection mechanism (e.g., via SourceConfig or Environment spec)
-		// 3. Support for both HTTPS (token-based) and SSH authentication
-		// Example: Auth: &githttp.BasicAuth{Username: "...", Password: token}
+		URL:  sourceConfig.RepositoryURL,
+		Auth: auth,
 	}
 
 	// Constrain the clone to a single branch when one is specified, to avoid cloning all branches.
@@ -265,38 +542,46 @@ func (r *EnvironmentReconciler) prepareSource(ctx context.Context, env *catalyst
 		cloneOptions.Depth = 1
 	}
 
-	_, err = git.PlainClone(tempDir, false, cloneOptions)
+	cloneStart := time.Now()
+	repo, err := git.PlainClone(tempDir, false, cloneOptions)
+	observeSince(gitCloneDuration.WithLabelValues(metricResult(err)), cloneStart)
 	if err != nil {
 		cleanup()
-		return "", nil, fmt.Errorf("failed to clone repo: %w", err)
+		return "", nil, withFailureReason(catalystv1alpha1.FailureReasonSourceCloneFailed, fmt.Errorf("failed to clone repo: %w", err))
 	}
 
 	// Checkout Commit if specified
 	if commitSha != "" {
-		repo, err := git.PlainOpen(tempDir)
+		w, err := repo.Worktree()
 		if err != nil {
 			cleanup()
-			return "", nil, fmt.Errorf("failed to open repo: %w", err)
+			return "", nil, fmt.Errorf("failed to get worktree: %w", err)
 		}
-		w, err := repo.Worktree()
+		hash, err := resolveCommit(repo, commitSha)
 		if err != nil {
 			cleanup()
-			return "", nil, fmt.Errorf("failed to get worktree: %w", err)
+			return "", nil, withFailureReason(catalystv1alpha1.FailureReasonSourceCloneFailed, err)
 		}
 		err = w.Checkout(&git.CheckoutOptions{
-			Hash: plumbing.NewHash(commitSha),
+			Hash: hash,
 		})
 		if err != nil {
 			cleanup()
-			return "", nil, fmt.Errorf("failed to checkout commit %s: %w", commitSha, err)
+			return "", nil, withFailureReason(catalystv1alpha1.FailureReasonSourceCloneFailed, fmt.Errorf("failed to checkout commit %s: %w", commitSha, err))
 		}
 	}
 
+	// Submodules and LFS objects of the checked out commit (lfs, submodules)
+	if err := checkoutSourceExtras(ctx, repo, tempDir, sourceConfig, auth); err != nil {
+		cleanup()
+		return "", nil, withFailureReason(catalystv1alpha1.FailureReasonSourceCloneFailed, err)
+	}
+
 	// Resolve Path within repo
 	sourcePath := filepath.Join(tempDir, template.Path)
 	if _, err := os.Stat(sourcePath); os.IsNotExist(err) {
 		cleanup()
-		return "", nil, fmt.Errorf("source not found at %s in repo %s", template.Path, sourceConfig.RepositoryURL)
+		return "", nil, withFailureReason(catalystv1alpha1.FailureReasonConfigInvalid, fmt.Errorf("source not found at %s in repo %s", template.Path, sourceConfig.RepositoryURL))
 	}
 
 	return sourcePath, cleanup, nil
@@ -413,7 +698,7 @@ func (l *genericConfigLoader) Namespace() (string, bool, error) {
 
 // mergeHelmValues merges values from template defaults and environment-specific config.
 // Uses deep merge strategy to preserve template defaults while allowing environment overrides.
-func (r *EnvironmentReconciler) mergeHelmValues(template *catalystv1alpha1.EnvironmentTemplate, env *catalystv1alpha1.Environment) (map[string]interface{}, error) {
+func (r *EnvironmentReconciler) mergeHelmValues(template *catalystv1alpha1.EnvironmentTemplateSpec, env *catalystv1alpha1.Environment) (map[string]interface{}, error) {
 	vals := make(map[string]interface{})
 
 	// Start with template values
@@ -493,6 +778,9 @@ func (r *EnvironmentReconciler) mergeHelmValues(template *catalystv1alpha1.Envir
 //
 //	global.images.<component-name>.repository (string)
 //	global.images.<component-name>.tag (string)
+//	global.images.<component-name>.digest (string, when the build recorded one)
+//
+// Charts pin immutable deploys with "{{ .repository }}@{{ .digest }}".
 //
 // Input:
 //
@@ -503,7 +791,7 @@ func (r *EnvironmentReconciler) mergeHelmValues(template *catalystv1alpha1.Envir
 // Example input builtImages:
 //
 //	{
-//	  "web": "ghcr.io/ncrmro/catalyst:abc123",
+//	  "web": "ghcr.io/ncrmro/catalyst:abc123@sha256:4f2a...",
 //	  "api": "ghcr.io/ncrmro/catalyst-api:def456"
 //	}
 //
@@ -514,7 +802,8 @@ func (r *EnvironmentReconciler) mergeHelmValues(template *catalystv1alpha1.Envir
 //	    "images": {
 //	      "web": {
 //	        "repository": "ghcr.io/ncrmro/catalyst",
-//	        "tag": "abc123"
+//	        "tag": "abc123",
+//	        "digest": "sha256:4f2a..."
 //	      },
 //	      "api": {
 //	        "repository": "ghcr.io/ncrmro/catalyst-api",
@@ -529,6 +818,7 @@ func (r *EnvironmentReconciler) mergeHelmValues(template *catalystv1alpha1.Envir
 // - "images" key doesn't exist under global
 // - Image reference has no tag (defaults to "latest")
 // - Image reference has multiple colons (e.g., registry with port)
+// - Image reference carries a digest (repo:tag@sha256:...)
 func injectBuiltImages(vals map[string]interface{}, builtImages map[string]string, log logr.Logger) {
 	// Ensure global.images structure exists
 	global, ok := vals["global"].(map[string]interface{})
@@ -547,16 +837,22 @@ func injectBuiltImages(vals map[string]interface{}, builtImages map[string]strin
 		// Format: registry/repo:tag or registry:port/repo:tag
 		// Strategy: Find the last colon to split repository from tag
 		repository, tag := splitImageRef(imageRef)
+		_, _, digest := parseImageRef(imageRef)
 
-		images[componentName] = map[string]interface{}{
+		image := map[string]interface{}{
 			"repository": repository,
 			"tag":        tag,
 		}
+		if digest != "" {
+			image["digest"] = digest
+		}
+		images[componentName] = image
 
 		log.V(1).Info("Injected built image into Helm values",
 			"component", componentName,
 			"repository", repository,
 			"tag", tag,
+			"digest", digest,
 		)
 	}
 
@@ -564,6 +860,27 @@ func injectBuiltImages(vals map[string]interface{}, builtImages map[string]strin
 	vals["global"] = global
 }
 
+// injectCatalystSecrets exposes the catalyst-secrets Secret to charts:
+//
+//	global.catalystSecrets.name (string)
+//	global.catalystSecrets.hash (string, for a checksum pod annotation)
+//
+// Nothing is injected until the Secret has been synced (empty hash).
+func injectCatalystSecrets(vals map[string]interface{}, hash string) {
+	if hash == "" {
+		return
+	}
+	global, ok := vals["global"].(map[string]interface{})
+	if !ok {
+		global = map[string]interface{}{}
+	}
+	global["catalystSecrets"] = map[string]interface{}{
+		"name": catalystSecretsName,
+		"hash": hash,
+	}
+	vals["global"] = global
+}
+
 // splitImageRef splits a container image reference into repository and tag.
 //
 // Examples:
@@ -572,35 +889,33 @@ func injectBuiltImages(vals map[string]interface{}, builtImages map[string]strin
 //	"ghcr.io/org/image:v1.2.3" -> ("ghcr.io/org/image", "v1.2.3")
 //	"registry.local:5000/app:sha-abc123" -> ("registry.local:5000/app", "sha-abc123")
 //	"myimage" -> ("myimage", "latest")
+//	"ghcr.io/org/image:v1@sha256:4f2a..." -> ("ghcr.io/org/image", "v1")
+//	"ghcr.io/org/image@sha256:4f2a..." -> ("ghcr.io/org/image", "")
 //
-// The function finds the last colon in the reference and treats everything
-// after it as the tag. This correctly handles registry addresses with ports.
+// Any digest is dropped (see parseImageRef); a digest-only reference has no tag.
 func splitImageRef(imageRef string) (repository string, tag string) {
-	// Find the last occurrence of ':'
-	lastColon := strings.LastIndex(imageRef, ":")
-
-	if lastColon == -1 {
+	repository, tag, digest := parseImageRef(imageRef)
+	if tag == "" && digest == "" {
 		// No tag specified, use default
-		return imageRef, "latest"
+		tag = "latest"
 	}
+	return repository, tag
+}
 
-	// Split at the last colon
-	repository = imageRef[:lastColon]
-	tag = imageRef[lastColon+1:]
-
-	// Handle edge case where the colon is part of the registry (e.g., "registry.local:5000")
-	// If there's no '/' after the colon, it's likely a registry port, not a tag
-	if !strings.Contains(tag, "/") && strings.Contains(repository, "/") {
-		// This is likely a real tag
-		return repository, tag
+// parseImageRef splits a container image reference into repository, tag and digest.
+// The digest follows '@'; the tag follows the last colon of the remaining name, unless
+// that colon belongs to a registry port (e.g. "registry.local:5000/app"). Missing parts are empty.
+func parseImageRef(imageRef string) (repository, tag, digest string) {
+	name := imageRef
+	if at := strings.Index(imageRef, "@"); at != -1 {
+		name, digest = imageRef[:at], imageRef[at+1:]
 	}
 
-	// If tag contains '/', it's probably part of the repository path
-	if strings.Contains(tag, "/") {
-		return imageRef, "latest"
+	lastColon := strings.LastIndex(name, ":")
+	if lastColon == -1 || strings.Contains(name[lastColon+1:], "/") {
+		return name, "", digest
 	}
-
-	return repository, tag
+	return name[:lastColon], name[lastColon+1:], digest
 }
 
 // cleanupStaleTempDirs removes temporary directories older than 24 hours
@@ -648,7 +963,9 @@ func cleanupStaleTempDirs(log logr.Logger) {
 
 		// Remove if older than cutoff
 		if info.ModTime().Before(cutoff) {
-			if err := os.RemoveAll(fullPath); err != nil {
+			err := os.RemoveAll(fullPath)
+			tempDirCleanupsTotal.WithLabelValues("stale", metricResult(err)).Inc()
+			if err != nil {
 				// Log error but continue - this could be a race with another process
 				log.Error(err, "Failed to remove stale temp directory", "path", fullPath)
 			} else {
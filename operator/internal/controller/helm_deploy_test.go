@@ -39,7 +39,7 @@ var _ = Describe("mergeHelmValues", func() {
 	})
 
 	It("should merge empty template values with environment config", func() {
-		template := &catalystv1alpha1.EnvironmentTemplate{
+		template := &catalystv1alpha1.EnvironmentTemplateSpec{
 			Values: runtime.RawExtension{Raw: nil},
 		}
 		env := &catalystv1alpha1.Environment{
@@ -69,7 +69,7 @@ var _ = Describe("mergeHelmValues", func() {
 			},
 		}
 		templateJSON, _ := json.Marshal(templateVals)
-		template := &catalystv1alpha1.EnvironmentTemplate{
+		template := &catalystv1alpha1.EnvironmentTemplateSpec{
 			Values: runtime.RawExtension{Raw: templateJSON},
 		}
 		env := &catalystv1alpha1.Environment{
@@ -96,7 +96,7 @@ var _ = Describe("mergeHelmValues", func() {
 			"image": "template/image:v1",
 		}
 		templateJSON, _ := json.Marshal(templateVals)
-		template := &catalystv1alpha1.EnvironmentTemplate{
+		template := &catalystv1alpha1.EnvironmentTemplateSpec{
 			Values: runtime.RawExtension{Raw: templateJSON},
 		}
 		env := &catalystv1alpha1.Environment{
@@ -121,7 +121,7 @@ var _ = Describe("mergeHelmValues", func() {
 			},
 		}
 		templateJSON, _ := json.Marshal(templateVals)
-		template := &catalystv1alpha1.EnvironmentTemplate{
+		template := &catalystv1alpha1.EnvironmentTemplateSpec{
 			Values: runtime.RawExtension{Raw: templateJSON},
 		}
 		env := &catalystv1alpha1.Environment{
@@ -148,7 +148,7 @@ var _ = Describe("mergeHelmValues", func() {
 			},
 		}
 		templateJSON, _ := json.Marshal(templateVals)
-		template := &catalystv1alpha1.EnvironmentTemplate{
+		template := &catalystv1alpha1.EnvironmentTemplateSpec{
 			Values: runtime.RawExtension{Raw: templateJSON},
 		}
 		env := &catalystv1alpha1.Environment{
@@ -167,7 +167,7 @@ var _ = Describe("mergeHelmValues", func() {
 	})
 
 	It("should handle invalid JSON in template values", func() {
-		template := &catalystv1alpha1.EnvironmentTemplate{
+		template := &catalystv1alpha1.EnvironmentTemplateSpec{
 			Values: runtime.RawExtension{Raw: []byte("{invalid json")},
 		}
 		env := &catalystv1alpha1.Environment{}
@@ -182,7 +182,7 @@ var _ = Describe("mergeHelmValues", func() {
 			"replicaCount": 3,
 		}
 		templateJSON, _ := json.Marshal(templateVals)
-		template := &catalystv1alpha1.EnvironmentTemplate{
+		template := &catalystv1alpha1.EnvironmentTemplateSpec{
 			Values: runtime.RawExtension{Raw: templateJSON},
 		}
 		env := &catalystv1alpha1.Environment{
@@ -346,6 +346,24 @@ var _ = Describe("injectBuiltImages", func() {
 		Expect(app["tag"]).To(Equal("v1.2.3"))
 	})
 
+	It("should inject the digest of pinned images", func() {
+		vals := make(map[string]interface{})
+		builtImages := map[string]string{
+			"web": "ghcr.io/ncrmro/catalyst:abc123@sha256:4f2a9d",
+			"api": "ghcr.io/ncrmro/catalyst-api:def456",
+		}
+
+		injectBuiltImages(vals, builtImages, log)
+
+		global := vals["global"].(map[string]interface{})
+		images := global["images"].(map[string]interface{})
+		web := images["web"].(map[string]interface{})
+		Expect(web["repository"]).To(Equal("ghcr.io/ncrmro/catalyst"))
+		Expect(web["tag"]).To(Equal("abc123"))
+		Expect(web["digest"]).To(Equal("sha256:4f2a9d"))
+		Expect(images["api"]).NotTo(HaveKey("digest"))
+	})
+
 	It("should preserve existing global values", func() {
 		vals := map[string]interface{}{
 			"global": map[string]interface{}{
@@ -394,4 +412,16 @@ var _ = Describe("splitImageRef", func() {
 		Expect(repo).To(Equal("ghcr.io/org/image"))
 		Expect(tag).To(Equal("sha-abc123def456"))
 	})
+
+	It("should drop the digest of a tagged digest reference", func() {
+		repo, tag := splitImageRef("registry.local:5000/app:v1@sha256:4f2a9d")
+		Expect(repo).To(Equal("registry.local:5000/app"))
+		Expect(tag).To(Equal("v1"))
+	})
+
+	It("should return no tag for a digest-only reference", func() {
+		repo, tag := splitImageRef("ghcr.io/org/image@sha256:4f2a9d")
+		Expect(repo).To(Equal("ghcr.io/org/image"))
+		Expect(tag).To(BeEmpty())
+	})
 })
@@ -0,0 +1,176 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+// Background Helm operations: an install or upgrade takes minutes, waiting on hooks and a
+// slow API server, which would hold a reconcile worker and risk its deadline. The reconciler
+// hands it to HelmOperations instead and records status.operationInProgress; later
+// reconciles observe the operation rather than starting another, and the first reconcile
+// after it finished collects its result. A finished operation enqueues its Environment.
+// Operations are tracked in memory only: after an operator restart, the reconcile clears
+// the stale status.operationInProgress and recovers a release the operation left pending.
+
+const (
+	helmOperationInstall = "HelmInstall"
+	helmOperationUpgrade = "HelmUpgrade"
+)
+
+// errHelmOperationInProgress reports a release with an operation still running
+var errHelmOperationInProgress = errors.New("a Helm operation is in progress for the release")
+
+// HelmOperations runs Helm operations in the background, one at a time per release and at
+// most Workers at once. On shutdown it stops starting operations and waits for the running
+// ones, a Helm operation cut short leaving its release locked in a pending state.
+type HelmOperations struct {
+	// Workers caps the operations running at once. Zero runs one per CPU.
+	Workers int
+
+	mu       sync.Mutex
+	ops      map[types.NamespacedName]*helmOperation
+	slots    chan struct{}
+	events   chan event.GenericEvent
+	stopping bool
+	running  sync.WaitGroup
+}
+
+// helmOperation is the operation of one release
+type helmOperation struct {
+	// environment is enqueued when the operation finishes
+	environment types.NamespacedName
+	kind        string
+	startedAt   time.Time
+	finished    bool
+	err         error
+}
+
+// init allocates the operations on first use
+func (h *HelmOperations) init() {
+	if h.ops != nil {
+		return
+	}
+	workers := h.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	h.ops = map[types.NamespacedName]*helmOperation{}
+	h.slots = make(chan struct{}, workers)
+	h.events = make(chan event.GenericEvent, 64)
+}
+
+// Start waits for ctx, then for the running operations
+func (h *HelmOperations) Start(ctx context.Context) error {
+	<-ctx.Done()
+	h.mu.Lock()
+	h.stopping = true
+	h.mu.Unlock()
+	h.running.Wait()
+	return nil
+}
+
+// NeedLeaderElection runs operations on the leader, where the reconciles start them
+func (h *HelmOperations) NeedLeaderElection() bool {
+	return true
+}
+
+// Source enqueues the Environments whose operations finished
+func (h *HelmOperations) Source() source.Source {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.init()
+	return source.Channel(h.events, &handler.EnqueueRequestForObject{})
+}
+
+// Begin starts run as the operation of kind on release for environment and returns it as
+// started. It returns false when the release has an operation, running or not collected yet,
+// or on shutdown. run gets ctx without its cancellation, so it outlives the reconcile that
+// started it.
+func (h *HelmOperations) Begin(ctx context.Context, release, environment types.NamespacedName, kind string, run func(ctx context.Context) error) (helmOperation, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.init()
+	if h.stopping || h.ops[release] != nil {
+		return helmOperation{}, false
+	}
+	op := &helmOperation{environment: environment, kind: kind, startedAt: time.Now()}
+	h.ops[release] = op
+	h.running.Add(1)
+	go func() {
+		defer h.running.Done()
+		h.slots <- struct{}{}
+		err := run(context.WithoutCancel(ctx))
+		<-h.slots
+
+		h.mu.Lock()
+		op.finished, op.err = true, err
+		h.mu.Unlock()
+		env := &catalystv1alpha1.Environment{}
+		env.Name, env.Namespace = environment.Name, environment.Namespace
+		select {
+		case h.events <- event.GenericEvent{Object: env}:
+		default:
+			// The reconciler resyncs environments observing an operation
+		}
+	}()
+	return *op, true
+}
+
+// Observe returns the operation of release. A finished operation is collected: the next
+// Observe finds none, and Begin may start another.
+func (h *HelmOperations) Observe(release types.NamespacedName) (helmOperation, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	op := h.ops[release]
+	if op == nil {
+		return helmOperation{}, false
+	}
+	if op.finished {
+		delete(h.ops, release)
+	}
+	return *op, true
+}
+
+// Running reports whether an operation of release is running
+func (h *HelmOperations) Running(release types.NamespacedName) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	op := h.ops[release]
+	return op != nil && !op.finished
+}
+
+// Forget drops the finished operation of release, for a release being uninstalled
+func (h *HelmOperations) Forget(release types.NamespacedName) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if op := h.ops[release]; op != nil && op.finished {
+		delete(h.ops, release)
+	}
+}
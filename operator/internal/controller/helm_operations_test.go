@@ -0,0 +1,259 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
+	kubefake "helm.sh/helm/v3/pkg/kube/fake"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/storage"
+	"helm.sh/helm/v3/pkg/storage/driver"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+// waitFinished waits for the completion event of an operation
+func waitFinished(t *testing.T, h *HelmOperations) types.NamespacedName {
+	t.Helper()
+	select {
+	case e := <-h.events:
+		return client.ObjectKeyFromObject(e.Object)
+	case <-time.After(5 * time.Second):
+		t.Fatal("operation did not finish")
+		return types.NamespacedName{}
+	}
+}
+
+// begun reports whether Begin started the operation
+func begun(_ helmOperation, started bool) bool {
+	return started
+}
+
+func TestHelmOperations(t *testing.T) {
+	h := &HelmOperations{Workers: 1}
+	ctx := context.Background()
+	release := types.NamespacedName{Namespace: "env-ns", Name: "pr-1"}
+	env := types.NamespacedName{Namespace: "team", Name: "pr-1"}
+
+	running, unblock := make(chan struct{}), make(chan struct{})
+	require.True(t, begun(h.Begin(ctx, release, env, helmOperationInstall, func(context.Context) error {
+		close(running)
+		<-unblock
+		return errors.New("timed out waiting for the condition")
+	})))
+	<-running
+	assert.False(t, begun(h.Begin(ctx, release, env, helmOperationUpgrade, nil)), "one operation per release")
+	assert.True(t, h.Running(release))
+	op, found := h.Observe(release)
+	require.True(t, found)
+	assert.False(t, op.finished)
+	assert.Equal(t, helmOperationInstall, op.kind)
+
+	// Workers bounds the operations running at once
+	other := types.NamespacedName{Namespace: "env-ns-2", Name: "pr-2"}
+	started := make(chan struct{})
+	require.True(t, begun(h.Begin(ctx, other, other, helmOperationUpgrade, func(context.Context) error {
+		close(started)
+		return nil
+	})))
+	select {
+	case <-started:
+		t.Fatal("the second operation started before a worker was free")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(unblock)
+	assert.Equal(t, env, waitFinished(t, h), "a finished operation enqueues its Environment")
+	assert.Equal(t, other, waitFinished(t, h))
+
+	// The finished operation is collected once
+	assert.False(t, h.Running(release))
+	op, found = h.Observe(release)
+	require.True(t, found)
+	assert.True(t, op.finished)
+	assert.EqualError(t, op.err, "timed out waiting for the condition")
+	_, found = h.Observe(release)
+	assert.False(t, found)
+
+	// Begin returns the operation as started, leaving a quick one for the next Observe
+	op, ok := h.Begin(ctx, release, env, helmOperationUpgrade, func(context.Context) error { return nil })
+	require.True(t, ok)
+	assert.False(t, op.finished)
+	waitFinished(t, h)
+	op, found = h.Observe(release)
+	require.True(t, found)
+	assert.True(t, op.finished)
+	assert.Equal(t, helmOperationUpgrade, op.kind)
+}
+
+func TestHelmOperationsShutdown(t *testing.T) {
+	h := &HelmOperations{}
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		_ = h.Start(ctx)
+		close(stopped)
+	}()
+
+	release := types.NamespacedName{Namespace: "env-ns", Name: "pr-1"}
+	unblock := make(chan struct{})
+	require.True(t, begun(h.Begin(ctx, release, release, helmOperationUpgrade, func(ctx context.Context) error {
+		<-unblock
+		return ctx.Err()
+	})))
+	cancel()
+	select {
+	case <-stopped:
+		t.Fatal("Start returned with an operation running")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(unblock)
+	<-stopped
+
+	op, _ := h.Observe(release)
+	assert.NoError(t, op.err, "operations are not cancelled with the reconcile or the manager")
+	assert.False(t, begun(h.Begin(context.Background(), release, release, helmOperationUpgrade, nil)), "no operations start on shutdown")
+}
+
+func TestReconcileHelmModeObservesOperation(t *testing.T) {
+	env := &catalystv1alpha1.Environment{ObjectMeta: metav1.ObjectMeta{Name: "pr-1", Namespace: "team"}}
+	c := newFakeClientBuilder().WithStatusSubresource(env).WithObjects(env).Build()
+	h := &HelmOperations{}
+	r := &EnvironmentReconciler{Client: c, Scheme: testScheme, Config: &rest.Config{Host: "https://127.0.0.1:1"}, HelmOperations: h}
+	ctx := context.Background()
+	release := types.NamespacedName{Namespace: "env-ns", Name: "pr-1"}
+
+	unblock := make(chan struct{})
+	require.True(t, begun(h.Begin(ctx, release, client.ObjectKeyFromObject(env), helmOperationUpgrade, func(context.Context) error {
+		<-unblock
+		return errors.New("upgrade failed")
+	})))
+
+	// A running operation is observed, not re-entered: no template is needed
+	ready, err := r.ReconcileHelmMode(ctx, env, &catalystv1alpha1.Project{}, "env-ns", nil, nil)
+	require.NoError(t, err)
+	assert.False(t, ready)
+	stored := &catalystv1alpha1.Environment{}
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(env), stored))
+	require.NotNil(t, stored.Status.OperationInProgress)
+	assert.Equal(t, helmOperationUpgrade, stored.Status.OperationInProgress.Type)
+
+	// The release can't be uninstalled meanwhile
+	assert.ErrorIs(t, r.uninstallHelmRelease(ctx, env, "env-ns"), errHelmOperationInProgress)
+
+	// The next reconcile collects the result
+	close(unblock)
+	waitFinished(t, h)
+	_, err = r.ReconcileHelmMode(ctx, env, &catalystv1alpha1.Project{}, "env-ns", nil, nil)
+	assert.EqualError(t, err, "upgrade failed")
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(env), stored))
+	assert.Nil(t, stored.Status.OperationInProgress)
+}
+
+func TestHelmInputsHash(t *testing.T) {
+	c := &chart.Chart{
+		Metadata:  &chart.Metadata{Name: "web", Version: "1.0.0"},
+		Templates: []*chart.File{{Name: "templates/deployment.yaml", Data: []byte("kind: Deployment")}},
+	}
+	vals := map[string]interface{}{"replicas": 1}
+	renderer := &helmPostRenderer{spec: &catalystv1alpha1.HelmPostRenderSpec{Labels: true}, labels: map[string]string{environmentLabel: "pr-1"}}
+
+	hash, err := helmInputsHash(c, vals, renderer)
+	require.NoError(t, err)
+	assert.Len(t, hash, 32, "fits a label value")
+	again, err := helmInputsHash(c, map[string]interface{}{"replicas": 1}, renderer)
+	require.NoError(t, err)
+	assert.Equal(t, hash, again, "the same inputs skip the upgrade")
+
+	changed, err := helmInputsHash(c, map[string]interface{}{"replicas": 2}, renderer)
+	require.NoError(t, err)
+	assert.NotEqual(t, hash, changed)
+	renderer.labels["catalyst.dev/team"] = "acme"
+	changed, err = helmInputsHash(c, vals, renderer)
+	require.NoError(t, err)
+	assert.NotEqual(t, hash, changed)
+	c.Templates[0].Data = []byte("kind: StatefulSet")
+	changed, err = helmInputsHash(c, vals, nil)
+	require.NoError(t, err)
+	assert.NotEqual(t, hash, changed)
+}
+
+func TestReconcileHelmModeClearsLostOperation(t *testing.T) {
+	// The operator restarted while an upgrade ran: status still records it, nothing tracks it
+	env := &catalystv1alpha1.Environment{ObjectMeta: metav1.ObjectMeta{Name: "pr-1", Namespace: "team"}}
+	env.Status.OperationInProgress = &catalystv1alpha1.OperationStatus{Type: helmOperationUpgrade, StartedAt: metav1.Now()}
+	c := newFakeClientBuilder().WithStatusSubresource(env).WithObjects(env).Build()
+	r := &EnvironmentReconciler{Client: c, Scheme: testScheme, Config: &rest.Config{Host: "https://127.0.0.1:1"}, HelmOperations: &HelmOperations{}}
+	ctx := context.Background()
+
+	_, err := r.ReconcileHelmMode(ctx, env, &catalystv1alpha1.Project{}, "env-ns", nil, nil)
+	require.Error(t, err)
+	stored := &catalystv1alpha1.Environment{}
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(env), stored))
+	assert.Nil(t, stored.Status.OperationInProgress)
+}
+
+func TestRecoverPendingHelmRelease(t *testing.T) {
+	newConfig := func(statuses ...release.Status) *action.Configuration {
+		cfg := &action.Configuration{
+			Releases:     storage.Init(driver.NewMemory()),
+			KubeClient:   &kubefake.PrintingKubeClient{Out: io.Discard},
+			Capabilities: chartutil.DefaultCapabilities,
+			Log:          func(string, ...interface{}) {},
+		}
+		for i, status := range statuses {
+			require.NoError(t, cfg.Releases.Create(&release.Release{
+				Name:      "pr-1",
+				Namespace: "env-ns",
+				Version:   i + 1,
+				Chart:     &chart.Chart{Metadata: &chart.Metadata{Name: "web", Version: "1.0.0"}},
+				Info:      &release.Info{Status: status},
+			}))
+		}
+		return cfg
+	}
+	recoverLast := func(cfg *action.Configuration) error {
+		last, err := cfg.Releases.Last("pr-1")
+		require.NoError(t, err)
+		return recoverPendingHelmRelease(logr.Discard(), cfg, last)
+	}
+
+	t.Run("pending install is uninstalled", func(t *testing.T) {
+		cfg := newConfig(release.StatusPendingInstall)
+		require.NoError(t, recoverLast(cfg))
+		_, err := cfg.Releases.Last("pr-1")
+		assert.ErrorIs(t, err, driver.ErrReleaseNotFound)
+	})
+
+	t.Run("pending upgrade is rolled back", func(t *testing.T) {
+		cfg := newConfig(release.StatusSuperseded, release.StatusDeployed, release.StatusPendingUpgrade)
+		require.NoError(t, recoverLast(cfg))
+		last, err := cfg.Releases.Last("pr-1")
+		require.NoError(t, err)
+		assert.Equal(t, 4, last.Version)
+		assert.Equal(t, release.StatusDeployed, last.Info.Status)
+		assert.Equal(t, "Rollback to 2", last.Info.Description)
+	})
+
+	t.Run("interrupted rollback skips the pending revisions", func(t *testing.T) {
+		cfg := newConfig(release.StatusDeployed, release.StatusPendingUpgrade, release.StatusPendingRollback)
+		require.NoError(t, recoverLast(cfg))
+		last, err := cfg.Releases.Last("pr-1")
+		require.NoError(t, err)
+		assert.Equal(t, release.StatusDeployed, last.Info.Status)
+		assert.Equal(t, "Rollback to 1", last.Info.Description)
+	})
+}
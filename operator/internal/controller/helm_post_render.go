@@ -0,0 +1,200 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+
+	"helm.sh/helm/v3/pkg/postrender"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+// podTemplatePaths locates the pod template of the workload kinds charts render
+var podTemplatePaths = map[string][]string{
+	"Deployment":  {"spec", "template"},
+	"StatefulSet": {"spec", "template"},
+	"DaemonSet":   {"spec", "template"},
+	"ReplicaSet":  {"spec", "template"},
+	"Job":         {"spec", "template"},
+	"CronJob":     {"spec", "jobTemplate", "spec", "template"},
+}
+
+// helmPostRenderer applies the Project's spec.helmPostRender to rendered charts, then hands
+// the output to the next post-renderer (the guardrails), if any
+type helmPostRenderer struct {
+	spec        *catalystv1alpha1.HelmPostRenderSpec
+	labels      map[string]string
+	pullSecrets []corev1.LocalObjectReference
+	next        postrender.PostRenderer
+}
+
+// helmPostRenderer returns the post-renderer of the environment's Helm releases, nil when
+// there is nothing to mutate or enforce
+func (r *EnvironmentReconciler) helmPostRenderer(ctx context.Context, env *catalystv1alpha1.Environment, project *catalystv1alpha1.Project, namespace string) (postrender.PostRenderer, error) {
+	spec := project.Spec.HelmPostRender
+	if spec == nil {
+		return guardrailsPostRenderer(), nil
+	}
+	renderer := &helmPostRenderer{spec: spec, next: guardrailsPostRenderer()}
+	if spec.Labels {
+		renderer.labels = map[string]string{environmentLabel: sanitizeLabelValue(env.Name)}
+		if hierarchy := ExtractNamespaceHierarchy(env.Labels); hierarchy != nil {
+			renderer.labels["catalyst.dev/team"] = hierarchy.Team
+			renderer.labels["catalyst.dev/project"] = hierarchy.Project
+		}
+	}
+	if spec.ImagePullSecrets {
+		// ensureRegistryCredentials patched the default ServiceAccount with the copied Secrets
+		sa := &corev1.ServiceAccount{}
+		if err := r.Get(ctx, client.ObjectKey{Name: "default", Namespace: namespace}, sa); err != nil {
+			return nil, fmt.Errorf("failed to read image pull secrets: %w", err)
+		}
+		renderer.pullSecrets = sa.ImagePullSecrets
+	}
+	return renderer, nil
+}
+
+// Run implements postrender.PostRenderer
+func (p *helmPostRenderer) Run(renderedManifests *bytes.Buffer) (*bytes.Buffer, error) {
+	objects, err := decodeManifests(renderedManifests.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	out := &bytes.Buffer{}
+	for _, obj := range objects {
+		if err := p.mutate(obj); err != nil {
+			return nil, fmt.Errorf("failed to post-render %s/%s: %w", obj.GetKind(), obj.GetName(), err)
+		}
+		doc, err := yaml.Marshal(obj.Object)
+		if err != nil {
+			return nil, err
+		}
+		out.WriteString("---\n")
+		out.Write(doc)
+	}
+	if p.next == nil {
+		return out, nil
+	}
+	return p.next.Run(out)
+}
+
+// mutate applies the post-render mutations to a rendered object
+func (p *helmPostRenderer) mutate(obj *unstructured.Unstructured) error {
+	if len(p.labels) > 0 {
+		obj.SetLabels(withLabels(obj.GetLabels(), p.labels))
+	}
+
+	var specPath []string
+	if obj.GetKind() == "Pod" {
+		specPath = []string{"spec"}
+	} else if templatePath, ok := podTemplatePaths[obj.GetKind()]; ok {
+		specPath = append(slices.Clone(templatePath), "spec")
+		if len(p.labels) > 0 {
+			labelsPath := append(slices.Clone(templatePath), "metadata", "labels")
+			current, _, _ := unstructured.NestedStringMap(obj.Object, labelsPath...)
+			if err := unstructured.SetNestedStringMap(obj.Object, withLabels(current, p.labels), labelsPath...); err != nil {
+				return err
+			}
+		}
+	} else {
+		return nil
+	}
+
+	raw, found, err := unstructured.NestedMap(obj.Object, specPath...)
+	if err != nil || !found {
+		return err
+	}
+	spec := &corev1.PodSpec{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(raw, spec); err != nil {
+		return err
+	}
+	p.mutatePodSpec(spec)
+	raw, err = runtime.DefaultUnstructuredConverter.ToUnstructured(spec)
+	if err != nil {
+		return err
+	}
+	return unstructured.SetNestedMap(obj.Object, raw, specPath...)
+}
+
+// mutatePodSpec fills in the pull secrets, security context and resources of a rendered pod
+func (p *helmPostRenderer) mutatePodSpec(spec *corev1.PodSpec) {
+	for _, secret := range p.pullSecrets {
+		if !slices.Contains(spec.ImagePullSecrets, secret) {
+			spec.ImagePullSecrets = append(spec.ImagePullSecrets, secret)
+		}
+	}
+	if p.spec.SecurityContext {
+		applyPodSecurity(spec)
+	}
+	if defaults := p.spec.DefaultResources; defaults != nil {
+		for i := range spec.InitContainers {
+			defaultContainerResources(&spec.InitContainers[i].Resources, defaults)
+		}
+		for i := range spec.Containers {
+			defaultContainerResources(&spec.Containers[i].Resources, defaults)
+		}
+	}
+}
+
+// defaultContainerResources sets the default requests and limits of the resources a
+// container sets none for, keeping requests within limits
+func defaultContainerResources(resources *corev1.ResourceRequirements, defaults *corev1.ResourceRequirements) {
+	for name, limit := range defaults.Limits {
+		if _, set := resources.Limits[name]; set {
+			continue
+		}
+		if request, set := resources.Requests[name]; set && request.Cmp(limit) > 0 {
+			limit = request
+		}
+		if resources.Limits == nil {
+			resources.Limits = corev1.ResourceList{}
+		}
+		resources.Limits[name] = limit
+	}
+	for name, request := range defaults.Requests {
+		if _, set := resources.Requests[name]; set {
+			continue
+		}
+		if limit, set := resources.Limits[name]; set && request.Cmp(limit) > 0 {
+			request = limit
+		}
+		if resources.Requests == nil {
+			resources.Requests = corev1.ResourceList{}
+		}
+		resources.Requests[name] = request
+	}
+}
+
+// withLabels returns current with labels added
+func withLabels(current, labels map[string]string) map[string]string {
+	merged := maps.Clone(current)
+	if merged == nil {
+		merged = map[string]string{}
+	}
+	maps.Copy(merged, labels)
+	return merged
+}
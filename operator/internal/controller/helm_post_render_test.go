@@ -0,0 +1,134 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+const renderedChart = `apiVersion: v1
+kind: Service
+metadata:
+  name: web
+spec:
+  selector:
+    app: web
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  labels:
+    app: web
+spec:
+  selector:
+    matchLabels:
+      app: web
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      imagePullSecrets:
+      - name: chart-pull
+      containers:
+      - name: web
+        image: ghcr.io/acme/web:1
+        resources:
+          requests:
+            cpu: "2"
+        securityContext:
+          readOnlyRootFilesystem: false
+---
+apiVersion: batch/v1
+kind: CronJob
+metadata:
+  name: cleanup
+spec:
+  schedule: "0 * * * *"
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          restartPolicy: Never
+          containers:
+          - name: cleanup
+            image: ghcr.io/acme/web:1
+`
+
+func TestHelmPostRenderer(t *testing.T) {
+	env := &catalystv1alpha1.Environment{ObjectMeta: metav1.ObjectMeta{Name: "pr-1", Namespace: "team", Labels: map[string]string{
+		"catalyst.dev/team": "acme", "catalyst.dev/project": "shop", "catalyst.dev/environment": "pr-1",
+	}}}
+	project := &catalystv1alpha1.Project{ObjectMeta: metav1.ObjectMeta{Name: "shop", Namespace: "acme"}}
+	sa := &corev1.ServiceAccount{
+		ObjectMeta:       metav1.ObjectMeta{Name: "default", Namespace: "env-ns"},
+		ImagePullSecrets: []corev1.LocalObjectReference{{Name: "registry-credentials"}},
+	}
+	c := newFakeClientBuilder().WithObjects(sa).Build()
+	r := &EnvironmentReconciler{Client: c, Scheme: testScheme}
+	ctx := context.Background()
+
+	// Without spec.helmPostRender (and guardrails) charts are deployed as rendered
+	renderer, err := r.helmPostRenderer(ctx, env, project, "env-ns")
+	require.NoError(t, err)
+	assert.Nil(t, renderer)
+
+	project.Spec.HelmPostRender = &catalystv1alpha1.HelmPostRenderSpec{
+		Labels:           true,
+		ImagePullSecrets: true,
+		SecurityContext:  true,
+		DefaultResources: &corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m"), corev1.ResourceMemory: resource.MustParse("128Mi")},
+			Limits:   corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1"), corev1.ResourceMemory: resource.MustParse("512Mi")},
+		},
+	}
+	renderer, err = r.helmPostRenderer(ctx, env, project, "env-ns")
+	require.NoError(t, err)
+	out, err := renderer.Run(bytes.NewBufferString(renderedChart))
+	require.NoError(t, err)
+	objects, err := decodeManifests(out.Bytes())
+	require.NoError(t, err)
+	require.Len(t, objects, 3)
+
+	catalystLabels := map[string]string{"catalyst.dev/team": "acme", "catalyst.dev/project": "shop", "catalyst.dev/environment": "pr-1"}
+	assert.Equal(t, catalystLabels, objects[0].GetLabels())
+	assert.Equal(t, map[string]interface{}{"app": "web"}, objects[0].Object["spec"].(map[string]interface{})["selector"], "Services are only labeled")
+
+	deployment := &appsv1.Deployment{}
+	require.NoError(t, runtime.DefaultUnstructuredConverter.FromUnstructured(objects[1].Object, deployment))
+	assert.Equal(t, map[string]string{"app": "web"}, deployment.Spec.Selector.MatchLabels, "selectors are immutable")
+	assert.Equal(t, "web", deployment.Labels["app"])
+	assert.Equal(t, "pr-1", deployment.Labels[environmentLabel])
+	assert.Equal(t, "pr-1", deployment.Spec.Template.Labels[environmentLabel])
+	pod := deployment.Spec.Template.Spec
+	assert.Equal(t, []corev1.LocalObjectReference{{Name: "chart-pull"}, {Name: "registry-credentials"}}, pod.ImagePullSecrets)
+	assert.Equal(t, ptr(true), pod.SecurityContext.RunAsNonRoot)
+	container := pod.Containers[0]
+	assert.Equal(t, ptr(false), container.SecurityContext.ReadOnlyRootFilesystem, "fields the chart sets are kept")
+	assert.Equal(t, ptr(false), container.SecurityContext.AllowPrivilegeEscalation)
+	assert.Equal(t, "2", container.Resources.Requests.Cpu().String())
+	assert.Equal(t, "2", container.Resources.Limits.Cpu().String(), "the default limit does not go below the request")
+	assert.Equal(t, "128Mi", container.Resources.Requests.Memory().String())
+	assert.Equal(t, "512Mi", container.Resources.Limits.Memory().String())
+
+	cronJob := &batchv1.CronJob{}
+	require.NoError(t, runtime.DefaultUnstructuredConverter.FromUnstructured(objects[2].Object, cronJob))
+	assert.Equal(t, "pr-1", cronJob.Spec.JobTemplate.Spec.Template.Labels[environmentLabel])
+	cleanup := cronJob.Spec.JobTemplate.Spec.Template.Spec
+	assert.Equal(t, []corev1.LocalObjectReference{{Name: "registry-credentials"}}, cleanup.ImagePullSecrets)
+	assert.True(t, hasMountPath(cleanup.Containers[0].VolumeMounts, "/tmp"))
+	assert.Equal(t, "100m", cleanup.Containers[0].Resources.Requests.Cpu().String())
+	assert.Equal(t, "1", cleanup.Containers[0].Resources.Limits.Cpu().String())
+}
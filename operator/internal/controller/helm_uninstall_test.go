@@ -0,0 +1,65 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+func TestResolveDeploymentMode(t *testing.T) {
+	helm := &catalystv1alpha1.EnvironmentTemplateSpec{Type: "helm"}
+	compose := &catalystv1alpha1.EnvironmentTemplateSpec{Type: "docker-compose"}
+
+	cases := []struct {
+		name     string
+		spec     catalystv1alpha1.EnvironmentSpec
+		template *catalystv1alpha1.EnvironmentTemplateSpec
+		want     string
+	}{
+		{"explicit mode wins", catalystv1alpha1.EnvironmentSpec{DeploymentMode: "workspace", Type: "development"}, helm, "workspace"},
+		{"helm template", catalystv1alpha1.EnvironmentSpec{Type: "development"}, helm, "helm"},
+		{"compose template", catalystv1alpha1.EnvironmentSpec{Type: "deployment"}, compose, "docker-compose"},
+		{"development type", catalystv1alpha1.EnvironmentSpec{Type: "development"}, nil, "development"},
+		{"staging type", catalystv1alpha1.EnvironmentSpec{Type: "staging"}, nil, "production"},
+		{"fallback", catalystv1alpha1.EnvironmentSpec{Type: "sandbox"}, nil, "workspace"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			env := &catalystv1alpha1.Environment{Spec: tc.spec}
+			assert.Equal(t, tc.want, resolveDeploymentMode(env, tc.template))
+		})
+	}
+}
+
+func TestRecordDeploymentSummary(t *testing.T) {
+	env := &catalystv1alpha1.Environment{Spec: catalystv1alpha1.EnvironmentSpec{
+		Sources: []catalystv1alpha1.EnvironmentSource{{Name: "primary", CommitSha: "0123456789abcdef"}},
+	}}
+	assert.True(t, recordDeploymentSummary(env, "helm"))
+	assert.Equal(t, "helm", env.Status.DeploymentMode)
+	assert.Equal(t, "0123456", env.Status.Commit)
+	assert.False(t, recordDeploymentSummary(env, "helm"), "unchanged summaries need no status update")
+
+	env.Spec.Sources = nil
+	assert.True(t, recordDeploymentSummary(env, "workspace"))
+	assert.Empty(t, env.Status.Commit)
+}
+
+func TestUninstallHelmReleaseMissingRelease(t *testing.T) {
+	t.Setenv("HELM_DRIVER", "memory")
+	r := &EnvironmentReconciler{Config: &rest.Config{Host: "https://127.0.0.1:1"}}
+	env := &catalystv1alpha1.Environment{ObjectMeta: metav1.ObjectMeta{Name: "pr-1", Namespace: "team"}}
+
+	// Uninstalling a release that was never installed (or already removed) is a no-op
+	require.NoError(t, r.uninstallHelmRelease(context.Background(), env, "env-ns"))
+
+	// Without a REST config the release can't be reached
+	r.Config = nil
+	assert.Error(t, r.uninstallHelmRelease(context.Background(), env, "env-ns"))
+}
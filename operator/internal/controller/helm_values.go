@@ -0,0 +1,136 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+// conditionHelmValuesInvalid is set on the Environment when the merged Helm values are
+// rejected before install or upgrade
+const conditionHelmValuesInvalid = "HelmValuesInvalid"
+
+// operatorValueKeys are injected by the operator and allowed by every values policy
+var operatorValueKeys = []string{"global.images", "global.catalystSecrets"}
+
+// helmValuesError is a rejection of the merged values, reason being the condition reason
+type helmValuesError struct {
+	reason  string
+	message string
+}
+
+func (e *helmValuesError) Error() string { return e.message }
+
+// validateHelmValues checks vals against the values.schema.json of the chart and its
+// subcharts, with the chart defaults applied as Helm does, and against the allowed keys
+// of policy. Nil when the values are valid.
+func validateHelmValues(chrt *chart.Chart, vals map[string]interface{}, policy *catalystv1alpha1.HelmValuesPolicySpec) error {
+	if policy != nil {
+		if disallowed := disallowedValueKeys(vals, append(append([]string{}, operatorValueKeys...), policy.AllowedKeys...)); len(disallowed) > 0 {
+			return &helmValuesError{
+				reason:  "KeyNotAllowed",
+				message: "helm values not allowed by the project policy: " + strings.Join(disallowed, ", "),
+			}
+		}
+	}
+
+	coalesced, err := chartutil.CoalesceValues(chrt, vals)
+	if err != nil {
+		return &helmValuesError{reason: "SchemaViolation", message: fmt.Sprintf("failed to merge chart defaults: %v", err)}
+	}
+	if err := chartutil.ValidateAgainstSchema(chrt, coalesced.AsMap()); err != nil {
+		return &helmValuesError{reason: "SchemaViolation", message: strings.TrimSpace(err.Error())}
+	}
+	return nil
+}
+
+// disallowedValueKeys returns the sorted dotted paths of the values in vals that no
+// allowed path covers. Nested maps are descended into; other values are leaves.
+func disallowedValueKeys(vals map[string]interface{}, allowed []string) []string {
+	var disallowed []string
+	var walk func(prefix string, m map[string]interface{})
+	walk = func(prefix string, m map[string]interface{}) {
+		for key, value := range m {
+			path := prefix + key
+			if valuePathAllowed(path, allowed) {
+				continue
+			}
+			if nested, ok := value.(map[string]interface{}); ok && len(nested) > 0 {
+				walk(path+".", nested)
+				continue
+			}
+			disallowed = append(disallowed, path)
+		}
+	}
+	walk("", vals)
+	sort.Strings(disallowed)
+	return disallowed
+}
+
+// valuePathAllowed reports whether path equals or lies beneath one of the allowed paths
+func valuePathAllowed(path string, allowed []string) bool {
+	for _, a := range allowed {
+		if path == a || strings.HasPrefix(path, a+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// recordHelmValuesValidation reports the outcome of validateHelmValues on the
+// HelmValuesInvalid condition and returns the error failing the environment. The condition
+// is only reported once values have been rejected.
+func (r *EnvironmentReconciler) recordHelmValuesValidation(ctx context.Context, env *catalystv1alpha1.Environment, validationErr error) error {
+	var invalid *helmValuesError
+	if !errors.As(validationErr, &invalid) && meta.FindStatusCondition(env.Status.Conditions, conditionHelmValuesInvalid) == nil {
+		return nil
+	}
+
+	condition := metav1.Condition{
+		Type:               conditionHelmValuesInvalid,
+		Status:             metav1.ConditionFalse,
+		Reason:             "Valid",
+		Message:            "Helm values satisfy the chart schema and the project policy",
+		ObservedGeneration: env.Generation,
+	}
+	if invalid != nil {
+		logf.FromContext(ctx).Info("Rejected helm values", "reason", invalid.reason, "message", invalid.message)
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = invalid.reason
+		condition.Message = invalid.message
+		// Written with the failure by the caller
+		meta.SetStatusCondition(&env.Status.Conditions, condition)
+		return withFailureReason(catalystv1alpha1.FailureReasonConfigInvalid, invalid)
+	}
+
+	if meta.SetStatusCondition(&env.Status.Conditions, condition) {
+		return r.Status().Update(ctx, env)
+	}
+	return nil
+}
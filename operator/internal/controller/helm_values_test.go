@@ -0,0 +1,85 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"helm.sh/helm/v3/pkg/chart"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+func schemaChart() *chart.Chart {
+	return &chart.Chart{
+		Metadata: &chart.Metadata{Name: "web", Version: "0.1.0", APIVersion: chart.APIVersionV2},
+		Values: map[string]interface{}{
+			"replicaCount": 1,
+			"ingress":      map[string]interface{}{"enabled": true, "tls": true},
+		},
+		Schema: []byte(`{
+  "$schema": "https://json-schema.org/draft-07/schema#",
+  "type": "object",
+  "required": ["replicaCount"],
+  "properties": {
+    "replicaCount": {"type": "integer", "minimum": 1},
+    "ingress": {"type": "object", "properties": {"tls": {"type": "boolean"}}}
+  }
+}`),
+	}
+}
+
+func TestValidateHelmValues_Schema(t *testing.T) {
+	assert.NoError(t, validateHelmValues(schemaChart(), map[string]interface{}{"replicaCount": 2}, nil))
+	assert.NoError(t, validateHelmValues(schemaChart(), map[string]interface{}{}, nil), "chart defaults apply")
+	assert.NoError(t, validateHelmValues(&chart.Chart{Metadata: &chart.Metadata{Name: "plain"}}, map[string]interface{}{"anything": "goes"}, nil))
+
+	err := validateHelmValues(schemaChart(), map[string]interface{}{"replicaCount": "two"}, nil)
+	require.Error(t, err)
+	var invalid *helmValuesError
+	require.ErrorAs(t, err, &invalid)
+	assert.Equal(t, "SchemaViolation", invalid.reason)
+	assert.Contains(t, invalid.message, "replicaCount")
+}
+
+func TestValidateHelmValues_AllowedKeys(t *testing.T) {
+	policy := &catalystv1alpha1.HelmValuesPolicySpec{AllowedKeys: []string{"replicaCount", "ingress.annotations"}}
+	vals := map[string]interface{}{
+		"replicaCount": 2,
+		"ingress":      map[string]interface{}{"annotations": map[string]interface{}{"a": "b"}},
+		"global":       map[string]interface{}{"images": map[string]interface{}{"web": map[string]interface{}{"tag": "abc"}}},
+	}
+	assert.NoError(t, validateHelmValues(schemaChart(), vals, policy), "operator values are always allowed")
+
+	vals["ingress"].(map[string]interface{})["tls"] = false
+	vals["debug"] = true
+	err := validateHelmValues(schemaChart(), vals, policy)
+	var invalid *helmValuesError
+	require.ErrorAs(t, err, &invalid)
+	assert.Equal(t, "KeyNotAllowed", invalid.reason)
+	assert.Equal(t, "helm values not allowed by the project policy: debug, ingress.tls", invalid.message)
+}
+
+func TestRecordHelmValuesValidation(t *testing.T) {
+	env := &catalystv1alpha1.Environment{ObjectMeta: metav1.ObjectMeta{Name: "pr-1", Namespace: "team"}}
+	c := newFakeClientBuilder().WithStatusSubresource(env).WithObjects(env).Build()
+	r := &EnvironmentReconciler{Client: c, Scheme: testScheme}
+	ctx := context.Background()
+
+	require.NoError(t, r.recordHelmValuesValidation(ctx, env, nil))
+	assert.Empty(t, env.Status.Conditions, "nothing to report before a rejection")
+
+	err := r.recordHelmValuesValidation(ctx, env, &helmValuesError{reason: "KeyNotAllowed", message: "helm values not allowed by the project policy: debug"})
+	require.Error(t, err)
+	assert.Equal(t, catalystv1alpha1.FailureReasonConfigInvalid, failureReasonOf(err, catalystv1alpha1.FailureReasonHelmInstallFailed))
+	assert.True(t, meta.IsStatusConditionTrue(env.Status.Conditions, conditionHelmValuesInvalid))
+
+	require.NoError(t, r.recordHelmValuesValidation(ctx, env, nil))
+	condition := meta.FindStatusCondition(env.Status.Conditions, conditionHelmValuesInvalid)
+	require.NotNil(t, condition)
+	assert.Equal(t, metav1.ConditionFalse, condition.Status)
+	assert.Equal(t, "Valid", condition.Reason)
+}
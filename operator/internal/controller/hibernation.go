@@ -0,0 +1,196 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"strconv"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+const (
+	// wakeAnnotation on an Environment requests wake-up of a hibernated environment.
+	// The operator clears spec.hibernate and removes the annotation.
+	wakeAnnotation = "catalyst.dev/wake"
+	// hibernatedReplicasAnnotation records a workload's replica count before hibernation
+	hibernatedReplicasAnnotation = "catalyst.dev/hibernated-replicas"
+	// phaseHibernated is the Environment phase while scaled to zero
+	phaseHibernated = "Hibernated"
+)
+
+// consumeWakeRequest clears spec.hibernate when the wake annotation is set.
+// Returns true if the Environment was updated.
+func (r *EnvironmentReconciler) consumeWakeRequest(ctx context.Context, env *catalystv1alpha1.Environment) (bool, error) {
+	if _, ok := env.Annotations[wakeAnnotation]; !ok {
+		return false, nil
+	}
+	logf.FromContext(ctx).Info("Wake requested", "environment", env.Name, "hibernated", env.Spec.Hibernate)
+	delete(env.Annotations, wakeAnnotation)
+	env.Spec.Hibernate = false
+	if err := r.Update(ctx, env); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// hibernateReplicas returns the replica count to record before scaling a workload to zero,
+// or false if the workload is already hibernated or scaled to zero.
+func hibernateReplicas(annotations map[string]string, replicas *int32) (string, bool) {
+	if _, ok := annotations[hibernatedReplicasAnnotation]; ok {
+		return "", false
+	}
+	current := int32(1)
+	if replicas != nil {
+		current = *replicas
+	}
+	if current == 0 {
+		return "", false
+	}
+	return strconv.Itoa(int(current)), true
+}
+
+// wakeReplicas returns the replica count to restore from the hibernation annotation
+func wakeReplicas(annotations map[string]string) (int32, bool) {
+	value, ok := annotations[hibernatedReplicasAnnotation]
+	if !ok {
+		return 0, false
+	}
+	replicas, err := strconv.Atoi(value)
+	if err != nil || replicas < 0 {
+		replicas = 1
+	}
+	return int32(replicas), true
+}
+
+// reconcileHibernation scales every Deployment and StatefulSet in the namespace to zero,
+// recording the previous replica counts. PVCs are left untouched. Workspace pods are deleted
+// and recreated on wake.
+func (r *EnvironmentReconciler) reconcileHibernation(ctx context.Context, env *catalystv1alpha1.Environment, namespace string) error {
+	log := logf.FromContext(ctx)
+
+	deployments := &appsv1.DeploymentList{}
+	if err := r.List(ctx, deployments, client.InNamespace(namespace)); err != nil {
+		return err
+	}
+	for i := range deployments.Items {
+		deploy := &deployments.Items[i]
+		replicas, ok := hibernateReplicas(deploy.Annotations, deploy.Spec.Replicas)
+		if !ok {
+			continue
+		}
+		if deploy.Annotations == nil {
+			deploy.Annotations = map[string]string{}
+		}
+		deploy.Annotations[hibernatedReplicasAnnotation] = replicas
+		deploy.Spec.Replicas = int32Ptr(0)
+		log.Info("Hibernating Deployment", "name", deploy.Name, "replicas", replicas)
+		if err := r.Update(ctx, deploy); err != nil {
+			return err
+		}
+	}
+
+	statefulSets := &appsv1.StatefulSetList{}
+	if err := r.List(ctx, statefulSets, client.InNamespace(namespace)); err != nil {
+		return err
+	}
+	for i := range statefulSets.Items {
+		sts := &statefulSets.Items[i]
+		replicas, ok := hibernateReplicas(sts.Annotations, sts.Spec.Replicas)
+		if !ok {
+			continue
+		}
+		if sts.Annotations == nil {
+			sts.Annotations = map[string]string{}
+		}
+		sts.Annotations[hibernatedReplicasAnnotation] = replicas
+		sts.Spec.Replicas = int32Ptr(0)
+		log.Info("Hibernating StatefulSet", "name", sts.Name, "replicas", replicas)
+		if err := r.Update(ctx, sts); err != nil {
+			return err
+		}
+	}
+
+	pods := &corev1.PodList{}
+	if err := r.List(ctx, pods, client.InNamespace(namespace), client.MatchingLabels{"catalyst.dev/pod-type": "workspace"}); err != nil {
+		return err
+	}
+	for i := range pods.Items {
+		log.Info("Deleting workspace Pod for hibernation", "pod", pods.Items[i].Name)
+		if err := r.Delete(ctx, &pods.Items[i]); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+
+	if env.Status.Phase != phaseHibernated {
+		env.Status.Phase = phaseHibernated
+		if err := r.Status().Update(ctx, env); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// wakeFromHibernation restores the replica counts recorded by reconcileHibernation and
+// moves the phase to Provisioning; the deployment mode reconcilers then report readiness as usual.
+func (r *EnvironmentReconciler) wakeFromHibernation(ctx context.Context, env *catalystv1alpha1.Environment, namespace string) error {
+	log := logf.FromContext(ctx)
+
+	deployments := &appsv1.DeploymentList{}
+	if err := r.List(ctx, deployments, client.InNamespace(namespace)); err != nil {
+		return err
+	}
+	for i := range deployments.Items {
+		deploy := &deployments.Items[i]
+		replicas, ok := wakeReplicas(deploy.Annotations)
+		if !ok {
+			continue
+		}
+		delete(deploy.Annotations, hibernatedReplicasAnnotation)
+		deploy.Spec.Replicas = int32Ptr(replicas)
+		log.Info("Waking Deployment", "name", deploy.Name, "replicas", replicas)
+		if err := r.Update(ctx, deploy); err != nil {
+			return err
+		}
+	}
+
+	statefulSets := &appsv1.StatefulSetList{}
+	if err := r.List(ctx, statefulSets, client.InNamespace(namespace)); err != nil {
+		return err
+	}
+	for i := range statefulSets.Items {
+		sts := &statefulSets.Items[i]
+		replicas, ok := wakeReplicas(sts.Annotations)
+		if !ok {
+			continue
+		}
+		delete(sts.Annotations, hibernatedReplicasAnnotation)
+		sts.Spec.Replicas = int32Ptr(replicas)
+		log.Info("Waking StatefulSet", "name", sts.Name, "replicas", replicas)
+		if err := r.Update(ctx, sts); err != nil {
+			return err
+		}
+	}
+
+	env.Status.Phase = "Provisioning"
+	return r.Status().Update(ctx, env)
+}
@@ -0,0 +1,96 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+func TestHibernateReplicas(t *testing.T) {
+	replicas, ok := hibernateReplicas(nil, int32Ptr(3))
+	assert.True(t, ok)
+	assert.Equal(t, "3", replicas)
+
+	// Defaulted replicas
+	replicas, ok = hibernateReplicas(nil, nil)
+	assert.True(t, ok)
+	assert.Equal(t, "1", replicas)
+
+	// Already scaled to zero or already hibernated
+	_, ok = hibernateReplicas(nil, int32Ptr(0))
+	assert.False(t, ok)
+	_, ok = hibernateReplicas(map[string]string{hibernatedReplicasAnnotation: "2"}, int32Ptr(0))
+	assert.False(t, ok)
+
+	restored, ok := wakeReplicas(map[string]string{hibernatedReplicasAnnotation: "2"})
+	assert.True(t, ok)
+	assert.Equal(t, int32(2), restored)
+	_, ok = wakeReplicas(nil)
+	assert.False(t, ok)
+}
+
+func TestHibernationRoundTrip(t *testing.T) {
+	env := &catalystv1alpha1.Environment{
+		ObjectMeta: metav1.ObjectMeta{Name: "pr-1", Namespace: "team"},
+		Spec:       catalystv1alpha1.EnvironmentSpec{Hibernate: true},
+	}
+	c := newFakeClientBuilder().WithStatusSubresource(env).WithObjects(
+		env,
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "env-ns"}, Spec: appsv1.DeploymentSpec{Replicas: int32Ptr(2)}},
+		&appsv1.StatefulSet{ObjectMeta: metav1.ObjectMeta{Name: "postgres", Namespace: "env-ns"}, Spec: appsv1.StatefulSetSpec{Replicas: int32Ptr(1)}},
+	).Build()
+	r := &EnvironmentReconciler{Client: c, Scheme: testScheme}
+	ctx := context.Background()
+
+	require.NoError(t, r.reconcileHibernation(ctx, env, "env-ns"))
+	assert.Equal(t, phaseHibernated, env.Status.Phase)
+
+	deploy := &appsv1.Deployment{}
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "web", Namespace: "env-ns"}, deploy))
+	assert.Equal(t, int32(0), *deploy.Spec.Replicas)
+	assert.Equal(t, "2", deploy.Annotations[hibernatedReplicasAnnotation])
+
+	// Idempotent: a second pass keeps the recorded count
+	require.NoError(t, r.reconcileHibernation(ctx, env, "env-ns"))
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "web", Namespace: "env-ns"}, deploy))
+	assert.Equal(t, "2", deploy.Annotations[hibernatedReplicasAnnotation])
+
+	require.NoError(t, r.wakeFromHibernation(ctx, env, "env-ns"))
+	assert.Equal(t, "Provisioning", env.Status.Phase)
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "web", Namespace: "env-ns"}, deploy))
+	assert.Equal(t, int32(2), *deploy.Spec.Replicas)
+	assert.NotContains(t, deploy.Annotations, hibernatedReplicasAnnotation)
+
+	sts := &appsv1.StatefulSet{}
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "postgres", Namespace: "env-ns"}, sts))
+	assert.Equal(t, int32(1), *sts.Spec.Replicas)
+}
+
+func TestConsumeWakeRequest(t *testing.T) {
+	env := &catalystv1alpha1.Environment{
+		ObjectMeta: metav1.ObjectMeta{Name: "pr-1", Namespace: "team", Annotations: map[string]string{wakeAnnotation: "2025-01-01T08:00:00Z"}},
+		Spec:       catalystv1alpha1.EnvironmentSpec{Hibernate: true},
+	}
+	c := newFakeClientBuilder().WithObjects(env).Build()
+	r := &EnvironmentReconciler{Client: c, Scheme: testScheme}
+
+	updated, err := r.consumeWakeRequest(context.Background(), env)
+	require.NoError(t, err)
+	assert.True(t, updated)
+
+	stored := &catalystv1alpha1.Environment{}
+	require.NoError(t, c.Get(context.Background(), client.ObjectKey{Name: "pr-1", Namespace: "team"}, stored))
+	assert.False(t, stored.Spec.Hibernate)
+	assert.NotContains(t, stored.Annotations, wakeAnnotation)
+
+	updated, err = r.consumeWakeRequest(context.Background(), stored)
+	require.NoError(t, err)
+	assert.False(t, updated)
+}
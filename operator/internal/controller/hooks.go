@@ -0,0 +1,151 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+const (
+	// preDeleteJobName is the Job running spec.hooks.preDelete.job
+	preDeleteJobName = "catalyst-pre-delete"
+	// conditionPreDeleteHook reports the pre-delete hook of a deleted Environment
+	conditionPreDeleteHook = "PreDeleteHook"
+	// defaultHookTimeout bounds lifecycle hooks that set no timeout
+	defaultHookTimeout = 10 * time.Minute
+)
+
+// preDeleteHook returns spec.hooks.preDelete, or nil
+func preDeleteHook(env *catalystv1alpha1.Environment) *catalystv1alpha1.PreDeleteHook {
+	if env.Spec.Hooks == nil {
+		return nil
+	}
+	return env.Spec.Hooks.PreDelete
+}
+
+// hookTimeout returns the timeout of a hook
+func hookTimeout(hook *catalystv1alpha1.PreDeleteHook) time.Duration {
+	if hook.Timeout != nil && hook.Timeout.Duration > 0 {
+		return hook.Timeout.Duration
+	}
+	return defaultHookTimeout
+}
+
+// desiredPreDeleteJob builds the Job of the pre-delete hook from its template
+func desiredPreDeleteJob(env *catalystv1alpha1.Environment, namespace string, hook *catalystv1alpha1.PreDeleteHook) *batchv1.Job {
+	spec := hook.Job.DeepCopy()
+	if spec.Template.Spec.RestartPolicy == "" {
+		spec.Template.Spec.RestartPolicy = corev1.RestartPolicyNever
+	}
+	if spec.ActiveDeadlineSeconds == nil {
+		spec.ActiveDeadlineSeconds = ptr(int64(hookTimeout(hook).Seconds()))
+	}
+	// Environment namespaces enforce the restricted standard by default
+	applyPodSecurity(&spec.Template.Spec)
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      preDeleteJobName,
+			Namespace: namespace,
+			Labels:    map[string]string{"catalyst.dev/job-type": "pre-delete"},
+		},
+		Spec: *spec,
+	}
+	labelEnvironmentWorkload(env, job)
+	prioritizeEnvironmentWorkload(env, job)
+	return job
+}
+
+// runPreDeleteHook runs the pre-delete hook Job of a deleted environment and reports
+// whether the teardown may continue: the Job succeeded, or failed under the Ignore policy.
+// Environments without a hook Job, or whose namespace is already gone, continue right away.
+func (r *EnvironmentReconciler) runPreDeleteHook(ctx context.Context, env *catalystv1alpha1.Environment, namespace string) (bool, error) {
+	hook := preDeleteHook(env)
+	if hook == nil || hook.Job == nil {
+		return true, nil
+	}
+	log := logf.FromContext(ctx)
+
+	ns := &corev1.Namespace{}
+	if err := r.Get(ctx, client.ObjectKey{Name: namespace}, ns); err != nil {
+		return apierrors.IsNotFound(err), client.IgnoreNotFound(err)
+	}
+	if !ns.DeletionTimestamp.IsZero() {
+		return true, nil
+	}
+
+	condition := metav1.Condition{
+		Type:               conditionPreDeleteHook,
+		Status:             metav1.ConditionFalse,
+		Reason:             "Running",
+		Message:            fmt.Sprintf("Waiting for Job %s", preDeleteJobName),
+		ObservedGeneration: env.Generation,
+	}
+	done := false
+
+	job := &batchv1.Job{}
+	err := r.Get(ctx, client.ObjectKey{Name: preDeleteJobName, Namespace: namespace}, job)
+	switch {
+	case apierrors.IsNotFound(err):
+		log.Info("Running pre-delete hook", "job", preDeleteJobName, "namespace", namespace)
+		if err := r.Create(ctx, desiredPreDeleteJob(env, namespace, hook)); err != nil && !isAlreadyExists(err) {
+			return false, fmt.Errorf("failed to create pre-delete hook Job: %w", err)
+		}
+	case err != nil:
+		return false, err
+	case job.Status.Succeeded > 0:
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "Succeeded"
+		condition.Message = fmt.Sprintf("Job %s succeeded", preDeleteJobName)
+		done = true
+	default:
+		for _, c := range job.Status.Conditions {
+			if c.Type != batchv1.JobFailed || c.Status != corev1.ConditionTrue {
+				continue
+			}
+			condition.Reason = "Failed"
+			condition.Message = fmt.Sprintf("Job %s failed (%s): %s", preDeleteJobName, c.Reason, c.Message)
+			if hook.FailurePolicy != catalystv1alpha1.HookFailurePolicyFail {
+				condition.Reason = "FailureIgnored"
+				done = true
+			}
+		}
+	}
+
+	if meta.SetStatusCondition(&env.Status.Conditions, condition) {
+		if condition.Reason == "Failed" || condition.Reason == "FailureIgnored" {
+			log.Info("Pre-delete hook failed", "message", condition.Message, "failurePolicy", hook.FailurePolicy)
+			recordEvent(r.Recorder, env, corev1.EventTypeWarning, eventPreDeleteHookFailed, "%s", condition.Message)
+		}
+		if err := r.Status().Update(ctx, env); err != nil {
+			return false, err
+		}
+	}
+	return done, nil
+}
@@ -0,0 +1,128 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+func preDeleteEnvironment(policy string) *catalystv1alpha1.Environment {
+	return &catalystv1alpha1.Environment{
+		ObjectMeta: metav1.ObjectMeta{Name: "pr-1", Namespace: "team"},
+		Spec: catalystv1alpha1.EnvironmentSpec{
+			Hooks: &catalystv1alpha1.EnvironmentHooks{
+				PreDelete: &catalystv1alpha1.PreDeleteHook{
+					Job: &batchv1.JobSpec{
+						Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+							Containers: []corev1.Container{{Name: "deregister", Image: "curlimages/curl", Args: []string{"-X", "DELETE", "https://dns.example.com/pr-1"}}},
+						}},
+					},
+					Timeout:       &metav1.Duration{Duration: 2 * time.Minute},
+					FailurePolicy: policy,
+				},
+			},
+		},
+	}
+}
+
+func newHookReconciler(t *testing.T, env *catalystv1alpha1.Environment, objs ...client.Object) (*EnvironmentReconciler, *record.FakeRecorder) {
+	t.Helper()
+	c := newFakeClientBuilder().WithStatusSubresource(env).WithObjects(append(objs, env)...).Build()
+	recorder := record.NewFakeRecorder(10)
+	return &EnvironmentReconciler{Client: c, Scheme: testScheme, Recorder: recorder}, recorder
+}
+
+func failJob(t *testing.T, r *EnvironmentReconciler, namespace string) {
+	t.Helper()
+	ctx := context.Background()
+	job := &batchv1.Job{}
+	require.NoError(t, r.Get(ctx, client.ObjectKey{Name: preDeleteJobName, Namespace: namespace}, job))
+	job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: corev1.ConditionTrue, Reason: "DeadlineExceeded", Message: "Job was active longer than specified deadline"}}
+	require.NoError(t, r.Status().Update(ctx, job))
+}
+
+func TestRunPreDeleteHook(t *testing.T) {
+	ctx := context.Background()
+	env := preDeleteEnvironment("")
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-shop-pr-1"}}
+	r, _ := newHookReconciler(t, env, ns)
+
+	done, err := r.runPreDeleteHook(ctx, env, ns.Name)
+	require.NoError(t, err)
+	assert.False(t, done, "teardown waits for the hook Job")
+
+	job := &batchv1.Job{}
+	require.NoError(t, r.Get(ctx, client.ObjectKey{Name: preDeleteJobName, Namespace: ns.Name}, job))
+	assert.Equal(t, int64(120), *job.Spec.ActiveDeadlineSeconds)
+	assert.Equal(t, corev1.RestartPolicyNever, job.Spec.Template.Spec.RestartPolicy)
+	assert.True(t, *job.Spec.Template.Spec.SecurityContext.RunAsNonRoot)
+	assert.Equal(t, "pr-1", job.Labels["catalyst.dev/environment"])
+	assert.Equal(t, "Running", meta.FindStatusCondition(env.Status.Conditions, conditionPreDeleteHook).Reason)
+
+	job.Status.Succeeded = 1
+	require.NoError(t, r.Status().Update(ctx, job))
+	done, err = r.runPreDeleteHook(ctx, env, ns.Name)
+	require.NoError(t, err)
+	assert.True(t, done)
+	assert.True(t, meta.IsStatusConditionTrue(env.Status.Conditions, conditionPreDeleteHook))
+}
+
+func TestRunPreDeleteHook_FailurePolicy(t *testing.T) {
+	ctx := context.Background()
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-shop-pr-1"}}
+
+	env := preDeleteEnvironment(catalystv1alpha1.HookFailurePolicyIgnore)
+	r, recorder := newHookReconciler(t, env, ns)
+	_, err := r.runPreDeleteHook(ctx, env, ns.Name)
+	require.NoError(t, err)
+	failJob(t, r, ns.Name)
+	done, err := r.runPreDeleteHook(ctx, env, ns.Name)
+	require.NoError(t, err)
+	assert.True(t, done, "failures are ignored by default")
+	assert.Equal(t, "FailureIgnored", meta.FindStatusCondition(env.Status.Conditions, conditionPreDeleteHook).Reason)
+	assert.Contains(t, <-recorder.Events, "Warning PreDeleteHookFailed Job catalyst-pre-delete failed (DeadlineExceeded)")
+
+	env = preDeleteEnvironment(catalystv1alpha1.HookFailurePolicyFail)
+	r, recorder = newHookReconciler(t, env, ns)
+	_, err = r.runPreDeleteHook(ctx, env, ns.Name)
+	require.NoError(t, err)
+	failJob(t, r, ns.Name)
+	done, err = r.runPreDeleteHook(ctx, env, ns.Name)
+	require.NoError(t, err)
+	assert.False(t, done, "the Fail policy blocks teardown")
+	assert.Equal(t, "Failed", meta.FindStatusCondition(env.Status.Conditions, conditionPreDeleteHook).Reason)
+	assert.Len(t, recorder.Events, 1)
+
+	// Reported once
+	_, err = r.runPreDeleteHook(ctx, env, ns.Name)
+	require.NoError(t, err)
+	assert.Len(t, recorder.Events, 1)
+}
+
+func TestRunPreDeleteHook_Skipped(t *testing.T) {
+	ctx := context.Background()
+
+	env := &catalystv1alpha1.Environment{ObjectMeta: metav1.ObjectMeta{Name: "pr-1", Namespace: "team"}}
+	r, _ := newHookReconciler(t, env)
+	done, err := r.runPreDeleteHook(ctx, env, "team-shop-pr-1")
+	require.NoError(t, err)
+	assert.True(t, done, "no hook")
+
+	env = preDeleteEnvironment("")
+	r, _ = newHookReconciler(t, env)
+	done, err = r.runPreDeleteHook(ctx, env, "team-shop-pr-1")
+	require.NoError(t, err)
+	assert.True(t, done, "namespace already gone")
+	assert.Empty(t, env.Status.Conditions)
+}
@@ -0,0 +1,260 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+	"github.com/ncrmro/catalyst/operator/internal/operatorconfig"
+)
+
+// Image pre-pulling: a node pulls the images of environment pods when the first of them lands
+// on it, minutes of ImagePullBackOff for large base images on a fresh node. With imagePrePull
+// in the operator configuration, the operator keeps a DaemonSet in its namespace running one
+// container per image on every node: the images configured, the ones most environment pods
+// (workloads, builds and clones) use, and the most recently deployed built images. The
+// containers sleep with a static busybox copied in by an init container, so the images need
+// no shell of their own.
+
+// +kubebuilder:rbac:groups=apps,resources=daemonsets,verbs=get;list;watch;create;update;patch;delete
+
+const (
+	imagePrePullName  = "catalyst-image-prepull"
+	imagePrePullPath  = "/catalyst-prepull"
+	imagePrePullSleep = imagePrePullPath + "/busybox"
+	// imagePrePullHelperImage provides the statically linked busybox the containers sleep with
+	imagePrePullHelperImage = "busybox:1.37.0-musl"
+
+	defaultPrePullMaxImages      = 10
+	defaultPrePullMaxBuiltImages = 10
+
+	// imagePrePullInterval is how often the pulled images are recomputed
+	imagePrePullInterval = 5 * time.Minute
+)
+
+// ImagePrePuller keeps the image pre-pull DaemonSet in sync with the operator configuration
+type ImagePrePuller struct {
+	Client client.Client
+	// Namespace holds the DaemonSet, the operator's namespace
+	Namespace string
+}
+
+// Start applies the DaemonSet until ctx is done
+func (p *ImagePrePuller) Start(ctx context.Context) error {
+	log := logf.FromContext(ctx).WithName("image-prepull")
+	ticker := time.NewTicker(imagePrePullInterval)
+	defer ticker.Stop()
+	for {
+		if err := p.ensure(ctx, operatorconfig.Current().ImagePrePull); err != nil {
+			log.Error(err, "Failed to apply the image pre-pull DaemonSet")
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// NeedLeaderElection applies from the leader only
+func (p *ImagePrePuller) NeedLeaderElection() bool {
+	return true
+}
+
+// ensure applies the DaemonSet, or deletes it when pre-pulling is disabled. A DaemonSet of
+// the same name the operator did not create is left alone.
+func (p *ImagePrePuller) ensure(ctx context.Context, cfg *operatorconfig.ImagePrePull) error {
+	existing := &appsv1.DaemonSet{}
+	err := p.Client.Get(ctx, client.ObjectKey{Name: imagePrePullName, Namespace: p.Namespace}, existing)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	found := err == nil
+	if found && existing.Labels["app.kubernetes.io/managed-by"] != "catalyst-operator" {
+		return nil
+	}
+	if cfg == nil {
+		if found {
+			return client.IgnoreNotFound(p.Client.Delete(ctx, existing))
+		}
+		return nil
+	}
+
+	pods := &corev1.PodList{}
+	if err := p.Client.List(ctx, pods, client.HasLabels{environmentLabel}); err != nil {
+		return err
+	}
+	envs := &catalystv1alpha1.EnvironmentList{}
+	if err := p.Client.List(ctx, envs); err != nil {
+		return err
+	}
+	images := prePullImages(cfg, pods.Items, envs.Items)
+
+	// Built images are pulled with the credentials builds push with
+	var pullSecrets []corev1.LocalObjectReference
+	secretName := currentRegistryConfig().SecretName
+	if err := p.Client.Get(ctx, client.ObjectKey{Name: secretName, Namespace: p.Namespace}, &corev1.Secret{}); err == nil {
+		pullSecrets = append(pullSecrets, corev1.LocalObjectReference{Name: secretName})
+	} else if !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	desired := desiredPrePullDaemonSet(p.Namespace, images, pullSecrets)
+	if !found {
+		logf.FromContext(ctx).Info("Creating the image pre-pull DaemonSet", "images", len(images))
+		return p.Client.Create(ctx, desired)
+	}
+	existing.Labels = desired.Labels
+	existing.Spec = desired.Spec
+	return p.Client.Update(ctx, existing)
+}
+
+// prePullImages returns the images to pull: the configured ones, then the maxImages images
+// the most environment pods use, then the maxBuiltImages images of the latest deployments
+// of environments, the most recent first
+func prePullImages(cfg *operatorconfig.ImagePrePull, pods []corev1.Pod, envs []catalystv1alpha1.Environment) []string {
+	maxImages := cmp.Or(cfg.MaxImages, defaultPrePullMaxImages)
+	maxBuiltImages := cmp.Or(cfg.MaxBuiltImages, defaultPrePullMaxBuiltImages)
+
+	var images []string
+	add := func(image string) bool {
+		if image == "" || slices.Contains(images, image) {
+			return false
+		}
+		images = append(images, image)
+		return true
+	}
+	for _, image := range cfg.Images {
+		add(image)
+	}
+
+	uses := map[string]int{}
+	for _, pod := range pods {
+		seen := map[string]bool{}
+		for _, container := range slices.Concat(pod.Spec.InitContainers, pod.Spec.Containers) {
+			if !seen[container.Image] {
+				seen[container.Image] = true
+				uses[container.Image]++
+			}
+		}
+	}
+	used := make([]string, 0, len(uses))
+	for image := range uses {
+		used = append(used, image)
+	}
+	slices.SortFunc(used, func(a, b string) int {
+		return cmp.Or(cmp.Compare(uses[b], uses[a]), cmp.Compare(a, b))
+	})
+	for i, added := 0, 0; i < len(used) && added < maxImages; i++ {
+		if add(used[i]) {
+			added++
+		}
+	}
+
+	type deployed struct {
+		image string
+		at    time.Time
+	}
+	var built []deployed
+	for _, env := range envs {
+		if len(env.Status.DeploymentHistory) == 0 {
+			continue
+		}
+		record := env.Status.DeploymentHistory[0]
+		for _, image := range record.Images {
+			ref := image.Image
+			if image.Digest != "" {
+				ref += "@" + image.Digest
+			}
+			built = append(built, deployed{image: ref, at: record.DeployedAt.Time})
+		}
+	}
+	slices.SortStableFunc(built, func(a, b deployed) int {
+		return cmp.Or(b.at.Compare(a.at), cmp.Compare(a.image, b.image))
+	})
+	for i, added := 0, 0; i < len(built) && added < maxBuiltImages; i++ {
+		if add(built[i].image) {
+			added++
+		}
+	}
+	return images
+}
+
+// desiredPrePullDaemonSet runs a sleeping container per image on every node
+func desiredPrePullDaemonSet(namespace string, images []string, pullSecrets []corev1.LocalObjectReference) *appsv1.DaemonSet {
+	labels := map[string]string{
+		"app.kubernetes.io/name":       imagePrePullName,
+		"app.kubernetes.io/component":  "image-prepull",
+		"app.kubernetes.io/managed-by": "catalyst-operator",
+	}
+	mount := []corev1.VolumeMount{{Name: "bin", MountPath: imagePrePullPath, ReadOnly: true}}
+	resources := corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("1m"),
+			corev1.ResourceMemory: resource.MustParse("4Mi"),
+		},
+		Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("16Mi")},
+	}
+	containers := make([]corev1.Container, 0, len(images))
+	for i, image := range images {
+		containers = append(containers, corev1.Container{
+			Name:            fmt.Sprintf("image-%d", i),
+			Image:           image,
+			ImagePullPolicy: corev1.PullIfNotPresent,
+			Command:         []string{imagePrePullSleep, "sleep", "2147483647"},
+			Resources:       resources,
+			VolumeMounts:    mount,
+		})
+	}
+	spec := corev1.PodSpec{
+		InitContainers: []corev1.Container{{
+			Name:         "busybox",
+			Image:        imagePrePullHelperImage,
+			Command:      []string{"cp", "/bin/busybox", imagePrePullSleep},
+			Resources:    resources,
+			VolumeMounts: []corev1.VolumeMount{{Name: "bin", MountPath: imagePrePullPath}},
+		}},
+		Containers:                    containers,
+		ImagePullSecrets:              pullSecrets,
+		TerminationGracePeriodSeconds: ptr(int64(0)),
+		Volumes: []corev1.Volume{{
+			Name:         "bin",
+			VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+		}},
+	}
+	applyPodSecurity(&spec)
+	return &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{Name: imagePrePullName, Namespace: namespace, Labels: labels},
+		Spec: appsv1.DaemonSetSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: corev1.PodTemplateSpec{ObjectMeta: metav1.ObjectMeta{Labels: labels}, Spec: spec},
+		},
+	}
+}
@@ -0,0 +1,97 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+	"github.com/ncrmro/catalyst/operator/internal/operatorconfig"
+)
+
+func prePullPod(name string, images ...string) corev1.Pod {
+	pod := corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name:      name,
+		Namespace: "acme-shop-dev",
+		Labels:    map[string]string{environmentLabel: "dev"},
+	}}
+	pod.Spec.InitContainers = []corev1.Container{{Name: "git-clone", Image: "alpine/git:2.45.2"}}
+	for _, image := range images {
+		pod.Spec.Containers = append(pod.Spec.Containers, corev1.Container{Name: "c", Image: image})
+	}
+	return pod
+}
+
+func prePullEnvironment(name string, deployedAt time.Time, images ...catalystv1alpha1.BuiltImage) catalystv1alpha1.Environment {
+	env := catalystv1alpha1.Environment{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "acme-shop"}}
+	env.Status.DeploymentHistory = []catalystv1alpha1.DeploymentRecord{
+		{Images: images, DeployedAt: metav1.NewTime(deployedAt)},
+		{Images: []catalystv1alpha1.BuiltImage{{Name: "web", Image: "registry/web:old"}}, DeployedAt: metav1.NewTime(deployedAt.Add(-time.Hour))},
+	}
+	return env
+}
+
+func TestPrePullImages(t *testing.T) {
+	pods := []corev1.Pod{
+		prePullPod("web", "node:22-slim"),
+		prePullPod("worker", "node:22-slim", "node:22-slim"),
+		prePullPod("postgres", "postgres:16"),
+		prePullPod("redis", "redis:7.4-alpine"),
+	}
+	now := time.Now()
+	envs := []catalystv1alpha1.Environment{
+		prePullEnvironment("older", now.Add(-time.Hour), catalystv1alpha1.BuiltImage{Name: "web", Image: "registry/web:a", Digest: "sha256:a"}),
+		prePullEnvironment("newer", now, catalystv1alpha1.BuiltImage{Name: "web", Image: "registry/web:b", Digest: "sha256:b"}),
+		{ObjectMeta: metav1.ObjectMeta{Name: "undeployed"}},
+	}
+
+	images := prePullImages(&operatorconfig.ImagePrePull{Images: []string{"nginx:1.27", "postgres:16"}}, pods, envs)
+	assert.Equal(t, []string{
+		"nginx:1.27", "postgres:16",
+		"alpine/git:2.45.2", "node:22-slim", "redis:7.4-alpine",
+		"registry/web:b@sha256:b", "registry/web:a@sha256:a",
+	}, images, "configured images first, then the most used ones, then the latest built ones")
+
+	images = prePullImages(&operatorconfig.ImagePrePull{MaxImages: 2, MaxBuiltImages: 1}, pods, envs)
+	assert.Equal(t, []string{"alpine/git:2.45.2", "node:22-slim", "registry/web:b@sha256:b"}, images)
+}
+
+func TestImagePrePuller(t *testing.T) {
+	pod := prePullPod("web", "node:22-slim")
+	registrySecret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: registrySecretName, Namespace: "catalyst-system"}}
+	c := newFakeClientBuilder().WithObjects(&pod, registrySecret).Build()
+	p := &ImagePrePuller{Client: c, Namespace: "catalyst-system"}
+	ctx := context.Background()
+	key := client.ObjectKey{Name: imagePrePullName, Namespace: "catalyst-system"}
+
+	require.NoError(t, p.ensure(ctx, &operatorconfig.ImagePrePull{}))
+	ds := &appsv1.DaemonSet{}
+	require.NoError(t, c.Get(ctx, key, ds))
+	spec := ds.Spec.Template.Spec
+	require.Len(t, spec.Containers, 2)
+	assert.Equal(t, "alpine/git:2.45.2", spec.Containers[0].Image)
+	assert.Equal(t, []string{imagePrePullSleep, "sleep", "2147483647"}, spec.Containers[1].Command)
+	assert.Equal(t, imagePrePullHelperImage, spec.InitContainers[0].Image)
+	assert.Equal(t, []corev1.LocalObjectReference{{Name: registrySecretName}}, spec.ImagePullSecrets)
+	assert.True(t, *spec.SecurityContext.RunAsNonRoot)
+
+	// Disabling pre-pulling deletes the DaemonSet
+	require.NoError(t, p.ensure(ctx, nil))
+	assert.True(t, apierrors.IsNotFound(c.Get(ctx, key, &appsv1.DaemonSet{})))
+
+	// A DaemonSet of the same name the operator did not create is left alone
+	require.NoError(t, c.Create(ctx, &appsv1.DaemonSet{ObjectMeta: metav1.ObjectMeta{Name: imagePrePullName, Namespace: "catalyst-system"}}))
+	require.NoError(t, p.ensure(ctx, &operatorconfig.ImagePrePull{}))
+	require.NoError(t, c.Get(ctx, key, ds))
+	assert.Empty(t, ds.Spec.Template.Spec.Containers)
+	require.NoError(t, p.ensure(ctx, nil))
+	require.NoError(t, c.Get(ctx, key, ds))
+}
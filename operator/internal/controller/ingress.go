@@ -0,0 +1,184 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+// Ingress customization:
+// spec.ingress of the Project, overridden by the Environment's, sets the IngressClass of the
+// preview Ingresses (canonical, alias, oauth2-proxy and file sync) and adds annotations and
+// paths to the canonical and alias Ingresses, and hosts to the canonical one. The keys of the
+// annotations added are recorded on the Ingress, so keys dropped from the spec are removed.
+
+// ingressAnnotationsAnnotation lists the spec.ingress annotation keys set on an Ingress
+const ingressAnnotationsAnnotation = "catalyst.dev/ingress-annotations"
+
+// resolveIngress merges the ingress customization of env over its project's. Nil when
+// neither sets one.
+func resolveIngress(env *catalystv1alpha1.Environment, project *catalystv1alpha1.Project) *catalystv1alpha1.EnvironmentIngress {
+	if project.Spec.Ingress == nil && env.Spec.Ingress == nil {
+		return nil
+	}
+	spec := &catalystv1alpha1.EnvironmentIngress{}
+	if project.Spec.Ingress != nil {
+		spec.IngressDefaults = *project.Spec.Ingress.DeepCopy()
+	}
+	if override := env.Spec.Ingress; override != nil {
+		if override.IngressClassName != "" {
+			spec.IngressClassName = override.IngressClassName
+		}
+		if len(override.Annotations) > 0 {
+			if spec.Annotations == nil {
+				spec.Annotations = map[string]string{}
+			}
+			maps.Copy(spec.Annotations, override.Annotations)
+		}
+		for _, path := range override.Paths {
+			spec.Paths = slices.DeleteFunc(spec.Paths, func(p catalystv1alpha1.IngressPath) bool { return p.Path == path.Path })
+			spec.Paths = append(spec.Paths, path)
+		}
+		spec.Hosts = override.Hosts
+		spec.TLSSecretName = override.TLSSecretName
+	}
+	return spec
+}
+
+// ingressClassName returns the IngressClass spec.ingress selects, "" for the default
+func ingressClassName(spec *catalystv1alpha1.EnvironmentIngress) string {
+	if spec == nil {
+		return ""
+	}
+	return spec.IngressClassName
+}
+
+// ingressHosts returns the additional hosts of spec that no Ingress or HTTPRoute outside the
+// environment namespace serves already
+func (r *EnvironmentReconciler) ingressHosts(ctx context.Context, env *catalystv1alpha1.Environment, namespace string, spec *catalystv1alpha1.EnvironmentIngress) ([]string, error) {
+	if spec == nil {
+		return nil, nil
+	}
+	log := logf.FromContext(ctx)
+	var hosts []string
+	for _, host := range spec.Hosts {
+		conflict, err := r.findHostConflict(ctx, host, namespace)
+		if err != nil {
+			return nil, err
+		}
+		if conflict != "" {
+			log.Info("Ingress host already in use", "host", host, "route", conflict)
+			recordEvent(r.Recorder, env, corev1.EventTypeWarning, eventIngressHostConflict, "Host %s is already served by %s", host, conflict)
+			continue
+		}
+		hosts = append(hosts, host)
+	}
+	return hosts, nil
+}
+
+// addIngressHosts routes hosts like the first rule of ingress
+func addIngressHosts(ingress *networkingv1.Ingress, hosts []string) {
+	for _, host := range hosts {
+		rule := *ingress.Spec.Rules[0].DeepCopy()
+		rule.Host = host
+		ingress.Spec.Rules = append(ingress.Spec.Rules, rule)
+	}
+}
+
+// applyIngressSpec adds the paths of spec to every rule of ingress, the annotations the
+// operator does not manage itself, and a TLS entry with spec.tlsSecretName for the
+// additional hosts the preview certificate does not cover. Call it after the operator's
+// own TLS and annotations are applied.
+func applyIngressSpec(ingress *networkingv1.Ingress, spec *catalystv1alpha1.EnvironmentIngress) {
+	if spec == nil {
+		return
+	}
+	for i := range ingress.Spec.Rules {
+		http := ingress.Spec.Rules[i].HTTP
+		var paths []networkingv1.HTTPIngressPath
+		for _, path := range spec.Paths {
+			pathType := networkingv1.PathTypePrefix
+			if path.PathType != "" {
+				pathType = networkingv1.PathType(path.PathType)
+			}
+			paths = append(paths, networkingv1.HTTPIngressPath{
+				Path:     path.Path,
+				PathType: &pathType,
+				Backend: networkingv1.IngressBackend{
+					Service: &networkingv1.IngressServiceBackend{
+						Name: path.Service,
+						Port: networkingv1.ServiceBackendPort{Number: path.Port},
+					},
+				},
+			})
+		}
+		// More specific paths ahead of the catch-all of web
+		http.Paths = append(paths, http.Paths...)
+	}
+
+	var keys []string
+	for key, value := range spec.Annotations {
+		if _, managed := ingress.Annotations[key]; managed {
+			continue
+		}
+		if ingress.Annotations == nil {
+			ingress.Annotations = map[string]string{}
+		}
+		ingress.Annotations[key] = value
+		keys = append(keys, key)
+	}
+	if len(keys) > 0 {
+		slices.Sort(keys)
+		ingress.Annotations[ingressAnnotationsAnnotation] = strings.Join(keys, ",")
+	}
+
+	if spec.TLSSecretName == "" {
+		return
+	}
+	var hosts []string
+	for _, rule := range ingress.Spec.Rules {
+		covered := slices.ContainsFunc(ingress.Spec.TLS, func(t networkingv1.IngressTLS) bool { return slices.Contains(t.Hosts, rule.Host) })
+		if slices.Contains(spec.Hosts, rule.Host) && !covered {
+			hosts = append(hosts, rule.Host)
+		}
+	}
+	if len(hosts) > 0 {
+		ingress.Spec.TLS = append(ingress.Spec.TLS, networkingv1.IngressTLS{Hosts: hosts, SecretName: spec.TLSSecretName})
+	}
+}
+
+// ingressAnnotationKeys returns the spec.ingress annotation keys existing and desired carry,
+// to sync with syncIngressAnnotations
+func ingressAnnotationKeys(existing, desired *networkingv1.Ingress) []string {
+	keys := []string{ingressAnnotationsAnnotation}
+	for _, ingress := range []*networkingv1.Ingress{existing, desired} {
+		if list := ingress.Annotations[ingressAnnotationsAnnotation]; list != "" {
+			keys = append(keys, strings.Split(list, ",")...)
+		}
+	}
+	slices.Sort(keys)
+	return slices.Compact(keys)
+}
@@ -0,0 +1,115 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+func TestResolveIngress(t *testing.T) {
+	project := &catalystv1alpha1.Project{}
+	env := &catalystv1alpha1.Environment{}
+	assert.Nil(t, resolveIngress(env, project))
+
+	project.Spec.Ingress = &catalystv1alpha1.IngressDefaults{
+		IngressClassName: "traefik",
+		Annotations:      map[string]string{"timeout": "60", "body-size": "10m"},
+		Paths:            []catalystv1alpha1.IngressPath{{Path: "/api", Service: "api", Port: 8080}, {Path: "/ws", Service: "ws", Port: 9000}},
+	}
+	env.Spec.Ingress = &catalystv1alpha1.EnvironmentIngress{
+		IngressDefaults: catalystv1alpha1.IngressDefaults{
+			Annotations: map[string]string{"timeout": "300"},
+			Paths:       []catalystv1alpha1.IngressPath{{Path: "/api", Service: "api-v2", Port: 8080}},
+		},
+		Hosts: []string{"shop.example.com"},
+	}
+	spec := resolveIngress(env, project)
+	require.NotNil(t, spec)
+	assert.Equal(t, "traefik", spec.IngressClassName)
+	assert.Equal(t, map[string]string{"timeout": "300", "body-size": "10m"}, spec.Annotations)
+	assert.Equal(t, []catalystv1alpha1.IngressPath{{Path: "/ws", Service: "ws", Port: 9000}, {Path: "/api", Service: "api-v2", Port: 8080}}, spec.Paths)
+	assert.Equal(t, []string{"shop.example.com"}, spec.Hosts)
+	assert.Equal(t, "10m", project.Spec.Ingress.Annotations["body-size"])
+	assert.Equal(t, "60", project.Spec.Ingress.Annotations["timeout"], "the project is not modified")
+}
+
+func TestApplyIngressSpec(t *testing.T) {
+	env := &catalystv1alpha1.Environment{ObjectMeta: metav1.ObjectMeta{Name: "pr-1"}}
+	spec := &catalystv1alpha1.EnvironmentIngress{
+		IngressDefaults: catalystv1alpha1.IngressDefaults{
+			Annotations: map[string]string{
+				"nginx.ingress.kubernetes.io/proxy-body-size": "50m",
+				nginxAuthTypeAnnotation:                       "none",
+			},
+			Paths: []catalystv1alpha1.IngressPath{{Path: "/api", PathType: "Exact", Service: "api", Port: 8080}},
+		},
+		Hosts:         []string{"shop.example.com"},
+		TLSSecretName: "shop-tls",
+	}
+	ingress := desiredIngress(env, "env-ns", false, "preview.example.com")
+	addIngressHosts(ingress, spec.Hosts)
+	(&previewTLS{Mode: previewTLSModeCopy, Domain: "preview.example.com"}).applyTo(ingress)
+	ingress.Annotations = map[string]string{nginxAuthTypeAnnotation: "basic"}
+	applyIngressSpec(ingress, spec)
+
+	require.Len(t, ingress.Spec.Rules, 2)
+	assert.Equal(t, "shop.example.com", ingress.Spec.Rules[1].Host)
+	for _, rule := range ingress.Spec.Rules {
+		paths := rule.HTTP.Paths
+		require.Len(t, paths, 2)
+		assert.Equal(t, "/api", paths[0].Path)
+		assert.Equal(t, networkingv1.PathTypeExact, *paths[0].PathType)
+		assert.Equal(t, "api", paths[0].Backend.Service.Name)
+		assert.Equal(t, "/", paths[1].Path, "web keeps the rest of the host")
+	}
+
+	assert.Equal(t, "50m", ingress.Annotations["nginx.ingress.kubernetes.io/proxy-body-size"])
+	assert.Equal(t, "basic", ingress.Annotations[nginxAuthTypeAnnotation], "operator annotations take precedence")
+	assert.Equal(t, "nginx.ingress.kubernetes.io/proxy-body-size", ingress.Annotations[ingressAnnotationsAnnotation])
+
+	require.Len(t, ingress.Spec.TLS, 2)
+	assert.Equal(t, []string{"pr-1.preview.example.com"}, ingress.Spec.TLS[0].Hosts)
+	assert.Equal(t, networkingv1.IngressTLS{Hosts: []string{"shop.example.com"}, SecretName: "shop-tls"}, ingress.Spec.TLS[1])
+}
+
+func TestIngressAnnotationKeys(t *testing.T) {
+	existing := &networkingv1.Ingress{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+		"timeout":                    "60",
+		"affinity":                   "cookie",
+		"unmanaged":                  "kept",
+		ingressAnnotationsAnnotation: "affinity,timeout",
+	}}}
+	desired := &networkingv1.Ingress{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+		"timeout":                    "300",
+		ingressAnnotationsAnnotation: "timeout",
+	}}}
+	keys := ingressAnnotationKeys(existing, desired)
+	assert.Equal(t, []string{"affinity", ingressAnnotationsAnnotation, "timeout"}, keys)
+
+	assert.True(t, syncIngressAnnotations(existing, desired, keys))
+	assert.Equal(t, map[string]string{"timeout": "300", "unmanaged": "kept", ingressAnnotationsAnnotation: "timeout"}, existing.Annotations)
+}
+
+func TestIngressHosts(t *testing.T) {
+	taken := &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "other-ns"},
+		Spec:       networkingv1.IngressSpec{Rules: []networkingv1.IngressRule{{Host: "taken.example.com"}}},
+	}
+	own := &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "env-ns"},
+		Spec:       networkingv1.IngressSpec{Rules: []networkingv1.IngressRule{{Host: "shop.example.com"}}},
+	}
+	c := newFakeClientBuilder().WithObjects(taken, own).Build()
+	r := &EnvironmentReconciler{Client: c, Scheme: testScheme}
+	env := &catalystv1alpha1.Environment{ObjectMeta: metav1.ObjectMeta{Name: "pr-1", Namespace: "team"}}
+
+	hosts, err := r.ingressHosts(context.Background(), env, "env-ns", &catalystv1alpha1.EnvironmentIngress{Hosts: []string{"shop.example.com", "taken.example.com"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"shop.example.com"}, hosts)
+}
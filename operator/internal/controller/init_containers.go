@@ -0,0 +1,130 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+const (
+	// conditionInitContainersReady is set on development environments once an init
+	// container of the web pods failed, and while it stays failed
+	conditionInitContainersReady = "InitContainersReady"
+	// defaultInitContainerTimeout bounds a single run of an init container, including git-clone
+	defaultInitContainerTimeout = 15 * time.Minute
+)
+
+// initContainerFailure is a failed init container of a web pod
+type initContainerFailure struct {
+	// reason is the condition reason: ExitedWithError or TimedOut
+	reason  string
+	message string
+}
+
+// initContainerTimeouts maps the init containers of the config to their timeout
+func initContainerTimeouts(config *catalystv1alpha1.EnvironmentConfig) map[string]time.Duration {
+	timeouts := map[string]time.Duration{}
+	for _, initSpec := range config.InitContainers {
+		if initSpec.Timeout != nil && initSpec.Timeout.Duration > 0 {
+			timeouts[initSpec.Name] = initSpec.Timeout.Duration
+		}
+	}
+	return timeouts
+}
+
+// findInitContainerFailure returns the first init container of pods that exited with a
+// non-zero code, or has been running longer than its timeout at now. A container waiting
+// in CrashLoopBackOff is reported through its last termination. Pods being deleted are
+// left out: they belong to a rollout that replaced them.
+func findInitContainerFailure(pods []corev1.Pod, timeouts map[string]time.Duration, now time.Time) *initContainerFailure {
+	for _, pod := range pods {
+		if pod.DeletionTimestamp != nil {
+			continue
+		}
+		for _, s := range pod.Status.InitContainerStatuses {
+			terminated := s.State.Terminated
+			if terminated == nil && s.State.Waiting != nil {
+				terminated = s.LastTerminationState.Terminated
+			}
+			if terminated != nil && terminated.ExitCode != 0 {
+				reason := terminated.Reason
+				if reason == "" {
+					reason = "Error"
+				}
+				return &initContainerFailure{
+					reason:  "ExitedWithError",
+					message: fmt.Sprintf("init container %s exited with code %d (%s)", s.Name, terminated.ExitCode, reason),
+				}
+			}
+
+			if s.State.Running == nil {
+				continue
+			}
+			timeout, ok := timeouts[s.Name]
+			if !ok {
+				timeout = defaultInitContainerTimeout
+			}
+			if now.Sub(s.State.Running.StartedAt.Time) > timeout {
+				return &initContainerFailure{
+					reason:  "TimedOut",
+					message: fmt.Sprintf("init container %s did not finish within %s", s.Name, timeout),
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// reportInitContainerFailure sets the InitContainersReady condition to False with the
+// failure and returns the error failing the environment
+func reportInitContainerFailure(env *catalystv1alpha1.Environment, failure *initContainerFailure) error {
+	meta.SetStatusCondition(&env.Status.Conditions, metav1.Condition{
+		Type:               conditionInitContainersReady,
+		Status:             metav1.ConditionFalse,
+		Reason:             failure.reason,
+		Message:            failure.message,
+		ObservedGeneration: env.Generation,
+	})
+	return withFailureReason(catalystv1alpha1.FailureReasonInitContainerFailed, errors.New(failure.message))
+}
+
+// clearInitContainerFailure marks the init containers ready again once the web pods
+// started. Environments that never had a failing init container get no condition.
+func (r *EnvironmentReconciler) clearInitContainerFailure(ctx context.Context, env *catalystv1alpha1.Environment) error {
+	if meta.FindStatusCondition(env.Status.Conditions, conditionInitContainersReady) == nil {
+		return nil
+	}
+	if meta.SetStatusCondition(&env.Status.Conditions, metav1.Condition{
+		Type:               conditionInitContainersReady,
+		Status:             metav1.ConditionTrue,
+		Reason:             "Completed",
+		Message:            "Init containers of the web pods completed",
+		ObservedGeneration: env.Generation,
+	}) {
+		return r.Status().Update(ctx, env)
+	}
+	return nil
+}
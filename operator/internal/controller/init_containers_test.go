@@ -0,0 +1,90 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+func webPod(statuses ...corev1.ContainerStatus) corev1.Pod {
+	return corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "web-abc"},
+		Status:     corev1.PodStatus{Phase: corev1.PodPending, InitContainerStatuses: statuses},
+	}
+}
+
+func TestFindInitContainerFailure(t *testing.T) {
+	now := time.Now()
+	completed := corev1.ContainerStatus{
+		Name:  "git-clone",
+		State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 0, Reason: "Completed"}},
+	}
+	crashLooping := corev1.ContainerStatus{
+		Name:                 "npm-install",
+		State:                corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+		LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 137, Reason: "OOMKilled"}},
+	}
+	running := func(name string, since time.Duration) corev1.ContainerStatus {
+		return corev1.ContainerStatus{
+			Name:  name,
+			State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{StartedAt: metav1.NewTime(now.Add(-since))}},
+		}
+	}
+
+	assert.Nil(t, findInitContainerFailure([]corev1.Pod{webPod(completed, running("npm-install", time.Minute))}, nil, now))
+
+	failure := findInitContainerFailure([]corev1.Pod{webPod(completed, crashLooping)}, nil, now)
+	require.NotNil(t, failure)
+	assert.Equal(t, "ExitedWithError", failure.reason)
+	assert.Equal(t, "init container npm-install exited with code 137 (OOMKilled)", failure.message)
+
+	failure = findInitContainerFailure([]corev1.Pod{webPod(completed, running("npm-install", 20*time.Minute))}, nil, now)
+	require.NotNil(t, failure, "the default timeout applies")
+	assert.Equal(t, "TimedOut", failure.reason)
+	assert.Equal(t, "init container npm-install did not finish within 15m0s", failure.message)
+
+	timeouts := initContainerTimeouts(&catalystv1alpha1.EnvironmentConfig{
+		InitContainers: []catalystv1alpha1.InitContainerSpec{
+			{Name: "npm-install", Timeout: &metav1.Duration{Duration: 30 * time.Minute}},
+			{Name: "migrate", Timeout: &metav1.Duration{Duration: time.Minute}},
+		},
+	})
+	assert.Nil(t, findInitContainerFailure([]corev1.Pod{webPod(running("npm-install", 20*time.Minute))}, timeouts, now))
+	assert.NotNil(t, findInitContainerFailure([]corev1.Pod{webPod(running("migrate", 2*time.Minute))}, timeouts, now))
+
+	terminating := webPod(crashLooping)
+	terminating.DeletionTimestamp = ptr(metav1.NewTime(now))
+	assert.Nil(t, findInitContainerFailure([]corev1.Pod{terminating}, nil, now), "replaced pods are ignored")
+}
+
+func TestInitContainerFailureCondition(t *testing.T) {
+	env := &catalystv1alpha1.Environment{ObjectMeta: metav1.ObjectMeta{Name: "pr-1", Namespace: "team"}}
+	c := newFakeClientBuilder().WithStatusSubresource(env).WithObjects(env).Build()
+	r := &EnvironmentReconciler{Client: c, Scheme: testScheme}
+	ctx := context.Background()
+
+	// Never failed: no condition
+	require.NoError(t, r.clearInitContainerFailure(ctx, env))
+	assert.Empty(t, env.Status.Conditions)
+
+	err := reportInitContainerFailure(env, &initContainerFailure{reason: "TimedOut", message: "init container npm-install did not finish within 15m0s"})
+	assert.EqualError(t, err, "init container npm-install did not finish within 15m0s")
+	assert.Equal(t, catalystv1alpha1.FailureReasonInitContainerFailed, failureReasonOf(err, catalystv1alpha1.FailureReasonDeploymentFailed))
+	condition := meta.FindStatusCondition(env.Status.Conditions, conditionInitContainersReady)
+	require.NotNil(t, condition)
+	assert.Equal(t, metav1.ConditionFalse, condition.Status)
+	assert.Equal(t, "TimedOut", condition.Reason)
+	require.NoError(t, r.markFailed(ctx, env, catalystv1alpha1.FailureReasonDeploymentFailed, err))
+	assert.Equal(t, "Failed", env.Status.Phase)
+
+	require.NoError(t, r.clearInitContainerFailure(ctx, env))
+	assert.True(t, meta.IsStatusConditionTrue(env.Status.Conditions, conditionInitContainersReady))
+}
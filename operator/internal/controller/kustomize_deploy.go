@@ -0,0 +1,214 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/kustomize/api/krusty"
+	"sigs.k8s.io/kustomize/api/types"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+	"sigs.k8s.io/yaml"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+	"github.com/ncrmro/catalyst/operator/internal/guardrails"
+)
+
+// Kustomize mode (template type "kustomize"):
+// The kustomization at the template path is built with a generated overlay that sets the
+// environment namespace and rewrites images named after a template build (e.g. "image: web")
+// to the built image with an `images:` transformer. The output is checked against the
+// guardrails and applied with server-side apply. Resources dropped from the kustomization are
+// not pruned; they are removed with the namespace.
+
+// kustomizeFieldOwner is the server-side apply field manager for kustomize resources
+const kustomizeFieldOwner = "catalyst-operator"
+
+// kustomizeImages converts built images into `images:` overrides, sorted by build name
+func kustomizeImages(builtImages map[string]string) []types.Image {
+	images := make([]types.Image, 0, len(builtImages))
+	for name, ref := range builtImages {
+		repository, tag, digest := parseImageRef(ref)
+		image := types.Image{Name: name, NewName: repository, Digest: digest}
+		if digest == "" {
+			image.NewTag = tag
+		}
+		images = append(images, image)
+	}
+	sort.Slice(images, func(i, j int) bool { return images[i].Name < images[j].Name })
+	return images
+}
+
+// renderKustomization builds the kustomization in dir through an overlay setting namespace and
+// the built image overrides. Returns the rendered multi-document YAML.
+func renderKustomization(dir, namespace string, builtImages map[string]string) ([]byte, error) {
+	base, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	overlayDir, err := os.MkdirTemp("", "catalyst-kustomize-*")
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		tempDirCleanupsTotal.WithLabelValues("source", metricResult(os.RemoveAll(overlayDir))).Inc()
+	}()
+
+	// Kustomize rejects absolute resource roots; reference the base relative to the overlay
+	relativeBase, err := filepath.Rel(overlayDir, base)
+	if err != nil {
+		return nil, err
+	}
+	overlay := types.Kustomization{
+		TypeMeta:  types.TypeMeta{APIVersion: types.KustomizationVersion, Kind: types.KustomizationKind},
+		Namespace: namespace,
+		Resources: []string{relativeBase},
+		Images:    kustomizeImages(builtImages),
+	}
+	data, err := yaml.Marshal(overlay)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(overlayDir, "kustomization.yaml"), data, 0o600); err != nil {
+		return nil, err
+	}
+
+	// The base lives outside the overlay root
+	options := krusty.MakeDefaultOptions()
+	options.LoadRestrictions = types.LoadRestrictionsNone
+	resources, err := krusty.MakeKustomizer(options).Run(filesys.MakeFsOnDisk(), overlayDir)
+	if err != nil {
+		return nil, fmt.Errorf("kustomize build failed: %w", err)
+	}
+	return resources.AsYaml()
+}
+
+// decodeManifests splits a multi-document YAML stream into objects
+func decodeManifests(manifests []byte) ([]*unstructured.Unstructured, error) {
+	var objects []*unstructured.Unstructured
+	decoder := utilyaml.NewYAMLOrJSONDecoder(bytes.NewReader(manifests), 4096)
+	for {
+		obj := &unstructured.Unstructured{}
+		if err := decoder.Decode(&obj.Object); err != nil {
+			if errors.Is(err, io.EOF) {
+				return objects, nil
+			}
+			return nil, fmt.Errorf("failed to parse rendered manifests: %w", err)
+		}
+		if len(obj.Object) > 0 {
+			objects = append(objects, obj)
+		}
+	}
+}
+
+// ReconcileKustomizeMode builds the template kustomization with the built images and applies it.
+// Ready once every rendered Deployment and StatefulSet has a ready replica.
+func (r *EnvironmentReconciler) ReconcileKustomizeMode(ctx context.Context, env *catalystv1alpha1.Environment, project *catalystv1alpha1.Project, namespace string, template *catalystv1alpha1.EnvironmentTemplateSpec) (bool, error) {
+	log := logf.FromContext(ctx)
+
+	if template == nil {
+		return false, fmt.Errorf("kustomize template is required")
+	}
+
+	// 1. Builds
+	var builtImages map[string]string
+	if len(template.Builds) > 0 {
+		var err error
+		builtImages, err = r.reconcileBuilds(ctx, env, project, namespace, template)
+		if err != nil {
+			return false, err
+		}
+		if builtImages == nil {
+			return false, nil // Waiting for builds
+		}
+	}
+
+	// 2. Render
+	sourcePath, cleanup, err := r.prepareSource(ctx, env, project, template)
+	if cleanup != nil {
+		defer cleanup()
+	}
+	if err != nil {
+		return false, err
+	}
+	manifests, err := renderKustomization(sourcePath, namespace, builtImages)
+	if err != nil {
+		return false, withFailureReason(catalystv1alpha1.FailureReasonConfigInvalid, err)
+	}
+
+	// 3. Guardrails; built images are pushed to the operator's registry
+	policy := guardrails.Current()
+	for _, image := range builtImages {
+		policy = policy.WithExemptImages(image)
+	}
+	violations, err := policy.CheckManifests(manifests)
+	if err != nil {
+		return false, err
+	}
+	if err := guardrails.AsError(violations); err != nil {
+		return false, err
+	}
+
+	// 4. Server-side apply
+	objects, err := decodeManifests(manifests)
+	if err != nil {
+		return false, err
+	}
+	for _, obj := range objects {
+		namespaced, err := r.IsObjectNamespaced(obj)
+		if err != nil {
+			return false, fmt.Errorf("failed to resolve scope of %s %s: %w", obj.GetKind(), obj.GetName(), err)
+		}
+		if !namespaced {
+			return false, fmt.Errorf("cluster-scoped %s %s is not supported in kustomize mode", obj.GetKind(), obj.GetName())
+		}
+		labelEnvironmentWorkload(env, obj)
+		prioritizeEnvironmentWorkload(env, obj)
+		if err := r.Apply(ctx, client.ApplyConfigurationFromUnstructured(obj), client.FieldOwner(kustomizeFieldOwner), client.ForceOwnership); err != nil {
+			return false, fmt.Errorf("failed to apply %s %s: %w", obj.GetKind(), obj.GetName(), err)
+		}
+	}
+	log.Info("Applied kustomization", "namespace", namespace, "objects", len(objects))
+
+	// 5. Readiness
+	for _, obj := range objects {
+		var ready bool
+		switch obj.GetKind() {
+		case "Deployment":
+			ready, err = r.isDeploymentReady(ctx, namespace, obj.GetName())
+		case "StatefulSet":
+			ready, err = r.isStatefulSetReady(ctx, namespace, obj.GetName())
+		default:
+			continue
+		}
+		if err != nil || !ready {
+			return false, err
+		}
+	}
+	return true, nil
+}
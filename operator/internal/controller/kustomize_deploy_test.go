@@ -0,0 +1,81 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+func TestRenderKustomization(t *testing.T) {
+	manifests, err := renderKustomization("testdata/kustomize", "env-ns", map[string]string{
+		"web": "registry/web:abc1234@sha256:0123",
+		"api": "registry/api:abc1234",
+	})
+	require.NoError(t, err)
+
+	objects, err := decodeManifests(manifests)
+	require.NoError(t, err)
+	require.Len(t, objects, 2)
+	deployment := &appsv1.Deployment{}
+	for _, obj := range objects {
+		assert.Equal(t, "env-ns", obj.GetNamespace())
+		if obj.GetKind() == "Deployment" {
+			require.NoError(t, runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, deployment))
+		}
+	}
+	containers := deployment.Spec.Template.Spec.Containers
+	assert.Equal(t, "registry/web@sha256:0123", containers[0].Image, "build name replaced by the pinned image")
+	assert.Equal(t, "redis:7", containers[1].Image, "other images are left alone")
+
+	_, err = renderKustomization("testdata/compose", "env-ns", nil)
+	assert.ErrorContains(t, err, "kustomize build failed")
+}
+
+func TestKustomizeImages(t *testing.T) {
+	images := kustomizeImages(map[string]string{
+		"web": "registry/web:abc1234@sha256:0123",
+		"api": "registry/api:abc1234",
+	})
+	require.Len(t, images, 2)
+	assert.Equal(t, "api", images[0].Name)
+	assert.Equal(t, "registry/api", images[0].NewName)
+	assert.Equal(t, "abc1234", images[0].NewTag)
+	assert.Equal(t, "sha256:0123", images[1].Digest)
+	assert.Empty(t, images[1].NewTag, "the digest pins the image")
+}
+
+func TestReconcileKustomizeMode(t *testing.T) {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(appsv1.SchemeGroupVersion.WithKind("Deployment"), meta.RESTScopeNamespace)
+	mapper.Add(corev1.SchemeGroupVersion.WithKind("Service"), meta.RESTScopeNamespace)
+	c := newFakeClientBuilder().WithRESTMapper(mapper).Build()
+	r := &EnvironmentReconciler{Client: c, Scheme: testScheme}
+	ctx := context.Background()
+	env := &catalystv1alpha1.Environment{ObjectMeta: metav1.ObjectMeta{Name: "pr-1", Namespace: "team"}}
+	template := &catalystv1alpha1.EnvironmentTemplateSpec{Type: "kustomize", Path: "testdata/kustomize"}
+
+	ready, err := r.ReconcileKustomizeMode(ctx, env, &catalystv1alpha1.Project{}, "env-ns", template)
+	require.NoError(t, err)
+	assert.False(t, ready, "the deployment has no ready replicas yet")
+
+	deployment := &appsv1.Deployment{}
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Namespace: "env-ns", Name: "web"}, deployment))
+	assert.Equal(t, "pr-1", deployment.Labels[environmentLabel])
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Namespace: "env-ns", Name: "web"}, &corev1.Service{}))
+
+	deployment.Status.ReadyReplicas = 1
+	require.NoError(t, c.Status().Update(ctx, deployment))
+	ready, err = r.ReconcileKustomizeMode(ctx, env, &catalystv1alpha1.Project{}, "env-ns", template)
+	require.NoError(t, err)
+	assert.True(t, ready)
+}
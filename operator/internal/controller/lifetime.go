@@ -0,0 +1,146 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+	"github.com/ncrmro/catalyst/operator/internal/lifetime"
+	"github.com/ncrmro/catalyst/operator/internal/sharding"
+)
+
+// EnvironmentJanitorReconciler enforces the maximum Environment lifetime: it records
+// status.expiresAt and deletes Environments past it, unless they carry the exemption annotation.
+type EnvironmentJanitorReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+	// Policy is the lifetime cap. Nil reads the one of the operator configuration in effect.
+	Policy *lifetime.Policy
+	// Shard limits enforcement to the Projects owned by this operator instance.
+	// Nil enforces on every Environment.
+	Shard *sharding.Shard
+	// Recorder emits an Event on each Environment deleted for its age. Nil records none.
+	Recorder record.EventRecorder
+}
+
+// Reconcile records when an Environment expires and deletes it once it has
+func (r *EnvironmentJanitorReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := logf.FromContext(ctx)
+
+	env := &catalystv1alpha1.Environment{}
+	if err := r.Get(ctx, req.NamespacedName, env); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if !env.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	teamNamespace := env.Namespace
+	if hierarchy := ExtractNamespaceHierarchy(env.Labels); hierarchy != nil {
+		teamNamespace = hierarchy.Team
+	}
+	if r.Shard.Partial() {
+		project := &catalystv1alpha1.Project{}
+		if err := r.Get(ctx, client.ObjectKey{Name: env.Spec.ProjectRef.Name, Namespace: teamNamespace}, project); err != nil {
+			return ctrl.Result{}, client.IgnoreNotFound(err)
+		}
+		if owned, err := ownsProject(ctx, r, r.Shard, project); err != nil || !owned {
+			return ctrl.Result{}, err
+		}
+	}
+
+	team := &corev1.Namespace{}
+	if err := r.Get(ctx, client.ObjectKey{Name: teamNamespace}, team); err != nil && !apierrors.IsNotFound(err) {
+		return ctrl.Result{}, err
+	}
+	policy := lifetime.Current()
+	if r.Policy != nil {
+		policy = *r.Policy
+	}
+	maxLifetime, capped, err := policy.MaxLifetimeFor(env.Spec.Type, team.Annotations)
+	if err != nil {
+		log.Error(err, "Ignoring team lifetime override", "namespace", teamNamespace)
+		maxLifetime, capped, _ = policy.MaxLifetimeFor(env.Spec.Type, nil)
+	}
+
+	var expiresAt *metav1.Time
+	if capped && !lifetime.Exempt(env.Annotations) {
+		// Status round-trips with second precision
+		t := metav1.NewTime(env.CreationTimestamp.Add(maxLifetime)).Rfc3339Copy()
+		expiresAt = &t
+	}
+	if !expiresAt.Equal(env.Status.ExpiresAt) {
+		env.Status.ExpiresAt = expiresAt
+		if err := r.Status().Update(ctx, env); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	if expiresAt == nil {
+		return ctrl.Result{}, nil
+	}
+
+	if remaining := time.Until(expiresAt.Time); remaining > 0 {
+		return ctrl.Result{RequeueAfter: remaining}, nil
+	}
+	log.Info("Deleting Environment past its maximum lifetime", "environment", env.Name, "created", env.CreationTimestamp, "maxLifetime", maxLifetime)
+	recordEvent(r.Recorder, env, corev1.EventTypeNormal, eventExpired, "Deleting Environment past its maximum lifetime of %s", maxLifetime)
+	if err := r.Delete(ctx, env); err != nil && !apierrors.IsNotFound(err) {
+		return ctrl.Result{}, err
+	}
+	environmentsExpiredTotal.Inc()
+	return ctrl.Result{}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *EnvironmentJanitorReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&catalystv1alpha1.Environment{}).
+		// Team lifetime overrides are annotations on the team namespace
+		Watches(&corev1.Namespace{}, handler.EnqueueRequestsFromMapFunc(r.environmentsForTeamNamespace),
+			builder.WithPredicates(predicate.AnnotationChangedPredicate{})).
+		Named("environment-janitor").
+		Complete(drainingReconciler{r})
+}
+
+// environmentsForTeamNamespace enqueues the team's Environments when its namespace changes
+func (r *EnvironmentJanitorReconciler) environmentsForTeamNamespace(ctx context.Context, obj client.Object) []reconcile.Request {
+	envs := &catalystv1alpha1.EnvironmentList{}
+	if err := r.List(ctx, envs, client.MatchingLabels{"catalyst.dev/team": obj.GetName()}); err != nil {
+		logf.FromContext(ctx).Error(err, "Failed to list Environments for team namespace", "namespace", obj.GetName())
+		return nil
+	}
+	requests := make([]reconcile.Request, 0, len(envs.Items))
+	for _, env := range envs.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&env)})
+	}
+	return requests
+}
@@ -0,0 +1,75 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+	"github.com/ncrmro/catalyst/operator/internal/lifetime"
+)
+
+func TestEnvironmentJanitor(t *testing.T) {
+	newEnv := func(name, envType string, age time.Duration, annotations map[string]string) *catalystv1alpha1.Environment {
+		return &catalystv1alpha1.Environment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				Namespace:         "team",
+				CreationTimestamp: metav1.NewTime(time.Now().Add(-age)),
+				Annotations:       annotations,
+			},
+			Spec: catalystv1alpha1.EnvironmentSpec{Type: envType},
+		}
+	}
+	stale := newEnv("stale", "development", 15*24*time.Hour, nil)
+	fresh := newEnv("fresh", "development", time.Hour, nil)
+	exempt := newEnv("exempt", "development", 30*24*time.Hour, map[string]string{lifetime.ExemptAnnotation: "demo"})
+	production := newEnv("production", "deployment", 30*24*time.Hour, nil)
+	team := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team"}}
+
+	c := newFakeClientBuilder().
+		WithStatusSubresource(&catalystv1alpha1.Environment{}).
+		WithObjects(stale, fresh, exempt, production, team).Build()
+	r := &EnvironmentJanitorReconciler{
+		Client: c,
+		Scheme: testScheme,
+		Policy: &lifetime.Policy{MaxLifetime: 14 * 24 * time.Hour, Types: []string{"development"}},
+	}
+	ctx := context.Background()
+	reconcileEnv := func(name string) ctrl.Result {
+		result, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKey{Name: name, Namespace: "team"}})
+		require.NoError(t, err)
+		return result
+	}
+
+	reconcileEnv("stale")
+	err := c.Get(ctx, client.ObjectKey{Name: "stale", Namespace: "team"}, &catalystv1alpha1.Environment{})
+	assert.True(t, apierrors.IsNotFound(err))
+
+	result := reconcileEnv("fresh")
+	assert.InDelta(t, (14*24*time.Hour - time.Hour).Seconds(), result.RequeueAfter.Seconds(), 5)
+	got := &catalystv1alpha1.Environment{}
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "fresh", Namespace: "team"}, got))
+	require.NotNil(t, got.Status.ExpiresAt)
+
+	for _, name := range []string{"exempt", "production"} {
+		assert.Zero(t, reconcileEnv(name).RequeueAfter)
+		require.NoError(t, c.Get(ctx, client.ObjectKey{Name: name, Namespace: "team"}, got))
+		assert.Nil(t, got.Status.ExpiresAt)
+	}
+
+	// A team override shortens the cap for its environments
+	team.Annotations = map[string]string{lifetime.MaxLifetimeAnnotation: "30m"}
+	require.NoError(t, c.Update(ctx, team))
+	reconcileEnv("fresh")
+	err = c.Get(ctx, client.ObjectKey{Name: "fresh", Namespace: "team"}, got)
+	assert.True(t, apierrors.IsNotFound(err))
+}
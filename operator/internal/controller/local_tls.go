@@ -0,0 +1,244 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/ncrmro/catalyst/operator/internal/operatorconfig"
+)
+
+// TLS for local preview routing: with localPreviewRouting and LOCAL_PREVIEW_TLS=true,
+// previews are served at https://<namespace>.localhost:<LOCAL_PREVIEW_TLS_PORT>/ (default
+// 8443), so Secure cookies and OAuth callbacks behave as in production. A cert-manager CA
+// ClusterIssuer signs a certificate per preview Ingress. Its CA is self-signed by the
+// operator, or LOCAL_PREVIEW_TLS_CA_SECRET names a kubernetes.io/tls Secret holding an
+// existing CA, e.g. the mkcert root CA browsers on the machine already trust:
+//
+//	kubectl -n cert-manager create secret tls mkcert-ca \
+//	  --cert="$(mkcert -CAROOT)/rootCA.pem" --key="$(mkcert -CAROOT)/rootCA-key.pem"
+//
+// Either way the CA certificate is published in the ConfigMap catalyst-local-ca (key
+// ca.crt) in the operator namespace, for installing it into the local trust store.
+
+const (
+	localCAName           = "catalyst-local-ca"
+	localSelfSignedIssuer = "catalyst-local-selfsigned"
+	localCAConfigMapKey   = "ca.crt"
+
+	defaultLocalTLSPort         = "8443"
+	defaultCertManagerNamespace = "cert-manager"
+
+	// certManagerIssuerAnnotation has cert-manager issue the certificate of an Ingress TLS section
+	certManagerIssuerAnnotation = "cert-manager.io/cluster-issuer"
+
+	// localCAResyncInterval is how often the issuers are re-applied; localCAPendingInterval
+	// retries while cert-manager has not issued the CA yet
+	localCAResyncInterval  = 5 * time.Minute
+	localCAPendingInterval = 10 * time.Second
+)
+
+var (
+	clusterIssuerGVK = schema.GroupVersionKind{Group: "cert-manager.io", Version: "v1", Kind: "ClusterIssuer"}
+	certificateGVK   = schema.GroupVersionKind{Group: "cert-manager.io", Version: "v1", Kind: "Certificate"}
+
+	// previewTLSAnnotations are kept in sync on existing preview Ingresses
+	previewTLSAnnotations = []string{certManagerIssuerAnnotation}
+)
+
+// +kubebuilder:rbac:groups=cert-manager.io,resources=clusterissuers;certificates,verbs=get;list;watch;create;update
+
+// localTLSConfig is the resolved TLS configuration of local preview routing
+type localTLSConfig struct {
+	// Port is the port the ingress controller serves HTTPS on locally
+	Port string
+	// CASecret is the CA Secret in CertManagerNamespace; empty has the operator self-sign one
+	CASecret string
+	// CertManagerNamespace is cert-manager's cluster resource namespace, where ClusterIssuers
+	// read their Secrets
+	CertManagerNamespace string
+}
+
+// currentLocalTLS reads localPreviewTLS of the operator configuration; nil unless local
+// routing serves TLS
+func currentLocalTLS() *localTLSConfig {
+	config := operatorconfig.Current()
+	if !config.LocalPreviewRouting || !config.LocalPreviewTLS.Enabled {
+		return nil
+	}
+	cfg := &localTLSConfig{
+		Port:                 config.LocalPreviewTLS.Port,
+		CASecret:             config.LocalPreviewTLS.CASecret,
+		CertManagerNamespace: config.LocalPreviewTLS.CertManagerNamespace,
+	}
+	if cfg.Port == "" {
+		cfg.Port = defaultLocalTLSPort
+	}
+	if cfg.CertManagerNamespace == "" {
+		cfg.CertManagerNamespace = defaultCertManagerNamespace
+	}
+	return cfg
+}
+
+// caSecretName returns the name of the Secret holding the CA
+func (c *localTLSConfig) caSecretName() string {
+	if c.CASecret != "" {
+		return c.CASecret
+	}
+	return localCAName
+}
+
+// previewTLS returns the Ingress TLS configuration of local previews
+func (c *localTLSConfig) previewTLS() *previewTLS {
+	if c == nil {
+		return nil
+	}
+	return &previewTLS{Mode: previewTLSModeLocalCA, Domain: "localhost", Issuer: localCAName, Port: c.Port}
+}
+
+// LocalCA provisions the cert-manager issuers of TLS for local preview routing and publishes
+// their CA certificate
+type LocalCA struct {
+	Client client.Client
+}
+
+// Start applies the issuers until ctx is done
+func (l *LocalCA) Start(ctx context.Context) error {
+	cfg := currentLocalTLS()
+	if cfg == nil {
+		return nil
+	}
+	log := logf.FromContext(ctx).WithName("local-ca")
+	for {
+		wait := localCAResyncInterval
+		if published, err := l.ensure(ctx, cfg, os.Getenv("POD_NAMESPACE")); err != nil {
+			log.Error(err, "Failed to provision the local preview CA")
+			wait = localCAPendingInterval
+		} else if !published {
+			log.Info("Waiting for cert-manager to issue the local preview CA", "secret", cfg.CertManagerNamespace+"/"+cfg.caSecretName())
+			wait = localCAPendingInterval
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(wait):
+		}
+	}
+}
+
+// NeedLeaderElection provisions from the leader only
+func (l *LocalCA) NeedLeaderElection() bool {
+	return true
+}
+
+// ensure applies the issuers and publishes the CA certificate in namespace. It returns
+// whether the CA was published, false while its Secret does not exist yet.
+func (l *LocalCA) ensure(ctx context.Context, cfg *localTLSConfig, namespace string) (bool, error) {
+	var objects []client.Object
+	if cfg.CASecret == "" {
+		objects = append(objects, desiredSelfSignedIssuer(), desiredLocalCACertificate(cfg.CertManagerNamespace))
+	}
+	objects = append(objects, desiredLocalCAIssuer(cfg.caSecretName()))
+	for _, obj := range objects {
+		if err := createOrReplace(ctx, l.Client, obj); err != nil {
+			if meta.IsNoMatchError(err) {
+				return false, fmt.Errorf("cert-manager is not installed: %w", err)
+			}
+			return false, fmt.Errorf("failed to apply %s %s: %w", obj.GetObjectKind().GroupVersionKind().Kind, obj.GetName(), err)
+		}
+	}
+
+	secret := &corev1.Secret{}
+	if err := l.Client.Get(ctx, client.ObjectKey{Name: cfg.caSecretName(), Namespace: cfg.CertManagerNamespace}, secret); err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	// The CA is the certificate of its own Secret, self-signed or the mkcert root
+	ca := secret.Data[corev1.TLSCertKey]
+	if len(ca) == 0 {
+		return false, nil
+	}
+	if namespace == "" {
+		namespace = cfg.CertManagerNamespace
+	}
+	return true, createOrReplace(ctx, l.Client, desiredLocalCAConfigMap(namespace, ca))
+}
+
+func localCALabels() map[string]string {
+	return map[string]string{
+		"catalyst.dev/component":       "local-ca",
+		"app.kubernetes.io/managed-by": "catalyst-operator",
+	}
+}
+
+func desiredSelfSignedIssuer() *unstructured.Unstructured {
+	issuer := &unstructured.Unstructured{}
+	issuer.SetGroupVersionKind(clusterIssuerGVK)
+	issuer.SetName(localSelfSignedIssuer)
+	issuer.SetLabels(localCALabels())
+	issuer.Object["spec"] = map[string]interface{}{"selfSigned": map[string]interface{}{}}
+	return issuer
+}
+
+// desiredLocalCACertificate is the self-signed CA, renewed by cert-manager
+func desiredLocalCACertificate(namespace string) *unstructured.Unstructured {
+	certificate := &unstructured.Unstructured{}
+	certificate.SetGroupVersionKind(certificateGVK)
+	certificate.SetName(localCAName)
+	certificate.SetNamespace(namespace)
+	certificate.SetLabels(localCALabels())
+	certificate.Object["spec"] = map[string]interface{}{
+		"isCA":       true,
+		"commonName": "Catalyst Local Preview CA",
+		"secretName": localCAName,
+		"duration":   "87600h",
+		"privateKey": map[string]interface{}{"algorithm": "ECDSA", "size": int64(256)},
+		"issuerRef":  map[string]interface{}{"name": localSelfSignedIssuer, "kind": "ClusterIssuer", "group": "cert-manager.io"},
+	}
+	return certificate
+}
+
+// desiredLocalCAIssuer signs the certificates of local preview Ingresses
+func desiredLocalCAIssuer(secretName string) *unstructured.Unstructured {
+	issuer := &unstructured.Unstructured{}
+	issuer.SetGroupVersionKind(clusterIssuerGVK)
+	issuer.SetName(localCAName)
+	issuer.SetLabels(localCALabels())
+	issuer.Object["spec"] = map[string]interface{}{"ca": map[string]interface{}{"secretName": secretName}}
+	return issuer
+}
+
+func desiredLocalCAConfigMap(namespace string, ca []byte) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: localCAName, Namespace: namespace, Labels: localCALabels()},
+		Data:       map[string]string{localCAConfigMapKey: string(ca)},
+	}
+}
@@ -0,0 +1,117 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+func TestCurrentLocalTLS(t *testing.T) {
+	t.Setenv("LOCAL_PREVIEW_TLS", "true")
+	assert.Nil(t, currentLocalTLS(), "TLS applies to local routing only")
+
+	t.Setenv("LOCAL_PREVIEW_ROUTING", "true")
+	assert.Equal(t, &localTLSConfig{Port: "8443", CertManagerNamespace: "cert-manager"}, currentLocalTLS())
+
+	t.Setenv("LOCAL_PREVIEW_TLS_PORT", "443")
+	t.Setenv("LOCAL_PREVIEW_TLS_CA_SECRET", "mkcert-ca")
+	cfg := currentLocalTLS()
+	assert.Equal(t, "443", cfg.Port)
+	assert.Equal(t, "mkcert-ca", cfg.caSecretName())
+
+	t.Setenv("LOCAL_PREVIEW_TLS", "")
+	assert.Nil(t, currentLocalTLS())
+	assert.Nil(t, currentLocalTLS().previewTLS())
+}
+
+func TestLocalPreviewTLSApplyTo(t *testing.T) {
+	t.Setenv("LOCAL_PREVIEW_ROUTING", "true")
+	t.Setenv("LOCAL_PREVIEW_TLS", "true")
+	env := &catalystv1alpha1.Environment{ObjectMeta: metav1.ObjectMeta{Name: "pr-1"}}
+	tls := currentLocalTLS().previewTLS()
+
+	ingress := desiredIngress(env, "acme-shop-pr-1", true)
+	tls.applyTo(ingress)
+	assert.Equal(t, "catalyst-local-ca", ingress.Annotations[certManagerIssuerAnnotation])
+	require.Len(t, ingress.Spec.TLS, 1)
+	assert.Equal(t, []string{"acme-shop-pr-1.localhost"}, ingress.Spec.TLS[0].Hosts)
+	assert.Equal(t, "web-tls", ingress.Spec.TLS[0].SecretName)
+
+	alias := desiredAliasIngress(env, "acme-shop-pr-1", aliasHost("login", true, ""), true, tls, nil)
+	assert.Equal(t, "web-alias-tls", alias.Spec.TLS[0].SecretName, "every Ingress has its own certificate")
+	assert.Equal(t, "https://acme-shop-pr-1.localhost:8443/", hostURL(ingress.Spec.Rules[0].Host, true, "8080", tls))
+}
+
+func TestLocalCAEnsure(t *testing.T) {
+	c := newFakeClientBuilder().Build()
+	l := &LocalCA{Client: c}
+	ctx := context.Background()
+	cfg := &localTLSConfig{Port: "8443", CertManagerNamespace: "cert-manager"}
+
+	// cert-manager has not issued the self-signed CA yet
+	published, err := l.ensure(ctx, cfg, "catalyst-system")
+	require.NoError(t, err)
+	assert.False(t, published)
+
+	selfSigned := &unstructured.Unstructured{}
+	selfSigned.SetGroupVersionKind(clusterIssuerGVK)
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: localSelfSignedIssuer}, selfSigned))
+	certificate := &unstructured.Unstructured{}
+	certificate.SetGroupVersionKind(certificateGVK)
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: localCAName, Namespace: "cert-manager"}, certificate))
+	isCA, _, _ := unstructured.NestedBool(certificate.Object, "spec", "isCA")
+	assert.True(t, isCA)
+	issuer := &unstructured.Unstructured{}
+	issuer.SetGroupVersionKind(clusterIssuerGVK)
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: localCAName}, issuer))
+	secretName, _, _ := unstructured.NestedString(issuer.Object, "spec", "ca", "secretName")
+	assert.Equal(t, localCAName, secretName)
+
+	// Once issued the CA certificate is published
+	require.NoError(t, c.Create(ctx, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: localCAName, Namespace: "cert-manager"},
+		Type:       corev1.SecretTypeTLS,
+		Data:       map[string][]byte{corev1.TLSCertKey: []byte("CA PEM"), corev1.TLSPrivateKeyKey: []byte("KEY")},
+	}))
+	published, err = l.ensure(ctx, cfg, "catalyst-system")
+	require.NoError(t, err)
+	assert.True(t, published)
+	configMap := &corev1.ConfigMap{}
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: localCAName, Namespace: "catalyst-system"}, configMap))
+	assert.Equal(t, map[string]string{"ca.crt": "CA PEM"}, configMap.Data)
+	assert.NotContains(t, configMap.Data, corev1.TLSPrivateKeyKey)
+}
+
+func TestLocalCAEnsure_ExistingCA(t *testing.T) {
+	mkcert := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "mkcert-ca", Namespace: "cert-manager"},
+		Data:       map[string][]byte{corev1.TLSCertKey: []byte("MKCERT PEM")},
+	}
+	c := newFakeClientBuilder().WithObjects(mkcert).Build()
+	ctx := context.Background()
+
+	published, err := (&LocalCA{Client: c}).ensure(ctx, &localTLSConfig{CASecret: "mkcert-ca", CertManagerNamespace: "cert-manager"}, "catalyst-system")
+	require.NoError(t, err)
+	assert.True(t, published)
+
+	certificate := &unstructured.Unstructured{}
+	certificate.SetGroupVersionKind(certificateGVK)
+	err = c.Get(ctx, client.ObjectKey{Name: localCAName, Namespace: "cert-manager"}, certificate)
+	assert.True(t, client.IgnoreNotFound(err) == nil && err != nil, "an existing CA is not self-signed")
+	issuer := &unstructured.Unstructured{}
+	issuer.SetGroupVersionKind(clusterIssuerGVK)
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: localCAName}, issuer))
+	secretName, _, _ := unstructured.NestedString(issuer.Object, "spec", "ca", "secretName")
+	assert.Equal(t, "mkcert-ca", secretName)
+	configMap := &corev1.ConfigMap{}
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: localCAName, Namespace: "catalyst-system"}, configMap))
+	assert.Equal(t, "MKCERT PEM", configMap.Data["ca.crt"])
+}
@@ -0,0 +1,198 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// Operator metrics, served by the manager's metrics endpoint (--metrics-bind-address).
+var (
+	environmentsByPhase = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "catalyst_environments",
+		Help: "Number of Environments by status phase",
+	}, []string{"phase"})
+
+	reconcileDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "catalyst_environment_reconcile_duration_seconds",
+		Help:    "Duration of Environment deployment mode reconciles",
+		Buckets: prometheus.ExponentialBuckets(0.05, 2, 12),
+	}, []string{"mode", "result"})
+
+	buildJobDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "catalyst_build_job_duration_seconds",
+		Help:    "Duration of finished build Jobs (start to completion)",
+		Buckets: prometheus.ExponentialBuckets(15, 2, 8),
+	}, []string{"result"})
+
+	buildJobsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "catalyst_build_jobs_total",
+		Help: "Finished build Jobs by result (success ratio: succeeded / all)",
+	}, []string{"result"})
+
+	helmOperationDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "catalyst_helm_operation_duration_seconds",
+		Help:    "Latency of Helm install and upgrade operations",
+		Buckets: prometheus.ExponentialBuckets(0.5, 2, 10),
+	}, []string{"operation", "result"})
+
+	gitCloneDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "catalyst_git_clone_duration_seconds",
+		Help:    "Duration of git clones performed by the operator (helm and docker-compose sources)",
+		Buckets: prometheus.ExponentialBuckets(0.25, 2, 10),
+	}, []string{"result"})
+
+	tempDirCleanupsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "catalyst_tempdir_cleanups_total",
+		Help: "Temporary directory removals by kind (source: after a reconcile, stale: periodic sweep) and result",
+	}, []string{"kind", "result"})
+
+	environmentsExpiredTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "catalyst_environments_expired_total",
+		Help: "Environments deleted by the janitor for exceeding the maximum lifetime",
+	})
+
+	orphanedResources = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "catalyst_orphaned_resources",
+		Help: "Environment namespaces without an Environment and service claims without a StatefulSet, as of the last sweep",
+	}, []string{"kind"})
+
+	orphansDeletedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "catalyst_orphans_deleted_total",
+		Help: "Orphaned namespaces and claims, and superseded build Jobs, deleted by the sweeper",
+	}, []string{"kind"})
+
+	scheduledEnvironmentsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "catalyst_scheduled_environments_total",
+		Help: "Environments created (up) and deleted (down) by EnvironmentSchedules",
+	}, []string{"action"})
+
+	driftDetectedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "catalyst_drift_detected_total",
+		Help: "Resources found changed or deleted outside of the operator, by kind",
+	}, []string{"kind"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		environmentsByPhase,
+		reconcileDuration,
+		buildJobDuration,
+		buildJobsTotal,
+		helmOperationDuration,
+		gitCloneDuration,
+		tempDirCleanupsTotal,
+		environmentsExpiredTotal,
+		orphanedResources,
+		orphansDeletedTotal,
+		scheduledEnvironmentsTotal,
+		driftDetectedTotal,
+	)
+}
+
+// metricResult maps an error to the "result" label value
+func metricResult(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "success"
+}
+
+// phaseTracker keeps the last observed phase per Environment to derive the phase gauge
+type phaseTracker struct {
+	mu     sync.Mutex
+	phases map[types.NamespacedName]string
+}
+
+var environmentPhases = &phaseTracker{phases: map[types.NamespacedName]string{}}
+
+// observe records the phase of an Environment; an empty phase counts as Pending.
+func (t *phaseTracker) observe(key types.NamespacedName, phase string) {
+	if phase == "" {
+		phase = "Pending"
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	previous, ok := t.phases[key]
+	if ok && previous == phase {
+		return
+	}
+	if ok {
+		environmentsByPhase.WithLabelValues(previous).Dec()
+	}
+	t.phases[key] = phase
+	environmentsByPhase.WithLabelValues(phase).Inc()
+}
+
+// forget removes a deleted Environment from the gauge
+func (t *phaseTracker) forget(key types.NamespacedName) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if previous, ok := t.phases[key]; ok {
+		environmentsByPhase.WithLabelValues(previous).Dec()
+		delete(t.phases, key)
+	}
+}
+
+// buildObservations remembers finished build Jobs already counted, so repeated
+// reconciles don't count a Job twice. Jobs finished before an operator restart
+// may be counted once more after it.
+var buildObservations sync.Map
+
+// observeBuildJob records duration and result of a finished build Job, once per Job.
+func observeBuildJob(job *batchv1.Job) {
+	if job == nil || (job.Status.Succeeded == 0 && job.Status.Failed == 0) {
+		return
+	}
+	if _, seen := buildObservations.LoadOrStore(job.UID, struct{}{}); seen {
+		return
+	}
+
+	result := "succeeded"
+	if job.Status.Succeeded == 0 {
+		result = "failed"
+	}
+	buildJobsTotal.WithLabelValues(result).Inc()
+
+	if job.Status.StartTime == nil {
+		return
+	}
+	// Failed Jobs have no completion time; use the Failed condition transition instead
+	end := time.Now()
+	if job.Status.CompletionTime != nil {
+		end = job.Status.CompletionTime.Time
+	} else {
+		for _, cond := range job.Status.Conditions {
+			if cond.Type == batchv1.JobFailed && cond.Status == corev1.ConditionTrue {
+				end = cond.LastTransitionTime.Time
+			}
+		}
+	}
+	buildJobDuration.WithLabelValues(result).Observe(end.Sub(job.Status.StartTime.Time).Seconds())
+}
+
+// observeSince records the elapsed time since start on a histogram
+func observeSince(h prometheus.Observer, start time.Time) {
+	h.Observe(time.Since(start).Seconds())
+}
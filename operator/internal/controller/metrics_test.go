@@ -0,0 +1,51 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestPhaseTracker(t *testing.T) {
+	tracker := &phaseTracker{phases: map[types.NamespacedName]string{}}
+	key := types.NamespacedName{Namespace: "metrics-test", Name: "pr-1"}
+	ready := testutil.ToFloat64(environmentsByPhase.WithLabelValues("Ready"))
+	pending := testutil.ToFloat64(environmentsByPhase.WithLabelValues("Pending"))
+
+	tracker.observe(key, "")
+	assert.Equal(t, pending+1, testutil.ToFloat64(environmentsByPhase.WithLabelValues("Pending")))
+
+	tracker.observe(key, "Ready")
+	tracker.observe(key, "Ready")
+	assert.Equal(t, pending, testutil.ToFloat64(environmentsByPhase.WithLabelValues("Pending")))
+	assert.Equal(t, ready+1, testutil.ToFloat64(environmentsByPhase.WithLabelValues("Ready")))
+
+	tracker.forget(key)
+	assert.Equal(t, ready, testutil.ToFloat64(environmentsByPhase.WithLabelValues("Ready")))
+}
+
+func TestObserveBuildJob(t *testing.T) {
+	succeeded := testutil.ToFloat64(buildJobsTotal.WithLabelValues("succeeded"))
+
+	start := time.Now().Add(-2 * time.Minute)
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{UID: "metrics-test-job"},
+		Status: batchv1.JobStatus{
+			Succeeded:      1,
+			StartTime:      &metav1.Time{Time: start},
+			CompletionTime: &metav1.Time{Time: start.Add(time.Minute)},
+		},
+	}
+	observeBuildJob(job)
+	observeBuildJob(job) // counted once
+	assert.Equal(t, succeeded+1, testutil.ToFloat64(buildJobsTotal.WithLabelValues("succeeded")))
+
+	// Running jobs are not counted
+	observeBuildJob(&batchv1.Job{ObjectMeta: metav1.ObjectMeta{UID: "metrics-test-running"}})
+	assert.Equal(t, succeeded+1, testutil.ToFloat64(buildJobsTotal.WithLabelValues("succeeded")))
+}
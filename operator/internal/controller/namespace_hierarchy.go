@@ -0,0 +1,228 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+// Binding team members requires the operator to hold, or be allowed to bind, their ClusterRoles
+// +kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=rolebindings,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=clusterroles,verbs=bind,resourceNames=admin;edit;view
+
+// Values of the catalyst.dev/namespace-type label above the environment namespaces
+const (
+	namespaceTypeLabel   = "catalyst.dev/namespace-type"
+	namespaceTypeTeam    = "team"
+	namespaceTypeProject = "project"
+)
+
+// teamMemberBindingPrefix names the RoleBinding of each team role, e.g. catalyst-team-edit
+const teamMemberBindingPrefix = "catalyst-team-"
+
+var teamRoles = []string{catalystv1alpha1.TeamRoleAdmin, catalystv1alpha1.TeamRoleEdit, catalystv1alpha1.TeamRoleView}
+
+// createOrReplace creates obj, or replaces the existing object with it
+func createOrReplace(ctx context.Context, c client.Client, obj client.Object) error {
+	existing := obj.DeepCopyObject().(client.Object)
+
+	err := c.Get(ctx, client.ObjectKey{Name: obj.GetName(), Namespace: obj.GetNamespace()}, existing)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return c.Create(ctx, obj)
+		}
+		return err
+	}
+
+	// Update: preserve important metadata from the existing object
+	obj.SetResourceVersion(existing.GetResourceVersion())
+	obj.SetUID(existing.GetUID())
+	obj.SetGeneration(existing.GetGeneration())
+	obj.SetManagedFields(existing.GetManagedFields())
+	return c.Update(ctx, obj)
+}
+
+// provisionHierarchyNamespace creates a team or project namespace with its labels, quota,
+// NetworkPolicy and the RoleBindings of the team members. team may be nil when the
+// namespace belongs to no Team resource: the defaults apply and nobody is bound.
+func provisionHierarchyNamespace(ctx context.Context, c client.Client, name string, labels map[string]string, team *catalystv1alpha1.Team) error {
+	if err := ensureHierarchyNamespace(ctx, c, name, labels); err != nil {
+		return fmt.Errorf("failed to ensure namespace %s: %w", name, err)
+	}
+	if err := createOrReplace(ctx, c, hierarchyResourceQuota(name, team)); err != nil {
+		return fmt.Errorf("failed to apply quota of namespace %s: %w", name, err)
+	}
+	if err := createOrReplace(ctx, c, hierarchyNetworkPolicy(name, labels["catalyst.dev/team"])); err != nil {
+		return fmt.Errorf("failed to apply network policy of namespace %s: %w", name, err)
+	}
+	if err := reconcileTeamMemberBindings(ctx, c, name, team); err != nil {
+		return fmt.Errorf("failed to bind team members in namespace %s: %w", name, err)
+	}
+	return nil
+}
+
+// ensureHierarchyNamespace creates the namespace, or adds missing hierarchy labels to an
+// existing one. Other labels are left alone.
+func ensureHierarchyNamespace(ctx context.Context, c client.Client, name string, labels map[string]string) error {
+	ns := &corev1.Namespace{}
+	err := c.Get(ctx, client.ObjectKey{Name: name}, ns)
+	if apierrors.IsNotFound(err) {
+		return c.Create(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}})
+	}
+	if err != nil {
+		return err
+	}
+
+	changed := false
+	if ns.Labels == nil {
+		ns.Labels = map[string]string{}
+	}
+	for key, value := range labels {
+		if ns.Labels[key] != value {
+			ns.Labels[key] = value
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	return c.Update(ctx, ns)
+}
+
+// hierarchyResourceQuota is the default quota, with the hard limits of the Team when set
+func hierarchyResourceQuota(namespace string, team *catalystv1alpha1.Team) *corev1.ResourceQuota {
+	quota := desiredResourceQuota(namespace)
+	if team != nil && len(team.Spec.Quota) > 0 {
+		quota.Spec.Hard = team.Spec.Quota.DeepCopy()
+	}
+	return quota
+}
+
+// hierarchyNetworkPolicy admits ingress from the namespace itself and the other namespaces
+// of the team (labelled catalyst.dev/team). Egress is unrestricted: unlike workloads in
+// environment namespaces, shared team infrastructure talks to arbitrary services.
+func hierarchyNetworkPolicy(namespace, team string) *networkingv1.NetworkPolicy {
+	return &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "default-policy",
+			Namespace: namespace,
+		},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{},
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+			Ingress: []networkingv1.NetworkPolicyIngressRule{
+				{
+					From: []networkingv1.NetworkPolicyPeer{
+						{PodSelector: &metav1.LabelSelector{}},
+						{NamespaceSelector: &metav1.LabelSelector{
+							MatchLabels: map[string]string{"catalyst.dev/team": team},
+						}},
+					},
+				},
+			},
+		},
+	}
+}
+
+// teamMemberRoleBindings binds the members of the Team to their ClusterRole in namespace,
+// one RoleBinding per role that has members
+func teamMemberRoleBindings(namespace string, team *catalystv1alpha1.Team) []*rbacv1.RoleBinding {
+	if team == nil {
+		return nil
+	}
+	var bindings []*rbacv1.RoleBinding
+	for _, role := range teamRoles {
+		var subjects []rbacv1.Subject
+		for _, member := range team.Spec.Members {
+			memberRole := member.Role
+			if memberRole == "" {
+				memberRole = catalystv1alpha1.TeamRoleEdit
+			}
+			if memberRole != role {
+				continue
+			}
+			subjects = append(subjects, teamMemberSubject(member, GenerateTeamNamespace(team.Name)))
+		}
+		if len(subjects) == 0 {
+			continue
+		}
+		bindings = append(bindings, &rbacv1.RoleBinding{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      teamMemberBindingPrefix + role,
+				Namespace: namespace,
+				Labels:    map[string]string{"catalyst.dev/team": sanitizeLabelValue(GenerateTeamNamespace(team.Name))},
+			},
+			RoleRef: rbacv1.RoleRef{
+				APIGroup: rbacv1.GroupName,
+				Kind:     "ClusterRole",
+				Name:     role,
+			},
+			Subjects: subjects,
+		})
+	}
+	return bindings
+}
+
+// teamMemberSubject converts a member to an RBAC subject. ServiceAccounts without a
+// namespace are looked up in the team namespace.
+func teamMemberSubject(member catalystv1alpha1.TeamMember, teamNamespace string) rbacv1.Subject {
+	switch member.Kind {
+	case rbacv1.ServiceAccountKind:
+		namespace := member.Namespace
+		if namespace == "" {
+			namespace = teamNamespace
+		}
+		return rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Name: member.Name, Namespace: namespace}
+	case rbacv1.GroupKind:
+		return rbacv1.Subject{Kind: rbacv1.GroupKind, APIGroup: rbacv1.GroupName, Name: member.Name}
+	default:
+		return rbacv1.Subject{Kind: rbacv1.UserKind, APIGroup: rbacv1.GroupName, Name: member.Name}
+	}
+}
+
+// reconcileTeamMemberBindings applies the member RoleBindings of namespace and deletes
+// those of roles no member holds anymore
+func reconcileTeamMemberBindings(ctx context.Context, c client.Client, namespace string, team *catalystv1alpha1.Team) error {
+	keep := map[string]bool{}
+	for _, binding := range teamMemberRoleBindings(namespace, team) {
+		keep[binding.Name] = true
+		if err := createOrReplace(ctx, c, binding); err != nil {
+			return err
+		}
+	}
+	for _, role := range teamRoles {
+		name := teamMemberBindingPrefix + role
+		if keep[name] {
+			continue
+		}
+		stale := &rbacv1.RoleBinding{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}}
+		if err := c.Delete(ctx, stale); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+func TestNamespaceHierarchy(t *testing.T) {
+	team := &catalystv1alpha1.Team{
+		ObjectMeta: metav1.ObjectMeta{Name: "acme"},
+		Spec: catalystv1alpha1.TeamSpec{
+			Members: []catalystv1alpha1.TeamMember{
+				{Kind: "User", Name: "alice@example.com", Role: catalystv1alpha1.TeamRoleAdmin},
+				{Kind: "Group", Name: "acme-developers"},
+				{Kind: "ServiceAccount", Name: "ci"},
+			},
+			Quota: corev1.ResourceList{corev1.ResourcePods: resource.MustParse("40")},
+		},
+	}
+	project := &catalystv1alpha1.Project{ObjectMeta: metav1.ObjectMeta{Name: "shop", Namespace: "acme"}}
+	c := newFakeClientBuilder().
+		WithStatusSubresource(team, project).
+		WithObjects(team, project).
+		Build()
+	ctx := context.Background()
+
+	teams := &TeamReconciler{Client: c, Scheme: testScheme}
+	_, err := teams.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: "acme"}})
+	require.NoError(t, err)
+	projects := &ProjectReconciler{Client: c, Scheme: testScheme}
+	_, err = projects.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: "shop", Namespace: "acme"}})
+	require.NoError(t, err)
+
+	got := &catalystv1alpha1.Team{}
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "acme"}, got))
+	assert.Equal(t, "acme", got.Status.Namespace)
+	gotProject := &catalystv1alpha1.Project{}
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(project), gotProject))
+	assert.Equal(t, "acme-shop", gotProject.Status.Namespace)
+
+	ns := &corev1.Namespace{}
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "acme"}, ns))
+	assert.Equal(t, "team", ns.Labels[namespaceTypeLabel])
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "acme-shop"}, ns))
+	assert.Equal(t, map[string]string{
+		"catalyst.dev/team":     "acme",
+		"catalyst.dev/project":  "shop",
+		namespaceTypeLabel:      "project",
+		podSecurityEnforceLabel: "restricted",
+		podSecurityAuditLabel:   "restricted",
+		podSecurityWarnLabel:    "restricted",
+	}, ns.Labels)
+
+	for _, namespace := range []string{"acme", "acme-shop"} {
+		quota := &corev1.ResourceQuota{}
+		require.NoError(t, c.Get(ctx, client.ObjectKey{Name: defaultQuotaName, Namespace: namespace}, quota))
+		assert.Equal(t, corev1.ResourceList{corev1.ResourcePods: resource.MustParse("40")}, quota.Spec.Hard, "the team quota replaces the defaults")
+
+		policy := &networkingv1.NetworkPolicy{}
+		require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "default-policy", Namespace: namespace}, policy))
+		assert.Equal(t, map[string]string{"catalyst.dev/team": "acme"}, policy.Spec.Ingress[0].From[1].NamespaceSelector.MatchLabels)
+
+		admins := &rbacv1.RoleBinding{}
+		require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "catalyst-team-admin", Namespace: namespace}, admins))
+		assert.Equal(t, rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: "admin"}, admins.RoleRef)
+		assert.Equal(t, "alice@example.com", admins.Subjects[0].Name)
+
+		editors := &rbacv1.RoleBinding{}
+		require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "catalyst-team-edit", Namespace: namespace}, editors))
+		require.Len(t, editors.Subjects, 2, "members default to the edit role")
+		assert.Equal(t, rbacv1.Subject{Kind: "ServiceAccount", Name: "ci", Namespace: "acme"}, editors.Subjects[1])
+	}
+
+	// Removing the admins deletes their RoleBindings
+	got.Spec.Members = got.Spec.Members[1:]
+	require.NoError(t, c.Update(ctx, got))
+	_, err = projects.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: "shop", Namespace: "acme"}})
+	require.NoError(t, err)
+	err = c.Get(ctx, client.ObjectKey{Name: "catalyst-team-admin", Namespace: "acme-shop"}, &rbacv1.RoleBinding{})
+	assert.True(t, apierrors.IsNotFound(err))
+
+	requests := projectsForTeam(c)(ctx, got)
+	assert.Equal(t, []ctrl.Request{{NamespacedName: client.ObjectKeyFromObject(project)}}, requests)
+}
+
+func TestProjectNamespace_WithoutTeam(t *testing.T) {
+	project := &catalystv1alpha1.Project{ObjectMeta: metav1.ObjectMeta{Name: "shop", Namespace: "default"}}
+	c := newFakeClientBuilder().WithStatusSubresource(project).WithObjects(project).Build()
+	ctx := context.Background()
+
+	r := &ProjectReconciler{Client: c, Scheme: testScheme}
+	_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(project)})
+	require.NoError(t, err)
+
+	quota := &corev1.ResourceQuota{}
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: defaultQuotaName, Namespace: "default-shop"}, quota))
+	assert.Equal(t, desiredResourceQuota("default-shop").Spec.Hard, quota.Spec.Hard)
+	bindings := &rbacv1.RoleBindingList{}
+	require.NoError(t, c.List(ctx, bindings, client.InNamespace("default-shop")))
+	assert.Empty(t, bindings.Items, "nobody is bound without a Team")
+}
@@ -0,0 +1,175 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+	"github.com/ncrmro/catalyst/operator/internal/operatorconfig"
+)
+
+// Nix flakes (buildStrategy nix, nix workspaces):
+// Pods run the nixos/nix image as the non-root user, so an init container copies the image's
+// store into an emptyDir mounted at /nix first. Builds of the "nix" strategy run these stages:
+//   - "nix-build" (init): builds the flake output into /workspace/image.tar, running it first
+//     when it is a streamLayeredImage script
+//   - "skopeo": pushes the archive, writing the digest to its termination message
+//
+// Workspaces of repositories with a flake.nix and no devcontainer.json run the nix image
+// and provision the flake's dev shell once, exported to the shells of the pod through
+// ENV and BASH_ENV.
+// Images are configurable with NIX_IMAGE and SKOPEO_IMAGE.
+
+const (
+	buildStrategyNix    = "nix"
+	defaultNixAttribute = "dockerImage"
+	defaultNixImage     = "nixos/nix:2.24.10"
+	defaultSkopeoImage  = "quay.io/skopeo/stable:v1.16.1"
+
+	nixStoreVolumeName = "nix-store"
+	nixFlakeFile       = "flake.nix"
+	// nixDevEnvFile holds the exported dev shell environment of nix workspaces
+	nixDevEnvFile = "/tmp/nix-dev-env.sh"
+	// nixImageArchive is the image archive nix builds hand to skopeo
+	nixImageArchive = "/workspace/image.tar"
+)
+
+// nixConfig enables flakes for the single-user store of the pod
+const nixConfig = `experimental-features = nix-command flakes
+build-users-group =
+sandbox = false`
+
+// nixBuildScript builds the flake output into the image archive
+const nixBuildScript = `set -eu
+cd "$BUILD_CONTEXT"
+nix build ".#$NIX_ATTRIBUTE" --out-link /workspace/result --print-build-logs
+if [ -f /workspace/result ] && [ -x /workspace/result ]; then
+  # dockerTools.streamLayeredImage builds a script streaming the archive
+  /workspace/result > ` + nixImageArchive + `
+else
+  cp -L /workspace/result ` + nixImageArchive + `
+fi
+`
+
+// skopeoPushScript pushes the image archive and reports the digest like kaniko does
+const skopeoPushScript = `set -eu
+skopeo copy $SKOPEO_FLAGS --digestfile /workspace/digest docker-archive:` + nixImageArchive + ` "docker://$DESTINATION"
+digest=$(cat /workspace/digest)
+printf '%s' "$digest" > ` + corev1.TerminationMessagePathDefault + `
+echo "${DESTINATION%:*}@$digest" > ` + scanImageRefFile + `
+`
+
+// nixDevShellScript exports the environment of the flake's default dev shell
+const nixDevShellScript = `nix print-dev-env > ` + nixDevEnvFile + `.tmp && mv ` + nixDevEnvFile + `.tmp ` + nixDevEnvFile
+
+// nixImage returns the image nix stages run
+func nixImage() string {
+	if image := operatorconfig.Current().Images.Nix; image != "" {
+		return image
+	}
+	return defaultNixImage
+}
+
+// nixEnv configures nix for the non-root user of the pod
+func nixEnv() []corev1.EnvVar {
+	return []corev1.EnvVar{
+		{Name: "NIX_CONFIG", Value: nixConfig},
+		{Name: "HOME", Value: "/tmp"},
+	}
+}
+
+// applyNixStore mounts a writable copy of the nix image's store at /nix in the given
+// containers, copied by an init container prepended to the pod
+func applyNixStore(spec *corev1.PodSpec, containers ...*corev1.Container) {
+	spec.Volumes = append(spec.Volumes, corev1.Volume{
+		Name:         nixStoreVolumeName,
+		VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+	})
+	for _, container := range containers {
+		container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{Name: nixStoreVolumeName, MountPath: "/nix"})
+	}
+	spec.InitContainers = append([]corev1.Container{{
+		Name:         nixStoreVolumeName,
+		Image:        nixImage(),
+		Command:      []string{"cp", "-R", "/nix/.", "/nix-store/"},
+		VolumeMounts: []corev1.VolumeMount{{Name: nixStoreVolumeName, MountPath: "/nix-store"}},
+	}}, spec.InitContainers...)
+}
+
+// applyNixBuild replaces the kaniko container of a build pod with the nix-build and skopeo
+// stages, keeping its volumes and resources
+func applyNixBuild(spec *corev1.PodSpec, build catalystv1alpha1.BuildSpec, context, destination string, registryCreds, insecure bool) {
+	kaniko := spec.Containers[0]
+	attribute := build.NixAttribute
+	if attribute == "" {
+		attribute = defaultNixAttribute
+	}
+	skopeoImage := operatorconfig.Current().Images.Skopeo
+	if skopeoImage == "" {
+		skopeoImage = defaultSkopeoImage
+	}
+	var skopeoFlags []string
+	if registryCreds {
+		skopeoFlags = append(skopeoFlags, "--dest-authfile", "/kaniko/.docker/config.json")
+	}
+	if insecure {
+		skopeoFlags = append(skopeoFlags, "--dest-tls-verify=false")
+	}
+
+	spec.InitContainers = append(spec.InitContainers, corev1.Container{
+		Name:    "nix-build",
+		Image:   nixImage(),
+		Command: []string{"sh", "-c", nixBuildScript},
+		Env: append(nixEnv(),
+			corev1.EnvVar{Name: "BUILD_CONTEXT", Value: context},
+			corev1.EnvVar{Name: "NIX_ATTRIBUTE", Value: attribute},
+		),
+		Resources:    kaniko.Resources,
+		VolumeMounts: []corev1.VolumeMount{kaniko.VolumeMounts[0]},
+	})
+	applyNixStore(spec, &spec.InitContainers[len(spec.InitContainers)-1])
+	spec.Containers = []corev1.Container{{
+		Name:    "skopeo",
+		Image:   skopeoImage,
+		Command: []string{"sh", "-c", skopeoPushScript},
+		Env: []corev1.EnvVar{
+			{Name: "DESTINATION", Value: destination},
+			{Name: "SKOPEO_FLAGS", Value: strings.Join(skopeoFlags, " ")},
+			{Name: "HOME", Value: "/tmp"},
+			{Name: "TMPDIR", Value: "/tmp"},
+		},
+		VolumeMounts: kaniko.VolumeMounts,
+	}}
+}
+
+// nixWorkspace is the dev container of workspaces provisioning a flake's dev shell
+func nixWorkspace() *devContainer {
+	return &devContainer{
+		Image: nixImage(),
+		ContainerEnv: map[string]string{
+			"ENV":        nixDevEnvFile,
+			"BASH_ENV":   nixDevEnvFile,
+			"NIX_CONFIG": nixConfig,
+			"HOME":       "/tmp",
+		},
+		postCreate: nixDevShellScript,
+		nix:        true,
+	}
+}
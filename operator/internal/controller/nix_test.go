@@ -0,0 +1,87 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-billy/v5/util"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+func TestDesiredBuildJob_Nix(t *testing.T) {
+	build := catalystv1alpha1.BuildSpec{Name: "web", Path: "/apps/web", BuildStrategy: buildStrategyNix}
+	job := desiredBuildJob("build-web", "ns", "registry/web:abc", "https://github.com/acme/app", "main", nil, build, "ghcr-push", true, nil, nil, nil)
+	spec := job.Spec.Template.Spec
+
+	names := func(containers []corev1.Container) []string {
+		var names []string
+		for _, c := range containers {
+			names = append(names, c.Name)
+		}
+		return names
+	}
+	assert.Equal(t, []string{nixStoreVolumeName, "git-clone", "nix-build"}, names(spec.InitContainers))
+	assert.Equal(t, []string{"skopeo"}, names(spec.Containers))
+
+	nixBuild := spec.InitContainers[2]
+	assert.Contains(t, nixBuild.Env, corev1.EnvVar{Name: "BUILD_CONTEXT", Value: "/workspace/source/apps/web"})
+	assert.Contains(t, nixBuild.Env, corev1.EnvVar{Name: "NIX_ATTRIBUTE", Value: defaultNixAttribute})
+	assert.True(t, hasMountPath(nixBuild.VolumeMounts, "/nix"))
+
+	skopeo := spec.Containers[0]
+	assert.Contains(t, skopeo.Env, corev1.EnvVar{Name: "DESTINATION", Value: "registry/web:abc"})
+	assert.Contains(t, skopeo.Env, corev1.EnvVar{Name: "SKOPEO_FLAGS", Value: "--dest-authfile /kaniko/.docker/config.json --dest-tls-verify=false"})
+	assert.True(t, hasMountPath(skopeo.VolumeMounts, "/kaniko/.docker"))
+	assertRestricted(t, spec)
+
+	// Scanned nix builds push before the scan stages
+	job = desiredBuildJob("build-web", "ns", "registry/web:abc", "https://github.com/acme/app", "main", nil, build, "", false, nil, &catalystv1alpha1.BuildScanSpec{}, nil)
+	spec = job.Spec.Template.Spec
+	assert.Equal(t, []string{nixStoreVolumeName, "git-clone", "nix-build", "skopeo", "scan"}, names(spec.InitContainers))
+	assert.Empty(t, spec.InitContainers[3].Args)
+}
+
+func TestReadDevContainer_Flake(t *testing.T) {
+	fs := memfs.New()
+	repo, err := git.Init(memory.NewStorage(), fs)
+	require.NoError(t, err)
+	worktree, err := repo.Worktree()
+	require.NoError(t, err)
+	require.NoError(t, util.WriteFile(fs, nixFlakeFile, []byte("{ outputs = _: {}; }"), 0644))
+	_, err = worktree.Add(nixFlakeFile)
+	require.NoError(t, err)
+	hash, err := worktree.Commit("flake", &git.CommitOptions{
+		Author: &object.Signature{Name: "dev", Email: "dev@example.com", When: time.Now()},
+	})
+	require.NoError(t, err)
+
+	dc, err := readDevContainer(repo, hash.String())
+	require.NoError(t, err)
+	require.NotNil(t, dc)
+	assert.True(t, dc.nix)
+	assert.Equal(t, defaultNixImage, dc.Image)
+
+	project := &catalystv1alpha1.Project{
+		Spec: catalystv1alpha1.ProjectSpec{
+			Sources: []catalystv1alpha1.SourceConfig{{Name: "primary", RepositoryURL: "https://github.com/acme/shop"}},
+		},
+	}
+	pod := desiredWorkspacePod(&catalystv1alpha1.Environment{}, "env-ns", &workspaceDevContainer{
+		Config: dc, Project: project, Source: &project.Spec.Sources[0], Commit: "main",
+	})
+	require.Len(t, pod.Spec.InitContainers, 2)
+	assert.Equal(t, nixStoreVolumeName, pod.Spec.InitContainers[0].Name)
+	container := pod.Spec.Containers[0]
+	assert.True(t, hasMountPath(container.VolumeMounts, "/nix"))
+	assert.Contains(t, container.Env, corev1.EnvVar{Name: "BASH_ENV", Value: nixDevEnvFile})
+	assert.Contains(t, container.Command[2], "nix print-dev-env")
+	assertRestricted(t, pod.Spec)
+}
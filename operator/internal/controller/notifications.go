@@ -0,0 +1,183 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+	"github.com/ncrmro/catalyst/operator/internal/notify"
+)
+
+// Notifications (Project spec.notifications):
+// Each phase transition of an environment is reported once to the commit in spec.sources[0]
+// as a commit status and to its pull request as a single comment edited in place.
+// status.notification records the last phase and commit reported, so transitions survive
+// operator restarts and are not re-posted. Delivery failures are logged and retried on the
+// next reconcile; they never block the deployment.
+
+// phaseTornDown is the reported phase of a deleted environment
+const phaseTornDown = "TornDown"
+
+// notifier returns the configured notifier, defaulting to GitHub through the web API
+func (r *EnvironmentReconciler) notifier() notify.Notifier {
+	if r.Notifier != nil {
+		return r.Notifier
+	}
+	return notify.NewGitHubNotifier(CatalystWebURL())
+}
+
+// phaseNotification maps a phase to a commit status. ok is false for phases not reported.
+func phaseNotification(phase string, reconcileErr error) (state, description string, ok bool) {
+	switch phase {
+	case "Ready":
+		return notify.StateSuccess, "Environment is ready", true
+	case "Failed":
+		if reconcileErr != nil {
+			return notify.StateFailure, "Deployment failed: " + reconcileErr.Error(), true
+		}
+		return notify.StateFailure, "Deployment failed", true
+	case "Building":
+		return notify.StatePending, "Building images", true
+	case "Pending", "Provisioning", "Deploying":
+		return notify.StatePending, "Deploying environment", true
+	case phaseTornDown:
+		return notify.StateSuccess, "Environment was torn down", true
+	}
+	return "", "", false
+}
+
+// notificationTarget resolves the repository, commit and pull request of the environment's
+// primary source. ok is false when the source is not a GitHub repository.
+func notificationTarget(env *catalystv1alpha1.Environment, project *catalystv1alpha1.Project) (notify.Notification, bool) {
+	if len(env.Spec.Sources) == 0 {
+		return notify.Notification{}, false
+	}
+	source := env.Spec.Sources[0]
+	for _, s := range project.Spec.Sources {
+		if s.Name != source.Name {
+			continue
+		}
+		owner, repo, ok := notify.ParseRepository(s.RepositoryURL)
+		if !ok {
+			return notify.Notification{}, false
+		}
+		return notify.Notification{
+			Owner:       owner,
+			Repo:        repo,
+			Commit:      source.CommitSha,
+			Context:     "catalyst/" + env.Name,
+			PullRequest: source.PrNumber,
+		}, true
+	}
+	return notify.Notification{}, false
+}
+
+// notificationComment renders the pull request comment for a phase
+func notificationComment(env *catalystv1alpha1.Environment, phase, description, commit string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "### Catalyst environment `%s`\n\n", env.Name)
+	fmt.Fprintf(&b, "| | |\n|---|---|\n| **Status** | %s |\n", phase)
+	if env.Status.URL != "" && phase != phaseTornDown {
+		fmt.Fprintf(&b, "| **Preview** | %s |\n", env.Status.URL)
+	}
+	if commit != "" {
+		fmt.Fprintf(&b, "| **Commit** | %s |\n", commit)
+	}
+	if phase == "Failed" {
+		fmt.Fprintf(&b, "\n```\n%s\n```\n", strings.ReplaceAll(description, "```", "'''"))
+	}
+	return b.String()
+}
+
+// sendNotification reports phase for the environment. Nil when there was nothing to send.
+func (r *EnvironmentReconciler) sendNotification(ctx context.Context, env *catalystv1alpha1.Environment, project *catalystv1alpha1.Project, phase string, reconcileErr error) (*catalystv1alpha1.NotificationStatus, error) {
+	spec := project.Spec.Notifications
+	state, description, ok := phaseNotification(phase, reconcileErr)
+	if !ok {
+		return nil, nil
+	}
+	n, ok := notificationTarget(env, project)
+	if !ok {
+		return nil, nil
+	}
+	reported := &catalystv1alpha1.NotificationStatus{Phase: phase, Commit: n.Commit}
+	if env.Status.Notification != nil {
+		reported.CommentID = env.Status.Notification.CommentID
+	}
+
+	n.State = state
+	n.Description = description
+	if phase == "Ready" {
+		n.TargetURL = env.Status.URL
+	}
+	if !spec.CommitStatus {
+		n.Commit = ""
+	}
+	if spec.PullRequestComment {
+		n.CommentID = reported.CommentID
+		n.Comment = notificationComment(env, phase, description, reported.Commit)
+	}
+	if n.Commit == "" && (n.PullRequest == 0 || n.Comment == "") {
+		return nil, nil
+	}
+
+	var err error
+	if reported.CommentID, err = r.notifier().Notify(ctx, project.Spec.GitHubInstallationId, n); err != nil {
+		return nil, err
+	}
+	return reported, nil
+}
+
+// reconcileNotifications reports the environment phase when it changed since the last report
+func (r *EnvironmentReconciler) reconcileNotifications(ctx context.Context, env *catalystv1alpha1.Environment, project *catalystv1alpha1.Project, reconcileErr error) error {
+	if project.Spec.Notifications == nil || project.Spec.GitHubInstallationId == "" {
+		return nil
+	}
+	commit := ""
+	if len(env.Spec.Sources) > 0 {
+		commit = env.Spec.Sources[0].CommitSha
+	}
+	if last := env.Status.Notification; last != nil && last.Phase == env.Status.Phase && last.Commit == commit {
+		return nil
+	}
+
+	reported, err := r.sendNotification(ctx, env, project, env.Status.Phase, reconcileErr)
+	if err != nil {
+		logf.FromContext(ctx).Error(err, "Failed to report environment phase to GitHub", "phase", env.Status.Phase)
+		return nil
+	}
+	if reported == nil {
+		return nil
+	}
+	env.Status.Notification = reported
+	return r.Status().Update(ctx, env)
+}
+
+// notifyTeardown reports the deletion of an environment, best effort
+func (r *EnvironmentReconciler) notifyTeardown(ctx context.Context, env *catalystv1alpha1.Environment, project *catalystv1alpha1.Project) {
+	if project.Spec.Notifications == nil || project.Spec.GitHubInstallationId == "" {
+		return
+	}
+	if _, err := r.sendNotification(ctx, env, project, phaseTornDown, nil); err != nil {
+		logf.FromContext(ctx).Error(err, "Failed to report environment teardown to GitHub")
+	}
+}
@@ -0,0 +1,116 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+	"github.com/ncrmro/catalyst/operator/internal/notify"
+)
+
+type recordingNotifier struct {
+	sent []notify.Notification
+	err  error
+}
+
+func (rn *recordingNotifier) Notify(_ context.Context, _ string, n notify.Notification) (int64, error) {
+	if rn.err != nil {
+		return n.CommentID, rn.err
+	}
+	rn.sent = append(rn.sent, n)
+	return 42, nil
+}
+
+func TestReconcileNotifications(t *testing.T) {
+	env := &catalystv1alpha1.Environment{
+		ObjectMeta: metav1.ObjectMeta{Name: "pr-7", Namespace: "acme"},
+		Spec: catalystv1alpha1.EnvironmentSpec{
+			Sources: []catalystv1alpha1.EnvironmentSource{{Name: "app", CommitSha: "abc123", Branch: "feature", PrNumber: 7}},
+		},
+		Status: catalystv1alpha1.EnvironmentStatus{Phase: "Building", URL: "https://pr-7.preview.example.com"},
+	}
+	project := &catalystv1alpha1.Project{Spec: catalystv1alpha1.ProjectSpec{
+		GitHubInstallationId: "12345",
+		Sources:              []catalystv1alpha1.SourceConfig{{Name: "app", RepositoryURL: "https://github.com/acme/shop.git"}},
+		Notifications:        &catalystv1alpha1.NotificationsSpec{CommitStatus: true, PullRequestComment: true},
+	}}
+	notifier := &recordingNotifier{}
+	c := newFakeClientBuilder().WithStatusSubresource(env).WithObjects(env).Build()
+	r := &EnvironmentReconciler{Client: c, Scheme: testScheme, Notifier: notifier}
+	ctx := context.Background()
+
+	require.NoError(t, r.reconcileNotifications(ctx, env, project, nil))
+	require.Len(t, notifier.sent, 1)
+	assert.Equal(t, notify.StatePending, notifier.sent[0].State)
+	assert.Equal(t, "acme", notifier.sent[0].Owner)
+	assert.Equal(t, "shop", notifier.sent[0].Repo)
+	assert.Equal(t, "catalyst/pr-7", notifier.sent[0].Context)
+	assert.Empty(t, notifier.sent[0].TargetURL)
+	assert.Equal(t, &catalystv1alpha1.NotificationStatus{Phase: "Building", Commit: "abc123", CommentID: 42}, env.Status.Notification)
+
+	// The same phase is reported once
+	require.NoError(t, r.reconcileNotifications(ctx, env, project, nil))
+	assert.Len(t, notifier.sent, 1)
+
+	// Build failures carry the error into the status and the comment
+	env.Status.Phase = "Failed"
+	require.NoError(t, r.reconcileNotifications(ctx, env, project, errors.New("build job failed: build-web-abc123")))
+	require.Len(t, notifier.sent, 2)
+	failed := notifier.sent[1]
+	assert.Equal(t, notify.StateFailure, failed.State)
+	assert.Equal(t, "Deployment failed: build job failed: build-web-abc123", failed.Description)
+	assert.Equal(t, int64(42), failed.CommentID, "the comment is edited in place")
+	assert.Contains(t, failed.Comment, "build job failed: build-web-abc123")
+
+	// A new commit is reported even in the same phase
+	env.Status.Phase = "Ready"
+	require.NoError(t, r.reconcileNotifications(ctx, env, project, nil))
+	env.Spec.Sources[0].CommitSha = "def456"
+	require.NoError(t, r.reconcileNotifications(ctx, env, project, nil))
+	require.Len(t, notifier.sent, 4)
+	ready := notifier.sent[3]
+	assert.Equal(t, "def456", ready.Commit)
+	assert.Equal(t, "https://pr-7.preview.example.com", ready.TargetURL)
+	assert.Contains(t, ready.Comment, "https://pr-7.preview.example.com")
+
+	// Delivery failures leave the transition to be reported again
+	notifier.err = errors.New("github unavailable")
+	env.Status.Phase = "Provisioning"
+	require.NoError(t, r.reconcileNotifications(ctx, env, project, nil))
+	assert.Equal(t, "Ready", env.Status.Notification.Phase)
+
+	// Teardown
+	notifier.err = nil
+	r.notifyTeardown(ctx, env, project)
+	require.Len(t, notifier.sent, 5)
+	assert.Equal(t, notify.StateSuccess, notifier.sent[4].State)
+	assert.Contains(t, notifier.sent[4].Comment, phaseTornDown)
+	assert.NotContains(t, notifier.sent[4].Comment, "https://pr-7.preview.example.com")
+}
+
+func TestReconcileNotifications_CommitStatusOnly(t *testing.T) {
+	env := &catalystv1alpha1.Environment{
+		ObjectMeta: metav1.ObjectMeta{Name: "staging"},
+		Spec: catalystv1alpha1.EnvironmentSpec{
+			Sources: []catalystv1alpha1.EnvironmentSource{{Name: "app", Branch: "main"}},
+		},
+		Status: catalystv1alpha1.EnvironmentStatus{Phase: "Ready"},
+	}
+	project := &catalystv1alpha1.Project{Spec: catalystv1alpha1.ProjectSpec{
+		GitHubInstallationId: "12345",
+		Sources:              []catalystv1alpha1.SourceConfig{{Name: "app", RepositoryURL: "https://github.com/acme/shop"}},
+		Notifications:        &catalystv1alpha1.NotificationsSpec{CommitStatus: true},
+	}}
+	notifier := &recordingNotifier{}
+	r := &EnvironmentReconciler{Notifier: notifier}
+
+	// Without a commit or a pull request there is nothing to report
+	require.NoError(t, r.reconcileNotifications(context.Background(), env, project, nil))
+	assert.Empty(t, notifier.sent)
+	assert.Nil(t, env.Status.Notification)
+}
@@ -0,0 +1,278 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+	"github.com/ncrmro/catalyst/operator/internal/operatorconfig"
+	"github.com/ncrmro/catalyst/operator/internal/sharding"
+)
+
+// Orphan sweeping:
+// Environment namespaces outlive their Environment when its finalizer is stripped, or when
+// etcd is restored from a backup taken before the Environment existed. The sweeper
+// periodically marks the environment namespaces no Environment generates with the
+// orphaned-since annotation, and unmarks them if their Environment shows up again. With
+// ORPHAN_SWEEP=delete it deletes them once they stayed orphaned for orphanGracePeriod.
+//
+// In the namespaces of live Environments it deletes finished build Jobs superseded by a newer
+// Job of the same build, and treats the data claims of managed services whose StatefulSet is
+// gone like orphaned namespaces.
+
+const (
+	// orphanedSinceAnnotation records when a resource was first found orphaned
+	orphanedSinceAnnotation = "catalyst.dev/orphaned-since"
+
+	// orphanSweepInterval is how often the sweeper runs
+	orphanSweepInterval = 10 * time.Minute
+	// orphanGracePeriod is how long a resource stays orphaned before ORPHAN_SWEEP=delete
+	// deletes it, so Environments restored shortly after their namespaces adopt them again
+	orphanGracePeriod = time.Hour
+)
+
+// OrphanSweeper removes what deleted Environments left behind
+type OrphanSweeper struct {
+	Client client.Client
+	// Shard limits the sweep to the namespaces of the Projects owned by this operator
+	// instance. Nil sweeps every namespace.
+	Shard *sharding.Shard
+	// Delete deletes orphaned namespaces and claims after orphanGracePeriod instead of
+	// only marking them, as does orphanSweep: delete in the operator configuration
+	Delete bool
+}
+
+// Start sweeps until ctx is done
+func (s *OrphanSweeper) Start(ctx context.Context) error {
+	log := logf.FromContext(ctx).WithName("orphan-sweeper")
+	ticker := time.NewTicker(orphanSweepInterval)
+	defer ticker.Stop()
+	for {
+		if err := s.sweep(ctx, time.Now()); err != nil {
+			log.Error(err, "Failed to sweep orphaned resources")
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// NeedLeaderElection sweeps from the leader only
+func (s *OrphanSweeper) NeedLeaderElection() bool {
+	return true
+}
+
+// sweep handles every environment namespace of the sweeper's shard
+func (s *OrphanSweeper) sweep(ctx context.Context, now time.Time) error {
+	envs := &catalystv1alpha1.EnvironmentList{}
+	if err := s.Client.List(ctx, envs); err != nil {
+		return err
+	}
+	live := map[string]bool{}
+	for _, env := range envs.Items {
+		if hierarchy := ExtractNamespaceHierarchy(env.Labels); hierarchy != nil {
+			live[GenerateEnvironmentNamespace(hierarchy.Team, hierarchy.Project, hierarchy.Environment)] = true
+		}
+	}
+
+	namespaces := &corev1.NamespaceList{}
+	if err := s.Client.List(ctx, namespaces, client.MatchingLabels{"catalyst.dev/namespace-type": "environment"}); err != nil {
+		return err
+	}
+	orphaned := map[string]int{}
+	for i := range namespaces.Items {
+		ns := &namespaces.Items[i]
+		if !ns.DeletionTimestamp.IsZero() {
+			continue
+		}
+		if owned, err := s.ownsNamespace(ctx, ns); err != nil {
+			return err
+		} else if !owned {
+			continue
+		}
+		var err error
+		if live[ns.Name] {
+			err = s.unmarkOrphan(ctx, ns)
+			if err == nil {
+				err = s.sweepNamespace(ctx, ns.Name, orphaned, now)
+			}
+		} else {
+			orphaned["namespace"]++
+			err = s.handleOrphan(ctx, ns, "namespace", now)
+		}
+		if err != nil {
+			return fmt.Errorf("namespace %s: %w", ns.Name, err)
+		}
+	}
+	for _, kind := range []string{"namespace", "claim"} {
+		orphanedResources.WithLabelValues(kind).Set(float64(orphaned[kind]))
+	}
+	return nil
+}
+
+// ownsNamespace reports whether the Project of an environment namespace belongs to the shard.
+// Namespaces of deleted Projects are assigned by the Project's name and the labels of its
+// team namespace.
+func (s *OrphanSweeper) ownsNamespace(ctx context.Context, ns *corev1.Namespace) (bool, error) {
+	if !s.Shard.Partial() {
+		return true, nil
+	}
+	team, projectName := ns.Labels["catalyst.dev/team"], ns.Labels["catalyst.dev/project"]
+	project := &catalystv1alpha1.Project{}
+	if err := s.Client.Get(ctx, client.ObjectKey{Name: projectName, Namespace: team}, project); apierrors.IsNotFound(err) {
+		project = &catalystv1alpha1.Project{ObjectMeta: metav1.ObjectMeta{Name: projectName, Namespace: team}}
+	} else if err != nil {
+		return false, err
+	}
+	return ownsProject(ctx, s.Client, s.Shard, project)
+}
+
+// handleOrphan marks an orphaned resource, and deletes it once it has been orphaned for
+// orphanGracePeriod when the sweeper deletes orphans
+func (s *OrphanSweeper) handleOrphan(ctx context.Context, obj client.Object, kind string, now time.Time) error {
+	log := logf.FromContext(ctx)
+	annotations := obj.GetAnnotations()
+	since, err := time.Parse(time.RFC3339, annotations[orphanedSinceAnnotation])
+	if err != nil {
+		log.Info("Found orphaned resource", "kind", kind, "name", obj.GetName(), "namespace", obj.GetNamespace())
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations[orphanedSinceAnnotation] = now.UTC().Format(time.RFC3339)
+		obj.SetAnnotations(annotations)
+		return s.Client.Update(ctx, obj)
+	}
+	deletes := s.Delete || operatorconfig.Current().OrphanSweep == "delete"
+	if !deletes || now.Sub(since) < orphanGracePeriod {
+		return nil
+	}
+	log.Info("Deleting orphaned resource", "kind", kind, "name", obj.GetName(), "namespace", obj.GetNamespace(), "orphanedSince", since)
+	if err := s.Client.Delete(ctx, obj); client.IgnoreNotFound(err) != nil {
+		return err
+	}
+	orphansDeletedTotal.WithLabelValues(kind).Inc()
+	return nil
+}
+
+// unmarkOrphan removes the orphaned-since annotation of a resource found in use again
+func (s *OrphanSweeper) unmarkOrphan(ctx context.Context, obj client.Object) error {
+	annotations := obj.GetAnnotations()
+	if _, ok := annotations[orphanedSinceAnnotation]; !ok {
+		return nil
+	}
+	delete(annotations, orphanedSinceAnnotation)
+	obj.SetAnnotations(annotations)
+	return s.Client.Update(ctx, obj)
+}
+
+// sweepNamespace removes the superseded build Jobs and handles the stranded service claims
+// of a live environment namespace, counting them in orphaned
+func (s *OrphanSweeper) sweepNamespace(ctx context.Context, namespace string, orphaned map[string]int, now time.Time) error {
+	jobs := &batchv1.JobList{}
+	if err := s.Client.List(ctx, jobs, client.InNamespace(namespace), client.MatchingLabels{"catalyst.dev/job-type": "build"}); err != nil {
+		return err
+	}
+	for _, job := range supersededBuildJobs(jobs.Items) {
+		logf.FromContext(ctx).Info("Deleting superseded build Job", "job", job.Name, "namespace", namespace)
+		if err := s.Client.Delete(ctx, job, client.PropagationPolicy("Background")); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+		orphansDeletedTotal.WithLabelValues("build-job").Inc()
+	}
+
+	claims := &corev1.PersistentVolumeClaimList{}
+	if err := s.Client.List(ctx, claims, client.InNamespace(namespace)); err != nil {
+		return err
+	}
+	statefulSets := &appsv1.StatefulSetList{}
+	if err := s.Client.List(ctx, statefulSets, client.InNamespace(namespace)); err != nil {
+		return err
+	}
+	services := map[string]bool{}
+	for _, sts := range statefulSets.Items {
+		services[sts.Name] = true
+	}
+	for i := range claims.Items {
+		claim := &claims.Items[i]
+		service, ok := managedServiceClaimService(claim)
+		if !ok || !claim.DeletionTimestamp.IsZero() {
+			continue
+		}
+		var err error
+		if services[service] {
+			err = s.unmarkOrphan(ctx, claim)
+		} else {
+			orphaned["claim"]++
+			err = s.handleOrphan(ctx, claim, "claim", now)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// supersededBuildJobs returns the finished build Jobs with a newer Job of the same build.
+// The newest Job of a build is kept: the environment reads its result.
+func supersededBuildJobs(jobs []batchv1.Job) []*batchv1.Job {
+	newest := map[string]*batchv1.Job{}
+	for i := range jobs {
+		job := &jobs[i]
+		build := job.Labels["catalyst.dev/build"]
+		if current, ok := newest[build]; !ok || current.CreationTimestamp.Before(&job.CreationTimestamp) {
+			newest[build] = job
+		}
+	}
+	var superseded []*batchv1.Job
+	for i := range jobs {
+		job := &jobs[i]
+		finished := job.Status.Succeeded > 0 || job.Status.Failed > 0
+		if finished && newest[job.Labels["catalyst.dev/build"]] != job {
+			superseded = append(superseded, job)
+		}
+	}
+	return superseded
+}
+
+// managedServiceClaimService returns the managed service a claim holds the data of. The
+// StatefulSet controller names the claims of desiredManagedServiceStatefulSet
+// "<service>-data-<service>-<ordinal>" and labels them with its selector.
+func managedServiceClaimService(claim *corev1.PersistentVolumeClaim) (string, bool) {
+	service := claim.Labels["app"]
+	if service == "" {
+		return "", false
+	}
+	ordinal, ok := strings.CutPrefix(claim.Name, fmt.Sprintf("%s-data-%s-", service, service))
+	if !ok || ordinal == "" || strings.Trim(ordinal, "0123456789") != "" {
+		return "", false
+	}
+	return service, true
+}
@@ -0,0 +1,165 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+	"github.com/ncrmro/catalyst/operator/internal/sharding"
+)
+
+func environmentNamespace(name string) *corev1.Namespace {
+	return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{
+		"catalyst.dev/namespace-type": "environment",
+		"catalyst.dev/team":           "acme",
+		"catalyst.dev/project":        "shop",
+	}}}
+}
+
+func buildJob(name, build string, created time.Time, succeeded int32) batchv1.Job {
+	return batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         "acme-shop-dev",
+			Labels:            map[string]string{"catalyst.dev/job-type": "build", "catalyst.dev/build": build},
+			CreationTimestamp: metav1.NewTime(created),
+		},
+		Status: batchv1.JobStatus{Succeeded: succeeded},
+	}
+}
+
+func TestSupersededBuildJobs(t *testing.T) {
+	now := time.Now()
+	jobs := []batchv1.Job{
+		buildJob("build-web-aaaaaaa", "web", now.Add(-2*time.Hour), 1),
+		buildJob("build-web-bbbbbbb", "web", now.Add(-time.Hour), 1),
+		buildJob("build-web-ccccccc", "web", now, 1),
+		buildJob("build-api-aaaaaaa", "api", now.Add(-2*time.Hour), 1),
+		buildJob("build-web-running", "web", now.Add(-3*time.Hour), 0),
+	}
+	var names []string
+	for _, job := range supersededBuildJobs(jobs) {
+		names = append(names, job.Name)
+	}
+	assert.Equal(t, []string{"build-web-aaaaaaa", "build-web-bbbbbbb"}, names, "the newest Job of each build and running Jobs are kept")
+}
+
+func TestManagedServiceClaimService(t *testing.T) {
+	claim := func(name, app string) *corev1.PersistentVolumeClaim {
+		return &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{"app": app}}}
+	}
+	service, ok := managedServiceClaimService(claim("postgres-data-postgres-0", "postgres"))
+	assert.True(t, ok)
+	assert.Equal(t, "postgres", service)
+
+	_, ok = managedServiceClaimService(claim("uploads", "web"))
+	assert.False(t, ok, "config volumes are not service claims")
+	_, ok = managedServiceClaimService(claim("postgres-data-postgres-x", "postgres"))
+	assert.False(t, ok)
+	_, ok = managedServiceClaimService(claim("postgres-data-postgres-0", ""))
+	assert.False(t, ok)
+}
+
+func TestOrphanSweeper(t *testing.T) {
+	liveNamespace := GenerateEnvironmentNamespace("acme", "shop", "dev")
+	orphanNamespace := GenerateEnvironmentNamespace("acme", "shop", "gone")
+	env := &catalystv1alpha1.Environment{ObjectMeta: metav1.ObjectMeta{
+		Name:      "dev",
+		Namespace: "acme-shop",
+		Labels:    map[string]string{"catalyst.dev/team": "acme", "catalyst.dev/project": "shop", "catalyst.dev/environment": "dev"},
+	}}
+	now := time.Now()
+	oldJob := buildJob("build-web-aaaaaaa", "web", now.Add(-time.Hour), 1)
+	newJob := buildJob("build-web-bbbbbbb", "web", now, 1)
+	oldJob.Namespace, newJob.Namespace = liveNamespace, liveNamespace
+	redis := &appsv1.StatefulSet{ObjectMeta: metav1.ObjectMeta{Name: "redis", Namespace: liveNamespace}}
+	claim := func(service string) *corev1.PersistentVolumeClaim {
+		return &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{
+			Name: service + "-data-" + service + "-0", Namespace: liveNamespace, Labels: map[string]string{"app": service},
+		}}
+	}
+	c := newFakeClientBuilder().WithObjects(
+		env, environmentNamespace(liveNamespace), environmentNamespace(orphanNamespace),
+		&oldJob, &newJob, redis, claim("redis"), claim("postgres"),
+	).Build()
+	s := &OrphanSweeper{Client: c}
+	ctx := context.Background()
+
+	require.NoError(t, s.sweep(ctx, now))
+	orphan := &corev1.Namespace{}
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: orphanNamespace}, orphan))
+	assert.Equal(t, now.UTC().Format(time.RFC3339), orphan.Annotations[orphanedSinceAnnotation])
+	live := &corev1.Namespace{}
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: liveNamespace}, live))
+	assert.NotContains(t, live.Annotations, orphanedSinceAnnotation)
+
+	assert.True(t, apierrors.IsNotFound(c.Get(ctx, client.ObjectKeyFromObject(&oldJob), &batchv1.Job{})), "superseded build Job deleted")
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(&newJob), &batchv1.Job{}))
+	stranded := &corev1.PersistentVolumeClaim{}
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(claim("postgres")), stranded))
+	assert.Contains(t, stranded.Annotations, orphanedSinceAnnotation)
+	used := &corev1.PersistentVolumeClaim{}
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(claim("redis")), used))
+	assert.NotContains(t, used.Annotations, orphanedSinceAnnotation)
+
+	// Marking only keeps orphans past the grace period
+	later := now.Add(orphanGracePeriod + time.Minute)
+	require.NoError(t, s.sweep(ctx, later))
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: orphanNamespace}, orphan))
+
+	// A namespace whose Environment shows up again is unmarked, and marked anew once it is gone
+	restored := env.DeepCopy()
+	restored.ResourceVersion = ""
+	restored.Name = "gone"
+	restored.Labels["catalyst.dev/environment"] = "gone"
+	require.NoError(t, c.Create(ctx, restored))
+	require.NoError(t, s.sweep(ctx, later))
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: orphanNamespace}, orphan))
+	assert.NotContains(t, orphan.Annotations, orphanedSinceAnnotation)
+	require.NoError(t, c.Delete(ctx, restored))
+
+	s.Delete = true
+	require.NoError(t, s.sweep(ctx, later))
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: orphanNamespace}, orphan), "newly found orphans wait for the grace period")
+	assert.True(t, apierrors.IsNotFound(c.Get(ctx, client.ObjectKeyFromObject(claim("postgres")), stranded)), "deleted past the grace period")
+
+	require.NoError(t, s.sweep(ctx, later.Add(orphanGracePeriod)))
+	assert.True(t, apierrors.IsNotFound(c.Get(ctx, client.ObjectKey{Name: orphanNamespace}, orphan)))
+}
+
+func TestOrphanSweeperOwnsNamespace(t *testing.T) {
+	// The Project of the orphaned namespace is gone; its team namespace still decides
+	teamNamespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "acme", Labels: map[string]string{"catalyst.dev/team": "acme"}}}
+	orphan := environmentNamespace(GenerateEnvironmentNamespace("acme", "shop", "gone"))
+	c := newFakeClientBuilder().WithObjects(teamNamespace, orphan).Build()
+	ctx := context.Background()
+
+	selected := &sharding.Shard{Index: 0, Count: 1}
+	require.NoError(t, selected.SetNamespaceSelector("catalyst.dev/team=acme"))
+	owned, err := (&OrphanSweeper{Client: c, Shard: selected}).ownsNamespace(ctx, orphan)
+	require.NoError(t, err)
+	assert.True(t, owned)
+
+	other := &sharding.Shard{Index: 0, Count: 1}
+	require.NoError(t, other.SetNamespaceSelector("catalyst.dev/team=globex"))
+	owned, err = (&OrphanSweeper{Client: c, Shard: other}).ownsNamespace(ctx, orphan)
+	require.NoError(t, err)
+	assert.False(t, owned, "another instance's team namespace is left alone")
+
+	require.NoError(t, other.SetNamespaceSelector(""))
+	other.Count = 2
+	other.Index = 1 - sharding.Of("acme", "shop", nil, 2)
+	owned, err = (&OrphanSweeper{Client: c, Shard: other}).ownsNamespace(ctx, orphan)
+	require.NoError(t, err)
+	assert.False(t, owned, "another shard's Project is left alone")
+}
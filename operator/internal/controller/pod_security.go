@@ -0,0 +1,139 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+// Pod Security Admission labels of the project and environment namespaces
+const (
+	podSecurityEnforceLabel = "pod-security.kubernetes.io/enforce"
+	podSecurityAuditLabel   = "pod-security.kubernetes.io/audit"
+	podSecurityWarnLabel    = "pod-security.kubernetes.io/warn"
+)
+
+// podSecurityRestricted is the Pod Security Standard of projects that set none
+const podSecurityRestricted = "restricted"
+
+// nonRootUID is the user generated pods run as, whatever user their image defaults to
+const nonRootUID int64 = 65532
+
+// podSecurityLabels are the Pod Security Admission labels enforcing the project's level
+func podSecurityLabels(project *catalystv1alpha1.Project) map[string]string {
+	level := podSecurityRestricted
+	if project != nil && project.Spec.PodSecurity != "" {
+		level = project.Spec.PodSecurity
+	}
+	return map[string]string{
+		podSecurityEnforceLabel: level,
+		podSecurityAuditLabel:   level,
+		podSecurityWarnLabel:    level,
+	}
+}
+
+// applyPodSecurity hardens a generated pod to the restricted Pod Security Standard: it runs
+// as a non-root user with the runtime default seccomp profile, and its containers drop all
+// capabilities and get a read-only root filesystem, with emptyDir volumes mounted at /tmp
+// and writablePaths. Fields the caller already set are kept.
+func applyPodSecurity(spec *corev1.PodSpec, writablePaths ...string) {
+	if spec.SecurityContext == nil {
+		spec.SecurityContext = &corev1.PodSecurityContext{}
+	}
+	sc := spec.SecurityContext
+	if sc.RunAsNonRoot == nil {
+		sc.RunAsNonRoot = ptr(true)
+	}
+	if sc.RunAsUser == nil {
+		sc.RunAsUser = ptr(nonRootUID)
+	}
+	if sc.RunAsGroup == nil {
+		sc.RunAsGroup = ptr(nonRootUID)
+	}
+	if sc.FSGroup == nil {
+		// Volumes are writable by the non-root user
+		sc.FSGroup = ptr(nonRootUID)
+	}
+	if sc.SeccompProfile == nil {
+		sc.SeccompProfile = &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault}
+	}
+
+	writablePaths = append([]string{"/tmp"}, writablePaths...)
+	for i := range spec.InitContainers {
+		hardenContainer(spec, &spec.InitContainers[i], writablePaths)
+	}
+	for i := range spec.Containers {
+		hardenContainer(spec, &spec.Containers[i], writablePaths)
+	}
+}
+
+// hardenContainer fills in the restricted security context of a container and mounts the
+// writable paths it does not mount already
+func hardenContainer(spec *corev1.PodSpec, container *corev1.Container, writablePaths []string) {
+	if container.SecurityContext == nil {
+		container.SecurityContext = &corev1.SecurityContext{}
+	}
+	sc := container.SecurityContext
+	if sc.AllowPrivilegeEscalation == nil {
+		sc.AllowPrivilegeEscalation = ptr(false)
+	}
+	if sc.Capabilities == nil {
+		sc.Capabilities = &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}}
+	}
+	if sc.ReadOnlyRootFilesystem == nil {
+		sc.ReadOnlyRootFilesystem = ptr(true)
+	}
+	if !*sc.ReadOnlyRootFilesystem {
+		return
+	}
+
+	for _, path := range writablePaths {
+		if hasMountPath(container.VolumeMounts, path) {
+			continue
+		}
+		name := "writable-" + strings.ReplaceAll(strings.Trim(path, "/"), "/", "-")
+		if !hasVolume(spec.Volumes, name) {
+			spec.Volumes = append(spec.Volumes, corev1.Volume{
+				Name:         name,
+				VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+			})
+		}
+		container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{Name: name, MountPath: path})
+	}
+}
+
+func hasMountPath(mounts []corev1.VolumeMount, path string) bool {
+	for _, m := range mounts {
+		if m.MountPath == path {
+			return true
+		}
+	}
+	return false
+}
+
+func hasVolume(volumes []corev1.Volume, name string) bool {
+	for _, v := range volumes {
+		if v.Name == name {
+			return true
+		}
+	}
+	return false
+}
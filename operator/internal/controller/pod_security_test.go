@@ -0,0 +1,92 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+// assertRestricted checks the fields the restricted Pod Security Standard requires
+func assertRestricted(t *testing.T, spec corev1.PodSpec) {
+	t.Helper()
+	require.NotNil(t, spec.SecurityContext)
+	assert.True(t, *spec.SecurityContext.RunAsNonRoot)
+	assert.NotZero(t, *spec.SecurityContext.RunAsUser)
+	assert.Equal(t, corev1.SeccompProfileTypeRuntimeDefault, spec.SecurityContext.SeccompProfile.Type)
+	for _, c := range append(append([]corev1.Container{}, spec.InitContainers...), spec.Containers...) {
+		require.NotNil(t, c.SecurityContext, c.Name)
+		assert.False(t, *c.SecurityContext.AllowPrivilegeEscalation, c.Name)
+		assert.Equal(t, []corev1.Capability{"ALL"}, c.SecurityContext.Capabilities.Drop, c.Name)
+		assert.Nil(t, c.SecurityContext.Privileged, c.Name)
+	}
+}
+
+func TestApplyPodSecurity(t *testing.T) {
+	spec := corev1.PodSpec{
+		InitContainers: []corev1.Container{{Name: "init"}},
+		Containers: []corev1.Container{
+			{Name: "app", VolumeMounts: []corev1.VolumeMount{{Name: "scratch", MountPath: "/tmp"}}},
+			{Name: "builder", SecurityContext: &corev1.SecurityContext{ReadOnlyRootFilesystem: ptr(false)}},
+		},
+		Volumes: []corev1.Volume{{Name: "scratch"}},
+	}
+	applyPodSecurity(&spec, "/var/run/app")
+	assertRestricted(t, spec)
+
+	init, app, builder := spec.InitContainers[0], spec.Containers[0], spec.Containers[1]
+	assert.True(t, *init.SecurityContext.ReadOnlyRootFilesystem)
+	assert.Equal(t, []corev1.VolumeMount{
+		{Name: "writable-tmp", MountPath: "/tmp"},
+		{Name: "writable-var-run-app", MountPath: "/var/run/app"},
+	}, init.VolumeMounts)
+	assert.Equal(t, []corev1.VolumeMount{
+		{Name: "scratch", MountPath: "/tmp"},
+		{Name: "writable-var-run-app", MountPath: "/var/run/app"},
+	}, app.VolumeMounts, "existing mounts are kept")
+	assert.False(t, *builder.SecurityContext.ReadOnlyRootFilesystem, "explicit settings are kept")
+	assert.Empty(t, builder.VolumeMounts)
+	assert.Len(t, spec.Volumes, 3, "writable volumes are shared by the containers")
+}
+
+func TestGeneratedWorkloads_MeetRestricted(t *testing.T) {
+	project := &catalystv1alpha1.Project{
+		Spec: catalystv1alpha1.ProjectSpec{
+			Sources: []catalystv1alpha1.SourceConfig{{Name: "app", RepositoryURL: "https://github.com/acme/app", Branch: "main"}},
+		},
+	}
+	env := &catalystv1alpha1.Environment{
+		ObjectMeta: metav1.ObjectMeta{Name: "pr-1"},
+		Spec:       catalystv1alpha1.EnvironmentSpec{ProjectRef: catalystv1alpha1.ProjectReference{Name: "app"}},
+	}
+	config := &catalystv1alpha1.EnvironmentConfig{Image: "app:v1"}
+	postgres := catalystv1alpha1.ManagedServiceSpec{
+		Name:      "postgres",
+		Container: catalystv1alpha1.ManagedServiceContainer{Image: "postgres:16"},
+	}
+
+	assertRestricted(t, desiredDeploymentFromConfig("ns", config).Spec.Template.Spec)
+	assertRestricted(t, desiredDevelopmentDeploymentFromConfig(env, project, "ns", config).Spec.Template.Spec)
+	assertRestricted(t, desiredWorkspacePod(env, "ns", nil).Spec)
+	job := desiredBuildJob("build-web", "ns", "registry/web:1", "https://github.com/acme/app", "main", nil, catalystv1alpha1.BuildSpec{Name: "web"}, "", false, nil, nil, nil)
+	assertRestricted(t, job.Spec.Template.Spec)
+
+	statefulSet := desiredManagedServiceStatefulSet("ns", postgres)
+	assertRestricted(t, statefulSet.Spec.Template.Spec)
+	assert.Contains(t, statefulSet.Spec.Template.Spec.Containers[0].VolumeMounts,
+		corev1.VolumeMount{Name: "writable-var-run-postgresql", MountPath: "/var/run/postgresql"})
+}
+
+func TestPodSecurityLabels(t *testing.T) {
+	assert.Equal(t, "restricted", podSecurityLabels(&catalystv1alpha1.Project{})[podSecurityEnforceLabel])
+	project := &catalystv1alpha1.Project{Spec: catalystv1alpha1.ProjectSpec{PodSecurity: "baseline"}}
+	assert.Equal(t, map[string]string{
+		podSecurityEnforceLabel: "baseline",
+		podSecurityAuditLabel:   "baseline",
+		podSecurityWarnLabel:    "baseline",
+	}, podSecurityLabels(project))
+}
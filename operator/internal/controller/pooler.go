@@ -0,0 +1,412 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net/url"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+const (
+	defaultPoolerImage    = "edoburu/pgbouncer:v1.23.1-p2"
+	defaultPoolMode       = "session"
+	defaultPoolSize       = 10
+	defaultMaxClientConns = 200
+	postgresPort          = 5432
+
+	// poolerConfigHashAnnotation records the hash of the pgbouncer config on the pooler pod
+	// template, so config changes roll the pod
+	poolerConfigHashAnnotation = "catalyst.dev/pooler-config-hash"
+)
+
+// isPostgresService reports whether a managed service is a Postgres instance
+func isPostgresService(svcSpec catalystv1alpha1.ManagedServiceSpec) bool {
+	return svcSpec.Name == "postgres" || svcSpec.Name == "postgresql"
+}
+
+// validatePoolers rejects connection poolers a deployment mode cannot run. Poolers front the
+// managed Postgres StatefulSet, which only development mode runs: production mode runs no
+// managed services but the provider-backed ones, and provider clusters bring their own pooling.
+func validatePoolers(config *catalystv1alpha1.EnvironmentConfig, mode string) error {
+	for _, svcSpec := range config.Services {
+		switch {
+		case svcSpec.Pooler == nil:
+		case mode != "development":
+			return fmt.Errorf("services %s: pooler is only supported in development mode", svcSpec.Name)
+		case svcSpec.Provider != "":
+			return fmt.Errorf("services %s: pooler does not apply to provider %s", svcSpec.Name, svcSpec.Provider)
+		case !isPostgresService(svcSpec):
+			return fmt.Errorf("services %s: pooler is only supported for postgres", svcSpec.Name)
+		}
+	}
+	return nil
+}
+
+// poolerName returns the name of the pooler Deployment/Service/Secret for a managed service
+func poolerName(svcSpec catalystv1alpha1.ManagedServiceSpec) string {
+	return svcSpec.Name + "-pooler"
+}
+
+// managedServicePort returns the first container port of a managed service (5432 for postgres by default)
+func managedServicePort(svcSpec catalystv1alpha1.ManagedServiceSpec) int32 {
+	if len(svcSpec.Container.Ports) > 0 {
+		return svcSpec.Container.Ports[0].ContainerPort
+	}
+	return postgresPort
+}
+
+// managedServiceEnv returns the literal value of an env var on the managed service container
+func managedServiceEnv(svcSpec catalystv1alpha1.ManagedServiceSpec, name, fallback string) string {
+	for _, e := range svcSpec.Container.Env {
+		if e.Name == name && e.Value != "" {
+			return e.Value
+		}
+	}
+	return fallback
+}
+
+// resolveManagedServiceEnv returns the value of an env var on the managed service container,
+// literal or from a Secret key in the namespace. Other valueFrom sources are refused.
+func (r *EnvironmentReconciler) resolveManagedServiceEnv(ctx context.Context, namespace string, svcSpec catalystv1alpha1.ManagedServiceSpec, name, fallback string) (string, error) {
+	for _, e := range svcSpec.Container.Env {
+		if e.Name != name {
+			continue
+		}
+		if e.ValueFrom == nil {
+			if e.Value != "" {
+				return e.Value, nil
+			}
+			continue
+		}
+		ref := e.ValueFrom.SecretKeyRef
+		if ref == nil {
+			return "", fmt.Errorf("%s of service %s: only value and valueFrom.secretKeyRef are supported", name, svcSpec.Name)
+		}
+		secret := &corev1.Secret{}
+		if err := r.Get(ctx, client.ObjectKey{Name: ref.Name, Namespace: namespace}, secret); err != nil {
+			if apierrors.IsNotFound(err) && ref.Optional != nil && *ref.Optional {
+				continue
+			}
+			return "", fmt.Errorf("failed to read %s of service %s from Secret %s: %w", name, svcSpec.Name, ref.Name, err)
+		}
+		value, ok := secret.Data[ref.Key]
+		if !ok {
+			if ref.Optional != nil && *ref.Optional {
+				continue
+			}
+			return "", fmt.Errorf("%s of service %s: Secret %s has no key %q", name, svcSpec.Name, ref.Name, ref.Key)
+		}
+		return string(value), nil
+	}
+	return fallback, nil
+}
+
+// desiredPgbouncerConfig generates pgbouncer.ini and userlist.txt for a managed Postgres,
+// authenticating as user with password (the postgres container's POSTGRES_USER/POSTGRES_PASSWORD).
+func desiredPgbouncerConfig(svcSpec catalystv1alpha1.ManagedServiceSpec, user, password string) map[string]string {
+	pooler := svcSpec.Pooler
+	poolMode := pooler.PoolMode
+	if poolMode == "" {
+		poolMode = defaultPoolMode
+	}
+	poolSize := pooler.DefaultPoolSize
+	if poolSize == 0 {
+		poolSize = defaultPoolSize
+	}
+	maxClientConns := pooler.MaxClientConnections
+	if maxClientConns == 0 {
+		maxClientConns = defaultMaxClientConns
+	}
+
+	ini := fmt.Sprintf(`[databases]
+* = host=%s port=%d
+
+[pgbouncer]
+listen_addr = 0.0.0.0
+listen_port = %d
+auth_type = plain
+auth_file = /etc/pgbouncer/userlist.txt
+pool_mode = %s
+default_pool_size = %d
+max_client_conn = %d
+ignore_startup_parameters = extra_float_digits,options
+`, svcSpec.Name, managedServicePort(svcSpec), postgresPort, poolMode, poolSize, maxClientConns)
+
+	userlist := pgbouncerQuote(user) + " " + pgbouncerQuote(password) + "\n"
+
+	return map[string]string{
+		"pgbouncer.ini": ini,
+		"userlist.txt":  userlist,
+	}
+}
+
+// pgbouncerQuote quotes a userlist.txt field: pgbouncer reads it verbatim between double
+// quotes, a doubled quote standing for one
+func pgbouncerQuote(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
+
+// poolerConfigHash returns a stable hash of the pgbouncer config
+func poolerConfigHash(config map[string]string) string {
+	data := make(map[string][]byte, len(config))
+	for key, value := range config {
+		data[key] = []byte(value)
+	}
+	return secretsHash(data)
+}
+
+// desiredPoolerSecret stores the generated pgbouncer config (contains credentials)
+func desiredPoolerSecret(namespace string, svcSpec catalystv1alpha1.ManagedServiceSpec, config map[string]string) *corev1.Secret {
+	data := make(map[string][]byte, len(config))
+	for key, value := range config {
+		data[key] = []byte(value)
+	}
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      poolerName(svcSpec),
+			Namespace: namespace,
+			Labels: map[string]string{
+				"app":                          poolerName(svcSpec),
+				"app.kubernetes.io/managed-by": "catalyst-operator",
+			},
+		},
+		Data: data,
+	}
+}
+
+// desiredPoolerDeployment creates the pgbouncer Deployment for a managed Postgres. configHash
+// (see poolerConfigHash) is recorded on the pod template.
+func desiredPoolerDeployment(namespace string, svcSpec catalystv1alpha1.ManagedServiceSpec, configHash string) *appsv1.Deployment {
+	name := poolerName(svcSpec)
+	image := svcSpec.Pooler.Image
+	if image == "" {
+		image = defaultPoolerImage
+	}
+
+	container := corev1.Container{
+		Name:    "pgbouncer",
+		Image:   image,
+		Command: []string{"pgbouncer", "/etc/pgbouncer/pgbouncer.ini"},
+		Ports: []corev1.ContainerPort{
+			{Name: "postgres", ContainerPort: postgresPort, Protocol: corev1.ProtocolTCP},
+		},
+		ReadinessProbe: &corev1.Probe{
+			ProbeHandler: corev1.ProbeHandler{
+				TCPSocket: &corev1.TCPSocketAction{Port: intstr.FromInt(postgresPort)},
+			},
+			PeriodSeconds: 5,
+		},
+		VolumeMounts: []corev1.VolumeMount{
+			{Name: "config", MountPath: "/etc/pgbouncer", ReadOnly: true},
+		},
+	}
+	if svcSpec.Pooler.Resources != nil {
+		container.Resources = *svcSpec.Pooler.Resources
+	}
+
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    map[string]string{"app": name},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: int32Ptr(1),
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"app": name},
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      map[string]string{"app": name},
+					Annotations: map[string]string{poolerConfigHashAnnotation: configHash},
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{container},
+					Volumes: []corev1.Volume{
+						{
+							Name: "config",
+							VolumeSource: corev1.VolumeSource{
+								Secret: &corev1.SecretVolumeSource{SecretName: name},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	applyPodSecurity(&deployment.Spec.Template.Spec)
+	return deployment
+}
+
+// desiredPoolerService exposes the pgbouncer Deployment on the Postgres port
+func desiredPoolerService(namespace string, svcSpec catalystv1alpha1.ManagedServiceSpec) *corev1.Service {
+	name := poolerName(svcSpec)
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: corev1.ServiceSpec{
+			Selector: map[string]string{"app": name},
+			Ports: []corev1.ServicePort{
+				{
+					Port:       postgresPort,
+					TargetPort: intstr.FromInt(postgresPort),
+					Protocol:   corev1.ProtocolTCP,
+				},
+			},
+		},
+	}
+}
+
+// reconcilePooler creates or updates the pgbouncer Secret, Deployment and Service of a managed
+// Postgres. Config changes roll the pooler pod through the config hash on its template.
+// Returns true once the pooler is ready to accept connections.
+func (r *EnvironmentReconciler) reconcilePooler(ctx context.Context, env *catalystv1alpha1.Environment, namespace string, config *catalystv1alpha1.EnvironmentConfig, svcSpec catalystv1alpha1.ManagedServiceSpec) (bool, error) {
+	log := logf.FromContext(ctx)
+
+	user, err := r.resolveManagedServiceEnv(ctx, namespace, svcSpec, "POSTGRES_USER", "postgres")
+	if err != nil {
+		return false, withFailureReason(catalystv1alpha1.FailureReasonConfigInvalid, err)
+	}
+	password, err := r.resolveManagedServiceEnv(ctx, namespace, svcSpec, "POSTGRES_PASSWORD", "")
+	if err != nil {
+		return false, withFailureReason(catalystv1alpha1.FailureReasonConfigInvalid, err)
+	}
+	pgbouncerConfig := desiredPgbouncerConfig(svcSpec, user, password)
+
+	secret := desiredPoolerSecret(namespace, svcSpec, pgbouncerConfig)
+	existing := &corev1.Secret{}
+	if err := r.Get(ctx, client.ObjectKeyFromObject(secret), existing); err != nil {
+		if client.IgnoreNotFound(err) != nil {
+			return false, err
+		}
+		if err := r.Create(ctx, secret); err != nil {
+			return false, fmt.Errorf("failed to create pooler config for %s: %w", svcSpec.Name, err)
+		}
+	} else if !maps.EqualFunc(existing.Data, secret.Data, func(a, b []byte) bool { return string(a) == string(b) }) {
+		existing.Data = secret.Data
+		if err := r.Update(ctx, existing); err != nil {
+			return false, fmt.Errorf("failed to update pooler config for %s: %w", svcSpec.Name, err)
+		}
+	}
+
+	deployment := desiredPoolerDeployment(namespace, svcSpec, poolerConfigHash(pgbouncerConfig))
+	labelEnvironmentWorkload(env, deployment)
+	prioritizeEnvironmentWorkload(env, deployment)
+	scheduleEnvironmentWorkload(config, deployment)
+	existingDeployment := &appsv1.Deployment{}
+	if err := r.Get(ctx, client.ObjectKeyFromObject(deployment), existingDeployment); apierrors.IsNotFound(err) {
+		if err := r.Create(ctx, deployment); err != nil && !isAlreadyExists(err) {
+			return false, fmt.Errorf("failed to create pooler Deployment for %s: %w", svcSpec.Name, err)
+		}
+	} else if err != nil {
+		return false, err
+	} else if poolerDeploymentChanged(existingDeployment, deployment) {
+		log.Info("Updating connection pooler", "service", svcSpec.Name, "namespace", namespace)
+		existingDeployment.Labels = deployment.Labels
+		existingDeployment.Spec = deployment.Spec
+		if err := r.Update(ctx, existingDeployment); err != nil {
+			return false, fmt.Errorf("failed to update pooler Deployment for %s: %w", svcSpec.Name, err)
+		}
+	}
+
+	service := desiredPoolerService(namespace, svcSpec)
+	existingService := &corev1.Service{}
+	if err := r.Get(ctx, client.ObjectKeyFromObject(service), existingService); apierrors.IsNotFound(err) {
+		if err := r.Create(ctx, service); err != nil && !isAlreadyExists(err) {
+			return false, fmt.Errorf("failed to create pooler Service for %s: %w", svcSpec.Name, err)
+		}
+	} else if err != nil {
+		return false, err
+	} else if driftSpecHash(service) != driftSpecHash(existingService) {
+		existingService.Spec.Ports = service.Spec.Ports
+		existingService.Spec.Selector = service.Spec.Selector
+		if err := r.Update(ctx, existingService); err != nil {
+			return false, fmt.Errorf("failed to update pooler Service for %s: %w", svcSpec.Name, err)
+		}
+	}
+
+	ready, err := r.isDeploymentReady(ctx, namespace, deployment.Name)
+	if err != nil {
+		return false, err
+	}
+	if !ready {
+		log.Info("Waiting for connection pooler to be ready", "service", svcSpec.Name, "namespace", namespace)
+	}
+	return ready, nil
+}
+
+// poolerDeploymentChanged reports whether the pooler Deployment differs from the desired one in
+// its config hash, image, resources or scheduling
+func poolerDeploymentChanged(existing, desired *appsv1.Deployment) bool {
+	existingPod, desiredPod := &existing.Spec.Template.Spec, &desired.Spec.Template.Spec
+	if existing.Spec.Template.Annotations[poolerConfigHashAnnotation] != desired.Spec.Template.Annotations[poolerConfigHashAnnotation] ||
+		driftSpecHash(existing) != driftSpecHash(desired) ||
+		len(existingPod.Containers) != len(desiredPod.Containers) {
+		return true
+	}
+	for i := range desiredPod.Containers {
+		if !equality.Semantic.DeepEqual(existingPod.Containers[i].Resources, desiredPod.Containers[i].Resources) {
+			return true
+		}
+	}
+	return !equality.Semantic.DeepEqual(existingPod.Affinity, desiredPod.Affinity) ||
+		!equality.Semantic.DeepEqual(existingPod.NodeSelector, desiredPod.NodeSelector) ||
+		!equality.Semantic.DeepEqual(existingPod.Tolerations, desiredPod.Tolerations) ||
+		existingPod.PriorityClassName != desiredPod.PriorityClassName
+}
+
+// rewriteDatabaseURLForPooler points DATABASE_URL at the pooler Service for any
+// managed Postgres with a pooler enabled. Returns a new slice; input is not modified.
+func rewriteDatabaseURLForPooler(envVars []corev1.EnvVar, services []catalystv1alpha1.ManagedServiceSpec) []corev1.EnvVar {
+	result := make([]corev1.EnvVar, len(envVars))
+	copy(result, envVars)
+
+	for _, svcSpec := range services {
+		if svcSpec.Pooler == nil || !isPostgresService(svcSpec) {
+			continue
+		}
+		for i, e := range result {
+			if e.Name != "DATABASE_URL" || e.Value == "" {
+				continue
+			}
+			u, err := url.Parse(e.Value)
+			if err != nil || (u.Hostname() != svcSpec.Name && !strings.HasPrefix(u.Hostname(), svcSpec.Name+".")) {
+				continue
+			}
+			u.Host = fmt.Sprintf("%s:%d", poolerName(svcSpec), postgresPort)
+			result[i].Value = u.String()
+		}
+	}
+
+	return result
+}
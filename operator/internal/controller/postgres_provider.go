@@ -0,0 +1,533 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+// Postgres operator integration (managed service provider):
+//   - cloudnative-pg: a postgresql.cnpg.io Cluster named after the service, bootstrapped with
+//     the service's database and owner. CloudNativePG generates the "<cluster>-app" Secret.
+//   - zalando: an acid.zalan.do postgresql named "catalyst-<service>" (the Zalando operator
+//     requires the team prefix) with the service's database and user. The Zalando operator
+//     generates the "<user>.<cluster>.credentials.postgresql.acid.zalan.do" Secret.
+//
+// With sharedCluster the environment gets a database and user named after its namespace on
+// an existing cluster instead: a Database and a managed role, whose password Secret the
+// operator generates, on cloudnative-pg; users and databases entries on zalando. The finalizer
+// releases them.
+//
+// Either way the generated credentials are copied, with host, port and database, into the
+// "<service>-connection" Secret of the environment namespace, which the web container reads.
+
+// +kubebuilder:rbac:groups=postgresql.cnpg.io,resources=clusters;databases,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=acid.zalan.do,resources=postgresqls,verbs=get;list;watch;create;update;patch;delete
+
+const (
+	postgresProviderCNPG    = "cloudnative-pg"
+	postgresProviderZalando = "zalando"
+	// zalandoTeamID prefixes the names of the zalando clusters the operator creates
+	zalandoTeamID = "catalyst"
+	// defaultProviderPostgresUser owns the database of a provider cluster
+	defaultProviderPostgresUser = "app"
+	// defaultProviderPostgresVersion is the zalando major version when the image names none
+	defaultProviderPostgresVersion = "16"
+	// defaultProviderStorageSize is the data volume size when the service sets no storage
+	defaultProviderStorageSize = "1Gi"
+)
+
+var (
+	cnpgClusterGVK       = schema.GroupVersionKind{Group: "postgresql.cnpg.io", Version: "v1", Kind: "Cluster"}
+	cnpgDatabaseGVK      = schema.GroupVersionKind{Group: "postgresql.cnpg.io", Version: "v1", Kind: "Database"}
+	zalandoPostgresqlGVK = schema.GroupVersionKind{Group: "acid.zalan.do", Version: "v1", Kind: "postgresql"}
+)
+
+// postgresTarget locates the database of a provider-backed service
+type postgresTarget struct {
+	// cluster and namespace name the provider cluster resource
+	cluster   string
+	namespace string
+	// host is the DNS name of the primary, namespace-qualified for shared clusters
+	host     string
+	database string
+	user     string
+	// credentialsSecret holds the username and password keys, in namespace
+	credentialsSecret string
+}
+
+// postgresConnectionSecretName names the Secret the web container reads the connection from
+func postgresConnectionSecretName(svcSpec catalystv1alpha1.ManagedServiceSpec) string {
+	return svcSpec.Name + "-connection"
+}
+
+// sharedPostgresIdentifier names the database and user of an environment on a shared cluster
+func sharedPostgresIdentifier(namespace string) string {
+	return strings.ReplaceAll(namespace, "-", "_")
+}
+
+// resolvePostgresTarget locates the database of a provider-backed service of the environment
+// namespace
+func resolvePostgresTarget(namespace string, svcSpec catalystv1alpha1.ManagedServiceSpec) postgresTarget {
+	t := postgresTarget{
+		cluster:   svcSpec.Name,
+		namespace: namespace,
+		user:      managedServiceEnv(svcSpec, "POSTGRES_USER", defaultProviderPostgresUser),
+		database:  svcSpec.Database,
+	}
+	if t.database == "" {
+		t.database = managedServiceEnv(svcSpec, "POSTGRES_DB", t.user)
+	}
+	if shared := svcSpec.SharedCluster; shared != nil {
+		t.cluster, t.namespace = shared.Name, shared.Namespace
+		t.database = sharedPostgresIdentifier(namespace)
+		t.user = t.database
+	} else if svcSpec.Provider == postgresProviderZalando {
+		t.cluster = zalandoTeamID + "-" + svcSpec.Name
+	}
+
+	switch svcSpec.Provider {
+	case postgresProviderZalando:
+		t.host = t.cluster
+		t.credentialsSecret = fmt.Sprintf("%s.%s.credentials.postgresql.acid.zalan.do", strings.ReplaceAll(t.user, "_", "-"), t.cluster)
+	default:
+		t.host = t.cluster + "-rw"
+		t.credentialsSecret = t.cluster + "-app"
+		if svcSpec.SharedCluster != nil {
+			t.credentialsSecret = t.cluster + "-" + namespace
+		}
+	}
+	if svcSpec.SharedCluster != nil {
+		t.host += "." + t.namespace + ".svc"
+	}
+	return t
+}
+
+// providerInstances returns the number of instances of a provider cluster
+func providerInstances(svcSpec catalystv1alpha1.ManagedServiceSpec) int64 {
+	if svcSpec.Instances != nil && *svcSpec.Instances > 0 {
+		return int64(*svcSpec.Instances)
+	}
+	return 1
+}
+
+// providerVolume returns the size and storage class of the data volume of a provider cluster
+func providerVolume(svcSpec catalystv1alpha1.ManagedServiceSpec) map[string]interface{} {
+	size := defaultProviderStorageSize
+	volume := map[string]interface{}{}
+	if svcSpec.Storage != nil {
+		if q, ok := svcSpec.Storage.Resources.Requests[corev1.ResourceStorage]; ok {
+			size = q.String()
+		}
+		if svcSpec.Storage.StorageClassName != nil {
+			volume["storageClass"] = *svcSpec.Storage.StorageClassName
+		}
+	}
+	volume["size"] = size
+	return volume
+}
+
+// postgresVersionPattern matches the major version in an image name, e.g. postgres:16 or spilo-16:3.2
+var postgresVersionPattern = regexp.MustCompile(`\d+`)
+
+// postgresMajorVersion returns the Postgres major version named by an image
+func postgresMajorVersion(image string) string {
+	if v := postgresVersionPattern.FindString(path.Base(image)); v != "" {
+		return v
+	}
+	return defaultProviderPostgresVersion
+}
+
+// desiredPostgresCluster builds the provider cluster of a dedicated (not shared) service
+func desiredPostgresCluster(namespace string, svcSpec catalystv1alpha1.ManagedServiceSpec) *unstructured.Unstructured {
+	t := resolvePostgresTarget(namespace, svcSpec)
+	cluster := &unstructured.Unstructured{}
+	cluster.SetName(t.cluster)
+	cluster.SetNamespace(namespace)
+	cluster.SetLabels(map[string]string{
+		"app":                          svcSpec.Name,
+		"app.kubernetes.io/managed-by": "catalyst-operator",
+	})
+
+	var resources map[string]interface{}
+	if svcSpec.Container.Resources != nil {
+		resources, _ = runtime.DefaultUnstructuredConverter.ToUnstructured(svcSpec.Container.Resources)
+	}
+
+	volume := providerVolume(svcSpec)
+	switch svcSpec.Provider {
+	case postgresProviderZalando:
+		cluster.SetGroupVersionKind(zalandoPostgresqlGVK)
+		spec := map[string]interface{}{
+			"teamId":            zalandoTeamID,
+			"numberOfInstances": providerInstances(svcSpec),
+			"postgresql":        map[string]interface{}{"version": postgresMajorVersion(svcSpec.Container.Image)},
+			"volume":            volume,
+			"users":             map[string]interface{}{t.user: []interface{}{}},
+			"databases":         map[string]interface{}{t.database: t.user},
+		}
+		if resources != nil {
+			spec["resources"] = resources
+		}
+		cluster.Object["spec"] = spec
+	default:
+		cluster.SetGroupVersionKind(cnpgClusterGVK)
+		spec := map[string]interface{}{
+			"instances": providerInstances(svcSpec),
+			"bootstrap": map[string]interface{}{
+				"initdb": map[string]interface{}{"database": t.database, "owner": t.user},
+			},
+			"storage": volume,
+		}
+		if svcSpec.Container.Image != "" {
+			spec["imageName"] = svcSpec.Container.Image
+		}
+		if providerInstances(svcSpec) > 1 {
+			spec["affinity"] = cnpgSpreadAffinity(t.cluster)
+		}
+		if resources != nil {
+			spec["resources"] = resources
+		}
+		cluster.Object["spec"] = spec
+	}
+	return cluster
+}
+
+// desiredCNPGDatabase builds the Database of an environment on a shared cloudnative-pg
+// cluster. The database is dropped when the resource is deleted.
+func desiredCNPGDatabase(env *catalystv1alpha1.Environment, t postgresTarget) *unstructured.Unstructured {
+	database := &unstructured.Unstructured{}
+	database.SetGroupVersionKind(cnpgDatabaseGVK)
+	database.SetName(t.credentialsSecret)
+	database.SetNamespace(t.namespace)
+	database.SetLabels(map[string]string{
+		"app.kubernetes.io/managed-by": "catalyst-operator",
+		environmentLabel:               sanitizeLabelValue(env.Name),
+	})
+	database.Object["spec"] = map[string]interface{}{
+		"name":                  t.database,
+		"owner":                 t.user,
+		"cluster":               map[string]interface{}{"name": t.cluster},
+		"ensure":                "present",
+		"databaseReclaimPolicy": "delete",
+	}
+	return database
+}
+
+// desiredPostgresConnectionSecret stores the connection of a provider-backed service
+func desiredPostgresConnectionSecret(namespace string, svcSpec catalystv1alpha1.ManagedServiceSpec, t postgresTarget, username, password string) *corev1.Secret {
+	port := strconv.Itoa(postgresPort)
+	uri := url.URL{
+		Scheme: "postgresql",
+		User:   url.UserPassword(username, password),
+		Host:   net.JoinHostPort(t.host, port),
+		Path:   "/" + t.database,
+	}
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      postgresConnectionSecretName(svcSpec),
+			Namespace: namespace,
+			Labels: map[string]string{
+				"app":                          svcSpec.Name,
+				"app.kubernetes.io/managed-by": "catalyst-operator",
+			},
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{
+			"host":     []byte(t.host),
+			"port":     []byte(port),
+			"dbname":   []byte(t.database),
+			"username": []byte(username),
+			"password": []byte(password),
+			"uri":      []byte(uri.String()),
+		},
+	}
+}
+
+// postgresConnectionEnv points DATABASE_URL and the libpq PG* variables at the connection
+// Secret of the first provider-backed service, replacing their literal values. Returns a new
+// slice; input is not modified.
+func postgresConnectionEnv(envVars []corev1.EnvVar, services []catalystv1alpha1.ManagedServiceSpec) []corev1.EnvVar {
+	result := make([]corev1.EnvVar, len(envVars))
+	copy(result, envVars)
+
+	for _, svcSpec := range services {
+		if svcSpec.Provider == "" {
+			continue
+		}
+		for _, v := range []struct{ name, key string }{
+			{"DATABASE_URL", "uri"},
+			{"PGHOST", "host"},
+			{"PGPORT", "port"},
+			{"PGDATABASE", "dbname"},
+			{"PGUSER", "username"},
+			{"PGPASSWORD", "password"},
+		} {
+			envVar := corev1.EnvVar{
+				Name: v.name,
+				ValueFrom: &corev1.EnvVarSource{
+					SecretKeyRef: &corev1.SecretKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{Name: postgresConnectionSecretName(svcSpec)},
+						Key:                  v.key,
+					},
+				},
+			}
+			replaced := false
+			for i := range result {
+				if result[i].Name == v.name {
+					result[i] = envVar
+					replaced = true
+				}
+			}
+			if !replaced {
+				result = append(result, envVar)
+			}
+		}
+		break
+	}
+	return result
+}
+
+// postgresProviderError reports a failure to manage the resources of the provider, failing
+// the environment's configuration when the provider operator is not installed
+func postgresProviderError(svcSpec catalystv1alpha1.ManagedServiceSpec, err error) error {
+	if meta.IsNoMatchError(err) {
+		return withFailureReason(catalystv1alpha1.FailureReasonConfigInvalid,
+			fmt.Errorf("service %s: the %s operator is not installed", svcSpec.Name, svcSpec.Provider))
+	}
+	return fmt.Errorf("failed to reconcile %s cluster for service %s: %w", svcSpec.Provider, svcSpec.Name, err)
+}
+
+// reconcilePostgresProvider ensures the provider cluster, or the environment's database on the
+// shared cluster, and the connection Secret of a provider-backed service.
+// Returns true once the database accepts the environment's user.
+func (r *EnvironmentReconciler) reconcilePostgresProvider(ctx context.Context, env *catalystv1alpha1.Environment, namespace string, svcSpec catalystv1alpha1.ManagedServiceSpec) (bool, error) {
+	log := logf.FromContext(ctx)
+	t := resolvePostgresTarget(namespace, svcSpec)
+
+	if svcSpec.SharedCluster != nil {
+		if err := r.attachSharedPostgres(ctx, env, namespace, svcSpec, t); err != nil {
+			return false, postgresProviderError(svcSpec, err)
+		}
+	} else {
+		cluster := desiredPostgresCluster(namespace, svcSpec)
+		labelEnvironmentWorkload(env, cluster)
+		if err := r.Create(ctx, cluster); err != nil && !isAlreadyExists(err) {
+			return false, postgresProviderError(svcSpec, err)
+		}
+	}
+
+	ready, err := r.isPostgresTargetReady(ctx, svcSpec, t)
+	if err != nil {
+		return false, postgresProviderError(svcSpec, err)
+	}
+	if !ready {
+		log.Info("Waiting for Postgres cluster to be ready", "service", svcSpec.Name, "provider", svcSpec.Provider, "cluster", t.namespace+"/"+t.cluster)
+		return false, nil
+	}
+
+	credentials := &corev1.Secret{}
+	if err := r.Get(ctx, client.ObjectKey{Name: t.credentialsSecret, Namespace: t.namespace}, credentials); err != nil {
+		if apierrors.IsNotFound(err) {
+			log.Info("Waiting for Postgres credentials", "service", svcSpec.Name, "secret", t.namespace+"/"+t.credentialsSecret)
+			return false, nil
+		}
+		return false, err
+	}
+	connection := desiredPostgresConnectionSecret(namespace, svcSpec, t, string(credentials.Data["username"]), string(credentials.Data["password"]))
+	if err := createOrReplace(ctx, r.Client, connection); err != nil {
+		return false, fmt.Errorf("failed to write connection Secret for service %s: %w", svcSpec.Name, err)
+	}
+	return true, nil
+}
+
+// isPostgresTargetReady reports whether the provider cluster, and on a shared cloudnative-pg
+// cluster the environment's Database, are ready
+func (r *EnvironmentReconciler) isPostgresTargetReady(ctx context.Context, svcSpec catalystv1alpha1.ManagedServiceSpec, t postgresTarget) (bool, error) {
+	cluster := &unstructured.Unstructured{}
+	cluster.SetGroupVersionKind(cnpgClusterGVK)
+	if svcSpec.Provider == postgresProviderZalando {
+		cluster.SetGroupVersionKind(zalandoPostgresqlGVK)
+	}
+	if err := r.Get(ctx, client.ObjectKey{Name: t.cluster, Namespace: t.namespace}, cluster); err != nil {
+		return false, client.IgnoreNotFound(err)
+	}
+
+	if svcSpec.Provider == postgresProviderZalando {
+		status, _, _ := unstructured.NestedString(cluster.Object, "status", "PostgresClusterStatus")
+		return status == "Running", nil
+	}
+	if readyInstances, _, _ := unstructured.NestedInt64(cluster.Object, "status", "readyInstances"); readyInstances == 0 {
+		return false, nil
+	}
+	if svcSpec.SharedCluster == nil {
+		return true, nil
+	}
+	database := &unstructured.Unstructured{}
+	database.SetGroupVersionKind(cnpgDatabaseGVK)
+	if err := r.Get(ctx, client.ObjectKey{Name: t.credentialsSecret, Namespace: t.namespace}, database); err != nil {
+		return false, client.IgnoreNotFound(err)
+	}
+	applied, _, _ := unstructured.NestedBool(database.Object, "status", "applied")
+	return applied, nil
+}
+
+// attachSharedPostgres adds the environment's user and database to the shared cluster
+func (r *EnvironmentReconciler) attachSharedPostgres(ctx context.Context, env *catalystv1alpha1.Environment, namespace string, svcSpec catalystv1alpha1.ManagedServiceSpec, t postgresTarget) error {
+	if svcSpec.Provider == postgresProviderZalando {
+		return r.updateSharedCluster(ctx, zalandoPostgresqlGVK, t, func(cluster *unstructured.Unstructured) {
+			_ = unstructured.SetNestedField(cluster.Object, []interface{}{}, "spec", "users", t.user)
+			_ = unstructured.SetNestedField(cluster.Object, t.user, "spec", "databases", t.database)
+		})
+	}
+
+	// The password of the managed role is generated once (random, hex-encoded)
+	secret := &corev1.Secret{}
+	err := r.Get(ctx, client.ObjectKey{Name: t.credentialsSecret, Namespace: t.namespace}, secret)
+	if apierrors.IsNotFound(err) {
+		password, genErr := generateGatewayToken()
+		if genErr != nil {
+			return genErr
+		}
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      t.credentialsSecret,
+				Namespace: t.namespace,
+				Labels: map[string]string{
+					"app.kubernetes.io/managed-by": "catalyst-operator",
+					environmentLabel:               sanitizeLabelValue(env.Name),
+					// Lets CloudNativePG reload the role when the password changes
+					"cnpg.io/reload": "true",
+				},
+			},
+			Type: corev1.SecretTypeBasicAuth,
+			Data: map[string][]byte{
+				corev1.BasicAuthUsernameKey: []byte(t.user),
+				corev1.BasicAuthPasswordKey: []byte(password),
+			},
+		}
+		if err := r.Create(ctx, secret); err != nil && !isAlreadyExists(err) {
+			return err
+		}
+	} else if err != nil {
+		return err
+	}
+
+	if err := r.updateSharedCluster(ctx, cnpgClusterGVK, t, func(cluster *unstructured.Unstructured) {
+		setCNPGManagedRole(cluster, map[string]interface{}{
+			"name":           t.user,
+			"ensure":         "present",
+			"login":          true,
+			"passwordSecret": map[string]interface{}{"name": t.credentialsSecret},
+		})
+	}); err != nil {
+		return err
+	}
+
+	if err := r.Create(ctx, desiredCNPGDatabase(env, t)); err != nil && !isAlreadyExists(err) {
+		return err
+	}
+	return nil
+}
+
+// releaseSharedPostgres removes the users and databases environments got on shared clusters.
+// The cloudnative-pg role is kept in the cluster spec with ensure absent, which drops it.
+func (r *EnvironmentReconciler) releaseSharedPostgres(ctx context.Context, env *catalystv1alpha1.Environment, template *catalystv1alpha1.EnvironmentTemplateSpec, namespace string) error {
+	var templateConfig *catalystv1alpha1.EnvironmentConfig
+	if template != nil {
+		templateConfig = template.Config
+	}
+	config := resolveConfig(&env.Spec.Config, templateConfig)
+
+	for _, svcSpec := range config.Services {
+		if svcSpec.Provider == "" || svcSpec.SharedCluster == nil {
+			continue
+		}
+		t := resolvePostgresTarget(namespace, svcSpec)
+		logf.FromContext(ctx).Info("Releasing database on shared Postgres cluster", "service", svcSpec.Name, "cluster", t.namespace+"/"+t.cluster, "database", t.database)
+
+		var err error
+		if svcSpec.Provider == postgresProviderZalando {
+			err = r.updateSharedCluster(ctx, zalandoPostgresqlGVK, t, func(cluster *unstructured.Unstructured) {
+				unstructured.RemoveNestedField(cluster.Object, "spec", "users", t.user)
+				unstructured.RemoveNestedField(cluster.Object, "spec", "databases", t.database)
+			})
+		} else {
+			err = r.Delete(ctx, desiredCNPGDatabase(env, t))
+			if err == nil || apierrors.IsNotFound(err) {
+				err = r.updateSharedCluster(ctx, cnpgClusterGVK, t, func(cluster *unstructured.Unstructured) {
+					setCNPGManagedRole(cluster, map[string]interface{}{"name": t.user, "ensure": "absent"})
+				})
+			}
+		}
+		if err != nil && !apierrors.IsNotFound(err) && !meta.IsNoMatchError(err) {
+			return fmt.Errorf("failed to release database of service %s: %w", svcSpec.Name, err)
+		}
+	}
+	return nil
+}
+
+// updateSharedCluster applies mutate to the shared cluster, updating it when changed
+func (r *EnvironmentReconciler) updateSharedCluster(ctx context.Context, gvk schema.GroupVersionKind, t postgresTarget, mutate func(*unstructured.Unstructured)) error {
+	cluster := &unstructured.Unstructured{}
+	cluster.SetGroupVersionKind(gvk)
+	if err := r.Get(ctx, client.ObjectKey{Name: t.cluster, Namespace: t.namespace}, cluster); err != nil {
+		return err
+	}
+	before := cluster.DeepCopy()
+	mutate(cluster)
+	if equality.Semantic.DeepEqual(before.Object, cluster.Object) {
+		return nil
+	}
+	return r.Update(ctx, cluster)
+}
+
+// setCNPGManagedRole replaces or appends the role of the same name in spec.managed.roles
+func setCNPGManagedRole(cluster *unstructured.Unstructured, role map[string]interface{}) {
+	roles, _, _ := unstructured.NestedSlice(cluster.Object, "spec", "managed", "roles")
+	replaced := false
+	for i, existing := range roles {
+		if m, ok := existing.(map[string]interface{}); ok && m["name"] == role["name"] {
+			roles[i] = role
+			replaced = true
+		}
+	}
+	if !replaced {
+		roles = append(roles, role)
+	}
+	_ = unstructured.SetNestedSlice(cluster.Object, roles, "spec", "managed", "roles")
+}
@@ -0,0 +1,383 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+// Workspace prebuilds (Project spec.prebuild):
+//  1. The ProjectReconciler runs a Job in the project namespace per prebuild revision (the
+//     commit or branch, with the template content): it clones the default branch into a
+//     fresh claim shaped like the template's code volume and runs the template steps on it.
+//  2. Once the Job succeeds its claim becomes status.prebuild.claimName, and the claim and
+//     Job of the previous prebuild are deleted.
+//  3. Development environments of the branch create their code volume claim as a clone of it
+//     (a cross-namespace dataSourceRef, allowed by a ReferenceGrant deleted once the claim is
+//     bound). git-clone finds the prebuild marker and checks out the environment's commit
+//     once, instead of keeping the working directory.
+
+// +kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=persistentvolumeclaims,verbs=get;list;watch;create;update;patch;delete
+
+const (
+	// prebuildTemplate is the template prebuilt when spec.prebuild.template is unset
+	prebuildTemplate = "development"
+	// prebuildMarker is left in the code volume by the prebuild Job for git-clone
+	prebuildMarker = ".catalyst-prebuild"
+	// prebuildGrantAnnotation on a code volume claim names the ReferenceGrant it was cloned with
+	prebuildGrantAnnotation = "catalyst.dev/prebuild-grant"
+)
+
+// prebuildTemplateKey returns the key of the template a prebuild prepares
+func prebuildTemplateKey(spec *catalystv1alpha1.PrebuildSpec) string {
+	if spec.Template != "" {
+		return spec.Template
+	}
+	return prebuildTemplate
+}
+
+// prebuildBranch returns the default branch prebuilds are made from
+func prebuildBranch(project *catalystv1alpha1.Project) string {
+	if project.Spec.Sources[0].Branch != "" {
+		return project.Spec.Sources[0].Branch
+	}
+	return "main"
+}
+
+// prebuildName names the Job and claim of a prebuild of revision with the template content
+func prebuildName(revision, templateHash string) string {
+	sum := sha256.Sum256([]byte(revision + "/" + templateHash))
+	return "prebuild-" + hex.EncodeToString(sum[:])[:10]
+}
+
+// codeVolumeClaim returns the claim of the config's code volume, nil if it is no claim
+func codeVolumeClaim(config *catalystv1alpha1.EnvironmentConfig) *corev1.PersistentVolumeClaimSpec {
+	name, _ := codeVolume(config)
+	for _, vol := range config.Volumes {
+		if vol.Name == name {
+			return vol.PersistentVolumeClaim
+		}
+	}
+	return nil
+}
+
+// prebuildSteps returns the template init containers a prebuild runs on the cloned code
+func prebuildSteps(spec *catalystv1alpha1.PrebuildSpec, config *catalystv1alpha1.EnvironmentConfig) []catalystv1alpha1.InitContainerSpec {
+	codeVolumeName, _ := codeVolume(config)
+	var steps []catalystv1alpha1.InitContainerSpec
+	for _, init := range config.InitContainers {
+		if len(spec.Steps) > 0 {
+			if slices.Contains(spec.Steps, init.Name) {
+				steps = append(steps, init)
+			}
+			continue
+		}
+		if !slices.ContainsFunc(init.VolumeMounts, func(m corev1.VolumeMount) bool { return m.Name != codeVolumeName }) {
+			steps = append(steps, init)
+		}
+	}
+	return steps
+}
+
+// desiredPrebuildJob clones revision into the claim named name and runs the steps on it,
+// leaving the prebuild marker behind
+func desiredPrebuildJob(project *catalystv1alpha1.Project, namespace, name, revision string, config *catalystv1alpha1.EnvironmentConfig) *batchv1.Job {
+	source := &project.Spec.Sources[0]
+	codeVolumeName, codeMountPath := codeVolume(config)
+
+	initContainers := []corev1.Container{gitCloneInitContainer(project, source, revision, codeVolumeName, codeMountPath)}
+	for _, step := range prebuildSteps(project.Spec.Prebuild, config) {
+		container := corev1.Container{
+			Name:         step.Name,
+			Image:        step.Image,
+			Command:      step.Command,
+			Args:         step.Args,
+			WorkingDir:   step.WorkingDir,
+			Env:          step.Env,
+			VolumeMounts: step.VolumeMounts,
+		}
+		if step.Resources != nil {
+			container.Resources = *step.Resources
+		}
+		initContainers = append(initContainers, container)
+	}
+	marker := corev1.Container{
+		Name:         "prebuild",
+		Image:        initContainers[0].Image,
+		Command:      []string{"touch", codeMountPath + "/" + prebuildMarker},
+		VolumeMounts: []corev1.VolumeMount{{Name: codeVolumeName, MountPath: codeMountPath}},
+	}
+
+	spec := corev1.PodSpec{
+		RestartPolicy:  corev1.RestartPolicyNever,
+		InitContainers: initContainers,
+		Containers:     []corev1.Container{marker},
+		Volumes: []corev1.Volume{
+			{Name: codeVolumeName, VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: name},
+			}},
+			gitScriptsVolume(),
+		},
+	}
+	applyPodSecurity(&spec)
+
+	labels := map[string]string{
+		"catalyst.dev/job-type":        "prebuild",
+		"catalyst.dev/project":         sanitizeLabelValue(project.Name),
+		"app.kubernetes.io/managed-by": "catalyst-operator",
+	}
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: labels},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: ptr(int32(0)),
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec:       spec,
+			},
+		},
+	}
+}
+
+// reconcilePrebuild runs the prebuild of the current revision in the project namespace and
+// records it in status.prebuild. Returns true while the prebuild is running.
+func (r *ProjectReconciler) reconcilePrebuild(ctx context.Context, project *catalystv1alpha1.Project, namespace string) (bool, error) {
+	spec := project.Spec.Prebuild
+	if spec == nil || len(project.Spec.Sources) == 0 {
+		return false, nil
+	}
+	log := logf.FromContext(ctx)
+
+	key := prebuildTemplateKey(spec)
+	template, ok := project.Spec.Templates[key]
+	if !ok || template.Config == nil {
+		return false, r.recordPrebuild(ctx, project, catalystv1alpha1.PrebuildStatus{Phase: buildPhaseFailed, Message: fmt.Sprintf("template %q has no config to prebuild", key)})
+	}
+	config := resolveConfig(&catalystv1alpha1.EnvironmentConfig{}, template.Config)
+	resolveStorage(&config, project.Spec.Storage)
+	claim := codeVolumeClaim(&config)
+	if claim == nil {
+		return false, r.recordPrebuild(ctx, project, catalystv1alpha1.PrebuildStatus{Phase: buildPhaseFailed, Message: fmt.Sprintf("template %q has no code volume claim to prebuild", key)})
+	}
+
+	revision := spec.CommitSha
+	if revision == "" {
+		revision = prebuildBranch(project)
+	}
+	name := prebuildName(revision, templateHash(&template))
+	previous := project.Status.Prebuild
+	if previous != nil && previous.JobName == name && previous.Phase != buildPhaseRunning {
+		return false, nil
+	}
+	status := catalystv1alpha1.PrebuildStatus{Revision: revision, JobName: name, Phase: buildPhaseRunning}
+	if previous != nil {
+		status.ClaimName = previous.ClaimName
+		status.CompletedAt = previous.CompletedAt
+	}
+
+	if err := ensureGitScripts(ctx, r.Client, namespace); err != nil {
+		return false, fmt.Errorf("failed to ensure git scripts ConfigMap: %w", err)
+	}
+	if _, err := copyGitCredentials(ctx, r.Client, project, namespace); err != nil {
+		return false, err
+	}
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: map[string]string{"catalyst.dev/project": sanitizeLabelValue(project.Name)}},
+		Spec:       *claim.DeepCopy(),
+	}
+	if err := r.Create(ctx, pvc); err != nil && !isAlreadyExists(err) {
+		return false, fmt.Errorf("failed to create prebuild claim: %w", err)
+	}
+	job := desiredPrebuildJob(project, namespace, name, revision, &config)
+	if err := r.Create(ctx, job); err != nil {
+		if !isAlreadyExists(err) {
+			return false, fmt.Errorf("failed to create prebuild Job: %w", err)
+		}
+		if err := r.Get(ctx, client.ObjectKeyFromObject(job), job); err != nil {
+			return false, err
+		}
+	} else {
+		log.Info("Prebuilding workspace", "revision", revision, "job", name)
+	}
+
+	switch {
+	case job.Status.Succeeded > 0:
+		status.Phase = buildPhaseSucceeded
+		status.ClaimName = name
+		status.CompletedAt = ptr(metav1.Now().Rfc3339Copy())
+		if previous != nil && previous.ClaimName != "" && previous.ClaimName != name {
+			if err := r.deletePrebuild(ctx, namespace, previous.ClaimName); err != nil {
+				return false, err
+			}
+		}
+		recordEvent(r.Recorder, project, corev1.EventTypeNormal, eventPrebuildSucceeded, "Prebuilt workspace of %s", revision)
+	case job.Status.Failed > 0:
+		status.Phase = buildPhaseFailed
+		status.Message = fmt.Sprintf("prebuild job failed: %s", name)
+		if err := r.Delete(ctx, pvc); err != nil && !apierrors.IsNotFound(err) {
+			return false, err
+		}
+		recordEvent(r.Recorder, project, corev1.EventTypeWarning, eventPrebuildFailed, "Prebuild of %s failed", revision)
+	}
+	return status.Phase == buildPhaseRunning, r.recordPrebuild(ctx, project, status)
+}
+
+// recordPrebuild updates status.prebuild when it changed
+func (r *ProjectReconciler) recordPrebuild(ctx context.Context, project *catalystv1alpha1.Project, status catalystv1alpha1.PrebuildStatus) error {
+	if equality.Semantic.DeepEqual(project.Status.Prebuild, &status) {
+		return nil
+	}
+	project.Status.Prebuild = &status
+	return r.Status().Update(ctx, project)
+}
+
+// deletePrebuild removes the Job and claim of a superseded prebuild. Claims cloned from it
+// are provisioned already.
+func (r *ProjectReconciler) deletePrebuild(ctx context.Context, namespace, name string) error {
+	job := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}}
+	if err := r.Delete(ctx, job, client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	pvc := &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}}
+	if err := r.Delete(ctx, pvc); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// prebuildGrantName names the ReferenceGrant an environment namespace clones the prebuild with
+func prebuildGrantName(targetNamespace string) string {
+	return "prebuild-" + targetNamespace
+}
+
+// desiredPrebuildReferenceGrant allows the claims of targetNamespace to clone the prebuild claim
+func desiredPrebuildReferenceGrant(projectNamespace, targetNamespace, claimName string) *unstructured.Unstructured {
+	grant := &unstructured.Unstructured{}
+	grant.SetGroupVersionKind(referenceGrantGVK)
+	grant.SetName(prebuildGrantName(targetNamespace))
+	grant.SetNamespace(projectNamespace)
+	grant.SetLabels(map[string]string{"app.kubernetes.io/managed-by": "catalyst-operator"})
+	grant.Object["spec"] = map[string]interface{}{
+		"from": []interface{}{
+			map[string]interface{}{"group": "", "kind": "PersistentVolumeClaim", "namespace": targetNamespace},
+		},
+		"to": []interface{}{
+			map[string]interface{}{"group": "", "kind": "PersistentVolumeClaim", "name": claimName},
+		},
+	}
+	return grant
+}
+
+// clonePrebuiltWorkspace points the code volume claim of a development environment at the
+// project's prebuilt workspace, before the claim is created, when the environment builds from
+// the prebuilt branch. Environments start from an empty volume otherwise.
+func (r *EnvironmentReconciler) clonePrebuiltWorkspace(ctx context.Context, env *catalystv1alpha1.Environment, project *catalystv1alpha1.Project, pvc *corev1.PersistentVolumeClaim) error {
+	spec, status := project.Spec.Prebuild, project.Status.Prebuild
+	if spec == nil || status == nil || status.ClaimName == "" || project.Status.Namespace == "" || len(project.Spec.Sources) == 0 {
+		return nil
+	}
+	if env.Spec.Type != prebuildTemplateKey(spec) || sourceBranch(env, &project.Spec.Sources[0]) != prebuildBranch(project) {
+		return nil
+	}
+	if err := r.Get(ctx, client.ObjectKeyFromObject(pvc), &corev1.PersistentVolumeClaim{}); !apierrors.IsNotFound(err) {
+		return err
+	}
+	log := logf.FromContext(ctx)
+
+	prebuilt := &corev1.PersistentVolumeClaim{}
+	if err := r.Get(ctx, client.ObjectKey{Name: status.ClaimName, Namespace: project.Status.Namespace}, prebuilt); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	if prebuilt.Spec.StorageClassName != nil && pvc.Spec.StorageClassName != nil && *prebuilt.Spec.StorageClassName != *pvc.Spec.StorageClassName {
+		log.Info("Code volume uses another storage class than the prebuild, starting empty", "prebuild", status.ClaimName)
+		return nil
+	}
+	// Clones are at least as large as their source
+	if size, ok := storageRequest(&prebuilt.Spec); ok {
+		if current, ok := storageRequest(&pvc.Spec); !ok || current.Cmp(size) < 0 {
+			if pvc.Spec.Resources.Requests == nil {
+				pvc.Spec.Resources.Requests = corev1.ResourceList{}
+			}
+			pvc.Spec.Resources.Requests[corev1.ResourceStorage] = size
+		}
+	}
+
+	grant := desiredPrebuildReferenceGrant(project.Status.Namespace, pvc.Namespace, status.ClaimName)
+	if err := r.Create(ctx, grant); err != nil && !isAlreadyExists(err) {
+		if meta.IsNoMatchError(err) {
+			log.Info("ReferenceGrant API not installed, code volume starts without the prebuild")
+			return nil
+		}
+		return fmt.Errorf("failed to create ReferenceGrant for prebuild: %w", err)
+	}
+	pvc.Spec.StorageClassName = prebuilt.Spec.StorageClassName
+	pvc.Spec.DataSourceRef = &corev1.TypedObjectReference{Kind: "PersistentVolumeClaim", Name: status.ClaimName, Namespace: ptr(project.Status.Namespace)}
+	if pvc.Annotations == nil {
+		pvc.Annotations = map[string]string{}
+	}
+	pvc.Annotations[prebuildGrantAnnotation] = project.Status.Namespace + "/" + grant.GetName()
+	log.Info("Cloning code volume from prebuild", "prebuild", status.ClaimName, "revision", status.Revision)
+	return nil
+}
+
+// releasePrebuildGrant deletes the ReferenceGrant a code volume claim was cloned with once
+// the claim is bound
+func (r *EnvironmentReconciler) releasePrebuildGrant(ctx context.Context, namespace, claimName string) error {
+	pvc := &corev1.PersistentVolumeClaim{}
+	if err := r.Get(ctx, client.ObjectKey{Name: claimName, Namespace: namespace}, pvc); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	grant, ok := pvc.Annotations[prebuildGrantAnnotation]
+	if !ok || pvc.Status.Phase != corev1.ClaimBound {
+		return nil
+	}
+	if err := r.deletePrebuildGrant(ctx, grant); err != nil {
+		return err
+	}
+	delete(pvc.Annotations, prebuildGrantAnnotation)
+	return r.Update(ctx, pvc)
+}
+
+// deletePrebuildGrant deletes a "namespace/name" prebuild ReferenceGrant
+func (r *EnvironmentReconciler) deletePrebuildGrant(ctx context.Context, ref string) error {
+	grant := &unstructured.Unstructured{}
+	grant.SetGroupVersionKind(referenceGrantGVK)
+	namespace, name, _ := strings.Cut(ref, "/")
+	grant.SetNamespace(namespace)
+	grant.SetName(name)
+	if err := r.Delete(ctx, grant); err != nil && !apierrors.IsNotFound(err) && !meta.IsNoMatchError(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,206 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+func prebuildProject() *catalystv1alpha1.Project {
+	return &catalystv1alpha1.Project{
+		ObjectMeta: metav1.ObjectMeta{Name: "shop", Namespace: "acme"},
+		Spec: catalystv1alpha1.ProjectSpec{
+			Sources: []catalystv1alpha1.SourceConfig{{Name: "app", RepositoryURL: "https://github.com/acme/shop", Branch: "main"}},
+			Templates: map[string]catalystv1alpha1.EnvironmentTemplateSpec{
+				"development": {Config: &catalystv1alpha1.EnvironmentConfig{
+					Image:        "node:22",
+					VolumeMounts: []corev1.VolumeMount{{Name: "code", MountPath: "/app"}},
+					Volumes: []catalystv1alpha1.VolumeSpec{{Name: "code", PersistentVolumeClaim: &corev1.PersistentVolumeClaimSpec{
+						AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+						Resources: corev1.VolumeResourceRequirements{Requests: corev1.ResourceList{
+							corev1.ResourceStorage: resource.MustParse("5Gi"),
+						}},
+					}}},
+					InitContainers: []catalystv1alpha1.InitContainerSpec{
+						{Name: "npm-install", Image: "node:22", Command: []string{"npm", "ci"}, WorkingDir: "/app", VolumeMounts: []corev1.VolumeMount{{Name: "code", MountPath: "/app"}}},
+						{Name: "migrate", Image: "node:22", Command: []string{"npm", "run", "migrate"}, VolumeMounts: []corev1.VolumeMount{{Name: "code", MountPath: "/app"}, {Name: "secrets", MountPath: "/secrets"}}},
+					},
+				}},
+			},
+			Prebuild: &catalystv1alpha1.PrebuildSpec{},
+		},
+		Status: catalystv1alpha1.ProjectStatus{Namespace: GenerateProjectNamespace("acme", "shop")},
+	}
+}
+
+func TestPrebuildSteps(t *testing.T) {
+	config := prebuildProject().Spec.Templates["development"].Config
+
+	steps := prebuildSteps(&catalystv1alpha1.PrebuildSpec{}, config)
+	require.Len(t, steps, 1, "steps needing more than the code volume are not prebuilt")
+	assert.Equal(t, "npm-install", steps[0].Name)
+
+	steps = prebuildSteps(&catalystv1alpha1.PrebuildSpec{Steps: []string{"migrate"}}, config)
+	require.Len(t, steps, 1)
+	assert.Equal(t, "migrate", steps[0].Name)
+}
+
+func TestReconcilePrebuild(t *testing.T) {
+	project := prebuildProject()
+	namespace := project.Status.Namespace
+	c := newFakeClientBuilder().WithStatusSubresource(project).WithObjects(project).Build()
+	r := &ProjectReconciler{Client: c, Scheme: testScheme}
+	ctx := context.Background()
+
+	running, err := r.reconcilePrebuild(ctx, project, namespace)
+	require.NoError(t, err)
+	assert.True(t, running)
+	require.NotNil(t, project.Status.Prebuild)
+	assert.Equal(t, "main", project.Status.Prebuild.Revision)
+	assert.Equal(t, buildPhaseRunning, project.Status.Prebuild.Phase)
+	first := project.Status.Prebuild.JobName
+
+	job := &batchv1.Job{}
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: first, Namespace: namespace}, job))
+	spec := job.Spec.Template.Spec
+	require.Len(t, spec.InitContainers, 2)
+	assert.Equal(t, "git-clone", spec.InitContainers[0].Name)
+	assert.Equal(t, "npm-install", spec.InitContainers[1].Name)
+	assert.Equal(t, []string{"touch", "/app/" + prebuildMarker}, spec.Containers[0].Command)
+	assert.Equal(t, first, spec.Volumes[0].PersistentVolumeClaim.ClaimName)
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: first, Namespace: namespace}, &corev1.PersistentVolumeClaim{}))
+
+	// The workspace becomes available once the Job succeeds
+	job.Status.Succeeded = 1
+	require.NoError(t, c.Status().Update(ctx, job))
+	running, err = r.reconcilePrebuild(ctx, project, namespace)
+	require.NoError(t, err)
+	assert.False(t, running)
+	assert.Equal(t, buildPhaseSucceeded, project.Status.Prebuild.Phase)
+	assert.Equal(t, first, project.Status.Prebuild.ClaimName)
+	assert.NotNil(t, project.Status.Prebuild.CompletedAt)
+
+	// A new commit is prebuilt next to the current workspace, which it then supersedes
+	project.Spec.Prebuild.CommitSha = "bbb2222"
+	require.NoError(t, c.Update(ctx, project))
+	running, err = r.reconcilePrebuild(ctx, project, namespace)
+	require.NoError(t, err)
+	assert.True(t, running)
+	second := project.Status.Prebuild.JobName
+	assert.NotEqual(t, first, second)
+	assert.Equal(t, first, project.Status.Prebuild.ClaimName, "environments keep cloning the previous prebuild")
+
+	job = &batchv1.Job{}
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: second, Namespace: namespace}, job))
+	job.Status.Succeeded = 1
+	require.NoError(t, c.Status().Update(ctx, job))
+	_, err = r.reconcilePrebuild(ctx, project, namespace)
+	require.NoError(t, err)
+	assert.Equal(t, second, project.Status.Prebuild.ClaimName)
+	err = c.Get(ctx, client.ObjectKey{Name: first, Namespace: namespace}, &corev1.PersistentVolumeClaim{})
+	assert.True(t, client.IgnoreNotFound(err) == nil && err != nil, "superseded prebuild claim is deleted")
+}
+
+func TestReconcilePrebuild_Failed(t *testing.T) {
+	project := prebuildProject()
+	namespace := project.Status.Namespace
+	c := newFakeClientBuilder().WithStatusSubresource(project).WithObjects(project).Build()
+	r := &ProjectReconciler{Client: c, Scheme: testScheme}
+	ctx := context.Background()
+
+	_, err := r.reconcilePrebuild(ctx, project, namespace)
+	require.NoError(t, err)
+	name := project.Status.Prebuild.JobName
+	job := &batchv1.Job{}
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: name, Namespace: namespace}, job))
+	job.Status.Failed = 1
+	require.NoError(t, c.Status().Update(ctx, job))
+
+	running, err := r.reconcilePrebuild(ctx, project, namespace)
+	require.NoError(t, err)
+	assert.False(t, running)
+	assert.Equal(t, buildPhaseFailed, project.Status.Prebuild.Phase)
+	assert.Empty(t, project.Status.Prebuild.ClaimName)
+	err = c.Get(ctx, client.ObjectKey{Name: name, Namespace: namespace}, &corev1.PersistentVolumeClaim{})
+	assert.True(t, client.IgnoreNotFound(err) == nil && err != nil, "failed prebuild claim is deleted")
+
+	// The same revision is not retried
+	running, err = r.reconcilePrebuild(ctx, project, namespace)
+	require.NoError(t, err)
+	assert.False(t, running)
+}
+
+func TestClonePrebuiltWorkspace(t *testing.T) {
+	project := prebuildProject()
+	project.Status.Prebuild = &catalystv1alpha1.PrebuildStatus{Revision: "main", JobName: "prebuild-abc", Phase: buildPhaseSucceeded, ClaimName: "prebuild-abc"}
+	prebuilt := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "prebuild-abc", Namespace: project.Status.Namespace},
+		Spec: corev1.PersistentVolumeClaimSpec{Resources: corev1.VolumeResourceRequirements{Requests: corev1.ResourceList{
+			corev1.ResourceStorage: resource.MustParse("8Gi"),
+		}}},
+	}
+	env := &catalystv1alpha1.Environment{
+		ObjectMeta: metav1.ObjectMeta{Name: "pr-12", Namespace: "acme"},
+		Spec: catalystv1alpha1.EnvironmentSpec{
+			ProjectRef: catalystv1alpha1.ProjectReference{Name: "shop"},
+			Type:       "development",
+			Sources:    []catalystv1alpha1.EnvironmentSource{{Name: "app", Branch: "main"}},
+		},
+	}
+	namespace := GenerateEnvironmentNamespace("acme", "shop", "pr-12")
+	c := newFakeClientBuilder().WithObjects(prebuilt).Build()
+	r := &EnvironmentReconciler{Client: c, Scheme: testScheme}
+	ctx := context.Background()
+
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "code", Namespace: namespace},
+		Spec: corev1.PersistentVolumeClaimSpec{Resources: corev1.VolumeResourceRequirements{Requests: corev1.ResourceList{
+			corev1.ResourceStorage: resource.MustParse("5Gi"),
+		}}},
+	}
+	require.NoError(t, r.clonePrebuiltWorkspace(ctx, env, project, pvc))
+	require.NotNil(t, pvc.Spec.DataSourceRef)
+	assert.Equal(t, "prebuild-abc", pvc.Spec.DataSourceRef.Name)
+	assert.Equal(t, project.Status.Namespace, *pvc.Spec.DataSourceRef.Namespace)
+	size := pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+	assert.Equal(t, "8Gi", size.String(), "clones are at least as large as the prebuild")
+	ref := pvc.Annotations[prebuildGrantAnnotation]
+	assert.Equal(t, project.Status.Namespace+"/"+prebuildGrantName(namespace), ref)
+
+	grant := &unstructured.Unstructured{}
+	grant.SetGroupVersionKind(referenceGrantGVK)
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: prebuildGrantName(namespace), Namespace: project.Status.Namespace}, grant))
+	from, _, _ := unstructured.NestedSlice(grant.Object, "spec", "from")
+	assert.Equal(t, namespace, from[0].(map[string]interface{})["namespace"])
+
+	// The grant is released once the clone is bound
+	require.NoError(t, c.Create(ctx, pvc))
+	require.NoError(t, r.releasePrebuildGrant(ctx, namespace, "code"))
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(grant), grant), "pending clones keep the grant")
+	pvc.Status.Phase = corev1.ClaimBound
+	require.NoError(t, c.Status().Update(ctx, pvc))
+	require.NoError(t, r.releasePrebuildGrant(ctx, namespace, "code"))
+	err := c.Get(ctx, client.ObjectKeyFromObject(grant), grant)
+	assert.True(t, client.IgnoreNotFound(err) == nil && err != nil)
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(pvc), pvc))
+	assert.NotContains(t, pvc.Annotations, prebuildGrantAnnotation)
+
+	// Existing claims and other branches are left alone
+	existing := &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "code", Namespace: namespace}}
+	require.NoError(t, r.clonePrebuiltWorkspace(ctx, env, project, existing))
+	assert.Nil(t, existing.Spec.DataSourceRef)
+	env.Spec.Sources[0].Branch = "feature"
+	other := &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "code", Namespace: GenerateEnvironmentNamespace("acme", "shop", "pr-13")}}
+	require.NoError(t, r.clonePrebuiltWorkspace(ctx, env, project, other))
+	assert.Nil(t, other.Spec.DataSourceRef)
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+	"github.com/ncrmro/catalyst/operator/internal/operatorconfig"
+)
+
+// Preview host templating (production routing):
+// PREVIEW_DOMAIN is the operator-wide base domain (default preview.catalyst.dev); a Project
+// can use its own with spec.baseDomain. PREVIEW_HOST_TEMPLATE builds environment hosts from it
+// (default "{{env}}.{{baseDomain}}") using the placeholders {{env}}, {{project}}, {{team}}
+// and {{baseDomain}}. Rendered hosts must be valid DNS names.
+
+const (
+	defaultPreviewDomain       = "preview.catalyst.dev"
+	defaultPreviewHostTemplate = "{{env}}.{{baseDomain}}"
+)
+
+// previewBaseDomain returns the Project base domain, else preview.domain, else the default
+func previewBaseDomain(project *catalystv1alpha1.Project) string {
+	if project != nil && project.Spec.BaseDomain != "" {
+		return project.Spec.BaseDomain
+	}
+	if domain := operatorconfig.Current().Preview.Domain; domain != "" {
+		return domain
+	}
+	return defaultPreviewDomain
+}
+
+// previewHostTemplate returns preview.hostTemplate or the default template
+func previewHostTemplate() string {
+	if tmpl := operatorconfig.Current().Preview.HostTemplate; tmpl != "" {
+		return tmpl
+	}
+	return defaultPreviewHostTemplate
+}
+
+// renderPreviewHost expands a host template and validates the result as a DNS name
+func renderPreviewHost(tmpl string, env *catalystv1alpha1.Environment, project *catalystv1alpha1.Project, team, baseDomain string) (string, error) {
+	host := strings.NewReplacer(
+		"{{env}}", env.Name,
+		"{{project}}", project.Name,
+		"{{team}}", team,
+		"{{baseDomain}}", baseDomain,
+	).Replace(tmpl)
+	if strings.Contains(host, "{{") {
+		return "", fmt.Errorf("preview host template %q has an unknown placeholder", tmpl)
+	}
+	if errs := validation.IsDNS1123Subdomain(host); len(errs) > 0 {
+		return "", fmt.Errorf("preview host %q is not a valid DNS name: %s", host, strings.Join(errs, "; "))
+	}
+	for _, label := range strings.Split(host, ".") {
+		if len(label) > validation.DNS1123LabelMaxLength {
+			return "", fmt.Errorf("preview host %q: label %q exceeds %d characters", host, label, validation.DNS1123LabelMaxLength)
+		}
+	}
+	return host, nil
+}
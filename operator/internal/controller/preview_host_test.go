@@ -0,0 +1,49 @@
+package controller
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+func TestPreviewBaseDomain(t *testing.T) {
+	project := &catalystv1alpha1.Project{}
+	assert.Equal(t, defaultPreviewDomain, previewBaseDomain(project))
+
+	t.Setenv("PREVIEW_DOMAIN", "preview.example.com")
+	assert.Equal(t, "preview.example.com", previewBaseDomain(project))
+
+	project.Spec.BaseDomain = "apps.acme.dev"
+	assert.Equal(t, "apps.acme.dev", previewBaseDomain(project))
+}
+
+func TestRenderPreviewHost(t *testing.T) {
+	env := &catalystv1alpha1.Environment{ObjectMeta: metav1.ObjectMeta{Name: "pr-42"}}
+	project := &catalystv1alpha1.Project{ObjectMeta: metav1.ObjectMeta{Name: "shop"}}
+
+	host, err := renderPreviewHost(previewHostTemplate(), env, project, "acme", "preview.example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "pr-42.preview.example.com", host)
+
+	host, err = renderPreviewHost("{{env}}.{{project}}.{{baseDomain}}", env, project, "acme", "preview.example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "pr-42.shop.preview.example.com", host)
+
+	host, err = renderPreviewHost("{{env}}-{{project}}-{{team}}.{{baseDomain}}", env, project, "acme", "preview.example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "pr-42-shop-acme.preview.example.com", host)
+
+	_, err = renderPreviewHost("{{env}}.{{region}}.{{baseDomain}}", env, project, "acme", "preview.example.com")
+	assert.ErrorContains(t, err, "unknown placeholder")
+
+	// Each DNS label is limited to 63 characters
+	env.Name = strings.Repeat("a", 40)
+	project.Name = strings.Repeat("b", 30)
+	_, err = renderPreviewHost("{{env}}-{{project}}.{{baseDomain}}", env, project, "acme", "preview.example.com")
+	assert.ErrorContains(t, err, "exceeds 63 characters")
+}
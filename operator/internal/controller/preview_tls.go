@@ -0,0 +1,186 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/ncrmro/catalyst/operator/internal/operatorconfig"
+)
+
+// Shared wildcard TLS for preview hosts:
+// One certificate for *.<PREVIEW_DOMAIN> (a kubernetes.io/tls Secret in a central namespace,
+// PREVIEW_TLS_SECRET=<namespace>/<name>) serves every preview Ingress, instead of issuing a
+// certificate per environment. PREVIEW_TLS_MODE selects how Ingresses use it:
+//   - "copy" (default): the Secret is copied into each environment namespace and referenced
+//     by the Ingress TLS section. Renewals are propagated on the next reconcile.
+//   - "default-certificate": ingress-nginx serves it as --default-ssl-certificate; Ingresses
+//     only list their hosts under TLS, without a secretName.
+//
+// Local preview routing with TLS (see currentLocalTLS) uses a third mode: cert-manager issues
+// a certificate per Ingress from the local CA.
+
+const (
+	previewTLSSecretName = "preview-tls"
+
+	previewTLSModeCopy               = "copy"
+	previewTLSModeDefaultCertificate = "default-certificate"
+	previewTLSModeLocalCA            = "local-ca"
+)
+
+// previewTLS is the resolved wildcard TLS configuration; a nil *previewTLS disables TLS.
+type previewTLS struct {
+	// SourceNamespace and SourceName locate the central wildcard Secret
+	SourceNamespace string
+	SourceName      string
+	// Mode is previewTLSModeCopy, previewTLSModeDefaultCertificate or previewTLSModeLocalCA
+	Mode string
+	// Domain is the preview domain the wildcard covers
+	Domain string
+	// Issuer is the ClusterIssuer of the certificates in previewTLSModeLocalCA
+	Issuer string
+	// Port is the HTTPS port of local preview URLs
+	Port string
+}
+
+// currentPreviewTLS reads preview.tls of the operator configuration; nil if TLS is not configured.
+func currentPreviewTLS(previewDomain string) (*previewTLS, error) {
+	cfg := operatorconfig.Current().Preview.TLS
+	ref := cfg.Secret
+	if ref == "" {
+		return nil, nil
+	}
+	namespace, name, found := strings.Cut(ref, "/")
+	if !found || namespace == "" || name == "" {
+		return nil, fmt.Errorf("preview.tls.secret must be <namespace>/<name>, got %q", ref)
+	}
+	mode := cfg.Mode
+	switch mode {
+	case "":
+		mode = previewTLSModeCopy
+	case previewTLSModeCopy, previewTLSModeDefaultCertificate:
+	default:
+		return nil, fmt.Errorf("unsupported preview.tls.mode %q", mode)
+	}
+	if previewDomain == "" {
+		previewDomain = defaultPreviewDomain
+	}
+	return &previewTLS{SourceNamespace: namespace, SourceName: name, Mode: mode, Domain: previewDomain}, nil
+}
+
+// covers reports whether the wildcard certificate is valid for host (exactly one label below the domain)
+func (t *previewTLS) covers(host string) bool {
+	label, found := strings.CutSuffix(host, "."+t.Domain)
+	return found && label != "" && !strings.Contains(label, ".")
+}
+
+// applyTo sets the Ingress TLS section for all rule hosts covered by the wildcard
+func (t *previewTLS) applyTo(ingress *networkingv1.Ingress) {
+	if t == nil {
+		return
+	}
+	var hosts []string
+	for _, rule := range ingress.Spec.Rules {
+		if t.covers(rule.Host) {
+			hosts = append(hosts, rule.Host)
+		}
+	}
+	if len(hosts) == 0 {
+		ingress.Spec.TLS = nil
+		return
+	}
+	entry := networkingv1.IngressTLS{Hosts: hosts}
+	switch t.Mode {
+	case previewTLSModeCopy:
+		entry.SecretName = previewTLSSecretName
+	case previewTLSModeLocalCA:
+		// A certificate per Ingress, issued into <ingress>-tls
+		entry.SecretName = ingress.Name + "-tls"
+		if ingress.Annotations == nil {
+			ingress.Annotations = map[string]string{}
+		}
+		ingress.Annotations[certManagerIssuerAnnotation] = t.Issuer
+	}
+	ingress.Spec.TLS = []networkingv1.IngressTLS{entry}
+}
+
+// desiredPreviewTLSSecret copies the wildcard certificate into an environment namespace
+func desiredPreviewTLSSecret(source *corev1.Secret, namespace string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      previewTLSSecretName,
+			Namespace: namespace,
+			Labels: map[string]string{
+				"catalyst.dev/component": "preview-tls",
+			},
+			Annotations: map[string]string{
+				"catalyst.dev/source-secret": source.Namespace + "/" + source.Name,
+			},
+		},
+		Type: corev1.SecretTypeTLS,
+		Data: maps.Clone(source.Data),
+	}
+}
+
+// ensurePreviewTLS resolves the wildcard TLS configuration and, in copy mode, keeps the
+// environment namespace copy of the certificate in sync. Returns nil (no TLS) when it is
+// not configured or the central Secret does not exist yet.
+func (r *EnvironmentReconciler) ensurePreviewTLS(ctx context.Context, namespace, previewDomain string) (*previewTLS, error) {
+	log := logf.FromContext(ctx)
+
+	tls, err := currentPreviewTLS(previewDomain)
+	if err != nil || tls == nil || tls.Mode != previewTLSModeCopy {
+		return tls, err
+	}
+
+	source := &corev1.Secret{}
+	if err := r.Get(ctx, client.ObjectKey{Name: tls.SourceName, Namespace: tls.SourceNamespace}, source); err != nil {
+		if apierrors.IsNotFound(err) {
+			log.Info("Wildcard TLS Secret not found, serving previews without TLS", "secret", tls.SourceNamespace+"/"+tls.SourceName)
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	desired := desiredPreviewTLSSecret(source, namespace)
+	existing := &corev1.Secret{}
+	err = r.Get(ctx, client.ObjectKey{Name: previewTLSSecretName, Namespace: namespace}, existing)
+	if apierrors.IsNotFound(err) {
+		log.Info("Copying wildcard TLS Secret", "namespace", namespace)
+		return tls, r.Create(ctx, desired)
+	} else if err != nil {
+		return nil, err
+	}
+	if !maps.EqualFunc(existing.Data, desired.Data, func(a, b []byte) bool { return string(a) == string(b) }) {
+		log.Info("Updating wildcard TLS Secret copy", "namespace", namespace)
+		existing.Data = desired.Data
+		if err := r.Update(ctx, existing); err != nil {
+			return nil, err
+		}
+	}
+	return tls, nil
+}
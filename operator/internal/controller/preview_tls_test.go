@@ -0,0 +1,90 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+func TestCurrentPreviewTLS(t *testing.T) {
+	tls, err := currentPreviewTLS("preview.example.com")
+	require.NoError(t, err)
+	assert.Nil(t, tls)
+
+	t.Setenv("PREVIEW_TLS_SECRET", "catalyst-system/wildcard")
+	tls, err = currentPreviewTLS("preview.example.com")
+	require.NoError(t, err)
+	assert.Equal(t, &previewTLS{SourceNamespace: "catalyst-system", SourceName: "wildcard", Mode: previewTLSModeCopy, Domain: "preview.example.com"}, tls)
+
+	t.Setenv("PREVIEW_TLS_MODE", "default-certificate")
+	tls, err = currentPreviewTLS("preview.example.com")
+	require.NoError(t, err)
+	assert.Equal(t, previewTLSModeDefaultCertificate, tls.Mode)
+}
+
+func TestPreviewTLSApplyTo(t *testing.T) {
+	env := &catalystv1alpha1.Environment{ObjectMeta: metav1.ObjectMeta{Name: "pr-1"}}
+	tls := &previewTLS{Mode: previewTLSModeCopy, Domain: "preview.example.com"}
+
+	ingress := desiredIngress(env, "env-ns", false, "preview.example.com")
+	tls.applyTo(ingress)
+	require.Len(t, ingress.Spec.TLS, 1)
+	assert.Equal(t, []string{"pr-1.preview.example.com"}, ingress.Spec.TLS[0].Hosts)
+	assert.Equal(t, previewTLSSecretName, ingress.Spec.TLS[0].SecretName)
+
+	// The ingress-nginx default certificate needs no secretName
+	tls.Mode = previewTLSModeDefaultCertificate
+	tls.applyTo(ingress)
+	assert.Empty(t, ingress.Spec.TLS[0].SecretName)
+
+	// The wildcard only covers a single label below the domain
+	assert.False(t, tls.covers("a.b.preview.example.com"))
+	assert.False(t, tls.covers("preview.example.com"))
+
+	var disabled *previewTLS
+	ingress = desiredIngress(env, "env-ns", false, "preview.example.com")
+	disabled.applyTo(ingress)
+	assert.Empty(t, ingress.Spec.TLS)
+}
+
+func TestEnsurePreviewTLS(t *testing.T) {
+	source := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "wildcard", Namespace: "catalyst-system"},
+		Type:       corev1.SecretTypeTLS,
+		Data:       map[string][]byte{corev1.TLSCertKey: []byte("cert-v1"), corev1.TLSPrivateKeyKey: []byte("key-v1")},
+	}
+	c := newFakeClientBuilder().WithObjects(source).Build()
+	r := &EnvironmentReconciler{Client: c, Scheme: testScheme}
+	ctx := context.Background()
+
+	t.Setenv("PREVIEW_TLS_SECRET", "catalyst-system/wildcard")
+	tls, err := r.ensurePreviewTLS(ctx, "env-ns", "preview.example.com")
+	require.NoError(t, err)
+	require.NotNil(t, tls)
+
+	copied := &corev1.Secret{}
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: previewTLSSecretName, Namespace: "env-ns"}, copied))
+	assert.Equal(t, corev1.SecretTypeTLS, copied.Type)
+	assert.Equal(t, []byte("cert-v1"), copied.Data[corev1.TLSCertKey])
+
+	// Renewals are propagated
+	source.Data[corev1.TLSCertKey] = []byte("cert-v2")
+	require.NoError(t, c.Update(ctx, source))
+	_, err = r.ensurePreviewTLS(ctx, "env-ns", "preview.example.com")
+	require.NoError(t, err)
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: previewTLSSecretName, Namespace: "env-ns"}, copied))
+	assert.Equal(t, []byte("cert-v2"), copied.Data[corev1.TLSCertKey])
+
+	// A missing central Secret disables TLS instead of failing the reconcile
+	t.Setenv("PREVIEW_TLS_SECRET", "catalyst-system/missing")
+	tls, err = r.ensurePreviewTLS(ctx, "env-ns", "preview.example.com")
+	require.NoError(t, err)
+	assert.Nil(t, tls)
+}
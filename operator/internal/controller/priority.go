@@ -0,0 +1,116 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+// environmentPriority is the PriorityClass an Environment priority level maps to
+type environmentPriority struct {
+	value      int32
+	preemption corev1.PreemptionPolicy
+}
+
+// environmentPriorities are the PriorityClasses by spec.priority. Low pods never preempt,
+// so previews wait for capacity instead of evicting other workloads.
+var environmentPriorities = map[string]environmentPriority{
+	catalystv1alpha1.EnvironmentPriorityLow:    {value: 1000, preemption: corev1.PreemptNever},
+	catalystv1alpha1.EnvironmentPriorityNormal: {value: 10000, preemption: corev1.PreemptLowerPriority},
+	catalystv1alpha1.EnvironmentPriorityHigh:   {value: 100000, preemption: corev1.PreemptLowerPriority},
+}
+
+// priorityClassName returns the PriorityClass of an Environment, empty if it sets no priority
+func priorityClassName(env *catalystv1alpha1.Environment) string {
+	if _, ok := environmentPriorities[env.Spec.Priority]; !ok {
+		return ""
+	}
+	return "catalyst-" + env.Spec.Priority
+}
+
+// desiredPriorityClass creates the PriorityClass of an Environment
+func desiredPriorityClass(env *catalystv1alpha1.Environment) *schedulingv1.PriorityClass {
+	priority := environmentPriorities[env.Spec.Priority]
+	return &schedulingv1.PriorityClass{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   priorityClassName(env),
+			Labels: map[string]string{"app.kubernetes.io/managed-by": "catalyst-operator"},
+		},
+		Value:            priority.value,
+		PreemptionPolicy: ptr(priority.preemption),
+		Description:      fmt.Sprintf("Catalyst environments with spec.priority %s", env.Spec.Priority),
+	}
+}
+
+// ensurePriorityClass creates the PriorityClass the Environment's pods are scheduled with.
+// PriorityClasses are shared by every Environment of the level and never deleted.
+func (r *EnvironmentReconciler) ensurePriorityClass(ctx context.Context, env *catalystv1alpha1.Environment) error {
+	if priorityClassName(env) == "" {
+		return nil
+	}
+	if err := r.Create(ctx, desiredPriorityClass(env)); err != nil && !apierrors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create PriorityClass for priority %s: %w", env.Spec.Priority, err)
+	}
+	return nil
+}
+
+// prioritizeEnvironmentWorkload sets the Environment's PriorityClass on a workload's pods,
+// unless they already name one
+func prioritizeEnvironmentWorkload(env *catalystv1alpha1.Environment, obj client.Object) {
+	name := priorityClassName(env)
+	if name == "" {
+		return
+	}
+	var spec *corev1.PodSpec
+	switch w := obj.(type) {
+	case *corev1.Pod:
+		spec = &w.Spec
+	case *appsv1.Deployment:
+		spec = &w.Spec.Template.Spec
+	case *appsv1.StatefulSet:
+		spec = &w.Spec.Template.Spec
+	case *batchv1.Job:
+		spec = &w.Spec.Template.Spec
+	case *unstructured.Unstructured:
+		// Rendered manifests (kustomize)
+		switch w.GetKind() {
+		case "Deployment", "StatefulSet", "DaemonSet", "Job":
+			path := []string{"spec", "template", "spec", "priorityClassName"}
+			if current, _, _ := unstructured.NestedString(w.Object, path...); current == "" {
+				_ = unstructured.SetNestedField(w.Object, name, path...)
+			}
+		}
+		return
+	default:
+		return
+	}
+	if spec.PriorityClassName == "" {
+		spec.PriorityClassName = name
+	}
+}
@@ -0,0 +1,71 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+func TestEnsurePriorityClass(t *testing.T) {
+	c := newFakeClientBuilder().Build()
+	r := &EnvironmentReconciler{Client: c, Scheme: testScheme}
+	ctx := context.Background()
+
+	env := &catalystv1alpha1.Environment{Spec: catalystv1alpha1.EnvironmentSpec{Priority: catalystv1alpha1.EnvironmentPriorityLow}}
+	require.NoError(t, r.ensurePriorityClass(ctx, env))
+	require.NoError(t, r.ensurePriorityClass(ctx, env), "existing PriorityClasses are kept")
+
+	class := &schedulingv1.PriorityClass{}
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "catalyst-low"}, class))
+	assert.Equal(t, int32(1000), class.Value)
+	assert.Equal(t, corev1.PreemptNever, *class.PreemptionPolicy)
+
+	require.NoError(t, r.ensurePriorityClass(ctx, &catalystv1alpha1.Environment{}))
+	classes := &schedulingv1.PriorityClassList{}
+	require.NoError(t, c.List(ctx, classes))
+	assert.Len(t, classes.Items, 1)
+}
+
+func TestPrioritizeEnvironmentWorkload(t *testing.T) {
+	env := &catalystv1alpha1.Environment{
+		ObjectMeta: metav1.ObjectMeta{Name: "prod"},
+		Spec:       catalystv1alpha1.EnvironmentSpec{Priority: catalystv1alpha1.EnvironmentPriorityHigh},
+	}
+
+	pod := desiredWorkspacePod(env, "env-ns", nil)
+	assert.Equal(t, "catalyst-high", pod.Spec.PriorityClassName)
+
+	hook := &catalystv1alpha1.PreDeleteHook{Job: &batchv1.JobSpec{Template: corev1.PodTemplateSpec{
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "cleanup", Image: "busybox"}}},
+	}}}
+	job := desiredPreDeleteJob(env, "env-ns", hook)
+	assert.Equal(t, "catalyst-high", job.Spec.Template.Spec.PriorityClassName)
+
+	// Pods naming a PriorityClass, like builds with an operator default, keep it
+	job.Spec.Template.Spec.PriorityClassName = "builds"
+	prioritizeEnvironmentWorkload(env, job)
+	assert.Equal(t, "builds", job.Spec.Template.Spec.PriorityClassName)
+
+	rendered := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]interface{}{"name": "web"},
+		"spec":       map[string]interface{}{"template": map[string]interface{}{"spec": map[string]interface{}{}}},
+	}}
+	prioritizeEnvironmentWorkload(env, rendered)
+	name, _, _ := unstructured.NestedString(rendered.Object, "spec", "template", "spec", "priorityClassName")
+	assert.Equal(t, "catalyst-high", name)
+
+	unset := desiredWorkspacePod(&catalystv1alpha1.Environment{ObjectMeta: metav1.ObjectMeta{Name: "preview"}}, "env-ns", nil)
+	assert.Empty(t, unset.Spec.PriorityClassName)
+}
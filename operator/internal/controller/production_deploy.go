@@ -21,16 +21,20 @@ import (
 	"fmt"
 
 	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 
 	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+	"github.com/ncrmro/catalyst/operator/internal/guardrails"
 )
 
 // ReconcileProductionMode handles the reconciliation for production deployment mode.
 // It deploys using resolved config from Project template and Environment overrides.
-func (r *EnvironmentReconciler) ReconcileProductionMode(ctx context.Context, env *catalystv1alpha1.Environment, project *catalystv1alpha1.Project, namespace string, isLocal bool, template *catalystv1alpha1.EnvironmentTemplate) (bool, error) {
+func (r *EnvironmentReconciler) ReconcileProductionMode(ctx context.Context, env *catalystv1alpha1.Environment, project *catalystv1alpha1.Project, namespace string, isLocal bool, template *catalystv1alpha1.EnvironmentTemplateSpec) (bool, error) {
 	log := logf.FromContext(ctx)
 
 	// 0. Get and validate configuration (required - no fallbacks)
@@ -38,13 +42,26 @@ func (r *EnvironmentReconciler) ReconcileProductionMode(ctx context.Context, env
 	if err != nil {
 		return false, fmt.Errorf("failed to get template config: %w", err)
 	}
+	if template != nil {
+		// Honor the template revision the environment is pinned to
+		templateConfig = template.Config
+	}
 
 	// Resolve config (merge template + environment overrides)
 	config := resolveConfig(&env.Spec.Config, templateConfig)
+	resolveStorage(&config, project.Spec.Storage)
 
 	// Validate that required fields are present
 	if err := validateConfig(&config); err != nil {
-		return false, fmt.Errorf("invalid configuration: %w", err)
+		return false, withFailureReason(catalystv1alpha1.FailureReasonConfigInvalid, fmt.Errorf("invalid configuration: %w", err))
+	}
+	if err := validatePoolers(&config, "production"); err != nil {
+		return false, withFailureReason(catalystv1alpha1.FailureReasonConfigInvalid, fmt.Errorf("invalid configuration: %w", err))
+	}
+
+	// Enforce platform guardrails on the resolved config
+	if err := guardrails.AsError(guardrails.Current().CheckConfig("environment config", &config)); err != nil {
+		return false, err
 	}
 
 	log.Info("Using resolved config",
@@ -52,13 +69,41 @@ func (r *EnvironmentReconciler) ReconcileProductionMode(ctx context.Context, env
 		"ports", len(config.Ports),
 	)
 
-	// 1. Create/update deployment using config
+	// 0b. Postgres clusters of the provider-backed managed services; production mode runs no
+	// other managed services
+	for _, svcSpec := range config.Services {
+		if svcSpec.Provider == "" {
+			continue
+		}
+		if ready, err := r.reconcilePostgresProvider(ctx, env, namespace, svcSpec); err != nil {
+			return false, err
+		} else if !ready {
+			return false, nil
+		}
+	}
+	config.Env = postgresConnectionEnv(config.Env, config.Services)
+
+	// 1. Create/update deployment using config; the secrets hash restarts pods on changes
+	secretsHash, err := r.catalystSecretsHash(ctx, namespace)
+	if err != nil {
+		return false, err
+	}
 	deployment := desiredDeploymentFromConfig(namespace, &config)
+	labelEnvironmentWorkload(env, deployment)
+	prioritizeEnvironmentWorkload(env, deployment)
+	setSecretsHash(&deployment.Spec.Template, secretsHash)
+	if desiredReplicas(&config) > 1 {
+		deployment.Spec.Template.Spec.Affinity = spreadAffinity(deployment.Spec.Selector.MatchLabels)
+	}
+	scheduleEnvironmentWorkload(&config, deployment)
 
 	existingDeployment := &appsv1.Deployment{}
 	getErr := r.Get(ctx, client.ObjectKey{Name: "web", Namespace: namespace}, existingDeployment)
 
 	if getErr != nil && apierrors.IsNotFound(getErr) {
+		if err := r.checkDrift(ctx, env, deployment, nil); err != nil {
+			return false, err
+		}
 		log.Info("Creating Production Deployment", "namespace", namespace, "image", deployment.Spec.Template.Spec.Containers[0].Image)
 		if err := r.Create(ctx, deployment); err != nil {
 			return false, err
@@ -66,15 +111,32 @@ func (r *EnvironmentReconciler) ReconcileProductionMode(ctx context.Context, env
 	} else if getErr != nil {
 		return false, getErr
 	} else {
-		// Deployment exists, check if image needs update
+		// Deployment exists, check if image or secrets need update
 		currentImage := ""
 		if len(existingDeployment.Spec.Template.Spec.Containers) > 0 {
 			currentImage = existingDeployment.Spec.Template.Spec.Containers[0].Image
 		}
 		desiredImage := deployment.Spec.Template.Spec.Containers[0].Image
+		currentHash := existingDeployment.Spec.Template.Annotations[secretsHashAnnotation]
+
+		// The autoscaler owns the replica count of an autoscaled Deployment
+		replicasChanged := false
+		if config.Autoscaling != nil {
+			deployment.Spec.Replicas = existingDeployment.Spec.Replicas
+		} else {
+			replicasChanged = existingDeployment.Spec.Replicas == nil || *existingDeployment.Spec.Replicas != *deployment.Spec.Replicas
+		}
+
+		existingPod, desiredPod := &existingDeployment.Spec.Template.Spec, &deployment.Spec.Template.Spec
+		schedulingChanged := !equality.Semantic.DeepEqual(existingPod.Affinity, desiredPod.Affinity) ||
+			!equality.Semantic.DeepEqual(existingPod.NodeSelector, desiredPod.NodeSelector) ||
+			!equality.Semantic.DeepEqual(existingPod.Tolerations, desiredPod.Tolerations)
 
-		if currentImage != desiredImage {
-			log.Info("Updating Production Deployment image", "from", currentImage, "to", desiredImage)
+		if err := r.checkDrift(ctx, env, deployment, existingDeployment); err != nil {
+			return false, err
+		}
+		if currentImage != desiredImage || currentHash != secretsHash || replicasChanged || schedulingChanged {
+			log.Info("Updating Production Deployment", "from", currentImage, "to", desiredImage, "secretsChanged", currentHash != secretsHash, "replicas", deployment.Spec.Replicas, "schedulingChanged", schedulingChanged)
 			existingDeployment.Spec = deployment.Spec
 			if err := r.Update(ctx, existingDeployment); err != nil {
 				return false, err
@@ -82,9 +144,38 @@ func (r *EnvironmentReconciler) ReconcileProductionMode(ctx context.Context, env
 		}
 	}
 
-	// 2. Create service (idempotent)
+	// 2. Create service, restoring its ports and selector when edited
 	service := desiredServiceFromConfig(namespace, &config)
-	if err := r.Create(ctx, service); err != nil && !apierrors.IsAlreadyExists(err) {
+	existingService := &corev1.Service{}
+	if err := r.Get(ctx, client.ObjectKey{Name: service.Name, Namespace: namespace}, existingService); apierrors.IsNotFound(err) {
+		if err := r.checkDrift(ctx, env, service, nil); err != nil {
+			return false, err
+		}
+		if err := r.Create(ctx, service); err != nil && !apierrors.IsAlreadyExists(err) {
+			return false, err
+		}
+	} else if err != nil {
+		return false, err
+	} else {
+		if err := r.checkDrift(ctx, env, service, existingService); err != nil {
+			return false, err
+		}
+		if driftSpecHash(service) != driftSpecHash(existingService) {
+			existingService.Spec.Ports = service.Spec.Ports
+			existingService.Spec.Selector = service.Spec.Selector
+			if err := r.Update(ctx, existingService); err != nil {
+				return false, err
+			}
+		}
+	}
+
+	// 2b. Autoscaler (config.autoscaling)
+	if err := r.reconcileAutoscaler(ctx, namespace, config.Autoscaling); err != nil {
+		return false, err
+	}
+
+	// 2c. PodDisruptionBudget, so node drains keep a replica serving
+	if err := r.reconcilePodDisruptionBudget(ctx, env, namespace, &config); err != nil {
 		return false, err
 	}
 
@@ -100,3 +191,38 @@ func (r *EnvironmentReconciler) ReconcileProductionMode(ctx context.Context, env
 
 	return ready, nil
 }
+
+// +kubebuilder:rbac:groups=autoscaling,resources=horizontalpodautoscalers,verbs=get;list;watch;create;update;patch;delete
+
+// reconcileAutoscaler creates or updates the HorizontalPodAutoscaler of the web Deployment,
+// or deletes it once autoscaling is removed from the config
+func (r *EnvironmentReconciler) reconcileAutoscaler(ctx context.Context, namespace string, autoscaling *catalystv1alpha1.AutoscalingSpec) error {
+	log := logf.FromContext(ctx)
+
+	existing := &autoscalingv2.HorizontalPodAutoscaler{}
+	err := r.Get(ctx, client.ObjectKey{Name: "web", Namespace: namespace}, existing)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	found := err == nil
+
+	if autoscaling == nil {
+		if found {
+			log.Info("Deleting HorizontalPodAutoscaler", "namespace", namespace)
+			return client.IgnoreNotFound(r.Delete(ctx, existing))
+		}
+		return nil
+	}
+
+	desired := desiredHorizontalPodAutoscaler(namespace, autoscaling)
+	if !found {
+		log.Info("Creating HorizontalPodAutoscaler", "namespace", namespace, "minReplicas", *desired.Spec.MinReplicas, "maxReplicas", desired.Spec.MaxReplicas)
+		return r.Create(ctx, desired)
+	}
+	if !equality.Semantic.DeepEqual(existing.Spec, desired.Spec) {
+		log.Info("Updating HorizontalPodAutoscaler", "namespace", namespace, "minReplicas", *desired.Spec.MinReplicas, "maxReplicas", desired.Spec.MaxReplicas)
+		existing.Spec = desired.Spec
+		return r.Update(ctx, existing)
+	}
+	return nil
+}
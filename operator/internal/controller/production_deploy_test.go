@@ -0,0 +1,81 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+func TestDesiredDeploymentFromConfig_Replicas(t *testing.T) {
+	config := &catalystv1alpha1.EnvironmentConfig{Image: "app:1"}
+	assert.Equal(t, int32(1), *desiredDeploymentFromConfig("ns", config).Spec.Replicas)
+
+	config.Replicas = ptr(int32(3))
+	assert.Equal(t, int32(3), *desiredDeploymentFromConfig("ns", config).Spec.Replicas)
+
+	// Autoscaled Deployments start at the lower bound
+	config.Autoscaling = &catalystv1alpha1.AutoscalingSpec{MinReplicas: ptr(int32(2)), MaxReplicas: 10}
+	assert.Equal(t, int32(2), *desiredDeploymentFromConfig("ns", config).Spec.Replicas)
+}
+
+func TestDesiredDeploymentFromConfig_Sidecars(t *testing.T) {
+	config := &catalystv1alpha1.EnvironmentConfig{
+		Image: "app:1",
+		Env:   []corev1.EnvVar{{Name: "DATABASE_URL", Value: "postgres://db"}, {Name: "ROLE", Value: "web"}},
+		Sidecars: []catalystv1alpha1.ContainerSpec{
+			{Name: "worker", Command: []string{"npm", "run", "worker"}, Env: []corev1.EnvVar{{Name: "ROLE", Value: "worker"}}},
+			{Name: "otel-collector", Image: "otel/opentelemetry-collector:0.110.0"},
+		},
+	}
+	spec := desiredDeploymentFromConfig("ns", config).Spec.Template.Spec
+	require.Len(t, spec.Containers, 3)
+
+	worker := spec.Containers[1]
+	assert.Equal(t, "worker", worker.Name)
+	assert.Equal(t, "app:1", worker.Image, "sidecars default to the main image")
+	assert.Equal(t, []corev1.EnvVar{{Name: "DATABASE_URL", Value: "postgres://db"}, {Name: "ROLE", Value: "worker"}}, worker.Env)
+	assert.Equal(t, []corev1.EnvFromSource{catalystSecretsEnvFrom()}, worker.EnvFrom)
+	assert.Equal(t, "otel/opentelemetry-collector:0.110.0", spec.Containers[2].Image)
+	assertRestricted(t, spec)
+}
+
+func TestReconcileAutoscaler(t *testing.T) {
+	c := newFakeClientBuilder().Build()
+	r := &EnvironmentReconciler{Client: c, Scheme: testScheme}
+	ctx := context.Background()
+	key := client.ObjectKey{Name: "web", Namespace: "ns"}
+
+	// Created with the default bounds and CPU target
+	require.NoError(t, r.reconcileAutoscaler(ctx, "ns", &catalystv1alpha1.AutoscalingSpec{MaxReplicas: 4}))
+	hpa := &autoscalingv2.HorizontalPodAutoscaler{}
+	require.NoError(t, c.Get(ctx, key, hpa))
+	assert.Equal(t, "Deployment", hpa.Spec.ScaleTargetRef.Kind)
+	assert.Equal(t, "web", hpa.Spec.ScaleTargetRef.Name)
+	assert.Equal(t, int32(1), *hpa.Spec.MinReplicas)
+	assert.Equal(t, int32(4), hpa.Spec.MaxReplicas)
+	require.Len(t, hpa.Spec.Metrics, 1)
+	assert.Equal(t, corev1.ResourceCPU, hpa.Spec.Metrics[0].Resource.Name)
+	assert.Equal(t, int32(80), *hpa.Spec.Metrics[0].Resource.Target.AverageUtilization)
+
+	// Config changes update the autoscaler
+	require.NoError(t, r.reconcileAutoscaler(ctx, "ns", &catalystv1alpha1.AutoscalingSpec{
+		MinReplicas: ptr(int32(2)), MaxReplicas: 8, TargetCPUUtilizationPercentage: ptr(int32(60)),
+	}))
+	require.NoError(t, c.Get(ctx, key, hpa))
+	assert.Equal(t, int32(2), *hpa.Spec.MinReplicas)
+	assert.Equal(t, int32(8), hpa.Spec.MaxReplicas)
+	assert.Equal(t, int32(60), *hpa.Spec.Metrics[0].Resource.Target.AverageUtilization)
+
+	// Removing autoscaling deletes it
+	require.NoError(t, r.reconcileAutoscaler(ctx, "ns", nil))
+	assert.True(t, apierrors.IsNotFound(c.Get(ctx, key, hpa)))
+	require.NoError(t, r.reconcileAutoscaler(ctx, "ns", nil))
+}
@@ -18,46 +18,145 @@ package controller
 
 import (
 	"context"
+	"maps"
 
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+	"github.com/ncrmro/catalyst/operator/internal/sharding"
 )
 
 // ProjectReconciler reconciles a Project object
 type ProjectReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
+	// Shard limits reconciliation to the Projects owned by this operator instance.
+	// Nil reconciles every Project.
+	Shard *sharding.Shard
+	// Recorder emits an Event when the project namespace is provisioned. Nil records none.
+	Recorder record.EventRecorder
 }
 
 // +kubebuilder:rbac:groups=catalyst.catalyst.dev,resources=projects,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=catalyst.catalyst.dev,resources=projects/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=catalyst.catalyst.dev,resources=projects/finalizers,verbs=update
+// +kubebuilder:rbac:groups=catalyst.catalyst.dev,resources=teams,verbs=get;list;watch
+// +kubebuilder:rbac:groups=catalyst.catalyst.dev,resources=environments,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=resourcequotas,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=networking.k8s.io,resources=networkpolicies,verbs=get;list;watch;create;update;patch;delete
 
-// Reconcile is part of the main kubernetes reconciliation loop which aims to
-// move the current state of the cluster closer to the desired state.
-// TODO(user): Modify the Reconcile function to compare the state specified by
-// the Project object against the actual cluster state, and then
-// perform operations to make the cluster state reflect the state specified by
-// the user.
-//
-// For more details, check Reconcile and its Result here:
-// - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.22.4/pkg/reconcile
+// Reconcile provisions the project namespace holding the Project's Environments, and
+// records a new TemplateRevision in Project status whenever the content of a template
+// (inline or from the template catalog) changes, so Environments can stay pinned to the
+// revision they were rendered from. It also keeps the workspace prebuild up to date.
 func (r *ProjectReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
-	_ = logf.FromContext(ctx)
+	log := logf.FromContext(ctx)
 
-	// TODO(user): your logic here
+	project := &catalystv1alpha1.Project{}
+	if err := r.Get(ctx, req.NamespacedName, project); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if owned, err := ownsProject(ctx, r, r.Shard, project); err != nil || !owned {
+		return ctrl.Result{}, err
+	}
+
+	if err := resolveTemplateCatalog(ctx, r, project); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	namespace, err := r.reconcileProjectNamespace(ctx, project)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	inUse, err := templateHashesInUse(ctx, r, project)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	revisions, changed := recordTemplateRevisions(project.Spec.Templates, project.Status.TemplateRevisions, inUse, metav1.Now())
+	if changed {
+		log.Info("Recording template revisions", "project", project.Name, "revisions", len(revisions))
+	}
+	if project.Status.Namespace != namespace {
+		recordEvent(r.Recorder, project, corev1.EventTypeNormal, eventNamespaceCreated, "Provisioned project namespace %s", namespace)
+	}
+	if changed || project.Status.Namespace != namespace {
+		project.Status.TemplateRevisions = revisions
+		project.Status.Namespace = namespace
+		templates := project.Spec.Templates
+		if err := r.Status().Update(ctx, project); err != nil {
+			return ctrl.Result{}, err
+		}
+		// The update returns the stored spec, without the resolved catalog templates
+		project.Spec.Templates = templates
+	}
+
+	// Prebuild Jobs run in the project namespace, which the Project cannot own
+	if running, err := r.reconcilePrebuild(ctx, project, namespace); err != nil {
+		return ctrl.Result{}, err
+	} else if running {
+		return ctrl.Result{RequeueAfter: workloadResyncInterval}, nil
+	}
 
 	return ctrl.Result{}, nil
 }
 
+// reconcileProjectNamespace provisions the project namespace. The Project's namespace is
+// the team namespace; the members and quota of the Team of that name apply, if one exists.
+func (r *ProjectReconciler) reconcileProjectNamespace(ctx context.Context, project *catalystv1alpha1.Project) (string, error) {
+	team := &catalystv1alpha1.Team{}
+	if err := r.Get(ctx, client.ObjectKey{Name: project.Namespace}, team); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return "", err
+		}
+		team = nil
+	}
+
+	namespace := GenerateProjectNamespace(project.Namespace, project.Name)
+	labels := map[string]string{
+		"catalyst.dev/team":    sanitizeLabelValue(project.Namespace),
+		"catalyst.dev/project": sanitizeLabelValue(project.Name),
+		namespaceTypeLabel:     namespaceTypeProject,
+	}
+	maps.Copy(labels, podSecurityLabels(project))
+	if err := provisionHierarchyNamespace(ctx, r.Client, namespace, labels, team); err != nil {
+		return "", err
+	}
+	return namespace, nil
+}
+
+// projectsForTeam enqueues the Projects in the team namespace, so member and quota changes
+// reach their project namespaces
+func projectsForTeam(c client.Reader) func(context.Context, client.Object) []reconcile.Request {
+	return func(ctx context.Context, obj client.Object) []reconcile.Request {
+		projects := &catalystv1alpha1.ProjectList{}
+		if err := c.List(ctx, projects, client.InNamespace(GenerateTeamNamespace(obj.GetName()))); err != nil {
+			return nil
+		}
+		requests := make([]reconcile.Request, 0, len(projects.Items))
+		for _, project := range projects.Items {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&project)})
+		}
+		return requests
+	}
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *ProjectReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&catalystv1alpha1.Project{}).
+		Watches(&catalystv1alpha1.EnvironmentTemplate{}, handler.EnqueueRequestsFromMapFunc(projectsForEnvironmentTemplate(r))).
+		Watches(&catalystv1alpha1.Team{}, handler.EnqueueRequestsFromMapFunc(projectsForTeam(r))).
 		Named("project").
-		Complete(r)
+		Complete(drainingReconciler{r})
 }
@@ -59,7 +59,7 @@ var _ = Describe("Project Controller", func() {
 								Branch:        "main",
 							},
 						},
-						Templates: map[string]catalystv1alpha1.EnvironmentTemplate{
+						Templates: map[string]catalystv1alpha1.EnvironmentTemplateSpec{
 							"production": {
 								Type: "helm",
 								Path: "./charts/app",
@@ -0,0 +1,241 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+// Promotion (spec.promoteFrom):
+// Each image set an environment deploys is numbered in status.deploymentHistory with the hash
+// of the template it was deployed with. Promoting a revision of a Ready Environment snapshots
+// that template (from the Project's template revisions) and the images into status.promotion;
+// the environment then renders from the snapshot and deploys the images by digest, so every
+// stage runs the same artifacts. Its own spec.config overrides (hosts, replicas, secrets)
+// still apply. Until a revision resolves, a new environment stays Pending and one deployed
+// before keeps what it deploys; the Promoted condition says why. Changes of the source
+// enqueue the environments promoting it.
+//
+// A rollback (spec.rollbackTo) is the promotion of a revision of the environment's own
+// history; unlike the source of a promotion, the environment need not be Ready.
+
+const (
+	// conditionPromoted reports whether the revision of spec.promoteFrom (or spec.rollbackTo) is deployed
+	conditionPromoted = "Promoted"
+	// environmentPromoteFromIndex indexes Environments by spec.promoteFrom.environment
+	environmentPromoteFromIndex = "catalyst.dev/promote-from"
+)
+
+// environmentPromoteFrom is the index function for environmentPromoteFromIndex
+func environmentPromoteFrom(obj client.Object) []string {
+	env, ok := obj.(*catalystv1alpha1.Environment)
+	if !ok || env.Spec.PromoteFrom == nil {
+		return nil
+	}
+	return []string{env.Spec.PromoteFrom.Environment}
+}
+
+// environmentsPromotedFrom enqueues the Environments of the namespace promoting an Environment
+func (r *EnvironmentReconciler) environmentsPromotedFrom(ctx context.Context, obj client.Object) []reconcile.Request {
+	envs := &catalystv1alpha1.EnvironmentList{}
+	if err := r.List(ctx, envs, client.InNamespace(obj.GetNamespace()), client.MatchingFields{environmentPromoteFromIndex: obj.GetName()}); err != nil {
+		logf.FromContext(ctx).Error(err, "Failed to list promoting Environments", "namespace", obj.GetNamespace(), "name", obj.GetName())
+		return nil
+	}
+	requests := make([]reconcile.Request, 0, len(envs.Items))
+	for _, env := range envs.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&env)})
+	}
+	return requests
+}
+
+// activePromotion returns the promoted revision the environment deploys, nil when it builds
+// its own images
+func activePromotion(env *catalystv1alpha1.Environment) *catalystv1alpha1.PromotionStatus {
+	if requestedPromotion(env) == nil {
+		return nil
+	}
+	return env.Status.Promotion
+}
+
+// requestedPromotion returns the revision spec.promoteFrom or spec.rollbackTo asks
+// for, nil when the environment builds its own images
+func requestedPromotion(env *catalystv1alpha1.Environment) *catalystv1alpha1.EnvironmentPromotion {
+	if env.Spec.RollbackTo != 0 {
+		return &catalystv1alpha1.EnvironmentPromotion{Environment: env.Name, Revision: env.Spec.RollbackTo}
+	}
+	return env.Spec.PromoteFrom
+}
+
+// promotedImage returns the promoted image of a build
+func promotedImage(promotion *catalystv1alpha1.PromotionStatus, buildName string) *catalystv1alpha1.BuiltImage {
+	for i := range promotion.Images {
+		if promotion.Images[i].Name == buildName {
+			return &promotion.Images[i]
+		}
+	}
+	return nil
+}
+
+// promotionTemplate returns the template a source deployed a record with: the current
+// template when its hash matches, a recorded template revision otherwise. Nil once the
+// revision was trimmed from the Project.
+func promotionTemplate(project *catalystv1alpha1.Project, key, hash string) (*catalystv1alpha1.EnvironmentTemplateSpec, int64) {
+	rev := findTemplateRevision(project.Status.TemplateRevisions, key, hash)
+	if rev != nil {
+		return rev.Spec.DeepCopy(), rev.Revision
+	}
+	if tmpl, ok := project.Spec.Templates[key]; ok && hash != "" && templateHash(&tmpl) == hash {
+		return tmpl.DeepCopy(), 0
+	}
+	return nil, 0
+}
+
+// resolvePromotion snapshots the requested revision of the source (env itself for a
+// rollback). A failure returns the condition reason and message explaining it.
+func resolvePromotion(env, source *catalystv1alpha1.Environment, project *catalystv1alpha1.Project, now metav1.Time) (*catalystv1alpha1.PromotionStatus, string, string) {
+	spec := requestedPromotion(env)
+	rollback := source.Name == env.Name
+	switch {
+	case source.Spec.ProjectRef.Name != env.Spec.ProjectRef.Name:
+		return nil, "ProjectMismatch", fmt.Sprintf("%s belongs to project %s, not %s", source.Name, source.Spec.ProjectRef.Name, env.Spec.ProjectRef.Name)
+	case !rollback && source.Status.Phase != "Ready":
+		phase := source.Status.Phase
+		if phase == "" {
+			phase = phasePending
+		}
+		return nil, "SourceNotReady", fmt.Sprintf("Waiting for %s to be Ready (%s)", source.Name, phase)
+	}
+
+	var record *catalystv1alpha1.DeploymentRecord
+	for i := range source.Status.DeploymentHistory {
+		if source.Status.DeploymentHistory[i].Revision == spec.Revision {
+			record = &source.Status.DeploymentHistory[i]
+			break
+		}
+	}
+	if record == nil {
+		return nil, "RevisionNotFound", fmt.Sprintf("%s has no revision %d in its deployment history", source.Name, spec.Revision)
+	}
+	for _, image := range record.Images {
+		if image.Digest == "" {
+			return nil, "MissingDigest", fmt.Sprintf("Image %s of revision %d has no digest", image.Image, spec.Revision)
+		}
+	}
+	template, templateRevision := promotionTemplate(project, source.Spec.Type, record.TemplateHash)
+	if template == nil {
+		return nil, "TemplateRevisionNotFound", fmt.Sprintf("The template revision %s deployed revision %d with is no longer recorded", record.TemplateHash, spec.Revision)
+	}
+	return &catalystv1alpha1.PromotionStatus{
+		Source:           source.Name,
+		Revision:         spec.Revision,
+		TemplateRevision: templateRevision,
+		TemplateHash:     record.TemplateHash,
+		Template:         *template,
+		Images:           append([]catalystv1alpha1.BuiltImage{}, record.Images...),
+		PromotedAt:       now,
+	}, "", ""
+}
+
+// reconcilePromotion records the revision of spec.promoteFrom in status.promotion and the
+// Promoted condition. It returns false while a new environment has no revision to deploy.
+func (r *EnvironmentReconciler) reconcilePromotion(ctx context.Context, env *catalystv1alpha1.Environment, project *catalystv1alpha1.Project) (bool, error) {
+	log := logf.FromContext(ctx)
+	spec := requestedPromotion(env)
+	if spec == nil {
+		changed := meta.RemoveStatusCondition(&env.Status.Conditions, conditionPromoted)
+		if env.Status.Promotion != nil {
+			env.Status.Promotion = nil
+			changed = true
+		}
+		if changed {
+			return true, r.Status().Update(ctx, env)
+		}
+		return true, nil
+	}
+	if current := env.Status.Promotion; current != nil && current.Source == spec.Environment && current.Revision == spec.Revision {
+		return true, nil
+	}
+
+	condition := metav1.Condition{
+		Type:               conditionPromoted,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: env.Generation,
+	}
+	rollback := env.Spec.RollbackTo != 0
+	source := env
+	var err error
+	if !rollback {
+		source = &catalystv1alpha1.Environment{}
+		err = r.Get(ctx, client.ObjectKey{Name: spec.Environment, Namespace: env.Namespace}, source)
+	}
+	var promotion *catalystv1alpha1.PromotionStatus
+	switch {
+	case !rollback && spec.Environment == env.Name:
+		condition.Reason, condition.Message = "Invalid", "An environment cannot be promoted from itself; use spec.rollbackTo"
+	case apierrors.IsNotFound(err):
+		condition.Reason, condition.Message = "SourceNotFound", fmt.Sprintf("Environment %s not found", spec.Environment)
+	case err != nil:
+		return false, fmt.Errorf("failed to fetch promotion source %s: %w", spec.Environment, err)
+	default:
+		promotion, condition.Reason, condition.Message = resolvePromotion(env, source, project, metav1.Now().Rfc3339Copy())
+	}
+
+	if promotion != nil && rollback {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "RolledBack"
+		condition.Message = fmt.Sprintf("Deploying revision %d of the deployment history", promotion.Revision)
+		log.Info("Rolling back environment", "revision", promotion.Revision, "templateHash", promotion.TemplateHash)
+		recordEvent(r.Recorder, env, corev1.EventTypeNormal, eventRolledBack, "Rolled back to revision %d", promotion.Revision)
+	} else if promotion != nil {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "Promoted"
+		condition.Message = fmt.Sprintf("Deploying revision %d of %s", promotion.Revision, promotion.Source)
+		log.Info("Promoting environment", "source", promotion.Source, "revision", promotion.Revision, "templateHash", promotion.TemplateHash)
+		recordEvent(r.Recorder, env, corev1.EventTypeNormal, eventPromoted, "Promoted revision %d of %s", promotion.Revision, promotion.Source)
+	}
+	if promotion != nil {
+		env.Status.Promotion = promotion
+		meta.SetStatusCondition(&env.Status.Conditions, condition)
+		return true, r.Status().Update(ctx, env)
+	}
+
+	// Environments promoted or deployed before keep deploying what they deploy
+	blocked := env.Status.Promotion == nil && (env.Status.Phase == "" || env.Status.Phase == phasePending)
+	changed := meta.SetStatusCondition(&env.Status.Conditions, condition)
+	if blocked && env.Status.Phase != phasePending {
+		env.Status.Phase = phasePending
+		changed = true
+	}
+	if changed {
+		if err := r.Status().Update(ctx, env); err != nil {
+			return false, err
+		}
+	}
+	return !blocked, nil
+}
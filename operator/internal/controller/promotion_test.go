@@ -0,0 +1,218 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+func TestReconcilePromotion(t *testing.T) {
+	template := catalystv1alpha1.EnvironmentTemplateSpec{Builds: []catalystv1alpha1.BuildSpec{{Name: "web", SourceRef: "app"}}}
+	oldTemplate := catalystv1alpha1.EnvironmentTemplateSpec{Builds: []catalystv1alpha1.BuildSpec{{Name: "web", SourceRef: "app", Path: "/old"}}}
+	project := &catalystv1alpha1.Project{
+		ObjectMeta: metav1.ObjectMeta{Name: "shop", Namespace: "team"},
+		Spec:       catalystv1alpha1.ProjectSpec{Templates: map[string]catalystv1alpha1.EnvironmentTemplateSpec{"development": template}},
+		Status: catalystv1alpha1.ProjectStatus{TemplateRevisions: []catalystv1alpha1.TemplateRevision{
+			{Template: "development", Revision: 1, Hash: templateHash(&oldTemplate), Spec: oldTemplate},
+		}},
+	}
+	images := []catalystv1alpha1.BuiltImage{{Name: "web", Image: "registry/shop/web-pr-1:bbb", Digest: "sha256:b", Commit: "bbb"}}
+	preview := &catalystv1alpha1.Environment{
+		ObjectMeta: metav1.ObjectMeta{Name: "pr-1", Namespace: "team"},
+		Spec:       catalystv1alpha1.EnvironmentSpec{ProjectRef: catalystv1alpha1.ProjectReference{Name: "shop"}, Type: "development"},
+		Status: catalystv1alpha1.EnvironmentStatus{
+			Phase:       "Building",
+			BuiltImages: images,
+			DeploymentHistory: []catalystv1alpha1.DeploymentRecord{
+				{Revision: 2, Images: images, TemplateHash: templateHash(&template)},
+				{Revision: 1, Images: []catalystv1alpha1.BuiltImage{{Name: "web", Image: "registry/shop/web-pr-1:aaa", Digest: "sha256:a"}}, TemplateHash: templateHash(&oldTemplate)},
+			},
+		},
+	}
+	staging := &catalystv1alpha1.Environment{
+		ObjectMeta: metav1.ObjectMeta{Name: "staging", Namespace: "team", Generation: 1},
+		Spec: catalystv1alpha1.EnvironmentSpec{
+			ProjectRef:  catalystv1alpha1.ProjectReference{Name: "shop"},
+			Type:        "deployment",
+			PromoteFrom: &catalystv1alpha1.EnvironmentPromotion{Environment: "pr-1", Revision: 2},
+		},
+	}
+	c := newFakeClientBuilder().WithStatusSubresource(preview, staging).WithObjects(preview, staging).Build()
+	recorder := record.NewFakeRecorder(10)
+	r := &EnvironmentReconciler{Client: c, Scheme: testScheme, Recorder: recorder}
+	ctx := context.Background()
+
+	// A new environment waits for the source to be Ready
+	ready, err := r.reconcilePromotion(ctx, staging, project)
+	require.NoError(t, err)
+	assert.False(t, ready)
+	assert.Equal(t, phasePending, staging.Status.Phase)
+	condition := meta.FindStatusCondition(staging.Status.Conditions, conditionPromoted)
+	require.NotNil(t, condition)
+	assert.Equal(t, "SourceNotReady", condition.Reason)
+	assert.Nil(t, activePromotion(staging))
+
+	preview.Status.Phase = "Ready"
+	require.NoError(t, c.Status().Update(ctx, preview))
+	ready, err = r.reconcilePromotion(ctx, staging, project)
+	require.NoError(t, err)
+	assert.True(t, ready)
+	promotion := activePromotion(staging)
+	require.NotNil(t, promotion)
+	assert.Equal(t, "pr-1", promotion.Source)
+	assert.Equal(t, int64(2), promotion.Revision)
+	assert.Equal(t, images, promotion.Images)
+	assert.Equal(t, template, promotion.Template, "the current template matches the revision's hash")
+	assert.Equal(t, metav1.ConditionTrue, meta.FindStatusCondition(staging.Status.Conditions, conditionPromoted).Status)
+	assert.Equal(t, "Normal Promoted Promoted revision 2 of pr-1", <-recorder.Events)
+
+	// Older revisions render from their recorded template revision
+	staging.Spec.PromoteFrom.Revision = 1
+	_, err = r.reconcilePromotion(ctx, staging, project)
+	require.NoError(t, err)
+	assert.Equal(t, oldTemplate, staging.Status.Promotion.Template)
+	assert.Equal(t, int64(1), staging.Status.Promotion.TemplateRevision)
+	<-recorder.Events
+
+	// An unknown revision keeps the current one deployed
+	staging.Spec.PromoteFrom.Revision = 7
+	ready, err = r.reconcilePromotion(ctx, staging, project)
+	require.NoError(t, err)
+	assert.True(t, ready)
+	assert.Equal(t, int64(1), activePromotion(staging).Revision)
+	assert.Equal(t, "RevisionNotFound", meta.FindStatusCondition(staging.Status.Conditions, conditionPromoted).Reason)
+
+	// Clearing spec.promoteFrom builds from the sources again
+	staging.Spec.PromoteFrom = nil
+	ready, err = r.reconcilePromotion(ctx, staging, project)
+	require.NoError(t, err)
+	assert.True(t, ready)
+	assert.Nil(t, staging.Status.Promotion)
+	assert.Nil(t, meta.FindStatusCondition(staging.Status.Conditions, conditionPromoted))
+}
+
+func TestReconcilePromotion_Rollback(t *testing.T) {
+	template := catalystv1alpha1.EnvironmentTemplateSpec{Builds: []catalystv1alpha1.BuildSpec{{Name: "web", SourceRef: "app"}}}
+	project := &catalystv1alpha1.Project{
+		ObjectMeta: metav1.ObjectMeta{Name: "shop", Namespace: "team"},
+		Spec:       catalystv1alpha1.ProjectSpec{Templates: map[string]catalystv1alpha1.EnvironmentTemplateSpec{"deployment": template}},
+	}
+	good := []catalystv1alpha1.BuiltImage{{Name: "web", Image: "registry/shop/web:aaa", Digest: "sha256:a", Commit: "aaa"}}
+	env := &catalystv1alpha1.Environment{
+		ObjectMeta: metav1.ObjectMeta{Name: "production", Namespace: "team", Generation: 3},
+		Spec: catalystv1alpha1.EnvironmentSpec{
+			ProjectRef: catalystv1alpha1.ProjectReference{Name: "shop"},
+			Type:       "deployment",
+			RollbackTo: 1,
+		},
+		Status: catalystv1alpha1.EnvironmentStatus{
+			Phase: "Failed",
+			DeploymentHistory: []catalystv1alpha1.DeploymentRecord{
+				{Revision: 2, Images: []catalystv1alpha1.BuiltImage{{Name: "web", Image: "registry/shop/web:bbb", Digest: "sha256:b"}}, TemplateHash: templateHash(&template), Outcome: catalystv1alpha1.DeploymentOutcomeFailed},
+				{Revision: 1, Images: good, TemplateHash: templateHash(&template), Outcome: catalystv1alpha1.DeploymentOutcomeReady},
+			},
+		},
+	}
+	c := newFakeClientBuilder().WithStatusSubresource(env).WithObjects(env).Build()
+	recorder := record.NewFakeRecorder(10)
+	r := &EnvironmentReconciler{Client: c, Scheme: testScheme, Recorder: recorder}
+	ctx := context.Background()
+
+	// A failed environment rolls back to a revision of its own history
+	ready, err := r.reconcilePromotion(ctx, env, project)
+	require.NoError(t, err)
+	assert.True(t, ready)
+	promotion := activePromotion(env)
+	require.NotNil(t, promotion)
+	assert.Equal(t, "production", promotion.Source)
+	assert.Equal(t, good, promotion.Images)
+	condition := meta.FindStatusCondition(env.Status.Conditions, conditionPromoted)
+	require.NotNil(t, condition)
+	assert.Equal(t, "RolledBack", condition.Reason)
+	assert.Equal(t, "Normal RolledBack Rolled back to revision 1", <-recorder.Events)
+
+	// Clearing spec.rollbackTo builds from the sources again
+	env.Spec.RollbackTo = 0
+	require.NoError(t, c.Update(ctx, env))
+	_, err = r.reconcilePromotion(ctx, env, project)
+	require.NoError(t, err)
+	assert.Nil(t, env.Status.Promotion)
+
+	// Self-promotion is still invalid
+	env.Spec.PromoteFrom = &catalystv1alpha1.EnvironmentPromotion{Environment: "production", Revision: 1}
+	require.NoError(t, c.Update(ctx, env))
+	_, err = r.reconcilePromotion(ctx, env, project)
+	require.NoError(t, err)
+	assert.Equal(t, "Invalid", meta.FindStatusCondition(env.Status.Conditions, conditionPromoted).Reason)
+	assert.Nil(t, activePromotion(env))
+}
+
+func TestResolvePromotion_MissingDigest(t *testing.T) {
+	template := catalystv1alpha1.EnvironmentTemplateSpec{}
+	project := &catalystv1alpha1.Project{Spec: catalystv1alpha1.ProjectSpec{Templates: map[string]catalystv1alpha1.EnvironmentTemplateSpec{"development": template}}}
+	source := &catalystv1alpha1.Environment{
+		ObjectMeta: metav1.ObjectMeta{Name: "pr-1"},
+		Spec:       catalystv1alpha1.EnvironmentSpec{Type: "development"},
+		Status: catalystv1alpha1.EnvironmentStatus{Phase: "Ready", DeploymentHistory: []catalystv1alpha1.DeploymentRecord{
+			{Revision: 1, Images: []catalystv1alpha1.BuiltImage{{Name: "web", Image: "registry/web:aaa"}}, TemplateHash: templateHash(&template)},
+		}},
+	}
+	env := &catalystv1alpha1.Environment{Spec: catalystv1alpha1.EnvironmentSpec{PromoteFrom: &catalystv1alpha1.EnvironmentPromotion{Environment: "pr-1", Revision: 1}}}
+
+	promotion, reason, _ := resolvePromotion(env, source, project, metav1.Now())
+	assert.Nil(t, promotion)
+	assert.Equal(t, "MissingDigest", reason, "only images pinned by digest are promoted")
+
+	source.Status.DeploymentHistory[0].Images[0].Digest = "sha256:a"
+	source.Status.DeploymentHistory[0].TemplateHash = "trimmed"
+	_, reason, _ = resolvePromotion(env, source, project, metav1.Now())
+	assert.Equal(t, "TemplateRevisionNotFound", reason)
+}
+
+func TestReconcileBuildsDeploysPromotedImages(t *testing.T) {
+	images := []catalystv1alpha1.BuiltImage{{Name: "web", Image: "registry/shop/web-pr-1:bbb", Digest: "sha256:b", Commit: "bbb"}}
+	template := catalystv1alpha1.EnvironmentTemplateSpec{Builds: []catalystv1alpha1.BuildSpec{{Name: "web", SourceRef: "app"}}}
+	env := &catalystv1alpha1.Environment{
+		ObjectMeta: metav1.ObjectMeta{Name: "staging", Namespace: "team"},
+		Spec: catalystv1alpha1.EnvironmentSpec{
+			Sources:     []catalystv1alpha1.EnvironmentSource{{Name: "app", Branch: "main"}},
+			PromoteFrom: &catalystv1alpha1.EnvironmentPromotion{Environment: "pr-1", Revision: 2},
+		},
+		Status: catalystv1alpha1.EnvironmentStatus{
+			TemplateHash: templateHash(&template),
+			Promotion: &catalystv1alpha1.PromotionStatus{
+				Source: "pr-1", Revision: 2, TemplateHash: templateHash(&template), Template: template, Images: images,
+				PromotedAt: metav1.NewTime(time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)),
+			},
+		},
+	}
+	project := &catalystv1alpha1.Project{
+		ObjectMeta: metav1.ObjectMeta{Name: "shop", Namespace: "team"},
+		Spec:       catalystv1alpha1.ProjectSpec{Sources: []catalystv1alpha1.SourceConfig{{Name: "app", RepositoryURL: "https://github.com/acme/shop"}}},
+	}
+	c := newFakeClientBuilder().WithStatusSubresource(env).WithObjects(env).Build()
+	r := &EnvironmentReconciler{Client: c, Scheme: testScheme}
+	ctx := context.Background()
+
+	builtImages, err := r.reconcileBuilds(ctx, env, project, "env-ns", &template)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"web": "registry/shop/web-pr-1:bbb@sha256:b"}, builtImages)
+
+	jobs := &batchv1.JobList{}
+	require.NoError(t, c.List(ctx, jobs, client.InNamespace("env-ns")))
+	assert.Empty(t, jobs.Items, "promoted images are not rebuilt")
+	assert.Equal(t, buildPhaseReused, env.Status.Builds[0].Phase)
+	assert.Equal(t, images, env.Status.BuiltImages)
+	require.Len(t, env.Status.DeploymentHistory, 1)
+	assert.Equal(t, int64(1), env.Status.DeploymentHistory[0].Revision)
+}
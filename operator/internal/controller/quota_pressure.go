@@ -0,0 +1,200 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+// Resource priorities during namespace quota contention.
+//
+// The primary workload (the "web" Deployment that the Ingress routes to) always has priority.
+// Lower-priority resources (build Jobs) are only created when the quota headroom covers both
+// their requests and the part of the primary workload that hasn't been admitted yet, so that
+// a rebuild can't starve a rolling update of the app. On a first deploy there is no primary
+// Deployment yet and builds are its prerequisite, so they are never deferred.
+const (
+	// conditionQuotaPressure is set on the Environment while lower-priority resources are deferred
+	conditionQuotaPressure = "QuotaPressure"
+	// primaryDeploymentName is the primary workload of an environment namespace
+	primaryDeploymentName = "web"
+	// defaultQuotaName is the ResourceQuota created for every environment namespace
+	defaultQuotaName = "default-quota"
+)
+
+// podRequests returns the quota usage of a single pod: requests and limits of its containers
+// (init containers run sequentially, so the largest one counts if it exceeds the app containers)
+// plus one pod.
+func podRequests(spec *corev1.PodSpec) corev1.ResourceList {
+	total := corev1.ResourceList{}
+	add := func(list corev1.ResourceList, name corev1.ResourceName, q resource.Quantity) {
+		current := list[name]
+		current.Add(q)
+		list[name] = current
+	}
+	for _, c := range spec.Containers {
+		for name, q := range c.Resources.Requests {
+			add(total, corev1.ResourceName("requests."+string(name)), q)
+		}
+		for name, q := range c.Resources.Limits {
+			add(total, corev1.ResourceName("limits."+string(name)), q)
+		}
+	}
+	for _, c := range spec.InitContainers {
+		init := corev1.ResourceList{}
+		for name, q := range c.Resources.Requests {
+			init[corev1.ResourceName("requests."+string(name))] = q
+		}
+		for name, q := range c.Resources.Limits {
+			init[corev1.ResourceName("limits."+string(name))] = q
+		}
+		for name, q := range init {
+			if current, ok := total[name]; !ok || q.Cmp(current) > 0 {
+				total[name] = q
+			}
+		}
+	}
+	total[corev1.ResourcePods] = resource.MustParse("1")
+	return total
+}
+
+// scaleResources multiplies every quantity in the list by n
+func scaleResources(list corev1.ResourceList, n int64) corev1.ResourceList {
+	scaled := corev1.ResourceList{}
+	for name, q := range list {
+		scaled[name] = *resource.NewMilliQuantity(q.MilliValue()*n, q.Format)
+	}
+	return scaled
+}
+
+// quotaShortfall returns, for each resource tracked by the quota, how much of needed
+// exceeds the remaining headroom (hard - used). Empty if everything fits.
+func quotaShortfall(quota *corev1.ResourceQuota, needed corev1.ResourceList) corev1.ResourceList {
+	shortfall := corev1.ResourceList{}
+	for name, hard := range quota.Status.Hard {
+		want, ok := needed[name]
+		if !ok {
+			continue
+		}
+		headroom := hard.DeepCopy()
+		if used, ok := quota.Status.Used[name]; ok {
+			headroom.Sub(used)
+		}
+		if want.Cmp(headroom) > 0 {
+			missing := want.DeepCopy()
+			missing.Sub(headroom)
+			shortfall[name] = missing
+		}
+	}
+	return shortfall
+}
+
+// formatResources renders a resource list as "name=qty" pairs in a stable order
+func formatResources(list corev1.ResourceList) string {
+	parts := make([]string, 0, len(list))
+	for name, q := range list {
+		parts = append(parts, fmt.Sprintf("%s=%s", name, q.String()))
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ", ")
+}
+
+// primaryWorkloadReserve returns the quota the primary workload still needs: per-pod usage
+// for every desired replica that isn't ready yet. Nil if there is no primary Deployment.
+func (r *EnvironmentReconciler) primaryWorkloadReserve(ctx context.Context, namespace string) (corev1.ResourceList, error) {
+	deploy := &appsv1.Deployment{}
+	if err := r.Get(ctx, client.ObjectKey{Name: primaryDeploymentName, Namespace: namespace}, deploy); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	desired := int32(1)
+	if deploy.Spec.Replicas != nil {
+		desired = *deploy.Spec.Replicas
+	}
+	missing := desired - deploy.Status.ReadyReplicas
+	if missing <= 0 {
+		return nil, nil
+	}
+	return scaleResources(podRequests(&deploy.Spec.Template.Spec), int64(missing)), nil
+}
+
+// deferForQuota reports whether creating a lower-priority resource with the given pod spec
+// should be deferred, and records the QuotaPressure condition with the computed shortfall.
+func (r *EnvironmentReconciler) deferForQuota(ctx context.Context, env *catalystv1alpha1.Environment, namespace, resourceDesc string, spec *corev1.PodSpec) (bool, error) {
+	log := logf.FromContext(ctx)
+
+	quota := &corev1.ResourceQuota{}
+	if err := r.Get(ctx, client.ObjectKey{Name: defaultQuotaName, Namespace: namespace}, quota); err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	needed := podRequests(spec)
+	reserve, err := r.primaryWorkloadReserve(ctx, namespace)
+	if err != nil {
+		return false, err
+	}
+	for name, q := range reserve {
+		current := needed[name]
+		current.Add(q)
+		needed[name] = current
+	}
+
+	condition := metav1.Condition{
+		Type:               conditionQuotaPressure,
+		Status:             metav1.ConditionFalse,
+		Reason:             "SufficientHeadroom",
+		Message:            "Namespace quota has headroom for all resources",
+		ObservedGeneration: env.Generation,
+	}
+	shortfall := quotaShortfall(quota, needed)
+	deferred := len(shortfall) > 0
+	if deferred {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "InsufficientHeadroom"
+		condition.Message = fmt.Sprintf("Deferred %s to keep quota for the primary workload; shortfall: %s", resourceDesc, formatResources(shortfall))
+		log.Info("Deferring lower-priority resource under quota pressure", "resource", resourceDesc, "shortfall", formatResources(shortfall))
+	} else if meta.FindStatusCondition(env.Status.Conditions, conditionQuotaPressure) == nil {
+		// Only report the condition once pressure has been observed
+		return false, nil
+	}
+
+	if meta.SetStatusCondition(&env.Status.Conditions, condition) {
+		if err := r.Status().Update(ctx, env); err != nil {
+			return false, err
+		}
+	}
+	return deferred, nil
+}
@@ -0,0 +1,94 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+func containerWithRequests(cpu, memory string) corev1.Container {
+	return corev1.Container{Resources: corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu), corev1.ResourceMemory: resource.MustParse(memory)},
+	}}
+}
+
+func TestPodRequests(t *testing.T) {
+	spec := &corev1.PodSpec{
+		InitContainers: []corev1.Container{containerWithRequests("2", "64Mi")},
+		Containers:     []corev1.Container{containerWithRequests("500m", "256Mi"), containerWithRequests("250m", "256Mi")},
+	}
+	requests := podRequests(spec)
+
+	cpu := requests[corev1.ResourceRequestsCPU]
+	memory := requests[corev1.ResourceRequestsMemory]
+	pods := requests[corev1.ResourcePods]
+	assert.Equal(t, "2", cpu.String(), "largest init container wins")
+	assert.Equal(t, "512Mi", memory.String())
+	assert.Equal(t, int64(1), pods.Value())
+}
+
+func TestQuotaShortfall(t *testing.T) {
+	quota := &corev1.ResourceQuota{Status: corev1.ResourceQuotaStatus{
+		Hard: corev1.ResourceList{corev1.ResourceRequestsCPU: resource.MustParse("2"), corev1.ResourcePods: resource.MustParse("20")},
+		Used: corev1.ResourceList{corev1.ResourceRequestsCPU: resource.MustParse("1500m"), corev1.ResourcePods: resource.MustParse("3")},
+	}}
+
+	shortfall := quotaShortfall(quota, corev1.ResourceList{
+		corev1.ResourceRequestsCPU:    resource.MustParse("1"),
+		corev1.ResourcePods:           resource.MustParse("1"),
+		corev1.ResourceRequestsMemory: resource.MustParse("1Gi"), // not tracked by the quota
+	})
+	assert.Equal(t, "requests.cpu=500m", formatResources(shortfall))
+
+	assert.Empty(t, quotaShortfall(quota, corev1.ResourceList{corev1.ResourceRequestsCPU: resource.MustParse("500m")}))
+}
+
+func TestDeferForQuota(t *testing.T) {
+	env := &catalystv1alpha1.Environment{ObjectMeta: metav1.ObjectMeta{Name: "pr-1", Namespace: "team"}}
+	quota := &corev1.ResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{Name: defaultQuotaName, Namespace: "env-ns"},
+		Status: corev1.ResourceQuotaStatus{
+			Hard: corev1.ResourceList{corev1.ResourceRequestsCPU: resource.MustParse("2")},
+			Used: corev1.ResourceList{corev1.ResourceRequestsCPU: resource.MustParse("1")},
+		},
+	}
+	// Rolling update of the primary workload: one of two replicas not ready yet
+	web := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: primaryDeploymentName, Namespace: "env-ns"},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: int32Ptr(2),
+			Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{Containers: []corev1.Container{containerWithRequests("500m", "256Mi")}}},
+		},
+		Status: appsv1.DeploymentStatus{ReadyReplicas: 1},
+	}
+	c := newFakeClientBuilder().WithStatusSubresource(env).WithObjects(env, quota, web).Build()
+	r := &EnvironmentReconciler{Client: c, Scheme: testScheme}
+	ctx := context.Background()
+
+	build := &corev1.PodSpec{Containers: []corev1.Container{containerWithRequests("1", "1Gi")}}
+	deferred, err := r.deferForQuota(ctx, env, "env-ns", "build web", build)
+	require.NoError(t, err)
+	assert.True(t, deferred)
+	cond := meta.FindStatusCondition(env.Status.Conditions, conditionQuotaPressure)
+	require.NotNil(t, cond)
+	assert.Equal(t, metav1.ConditionTrue, cond.Status)
+	assert.Contains(t, cond.Message, "requests.cpu=500m")
+
+	// A smaller build fits next to the pending replica and clears the pressure
+	small := &corev1.PodSpec{Containers: []corev1.Container{containerWithRequests("500m", "1Gi")}}
+	deferred, err = r.deferForQuota(ctx, env, "env-ns", "build web", small)
+	require.NoError(t, err)
+	assert.False(t, deferred)
+	cond = meta.FindStatusCondition(env.Status.Conditions, conditionQuotaPressure)
+	require.NotNil(t, cond)
+	assert.Equal(t, metav1.ConditionFalse, cond.Status)
+}
@@ -0,0 +1,206 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/ncrmro/catalyst/operator/internal/operatorconfig"
+)
+
+// defaultRegistryPathTemplate is the repository path for built images
+const defaultRegistryPathTemplate = "{project}/{build}-{environment}"
+
+const (
+	// registryCAConfigMap holds the registry CA bundle (key ca.crt) in the namespaces of build
+	// and signing Jobs
+	registryCAConfigMap = "registry-ca"
+	registryCAVolume    = "registry-ca"
+	registryCAMountPath = "/etc/catalyst/registry-ca"
+)
+
+const (
+	registryGCEnabled = "enabled"
+	registryGCDryRun  = "dry-run"
+	// defaultRegistryGCKeep is the number of recent image sets kept per environment
+	defaultRegistryGCKeep = 5
+)
+
+// RegistryConfig describes the registry builds are pushed to and pulled from, from the
+// registry section of the operator configuration (env REGISTRY_*):
+//   - endpoint: host[:port][/prefix], e.g. "ghcr.io/acme", "harbor.example.com/previews",
+//     "123456789012.dkr.ecr.us-east-1.amazonaws.com" (default: in-cluster registry)
+//   - credentialsSecret: dockerconfigjson Secret in the project namespace, copied into
+//     environment namespaces for kaniko push and imagePullSecrets (default: registry-credentials).
+//     ECR tokens are short-lived and must be refreshed externally (e.g. external-secrets).
+//   - insecure: push over plain HTTP (default: true only for the in-cluster registry)
+//   - caBundle: PEM certificates the registry's TLS certificate is verified against, for
+//     registries signed by a private CA. Builds push with it and the garbage collector deletes with it.
+//   - pathTemplate: repository path, supports {project}, {build}, {environment}
+//   - gc: "enabled" deletes the images of an environment from the registry when it is
+//     deleted, and the images of builds older than the gcKeep most recent image sets
+//     (default 5). "dry-run" only logs the images that would be deleted. Requires a path
+//     template containing {environment}, so repositories are not shared between environments.
+//   - managed: whether the operator runs the in-cluster registry (default: true when
+//     endpoint is unset)
+//   - image, storageSize and storageClass: image and volume of the managed registry and its
+//     caches (default: registry:2.8.3 on 20Gi of the default class)
+//   - mirrors: upstream registries, e.g. [docker.io, ghcr.io], the managed registry runs a
+//     pull-through cache of for the base images of builds
+type RegistryConfig struct {
+	Endpoint     string
+	SecretName   string
+	Insecure     bool
+	CABundle     string
+	PathTemplate string
+	GC           string
+	GCKeep       int
+
+	Managed      bool
+	Image        string
+	StorageSize  string
+	StorageClass string
+	Mirrors      []string
+}
+
+// currentRegistryConfig resolves the registry configuration in effect
+func currentRegistryConfig() RegistryConfig {
+	registry := operatorconfig.Current().Registry
+	cfg := RegistryConfig{
+		Endpoint:     normalizeRegistryEndpoint(registry.Endpoint),
+		SecretName:   registry.CredentialsSecret,
+		CABundle:     registry.CABundle,
+		PathTemplate: registry.PathTemplate,
+		GC:           registry.GC,
+		GCKeep:       defaultRegistryGCKeep,
+		Image:        registry.Image,
+		StorageSize:  registry.StorageSize,
+		StorageClass: registry.StorageClass,
+		Mirrors:      registry.Mirrors,
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = registryInternal
+	}
+	if cfg.SecretName == "" {
+		cfg.SecretName = registrySecretName
+	}
+	if cfg.PathTemplate == "" {
+		cfg.PathTemplate = defaultRegistryPathTemplate
+	}
+
+	cfg.Insecure = cfg.Endpoint == registryInternal
+	if registry.Insecure != nil {
+		cfg.Insecure = *registry.Insecure
+	}
+	if registry.GCKeep > 0 {
+		cfg.GCKeep = registry.GCKeep
+	}
+
+	cfg.Managed = cfg.Endpoint == registryInternal
+	if registry.Managed != nil {
+		cfg.Managed = *registry.Managed
+	}
+	if cfg.Image == "" {
+		cfg.Image = defaultRegistryImage
+	}
+	if cfg.StorageSize == "" {
+		cfg.StorageSize = defaultRegistryStorageSize
+	}
+	return cfg
+}
+
+// normalizeRegistryEndpoint drops the trailing slash of endpoint and lowercases its path
+// prefix, as repository names of image references must be lowercase. The host is kept.
+func normalizeRegistryEndpoint(endpoint string) string {
+	endpoint = strings.TrimSuffix(endpoint, "/")
+	if host, prefix, ok := strings.Cut(endpoint, "/"); ok {
+		return host + "/" + strings.ToLower(prefix)
+	}
+	return endpoint
+}
+
+// host returns the registry host[:port] of the endpoint
+func (c RegistryConfig) host() string {
+	host, _, _ := strings.Cut(c.Endpoint, "/")
+	return host
+}
+
+// imageRef returns the fully qualified image reference for a build.
+func (c RegistryConfig) imageRef(project, build, environment, tag string) string {
+	path := strings.NewReplacer(
+		"{project}", project,
+		"{build}", build,
+		"{environment}", environment,
+	).Replace(c.PathTemplate)
+	return c.Endpoint + "/" + strings.Trim(strings.ToLower(path), "/") + ":" + tag
+}
+
+// collectsGarbage returns whether stale images are deleted (or logged, in dry-run mode).
+// Repositories shared between environments are never collected.
+func (c RegistryConfig) collectsGarbage() bool {
+	return (c.GC == registryGCEnabled || c.GC == registryGCDryRun) && strings.Contains(c.PathTemplate, "{environment}")
+}
+
+// ensureRegistryCA publishes the registry CA bundle in namespace for build and signing Jobs,
+// or deletes it once no bundle is configured
+func ensureRegistryCA(ctx context.Context, c client.Client, namespace string, cfg RegistryConfig) error {
+	configMap := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: registryCAConfigMap, Namespace: namespace}}
+	if cfg.CABundle == "" {
+		return client.IgnoreNotFound(c.Delete(ctx, configMap))
+	}
+	configMap.Data = map[string]string{"ca.crt": cfg.CABundle}
+	return createOrReplace(ctx, c, configMap)
+}
+
+// applyRegistryCA has the containers of a build or signing pod trust the registry CA bundle:
+// kaniko for the registry host only, the other (Go) tools through SSL_CERT_DIR, which adds to
+// the system roots. The git clone does not talk to the registry.
+func applyRegistryCA(spec *corev1.PodSpec, cfg RegistryConfig) {
+	if cfg.CABundle == "" {
+		return
+	}
+	spec.Volumes = append(spec.Volumes, corev1.Volume{
+		Name: registryCAVolume,
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{LocalObjectReference: corev1.LocalObjectReference{Name: registryCAConfigMap}},
+		},
+	})
+	mount := corev1.VolumeMount{Name: registryCAVolume, MountPath: registryCAMountPath, ReadOnly: true}
+	apply := func(container *corev1.Container) {
+		switch {
+		case container.Name == "git-clone":
+			return
+		case container.Name == "kaniko" || strings.HasPrefix(container.Name, "kaniko-"):
+			// The kaniko image points SSL_CERT_DIR at its own roots
+			container.Args = append(container.Args, "--registry-certificate="+cfg.host()+"="+registryCAMountPath+"/ca.crt")
+		default:
+			container.Env = append(container.Env, corev1.EnvVar{Name: "SSL_CERT_DIR", Value: registryCAMountPath})
+		}
+		container.VolumeMounts = append(container.VolumeMounts, mount)
+	}
+	for i := range spec.InitContainers {
+		apply(&spec.InitContainers[i])
+	}
+	for i := range spec.Containers {
+		apply(&spec.Containers[i])
+	}
+}
@@ -0,0 +1,209 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+	"github.com/ncrmro/catalyst/operator/internal/registry"
+)
+
+// Registry garbage collection (REGISTRY_GC):
+// The images an environment pushed are tracked in status.builtImages (the current image set)
+// and status.deploymentHistory (every image set recorded). When a new image set is recorded,
+// images only used by the image sets beyond the REGISTRY_GC_KEEP most recent are deleted from
+// the registry and those sets dropped from the history. Deleting the environment deletes all
+// of its images. Images another environment of the project deploys or recorded, as after a
+// promotion (spec.promoteFrom), are kept. Registry errors are logged and retried with the
+// next build, never failing the environment.
+
+// staleImages returns the images of records that neither the kept records nor the current
+// image set use. An image is in use when its tag or its digest is, so a tag moved to a
+// rebuilt image is never deleted through the old digest.
+func staleImages(records, kept []catalystv1alpha1.DeploymentRecord, current []catalystv1alpha1.BuiltImage) []catalystv1alpha1.BuiltImage {
+	inUse := map[string]bool{}
+	use := func(images []catalystv1alpha1.BuiltImage) {
+		for _, image := range images {
+			inUse[image.Image] = true
+			if image.Digest != "" {
+				inUse[image.Digest] = true
+			}
+		}
+	}
+	use(current)
+	for _, record := range kept {
+		use(record.Images)
+	}
+
+	var stale []catalystv1alpha1.BuiltImage
+	for _, record := range records {
+		for _, image := range record.Images {
+			if inUse[image.Image] || (image.Digest != "" && inUse[image.Digest]) {
+				continue
+			}
+			stale = append(stale, image)
+			use([]catalystv1alpha1.BuiltImage{image})
+		}
+	}
+	return stale
+}
+
+// imagesUsedElsewhere returns the tags and digests of the images the other Environments of
+// the project deploy or recorded
+func (r *EnvironmentReconciler) imagesUsedElsewhere(ctx context.Context, env *catalystv1alpha1.Environment) (map[string]bool, error) {
+	envs := &catalystv1alpha1.EnvironmentList{}
+	if err := r.List(ctx, envs, client.InNamespace(env.Namespace)); err != nil {
+		return nil, err
+	}
+	inUse := map[string]bool{}
+	for _, other := range envs.Items {
+		if other.Name == env.Name || other.Spec.ProjectRef.Name != env.Spec.ProjectRef.Name {
+			continue
+		}
+		images := slices.Clone(other.Status.BuiltImages)
+		for _, record := range other.Status.DeploymentHistory {
+			images = append(images, record.Images...)
+		}
+		if other.Status.Promotion != nil {
+			images = append(images, other.Status.Promotion.Images...)
+		}
+		for _, image := range images {
+			inUse[image.Image] = true
+			if image.Digest != "" {
+				inUse[image.Digest] = true
+			}
+		}
+	}
+	return inUse, nil
+}
+
+// withoutImagesUsedElsewhere drops the images other environments use from images
+func (r *EnvironmentReconciler) withoutImagesUsedElsewhere(ctx context.Context, env *catalystv1alpha1.Environment, images []catalystv1alpha1.BuiltImage) ([]catalystv1alpha1.BuiltImage, error) {
+	if len(images) == 0 {
+		return nil, nil
+	}
+	inUse, err := r.imagesUsedElsewhere(ctx, env)
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(images, func(image catalystv1alpha1.BuiltImage) bool {
+		return inUse[image.Image] || (image.Digest != "" && inUse[image.Digest])
+	}), nil
+}
+
+// imageDeleter returns the client deleting images with the project's registry credentials
+func (r *EnvironmentReconciler) imageDeleter(ctx context.Context, project *catalystv1alpha1.Project, cfg RegistryConfig) (registry.Deleter, error) {
+	if r.ImageDeleter != nil {
+		return r.ImageDeleter, nil
+	}
+	var credentials map[string]registry.Credentials
+	secret := &corev1.Secret{}
+	err := r.Get(ctx, client.ObjectKey{Name: cfg.SecretName, Namespace: project.Namespace}, secret)
+	switch {
+	case apierrors.IsNotFound(err):
+		// Registries without authentication, like the in-cluster registry
+	case err != nil:
+		return nil, err
+	default:
+		if credentials, err = registry.CredentialsFromDockerConfig(secret.Data[corev1.DockerConfigJsonKey]); err != nil {
+			return nil, fmt.Errorf("registry credentials %s/%s: %w", project.Namespace, cfg.SecretName, err)
+		}
+	}
+	deleter := registry.NewClient(credentials, cfg.Insecure)
+	if cfg.CABundle != "" {
+		if err := deleter.TrustCABundle([]byte(cfg.CABundle)); err != nil {
+			return nil, withFailureReason(catalystv1alpha1.FailureReasonConfigInvalid, fmt.Errorf("registry CA bundle: %w", err))
+		}
+	}
+	return deleter, nil
+}
+
+// deleteImages deletes images from the registry, or logs them in dry-run mode
+func (r *EnvironmentReconciler) deleteImages(ctx context.Context, project *catalystv1alpha1.Project, cfg RegistryConfig, images []catalystv1alpha1.BuiltImage) error {
+	log := logf.FromContext(ctx)
+	if len(images) == 0 {
+		return nil
+	}
+	deleter, err := r.imageDeleter(ctx, project, cfg)
+	if err != nil {
+		return err
+	}
+	for _, built := range images {
+		image, err := registry.ParseImage(built.Image)
+		if err != nil {
+			return err
+		}
+		image.Digest = built.Digest
+		if cfg.GC == registryGCDryRun {
+			log.Info("Would delete stale image (REGISTRY_GC=dry-run)", "image", image.String())
+			continue
+		}
+		if err := deleter.Delete(ctx, image); err != nil {
+			return fmt.Errorf("failed to delete image %s: %w", image, err)
+		}
+		log.Info("Deleted stale image", "image", image.String())
+	}
+	return nil
+}
+
+// pruneImageHistory deletes the images of the image sets in previous or history beyond the
+// REGISTRY_GC_KEEP most recent of history, and returns the history without them. The
+// history is returned unchanged in dry-run mode or when a deletion fails.
+func (r *EnvironmentReconciler) pruneImageHistory(ctx context.Context, env *catalystv1alpha1.Environment, project *catalystv1alpha1.Project, previous, history []catalystv1alpha1.DeploymentRecord) []catalystv1alpha1.DeploymentRecord {
+	cfg := currentRegistryConfig()
+	if !cfg.collectsGarbage() {
+		return history
+	}
+	kept := history[:min(cfg.GCKeep, len(history))]
+	stale, err := r.withoutImagesUsedElsewhere(ctx, env, staleImages(slices.Concat(previous, history), kept, env.Status.BuiltImages))
+	if err == nil {
+		err = r.deleteImages(ctx, project, cfg, stale)
+	}
+	if err != nil {
+		logf.FromContext(ctx).Error(err, "Failed to delete stale images; retrying with the next build")
+		return history
+	}
+	if cfg.GC == registryGCDryRun {
+		return history
+	}
+	return kept
+}
+
+// deleteEnvironmentImages deletes every image the environment pushed. Failures are logged:
+// a registry refusing deletes must not block the environment's deletion.
+func (r *EnvironmentReconciler) deleteEnvironmentImages(ctx context.Context, env *catalystv1alpha1.Environment, project *catalystv1alpha1.Project) {
+	cfg := currentRegistryConfig()
+	if !cfg.collectsGarbage() {
+		return
+	}
+	images, err := r.withoutImagesUsedElsewhere(ctx, env, staleImages(slices.Concat(env.Status.DeploymentHistory,
+		[]catalystv1alpha1.DeploymentRecord{{Images: env.Status.BuiltImages}}), nil, nil))
+	if err == nil {
+		err = r.deleteImages(ctx, project, cfg, images)
+	}
+	if err != nil {
+		logf.FromContext(ctx).Error(err, "Failed to delete environment images")
+	}
+}
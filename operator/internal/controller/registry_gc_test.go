@@ -0,0 +1,126 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+	"github.com/ncrmro/catalyst/operator/internal/registry"
+)
+
+// fakeImageDeleter records deleted images
+type fakeImageDeleter struct {
+	deleted []string
+	err     error
+}
+
+func (f *fakeImageDeleter) Delete(_ context.Context, image registry.Image) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.deleted = append(f.deleted, image.String())
+	return nil
+}
+
+// gcReconciler deletes images through deleter, with the other Environments of the namespace in its client
+func gcReconciler(t *testing.T, deleter *fakeImageDeleter, envs ...client.Object) *EnvironmentReconciler {
+	c := newFakeClientBuilder().WithObjects(envs...).Build()
+	return &EnvironmentReconciler{Client: c, Scheme: testScheme, ImageDeleter: deleter}
+}
+
+func imageRecord(images ...string) catalystv1alpha1.DeploymentRecord {
+	record := catalystv1alpha1.DeploymentRecord{}
+	for _, image := range images {
+		record.Images = append(record.Images, catalystv1alpha1.BuiltImage{Name: "web", Image: image, Digest: "sha256:" + image[len(image)-1:]})
+	}
+	return record
+}
+
+func TestStaleImages(t *testing.T) {
+	records := []catalystv1alpha1.DeploymentRecord{
+		imageRecord("reg/web:c"),
+		imageRecord("reg/web:b"),
+		imageRecord("reg/web:a"),
+		imageRecord("reg/web:a"),
+	}
+	// A rebuild of the kept tag with a new digest keeps the old digest
+	rebuilt := catalystv1alpha1.DeploymentRecord{Images: []catalystv1alpha1.BuiltImage{{Name: "web", Image: "reg/web:c", Digest: "sha256:old"}}}
+
+	stale := staleImages(append(records, rebuilt), records[:1], nil)
+	require.Len(t, stale, 2)
+	assert.Equal(t, "reg/web:b", stale[0].Image)
+	assert.Equal(t, "reg/web:a", stale[1].Image)
+
+	assert.Len(t, staleImages(records, records[:1], records[1].Images), 1, "the current image set is kept")
+}
+
+func TestPruneImageHistory(t *testing.T) {
+	t.Setenv("REGISTRY_GC", "enabled")
+	t.Setenv("REGISTRY_GC_KEEP", "2")
+	deleter := &fakeImageDeleter{}
+	r := gcReconciler(t, deleter)
+	ctx := context.Background()
+	project := &catalystv1alpha1.Project{ObjectMeta: metav1.ObjectMeta{Name: "shop", Namespace: "team"}}
+	registryHost := currentRegistryConfig().Endpoint
+
+	previous := []catalystv1alpha1.DeploymentRecord{
+		imageRecord(registryHost + "/shop/web-pr-1:b"),
+		imageRecord(registryHost + "/shop/web-pr-1:a"),
+	}
+	current := imageRecord(registryHost + "/shop/web-pr-1:c")
+	env := &catalystv1alpha1.Environment{Status: catalystv1alpha1.EnvironmentStatus{BuiltImages: current.Images}}
+	history := append([]catalystv1alpha1.DeploymentRecord{current}, previous...)
+
+	pruned := r.pruneImageHistory(ctx, env, project, previous, history)
+	assert.Equal(t, history[:2], pruned)
+	assert.Equal(t, []string{registryHost + "/shop/web-pr-1:a@sha256:a"}, deleter.deleted)
+
+	// Failed deletes keep the images tracked for the next build
+	deleter.err = errors.New("registry does not allow deleting manifests")
+	assert.Equal(t, history, r.pruneImageHistory(ctx, env, project, previous, history))
+
+	t.Setenv("REGISTRY_GC", "dry-run")
+	deleter.err = nil
+	deleter.deleted = nil
+	assert.Equal(t, history, r.pruneImageHistory(ctx, env, project, previous, history))
+	assert.Empty(t, deleter.deleted)
+}
+
+func TestDeleteEnvironmentImages(t *testing.T) {
+	t.Setenv("REGISTRY_GC", "enabled")
+	deleter := &fakeImageDeleter{}
+	r := gcReconciler(t, deleter)
+	ctx := context.Background()
+	project := &catalystv1alpha1.Project{ObjectMeta: metav1.ObjectMeta{Name: "shop", Namespace: "team"}}
+	env := &catalystv1alpha1.Environment{Status: catalystv1alpha1.EnvironmentStatus{
+		BuiltImages:       imageRecord("ghcr.io/acme/shop/web-pr-1:b").Images,
+		DeploymentHistory: []catalystv1alpha1.DeploymentRecord{imageRecord("ghcr.io/acme/shop/web-pr-1:b"), imageRecord("ghcr.io/acme/shop/web-pr-1:a")},
+	}}
+
+	r.deleteEnvironmentImages(ctx, env, project)
+	assert.Equal(t, []string{"ghcr.io/acme/shop/web-pr-1:b@sha256:b", "ghcr.io/acme/shop/web-pr-1:a@sha256:a"}, deleter.deleted)
+
+	// Images promoted to another environment of the project are kept
+	env.ObjectMeta = metav1.ObjectMeta{Name: "pr-1", Namespace: "team"}
+	env.Spec.ProjectRef.Name = "shop"
+	staging := &catalystv1alpha1.Environment{
+		ObjectMeta: metav1.ObjectMeta{Name: "staging", Namespace: "team"},
+		Spec:       catalystv1alpha1.EnvironmentSpec{ProjectRef: catalystv1alpha1.ProjectReference{Name: "shop"}},
+		Status:     catalystv1alpha1.EnvironmentStatus{BuiltImages: imageRecord("ghcr.io/acme/shop/web-pr-1:b").Images},
+	}
+	deleter.deleted = nil
+	gcReconciler(t, deleter, env, staging).deleteEnvironmentImages(ctx, env, project)
+	assert.Equal(t, []string{"ghcr.io/acme/shop/web-pr-1:a@sha256:a"}, deleter.deleted)
+
+	// Repositories shared between environments are left alone
+	t.Setenv("REGISTRY_PATH_TEMPLATE", "{project}/{build}")
+	deleter.deleted = nil
+	r.deleteEnvironmentImages(ctx, env, project)
+	assert.Empty(t, deleter.deleted)
+}
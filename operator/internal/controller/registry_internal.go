@@ -0,0 +1,269 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// The in-cluster registry: without REGISTRY_ENDPOINT, builds push to registryInternal and the
+// operator runs that registry itself, a registry:2 Deployment storing images on a PVC behind
+// the Service "registry" in the default namespace. REGISTRY_MIRRORS adds a pull-through cache
+// per upstream registry next to it, which builds pull base images through. The objects are
+// re-applied periodically; objects of the same name the operator did not create are left
+// alone, so an existing registry keeps serving.
+
+const (
+	internalRegistryName      = "registry"
+	internalRegistryNamespace = "default"
+	internalRegistryPort      = 5000
+	registryMirrorPrefix      = "registry-mirror-"
+	registryDataPath          = "/var/lib/registry"
+
+	defaultRegistryImage       = "registry:2.8.3"
+	defaultRegistryStorageSize = "20Gi"
+	dockerHubRegistry          = "docker.io"
+
+	// internalRegistryResyncInterval is how often the registry objects are re-applied
+	internalRegistryResyncInterval = 5 * time.Minute
+)
+
+// errUnmanagedRegistry reports a registry object the operator did not create
+var errUnmanagedRegistry = errors.New("not managed by the operator")
+
+// InternalRegistry provisions the in-cluster registry and its pull-through caches when the
+// registry configuration asks for a managed registry
+type InternalRegistry struct {
+	Client client.Client
+}
+
+// Start applies the registry objects until ctx is done
+func (r *InternalRegistry) Start(ctx context.Context) error {
+	cfg := currentRegistryConfig()
+	if !cfg.Managed {
+		return nil
+	}
+	log := logf.FromContext(ctx).WithName("internal-registry")
+	ticker := time.NewTicker(internalRegistryResyncInterval)
+	defer ticker.Stop()
+	for {
+		if err := r.ensure(ctx, cfg); err != nil {
+			log.Error(err, "Failed to provision the in-cluster registry")
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// NeedLeaderElection provisions from the leader only
+func (r *InternalRegistry) NeedLeaderElection() bool {
+	return true
+}
+
+// ensure applies the registry and mirror objects, skipping the ones of another owner
+func (r *InternalRegistry) ensure(ctx context.Context, cfg RegistryConfig) error {
+	log := logf.FromContext(ctx)
+	size, err := resource.ParseQuantity(cfg.StorageSize)
+	if err != nil {
+		return fmt.Errorf("invalid REGISTRY_STORAGE_SIZE %q: %w", cfg.StorageSize, err)
+	}
+	instances := map[string][]corev1.EnvVar{internalRegistryName: nil}
+	if cfg.collectsGarbage() {
+		// Image deletes of REGISTRY_GC
+		instances[internalRegistryName] = []corev1.EnvVar{{Name: "REGISTRY_STORAGE_DELETE_ENABLED", Value: "true"}}
+	}
+	for _, upstream := range cfg.Mirrors {
+		instances[registryMirrorName(upstream)] = []corev1.EnvVar{{Name: "REGISTRY_PROXY_REMOTEURL", Value: registryMirrorRemoteURL(upstream)}}
+	}
+
+	for name, env := range instances {
+		objects := []client.Object{
+			desiredRegistryPVC(name, size, cfg.StorageClass),
+			desiredRegistryService(name),
+			desiredRegistryDeployment(name, cfg.Image, env),
+		}
+		for _, obj := range objects {
+			if err := r.apply(ctx, obj); errors.Is(err, errUnmanagedRegistry) {
+				log.Info("Leaving registry object alone", "kind", fmt.Sprintf("%T", obj), "name", obj.GetName(), "namespace", obj.GetNamespace())
+			} else if err != nil {
+				return fmt.Errorf("failed to apply %s: %w", obj.GetName(), err)
+			}
+		}
+	}
+	return nil
+}
+
+// apply creates obj, or updates the Deployment the operator created before. Claims and
+// Services are only created, their specs being largely immutable.
+func (r *InternalRegistry) apply(ctx context.Context, obj client.Object) error {
+	existing := obj.DeepCopyObject().(client.Object)
+	if err := r.Client.Get(ctx, client.ObjectKeyFromObject(obj), existing); apierrors.IsNotFound(err) {
+		return r.Client.Create(ctx, obj)
+	} else if err != nil {
+		return err
+	}
+	if existing.GetLabels()["app.kubernetes.io/managed-by"] != "catalyst-operator" {
+		return errUnmanagedRegistry
+	}
+	deployment, ok := obj.(*appsv1.Deployment)
+	if !ok {
+		return nil
+	}
+	current := existing.(*appsv1.Deployment)
+	current.Labels = deployment.Labels
+	current.Spec = deployment.Spec
+	return r.Client.Update(ctx, current)
+}
+
+// registryLabels select the pods of a registry instance
+func registryLabels(name string) map[string]string {
+	return map[string]string{
+		"app.kubernetes.io/name":       name,
+		"app.kubernetes.io/component":  "registry",
+		"app.kubernetes.io/managed-by": "catalyst-operator",
+	}
+}
+
+func desiredRegistryPVC(name string, size resource.Quantity, storageClass string) *corev1.PersistentVolumeClaim {
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: internalRegistryNamespace, Labels: registryLabels(name)},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+			Resources:   corev1.VolumeResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceStorage: size}},
+		},
+	}
+	if storageClass != "" {
+		pvc.Spec.StorageClassName = &storageClass
+	}
+	return pvc
+}
+
+func desiredRegistryService(name string) *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: internalRegistryNamespace, Labels: registryLabels(name)},
+		Spec: corev1.ServiceSpec{
+			Selector: registryLabels(name),
+			Ports: []corev1.ServicePort{{
+				Name:       "registry",
+				Port:       internalRegistryPort,
+				TargetPort: intstr.FromInt32(internalRegistryPort),
+				Protocol:   corev1.ProtocolTCP,
+			}},
+		},
+	}
+}
+
+func desiredRegistryDeployment(name, image string, env []corev1.EnvVar) *appsv1.Deployment {
+	labels := registryLabels(name)
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: internalRegistryNamespace, Labels: labels},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: ptr(int32(1)),
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			// The data volume is ReadWriteOnce
+			Strategy: appsv1.DeploymentStrategy{Type: appsv1.RecreateDeploymentStrategyType},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{
+						Name:  "registry",
+						Image: image,
+						Env:   env,
+						Ports: []corev1.ContainerPort{{Name: "registry", ContainerPort: internalRegistryPort, Protocol: corev1.ProtocolTCP}},
+						ReadinessProbe: &corev1.Probe{
+							ProbeHandler: corev1.ProbeHandler{HTTPGet: &corev1.HTTPGetAction{Path: "/v2/", Port: intstr.FromInt32(internalRegistryPort)}},
+						},
+						Resources: corev1.ResourceRequirements{
+							Requests: corev1.ResourceList{
+								corev1.ResourceCPU:    resource.MustParse("50m"),
+								corev1.ResourceMemory: resource.MustParse("128Mi"),
+							},
+							Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("512Mi")},
+						},
+						VolumeMounts: []corev1.VolumeMount{{Name: "data", MountPath: registryDataPath}},
+					}},
+					Volumes: []corev1.Volume{{
+						Name:         "data",
+						VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: name}},
+					}},
+				},
+			},
+		},
+	}
+	applyPodSecurity(&deployment.Spec.Template.Spec)
+	return deployment
+}
+
+var invalidRegistryNameChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// registryMirrorName returns the name of the pull-through cache of an upstream registry
+func registryMirrorName(upstream string) string {
+	return registryMirrorPrefix + strings.Trim(invalidRegistryNameChars.ReplaceAllString(strings.ToLower(upstream), "-"), "-")
+}
+
+// registryMirrorRemoteURL returns the URL a pull-through cache proxies
+func registryMirrorRemoteURL(upstream string) string {
+	if upstream == dockerHubRegistry {
+		return "https://registry-1.docker.io"
+	}
+	return "https://" + upstream
+}
+
+// registryMirrorHost returns the in-cluster address of the pull-through cache of an upstream
+func registryMirrorHost(upstream string) string {
+	return fmt.Sprintf("%s.%s.svc.cluster.local:%d", registryMirrorName(upstream), internalRegistryNamespace, internalRegistryPort)
+}
+
+// applyRegistryMirrors has the kaniko container of a build pull base images through the
+// pull-through caches, over plain HTTP
+func applyRegistryMirrors(spec *corev1.PodSpec, cfg RegistryConfig) {
+	if !cfg.Managed || len(cfg.Mirrors) == 0 {
+		return
+	}
+	for i := range spec.Containers {
+		container := &spec.Containers[i]
+		if container.Name != "kaniko" {
+			continue
+		}
+		for _, upstream := range cfg.Mirrors {
+			host := registryMirrorHost(upstream)
+			if upstream == dockerHubRegistry {
+				container.Args = append(container.Args, "--registry-mirror="+host)
+			} else {
+				container.Args = append(container.Args, "--registry-map="+upstream+"="+host)
+			}
+			container.Args = append(container.Args, "--insecure-registry="+host)
+		}
+	}
+}
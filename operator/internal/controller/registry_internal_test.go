@@ -0,0 +1,106 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestCurrentRegistryConfig_Managed(t *testing.T) {
+	cfg := currentRegistryConfig()
+	assert.True(t, cfg.Managed, "the in-cluster registry is managed by default")
+	assert.Equal(t, defaultRegistryImage, cfg.Image)
+	assert.Equal(t, defaultRegistryStorageSize, cfg.StorageSize)
+	assert.Empty(t, cfg.Mirrors)
+
+	t.Setenv("REGISTRY_MIRRORS", "docker.io, ghcr.io,")
+	t.Setenv("REGISTRY_STORAGE_CLASS", "fast")
+	cfg = currentRegistryConfig()
+	assert.Equal(t, []string{"docker.io", "ghcr.io"}, cfg.Mirrors)
+	assert.Equal(t, "fast", cfg.StorageClass)
+
+	t.Setenv("REGISTRY_MANAGED", "false")
+	assert.False(t, currentRegistryConfig().Managed)
+
+	t.Setenv("REGISTRY_MANAGED", "")
+	t.Setenv("REGISTRY_ENDPOINT", "ghcr.io/acme")
+	assert.False(t, currentRegistryConfig().Managed, "external registries are not managed")
+}
+
+func TestInternalRegistryEnsure(t *testing.T) {
+	t.Setenv("REGISTRY_MIRRORS", "docker.io,ghcr.io")
+	t.Setenv("REGISTRY_GC", registryGCEnabled)
+	c := newFakeClientBuilder().Build()
+	r := &InternalRegistry{Client: c}
+	ctx := context.Background()
+
+	require.NoError(t, r.ensure(ctx, currentRegistryConfig()))
+
+	deployment := &appsv1.Deployment{}
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "registry", Namespace: "default"}, deployment))
+	assert.Equal(t, appsv1.RecreateDeploymentStrategyType, deployment.Spec.Strategy.Type)
+	container := deployment.Spec.Template.Spec.Containers[0]
+	assert.Equal(t, defaultRegistryImage, container.Image)
+	assert.Equal(t, []corev1.EnvVar{{Name: "REGISTRY_STORAGE_DELETE_ENABLED", Value: "true"}}, container.Env)
+	assert.True(t, hasMountPath(container.VolumeMounts, registryDataPath))
+	assert.True(t, *deployment.Spec.Template.Spec.SecurityContext.RunAsNonRoot)
+
+	service := &corev1.Service{}
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "registry", Namespace: "default"}, service))
+	assert.Equal(t, int32(internalRegistryPort), service.Spec.Ports[0].Port)
+	assert.Equal(t, registryInternal, service.Name+"."+service.Namespace+".svc.cluster.local:5000")
+	pvc := &corev1.PersistentVolumeClaim{}
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "registry", Namespace: "default"}, pvc))
+	assert.Equal(t, "20Gi", pvc.Spec.Resources.Requests.Storage().String())
+
+	mirror := &appsv1.Deployment{}
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "registry-mirror-docker-io", Namespace: "default"}, mirror))
+	assert.Equal(t, []corev1.EnvVar{{Name: "REGISTRY_PROXY_REMOTEURL", Value: "https://registry-1.docker.io"}}, mirror.Spec.Template.Spec.Containers[0].Env)
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "registry-mirror-ghcr-io", Namespace: "default"}, mirror))
+	assert.Equal(t, []corev1.EnvVar{{Name: "REGISTRY_PROXY_REMOTEURL", Value: "https://ghcr.io"}}, mirror.Spec.Template.Spec.Containers[0].Env)
+
+	// Re-applying restores edits
+	deployment.Spec.Template.Spec.Containers[0].Image = "registry:edited"
+	require.NoError(t, c.Update(ctx, deployment))
+	require.NoError(t, r.ensure(ctx, currentRegistryConfig()))
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(deployment), deployment))
+	assert.Equal(t, defaultRegistryImage, deployment.Spec.Template.Spec.Containers[0].Image)
+}
+
+func TestInternalRegistryEnsure_Unmanaged(t *testing.T) {
+	existing := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "registry", Namespace: "default"},
+		Spec: appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "registry", Image: "registry:2.7"}},
+		}}},
+	}
+	c := newFakeClientBuilder().WithObjects(existing).Build()
+	r := &InternalRegistry{Client: c}
+	ctx := context.Background()
+
+	require.NoError(t, r.ensure(ctx, currentRegistryConfig()))
+	deployment := &appsv1.Deployment{}
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(existing), deployment))
+	assert.Equal(t, "registry:2.7", deployment.Spec.Template.Spec.Containers[0].Image, "a registry of another owner is left alone")
+}
+
+func TestApplyRegistryMirrors(t *testing.T) {
+	spec := &corev1.PodSpec{Containers: []corev1.Container{{Name: "kaniko", Args: []string{"--cache=true"}}}}
+	applyRegistryMirrors(spec, RegistryConfig{Mirrors: []string{"docker.io"}})
+	assert.Equal(t, []string{"--cache=true"}, spec.Containers[0].Args, "mirrors of an unmanaged registry are not used")
+
+	applyRegistryMirrors(spec, RegistryConfig{Managed: true, Mirrors: []string{"docker.io", "ghcr.io"}})
+	assert.Equal(t, []string{
+		"--cache=true",
+		"--registry-mirror=registry-mirror-docker-io.default.svc.cluster.local:5000",
+		"--insecure-registry=registry-mirror-docker-io.default.svc.cluster.local:5000",
+		"--registry-map=ghcr.io=registry-mirror-ghcr-io.default.svc.cluster.local:5000",
+		"--insecure-registry=registry-mirror-ghcr-io.default.svc.cluster.local:5000",
+	}, spec.Containers[0].Args)
+}
@@ -18,62 +18,113 @@ package controller
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
 
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
 )
 
-// ensureRegistryCredentials copies registry secrets from source namespace to target namespace
-// and patches the default ServiceAccount to use them for image pulling.
-func (r *EnvironmentReconciler) ensureRegistryCredentials(ctx context.Context, sourceNs, targetNs string) error {
+// buildCredentialsSecretName is the Secret build Jobs read registry credentials from when the
+// project has imagePullSecrets: those merged with the push credentials of the build registry
+const buildCredentialsSecretName = "build-registry-credentials"
+
+// registryCredentialsLabel marks the registry Secrets copied into environment namespaces, so
+// those the project no longer references are pruned
+const registryCredentialsLabel = "catalyst.dev/registry-credentials"
+
+// ensureRegistryCredentials copies the registry credentials Secret and the imagePullSecrets of
+// the project from its namespace to the target namespace, merges them into the build
+// credentials, and patches the default ServiceAccount to use them for image pulling. Only
+// dockerconfigjson Secrets are copied; copies the project no longer references are deleted
+// and dropped from the ServiceAccount.
+func (r *EnvironmentReconciler) ensureRegistryCredentials(ctx context.Context, project *catalystv1alpha1.Project, targetNs string) error {
 	log := logf.FromContext(ctx)
+	secretName := currentRegistryConfig().SecretName
 
-	// 1. Copy Secret
-	// Check if secret exists in source
+	// 1. Copy Secrets; the registry credentials go last, so they win for the build registry
+	var copied []*corev1.Secret
+	for _, ref := range project.Spec.ImagePullSecrets {
+		secret := &corev1.Secret{}
+		if err := r.Get(ctx, client.ObjectKey{Name: ref.Name, Namespace: project.Namespace}, secret); err != nil {
+			if apierrors.IsNotFound(err) {
+				return withFailureReason(catalystv1alpha1.FailureReasonConfigInvalid,
+					fmt.Errorf("image pull Secret %s/%s not found", project.Namespace, ref.Name))
+			}
+			return err
+		}
+		if err := validateDockerConfigSecret(secret); err != nil {
+			return withFailureReason(catalystv1alpha1.FailureReasonConfigInvalid, err)
+		}
+		copied = append(copied, secret)
+	}
 	sourceSecret := &corev1.Secret{}
-	err := r.Get(ctx, client.ObjectKey{Name: registrySecretName, Namespace: sourceNs}, sourceSecret)
-	if err != nil {
-		if apierrors.IsNotFound(err) {
-			// No registry credentials configured for this project. Not an error.
-			// Users might be using public images or node-local registry.
-			log.Info("No registry credentials found in project namespace", "namespace", sourceNs)
-			return nil
+	if err := r.Get(ctx, client.ObjectKey{Name: secretName, Namespace: project.Namespace}, sourceSecret); err == nil {
+		if err := validateDockerConfigSecret(sourceSecret); err != nil {
+			return withFailureReason(catalystv1alpha1.FailureReasonConfigInvalid, err)
 		}
+		copied = append(copied, sourceSecret)
+	} else if !apierrors.IsNotFound(err) {
 		return err
+	} else if len(copied) == 0 {
+		// No registry credentials configured for this project. Not an error.
+		// Users might be using public images or node-local registry.
+		log.Info("No registry credentials found in project namespace", "namespace", project.Namespace)
 	}
 
-	// Check if secret exists in target
-	targetSecret := &corev1.Secret{}
-	err = r.Get(ctx, client.ObjectKey{Name: registrySecretName, Namespace: targetNs}, targetSecret)
-	if err != nil && !apierrors.IsNotFound(err) {
-		return err
+	desired := map[string]bool{}
+	for _, secret := range copied {
+		desired[secret.Name] = true
+		if err := createOrReplace(ctx, r.Client, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: secret.Name, Namespace: targetNs, Labels: map[string]string{registryCredentialsLabel: "true"}},
+			Data:       secret.Data,
+			Type:       secret.Type,
+		}); err != nil {
+			return err
+		}
 	}
 
-	if apierrors.IsNotFound(err) {
-		// Create copy
-		log.Info("Copying registry credentials to target namespace", "namespace", targetNs)
-		newSecret := &corev1.Secret{
-			ObjectMeta: metav1.ObjectMeta{
-				Name:      registrySecretName,
-				Namespace: targetNs,
-			},
-			Data: sourceSecret.Data,
-			Type: sourceSecret.Type,
+	// 1b. Build credentials: kaniko reads a single docker config for pulls and pushes
+	if len(project.Spec.ImagePullSecrets) > 0 {
+		config, err := mergeDockerConfigs(copied)
+		if err != nil {
+			return withFailureReason(catalystv1alpha1.FailureReasonConfigInvalid, err)
 		}
-		if err := r.Create(ctx, newSecret); err != nil {
+		desired[buildCredentialsSecretName] = true
+		if err := createOrReplace(ctx, r.Client, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: buildCredentialsSecretName, Namespace: targetNs, Labels: map[string]string{registryCredentialsLabel: "true"}},
+			Type:       corev1.SecretTypeDockerConfigJson,
+			Data:       map[string][]byte{corev1.DockerConfigJsonKey: config},
+		}); err != nil {
 			return err
 		}
-	} else {
-		// Update existing (sync)
-		targetSecret.Data = sourceSecret.Data
-		targetSecret.Type = sourceSecret.Type
-		if err := r.Update(ctx, targetSecret); err != nil {
-			return err
+	}
+
+	// 1c. Prune the copies the project no longer references
+	existing := &corev1.SecretList{}
+	if err := r.List(ctx, existing, client.InNamespace(targetNs), client.MatchingLabels{registryCredentialsLabel: "true"}); err != nil {
+		return err
+	}
+	pruned := map[string]bool{}
+	for i := range existing.Items {
+		if name := existing.Items[i].Name; !desired[name] {
+			log.Info("Deleting registry credentials no longer referenced", "namespace", targetNs, "secret", name)
+			if err := r.Delete(ctx, &existing.Items[i]); client.IgnoreNotFound(err) != nil {
+				return err
+			}
+			pruned[name] = true
 		}
 	}
+	if len(copied) == 0 && len(pruned) == 0 {
+		return nil
+	}
 
 	// 2. Patch ServiceAccount
 	sa := &corev1.ServiceAccount{}
@@ -82,18 +133,16 @@ func (r *EnvironmentReconciler) ensureRegistryCredentials(ctx context.Context, s
 		return err
 	}
 
-	// Check if already has imagePullSecret
-	hasSecret := false
-	for _, ref := range sa.ImagePullSecrets {
-		if ref.Name == registrySecretName {
-			hasSecret = true
-			break
+	refs := slices.DeleteFunc(slices.Clone(sa.ImagePullSecrets), func(ref corev1.LocalObjectReference) bool { return pruned[ref.Name] })
+	for _, secret := range copied {
+		if !slices.ContainsFunc(refs, func(ref corev1.LocalObjectReference) bool { return ref.Name == secret.Name }) {
+			refs = append(refs, corev1.LocalObjectReference{Name: secret.Name})
 		}
 	}
 
-	if !hasSecret {
+	if !slices.Equal(refs, sa.ImagePullSecrets) {
 		log.Info("Patching default ServiceAccount with imagePullSecrets", "namespace", targetNs)
-		sa.ImagePullSecrets = append(sa.ImagePullSecrets, corev1.LocalObjectReference{Name: registrySecretName})
+		sa.ImagePullSecrets = refs
 		if err := r.Update(ctx, sa); err != nil {
 			return err
 		}
@@ -101,3 +150,38 @@ func (r *EnvironmentReconciler) ensureRegistryCredentials(ctx context.Context, s
 
 	return nil
 }
+
+// validateDockerConfigSecret checks that a registry Secret is a valid dockerconfigjson Secret,
+// the only kind kubelets and the build tools read
+func validateDockerConfigSecret(secret *corev1.Secret) error {
+	if secret.Type != corev1.SecretTypeDockerConfigJson {
+		return fmt.Errorf("registry Secret %s/%s has type %q, expected %s", secret.Namespace, secret.Name, secret.Type, corev1.SecretTypeDockerConfigJson)
+	}
+	var config struct {
+		Auths map[string]json.RawMessage `json:"auths"`
+	}
+	if err := json.Unmarshal(secret.Data[corev1.DockerConfigJsonKey], &config); err != nil {
+		return fmt.Errorf("registry Secret %s/%s: invalid docker config: %w", secret.Namespace, secret.Name, err)
+	}
+	return nil
+}
+
+// mergeDockerConfigs merges the registry auths of dockerconfigjson Secrets into one docker
+// config. Later Secrets win for registries in several.
+func mergeDockerConfigs(secrets []*corev1.Secret) ([]byte, error) {
+	auths := map[string]json.RawMessage{}
+	for _, secret := range secrets {
+		data, ok := secret.Data[corev1.DockerConfigJsonKey]
+		if !ok {
+			return nil, fmt.Errorf("registry Secret %s has no %s key", secret.Name, corev1.DockerConfigJsonKey)
+		}
+		var config struct {
+			Auths map[string]json.RawMessage `json:"auths"`
+		}
+		if err := json.Unmarshal(data, &config); err != nil {
+			return nil, fmt.Errorf("registry Secret %s: invalid docker config: %w", secret.Name, err)
+		}
+		maps.Copy(auths, config.Auths)
+	}
+	return json.Marshal(map[string]interface{}{"auths": auths})
+}
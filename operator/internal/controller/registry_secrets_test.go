@@ -0,0 +1,105 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+func dockerConfigSecret(name, namespace, auths string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Type:       corev1.SecretTypeDockerConfigJson,
+		Data:       map[string][]byte{corev1.DockerConfigJsonKey: []byte(`{"auths":` + auths + `}`)},
+	}
+}
+
+func TestMergeDockerConfigs(t *testing.T) {
+	merged, err := mergeDockerConfigs([]*corev1.Secret{
+		dockerConfigSecret("base-images", "acme", `{"ghcr.io":{"auth":"base"},"registry.acme.dev":{"auth":"old"}}`),
+		dockerConfigSecret("registry-credentials", "acme", `{"registry.acme.dev":{"auth":"push"}}`),
+	})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"auths":{"ghcr.io":{"auth":"base"},"registry.acme.dev":{"auth":"push"}}}`, string(merged))
+
+	_, err = mergeDockerConfigs([]*corev1.Secret{{ObjectMeta: metav1.ObjectMeta{Name: "opaque"}, Data: map[string][]byte{"token": nil}}})
+	assert.ErrorContains(t, err, "registry Secret opaque has no .dockerconfigjson key")
+}
+
+func TestEnsureRegistryCredentials_ImagePullSecrets(t *testing.T) {
+	sa := &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: "default", Namespace: "acme-pr-1"}}
+	c := newFakeClientBuilder().WithObjects(
+		dockerConfigSecret("registry-credentials", "acme", `{"registry.acme.dev":{"auth":"push"}}`),
+		dockerConfigSecret("base-images", "acme", `{"ghcr.io":{"auth":"base"}}`),
+		sa,
+	).Build()
+	r := &EnvironmentReconciler{Client: c, Scheme: testScheme}
+	ctx := context.Background()
+	project := &catalystv1alpha1.Project{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "acme"},
+		Spec:       catalystv1alpha1.ProjectSpec{ImagePullSecrets: []corev1.LocalObjectReference{{Name: "base-images"}}},
+	}
+
+	require.NoError(t, r.ensureRegistryCredentials(ctx, project, "acme-pr-1"))
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(sa), sa))
+	assert.Equal(t, []corev1.LocalObjectReference{{Name: "base-images"}, {Name: "registry-credentials"}}, sa.ImagePullSecrets)
+	build := &corev1.Secret{}
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: buildCredentialsSecretName, Namespace: "acme-pr-1"}, build))
+	assert.Equal(t, corev1.SecretTypeDockerConfigJson, build.Type)
+	var config map[string]map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(build.Data[corev1.DockerConfigJsonKey], &config))
+	assert.Contains(t, config["auths"], "ghcr.io")
+	assert.Contains(t, config["auths"], "registry.acme.dev")
+
+	// Reconciling again leaves the ServiceAccount as it is
+	require.NoError(t, r.ensureRegistryCredentials(ctx, project, "acme-pr-1"))
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(sa), sa))
+	assert.Len(t, sa.ImagePullSecrets, 2)
+
+	project.Spec.ImagePullSecrets = append(project.Spec.ImagePullSecrets, corev1.LocalObjectReference{Name: "missing"})
+	err := r.ensureRegistryCredentials(ctx, project, "acme-pr-1")
+	assert.ErrorContains(t, err, "image pull Secret acme/missing not found")
+	assert.Equal(t, catalystv1alpha1.FailureReasonConfigInvalid, failureReasonOf(err, ""))
+}
+
+func TestEnsureRegistryCredentials_Prune(t *testing.T) {
+	sa := &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: "default", Namespace: "acme-pr-1"}, ImagePullSecrets: []corev1.LocalObjectReference{{Name: "own"}}}
+	c := newFakeClientBuilder().WithObjects(
+		dockerConfigSecret("registry-credentials", "acme", `{"registry.acme.dev":{"auth":"push"}}`),
+		dockerConfigSecret("base-images", "acme", `{"ghcr.io":{"auth":"base"}}`),
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "opaque", Namespace: "acme"}, Data: map[string][]byte{"token": []byte("x")}},
+		sa,
+	).Build()
+	r := &EnvironmentReconciler{Client: c, Scheme: testScheme}
+	ctx := context.Background()
+	project := &catalystv1alpha1.Project{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "acme"},
+		Spec:       catalystv1alpha1.ProjectSpec{ImagePullSecrets: []corev1.LocalObjectReference{{Name: "base-images"}}},
+	}
+	require.NoError(t, r.ensureRegistryCredentials(ctx, project, "acme-pr-1"))
+
+	// Dropping the imagePullSecret deletes its copy and the build credentials
+	project.Spec.ImagePullSecrets = nil
+	require.NoError(t, r.ensureRegistryCredentials(ctx, project, "acme-pr-1"))
+	for _, name := range []string{"base-images", buildCredentialsSecretName} {
+		assert.True(t, apierrors.IsNotFound(c.Get(ctx, client.ObjectKey{Name: name, Namespace: "acme-pr-1"}, &corev1.Secret{})), name)
+	}
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(sa), sa))
+	assert.Equal(t, []corev1.LocalObjectReference{{Name: "own"}, {Name: "registry-credentials"}}, sa.ImagePullSecrets, "references the operator did not add are kept")
+
+	// Only dockerconfigjson Secrets are copied
+	project.Spec.ImagePullSecrets = []corev1.LocalObjectReference{{Name: "opaque"}}
+	err := r.ensureRegistryCredentials(ctx, project, "acme-pr-1")
+	assert.ErrorContains(t, err, `registry Secret acme/opaque has type ""`)
+	assert.Equal(t, catalystv1alpha1.FailureReasonConfigInvalid, failureReasonOf(err, ""))
+	assert.True(t, apierrors.IsNotFound(c.Get(ctx, client.ObjectKey{Name: "opaque", Namespace: "acme-pr-1"}, &corev1.Secret{})))
+}
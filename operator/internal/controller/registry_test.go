@@ -0,0 +1,88 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+func TestCurrentRegistryConfig_Defaults(t *testing.T) {
+	cfg := currentRegistryConfig()
+
+	assert.Equal(t, registryInternal, cfg.Endpoint)
+	assert.Equal(t, registrySecretName, cfg.SecretName)
+	assert.True(t, cfg.Insecure)
+	assert.Equal(t, registryInternal+"/proj/web-pr-1:abc123", cfg.imageRef("proj", "web", "pr-1", "abc123"))
+}
+
+func TestCurrentRegistryConfig_External(t *testing.T) {
+	t.Setenv("REGISTRY_ENDPOINT", "ghcr.io/Acme/")
+	t.Setenv("REGISTRY_CREDENTIALS_SECRET", "ghcr-push")
+	t.Setenv("REGISTRY_PATH_TEMPLATE", "previews/{project}-{build}")
+
+	cfg := currentRegistryConfig()
+
+	assert.Equal(t, "ghcr.io/acme", cfg.Endpoint, "repository names are lowercase")
+	assert.Equal(t, "ghcr-push", cfg.SecretName)
+	assert.False(t, cfg.Insecure)
+	assert.Equal(t, "ghcr.io/acme/previews/proj-web:abc123", cfg.imageRef("proj", "web", "pr-1", "abc123"))
+}
+
+func TestDesiredBuildJob_RegistryOptions(t *testing.T) {
+	build := catalystv1alpha1.BuildSpec{Name: "web"}
+
+	job := desiredBuildJob("build-web", "ns", "ghcr.io/acme/web:1", "https://github.com/acme/app", "main", nil, build, "ghcr-push", false, nil, nil, nil)
+	kaniko := job.Spec.Template.Spec.Containers[0]
+	assert.NotContains(t, kaniko.Args, "--insecure")
+	assert.Equal(t, "ghcr-push", job.Spec.Template.Spec.Volumes[2].Secret.SecretName)
+
+	job = desiredBuildJob("build-web", "ns", "registry/web:1", "https://github.com/acme/app", "main", nil, build, "", true, nil, nil, nil)
+	assert.Contains(t, job.Spec.Template.Spec.Containers[0].Args, "--insecure")
+	assert.Len(t, job.Spec.Template.Spec.Volumes, 3) // Workspace, scripts and /tmp
+}
+
+func TestApplyRegistryCA(t *testing.T) {
+	build := catalystv1alpha1.BuildSpec{Name: "web", Platforms: []string{"linux/amd64", "linux/arm64"}}
+	job := desiredBuildJob("build-web", "ns", "harbor.acme.dev:8443/previews/web:1", "https://github.com/acme/app", "main", nil, build, "", false, nil, nil, nil)
+	spec := &job.Spec.Template.Spec
+
+	applyRegistryCA(spec, RegistryConfig{Endpoint: "harbor.acme.dev:8443/previews"})
+	assert.Len(t, spec.Volumes, 3, "no bundle, nothing mounted")
+
+	applyRegistryCA(spec, RegistryConfig{Endpoint: "harbor.acme.dev:8443/previews", CABundle: "PEM"})
+	assert.Equal(t, registryCAConfigMap, spec.Volumes[3].ConfigMap.Name)
+	for _, container := range spec.InitContainers {
+		if container.Name == "git-clone" {
+			assert.NotContains(t, container.VolumeMounts, corev1.VolumeMount{Name: registryCAVolume, MountPath: registryCAMountPath, ReadOnly: true})
+			continue
+		}
+		assert.Contains(t, container.Args, "--registry-certificate=harbor.acme.dev:8443="+registryCAMountPath+"/ca.crt", container.Name)
+		assert.Contains(t, container.VolumeMounts, corev1.VolumeMount{Name: registryCAVolume, MountPath: registryCAMountPath, ReadOnly: true})
+	}
+	index := spec.Containers[0]
+	assert.Equal(t, buildIndexContainer, index.Name)
+	assert.Contains(t, index.Env, corev1.EnvVar{Name: "SSL_CERT_DIR", Value: registryCAMountPath})
+	assert.Contains(t, index.VolumeMounts, corev1.VolumeMount{Name: registryCAVolume, MountPath: registryCAMountPath, ReadOnly: true})
+}
+
+func TestEnsureRegistryCA(t *testing.T) {
+	ctx := context.Background()
+	c := newFakeClientBuilder().Build()
+	key := client.ObjectKey{Name: registryCAConfigMap, Namespace: "env-ns"}
+
+	require.NoError(t, ensureRegistryCA(ctx, c, "env-ns", RegistryConfig{CABundle: "PEM"}))
+	configMap := &corev1.ConfigMap{}
+	require.NoError(t, c.Get(ctx, key, configMap))
+	assert.Equal(t, map[string]string{"ca.crt": "PEM"}, configMap.Data)
+
+	require.NoError(t, ensureRegistryCA(ctx, c, "env-ns", RegistryConfig{}))
+	assert.True(t, apierrors.IsNotFound(c.Get(ctx, key, configMap)), "removed with the bundle")
+	require.NoError(t, ensureRegistryCA(ctx, c, "env-ns", RegistryConfig{}))
+}
@@ -0,0 +1,548 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/yaml"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+	"github.com/ncrmro/catalyst/operator/internal/guardrails"
+)
+
+// Rendering:
+// The catalyst.dev/render annotation requests a rendering of what an Environment deploys: the
+// resolved config, the resources of its deployment mode (the compose translation, the Helm
+// chart or kustomization rendered from a checkout of the source, the GitOps objects) and the
+// guardrail violations they would cause. Nothing is applied and no builds run; built images
+// are the references the builds push to. The rendering is written to the ConfigMap
+// "<environment>-render" next to the Environment and recorded in status.render, and the
+// annotation is removed. With catalyst.dev/render-only set to "true" the rendering follows
+// every spec change and the environment deploys nothing until the annotation is removed, so
+// what catalyst will do can be reviewed before it does it.
+
+const (
+	// RenderAnnotation on an Environment requests a rendering; the operator removes it
+	RenderAnnotation = "catalyst.dev/render"
+	// RenderOnlyAnnotation set to "true" renders an Environment instead of deploying it
+	RenderOnlyAnnotation = "catalyst.dev/render-only"
+	// RenderManifestsKey is the rendering ConfigMap key of the resources, as multi-document YAML
+	RenderManifestsKey = "manifests.yaml"
+	// RenderConfigKey is the rendering ConfigMap key of the resolved config (development and
+	// production modes)
+	RenderConfigKey = "config.yaml"
+	// RenderViolationsKey is the rendering ConfigMap key of the guardrail violations, one per line
+	RenderViolationsKey = "violations"
+	// RenderNotesKey is the rendering ConfigMap key of what the rendering leaves out, one per line
+	RenderNotesKey = "notes"
+
+	// maxRenderSize keeps the rendering ConfigMap under the 1MiB object size limit
+	maxRenderSize = 900 * 1024
+)
+
+// RenderConfigMapName returns the name of the ConfigMap holding an Environment's rendering
+func RenderConfigMapName(envName string) string {
+	return envName + "-render"
+}
+
+// isRenderOnly reports whether an Environment is rendered instead of deployed
+func isRenderOnly(env *catalystv1alpha1.Environment) bool {
+	return env.Annotations[RenderOnlyAnnotation] == "true"
+}
+
+// renderPending reports whether an Environment asks for a rendering: one was requested, or a
+// render-only environment's spec changed since the last one
+func renderPending(env *catalystv1alpha1.Environment) bool {
+	if _, ok := env.Annotations[RenderAnnotation]; ok {
+		return true
+	}
+	return isRenderOnly(env) && (env.Status.Render == nil || env.Status.Render.ObservedGeneration != env.Generation)
+}
+
+// rendering is what a deployment mode would apply
+type rendering struct {
+	// config is the resolved config of the development and production modes
+	config *catalystv1alpha1.EnvironmentConfig
+	// objects are the resources the mode applies itself
+	objects []client.Object
+	// manifests are resources rendered by Helm
+	manifests  []byte
+	violations []guardrails.Violation
+	notes      []string
+}
+
+// reconcileRender renders the Environment when asked to. Rendering failures are reported in
+// status.render rather than retried. Returns true if the Environment was updated.
+func (r *EnvironmentReconciler) reconcileRender(ctx context.Context, env *catalystv1alpha1.Environment, project *catalystv1alpha1.Project, template *catalystv1alpha1.EnvironmentTemplateSpec, namespace string) (bool, error) {
+	if !renderPending(env) {
+		return false, nil
+	}
+	log := logf.FromContext(ctx)
+
+	mode := resolveDeploymentMode(env, template)
+	status := &catalystv1alpha1.RenderStatus{DeploymentMode: mode, ObservedGeneration: env.Generation, RenderedAt: metav1.Now()}
+	out, err := r.renderEnvironment(ctx, mode, env, project, template, namespace)
+	var configMap *corev1.ConfigMap
+	if err == nil {
+		configMap, err = r.desiredRenderConfigMap(env, out)
+	}
+	if err != nil {
+		log.Info("Cannot render environment", "mode", mode, "error", err.Error())
+		status.Error = err.Error()
+	} else {
+		if err := createOrReplace(ctx, r.Client, configMap); err != nil {
+			return false, fmt.Errorf("failed to write rendering: %w", err)
+		}
+		status.ConfigMap = configMap.Name
+	}
+
+	env.Status.Render = status
+	if err := r.Status().Update(ctx, env); err != nil {
+		return false, err
+	}
+	if status.Error != "" {
+		recordEvent(r.Recorder, env, corev1.EventTypeWarning, eventRenderFailed, "Cannot render the %s resources: %s", mode, status.Error)
+	} else {
+		recordEvent(r.Recorder, env, corev1.EventTypeNormal, eventRendered, "Rendered the %s resources into ConfigMap %s", mode, status.ConfigMap)
+	}
+
+	if _, ok := env.Annotations[RenderAnnotation]; ok {
+		delete(env.Annotations, RenderAnnotation)
+		if err := r.Update(ctx, env); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// renderEnvironment renders the resources of a deployment mode
+func (r *EnvironmentReconciler) renderEnvironment(ctx context.Context, mode string, env *catalystv1alpha1.Environment, project *catalystv1alpha1.Project, template *catalystv1alpha1.EnvironmentTemplateSpec, namespace string) (*rendering, error) {
+	switch mode {
+	case "development", "production":
+		return r.renderConfigMode(ctx, mode, env, project, template, namespace)
+	case "workspace":
+		return r.renderWorkspace(ctx, env, project, namespace), nil
+	}
+	if template == nil {
+		return nil, fmt.Errorf("%s mode requires a template", mode)
+	}
+
+	var out *rendering
+	var err error
+	switch mode {
+	case "gitops":
+		out, err = r.renderGitOps(ctx, env, project, template, namespace)
+	default:
+		sourcePath, cleanup, prepareErr := r.prepareSource(ctx, env, project, template)
+		if cleanup != nil {
+			defer cleanup()
+		}
+		if prepareErr != nil {
+			return nil, prepareErr
+		}
+		switch mode {
+		case "docker-compose":
+			out, err = r.renderCompose(ctx, env, project, template, namespace, sourcePath)
+		case "helm":
+			out, err = r.renderHelm(ctx, env, project, template, namespace, sourcePath)
+		case "kustomize":
+			out, err = r.renderKustomize(env, project, template, namespace, sourcePath)
+		default:
+			return r.renderWorkspace(ctx, env, project, namespace), nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// renderImages returns the images builds deploy: the promoted images, the images of pinned
+// commits built before, otherwise the references the builds push to
+func renderImages(env *catalystv1alpha1.Environment, project *catalystv1alpha1.Project, builds []catalystv1alpha1.BuildSpec) map[string]string {
+	images := map[string]string{}
+	registry := currentRegistryConfig()
+	promotion := activePromotion(env)
+	for _, build := range builds {
+		if promotion != nil {
+			if image := promotedImage(promotion, build.Name); image != nil {
+				images[build.Name] = image.Image + "@" + image.Digest
+			}
+			continue
+		}
+		commit, pinned := buildCommit(env, build)
+		if past := historicalImage(env.Status.DeploymentHistory, build.Name, commit); pinned && past != nil {
+			images[build.Name] = past.Image + "@" + past.Digest
+			continue
+		}
+		images[build.Name] = buildImageRef(registry, project, env, build.Name, commit)
+	}
+	return images
+}
+
+// renderConfigMode renders the development and production modes from the resolved config
+func (r *EnvironmentReconciler) renderConfigMode(ctx context.Context, mode string, env *catalystv1alpha1.Environment, project *catalystv1alpha1.Project, template *catalystv1alpha1.EnvironmentTemplateSpec, namespace string) (*rendering, error) {
+	templateConfig, err := getTemplateConfig(project, env)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get template config: %w", err)
+	}
+	if template != nil {
+		templateConfig = template.Config
+	}
+	config := resolveConfig(&env.Spec.Config, templateConfig)
+	if mode == "development" {
+		expandFrameworkPreset(&config)
+		config.Services = expandServicePresets(config.Services)
+	}
+	resolveStorage(&config, project.Spec.Storage)
+	if err := validateConfig(&config); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+	if err := validatePoolers(&config, mode); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+	secretsHash, err := r.catalystSecretsHash(ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &rendering{config: &config, violations: guardrails.Current().CheckConfig("environment config", &config)}
+	for _, svcSpec := range config.Services {
+		if svcSpec.Provider != "" {
+			out.notes = append(out.notes, fmt.Sprintf("service %s: the %s cluster is created by its operator", svcSpec.Name, svcSpec.Provider))
+			continue
+		}
+		if mode == "production" {
+			out.notes = append(out.notes, fmt.Sprintf("service %s: production mode runs no managed services", svcSpec.Name))
+			continue
+		}
+		statefulSet := desiredManagedServiceStatefulSet(namespace, svcSpec)
+		labelEnvironmentWorkload(env, statefulSet)
+		prioritizeEnvironmentWorkload(env, statefulSet)
+		scheduleEnvironmentWorkload(&config, statefulSet)
+		out.objects = append(out.objects, statefulSet, desiredManagedServiceService(namespace, svcSpec))
+		if svcSpec.Seed != nil && svcSpec.Seed.DumpURL != "" {
+			out.notes = append(out.notes, fmt.Sprintf("service %s: the seed Job is created once the service is ready", svcSpec.Name))
+		}
+		if svcSpec.Pooler != nil {
+			out.notes = append(out.notes, fmt.Sprintf("service %s: the connection pooler is created once the service is ready", svcSpec.Name))
+		}
+	}
+
+	if mode == "production" {
+		config.Env = postgresConnectionEnv(config.Env, config.Services)
+		deployment := desiredDeploymentFromConfig(namespace, &config)
+		labelEnvironmentWorkload(env, deployment)
+		prioritizeEnvironmentWorkload(env, deployment)
+		if desiredReplicas(&config) > 1 {
+			deployment.Spec.Template.Spec.Affinity = spreadAffinity(deployment.Spec.Selector.MatchLabels)
+		}
+		scheduleEnvironmentWorkload(&config, deployment)
+		setSecretsHash(&deployment.Spec.Template, secretsHash)
+		out.objects = append(out.objects, deployment, desiredServiceFromConfig(namespace, &config))
+		if config.Autoscaling != nil {
+			out.objects = append(out.objects, desiredHorizontalPodAutoscaler(namespace, config.Autoscaling))
+		}
+		return out, nil
+	}
+
+	for _, volSpec := range config.Volumes {
+		if volSpec.PersistentVolumeClaim != nil {
+			out.objects = append(out.objects, &corev1.PersistentVolumeClaim{
+				ObjectMeta: metav1.ObjectMeta{Name: volSpec.Name, Namespace: namespace},
+				Spec:       *volSpec.PersistentVolumeClaim,
+			})
+		}
+	}
+	config.Env = rewriteDatabaseURLForPooler(config.Env, config.Services)
+	config.Env = postgresConnectionEnv(config.Env, config.Services)
+	webDeployment := desiredDevelopmentDeploymentFromConfig(env, project, namespace, &config)
+	labelEnvironmentWorkload(env, webDeployment)
+	prioritizeEnvironmentWorkload(env, webDeployment)
+	scheduleEnvironmentWorkload(&config, webDeployment)
+	setSecretsHash(&webDeployment.Spec.Template, secretsHash)
+	setGitScriptsHash(&webDeployment.Spec.Template)
+	out.objects = append(out.objects, webDeployment, desiredDevelopmentServiceFromConfig(namespace, &config))
+	return out, nil
+}
+
+// renderCompose renders the compose translation of a source checkout
+func (r *EnvironmentReconciler) renderCompose(ctx context.Context, env *catalystv1alpha1.Environment, project *catalystv1alpha1.Project, template *catalystv1alpha1.EnvironmentTemplateSpec, namespace, sourcePath string) (*rendering, error) {
+	variables, err := r.composeVariables(ctx, env, template, namespace)
+	if err != nil {
+		return nil, err
+	}
+	compose, err := readComposeFile(sourcePath, env, template, variables)
+	if err != nil {
+		return nil, err
+	}
+	builds := append(append([]catalystv1alpha1.BuildSpec{}, template.Builds...), composeBuilds(compose, project, template)...)
+	images := renderImages(env, project, builds)
+	secretsHash, err := r.catalystSecretsHash(ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+	objects, _, err := r.desiredComposeObjects(namespace, sourcePath, env, compose, images, secretsHash)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &rendering{}
+	for _, pvc := range desiredComposePVCs(namespace, compose, project.Spec.Storage) {
+		out.objects = append(out.objects, pvc)
+	}
+	policy := composeGuardrails(images)
+	config := resolveConfig(&env.Spec.Config, template.Config)
+	for _, obj := range objects {
+		labelEnvironmentWorkload(env, obj)
+		prioritizeEnvironmentWorkload(env, obj)
+		scheduleEnvironmentWorkload(&config, obj)
+		out.violations = append(out.violations, policy.CheckObject(obj)...)
+		out.objects = append(out.objects, obj)
+	}
+	return out, nil
+}
+
+// renderHelm renders the chart of a source checkout with the values the release is installed with
+func (r *EnvironmentReconciler) renderHelm(ctx context.Context, env *catalystv1alpha1.Environment, project *catalystv1alpha1.Project, template *catalystv1alpha1.EnvironmentTemplateSpec, namespace, sourcePath string) (*rendering, error) {
+	vals, err := r.mergeHelmValues(template, env)
+	if err != nil {
+		return nil, fmt.Errorf("failed to merge helm values: %w", err)
+	}
+	if images := renderImages(env, project, template.Builds); len(images) > 0 {
+		injectBuiltImages(vals, images, logf.FromContext(ctx))
+	}
+	secretsHash, err := r.catalystSecretsHash(ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+	injectCatalystSecrets(vals, secretsHash)
+
+	chartRequested, err := loader.Load(sourcePath)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateHelmValues(chartRequested, vals, project.Spec.HelmValuesPolicy); err != nil {
+		return nil, err
+	}
+	manifests, err := renderHelmChart(chartRequested, env.Name, namespace, vals)
+	if err != nil {
+		return nil, err
+	}
+	violations, err := guardrails.Current().CheckManifests(manifests)
+	if err != nil {
+		return nil, err
+	}
+	return &rendering{manifests: manifests, violations: violations}, nil
+}
+
+// renderHelmChart renders a chart like `helm template`, hooks included
+func renderHelmChart(chrt *chart.Chart, releaseName, namespace string, vals map[string]interface{}) ([]byte, error) {
+	install := action.NewInstall(&action.Configuration{Log: func(string, ...interface{}) {}})
+	install.ReleaseName = releaseName
+	install.Namespace = namespace
+	install.DryRun = true
+	install.ClientOnly = true
+	install.Replace = true
+	rel, err := install.Run(chrt, vals)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render chart: %w", err)
+	}
+
+	var manifests bytes.Buffer
+	manifests.WriteString(strings.TrimSpace(rel.Manifest))
+	for _, hook := range rel.Hooks {
+		_, _ = fmt.Fprintf(&manifests, "\n---\n# Source: %s\n%s", hook.Path, strings.TrimSpace(hook.Manifest))
+	}
+	manifests.WriteString("\n")
+	return manifests.Bytes(), nil
+}
+
+// renderKustomize renders the kustomization of a source checkout
+func (r *EnvironmentReconciler) renderKustomize(env *catalystv1alpha1.Environment, project *catalystv1alpha1.Project, template *catalystv1alpha1.EnvironmentTemplateSpec, namespace, sourcePath string) (*rendering, error) {
+	images := renderImages(env, project, template.Builds)
+	manifests, err := renderKustomization(sourcePath, namespace, images)
+	if err != nil {
+		return nil, err
+	}
+	policy := guardrails.Current()
+	for _, image := range images {
+		policy = policy.WithExemptImages(image)
+	}
+	violations, err := policy.CheckManifests(manifests)
+	if err != nil {
+		return nil, err
+	}
+	objects, err := decodeManifests(manifests)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &rendering{violations: violations}
+	for _, obj := range objects {
+		labelEnvironmentWorkload(env, obj)
+		prioritizeEnvironmentWorkload(env, obj)
+		out.objects = append(out.objects, obj)
+	}
+	return out, nil
+}
+
+// renderGitOps renders the objects handing the environment to the GitOps engine
+func (r *EnvironmentReconciler) renderGitOps(ctx context.Context, env *catalystv1alpha1.Environment, project *catalystv1alpha1.Project, template *catalystv1alpha1.EnvironmentTemplateSpec, namespace string) (*rendering, error) {
+	cfg, err := currentGitOpsConfig()
+	if err != nil {
+		return nil, err
+	}
+	src, err := resolveGitOpsSource(env, project, template)
+	if err != nil {
+		return nil, err
+	}
+	isHelm := template.Type == "helm"
+	var values map[string]interface{}
+	if isHelm {
+		vals, err := r.mergeHelmValues(template, env)
+		if err != nil {
+			return nil, fmt.Errorf("failed to merge helm values: %w", err)
+		}
+		if images := renderImages(env, project, template.Builds); len(images) > 0 {
+			injectBuiltImages(vals, images, logf.FromContext(ctx))
+		}
+		if values, err = jsonValues(vals); err != nil {
+			return nil, fmt.Errorf("failed to encode helm values: %w", err)
+		}
+	}
+
+	out := &rendering{notes: []string{fmt.Sprintf("%s renders and applies the workloads", cfg.Engine)}}
+	if cfg.Engine == gitopsEngineArgoCD {
+		out.objects = append(out.objects, desiredArgoApplication(env, namespace, cfg, src, isHelm, values))
+	} else {
+		for _, obj := range desiredFluxObjects(env, namespace, cfg, src, isHelm, values) {
+			out.objects = append(out.objects, obj)
+		}
+	}
+	return out, nil
+}
+
+// renderWorkspace renders the workspace pod
+func (r *EnvironmentReconciler) renderWorkspace(ctx context.Context, env *catalystv1alpha1.Environment, project *catalystv1alpha1.Project, namespace string) *rendering {
+	out := &rendering{}
+	devContainer, err := r.loadWorkspaceDevContainer(ctx, env, project)
+	if err != nil {
+		out.notes = append(out.notes, fmt.Sprintf("cannot read devcontainer.json, rendered the default workspace pod: %v", err))
+		devContainer = nil
+	}
+	out.objects = append(out.objects, desiredWorkspacePod(env, namespace, devContainer))
+	return out
+}
+
+// renderManifest encodes an object as YAML, without status and empty timestamps
+func (r *EnvironmentReconciler) renderManifest(obj client.Object) ([]byte, error) {
+	gvk, err := apiutil.GVKForObject(obj, r.Scheme)
+	if err != nil {
+		return nil, err
+	}
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+	u := &unstructured.Unstructured{Object: content}
+	u.SetGroupVersionKind(gvk)
+	unstructured.RemoveNestedField(u.Object, "status")
+	unstructured.RemoveNestedField(u.Object, "metadata", "creationTimestamp")
+	unstructured.RemoveNestedField(u.Object, "spec", "template", "metadata", "creationTimestamp")
+	if claims, ok, _ := unstructured.NestedSlice(u.Object, "spec", "volumeClaimTemplates"); ok {
+		for _, claim := range claims {
+			if claim, ok := claim.(map[string]interface{}); ok {
+				unstructured.RemoveNestedField(claim, "status")
+				unstructured.RemoveNestedField(claim, "metadata", "creationTimestamp")
+			}
+		}
+		_ = unstructured.SetNestedSlice(u.Object, claims, "spec", "volumeClaimTemplates")
+	}
+	return yaml.Marshal(u.Object)
+}
+
+// desiredRenderConfigMap returns the ConfigMap of a rendering, owned by the Environment
+func (r *EnvironmentReconciler) desiredRenderConfigMap(env *catalystv1alpha1.Environment, out *rendering) (*corev1.ConfigMap, error) {
+	var manifests bytes.Buffer
+	for _, obj := range out.objects {
+		data, err := r.renderManifest(obj)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode %s: %w", obj.GetName(), err)
+		}
+		manifests.WriteString("---\n")
+		manifests.Write(data)
+	}
+	manifests.Write(out.manifests)
+
+	data := map[string]string{RenderManifestsKey: manifests.String()}
+	if out.config != nil {
+		config, err := yaml.Marshal(out.config)
+		if err != nil {
+			return nil, err
+		}
+		data[RenderConfigKey] = string(config)
+	}
+	if len(out.violations) > 0 {
+		lines := make([]string, 0, len(out.violations))
+		for _, v := range out.violations {
+			lines = append(lines, v.String())
+		}
+		data[RenderViolationsKey] = strings.Join(lines, "\n") + "\n"
+	}
+	if len(out.notes) > 0 {
+		data[RenderNotesKey] = strings.Join(out.notes, "\n") + "\n"
+	}
+	size := 0
+	for _, value := range data {
+		size += len(value)
+	}
+	if size > maxRenderSize {
+		return nil, fmt.Errorf("the rendering is %d bytes, over the %d byte ConfigMap limit", size, maxRenderSize)
+	}
+
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      RenderConfigMapName(env.Name),
+			Namespace: env.Namespace,
+			Labels: map[string]string{
+				"app.kubernetes.io/managed-by": "catalyst-operator",
+				"catalyst.dev/environment":     sanitizeLabelValue(env.Name),
+			},
+		},
+		Data: data,
+	}
+	if err := controllerutil.SetControllerReference(env, configMap, r.Scheme); err != nil {
+		return nil, err
+	}
+	return configMap, nil
+}
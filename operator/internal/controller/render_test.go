@@ -0,0 +1,148 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"helm.sh/helm/v3/pkg/chart"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+func TestRenderPending(t *testing.T) {
+	env := &catalystv1alpha1.Environment{ObjectMeta: metav1.ObjectMeta{Generation: 2}}
+	assert.False(t, renderPending(env))
+
+	env.Annotations = map[string]string{RenderAnnotation: "2026-01-01T00:00:00Z"}
+	assert.True(t, renderPending(env))
+
+	env.Annotations = map[string]string{RenderOnlyAnnotation: "true"}
+	assert.True(t, isRenderOnly(env))
+	assert.True(t, renderPending(env), "render-only environments render when first seen")
+	env.Status.Render = &catalystv1alpha1.RenderStatus{ObservedGeneration: 2}
+	assert.False(t, renderPending(env))
+	env.Generation = 3
+	assert.True(t, renderPending(env), "render-only environments render spec changes")
+
+	env.Annotations = map[string]string{RenderOnlyAnnotation: "false"}
+	assert.False(t, isRenderOnly(env))
+}
+
+func TestReconcileRender_Development(t *testing.T) {
+	template := &catalystv1alpha1.EnvironmentTemplateSpec{Config: &catalystv1alpha1.EnvironmentConfig{
+		Image:    "node:22",
+		Command:  []string{"npm", "run", "dev"},
+		Ports:    []corev1.ContainerPort{{ContainerPort: 3000, Protocol: corev1.ProtocolTCP}},
+		Services: []catalystv1alpha1.ManagedServiceSpec{{Name: "cache", Preset: "redis"}},
+	}}
+	project := &catalystv1alpha1.Project{
+		ObjectMeta: metav1.ObjectMeta{Name: "shop", Namespace: "team"},
+		Spec: catalystv1alpha1.ProjectSpec{
+			Sources:   []catalystv1alpha1.SourceConfig{{Name: "app", RepositoryURL: "https://github.com/acme/shop.git", Branch: "main"}},
+			Templates: map[string]catalystv1alpha1.EnvironmentTemplateSpec{"development": *template},
+		},
+	}
+	env := &catalystv1alpha1.Environment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "pr-1", Namespace: "team", Generation: 1,
+			Annotations: map[string]string{RenderAnnotation: "now"},
+		},
+		Spec: catalystv1alpha1.EnvironmentSpec{
+			ProjectRef: catalystv1alpha1.ProjectReference{Name: "shop"},
+			Type:       "development",
+			Sources:    []catalystv1alpha1.EnvironmentSource{{Name: "app", Branch: "feature"}},
+		},
+	}
+	c := newFakeClientBuilder().WithStatusSubresource(env).WithObjects(env).Build()
+	recorder := record.NewFakeRecorder(10)
+	r := &EnvironmentReconciler{Client: c, Scheme: testScheme, Recorder: recorder}
+	ctx := context.Background()
+
+	rendered, err := r.reconcileRender(ctx, env, project, template, "team-shop-pr-1")
+	require.NoError(t, err)
+	assert.True(t, rendered)
+	require.NotNil(t, env.Status.Render)
+	assert.Empty(t, env.Status.Render.Error)
+	assert.Equal(t, "development", env.Status.Render.DeploymentMode)
+	assert.Equal(t, RenderConfigMapName("pr-1"), env.Status.Render.ConfigMap)
+	assert.Contains(t, <-recorder.Events, eventRendered)
+
+	stored := &catalystv1alpha1.Environment{}
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(env), stored))
+	assert.NotContains(t, stored.Annotations, RenderAnnotation, "the request is consumed")
+	require.NotNil(t, stored.Status.Render)
+
+	configMap := &corev1.ConfigMap{}
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "pr-1-render", Namespace: "team"}, configMap))
+	require.Len(t, configMap.OwnerReferences, 1)
+	assert.Equal(t, "pr-1", configMap.OwnerReferences[0].Name)
+	manifests := configMap.Data[RenderManifestsKey]
+	assert.Contains(t, manifests, "kind: StatefulSet")
+	assert.Contains(t, manifests, "name: cache")
+	assert.Contains(t, manifests, "kind: Deployment")
+	assert.Contains(t, manifests, "namespace: team-shop-pr-1")
+	assert.Contains(t, manifests, "image: node:22")
+	assert.NotContains(t, manifests, "creationTimestamp")
+	assert.NotContains(t, manifests, "status:")
+	assert.Contains(t, configMap.Data[RenderConfigKey], "image: node:22")
+
+	// Nothing pending, nothing rendered
+	rendered, err = r.reconcileRender(ctx, stored, project, template, "team-shop-pr-1")
+	require.NoError(t, err)
+	assert.False(t, rendered)
+}
+
+func TestReconcileRender_Failure(t *testing.T) {
+	project := &catalystv1alpha1.Project{ObjectMeta: metav1.ObjectMeta{Name: "shop", Namespace: "team"}}
+	env := &catalystv1alpha1.Environment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "pr-1", Namespace: "team", Generation: 1,
+			Annotations: map[string]string{RenderOnlyAnnotation: "true"},
+		},
+		Spec: catalystv1alpha1.EnvironmentSpec{
+			ProjectRef:     catalystv1alpha1.ProjectReference{Name: "shop"},
+			Type:           "development",
+			DeploymentMode: "helm",
+		},
+	}
+	c := newFakeClientBuilder().WithStatusSubresource(env).WithObjects(env).Build()
+	recorder := record.NewFakeRecorder(10)
+	r := &EnvironmentReconciler{Client: c, Scheme: testScheme, Recorder: recorder}
+
+	rendered, err := r.reconcileRender(context.Background(), env, project, nil, "team-shop-pr-1")
+	require.NoError(t, err, "rendering failures are reported, not retried")
+	assert.True(t, rendered)
+	require.NotNil(t, env.Status.Render)
+	assert.Equal(t, "helm mode requires a template", env.Status.Render.Error)
+	assert.Empty(t, env.Status.Render.ConfigMap)
+	assert.Equal(t, int64(1), env.Status.Render.ObservedGeneration)
+	assert.Contains(t, <-recorder.Events, eventRenderFailed)
+	assert.False(t, renderPending(env), "the failed generation is not rendered again")
+}
+
+func TestRenderHelmChart(t *testing.T) {
+	chrt := &chart.Chart{
+		Metadata: &chart.Metadata{Name: "web", Version: "0.1.0", APIVersion: chart.APIVersionV2},
+		Templates: []*chart.File{
+			{Name: "templates/configmap.yaml", Data: []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: {{ .Release.Name }}\n  namespace: {{ .Release.Namespace }}\ndata:\n  image: {{ .Values.image }}\n")},
+			{Name: "templates/migrate.yaml", Data: []byte("apiVersion: batch/v1\nkind: Job\nmetadata:\n  name: migrate\n  annotations:\n    helm.sh/hook: pre-install\n")},
+		},
+	}
+	manifests, err := renderHelmChart(chrt, "pr-1", "team-shop-pr-1", map[string]interface{}{"image": "registry/web:abc"})
+	require.NoError(t, err)
+	out := string(manifests)
+	assert.Contains(t, out, "name: pr-1")
+	assert.Contains(t, out, "namespace: team-shop-pr-1")
+	assert.Contains(t, out, "image: registry/web:abc")
+	assert.Contains(t, out, "# Source: web/templates/migrate.yaml", "hooks are rendered")
+
+	objects, err := decodeManifests(manifests)
+	require.NoError(t, err)
+	assert.Len(t, objects, 2)
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+// +kubebuilder:rbac:groups=metrics.k8s.io,resources=pods,verbs=get;list
+
+// resourceUsageInterval is how often status.resources is refreshed
+const resourceUsageInterval = 2 * time.Minute
+
+// podMetricsListGVK is the metrics-server pod metrics list. The resource metrics API serves
+// no watch, so it is read as unstructured, which the manager client never caches.
+var podMetricsListGVK = schema.GroupVersionKind{Group: "metrics.k8s.io", Version: "v1beta1", Kind: "PodMetricsList"}
+
+// sumPodMetrics adds up the container usage of pod metrics. CPU is rounded up to millicores
+// and memory up to mebibytes for display.
+func sumPodMetrics(items []unstructured.Unstructured) (cpu, memory resource.Quantity) {
+	var cpuMilli, memoryBytes int64
+	for _, item := range items {
+		containers, _, _ := unstructured.NestedSlice(item.Object, "containers")
+		for _, c := range containers {
+			container, ok := c.(map[string]any)
+			if !ok {
+				continue
+			}
+			usage, _, _ := unstructured.NestedStringMap(container, "usage")
+			if q, err := resource.ParseQuantity(usage["cpu"]); err == nil {
+				cpuMilli += q.MilliValue()
+			}
+			if q, err := resource.ParseQuantity(usage["memory"]); err == nil {
+				memoryBytes += q.Value()
+			}
+		}
+	}
+	const mebibyte = 1 << 20
+	return *resource.NewMilliQuantity(cpuMilli, resource.DecimalSI),
+		*resource.NewQuantity((memoryBytes+mebibyte-1)/mebibyte*mebibyte, resource.BinarySI)
+}
+
+// quotaPercentages returns the share of each hard quota limit in use, in percent
+func quotaPercentages(quota *corev1.ResourceQuota) (map[corev1.ResourceName]int32, int32) {
+	percentages := map[corev1.ResourceName]int32{}
+	var highest int32
+	for name, hard := range quota.Status.Hard {
+		if hard.IsZero() {
+			continue
+		}
+		used := quota.Status.Used[name]
+		percent := int32(used.MilliValue() * 100 / hard.MilliValue())
+		percentages[name] = percent
+		highest = max(highest, percent)
+	}
+	return percentages, highest
+}
+
+// reconcileResourceUsage refreshes status.resources from metrics-server and the namespace
+// quota every resourceUsageInterval. Returns false when metrics-server is not installed.
+func (r *EnvironmentReconciler) reconcileResourceUsage(ctx context.Context, env *catalystv1alpha1.Environment, namespace string) (bool, error) {
+	if r.Capabilities != nil && !r.Capabilities.MetricsServer {
+		return false, nil
+	}
+	if env.Status.Resources != nil && time.Since(env.Status.Resources.ObservedAt.Time) < resourceUsageInterval {
+		return true, nil
+	}
+
+	metrics := &unstructured.UnstructuredList{}
+	metrics.SetGroupVersionKind(podMetricsListGVK)
+	if err := r.List(ctx, metrics, client.InNamespace(namespace)); err != nil {
+		// Metrics are advisory: an unavailable metrics API never fails the reconcile
+		logf.FromContext(ctx).V(1).Info("Pod metrics unavailable", "namespace", namespace, "error", err.Error())
+		return true, nil
+	}
+	cpu, memory := sumPodMetrics(metrics.Items)
+	usage := &catalystv1alpha1.ResourceUsage{
+		CPU:        &cpu,
+		Memory:     &memory,
+		Pods:       int32(len(metrics.Items)),
+		ObservedAt: metav1.Now().Rfc3339Copy(),
+	}
+
+	quota := &corev1.ResourceQuota{}
+	if err := r.Get(ctx, client.ObjectKey{Name: defaultQuotaName, Namespace: namespace}, quota); err != nil && !apierrors.IsNotFound(err) {
+		return true, err
+	} else if err == nil {
+		usage.Quota, usage.QuotaPercent = quotaPercentages(quota)
+	}
+
+	env.Status.Resources = usage
+	return true, r.Status().Update(ctx, env)
+}
@@ -0,0 +1,76 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+	"github.com/ncrmro/catalyst/operator/internal/capabilities"
+)
+
+func podMetrics(name string, usage ...map[string]any) *unstructured.Unstructured {
+	containers := make([]any, 0, len(usage))
+	for _, u := range usage {
+		containers = append(containers, map[string]any{"name": "c", "usage": u})
+	}
+	obj := &unstructured.Unstructured{Object: map[string]any{"containers": containers}}
+	obj.SetAPIVersion("metrics.k8s.io/v1beta1")
+	obj.SetKind("PodMetrics")
+	obj.SetName(name)
+	obj.SetNamespace("acme-shop-pr-1")
+	return obj
+}
+
+func TestReconcileResourceUsage(t *testing.T) {
+	env := &catalystv1alpha1.Environment{ObjectMeta: metav1.ObjectMeta{Name: "pr-1", Namespace: "acme"}}
+	quota := desiredResourceQuota("acme-shop-pr-1")
+	quota.Status.Hard = quota.Spec.Hard
+	quota.Status.Used = corev1.ResourceList{
+		corev1.ResourceRequestsCPU:    resource.MustParse("500m"),
+		corev1.ResourceRequestsMemory: resource.MustParse("3Gi"),
+	}
+	c := newFakeClientBuilder().WithStatusSubresource(env).WithObjects(
+		env, quota,
+		podMetrics("web-1", map[string]any{"cpu": "153428571n", "memory": "120Mi"}, map[string]any{"cpu": "2m", "memory": "1048577"}),
+		podMetrics("postgres-0", map[string]any{"cpu": "10m", "memory": "64Mi"}),
+	).Build()
+	r := &EnvironmentReconciler{Client: c, Scheme: testScheme}
+	ctx := context.Background()
+
+	tracked, err := r.reconcileResourceUsage(ctx, env, "acme-shop-pr-1")
+	require.NoError(t, err)
+	assert.True(t, tracked)
+	usage := env.Status.Resources
+	require.NotNil(t, usage)
+	assert.Equal(t, "166m", usage.CPU.String(), "nanocores are rounded up to millicores")
+	assert.Equal(t, "186Mi", usage.Memory.String(), "memory is rounded up to MiB")
+	assert.Equal(t, int32(2), usage.Pods)
+	assert.Equal(t, int32(25), usage.Quota[corev1.ResourceRequestsCPU])
+	assert.Equal(t, int32(75), usage.Quota[corev1.ResourceRequestsMemory])
+	assert.Equal(t, int32(0), usage.Quota[corev1.ResourcePods])
+	assert.Equal(t, int32(75), usage.QuotaPercent)
+
+	// Refreshed at most once per interval
+	observed := usage.ObservedAt
+	_, err = r.reconcileResourceUsage(ctx, env, "acme-shop-pr-1")
+	require.NoError(t, err)
+	assert.Equal(t, observed, env.Status.Resources.ObservedAt)
+	env.Status.Resources.ObservedAt = metav1.NewTime(time.Now().Add(-resourceUsageInterval))
+	_, err = r.reconcileResourceUsage(ctx, env, "acme-shop-pr-1")
+	require.NoError(t, err)
+	assert.True(t, env.Status.Resources.ObservedAt.After(time.Now().Add(-time.Minute)))
+
+	// Clusters without metrics-server are skipped
+	r.Capabilities = &capabilities.Capabilities{}
+	tracked, err = r.reconcileResourceUsage(ctx, &catalystv1alpha1.Environment{}, "acme-shop-pr-1")
+	require.NoError(t, err)
+	assert.False(t, tracked)
+}
@@ -22,7 +22,7 @@ import (
 func desiredResourceQuota(namespace string) *corev1.ResourceQuota {
 	return &corev1.ResourceQuota{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      "default-quota",
+			Name:      defaultQuotaName,
 			Namespace: namespace,
 		},
 		Spec: corev1.ResourceQuotaSpec{
@@ -62,10 +62,15 @@ func desiredNetworkPolicy(namespace, ingressNamespace string) *networkingv1.Netw
 					},
 				},
 				{
-					// Allow intra-namespace communication (e.g., web → postgres)
+					// Allow intra-namespace communication (e.g., web → postgres). Compose
+					// services only talk over their networks (compose-network-* policies).
 					From: []networkingv1.NetworkPolicyPeer{
 						{
-							PodSelector: &metav1.LabelSelector{},
+							PodSelector: &metav1.LabelSelector{
+								MatchExpressions: []metav1.LabelSelectorRequirement{
+									{Key: composeServiceLabel, Operator: metav1.LabelSelectorOpDoesNotExist},
+								},
+							},
 						},
 					},
 				},
@@ -5,6 +5,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func TestDesiredResourceQuota(t *testing.T) {
@@ -25,4 +26,7 @@ func TestDesiredNetworkPolicy(t *testing.T) {
 	assert.Equal(t, "default-policy", policy.Name)
 	assert.Equal(t, "test-ns", policy.Namespace)
 	assert.Equal(t, "ingress-namespace", policy.Spec.Ingress[0].From[0].NamespaceSelector.MatchLabels["kubernetes.io/metadata.name"])
+	// Compose services are admitted by the policies of their networks instead
+	assert.Equal(t, []metav1.LabelSelectorRequirement{{Key: composeServiceLabel, Operator: metav1.LabelSelectorOpDoesNotExist}},
+		policy.Spec.Ingress[1].From[0].PodSelector.MatchExpressions)
 }
@@ -0,0 +1,95 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+const (
+	// rollbackAnnotation on an Environment requests a rollback to a revision of its deployment
+	// history, a number or "previous" (the latest Ready revision before the current one). The
+	// operator sets spec.rollbackTo and removes the annotation.
+	rollbackAnnotation = "catalyst.dev/rollback-to"
+	rollbackPrevious   = "previous"
+)
+
+// rollbackTarget resolves the value of the rollback annotation to a revision of the history
+func rollbackTarget(env *catalystv1alpha1.Environment, value string) (int64, error) {
+	history := env.Status.DeploymentHistory
+	if value == rollbackPrevious {
+		// The current revision is the front of the history, or the one rolled back to
+		current := int64(0)
+		if len(history) > 0 {
+			current = history[0].Revision
+		}
+		if promotion := activePromotion(env); promotion != nil && promotion.Source == env.Name {
+			current = promotion.Revision
+		}
+		for _, record := range history {
+			if record.Revision != current && record.Outcome == catalystv1alpha1.DeploymentOutcomeReady {
+				return record.Revision, nil
+			}
+		}
+		return 0, fmt.Errorf("no Ready revision before revision %d in the deployment history", current)
+	}
+	revision, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || revision < 1 {
+		return 0, fmt.Errorf("invalid revision %q: want a revision number or %q", value, rollbackPrevious)
+	}
+	for _, record := range history {
+		if record.Revision == revision {
+			return revision, nil
+		}
+	}
+	return 0, fmt.Errorf("no revision %d in the deployment history", revision)
+}
+
+// consumeRollbackRequest sets spec.rollbackTo when the rollback annotation is set. Requests
+// that resolve to no revision are dropped with a Warning Event.
+// Returns true if the Environment was updated.
+func (r *EnvironmentReconciler) consumeRollbackRequest(ctx context.Context, env *catalystv1alpha1.Environment) (bool, error) {
+	value, ok := env.Annotations[rollbackAnnotation]
+	if !ok {
+		return false, nil
+	}
+	log := logf.FromContext(ctx)
+	delete(env.Annotations, rollbackAnnotation)
+	revision, err := rollbackTarget(env, value)
+	switch {
+	case err != nil:
+		log.Info("Ignoring rollback request", "value", value, "reason", err.Error())
+		recordEvent(r.Recorder, env, corev1.EventTypeWarning, eventRollbackRejected, "Rollback to %s rejected: %s", value, err)
+	case env.Spec.PromoteFrom != nil:
+		log.Info("Ignoring rollback request of a promoted environment", "value", value)
+		recordEvent(r.Recorder, env, corev1.EventTypeWarning, eventRollbackRejected, "Rollback to %s rejected: the environment deploys spec.promoteFrom", value)
+	default:
+		log.Info("Rollback requested", "revision", revision)
+		env.Spec.RollbackTo = revision
+	}
+	if err := r.Update(ctx, env); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,87 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+func TestRollbackTarget(t *testing.T) {
+	env := &catalystv1alpha1.Environment{
+		ObjectMeta: metav1.ObjectMeta{Name: "staging"},
+		Status: catalystv1alpha1.EnvironmentStatus{DeploymentHistory: []catalystv1alpha1.DeploymentRecord{
+			{Revision: 4, Outcome: catalystv1alpha1.DeploymentOutcomeFailed},
+			{Revision: 3, Outcome: catalystv1alpha1.DeploymentOutcomeReady},
+			{Revision: 2, Outcome: catalystv1alpha1.DeploymentOutcomeFailed},
+			{Revision: 1, Outcome: catalystv1alpha1.DeploymentOutcomeReady},
+		}},
+	}
+
+	revision, err := rollbackTarget(env, "previous")
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), revision)
+
+	revision, err = rollbackTarget(env, "2")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), revision, "any recorded revision can be rolled back to")
+
+	_, err = rollbackTarget(env, "7")
+	assert.ErrorContains(t, err, "no revision 7")
+	_, err = rollbackTarget(env, "latest")
+	assert.ErrorContains(t, err, `invalid revision "latest"`)
+
+	// Rolled back to 3, previous goes further back
+	env.Spec.RollbackTo = 3
+	env.Status.Promotion = &catalystv1alpha1.PromotionStatus{Source: "staging", Revision: 3}
+	revision, err = rollbackTarget(env, "previous")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), revision)
+
+	env.Spec.RollbackTo = 0
+	env.Status.DeploymentHistory = env.Status.DeploymentHistory[:1]
+	_, err = rollbackTarget(env, "previous")
+	assert.ErrorContains(t, err, "no Ready revision before revision 4")
+}
+
+func TestConsumeRollbackRequest(t *testing.T) {
+	env := &catalystv1alpha1.Environment{
+		ObjectMeta: metav1.ObjectMeta{Name: "staging", Namespace: "team", Annotations: map[string]string{rollbackAnnotation: "previous"}},
+		Status: catalystv1alpha1.EnvironmentStatus{DeploymentHistory: []catalystv1alpha1.DeploymentRecord{
+			{Revision: 2, Outcome: catalystv1alpha1.DeploymentOutcomeFailed},
+			{Revision: 1, Outcome: catalystv1alpha1.DeploymentOutcomeReady},
+		}},
+	}
+	c := newFakeClientBuilder().WithObjects(env).Build()
+	recorder := record.NewFakeRecorder(10)
+	r := &EnvironmentReconciler{Client: c, Scheme: testScheme, Recorder: recorder}
+	ctx := context.Background()
+
+	updated, err := r.consumeRollbackRequest(ctx, env)
+	require.NoError(t, err)
+	assert.True(t, updated)
+	stored := &catalystv1alpha1.Environment{}
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(env), stored))
+	assert.Equal(t, int64(1), stored.Spec.RollbackTo)
+	assert.NotContains(t, stored.Annotations, rollbackAnnotation)
+
+	updated, err = r.consumeRollbackRequest(ctx, stored)
+	require.NoError(t, err)
+	assert.False(t, updated, "no request")
+
+	// Unknown revisions are dropped with a Warning
+	stored.Annotations = map[string]string{rollbackAnnotation: "9"}
+	updated, err = r.consumeRollbackRequest(ctx, stored)
+	require.NoError(t, err)
+	assert.True(t, updated)
+	assert.Equal(t, int64(1), stored.Spec.RollbackTo)
+	assert.NotContains(t, stored.Annotations, rollbackAnnotation)
+	require.Len(t, recorder.Events, 1)
+	assert.Contains(t, <-recorder.Events, eventRollbackRejected)
+}
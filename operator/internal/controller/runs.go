@@ -0,0 +1,278 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+// Ad-hoc runs (spec.runs):
+// Each entry is executed once as a Job ("run-<name>") in the environment namespace. The Job
+// reuses the pod template of the web Deployment (image, env, envFrom, volumes) with the run
+// command, so it sees the same workspace and configuration as the app. Exit code, duration
+// and the pod holding the logs are recorded in status.runs.
+
+const (
+	runPhasePending   = "Pending"
+	runPhaseRunning   = "Running"
+	runPhaseSucceeded = "Succeeded"
+	runPhaseFailed    = "Failed"
+
+	// runLabel carries the run name on run Jobs
+	runLabel = "catalyst.dev/run"
+	// runContainerName is the container executing the run command
+	runContainerName = "run"
+	// runJobTTL keeps finished run Jobs, and with them the pod logs, for a day
+	runJobTTL = int32(24 * 60 * 60)
+)
+
+// runJobName returns the Job name for a run
+func runJobName(name string) string {
+	return "run-" + name
+}
+
+// isRunFinished reports whether a run reached a terminal phase
+func isRunFinished(status *catalystv1alpha1.RunStatus) bool {
+	return status != nil && (status.Phase == runPhaseSucceeded || status.Phase == runPhaseFailed)
+}
+
+// findRunStatus returns the recorded status of a run, or nil
+func findRunStatus(statuses []catalystv1alpha1.RunStatus, name string) *catalystv1alpha1.RunStatus {
+	for i := range statuses {
+		if statuses[i].Name == name {
+			return &statuses[i]
+		}
+	}
+	return nil
+}
+
+// desiredRunJob executes the run command with the pod template of the web Deployment.
+// Init containers are dropped (the workspace is already populated) and, when the pod mounts
+// PersistentVolumeClaims, the Job is scheduled next to a web pod so ReadWriteOnce volumes attach.
+func desiredRunJob(env *catalystv1alpha1.Environment, namespace string, run catalystv1alpha1.EnvironmentRun, web *appsv1.Deployment) *batchv1.Job {
+	podSpec := web.Spec.Template.Spec.DeepCopy()
+
+	var container corev1.Container
+	if len(podSpec.Containers) > 0 {
+		container = podSpec.Containers[0]
+		for _, c := range podSpec.Containers {
+			if c.Name == "app" {
+				container = c
+				break
+			}
+		}
+	}
+	container.Name = runContainerName
+	container.Command = run.Command
+	container.Args = nil
+	container.Ports = nil
+	container.LivenessProbe = nil
+	container.ReadinessProbe = nil
+	container.StartupProbe = nil
+	if run.Image != "" {
+		container.Image = run.Image
+	}
+
+	podSpec.Containers = []corev1.Container{container}
+	podSpec.InitContainers = nil
+	podSpec.RestartPolicy = corev1.RestartPolicyNever
+	for _, v := range podSpec.Volumes {
+		if v.PersistentVolumeClaim != nil && web.Spec.Selector != nil {
+			podSpec.Affinity = &corev1.Affinity{
+				PodAffinity: &corev1.PodAffinity{
+					RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{
+						{LabelSelector: web.Spec.Selector.DeepCopy(), TopologyKey: corev1.LabelHostname},
+					},
+				},
+			}
+			break
+		}
+	}
+
+	labels := map[string]string{
+		"catalyst.dev/environment": sanitizeLabelValue(env.Name),
+		runLabel:                   run.Name,
+	}
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      runJobName(run.Name),
+			Namespace: namespace,
+			Labels:    labels,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:            int32Ptr(0),
+			TTLSecondsAfterFinished: ptr(runJobTTL),
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec:       *podSpec,
+			},
+		},
+	}
+}
+
+// runStatusFromJob derives the run status from its Job and pods
+func runStatusFromJob(name string, job *batchv1.Job, pods []corev1.Pod) catalystv1alpha1.RunStatus {
+	status := catalystv1alpha1.RunStatus{
+		Name:      name,
+		Phase:     runPhasePending,
+		JobName:   job.Name,
+		StartTime: job.Status.StartTime,
+	}
+	switch {
+	case job.Status.Succeeded > 0:
+		status.Phase = runPhaseSucceeded
+	case job.Status.Failed > 0:
+		status.Phase = runPhaseFailed
+	case job.Status.Active > 0:
+		status.Phase = runPhaseRunning
+	}
+	for _, c := range job.Status.Conditions {
+		if c.Type == batchv1.JobFailed && c.Status == corev1.ConditionTrue {
+			status.Phase = runPhaseFailed
+			status.Message = c.Message
+		}
+	}
+
+	// The most recent pod holds the logs and the exit code
+	var latest *corev1.Pod
+	for i := range pods {
+		if latest == nil || latest.CreationTimestamp.Before(&pods[i].CreationTimestamp) {
+			latest = &pods[i]
+		}
+	}
+	if latest == nil {
+		return status
+	}
+	status.LogRef = latest.Namespace + "/" + latest.Name
+	for _, cs := range latest.Status.ContainerStatuses {
+		if cs.Name != runContainerName {
+			continue
+		}
+		if terminated := cs.State.Terminated; terminated != nil {
+			status.ExitCode = ptr(terminated.ExitCode)
+			if !terminated.StartedAt.IsZero() {
+				status.StartTime = ptr(terminated.StartedAt)
+				status.Duration = &metav1.Duration{Duration: terminated.FinishedAt.Sub(terminated.StartedAt.Time)}
+			}
+		} else if running := cs.State.Running; running != nil {
+			status.StartTime = ptr(running.StartedAt)
+		}
+	}
+	return status
+}
+
+// reconcileRuns starts a Job for every spec.runs entry that has not finished, records the
+// results in status.runs and deletes the Jobs of removed entries. It returns whether any run
+// is still pending or running, so the caller keeps polling.
+func (r *EnvironmentReconciler) reconcileRuns(ctx context.Context, env *catalystv1alpha1.Environment, namespace string) (bool, error) {
+	log := logf.FromContext(ctx)
+
+	var statuses []catalystv1alpha1.RunStatus
+	for _, run := range env.Spec.Runs {
+		if previous := findRunStatus(env.Status.Runs, run.Name); isRunFinished(previous) {
+			statuses = append(statuses, *previous)
+			continue
+		}
+		status := catalystv1alpha1.RunStatus{Name: run.Name, Phase: runPhasePending, JobName: runJobName(run.Name)}
+
+		job := &batchv1.Job{}
+		err := r.Get(ctx, client.ObjectKey{Name: runJobName(run.Name), Namespace: namespace}, job)
+		if apierrors.IsNotFound(err) {
+			web := &appsv1.Deployment{}
+			if err := r.Get(ctx, client.ObjectKey{Name: "web", Namespace: namespace}, web); err != nil {
+				if !apierrors.IsNotFound(err) {
+					return false, err
+				}
+				status.Message = "Waiting for the web Deployment"
+				statuses = append(statuses, status)
+				continue
+			}
+			job = desiredRunJob(env, namespace, run, web)
+
+			// Runs yield to the primary workload when the namespace quota is nearly full
+			if deferred, err := r.deferForQuota(ctx, env, namespace, "run "+run.Name, &job.Spec.Template.Spec); err != nil {
+				return false, err
+			} else if deferred {
+				status.Message = "Deferred until the namespace quota has headroom"
+				statuses = append(statuses, status)
+				continue
+			}
+
+			log.Info("Creating run Job", "run", run.Name, "job", job.Name, "command", run.Command)
+			if err := r.Create(ctx, job); err != nil && !isAlreadyExists(err) {
+				return false, fmt.Errorf("failed to create Job for run %s: %w", run.Name, err)
+			}
+			statuses = append(statuses, status)
+			continue
+		} else if err != nil {
+			return false, err
+		}
+
+		pods := &corev1.PodList{}
+		if err := r.List(ctx, pods, client.InNamespace(namespace), client.MatchingLabels{batchv1.JobNameLabel: job.Name}); err != nil {
+			return false, err
+		}
+		status = runStatusFromJob(run.Name, job, pods.Items)
+		if isRunFinished(&status) {
+			log.Info("Run finished", "run", run.Name, "phase", status.Phase, "exitCode", status.ExitCode)
+		}
+		statuses = append(statuses, status)
+	}
+
+	active := false
+	for i := range statuses {
+		if !isRunFinished(&statuses[i]) {
+			active = true
+		}
+	}
+
+	// Remove the Jobs of runs dropped from the spec
+	jobs := &batchv1.JobList{}
+	if err := r.List(ctx, jobs, client.InNamespace(namespace), client.HasLabels{runLabel}); err != nil {
+		return false, err
+	}
+	for i := range jobs.Items {
+		job := &jobs.Items[i]
+		if findRunStatus(statuses, job.Labels[runLabel]) != nil {
+			continue
+		}
+		log.Info("Deleting Job of removed run", "run", job.Labels[runLabel], "job", job.Name)
+		if err := r.Delete(ctx, job, client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil && !apierrors.IsNotFound(err) {
+			return false, err
+		}
+	}
+
+	if !equality.Semantic.DeepEqual(env.Status.Runs, statuses) {
+		env.Status.Runs = statuses
+		if err := r.Status().Update(ctx, env); err != nil {
+			return false, err
+		}
+	}
+	return active, nil
+}
@@ -0,0 +1,175 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+func runTestWebDeployment(namespace string) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: namespace},
+		Spec: appsv1.DeploymentSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					InitContainers: []corev1.Container{{Name: "git-clone", Image: gitCloneImage}},
+					Containers: []corev1.Container{{
+						Name:           "app",
+						Image:          "node:22-slim",
+						Command:        []string{"npm", "run", "dev"},
+						WorkingDir:     "/code",
+						Env:            []corev1.EnvVar{{Name: "DATABASE_URL", Value: "postgres://postgres/app"}},
+						VolumeMounts:   []corev1.VolumeMount{{Name: "code", MountPath: "/code"}},
+						Ports:          []corev1.ContainerPort{{ContainerPort: 3000}},
+						ReadinessProbe: &corev1.Probe{},
+					}},
+					Volumes: []corev1.Volume{{
+						Name:         "code",
+						VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "code"}},
+					}},
+				},
+			},
+		},
+	}
+}
+
+func TestDesiredRunJob(t *testing.T) {
+	env := &catalystv1alpha1.Environment{ObjectMeta: metav1.ObjectMeta{Name: "pr-1"}}
+	run := catalystv1alpha1.EnvironmentRun{Name: "e2e", Command: []string{"npm", "run", "test:e2e"}}
+
+	job := desiredRunJob(env, "env-ns", run, runTestWebDeployment("env-ns"))
+
+	assert.Equal(t, "run-e2e", job.Name)
+	assert.Equal(t, "e2e", job.Labels[runLabel])
+	assert.Equal(t, int32(0), *job.Spec.BackoffLimit)
+	spec := job.Spec.Template.Spec
+	assert.Equal(t, corev1.RestartPolicyNever, spec.RestartPolicy)
+	assert.Empty(t, spec.InitContainers)
+	require.Len(t, spec.Containers, 1)
+	c := spec.Containers[0]
+	assert.Equal(t, []string{"npm", "run", "test:e2e"}, c.Command)
+	assert.Equal(t, "node:22-slim", c.Image)
+	assert.Equal(t, "/code", c.WorkingDir)
+	assert.Equal(t, "DATABASE_URL", c.Env[0].Name)
+	assert.Equal(t, "code", c.VolumeMounts[0].Name)
+	assert.Nil(t, c.ReadinessProbe)
+	assert.Empty(t, c.Ports)
+
+	// ReadWriteOnce workspace volumes require the run to land on the web pod's node
+	require.NotNil(t, spec.Affinity)
+	term := spec.Affinity.PodAffinity.RequiredDuringSchedulingIgnoredDuringExecution[0]
+	assert.Equal(t, corev1.LabelHostname, term.TopologyKey)
+	assert.Equal(t, map[string]string{"app": "web"}, term.LabelSelector.MatchLabels)
+
+	run.Image = "mcr.microsoft.com/playwright:v1.48.0"
+	job = desiredRunJob(env, "env-ns", run, runTestWebDeployment("env-ns"))
+	assert.Equal(t, "mcr.microsoft.com/playwright:v1.48.0", job.Spec.Template.Spec.Containers[0].Image)
+}
+
+func TestRunStatusFromJob(t *testing.T) {
+	started := metav1.NewTime(time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC))
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{Name: "run-e2e"},
+		Status: batchv1.JobStatus{
+			Failed: 1,
+			Conditions: []batchv1.JobCondition{
+				{Type: batchv1.JobFailed, Status: corev1.ConditionTrue, Message: "Job has reached the specified backoff limit"},
+			},
+		},
+	}
+	pod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "run-e2e-abc12", Namespace: "env-ns"},
+		Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{
+			Name: runContainerName,
+			State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+				ExitCode:   2,
+				StartedAt:  started,
+				FinishedAt: metav1.NewTime(started.Add(90 * time.Second)),
+			}},
+		}}},
+	}
+
+	status := runStatusFromJob("e2e", job, []corev1.Pod{pod})
+
+	assert.Equal(t, runPhaseFailed, status.Phase)
+	assert.Equal(t, "Job has reached the specified backoff limit", status.Message)
+	require.NotNil(t, status.ExitCode)
+	assert.Equal(t, int32(2), *status.ExitCode)
+	assert.Equal(t, 90*time.Second, status.Duration.Duration)
+	assert.Equal(t, "env-ns/run-e2e-abc12", status.LogRef)
+
+	// Without pods only the Job phase is known
+	status = runStatusFromJob("e2e", &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "run-e2e"}, Status: batchv1.JobStatus{Active: 1}}, nil)
+	assert.Equal(t, runPhaseRunning, status.Phase)
+	assert.Nil(t, status.ExitCode)
+}
+
+func TestReconcileRuns(t *testing.T) {
+	env := &catalystv1alpha1.Environment{
+		ObjectMeta: metav1.ObjectMeta{Name: "pr-1", Namespace: "team"},
+		Spec: catalystv1alpha1.EnvironmentSpec{
+			Runs: []catalystv1alpha1.EnvironmentRun{{Name: "e2e", Command: []string{"npm", "run", "test:e2e"}}},
+		},
+	}
+	c := newFakeClientBuilder().WithStatusSubresource(env).WithObjects(env).Build()
+	r := &EnvironmentReconciler{Client: c, Scheme: testScheme}
+	ctx := context.Background()
+
+	// The run waits for the web Deployment to take its pod template from
+	active, err := r.reconcileRuns(ctx, env, "env-ns")
+	require.NoError(t, err)
+	assert.True(t, active)
+	require.Len(t, env.Status.Runs, 1)
+	assert.Equal(t, runPhasePending, env.Status.Runs[0].Phase)
+	assert.Equal(t, "Waiting for the web Deployment", env.Status.Runs[0].Message)
+
+	require.NoError(t, c.Create(ctx, runTestWebDeployment("env-ns")))
+	active, err = r.reconcileRuns(ctx, env, "env-ns")
+	require.NoError(t, err)
+	assert.True(t, active)
+	job := &batchv1.Job{}
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "run-e2e", Namespace: "env-ns"}, job))
+
+	// Record the result once the Job succeeded
+	job.Status.Succeeded = 1
+	require.NoError(t, c.Status().Update(ctx, job))
+	started := metav1.NewTime(time.Now().Add(-time.Minute).Truncate(time.Second))
+	require.NoError(t, c.Create(ctx, &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "run-e2e-x1", Namespace: "env-ns", Labels: map[string]string{batchv1.JobNameLabel: "run-e2e"}},
+		Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{
+			Name: runContainerName,
+			State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+				StartedAt: started, FinishedAt: metav1.NewTime(started.Add(30 * time.Second)),
+			}},
+		}}},
+	}))
+	active, err = r.reconcileRuns(ctx, env, "env-ns")
+	require.NoError(t, err)
+	assert.False(t, active)
+	status := env.Status.Runs[0]
+	assert.Equal(t, runPhaseSucceeded, status.Phase)
+	assert.Equal(t, int32(0), *status.ExitCode)
+	assert.Equal(t, 30*time.Second, status.Duration.Duration)
+	assert.Equal(t, "env-ns/run-e2e-x1", status.LogRef)
+
+	// Removing the run deletes its Job and status
+	env.Spec.Runs = nil
+	active, err = r.reconcileRuns(ctx, env, "env-ns")
+	require.NoError(t, err)
+	assert.False(t, active)
+	assert.Empty(t, env.Status.Runs)
+	err = c.Get(ctx, client.ObjectKey{Name: "run-e2e", Namespace: "env-ns"}, &batchv1.Job{})
+	assert.True(t, apierrors.IsNotFound(err))
+}
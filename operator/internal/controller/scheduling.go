@@ -0,0 +1,85 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"maps"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+// Environment scheduling (config.nodeSelector, tolerations, affinity and arch):
+// The pods the operator generates for an environment (web, managed services, poolers, seed
+// Jobs and compose services) are placed on the configured nodes. Helm and kustomize manifests
+// keep their own scheduling, and provider-backed Postgres clusters are scheduled by their
+// operator. Builds are scheduled by their podOverrides (see build_scheduling.go).
+
+// applyNodePlacement merges a node selector, CPU architecture, tolerations and affinity into a
+// pod spec. Keys of nodeSelector win, tolerations are added, and the parts of affinity that are
+// set replace those of the spec.
+func applyNodePlacement(spec *corev1.PodSpec, nodeSelector map[string]string, arch string, tolerations []corev1.Toleration, affinity *corev1.Affinity) {
+	if len(nodeSelector) > 0 || arch != "" {
+		if spec.NodeSelector == nil {
+			spec.NodeSelector = map[string]string{}
+		}
+		maps.Copy(spec.NodeSelector, nodeSelector)
+		if arch != "" {
+			spec.NodeSelector[corev1.LabelArchStable] = arch
+		}
+	}
+	for _, toleration := range tolerations {
+		spec.Tolerations = append(spec.Tolerations, *toleration.DeepCopy())
+	}
+	if affinity == nil {
+		return
+	}
+	if spec.Affinity == nil {
+		spec.Affinity = &corev1.Affinity{}
+	}
+	if affinity.NodeAffinity != nil {
+		spec.Affinity.NodeAffinity = affinity.NodeAffinity.DeepCopy()
+	}
+	if affinity.PodAffinity != nil {
+		spec.Affinity.PodAffinity = affinity.PodAffinity.DeepCopy()
+	}
+	if affinity.PodAntiAffinity != nil {
+		spec.Affinity.PodAntiAffinity = affinity.PodAntiAffinity.DeepCopy()
+	}
+}
+
+// scheduleEnvironmentWorkload places a workload's pods on the nodes of the resolved config
+func scheduleEnvironmentWorkload(config *catalystv1alpha1.EnvironmentConfig, obj client.Object) {
+	var spec *corev1.PodSpec
+	switch w := obj.(type) {
+	case *corev1.Pod:
+		spec = &w.Spec
+	case *appsv1.Deployment:
+		spec = &w.Spec.Template.Spec
+	case *appsv1.StatefulSet:
+		spec = &w.Spec.Template.Spec
+	case *batchv1.Job:
+		spec = &w.Spec.Template.Spec
+	default:
+		return
+	}
+	applyNodePlacement(spec, config.NodeSelector, config.Arch, config.Tolerations, config.Affinity)
+}
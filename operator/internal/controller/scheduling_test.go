@@ -0,0 +1,44 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+func TestScheduleEnvironmentWorkload(t *testing.T) {
+	config := &catalystv1alpha1.EnvironmentConfig{
+		NodeSelector: map[string]string{"catalyst.dev/pool": "previews"},
+		Arch:         "arm64",
+		Tolerations:  []corev1.Toleration{{Key: "previews", Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule}},
+		Affinity: &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+				MatchExpressions: []corev1.NodeSelectorRequirement{{Key: "disk", Operator: corev1.NodeSelectorOpIn, Values: []string{"ssd"}}},
+			}}},
+		}},
+	}
+	deployment := desiredDeploymentFromConfig("ns", &catalystv1alpha1.EnvironmentConfig{Image: "app:1"})
+	deployment.Spec.Template.Spec.Affinity = spreadAffinity(deployment.Spec.Selector.MatchLabels)
+
+	scheduleEnvironmentWorkload(config, deployment)
+	spec := deployment.Spec.Template.Spec
+	assert.Equal(t, map[string]string{"catalyst.dev/pool": "previews", "kubernetes.io/arch": "arm64"}, spec.NodeSelector)
+	assert.Equal(t, config.Tolerations, spec.Tolerations)
+	assert.Equal(t, config.Affinity.NodeAffinity, spec.Affinity.NodeAffinity)
+	assert.NotNil(t, spec.Affinity.PodAntiAffinity, "the production spreading is kept without a pod anti-affinity")
+
+	// A configured pod anti-affinity replaces the spreading
+	config.Affinity.PodAntiAffinity = &corev1.PodAntiAffinity{}
+	scheduleEnvironmentWorkload(config, deployment)
+	assert.Equal(t, &corev1.PodAntiAffinity{}, deployment.Spec.Template.Spec.Affinity.PodAntiAffinity)
+
+	// Without scheduling config, pods are left to the scheduler
+	statefulSet := desiredManagedServiceStatefulSet("ns", catalystv1alpha1.ManagedServiceSpec{Name: "redis", Container: catalystv1alpha1.ManagedServiceContainer{Image: "redis:7"}})
+	scheduleEnvironmentWorkload(&catalystv1alpha1.EnvironmentConfig{}, statefulSet)
+	assert.Nil(t, statefulSet.Spec.Template.Spec.NodeSelector)
+	assert.Nil(t, statefulSet.Spec.Template.Spec.Tolerations)
+	assert.Nil(t, statefulSet.Spec.Template.Spec.Affinity)
+}
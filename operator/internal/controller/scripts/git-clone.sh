@@ -11,6 +11,9 @@
 #   GIT_CLONE_DEST     - Destination subdirectory (e.g., source)
 #   INSTALLATION_ID    - GitHub App installation ID
 #   CATALYST_WEB_URL   - URL of the Catalyst web server (optional)
+#   GIT_TOKEN          - Access token replacing the installation (see git-credential-catalyst.sh)
+#   GIT_LFS            - "true" downloads Git LFS objects (needs git-lfs in the image)
+#   GIT_SUBMODULES     - "init" or "recursive" checks out submodules
 #
 # The credential helper script must be mounted at /scripts/git-credential-catalyst.sh
 
@@ -24,9 +27,21 @@ if [ ! -f /scripts/git-credential-catalyst.sh ]; then
     exit 1
 fi
 
+# Pods run as an arbitrary non-root user on a read-only root filesystem
+[ -w "${HOME:-/}" ] || export HOME=/tmp
+
 # Configure git to use the credential helper
 # Note: The script should already be executable via ConfigMap defaultMode
-git config --global credential.helper /scripts/git-credential-catalyst.sh
+REPO_HOST=$(echo "$GIT_REPO_URL" | sed -E -n 's#^https?://([^/@]*@)?([^/]*).*#\2#p')
+if [ -n "$REPO_HOST" ]; then
+    # Credentials only go to the repository host, which private submodules on it reuse;
+    # their SSH URLs are fetched over HTTPS with the same credentials
+    git config --global credential."https://$REPO_HOST".helper /scripts/git-credential-catalyst.sh
+    git config --global url."https://$REPO_HOST/".insteadOf "git@$REPO_HOST:"
+    git config --global --add url."https://$REPO_HOST/".insteadOf "ssh://git@$REPO_HOST/"
+else
+    git config --global credential.helper /scripts/git-credential-catalyst.sh
+fi
 echo "Git credential helper configured"
 
 # Validate required environment variables
@@ -51,7 +66,19 @@ echo "Commit: $GIT_COMMIT"
 echo "Destination: $CLONE_PATH"
 
 # Handle existing directories
-if [ -d "$CLONE_PATH/.git" ]; then
+if [ -d "$CLONE_PATH/.git" ] && [ -f "$CLONE_PATH/.catalyst-prebuild" ]; then
+    # Cloned from a prebuilt workspace — check out the requested revision once, keeping
+    # the dependencies and build outputs of the prebuild
+    echo "Prebuilt workspace found at $CLONE_PATH, checking out $GIT_COMMIT..."
+    cd "$CLONE_PATH"
+    git fetch origin
+    if git rev-parse --verify -q "origin/$GIT_COMMIT" >/dev/null; then
+        git checkout -B "$GIT_COMMIT" "origin/$GIT_COMMIT"
+    else
+        git checkout "$GIT_COMMIT"
+    fi
+    rm -f .catalyst-prebuild
+elif [ -d "$CLONE_PATH/.git" ]; then
     # Already cloned — fetch to update refs only, preserving local changes
     echo "Existing git repository found at $CLONE_PATH, fetching updates..."
     cd "$CLONE_PATH"
@@ -67,6 +94,28 @@ else
     git clone "$GIT_REPO_URL" "$CLONE_PATH"
     cd "$CLONE_PATH"
     git checkout "$GIT_COMMIT"
+
+    case "$GIT_SUBMODULES" in
+        recursive)
+            echo "=== Checking out submodules (recursive) ==="
+            git submodule update --init --recursive
+            ;;
+        init)
+            echo "=== Checking out submodules ==="
+            git submodule update --init
+            ;;
+    esac
+
+    if [ "$GIT_LFS" = "true" ]; then
+        echo "=== Downloading Git LFS objects ==="
+        if ! command -v git-lfs >/dev/null 2>&1; then
+            echo "Error: lfs is enabled but git-lfs is not installed in the git-clone image (GIT_CLONE_IMAGE)" >&2
+            exit 1
+        fi
+        git lfs install --local
+        git lfs pull
+        git submodule foreach --recursive 'git lfs install --local && git lfs pull'
+    fi
 fi
 
 echo "=== Clone Complete ==="
@@ -11,6 +11,12 @@
 #   INSTALLATION_ID    - GitHub App installation ID
 #   CATALYST_WEB_URL   - URL of the Catalyst web server (optional, has default)
 #
+# GitLab and Bitbucket sources set these instead, from the short-lived token the
+# operator minted into the namespace, and the token is returned as is:
+#   GIT_TOKEN            - Access token
+#   GIT_USERNAME         - Username (optional, defaults to GIT_DEFAULT_USERNAME)
+#   GIT_DEFAULT_USERNAME - Provider default, e.g. oauth2 (GitLab), x-token-auth (Bitbucket)
+#
 # Usage: Configure via git config:
 #   git config --global credential.helper /scripts/git-credential-catalyst.sh
 
@@ -33,6 +39,13 @@ while IFS= read -r line; do
     [ -z "$line" ] && break
 done
 
+# Static token from the source's credentials Secret
+if [ -n "$GIT_TOKEN" ]; then
+    echo "username=${GIT_USERNAME:-${GIT_DEFAULT_USERNAME:-x-access-token}}"
+    echo "password=$GIT_TOKEN"
+    exit 0
+fi
+
 # Read pod's ServiceAccount token for authentication
 SA_TOKEN=$(cat /var/run/secrets/kubernetes.io/serviceaccount/token 2>/dev/null)
 if [ -z "$SA_TOKEN" ]; then
@@ -0,0 +1,199 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+// Managed service seeding (spec.services[].seed):
+//   - dumpURL (postgres-only): once the StatefulSet is ready, the "<service>-seed" Job downloads the dump
+//     (aws CLI for s3://, curl for https://) and restores it with the service image, so the
+//     client tools match the server version. The web Deployment is only created after the Job
+//     succeeded; the finished Job is kept as the record that the database was seeded.
+//   - volumeSnapshot: the data volume claim is created from the snapshot, so the data is in
+//     place before the service starts and no Job is needed. Clones (spec.cloneFrom) use this
+//     to start from a snapshot of the source's volume.
+
+const (
+	seedS3Image   = "amazon/aws-cli:2.17.0"
+	seedHTTPImage = "curlimages/curl:8.8.0"
+	seedDumpDir   = "/seed"
+
+	volumeSnapshotAPIGroup = "snapshot.storage.k8s.io"
+)
+
+// seedJobName returns the seed Job name for a managed service
+func seedJobName(svcSpec catalystv1alpha1.ManagedServiceSpec) string {
+	return svcSpec.Name + "-seed"
+}
+
+// seedRestoreScript waits for the server and restores the downloaded dump: pg_restore for
+// custom-format archives, psql for plain SQL
+const seedRestoreScript = `set -eu
+dump=` + seedDumpDir + `/dump
+case "$SEED_DUMP_URL" in
+  *.gz) gunzip -c "$dump" > "$dump.raw"; dump="$dump.raw" ;;
+esac
+until pg_isready -q; do sleep 2; done
+if [ "$(head -c 5 "$dump")" = "PGDMP" ]; then
+  pg_restore --no-owner --no-privileges --exit-on-error -d "$PGDATABASE" "$dump"
+else
+  psql -v ON_ERROR_STOP=1 -f "$dump"
+fi
+`
+
+// serviceEnvVar copies an env var of the managed service container under another name,
+// keeping valueFrom references, or returns a literal fallback
+func serviceEnvVar(svcSpec catalystv1alpha1.ManagedServiceSpec, from, to, fallback string) corev1.EnvVar {
+	for _, e := range svcSpec.Container.Env {
+		if e.Name == from && (e.Value != "" || e.ValueFrom != nil) {
+			return corev1.EnvVar{Name: to, Value: e.Value, ValueFrom: e.ValueFrom.DeepCopy()}
+		}
+	}
+	return corev1.EnvVar{Name: to, Value: fallback}
+}
+
+// seedVolumeDataSource points a data volume claim at the golden VolumeSnapshot.
+// Same-namespace snapshots use dataSource; "namespace/name" needs dataSourceRef.
+func seedVolumeDataSource(spec *corev1.PersistentVolumeClaimSpec, snapshot string) {
+	apiGroup := volumeSnapshotAPIGroup
+	namespace, name, crossNamespace := strings.Cut(snapshot, "/")
+	if !crossNamespace {
+		spec.DataSource = &corev1.TypedLocalObjectReference{APIGroup: &apiGroup, Kind: "VolumeSnapshot", Name: snapshot}
+		return
+	}
+	spec.DataSourceRef = &corev1.TypedObjectReference{APIGroup: &apiGroup, Kind: "VolumeSnapshot", Name: name, Namespace: &namespace}
+}
+
+// desiredSeedJob restores seed.dumpURL into the managed Postgres
+func desiredSeedJob(namespace string, svcSpec catalystv1alpha1.ManagedServiceSpec) *batchv1.Job {
+	seed := svcSpec.Seed
+	user := managedServiceEnv(svcSpec, "POSTGRES_USER", "postgres")
+	database := svcSpec.Database
+	if database == "" {
+		database = managedServiceEnv(svcSpec, "POSTGRES_DB", user)
+	}
+
+	download := corev1.Container{
+		Name:  "download",
+		Image: seedHTTPImage,
+		Args:  []string{"-fsSL", "-o", seedDumpDir + "/dump", seed.DumpURL},
+	}
+	if strings.HasPrefix(seed.DumpURL, "s3://") {
+		download.Image = seedS3Image
+		download.Args = []string{"s3", "cp", seed.DumpURL, seedDumpDir + "/dump"}
+	}
+	if seed.CredentialsSecret != "" {
+		download.EnvFrom = []corev1.EnvFromSource{{
+			SecretRef: &corev1.SecretEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: seed.CredentialsSecret}},
+		}}
+	}
+	dumpMount := []corev1.VolumeMount{{Name: "dump", MountPath: seedDumpDir}}
+	download.VolumeMounts = dumpMount
+
+	restore := corev1.Container{
+		Name:    "restore",
+		Image:   svcSpec.Container.Image,
+		Command: []string{"sh", "-c", seedRestoreScript},
+		Env: []corev1.EnvVar{
+			{Name: "SEED_DUMP_URL", Value: seed.DumpURL},
+			{Name: "PGHOST", Value: svcSpec.Name},
+			{Name: "PGPORT", Value: fmt.Sprintf("%d", managedServicePort(svcSpec))},
+			serviceEnvVar(svcSpec, "POSTGRES_USER", "PGUSER", "postgres"),
+			serviceEnvVar(svcSpec, "POSTGRES_PASSWORD", "PGPASSWORD", ""),
+			{Name: "PGDATABASE", Value: database},
+		},
+		VolumeMounts: dumpMount,
+	}
+
+	labels := map[string]string{
+		"app":                          seedJobName(svcSpec),
+		"app.kubernetes.io/managed-by": "catalyst-operator",
+	}
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      seedJobName(svcSpec),
+			Namespace: namespace,
+			Labels:    labels,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: int32Ptr(2),
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					RestartPolicy:  corev1.RestartPolicyNever,
+					InitContainers: []corev1.Container{download},
+					Containers:     []corev1.Container{restore},
+					Volumes: []corev1.Volume{{
+						Name:         "dump",
+						VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+					}},
+				},
+			},
+		},
+	}
+	applyPodSecurity(&job.Spec.Template.Spec)
+	return job
+}
+
+// reconcileSeed runs the seed Job of a managed service and reports whether seeding finished.
+// Services without a dump to restore are ready immediately.
+func (r *EnvironmentReconciler) reconcileSeed(ctx context.Context, env *catalystv1alpha1.Environment, namespace string, config *catalystv1alpha1.EnvironmentConfig, svcSpec catalystv1alpha1.ManagedServiceSpec) (bool, error) {
+	if svcSpec.Seed == nil || svcSpec.Seed.DumpURL == "" {
+		return true, nil
+	}
+	log := logf.FromContext(ctx)
+
+	job := &batchv1.Job{}
+	if err := r.Get(ctx, client.ObjectKey{Name: seedJobName(svcSpec), Namespace: namespace}, job); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return false, err
+		}
+		job = desiredSeedJob(namespace, svcSpec)
+		labelEnvironmentWorkload(env, job)
+		prioritizeEnvironmentWorkload(env, job)
+		scheduleEnvironmentWorkload(config, job)
+		log.Info("Creating seed Job", "service", svcSpec.Name, "dumpURL", svcSpec.Seed.DumpURL)
+		if err := r.Create(ctx, job); err != nil && !isAlreadyExists(err) {
+			return false, fmt.Errorf("failed to create seed Job for %s: %w", svcSpec.Name, err)
+		}
+		return false, nil
+	}
+
+	if job.Status.Succeeded > 0 {
+		return true, nil
+	}
+	for _, c := range job.Status.Conditions {
+		if c.Type == batchv1.JobFailed && c.Status == corev1.ConditionTrue {
+			return false, fmt.Errorf("seed Job for %s failed (delete it to retry): %s", svcSpec.Name, c.Message)
+		}
+	}
+	log.Info("Waiting for seed Job", "service", svcSpec.Name, "job", job.Name)
+	return false, nil
+}
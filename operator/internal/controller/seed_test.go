@@ -0,0 +1,112 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+func postgresWithSeed(seed *catalystv1alpha1.ServiceSeedSpec) catalystv1alpha1.ManagedServiceSpec {
+	return catalystv1alpha1.ManagedServiceSpec{
+		Name:     "postgres",
+		Database: "app",
+		Container: catalystv1alpha1.ManagedServiceContainer{
+			Image: "postgres:16",
+			Env: []corev1.EnvVar{
+				{Name: "POSTGRES_USER", Value: "app"},
+				{Name: "POSTGRES_PASSWORD", ValueFrom: &corev1.EnvVarSource{
+					SecretKeyRef: &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "db"}, Key: "password"},
+				}},
+			},
+		},
+		Storage: &corev1.PersistentVolumeClaimSpec{
+			Resources: corev1.VolumeResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("1Gi")}},
+		},
+		Seed: seed,
+	}
+}
+
+func TestDesiredSeedJob(t *testing.T) {
+	svc := postgresWithSeed(&catalystv1alpha1.ServiceSeedSpec{DumpURL: "s3://qa-fixtures/app.dump.gz", CredentialsSecret: "fixtures-s3"})
+
+	job := desiredSeedJob("test-ns", svc)
+
+	assert.Equal(t, "postgres-seed", job.Name)
+	spec := job.Spec.Template.Spec
+	download := spec.InitContainers[0]
+	assert.Equal(t, seedS3Image, download.Image)
+	assert.Equal(t, []string{"s3", "cp", "s3://qa-fixtures/app.dump.gz", "/seed/dump"}, download.Args)
+	assert.Equal(t, "fixtures-s3", download.EnvFrom[0].SecretRef.Name)
+
+	restore := spec.Containers[0]
+	assert.Equal(t, "postgres:16", restore.Image, "client tools match the server version")
+	env := map[string]corev1.EnvVar{}
+	for _, e := range restore.Env {
+		env[e.Name] = e
+	}
+	assert.Equal(t, "postgres", env["PGHOST"].Value)
+	assert.Equal(t, "5432", env["PGPORT"].Value)
+	assert.Equal(t, "app", env["PGUSER"].Value)
+	assert.Equal(t, "app", env["PGDATABASE"].Value)
+	require.NotNil(t, env["PGPASSWORD"].ValueFrom)
+	assert.Equal(t, "db", env["PGPASSWORD"].ValueFrom.SecretKeyRef.Name)
+
+	job = desiredSeedJob("test-ns", postgresWithSeed(&catalystv1alpha1.ServiceSeedSpec{DumpURL: "https://fixtures.example.com/app.sql"}))
+	assert.Equal(t, seedHTTPImage, job.Spec.Template.Spec.InitContainers[0].Image)
+	assert.Empty(t, job.Spec.Template.Spec.InitContainers[0].EnvFrom)
+}
+
+func TestSeedVolumeSnapshot(t *testing.T) {
+	sts := desiredManagedServiceStatefulSet("test-ns", postgresWithSeed(&catalystv1alpha1.ServiceSeedSpec{VolumeSnapshot: "golden"}))
+	claim := sts.Spec.VolumeClaimTemplates[0].Spec
+	require.NotNil(t, claim.DataSource)
+	assert.Equal(t, "VolumeSnapshot", claim.DataSource.Kind)
+	assert.Equal(t, "golden", claim.DataSource.Name)
+	assert.Equal(t, volumeSnapshotAPIGroup, *claim.DataSource.APIGroup)
+
+	// Snapshots in another namespace need dataSourceRef
+	sts = desiredManagedServiceStatefulSet("test-ns", postgresWithSeed(&catalystv1alpha1.ServiceSeedSpec{VolumeSnapshot: "fixtures/golden"}))
+	claim = sts.Spec.VolumeClaimTemplates[0].Spec
+	assert.Nil(t, claim.DataSource)
+	require.NotNil(t, claim.DataSourceRef)
+	assert.Equal(t, "golden", claim.DataSourceRef.Name)
+	assert.Equal(t, "fixtures", *claim.DataSourceRef.Namespace)
+}
+
+func TestReconcileSeed(t *testing.T) {
+	c := newFakeClientBuilder().Build()
+	r := &EnvironmentReconciler{Client: c, Scheme: testScheme}
+	ctx := context.Background()
+
+	// Snapshot seeding needs no Job
+	seeded, err := r.reconcileSeed(ctx, &catalystv1alpha1.Environment{}, "test-ns", &catalystv1alpha1.EnvironmentConfig{}, postgresWithSeed(&catalystv1alpha1.ServiceSeedSpec{VolumeSnapshot: "golden"}))
+	require.NoError(t, err)
+	assert.True(t, seeded)
+
+	svc := postgresWithSeed(&catalystv1alpha1.ServiceSeedSpec{DumpURL: "https://fixtures.example.com/app.sql"})
+	seeded, err = r.reconcileSeed(ctx, &catalystv1alpha1.Environment{}, "test-ns", &catalystv1alpha1.EnvironmentConfig{}, svc)
+	require.NoError(t, err)
+	assert.False(t, seeded)
+
+	job := &batchv1.Job{}
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "postgres-seed", Namespace: "test-ns"}, job))
+	job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: corev1.ConditionTrue, Message: "BackoffLimitExceeded"}}
+	require.NoError(t, c.Status().Update(ctx, job))
+	_, err = r.reconcileSeed(ctx, &catalystv1alpha1.Environment{}, "test-ns", &catalystv1alpha1.EnvironmentConfig{}, svc)
+	require.ErrorContains(t, err, "BackoffLimitExceeded")
+
+	job.Status.Conditions = nil
+	job.Status.Succeeded = 1
+	require.NoError(t, c.Status().Update(ctx, job))
+	seeded, err = r.reconcileSeed(ctx, &catalystv1alpha1.Environment{}, "test-ns", &catalystv1alpha1.EnvironmentConfig{}, svc)
+	require.NoError(t, err)
+	assert.True(t, seeded)
+}
@@ -0,0 +1,153 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+// servicePreset is the built-in definition a managed service preset expands into
+type servicePreset struct {
+	container catalystv1alpha1.ManagedServiceContainer
+	// storage is the size of the data volume, empty for services that keep no data
+	storage string
+	// dataPath is where the service keeps its data: the data volume, or an emptyDir
+	dataPath string
+}
+
+// servicePresets are the managed service presets by name. Credentials are development
+// defaults; override them through the service env.
+var servicePresets = map[string]servicePreset{
+	"redis": {
+		container: catalystv1alpha1.ManagedServiceContainer{
+			Image: "redis:7.4-alpine",
+			Ports: []corev1.ContainerPort{{Name: "redis", ContainerPort: 6379, Protocol: corev1.ProtocolTCP}},
+			ReadinessProbe: &corev1.Probe{
+				ProbeHandler:  corev1.ProbeHandler{Exec: &corev1.ExecAction{Command: []string{"redis-cli", "ping"}}},
+				PeriodSeconds: 5,
+			},
+		},
+		storage:  "1Gi",
+		dataPath: "/data",
+	},
+	"minio": {
+		container: catalystv1alpha1.ManagedServiceContainer{
+			Image: "minio/minio:RELEASE.2024-12-18T13-15-44Z",
+			Args:  []string{"server", "/data", "--console-address", ":9001"},
+			Ports: []corev1.ContainerPort{
+				{Name: "s3", ContainerPort: 9000, Protocol: corev1.ProtocolTCP},
+				{Name: "console", ContainerPort: 9001, Protocol: corev1.ProtocolTCP},
+			},
+			Env: []corev1.EnvVar{
+				{Name: "MINIO_ROOT_USER", Value: "minio"},
+				{Name: "MINIO_ROOT_PASSWORD", Value: "minio-password"},
+			},
+			ReadinessProbe: &corev1.Probe{
+				ProbeHandler: corev1.ProbeHandler{
+					HTTPGet: &corev1.HTTPGetAction{Path: "/minio/health/ready", Port: intstr.FromInt(9000)},
+				},
+				PeriodSeconds: 5,
+			},
+		},
+		storage:  "5Gi",
+		dataPath: "/data",
+	},
+	"mailpit": {
+		container: catalystv1alpha1.ManagedServiceContainer{
+			Image: "axllent/mailpit:v1.21",
+			Ports: []corev1.ContainerPort{
+				{Name: "smtp", ContainerPort: 1025, Protocol: corev1.ProtocolTCP},
+				{Name: "http", ContainerPort: 8025, Protocol: corev1.ProtocolTCP},
+			},
+			ReadinessProbe: &corev1.Probe{
+				ProbeHandler: corev1.ProbeHandler{
+					HTTPGet: &corev1.HTTPGetAction{Path: "/readyz", Port: intstr.FromInt(8025)},
+				},
+				PeriodSeconds: 5,
+			},
+		},
+	},
+}
+
+// expandServicePresets returns services with their presets expanded: fields the service
+// leaves unset come from the preset, and preset env vars the service does not set are
+// appended. Services without a known preset are returned as is; input is not modified.
+func expandServicePresets(services []catalystv1alpha1.ManagedServiceSpec) []catalystv1alpha1.ManagedServiceSpec {
+	if len(services) == 0 {
+		return services
+	}
+	result := make([]catalystv1alpha1.ManagedServiceSpec, len(services))
+	for i, svc := range services {
+		result[i] = *svc.DeepCopy()
+		preset, ok := servicePresets[svc.Preset]
+		if !ok {
+			continue
+		}
+		expanded := &result[i]
+		defaults := preset.container.DeepCopy()
+		if expanded.Container.Image == "" {
+			expanded.Container.Image = defaults.Image
+		}
+		if len(expanded.Container.Args) == 0 {
+			expanded.Container.Args = defaults.Args
+		}
+		if len(expanded.Container.Ports) == 0 {
+			expanded.Container.Ports = defaults.Ports
+		}
+		for _, e := range defaults.Env {
+			if !hasEnvVar(expanded.Container.Env, e.Name) {
+				expanded.Container.Env = append(expanded.Container.Env, e)
+			}
+		}
+		if expanded.Container.ReadinessProbe == nil {
+			expanded.Container.ReadinessProbe = defaults.ReadinessProbe
+		}
+		if expanded.Storage == nil && preset.storage != "" {
+			expanded.Storage = &corev1.PersistentVolumeClaimSpec{
+				AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+				Resources: corev1.VolumeResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(preset.storage)},
+				},
+			}
+		}
+	}
+	return result
+}
+
+// managedServiceDataPath returns where a managed service keeps its data
+func managedServiceDataPath(svcSpec catalystv1alpha1.ManagedServiceSpec) string {
+	if preset, ok := servicePresets[svcSpec.Preset]; ok && preset.dataPath != "" {
+		return preset.dataPath
+	}
+	if isPostgresService(svcSpec) {
+		return "/var/lib/postgresql/data"
+	}
+	return "/var/lib/" + svcSpec.Name
+}
+
+func hasEnvVar(envVars []corev1.EnvVar, name string) bool {
+	for _, e := range envVars {
+		if e.Name == name {
+			return true
+		}
+	}
+	return false
+}
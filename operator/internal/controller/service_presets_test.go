@@ -0,0 +1,71 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+func TestExpandServicePresets(t *testing.T) {
+	services := []catalystv1alpha1.ManagedServiceSpec{
+		{Name: "cache", Preset: "redis"},
+		{
+			Name:   "s3",
+			Preset: "minio",
+			Container: catalystv1alpha1.ManagedServiceContainer{
+				Env: []corev1.EnvVar{{Name: "MINIO_ROOT_PASSWORD", Value: "from-template"}},
+			},
+		},
+		{Name: "mail", Preset: "mailpit"},
+		{Name: "postgres", Container: catalystv1alpha1.ManagedServiceContainer{Image: "postgres:16"}},
+	}
+
+	expanded := expandServicePresets(services)
+	require.Len(t, expanded, 4)
+	assert.Empty(t, services[0].Container.Image, "input is not modified")
+
+	redis := expanded[0]
+	assert.Equal(t, "redis:7.4-alpine", redis.Container.Image)
+	assert.Equal(t, int32(6379), redis.Container.Ports[0].ContainerPort)
+	assert.NotNil(t, redis.Container.ReadinessProbe.Exec)
+	require.NotNil(t, redis.Storage)
+	assert.True(t, redis.Storage.Resources.Requests.Storage().Equal(resource.MustParse("1Gi")))
+
+	minio := expanded[1]
+	assert.Equal(t, []string{"server", "/data", "--console-address", ":9001"}, minio.Container.Args)
+	assert.Equal(t, []corev1.EnvVar{
+		{Name: "MINIO_ROOT_PASSWORD", Value: "from-template"},
+		{Name: "MINIO_ROOT_USER", Value: "minio"},
+	}, minio.Container.Env)
+
+	assert.Nil(t, expanded[2].Storage, "mailpit keeps no data")
+	assert.Equal(t, services[3], expanded[3])
+}
+
+func TestDesiredManagedServiceStatefulSetPreset(t *testing.T) {
+	services := expandServicePresets([]catalystv1alpha1.ManagedServiceSpec{
+		{Name: "cache", Preset: "redis"},
+		{Name: "mail", Preset: "mailpit"},
+	})
+
+	redis := desiredManagedServiceStatefulSet("env-ns", services[0])
+	container := redis.Spec.Template.Spec.Containers[0]
+	assert.Equal(t, "/data", container.VolumeMounts[0].MountPath)
+	assert.Equal(t, "cache-data", container.VolumeMounts[0].Name)
+	assert.NotNil(t, container.ReadinessProbe)
+	assert.Len(t, redis.Spec.VolumeClaimTemplates, 1)
+
+	// Without a data volume the data path is still writable
+	mailpit := desiredManagedServiceStatefulSet("env-ns", services[1])
+	assert.True(t, hasMountPath(mailpit.Spec.Template.Spec.Containers[0].VolumeMounts, "/tmp"))
+
+	service := desiredManagedServiceService("env-ns", services[1])
+	require.Len(t, service.Spec.Ports, 2)
+	assert.Equal(t, "smtp", service.Spec.Ports[0].Name)
+	assert.Equal(t, "http", service.Spec.Ports[1].Name)
+}
@@ -0,0 +1,44 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+	"github.com/ncrmro/catalyst/operator/internal/sharding"
+)
+
+// ownsProject reports whether this operator instance reconciles the Project: it belongs to
+// the shard, and its namespace matches the shard's namespace selector. A Project whose
+// namespace is gone is not owned.
+func ownsProject(ctx context.Context, c client.Reader, shard *sharding.Shard, project *catalystv1alpha1.Project) (bool, error) {
+	if !shard.Owns(project.Namespace, project.Name, project.Labels) {
+		return false, nil
+	}
+	if shard == nil || shard.Namespaces == nil {
+		return true, nil
+	}
+	ns := &corev1.Namespace{}
+	if err := c.Get(ctx, client.ObjectKey{Name: project.Namespace}, ns); err != nil {
+		return false, client.IgnoreNotFound(err)
+	}
+	return shard.OwnsNamespace(ns.Labels), nil
+}
@@ -0,0 +1,75 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+	"github.com/ncrmro/catalyst/operator/internal/sharding"
+)
+
+func TestProjectReconcile_SkipsOtherShards(t *testing.T) {
+	project := &catalystv1alpha1.Project{
+		ObjectMeta: metav1.ObjectMeta{Name: "shop", Namespace: "team", Labels: map[string]string{sharding.Label: "1"}},
+		Spec: catalystv1alpha1.ProjectSpec{
+			Templates: map[string]catalystv1alpha1.EnvironmentTemplateSpec{
+				"deployment": {Type: "helm", Path: "charts/shop"},
+			},
+		},
+	}
+	c := newFakeClientBuilder().WithStatusSubresource(project).WithObjects(project).Build()
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "shop", Namespace: "team"}}
+
+	other := &ProjectReconciler{Client: c, Scheme: testScheme, Shard: &sharding.Shard{Index: 0, Count: 2}}
+	_, err := other.Reconcile(ctx, req)
+	require.NoError(t, err)
+	got := &catalystv1alpha1.Project{}
+	require.NoError(t, c.Get(ctx, req.NamespacedName, got))
+	assert.Empty(t, got.Status.TemplateRevisions)
+
+	owner := &ProjectReconciler{Client: c, Scheme: testScheme, Shard: &sharding.Shard{Index: 1, Count: 2}}
+	_, err = owner.Reconcile(ctx, req)
+	require.NoError(t, err)
+	require.NoError(t, c.Get(ctx, req.NamespacedName, got))
+	assert.NotEmpty(t, got.Status.TemplateRevisions)
+}
+
+func TestProjectReconcile_SkipsUnselectedNamespaces(t *testing.T) {
+	namespace := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: "team", Labels: map[string]string{"catalyst.dev/team": "team-b"}},
+	}
+	project := &catalystv1alpha1.Project{
+		ObjectMeta: metav1.ObjectMeta{Name: "shop", Namespace: "team"},
+		Spec: catalystv1alpha1.ProjectSpec{
+			Templates: map[string]catalystv1alpha1.EnvironmentTemplateSpec{
+				"deployment": {Type: "helm", Path: "charts/shop"},
+			},
+		},
+	}
+	c := newFakeClientBuilder().WithStatusSubresource(project).WithObjects(namespace, project).Build()
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "shop", Namespace: "team"}}
+
+	teamA := &sharding.Shard{Count: 1}
+	require.NoError(t, teamA.SetNamespaceSelector("catalyst.dev/team=team-a"))
+	_, err := (&ProjectReconciler{Client: c, Scheme: testScheme, Shard: teamA}).Reconcile(ctx, req)
+	require.NoError(t, err)
+	got := &catalystv1alpha1.Project{}
+	require.NoError(t, c.Get(ctx, req.NamespacedName, got))
+	assert.Empty(t, got.Status.TemplateRevisions)
+
+	teamB := &sharding.Shard{Count: 1}
+	require.NoError(t, teamB.SetNamespaceSelector("catalyst.dev/team=team-b"))
+	_, err = (&ProjectReconciler{Client: c, Scheme: testScheme, Shard: teamB}).Reconcile(ctx, req)
+	require.NoError(t, err)
+	require.NoError(t, c.Get(ctx, req.NamespacedName, got))
+	assert.NotEmpty(t, got.Status.TemplateRevisions)
+}
@@ -0,0 +1,139 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+	"github.com/ncrmro/catalyst/operator/internal/operatorconfig"
+)
+
+// Shared-host debug routing:
+// A single stable hostname (SHARED_PREVIEW_HOST) routes to a specific preview environment
+// when the request carries `X-Catalyst-Env: <namespace>` or a `catalyst-env=<namespace>` cookie,
+// the namespace of the environment: names repeat across projects and teams, namespaces do not.
+// Each environment attaches its own HTTPRoute to a shared Gateway (GATEWAY_NAME/GATEWAY_NAMESPACE);
+// Gateway API merges routes for the same hostname across namespaces.
+// Responses carry X-Catalyst-Env so clients can trace which environment served them.
+
+const (
+	envRoutingHeader    = "X-Catalyst-Env"
+	envRoutingCookie    = "catalyst-env"
+	sharedRouteName     = "web-shared"
+	sharedRouteBackend  = "web"
+	sharedRouteBackPort = 80
+)
+
+var httpRouteGVK = schema.GroupVersionKind{Group: "gateway.networking.k8s.io", Version: "v1", Kind: "HTTPRoute"}
+
+// desiredSharedHTTPRoute creates the HTTPRoute matching the environment namespace in the
+// header or cookie.
+func desiredSharedHTTPRoute(env *catalystv1alpha1.Environment, namespace, sharedHost, gatewayName, gatewayNamespace string) *unstructured.Unstructured {
+	parentRef := map[string]interface{}{"name": gatewayName}
+	if gatewayNamespace != "" {
+		parentRef["namespace"] = gatewayNamespace
+	}
+
+	cookiePattern := fmt.Sprintf(`(^|.*;\s*)%s=%s(;.*|$)`, envRoutingCookie, regexp.QuoteMeta(namespace))
+
+	route := &unstructured.Unstructured{}
+	route.SetGroupVersionKind(httpRouteGVK)
+	route.SetName(sharedRouteName)
+	route.SetNamespace(namespace)
+	route.SetLabels(map[string]string{
+		"catalyst.dev/environment": sanitizeLabelValue(env.Name),
+	})
+	route.Object["spec"] = map[string]interface{}{
+		"parentRefs": []interface{}{parentRef},
+		"hostnames":  []interface{}{sharedHost},
+		"rules": []interface{}{
+			map[string]interface{}{
+				"matches": []interface{}{
+					map[string]interface{}{
+						"headers": []interface{}{
+							map[string]interface{}{"type": "Exact", "name": envRoutingHeader, "value": namespace},
+						},
+					},
+					map[string]interface{}{
+						"headers": []interface{}{
+							map[string]interface{}{"type": "RegularExpression", "name": "Cookie", "value": cookiePattern},
+						},
+					},
+				},
+				"filters": []interface{}{
+					map[string]interface{}{
+						"type": "ResponseHeaderModifier",
+						"responseHeaderModifier": map[string]interface{}{
+							"set": []interface{}{
+								map[string]interface{}{"name": envRoutingHeader, "value": namespace},
+							},
+						},
+					},
+				},
+				"backendRefs": []interface{}{
+					map[string]interface{}{"name": sharedRouteBackend, "port": int64(sharedRouteBackPort)},
+				},
+			},
+		},
+	}
+	return route
+}
+
+// reconcileSharedRouting creates/updates the shared-host HTTPRoute when preview.sharedHost
+// and gateway.name are configured, and deletes it once they are not. The route bypasses the
+// Ingress, so environments protected by spec.access get none. Missing Gateway API CRDs are
+// logged and ignored.
+func (r *EnvironmentReconciler) reconcileSharedRouting(ctx context.Context, env *catalystv1alpha1.Environment, namespace string) error {
+	log := logf.FromContext(ctx)
+
+	if r.Capabilities != nil && !r.Capabilities.GatewayAPI {
+		// Reported on the CapabilitiesAvailable condition
+		return nil
+	}
+	cfg := operatorconfig.Current()
+	sharedHost := cfg.Preview.SharedHost
+	gatewayName := cfg.Gateway.Name
+	if sharedHost == "" || gatewayName == "" || accessType(env) != catalystv1alpha1.AccessTypePublic {
+		stale := &unstructured.Unstructured{}
+		stale.SetGroupVersionKind(httpRouteGVK)
+		stale.SetName(sharedRouteName)
+		stale.SetNamespace(namespace)
+		if err := r.Delete(ctx, stale); err != nil && !apierrors.IsNotFound(err) && !meta.IsNoMatchError(err) {
+			return fmt.Errorf("failed to delete shared HTTPRoute: %w", err)
+		}
+		return nil
+	}
+
+	route := desiredSharedHTTPRoute(env, namespace, sharedHost, gatewayName, cfg.Gateway.Namespace)
+	if err := r.patchOrUpdate(ctx, route); err != nil {
+		if meta.IsNoMatchError(err) {
+			log.Info("Gateway API not installed, skipping shared-host routing", "host", sharedHost)
+			return nil
+		}
+		return fmt.Errorf("failed to reconcile shared HTTPRoute: %w", err)
+	}
+	return nil
+}
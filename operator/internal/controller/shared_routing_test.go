@@ -0,0 +1,79 @@
+package controller
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+func TestDesiredSharedHTTPRoute(t *testing.T) {
+	env := &catalystv1alpha1.Environment{ObjectMeta: metav1.ObjectMeta{Name: "pr-42"}}
+
+	route := desiredSharedHTTPRoute(env, "team-proj-pr-42", "app.preview.example.com", "shared", "gateway-system")
+
+	assert.Equal(t, "HTTPRoute", route.GetKind())
+	assert.Equal(t, "team-proj-pr-42", route.GetNamespace())
+
+	hostnames, _, _ := unstructured.NestedStringSlice(route.Object, "spec", "hostnames")
+	assert.Equal(t, []string{"app.preview.example.com"}, hostnames)
+
+	rules, _, _ := unstructured.NestedSlice(route.Object, "spec", "rules")
+	require.Len(t, rules, 1)
+	matches := rules[0].(map[string]interface{})["matches"].([]interface{})
+	require.Len(t, matches, 2)
+
+	header := matches[0].(map[string]interface{})["headers"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, envRoutingHeader, header["name"])
+	assert.Equal(t, "team-proj-pr-42", header["value"], "environment names repeat across projects")
+
+	cookie := matches[1].(map[string]interface{})["headers"].([]interface{})[0].(map[string]interface{})
+	pattern := regexp.MustCompile(cookie["value"].(string))
+	assert.True(t, pattern.MatchString("session=abc; catalyst-env=team-proj-pr-42"))
+	assert.False(t, pattern.MatchString("catalyst-env=pr-42"))
+	assert.False(t, pattern.MatchString("catalyst-env=team-proj-pr-421"))
+}
+
+func TestReconcileSharedRouting_DeletesRouteWhenDisabled(t *testing.T) {
+	ctx := context.Background()
+	env := &catalystv1alpha1.Environment{ObjectMeta: metav1.ObjectMeta{Name: "pr-42"}}
+	route := desiredSharedHTTPRoute(env, "team-proj-pr-42", "app.preview.example.com", "shared", "gateway-system")
+	c := newFakeClientBuilder().WithObjects(route).Build()
+	r := &EnvironmentReconciler{Client: c}
+
+	require.NoError(t, r.reconcileSharedRouting(ctx, env, "team-proj-pr-42"))
+	existing := &unstructured.Unstructured{}
+	existing.SetGroupVersionKind(httpRouteGVK)
+	err := c.Get(ctx, client.ObjectKey{Name: sharedRouteName, Namespace: "team-proj-pr-42"}, existing)
+	assert.True(t, apierrors.IsNotFound(err), "shared routing is not configured")
+
+	require.NoError(t, r.reconcileSharedRouting(ctx, env, "team-proj-pr-42"), "nothing left to delete")
+}
+
+func TestReconcileSharedRouting_SkipsProtectedEnvironments(t *testing.T) {
+	t.Setenv("SHARED_PREVIEW_HOST", "app.preview.example.com")
+	t.Setenv("GATEWAY_NAME", "shared")
+	ctx := context.Background()
+	env := &catalystv1alpha1.Environment{ObjectMeta: metav1.ObjectMeta{Name: "pr-42"}}
+	route := desiredSharedHTTPRoute(env, "team-proj-pr-42", "app.preview.example.com", "shared", "")
+	c := newFakeClientBuilder().WithObjects(route).Build()
+	r := &EnvironmentReconciler{Client: c}
+
+	require.NoError(t, r.reconcileSharedRouting(ctx, env, "team-proj-pr-42"))
+	existing := &unstructured.Unstructured{}
+	existing.SetGroupVersionKind(httpRouteGVK)
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: sharedRouteName, Namespace: "team-proj-pr-42"}, existing), "public environments are routed")
+
+	// The route would reach the web Service past basicAuth
+	env.Spec.Access = &catalystv1alpha1.EnvironmentAccess{Type: catalystv1alpha1.AccessTypeBasicAuth}
+	require.NoError(t, r.reconcileSharedRouting(ctx, env, "team-proj-pr-42"))
+	assert.True(t, apierrors.IsNotFound(c.Get(ctx, client.ObjectKey{Name: sharedRouteName, Namespace: "team-proj-pr-42"}, existing)))
+}
@@ -0,0 +1,234 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+// Storage (size and storageClassName of volumes and managed services, Project spec.storage):
+// Volume and managed-service claims are rendered from their PVC spec with size and
+// storageClassName set over it; the Project's spec.storage fills in the storage class and size
+// claims still lack. Only the storage request of a bound claim can change: when the desired
+// size grows past it, the claim is patched if its StorageClass allows volume expansion and the
+// CSI driver resizes the volume. The VolumesExpanded condition reports claims that cannot
+// grow and resizes in progress. Claims never shrink.
+
+// +kubebuilder:rbac:groups=storage.k8s.io,resources=storageclasses,verbs=get;list;watch
+
+const (
+	// conditionVolumesExpanded reports whether the claims have the size they request
+	conditionVolumesExpanded = "VolumesExpanded"
+)
+
+// storageRequest returns the storage a claim spec requests
+func storageRequest(spec *corev1.PersistentVolumeClaimSpec) (resource.Quantity, bool) {
+	if spec == nil {
+		return resource.Quantity{}, false
+	}
+	q, ok := spec.Resources.Requests[corev1.ResourceStorage]
+	return q, ok
+}
+
+// applyStorageDefaults fills the project storage class and size into a claim spec lacking them
+func applyStorageDefaults(spec *corev1.PersistentVolumeClaimSpec, defaults *catalystv1alpha1.StorageDefaultsSpec) {
+	if defaults == nil {
+		return
+	}
+	if spec.StorageClassName == nil && defaults.StorageClassName != nil {
+		spec.StorageClassName = ptr(*defaults.StorageClassName)
+	}
+	if _, ok := storageRequest(spec); !ok && defaults.Size != nil {
+		if spec.Resources.Requests == nil {
+			spec.Resources.Requests = corev1.ResourceList{}
+		}
+		spec.Resources.Requests[corev1.ResourceStorage] = defaults.Size.DeepCopy()
+	}
+}
+
+// claimSpec returns a claim spec with the size and storage class overrides and the project
+// defaults applied; nil when neither a spec nor a size is set
+func claimSpec(spec *corev1.PersistentVolumeClaimSpec, size *resource.Quantity, storageClassName *string, defaults *catalystv1alpha1.StorageDefaultsSpec) *corev1.PersistentVolumeClaimSpec {
+	if spec == nil && size == nil {
+		return nil
+	}
+	result := &corev1.PersistentVolumeClaimSpec{}
+	if spec != nil {
+		result = spec.DeepCopy()
+	}
+	if len(result.AccessModes) == 0 {
+		result.AccessModes = []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce}
+	}
+	if size != nil {
+		if result.Resources.Requests == nil {
+			result.Resources.Requests = corev1.ResourceList{}
+		}
+		result.Resources.Requests[corev1.ResourceStorage] = size.DeepCopy()
+	}
+	if storageClassName != nil {
+		result.StorageClassName = ptr(*storageClassName)
+	}
+	applyStorageDefaults(result, defaults)
+	return result
+}
+
+// resolveStorage renders the claims of the volumes and managed services of a resolved config.
+// The slices are copied, so the config may share them with the Environment spec.
+func resolveStorage(config *catalystv1alpha1.EnvironmentConfig, defaults *catalystv1alpha1.StorageDefaultsSpec) {
+	config.Volumes = append([]catalystv1alpha1.VolumeSpec(nil), config.Volumes...)
+	for i := range config.Volumes {
+		vol := &config.Volumes[i]
+		vol.PersistentVolumeClaim = claimSpec(vol.PersistentVolumeClaim, vol.Size, vol.StorageClassName, defaults)
+	}
+	config.Services = append([]catalystv1alpha1.ManagedServiceSpec(nil), config.Services...)
+	for i := range config.Services {
+		svc := &config.Services[i]
+		svc.Storage = claimSpec(svc.Storage, svc.Size, svc.StorageClassName, defaults)
+	}
+}
+
+// configClaimSizes returns the storage requested by the volume and managed-service claims of
+// a resolved config, keyed by claim name
+func configClaimSizes(config *catalystv1alpha1.EnvironmentConfig) map[string]resource.Quantity {
+	sizes := map[string]resource.Quantity{}
+	for _, vol := range config.Volumes {
+		if q, ok := storageRequest(vol.PersistentVolumeClaim); ok {
+			sizes[vol.Name] = q
+		}
+	}
+	for _, svc := range config.Services {
+		if svc.Provider != "" {
+			continue // The Postgres operator resizes its own volumes
+		}
+		if q, ok := storageRequest(svc.Storage); ok {
+			sizes[managedServiceClaimName(svc)] = q
+		}
+	}
+	return sizes
+}
+
+// volumeExpansionBlocked returns why a claim cannot grow, empty when its StorageClass allows
+// volume expansion
+func (r *EnvironmentReconciler) volumeExpansionBlocked(ctx context.Context, claim *corev1.PersistentVolumeClaim) (string, error) {
+	if claim.Spec.StorageClassName == nil || *claim.Spec.StorageClassName == "" {
+		return "it has no StorageClass", nil
+	}
+	name := *claim.Spec.StorageClassName
+	class := &storagev1.StorageClass{}
+	if err := r.Get(ctx, client.ObjectKey{Name: name}, class); err != nil {
+		if apierrors.IsNotFound(err) {
+			return fmt.Sprintf("StorageClass %s not found", name), nil
+		}
+		return "", fmt.Errorf("failed to fetch StorageClass %s: %w", name, err)
+	}
+	if class.AllowVolumeExpansion == nil || !*class.AllowVolumeExpansion {
+		return fmt.Sprintf("StorageClass %s does not allow volume expansion", name), nil
+	}
+	return "", nil
+}
+
+// expandClaims grows the bound claims of the namespace whose desired storage request is
+// larger than their own and reports the outcome on the VolumesExpanded condition. Claims not
+// created yet are skipped.
+func (r *EnvironmentReconciler) expandClaims(ctx context.Context, env *catalystv1alpha1.Environment, namespace string, sizes map[string]resource.Quantity) error {
+	log := logf.FromContext(ctx)
+	names := make([]string, 0, len(sizes))
+	for name := range sizes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var blocked, resizing []string
+	for _, name := range names {
+		claim := &corev1.PersistentVolumeClaim{}
+		if err := r.Get(ctx, client.ObjectKey{Name: name, Namespace: namespace}, claim); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return fmt.Errorf("failed to fetch PVC %s: %w", name, err)
+		}
+		if claim.Status.Phase != corev1.ClaimBound {
+			continue // Only bound claims can be resized
+		}
+		desired := sizes[name]
+		current, _ := storageRequest(&claim.Spec)
+		if desired.Cmp(current) > 0 {
+			reason, err := r.volumeExpansionBlocked(ctx, claim)
+			if err != nil {
+				return err
+			}
+			if reason != "" {
+				blocked = append(blocked, fmt.Sprintf("%s cannot grow to %s: %s", name, desired.String(), reason))
+				continue
+			}
+			patch := client.MergeFrom(claim.DeepCopy())
+			if claim.Spec.Resources.Requests == nil {
+				claim.Spec.Resources.Requests = corev1.ResourceList{}
+			}
+			claim.Spec.Resources.Requests[corev1.ResourceStorage] = desired
+			if err := r.Patch(ctx, claim, patch); err != nil {
+				return fmt.Errorf("failed to expand PVC %s: %w", name, err)
+			}
+			log.Info("Expanding PVC", "pvc", name, "from", current.String(), "to", desired.String())
+			recordEvent(r.Recorder, env, corev1.EventTypeNormal, eventVolumeExpanding, "Expanding PVC %s from %s to %s", name, current.String(), desired.String())
+		}
+		requested, _ := storageRequest(&claim.Spec)
+		if capacity, ok := claim.Status.Capacity[corev1.ResourceStorage]; ok && capacity.Cmp(requested) < 0 {
+			resizing = append(resizing, name)
+		}
+	}
+
+	condition := metav1.Condition{
+		Type:               conditionVolumesExpanded,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: env.Generation,
+	}
+	switch {
+	case len(blocked) > 0:
+		condition.Reason = "ExpansionNotSupported"
+		condition.Message = strings.Join(blocked, "; ")
+	case len(resizing) > 0:
+		condition.Reason = "Resizing"
+		condition.Message = "Waiting for the volumes of " + strings.Join(resizing, ", ") + " to resize"
+	default:
+		// Environments that never grew a claim get no condition
+		if meta.FindStatusCondition(env.Status.Conditions, conditionVolumesExpanded) == nil {
+			return nil
+		}
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "Expanded"
+		condition.Message = "All PVCs have the size they request"
+	}
+	if meta.SetStatusCondition(&env.Status.Conditions, condition) {
+		return r.Status().Update(ctx, env)
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+func TestResolveStorage(t *testing.T) {
+	size := resource.MustParse("5Gi")
+	defaults := &catalystv1alpha1.StorageDefaultsSpec{StorageClassName: ptr("fast"), Size: ptr(resource.MustParse("2Gi"))}
+	volumes := []catalystv1alpha1.VolumeSpec{
+		{Name: "uploads", Size: &size},
+		{Name: "cache", PersistentVolumeClaim: &corev1.PersistentVolumeClaimSpec{AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteMany}}, StorageClassName: ptr("nfs")},
+		{Name: "tmp"},
+	}
+	config := catalystv1alpha1.EnvironmentConfig{
+		Volumes: volumes,
+		Services: []catalystv1alpha1.ManagedServiceSpec{
+			{Name: "postgres", Storage: &corev1.PersistentVolumeClaimSpec{Resources: corev1.VolumeResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("1Gi")},
+			}}, Size: &size},
+			{Name: "worker"},
+		},
+	}
+
+	resolveStorage(&config, defaults)
+	uploads := config.Volumes[0].PersistentVolumeClaim
+	require.NotNil(t, uploads, "a size alone creates a claim")
+	assert.Equal(t, []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce}, uploads.AccessModes)
+	assert.Equal(t, "5Gi", uploads.Resources.Requests.Storage().String())
+	assert.Equal(t, "fast", *uploads.StorageClassName)
+
+	cache := config.Volumes[1].PersistentVolumeClaim
+	assert.Equal(t, []corev1.PersistentVolumeAccessMode{corev1.ReadWriteMany}, cache.AccessModes)
+	assert.Equal(t, "2Gi", cache.Resources.Requests.Storage().String(), "the project size fills in missing requests")
+	assert.Equal(t, "nfs", *cache.StorageClassName)
+	assert.Nil(t, config.Volumes[2].PersistentVolumeClaim)
+	assert.Nil(t, volumes[0].PersistentVolumeClaim, "the input is not modified")
+
+	assert.Equal(t, "5Gi", config.Services[0].Storage.Resources.Requests.Storage().String())
+	assert.Equal(t, "fast", *config.Services[0].Storage.StorageClassName)
+	assert.Nil(t, config.Services[1].Storage, "services without storage stay ephemeral")
+
+	assert.Equal(t, map[string]resource.Quantity{
+		"uploads":                  size,
+		"cache":                    resource.MustParse("2Gi"),
+		"postgres-data-postgres-0": size,
+	}, configClaimSizes(&config))
+}
+
+func TestExpandClaims(t *testing.T) {
+	claim := func(name, class, size string) *corev1.PersistentVolumeClaim {
+		return &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "env-ns"},
+			Spec: corev1.PersistentVolumeClaimSpec{
+				StorageClassName: ptr(class),
+				Resources: corev1.VolumeResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(size)},
+				},
+			},
+			Status: corev1.PersistentVolumeClaimStatus{
+				Phase:    corev1.ClaimBound,
+				Capacity: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(size)},
+			},
+		}
+	}
+	env := &catalystv1alpha1.Environment{ObjectMeta: metav1.ObjectMeta{Name: "pr-1", Namespace: "team"}}
+	c := newFakeClientBuilder().WithStatusSubresource(env).WithObjects(
+		env,
+		&storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: "expandable"}, Provisioner: "csi.example.com", AllowVolumeExpansion: ptr(true)},
+		&storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: "fixed"}, Provisioner: "csi.example.com"},
+		claim("uploads", "expandable", "1Gi"),
+		claim("postgres-data-postgres-0", "fixed", "1Gi"),
+	).Build()
+	recorder := record.NewFakeRecorder(10)
+	r := &EnvironmentReconciler{Client: c, Scheme: testScheme, Recorder: recorder}
+	ctx := context.Background()
+
+	// Unchanged sizes report nothing
+	sizes := map[string]resource.Quantity{"uploads": resource.MustParse("1Gi"), "missing": resource.MustParse("1Gi")}
+	require.NoError(t, r.expandClaims(ctx, env, "env-ns", sizes))
+	assert.Nil(t, meta.FindStatusCondition(env.Status.Conditions, conditionVolumesExpanded))
+
+	sizes = map[string]resource.Quantity{"uploads": resource.MustParse("10Gi"), "postgres-data-postgres-0": resource.MustParse("5Gi")}
+	require.NoError(t, r.expandClaims(ctx, env, "env-ns", sizes))
+	uploads := &corev1.PersistentVolumeClaim{}
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "uploads", Namespace: "env-ns"}, uploads))
+	assert.Equal(t, "10Gi", uploads.Spec.Resources.Requests.Storage().String())
+	assert.Equal(t, "Normal VolumeExpanding Expanding PVC uploads from 1Gi to 10Gi", <-recorder.Events)
+	postgres := &corev1.PersistentVolumeClaim{}
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "postgres-data-postgres-0", Namespace: "env-ns"}, postgres))
+	assert.Equal(t, "1Gi", postgres.Spec.Resources.Requests.Storage().String())
+	condition := meta.FindStatusCondition(env.Status.Conditions, conditionVolumesExpanded)
+	require.NotNil(t, condition)
+	assert.Equal(t, metav1.ConditionFalse, condition.Status)
+	assert.Equal(t, "ExpansionNotSupported", condition.Reason)
+	assert.Equal(t, "postgres-data-postgres-0 cannot grow to 5Gi: StorageClass fixed does not allow volume expansion", condition.Message)
+
+	// The resize is in progress until the capacity catches up
+	delete(sizes, "postgres-data-postgres-0")
+	require.NoError(t, r.expandClaims(ctx, env, "env-ns", sizes))
+	assert.Equal(t, "Resizing", meta.FindStatusCondition(env.Status.Conditions, conditionVolumesExpanded).Reason)
+
+	uploads.Status.Capacity[corev1.ResourceStorage] = resource.MustParse("10Gi")
+	require.NoError(t, c.Status().Update(ctx, uploads))
+	require.NoError(t, r.expandClaims(ctx, env, "env-ns", sizes))
+	condition = meta.FindStatusCondition(env.Status.Conditions, conditionVolumesExpanded)
+	assert.Equal(t, metav1.ConditionTrue, condition.Status)
+	assert.Equal(t, "Expanded", condition.Reason)
+
+	// Claims never shrink
+	sizes["uploads"] = resource.MustParse("2Gi")
+	require.NoError(t, r.expandClaims(ctx, env, "env-ns", sizes))
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "uploads", Namespace: "env-ns"}, uploads))
+	assert.Equal(t, "10Gi", uploads.Spec.Resources.Requests.Storage().String())
+}
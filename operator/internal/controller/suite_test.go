@@ -25,14 +25,18 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/envtest"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
 	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+	"github.com/ncrmro/catalyst/operator/internal/operatorconfig"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -47,6 +51,36 @@ var (
 	k8sClient client.Client
 )
 
+// testScheme holds the client-go and Catalyst types for the unit tests that run against the
+// fake client.
+var testScheme = func() *runtime.Scheme {
+	s := runtime.NewScheme()
+	utilruntime.Must(scheme.AddToScheme(s))
+	utilruntime.Must(catalystv1alpha1.AddToScheme(s))
+	return s
+}()
+
+// newFakeClientBuilder returns a fake client builder using testScheme.
+func newFakeClientBuilder() *fake.ClientBuilder {
+	return fake.NewClientBuilder().WithScheme(testScheme)
+}
+
+// setOperatorConfig puts the environment configuration changed by update in effect for the
+// rest of the test
+func setOperatorConfig(t *testing.T, update func(c *operatorconfig.Config)) {
+	t.Helper()
+	c, err := operatorconfig.Load("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	update(c)
+	if err := c.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	operatorconfig.Set(c)
+	t.Cleanup(func() { operatorconfig.Set(nil) })
+}
+
 func TestControllers(t *testing.T) {
 	RegisterFailHandler(Fail)
 
@@ -0,0 +1,231 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+// One-off tasks (spec.tasks):
+// Each entry runs as a Job ("task-<name>") once per deploy, after the deployment mode has
+// applied the web Deployment, with its pod template like ad-hoc runs. The revision of a task
+// hashes the deployed commits, the template and the task definition; when it changes the
+// previous Job is replaced. Results are recorded in status.tasks and summarized in the
+// TasksSucceeded condition.
+
+const (
+	// conditionTasksSucceeded reports whether every task succeeded for the current deploy
+	conditionTasksSucceeded = "TasksSucceeded"
+
+	// taskLabel carries the task name on task Jobs
+	taskLabel = "catalyst.dev/task"
+	// taskRevisionAnnotation records the revision a task Job runs for
+	taskRevisionAnnotation = "catalyst.dev/task-revision"
+)
+
+// taskJobName returns the Job name for a task
+func taskJobName(name string) string {
+	return "task-" + name
+}
+
+// taskRevision identifies the deploy and definition a task runs for
+func taskRevision(env *catalystv1alpha1.Environment, task catalystv1alpha1.EnvironmentTask) string {
+	data, _ := json.Marshal(struct {
+		Sources      []catalystv1alpha1.EnvironmentSource `json:"sources"`
+		TemplateHash string                               `json:"templateHash"`
+		Task         catalystv1alpha1.EnvironmentTask     `json:"task"`
+	}{env.Spec.Sources, env.Status.TemplateHash, task})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+// desiredTaskJob executes the task command with the pod template of the web Deployment
+func desiredTaskJob(env *catalystv1alpha1.Environment, namespace string, task catalystv1alpha1.EnvironmentTask, revision string, web *appsv1.Deployment) *batchv1.Job {
+	job := desiredRunJob(env, namespace, catalystv1alpha1.EnvironmentRun{Name: task.Name, Command: task.Command, Image: task.Image}, web)
+	job.Name = taskJobName(task.Name)
+	job.Annotations = map[string]string{taskRevisionAnnotation: revision}
+	labels := map[string]string{
+		"catalyst.dev/environment": sanitizeLabelValue(env.Name),
+		taskLabel:                  task.Name,
+	}
+	job.Labels = labels
+	job.Spec.Template.Labels = labels
+	if task.Timeout != nil {
+		job.Spec.ActiveDeadlineSeconds = ptr(int64(task.Timeout.Seconds()))
+	}
+	container := &job.Spec.Template.Spec.Containers[0]
+	container.Env = mergeEnvVars(container.Env, task.Env)
+	return job
+}
+
+// tasksCondition summarizes the task statuses of the current deploy
+func tasksCondition(env *catalystv1alpha1.Environment, statuses []catalystv1alpha1.RunStatus) metav1.Condition {
+	condition := metav1.Condition{
+		Type:               conditionTasksSucceeded,
+		Status:             metav1.ConditionTrue,
+		Reason:             "Succeeded",
+		Message:            "All tasks succeeded for the current deploy",
+		ObservedGeneration: env.Generation,
+	}
+	var failed, pending []string
+	for _, status := range statuses {
+		switch status.Phase {
+		case runPhaseFailed:
+			failed = append(failed, status.Name)
+		case runPhaseSucceeded:
+		default:
+			pending = append(pending, status.Name)
+		}
+	}
+	switch {
+	case len(failed) > 0:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "TaskFailed"
+		condition.Message = fmt.Sprintf("Tasks failed: %s", strings.Join(failed, ", "))
+	case len(pending) > 0:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "Running"
+		condition.Message = fmt.Sprintf("Tasks not finished: %s", strings.Join(pending, ", "))
+	}
+	return condition
+}
+
+// reconcileTasks runs every spec.tasks entry once for the current revision, records the
+// results in status.tasks and the TasksSucceeded condition, and deletes the Jobs of removed
+// entries. It returns whether any task is still pending or running, so the caller keeps polling.
+func (r *EnvironmentReconciler) reconcileTasks(ctx context.Context, env *catalystv1alpha1.Environment, namespace string) (bool, error) {
+	log := logf.FromContext(ctx)
+
+	var statuses []catalystv1alpha1.RunStatus
+	for _, task := range env.Spec.Tasks {
+		revision := taskRevision(env, task)
+		if previous := findRunStatus(env.Status.Tasks, task.Name); isRunFinished(previous) && previous.Revision == revision {
+			statuses = append(statuses, *previous)
+			continue
+		}
+		status := catalystv1alpha1.RunStatus{Name: task.Name, Revision: revision, Phase: runPhasePending, JobName: taskJobName(task.Name)}
+
+		job := &batchv1.Job{}
+		err := r.Get(ctx, client.ObjectKey{Name: taskJobName(task.Name), Namespace: namespace}, job)
+		if apierrors.IsNotFound(err) {
+			web := &appsv1.Deployment{}
+			if err := r.Get(ctx, client.ObjectKey{Name: "web", Namespace: namespace}, web); err != nil {
+				if !apierrors.IsNotFound(err) {
+					return false, err
+				}
+				status.Message = "Waiting for the web Deployment"
+				statuses = append(statuses, status)
+				continue
+			}
+			job = desiredTaskJob(env, namespace, task, revision, web)
+
+			// Tasks yield to the primary workload when the namespace quota is nearly full
+			if deferred, err := r.deferForQuota(ctx, env, namespace, "task "+task.Name, &job.Spec.Template.Spec); err != nil {
+				return false, err
+			} else if deferred {
+				status.Message = "Deferred until the namespace quota has headroom"
+				statuses = append(statuses, status)
+				continue
+			}
+
+			log.Info("Creating task Job", "task", task.Name, "job", job.Name, "revision", revision)
+			if err := r.Create(ctx, job); err != nil && !isAlreadyExists(err) {
+				return false, fmt.Errorf("failed to create Job for task %s: %w", task.Name, err)
+			}
+			statuses = append(statuses, status)
+			continue
+		} else if err != nil {
+			return false, err
+		}
+
+		if job.Annotations[taskRevisionAnnotation] != revision {
+			// A new deploy: replace the Job of the previous one, the next reconcile recreates it
+			log.Info("Replacing task Job for a new revision", "task", task.Name, "job", job.Name, "revision", revision)
+			if err := r.Delete(ctx, job, client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil && !apierrors.IsNotFound(err) {
+				return false, err
+			}
+			status.Message = "Restarting for a new revision"
+			statuses = append(statuses, status)
+			continue
+		}
+
+		pods := &corev1.PodList{}
+		if err := r.List(ctx, pods, client.InNamespace(namespace), client.MatchingLabels{batchv1.JobNameLabel: job.Name}); err != nil {
+			return false, err
+		}
+		status = runStatusFromJob(task.Name, job, pods.Items)
+		status.Revision = revision
+		if isRunFinished(&status) {
+			log.Info("Task finished", "task", task.Name, "phase", status.Phase, "exitCode", status.ExitCode)
+		}
+		statuses = append(statuses, status)
+	}
+
+	active := false
+	for i := range statuses {
+		if !isRunFinished(&statuses[i]) {
+			active = true
+		}
+	}
+
+	// Remove the Jobs of tasks dropped from the spec
+	jobs := &batchv1.JobList{}
+	if err := r.List(ctx, jobs, client.InNamespace(namespace), client.HasLabels{taskLabel}); err != nil {
+		return false, err
+	}
+	for i := range jobs.Items {
+		job := &jobs.Items[i]
+		if findRunStatus(statuses, job.Labels[taskLabel]) != nil {
+			continue
+		}
+		log.Info("Deleting Job of removed task", "task", job.Labels[taskLabel], "job", job.Name)
+		if err := r.Delete(ctx, job, client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil && !apierrors.IsNotFound(err) {
+			return false, err
+		}
+	}
+
+	changed := !equality.Semantic.DeepEqual(env.Status.Tasks, statuses)
+	env.Status.Tasks = statuses
+	if len(statuses) == 0 {
+		changed = meta.RemoveStatusCondition(&env.Status.Conditions, conditionTasksSucceeded) || changed
+	} else {
+		changed = meta.SetStatusCondition(&env.Status.Conditions, tasksCondition(env, statuses)) || changed
+	}
+	if changed {
+		if err := r.Status().Update(ctx, env); err != nil {
+			return false, err
+		}
+	}
+	return active, nil
+}
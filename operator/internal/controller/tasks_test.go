@@ -0,0 +1,116 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+func TestDesiredTaskJob(t *testing.T) {
+	env := &catalystv1alpha1.Environment{ObjectMeta: metav1.ObjectMeta{Name: "pr-1"}}
+	task := catalystv1alpha1.EnvironmentTask{
+		Name:    "migrate",
+		Command: []string{"npm", "run", "db:migrate"},
+		Env:     []corev1.EnvVar{{Name: "DATABASE_URL", Value: "postgres://postgres/admin"}},
+		Timeout: &metav1.Duration{Duration: 5 * time.Minute},
+	}
+	job := desiredTaskJob(env, "env-ns", task, "abc", runTestWebDeployment("env-ns"))
+
+	assert.Equal(t, "task-migrate", job.Name)
+	assert.Equal(t, "abc", job.Annotations[taskRevisionAnnotation])
+	assert.Equal(t, "migrate", job.Labels[taskLabel])
+	assert.NotContains(t, job.Labels, runLabel, "task Jobs are not collected as runs")
+	assert.Equal(t, job.Labels, job.Spec.Template.Labels)
+	assert.Equal(t, int64(300), *job.Spec.ActiveDeadlineSeconds)
+	container := job.Spec.Template.Spec.Containers[0]
+	assert.Equal(t, "node:22-slim", container.Image)
+	assert.Equal(t, task.Command, container.Command)
+	assert.Equal(t, []corev1.EnvVar{{Name: "DATABASE_URL", Value: "postgres://postgres/admin"}}, container.Env)
+}
+
+func TestReconcileTasks(t *testing.T) {
+	env := &catalystv1alpha1.Environment{
+		ObjectMeta: metav1.ObjectMeta{Name: "pr-1", Namespace: "team"},
+		Spec: catalystv1alpha1.EnvironmentSpec{
+			Sources: []catalystv1alpha1.EnvironmentSource{{Name: "primary", CommitSha: "aaa"}},
+			Tasks:   []catalystv1alpha1.EnvironmentTask{{Name: "migrate", Command: []string{"npm", "run", "db:migrate"}}},
+		},
+	}
+	c := newFakeClientBuilder().WithStatusSubresource(env).
+		WithObjects(env, runTestWebDeployment("env-ns")).Build()
+	r := &EnvironmentReconciler{Client: c, Scheme: testScheme}
+	ctx := context.Background()
+	key := client.ObjectKey{Name: "task-migrate", Namespace: "env-ns"}
+
+	active, err := r.reconcileTasks(ctx, env, "env-ns")
+	require.NoError(t, err)
+	assert.True(t, active)
+	condition := meta.FindStatusCondition(env.Status.Conditions, conditionTasksSucceeded)
+	require.NotNil(t, condition)
+	assert.Equal(t, "Running", condition.Reason)
+
+	// The task succeeds once for this deploy
+	job := &batchv1.Job{}
+	require.NoError(t, c.Get(ctx, key, job))
+	job.Status.Succeeded = 1
+	require.NoError(t, c.Status().Update(ctx, job))
+	active, err = r.reconcileTasks(ctx, env, "env-ns")
+	require.NoError(t, err)
+	assert.False(t, active)
+	assert.Equal(t, runPhaseSucceeded, env.Status.Tasks[0].Phase)
+	assert.True(t, meta.IsStatusConditionTrue(env.Status.Conditions, conditionTasksSucceeded))
+
+	// It does not run again until the next deploy, even once its Job expired
+	require.NoError(t, c.Delete(ctx, job))
+	active, err = r.reconcileTasks(ctx, env, "env-ns")
+	require.NoError(t, err)
+	assert.False(t, active)
+	assert.True(t, apierrors.IsNotFound(c.Get(ctx, key, &batchv1.Job{})))
+
+	// A new commit runs it again
+	env.Spec.Sources[0].CommitSha = "bbb"
+	active, err = r.reconcileTasks(ctx, env, "env-ns")
+	require.NoError(t, err)
+	assert.True(t, active)
+	require.NoError(t, c.Get(ctx, key, job))
+	assert.Equal(t, env.Status.Tasks[0].Revision, job.Annotations[taskRevisionAnnotation])
+
+	// Changing the rerun token replaces the Job of the previous revision
+	env.Spec.Tasks[0].Rerun = "1"
+	_, err = r.reconcileTasks(ctx, env, "env-ns")
+	require.NoError(t, err)
+	assert.Equal(t, "Restarting for a new revision", env.Status.Tasks[0].Message)
+	assert.True(t, apierrors.IsNotFound(c.Get(ctx, key, &batchv1.Job{})))
+	_, err = r.reconcileTasks(ctx, env, "env-ns")
+	require.NoError(t, err)
+	require.NoError(t, c.Get(ctx, key, job))
+
+	// Failures are reported in the condition
+	job.Status.Failed = 1
+	require.NoError(t, c.Status().Update(ctx, job))
+	_, err = r.reconcileTasks(ctx, env, "env-ns")
+	require.NoError(t, err)
+	condition = meta.FindStatusCondition(env.Status.Conditions, conditionTasksSucceeded)
+	assert.Equal(t, "TaskFailed", condition.Reason)
+	assert.Equal(t, "Tasks failed: migrate", condition.Message)
+
+	// Removing the task deletes its Job, status and condition
+	env.Spec.Tasks = nil
+	active, err = r.reconcileTasks(ctx, env, "env-ns")
+	require.NoError(t, err)
+	assert.False(t, active)
+	assert.Empty(t, env.Status.Tasks)
+	assert.Nil(t, meta.FindStatusCondition(env.Status.Conditions, conditionTasksSucceeded))
+	assert.True(t, apierrors.IsNotFound(c.Get(ctx, key, &batchv1.Job{})))
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+	"github.com/ncrmro/catalyst/operator/internal/sharding"
+)
+
+// TeamReconciler reconciles a Team object
+type TeamReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+	// Shard limits reconciliation to the first shard: Teams are shared by the Projects of
+	// every shard. Its namespace selector further limits it to the Teams whose namespace
+	// matches. Nil reconciles every Team.
+	Shard *sharding.Shard
+	// Recorder emits an Event when the team namespace is provisioned. Nil records none.
+	Recorder record.EventRecorder
+}
+
+// +kubebuilder:rbac:groups=catalyst.catalyst.dev,resources=teams,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=catalyst.catalyst.dev,resources=teams/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=catalyst.catalyst.dev,resources=teams/finalizers,verbs=update
+
+// Reconcile provisions the team namespace holding the team's Projects, with its labels,
+// quota, NetworkPolicy and the RoleBindings of the team members.
+func (r *TeamReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := logf.FromContext(ctx)
+
+	team := &catalystv1alpha1.Team{}
+	if err := r.Get(ctx, req.NamespacedName, team); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if r.Shard.Enabled() && r.Shard.Index != 0 {
+		return ctrl.Result{}, nil
+	}
+
+	namespace := GenerateTeamNamespace(team.Name)
+	labels := map[string]string{
+		"catalyst.dev/team": sanitizeLabelValue(namespace),
+		namespaceTypeLabel:  namespaceTypeTeam,
+	}
+	// Per-team operators select the namespace the Team provisions
+	if !r.Shard.OwnsNamespace(labels) {
+		return ctrl.Result{}, nil
+	}
+	if err := provisionHierarchyNamespace(ctx, r.Client, namespace, labels, team); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	changed := team.Status.Namespace != namespace
+	if changed {
+		recordEvent(r.Recorder, team, corev1.EventTypeNormal, eventNamespaceCreated, "Provisioned team namespace %s", namespace)
+	}
+	team.Status.Namespace = namespace
+	if meta.SetStatusCondition(&team.Status.Conditions, metav1.Condition{
+		Type:               "Ready",
+		Status:             metav1.ConditionTrue,
+		Reason:             "NamespaceProvisioned",
+		Message:            "team namespace " + namespace + " is provisioned",
+		ObservedGeneration: team.Generation,
+	}) || changed {
+		log.Info("Provisioned team namespace", "team", team.Name, "namespace", namespace)
+		if err := r.Status().Update(ctx, team); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *TeamReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&catalystv1alpha1.Team{}).
+		Named("team").
+		Complete(drainingReconciler{r})
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+// Template catalog:
+// Shared EnvironmentTemplates are referenced from Project spec.templateRefs. Controllers
+// resolve the references into the in-memory copy of spec.templates right after fetching the
+// Project, so revisions, rollouts and rendering treat catalog and inline templates alike.
+
+// +kubebuilder:rbac:groups=catalyst.catalyst.dev,resources=environmenttemplates,verbs=get;list;watch
+
+// templateRefNamespace returns the namespace of a catalog reference (default: the Project's)
+func templateRefNamespace(project *catalystv1alpha1.Project, ref catalystv1alpha1.TemplateReference) string {
+	if ref.Namespace != "" {
+		return ref.Namespace
+	}
+	return project.Namespace
+}
+
+// resolveTemplateCatalog copies the referenced catalog templates into project.Spec.Templates.
+// Inline templates with the same key are kept. A missing catalog template is an error.
+func resolveTemplateCatalog(ctx context.Context, c client.Reader, project *catalystv1alpha1.Project) error {
+	for key, ref := range project.Spec.TemplateRefs {
+		if _, inline := project.Spec.Templates[key]; inline {
+			continue
+		}
+		namespace := templateRefNamespace(project, ref)
+		catalogTemplate := &catalystv1alpha1.EnvironmentTemplate{}
+		if err := c.Get(ctx, client.ObjectKey{Name: ref.Name, Namespace: namespace}, catalogTemplate); err != nil {
+			if apierrors.IsNotFound(err) {
+				return fmt.Errorf("template %q references EnvironmentTemplate %s/%s, which does not exist", key, namespace, ref.Name)
+			}
+			return err
+		}
+		if project.Spec.Templates == nil {
+			project.Spec.Templates = map[string]catalystv1alpha1.EnvironmentTemplateSpec{}
+		}
+		project.Spec.Templates[key] = catalogTemplate.Spec
+	}
+	return nil
+}
+
+// projectsForEnvironmentTemplate enqueues the Projects referencing a catalog template,
+// so a template change is recorded as a new revision on every Project using it
+func projectsForEnvironmentTemplate(c client.Reader) func(context.Context, client.Object) []reconcile.Request {
+	return func(ctx context.Context, obj client.Object) []reconcile.Request {
+		projects := &catalystv1alpha1.ProjectList{}
+		if err := c.List(ctx, projects); err != nil {
+			return nil
+		}
+		var requests []reconcile.Request
+		for _, project := range projects.Items {
+			for _, ref := range project.Spec.TemplateRefs {
+				if ref.Name == obj.GetName() && templateRefNamespace(&project, ref) == obj.GetNamespace() {
+					requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&project)})
+					break
+				}
+			}
+		}
+		return requests
+	}
+}
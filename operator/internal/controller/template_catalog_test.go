@@ -0,0 +1,71 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+func TestResolveTemplateCatalog(t *testing.T) {
+	golden := &catalystv1alpha1.EnvironmentTemplate{
+		ObjectMeta: metav1.ObjectMeta{Name: "nextjs-postgres", Namespace: "catalog"},
+		Spec:       catalystv1alpha1.EnvironmentTemplateSpec{Type: "manifest", Config: &catalystv1alpha1.EnvironmentConfig{Image: "node:22-slim"}},
+	}
+	local := &catalystv1alpha1.EnvironmentTemplate{
+		ObjectMeta: metav1.ObjectMeta{Name: "go-api", Namespace: "team"},
+		Spec:       catalystv1alpha1.EnvironmentTemplateSpec{Type: "helm", Path: "charts/api"},
+	}
+	c := newFakeClientBuilder().WithObjects(golden, local).Build()
+	ctx := context.Background()
+
+	project := &catalystv1alpha1.Project{
+		ObjectMeta: metav1.ObjectMeta{Name: "shop", Namespace: "team"},
+		Spec: catalystv1alpha1.ProjectSpec{
+			Templates: map[string]catalystv1alpha1.EnvironmentTemplateSpec{
+				"deployment": {Type: "helm", Path: "charts/shop"},
+			},
+			TemplateRefs: map[string]catalystv1alpha1.TemplateReference{
+				"development": {Name: "nextjs-postgres", Namespace: "catalog"},
+				"staging":     {Name: "go-api"},
+				// Inline templates take precedence
+				"deployment": {Name: "go-api"},
+			},
+		},
+	}
+	require.NoError(t, resolveTemplateCatalog(ctx, c, project))
+	assert.Equal(t, "node:22-slim", project.Spec.Templates["development"].Config.Image)
+	assert.Equal(t, "charts/api", project.Spec.Templates["staging"].Path)
+	assert.Equal(t, "charts/shop", project.Spec.Templates["deployment"].Path)
+
+	project.Spec.TemplateRefs["preview"] = catalystv1alpha1.TemplateReference{Name: "missing"}
+	err := resolveTemplateCatalog(ctx, c, project)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "team/missing")
+}
+
+func TestProjectsForEnvironmentTemplate(t *testing.T) {
+	using := &catalystv1alpha1.Project{
+		ObjectMeta: metav1.ObjectMeta{Name: "shop", Namespace: "team"},
+		Spec: catalystv1alpha1.ProjectSpec{TemplateRefs: map[string]catalystv1alpha1.TemplateReference{
+			"development": {Name: "nextjs-postgres", Namespace: "catalog"},
+		}},
+	}
+	other := &catalystv1alpha1.Project{
+		ObjectMeta: metav1.ObjectMeta{Name: "blog", Namespace: "team"},
+		Spec: catalystv1alpha1.ProjectSpec{TemplateRefs: map[string]catalystv1alpha1.TemplateReference{
+			"development": {Name: "nextjs-postgres"},
+		}},
+	}
+	c := newFakeClientBuilder().WithObjects(using, other).Build()
+
+	requests := projectsForEnvironmentTemplate(c)(context.Background(), &catalystv1alpha1.EnvironmentTemplate{
+		ObjectMeta: metav1.ObjectMeta{Name: "nextjs-postgres", Namespace: "catalog"},
+	})
+	require.Len(t, requests, 1)
+	assert.Equal(t, "shop", requests[0].Name)
+}
@@ -0,0 +1,254 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+// Template revisions (versioned templates with rollout):
+// - The Project controller records every content change of spec.templates[key]
+//   as a new TemplateRevision in Project status.
+// - Environments record the revision hash they were rendered from and, depending
+//   on the template's rollout strategy, keep rendering from that revision until
+//   they are allowed to migrate.
+
+const (
+	rolloutImmediate           = "Immediate"
+	rolloutNewEnvironmentsOnly = "NewEnvironmentsOnly"
+	rolloutBatched             = "Batched"
+
+	// maxTemplateRevisions is the number of revisions retained per template key,
+	// not counting older revisions Environments are still pinned to
+	maxTemplateRevisions = 5
+)
+
+// templateHash computes a stable content hash of a template.
+// The rollout policy is excluded so that changing how a revision rolls out
+// does not itself produce a new revision.
+func templateHash(template *catalystv1alpha1.EnvironmentTemplateSpec) string {
+	t := template.DeepCopy()
+	t.Rollout = nil
+	data, err := json.Marshal(t)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+// recordTemplateRevisions appends a new revision for every template whose content
+// changed since its latest recorded revision and trims history to maxTemplateRevisions.
+// Revisions whose hash is in inUse are kept regardless, so Environments pinned to them
+// can keep rendering from them. Returns the updated history and whether it changed.
+func recordTemplateRevisions(templates map[string]catalystv1alpha1.EnvironmentTemplateSpec, history []catalystv1alpha1.TemplateRevision, inUse map[string]bool, now metav1.Time) ([]catalystv1alpha1.TemplateRevision, bool) {
+	keys := make([]string, 0, len(templates))
+	for key := range templates {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	changed := false
+	result := append([]catalystv1alpha1.TemplateRevision{}, history...)
+	for _, key := range keys {
+		tmpl := templates[key]
+		hash := templateHash(&tmpl)
+
+		latest := latestTemplateRevision(result, key)
+		if latest != nil && latest.Hash == hash {
+			continue
+		}
+
+		revision := int64(1)
+		if latest != nil {
+			revision = latest.Revision + 1
+		}
+		spec := tmpl.DeepCopy()
+		spec.Rollout = nil
+		result = append(result, catalystv1alpha1.TemplateRevision{
+			Template:  key,
+			Revision:  revision,
+			Hash:      hash,
+			CreatedAt: now,
+			Spec:      *spec,
+		})
+		changed = true
+	}
+
+	// Trim each template's history to the most recent revisions and those still in use
+	counts := make(map[string]int)
+	trimmed := make([]catalystv1alpha1.TemplateRevision, 0, len(result))
+	for i := len(result) - 1; i >= 0; i-- {
+		rev := result[i]
+		if inUse[rev.Hash] {
+			trimmed = append([]catalystv1alpha1.TemplateRevision{rev}, trimmed...)
+			continue
+		}
+		if counts[rev.Template] >= maxTemplateRevisions {
+			changed = true
+			continue
+		}
+		counts[rev.Template]++
+		trimmed = append([]catalystv1alpha1.TemplateRevision{rev}, trimmed...)
+	}
+
+	return trimmed, changed
+}
+
+// templateHashesInUse returns the template hashes the Project's Environments were rendered from.
+func templateHashesInUse(ctx context.Context, c client.Reader, project *catalystv1alpha1.Project) (map[string]bool, error) {
+	envList := &catalystv1alpha1.EnvironmentList{}
+	if err := c.List(ctx, envList, client.InNamespace(project.Namespace)); err != nil {
+		return nil, err
+	}
+	inUse := make(map[string]bool)
+	for _, env := range envList.Items {
+		if env.Spec.ProjectRef.Name == project.Name && env.Status.TemplateHash != "" {
+			inUse[env.Status.TemplateHash] = true
+		}
+	}
+	return inUse, nil
+}
+
+// latestTemplateRevision returns the highest recorded revision for a template key.
+func latestTemplateRevision(history []catalystv1alpha1.TemplateRevision, key string) *catalystv1alpha1.TemplateRevision {
+	var latest *catalystv1alpha1.TemplateRevision
+	for i := range history {
+		if history[i].Template != key {
+			continue
+		}
+		if latest == nil || history[i].Revision > latest.Revision {
+			latest = &history[i]
+		}
+	}
+	return latest
+}
+
+// findTemplateRevision returns the recorded revision of a template key with the given hash.
+func findTemplateRevision(history []catalystv1alpha1.TemplateRevision, key, hash string) *catalystv1alpha1.TemplateRevision {
+	for i := range history {
+		if history[i].Template == key && history[i].Hash == hash {
+			return &history[i]
+		}
+	}
+	return nil
+}
+
+// templateSelection is the outcome of choosing which template revision to render.
+type templateSelection struct {
+	// Template to render from (current spec or a pinned historical revision)
+	Template *catalystv1alpha1.EnvironmentTemplateSpec
+	// Revision number (0 if the Project controller has not recorded it yet)
+	Revision int64
+	// Hash of the selected template
+	Hash string
+	// Deferred is true when the environment is held on an older revision and
+	// should be retried later (Batched rollout with no free slot).
+	Deferred bool
+}
+
+// selectTemplateRevision decides which revision of a template an environment renders from.
+// migrating is the number of environments already migrating to the current revision.
+func selectTemplateRevision(env *catalystv1alpha1.Environment, project *catalystv1alpha1.Project, key string, current *catalystv1alpha1.EnvironmentTemplateSpec, migrating int) templateSelection {
+	history := project.Status.TemplateRevisions
+	currentHash := templateHash(current)
+	latest := templateSelection{Template: current, Hash: currentHash}
+	if rev := findTemplateRevision(history, key, currentHash); rev != nil {
+		latest.Revision = rev.Revision
+	}
+
+	// New environments and environments already on the current revision render from it
+	if env.Status.TemplateHash == "" || env.Status.TemplateHash == currentHash {
+		return latest
+	}
+
+	strategy := rolloutImmediate
+	batchSize := 0
+	if current.Rollout != nil {
+		if current.Rollout.Strategy != "" {
+			strategy = current.Rollout.Strategy
+		}
+		batchSize = current.Rollout.BatchSize
+	}
+
+	switch strategy {
+	case rolloutNewEnvironmentsOnly:
+		// Stay on the pinned revision
+	case rolloutBatched:
+		if batchSize < 1 {
+			batchSize = 1
+		}
+		if migrating < batchSize {
+			return latest
+		}
+	default:
+		return latest
+	}
+
+	pinned := findTemplateRevision(history, key, env.Status.TemplateHash)
+	if pinned == nil {
+		// Revisions in use are retained, so this one was never recorded (the environment
+		// predates revision history); nothing to render from but the current spec
+		return latest
+	}
+	return templateSelection{
+		Template: pinned.Spec.DeepCopy(),
+		Revision: pinned.Revision,
+		Hash:     pinned.Hash,
+		Deferred: strategy == rolloutBatched,
+	}
+}
+
+// resolveTemplateRevision selects the template revision for an environment, counting
+// in-flight migrations for Batched rollouts.
+func (r *EnvironmentReconciler) resolveTemplateRevision(ctx context.Context, env *catalystv1alpha1.Environment, project *catalystv1alpha1.Project, current *catalystv1alpha1.EnvironmentTemplateSpec) (templateSelection, error) {
+	log := logf.FromContext(ctx)
+
+	migrating := 0
+	if current.Rollout != nil && current.Rollout.Strategy == rolloutBatched {
+		currentHash := templateHash(current)
+		envList := &catalystv1alpha1.EnvironmentList{}
+		if err := r.List(ctx, envList, client.InNamespace(env.Namespace)); err != nil {
+			return templateSelection{}, err
+		}
+		for _, other := range envList.Items {
+			if other.Name == env.Name || other.Spec.ProjectRef.Name != project.Name || other.Spec.Type != env.Spec.Type {
+				continue
+			}
+			if other.Status.TemplateHash == currentHash && other.Status.Phase != "Ready" {
+				migrating++
+			}
+		}
+	}
+
+	selection := selectTemplateRevision(env, project, env.Spec.Type, current, migrating)
+	if selection.Deferred {
+		log.Info("Template rollout deferred; environment stays on pinned revision",
+			"revision", selection.Revision, "migrating", migrating)
+	}
+	return selection, nil
+}
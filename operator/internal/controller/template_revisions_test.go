@@ -0,0 +1,207 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+func TestTemplateHash_IgnoresRollout(t *testing.T) {
+	a := &catalystv1alpha1.EnvironmentTemplateSpec{Type: "helm", Path: "charts/app"}
+	b := a.DeepCopy()
+	b.Rollout = &catalystv1alpha1.TemplateRollout{Strategy: rolloutBatched, BatchSize: 2}
+
+	assert.Equal(t, templateHash(a), templateHash(b))
+
+	b.Path = "charts/other"
+	assert.NotEqual(t, templateHash(a), templateHash(b))
+}
+
+func TestRecordTemplateRevisions(t *testing.T) {
+	now := metav1.Now()
+	templates := map[string]catalystv1alpha1.EnvironmentTemplateSpec{
+		"development": {Type: "helm", Path: "charts/v1"},
+	}
+
+	history, changed := recordTemplateRevisions(templates, nil, nil, now)
+	assert.True(t, changed)
+	assert.Len(t, history, 1)
+	assert.Equal(t, int64(1), history[0].Revision)
+
+	// Unchanged template records nothing
+	_, changed = recordTemplateRevisions(templates, history, nil, now)
+	assert.False(t, changed)
+
+	// Changed template appends the next revision
+	templates["development"] = catalystv1alpha1.EnvironmentTemplateSpec{Type: "helm", Path: "charts/v2"}
+	history, changed = recordTemplateRevisions(templates, history, nil, now)
+	assert.True(t, changed)
+	assert.Len(t, history, 2)
+	assert.Equal(t, int64(2), history[1].Revision)
+}
+
+func TestRecordTemplateRevisions_TrimsHistory(t *testing.T) {
+	now := metav1.Now()
+	var history []catalystv1alpha1.TemplateRevision
+	for i := 0; i < maxTemplateRevisions+2; i++ {
+		templates := map[string]catalystv1alpha1.EnvironmentTemplateSpec{
+			"development": {Type: "helm", Path: string(rune('a' + i))},
+		}
+		history, _ = recordTemplateRevisions(templates, history, nil, now)
+	}
+
+	assert.Len(t, history, maxTemplateRevisions)
+	assert.Equal(t, int64(maxTemplateRevisions+2), history[len(history)-1].Revision)
+}
+
+func TestSelectTemplateRevision(t *testing.T) {
+	v1 := catalystv1alpha1.EnvironmentTemplateSpec{Type: "helm", Path: "charts/v1"}
+	v2 := catalystv1alpha1.EnvironmentTemplateSpec{Type: "helm", Path: "charts/v2"}
+	history, _ := recordTemplateRevisions(map[string]catalystv1alpha1.EnvironmentTemplateSpec{"development": v1}, nil, nil, metav1.Now())
+	history, _ = recordTemplateRevisions(map[string]catalystv1alpha1.EnvironmentTemplateSpec{"development": v2}, history, nil, metav1.Now())
+	project := &catalystv1alpha1.Project{Status: catalystv1alpha1.ProjectStatus{TemplateRevisions: history}}
+
+	pinnedEnv := &catalystv1alpha1.Environment{Status: catalystv1alpha1.EnvironmentStatus{TemplateHash: templateHash(&v1)}}
+
+	t.Run("new environment uses current revision", func(t *testing.T) {
+		current := v2.DeepCopy()
+		current.Rollout = &catalystv1alpha1.TemplateRollout{Strategy: rolloutNewEnvironmentsOnly}
+		sel := selectTemplateRevision(&catalystv1alpha1.Environment{}, project, "development", current, 0)
+		assert.Equal(t, int64(2), sel.Revision)
+		assert.Equal(t, "charts/v2", sel.Template.Path)
+	})
+
+	t.Run("immediate migrates existing environments", func(t *testing.T) {
+		sel := selectTemplateRevision(pinnedEnv, project, "development", v2.DeepCopy(), 0)
+		assert.Equal(t, int64(2), sel.Revision)
+	})
+
+	t.Run("new environments only keeps existing pinned", func(t *testing.T) {
+		current := v2.DeepCopy()
+		current.Rollout = &catalystv1alpha1.TemplateRollout{Strategy: rolloutNewEnvironmentsOnly}
+		sel := selectTemplateRevision(pinnedEnv, project, "development", current, 0)
+		assert.Equal(t, int64(1), sel.Revision)
+		assert.Equal(t, "charts/v1", sel.Template.Path)
+		assert.False(t, sel.Deferred)
+	})
+
+	t.Run("batched migrates while slots are free", func(t *testing.T) {
+		current := v2.DeepCopy()
+		current.Rollout = &catalystv1alpha1.TemplateRollout{Strategy: rolloutBatched, BatchSize: 2}
+		sel := selectTemplateRevision(pinnedEnv, project, "development", current, 1)
+		assert.Equal(t, int64(2), sel.Revision)
+
+		sel = selectTemplateRevision(pinnedEnv, project, "development", current, 2)
+		assert.Equal(t, int64(1), sel.Revision)
+		assert.True(t, sel.Deferred)
+	})
+}
+
+func TestRecordTemplateRevisions_KeepsPinnedRevisions(t *testing.T) {
+	// The environment was rendered from the first revision, which is older than the
+	// retained history but still in use
+	var history []catalystv1alpha1.TemplateRevision
+	var first catalystv1alpha1.EnvironmentTemplateSpec
+	var current *catalystv1alpha1.EnvironmentTemplateSpec
+	for i := 0; i < maxTemplateRevisions+2; i++ {
+		tmpl := catalystv1alpha1.EnvironmentTemplateSpec{Type: "helm", Path: "charts/v" + string(rune('1'+i))}
+		if i == 0 {
+			first = tmpl
+		}
+		current = tmpl.DeepCopy()
+		inUse := map[string]bool{templateHash(&first): true}
+		history, _ = recordTemplateRevisions(map[string]catalystv1alpha1.EnvironmentTemplateSpec{"development": tmpl}, history, inUse, metav1.Now())
+	}
+	assert.Len(t, history, maxTemplateRevisions+1)
+	require.NotNil(t, findTemplateRevision(history, "development", templateHash(&first)))
+	assert.Nil(t, findTemplateRevision(history, "development", templateHash(&catalystv1alpha1.EnvironmentTemplateSpec{Type: "helm", Path: "charts/v2"})))
+
+	project := &catalystv1alpha1.Project{Status: catalystv1alpha1.ProjectStatus{TemplateRevisions: history}}
+	env := &catalystv1alpha1.Environment{Status: catalystv1alpha1.EnvironmentStatus{TemplateHash: templateHash(&first)}}
+
+	current.Rollout = &catalystv1alpha1.TemplateRollout{Strategy: rolloutNewEnvironmentsOnly}
+	sel := selectTemplateRevision(env, project, "development", current, 0)
+	assert.Equal(t, int64(1), sel.Revision, "pinned environment stays on its revision")
+	assert.Equal(t, "charts/v1", sel.Template.Path)
+
+	current.Rollout = &catalystv1alpha1.TemplateRollout{Strategy: rolloutBatched, BatchSize: 1}
+	sel = selectTemplateRevision(env, project, "development", current, 1)
+	assert.Equal(t, int64(1), sel.Revision, "pinned environment waits for a free slot")
+	assert.True(t, sel.Deferred)
+
+	// Once no environment uses it, the revision is trimmed
+	history, changed := recordTemplateRevisions(map[string]catalystv1alpha1.EnvironmentTemplateSpec{"development": *current}, history, nil, metav1.Now())
+	assert.True(t, changed)
+	assert.Len(t, history, maxTemplateRevisions)
+	assert.Nil(t, findTemplateRevision(history, "development", templateHash(&first)))
+}
+
+func TestTemplateHashesInUse(t *testing.T) {
+	project := &catalystv1alpha1.Project{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "team"}}
+	environment := func(name, namespace, project, hash string) *catalystv1alpha1.Environment {
+		return &catalystv1alpha1.Environment{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec:       catalystv1alpha1.EnvironmentSpec{ProjectRef: catalystv1alpha1.ProjectReference{Name: project}},
+			Status:     catalystv1alpha1.EnvironmentStatus{TemplateHash: hash},
+		}
+	}
+	c := newFakeClientBuilder().WithObjects(
+		environment("pr-1", "team", "app", "aaa"),
+		environment("pr-2", "team", "app", ""),
+		environment("other-project", "team", "web", "bbb"),
+		environment("other-team", "elsewhere", "app", "ccc"),
+	).Build()
+
+	inUse, err := templateHashesInUse(context.Background(), c, project)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{"aaa": true}, inUse)
+}
+
+func TestResolveTemplateRevision_CountsMigrations(t *testing.T) {
+	v1 := catalystv1alpha1.EnvironmentTemplateSpec{Type: "helm", Path: "charts/v1"}
+	v2 := catalystv1alpha1.EnvironmentTemplateSpec{Type: "helm", Path: "charts/v2"}
+	history, _ := recordTemplateRevisions(map[string]catalystv1alpha1.EnvironmentTemplateSpec{"development": v1}, nil, nil, metav1.Now())
+	history, _ = recordTemplateRevisions(map[string]catalystv1alpha1.EnvironmentTemplateSpec{"development": v2}, history, nil, metav1.Now())
+	project := &catalystv1alpha1.Project{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "team"},
+		Status:     catalystv1alpha1.ProjectStatus{TemplateRevisions: history},
+	}
+
+	environment := func(name, project, envType, hash, phase string) *catalystv1alpha1.Environment {
+		return &catalystv1alpha1.Environment{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "team"},
+			Spec: catalystv1alpha1.EnvironmentSpec{
+				ProjectRef: catalystv1alpha1.ProjectReference{Name: project},
+				Type:       envType,
+			},
+			Status: catalystv1alpha1.EnvironmentStatus{TemplateHash: hash, Phase: phase},
+		}
+	}
+	pinned := environment("pr-1", "app", "development", templateHash(&v1), "Ready")
+	c := newFakeClientBuilder().WithObjects(
+		pinned,
+		environment("migrating", "app", "development", templateHash(&v2), "Deploying"),
+		environment("migrated", "app", "development", templateHash(&v2), "Ready"),
+		environment("other-project", "web", "development", templateHash(&v2), "Deploying"),
+		environment("other-type", "app", "deployment", templateHash(&v2), "Deploying"),
+	).Build()
+	r := &EnvironmentReconciler{Client: c, Scheme: testScheme}
+
+	current := v2.DeepCopy()
+	current.Rollout = &catalystv1alpha1.TemplateRollout{Strategy: rolloutBatched, BatchSize: 1}
+	sel, err := r.resolveTemplateRevision(context.Background(), pinned, project, current)
+	require.NoError(t, err)
+	assert.True(t, sel.Deferred, "the one migrating environment of the project and type fills the batch")
+	assert.Equal(t, int64(1), sel.Revision)
+
+	current.Rollout.BatchSize = 2
+	sel, err = r.resolveTemplateRevision(context.Background(), pinned, project, current)
+	require.NoError(t, err)
+	assert.False(t, sel.Deferred, "Ready environments and other projects and types don't count")
+	assert.Equal(t, int64(2), sel.Revision)
+}
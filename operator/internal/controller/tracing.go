@@ -0,0 +1,66 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+	"github.com/ncrmro/catalyst/operator/internal/tracing"
+)
+
+// Span attributes identifying the environment of a reconcile. Every reconcile is its own
+// trace; the environment and commit attributes find the retries of one provision.
+const (
+	attrEnvironment          = "catalyst.environment"
+	attrEnvironmentNamespace = "catalyst.environment.namespace"
+	attrGeneration           = "catalyst.environment.generation"
+	attrCommit               = "catalyst.commit"
+	attrPhase                = "catalyst.phase"
+)
+
+// environmentAttributes returns the span attributes of env
+func environmentAttributes(env *catalystv1alpha1.Environment) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{
+		attribute.String(attrEnvironment, env.Name),
+		attribute.String(attrEnvironmentNamespace, env.Namespace),
+		attribute.Int64(attrGeneration, env.Generation),
+		attribute.String(attrPhase, env.Status.Phase),
+	}
+	if len(env.Spec.Sources) > 0 && env.Spec.Sources[0].CommitSha != "" {
+		attrs = append(attrs, attribute.String(attrCommit, env.Spec.Sources[0].CommitSha))
+	}
+	return attrs
+}
+
+// startReconcileSpan starts the root span of a reconcile; the environment attributes are added
+// once the Environment is read
+func startReconcileSpan(ctx context.Context, req ctrl.Request) (context.Context, trace.Span) {
+	return tracing.Start(ctx, "Environment.Reconcile", trace.WithAttributes(
+		attribute.String(attrEnvironment, req.Name),
+		attribute.String(attrEnvironmentNamespace, req.Namespace),
+	))
+}
+
+// startSpan starts a span of a reconcile step of env
+func startSpan(ctx context.Context, name string, env *catalystv1alpha1.Environment, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracing.Start(ctx, name, trace.WithAttributes(append(environmentAttributes(env), attrs...)...))
+}
@@ -0,0 +1,63 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+// recordSpans installs a tracer provider recording the ended spans for the test
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	return recorder
+}
+
+func TestEnvironmentAttributes(t *testing.T) {
+	env := &catalystv1alpha1.Environment{
+		ObjectMeta: metav1.ObjectMeta{Name: "pr-1", Namespace: "team-shop", Generation: 3},
+		Spec:       catalystv1alpha1.EnvironmentSpec{Sources: []catalystv1alpha1.EnvironmentSource{{Name: "web", CommitSha: "abc123"}}},
+		Status:     catalystv1alpha1.EnvironmentStatus{Phase: "Building"},
+	}
+	assert.ElementsMatch(t, []attribute.KeyValue{
+		attribute.String(attrEnvironment, "pr-1"),
+		attribute.String(attrEnvironmentNamespace, "team-shop"),
+		attribute.Int64(attrGeneration, 3),
+		attribute.String(attrPhase, "Building"),
+		attribute.String(attrCommit, "abc123"),
+	}, environmentAttributes(env))
+
+	env.Spec.Sources = nil
+	assert.Len(t, environmentAttributes(env), 4, "environments without sources have no commit")
+}
+
+func TestPrepareSource_Span(t *testing.T) {
+	recorder := recordSpans(t)
+	env := &catalystv1alpha1.Environment{
+		ObjectMeta: metav1.ObjectMeta{Name: "pr-1", Namespace: "team-shop"},
+		Spec:       catalystv1alpha1.EnvironmentSpec{Sources: []catalystv1alpha1.EnvironmentSource{{Name: "web", CommitSha: "abc123"}}},
+	}
+	r := &EnvironmentReconciler{}
+
+	_, _, err := r.prepareSource(context.Background(), env, &catalystv1alpha1.Project{}, &catalystv1alpha1.EnvironmentTemplateSpec{})
+	require.Error(t, err)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "prepareSource", spans[0].Name())
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	assert.Contains(t, spans[0].Attributes(), attribute.String(attrCommit, "abc123"))
+	assert.Contains(t, spans[0].Attributes(), attribute.String(attrEnvironment, "pr-1"))
+}
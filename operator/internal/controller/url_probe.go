@@ -0,0 +1,243 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+	"github.com/ncrmro/catalyst/operator/internal/operatorconfig"
+	"github.com/ncrmro/catalyst/operator/internal/tracing"
+)
+
+// URLProbeConfig probes environment URLs over HTTP before environments turn Ready, so
+// status.url does not point at a 502 while the ingress controller has no ready backend yet.
+// Configured via operator environment variables:
+//   - URL_PROBE: "true" enables probing
+//   - URL_PROBE_INTERVAL: how often the URL of a Ready environment is probed again (default 1m)
+//   - URL_PROBE_TIMEOUT: timeout of a probe request (default 5s)
+//   - URL_PROBE_INSECURE_SKIP_VERIFY: "true" accepts any TLS certificate, for clusters with
+//     self-signed preview certificates
+//
+// A probe is a GET of status.url that does not follow redirects. Any response below 500 means
+// the application answers: sign-in redirects, 401s and 404s come from a running backend, while
+// 502, 503 and 504 come from the ingress controller. With generated basicAuth access the
+// probe sends the generated credentials. Failed probes are retried with exponential backoff;
+// the URLReady condition and status.urlProbe report the last result. Workspace and GitOps
+// environments are not probed.
+type URLProbeConfig struct {
+	Enabled            bool
+	Interval           time.Duration
+	Timeout            time.Duration
+	InsecureSkipVerify bool
+}
+
+const (
+	// conditionURLReady reports whether status.url answers HTTP requests
+	conditionURLReady = "URLReady"
+
+	defaultURLProbeInterval = time.Minute
+	defaultURLProbeTimeout  = 5 * time.Second
+	// urlProbeRetryInterval is the first retry of a failed probe; retries double from there
+	// up to the probe interval
+	urlProbeRetryInterval = 2 * time.Second
+)
+
+// currentURLProbeConfig returns the URL probe settings in effect. Unset durations use the
+// defaults.
+func currentURLProbeConfig() URLProbeConfig {
+	probe := operatorconfig.Current().URLProbe
+	cfg := URLProbeConfig{
+		Enabled:            probe.Enabled,
+		Interval:           defaultURLProbeInterval,
+		Timeout:            defaultURLProbeTimeout,
+		InsecureSkipVerify: probe.InsecureSkipVerify,
+	}
+	if probe.Interval.Duration > 0 {
+		cfg.Interval = probe.Interval.Duration
+	}
+	if probe.Timeout.Duration > 0 {
+		cfg.Timeout = probe.Timeout.Duration
+	}
+	return cfg
+}
+
+// urlProbeBackoff returns how long to wait after the given number of consecutive failures
+func urlProbeBackoff(cfg URLProbeConfig, failures int32) time.Duration {
+	wait := urlProbeRetryInterval
+	for i := int32(1); i < failures && wait < cfg.Interval; i++ {
+		wait *= 2
+	}
+	return min(wait, cfg.Interval)
+}
+
+// markReady moves an environment whose workloads are ready to the Ready phase, once its URL
+// answers when URL probing is enabled. The result requeues for the next probe.
+func (r *EnvironmentReconciler) markReady(ctx context.Context, env *catalystv1alpha1.Environment, namespace string) (ctrl.Result, error) {
+	answering, next, err := r.reconcileURLProbe(ctx, env, namespace)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if !answering {
+		logf.FromContext(ctx).Info("Waiting for the environment URL to answer", "url", env.Status.URL, "retryAfter", next)
+		return ctrl.Result{RequeueAfter: next}, nil
+	}
+	outcomeChanged := recordDeploymentOutcome(env, catalystv1alpha1.DeploymentOutcomeReady, metav1.Now().Rfc3339Copy())
+	if env.Status.Phase != "Ready" || outcomeChanged {
+		env.Status.Phase = "Ready"
+		if err := r.Status().Update(ctx, env); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	return ctrl.Result{RequeueAfter: next}, nil
+}
+
+// reconcileURLProbe probes status.url when the last probe is due and records the result in
+// status.urlProbe and the URLReady condition. It returns whether the URL answered at the last
+// probe and when the next probe is due; without probing every URL answers.
+func (r *EnvironmentReconciler) reconcileURLProbe(ctx context.Context, env *catalystv1alpha1.Environment, namespace string) (bool, time.Duration, error) {
+	cfg := currentURLProbeConfig()
+	if !cfg.Enabled || env.Status.URL == "" {
+		changed := meta.RemoveStatusCondition(&env.Status.Conditions, conditionURLReady)
+		if env.Status.URLProbe != nil {
+			env.Status.URLProbe = nil
+			changed = true
+		}
+		if changed {
+			return true, 0, r.Status().Update(ctx, env)
+		}
+		return true, 0, nil
+	}
+
+	now := time.Now()
+	if last := env.Status.URLProbe; last != nil && last.URL == env.Status.URL {
+		wait := cfg.Interval
+		if last.ConsecutiveFailures > 0 {
+			wait = urlProbeBackoff(cfg, last.ConsecutiveFailures)
+		}
+		if due := last.LastProbeTime.Add(wait); now.Before(due) {
+			return last.ConsecutiveFailures == 0, due.Sub(now), nil
+		}
+	}
+
+	username, password, err := r.urlProbeCredentials(ctx, env, namespace)
+	if err != nil {
+		return false, 0, err
+	}
+	status := &catalystv1alpha1.URLProbeStatus{URL: env.Status.URL, LastProbeTime: metav1.NewTime(now)}
+	probeCtx, span := startSpan(ctx, "probeURL", env, attribute.String("url.full", env.Status.URL))
+	code, err := r.probeURL(probeCtx, cfg, env.Status.URL, username, password)
+	span.SetAttributes(attribute.Int("http.response.status_code", code))
+	tracing.End(span, err)
+	status.HTTPStatus = int32(code)
+	switch {
+	case err != nil:
+		status.Error = err.Error()
+	case code >= http.StatusInternalServerError:
+		status.Error = fmt.Sprintf("answered %d %s", code, http.StatusText(code))
+	}
+
+	condition := metav1.Condition{
+		Type:               conditionURLReady,
+		Status:             metav1.ConditionTrue,
+		Reason:             "Answering",
+		Message:            fmt.Sprintf("%s answered %d", env.Status.URL, code),
+		ObservedGeneration: env.Generation,
+	}
+	next := cfg.Interval
+	if status.Error != "" {
+		status.ConsecutiveFailures = 1
+		if last := env.Status.URLProbe; last != nil && last.URL == env.Status.URL {
+			status.ConsecutiveFailures = last.ConsecutiveFailures + 1
+		}
+		next = urlProbeBackoff(cfg, status.ConsecutiveFailures)
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "NotAnswering"
+		condition.Message = fmt.Sprintf("%s: %s", env.Status.URL, status.Error)
+	}
+	env.Status.URLProbe = status
+	meta.SetStatusCondition(&env.Status.Conditions, condition)
+	if err := r.Status().Update(ctx, env); err != nil {
+		return false, 0, err
+	}
+	return status.Error == "", next, nil
+}
+
+// urlProbeCredentials returns the credentials of generated basicAuth access, which reach the
+// application; an htpasswd Secret of the user's holds no password, and its 401 still comes
+// from the ingress controller answering
+func (r *EnvironmentReconciler) urlProbeCredentials(ctx context.Context, env *catalystv1alpha1.Environment, namespace string) (string, string, error) {
+	if accessType(env) != catalystv1alpha1.AccessTypeBasicAuth {
+		return "", "", nil
+	}
+	name, generated := basicAuthSecret(env)
+	if !generated {
+		return "", "", nil
+	}
+	secret := &corev1.Secret{}
+	if err := r.Get(ctx, client.ObjectKey{Name: name, Namespace: namespace}, secret); err != nil {
+		if apierrors.IsNotFound(err) {
+			return "", "", nil
+		}
+		return "", "", err
+	}
+	return string(secret.Data["username"]), string(secret.Data["password"]), nil
+}
+
+// probeURL GETs url without following redirects and returns the response status code
+func (r *EnvironmentReconciler) probeURL(ctx context.Context, cfg URLProbeConfig, url, username, password string) (int, error) {
+	httpClient := r.HTTPClient
+	if httpClient == nil {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		if cfg.InsecureSkipVerify {
+			transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true} //nolint:gosec
+		}
+		httpClient = &http.Client{
+			Transport:     transport,
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		}
+	}
+	ctx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("User-Agent", "catalyst-operator")
+	if username != "" {
+		req.SetBasicAuth(username, password)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	_ = resp.Body.Close()
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,142 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+func TestCurrentURLProbeConfig(t *testing.T) {
+	assert.Equal(t, URLProbeConfig{Interval: time.Minute, Timeout: 5 * time.Second}, currentURLProbeConfig())
+
+	t.Setenv("URL_PROBE", "true")
+	t.Setenv("URL_PROBE_INTERVAL", "30s")
+	t.Setenv("URL_PROBE_TIMEOUT", "bogus")
+	t.Setenv("URL_PROBE_INSECURE_SKIP_VERIFY", "true")
+	assert.Equal(t, URLProbeConfig{Enabled: true, Interval: 30 * time.Second, Timeout: 5 * time.Second, InsecureSkipVerify: true}, currentURLProbeConfig())
+}
+
+func TestURLProbeBackoff(t *testing.T) {
+	cfg := URLProbeConfig{Interval: 20 * time.Second}
+	assert.Equal(t, 2*time.Second, urlProbeBackoff(cfg, 1))
+	assert.Equal(t, 4*time.Second, urlProbeBackoff(cfg, 2))
+	assert.Equal(t, 16*time.Second, urlProbeBackoff(cfg, 4))
+	assert.Equal(t, 20*time.Second, urlProbeBackoff(cfg, 5), "retries back off up to the probe interval")
+	assert.Equal(t, 20*time.Second, urlProbeBackoff(cfg, 40))
+}
+
+func TestMarkReady_URLProbe(t *testing.T) {
+	t.Setenv("URL_PROBE", "true")
+
+	var status atomic.Int32
+	status.Store(http.StatusBadGateway)
+	var authorized atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		user, password, ok := req.BasicAuth()
+		authorized.Store(ok && user == "preview" && password == "s3cret")
+		if req.URL.Path == "/" {
+			http.Redirect(w, req, "/login", http.StatusFound)
+			return
+		}
+		w.WriteHeader(int(status.Load()))
+	}))
+	defer server.Close()
+
+	env := &catalystv1alpha1.Environment{
+		ObjectMeta: metav1.ObjectMeta{Name: "pr-1", Namespace: "team", Generation: 1},
+		Spec:       catalystv1alpha1.EnvironmentSpec{Access: &catalystv1alpha1.EnvironmentAccess{Type: catalystv1alpha1.AccessTypeBasicAuth}},
+		Status:     catalystv1alpha1.EnvironmentStatus{Phase: "Provisioning", URL: server.URL + "/health"},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: basicAuthSecretName, Namespace: "env-ns"},
+		Data:       map[string][]byte{"username": []byte("preview"), "password": []byte("s3cret")},
+	}
+	c := newFakeClientBuilder().WithStatusSubresource(env).WithObjects(env, secret).Build()
+	r := &EnvironmentReconciler{Client: c, Scheme: testScheme}
+	ctx := context.Background()
+
+	// The ingress controller has no backend yet
+	result, err := r.markReady(ctx, env, "env-ns")
+	require.NoError(t, err)
+	assert.Equal(t, "Provisioning", env.Status.Phase)
+	assert.Equal(t, urlProbeRetryInterval, result.RequeueAfter)
+	require.NotNil(t, env.Status.URLProbe)
+	assert.Equal(t, int32(http.StatusBadGateway), env.Status.URLProbe.HTTPStatus)
+	assert.Equal(t, int32(1), env.Status.URLProbe.ConsecutiveFailures)
+	assert.Equal(t, "answered 502 Bad Gateway", env.Status.URLProbe.Error)
+	assert.True(t, authorized.Load(), "the probe sends the generated basic auth credentials")
+	condition := meta.FindStatusCondition(env.Status.Conditions, conditionURLReady)
+	require.NotNil(t, condition)
+	assert.Equal(t, metav1.ConditionFalse, condition.Status)
+
+	// Retries wait for the backoff
+	probed := env.Status.URLProbe.LastProbeTime
+	result, err = r.markReady(ctx, env, "env-ns")
+	require.NoError(t, err)
+	assert.Equal(t, probed, env.Status.URLProbe.LastProbeTime)
+	assert.Positive(t, result.RequeueAfter)
+	assert.LessOrEqual(t, result.RequeueAfter, urlProbeRetryInterval)
+
+	// The application answers, even with a client error
+	status.Store(http.StatusNotFound)
+	env.Status.URLProbe.LastProbeTime = metav1.NewTime(time.Now().Add(-time.Minute))
+	result, err = r.markReady(ctx, env, "env-ns")
+	require.NoError(t, err)
+	assert.Equal(t, "Ready", env.Status.Phase)
+	assert.Equal(t, defaultURLProbeInterval, result.RequeueAfter, "Ready environments are probed periodically")
+	assert.Equal(t, int32(http.StatusNotFound), env.Status.URLProbe.HTTPStatus)
+	assert.Zero(t, env.Status.URLProbe.ConsecutiveFailures)
+	assert.Empty(t, env.Status.URLProbe.Error)
+	assert.True(t, meta.IsStatusConditionTrue(env.Status.Conditions, conditionURLReady))
+
+	// Redirects are not followed
+	env.Status.URL = server.URL + "/"
+	_, err = r.markReady(ctx, env, "env-ns")
+	require.NoError(t, err)
+	assert.Equal(t, int32(http.StatusFound), env.Status.URLProbe.HTTPStatus)
+
+	// Without probing the condition and status are removed
+	t.Setenv("URL_PROBE", "")
+	result, err = r.markReady(ctx, env, "env-ns")
+	require.NoError(t, err)
+	assert.Zero(t, result.RequeueAfter)
+	assert.Nil(t, env.Status.URLProbe)
+	assert.Nil(t, meta.FindStatusCondition(env.Status.Conditions, conditionURLReady))
+}
+
+func TestMarkReady_Unreachable(t *testing.T) {
+	t.Setenv("URL_PROBE", "true")
+	t.Setenv("URL_PROBE_TIMEOUT", "500ms")
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL
+	server.Close()
+
+	env := &catalystv1alpha1.Environment{
+		ObjectMeta: metav1.ObjectMeta{Name: "pr-1", Namespace: "team"},
+		Status: catalystv1alpha1.EnvironmentStatus{
+			Phase: "Provisioning", URL: url,
+			URLProbe: &catalystv1alpha1.URLProbeStatus{URL: url, ConsecutiveFailures: 2, LastProbeTime: metav1.NewTime(time.Now().Add(-time.Minute))},
+		},
+	}
+	c := newFakeClientBuilder().WithStatusSubresource(env).WithObjects(env).Build()
+	r := &EnvironmentReconciler{Client: c, Scheme: testScheme}
+
+	result, err := r.markReady(context.Background(), env, "env-ns")
+	require.NoError(t, err)
+	assert.Equal(t, "Provisioning", env.Status.Phase)
+	assert.Equal(t, 4*urlProbeRetryInterval, result.RequeueAfter)
+	assert.Zero(t, env.Status.URLProbe.HTTPStatus)
+	assert.NotEmpty(t, env.Status.URLProbe.Error)
+	assert.Equal(t, int32(3), env.Status.URLProbe.ConsecutiveFailures)
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+// Dependent service readiness (wait-for init containers):
+// The web pods of the development and production modes, and compose services with depends_on,
+// start with one init container per service they depend on, looping until it accepts TCP
+// connections, or answers HTTP when a managed service has an httpGet readiness probe. Waiting
+// in the pod, rather than only in the reconcile, holds the app back whenever its pod starts:
+// after a node drain or a StatefulSet restart as much as on the first deploy.
+
+// waitForImage runs the wait loops
+const waitForImage = "busybox:1.36"
+
+// waitTarget is a service a pod waits for
+type waitTarget struct {
+	// name names the init container "wait-for-<name>"
+	name string
+	host string
+	port int32
+	// httpPath, when set, waits for an HTTP response instead of a TCP connection
+	httpPath string
+}
+
+// waitForInitContainer returns the init container waiting for target
+func waitForInitContainer(target waitTarget) corev1.Container {
+	check := fmt.Sprintf("nc -z %s %d", target.host, target.port)
+	if target.httpPath != "" {
+		check = fmt.Sprintf("wget -q -O /dev/null -T 2 http://%s:%d%s", target.host, target.port, target.httpPath)
+	}
+	return corev1.Container{
+		Name:    "wait-for-" + target.name,
+		Image:   waitForImage,
+		Command: []string{"sh", "-c", fmt.Sprintf("until %s; do echo waiting for %s; sleep 2; done", check, target.name)},
+	}
+}
+
+// managedServiceWaitTargets returns the services the web pods wait for: the Service of each
+// managed service (its pooler when pooled), or the primary of a provider-backed Postgres
+func managedServiceWaitTargets(namespace string, services []catalystv1alpha1.ManagedServiceSpec) []waitTarget {
+	var targets []waitTarget
+	for _, svcSpec := range services {
+		if svcSpec.Provider != "" {
+			t := resolvePostgresTarget(namespace, svcSpec)
+			targets = append(targets, waitTarget{name: svcSpec.Name, host: t.host, port: postgresPort})
+			continue
+		}
+		if svcSpec.Pooler != nil && isPostgresService(svcSpec) {
+			targets = append(targets, waitTarget{name: poolerName(svcSpec), host: poolerName(svcSpec), port: postgresPort})
+			continue
+		}
+		target := waitTarget{name: svcSpec.Name, host: svcSpec.Name}
+		for _, p := range svcSpec.Container.Ports {
+			if p.Protocol == "" || p.Protocol == corev1.ProtocolTCP {
+				target.port = p.ContainerPort
+				break
+			}
+		}
+		if probe := svcSpec.Container.ReadinessProbe; probe != nil && probe.HTTPGet != nil && probe.HTTPGet.Port.IntVal > 0 {
+			target.port = probe.HTTPGet.Port.IntVal
+			target.httpPath = probe.HTTPGet.Path
+			if target.httpPath == "" {
+				target.httpPath = "/"
+			}
+		}
+		if target.port == 0 {
+			if !isPostgresService(svcSpec) {
+				continue
+			}
+			target.port = postgresPort
+		}
+		targets = append(targets, target)
+	}
+	return targets
+}
+
+// waitForInitContainers returns the init containers waiting for targets
+func waitForInitContainers(targets []waitTarget) []corev1.Container {
+	var containers []corev1.Container
+	for _, target := range targets {
+		containers = append(containers, waitForInitContainer(target))
+	}
+	return containers
+}
@@ -0,0 +1,68 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+func TestManagedServiceWaitTargets(t *testing.T) {
+	services := []catalystv1alpha1.ManagedServiceSpec{
+		{Name: "postgres"},
+		{Name: "redis", Container: catalystv1alpha1.ManagedServiceContainer{Ports: []corev1.ContainerPort{{ContainerPort: 6379}}}},
+		{Name: "minio", Container: catalystv1alpha1.ManagedServiceContainer{
+			Ports:          []corev1.ContainerPort{{ContainerPort: 9000}},
+			ReadinessProbe: &corev1.Probe{ProbeHandler: corev1.ProbeHandler{HTTPGet: &corev1.HTTPGetAction{Path: "/minio/health/ready", Port: intstr.FromInt32(9000)}}},
+		}},
+		{Name: "db", Provider: postgresProviderCNPG},
+		{Name: "postgresql", Pooler: &catalystv1alpha1.ConnectionPoolerSpec{}},
+		{Name: "worker"},
+	}
+	assert.Equal(t, []waitTarget{
+		{name: "postgres", host: "postgres", port: 5432},
+		{name: "redis", host: "redis", port: 6379},
+		{name: "minio", host: "minio", port: 9000, httpPath: "/minio/health/ready"},
+		{name: "db", host: "db-rw", port: 5432},
+		{name: "postgresql-pooler", host: "postgresql-pooler", port: 5432},
+	}, managedServiceWaitTargets("ns", services), "services without ports cannot be waited for")
+}
+
+func TestWaitForInitContainer(t *testing.T) {
+	tcp := waitForInitContainer(waitTarget{name: "redis", host: "redis", port: 6379})
+	assert.Equal(t, "wait-for-redis", tcp.Name)
+	assert.Equal(t, waitForImage, tcp.Image)
+	assert.Equal(t, []string{"sh", "-c", "until nc -z redis 6379; do echo waiting for redis; sleep 2; done"}, tcp.Command)
+
+	http := waitForInitContainer(waitTarget{name: "minio", host: "minio", port: 9000, httpPath: "/minio/health/ready"})
+	assert.Contains(t, http.Command[2], "wget -q -O /dev/null -T 2 http://minio:9000/minio/health/ready")
+}
+
+func TestDeploymentsWaitForManagedServices(t *testing.T) {
+	config := &catalystv1alpha1.EnvironmentConfig{
+		Image:    "app:1",
+		Services: []catalystv1alpha1.ManagedServiceSpec{{Name: "postgres"}, {Name: "db", Provider: postgresProviderZalando}},
+	}
+
+	// Production mode runs only the provider-backed services
+	spec := desiredDeploymentFromConfig("ns", config).Spec.Template.Spec
+	require.Len(t, spec.InitContainers, 1)
+	assert.Equal(t, "wait-for-db", spec.InitContainers[0].Name)
+	assert.Contains(t, spec.InitContainers[0].Command[2], "nc -z catalyst-db 5432")
+	assert.True(t, *spec.InitContainers[0].SecurityContext.ReadOnlyRootFilesystem, "wait containers are hardened like the others")
+
+	config.InitContainers = []catalystv1alpha1.InitContainerSpec{{Name: "migrate", Image: "app:1"}}
+	project := &catalystv1alpha1.Project{Spec: catalystv1alpha1.ProjectSpec{DependencyCache: &catalystv1alpha1.DependencyCacheSpec{}}}
+	spec = desiredDevelopmentDeploymentFromConfig(&catalystv1alpha1.Environment{}, project, "ns", config).Spec.Template.Spec
+	var names []string
+	for _, c := range spec.InitContainers {
+		names = append(names, c.Name)
+	}
+	assert.Equal(t, []string{"wait-for-postgres", "wait-for-db", "migrate"}, names, "migrations run once the services accept connections")
+	assert.False(t, hasMountPath(spec.InitContainers[0].VolumeMounts, defaultDependencyCacheMountPath), "no dependency cache for wait containers")
+	assert.True(t, hasMountPath(spec.InitContainers[2].VolumeMounts, defaultDependencyCacheMountPath))
+}
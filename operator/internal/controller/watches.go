@@ -0,0 +1,97 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+// Workload watches:
+// Deployments, StatefulSets, Jobs and Pods the operator creates in environment namespaces carry
+// the catalyst.dev/environment label. Their events are mapped back to the Environment through
+// an index on the target namespace, so readiness and Job completion trigger a reconcile
+// instead of short RequeueAfter polling loops.
+
+const (
+	environmentLabel = "catalyst.dev/environment"
+	// environmentTargetNamespaceIndex indexes Environments by the namespace their workloads run in
+	environmentTargetNamespaceIndex = "catalyst.dev/target-namespace"
+	// workloadResyncInterval is the safety net while waiting on watched workloads, covering
+	// workloads created before they were labeled
+	workloadResyncInterval = time.Minute
+)
+
+// labelEnvironmentWorkload adds the environment label to a workload and its pod template
+// (not the selector, which is immutable)
+func labelEnvironmentWorkload(env *catalystv1alpha1.Environment, obj client.Object) {
+	value := sanitizeLabelValue(env.Name)
+	addLabel := func(labels map[string]string) map[string]string {
+		if labels == nil {
+			labels = map[string]string{}
+		}
+		labels[environmentLabel] = value
+		return labels
+	}
+	obj.SetLabels(addLabel(obj.GetLabels()))
+	switch w := obj.(type) {
+	case *appsv1.Deployment:
+		w.Spec.Template.Labels = addLabel(w.Spec.Template.Labels)
+	case *appsv1.StatefulSet:
+		w.Spec.Template.Labels = addLabel(w.Spec.Template.Labels)
+	case *batchv1.Job:
+		w.Spec.Template.Labels = addLabel(w.Spec.Template.Labels)
+	}
+}
+
+// environmentTargetNamespace is the index function for environmentTargetNamespaceIndex
+func environmentTargetNamespace(obj client.Object) []string {
+	hierarchy := ExtractNamespaceHierarchy(obj.GetLabels())
+	if hierarchy == nil {
+		return nil
+	}
+	return []string{GenerateEnvironmentNamespace(hierarchy.Team, hierarchy.Project, hierarchy.Environment)}
+}
+
+// hasEnvironmentLabel filters watch events to workloads created for an Environment
+var hasEnvironmentLabel = predicate.NewPredicateFuncs(func(obj client.Object) bool {
+	_, ok := obj.GetLabels()[environmentLabel]
+	return ok
+})
+
+// environmentsForWorkload enqueues the Environment whose target namespace holds the workload
+func (r *EnvironmentReconciler) environmentsForWorkload(ctx context.Context, obj client.Object) []reconcile.Request {
+	envs := &catalystv1alpha1.EnvironmentList{}
+	if err := r.List(ctx, envs, client.MatchingFields{environmentTargetNamespaceIndex: obj.GetNamespace()}); err != nil {
+		logf.FromContext(ctx).Error(err, "Failed to list Environments for workload", "namespace", obj.GetNamespace(), "name", obj.GetName())
+		return nil
+	}
+	requests := make([]reconcile.Request, 0, len(envs.Items))
+	for _, env := range envs.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&env)})
+	}
+	return requests
+}
@@ -0,0 +1,56 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+func TestLabelEnvironmentWorkload(t *testing.T) {
+	env := &catalystv1alpha1.Environment{ObjectMeta: metav1.ObjectMeta{Name: "pr-1"}}
+
+	deployment := &appsv1.Deployment{}
+	deployment.Spec.Selector = &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}}
+	labelEnvironmentWorkload(env, deployment)
+	assert.Equal(t, "pr-1", deployment.Labels[environmentLabel])
+	assert.Equal(t, "pr-1", deployment.Spec.Template.Labels[environmentLabel])
+	assert.Equal(t, map[string]string{"app": "web"}, deployment.Spec.Selector.MatchLabels, "selectors are immutable")
+
+	job := &batchv1.Job{}
+	labelEnvironmentWorkload(env, job)
+	assert.True(t, hasEnvironmentLabel.Create(event.CreateEvent{Object: job}))
+	assert.False(t, hasEnvironmentLabel.Create(event.CreateEvent{Object: &batchv1.Job{}}))
+}
+
+func TestEnvironmentsForWorkload(t *testing.T) {
+	hierarchy := func(env string) map[string]string {
+		return map[string]string{
+			"catalyst.dev/team":        "acme",
+			"catalyst.dev/project":     "shop",
+			"catalyst.dev/environment": env,
+		}
+	}
+	pr1 := &catalystv1alpha1.Environment{ObjectMeta: metav1.ObjectMeta{Name: "pr-1", Namespace: "acme", Labels: hierarchy("pr-1")}}
+	pr2 := &catalystv1alpha1.Environment{ObjectMeta: metav1.ObjectMeta{Name: "pr-2", Namespace: "acme", Labels: hierarchy("pr-2")}}
+	c := newFakeClientBuilder().
+		WithIndex(&catalystv1alpha1.Environment{}, environmentTargetNamespaceIndex, environmentTargetNamespace).
+		WithObjects(pr1, pr2).Build()
+	r := &EnvironmentReconciler{Client: c, Scheme: testScheme}
+
+	job := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "web-build", Namespace: GenerateEnvironmentNamespace("acme", "shop", "pr-2")}}
+	requests := r.environmentsForWorkload(context.Background(), job)
+	require.Len(t, requests, 1)
+	assert.Equal(t, "pr-2", requests[0].Name)
+	assert.Equal(t, "acme", requests[0].Namespace)
+
+	job.Namespace = "unrelated"
+	assert.Empty(t, r.environmentsForWorkload(context.Background(), job))
+}
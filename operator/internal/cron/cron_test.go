@@ -0,0 +1,73 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cron
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse_Invalid(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "* * * * 8", "*/0 * * * *", "5-1 * * * *", "* * * FOO *"} {
+		_, err := Parse(expr)
+		assert.Error(t, err, expr)
+	}
+}
+
+func TestNextAndPrev(t *testing.T) {
+	// Wednesday
+	now := time.Date(2025, 6, 11, 12, 30, 15, 0, time.UTC)
+	cases := []struct {
+		expr       string
+		next, prev time.Time
+	}{
+		{"0 6 * * *", time.Date(2025, 6, 12, 6, 0, 0, 0, time.UTC), time.Date(2025, 6, 11, 6, 0, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2025, 6, 11, 12, 45, 0, 0, time.UTC), time.Date(2025, 6, 11, 12, 30, 0, 0, time.UTC)},
+		{"0 20 * * MON-FRI", time.Date(2025, 6, 11, 20, 0, 0, 0, time.UTC), time.Date(2025, 6, 10, 20, 0, 0, 0, time.UTC)},
+		{"0 9 * * sat,7", time.Date(2025, 6, 14, 9, 0, 0, 0, time.UTC), time.Date(2025, 6, 8, 9, 0, 0, 0, time.UTC)},
+		{"0 0 1 JAN *", time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"30 8-18/2 * * *", time.Date(2025, 6, 11, 14, 30, 0, 0, time.UTC), time.Date(2025, 6, 11, 12, 30, 0, 0, time.UTC)},
+		// Both day fields restricted: the 15th or any Monday
+		{"0 0 15 * 1", time.Date(2025, 6, 15, 0, 0, 0, 0, time.UTC), time.Date(2025, 6, 9, 0, 0, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2025, 6, 15, 0, 0, 0, 0, time.UTC), time.Date(2025, 6, 8, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tc := range cases {
+		s, err := Parse(tc.expr)
+		require.NoError(t, err, tc.expr)
+		assert.Equal(t, tc.next, s.Next(now), "next %s", tc.expr)
+		assert.Equal(t, tc.prev, s.Prev(now), "prev %s", tc.expr)
+	}
+
+	never, err := Parse("0 0 30 2 *")
+	require.NoError(t, err)
+	assert.True(t, never.Next(now).IsZero())
+	assert.True(t, never.Prev(now).IsZero())
+}
+
+func TestNext_TimeZone(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	s, err := Parse("0 6 * * *")
+	require.NoError(t, err)
+
+	// 6am in Berlin is 4am UTC in summer
+	next := s.Next(time.Date(2025, 6, 11, 0, 0, 0, 0, berlin))
+	assert.Equal(t, time.Date(2025, 6, 11, 4, 0, 0, 0, time.UTC), next.UTC())
+}
@@ -0,0 +1,240 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package dashboard serves a read-only operator status page generated from the
+// manager's informer cache, for clusters where the Catalyst web app isn't deployed.
+package dashboard
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"html/template"
+	"net/http"
+	"sort"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+// maxRecentFailures is the number of failed environments listed on the page
+const maxRecentFailures = 10
+
+// Server serves the status page. It implements manager.Runnable.
+type Server struct {
+	// Reader is used to list resources (the manager's cached client)
+	Reader client.Reader
+	// BindAddress is the address the page is served on (e.g. ":8082")
+	BindAddress string
+	// Filter protects the handler with authn/authz (e.g. filters.WithAuthenticationAndAuthorization).
+	// Access is then granted via RBAC on the nonResourceURL "/dashboard".
+	Filter metricsserver.Filter
+}
+
+// EnvironmentSummary is a single row on the status page
+type EnvironmentSummary struct {
+	Name          string `json:"name"`
+	Namespace     string `json:"namespace"`
+	Project       string `json:"project"`
+	Type          string `json:"type"`
+	Phase         string `json:"phase"`
+	URL           string `json:"url,omitempty"`
+	BuildDuration string `json:"buildDuration,omitempty"`
+	Message       string `json:"message,omitempty"`
+}
+
+// Status is the data rendered by the status page
+type Status struct {
+	GeneratedAt     time.Time            `json:"generatedAt"`
+	Environments    []EnvironmentSummary `json:"environments"`
+	Phases          map[string]int       `json:"phases"`
+	RecentFailures  []EnvironmentSummary `json:"recentFailures"`
+	BuildQueueDepth int                  `json:"buildQueueDepth"`
+}
+
+// NeedLeaderElection allows every replica to serve the page.
+func (s *Server) NeedLeaderElection() bool {
+	return false
+}
+
+// Start serves the status page until the context is cancelled.
+func (s *Server) Start(ctx context.Context) error {
+	log := logf.FromContext(ctx).WithName("dashboard")
+
+	handler := http.Handler(s.Handler())
+	if s.Filter != nil {
+		var err error
+		handler, err = s.Filter(log, handler)
+		if err != nil {
+			return err
+		}
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/dashboard", handler)
+	mux.Handle("/dashboard/", handler)
+
+	srv := &http.Server{
+		Addr:              s.BindAddress,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			log.Error(err, "error shutting down dashboard server")
+		}
+	}()
+
+	log.Info("Serving status page", "address", s.BindAddress, "path", "/dashboard")
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// Handler returns the unprotected status page handler.
+// Serves HTML by default and JSON for "?format=json".
+func (s *Server) Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		status, err := s.collect(req.Context())
+		if err != nil {
+			logf.Log.WithName("dashboard").Error(err, "failed to collect status")
+			http.Error(w, "failed to collect status", http.StatusInternalServerError)
+			return
+		}
+
+		if req.URL.Query().Get("format") == "json" {
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(status)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := statusPage.Execute(w, status); err != nil {
+			http.Error(w, "failed to render status", http.StatusInternalServerError)
+		}
+	}
+}
+
+// collect builds the page data from the cache
+func (s *Server) collect(ctx context.Context) (*Status, error) {
+	envList := &catalystv1alpha1.EnvironmentList{}
+	if err := s.Reader.List(ctx, envList); err != nil {
+		return nil, err
+	}
+
+	status := &Status{
+		GeneratedAt:  time.Now().UTC(),
+		Environments: make([]EnvironmentSummary, 0, len(envList.Items)),
+		Phases:       map[string]int{},
+	}
+
+	for _, env := range envList.Items {
+		phase := env.Status.Phase
+		if phase == "" {
+			phase = "Pending"
+		}
+		summary := EnvironmentSummary{
+			Name:      env.Name,
+			Namespace: env.Namespace,
+			Project:   env.Spec.ProjectRef.Name,
+			Type:      env.Spec.Type,
+			Phase:     phase,
+			URL:       env.Status.URL,
+		}
+		if env.Status.BuildDuration != nil {
+			summary.BuildDuration = env.Status.BuildDuration.Duration.Round(time.Second).String()
+		}
+		summary.Message = env.Status.Message
+		for _, cond := range env.Status.Conditions {
+			if summary.Message == "" && cond.Message != "" && string(cond.Status) != "True" {
+				summary.Message = cond.Message
+			}
+		}
+
+		status.Environments = append(status.Environments, summary)
+		status.Phases[phase]++
+		if phase == "Failed" {
+			status.RecentFailures = append(status.RecentFailures, summary)
+		}
+	}
+
+	sort.Slice(status.Environments, func(i, j int) bool {
+		a, b := status.Environments[i], status.Environments[j]
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+	if len(status.RecentFailures) > maxRecentFailures {
+		status.RecentFailures = status.RecentFailures[:maxRecentFailures]
+	}
+
+	// Build queue depth: build Jobs that have not finished yet
+	jobList := &batchv1.JobList{}
+	if err := s.Reader.List(ctx, jobList, client.MatchingLabels{"catalyst.dev/job-type": "build"}); err != nil {
+		return nil, err
+	}
+	for _, job := range jobList.Items {
+		if job.Status.Succeeded == 0 && job.Status.Failed == 0 {
+			status.BuildQueueDepth++
+		}
+	}
+
+	return status, nil
+}
+
+var statusPage = template.Must(template.New("status").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Catalyst Operator Status</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; width: 100%; }
+th, td { text-align: left; padding: 4px 8px; border-bottom: 1px solid #ddd; }
+.Ready { color: #1a7f37; } .Failed { color: #cf222e; } .Building, .Provisioning, .Pending { color: #9a6700; }
+</style>
+</head>
+<body>
+<h1>Catalyst Operator Status</h1>
+<p>Generated {{ .GeneratedAt.Format "2006-01-02 15:04:05 MST" }} &middot; Build queue depth: {{ .BuildQueueDepth }}</p>
+<p>{{ range $phase, $count := .Phases }}<span class="{{ $phase }}">{{ $phase }}: {{ $count }}</span> {{ end }}</p>
+{{ if .RecentFailures }}
+<h2>Recent failures</h2>
+<ul>
+{{ range .RecentFailures }}<li>{{ .Namespace }}/{{ .Name }}{{ if .Message }}: {{ .Message }}{{ end }}</li>
+{{ end }}
+</ul>
+{{ end }}
+<h2>Environments</h2>
+<table>
+<tr><th>Namespace</th><th>Name</th><th>Project</th><th>Type</th><th>Phase</th><th>URL</th><th>Build</th></tr>
+{{ range .Environments }}<tr><td>{{ .Namespace }}</td><td>{{ .Name }}</td><td>{{ .Project }}</td><td>{{ .Type }}</td><td class="{{ .Phase }}">{{ .Phase }}</td><td>{{ if .URL }}<a href="{{ .URL }}">{{ .URL }}</a>{{ end }}</td><td>{{ .BuildDuration }}</td></tr>
+{{ end }}
+</table>
+</body>
+</html>
+`))
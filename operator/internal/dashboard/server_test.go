@@ -0,0 +1,75 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+func newTestServer(t *testing.T) *Server {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, catalystv1alpha1.AddToScheme(scheme))
+
+	reader := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&catalystv1alpha1.Environment{
+			ObjectMeta: metav1.ObjectMeta{Name: "pr-1", Namespace: "team"},
+			Spec:       catalystv1alpha1.EnvironmentSpec{ProjectRef: catalystv1alpha1.ProjectReference{Name: "app"}, Type: "development"},
+			Status:     catalystv1alpha1.EnvironmentStatus{Phase: "Ready", URL: "https://pr-1.preview.example.com"},
+		},
+		&catalystv1alpha1.Environment{
+			ObjectMeta: metav1.ObjectMeta{Name: "pr-2", Namespace: "team"},
+			Spec:       catalystv1alpha1.EnvironmentSpec{ProjectRef: catalystv1alpha1.ProjectReference{Name: "app"}, Type: "development"},
+			Status:     catalystv1alpha1.EnvironmentStatus{Phase: "Failed"},
+		},
+		&batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{Name: "build-web-abc", Namespace: "team-app-pr-1", Labels: map[string]string{"catalyst.dev/job-type": "build"}},
+		},
+		&batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{Name: "build-web-def", Namespace: "team-app-pr-2", Labels: map[string]string{"catalyst.dev/job-type": "build"}},
+			Status:     batchv1.JobStatus{Succeeded: 1},
+		},
+	).Build()
+
+	return &Server{Reader: reader}
+}
+
+func TestHandler_JSON(t *testing.T) {
+	server := newTestServer(t)
+
+	rec := httptest.NewRecorder()
+	server.Handler()(rec, httptest.NewRequest(http.MethodGet, "/dashboard?format=json", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var status Status
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &status))
+	assert.Len(t, status.Environments, 2)
+	assert.Equal(t, 1, status.Phases["Ready"])
+	assert.Equal(t, 1, status.Phases["Failed"])
+	require.Len(t, status.RecentFailures, 1)
+	assert.Equal(t, "pr-2", status.RecentFailures[0].Name)
+	assert.Equal(t, 1, status.BuildQueueDepth)
+}
+
+func TestHandler_HTML(t *testing.T) {
+	server := newTestServer(t)
+
+	rec := httptest.NewRecorder()
+	server.Handler()(rec, httptest.NewRequest(http.MethodGet, "/dashboard", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	assert.Contains(t, rec.Header().Get("Content-Type"), "text/html")
+	assert.Contains(t, rec.Body.String(), "https://pr-1.preview.example.com")
+	assert.Contains(t, rec.Body.String(), "Build queue depth: 1")
+}
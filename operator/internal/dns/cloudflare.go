@@ -0,0 +1,165 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var _ Provider = &CloudflareProvider{}
+
+// CloudflareProvider manages records of one Cloudflare zone with an API token allowed to
+// edit its DNS records
+type CloudflareProvider struct {
+	APIURL     string
+	ZoneID     string
+	Token      string
+	HTTPClient *http.Client
+}
+
+// NewCloudflareProvider creates a provider for the zone on api.cloudflare.com
+func NewCloudflareProvider(zoneID, token string) *CloudflareProvider {
+	return &CloudflareProvider{
+		APIURL:     "https://api.cloudflare.com/client/v4",
+		ZoneID:     zoneID,
+		Token:      token,
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// cloudflareRecord is a DNS record of the Cloudflare API
+type cloudflareRecord struct {
+	ID      string `json:"id,omitempty"`
+	Type    string `json:"type"`
+	Name    string `json:"name"`
+	Content string `json:"content"`
+	TTL     int    `json:"ttl"`
+	Proxied bool   `json:"proxied"`
+}
+
+// Ensure creates the record, or replaces the records of the host that differ from it
+func (cf *CloudflareProvider) Ensure(ctx context.Context, record Record) error {
+	existing, err := cf.list(ctx, record.Host)
+	if err != nil {
+		return err
+	}
+	desired := cloudflareRecord{
+		Type:    record.Type(),
+		Name:    canonical(record.Host),
+		Content: record.Target,
+		TTL:     record.TTL,
+	}
+	if desired.TTL == 0 {
+		// Automatic
+		desired.TTL = 1
+	}
+	for _, rec := range existing {
+		if rec.Type == desired.Type && rec.Content == desired.Content && rec.TTL == desired.TTL {
+			return nil
+		}
+	}
+	for _, rec := range existing {
+		// A host has a CNAME or address records, not both: replace what is there
+		if err := cf.call(ctx, http.MethodDelete, "/dns_records/"+rec.ID, nil, nil); err != nil {
+			return fmt.Errorf("failed to delete DNS record %s of %s: %w", rec.ID, record.Host, err)
+		}
+	}
+	if err := cf.call(ctx, http.MethodPost, "/dns_records", desired, nil); err != nil {
+		return fmt.Errorf("failed to create DNS record of %s: %w", record.Host, err)
+	}
+	return nil
+}
+
+// Delete removes the records of a host
+func (cf *CloudflareProvider) Delete(ctx context.Context, host string) error {
+	existing, err := cf.list(ctx, host)
+	if err != nil {
+		return err
+	}
+	for _, rec := range existing {
+		if err := cf.call(ctx, http.MethodDelete, "/dns_records/"+rec.ID, nil, nil); err != nil {
+			return fmt.Errorf("failed to delete DNS record %s of %s: %w", rec.ID, host, err)
+		}
+	}
+	return nil
+}
+
+// list returns the A, AAAA and CNAME records of a host
+func (cf *CloudflareProvider) list(ctx context.Context, host string) ([]cloudflareRecord, error) {
+	var records []cloudflareRecord
+	if err := cf.call(ctx, http.MethodGet, "/dns_records?name="+url.QueryEscape(canonical(host)), nil, &records); err != nil {
+		return nil, fmt.Errorf("failed to list DNS records of %s: %w", host, err)
+	}
+	result := records[:0]
+	for _, rec := range records {
+		if rec.Type == "A" || rec.Type == "AAAA" || rec.Type == "CNAME" {
+			result = append(result, rec)
+		}
+	}
+	return result, nil
+}
+
+// call sends a request to the zone's API and decodes the result into out
+func (cf *CloudflareProvider) call(ctx context.Context, method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		payload, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(payload)
+	}
+	endpoint := fmt.Sprintf("%s/zones/%s%s", strings.TrimSuffix(cf.APIURL, "/"), cf.ZoneID, path)
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+cf.Token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := cf.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("cloudflare: unexpected status code: %d, body: %s", resp.StatusCode, string(msg))
+	}
+	if out == nil {
+		return nil
+	}
+	envelope := struct {
+		Result json.RawMessage `json:"result"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		return fmt.Errorf("failed to parse cloudflare response: %w", err)
+	}
+	if err := json.Unmarshal(envelope.Result, out); err != nil {
+		return fmt.Errorf("failed to parse cloudflare response: %w", err)
+	}
+	return nil
+}
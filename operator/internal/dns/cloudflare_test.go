@@ -0,0 +1,104 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordType(t *testing.T) {
+	assert.Equal(t, "A", Record{Target: "203.0.113.10"}.Type())
+	assert.Equal(t, "AAAA", Record{Target: "2001:db8::1"}.Type())
+	assert.Equal(t, "CNAME", Record{Target: "lb.example.com"}.Type())
+}
+
+// fakeCloudflare serves the DNS records API of zone z1 from an in-memory record list
+func fakeCloudflare(t *testing.T, records map[string]cloudflareRecord) *CloudflareProvider {
+	nextID := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer cf-token", r.Header.Get("Authorization"))
+		path := strings.TrimPrefix(r.URL.Path, "/zones/z1/dns_records")
+		switch {
+		case r.Method == http.MethodGet && path == "":
+			result := []cloudflareRecord{}
+			for _, rec := range records {
+				if rec.Name == r.URL.Query().Get("name") {
+					result = append(result, rec)
+				}
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"success": true, "result": result})
+		case r.Method == http.MethodPost && path == "":
+			var rec cloudflareRecord
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&rec))
+			nextID++
+			rec.ID = fmt.Sprintf("new%d", nextID)
+			records[rec.ID] = rec
+			_ = json.NewEncoder(w).Encode(map[string]any{"success": true, "result": rec})
+		case r.Method == http.MethodDelete:
+			delete(records, strings.TrimPrefix(path, "/"))
+			_, _ = w.Write([]byte(`{"success":true,"result":{}}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	provider := NewCloudflareProvider("z1", "cf-token")
+	provider.APIURL = server.URL
+	return provider
+}
+
+func TestCloudflareProvider(t *testing.T) {
+	records := map[string]cloudflareRecord{
+		"old": {ID: "old", Type: "CNAME", Name: "pr-1.preview.example.com", Content: "old-lb.example.com", TTL: 1},
+		"txt": {ID: "txt", Type: "TXT", Name: "pr-1.preview.example.com", Content: "verification", TTL: 1},
+	}
+	provider := fakeCloudflare(t, records)
+	ctx := context.Background()
+
+	require.NoError(t, provider.Ensure(ctx, Record{Host: "PR-1.preview.example.com.", Target: "203.0.113.10"}))
+	assert.NotContains(t, records, "old", "the stale CNAME is replaced")
+	assert.Contains(t, records, "txt", "other record types are kept")
+	require.Len(t, records, 2)
+	created := records["new1"]
+	assert.Equal(t, cloudflareRecord{ID: "new1", Type: "A", Name: "pr-1.preview.example.com", Content: "203.0.113.10", TTL: 1}, created)
+
+	// Up-to-date records are left alone
+	require.NoError(t, provider.Ensure(ctx, Record{Host: "pr-1.preview.example.com", Target: "203.0.113.10"}))
+	assert.Contains(t, records, "new1")
+
+	require.NoError(t, provider.Delete(ctx, "pr-1.preview.example.com"))
+	assert.Equal(t, []string{"txt"}, keys(records))
+	require.NoError(t, provider.Delete(ctx, "pr-1.preview.example.com"))
+}
+
+func keys(m map[string]cloudflareRecord) []string {
+	var result []string
+	for k := range m {
+		result = append(result, k)
+	}
+	return result
+}
@@ -0,0 +1,60 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package dns manages the DNS records of preview hosts through a DNS provider API, for
+// clusters where external-dns does not publish them.
+package dns
+
+import (
+	"context"
+	"net"
+	"strings"
+)
+
+// Record points a host at the address of the ingress controller
+type Record struct {
+	Host string
+	// Target is an IP address (A or AAAA record) or a hostname (CNAME record)
+	Target string
+	// TTL in seconds; 0 uses the provider default
+	TTL int
+}
+
+// Type returns the DNS record type of the record's target
+func (r Record) Type() string {
+	ip := net.ParseIP(r.Target)
+	switch {
+	case ip == nil:
+		return "CNAME"
+	case ip.To4() == nil:
+		return "AAAA"
+	default:
+		return "A"
+	}
+}
+
+// Provider creates and deletes DNS records
+type Provider interface {
+	// Ensure creates the record, or updates the record of the host to match
+	Ensure(ctx context.Context, record Record) error
+	// Delete removes the records of a host; a missing record is not an error
+	Delete(ctx context.Context, host string) error
+}
+
+// canonical lowercases a host and strips the trailing dot
+func canonical(host string) string {
+	return strings.ToLower(strings.TrimSuffix(host, "."))
+}
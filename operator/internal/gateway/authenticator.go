@@ -0,0 +1,91 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gateway
+
+import (
+	"context"
+	"fmt"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// +kubebuilder:rbac:groups=authentication.k8s.io,resources=tokenreviews,verbs=create
+// +kubebuilder:rbac:groups=authorization.k8s.io,resources=subjectaccessreviews,verbs=create
+
+// Authenticator verifies the identities of gateway clients
+type Authenticator interface {
+	// Authenticate returns the user of a bearer token, or nil when the token is invalid
+	Authenticate(ctx context.Context, token string) (*authenticationv1.UserInfo, error)
+	// CanImpersonate reports whether user may act as target
+	CanImpersonate(ctx context.Context, user authenticationv1.UserInfo, target string) (bool, error)
+}
+
+// kubeAuthenticator checks identities with TokenReviews and SubjectAccessReviews
+type kubeAuthenticator struct {
+	clientset kubernetes.Interface
+}
+
+// NewKubeAuthenticator returns an Authenticator backed by the API server's authenticators and
+// RBAC, so clients present the same tokens they use with kubectl.
+func NewKubeAuthenticator(config *rest.Config) (Authenticator, error) {
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	return &kubeAuthenticator{clientset: clientset}, nil
+}
+
+func (a *kubeAuthenticator) Authenticate(ctx context.Context, token string) (*authenticationv1.UserInfo, error) {
+	review, err := a.clientset.AuthenticationV1().TokenReviews().Create(ctx, &authenticationv1.TokenReview{
+		Spec: authenticationv1.TokenReviewSpec{Token: token},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to review identity token: %w", err)
+	}
+	if !review.Status.Authenticated {
+		return nil, nil
+	}
+	return &review.Status.User, nil
+}
+
+func (a *kubeAuthenticator) CanImpersonate(ctx context.Context, user authenticationv1.UserInfo, target string) (bool, error) {
+	extra := make(map[string]authorizationv1.ExtraValue, len(user.Extra))
+	for k, v := range user.Extra {
+		extra[k] = authorizationv1.ExtraValue(v)
+	}
+	review, err := a.clientset.AuthorizationV1().SubjectAccessReviews().Create(ctx, &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			User:   user.Username,
+			UID:    user.UID,
+			Groups: user.Groups,
+			Extra:  extra,
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Verb:     "impersonate",
+				Resource: "users",
+				Name:     target,
+			},
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return false, fmt.Errorf("failed to review impersonation: %w", err)
+	}
+	return review.Status.Allowed, nil
+}
@@ -0,0 +1,473 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package gateway exposes workspace pods over a WebSocket exec endpoint authenticated with
+// per-environment tokens, so the web UI and CLI can attach terminals without holding
+// pods/exec RBAC in every environment namespace. The operator performs the exec on their behalf.
+package gateway
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+	"github.com/ncrmro/catalyst/operator/internal/audit"
+	"github.com/ncrmro/catalyst/operator/internal/controller"
+)
+
+// +kubebuilder:rbac:groups="",resources=pods/exec,verbs=create
+
+const (
+	// identityHeader carries the Kubernetes bearer token of the user a session is audited as
+	identityHeader = "X-Catalyst-Identity"
+	// impersonateHeader names the user a trusted caller acts for
+	impersonateHeader = "Impersonate-User"
+)
+
+// defaultShell is run when the client doesn't request a command
+var defaultShell = []string{"/bin/sh", "-c", "command -v bash >/dev/null && exec bash || exec sh"}
+
+// Executor starts an exec session in a pod container
+type Executor interface {
+	Exec(ctx context.Context, namespace, pod, container string, command []string, streams remotecommand.StreamOptions) error
+}
+
+// Server serves /exec/{namespace}/{environment}. It implements manager.Runnable.
+//
+// Clients authenticate with the environment's gateway token (see controller.GatewayTokenSecretName),
+// sent as "Authorization: Bearer <token>" or, for browsers, the "token" query parameter.
+// Optional query parameters: "container" and repeated "command".
+//
+// The audited actor is the user of a Kubernetes bearer token sent in the "X-Catalyst-Identity"
+// header, verified with a TokenReview. Callers acting for their own users (e.g. the web UI) name
+// the user in the "Impersonate-User" header, which is honored only when the caller may
+// impersonate that user. Sessions without an identity are audited without an actor.
+//
+// Protocol: binary frames carry stdin (client to server) and terminal output (server to client);
+// text frames from the client are control messages, e.g. {"type":"resize","cols":120,"rows":40}.
+// The server closes the connection when the exec session ends, with the error as close reason.
+type Server struct {
+	// Reader reads Environments, token Secrets and Pods. Use an uncached reader
+	// (mgr.GetAPIReader()) to avoid caching every Secret in the cluster.
+	Reader client.Reader
+	// Executor runs the exec session; see NewSPDYExecutor
+	Executor Executor
+	// BindAddress is the address the endpoint is served on (e.g. ":8083")
+	BindAddress string
+	// AllowedOrigins lists browser origins allowed to connect (e.g. "https://catalyst.example.com").
+	// When empty, only same-origin requests and non-browser clients are accepted.
+	AllowedOrigins []string
+	// Audit records the start and end of every session. Sessions are refused when the start
+	// cannot be recorded. Nil disables auditing.
+	Audit audit.Sink
+	// Authenticator verifies the identity tokens of the audited actors; see NewKubeAuthenticator.
+	// Nil refuses sessions that present an identity.
+	Authenticator Authenticator
+	// TrustedProxies lists the networks of the reverse proxies whose X-Forwarded-For header is
+	// believed. The header of any other peer is ignored.
+	TrustedProxies []*net.IPNet
+}
+
+// NeedLeaderElection allows every replica to serve sessions.
+func (s *Server) NeedLeaderElection() bool {
+	return false
+}
+
+// Start serves the gateway until the context is cancelled.
+func (s *Server) Start(ctx context.Context) error {
+	log := logf.FromContext(ctx).WithName("gateway")
+
+	mux := http.NewServeMux()
+	mux.Handle("/exec/", s.Handler())
+
+	srv := &http.Server{
+		Addr:              s.BindAddress,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			log.Error(err, "error shutting down gateway server")
+		}
+	}()
+
+	log.Info("Serving workspace gateway", "address", s.BindAddress, "path", "/exec/")
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// target is the workspace container a session attaches to
+type target struct {
+	Namespace string
+	Pod       string
+	Container string
+}
+
+// Handler returns the exec endpoint handler.
+func (s *Server) Handler() http.HandlerFunc {
+	upgrader := websocket.Upgrader{
+		ReadBufferSize:  4096,
+		WriteBufferSize: 4096,
+		CheckOrigin:     s.checkOrigin,
+	}
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		log := logf.Log.WithName("gateway")
+
+		namespace, name, ok := parseExecPath(req.URL.Path)
+		if !ok {
+			http.Error(w, "expected /exec/{namespace}/{environment}", http.StatusNotFound)
+			return
+		}
+
+		t, status, err := s.authorize(req.Context(), namespace, name, requestToken(req))
+		if err != nil {
+			if status == http.StatusInternalServerError {
+				log.Error(err, "failed to resolve workspace", "namespace", namespace, "environment", name)
+			}
+			http.Error(w, err.Error(), status)
+			return
+		}
+		if c := req.URL.Query().Get("container"); c != "" {
+			t.Container = c
+		}
+		command := req.URL.Query()["command"]
+		if len(command) == 0 {
+			command = defaultShell
+		}
+		actor, status, err := s.authenticate(req.Context(), req)
+		if err != nil {
+			if status == http.StatusInternalServerError {
+				log.Error(err, "failed to verify identity", "environment", namespace+"/"+name)
+			}
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		event := s.auditEvent(req, namespace, name, t, command, actor)
+		if err := s.record(req.Context(), event); err != nil {
+			log.Error(err, "failed to record exec session", "environment", namespace+"/"+name)
+			http.Error(w, "audit log unavailable", http.StatusServiceUnavailable)
+			return
+		}
+
+		conn, err := upgrader.Upgrade(w, req, nil)
+		if err != nil {
+			// Upgrade already wrote the HTTP error
+			s.recordEnd(event, err)
+			return
+		}
+		defer func() { _ = conn.Close() }()
+
+		log.Info("Attaching terminal", "environment", namespace+"/"+name, "pod", t.Pod, "container", t.Container, "actor", event.Actor)
+		session := newSession(conn)
+		err = s.Executor.Exec(req.Context(), t.Namespace, t.Pod, t.Container, command, remotecommand.StreamOptions{
+			Stdin:             session,
+			Stdout:            session,
+			Tty:               true,
+			TerminalSizeQueue: session,
+		})
+		session.close(err)
+		s.recordEnd(event, err)
+	}
+}
+
+// auditEvent describes a session about to start
+func (s *Server) auditEvent(req *http.Request, namespace, name string, t *target, command []string, actor string) catalystv1alpha1.AuditEventSpec {
+	id := make([]byte, 8)
+	_, _ = rand.Read(id)
+	return catalystv1alpha1.AuditEventSpec{
+		Action:      catalystv1alpha1.AuditActionExecStarted,
+		Time:        metav1.Now(),
+		SessionID:   hex.EncodeToString(id),
+		Namespace:   namespace,
+		Environment: name,
+		Pod:         t.Namespace + "/" + t.Pod,
+		Container:   t.Container,
+		Command:     command,
+		Actor:       actor,
+		RemoteAddr:  s.remoteAddr(req),
+		UserAgent:   req.UserAgent(),
+	}
+}
+
+func (s *Server) record(ctx context.Context, event catalystv1alpha1.AuditEventSpec) error {
+	if s.Audit == nil {
+		return nil
+	}
+	return s.Audit.Record(ctx, event)
+}
+
+// recordEnd records the end of a session started with start. The request context is already
+// cancelled when the client hung up, so the end is recorded on a fresh one.
+func (s *Server) recordEnd(start catalystv1alpha1.AuditEventSpec, execErr error) {
+	end := start
+	end.Action = catalystv1alpha1.AuditActionExecEnded
+	end.Time = metav1.Now()
+	end.Duration = &metav1.Duration{Duration: end.Time.Sub(start.Time.Time).Round(time.Millisecond)}
+	if execErr != nil {
+		end.Error = execErr.Error()
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := s.record(ctx, end); err != nil {
+		logf.Log.WithName("gateway").Error(err, "failed to record end of exec session", "environment", start.Namespace+"/"+start.Environment, "session", start.SessionID)
+	}
+}
+
+// remoteAddr is the client address. When the peer is a trusted proxy, X-Forwarded-For is walked
+// from the nearest hop back to the first address that isn't a trusted proxy.
+func (s *Server) remoteAddr(req *http.Request) string {
+	peer := req.RemoteAddr
+	if host, _, err := net.SplitHostPort(req.RemoteAddr); err == nil {
+		peer = host
+	}
+	if !s.trustedProxy(peer) {
+		return peer
+	}
+	hops := strings.Split(strings.Join(req.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if hop == "" {
+			continue
+		}
+		if !s.trustedProxy(hop) {
+			return hop
+		}
+		peer = hop
+	}
+	return peer
+}
+
+// trustedProxy reports whether addr is in one of the TrustedProxies networks
+func (s *Server) trustedProxy(addr string) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, network := range s.TrustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseTrustedProxies parses a comma-separated list of addresses and CIDRs
+func ParseTrustedProxies(list string) ([]*net.IPNet, error) {
+	var networks []*net.IPNet
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid proxy address %q", entry)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy network %q: %w", entry, err)
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
+// authenticate resolves the audited actor from the identity headers. Returns the HTTP status to
+// report on error.
+func (s *Server) authenticate(ctx context.Context, req *http.Request) (string, int, error) {
+	token := strings.TrimSpace(req.Header.Get(identityHeader))
+	impersonate := req.Header.Get(impersonateHeader)
+	if token == "" {
+		if impersonate != "" {
+			return "", http.StatusUnauthorized, fmt.Errorf("%s requires an %s token", impersonateHeader, identityHeader)
+		}
+		return "", http.StatusOK, nil
+	}
+	if s.Authenticator == nil {
+		return "", http.StatusUnauthorized, errors.New("identity tokens are not accepted")
+	}
+
+	user, err := s.Authenticator.Authenticate(ctx, token)
+	if err != nil {
+		return "", http.StatusInternalServerError, err
+	}
+	if user == nil {
+		return "", http.StatusUnauthorized, errors.New("invalid identity token")
+	}
+	if impersonate == "" {
+		return user.Username, http.StatusOK, nil
+	}
+	allowed, err := s.Authenticator.CanImpersonate(ctx, *user, impersonate)
+	if err != nil {
+		return "", http.StatusInternalServerError, err
+	}
+	if !allowed {
+		return "", http.StatusForbidden, fmt.Errorf("%s may not impersonate %s", user.Username, impersonate)
+	}
+	return impersonate, http.StatusOK, nil
+}
+
+// authorize validates the token for an Environment and resolves its running workspace pod.
+// Returns the HTTP status to report on error.
+func (s *Server) authorize(ctx context.Context, namespace, name, token string) (*target, int, error) {
+	if token == "" {
+		return nil, http.StatusUnauthorized, errors.New("missing token")
+	}
+
+	env := &catalystv1alpha1.Environment{}
+	if err := s.Reader.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, env); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, http.StatusNotFound, errors.New("environment not found")
+		}
+		return nil, http.StatusInternalServerError, err
+	}
+
+	secret := &corev1.Secret{}
+	if err := s.Reader.Get(ctx, client.ObjectKey{Namespace: namespace, Name: controller.GatewayTokenSecretName(name)}, secret); err != nil {
+		if apierrors.IsNotFound(err) {
+			// Not provisioned yet (or not a workspace environment): indistinguishable from a bad token
+			return nil, http.StatusUnauthorized, errors.New("invalid token")
+		}
+		return nil, http.StatusInternalServerError, err
+	}
+	expected := secret.Data[controller.GatewayTokenKey]
+	if len(expected) == 0 || subtle.ConstantTimeCompare(expected, []byte(token)) != 1 {
+		return nil, http.StatusUnauthorized, errors.New("invalid token")
+	}
+
+	hierarchy := controller.ExtractNamespaceHierarchy(env.Labels)
+	if hierarchy == nil {
+		return nil, http.StatusConflict, errors.New("environment is missing hierarchy labels")
+	}
+	targetNamespace := controller.GenerateEnvironmentNamespace(hierarchy.Team, hierarchy.Project, hierarchy.Environment)
+
+	pods := &corev1.PodList{}
+	if err := s.Reader.List(ctx, pods, client.InNamespace(targetNamespace), client.MatchingLabels{
+		"catalyst.dev/pod-type":    "workspace",
+		"catalyst.dev/environment": env.Name,
+	}); err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	for _, pod := range pods.Items {
+		if pod.Status.Phase == corev1.PodRunning && pod.DeletionTimestamp.IsZero() {
+			return &target{Namespace: targetNamespace, Pod: pod.Name, Container: "workspace"}, http.StatusOK, nil
+		}
+	}
+	return nil, http.StatusConflict, errors.New("workspace pod is not running")
+}
+
+func (s *Server) checkOrigin(req *http.Request) bool {
+	origin := req.Header.Get("Origin")
+	if origin == "" {
+		// Non-browser clients (CLI)
+		return true
+	}
+	for _, allowed := range s.AllowedOrigins {
+		if strings.EqualFold(origin, allowed) {
+			return true
+		}
+	}
+	return strings.EqualFold(origin, "http://"+req.Host) || strings.EqualFold(origin, "https://"+req.Host)
+}
+
+// parseExecPath splits /exec/{namespace}/{environment}
+func parseExecPath(path string) (string, string, bool) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(path, "/exec/"), "/"), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+// requestToken reads the bearer token from the Authorization header or the "token" query parameter
+func requestToken(req *http.Request) string {
+	if auth := req.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		return strings.TrimSpace(strings.TrimPrefix(auth, "Bearer "))
+	}
+	return req.URL.Query().Get("token")
+}
+
+// spdyExecutor execs into pods through the API server with the operator's credentials
+type spdyExecutor struct {
+	config    *rest.Config
+	clientset kubernetes.Interface
+}
+
+// NewSPDYExecutor returns an Executor using the operator's rest config.
+func NewSPDYExecutor(config *rest.Config) (Executor, error) {
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	return &spdyExecutor{config: config, clientset: clientset}, nil
+}
+
+func (e *spdyExecutor) Exec(ctx context.Context, namespace, pod, container string, command []string, streams remotecommand.StreamOptions) error {
+	req := e.clientset.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Name(pod).
+		Namespace(namespace).
+		SubResource("exec").
+		VersionedParams(&corev1.PodExecOptions{
+			Container: container,
+			Command:   command,
+			Stdin:     streams.Stdin != nil,
+			Stdout:    streams.Stdout != nil,
+			Stderr:    streams.Stderr != nil,
+			TTY:       streams.Tty,
+		}, scheme.ParameterCodec)
+
+	exec, err := remotecommand.NewSPDYExecutor(e.config, http.MethodPost, req.URL())
+	if err != nil {
+		return fmt.Errorf("failed to create executor: %w", err)
+	}
+	return exec.StreamWithContext(ctx, streams)
+}
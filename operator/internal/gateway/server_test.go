@@ -0,0 +1,288 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/remotecommand"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+	"github.com/ncrmro/catalyst/operator/internal/controller"
+)
+
+// echoExecutor records the target and echoes stdin back until EOF
+type echoExecutor struct {
+	namespace, pod, container string
+	command                   []string
+}
+
+func (e *echoExecutor) Exec(_ context.Context, namespace, pod, container string, command []string, streams remotecommand.StreamOptions) error {
+	e.namespace, e.pod, e.container, e.command = namespace, pod, container, command
+	_, err := io.Copy(streams.Stdout, streams.Stdin)
+	return err
+}
+
+// recordingSink collects audit events
+type recordingSink struct {
+	mu     sync.Mutex
+	events []catalystv1alpha1.AuditEventSpec
+}
+
+func (s *recordingSink) Record(_ context.Context, event catalystv1alpha1.AuditEventSpec) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, event)
+	return nil
+}
+
+func (s *recordingSink) recorded() []catalystv1alpha1.AuditEventSpec {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]catalystv1alpha1.AuditEventSpec{}, s.events...)
+}
+
+// staticAuthenticator maps tokens to users and lets the web UI impersonate anyone
+type staticAuthenticator map[string]string
+
+func (a staticAuthenticator) Authenticate(_ context.Context, token string) (*authenticationv1.UserInfo, error) {
+	user, ok := a[token]
+	if !ok {
+		return nil, nil
+	}
+	return &authenticationv1.UserInfo{Username: user}, nil
+}
+
+func (a staticAuthenticator) CanImpersonate(_ context.Context, user authenticationv1.UserInfo, _ string) (bool, error) {
+	return user.Username == "system:serviceaccount:catalyst:web", nil
+}
+
+func newTestServer(t *testing.T, podPhase corev1.PodPhase) (*Server, *echoExecutor) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, catalystv1alpha1.AddToScheme(scheme))
+
+	targetNamespace := controller.GenerateEnvironmentNamespace("team", "app", "pr-1")
+	reader := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&catalystv1alpha1.Environment{
+			ObjectMeta: metav1.ObjectMeta{Name: "pr-1", Namespace: "team", Labels: map[string]string{
+				"catalyst.dev/team":        "team",
+				"catalyst.dev/project":     "app",
+				"catalyst.dev/environment": "pr-1",
+			}},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: controller.GatewayTokenSecretName("pr-1"), Namespace: "team"},
+			Data:       map[string][]byte{controller.GatewayTokenKey: []byte("s3cret")},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "workspace-app-latest", Namespace: targetNamespace, Labels: map[string]string{
+				"catalyst.dev/pod-type":    "workspace",
+				"catalyst.dev/environment": "pr-1",
+			}},
+			Status: corev1.PodStatus{Phase: podPhase},
+		},
+	).Build()
+
+	executor := &echoExecutor{}
+	return &Server{Reader: reader, Executor: executor, Authenticator: staticAuthenticator{
+		"alice-token": "alice@example.com",
+		"web-token":   "system:serviceaccount:catalyst:web",
+	}}, executor
+}
+
+func TestAuthorize(t *testing.T) {
+	server, _ := newTestServer(t, corev1.PodRunning)
+	ctx := context.Background()
+
+	target, status, err := server.authorize(ctx, "team", "pr-1", "s3cret")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "workspace-app-latest", target.Pod)
+	assert.Equal(t, controller.GenerateEnvironmentNamespace("team", "app", "pr-1"), target.Namespace)
+
+	_, status, _ = server.authorize(ctx, "team", "pr-1", "wrong")
+	assert.Equal(t, http.StatusUnauthorized, status)
+	_, status, _ = server.authorize(ctx, "team", "pr-1", "")
+	assert.Equal(t, http.StatusUnauthorized, status)
+	_, status, _ = server.authorize(ctx, "team", "missing", "s3cret")
+	assert.Equal(t, http.StatusNotFound, status)
+
+	pending, _ := newTestServer(t, corev1.PodPending)
+	_, status, _ = pending.authorize(ctx, "team", "pr-1", "s3cret")
+	assert.Equal(t, http.StatusConflict, status)
+}
+
+func TestParseExecPath(t *testing.T) {
+	ns, name, ok := parseExecPath("/exec/team/pr-1")
+	assert.True(t, ok)
+	assert.Equal(t, "team", ns)
+	assert.Equal(t, "pr-1", name)
+
+	_, _, ok = parseExecPath("/exec/team")
+	assert.False(t, ok)
+	_, _, ok = parseExecPath("/exec/team/pr-1/extra")
+	assert.False(t, ok)
+}
+
+func TestHandler_Session(t *testing.T) {
+	server, executor := newTestServer(t, corev1.PodRunning)
+	sink := &recordingSink{}
+	server.Audit = sink
+	ts := httptest.NewServer(server.Handler())
+	defer ts.Close()
+	wsURL := "ws" + strings.TrimPrefix(ts.URL, "http") + "/exec/team/pr-1?command=sh"
+
+	// Rejected before the upgrade
+	_, resp, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	require.Error(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	assert.Empty(t, sink.recorded(), "rejected requests attach to nothing")
+
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL, http.Header{
+		"Authorization":       []string{"Bearer s3cret"},
+		"X-Catalyst-Identity": []string{"alice-token"},
+	})
+	require.NoError(t, err)
+	defer func() { _ = conn.Close() }()
+
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"resize","cols":120,"rows":40}`)))
+	require.NoError(t, conn.WriteMessage(websocket.BinaryMessage, []byte("echo hi\n")))
+	msgType, data, err := conn.ReadMessage()
+	require.NoError(t, err)
+	assert.Equal(t, websocket.BinaryMessage, msgType)
+	assert.Equal(t, "echo hi\n", string(data))
+
+	assert.Equal(t, "workspace", executor.container)
+	assert.Equal(t, []string{"sh"}, executor.command)
+
+	// The session is audited when it starts and when it ends
+	events := sink.recorded()
+	require.Len(t, events, 1)
+	started := events[0]
+	assert.Equal(t, catalystv1alpha1.AuditActionExecStarted, started.Action)
+	assert.Equal(t, "alice@example.com", started.Actor)
+	assert.Equal(t, "team", started.Namespace)
+	assert.Equal(t, "pr-1", started.Environment)
+	assert.Equal(t, controller.GenerateEnvironmentNamespace("team", "app", "pr-1")+"/workspace-app-latest", started.Pod)
+	assert.Equal(t, "127.0.0.1", started.RemoteAddr)
+	assert.NotEmpty(t, started.SessionID)
+
+	require.NoError(t, conn.Close())
+	require.Eventually(t, func() bool { return len(sink.recorded()) == 2 }, 5*time.Second, 10*time.Millisecond)
+	ended := sink.recorded()[1]
+	assert.Equal(t, catalystv1alpha1.AuditActionExecEnded, ended.Action)
+	assert.Equal(t, started.SessionID, ended.SessionID)
+	require.NotNil(t, ended.Duration)
+}
+
+func TestAuthenticate(t *testing.T) {
+	server, _ := newTestServer(t, corev1.PodRunning)
+	ctx := context.Background()
+	request := func(headers map[string]string) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "http://gateway:8083/exec/team/pr-1?user=mallory", nil)
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		return req
+	}
+
+	actor, status, err := server.authenticate(ctx, request(nil))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Empty(t, actor, "self-declared users are not audited")
+
+	actor, _, err = server.authenticate(ctx, request(map[string]string{"X-Catalyst-Identity": "alice-token"}))
+	require.NoError(t, err)
+	assert.Equal(t, "alice@example.com", actor)
+
+	_, status, _ = server.authenticate(ctx, request(map[string]string{"X-Catalyst-Identity": "forged"}))
+	assert.Equal(t, http.StatusUnauthorized, status)
+
+	actor, _, err = server.authenticate(ctx, request(map[string]string{
+		"X-Catalyst-Identity": "web-token",
+		"Impersonate-User":    "bob@example.com",
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, "bob@example.com", actor, "the web UI acts for its users")
+
+	_, status, _ = server.authenticate(ctx, request(map[string]string{
+		"X-Catalyst-Identity": "alice-token",
+		"Impersonate-User":    "bob@example.com",
+	}))
+	assert.Equal(t, http.StatusForbidden, status)
+
+	_, status, _ = server.authenticate(ctx, request(map[string]string{"Impersonate-User": "bob@example.com"}))
+	assert.Equal(t, http.StatusUnauthorized, status)
+
+	server.Authenticator = nil
+	_, status, _ = server.authenticate(ctx, request(map[string]string{"X-Catalyst-Identity": "alice-token"}))
+	assert.Equal(t, http.StatusUnauthorized, status)
+}
+
+func TestRemoteAddr(t *testing.T) {
+	proxies, err := ParseTrustedProxies("10.0.0.0/8, 192.168.1.1")
+	require.NoError(t, err)
+	server := &Server{TrustedProxies: proxies}
+	req := httptest.NewRequest(http.MethodGet, "http://gateway:8083/exec/team/pr-1", nil)
+	req.Header.Set("X-Forwarded-For", "1.2.3.4, 203.0.113.7, 10.1.2.3")
+
+	req.RemoteAddr = "198.51.100.1:4242"
+	assert.Equal(t, "198.51.100.1", server.remoteAddr(req), "untrusted peers cannot forward addresses")
+
+	req.RemoteAddr = "192.168.1.1:4242"
+	assert.Equal(t, "203.0.113.7", server.remoteAddr(req), "the nearest untrusted hop is the client")
+
+	req.Header.Set("X-Forwarded-For", "10.9.9.9")
+	assert.Equal(t, "10.9.9.9", server.remoteAddr(req))
+
+	_, err = ParseTrustedProxies("10.0.0.0/33")
+	assert.Error(t, err)
+	_, err = ParseTrustedProxies("proxy.local")
+	assert.Error(t, err)
+}
+
+// failingSink rejects every event
+type failingSink struct{}
+
+func (failingSink) Record(context.Context, catalystv1alpha1.AuditEventSpec) error {
+	return errors.New("sink unavailable")
+}
+
+func TestHandler_AuditUnavailable(t *testing.T) {
+	server, _ := newTestServer(t, corev1.PodRunning)
+	server.Audit = failingSink{}
+	ts := httptest.NewServer(server.Handler())
+	defer ts.Close()
+
+	_, resp, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(ts.URL, "http")+"/exec/team/pr-1?token=s3cret", nil)
+	require.Error(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode, "unaudited sessions are refused")
+}
+
+func TestCheckOrigin(t *testing.T) {
+	server := &Server{AllowedOrigins: []string{"https://catalyst.example.com"}}
+	req := httptest.NewRequest(http.MethodGet, "http://gateway:8083/exec/team/pr-1", nil)
+
+	assert.True(t, server.checkOrigin(req), "non-browser clients send no Origin")
+	req.Header.Set("Origin", "https://catalyst.example.com")
+	assert.True(t, server.checkOrigin(req))
+	req.Header.Set("Origin", "https://evil.example.com")
+	assert.False(t, server.checkOrigin(req))
+}
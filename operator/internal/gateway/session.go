@@ -0,0 +1,133 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gateway
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+// maxCloseReason keeps close frames under the 125 byte control frame limit
+const maxCloseReason = 120
+
+// controlMessage is a text frame sent by the client
+type controlMessage struct {
+	Type string `json:"type"`
+	Cols uint16 `json:"cols,omitempty"`
+	Rows uint16 `json:"rows,omitempty"`
+}
+
+// session adapts a WebSocket connection to the exec streams:
+// stdin (io.Reader), terminal output (io.Writer) and resize events (remotecommand.TerminalSizeQueue).
+type session struct {
+	conn *websocket.Conn
+
+	stdin      *io.PipeReader
+	stdinWrite *io.PipeWriter
+	sizes      chan remotecommand.TerminalSize
+	done       chan struct{}
+
+	writeMu   sync.Mutex
+	closeOnce sync.Once
+}
+
+func newSession(conn *websocket.Conn) *session {
+	r, w := io.Pipe()
+	s := &session{
+		conn:       conn,
+		stdin:      r,
+		stdinWrite: w,
+		sizes:      make(chan remotecommand.TerminalSize, 1),
+		done:       make(chan struct{}),
+	}
+	go s.readLoop()
+	return s
+}
+
+// readLoop forwards binary frames to stdin and resize messages to the size queue
+func (s *session) readLoop() {
+	defer func() { _ = s.stdinWrite.Close() }()
+	for {
+		msgType, data, err := s.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		switch msgType {
+		case websocket.BinaryMessage:
+			if _, err := s.stdinWrite.Write(data); err != nil {
+				return
+			}
+		case websocket.TextMessage:
+			var msg controlMessage
+			if err := json.Unmarshal(data, &msg); err != nil || msg.Type != "resize" || msg.Cols == 0 || msg.Rows == 0 {
+				continue
+			}
+			select {
+			case s.sizes <- remotecommand.TerminalSize{Width: msg.Cols, Height: msg.Rows}:
+			case <-s.done:
+				return
+			}
+		}
+	}
+}
+
+func (s *session) Read(p []byte) (int, error) {
+	return s.stdin.Read(p)
+}
+
+func (s *session) Write(p []byte) (int, error) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	if err := s.conn.WriteMessage(websocket.BinaryMessage, p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Next implements remotecommand.TerminalSizeQueue; nil ends the queue.
+func (s *session) Next() *remotecommand.TerminalSize {
+	select {
+	case size := <-s.sizes:
+		return &size
+	case <-s.done:
+		return nil
+	}
+}
+
+// close ends the session, reporting the exec error (if any) as the close reason
+func (s *session) close(execErr error) {
+	s.closeOnce.Do(func() {
+		close(s.done)
+		_ = s.stdin.Close()
+
+		code, reason := websocket.CloseNormalClosure, ""
+		if execErr != nil {
+			code, reason = websocket.CloseInternalServerErr, execErr.Error()
+			if len(reason) > maxCloseReason {
+				reason = reason[:maxCloseReason]
+			}
+		}
+		s.writeMu.Lock()
+		defer s.writeMu.Unlock()
+		_ = s.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(time.Second))
+	})
+}
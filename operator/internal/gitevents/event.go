@@ -0,0 +1,176 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitevents
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// zeroCommit is the "after" commit of a push deleting a branch
+const zeroCommit = "0000000000000000000000000000000000000000"
+
+// Event is a new commit on a branch or pull request, parsed from a provider webhook
+type Event struct {
+	// RepositoryURLs are the clone and web URLs of the repository
+	RepositoryURLs []string
+	// Branch received the commit; for pull requests, the head branch
+	Branch string
+	// PullRequest is the pull (merge) request number; 0 for pushes
+	PullRequest int
+	// Commit is the new head commit
+	Commit string
+}
+
+type githubRepository struct {
+	CloneURL string `json:"clone_url"`
+	HTMLURL  string `json:"html_url"`
+	SSHURL   string `json:"ssh_url"`
+}
+
+// parseGitHubEvent parses a GitHub push or pull_request payload. Other events, branch
+// deletions and pull request actions that do not move the head return nil.
+func parseGitHubEvent(eventType string, body []byte) (*Event, error) {
+	switch eventType {
+	case "push":
+		var payload struct {
+			Ref        string           `json:"ref"`
+			After      string           `json:"after"`
+			Deleted    bool             `json:"deleted"`
+			Repository githubRepository `json:"repository"`
+		}
+		if err := json.Unmarshal(body, &payload); err != nil {
+			return nil, fmt.Errorf("invalid push payload: %w", err)
+		}
+		branch, ok := strings.CutPrefix(payload.Ref, "refs/heads/")
+		if !ok || payload.Deleted || payload.After == "" || payload.After == zeroCommit {
+			return nil, nil
+		}
+		return &Event{
+			RepositoryURLs: []string{payload.Repository.CloneURL, payload.Repository.HTMLURL, payload.Repository.SSHURL},
+			Branch:         branch,
+			Commit:         payload.After,
+		}, nil
+	case "pull_request":
+		var payload struct {
+			Action      string `json:"action"`
+			Number      int    `json:"number"`
+			PullRequest struct {
+				Head struct {
+					Ref string `json:"ref"`
+					SHA string `json:"sha"`
+				} `json:"head"`
+			} `json:"pull_request"`
+			Repository githubRepository `json:"repository"`
+		}
+		if err := json.Unmarshal(body, &payload); err != nil {
+			return nil, fmt.Errorf("invalid pull_request payload: %w", err)
+		}
+		switch payload.Action {
+		case "opened", "reopened", "synchronize":
+		default:
+			return nil, nil
+		}
+		return &Event{
+			RepositoryURLs: []string{payload.Repository.CloneURL, payload.Repository.HTMLURL, payload.Repository.SSHURL},
+			Branch:         payload.PullRequest.Head.Ref,
+			PullRequest:    payload.Number,
+			Commit:         payload.PullRequest.Head.SHA,
+		}, nil
+	}
+	return nil, nil
+}
+
+type gitlabProject struct {
+	GitHTTPURL string `json:"git_http_url"`
+	GitSSHURL  string `json:"git_ssh_url"`
+	WebURL     string `json:"web_url"`
+}
+
+// parseGitLabEvent parses a GitLab push or merge request payload. Other events, branch
+// deletions and merge request actions that do not move the head return nil.
+func parseGitLabEvent(eventType string, body []byte) (*Event, error) {
+	switch eventType {
+	case "Push Hook":
+		var payload struct {
+			Ref     string        `json:"ref"`
+			After   string        `json:"after"`
+			Project gitlabProject `json:"project"`
+		}
+		if err := json.Unmarshal(body, &payload); err != nil {
+			return nil, fmt.Errorf("invalid push payload: %w", err)
+		}
+		branch, ok := strings.CutPrefix(payload.Ref, "refs/heads/")
+		if !ok || payload.After == "" || payload.After == zeroCommit {
+			return nil, nil
+		}
+		return &Event{
+			RepositoryURLs: []string{payload.Project.GitHTTPURL, payload.Project.WebURL, payload.Project.GitSSHURL},
+			Branch:         branch,
+			Commit:         payload.After,
+		}, nil
+	case "Merge Request Hook":
+		var payload struct {
+			ObjectAttributes struct {
+				IID          int    `json:"iid"`
+				Action       string `json:"action"`
+				SourceBranch string `json:"source_branch"`
+				LastCommit   struct {
+					ID string `json:"id"`
+				} `json:"last_commit"`
+			} `json:"object_attributes"`
+			Project gitlabProject `json:"project"`
+		}
+		if err := json.Unmarshal(body, &payload); err != nil {
+			return nil, fmt.Errorf("invalid merge request payload: %w", err)
+		}
+		attrs := payload.ObjectAttributes
+		switch attrs.Action {
+		case "open", "reopen", "update":
+		default:
+			return nil, nil
+		}
+		if attrs.LastCommit.ID == "" {
+			return nil, nil
+		}
+		return &Event{
+			RepositoryURLs: []string{payload.Project.GitHTTPURL, payload.Project.WebURL, payload.Project.GitSSHURL},
+			Branch:         attrs.SourceBranch,
+			PullRequest:    attrs.IID,
+			Commit:         attrs.LastCommit.ID,
+		}, nil
+	}
+	return nil, nil
+}
+
+// normalizeRepositoryURL reduces https, ssh and scp-style git URLs of a repository to the
+// same "host/owner/repo" form
+func normalizeRepositoryURL(u string) string {
+	u = strings.ToLower(strings.TrimSpace(u))
+	if i := strings.Index(u, "://"); i >= 0 {
+		u = u[i+3:]
+	} else if at := strings.Index(u, "@"); at >= 0 {
+		// scp-style git@host:owner/repo
+		u = strings.Replace(u[at+1:], ":", "/", 1)
+	}
+	if at := strings.Index(u, "@"); at >= 0 && at < strings.Index(u, "/") {
+		// user info of an URL
+		u = u[at+1:]
+	}
+	return strings.TrimSuffix(strings.TrimSuffix(u, "/"), ".git")
+}
@@ -0,0 +1,251 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package gitevents receives GitHub and GitLab push and pull (merge) request webhooks and
+// moves the Environments tracking the branch or pull request to the new commit, so pushes
+// redeploy without the web app mediating every update.
+package gitevents
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+// +kubebuilder:rbac:groups=catalyst.catalyst.dev,resources=projects,verbs=get;list;watch
+// +kubebuilder:rbac:groups=catalyst.catalyst.dev,resources=environments,verbs=get;list;watch;patch
+
+// maxPayloadSize is the largest webhook payload read; GitHub caps payloads at 25 MB
+const maxPayloadSize = 25 << 20
+
+// Server serves /webhooks/github and /webhooks/gitlab. It implements manager.Runnable.
+//
+// GitHub deliveries are authenticated with the X-Hub-Signature-256 HMAC of the payload,
+// GitLab deliveries with the X-Gitlab-Token header, both using Secret. A push moves the
+// sources of the Environments on the pushed branch to the new commit; a pull request
+// opened or updated moves the sources with its number (spec.sources[].prNumber). Sources
+// match by the repository URL of the Project source of the same name.
+type Server struct {
+	// Client lists Projects and Environments and patches Environments (the manager's client)
+	Client client.Client
+	// BindAddress is the address the endpoint is served on (e.g. ":8084")
+	BindAddress string
+	// Secret is the webhook secret configured on the repositories
+	Secret string
+}
+
+// NeedLeaderElection allows every replica to receive webhooks.
+func (s *Server) NeedLeaderElection() bool {
+	return false
+}
+
+// Start serves the webhook endpoints until the context is cancelled.
+func (s *Server) Start(ctx context.Context) error {
+	log := logf.FromContext(ctx).WithName("gitevents")
+
+	mux := http.NewServeMux()
+	mux.Handle("/webhooks/", s.Handler())
+
+	srv := &http.Server{
+		Addr:              s.BindAddress,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			log.Error(err, "error shutting down git webhook server")
+		}
+	}()
+
+	log.Info("Serving git webhooks", "address", s.BindAddress, "path", "/webhooks/")
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// Handler returns the webhook handler. It responds with the Environments it updated.
+func (s *Server) Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		log := logf.Log.WithName("gitevents")
+		if req.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		body, err := io.ReadAll(io.LimitReader(req.Body, maxPayloadSize))
+		if err != nil {
+			http.Error(w, "failed to read payload", http.StatusBadRequest)
+			return
+		}
+
+		var event *Event
+		switch strings.TrimPrefix(req.URL.Path, "/webhooks/") {
+		case "github":
+			if !s.validGitHubSignature(body, req.Header.Get("X-Hub-Signature-256")) {
+				http.Error(w, "invalid signature", http.StatusUnauthorized)
+				return
+			}
+			event, err = parseGitHubEvent(req.Header.Get("X-GitHub-Event"), body)
+		case "gitlab":
+			if s.Secret == "" || subtle.ConstantTimeCompare([]byte(req.Header.Get("X-Gitlab-Token")), []byte(s.Secret)) != 1 {
+				http.Error(w, "invalid token", http.StatusUnauthorized)
+				return
+			}
+			event, err = parseGitLabEvent(req.Header.Get("X-Gitlab-Event"), body)
+		default:
+			http.NotFound(w, req)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if event == nil {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		updated, err := s.apply(req.Context(), event)
+		if err != nil {
+			log.Error(err, "Failed to update environments", "branch", event.Branch, "pullRequest", event.PullRequest, "commit", event.Commit)
+			http.Error(w, "failed to update environments", http.StatusInternalServerError)
+			return
+		}
+		if len(updated) > 0 {
+			log.Info("Moved environments to pushed commit", "environments", updated, "commit", event.Commit)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string][]string{"updated": updated})
+	}
+}
+
+// validGitHubSignature checks the "sha256=<hex HMAC>" signature of a GitHub payload
+func (s *Server) validGitHubSignature(body []byte, signature string) bool {
+	sum, ok := strings.CutPrefix(signature, "sha256=")
+	if s.Secret == "" || !ok {
+		return false
+	}
+	got, err := hex.DecodeString(sum)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(s.Secret))
+	mac.Write(body)
+	return hmac.Equal(got, mac.Sum(nil))
+}
+
+// apply moves the Environment sources the event concerns to its commit and returns the
+// "namespace/name" of the Environments it updated
+func (s *Server) apply(ctx context.Context, event *Event) ([]string, error) {
+	repos := map[string]bool{}
+	for _, u := range event.RepositoryURLs {
+		if u != "" {
+			repos[normalizeRepositoryURL(u)] = true
+		}
+	}
+
+	projects := &catalystv1alpha1.ProjectList{}
+	if err := s.Client.List(ctx, projects); err != nil {
+		return nil, err
+	}
+	var updated []string
+	for _, project := range projects.Items {
+		var sources []string
+		for _, src := range project.Spec.Sources {
+			if repos[normalizeRepositoryURL(src.RepositoryURL)] {
+				sources = append(sources, src.Name)
+			}
+		}
+		if len(sources) == 0 {
+			continue
+		}
+
+		envs := &catalystv1alpha1.EnvironmentList{}
+		if err := s.Client.List(ctx, envs, client.InNamespace(project.Namespace)); err != nil {
+			return nil, err
+		}
+		for i := range envs.Items {
+			env := &envs.Items[i]
+			if env.Spec.ProjectRef.Name != project.Name || !env.DeletionTimestamp.IsZero() || !moveSources(env, sources, event) {
+				continue
+			}
+			if err := s.patchSources(ctx, client.ObjectKeyFromObject(env), sources, event); err != nil {
+				return updated, err
+			}
+			updated = append(updated, env.Namespace+"/"+env.Name)
+		}
+	}
+	return updated, nil
+}
+
+// patchSources moves the Environment's sources to the event commit, retrying on conflicts
+// with concurrent updates
+func (s *Server) patchSources(ctx context.Context, key client.ObjectKey, sources []string, event *Event) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		env := &catalystv1alpha1.Environment{}
+		if err := s.Client.Get(ctx, key, env); err != nil {
+			return client.IgnoreNotFound(err)
+		}
+		patch := client.MergeFromWithOptions(env.DeepCopy(), client.MergeFromWithOptimisticLock{})
+		if !moveSources(env, sources, event) {
+			return nil
+		}
+		return s.Client.Patch(ctx, env, patch)
+	})
+}
+
+// moveSources points the Environment sources of the event's repository (named sources)
+// that track its branch or pull request at the event commit. Reports whether any moved.
+func moveSources(env *catalystv1alpha1.Environment, sources []string, event *Event) bool {
+	moved := false
+	for i := range env.Spec.Sources {
+		src := &env.Spec.Sources[i]
+		if !slices.Contains(sources, src.Name) || src.CommitSha == event.Commit {
+			continue
+		}
+		switch {
+		case event.PullRequest != 0 && src.PrNumber == event.PullRequest:
+			src.CommitSha = event.Commit
+			if event.Branch != "" {
+				src.Branch = event.Branch
+			}
+			moved = true
+		case event.PullRequest == 0 && src.Branch == event.Branch:
+			src.CommitSha = event.Commit
+			moved = true
+		}
+	}
+	return moved
+}
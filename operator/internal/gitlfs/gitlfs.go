@@ -0,0 +1,308 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package gitlfs downloads the Git LFS objects of a checked out worktree. go-git leaves LFS
+// tracked files as pointer files; Pull replaces them with their content from the LFS server
+// of the repository, through the batch API with basic transfers.
+package gitlfs
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	pointerVersion = "version https://git-lfs.github.com/spec/v1"
+	// maxPointerSize bounds the files read as potential pointers
+	maxPointerSize = 1024
+	// batchSize is the number of objects requested per batch call
+	batchSize = 100
+	mediaType = "application/vnd.git-lfs+json"
+)
+
+// Credentials authenticate against the LFS server of the repository host
+type Credentials struct {
+	Username string
+	Password string
+}
+
+// Pointer is a parsed LFS pointer file
+type Pointer struct {
+	OID  string `json:"oid"`
+	Size int64  `json:"size"`
+}
+
+// ParsePointer parses an LFS pointer file, reporting false for any other content
+func ParsePointer(data []byte) (Pointer, bool) {
+	if len(data) > maxPointerSize || !bytes.HasPrefix(data, []byte(pointerVersion+"\n")) {
+		return Pointer{}, false
+	}
+	var p Pointer
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		key, value, _ := strings.Cut(scanner.Text(), " ")
+		switch key {
+		case "oid":
+			oid, ok := strings.CutPrefix(value, "sha256:")
+			if !ok || len(oid) != sha256.Size*2 {
+				return Pointer{}, false
+			}
+			p.OID = oid
+		case "size":
+			size, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || size < 0 {
+				return Pointer{}, false
+			}
+			p.Size = size
+		}
+	}
+	return p, p.OID != ""
+}
+
+// Endpoint returns the LFS server URL of an HTTP(S) repository URL
+func Endpoint(remoteURL string) string {
+	endpoint := strings.TrimSuffix(remoteURL, "/")
+	if !strings.HasSuffix(endpoint, ".git") {
+		endpoint += ".git"
+	}
+	return endpoint + "/info/lfs"
+}
+
+// Pull replaces the pointer files of the worktree at dir with their objects from the LFS
+// server of remoteURL. Nested repositories (submodules) are left to their own Pull. A nil
+// client uses http.DefaultClient; nil credentials send none.
+func Pull(ctx context.Context, client *http.Client, dir, remoteURL string, credentials *Credentials) error {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	files := map[string][]string{}
+	var pointers []Pointer
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			if path != dir {
+				if _, err := os.Lstat(filepath.Join(path, ".git")); err == nil {
+					return filepath.SkipDir
+				}
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil || info.Size() > maxPointerSize {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if p, ok := ParsePointer(data); ok {
+			if _, seen := files[p.OID]; !seen {
+				pointers = append(pointers, p)
+			}
+			files[p.OID] = append(files[p.OID], path)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	endpoint := Endpoint(remoteURL)
+	for start := 0; start < len(pointers); start += batchSize {
+		batch := pointers[start:min(start+batchSize, len(pointers))]
+		objects, err := requestBatch(ctx, client, endpoint, batch, credentials)
+		if err != nil {
+			return err
+		}
+		for _, object := range objects {
+			if len(files[object.OID]) == 0 {
+				continue
+			}
+			if object.Error != nil {
+				return fmt.Errorf("LFS object %s: %s (%d)", object.OID, object.Error.Message, object.Error.Code)
+			}
+			download, ok := object.Actions["download"]
+			if !ok {
+				return fmt.Errorf("LFS server returned no download for object %s", object.OID)
+			}
+			if err := downloadObject(ctx, client, endpoint, object.Pointer, download, credentials, files[object.OID]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+type batchRequest struct {
+	Operation string    `json:"operation"`
+	Transfers []string  `json:"transfers"`
+	Objects   []Pointer `json:"objects"`
+}
+
+type batchResponse struct {
+	Objects []batchObject `json:"objects"`
+	Message string        `json:"message"`
+}
+
+type batchObject struct {
+	Pointer
+	Actions map[string]action `json:"actions"`
+	Error   *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+type action struct {
+	Href   string            `json:"href"`
+	Header map[string]string `json:"header"`
+}
+
+// requestBatch asks the LFS server for the download actions of the objects
+func requestBatch(ctx context.Context, client *http.Client, endpoint string, objects []Pointer, credentials *Credentials) ([]batchObject, error) {
+	body, err := json.Marshal(batchRequest{Operation: "download", Transfers: []string{"basic"}, Objects: objects})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint+"/objects/batch", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", mediaType)
+	req.Header.Set("Content-Type", mediaType)
+	if credentials != nil {
+		req.SetBasicAuth(credentials.Username, credentials.Password)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("LFS batch request to %s: %w", endpoint, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	var batch batchResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 10<<20)).Decode(&batch); err != nil && resp.StatusCode == http.StatusOK {
+		return nil, fmt.Errorf("decoding LFS batch response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("LFS batch request to %s: %s %s", endpoint, resp.Status, batch.Message)
+	}
+	return batch.Objects, nil
+}
+
+// downloadObject fetches an object, verifies its size and hash, and writes it over the
+// pointer files of the object
+func downloadObject(ctx context.Context, client *http.Client, endpoint string, p Pointer, download action, credentials *Credentials, paths []string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, download.Href, nil)
+	if err != nil {
+		return err
+	}
+	for key, value := range download.Header {
+		req.Header.Set(key, value)
+	}
+	// Signed storage URLs carry their own authorization; credentials only go to the LFS host
+	if credentials != nil && req.Header.Get("Authorization") == "" && sameHost(download.Href, endpoint) {
+		req.SetBasicAuth(credentials.Username, credentials.Password)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("downloading LFS object %s: %w", p.OID, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("downloading LFS object %s: %s", p.OID, resp.Status)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(paths[0]), ".lfs-*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	hash := sha256.New()
+	n, err := io.Copy(io.MultiWriter(tmp, hash), io.LimitReader(resp.Body, p.Size+1))
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("downloading LFS object %s: %w", p.OID, err)
+	}
+	if n != p.Size || hex.EncodeToString(hash.Sum(nil)) != p.OID {
+		return fmt.Errorf("LFS object %s does not match its pointer", p.OID)
+	}
+
+	info, err := os.Stat(paths[0])
+	if err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), info.Mode().Perm()); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), paths[0]); err != nil {
+		return err
+	}
+	// Identical files share an object
+	for _, path := range paths[1:] {
+		if err := copyFile(paths[0], path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyFile overwrites the content of dst with src, keeping the mode of dst
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = in.Close() }()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_TRUNC, 0)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// sameHost reports whether both URLs point at the same host
+func sameHost(a, b string) bool {
+	ua, errA := url.Parse(a)
+	ub, errB := url.Parse(b)
+	return errA == nil && errB == nil && strings.EqualFold(ua.Host, ub.Host)
+}
@@ -0,0 +1,142 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gitlfs
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func pointerFile(content string) (string, string) {
+	sum := sha256.Sum256([]byte(content))
+	oid := hex.EncodeToString(sum[:])
+	return oid, fmt.Sprintf("%s\noid sha256:%s\nsize %d\n", pointerVersion, oid, len(content))
+}
+
+func TestParsePointer(t *testing.T) {
+	oid, pointer := pointerFile("chart")
+	p, ok := ParsePointer([]byte(pointer))
+	require.True(t, ok)
+	assert.Equal(t, Pointer{OID: oid, Size: 5}, p)
+
+	for _, data := range []string{"chart", pointerVersion + "\nsize 5\n", pointerVersion + "\noid sha256:abc\nsize 5\n"} {
+		_, ok := ParsePointer([]byte(data))
+		assert.False(t, ok, data)
+	}
+}
+
+func TestEndpoint(t *testing.T) {
+	assert.Equal(t, "https://github.com/acme/app.git/info/lfs", Endpoint("https://github.com/acme/app"))
+	assert.Equal(t, "https://github.com/acme/app.git/info/lfs", Endpoint("https://github.com/acme/app.git/"))
+}
+
+func TestPull(t *testing.T) {
+	content := "binary chart archive"
+	oid, pointer := pointerFile(content)
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, ok := r.BasicAuth()
+		if !ok || username != "oauth2" || password != "token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/acme/app.git/info/lfs/objects/batch":
+			var req batchRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			assert.Equal(t, "download", req.Operation)
+			assert.Equal(t, []Pointer{{OID: oid, Size: int64(len(content))}}, req.Objects)
+			w.Header().Set("Content-Type", mediaType)
+			_ = json.NewEncoder(w).Encode(batchResponse{Objects: []batchObject{{
+				Pointer: req.Objects[0],
+				Actions: map[string]action{"download": {Href: server.URL + "/objects/" + oid}},
+			}}})
+		case "/objects/" + oid:
+			_, _ = w.Write([]byte(content))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "charts"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "charts", "dep.tgz"), []byte(pointer), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "copy.tgz"), []byte(pointer), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("# app\n"), 0o644))
+	// Submodules pull from their own LFS server
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "lib"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "lib", ".git"), []byte("gitdir: ../.git/modules/lib\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "lib", "asset.bin"), []byte(pointer), 0o644))
+
+	err := Pull(context.Background(), server.Client(), dir, server.URL+"/acme/app", &Credentials{Username: "oauth2", Password: "token"})
+	require.NoError(t, err)
+
+	for _, name := range []string{"charts/dep.tgz", "copy.tgz"} {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		require.NoError(t, err)
+		assert.Equal(t, content, string(data), name)
+	}
+	info, err := os.Stat(filepath.Join(dir, "copy.tgz"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+	data, err := os.ReadFile(filepath.Join(dir, "lib", "asset.bin"))
+	require.NoError(t, err)
+	assert.Equal(t, pointer, string(data))
+
+	// Without credentials the server refuses the batch
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "copy.tgz"), []byte(pointer), 0o600))
+	err = Pull(context.Background(), server.Client(), dir, server.URL+"/acme/app", nil)
+	assert.ErrorContains(t, err, "401")
+}
+
+func TestPull_CorruptObject(t *testing.T) {
+	oid, pointer := pointerFile("expected")
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			_ = json.NewEncoder(w).Encode(batchResponse{Objects: []batchObject{{
+				Pointer: Pointer{OID: oid, Size: 8},
+				Actions: map[string]action{"download": {Href: server.URL + "/object"}},
+			}}})
+			return
+		}
+		_, _ = w.Write([]byte("tampered"))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "file.bin"), []byte(pointer), 0o644))
+	err := Pull(context.Background(), server.Client(), dir, server.URL+"/acme/app", nil)
+	assert.ErrorContains(t, err, "does not match its pointer")
+
+	data, err := os.ReadFile(filepath.Join(dir, "file.bin"))
+	require.NoError(t, err)
+	assert.Equal(t, pointer, string(data), "the pointer is left in place")
+}
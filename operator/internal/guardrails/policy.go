@@ -0,0 +1,276 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package guardrails implements the platform admin policy of disallowed workload fields.
+// It is enforced by the Project validating webhook (template configs) and at render time
+// for Helm, docker-compose and manifest output.
+package guardrails
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/yaml"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+	"github.com/ncrmro/catalyst/operator/internal/operatorconfig"
+)
+
+// Policy is the set of guardrails. The zero value allows everything.
+type Policy struct {
+	// ForbidPrivileged rejects privileged containers and privilege escalation
+	ForbidPrivileged bool
+	// ForbidHostPath rejects hostPath volumes
+	ForbidHostPath bool
+	// ForbidLoadBalancer rejects Services of type LoadBalancer
+	ForbidLoadBalancer bool
+	// AllowedRegistries restricts images to these registries or repository prefixes
+	// (e.g. "ghcr.io/acme"). Images without a registry resolve to docker.io. Empty allows all.
+	AllowedRegistries []string
+
+	// exemptImages are operator-managed images (git clone, wait loops) always allowed
+	exemptImages map[string]bool
+}
+
+// Current returns the policy of the guardrails section of the operator configuration
+// (env GUARDRAIL_*)
+func Current() Policy {
+	cfg := operatorconfig.Current().Guardrails
+	p := Policy{
+		ForbidPrivileged:   cfg.ForbidPrivileged,
+		ForbidHostPath:     cfg.ForbidHostPath,
+		ForbidLoadBalancer: cfg.ForbidLoadBalancer,
+	}
+	for _, registry := range cfg.AllowedRegistries {
+		if registry = strings.TrimSuffix(strings.TrimSpace(registry), "/"); registry != "" {
+			p.AllowedRegistries = append(p.AllowedRegistries, registry)
+		}
+	}
+	return p
+}
+
+// Enabled reports whether any guardrail is configured
+func (p Policy) Enabled() bool {
+	return p.ForbidPrivileged || p.ForbidHostPath || p.ForbidLoadBalancer || len(p.AllowedRegistries) > 0
+}
+
+// WithExemptImages returns a copy of the policy that always allows the given images
+func (p Policy) WithExemptImages(images ...string) Policy {
+	exempt := make(map[string]bool, len(p.exemptImages)+len(images))
+	for image := range p.exemptImages {
+		exempt[image] = true
+	}
+	for _, image := range images {
+		exempt[image] = true
+	}
+	p.exemptImages = exempt
+	return p
+}
+
+// Violation is a single disallowed field
+type Violation struct {
+	// Object identifies the offending object, e.g. "Deployment/web" or "template development"
+	Object string
+	// Message describes the disallowed field
+	Message string
+}
+
+func (v Violation) String() string {
+	return v.Object + ": " + v.Message
+}
+
+// ViolationError is returned when rendered output violates the policy
+type ViolationError struct {
+	Violations []Violation
+}
+
+func (e *ViolationError) Error() string {
+	parts := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		parts = append(parts, v.String())
+	}
+	return "guardrail violations: " + strings.Join(parts, "; ")
+}
+
+// AsError returns a *ViolationError, or nil if there are no violations
+func AsError(violations []Violation) error {
+	if len(violations) == 0 {
+		return nil
+	}
+	return &ViolationError{Violations: violations}
+}
+
+// IsViolation reports whether err contains a *ViolationError
+func IsViolation(err error) (*ViolationError, bool) {
+	var v *ViolationError
+	ok := errors.As(err, &v)
+	return v, ok
+}
+
+// normalizeImage expands an image reference to include its registry (docker.io by default)
+func normalizeImage(image string) string {
+	first, rest, found := strings.Cut(image, "/")
+	if !found {
+		return "docker.io/library/" + image
+	}
+	if strings.ContainsAny(first, ".:") || first == "localhost" {
+		return image
+	}
+	return "docker.io/" + first + "/" + rest
+}
+
+// ImageAllowed reports whether the image comes from an allowed registry
+func (p Policy) ImageAllowed(image string) bool {
+	if len(p.AllowedRegistries) == 0 || image == "" || p.exemptImages[image] {
+		return true
+	}
+	normalized := normalizeImage(image)
+	for _, allowed := range p.AllowedRegistries {
+		if normalized == allowed || strings.HasPrefix(normalized, allowed+"/") || image == allowed || strings.HasPrefix(image, allowed+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func (p Policy) checkImage(object, image string) []Violation {
+	if p.ImageAllowed(image) {
+		return nil
+	}
+	return []Violation{{Object: object, Message: fmt.Sprintf("image %q is not from an approved registry (%s)", image, strings.Join(p.AllowedRegistries, ", "))}}
+}
+
+// CheckPodSpec checks the containers and volumes of a pod spec
+func (p Policy) CheckPodSpec(object string, spec *corev1.PodSpec) []Violation {
+	var violations []Violation
+	containers := append(append([]corev1.Container{}, spec.InitContainers...), spec.Containers...)
+	for _, c := range containers {
+		violations = append(violations, p.checkImage(object, c.Image)...)
+		if p.ForbidPrivileged && c.SecurityContext != nil {
+			if c.SecurityContext.Privileged != nil && *c.SecurityContext.Privileged {
+				violations = append(violations, Violation{Object: object, Message: fmt.Sprintf("container %q is privileged", c.Name)})
+			}
+			if c.SecurityContext.AllowPrivilegeEscalation != nil && *c.SecurityContext.AllowPrivilegeEscalation {
+				violations = append(violations, Violation{Object: object, Message: fmt.Sprintf("container %q allows privilege escalation", c.Name)})
+			}
+		}
+	}
+	if p.ForbidHostPath {
+		for _, v := range spec.Volumes {
+			if v.HostPath != nil {
+				violations = append(violations, Violation{Object: object, Message: fmt.Sprintf("volume %q uses hostPath %s", v.Name, v.HostPath.Path)})
+			}
+		}
+	}
+	return violations
+}
+
+// CheckService checks a Service
+func (p Policy) CheckService(object string, svc *corev1.Service) []Violation {
+	if p.ForbidLoadBalancer && svc.Spec.Type == corev1.ServiceTypeLoadBalancer {
+		return []Violation{{Object: object, Message: "Service type LoadBalancer is not allowed"}}
+	}
+	return nil
+}
+
+// CheckObject checks a typed workload or Service; other kinds are ignored.
+func (p Policy) CheckObject(obj runtime.Object) []Violation {
+	switch o := obj.(type) {
+	case *corev1.Pod:
+		return p.CheckPodSpec("Pod/"+o.Name, &o.Spec)
+	case *appsv1.Deployment:
+		return p.CheckPodSpec("Deployment/"+o.Name, &o.Spec.Template.Spec)
+	case *appsv1.StatefulSet:
+		return p.CheckPodSpec("StatefulSet/"+o.Name, &o.Spec.Template.Spec)
+	case *appsv1.DaemonSet:
+		return p.CheckPodSpec("DaemonSet/"+o.Name, &o.Spec.Template.Spec)
+	case *appsv1.ReplicaSet:
+		return p.CheckPodSpec("ReplicaSet/"+o.Name, &o.Spec.Template.Spec)
+	case *batchv1.Job:
+		return p.CheckPodSpec("Job/"+o.Name, &o.Spec.Template.Spec)
+	case *batchv1.CronJob:
+		return p.CheckPodSpec("CronJob/"+o.Name, &o.Spec.JobTemplate.Spec.Template.Spec)
+	case *corev1.Service:
+		return p.CheckService("Service/"+o.Name, o)
+	}
+	return nil
+}
+
+// typedKinds maps rendered manifest kinds to the typed objects CheckObject understands
+var typedKinds = map[string]func() runtime.Object{
+	"Pod":         func() runtime.Object { return &corev1.Pod{} },
+	"Deployment":  func() runtime.Object { return &appsv1.Deployment{} },
+	"StatefulSet": func() runtime.Object { return &appsv1.StatefulSet{} },
+	"DaemonSet":   func() runtime.Object { return &appsv1.DaemonSet{} },
+	"ReplicaSet":  func() runtime.Object { return &appsv1.ReplicaSet{} },
+	"Job":         func() runtime.Object { return &batchv1.Job{} },
+	"CronJob":     func() runtime.Object { return &batchv1.CronJob{} },
+	"Service":     func() runtime.Object { return &corev1.Service{} },
+}
+
+// CheckManifests checks a multi-document YAML stream (e.g. Helm rendered output)
+func (p Policy) CheckManifests(manifests []byte) ([]Violation, error) {
+	var violations []Violation
+	decoder := yaml.NewYAMLOrJSONDecoder(bytes.NewReader(manifests), 4096)
+	for {
+		var doc map[string]interface{}
+		if err := decoder.Decode(&doc); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("failed to parse rendered manifests: %w", err)
+		}
+		kind, _ := doc["kind"].(string)
+		newObj, ok := typedKinds[kind]
+		if !ok {
+			continue
+		}
+		obj := newObj()
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(doc, obj); err != nil {
+			return nil, fmt.Errorf("failed to decode rendered %s: %w", kind, err)
+		}
+		violations = append(violations, p.CheckObject(obj)...)
+	}
+	return violations, nil
+}
+
+// CheckConfig checks the images of an EnvironmentConfig (main, init, sidecar and managed
+// service containers)
+func (p Policy) CheckConfig(object string, config *catalystv1alpha1.EnvironmentConfig) []Violation {
+	if config == nil {
+		return nil
+	}
+	violations := p.checkImage(object, config.Image)
+	for _, init := range config.InitContainers {
+		violations = append(violations, p.checkImage(object, init.Image)...)
+	}
+	for _, sidecar := range config.Sidecars {
+		if sidecar.Image != "" {
+			violations = append(violations, p.checkImage(object, sidecar.Image)...)
+		}
+	}
+	for _, svc := range config.Services {
+		violations = append(violations, p.checkImage(object, svc.Container.Image)...)
+	}
+	return violations
+}
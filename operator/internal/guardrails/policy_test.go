@@ -0,0 +1,98 @@
+package guardrails
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+)
+
+const renderedChart = `
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+spec:
+  type: LoadBalancer
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      containers:
+        - name: web
+          image: quay.io/acme/web:1
+          securityContext:
+            privileged: true
+      volumes:
+        - name: docker
+          hostPath:
+            path: /var/run/docker.sock
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: ignored
+`
+
+func TestImageAllowed(t *testing.T) {
+	policy := Policy{AllowedRegistries: []string{"ghcr.io/acme", "docker.io/library"}}
+
+	assert.True(t, policy.ImageAllowed("ghcr.io/acme/web:1"))
+	assert.True(t, policy.ImageAllowed("nginx:1.27"), "official images resolve to docker.io/library")
+	assert.False(t, policy.ImageAllowed("ghcr.io/acme-evil/web:1"))
+	assert.False(t, policy.ImageAllowed("bitnami/redis"))
+	assert.True(t, policy.WithExemptImages("busybox:1.36").ImageAllowed("busybox:1.36"))
+	assert.True(t, Policy{}.ImageAllowed("anything/goes"))
+}
+
+func TestCheckManifests(t *testing.T) {
+	policy := Policy{ForbidPrivileged: true, ForbidHostPath: true, ForbidLoadBalancer: true, AllowedRegistries: []string{"ghcr.io/acme"}}
+
+	violations, err := policy.CheckManifests([]byte(renderedChart))
+	require.NoError(t, err)
+	require.Len(t, violations, 4)
+	assert.Equal(t, "Service/web", violations[0].Object)
+	assert.Contains(t, violations[1].Message, "quay.io/acme/web:1")
+	assert.Contains(t, violations[2].Message, "privileged")
+	assert.Contains(t, violations[3].Message, "hostPath")
+
+	// The zero policy allows everything
+	violations, err = Policy{}.CheckManifests([]byte(renderedChart))
+	require.NoError(t, err)
+	assert.Empty(t, violations)
+}
+
+func TestPostRenderer(t *testing.T) {
+	renderer := &PostRenderer{Policy: Policy{ForbidLoadBalancer: true}}
+
+	_, err := renderer.Run(bytes.NewBufferString(renderedChart))
+	violation, ok := IsViolation(err)
+	require.True(t, ok)
+	assert.Len(t, violation.Violations, 1)
+
+	out, err := (&PostRenderer{Policy: Policy{}}).Run(bytes.NewBufferString(renderedChart))
+	require.NoError(t, err)
+	assert.Equal(t, renderedChart, out.String())
+}
+
+func TestCheckConfig(t *testing.T) {
+	policy := Policy{AllowedRegistries: []string{"ghcr.io/acme"}}
+	config := &catalystv1alpha1.EnvironmentConfig{
+		Image:          "ghcr.io/acme/web:1",
+		InitContainers: []catalystv1alpha1.InitContainerSpec{{Name: "migrate", Image: "ghcr.io/acme/web:1"}},
+		Services:       []catalystv1alpha1.ManagedServiceSpec{{Name: "postgres", Container: catalystv1alpha1.ManagedServiceContainer{Image: "postgres:16"}}},
+	}
+
+	violations := policy.CheckConfig("template development", config)
+	require.Len(t, violations, 1)
+	assert.Equal(t, "template development", violations[0].Object)
+	assert.Contains(t, violations[0].Message, "postgres:16")
+	assert.Nil(t, policy.CheckConfig("template helm", nil))
+}
@@ -0,0 +1,39 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package guardrails
+
+import (
+	"bytes"
+)
+
+// PostRenderer is a Helm post-renderer that rejects rendered charts violating the policy.
+// The manifests are passed through unchanged.
+type PostRenderer struct {
+	Policy Policy
+}
+
+// Run implements postrender.PostRenderer
+func (r *PostRenderer) Run(renderedManifests *bytes.Buffer) (*bytes.Buffer, error) {
+	violations, err := r.Policy.CheckManifests(renderedManifests.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	if err := AsError(violations); err != nil {
+		return nil, err
+	}
+	return renderedManifests, nil
+}
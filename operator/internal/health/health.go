@@ -0,0 +1,72 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package health provides the /readyz checks of the operator. A replica is ready once its
+// informer caches have synced and the Catalyst web API, which serves git credentials to
+// clone and build pods, answers. Liveness stays a plain ping so a web outage never restarts
+// the operator.
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+)
+
+// checkTimeout bounds each check; probes time out after one second by default
+const checkTimeout = 900 * time.Millisecond
+
+// CacheSyncWaiter is implemented by the manager's cache
+type CacheSyncWaiter interface {
+	WaitForCacheSync(ctx context.Context) bool
+}
+
+// CacheSynced fails until every informer of cache has synced
+func CacheSynced(cache CacheSyncWaiter) healthz.Checker {
+	return func(req *http.Request) error {
+		ctx, cancel := context.WithTimeout(req.Context(), checkTimeout)
+		defer cancel()
+		if !cache.WaitForCacheSync(ctx) {
+			return errors.New("informer caches have not synced")
+		}
+		return nil
+	}
+}
+
+// Reachable fails while url can't be connected to. Any HTTP response counts as reachable;
+// a nil client uses http.DefaultClient.
+func Reachable(client *http.Client, url string) healthz.Checker {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return func(req *http.Request) error {
+		ctx, cancel := context.WithTimeout(req.Context(), checkTimeout)
+		defer cancel()
+		probe, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := client.Do(probe)
+		if err != nil {
+			return fmt.Errorf("%s is unreachable: %w", url, err)
+		}
+		return resp.Body.Close()
+	}
+}
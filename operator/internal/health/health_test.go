@@ -0,0 +1,54 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package health
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeCache bool
+
+func (c fakeCache) WaitForCacheSync(ctx context.Context) bool {
+	if !c {
+		<-ctx.Done()
+	}
+	return bool(c)
+}
+
+func TestCacheSynced(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/readyz", nil)
+	assert.NoError(t, CacheSynced(fakeCache(true))(req))
+	assert.ErrorContains(t, CacheSynced(fakeCache(false))(req), "not synced")
+}
+
+func TestReachable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/readyz", nil)
+
+	// Any response means the web API is up
+	assert.NoError(t, Reachable(server.Client(), server.URL)(req))
+
+	server.Close()
+	assert.ErrorContains(t, Reachable(nil, server.URL)(req), "unreachable")
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package lifetime implements the hard cap on Environment age. Environments older than the
+// cap are deleted by the janitor controller, whatever their own settings. Exemptions are
+// granted with an annotation that the Environment validating webhook only accepts from
+// users allowed the "exempt" verb on environments.
+package lifetime
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ncrmro/catalyst/operator/internal/operatorconfig"
+)
+
+const (
+	// ExemptAnnotation on an Environment exempts it from the lifetime cap. The value
+	// records the reason, e.g. "customer demo until Q3".
+	ExemptAnnotation = "catalyst.dev/lifetime-exempt"
+	// MaxLifetimeAnnotation on a team namespace overrides the operator-wide cap for that
+	// team's Environments (a Go duration, e.g. "168h"; "0" disables the cap).
+	MaxLifetimeAnnotation = "catalyst.dev/max-environment-lifetime"
+	// ExemptVerb is the RBAC verb on catalyst.catalyst.dev environments required to set
+	// ExemptAnnotation
+	ExemptVerb = "exempt"
+)
+
+// Policy is the lifetime cap. The zero value never expires Environments.
+type Policy struct {
+	// MaxLifetime is the operator-wide cap measured from creation; 0 disables it
+	MaxLifetime time.Duration
+	// Types are the Environment types (spec.type) the cap applies to
+	Types []string
+}
+
+// Current returns the policy of the lifetime section of the operator configuration
+// (env ENVIRONMENT_MAX_LIFETIME, ENVIRONMENT_MAX_LIFETIME_TYPES). The cap defaults to the
+// development type.
+func Current() Policy {
+	cfg := operatorconfig.Current().Lifetime
+	p := Policy{MaxLifetime: cfg.MaxLifetime.Duration}
+	for _, t := range cfg.Types {
+		if t = strings.TrimSpace(t); t != "" {
+			p.Types = append(p.Types, t)
+		}
+	}
+	if len(p.Types) == 0 {
+		p.Types = []string{"development"}
+	}
+	return p
+}
+
+// MaxLifetimeFor returns the cap for an Environment type, applying the team namespace
+// override. It returns false when no cap applies.
+func (p Policy) MaxLifetimeFor(envType string, teamAnnotations map[string]string) (time.Duration, bool, error) {
+	if !slices.Contains(p.Types, envType) {
+		return 0, false, nil
+	}
+	maxLifetime := p.MaxLifetime
+	if value, ok := teamAnnotations[MaxLifetimeAnnotation]; ok {
+		d, err := time.ParseDuration(value)
+		if err != nil || d < 0 {
+			return 0, false, fmt.Errorf("invalid %s annotation %q", MaxLifetimeAnnotation, value)
+		}
+		maxLifetime = d
+	}
+	return maxLifetime, maxLifetime > 0, nil
+}
+
+// Exempt reports whether the Environment annotations carry an exemption
+func Exempt(annotations map[string]string) bool {
+	_, ok := annotations[ExemptAnnotation]
+	return ok
+}
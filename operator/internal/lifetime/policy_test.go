@@ -0,0 +1,53 @@
+package lifetime
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCurrent(t *testing.T) {
+	assert.Equal(t, Policy{Types: []string{"development"}}, Current())
+
+	t.Setenv("ENVIRONMENT_MAX_LIFETIME", "336h")
+	t.Setenv("ENVIRONMENT_MAX_LIFETIME_TYPES", "development, staging")
+	assert.Equal(t, Policy{MaxLifetime: 336 * time.Hour, Types: []string{"development", "staging"}}, Current())
+}
+
+func TestMaxLifetimeFor(t *testing.T) {
+	p := Policy{MaxLifetime: 336 * time.Hour, Types: []string{"development"}}
+
+	d, ok, err := p.MaxLifetimeFor("development", nil)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 336*time.Hour, d)
+
+	_, ok, err = p.MaxLifetimeFor("deployment", nil)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	// Team namespaces can tighten, loosen or disable the cap
+	d, ok, err = p.MaxLifetimeFor("development", map[string]string{MaxLifetimeAnnotation: "72h"})
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 72*time.Hour, d)
+
+	_, ok, err = p.MaxLifetimeFor("development", map[string]string{MaxLifetimeAnnotation: "0"})
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	_, _, err = p.MaxLifetimeFor("development", map[string]string{MaxLifetimeAnnotation: "soon"})
+	assert.Error(t, err)
+
+	// Without an operator cap a team can still opt in
+	_, ok, err = Policy{Types: []string{"development"}}.MaxLifetimeFor("development", map[string]string{MaxLifetimeAnnotation: "24h"})
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestExempt(t *testing.T) {
+	assert.False(t, Exempt(nil))
+	assert.True(t, Exempt(map[string]string{ExemptAnnotation: "customer demo"}))
+}
@@ -0,0 +1,229 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package notify reports environment phase transitions to GitHub as commit statuses and
+// pull request comments.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// Commit status states
+const (
+	StatePending = "pending"
+	StateSuccess = "success"
+	StateFailure = "failure"
+)
+
+// maxDescriptionLength is the longest commit status description GitHub accepts
+const maxDescriptionLength = 140
+
+// Notification is one phase transition of an environment
+type Notification struct {
+	// Owner and Repo name the GitHub repository
+	Owner string
+	Repo  string
+	// Commit receives a commit status when set
+	Commit string
+	// Context distinguishes the status from other checks on the commit
+	Context     string
+	State       string
+	Description string
+	TargetURL   string
+	// PullRequest receives Comment when both are set. CommentID is the comment posted for
+	// a previous transition, updated in place.
+	PullRequest int
+	CommentID   int64
+	Comment     string
+}
+
+// Notifier delivers notifications with the credentials of a GitHub App installation.
+// It returns the ID of the pull request comment, if any.
+type Notifier interface {
+	Notify(ctx context.Context, installationID string, n Notification) (int64, error)
+}
+
+var _ Notifier = &GitHubNotifier{}
+
+// GitHubNotifier calls the GitHub REST API with installation tokens issued by the web API
+type GitHubNotifier struct {
+	WebAPIURL      string
+	GitHubAPIURL   string
+	HTTPClient     *http.Client
+	ServiceAccount string // Path to SA token
+}
+
+// NewGitHubNotifier creates a notifier for api.github.com
+func NewGitHubNotifier(webAPIURL string) *GitHubNotifier {
+	return &GitHubNotifier{
+		WebAPIURL:      webAPIURL,
+		GitHubAPIURL:   "https://api.github.com",
+		HTTPClient:     &http.Client{Timeout: 30 * time.Second},
+		ServiceAccount: "/var/run/secrets/kubernetes.io/serviceaccount/token",
+	}
+}
+
+// Notify posts the commit status and creates or updates the pull request comment
+func (gn *GitHubNotifier) Notify(ctx context.Context, installationID string, n Notification) (int64, error) {
+	token, err := gn.installationToken(ctx, installationID)
+	if err != nil {
+		return n.CommentID, err
+	}
+
+	if n.Commit != "" {
+		status := struct {
+			State       string `json:"state"`
+			Context     string `json:"context"`
+			Description string `json:"description"`
+			TargetURL   string `json:"target_url,omitempty"`
+		}{n.State, n.Context, truncate(n.Description, maxDescriptionLength), n.TargetURL}
+		path := fmt.Sprintf("/repos/%s/%s/statuses/%s", n.Owner, n.Repo, n.Commit)
+		if _, err := gn.call(ctx, token, http.MethodPost, path, status, nil); err != nil {
+			return n.CommentID, fmt.Errorf("failed to post commit status: %w", err)
+		}
+	}
+
+	if n.PullRequest == 0 || n.Comment == "" {
+		return n.CommentID, nil
+	}
+	var comment struct {
+		ID int64 `json:"id"`
+	}
+	body := map[string]string{"body": n.Comment}
+	if n.CommentID != 0 {
+		path := fmt.Sprintf("/repos/%s/%s/issues/comments/%d", n.Owner, n.Repo, n.CommentID)
+		status, err := gn.call(ctx, token, http.MethodPatch, path, body, &comment)
+		if err == nil {
+			return comment.ID, nil
+		}
+		if status != http.StatusNotFound {
+			return n.CommentID, fmt.Errorf("failed to update pull request comment: %w", err)
+		}
+		// The comment was deleted; post a new one
+	}
+	path := fmt.Sprintf("/repos/%s/%s/issues/%d/comments", n.Owner, n.Repo, n.PullRequest)
+	if _, err := gn.call(ctx, token, http.MethodPost, path, body, &comment); err != nil {
+		return n.CommentID, fmt.Errorf("failed to post pull request comment: %w", err)
+	}
+	return comment.ID, nil
+}
+
+// installationToken fetches a GitHub App installation token from the web API, the endpoint
+// the build git credential helper uses
+func (gn *GitHubNotifier) installationToken(ctx context.Context, installationID string) (string, error) {
+	saToken, err := os.ReadFile(gn.ServiceAccount)
+	if err != nil {
+		return "", fmt.Errorf("failed to read SA token: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/api/git-token/%s", gn.WebAPIURL, installationID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(saToken)))
+
+	resp, err := gn.HTTPClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch installation token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return "", fmt.Errorf("unauthorized: web API rejected the ServiceAccount token for installation %s", installationID)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("git-token: unexpected status code: %d, body: %s", resp.StatusCode, string(body))
+	}
+	token := strings.TrimSpace(string(body))
+	if token == "" {
+		return "", fmt.Errorf("git-token returned an empty token")
+	}
+	return token, nil
+}
+
+// call sends a JSON request to the GitHub API and decodes the response into out.
+// It returns the response status code.
+func (gn *GitHubNotifier) call(ctx context.Context, token, method, path string, in, out any) (int, error) {
+	payload, err := json.Marshal(in)
+	if err != nil {
+		return 0, err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(gn.GitHubAPIURL, "/")+path, bytes.NewReader(payload))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := gn.HTTPClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return resp.StatusCode, fmt.Errorf("github: unexpected status code: %d, body: %s", resp.StatusCode, string(body))
+	}
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return resp.StatusCode, fmt.Errorf("failed to parse github response: %w", err)
+		}
+	}
+	return resp.StatusCode, nil
+}
+
+// ParseRepository extracts the owner and name of a GitHub repository from its clone URL
+// (https://github.com/owner/repo.git, git@github.com:owner/repo.git). ok is false for other hosts.
+func ParseRepository(repositoryURL string) (owner, repo string, ok bool) {
+	rest, found := strings.CutPrefix(repositoryURL, "git@github.com:")
+	if !found {
+		for _, prefix := range []string{"https://github.com/", "http://github.com/", "ssh://git@github.com/"} {
+			if rest, found = strings.CutPrefix(repositoryURL, prefix); found {
+				break
+			}
+		}
+	}
+	if !found {
+		return "", "", false
+	}
+	parts := strings.Split(strings.TrimSuffix(strings.TrimSuffix(rest, "/"), ".git"), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-3]) + "..."
+}
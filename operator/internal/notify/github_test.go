@@ -0,0 +1,125 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestNotifier(t *testing.T, handler http.HandlerFunc) *GitHubNotifier {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("sa-token\n"), 0600))
+
+	notifier := NewGitHubNotifier(server.URL)
+	notifier.GitHubAPIURL = server.URL
+	notifier.ServiceAccount = tokenFile
+	return notifier
+}
+
+func TestGitHubNotifier_Notify(t *testing.T) {
+	var requests []string
+	notifier := newTestNotifier(t, func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		if r.URL.Path == "/api/git-token/12345" {
+			assert.Equal(t, "Bearer sa-token", r.Header.Get("Authorization"))
+			_, _ = w.Write([]byte("ghs_installation\n"))
+			return
+		}
+		assert.Equal(t, "Bearer ghs_installation", r.Header.Get("Authorization"))
+		var body map[string]string
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		switch r.Method + " " + r.URL.Path {
+		case "POST /repos/acme/shop/statuses/abc123":
+			assert.Equal(t, "success", body["state"])
+			assert.Equal(t, "catalyst/pr-7", body["context"])
+			assert.Equal(t, "https://pr-7.preview.example.com", body["target_url"])
+			assert.Len(t, body["description"], maxDescriptionLength)
+			w.WriteHeader(http.StatusCreated)
+		case "PATCH /repos/acme/shop/issues/comments/41":
+			w.WriteHeader(http.StatusNotFound)
+		case "POST /repos/acme/shop/issues/7/comments":
+			assert.Contains(t, body["body"], "Ready")
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"id":42}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	})
+
+	commentID, err := notifier.Notify(context.Background(), "12345", Notification{
+		Owner:       "acme",
+		Repo:        "shop",
+		Commit:      "abc123",
+		Context:     "catalyst/pr-7",
+		State:       StateSuccess,
+		Description: strings.Repeat("x", 200),
+		TargetURL:   "https://pr-7.preview.example.com",
+		PullRequest: 7,
+		CommentID:   41,
+		Comment:     "Ready",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), commentID, "a deleted comment is posted again")
+	assert.Equal(t, []string{
+		"GET /api/git-token/12345",
+		"POST /repos/acme/shop/statuses/abc123",
+		"PATCH /repos/acme/shop/issues/comments/41",
+		"POST /repos/acme/shop/issues/7/comments",
+	}, requests)
+}
+
+func TestGitHubNotifier_TokenDenied(t *testing.T) {
+	notifier := newTestNotifier(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	})
+
+	_, err := notifier.Notify(context.Background(), "12345", Notification{Owner: "acme", Repo: "shop", Commit: "abc123"})
+	assert.ErrorContains(t, err, "unauthorized")
+}
+
+func TestParseRepository(t *testing.T) {
+	tests := []struct {
+		url         string
+		owner, repo string
+		ok          bool
+	}{
+		{"https://github.com/acme/shop", "acme", "shop", true},
+		{"https://github.com/acme/shop.git", "acme", "shop", true},
+		{"git@github.com:acme/shop.git", "acme", "shop", true},
+		{"ssh://git@github.com/acme/shop/", "acme", "shop", true},
+		{"https://gitlab.com/acme/shop", "", "", false},
+		{"https://github.com/acme", "", "", false},
+	}
+	for _, tt := range tests {
+		owner, repo, ok := ParseRepository(tt.url)
+		assert.Equal(t, tt.ok, ok, tt.url)
+		assert.Equal(t, tt.owner, owner, tt.url)
+		assert.Equal(t, tt.repo, repo, tt.url)
+	}
+}
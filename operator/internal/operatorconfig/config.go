@@ -0,0 +1,642 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package operatorconfig holds the operator settings of its configuration file (--config), a
+// ComponentConfig-style YAML document usually mounted from a ConfigMap. The file is
+// validated at startup and reloaded when it changes; controllers read Current() on every
+// reconcile, so reloaded settings apply without a restart. Settings the file leaves unset
+// fall back to the environment variables the operator was configured with before.
+package operatorconfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// APIVersion and Kind identify the configuration file
+	APIVersion = "catalyst.dev/v1alpha1"
+	Kind       = "OperatorConfig"
+)
+
+// Config is the operator configuration file, e.g.
+//
+//	apiVersion: catalyst.dev/v1alpha1
+//	kind: OperatorConfig
+//	localPreviewRouting: true
+//	ingressPort: 8080
+//	registry:
+//	  endpoint: ghcr.io/acme
+//
+// Credentials (DNS_CLOUDFLARE_API_TOKEN, GIT_WEBHOOK_SECRET, AWS_*) and the operator's own
+// namespace (POD_NAMESPACE) stay environment variables, so the file holds no secrets.
+type Config struct {
+	// APIVersion is catalyst.dev/v1alpha1
+	APIVersion string `json:"apiVersion,omitempty"`
+	// Kind is OperatorConfig
+	Kind string `json:"kind,omitempty"`
+
+	// LocalPreviewRouting serves environments at http://<namespace>.localhost:<ingressPort>/
+	// instead of hosts of the preview domain with TLS (env LOCAL_PREVIEW_ROUTING=true)
+	LocalPreviewRouting bool `json:"localPreviewRouting,omitempty"`
+
+	// IngressPort is the host port of the ingress controller in local preview URLs
+	// (env INGRESS_PORT, default 8080)
+	IngressPort int32 `json:"ingressPort,omitempty"`
+
+	// EnablePATFallback lets the git credential helper of build and clone pods fall back to
+	// the personal access token mode of the Catalyst web API (env ENABLE_PAT_FALLBACK=true)
+	EnablePATFallback bool `json:"enablePatFallback,omitempty"`
+
+	// SeedSelfDeploy sets SEED_SELF_DEPLOY=true in development environments, so the seed
+	// script deploys Catalyst itself as a fixture project (env SEED_SELF_DEPLOY=true)
+	SeedSelfDeploy bool `json:"seedSelfDeploy,omitempty"`
+
+	// HelmDriver is the storage of Helm release records: secret (default), configmap or memory
+	// (env HELM_DRIVER)
+	HelmDriver string `json:"helmDriver,omitempty"`
+
+	// ImagePrePull pulls the images environments use onto every node ahead of their pods;
+	// unset disables it
+	ImagePrePull *ImagePrePull `json:"imagePrePull,omitempty"`
+
+	// CatalystWebURL is the URL of the Catalyst web API (env CATALYST_WEB_URL, default the
+	// in-cluster catalyst-web Service)
+	CatalystWebURL string `json:"catalystWebURL,omitempty"`
+
+	// IngressNamespace is the namespace of the ingress controller, allowed into environment
+	// namespaces (env INGRESS_NAMESPACE, default the operator namespace)
+	IngressNamespace string `json:"ingressNamespace,omitempty"`
+
+	// EnvironmentRevisions mirrors deployment records to EnvironmentRevisions
+	// (env ENVIRONMENT_REVISIONS=true)
+	EnvironmentRevisions bool `json:"environmentRevisions,omitempty"`
+
+	// OrphanSweep is "delete" to delete orphaned namespaces and claims instead of only
+	// marking them (env ORPHAN_SWEEP)
+	OrphanSweep string `json:"orphanSweep,omitempty"`
+
+	// Preview configures the hosts and TLS of preview URLs
+	Preview Preview `json:"preview,omitempty"`
+
+	// LocalPreviewTLS serves local preview routing over HTTPS
+	LocalPreviewTLS LocalPreviewTLS `json:"localPreviewTLS,omitempty"`
+
+	// Gateway is the Gateway API Gateway preview HTTPRoutes attach to
+	Gateway Gateway `json:"gateway,omitempty"`
+
+	// DNS publishes the records of preview hosts
+	DNS DNS `json:"dns,omitempty"`
+
+	// Registry is the registry builds are pushed to and pulled from
+	Registry Registry `json:"registry,omitempty"`
+
+	// URLProbe checks preview URLs before environments become Ready
+	URLProbe URLProbe `json:"urlProbe,omitempty"`
+
+	// Builds are the scheduling defaults of build pods
+	Builds Builds `json:"builds,omitempty"`
+
+	// GitOps is the engine of environments with deploymentMode gitops
+	GitOps GitOps `json:"gitops,omitempty"`
+
+	// Guardrails restrict what environment configs and manifests may deploy
+	Guardrails Guardrails `json:"guardrails,omitempty"`
+
+	// Lifetime caps the age of environments
+	Lifetime Lifetime `json:"lifetime,omitempty"`
+
+	// Images override the images of operator-managed containers
+	Images Images `json:"images,omitempty"`
+
+	// Secrets are the secrets backends Projects may select with spec.secrets.provider
+	Secrets Secrets `json:"secrets,omitempty"`
+}
+
+// ImagePrePull selects the images pulled onto every node, e.g.
+//
+//	imagePrePull:
+//	  images: [node:22-slim]
+//	  maxImages: 10
+type ImagePrePull struct {
+	// Images are always pulled, first
+	Images []string `json:"images,omitempty"`
+
+	// MaxImages is how many of the images environment pods use are pulled, the most used
+	// first (default 10)
+	MaxImages int `json:"maxImages,omitempty"`
+
+	// MaxBuiltImages is how many images built for environments are pulled, the most recently
+	// deployed first (default 10)
+	MaxBuiltImages int `json:"maxBuiltImages,omitempty"`
+}
+
+// Preview configures preview URLs
+type Preview struct {
+	// Domain is the base domain of preview hosts (env PREVIEW_DOMAIN)
+	Domain string `json:"domain,omitempty"`
+	// HostTemplate is the template of preview hosts, with the {{env}}, {{project}}, {{team}}
+	// and {{baseDomain}} placeholders (env PREVIEW_HOST_TEMPLATE)
+	HostTemplate string `json:"hostTemplate,omitempty"`
+	// Routing is ingress (default) or gateway (env PREVIEW_ROUTING)
+	Routing string `json:"routing,omitempty"`
+	// SharedHost routes requests to the environment whose namespace their X-Catalyst-Env
+	// header names (env SHARED_PREVIEW_HOST)
+	SharedHost string `json:"sharedHost,omitempty"`
+	// TLS is the shared wildcard certificate of the preview domain
+	TLS PreviewTLS `json:"tls,omitempty"`
+}
+
+// PreviewTLS is the shared wildcard certificate of the preview domain
+type PreviewTLS struct {
+	// Secret is the <namespace>/<name> of the kubernetes.io/tls Secret (env PREVIEW_TLS_SECRET)
+	Secret string `json:"secret,omitempty"`
+	// Mode is copy (default) or default-certificate (env PREVIEW_TLS_MODE)
+	Mode string `json:"mode,omitempty"`
+}
+
+// LocalPreviewTLS serves local preview routing over HTTPS with certificates of a local CA
+type LocalPreviewTLS struct {
+	// Enabled turns it on with localPreviewRouting (env LOCAL_PREVIEW_TLS=true)
+	Enabled bool `json:"enabled,omitempty"`
+	// Port is the HTTPS port of the ingress controller (env LOCAL_PREVIEW_TLS_PORT)
+	Port string `json:"port,omitempty"`
+	// CASecret is an existing CA Secret; empty self-signs one (env LOCAL_PREVIEW_TLS_CA_SECRET)
+	CASecret string `json:"caSecret,omitempty"`
+	// CertManagerNamespace is cert-manager's cluster resource namespace
+	// (env CERT_MANAGER_NAMESPACE)
+	CertManagerNamespace string `json:"certManagerNamespace,omitempty"`
+}
+
+// Gateway is the Gateway preview HTTPRoutes attach to
+type Gateway struct {
+	// Name of the Gateway (env GATEWAY_NAME)
+	Name string `json:"name,omitempty"`
+	// Namespace of the Gateway (env GATEWAY_NAMESPACE)
+	Namespace string `json:"namespace,omitempty"`
+	// Class has the operator manage the Gateway with this GatewayClass (env GATEWAY_CLASS)
+	Class string `json:"class,omitempty"`
+	// ClusterIssuer issues the certificates of the managed Gateway (env GATEWAY_CLUSTER_ISSUER)
+	ClusterIssuer string `json:"clusterIssuer,omitempty"`
+}
+
+// DNS publishes the records of preview hosts
+type DNS struct {
+	// Provider is external-dns or cloudflare (env DNS_PROVIDER)
+	Provider string `json:"provider,omitempty"`
+	// Target the records point to (env DNS_TARGET)
+	Target string `json:"target,omitempty"`
+	// TTL of the records in seconds (env DNS_TTL)
+	TTL int `json:"ttl,omitempty"`
+	// CloudflareZoneID is the zone of the records (env DNS_CLOUDFLARE_ZONE_ID)
+	CloudflareZoneID string `json:"cloudflareZoneID,omitempty"`
+}
+
+// Registry is the registry builds are pushed to and pulled from
+type Registry struct {
+	// Endpoint is host[:port][/prefix] (env REGISTRY_ENDPOINT)
+	Endpoint string `json:"endpoint,omitempty"`
+	// CredentialsSecret is the dockerconfigjson Secret of the registry
+	// (env REGISTRY_CREDENTIALS_SECRET)
+	CredentialsSecret string `json:"credentialsSecret,omitempty"`
+	// Insecure pushes over plain HTTP (env REGISTRY_INSECURE)
+	Insecure *bool `json:"insecure,omitempty"`
+	// CABundle are the PEM certificates of the CA signing the registry's TLS certificate
+	// (env REGISTRY_CA_BUNDLE)
+	CABundle string `json:"caBundle,omitempty"`
+	// PathTemplate is the repository path (env REGISTRY_PATH_TEMPLATE)
+	PathTemplate string `json:"pathTemplate,omitempty"`
+	// GC is enabled or dry-run (env REGISTRY_GC)
+	GC string `json:"gc,omitempty"`
+	// GCKeep is the number of recent image sets kept (env REGISTRY_GC_KEEP)
+	GCKeep int `json:"gcKeep,omitempty"`
+	// Managed runs the in-cluster registry (env REGISTRY_MANAGED)
+	Managed *bool `json:"managed,omitempty"`
+	// Image of the managed registry (env REGISTRY_IMAGE)
+	Image string `json:"image,omitempty"`
+	// StorageSize of the managed registry (env REGISTRY_STORAGE_SIZE)
+	StorageSize string `json:"storageSize,omitempty"`
+	// StorageClass of the managed registry (env REGISTRY_STORAGE_CLASS)
+	StorageClass string `json:"storageClass,omitempty"`
+	// Mirrors are upstream registries the managed registry caches (env REGISTRY_MIRRORS)
+	Mirrors []string `json:"mirrors,omitempty"`
+}
+
+// URLProbe checks preview URLs before environments become Ready
+type URLProbe struct {
+	// Enabled turns the probe on (env URL_PROBE=true)
+	Enabled bool `json:"enabled,omitempty"`
+	// Interval between probes of a Ready environment (env URL_PROBE_INTERVAL)
+	Interval metav1.Duration `json:"interval,omitempty"`
+	// Timeout of a probe (env URL_PROBE_TIMEOUT)
+	Timeout metav1.Duration `json:"timeout,omitempty"`
+	// InsecureSkipVerify accepts any certificate (env URL_PROBE_INSECURE_SKIP_VERIFY=true)
+	InsecureSkipVerify bool `json:"insecureSkipVerify,omitempty"`
+}
+
+// Builds are the scheduling defaults of build pods
+type Builds struct {
+	// NodeSelector of build pods (env BUILD_NODE_SELECTOR, JSON)
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+	// Tolerations of build pods (env BUILD_TOLERATIONS, JSON)
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+	// PriorityClassName of build pods (env BUILD_PRIORITY_CLASS_NAME)
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+	// BuildArgSecrets are the Secrets of the environment namespace build args may read with
+	// secretKeyRef (default: catalyst-secrets). Build arg values end up in the image history.
+	BuildArgSecrets []string `json:"buildArgSecrets,omitempty"`
+}
+
+// GitOps is the engine of environments with deploymentMode gitops
+type GitOps struct {
+	// Engine is argocd (default) or flux (env GITOPS_ENGINE)
+	Engine string `json:"engine,omitempty"`
+	// ArgoCDNamespace is the namespace Argo CD watches (env ARGOCD_NAMESPACE)
+	ArgoCDNamespace string `json:"argocdNamespace,omitempty"`
+	// ArgoCDProject the Applications belong to (env ARGOCD_PROJECT)
+	ArgoCDProject string `json:"argocdProject,omitempty"`
+}
+
+// Guardrails restrict what environment configs and manifests may deploy
+type Guardrails struct {
+	// ForbidPrivileged rejects privileged containers (env GUARDRAIL_FORBID_PRIVILEGED=true)
+	ForbidPrivileged bool `json:"forbidPrivileged,omitempty"`
+	// ForbidHostPath rejects hostPath volumes (env GUARDRAIL_FORBID_HOSTPATH=true)
+	ForbidHostPath bool `json:"forbidHostPath,omitempty"`
+	// ForbidLoadBalancer rejects LoadBalancer Services (env GUARDRAIL_FORBID_LOADBALANCER=true)
+	ForbidLoadBalancer bool `json:"forbidLoadBalancer,omitempty"`
+	// AllowedRegistries restricts images to these registries (env GUARDRAIL_ALLOWED_REGISTRIES)
+	AllowedRegistries []string `json:"allowedRegistries,omitempty"`
+}
+
+// Lifetime caps the age of environments
+type Lifetime struct {
+	// MaxLifetime from creation; 0 disables the cap (env ENVIRONMENT_MAX_LIFETIME)
+	MaxLifetime metav1.Duration `json:"maxLifetime,omitempty"`
+	// Types are the environment types the cap applies to (env ENVIRONMENT_MAX_LIFETIME_TYPES,
+	// default development)
+	Types []string `json:"types,omitempty"`
+}
+
+// Images override the images of operator-managed containers
+type Images struct {
+	// GitClone clones sources (env GIT_CLONE_IMAGE)
+	GitClone string `json:"gitClone,omitempty"`
+	// FileSync and FileSyncTunnel run the file sync sidecars (env FILE_SYNC_IMAGE,
+	// FILE_SYNC_TUNNEL_IMAGE)
+	FileSync       string `json:"fileSync,omitempty"`
+	FileSyncTunnel string `json:"fileSyncTunnel,omitempty"`
+	// Nix and Skopeo run nix builds (env NIX_IMAGE, SKOPEO_IMAGE)
+	Nix    string `json:"nix,omitempty"`
+	Skopeo string `json:"skopeo,omitempty"`
+	// Crane assembles multi-platform images (env CRANE_IMAGE)
+	Crane string `json:"crane,omitempty"`
+}
+
+// Secrets are the secrets backends Projects may select. A Project only picks the backend and
+// the path of its secrets, which is always below its team, e.g.
+//
+//	secrets:
+//	  vault:
+//	    address: https://vault.acme.internal:8200
+//	    role: catalyst-operator
+//	    serviceAccount: catalyst-system/catalyst-operator
+type Secrets struct {
+	// Vault is the HashiCorp Vault server; unset rejects provider vault
+	Vault *VaultSecrets `json:"vault,omitempty"`
+	// AWSSecretsManager is the AWS Secrets Manager region; unset rejects provider
+	// aws-secrets-manager
+	AWSSecretsManager *AWSSecretsManagerSecrets `json:"awsSecretsManager,omitempty"`
+}
+
+// VaultSecrets configures the Vault KV v2 engine environment secrets are read from
+type VaultSecrets struct {
+	// Address of the Vault server
+	Address string `json:"address"`
+	// Role of the Kubernetes auth method bound to ServiceAccount and Audience
+	Role string `json:"role"`
+	// AuthMount is the mount path of the Kubernetes auth method (default kubernetes)
+	AuthMount string `json:"authMount,omitempty"`
+	// Mount is the mount path of the KV v2 secrets engine (default secret)
+	Mount string `json:"mount,omitempty"`
+	// Namespace is the Vault Enterprise namespace
+	Namespace string `json:"namespace,omitempty"`
+	// ServiceAccount (<namespace>/<name>) a short-lived token is requested for on each login
+	ServiceAccount string `json:"serviceAccount"`
+	// Audience of the requested token (default vault)
+	Audience string `json:"audience,omitempty"`
+}
+
+// AWSSecretsManagerSecrets configures the AWS Secrets Manager environment secrets are read from
+type AWSSecretsManagerSecrets struct {
+	// Region of the secrets
+	Region string `json:"region"`
+	// RoleARN is assumed with the operator's web identity token (IRSA); defaults to the
+	// operator credentials (AWS_ROLE_ARN or static keys)
+	RoleARN string `json:"roleArn,omitempty"`
+}
+
+// Default returns the configuration without a file or environment variables
+func Default() *Config {
+	return &Config{APIVersion: APIVersion, Kind: Kind, IngressPort: 8080}
+}
+
+// FromEnv returns the default configuration with the legacy environment variables applied
+func FromEnv() (*Config, error) {
+	c := Default()
+	c.LocalPreviewRouting = os.Getenv("LOCAL_PREVIEW_ROUTING") == "true"
+	c.EnablePATFallback = os.Getenv("ENABLE_PAT_FALLBACK") == "true"
+	c.SeedSelfDeploy = os.Getenv("SEED_SELF_DEPLOY") == "true"
+	c.HelmDriver = os.Getenv("HELM_DRIVER")
+	if value := os.Getenv("INGRESS_PORT"); value != "" {
+		port, err := strconv.ParseInt(value, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid INGRESS_PORT %q", value)
+		}
+		c.IngressPort = int32(port)
+	}
+	c.CatalystWebURL = os.Getenv("CATALYST_WEB_URL")
+	c.IngressNamespace = os.Getenv("INGRESS_NAMESPACE")
+	c.EnvironmentRevisions = os.Getenv("ENVIRONMENT_REVISIONS") == "true"
+	c.OrphanSweep = os.Getenv("ORPHAN_SWEEP")
+
+	c.Preview = Preview{
+		Domain:       os.Getenv("PREVIEW_DOMAIN"),
+		HostTemplate: os.Getenv("PREVIEW_HOST_TEMPLATE"),
+		Routing:      os.Getenv("PREVIEW_ROUTING"),
+		SharedHost:   os.Getenv("SHARED_PREVIEW_HOST"),
+		TLS:          PreviewTLS{Secret: os.Getenv("PREVIEW_TLS_SECRET"), Mode: os.Getenv("PREVIEW_TLS_MODE")},
+	}
+	c.LocalPreviewTLS = LocalPreviewTLS{
+		Enabled:              os.Getenv("LOCAL_PREVIEW_TLS") == "true",
+		Port:                 os.Getenv("LOCAL_PREVIEW_TLS_PORT"),
+		CASecret:             os.Getenv("LOCAL_PREVIEW_TLS_CA_SECRET"),
+		CertManagerNamespace: os.Getenv("CERT_MANAGER_NAMESPACE"),
+	}
+	c.Gateway = Gateway{
+		Name:          os.Getenv("GATEWAY_NAME"),
+		Namespace:     os.Getenv("GATEWAY_NAMESPACE"),
+		Class:         os.Getenv("GATEWAY_CLASS"),
+		ClusterIssuer: os.Getenv("GATEWAY_CLUSTER_ISSUER"),
+	}
+	// Malformed numbers, durations and JSON are ignored, as before the configuration file
+	c.DNS = DNS{
+		Provider:         os.Getenv("DNS_PROVIDER"),
+		Target:           os.Getenv("DNS_TARGET"),
+		CloudflareZoneID: os.Getenv("DNS_CLOUDFLARE_ZONE_ID"),
+	}
+	c.DNS.TTL, _ = strconv.Atoi(os.Getenv("DNS_TTL"))
+	c.Registry = Registry{
+		Endpoint:          os.Getenv("REGISTRY_ENDPOINT"),
+		CredentialsSecret: os.Getenv("REGISTRY_CREDENTIALS_SECRET"),
+		Insecure:          envBool("REGISTRY_INSECURE"),
+		CABundle:          os.Getenv("REGISTRY_CA_BUNDLE"),
+		PathTemplate:      os.Getenv("REGISTRY_PATH_TEMPLATE"),
+		GC:                os.Getenv("REGISTRY_GC"),
+		Managed:           envBool("REGISTRY_MANAGED"),
+		Image:             os.Getenv("REGISTRY_IMAGE"),
+		StorageSize:       os.Getenv("REGISTRY_STORAGE_SIZE"),
+		StorageClass:      os.Getenv("REGISTRY_STORAGE_CLASS"),
+		Mirrors:           envList("REGISTRY_MIRRORS"),
+	}
+	if keep, err := strconv.Atoi(os.Getenv("REGISTRY_GC_KEEP")); err == nil && keep > 0 {
+		c.Registry.GCKeep = keep
+	}
+	c.URLProbe = URLProbe{
+		Enabled:            os.Getenv("URL_PROBE") == "true",
+		Interval:           envDuration("URL_PROBE_INTERVAL"),
+		Timeout:            envDuration("URL_PROBE_TIMEOUT"),
+		InsecureSkipVerify: os.Getenv("URL_PROBE_INSECURE_SKIP_VERIFY") == "true",
+	}
+	c.Builds = Builds{PriorityClassName: os.Getenv("BUILD_PRIORITY_CLASS_NAME")}
+	if value := os.Getenv("BUILD_NODE_SELECTOR"); value != "" {
+		_ = json.Unmarshal([]byte(value), &c.Builds.NodeSelector)
+	}
+	if value := os.Getenv("BUILD_TOLERATIONS"); value != "" {
+		_ = json.Unmarshal([]byte(value), &c.Builds.Tolerations)
+	}
+	c.GitOps = GitOps{
+		Engine:          os.Getenv("GITOPS_ENGINE"),
+		ArgoCDNamespace: os.Getenv("ARGOCD_NAMESPACE"),
+		ArgoCDProject:   os.Getenv("ARGOCD_PROJECT"),
+	}
+	c.Guardrails = Guardrails{
+		ForbidPrivileged:   os.Getenv("GUARDRAIL_FORBID_PRIVILEGED") == "true",
+		ForbidHostPath:     os.Getenv("GUARDRAIL_FORBID_HOSTPATH") == "true",
+		ForbidLoadBalancer: os.Getenv("GUARDRAIL_FORBID_LOADBALANCER") == "true",
+		AllowedRegistries:  envList("GUARDRAIL_ALLOWED_REGISTRIES"),
+	}
+	if value := os.Getenv("ENVIRONMENT_MAX_LIFETIME"); value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ENVIRONMENT_MAX_LIFETIME %q", value)
+		}
+		c.Lifetime.MaxLifetime = metav1.Duration{Duration: d}
+	}
+	c.Lifetime.Types = envList("ENVIRONMENT_MAX_LIFETIME_TYPES")
+	c.Images = Images{
+		GitClone:       os.Getenv("GIT_CLONE_IMAGE"),
+		FileSync:       os.Getenv("FILE_SYNC_IMAGE"),
+		FileSyncTunnel: os.Getenv("FILE_SYNC_TUNNEL_IMAGE"),
+		Nix:            os.Getenv("NIX_IMAGE"),
+		Skopeo:         os.Getenv("SKOPEO_IMAGE"),
+		Crane:          os.Getenv("CRANE_IMAGE"),
+	}
+	return c, nil
+}
+
+// envBool reads a boolean environment variable; nil when unset or malformed
+func envBool(name string) *bool {
+	v, err := strconv.ParseBool(os.Getenv(name))
+	if err != nil {
+		return nil
+	}
+	return &v
+}
+
+// envDuration reads a duration environment variable; zero when unset or malformed
+func envDuration(name string) metav1.Duration {
+	d, err := time.ParseDuration(os.Getenv(name))
+	if err != nil || d < 0 {
+		return metav1.Duration{}
+	}
+	return metav1.Duration{Duration: d}
+}
+
+// envList reads a comma-separated environment variable
+func envList(name string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(name), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// Parse reads a configuration file over the environment configuration (FromEnv) and
+// validates it. Unknown fields are rejected.
+func Parse(data []byte) (*Config, error) {
+	c, err := FromEnv()
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.UnmarshalStrict(data, c); err != nil {
+		return nil, fmt.Errorf("invalid operator configuration: %w", err)
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Load reads the configuration file at path, or only the environment when path is empty
+func Load(path string) (*Config, error) {
+	if path == "" {
+		c, err := FromEnv()
+		if err != nil {
+			return nil, err
+		}
+		return c, c.Validate()
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read operator configuration: %w", err)
+	}
+	return Parse(data)
+}
+
+// Validate checks the settings
+func (c *Config) Validate() error {
+	if c.APIVersion != APIVersion || c.Kind != Kind {
+		return fmt.Errorf("operator configuration must be apiVersion %s, kind %s (got %s, %s)", APIVersion, Kind, c.APIVersion, c.Kind)
+	}
+	if c.IngressPort < 1 || c.IngressPort > 65535 {
+		return fmt.Errorf("ingressPort %d is not a valid port", c.IngressPort)
+	}
+	switch c.HelmDriver {
+	case "", "secret", "configmap", "memory":
+	default:
+		return fmt.Errorf("helmDriver %q must be secret, configmap or memory", c.HelmDriver)
+	}
+	switch c.OrphanSweep {
+	case "", "mark", "delete":
+	default:
+		return fmt.Errorf("orphanSweep %q must be mark or delete", c.OrphanSweep)
+	}
+	switch c.Preview.Routing {
+	case "", "ingress", "gateway":
+	default:
+		return fmt.Errorf("preview.routing %q must be ingress or gateway", c.Preview.Routing)
+	}
+	switch c.Preview.TLS.Mode {
+	case "", "copy", "default-certificate":
+	default:
+		return fmt.Errorf("preview.tls.mode %q must be copy or default-certificate", c.Preview.TLS.Mode)
+	}
+	if ref := c.Preview.TLS.Secret; ref != "" {
+		if namespace, name, _ := strings.Cut(ref, "/"); namespace == "" || name == "" {
+			return fmt.Errorf("preview.tls.secret must be <namespace>/<name>, got %q", ref)
+		}
+	}
+	switch c.GitOps.Engine {
+	case "", "argocd", "flux":
+	default:
+		return fmt.Errorf("gitops.engine %q must be argocd or flux", c.GitOps.Engine)
+	}
+	if c.Lifetime.MaxLifetime.Duration < 0 {
+		return fmt.Errorf("lifetime.maxLifetime must not be negative")
+	}
+	if v := c.Secrets.Vault; v != nil {
+		if v.Address == "" || v.Role == "" {
+			return fmt.Errorf("secrets.vault requires address and role")
+		}
+		if namespace, name, _ := strings.Cut(v.ServiceAccount, "/"); namespace == "" || name == "" {
+			return fmt.Errorf("secrets.vault.serviceAccount must be <namespace>/<name>, got %q", v.ServiceAccount)
+		}
+	}
+	if a := c.Secrets.AWSSecretsManager; a != nil && a.Region == "" {
+		return fmt.Errorf("secrets.awsSecretsManager requires region")
+	}
+	if p := c.ImagePrePull; p != nil {
+		if p.MaxImages < 0 || p.MaxBuiltImages < 0 {
+			return fmt.Errorf("imagePrePull maxImages and maxBuiltImages must not be negative")
+		}
+		for _, image := range p.Images {
+			if image == "" {
+				return fmt.Errorf("imagePrePull images must not be empty")
+			}
+		}
+	}
+	return nil
+}
+
+var (
+	current atomic.Pointer[Config]
+
+	subscribersMu sync.Mutex
+	subscribers   []chan struct{}
+)
+
+// Current returns the configuration in effect: the one last set, or the environment
+// configuration (the defaults when it is invalid)
+func Current() *Config {
+	if c := current.Load(); c != nil {
+		return c
+	}
+	c, err := Load("")
+	if err != nil {
+		return Default()
+	}
+	return c
+}
+
+// Set makes c the configuration in effect; nil restores the environment configuration
+func Set(c *Config) {
+	current.Store(c)
+}
+
+// Subscribe returns a channel that receives a value after the configuration file was
+// reloaded. Reloads the subscriber has not received yet coalesce into one.
+func Subscribe() <-chan struct{} {
+	ch := make(chan struct{}, 1)
+	subscribersMu.Lock()
+	defer subscribersMu.Unlock()
+	subscribers = append(subscribers, ch)
+	return ch
+}
+
+// notifyReload signals the subscribers of a reload
+func notifyReload() {
+	subscribersMu.Lock()
+	defer subscribersMu.Unlock()
+	for _, ch := range subscribers {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package operatorconfig
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromEnv(t *testing.T) {
+	c, err := FromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, Default(), c)
+
+	t.Setenv("LOCAL_PREVIEW_ROUTING", "true")
+	t.Setenv("INGRESS_PORT", "9080")
+	t.Setenv("ENABLE_PAT_FALLBACK", "true")
+	t.Setenv("HELM_DRIVER", "configmap")
+	c, err = FromEnv()
+	require.NoError(t, err)
+	assert.True(t, c.LocalPreviewRouting)
+	assert.Equal(t, int32(9080), c.IngressPort)
+	assert.True(t, c.EnablePATFallback)
+	assert.False(t, c.SeedSelfDeploy)
+	assert.Equal(t, "configmap", c.HelmDriver)
+
+	t.Setenv("REGISTRY_ENDPOINT", "ghcr.io/acme")
+	t.Setenv("REGISTRY_INSECURE", "false")
+	t.Setenv("REGISTRY_MIRRORS", "docker.io, ghcr.io")
+	t.Setenv("URL_PROBE_INTERVAL", "1m")
+	t.Setenv("URL_PROBE_TIMEOUT", "soon")
+	t.Setenv("BUILD_NODE_SELECTOR", `{"catalyst.dev/pool":"builds"}`)
+	t.Setenv("ENVIRONMENT_MAX_LIFETIME", "336h")
+	c, err = FromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, Registry{Endpoint: "ghcr.io/acme", Insecure: new(bool), Mirrors: []string{"docker.io", "ghcr.io"}}, c.Registry)
+	assert.Equal(t, time.Minute, c.URLProbe.Interval.Duration)
+	assert.Zero(t, c.URLProbe.Timeout.Duration, "malformed durations are ignored")
+	assert.Equal(t, map[string]string{"catalyst.dev/pool": "builds"}, c.Builds.NodeSelector)
+	assert.Equal(t, 336*time.Hour, c.Lifetime.MaxLifetime.Duration)
+
+	t.Setenv("ENVIRONMENT_MAX_LIFETIME", "two weeks")
+	_, err = FromEnv()
+	assert.Error(t, err)
+
+	t.Setenv("INGRESS_PORT", "http")
+	_, err = FromEnv()
+	assert.Error(t, err)
+}
+
+func TestParse(t *testing.T) {
+	t.Setenv("SEED_SELF_DEPLOY", "true")
+	t.Setenv("INGRESS_PORT", "9080")
+
+	// The file overrides the environment; what it leaves unset falls back to it
+	c, err := Parse([]byte(`apiVersion: catalyst.dev/v1alpha1
+kind: OperatorConfig
+localPreviewRouting: true
+ingressPort: 8443
+helmDriver: memory
+`))
+	require.NoError(t, err)
+	assert.Equal(t, &Config{APIVersion: APIVersion, Kind: Kind, LocalPreviewRouting: true, IngressPort: 8443, SeedSelfDeploy: true, HelmDriver: "memory"}, c)
+
+	// Sections are typed, durations are Go durations
+	c, err = Parse([]byte(`registry:
+  endpoint: harbor.acme.dev/previews
+  managed: false
+urlProbe:
+  enabled: true
+  interval: 30s
+preview:
+  domain: preview.acme.dev
+  tls:
+    secret: catalyst-system/wildcard
+`))
+	require.NoError(t, err)
+	assert.Equal(t, "harbor.acme.dev/previews", c.Registry.Endpoint)
+	assert.False(t, *c.Registry.Managed)
+	assert.Equal(t, 30*time.Second, c.URLProbe.Interval.Duration)
+	assert.Equal(t, "catalyst-system/wildcard", c.Preview.TLS.Secret)
+
+	// An empty imagePrePull enables pre-pulling with the defaults
+	c, err = Parse([]byte("imagePrePull: {}\n"))
+	require.NoError(t, err)
+	assert.Equal(t, &ImagePrePull{}, c.ImagePrePull)
+
+	for name, data := range map[string]string{
+		"unknown field": "kind: OperatorConfig\nlocalPreviewRoutng: true\n",
+		"wrong kind":    "kind: Config\n",
+		"port":          "ingressPort: 70000\n",
+		"helm driver":   "helmDriver: sql\n",
+		"max images":    "imagePrePull:\n  maxImages: -1\n",
+		"orphan sweep":  "orphanSweep: purge\n",
+		"routing":       "preview:\n  routing: istio\n",
+		"tls secret":    "preview:\n  tls:\n    secret: wildcard\n",
+		"gitops engine": "gitops:\n  engine: spinnaker\n",
+		"vault account": "secrets:\n  vault:\n    address: https://vault:8200\n    role: catalyst\n",
+		"aws region":    "secrets:\n  awsSecretsManager:\n    roleArn: arn:aws:iam::1:role/x\n",
+	} {
+		_, err := Parse([]byte(data))
+		assert.Error(t, err, name)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	c, err := Load("")
+	require.NoError(t, err)
+	assert.Equal(t, Default(), c)
+
+	_, err = Load(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.Error(t, err)
+
+	t.Setenv("HELM_DRIVER", "sql")
+	_, err = Load("")
+	assert.Error(t, err, "the environment is validated too")
+	assert.Equal(t, Default(), Current(), "an invalid environment falls back to the defaults")
+}
+
+func TestWatcherReload(t *testing.T) {
+	t.Cleanup(func() { current.Store(nil) })
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("ingressPort: 8081\n"), 0o600))
+	w := &Watcher{Path: path}
+	ctx := context.Background()
+
+	w.reload(ctx)
+	assert.Equal(t, int32(8081), Current().IngressPort)
+
+	require.NoError(t, os.WriteFile(path, []byte("ingressPort: 8082\nlocalPreviewRouting: true\n"), 0o600))
+	w.reload(ctx)
+	assert.Equal(t, int32(8082), Current().IngressPort)
+	assert.True(t, Current().LocalPreviewRouting)
+
+	// An invalid file keeps the last valid configuration
+	require.NoError(t, os.WriteFile(path, []byte("ingressPort: none\n"), 0o600))
+	w.reload(ctx)
+	assert.Equal(t, int32(8082), Current().IngressPort)
+}
+
+func TestSubscribe(t *testing.T) {
+	t.Cleanup(func() { current.Store(nil) })
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("ingressPort: 8081\n"), 0o600))
+	w := &Watcher{Path: path}
+	ctx := context.Background()
+	reloads := Subscribe()
+
+	w.reload(ctx)
+	assert.Empty(t, reloads, "loading the file at startup is not a reload")
+
+	// Reloads the subscriber has not received yet coalesce
+	for _, port := range []string{"8082", "8083"} {
+		require.NoError(t, os.WriteFile(path, []byte("ingressPort: "+port+"\n"), 0o600))
+		w.reload(ctx)
+	}
+	assert.Len(t, reloads, 1)
+	<-reloads
+
+	w.reload(ctx)
+	assert.Empty(t, reloads, "an unchanged file is not reloaded")
+}
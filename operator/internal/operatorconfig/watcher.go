@@ -0,0 +1,109 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package operatorconfig
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// defaultPollInterval backs up file events, which the symlink swap of ConfigMap volume
+// updates does not raise for the file itself
+const defaultPollInterval = 10 * time.Second
+
+// Watcher reloads the configuration file when it changes, sets it in effect and notifies the
+// subscribers (Subscribe). An invalid file is logged and ignored, keeping the last valid
+// configuration. It implements manager.Runnable.
+type Watcher struct {
+	// Path of the configuration file
+	Path string
+	// PollInterval re-reads the file in addition to file events (default 10s)
+	PollInterval time.Duration
+
+	last []byte
+}
+
+// Start watches the file until ctx is done
+func (w *Watcher) Start(ctx context.Context) error {
+	log := logf.FromContext(ctx).WithName("operator-config")
+	interval := w.PollInterval
+	if interval == 0 {
+		interval = defaultPollInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var events chan fsnotify.Event
+	if watcher, err := fsnotify.NewWatcher(); err != nil {
+		log.Error(err, "Cannot watch the operator configuration, polling it")
+	} else {
+		defer func() { _ = watcher.Close() }()
+		// The directory, since ConfigMap updates replace the file rather than write it
+		if err := watcher.Add(filepath.Dir(w.Path)); err != nil {
+			log.Error(err, "Cannot watch the operator configuration, polling it")
+		}
+		events = watcher.Events
+	}
+
+	w.reload(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-events:
+		case <-ticker.C:
+		}
+		w.reload(ctx)
+	}
+}
+
+// NeedLeaderElection reloads the configuration on every replica
+func (w *Watcher) NeedLeaderElection() bool {
+	return false
+}
+
+// reload sets the file in effect when its content changed and is valid
+func (w *Watcher) reload(ctx context.Context) {
+	log := logf.FromContext(ctx).WithName("operator-config")
+	data, err := os.ReadFile(w.Path)
+	if err != nil {
+		log.Error(err, "Cannot read the operator configuration, keeping the current one", "path", w.Path)
+		return
+	}
+	if w.last != nil && bytes.Equal(data, w.last) {
+		return
+	}
+	initial := w.last == nil
+	// Each content is reported once, valid or not
+	w.last = data
+	c, err := Parse(data)
+	if err != nil {
+		log.Error(err, "Invalid operator configuration, keeping the current one", "path", w.Path)
+		return
+	}
+	Set(c)
+	if !initial {
+		log.Info("Reloaded operator configuration", "path", w.Path)
+		notifyReload()
+	}
+}
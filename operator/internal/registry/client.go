@@ -0,0 +1,337 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+var _ Deleter = &Client{}
+
+// manifestMediaTypes are accepted when resolving a tag, so the digest of the stored
+// manifest (image index or single-platform manifest) is returned
+var manifestMediaTypes = []string{
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}
+
+// Client deletes images through the Docker Registry HTTP API v2 (Docker Registry, Harbor and
+// most self-hosted registries), or through the GitHub Packages API for ghcr.io, which does
+// not support manifest deletes
+type Client struct {
+	// Credentials by registry host. For ghcr.io the password is a GitHub token allowed
+	// to delete packages.
+	Credentials map[string]Credentials
+	// Insecure talks plain HTTP to the registries
+	Insecure     bool
+	GitHubAPIURL string
+	HTTPClient   *http.Client
+}
+
+// NewClient creates a client authenticating with the credentials by registry host
+func NewClient(credentials map[string]Credentials, insecure bool) *Client {
+	return &Client{
+		Credentials:  credentials,
+		Insecure:     insecure,
+		GitHubAPIURL: "https://api.github.com",
+		HTTPClient:   &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// TrustCABundle verifies the TLS certificates of the registries against the PEM certificates
+// of bundle, in addition to the system roots
+func (c *Client) TrustCABundle(bundle []byte) error {
+	roots, err := x509.SystemCertPool()
+	if err != nil {
+		roots = x509.NewCertPool()
+	}
+	if !roots.AppendCertsFromPEM(bundle) {
+		return errors.New("no PEM certificates found")
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{RootCAs: roots, MinVersion: tls.VersionTLS12}
+	c.HTTPClient.Transport = transport
+	return nil
+}
+
+// Delete removes the image's manifest, with every tag pointing at it
+func (c *Client) Delete(ctx context.Context, image Image) error {
+	if image.Host == "ghcr.io" {
+		return c.deletePackageVersion(ctx, image)
+	}
+	return c.deleteManifest(ctx, image)
+}
+
+// deleteManifest deletes the manifest by digest, resolving the tag first if the digest is unknown
+func (c *Client) deleteManifest(ctx context.Context, image Image) error {
+	scheme := "https"
+	if c.Insecure {
+		scheme = "http"
+	}
+	manifests := fmt.Sprintf("%s://%s/v2/%s/manifests/", scheme, image.Host, image.Repository)
+
+	digest := image.Digest
+	if digest == "" {
+		resp, err := c.do(ctx, image, http.MethodHead, manifests+image.Tag)
+		if err != nil {
+			return err
+		}
+		_ = resp.Body.Close()
+		switch resp.StatusCode {
+		case http.StatusOK:
+		case http.StatusNotFound:
+			return nil
+		default:
+			return fmt.Errorf("failed to resolve %s: %s", image, resp.Status)
+		}
+		if digest = resp.Header.Get("Docker-Content-Digest"); digest == "" {
+			return fmt.Errorf("registry %s returned no digest for %s", image.Host, image)
+		}
+	}
+
+	resp, err := c.do(ctx, image, http.MethodDelete, manifests+digest)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusAccepted, http.StatusNotFound:
+		return nil
+	case http.StatusMethodNotAllowed:
+		// Docker Registry refuses deletes unless REGISTRY_STORAGE_DELETE_ENABLED=true
+		return fmt.Errorf("registry %s does not allow deleting manifests", image.Host)
+	default:
+		return fmt.Errorf("failed to delete %s: %s", image, resp.Status)
+	}
+}
+
+// do sends a registry request, answering a Basic or Bearer authentication challenge with the
+// credentials of the registry
+func (c *Client) do(ctx context.Context, image Image, method, target string) (*http.Response, error) {
+	send := func(authorization string) (*http.Response, error) {
+		req, err := http.NewRequestWithContext(ctx, method, target, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", strings.Join(manifestMediaTypes, ", "))
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		return c.HTTPClient.Do(req)
+	}
+
+	resp, err := send("")
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+	authorization, err := c.authorize(ctx, image, resp.Header.Get("WWW-Authenticate"))
+	if err != nil {
+		return nil, err
+	}
+	return send(authorization)
+}
+
+// authorize returns the Authorization header answering a registry's challenge
+func (c *Client) authorize(ctx context.Context, image Image, challenge string) (string, error) {
+	creds, ok := c.Credentials[image.Host]
+	scheme, params := parseChallenge(challenge)
+	switch strings.ToLower(scheme) {
+	case "basic":
+		if !ok {
+			return "", fmt.Errorf("registry %s requires credentials", image.Host)
+		}
+		return "Basic " + base64.StdEncoding.EncodeToString([]byte(creds.Username+":"+creds.Password)), nil
+	case "bearer":
+		realm, err := url.Parse(params["realm"])
+		if err != nil || params["realm"] == "" {
+			return "", fmt.Errorf("registry %s sent an invalid token realm %q", image.Host, params["realm"])
+		}
+		scope := params["scope"]
+		if scope == "" {
+			scope = fmt.Sprintf("repository:%s:pull,delete", image.Repository)
+		}
+		query := realm.Query()
+		query.Set("scope", scope)
+		if service := params["service"]; service != "" {
+			query.Set("service", service)
+		}
+		realm.RawQuery = query.Encode()
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm.String(), nil)
+		if err != nil {
+			return "", err
+		}
+		if ok {
+			req.SetBasicAuth(creds.Username, creds.Password)
+		}
+		resp, err := c.HTTPClient.Do(req)
+		if err != nil {
+			return "", err
+		}
+		defer func() { _ = resp.Body.Close() }()
+		if resp.StatusCode != http.StatusOK {
+			return "", fmt.Errorf("registry %s token request failed: %s", image.Host, resp.Status)
+		}
+		var token struct {
+			Token       string `json:"token"`
+			AccessToken string `json:"access_token"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+			return "", fmt.Errorf("failed to decode registry token: %w", err)
+		}
+		if token.Token == "" {
+			token.Token = token.AccessToken
+		}
+		return "Bearer " + token.Token, nil
+	default:
+		return "", fmt.Errorf("registry %s sent an unsupported authentication challenge %q", image.Host, challenge)
+	}
+}
+
+// parseChallenge splits a WWW-Authenticate header into its scheme and parameters, e.g.
+// Bearer realm="https://auth.example.com/token",service="registry",scope="repository:a:pull,push"
+func parseChallenge(header string) (string, map[string]string) {
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(header), " ")
+	params := map[string]string{}
+	for {
+		rest = strings.TrimLeft(rest, ", ")
+		key, value, ok := strings.Cut(rest, "=")
+		if !ok {
+			return scheme, params
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		if strings.HasPrefix(value, `"`) {
+			// Quoted values may contain commas
+			end := strings.Index(value[1:], `"`)
+			if end < 0 {
+				params[key] = value[1:]
+				return scheme, params
+			}
+			params[key], rest = value[1:end+1], value[end+2:]
+		} else {
+			params[key], rest, _ = strings.Cut(value, ",")
+		}
+	}
+}
+
+// packageVersion is a container package version of the GitHub Packages API
+type packageVersion struct {
+	ID       int64  `json:"id"`
+	Name     string `json:"name"` // The manifest digest
+	Metadata struct {
+		Container struct {
+			Tags []string `json:"tags"`
+		} `json:"container"`
+	} `json:"metadata"`
+}
+
+// deletePackageVersion deletes the package version of a ghcr.io image, found by digest or tag.
+// Packages of organizations and users are both looked up, since the image path does not tell them apart.
+func (c *Client) deletePackageVersion(ctx context.Context, image Image) error {
+	owner, name, ok := strings.Cut(image.Repository, "/")
+	if !ok {
+		return fmt.Errorf("image %s has no package owner", image)
+	}
+	for _, kind := range []string{"orgs", "users"} {
+		versions := fmt.Sprintf("%s/%s/%s/packages/container/%s/versions",
+			strings.TrimSuffix(c.GitHubAPIURL, "/"), kind, url.PathEscape(owner), url.PathEscape(name))
+		version, found, err := c.findPackageVersion(ctx, versions, image)
+		if err != nil {
+			return err
+		}
+		if !found {
+			continue
+		}
+		if version == nil {
+			return nil
+		}
+		resp, err := c.github(ctx, http.MethodDelete, fmt.Sprintf("%s/%d", versions, version.ID), image)
+		if err != nil {
+			return err
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusNotFound {
+			return fmt.Errorf("failed to delete %s: %s", image, resp.Status)
+		}
+		return nil
+	}
+	return nil
+}
+
+// findPackageVersion pages through the versions of a package for the image. It returns
+// whether the package exists, and the version (nil if none matches).
+func (c *Client) findPackageVersion(ctx context.Context, versions string, image Image) (*packageVersion, bool, error) {
+	for page := 1; ; page++ {
+		resp, err := c.github(ctx, http.MethodGet, fmt.Sprintf("%s?per_page=100&page=%d", versions, page), image)
+		if err != nil {
+			return nil, false, err
+		}
+		if resp.StatusCode == http.StatusNotFound {
+			_ = resp.Body.Close()
+			return nil, false, nil
+		}
+		if resp.StatusCode != http.StatusOK {
+			_ = resp.Body.Close()
+			return nil, false, fmt.Errorf("failed to list package versions of %s: %s", image, resp.Status)
+		}
+		var list []packageVersion
+		err = json.NewDecoder(resp.Body).Decode(&list)
+		_ = resp.Body.Close()
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to decode package versions: %w", err)
+		}
+		if len(list) == 0 {
+			return nil, true, nil
+		}
+		for i := range list {
+			if (image.Digest != "" && list[i].Name == image.Digest) ||
+				(image.Digest == "" && slices.Contains(list[i].Metadata.Container.Tags, image.Tag)) {
+				return &list[i], true, nil
+			}
+		}
+	}
+}
+
+// github sends a GitHub API request authenticated with the ghcr.io token
+func (c *Client) github(ctx context.Context, method, target string, image Image) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if creds, ok := c.Credentials[image.Host]; ok {
+		req.Header.Set("Authorization", "Bearer "+creds.Password)
+	}
+	return c.HTTPClient.Do(req)
+}
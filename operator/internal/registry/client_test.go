@@ -0,0 +1,159 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseImage(t *testing.T) {
+	image, err := ParseImage("registry.local:5000/acme/web-pr-1:abc123@sha256:feed")
+	require.NoError(t, err)
+	assert.Equal(t, Image{Host: "registry.local:5000", Repository: "acme/web-pr-1", Tag: "abc123", Digest: "sha256:feed"}, image)
+	assert.Equal(t, "registry.local:5000/acme/web-pr-1:abc123@sha256:feed", image.String())
+
+	_, err = ParseImage("registry.local:5000/acme/web")
+	assert.Error(t, err)
+	_, err = ParseImage("web:latest")
+	assert.Error(t, err)
+}
+
+func TestCredentialsFromDockerConfig(t *testing.T) {
+	creds, err := CredentialsFromDockerConfig([]byte(`{"auths":{
+		"https://harbor.example.com/v2/":{"auth":"cm9ib3Q6czNjcmV0"},
+		"ghcr.io":{"username":"bot","password":"ghp_token"}}}`))
+	require.NoError(t, err)
+	assert.Equal(t, Credentials{Username: "robot", Password: "s3cret"}, creds["harbor.example.com"])
+	assert.Equal(t, "ghp_token", creds["ghcr.io"].Password)
+}
+
+func TestClientDeleteManifest(t *testing.T) {
+	var deleted []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		user, pass, _ := r.BasicAuth()
+		if user != "robot" || pass != "s3cret" || r.URL.Query().Get("scope") != "repository:acme/web:delete" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"token": "t0ken"})
+	})
+	var server *httptest.Server
+	mux.HandleFunc("/v2/acme/web/manifests/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer t0ken" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="`+server.URL+`/token",service="registry",scope="repository:acme/web:delete"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		reference := strings.TrimPrefix(r.URL.Path, "/v2/acme/web/manifests/")
+		switch {
+		case r.Method == http.MethodHead && reference == "abc123":
+			assert.Contains(t, r.Header.Get("Accept"), "application/vnd.oci.image.index.v1+json")
+			w.Header().Set("Docker-Content-Digest", "sha256:feed")
+		case r.Method == http.MethodDelete && strings.HasPrefix(reference, "sha256:"):
+			deleted = append(deleted, reference)
+			w.WriteHeader(http.StatusAccepted)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	server = httptest.NewServer(mux)
+	defer server.Close()
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	c := NewClient(map[string]Credentials{host: {Username: "robot", Password: "s3cret"}}, true)
+	ctx := context.Background()
+
+	require.NoError(t, c.Delete(ctx, Image{Host: host, Repository: "acme/web", Tag: "abc123"}))
+	require.NoError(t, c.Delete(ctx, Image{Host: host, Repository: "acme/web", Tag: "def456", Digest: "sha256:beef"}))
+	require.NoError(t, c.Delete(ctx, Image{Host: host, Repository: "acme/web", Tag: "gone"}), "missing tags are not an error")
+	assert.Equal(t, []string{"sha256:feed", "sha256:beef"}, deleted)
+}
+
+func TestClientTrustCABundle(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "https://")
+	image := Image{Host: host, Repository: "acme/web", Tag: "abc123"}
+
+	c := NewClient(nil, false)
+	require.Error(t, c.Delete(context.Background(), image), "private CAs are not trusted by default")
+
+	bundle := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	require.NoError(t, c.TrustCABundle(bundle))
+	require.NoError(t, c.Delete(context.Background(), image))
+
+	assert.Error(t, c.TrustCABundle([]byte("not a certificate")))
+}
+
+func TestParseChallenge(t *testing.T) {
+	scheme, params := parseChallenge(`Bearer realm="https://auth.example.com/token",service="registry",scope="repository:a:pull,push"`)
+	assert.Equal(t, "Bearer", scheme)
+	assert.Equal(t, map[string]string{
+		"realm":   "https://auth.example.com/token",
+		"service": "registry",
+		"scope":   "repository:a:pull,push",
+	}, params)
+}
+
+func TestClientDeletePackageVersion(t *testing.T) {
+	var deleted string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer ghp_token", r.Header.Get("Authorization"))
+		switch {
+		case strings.HasPrefix(r.URL.EscapedPath(), "/orgs/"):
+			// acme is a user
+			w.WriteHeader(http.StatusNotFound)
+		case r.Method == http.MethodGet && r.URL.EscapedPath() == "/users/acme/packages/container/shop%2Fweb-pr-1/versions":
+			if r.URL.Query().Get("page") != "1" {
+				_, _ = w.Write([]byte(`[]`))
+				return
+			}
+			_, _ = w.Write([]byte(`[
+				{"id": 1, "name": "sha256:feed", "metadata": {"container": {"tags": ["abc123"]}}},
+				{"id": 2, "name": "sha256:beef", "metadata": {"container": {"tags": ["def456"]}}}]`))
+		case r.Method == http.MethodDelete:
+			deleted = r.URL.EscapedPath()
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	c := NewClient(map[string]Credentials{"ghcr.io": {Username: "bot", Password: "ghp_token"}}, false)
+	c.GitHubAPIURL = server.URL
+	ctx := context.Background()
+
+	require.NoError(t, c.Delete(ctx, Image{Host: "ghcr.io", Repository: "acme/shop/web-pr-1", Tag: "def456"}))
+	assert.Equal(t, "/users/acme/packages/container/shop%2Fweb-pr-1/versions/2", deleted)
+
+	deleted = ""
+	require.NoError(t, c.Delete(ctx, Image{Host: "ghcr.io", Repository: "acme/shop/web-pr-1", Tag: "gone"}))
+	assert.Empty(t, deleted)
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package registry deletes pushed images through the registry API, so the tags of stale
+// builds do not accumulate in the registry.
+package registry
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Image is an image pushed to a registry
+type Image struct {
+	// Host of the registry, e.g. "ghcr.io" or "registry.default.svc.cluster.local:5000"
+	Host string
+	// Repository path within the registry, e.g. "acme/shop/web-pr-1"
+	Repository string
+	Tag        string
+	// Digest of the manifest; empty resolves the tag
+	Digest string
+}
+
+// String returns the image reference
+func (i Image) String() string {
+	ref := i.Host + "/" + i.Repository
+	if i.Tag != "" {
+		ref += ":" + i.Tag
+	}
+	if i.Digest != "" {
+		ref += "@" + i.Digest
+	}
+	return ref
+}
+
+// ParseImage parses a "host/repository[:tag][@digest]" image reference
+func ParseImage(ref string) (Image, error) {
+	var image Image
+	if at := strings.LastIndex(ref, "@"); at >= 0 {
+		ref, image.Digest = ref[:at], ref[at+1:]
+	}
+	host, path, ok := strings.Cut(ref, "/")
+	if !ok || host == "" || path == "" {
+		return Image{}, fmt.Errorf("image %q has no registry host", ref)
+	}
+	image.Host = host
+	// A colon after the last slash separates the tag
+	if colon := strings.LastIndex(path, ":"); colon > strings.LastIndex(path, "/") {
+		path, image.Tag = path[:colon], path[colon+1:]
+	}
+	if image.Tag == "" && image.Digest == "" {
+		return Image{}, fmt.Errorf("image %q has neither a tag nor a digest", ref)
+	}
+	image.Repository = path
+	return image, nil
+}
+
+// Credentials authenticate against a registry
+type Credentials struct {
+	Username string
+	Password string
+}
+
+// CredentialsFromDockerConfig reads the credentials by registry host of a dockerconfigjson
+// Secret's .dockerconfigjson key
+func CredentialsFromDockerConfig(data []byte) (map[string]Credentials, error) {
+	var config struct {
+		Auths map[string]struct {
+			Username string `json:"username"`
+			Password string `json:"password"`
+			Auth     string `json:"auth"`
+		} `json:"auths"`
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse docker config: %w", err)
+	}
+	credentials := make(map[string]Credentials, len(config.Auths))
+	for server, auth := range config.Auths {
+		creds := Credentials{Username: auth.Username, Password: auth.Password}
+		if auth.Auth != "" {
+			decoded, err := base64.StdEncoding.DecodeString(auth.Auth)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode docker config auth of %s: %w", server, err)
+			}
+			creds.Username, creds.Password, _ = strings.Cut(string(decoded), ":")
+		}
+		// Servers may be given as URLs, e.g. https://index.docker.io/v1/
+		host := strings.TrimPrefix(strings.TrimPrefix(server, "https://"), "http://")
+		host, _, _ = strings.Cut(host, "/")
+		credentials[host] = creds
+	}
+	return credentials, nil
+}
+
+// Deleter deletes images from a registry
+type Deleter interface {
+	// Delete removes the image's manifest; a missing image is not an error
+	Delete(ctx context.Context, image Image) error
+}
@@ -0,0 +1,238 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// AWSSecretsManagerProvider reads environment secrets from AWS Secrets Manager. The secret
+// value must be a JSON object of key-value pairs.
+//
+// Credentials come from AWS_ACCESS_KEY_ID / AWS_SECRET_ACCESS_KEY (and AWS_SESSION_TOKEN),
+// or from a web identity token (IRSA: AWS_WEB_IDENTITY_TOKEN_FILE) exchanged for RoleARN,
+// defaulting to AWS_ROLE_ARN.
+type AWSSecretsManagerProvider struct {
+	Region  string
+	RoleARN string
+	// Endpoint and STSEndpoint override the regional endpoints
+	Endpoint    string
+	STSEndpoint string
+	HTTPClient  *http.Client
+	// now is the signing clock
+	now func() time.Time
+}
+
+// awsCredentials are the credentials a request is signed with
+type awsCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// NewAWSSecretsManagerProvider creates a provider for the Secrets Manager endpoint of region
+func NewAWSSecretsManagerProvider(region, roleARN string) *AWSSecretsManagerProvider {
+	return &AWSSecretsManagerProvider{
+		Region:     region,
+		RoleARN:    roleARN,
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+		now:        time.Now,
+	}
+}
+
+// FetchSecrets reads the current value of the secret named secretID
+func (ap *AWSSecretsManagerProvider) FetchSecrets(ctx context.Context, secretID string) (map[string]string, error) {
+	creds, err := ap.credentials(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := json.Marshal(map[string]string{"SecretId": secretID})
+	if err != nil {
+		return nil, err
+	}
+	endpoint := ap.Endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://secretsmanager.%s.amazonaws.com", ap.Region)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint+"/", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	signV4(req, body, creds, ap.Region, "secretsmanager", ap.clock())
+
+	resp, err := ap.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch secret: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, statusError("secrets manager", resp)
+	}
+	var result struct {
+		SecretString string `json:"SecretString"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(result.SecretString), &values); err != nil {
+		return nil, fmt.Errorf("secret %s is not a JSON object of key-value pairs", secretID)
+	}
+	return stringValues(values), nil
+}
+
+func (ap *AWSSecretsManagerProvider) clock() time.Time {
+	if ap.now == nil {
+		return time.Now()
+	}
+	return ap.now()
+}
+
+// credentials resolves static credentials, or assumes the role with the web identity token
+func (ap *AWSSecretsManagerProvider) credentials(ctx context.Context) (awsCredentials, error) {
+	if accessKey := os.Getenv("AWS_ACCESS_KEY_ID"); accessKey != "" {
+		return awsCredentials{
+			AccessKeyID:     accessKey,
+			SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		}, nil
+	}
+
+	tokenFile := os.Getenv("AWS_WEB_IDENTITY_TOKEN_FILE")
+	roleARN := ap.RoleARN
+	if roleARN == "" {
+		roleARN = os.Getenv("AWS_ROLE_ARN")
+	}
+	if tokenFile == "" || roleARN == "" {
+		return awsCredentials{}, fmt.Errorf("no AWS credentials: set AWS_ACCESS_KEY_ID or a web identity token and role")
+	}
+	token, err := os.ReadFile(tokenFile)
+	if err != nil {
+		return awsCredentials{}, fmt.Errorf("failed to read web identity token: %w", err)
+	}
+
+	query := url.Values{
+		"Action":           {"AssumeRoleWithWebIdentity"},
+		"Version":          {"2011-06-15"},
+		"RoleArn":          {roleARN},
+		"RoleSessionName":  {"catalyst-operator"},
+		"WebIdentityToken": {strings.TrimSpace(string(token))},
+	}
+	endpoint := ap.STSEndpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://sts.%s.amazonaws.com", ap.Region)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint+"/?"+query.Encode(), nil)
+	if err != nil {
+		return awsCredentials{}, fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := ap.HTTPClient.Do(req)
+	if err != nil {
+		return awsCredentials{}, fmt.Errorf("failed to assume role: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return awsCredentials{}, statusError("sts", resp)
+	}
+
+	var result struct {
+		Credentials struct {
+			AccessKeyID     string `xml:"AccessKeyId"`
+			SecretAccessKey string `xml:"SecretAccessKey"`
+			SessionToken    string `xml:"SessionToken"`
+		} `xml:"AssumeRoleWithWebIdentityResult>Credentials"`
+	}
+	if err := xml.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return awsCredentials{}, fmt.Errorf("failed to parse sts response: %w", err)
+	}
+	return awsCredentials(result.Credentials), nil
+}
+
+// signV4 signs a request with AWS Signature Version 4, covering every header set on it
+func signV4(req *http.Request, body []byte, creds awsCredentials, region, service string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+	if creds.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	payloadHash := sha256.Sum256(body)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		strings.ReplaceAll(req.URL.Query().Encode(), "+", "%20"),
+		canonicalHeaders.String(),
+		signedHeaders,
+		hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := []byte("AWS4" + creds.SecretAccessKey)
+	for _, part := range []string{date, region, service, "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		creds.AccessKeyID, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
@@ -0,0 +1,59 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// SecretsProvider fetches the secrets of one environment as key-value pairs.
+// The key identifies the environment's secrets in the backend: the environment ID for the
+// web API, the secret path for Vault and AWS Secrets Manager.
+type SecretsProvider interface {
+	FetchSecrets(ctx context.Context, key string) (map[string]string, error)
+}
+
+var (
+	_ SecretsProvider = &SecretsFetcher{}
+	_ SecretsProvider = &VaultProvider{}
+	_ SecretsProvider = &AWSSecretsManagerProvider{}
+)
+
+// stringValues converts a JSON object into secrets. String values are used as-is, other
+// values keep their JSON encoding.
+func stringValues(raw map[string]json.RawMessage) map[string]string {
+	values := make(map[string]string, len(raw))
+	for key, value := range raw {
+		var s string
+		if err := json.Unmarshal(value, &s); err == nil {
+			values[key] = s
+		} else {
+			values[key] = string(value)
+		}
+	}
+	return values
+}
+
+// statusError describes an unexpected HTTP response of a secrets backend
+func statusError(backend string, resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Errorf("%s: unexpected status code: %d, body: %s", backend, resp.StatusCode, string(body))
+}
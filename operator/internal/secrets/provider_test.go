@@ -0,0 +1,146 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeToken(t *testing.T, token string) string {
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte(token), 0600))
+	return tokenFile
+}
+
+func staticToken(token string) func(context.Context) (string, error) {
+	return func(context.Context) (string, error) { return token, nil }
+}
+
+func TestVaultProvider_FetchSecrets(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "team-a", r.Header.Get("X-Vault-Namespace"))
+		switch r.URL.Path {
+		case "/v1/auth/k8s/login":
+			var login map[string]string
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&login))
+			assert.Equal(t, map[string]string{"role": "catalyst", "jwt": "sa-token"}, login)
+			_, _ = w.Write([]byte(`{"auth":{"client_token":"hvs.token"}}`))
+		case "/v1/kv/data/catalyst/shop/pr-1":
+			assert.Equal(t, "hvs.token", r.Header.Get("X-Vault-Token"))
+			_, _ = w.Write([]byte(`{"data":{"data":{"API_KEY":"sk-1","PORT":8080},"metadata":{"version":3}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	provider := NewVaultProvider(server.URL+"/", "catalyst", staticToken("sa-token"))
+	provider.AuthMount = "k8s"
+	provider.Mount = "kv"
+	provider.Namespace = "team-a"
+
+	secrets, err := provider.FetchSecrets(context.Background(), "catalyst/shop/pr-1")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"API_KEY": "sk-1", "PORT": "8080"}, secrets)
+
+	_, err = provider.FetchSecrets(context.Background(), "catalyst/shop/missing")
+	assert.ErrorContains(t, err, "vault secret not found")
+}
+
+func TestVaultProvider_LoginDenied(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	provider := NewVaultProvider(server.URL, "catalyst", staticToken("sa-token"))
+
+	_, err := provider.FetchSecrets(context.Background(), "catalyst/shop/pr-1")
+	assert.ErrorContains(t, err, "unauthorized")
+}
+
+// Test vector "get-vanilla" from the AWS Signature Version 4 test suite
+func TestSignV4(t *testing.T) {
+	req, err := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	require.NoError(t, err)
+	creds := awsCredentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"}
+
+	signV4(req, nil, creds, "us-east-1", "service", time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+
+	assert.Equal(t, "20150830T123600Z", req.Header.Get("X-Amz-Date"))
+	assert.Equal(t, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, "+
+		"SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31",
+		req.Header.Get("Authorization"))
+}
+
+func TestAWSSecretsManagerProvider_FetchSecrets(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("Action") == "AssumeRoleWithWebIdentity" {
+			assert.Equal(t, "arn:aws:iam::123456789012:role/catalyst", r.URL.Query().Get("RoleArn"))
+			assert.Equal(t, "web-identity", r.URL.Query().Get("WebIdentityToken"))
+			_, _ = w.Write([]byte(`<AssumeRoleWithWebIdentityResponse><AssumeRoleWithWebIdentityResult><Credentials>
+<AccessKeyId>ASIAEXAMPLE</AccessKeyId><SecretAccessKey>secret</SecretAccessKey><SessionToken>session</SessionToken>
+</Credentials></AssumeRoleWithWebIdentityResult></AssumeRoleWithWebIdentityResponse>`))
+			return
+		}
+		assert.Equal(t, "secretsmanager.GetSecretValue", r.Header.Get("X-Amz-Target"))
+		assert.Equal(t, "session", r.Header.Get("X-Amz-Security-Token"))
+		assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=ASIAEXAMPLE/"))
+		body, _ := io.ReadAll(r.Body)
+		if string(body) == `{"SecretId":"not-json"}` {
+			_, _ = w.Write([]byte(`{"SecretString":"plain"}`))
+			return
+		}
+		assert.JSONEq(t, `{"SecretId":"catalyst/shop/pr-1"}`, string(body))
+		_, _ = w.Write([]byte(`{"Name":"catalyst/shop/pr-1","SecretString":"{\"API_KEY\":\"sk-1\",\"DEBUG\":true}"}`))
+	}))
+	defer server.Close()
+
+	t.Setenv("AWS_ACCESS_KEY_ID", "")
+	t.Setenv("AWS_WEB_IDENTITY_TOKEN_FILE", writeToken(t, "web-identity"))
+	provider := NewAWSSecretsManagerProvider("us-east-1", "arn:aws:iam::123456789012:role/catalyst")
+	provider.Endpoint = server.URL
+	provider.STSEndpoint = server.URL
+
+	secrets, err := provider.FetchSecrets(context.Background(), "catalyst/shop/pr-1")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"API_KEY": "sk-1", "DEBUG": "true"}, secrets)
+
+	_, err = provider.FetchSecrets(context.Background(), "not-json")
+	assert.ErrorContains(t, err, "not a JSON object")
+}
+
+func TestAWSSecretsManagerProvider_NoCredentials(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "")
+	t.Setenv("AWS_WEB_IDENTITY_TOKEN_FILE", "")
+	t.Setenv("AWS_ROLE_ARN", "")
+
+	_, err := NewAWSSecretsManagerProvider("us-east-1", "").FetchSecrets(context.Background(), "catalyst/shop/pr-1")
+	assert.ErrorContains(t, err, "no AWS credentials")
+}
@@ -0,0 +1,148 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// VaultProvider reads environment secrets from a HashiCorp Vault KV v2 engine, logging in
+// with a ServiceAccount token through the Kubernetes auth method
+type VaultProvider struct {
+	Address string
+	// Role is the Kubernetes auth role bound to the operator ServiceAccount
+	Role string
+	// AuthMount is the mount path of the Kubernetes auth method
+	AuthMount string
+	// Mount is the mount path of the KV v2 secrets engine
+	Mount string
+	// Namespace is the Vault Enterprise namespace, if any
+	Namespace  string
+	HTTPClient *http.Client
+	// Token returns the ServiceAccount token of each login, a short-lived token requested
+	// for the Vault audience rather than the operator's own API token
+	Token func(ctx context.Context) (string, error)
+}
+
+// NewVaultProvider creates a Vault provider with the default kubernetes and secret mounts
+func NewVaultProvider(address, role string, token func(ctx context.Context) (string, error)) *VaultProvider {
+	return &VaultProvider{
+		Address:    strings.TrimSuffix(address, "/"),
+		Role:       role,
+		AuthMount:  "kubernetes",
+		Mount:      "secret",
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+		Token:      token,
+	}
+}
+
+// FetchSecrets logs in and reads the latest version of the secret at path
+func (vp *VaultProvider) FetchSecrets(ctx context.Context, path string) (map[string]string, error) {
+	token, err := vp.login(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("%s/v1/%s/data/%s", vp.Address, vp.Mount, strings.TrimPrefix(path, "/"))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	vp.setHeaders(req)
+	req.Header.Set("X-Vault-Token", token)
+
+	resp, err := vp.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read vault secret: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("vault secret not found: %s", path)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, statusError("vault", resp)
+	}
+
+	var result struct {
+		Data struct {
+			Data map[string]json.RawMessage `json:"data"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to parse vault response: %w", err)
+	}
+	return stringValues(result.Data.Data), nil
+}
+
+// login exchanges the ServiceAccount token for a Vault token
+func (vp *VaultProvider) login(ctx context.Context) (string, error) {
+	jwt, err := vp.Token(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to request SA token: %w", err)
+	}
+	body, err := json.Marshal(map[string]string{"role": vp.Role, "jwt": jwt})
+	if err != nil {
+		return "", err
+	}
+
+	url := fmt.Sprintf("%s/v1/auth/%s/login", vp.Address, vp.AuthMount)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	vp.setHeaders(req)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := vp.HTTPClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to log in to vault: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusBadRequest || resp.StatusCode == http.StatusForbidden {
+		return "", fmt.Errorf("unauthorized: vault rejected the ServiceAccount token for role %s", vp.Role)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", statusError("vault login", resp)
+	}
+
+	var result struct {
+		Auth struct {
+			ClientToken string `json:"client_token"`
+		} `json:"auth"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to parse vault login response: %w", err)
+	}
+	if result.Auth.ClientToken == "" {
+		return "", fmt.Errorf("vault login returned no token")
+	}
+	return result.Auth.ClientToken, nil
+}
+
+func (vp *VaultProvider) setHeaders(req *http.Request) {
+	if vp.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", vp.Namespace)
+	}
+}
@@ -0,0 +1,144 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package sharding splits reconciliation across several operator deployments. Each
+// deployment is started with a shard index and the shard count and reconciles a disjoint
+// subset of Projects and their Environments. Every shard runs leader election on its own
+// Lease, so replicas of one shard fail over while different shards work in parallel.
+//
+// A namespace selector further restricts a deployment to the Projects of matching
+// namespaces, e.g. per-team operators selecting catalyst.dev/team=<team namespace>.
+package sharding
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strconv"
+
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// Label pins a Project to a shard. A numeric value selects the shard index (modulo the
+// shard count); any other value is hashed, so Projects sharing a key land on the same shard.
+// Projects without the label are assigned by hashing their namespace and name.
+const Label = "catalyst.dev/shard"
+
+// Shard identifies the subset of Projects this operator instance reconciles
+type Shard struct {
+	// Index is this instance's shard, in [0, Count)
+	Index int
+	// Count is the total number of shards; 1 disables sharding
+	Count int
+	// Namespaces restricts this instance to the Projects of namespaces whose labels match;
+	// nil matches every namespace
+	Namespaces labels.Selector
+}
+
+// New validates a shard index and count
+func New(index, count int) (*Shard, error) {
+	if count < 1 {
+		return nil, fmt.Errorf("shard count must be at least 1, got %d", count)
+	}
+	if index < 0 || index >= count {
+		return nil, fmt.Errorf("shard index must be in [0, %d), got %d", count, index)
+	}
+	return &Shard{Index: index, Count: count}, nil
+}
+
+// Enabled reports whether work is split across more than one shard
+func (s *Shard) Enabled() bool {
+	return s != nil && s.Count > 1
+}
+
+// SetNamespaceSelector restricts the shard to namespaces matching a label selector, e.g.
+// "catalyst.dev/team=team-a". An empty selector matches every namespace.
+func (s *Shard) SetNamespaceSelector(selector string) error {
+	if selector == "" {
+		s.Namespaces = nil
+		return nil
+	}
+	parsed, err := labels.Parse(selector)
+	if err != nil {
+		return fmt.Errorf("invalid namespace selector %q: %w", selector, err)
+	}
+	s.Namespaces = parsed
+	return nil
+}
+
+// Partial reports whether this instance reconciles only some Projects, by shard or by
+// namespace selector
+func (s *Shard) Partial() bool {
+	return s.Enabled() || (s != nil && s.Namespaces != nil)
+}
+
+// OwnsNamespace reports whether the Projects of a namespace with the given labels may
+// belong to this instance. Without a namespace selector every namespace matches.
+func (s *Shard) OwnsNamespace(namespaceLabels map[string]string) bool {
+	return s == nil || s.Namespaces == nil || s.Namespaces.Matches(labels.Set(namespaceLabels))
+}
+
+// Owns reports whether the Project with the given namespace, name and labels belongs to
+// this shard. A nil or single shard owns every Project.
+func (s *Shard) Owns(namespace, name string, labels map[string]string) bool {
+	if !s.Enabled() {
+		return true
+	}
+	return Of(namespace, name, labels, s.Count) == s.Index
+}
+
+// Of returns the shard index of a Project for the given shard count
+func Of(namespace, name string, labels map[string]string, count int) int {
+	if key := labels[Label]; key != "" {
+		if n, err := strconv.Atoi(key); err == nil && n >= 0 {
+			return n % count
+		}
+		return hash(key, count)
+	}
+	return hash(namespace+"/"+name, count)
+}
+
+// LeaseName returns the leader election Lease name for this shard. Without sharding the
+// base name is kept, so unsharded installations keep their existing Lease. Instances with
+// different namespace selectors elect separate leaders.
+func (s *Shard) LeaseName(base string) string {
+	if s != nil && s.Namespaces != nil {
+		h := fnv.New32a()
+		_, _ = h.Write([]byte(s.Namespaces.String()))
+		base = fmt.Sprintf("ns-%08x.%s", h.Sum32(), base)
+	}
+	if !s.Enabled() {
+		return base
+	}
+	return fmt.Sprintf("shard-%d.%s", s.Index, base)
+}
+
+// String formats the shard for logs, e.g. "1/4" or "1/4 namespaces=catalyst.dev/team=a"
+func (s *Shard) String() string {
+	shard := "unsharded"
+	if s.Enabled() {
+		shard = fmt.Sprintf("%d/%d", s.Index, s.Count)
+	}
+	if s != nil && s.Namespaces != nil {
+		shard += " namespaces=" + s.Namespaces.String()
+	}
+	return shard
+}
+
+func hash(key string, count int) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	return int(h.Sum32() % uint32(count))
+}
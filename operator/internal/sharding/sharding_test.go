@@ -0,0 +1,93 @@
+package sharding
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	_, err := New(0, 0)
+	assert.Error(t, err)
+	_, err = New(3, 3)
+	assert.Error(t, err)
+	_, err = New(-1, 3)
+	assert.Error(t, err)
+
+	s, err := New(2, 3)
+	require.NoError(t, err)
+	assert.True(t, s.Enabled())
+	assert.Equal(t, "2/3", s.String())
+}
+
+func TestOwns_Disjoint(t *testing.T) {
+	shards := make([]*Shard, 4)
+	for i := range shards {
+		shards[i] = &Shard{Index: i, Count: len(shards)}
+	}
+
+	perShard := make([]int, len(shards))
+	for p := 0; p < 200; p++ {
+		owners := 0
+		for i, s := range shards {
+			if s.Owns("team-a", fmt.Sprintf("project-%d", p), nil) {
+				owners++
+				perShard[i]++
+			}
+		}
+		assert.Equal(t, 1, owners, "project-%d must have exactly one owner", p)
+	}
+	for i, n := range perShard {
+		assert.NotZero(t, n, "shard %d owns no projects", i)
+	}
+}
+
+func TestOf_Label(t *testing.T) {
+	assert.Equal(t, 2, Of("team-a", "web", map[string]string{Label: "2"}, 4))
+	assert.Equal(t, 1, Of("team-a", "web", map[string]string{Label: "5"}, 4))
+
+	// Projects sharing a non-numeric key are placed together
+	a := Of("team-a", "web", map[string]string{Label: "tenant-x"}, 4)
+	b := Of("team-b", "api", map[string]string{Label: "tenant-x"}, 4)
+	assert.Equal(t, a, b)
+}
+
+func TestUnsharded(t *testing.T) {
+	var s *Shard
+	assert.False(t, s.Enabled())
+	assert.True(t, s.Owns("team-a", "web", nil))
+	assert.Equal(t, "27340b24.catalyst.dev", s.LeaseName("27340b24.catalyst.dev"))
+
+	single := &Shard{Index: 0, Count: 1}
+	assert.True(t, single.Owns("team-a", "web", map[string]string{Label: "3"}))
+	assert.Equal(t, "27340b24.catalyst.dev", single.LeaseName("27340b24.catalyst.dev"))
+
+	sharded := &Shard{Index: 1, Count: 2}
+	assert.Equal(t, "shard-1.27340b24.catalyst.dev", sharded.LeaseName("27340b24.catalyst.dev"))
+}
+
+func TestNamespaceSelector(t *testing.T) {
+	s := &Shard{Index: 0, Count: 1}
+	assert.False(t, s.Partial())
+	require.NoError(t, s.SetNamespaceSelector("catalyst.dev/team=team-a"))
+	assert.True(t, s.Partial())
+	assert.True(t, s.OwnsNamespace(map[string]string{"catalyst.dev/team": "team-a"}))
+	assert.False(t, s.OwnsNamespace(map[string]string{"catalyst.dev/team": "team-b"}))
+	assert.False(t, s.OwnsNamespace(nil))
+	assert.Equal(t, "unsharded namespaces=catalyst.dev/team=team-a", s.String())
+
+	other := &Shard{Index: 0, Count: 1}
+	require.NoError(t, other.SetNamespaceSelector("catalyst.dev/team=team-b"))
+	assert.NotEqual(t, s.LeaseName("27340b24.catalyst.dev"), other.LeaseName("27340b24.catalyst.dev"),
+		"per-team operators elect separate leaders")
+
+	assert.Error(t, s.SetNamespaceSelector("catalyst.dev/team in (a"))
+	require.NoError(t, s.SetNamespaceSelector(""))
+	assert.True(t, s.OwnsNamespace(nil))
+
+	var unset *Shard
+	assert.True(t, unset.OwnsNamespace(nil))
+	assert.False(t, unset.Partial())
+}
@@ -0,0 +1,140 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+	"github.com/ncrmro/catalyst/operator/internal/accesspolicy"
+	"github.com/ncrmro/catalyst/operator/internal/lifetime"
+)
+
+// nolint:unused
+// log is for logging in this package.
+var environmentlog = logf.Log.WithName("environment-resource")
+
+// +kubebuilder:rbac:groups=authorization.k8s.io,resources=subjectaccessreviews,verbs=create
+
+// SetupEnvironmentWebhookWithManager registers the webhook for Environment in the manager.
+func SetupEnvironmentWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).For(&catalystv1alpha1.Environment{}).
+		WithValidator(&EnvironmentCustomValidator{Client: mgr.GetClient()}).
+		Complete()
+}
+
+// NOTE: The 'path' attribute must follow a specific pattern and should not be modified directly here.
+// Modifying the path for an invalid path can cause API server errors; failing to locate the webhook.
+// +kubebuilder:webhook:path=/validate-catalyst-catalyst-dev-v1alpha1-environment,mutating=false,failurePolicy=fail,sideEffects=None,groups=catalyst.catalyst.dev,resources=environments,verbs=create;update,versions=v1alpha1,name=venvironment-v1alpha1.kb.io,admissionReviewVersions=v1
+
+// EnvironmentCustomValidator only lets users allowed the "exempt" verb on environments add or
+// change the lifetime exemption annotation. Removing it is always allowed.
+type EnvironmentCustomValidator struct {
+	// Client creates SubjectAccessReviews for the requesting user
+	Client client.Client
+}
+
+var _ admission.CustomValidator = &EnvironmentCustomValidator{}
+
+// ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type Environment.
+func (v *EnvironmentCustomValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	env, ok := obj.(*catalystv1alpha1.Environment)
+	if !ok {
+		return nil, fmt.Errorf("expected a Environment object but got %T", obj)
+	}
+	environmentlog.Info("Validation for Environment upon creation", "name", env.GetName())
+
+	if err := accesspolicy.Validate(env.Spec.Access); err != nil {
+		return nil, err
+	}
+	return nil, v.validateExemption(ctx, nil, env)
+}
+
+// ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type Environment.
+func (v *EnvironmentCustomValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	env, ok := newObj.(*catalystv1alpha1.Environment)
+	if !ok {
+		return nil, fmt.Errorf("expected a Environment object for the newObj but got %T", newObj)
+	}
+	old, ok := oldObj.(*catalystv1alpha1.Environment)
+	if !ok {
+		return nil, fmt.Errorf("expected a Environment object for the oldObj but got %T", oldObj)
+	}
+	environmentlog.Info("Validation for Environment upon update", "name", env.GetName())
+
+	if err := accesspolicy.Validate(env.Spec.Access); err != nil {
+		return nil, err
+	}
+	return nil, v.validateExemption(ctx, old, env)
+}
+
+// ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type Environment.
+func (v *EnvironmentCustomValidator) ValidateDelete(_ context.Context, _ runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+func (v *EnvironmentCustomValidator) validateExemption(ctx context.Context, old, env *catalystv1alpha1.Environment) error {
+	reason, exempt := env.Annotations[lifetime.ExemptAnnotation]
+	if !exempt {
+		return nil
+	}
+	if old != nil {
+		if previous, ok := old.Annotations[lifetime.ExemptAnnotation]; ok && previous == reason {
+			return nil
+		}
+	}
+
+	req, err := admission.RequestFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	extra := make(map[string]authorizationv1.ExtraValue, len(req.UserInfo.Extra))
+	for k, v := range req.UserInfo.Extra {
+		extra[k] = authorizationv1.ExtraValue(v)
+	}
+	review := &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			User:   req.UserInfo.Username,
+			UID:    req.UserInfo.UID,
+			Groups: req.UserInfo.Groups,
+			Extra:  extra,
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace: env.Namespace,
+				Verb:      lifetime.ExemptVerb,
+				Group:     catalystv1alpha1.GroupVersion.Group,
+				Resource:  "environments",
+				Name:      env.Name,
+			},
+		},
+	}
+	if err := v.Client.Create(ctx, review); err != nil {
+		return fmt.Errorf("failed to authorize %s annotation: %w", lifetime.ExemptAnnotation, err)
+	}
+	if !review.Status.Allowed {
+		return fmt.Errorf("setting the %s annotation requires the %q verb on environments in namespace %s",
+			lifetime.ExemptAnnotation, lifetime.ExemptVerb, env.Namespace)
+	}
+	return nil
+}
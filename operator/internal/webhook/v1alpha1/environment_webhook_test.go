@@ -0,0 +1,89 @@
+package v1alpha1
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+	"github.com/ncrmro/catalyst/operator/internal/lifetime"
+)
+
+func TestEnvironmentCustomValidator(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+
+	// Only "admin" may exempt environments
+	var reviews []authorizationv1.SubjectAccessReviewSpec
+	c := fake.NewClientBuilder().WithScheme(scheme).WithInterceptorFuncs(interceptor.Funcs{
+		Create: func(_ context.Context, _ client.WithWatch, obj client.Object, _ ...client.CreateOption) error {
+			review := obj.(*authorizationv1.SubjectAccessReview)
+			reviews = append(reviews, review.Spec)
+			review.Status.Allowed = review.Spec.User == "admin"
+			return nil
+		},
+	}).Build()
+	validator := &EnvironmentCustomValidator{Client: c}
+	asUser := func(name string) context.Context {
+		return admission.NewContextWithRequest(context.Background(), admission.Request{
+			AdmissionRequest: admissionv1.AdmissionRequest{UserInfo: authenticationv1.UserInfo{Username: name}},
+		})
+	}
+
+	env := &catalystv1alpha1.Environment{ObjectMeta: metav1.ObjectMeta{Name: "pr-1", Namespace: "team"}}
+	_, err := validator.ValidateCreate(asUser("dev"), env)
+	require.NoError(t, err)
+	assert.Empty(t, reviews, "no review without the annotation")
+
+	exempt := env.DeepCopy()
+	exempt.Annotations = map[string]string{lifetime.ExemptAnnotation: "customer demo"}
+	_, err = validator.ValidateUpdate(asUser("dev"), env, exempt)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `"exempt" verb`)
+
+	_, err = validator.ValidateUpdate(asUser("admin"), env, exempt)
+	require.NoError(t, err)
+	require.Len(t, reviews, 2)
+	assert.Equal(t, &authorizationv1.ResourceAttributes{
+		Namespace: "team", Verb: "exempt", Group: "catalyst.catalyst.dev", Resource: "environments", Name: "pr-1",
+	}, reviews[1].ResourceAttributes)
+
+	// Unrelated updates to an exempt environment and removing the exemption need no permission
+	_, err = validator.ValidateUpdate(asUser("dev"), exempt, exempt.DeepCopy())
+	require.NoError(t, err)
+	_, err = validator.ValidateUpdate(asUser("dev"), exempt, env)
+	require.NoError(t, err)
+	assert.Len(t, reviews, 2)
+}
+
+func TestEnvironmentCustomValidator_OAuthProxyAccess(t *testing.T) {
+	validator := &EnvironmentCustomValidator{}
+	env := &catalystv1alpha1.Environment{
+		ObjectMeta: metav1.ObjectMeta{Name: "pr-1", Namespace: "team"},
+		Spec: catalystv1alpha1.EnvironmentSpec{Access: &catalystv1alpha1.EnvironmentAccess{
+			Type:       catalystv1alpha1.AccessTypeOAuthProxy,
+			OAuthProxy: &catalystv1alpha1.OAuthProxyAccess{SecretName: "oauth"},
+		}},
+	}
+
+	_, err := validator.ValidateCreate(context.Background(), env)
+	require.Error(t, err, "any GitHub account could sign in")
+	assert.Contains(t, err.Error(), "emailDomains")
+
+	restricted := env.DeepCopy()
+	restricted.Spec.Access.OAuthProxy.Args = []string{"--github-org=acme"}
+	_, err = validator.ValidateUpdate(context.Background(), env, restricted)
+	require.NoError(t, err)
+}
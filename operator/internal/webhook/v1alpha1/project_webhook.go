@@ -0,0 +1,102 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+	"github.com/ncrmro/catalyst/operator/internal/guardrails"
+)
+
+// nolint:unused
+// log is for logging in this package.
+var projectlog = logf.Log.WithName("project-resource")
+
+// SetupProjectWebhookWithManager registers the webhook for Project in the manager.
+func SetupProjectWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).For(&catalystv1alpha1.Project{}).
+		WithValidator(&ProjectCustomValidator{}).
+		Complete()
+}
+
+// NOTE: The 'path' attribute must follow a specific pattern and should not be modified directly here.
+// Modifying the path for an invalid path can cause API server errors; failing to locate the webhook.
+// +kubebuilder:webhook:path=/validate-catalyst-catalyst-dev-v1alpha1-project,mutating=false,failurePolicy=fail,sideEffects=None,groups=catalyst.catalyst.dev,resources=projects,verbs=create;update,versions=v1alpha1,name=vproject-v1alpha1.kb.io,admissionReviewVersions=v1
+
+// ProjectCustomValidator rejects Project templates whose configs violate the platform guardrails.
+// Helm and docker-compose templates are checked at render time instead.
+type ProjectCustomValidator struct {
+	// Policy is checked against the templates. Nil checks the guardrails of the operator
+	// configuration in effect.
+	Policy *guardrails.Policy
+}
+
+var _ admission.CustomValidator = &ProjectCustomValidator{}
+
+// ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type Project.
+func (v *ProjectCustomValidator) ValidateCreate(_ context.Context, obj runtime.Object) (admission.Warnings, error) {
+	project, ok := obj.(*catalystv1alpha1.Project)
+	if !ok {
+		return nil, fmt.Errorf("expected a Project object but got %T", obj)
+	}
+	projectlog.Info("Validation for Project upon creation", "name", project.GetName())
+
+	return nil, v.validateTemplates(project)
+}
+
+// ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type Project.
+func (v *ProjectCustomValidator) ValidateUpdate(_ context.Context, _, newObj runtime.Object) (admission.Warnings, error) {
+	project, ok := newObj.(*catalystv1alpha1.Project)
+	if !ok {
+		return nil, fmt.Errorf("expected a Project object for the newObj but got %T", newObj)
+	}
+	projectlog.Info("Validation for Project upon update", "name", project.GetName())
+
+	return nil, v.validateTemplates(project)
+}
+
+// ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type Project.
+func (v *ProjectCustomValidator) ValidateDelete(_ context.Context, _ runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+func (v *ProjectCustomValidator) validateTemplates(project *catalystv1alpha1.Project) error {
+	names := make([]string, 0, len(project.Spec.Templates))
+	for name := range project.Spec.Templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	policy := guardrails.Current()
+	if v.Policy != nil {
+		policy = *v.Policy
+	}
+	var violations []guardrails.Violation
+	for _, name := range names {
+		template := project.Spec.Templates[name]
+		violations = append(violations, policy.CheckConfig("template "+name, template.Config)...)
+	}
+	return guardrails.AsError(violations)
+}
@@ -0,0 +1,35 @@
+package v1alpha1
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+	"github.com/ncrmro/catalyst/operator/internal/guardrails"
+)
+
+func TestProjectCustomValidator(t *testing.T) {
+	validator := &ProjectCustomValidator{Policy: &guardrails.Policy{AllowedRegistries: []string{"ghcr.io/acme"}}}
+	project := &catalystv1alpha1.Project{
+		Spec: catalystv1alpha1.ProjectSpec{
+			Templates: map[string]catalystv1alpha1.EnvironmentTemplateSpec{
+				"development": {Type: "manifest", Config: &catalystv1alpha1.EnvironmentConfig{Image: "ghcr.io/acme/web:1"}},
+				"helm":        {Type: "helm", Path: "charts/app"},
+			},
+		},
+	}
+
+	_, err := validator.ValidateCreate(context.Background(), project)
+	require.NoError(t, err)
+
+	project.Spec.Templates["deployment"] = catalystv1alpha1.EnvironmentTemplateSpec{
+		Type:   "manifest",
+		Config: &catalystv1alpha1.EnvironmentConfig{Image: "docker.io/someone/web:1"},
+	}
+	_, err = validator.ValidateUpdate(context.Background(), &catalystv1alpha1.Project{}, project)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "template deployment")
+}
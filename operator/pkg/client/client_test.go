@@ -0,0 +1,83 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	catalystv1alpha1 "github.com/ncrmro/catalyst/operator/api/v1alpha1"
+	"github.com/ncrmro/catalyst/operator/pkg/client/clientset/versioned/fake"
+	"github.com/ncrmro/catalyst/operator/pkg/client/informers/externalversions"
+)
+
+func TestEnvironmentRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	clientset := fake.NewSimpleClientset()
+	environments := clientset.CatalystV1alpha1().Environments("acme")
+
+	env := &catalystv1alpha1.Environment{
+		ObjectMeta: metav1.ObjectMeta{Name: "pr-1", Namespace: "acme"},
+		Spec: catalystv1alpha1.EnvironmentSpec{
+			ProjectRef: catalystv1alpha1.ProjectReference{Name: "shop"},
+			Type:       "development",
+			Sources:    []catalystv1alpha1.EnvironmentSource{{Name: "primary", CommitSha: "0123456789abcdef"}},
+		},
+	}
+	_, err := environments.Create(ctx, env, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	got, err := environments.Get(ctx, "pr-1", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, env.Spec, got.Spec)
+
+	got.Status.Phase = "Ready"
+	_, err = environments.UpdateStatus(ctx, got, metav1.UpdateOptions{})
+	require.NoError(t, err)
+
+	list, err := environments.List(ctx, metav1.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, list.Items, 1)
+	assert.Equal(t, "Ready", list.Items[0].Status.Phase)
+
+	// The informer's lister serves the same object from its cache
+	factory := externalversions.NewSharedInformerFactory(clientset, 0)
+	lister := factory.Catalyst().V1alpha1().Environments().Lister()
+	stop := make(chan struct{})
+	defer close(stop)
+	factory.Start(stop)
+	factory.WaitForCacheSync(stop)
+	cached, err := lister.Environments("acme").List(labels.Everything())
+	require.NoError(t, err)
+	require.Len(t, cached, 1)
+	assert.Equal(t, env.Spec, cached[0].Spec)
+
+	require.NoError(t, environments.Delete(ctx, "pr-1", metav1.DeleteOptions{}))
+	_, err = environments.Get(ctx, "pr-1", metav1.GetOptions{})
+	assert.True(t, apierrors.IsNotFound(err))
+	assert.Eventually(t, func() bool {
+		_, err := lister.Environments("acme").Get("pr-1")
+		return apierrors.IsNotFound(err)
+	}, 5*time.Second, 10*time.Millisecond)
+}
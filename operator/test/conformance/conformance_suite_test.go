@@ -0,0 +1,116 @@
+//go:build conformance
+// +build conformance
+
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conformance
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/ncrmro/catalyst/operator/test/utils"
+)
+
+// Conformance suite: provisions one Environment per deployment mode on a Kind cluster
+// (created from kind-config.yaml, see `make test-conformance`) and asserts status
+// transitions and URL reachability through the Kind ingress.
+//
+// Optional Environment Variables:
+//   - CONFORMANCE_IMAGE: operator image to build and load (default example.com/operator:conformance)
+//   - CONFORMANCE_INGRESS_PORT: host port mapped to the ingress controller (default 8080)
+//   - CONFORMANCE_REPO_URL / CONFORMANCE_REPO_BRANCH: public repository containing testdata/
+//     for the helm and docker-compose modes (default: this repository, main)
+var (
+	operatorImage = envOrDefault("CONFORMANCE_IMAGE", "example.com/operator:conformance")
+	ingressPort   = envOrDefault("CONFORMANCE_INGRESS_PORT", "8080")
+	repoURL       = envOrDefault("CONFORMANCE_REPO_URL", "https://github.com/ncrmro/catalyst.git")
+	repoBranch    = envOrDefault("CONFORMANCE_REPO_BRANCH", "main")
+)
+
+const (
+	// operatorNamespace is where `make deploy` installs the operator
+	operatorNamespace = "operator-system"
+	// operatorDeployment is the name of the operator Deployment created by `make deploy`
+	operatorDeployment = "operator-controller-manager"
+	// ingressNginxManifest installs ingress-nginx configured for Kind
+	ingressNginxManifest = "https://raw.githubusercontent.com/kubernetes/ingress-nginx/controller-v1.11.2/deploy/static/provider/kind/deploy.yaml"
+)
+
+func envOrDefault(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+func TestConformance(t *testing.T) {
+	RegisterFailHandler(Fail)
+	_, _ = fmt.Fprintf(GinkgoWriter, "Starting deployment-mode conformance suite\n")
+	RunSpecs(t, "conformance suite")
+}
+
+var _ = BeforeSuite(func() {
+	By("building the operator image")
+	cmd := exec.Command("make", "docker-build", fmt.Sprintf("IMG=%s", operatorImage))
+	_, err := utils.Run(cmd)
+	Expect(err).NotTo(HaveOccurred(), "Failed to build the operator image")
+
+	By("loading the operator image on Kind")
+	Expect(utils.LoadImageToKindClusterWithName(operatorImage)).To(Succeed())
+
+	By("installing ingress-nginx")
+	cmd = exec.Command("kubectl", "apply", "-f", ingressNginxManifest)
+	_, err = utils.Run(cmd)
+	Expect(err).NotTo(HaveOccurred(), "Failed to install ingress-nginx")
+	cmd = exec.Command("kubectl", "wait", "--namespace", "ingress-nginx",
+		"--for=condition=ready", "pod", "--selector=app.kubernetes.io/component=controller",
+		"--timeout=180s")
+	_, err = utils.Run(cmd)
+	Expect(err).NotTo(HaveOccurred(), "ingress-nginx controller did not become ready")
+
+	By("installing CRDs and deploying the operator")
+	_, err = utils.Run(exec.Command("make", "install"))
+	Expect(err).NotTo(HaveOccurred(), "Failed to install CRDs")
+	_, err = utils.Run(exec.Command("make", "deploy", fmt.Sprintf("IMG=%s", operatorImage)))
+	Expect(err).NotTo(HaveOccurred(), "Failed to deploy the operator")
+
+	By("configuring local preview routing")
+	cmd = exec.Command("kubectl", "set", "env", "deployment/"+operatorDeployment, "-n", operatorNamespace,
+		"LOCAL_PREVIEW_ROUTING=true",
+		"INGRESS_PORT="+ingressPort,
+		"INGRESS_NAMESPACE=ingress-nginx",
+	)
+	_, err = utils.Run(cmd)
+	Expect(err).NotTo(HaveOccurred(), "Failed to configure operator environment")
+	cmd = exec.Command("kubectl", "rollout", "status", "deployment/"+operatorDeployment,
+		"-n", operatorNamespace, fmt.Sprintf("--timeout=%s", 3*time.Minute))
+	_, err = utils.Run(cmd)
+	Expect(err).NotTo(HaveOccurred(), "Operator did not become ready")
+})
+
+var _ = AfterSuite(func() {
+	By("undeploying the operator")
+	_, _ = utils.Run(exec.Command("make", "undeploy", "ignore-not-found=true"))
+	_, _ = utils.Run(exec.Command("make", "uninstall", "ignore-not-found=true"))
+})
@@ -0,0 +1,250 @@
+//go:build conformance
+// +build conformance
+
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conformance
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+
+	"github.com/ncrmro/catalyst/operator/test/utils"
+)
+
+// teamNamespace holds the Projects and Environments (FR-ENV-020 hierarchy: team/project/environment)
+const teamNamespace = "conformance"
+
+// managedProject has no git sources: workspace, development and production modes run
+// from the template config alone.
+const managedProject = `
+apiVersion: catalyst.catalyst.dev/v1alpha1
+kind: Project
+metadata:
+  name: managed
+  namespace: conformance
+spec:
+  sources: []
+  templates:
+    development:
+      type: manifest
+      config:
+        image: nginx:1.27-alpine
+        ports:
+          - containerPort: 80
+    deployment:
+      type: manifest
+      config:
+        image: nginx:1.27-alpine
+        ports:
+          - containerPort: 80
+`
+
+// gitProject renders helm and docker-compose modes from testdata/ in a public repository.
+const gitProject = `
+apiVersion: catalyst.catalyst.dev/v1alpha1
+kind: Project
+metadata:
+  name: git
+  namespace: conformance
+spec:
+  sources:
+    - name: main
+      repositoryUrl: %s
+      branch: %s
+  templates:
+    helm:
+      sourceRef: main
+      type: helm
+      path: operator/test/conformance/testdata/chart
+    compose:
+      sourceRef: main
+      type: docker-compose
+      path: operator/test/conformance/testdata/compose
+`
+
+const environmentManifest = `
+apiVersion: catalyst.catalyst.dev/v1alpha1
+kind: Environment
+metadata:
+  name: %[1]s
+  namespace: conformance
+  labels:
+    catalyst.dev/team: conformance
+    catalyst.dev/project: %[2]s
+    catalyst.dev/environment: %[1]s
+spec:
+  projectRef:
+    name: %[2]s
+  type: %[3]s
+  deploymentMode: %[4]s
+  sources:
+    - name: main
+      # Empty commitSha: shallow clone of the branch head
+      commitSha: ""
+      branch: %[5]s
+`
+
+// deploymentModeCase describes one Environment provisioned by the suite
+type deploymentModeCase struct {
+	name    string
+	project string
+	// envType selects the Project template
+	envType string
+	mode    string
+	// reachable asserts the environment URL serves HTTP 200 through the Kind ingress
+	reachable bool
+}
+
+func kubectlApply(manifest string) error {
+	cmd := exec.Command("kubectl", "apply", "-f", "-")
+	cmd.Stdin = strings.NewReader(manifest)
+	_, err := utils.Run(cmd)
+	return err
+}
+
+func environmentJSONPath(name, path string) (string, error) {
+	cmd := exec.Command("kubectl", "get", "environment", name, "-n", teamNamespace, "-o", "jsonpath="+path)
+	return utils.Run(cmd)
+}
+
+// watchPhases records every phase an Environment reports, from before it is created. stop ends
+// the watch and returns the phases in order, without repeats.
+func watchPhases(name string) (stop func() []string, err error) {
+	out := gbytes.NewBuffer()
+	// Watching the list works before the Environment exists
+	cmd := exec.Command("kubectl", "get", "environments", "-n", teamNamespace, "--field-selector", "metadata.name="+name,
+		"--watch", "-o", `jsonpath={.status.phase}{"\n"}`)
+	cmd.Stdout = out
+	cmd.Stderr = GinkgoWriter
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	return func() []string {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		var phases []string
+		for _, phase := range strings.Split(string(out.Contents()), "\n") {
+			if phase != "" && (len(phases) == 0 || phases[len(phases)-1] != phase) {
+				phases = append(phases, phase)
+			}
+		}
+		return phases
+	}, nil
+}
+
+// getThroughIngress requests an environment URL (http://<namespace>.localhost:<port>/)
+// via 127.0.0.1 with the Host header set, independent of host *.localhost resolution.
+func getThroughIngress(rawURL string) (int, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return 0, err
+	}
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("http://127.0.0.1:%s%s", ingressPort, u.RequestURI()), nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Host = u.Hostname()
+	resp, err := (&http.Client{Timeout: 5 * time.Second}).Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	return resp.StatusCode, nil
+}
+
+var _ = Describe("Deployment modes", Ordered, func() {
+	SetDefaultEventuallyTimeout(5 * time.Minute)
+	SetDefaultEventuallyPollingInterval(2 * time.Second)
+
+	BeforeAll(func() {
+		By("creating the team namespace and Projects")
+		_, _ = utils.Run(exec.Command("kubectl", "create", "ns", teamNamespace))
+		Expect(kubectlApply(managedProject)).To(Succeed())
+		Expect(kubectlApply(fmt.Sprintf(gitProject, repoURL, repoBranch))).To(Succeed())
+	})
+
+	AfterAll(func() {
+		By("deleting Environments and the team namespace")
+		_, _ = utils.Run(exec.Command("kubectl", "delete", "environments", "--all", "-n", teamNamespace, "--wait=true", "--timeout=3m"))
+		_, _ = utils.Run(exec.Command("kubectl", "delete", "ns", teamNamespace, "--ignore-not-found"))
+	})
+
+	AfterEach(func() {
+		if CurrentSpecReport().Failed() {
+			By("fetching operator logs and Environment status")
+			logs, _ := utils.Run(exec.Command("kubectl", "logs", "deployment/"+operatorDeployment, "-n", operatorNamespace, "--tail=200"))
+			_, _ = fmt.Fprintf(GinkgoWriter, "Operator logs:\n%s\n", logs)
+			envs, _ := utils.Run(exec.Command("kubectl", "get", "environments", "-n", teamNamespace, "-o", "yaml"))
+			_, _ = fmt.Fprintf(GinkgoWriter, "Environments:\n%s\n", envs)
+		}
+	})
+
+	DescribeTable("provisions an environment",
+		func(tc deploymentModeCase) {
+			stopWatch, err := watchPhases(tc.name)
+			Expect(err).NotTo(HaveOccurred())
+
+			By("creating the Environment")
+			Expect(kubectlApply(fmt.Sprintf(environmentManifest, tc.name, tc.project, tc.envType, tc.mode, repoBranch))).To(Succeed())
+
+			By("waiting for the Environment to leave Pending and become Ready")
+			Eventually(func(g Gomega) {
+				phase, err := environmentJSONPath(tc.name, "{.status.phase}")
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(phase).NotTo(Equal("Failed"), "environment failed")
+				g.Expect(phase).To(Equal("Ready"))
+			}).Should(Succeed())
+
+			By("verifying the phases led up to Ready")
+			phases := stopWatch()
+			_, _ = fmt.Fprintf(GinkgoWriter, "%s observed phases: %v\n", tc.name, phases)
+			Expect(phases).NotTo(ContainElement("Failed"))
+			Expect(len(phases)).To(BeNumerically(">=", 2), "Ready is not the first phase reported")
+			Expect(phases[0]).To(BeElementOf("Pending", "Provisioning", "Building"))
+			Expect(phases[len(phases)-1]).To(Equal("Ready"))
+
+			By("verifying the status URL")
+			envURL, err := environmentJSONPath(tc.name, "{.status.url}")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(envURL).To(HavePrefix("http://"))
+			Expect(envURL).To(ContainSubstring(".localhost:" + ingressPort))
+
+			if tc.reachable {
+				By("requesting the URL through the Kind ingress")
+				Eventually(func(g Gomega) {
+					status, err := getThroughIngress(envURL)
+					g.Expect(err).NotTo(HaveOccurred())
+					g.Expect(status).To(Equal(http.StatusOK))
+				}).Should(Succeed())
+			}
+		},
+		Entry("workspace", deploymentModeCase{name: "workspace", project: "managed", envType: "workspace", mode: "workspace"}),
+		Entry("development", deploymentModeCase{name: "development", project: "managed", envType: "development", mode: "development", reachable: true}),
+		Entry("production", deploymentModeCase{name: "production", project: "managed", envType: "deployment", mode: "production", reachable: true}),
+		Entry("helm", deploymentModeCase{name: "helm", project: "git", envType: "helm", mode: "helm", reachable: true}),
+		Entry("docker-compose", deploymentModeCase{name: "compose", project: "git", envType: "compose", mode: "docker-compose", reachable: true}),
+	)
+})
@@ -76,6 +76,18 @@ export type EnvironmentPhase =
   | "Ready"
   | "Failed";
 
+/**
+ * Category of an environment failure (status.failureReason)
+ */
+export type EnvironmentFailureReason =
+  | "SourceCloneFailed"
+  | "BuildFailed"
+  | "HelmInstallFailed"
+  | "QuotaExceeded"
+  | "ConfigInvalid"
+  | "InitContainerFailed"
+  | "DeploymentFailed";
+
 /**
  * EnvironmentSpec defines the desired state of Environment
  */
@@ -98,8 +110,14 @@ export interface EnvironmentSpec {
 export interface EnvironmentStatus {
   /** Current lifecycle phase */
   phase?: EnvironmentPhase;
+  /** Why the environment failed (phase Failed) */
+  message?: string;
+  /** Category of the failure (phase Failed) */
+  failureReason?: EnvironmentFailureReason;
   /** Public URL if available */
   url?: string;
+  /** A managed resource was modified or deleted outside of the operator */
+  drifted?: boolean;
   /** Detailed conditions */
   conditions?: Condition[];
 }
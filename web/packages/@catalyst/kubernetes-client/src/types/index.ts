@@ -19,6 +19,7 @@ export type {
 export type {
   Environment,
   EnvironmentConfig,
+  EnvironmentFailureReason,
   EnvironmentInput,
   EnvironmentList,
   EnvironmentPhase,